@@ -69,6 +69,13 @@ type TestConfig struct {
 
 	EnableEnterprise  bool
 	EnterpriseLicense string
+	// EnterpriseLicenseSecretName and EnterpriseLicenseSecretKey reference an existing
+	// secret with the enterprise license. The license secret isn't created when it is set.
+	EnterpriseLicenseSecretName string
+	EnterpriseLicenseSecretKey  string
+	// DisabledEnterpriseFeatures are enterprise features that aren't licensed or supported
+	// by the Consul image so that the tests using them are skipped.
+	DisabledEnterpriseFeatures []Feature
 
 	SkipDataDogTests        bool
 	DatadogHelmChartVersion string
@@ -109,6 +116,9 @@ type TestConfig struct {
 	UseKind         bool
 	UseOpenshift    bool
 
+	JUnitReportDirectory string
+	FlakeRetries         int
+
 	helmChartPath string
 }
 
@@ -126,9 +136,10 @@ func (t *TestConfig) HelmValuesFromConfig() (map[string]string, error) {
 		}
 		setIfNotEmpty(helmValues, "global.image", entImage)
 
-		if t.EnterpriseLicense != "" {
-			setIfNotEmpty(helmValues, "global.enterpriseLicense.secretName", LicenseSecretName)
-			setIfNotEmpty(helmValues, "global.enterpriseLicense.secretKey", LicenseSecretKey)
+		if t.EnterpriseLicense != "" || t.EnterpriseLicenseSecretName != "" {
+			secretName, secretKey := t.LicenseSecret()
+			setIfNotEmpty(helmValues, "global.enterpriseLicense.secretName", secretName)
+			setIfNotEmpty(helmValues, "global.enterpriseLicense.secretKey", secretKey)
 		}
 	}
 
@@ -179,6 +190,25 @@ func (t *TestConfig) HelmValuesFromConfig() (map[string]string, error) {
 	return helmValues, nil
 }
 
+// LicenseSecret returns the name and key of the secret with the enterprise license, which is
+// either an existing secret or the secret created by the test framework from EnterpriseLicense.
+func (t *TestConfig) LicenseSecret() (string, string) {
+	if t.EnterpriseLicenseSecretName != "" {
+		key := t.EnterpriseLicenseSecretKey
+		if key == "" {
+			key = LicenseSecretKey
+		}
+		return t.EnterpriseLicenseSecretName, key
+	}
+	return LicenseSecretName, LicenseSecretKey
+}
+
+// ShouldCreateLicenseSecret returns true if the test framework needs to create the secret with
+// the enterprise license rather than using an existing secret.
+func (t *TestConfig) ShouldCreateLicenseSecret() bool {
+	return t.EnterpriseLicense != "" && t.EnterpriseLicenseSecretName == ""
+}
+
 // IsExpectedClusterCount check that we have at least the required number of clusters to
 // run a test.
 func (t *TestConfig) IsExpectedClusterCount(count int) bool {
@@ -243,6 +273,59 @@ func (t *TestConfig) entImage() (string, error) {
 	return imageTag, nil
 }
 
+// Feature is a Consul Enterprise feature that tests can be gated on.
+type Feature string
+
+const (
+	FeatureNamespaces       Feature = "namespaces"
+	FeatureAdminPartitions  Feature = "admin-partitions"
+	FeatureNetworkSegments  Feature = "network-segments"
+	FeatureRateLimiting     Feature = "rate-limiting"
+	FeatureSamenessGroups   Feature = "sameness-groups"
+	FeatureJWTAuthorization Feature = "jwt-authorization"
+)
+
+// Features are all the enterprise features that can be disabled with -disable-enterprise-features.
+var Features = []Feature{
+	FeatureNamespaces,
+	FeatureAdminPartitions,
+	FeatureNetworkSegments,
+	FeatureRateLimiting,
+	FeatureSamenessGroups,
+	FeatureJWTAuthorization,
+}
+
+// IsEnterpriseFeatureEnabled returns true if enterprise tests are enabled and feature isn't disabled.
+func (t *TestConfig) IsEnterpriseFeatureEnabled(feature Feature) bool {
+	if !t.EnableEnterprise {
+		return false
+	}
+	for _, disabled := range t.DisabledEnterpriseFeatures {
+		if disabled == feature {
+			return false
+		}
+	}
+	return true
+}
+
+// SkipUnlessEnterprise skips the test if enterprise tests aren't enabled.
+func (c *TestConfig) SkipUnlessEnterprise(t *testing.T) {
+	t.Helper()
+	if !c.EnableEnterprise {
+		t.Skip("skipping this test because -enable-enterprise is not set")
+	}
+}
+
+// SkipUnlessEnterpriseFeature skips the test if enterprise tests aren't enabled or if feature
+// is disabled with -disable-enterprise-features, e.g. because the license doesn't include it.
+func (c *TestConfig) SkipUnlessEnterpriseFeature(t *testing.T, feature Feature) {
+	t.Helper()
+	c.SkipUnlessEnterprise(t)
+	if !c.IsEnterpriseFeatureEnabled(feature) {
+		t.Skipf("skipping this test because the %s enterprise feature is disabled with -disable-enterprise-features", feature)
+	}
+}
+
 func (c *TestConfig) SkipWhenOpenshiftAndCNI(t *testing.T) {
 	if c.EnableOpenshift && c.EnableCNI {
 		t.Skip("skipping because -enable-cni and -enable-openshift are set and this test doesn't deploy apps correctly")
@@ -71,6 +71,21 @@ func TestConfig_HelmValuesFromConfig(t *testing.T) {
 				"global.image": "consul:test-version",
 			},
 		},
+		{
+			"sets an existing ent license secret",
+			TestConfig{
+				EnableEnterprise:            true,
+				EnterpriseLicenseSecretName: "consul-license",
+				EnterpriseLicenseSecretKey:  "license",
+				ConsulImage:                 "consul:test-version",
+			},
+			map[string]string{
+				"global.enterpriseLicense.secretName":           "consul-license",
+				"global.enterpriseLicense.secretKey":            "license",
+				"connectInject.transparentProxy.defaultEnabled": "false",
+				"global.image": "consul:test-version",
+			},
+		},
 		{
 			"doesn't set ent license if license is empty",
 			TestConfig{
@@ -192,6 +207,15 @@ func TestConfig_HelmValuesFromConfig_EntImage(t *testing.T) {
 	}
 }
 
+func TestConfig_IsEnterpriseFeatureEnabled(t *testing.T) {
+	cfg := TestConfig{DisabledEnterpriseFeatures: []Feature{FeatureAdminPartitions}}
+	require.False(t, cfg.IsEnterpriseFeatureEnabled(FeatureNamespaces))
+
+	cfg.EnableEnterprise = true
+	require.True(t, cfg.IsEnterpriseFeatureEnabled(FeatureNamespaces))
+	require.False(t, cfg.IsEnterpriseFeatureEnabled(FeatureAdminPartitions))
+}
+
 func Test_KubeEnvListFromStringList(t *testing.T) {
 	tests := []struct {
 		name           string
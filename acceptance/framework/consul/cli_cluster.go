@@ -72,7 +72,7 @@ func NewCLICluster(
 		configureSCCs(t, ctx.KubernetesClient(t), cfg, consulNS)
 	}
 
-	if cfg.EnterpriseLicense != "" {
+	if cfg.ShouldCreateLicenseSecret() {
 		createOrUpdateLicenseSecret(t, ctx.KubernetesClient(t), cfg, consulNS)
 	}
 
@@ -82,7 +82,7 @@ func NewHelmCluster(
 		configureSCCs(t, ctx.KubernetesClient(t), cfg, ctx.KubectlOptions(t).Namespace)
 	}
 
-	if cfg.EnterpriseLicense != "" {
+	if cfg.ShouldCreateLicenseSecret() {
 		createOrUpdateLicenseSecret(t, ctx.KubernetesClient(t), cfg, ctx.KubectlOptions(t).Namespace)
 	}
 
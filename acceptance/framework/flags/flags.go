@@ -6,13 +6,16 @@ package flags
 import (
 	"errors"
 	"flag"
+	"fmt"
 	"os"
+	"slices"
 	"strings"
 	"sync"
 
 	"github.com/hashicorp/go-version"
 
 	"github.com/hashicorp/consul-k8s/acceptance/framework/config"
+	k8sversion "github.com/hashicorp/consul-k8s/version"
 )
 
 type TestFlags struct {
@@ -21,8 +24,12 @@ type TestFlags struct {
 	flagKubeNamespaces     listFlag
 	flagEnableMultiCluster bool
 
-	flagEnableEnterprise  bool
-	flagEnterpriseLicense string
+	flagEnableEnterprise            bool
+	flagEnterpriseLicense           string
+	flagEnterpriseLicenseFile       string
+	flagEnterpriseLicenseSecretName string
+	flagEnterpriseLicenseSecretKey  string
+	flagDisableEnterpriseFeatures   listFlag
 
 	flagEnableOpenshift bool
 
@@ -53,6 +60,9 @@ type TestFlags struct {
 
 	flagDebugDirectory string
 
+	flagJUnitReportDirectory string
+	flagFlakeRetries         int
+
 	flagUseAKS          bool
 	flagUseEKS          bool
 	flagUseGKE          bool
@@ -112,6 +122,17 @@ func (t *TestFlags) init() {
 			"Note that some features may require setting the enterprise license flag below or the env var CONSUL_ENT_LICENSE")
 	flag.StringVar(&t.flagEnterpriseLicense, "enterprise-license", "",
 		"The enterprise license for Consul.")
+	flag.StringVar(&t.flagEnterpriseLicenseFile, "enterprise-license-file", "",
+		"The path to a file with the enterprise license for Consul. It is used if -enterprise-license and the env var "+
+			"CONSUL_ENT_LICENSE are not set. Defaults to the env var CONSUL_LICENSE_PATH.")
+	flag.StringVar(&t.flagEnterpriseLicenseSecretName, "enterprise-license-secret-name", "",
+		"The name of an existing Kubernetes secret with the enterprise license for Consul in each namespace Consul is installed into. "+
+			"If set, the license secret is not created by the tests. Tests that store the license in Vault still require -enterprise-license.")
+	flag.StringVar(&t.flagEnterpriseLicenseSecretKey, "enterprise-license-secret-key", config.LicenseSecretKey,
+		"The key of the enterprise license in the -enterprise-license-secret-name secret.")
+	flag.Var(&t.flagDisableEnterpriseFeatures, "disable-enterprise-features",
+		"Comma separated list of enterprise features to skip the tests of, e.g. because the license doesn't include them. "+
+			"Valid features are: "+featureList(config.Features)+".")
 
 	flag.BoolVar(&t.flagEnableOpenshift, "enable-openshift", false,
 		"If true, the tests will automatically add Openshift Helm value for each Helm install.")
@@ -144,6 +165,12 @@ func (t *TestFlags) init() {
 	flag.StringVar(&t.flagDebugDirectory, "debug-directory", "", "The directory where to write debug information about failed test runs, "+
 		"such as logs and pod definitions. If not provided, a temporary directory will be created by the tests.")
 
+	flag.StringVar(&t.flagJUnitReportDirectory, "junit-report-dir", "", "The directory where to write a JUnit XML report "+
+		"of the test results of each test suite. If not provided, no report is written.")
+
+	flag.IntVar(&t.flagFlakeRetries, "flake-retries", 0, "The number of times to retry the failed tests of a test suite. "+
+		"Tests that pass on a retry are reported as flaky in the JUnit XML report.")
+
 	flag.BoolVar(&t.flagUseAKS, "use-aks", false,
 		"If true, the tests will assume they are running against an AKS cluster(s).")
 	flag.BoolVar(&t.flagUseEKS, "use-eks", false,
@@ -168,6 +195,36 @@ func (t *TestFlags) init() {
 	if t.flagEnterpriseLicense == "" {
 		t.flagEnterpriseLicense = os.Getenv("CONSUL_ENT_LICENSE")
 	}
+	if t.flagEnterpriseLicenseFile == "" {
+		t.flagEnterpriseLicenseFile = os.Getenv("CONSUL_LICENSE_PATH")
+	}
+}
+
+func featureList(features []config.Feature) string {
+	names := make([]string, 0, len(features))
+	for _, f := range features {
+		names = append(names, string(f))
+	}
+	return strings.Join(names, ", ")
+}
+
+// enterpriseLicense returns the enterprise license from -enterprise-license or otherwise
+// from the -enterprise-license-file.
+func (t *TestFlags) enterpriseLicense() (string, error) {
+	if t.flagEnterpriseLicense != "" || t.flagEnterpriseLicenseFile == "" {
+		return t.flagEnterpriseLicense, nil
+	}
+	license, err := os.ReadFile(t.flagEnterpriseLicenseFile)
+	if err != nil {
+		return "", fmt.Errorf("unable to read -enterprise-license-file: %w", err)
+	}
+	return strings.TrimSpace(string(license)), nil
+}
+
+// enableEnterprise returns true if -enable-enterprise is set or the Consul image is an enterprise
+// image, so that the enterprise tests run against enterprise images without setting it explicitly.
+func (t *TestFlags) enableEnterprise() bool {
+	return t.flagEnableEnterprise || k8sversion.IsEnterpriseImage(t.flagConsulImage)
 }
 
 func (t *TestFlags) Validate() error {
@@ -195,10 +252,29 @@ func (t *TestFlags) Validate() error {
 		}
 	}
 
-	if t.flagEnableEnterprise && t.flagEnterpriseLicense == "" {
+	license, err := t.enterpriseLicense()
+	if err != nil {
+		return err
+	}
+	if t.enableEnterprise() && license == "" && t.flagEnterpriseLicenseSecretName == "" {
+		if !t.flagEnableEnterprise {
+			return fmt.Errorf("-consul-image %q is an enterprise image but no license is provided with -enterprise-license, "+
+				"-enterprise-license-file or -enterprise-license-secret-name", t.flagConsulImage)
+		}
 		return errors.New("-enable-enterprise provided without setting env var CONSUL_ENT_LICENSE with consul license")
 	}
 
+	for _, feature := range t.flagDisableEnterpriseFeatures {
+		if !slices.Contains(config.Features, config.Feature(feature)) {
+			return fmt.Errorf("-disable-enterprise-features contains unknown feature %q, valid features are: %s",
+				feature, featureList(config.Features))
+		}
+	}
+
+	if t.flagFlakeRetries < 0 {
+		return errors.New("-flake-retries must be greater than or equal to 0")
+	}
+
 	return nil
 }
 
@@ -209,10 +285,19 @@ func (t *TestFlags) TestConfigFromFlags() *config.TestConfig {
 	consulVersion, _ := version.NewVersion(t.flagConsulVersion)
 	consulDataplaneVersion, _ := version.NewVersion(t.flagConsulDataplaneVersion)
 	kubeEnvs := config.NewKubeTestConfigList(t.flagKubeconfigs, t.flagKubecontexts, t.flagKubeNamespaces)
+	// The license is validated in Validate.
+	license, _ := t.enterpriseLicense()
+	var disabledFeatures []config.Feature
+	for _, feature := range t.flagDisableEnterpriseFeatures {
+		disabledFeatures = append(disabledFeatures, config.Feature(feature))
+	}
 
 	c := &config.TestConfig{
-		EnableEnterprise:  t.flagEnableEnterprise,
-		EnterpriseLicense: t.flagEnterpriseLicense,
+		EnableEnterprise:            t.enableEnterprise(),
+		EnterpriseLicense:           license,
+		EnterpriseLicenseSecretName: t.flagEnterpriseLicenseSecretName,
+		EnterpriseLicenseSecretKey:  t.flagEnterpriseLicenseSecretKey,
+		DisabledEnterpriseFeatures:  disabledFeatures,
 
 		KubeEnvs:           kubeEnvs,
 		EnableMultiCluster: t.flagEnableMultiCluster,
@@ -252,6 +337,9 @@ func (t *TestFlags) TestConfigFromFlags() *config.TestConfig {
 		UseGKEAutopilot:    t.flagUseGKEAutopilot,
 		UseKind:            t.flagUseKind,
 		UseOpenshift:       t.flagUseOpenshift,
+
+		JUnitReportDirectory: t.flagJUnitReportDirectory,
+		FlakeRetries:         t.flagFlakeRetries,
 	}
 
 	return c
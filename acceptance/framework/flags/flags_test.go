@@ -4,9 +4,13 @@
 package flags
 
 import (
+	"os"
+	"path/filepath"
 	"testing"
 
 	"github.com/stretchr/testify/require"
+
+	"github.com/hashicorp/consul-k8s/acceptance/framework/config"
 )
 
 func TestFlags_validate(t *testing.T) {
@@ -16,8 +20,14 @@ func TestFlags_validate(t *testing.T) {
 		flagKubeContexts       listFlag
 		flagNamespaces         listFlag
 
-		flagEnableEnt  bool
-		flagEntLicense string
+		flagEnableEnt        bool
+		flagEntLicense       string
+		flagEntLicenseFile   string
+		flagEntLicenseSecret string
+		flagConsulImage      string
+		flagDisableFeatures  listFlag
+
+		flagFlakeRetries int
 	}
 	tests := []struct {
 		name       string
@@ -139,6 +149,48 @@ func TestFlags_validate(t *testing.T) {
 			false,
 			"",
 		},
+		{
+			"enterprise license: no error when -enable-enterprise and -enterprise-license-secret-name are provided",
+			fields{
+				flagEnableEnt:        true,
+				flagEntLicenseSecret: "consul-license",
+			},
+			false,
+			"",
+		},
+		{
+			"enterprise license: error when -enterprise-license-file does not exist",
+			fields{
+				flagEnableEnt:      true,
+				flagEntLicenseFile: "does-not-exist.hclic",
+			},
+			true,
+			"unable to read -enterprise-license-file: open does-not-exist.hclic: no such file or directory",
+		},
+		{
+			"enterprise license: error when the consul image is an enterprise image but no license is provided",
+			fields{
+				flagConsulImage: "hashicorp/consul-enterprise:1.18.0-ent",
+			},
+			true,
+			`-consul-image "hashicorp/consul-enterprise:1.18.0-ent" is an enterprise image but no license is provided with -enterprise-license, -enterprise-license-file or -enterprise-license-secret-name`,
+		},
+		{
+			"enterprise features: error when -disable-enterprise-features contains an unknown feature",
+			fields{
+				flagDisableFeatures: listFlag{"namespaces", "foo"},
+			},
+			true,
+			`-disable-enterprise-features contains unknown feature "foo", valid features are: namespaces, admin-partitions, network-segments, rate-limiting, sameness-groups, jwt-authorization`,
+		},
+		{
+			"flake retries: error when -flake-retries is negative",
+			fields{
+				flagFlakeRetries: -1,
+			},
+			true,
+			"-flake-retries must be greater than or equal to 0",
+		},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
@@ -149,6 +201,12 @@ func TestFlags_validate(t *testing.T) {
 				flagKubeNamespaces:     tt.fields.flagNamespaces,
 				flagEnableEnterprise:   tt.fields.flagEnableEnt,
 				flagEnterpriseLicense:  tt.fields.flagEntLicense,
+				flagFlakeRetries:       tt.fields.flagFlakeRetries,
+
+				flagEnterpriseLicenseFile:       tt.fields.flagEntLicenseFile,
+				flagEnterpriseLicenseSecretName: tt.fields.flagEntLicenseSecret,
+				flagConsulImage:                 tt.fields.flagConsulImage,
+				flagDisableEnterpriseFeatures:   tt.fields.flagDisableFeatures,
 			}
 			err := tf.Validate()
 			if tt.wantErr {
@@ -159,3 +217,24 @@ func TestFlags_validate(t *testing.T) {
 		})
 	}
 }
+
+func TestFlags_TestConfigFromFlags_Enterprise(t *testing.T) {
+	licenseFile := filepath.Join(t.TempDir(), "license.hclic")
+	require.NoError(t, os.WriteFile(licenseFile, []byte("license\n"), 0600))
+
+	tf := &TestFlags{
+		flagConsulImage:                "hashicorp/consul-enterprise:1.18.0-ent",
+		flagEnterpriseLicenseFile:      licenseFile,
+		flagEnterpriseLicenseSecretKey: config.LicenseSecretKey,
+		flagDisableEnterpriseFeatures:  listFlag{"network-segments"},
+	}
+	require.NoError(t, tf.Validate())
+
+	cfg := tf.TestConfigFromFlags()
+	require.True(t, cfg.EnableEnterprise)
+	require.Equal(t, "license", cfg.EnterpriseLicense)
+	require.True(t, cfg.ShouldCreateLicenseSecret())
+	require.Equal(t, []config.Feature{config.FeatureNetworkSegments}, cfg.DisabledEnterpriseFeatures)
+	require.False(t, cfg.IsEnterpriseFeatureEnabled(config.FeatureNetworkSegments))
+	require.True(t, cfg.IsEnterpriseFeatureEnabled(config.FeatureNamespaces))
+}
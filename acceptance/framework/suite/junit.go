@@ -0,0 +1,132 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package suite
+
+import (
+	"encoding/xml"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// The JUnit XML types follow the format written by the Maven Surefire plugin which
+// is the de facto standard read by CI systems. Failed attempts of tests that are
+// retried are reported as flakyFailure elements when the test passes on a retry and
+// as rerunFailure elements when it doesn't.
+
+type junitTestSuites struct {
+	XMLName xml.Name         `xml:"testsuites"`
+	Suites  []junitTestSuite `xml:"testsuite"`
+}
+
+type junitTestSuite struct {
+	Name       string           `xml:"name,attr"`
+	Tests      int              `xml:"tests,attr"`
+	Failures   int              `xml:"failures,attr"`
+	Skipped    int              `xml:"skipped,attr"`
+	Flaky      int              `xml:"flaky,attr"`
+	Time       string           `xml:"time,attr"`
+	Timestamp  string           `xml:"timestamp,attr"`
+	Properties *junitProperties `xml:"properties,omitempty"`
+	TestCases  []junitTestCase  `xml:"testcase"`
+}
+
+type junitProperties struct {
+	Properties []junitProperty `xml:"property"`
+}
+
+type junitProperty struct {
+	Name  string `xml:"name,attr"`
+	Value string `xml:"value,attr"`
+}
+
+type junitTestCase struct {
+	Name          string           `xml:"name,attr"`
+	Classname     string           `xml:"classname,attr"`
+	Time          string           `xml:"time,attr"`
+	Properties    *junitProperties `xml:"properties,omitempty"`
+	Skipped       *junitMessage    `xml:"skipped,omitempty"`
+	Failure       *junitMessage    `xml:"failure,omitempty"`
+	FlakyFailures []junitMessage   `xml:"flakyFailure,omitempty"`
+	RerunFailures []junitMessage   `xml:"rerunFailure,omitempty"`
+	SystemOut     string           `xml:"system-out,omitempty"`
+}
+
+type junitMessage struct {
+	Message string `xml:"message,attr"`
+	Output  string `xml:",chardata"`
+}
+
+// writeJUnitReport writes the results of the tests of the suite as a JUnit XML report.
+func writeJUnitReport(w io.Writer, suiteName string, retries int, results []*testResult, duration time.Duration, timestamp time.Time) error {
+	suite := junitTestSuite{
+		Name:      suiteName,
+		Time:      formatSeconds(duration),
+		Timestamp: timestamp.UTC().Format(time.RFC3339),
+		Properties: &junitProperties{Properties: []junitProperty{
+			{Name: "flake-retries", Value: strconv.Itoa(retries)},
+		}},
+	}
+
+	for _, result := range results {
+		testCase := junitTestCase{
+			Name:      result.name,
+			Classname: suiteName,
+			Time:      formatSeconds(result.duration),
+		}
+		output := strings.Join(result.output, "\n")
+
+		switch result.status {
+		case testStatusFail:
+			suite.Failures++
+			testCase.Failure = &junitMessage{Message: "Failed", Output: output}
+		case testStatusSkip:
+			suite.Skipped++
+			testCase.Skipped = &junitMessage{Message: "Skipped", Output: output}
+		default:
+			testCase.SystemOut = output
+		}
+
+		if len(result.attempts) > 0 {
+			testCase.Properties = &junitProperties{Properties: []junitProperty{
+				{Name: "retries", Value: strconv.Itoa(len(result.attempts))},
+			}}
+			for i, attempt := range result.attempts {
+				message := junitMessage{
+					Message: fmt.Sprintf("Failed on attempt %d", i+1),
+					Output:  strings.Join(attempt.output, "\n"),
+				}
+				if result.status == testStatusPass {
+					testCase.FlakyFailures = append(testCase.FlakyFailures, message)
+				} else {
+					testCase.RerunFailures = append(testCase.RerunFailures, message)
+				}
+			}
+			if result.status == testStatusPass {
+				suite.Flaky++
+				testCase.Properties.Properties = append(testCase.Properties.Properties, junitProperty{Name: "flaky", Value: "true"})
+			}
+		}
+
+		suite.Tests++
+		suite.TestCases = append(suite.TestCases, testCase)
+	}
+
+	if _, err := io.WriteString(w, xml.Header); err != nil {
+		return err
+	}
+	enc := xml.NewEncoder(w)
+	enc.Indent("", "  ")
+	if err := enc.Encode(junitTestSuites{Suites: []junitTestSuite{suite}}); err != nil {
+		return err
+	}
+	_, err := io.WriteString(w, "\n")
+	return err
+}
+
+func formatSeconds(d time.Duration) string {
+	return fmt.Sprintf("%.3f", d.Seconds())
+}
@@ -0,0 +1,286 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package suite
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// reportChildEnvVar is set in the environment of the test binary run by the reporter
+// so that it runs the tests instead of reporting on them again.
+const reportChildEnvVar = "CONSUL_K8S_ACCEPTANCE_REPORT_CHILD"
+
+const (
+	testStatusPass = "PASS"
+	testStatusFail = "FAIL"
+	testStatusSkip = "SKIP"
+)
+
+var (
+	// testStartRegex matches the lines printed by `go test -v` when a test starts or resumes.
+	testStartRegex = regexp.MustCompile(`^=== (?:RUN|CONT|NAME)\s+(\S+)`)
+	// testResultRegex matches the lines printed by `go test -v` when a test finishes.
+	testResultRegex = regexp.MustCompile(`^\s*--- (PASS|FAIL|SKIP): (\S+) \(([0-9.]+)s\)`)
+)
+
+// testResult is the result of a single test or subtest.
+type testResult struct {
+	name     string
+	status   string
+	duration time.Duration
+	output   []string
+
+	// attempts holds the output of the previous failed attempts of the test
+	// when it has been retried.
+	attempts []*testResult
+}
+
+func (r *testResult) topLevel() bool {
+	return !strings.Contains(r.name, "/")
+}
+
+// testRun is the outcome of a single run of the test binary.
+type testRun struct {
+	results []*testResult
+	// failed is true if the test binary exited with an error.
+	failed bool
+	output string
+}
+
+// reporter runs the tests of the suite in a child process of the test binary so that it can
+// retry the tests that fail and write a JUnit XML report of the results.
+type reporter struct {
+	suiteName string
+	reportDir string
+	retries   int
+	args      []string
+	out       io.Writer
+
+	// runTestBinary runs the test binary with the given arguments and writes its output to out.
+	runTestBinary func(args []string, out io.Writer) error
+}
+
+func newReporter(reportDir string, retries int, args []string) *reporter {
+	return &reporter{
+		suiteName: strings.TrimSuffix(filepath.Base(os.Args[0]), ".test"),
+		reportDir: reportDir,
+		retries:   retries,
+		args:      args,
+		out:       os.Stdout,
+		runTestBinary: func(args []string, out io.Writer) error {
+			cmd := exec.Command(os.Args[0], args...)
+			cmd.Env = append(os.Environ(), reportChildEnvVar+"=true")
+			cmd.Stdout = out
+			cmd.Stderr = out
+			return cmd.Run()
+		},
+	}
+}
+
+// run runs the tests, retrying the failed ones up to the number of retries, writes the
+// report and returns the exit code of the suite.
+func (r *reporter) run() int {
+	start := time.Now()
+
+	run := r.runTests(nil)
+	results := run.results
+	for attempt := 1; attempt <= r.retries; attempt++ {
+		failed := failedTopLevelTests(results)
+		if len(failed) == 0 || (run.failed && !hasFailures(run.results)) {
+			// Nothing to retry, or the test binary failed outside the tests
+			// in which case we can't tell which tests to retry.
+			break
+		}
+		fmt.Fprintf(r.out, "Retrying failed tests (attempt %d of %d): %s\n", attempt, r.retries, strings.Join(failed, ", "))
+		run = r.runTests(failed)
+		results = mergeRetriedResults(results, run.results, failed)
+	}
+
+	// If the test binary failed without any test failing, e.g. when the tests time out or TestMain fails,
+	// record the failure so that it shows up in the report.
+	if run.failed && !hasFailures(run.results) {
+		results = append(results, &testResult{
+			name:   "TestMain",
+			status: testStatusFail,
+			output: []string{run.output},
+		})
+	}
+
+	if r.reportDir != "" {
+		if err := r.writeReport(results, time.Since(start), start); err != nil {
+			fmt.Fprintf(r.out, "Failed to write JUnit report: %s\n", err)
+			return 1
+		}
+	}
+
+	if hasFailures(results) {
+		return 1
+	}
+	return 0
+}
+
+// runTests runs the test binary once. If tests is not empty, only those top-level tests are run.
+func (r *reporter) runTests(tests []string) testRun {
+	// The flags are parsed in order so that the ones added here take
+	// precedence over the ones the suite was run with.
+	args := append([]string{}, r.args...)
+	args = append(args, "-test.v=true")
+	if len(tests) > 0 {
+		quoted := make([]string, 0, len(tests))
+		for _, test := range tests {
+			quoted = append(quoted, regexp.QuoteMeta(test))
+		}
+		args = append(args, fmt.Sprintf("-test.run=^(%s)$", strings.Join(quoted, "|")))
+	}
+
+	var buf bytes.Buffer
+	err := r.runTestBinary(args, io.MultiWriter(r.out, &buf))
+	output := buf.String()
+	return testRun{
+		results: parseTestOutput(strings.NewReader(output)),
+		failed:  err != nil,
+		output:  output,
+	}
+}
+
+func (r *reporter) writeReport(results []*testResult, duration time.Duration, timestamp time.Time) error {
+	if err := os.MkdirAll(r.reportDir, 0755); err != nil {
+		return err
+	}
+	f, err := os.Create(filepath.Join(r.reportDir, r.suiteName+".xml"))
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	return writeJUnitReport(f, r.suiteName, r.retries, results, duration, timestamp)
+}
+
+// parseTestOutput parses the output of `go test -v` into the results of the tests in the order
+// they started. Output lines are attributed to the test that was last reported as running,
+// which is where `go test -v` prints the logs of tests that don't run in parallel.
+// Tests that started but never finished, e.g. because the test binary panicked or timed out,
+// are reported as failed.
+func parseTestOutput(r io.Reader) []*testResult {
+	var results []*testResult
+	byName := make(map[string]*testResult)
+	var current *testResult
+
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0, 64*1024), 10*1024*1024)
+	for scanner.Scan() {
+		line := scanner.Text()
+		if m := testStartRegex.FindStringSubmatch(line); m != nil {
+			result, ok := byName[m[1]]
+			if !ok {
+				result = &testResult{name: m[1]}
+				byName[m[1]] = result
+				results = append(results, result)
+			}
+			current = result
+			continue
+		}
+		if m := testResultRegex.FindStringSubmatch(line); m != nil {
+			result, ok := byName[m[2]]
+			if !ok {
+				result = &testResult{name: m[2]}
+				byName[m[2]] = result
+				results = append(results, result)
+			}
+			result.status = m[1]
+			if seconds, err := strconv.ParseFloat(m[3], 64); err == nil {
+				result.duration = time.Duration(seconds * float64(time.Second))
+			}
+			// The output that follows a subtest belongs to its parent while the parent is
+			// running. The output that follows a finished test, like the final PASS or FAIL,
+			// belongs to no test at all.
+			current = nil
+			if !result.topLevel() {
+				if parent := byName[result.name[:strings.LastIndex(result.name, "/")]]; parent != nil && parent.status == "" {
+					current = parent
+				}
+			}
+			continue
+		}
+		if current != nil {
+			current.output = append(current.output, line)
+		}
+	}
+
+	for _, result := range results {
+		if result.status == "" {
+			result.status = testStatusFail
+		}
+	}
+	return results
+}
+
+// mergeRetriedResults replaces the results of the retried top-level tests and their subtests
+// with the results of the retry, keeping the failed attempt of each retried test.
+func mergeRetriedResults(results, retried []*testResult, tests []string) []*testResult {
+	retriedByName := make(map[string]*testResult)
+	for _, result := range retried {
+		retriedByName[result.name] = result
+	}
+
+	var merged []*testResult
+	for _, result := range results {
+		top := strings.SplitN(result.name, "/", 2)[0]
+		if !slices.Contains(tests, top) {
+			merged = append(merged, result)
+			continue
+		}
+		if !result.topLevel() {
+			continue
+		}
+		retry, ok := retriedByName[result.name]
+		if !ok {
+			// The test didn't run again so we keep the failed result.
+			retry = &testResult{name: result.name, status: testStatusFail}
+		}
+		retry.attempts = append(result.attempts, withoutAttempts(result))
+		merged = append(merged, retry)
+		for _, sub := range retried {
+			if strings.HasPrefix(sub.name, result.name+"/") {
+				merged = append(merged, sub)
+			}
+		}
+	}
+	return merged
+}
+
+func withoutAttempts(result *testResult) *testResult {
+	r := *result
+	r.attempts = nil
+	return &r
+}
+
+func failedTopLevelTests(results []*testResult) []string {
+	var failed []string
+	for _, result := range results {
+		if result.topLevel() && result.status == testStatusFail {
+			failed = append(failed, result.name)
+		}
+	}
+	return failed
+}
+
+func hasFailures(results []*testResult) bool {
+	for _, result := range results {
+		if result.status == testStatusFail {
+			return true
+		}
+	}
+	return false
+}
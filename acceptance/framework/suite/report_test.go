@@ -0,0 +1,160 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package suite
+
+import (
+	"encoding/xml"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseTestOutput(t *testing.T) {
+	output := `=== RUN   TestA
+    a_test.go:10: creating resources
+--- PASS: TestA (1.50s)
+=== RUN   TestB
+=== RUN   TestB/sub
+    b_test.go:20: expected true
+--- FAIL: TestB (2.00s)
+    --- FAIL: TestB/sub (1.00s)
+=== RUN   TestC
+    c_test.go:5: skipping on kind
+--- SKIP: TestC (0.00s)
+=== RUN   TestD
+panic: test timed out after 10m0s
+`
+	results := parseTestOutput(strings.NewReader(output))
+
+	require.Len(t, results, 5)
+	require.Equal(t, "TestA", results[0].name)
+	require.Equal(t, testStatusPass, results[0].status)
+	require.Equal(t, 1500*time.Millisecond, results[0].duration)
+	require.Equal(t, []string{"    a_test.go:10: creating resources"}, results[0].output)
+	require.Equal(t, "TestB", results[1].name)
+	require.Equal(t, testStatusFail, results[1].status)
+	require.Equal(t, "TestB/sub", results[2].name)
+	require.Equal(t, testStatusFail, results[2].status)
+	require.Equal(t, []string{"    b_test.go:20: expected true"}, results[2].output)
+	require.Equal(t, testStatusSkip, results[3].status)
+	// Tests that never finish are reported as failed.
+	require.Equal(t, "TestD", results[4].name)
+	require.Equal(t, testStatusFail, results[4].status)
+	require.Equal(t, []string{"panic: test timed out after 10m0s"}, results[4].output)
+}
+
+func TestReporter_Run(t *testing.T) {
+	cases := map[string]struct {
+		retries         int
+		runs            []string
+		expectedCode    int
+		expectedRunArgs []string
+		expectedReport  []string
+	}{
+		"passing tests": {
+			runs:            []string{"=== RUN   TestA\n--- PASS: TestA (1.00s)\nPASS\n"},
+			expectedCode:    0,
+			expectedRunArgs: []string{""},
+			expectedReport: []string{
+				`<testsuite name="basic" tests="1" failures="0" skipped="0" flaky="0"`,
+				`<testcase name="TestA" classname="basic" time="1.000">`,
+			},
+		},
+		"failing tests without retries": {
+			runs:            []string{"=== RUN   TestA\n    a_test.go:1: boom\n--- FAIL: TestA (1.00s)\nFAIL\n"},
+			expectedCode:    1,
+			expectedRunArgs: []string{""},
+			expectedReport: []string{
+				`<testsuite name="basic" tests="1" failures="1" skipped="0" flaky="0"`,
+				`<failure message="Failed">    a_test.go:1: boom</failure>`,
+			},
+		},
+		"flaky test passes on retry": {
+			retries: 2,
+			runs: []string{
+				"=== RUN   TestA\n--- PASS: TestA (1.00s)\n=== RUN   TestB\n    b_test.go:1: boom\n--- FAIL: TestB (2.00s)\nFAIL\n",
+				"=== RUN   TestB\n--- PASS: TestB (3.00s)\nPASS\n",
+			},
+			expectedCode:    0,
+			expectedRunArgs: []string{"", "-test.run=^(TestB)$"},
+			expectedReport: []string{
+				`<testsuite name="basic" tests="2" failures="0" skipped="0" flaky="1"`,
+				`<property name="flake-retries" value="2"></property>`,
+				`<testcase name="TestB" classname="basic" time="3.000">`,
+				`<property name="retries" value="1"></property>`,
+				`<property name="flaky" value="true"></property>`,
+				`<flakyFailure message="Failed on attempt 1">    b_test.go:1: boom</flakyFailure>`,
+			},
+		},
+		"test fails on every retry": {
+			retries: 2,
+			runs: []string{
+				"=== RUN   TestB\n=== RUN   TestB/sub\n--- FAIL: TestB (2.00s)\n    --- FAIL: TestB/sub (1.00s)\nFAIL\n",
+				"=== RUN   TestB\n=== RUN   TestB/sub\n--- FAIL: TestB (2.00s)\n    --- FAIL: TestB/sub (1.00s)\nFAIL\n",
+				"=== RUN   TestB\n=== RUN   TestB/sub\n--- FAIL: TestB (2.00s)\n    --- FAIL: TestB/sub (1.00s)\nFAIL\n",
+			},
+			expectedCode:    1,
+			expectedRunArgs: []string{"", "-test.run=^(TestB)$", "-test.run=^(TestB)$"},
+			expectedReport: []string{
+				`<testsuite name="basic" tests="2" failures="2" skipped="0" flaky="0"`,
+				`<property name="retries" value="2"></property>`,
+				`<rerunFailure message="Failed on attempt 1"></rerunFailure>`,
+				`<rerunFailure message="Failed on attempt 2"></rerunFailure>`,
+				`<testcase name="TestB/sub" classname="basic" time="1.000">`,
+			},
+		},
+		"test binary fails outside the tests": {
+			retries:         2,
+			runs:            []string{"flag provided but not defined: -foo\n"},
+			expectedCode:    1,
+			expectedRunArgs: []string{""},
+			expectedReport: []string{
+				`<testsuite name="basic" tests="1" failures="1" skipped="0" flaky="0"`,
+				`<testcase name="TestMain" classname="basic" time="0.000">`,
+				`<failure message="Failed">flag provided but not defined: -foo`,
+			},
+		},
+	}
+
+	for name, c := range cases {
+		t.Run(name, func(t *testing.T) {
+			reportDir := t.TempDir()
+			var runArgs []string
+			r := &reporter{
+				suiteName: "basic",
+				reportDir: reportDir,
+				retries:   c.retries,
+				args:      []string{"-enable-cni"},
+				out:       io.Discard,
+				runTestBinary: func(args []string, out io.Writer) error {
+					require.Equal(t, []string{"-enable-cni", "-test.v=true"}, args[:2])
+					runArgs = append(runArgs, strings.Join(args[2:], " "))
+					output := c.runs[len(runArgs)-1]
+					_, err := io.WriteString(out, output)
+					require.NoError(t, err)
+					if !strings.HasSuffix(output, "PASS\n") {
+						return errors.New("exit status 1")
+					}
+					return nil
+				},
+			}
+
+			require.Equal(t, c.expectedCode, r.run())
+			require.Equal(t, c.expectedRunArgs, runArgs)
+
+			report, err := os.ReadFile(filepath.Join(reportDir, "basic.xml"))
+			require.NoError(t, err)
+			require.NoError(t, xml.Unmarshal(report, &junitTestSuites{}))
+			for _, expected := range c.expectedReport {
+				require.Contains(t, string(report), expected)
+			}
+		})
+	}
+}
@@ -59,6 +59,14 @@ func (s *suite) Run() int {
 		}
 	}
 
+	// To write a JUnit report or retry failed tests, the reporter runs the tests in child
+	// processes of the test binary which share the debug directory of this process.
+	if (s.cfg.JUnitReportDirectory != "" || s.cfg.FlakeRetries > 0) && os.Getenv(reportChildEnvVar) == "" {
+		args := append([]string{}, os.Args[1:]...)
+		args = append(args, "-debug-directory="+s.cfg.DebugDirectory)
+		return newReporter(s.cfg.JUnitReportDirectory, s.cfg.FlakeRetries, args).run()
+	}
+
 	return s.m.Run()
 }
 
@@ -74,8 +74,8 @@ func TestAPIGateway_Tenancy(t *testing.T) {
 		t.Run(name, func(t *testing.T) {
 			cfg := suite.Config()
 
-			if !cfg.EnableEnterprise && c.namespaceMirroring {
-				t.Skipf("skipping this test because -enable-enterprise is not set")
+			if c.namespaceMirroring {
+				cfg.SkipUnlessEnterpriseFeature(t, config.FeatureNamespaces)
 			}
 
 			ctx := suite.Environment().DefaultContext(t)
@@ -11,6 +11,7 @@ import (
 	"testing"
 
 	terratestk8s "github.com/gruntwork-io/terratest/modules/k8s"
+	"github.com/hashicorp/consul-k8s/acceptance/framework/config"
 	"github.com/hashicorp/consul-k8s/acceptance/framework/connhelper"
 	"github.com/hashicorp/consul-k8s/acceptance/framework/consul"
 	"github.com/hashicorp/consul-k8s/acceptance/framework/helpers"
@@ -31,9 +32,7 @@ const StaticClientNamespace = "ns2"
 // and non-auto-encrypt secure installations, so testing just one is enough.
 func TestConnectInjectNamespaces(t *testing.T) {
 	cfg := suite.Config()
-	if !cfg.EnableEnterprise {
-		t.Skipf("skipping this test because -enable-enterprise is not set")
-	}
+	cfg.SkipUnlessEnterpriseFeature(t, config.FeatureNamespaces)
 	cfg.SkipWhenOpenshiftAndCNI(t)
 
 	cases := []struct {
@@ -244,9 +243,7 @@ func TestConnectInjectNamespaces(t *testing.T) {
 // and non-auto-encrypt secure installations, so testing just one is enough.
 func TestConnectInjectNamespaces_CleanupController(t *testing.T) {
 	cfg := suite.Config()
-	if !cfg.EnableEnterprise {
-		t.Skipf("skipping this test because -enable-enterprise is not set")
-	}
+	cfg.SkipUnlessEnterpriseFeature(t, config.FeatureNamespaces)
 	cfg.SkipWhenOpenshiftAndCNI(t)
 
 	consulDestNS := "consul-dest"
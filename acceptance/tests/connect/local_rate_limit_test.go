@@ -12,6 +12,7 @@ import (
 	"github.com/hashicorp/consul/sdk/testutil/retry"
 	"github.com/stretchr/testify/require"
 
+	"github.com/hashicorp/consul-k8s/acceptance/framework/config"
 	"github.com/hashicorp/consul-k8s/acceptance/framework/connhelper"
 	"github.com/hashicorp/consul-k8s/acceptance/framework/consul"
 	"github.com/hashicorp/consul-k8s/acceptance/framework/environment"
@@ -23,9 +24,8 @@ import (
 func TestConnectInject_LocalRateLimiting(t *testing.T) {
 	cfg := suite.Config()
 
-	if !cfg.EnableEnterprise {
-		t.Skipf("rate limiting is an enterprise only feature. -enable-enterprise must be set to run this test.")
-	} else if !cfg.UseKind {
+	cfg.SkipUnlessEnterpriseFeature(t, config.FeatureRateLimiting)
+	if !cfg.UseKind {
 		t.Skipf("rate limiting tests are time sensitive and can be flaky on cloud providers. Only test on Kind.")
 	}
 
@@ -55,9 +55,7 @@ func TestConsulDNSProxy_WithPartitionsAndCatalogSync(t *testing.T) {
 	if cfg.EnableCNI {
 		t.Skipf("skipping because -enable-cni is set")
 	}
-	if !cfg.EnableEnterprise {
-		t.Skipf("skipping this test because -enable-enterprise is not set")
-	}
+	cfg.SkipUnlessEnterpriseFeature(t, config.FeatureAdminPartitions)
 
 	cases := []dnsWithPartitionsTestCase{
 		{
@@ -9,6 +9,7 @@ import (
 	"testing"
 
 	terratestk8s "github.com/gruntwork-io/terratest/modules/k8s"
+	"github.com/hashicorp/consul-k8s/acceptance/framework/config"
 	"github.com/hashicorp/consul-k8s/acceptance/framework/consul"
 	"github.com/hashicorp/consul-k8s/acceptance/framework/helpers"
 	"github.com/hashicorp/consul-k8s/acceptance/framework/k8s"
@@ -26,9 +27,7 @@ const testNamespace = "test"
 // and non-auto-encrypt secure installations, so testing just one is enough.
 func TestIngressGatewaySingleNamespace(t *testing.T) {
 	cfg := suite.Config()
-	if !cfg.EnableEnterprise {
-		t.Skipf("skipping this test because -enable-enterprise is not set")
-	}
+	cfg.SkipUnlessEnterpriseFeature(t, config.FeatureNamespaces)
 
 	cases := []struct {
 		secure bool
@@ -148,9 +147,7 @@ func TestIngressGatewaySingleNamespace(t *testing.T) {
 // the ingress gateway and the connect service are in different namespaces.
 func TestIngressGatewayNamespaceMirroring(t *testing.T) {
 	cfg := suite.Config()
-	if !cfg.EnableEnterprise {
-		t.Skipf("skipping this test because -enable-enterprise is not set")
-	}
+	cfg.SkipUnlessEnterpriseFeature(t, config.FeatureNamespaces)
 
 	cases := []struct {
 		secure bool
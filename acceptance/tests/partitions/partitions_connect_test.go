@@ -10,6 +10,7 @@ import (
 	"testing"
 
 	terratestk8s "github.com/gruntwork-io/terratest/modules/k8s"
+	"github.com/hashicorp/consul-k8s/acceptance/framework/config"
 	"github.com/hashicorp/consul-k8s/acceptance/framework/consul"
 	"github.com/hashicorp/consul-k8s/acceptance/framework/helpers"
 	"github.com/hashicorp/consul-k8s/acceptance/framework/k8s"
@@ -35,9 +36,7 @@ func TestPartitions_Connect(t *testing.T) {
 	//	t.Skipf("TODO(flaky): NET-5819")
 	//}
 
-	if !cfg.EnableEnterprise {
-		t.Skipf("skipping this test because -enable-enterprise is not set")
-	}
+	cfg.SkipUnlessEnterpriseFeature(t, config.FeatureAdminPartitions)
 
 	const defaultPartition = "default"
 	const secondaryPartition = "secondary"
@@ -11,6 +11,7 @@ import (
 	"time"
 
 	terratestk8s "github.com/gruntwork-io/terratest/modules/k8s"
+	"github.com/hashicorp/consul-k8s/acceptance/framework/config"
 	"github.com/hashicorp/consul-k8s/acceptance/framework/consul"
 	"github.com/hashicorp/consul-k8s/acceptance/framework/helpers"
 	"github.com/hashicorp/consul-k8s/acceptance/framework/k8s"
@@ -29,9 +30,7 @@ func TestPartitions_Gateway(t *testing.T) {
 	env := suite.Environment()
 	cfg := suite.Config()
 
-	if !cfg.EnableEnterprise {
-		t.Skipf("skipping this test because -enable-enterprise is not set")
-	}
+	cfg.SkipUnlessEnterpriseFeature(t, config.FeatureAdminPartitions)
 
 	const defaultPartition = "default"
 	const secondaryPartition = "secondary"
@@ -12,6 +12,7 @@ import (
 	"time"
 
 	terratestk8s "github.com/gruntwork-io/terratest/modules/k8s"
+	"github.com/hashicorp/consul-k8s/acceptance/framework/config"
 	"github.com/hashicorp/consul-k8s/acceptance/framework/consul"
 	"github.com/hashicorp/consul-k8s/acceptance/framework/helpers"
 	"github.com/hashicorp/consul-k8s/acceptance/framework/k8s"
@@ -33,9 +34,7 @@ func TestPeering_ConnectNamespaces(t *testing.T) {
 	env := suite.Environment()
 	cfg := suite.Config()
 
-	if !cfg.EnableEnterprise {
-		t.Skipf("skipping this test because -enable-enterprise is not set")
-	}
+	cfg.SkipUnlessEnterpriseFeature(t, config.FeatureNamespaces)
 
 	ver, err := version.NewVersion("1.13.0")
 	require.NoError(t, err)
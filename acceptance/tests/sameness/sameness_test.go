@@ -65,9 +65,7 @@ func TestFailover_Connect(t *testing.T) {
 	env := suite.Environment()
 	cfg := suite.Config()
 
-	if !cfg.EnableEnterprise {
-		t.Skipf("skipping this test because -enable-enterprise is not set")
-	}
+	cfg.SkipUnlessEnterpriseFeature(t, config.FeatureSamenessGroups)
 
 	cases := []struct {
 		name        string
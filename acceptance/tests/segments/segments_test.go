@@ -10,6 +10,7 @@ import (
 	"github.com/stretchr/testify/require"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 
+	"github.com/hashicorp/consul-k8s/acceptance/framework/config"
 	"github.com/hashicorp/consul-k8s/acceptance/framework/connhelper"
 	"github.com/hashicorp/consul-k8s/acceptance/framework/consul"
 	"github.com/hashicorp/consul-k8s/acceptance/framework/helpers"
@@ -33,9 +34,7 @@ func TestSegments_MeshWithAgentfulClients(t *testing.T) {
 	for name, c := range cases {
 		t.Run(name, func(t *testing.T) {
 			cfg := suite.Config()
-			if !cfg.EnableEnterprise {
-				t.Skipf("skipping this test because -enable-enterprise is not set")
-			}
+			cfg.SkipUnlessEnterpriseFeature(t, config.FeatureNetworkSegments)
 			ctx := suite.Environment().DefaultContext(t)
 
 			releaseName := helpers.RandomName()
@@ -96,9 +95,7 @@ func TestSegments_MeshWithAgentfulClientsMultiCluster(t *testing.T) {
 	for name, c := range cases {
 		t.Run(name, func(t *testing.T) {
 			cfg := suite.Config()
-			if !cfg.EnableEnterprise {
-				t.Skipf("skipping this test because -enable-enterprise is not set")
-			}
+			cfg.SkipUnlessEnterpriseFeature(t, config.FeatureNetworkSegments)
 			releaseName := helpers.RandomName()
 
 			// deploy server cluster
@@ -9,6 +9,7 @@ import (
 	"testing"
 
 	terratestk8s "github.com/gruntwork-io/terratest/modules/k8s"
+	"github.com/hashicorp/consul-k8s/acceptance/framework/config"
 	"github.com/hashicorp/consul-k8s/acceptance/framework/consul"
 	"github.com/hashicorp/consul-k8s/acceptance/framework/helpers"
 	"github.com/hashicorp/consul-k8s/acceptance/framework/k8s"
@@ -21,9 +22,7 @@ const testNamespace = "ns1"
 // the terminating gateway and the connect service are in the same namespace.
 func TestTerminatingGatewaySingleNamespace(t *testing.T) {
 	cfg := suite.Config()
-	if !cfg.EnableEnterprise {
-		t.Skipf("skipping this test because -enable-enterprise is not set")
-	}
+	cfg.SkipUnlessEnterpriseFeature(t, config.FeatureNamespaces)
 
 	cases := []struct {
 		secure bool
@@ -125,9 +124,7 @@ func TestTerminatingGatewaySingleNamespace(t *testing.T) {
 // the external service, and the connect service are in different combinations of namespaces.
 func TestTerminatingGatewayNamespaceMirroring(t *testing.T) {
 	cfg := suite.Config()
-	if !cfg.EnableEnterprise {
-		t.Skipf("skipping this test because -enable-enterprise is not set")
-	}
+	cfg.SkipUnlessEnterpriseFeature(t, config.FeatureNamespaces)
 
 	type config struct {
 		path      string
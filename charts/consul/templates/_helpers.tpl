@@ -152,6 +152,34 @@ is passed to consul as a -config-file param on command line.
               [ -n "${HOSTNAME}" ] && sed -Ei "s|HOSTNAME|${HOSTNAME?}|g" /consul/extra-config/extra-from-values.json
 {{- end -}}
 
+{{/*
+Renders server.extraConfig without the keys that the server config reloader applies with `consul reload`,
+so that changes to only these keys don't change the config checksum of the server pods. The keys must be
+kept in sync with the reloadable keys of the server-config-reloader command.
+
+Usage: {{ include "consul.serverRestartExtraConfig" . }}
+*/}}
+{{- define "consul.serverRestartExtraConfig" -}}
+{{- $config := tpl .Values.server.extraConfig . | trimAll "\"" | fromJson -}}
+{{- range $key := list "check" "checks" "config_entries" "discard_check_output" "license_path" "log_level" "node_meta" "raft_snapshot_interval" "raft_snapshot_threshold" "raft_trailing_logs" "reporting" "service" "services" "watches" -}}
+{{- $_ := unset $config $key -}}
+{{- end -}}
+{{- range $key, $nestedKeys := dict "acl" (list "tokens") "limits" (list "request_limits" "rpc_max_burst" "rpc_rate") "telemetry" (list "prefix_filter") -}}
+{{- if kindIs "map" (get $config $key) -}}
+{{- $nested := deepCopy (get $config $key) -}}
+{{- range $nestedKey := $nestedKeys -}}
+{{- $_ := unset $nested $nestedKey -}}
+{{- end -}}
+{{- if $nested -}}
+{{- $_ := set $config $key $nested -}}
+{{- else -}}
+{{- $_ := unset $config $key -}}
+{{- end -}}
+{{- end -}}
+{{- end -}}
+{{- toJson $config -}}
+{{- end -}}
+
 {{/*
 Cleanup server.extraConfig entries to avoid conflicting entries:
     - server.enableAgentDebug:
@@ -657,3 +685,35 @@ Usage: {{ template "consul.imagePullPolicy" . }} TODO: melisa should we name thi
 {{fail "imagePullPolicy can only be IfNotPresent, Always, Never, or empty" }}
 {{ end }}
 {{- end -}}
+
+{{/*
+Renders the namespace mirroring prefix of connect inject, replacing {cluster}
+with connectInject.consulNamespaces.clusterName. The {k8s-namespace} variable
+is left to consul-k8s-control-plane.
+
+Usage: {{ template "consul.connectInjectMirroringPrefix" . }}
+*/}}
+{{- define "consul.connectInjectMirroringPrefix" -}}
+{{- $prefix := .Values.connectInject.consulNamespaces.mirroringK8SPrefix -}}
+{{- if contains "{cluster}" $prefix -}}
+{{- if not .Values.connectInject.consulNamespaces.clusterName -}}
+{{- fail "connectInject.consulNamespaces.clusterName must be set if connectInject.consulNamespaces.mirroringK8SPrefix contains {cluster}" -}}
+{{- end -}}
+{{- $prefix = replace "{cluster}" .Values.connectInject.consulNamespaces.clusterName $prefix -}}
+{{- end -}}
+{{- $prefix -}}
+{{- end -}}
+
+{{/*
+Renders the Consul namespace that the release namespace is mirrored into by connect inject.
+
+Usage: {{ template "consul.connectInjectMirroredReleaseNamespace" . }}
+*/}}
+{{- define "consul.connectInjectMirroredReleaseNamespace" -}}
+{{- $prefix := include "consul.connectInjectMirroringPrefix" . -}}
+{{- if contains "{k8s-namespace}" $prefix -}}
+{{- replace "{k8s-namespace}" .Release.Namespace $prefix -}}
+{{- else -}}
+{{- $prefix }}{{ .Release.Namespace -}}
+{{- end -}}
+{{- end -}}
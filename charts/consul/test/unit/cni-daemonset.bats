@@ -92,6 +92,59 @@ load _helpers
   [ "${actual}" = "true" ]
 }
 
+#--------------------------------------------------------------------
+# logFile
+
+@test "cni/DaemonSet: does not log to a file by default" {
+  cd `chart_dir`
+  local object=$(helm template \
+      -s templates/cni-daemonset.yaml  \
+      --set 'connectInject.cni.enabled=true' \
+      --set 'connectInject.enabled=true' \
+      . | tee /dev/stderr |
+      yq '.spec.template.spec' | tee /dev/stderr)
+
+  local actual=$(echo "$object" |
+    yq '.containers[0].command | any(contains("-log-file"))' | tee /dev/stderr)
+  [ "${actual}" = "false" ]
+
+  local actual=$(echo "$object" |
+    yq '[.volumes[] | select(.name == "cni-log-dir")] | length' | tee /dev/stderr)
+  [ "${actual}" = "0" ]
+}
+
+@test "cni/DaemonSet: logs to a file with connectInject.cni.logFile.path" {
+  cd `chart_dir`
+  local object=$(helm template \
+      -s templates/cni-daemonset.yaml  \
+      --set 'connectInject.cni.enabled=true' \
+      --set 'connectInject.enabled=true' \
+      --set 'connectInject.cni.logFile.path=/var/log/consul-cni/consul-cni.log' \
+      --set 'connectInject.cni.logFile.rotateMaxFiles=3' \
+      . | tee /dev/stderr |
+      yq '.spec.template.spec' | tee /dev/stderr)
+
+  local actual=$(echo "$object" |
+    yq '.containers[0].command | any(contains("-log-file=/var/log/consul-cni/consul-cni.log"))' | tee /dev/stderr)
+  [ "${actual}" = "true" ]
+
+  local actual=$(echo "$object" |
+    yq '.containers[0].command | any(contains("-log-rotate-max-bytes=10485760"))' | tee /dev/stderr)
+  [ "${actual}" = "true" ]
+
+  local actual=$(echo "$object" |
+    yq '.containers[0].command | any(contains("-log-rotate-max-files=3"))' | tee /dev/stderr)
+  [ "${actual}" = "true" ]
+
+  local actual=$(echo "$object" |
+    yq -r -c '.volumes[] | select(.name == "cni-log-dir")' | tee /dev/stderr)
+  [ "${actual}" = '{"name":"cni-log-dir","hostPath":{"path":"/var/log/consul-cni","type":"DirectoryOrCreate"}}' ]
+
+  local actual=$(echo "$object" |
+    yq -r -c '.containers[0].volumeMounts[] | select(.name == "cni-log-dir")' | tee /dev/stderr)
+  [ "${actual}" = '{"mountPath":"/var/log/consul-cni","name":"cni-log-dir"}' ]
+}
+
 #--------------------------------------------------------------------
 # updateStrategy
 
@@ -387,3 +440,89 @@ rollingUpdate:
   [ "${actualTemplateFoo}" = "bar" ]
   [ "${actualTemplateBaz}" = "qux" ]
 }
+
+#--------------------------------------------------------------------
+# metrics
+
+@test "cni/DaemonSet: metrics are disabled by default" {
+  cd `chart_dir`
+  local object=$(helm template \
+      -s templates/cni-daemonset.yaml \
+      --set 'connectInject.cni.enabled=true' \
+      --set 'connectInject.enabled=true' \
+      . | tee /dev/stderr |
+      yq -r '.spec.template' | tee /dev/stderr)
+
+  local actual=$(echo "$object" | yq -r '.metadata.annotations."prometheus.io/scrape"' | tee /dev/stderr)
+  [ "${actual}" = "null" ]
+
+  actual=$(echo "$object" | yq -r '.spec.containers[0].command | any(contains("-metrics-port"))' | tee /dev/stderr)
+  [ "${actual}" = "false" ]
+
+  actual=$(echo "$object" | yq -r '.spec.containers[0].ports' | tee /dev/stderr)
+  [ "${actual}" = "null" ]
+}
+
+@test "cni/DaemonSet: metrics are enabled with global.metrics.enabled" {
+  cd `chart_dir`
+  local object=$(helm template \
+      -s templates/cni-daemonset.yaml \
+      --set 'connectInject.cni.enabled=true' \
+      --set 'connectInject.enabled=true' \
+      --set 'global.metrics.enabled=true' \
+      . | tee /dev/stderr |
+      yq -r '.spec.template' | tee /dev/stderr)
+
+  local actual=$(echo "$object" | yq -r '.metadata.annotations."prometheus.io/scrape"' | tee /dev/stderr)
+  [ "${actual}" = "true" ]
+
+  actual=$(echo "$object" | yq -r '.metadata.annotations."prometheus.io/port"' | tee /dev/stderr)
+  [ "${actual}" = "20300" ]
+
+  actual=$(echo "$object" | yq -r '.metadata.annotations."prometheus.io/path"' | tee /dev/stderr)
+  [ "${actual}" = "/metrics" ]
+
+  actual=$(echo "$object" | yq -r '.spec.containers[0].command | any(contains("-metrics-port=20300"))' | tee /dev/stderr)
+  [ "${actual}" = "true" ]
+
+  actual=$(echo "$object" | yq -r '.spec.containers[0].command | any(contains("-metrics-path=/metrics"))' | tee /dev/stderr)
+  [ "${actual}" = "true" ]
+
+  actual=$(echo "$object" | yq -r '.spec.containers[0].ports[0].containerPort' | tee /dev/stderr)
+  [ "${actual}" = "20300" ]
+}
+
+@test "cni/DaemonSet: metrics can be disabled when global.metrics.enabled is true" {
+  cd `chart_dir`
+  local actual=$(helm template \
+      -s templates/cni-daemonset.yaml \
+      --set 'connectInject.cni.enabled=true' \
+      --set 'connectInject.enabled=true' \
+      --set 'global.metrics.enabled=true' \
+      --set 'connectInject.cni.metrics.enabled=false' \
+      . | tee /dev/stderr |
+      yq -r '.spec.template.spec.containers[0].command | any(contains("-metrics-port"))' | tee /dev/stderr)
+  [ "${actual}" = "false" ]
+}
+
+@test "cni/DaemonSet: metrics port and path can be configured" {
+  cd `chart_dir`
+  local object=$(helm template \
+      -s templates/cni-daemonset.yaml \
+      --set 'connectInject.cni.enabled=true' \
+      --set 'connectInject.enabled=true' \
+      --set 'connectInject.cni.metrics.enabled=true' \
+      --set 'connectInject.cni.metrics.port=20400' \
+      --set 'connectInject.cni.metrics.path=/cni-metrics' \
+      . | tee /dev/stderr |
+      yq -r '.spec.template' | tee /dev/stderr)
+
+  local actual=$(echo "$object" | yq -r '.metadata.annotations."prometheus.io/port"' | tee /dev/stderr)
+  [ "${actual}" = "20400" ]
+
+  actual=$(echo "$object" | yq -r '.spec.containers[0].command | any(contains("-metrics-port=20400"))' | tee /dev/stderr)
+  [ "${actual}" = "true" ]
+
+  actual=$(echo "$object" | yq -r '.spec.containers[0].command | any(contains("-metrics-path=/cni-metrics"))' | tee /dev/stderr)
+  [ "${actual}" = "true" ]
+}
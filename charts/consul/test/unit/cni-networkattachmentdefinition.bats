@@ -59,6 +59,30 @@ load _helpers
 
 }
 
+@test "cni/NetworkAttachmentDefinition: log file is set with connectInject.cni.logFile.path" {
+  cd `chart_dir`
+  local cmd=$(helm template \
+      -s templates/cni-networkattachmentdefinition.yaml  \
+      --set 'connectInject.cni.enabled=true' \
+      --set 'connectInject.enabled=true' \
+      --set 'connectInject.cni.multus=true' \
+      --set 'connectInject.cni.logFile.path=/var/log/consul-cni/consul-cni.log' \
+      . | tee /dev/stderr |
+      yq -rc '.spec.config' | tee /dev/stderr)
+
+  local actual=$(echo "$cmd" |
+    yq '.log_file' | tee /dev/stderr)
+  [ "${actual}" = '"/var/log/consul-cni/consul-cni.log"' ]
+
+  local actual=$(echo "$cmd" |
+    yq '.log_rotate_max_bytes' | tee /dev/stderr)
+  [ "${actual}" = '10485760' ]
+
+  local actual=$(echo "$cmd" |
+    yq '.log_rotate_max_files' | tee /dev/stderr)
+  [ "${actual}" = '5' ]
+}
+
 @test "cni/NetworkAttachmentDefinition: cni namespace has a default when not set" {
   cd `chart_dir`
   local actual=$(helm template \
@@ -256,6 +256,95 @@ load _helpers
   [ "${actual}" != null ]
 }
 
+#--------------------------------------------------------------------
+# events
+
+@test "connectInject/ClusterRole: allows creating and patching events by default" {
+  cd `chart_dir`
+  local object=$(helm template \
+      -s templates/connect-inject-clusterrole.yaml  \
+      . | tee /dev/stderr |
+      yq -c '.rules[] | select(.resources[] == "events")' | tee /dev/stderr)
+
+  local actual=$(echo $object | yq -r '.verbs | index("create")' | tee /dev/stderr)
+  [ "${actual}" != null ]
+
+  local actual=$(echo $object | yq -r '.verbs | index("patch")' | tee /dev/stderr)
+  [ "${actual}" != null ]
+}
+
+#--------------------------------------------------------------------
+# argoRollouts
+
+@test "connectInject/ClusterRole: does not allow reading Argo Rollouts by default" {
+  cd `chart_dir`
+  local actual=$(helm template \
+      -s templates/connect-inject-clusterrole.yaml  \
+      . | tee /dev/stderr |
+      yq '[.rules[] | select(.resources[] == "rollouts")] | length' | tee /dev/stderr)
+  [ "${actual}" = "0" ]
+}
+
+@test "connectInject/ClusterRole: allows getting Argo Rollouts with connectInject.argoRollouts.enabled=true" {
+  cd `chart_dir`
+  local object=$(helm template \
+      -s templates/connect-inject-clusterrole.yaml  \
+      --set 'connectInject.argoRollouts.enabled=true' \
+      . | tee /dev/stderr |
+      yq -c '.rules[] | select(.resources[] == "rollouts")' | tee /dev/stderr)
+
+  local actual=$(echo $object | yq -r '.apiGroups[0]' | tee /dev/stderr)
+  [ "${actual}" = "argoproj.io" ]
+
+  local actual=$(echo $object | yq -r '.verbs | index("get")' | tee /dev/stderr)
+  [ "${actual}" != null ]
+}
+
+#--------------------------------------------------------------------
+# dns.proxy.nodeLocal.configureKubeDNS
+
+@test "connectInject/ClusterRole: does not allow updating the kube-dns ConfigMap by default" {
+  cd `chart_dir`
+  local actual=$(helm template \
+      -s templates/connect-inject-clusterrole.yaml  \
+      . | tee /dev/stderr |
+      yq '[.rules[] | select(.resources[] == "configmaps")] | length' | tee /dev/stderr)
+  [ "${actual}" = "0" ]
+}
+
+@test "connectInject/ClusterRole: allows updating the kube-dns ConfigMap with dns.proxy.nodeLocal.configureKubeDNS=true" {
+  cd `chart_dir`
+  local object=$(helm template \
+      -s templates/connect-inject-clusterrole.yaml  \
+      --set 'dns.proxy.enabled=true' \
+      --set 'dns.proxy.nodeLocal.configureKubeDNS=true' \
+      . | tee /dev/stderr |
+      yq -c '.rules[] | select(.resources[] == "configmaps")' | tee /dev/stderr)
+
+  local actual=$(echo $object | yq -r '.resourceNames[0]' | tee /dev/stderr)
+  [ "${actual}" = "kube-dns" ]
+
+  local actual=$(echo $object | yq -r '.verbs | index("update")' | tee /dev/stderr)
+  [ "${actual}" != null ]
+}
+
+#--------------------------------------------------------------------
+# horizontalpodautoscalers
+
+@test "connectInject/ClusterRole: allows managing the HorizontalPodAutoscalers of gateways" {
+  cd `chart_dir`
+  local object=$(helm template \
+      -s templates/connect-inject-clusterrole.yaml  \
+      . | tee /dev/stderr |
+      yq -c '.rules[] | select(.resources[0] == "horizontalpodautoscalers")' | tee /dev/stderr)
+
+  local actual=$(echo $object | yq -r '.apiGroups[0]' | tee /dev/stderr)
+  [ "${actual}" = "autoscaling" ]
+
+  local actual=$(echo $object | yq -r '.verbs | index("watch")' | tee /dev/stderr)
+  [ "${actual}" != null ]
+}
+
 #--------------------------------------------------------------------
 # openshift
 
@@ -265,7 +354,7 @@ load _helpers
       -s templates/connect-inject-clusterrole.yaml  \
       --set 'global.openshift.enabled=true' \
       . | tee /dev/stderr |
-      yq '.rules[14].resourceNames | index("restricted-v2")' | tee /dev/stderr)
+      yq '.rules[] | select(.resources[0] == "securitycontextconstraints") | .resourceNames | index("restricted-v2")' | tee /dev/stderr)
   [ "${object}" == 0 ]
 }
 
@@ -276,6 +365,6 @@ load _helpers
       --set 'global.openshift.enabled=true' \
       --set 'connectInject.apiGateway.managedGatewayClass.openshiftSCCName=fakescc' \
       . | tee /dev/stderr |
-       yq '.rules[14].resourceNames | index("fakescc")' | tee /dev/stderr)
+       yq '.rules[] | select(.resources[0] == "securitycontextconstraints") | .resourceNames | index("fakescc")' | tee /dev/stderr)
    [ "${object}" == 0 ]
 }
@@ -0,0 +1,97 @@
+#!/usr/bin/env bats
+
+load _helpers
+
+@test "connectInject/ConfigMap: disabled by default" {
+  cd `chart_dir`
+  assert_empty helm template \
+      -s templates/connect-inject-configmap.yaml  \
+      .
+}
+
+@test "connectInject/ConfigMap: enabled with connectInject.enabled=true" {
+  cd `chart_dir`
+  local actual=$(helm template \
+      -s templates/connect-inject-configmap.yaml  \
+      --set 'connectInject.enabled=true' \
+      . | tee /dev/stderr |
+      yq -r '.data."config.json" | fromjson' | tee /dev/stderr)
+
+  local actualPullSecrets=$(echo "$actual" | yq -c '.image_pull_secrets' | tee /dev/stderr)
+  [ "${actualPullSecrets}" = "[]" ]
+
+  local actualOverrides=$(echo "$actual" | yq -c '.consul_dataplane_image_overrides' | tee /dev/stderr)
+  [ "${actualOverrides}" = "{}" ]
+}
+
+#--------------------------------------------------------------------
+# sidecarProxy.imageOverrides
+
+@test "connectInject/ConfigMap: consul-dataplane image overrides can be set" {
+  cd `chart_dir`
+  local actual=$(helm template \
+      -s templates/connect-inject-configmap.yaml  \
+      --set 'connectInject.enabled=true' \
+      --set 'connectInject.sidecarProxy.imageOverrides.pinned.image=hashicorp/consul-dataplane@sha256:1234' \
+      --set 'connectInject.sidecarProxy.imageOverrides.canary.image=registry.example.com/consul-dataplane:canary' \
+      --set 'connectInject.sidecarProxy.imageOverrides.canary.imagePullSecrets[0].name=registry-credentials' \
+      . | tee /dev/stderr |
+      yq -r '.data."config.json" | fromjson | .consul_dataplane_image_overrides' | tee /dev/stderr)
+
+  local actualImage=$(echo "$actual" | yq -r '.pinned.image' | tee /dev/stderr)
+  [ "${actualImage}" = "hashicorp/consul-dataplane@sha256:1234" ]
+
+  actualImage=$(echo "$actual" | yq -r '.canary.image' | tee /dev/stderr)
+  [ "${actualImage}" = "registry.example.com/consul-dataplane:canary" ]
+
+  local actualPullSecrets=$(echo "$actual" | yq -c '.pinned.image_pull_secrets' | tee /dev/stderr)
+  [ "${actualPullSecrets}" = "[]" ]
+
+  actualPullSecrets=$(echo "$actual" | yq -c '.canary.image_pull_secrets' | tee /dev/stderr)
+  [ "${actualPullSecrets}" = '[{"name":"registry-credentials"}]' ]
+}
+
+@test "connectInject/ConfigMap: fails if a consul-dataplane image override has no image" {
+  cd `chart_dir`
+  run helm template \
+      -s templates/connect-inject-configmap.yaml  \
+      --set 'connectInject.enabled=true' \
+      --set 'connectInject.sidecarProxy.imageOverrides.pinned.imagePullSecrets[0].name=registry-credentials' \
+      .
+  [ "$status" -eq 1 ]
+  [[ "$output" =~ "connectInject.sidecarProxy.imageOverrides.pinned.image must be set" ]]
+}
+
+#--------------------------------------------------------------------
+# sidecarProxy.architectureProfiles
+
+@test "connectInject/ConfigMap: architecture profiles are empty by default" {
+  cd `chart_dir`
+  local actual=$(helm template \
+      -s templates/connect-inject-configmap.yaml  \
+      --set 'connectInject.enabled=true' \
+      . | tee /dev/stderr |
+      yq -c '.data."config.json" | fromjson | .architecture_profiles' | tee /dev/stderr)
+  [ "${actual}" = "{}" ]
+}
+
+@test "connectInject/ConfigMap: architecture profiles can be set" {
+  cd `chart_dir`
+  local actual=$(helm template \
+      -s templates/connect-inject-configmap.yaml  \
+      --set 'connectInject.enabled=true' \
+      --set 'connectInject.sidecarProxy.architectureProfiles.arm64.image=hashicorp/consul-dataplane:arm64' \
+      --set 'connectInject.sidecarProxy.architectureProfiles.arm64.imagePullSecrets[0].name=registry-credentials' \
+      --set 'connectInject.sidecarProxy.architectureProfiles.arm64.resources.requests.cpu=50m' \
+      --set 'connectInject.sidecarProxy.architectureProfiles.arm64.resources.requests.memory=null' \
+      --set 'connectInject.sidecarProxy.architectureProfiles.arm64.resources.limits.memory=128Mi' \
+      --set 'connectInject.sidecarProxy.architectureProfiles.amd64.resources.requests.cpu=100m' \
+      . | tee /dev/stderr |
+      yq -r '.data."config.json" | fromjson | .architecture_profiles' | tee /dev/stderr)
+
+  local actualArm64=$(echo "$actual" | yq -c '.arm64' | tee /dev/stderr)
+  [ "${actualArm64}" = '{"image":"hashicorp/consul-dataplane:arm64","image_pull_secrets":[{"name":"registry-credentials"}],"resources":{"limits":{"memory":"128Mi"},"requests":{"cpu":"50m"}}}' ]
+
+  local actualAmd64=$(echo "$actual" | yq -c '.amd64' | tee /dev/stderr)
+  [ "${actualAmd64}" = '{"image":"","image_pull_secrets":[],"resources":{"requests":{"cpu":"100m"}}}' ]
+}
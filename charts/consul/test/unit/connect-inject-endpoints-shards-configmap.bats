@@ -0,0 +1,85 @@
+#!/usr/bin/env bats
+
+load _helpers
+
+@test "connectInject/EndpointsShardsConfigMap: disabled by default" {
+  cd `chart_dir`
+  assert_empty helm template \
+      -s templates/connect-inject-endpoints-shards-configmap.yaml  \
+      --set 'connectInject.enabled=true' \
+      .
+}
+
+@test "connectInject/EndpointsShardsConfigMap: disabled with connectInject.enabled=false" {
+  cd `chart_dir`
+  assert_empty helm template \
+      -s templates/connect-inject-endpoints-shards-configmap.yaml  \
+      --set 'connectInject.enabled=false' \
+      --set 'connectInject.replicas=2' \
+      --set 'connectInject.endpointsController.sharding.enabled=true' \
+      .
+}
+
+@test "connectInject/EndpointsShardsConfigMap: fails if shards is less than 1" {
+  cd `chart_dir`
+  run helm template \
+      -s templates/connect-inject-endpoints-shards-configmap.yaml  \
+      --set 'connectInject.enabled=true' \
+      --set 'connectInject.endpointsController.sharding.enabled=true' \
+      --set 'connectInject.endpointsController.sharding.shards=0' \
+      .
+  [ "$status" -eq 1 ]
+  [[ "$output" =~ "connectInject.endpointsController.sharding.shards must be at least 1" ]]
+}
+
+@test "connectInject/EndpointsShardsConfigMap: fails if shards is greater than connectInject.replicas" {
+  cd `chart_dir`
+  run helm template \
+      -s templates/connect-inject-endpoints-shards-configmap.yaml  \
+      --set 'connectInject.enabled=true' \
+      --set 'connectInject.replicas=2' \
+      --set 'connectInject.endpointsController.sharding.enabled=true' \
+      --set 'connectInject.endpointsController.sharding.shards=3' \
+      .
+  [ "$status" -eq 1 ]
+  [[ "$output" =~ "connectInject.endpointsController.sharding.shards must not be greater than connectInject.replicas" ]]
+}
+
+@test "connectInject/EndpointsShardsConfigMap: fails if a namespace is assigned to a shard that doesn't exist" {
+  cd `chart_dir`
+  run helm template \
+      -s templates/connect-inject-endpoints-shards-configmap.yaml  \
+      --set 'connectInject.enabled=true' \
+      --set 'connectInject.replicas=2' \
+      --set 'connectInject.endpointsController.sharding.enabled=true' \
+      --set 'connectInject.endpointsController.sharding.namespaces.2={team-a}' \
+      .
+  [ "$status" -eq 1 ]
+  [[ "$output" =~ "connectInject.endpointsController.sharding.namespaces has shard 2, which must be between 0 and 1" ]]
+}
+
+@test "connectInject/EndpointsShardsConfigMap: contains the number of shards and the assigned namespaces" {
+  cd `chart_dir`
+  local data=$(helm template \
+      -s templates/connect-inject-endpoints-shards-configmap.yaml  \
+      --set 'connectInject.enabled=true' \
+      --set 'connectInject.replicas=3' \
+      --set 'connectInject.endpointsController.sharding.enabled=true' \
+      --set 'connectInject.endpointsController.sharding.shards=3' \
+      --set 'connectInject.endpointsController.sharding.namespaces.0={team-a,team-b}' \
+      --set 'connectInject.endpointsController.sharding.namespaces.2={team-c}' \
+      . | tee /dev/stderr |
+      yq '.data' | tee /dev/stderr)
+
+  local actual=$(echo "$data" | yq -r '.shards' | tee /dev/stderr)
+  [ "${actual}" = "3" ]
+
+  actual=$(echo "$data" | yq -r '.["shard-0"]' | tee /dev/stderr)
+  [ "${actual}" = "team-a,team-b" ]
+
+  actual=$(echo "$data" | yq -r '.["shard-1"]' | tee /dev/stderr)
+  [ "${actual}" = "null" ]
+
+  actual=$(echo "$data" | yq -r '.["shard-2"]' | tee /dev/stderr)
+  [ "${actual}" = "team-c" ]
+}
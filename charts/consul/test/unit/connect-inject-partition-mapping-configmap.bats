@@ -0,0 +1,95 @@
+#!/usr/bin/env bats
+
+load _helpers
+
+@test "connectInject/PartitionMappingConfigMap: disabled by default" {
+  cd `chart_dir`
+  assert_empty helm template \
+      -s templates/connect-inject-partition-mapping-configmap.yaml  \
+      --set 'connectInject.enabled=true' \
+      --set 'global.adminPartitions.enabled=true' \
+      --set 'global.enableConsulNamespaces=true' \
+      .
+}
+
+@test "connectInject/PartitionMappingConfigMap: disabled with connectInject.enabled=false" {
+  cd `chart_dir`
+  assert_empty helm template \
+      -s templates/connect-inject-partition-mapping-configmap.yaml  \
+      --set 'connectInject.enabled=false' \
+      --set 'global.adminPartitions.enabled=true' \
+      --set 'global.enableConsulNamespaces=true' \
+      --set 'connectInject.partitionMapping.team-a=ap1' \
+      .
+}
+
+@test "connectInject/PartitionMappingConfigMap: fails if global.adminPartitions.enabled=false" {
+  cd `chart_dir`
+  run helm template \
+      -s templates/connect-inject-partition-mapping-configmap.yaml  \
+      --set 'connectInject.enabled=true' \
+      --set 'connectInject.partitionMapping.team-a=ap1' \
+      .
+  [ "$status" -eq 1 ]
+  [[ "$output" =~ "global.adminPartitions.enabled must be true if connectInject.partitionMapping is set" ]]
+}
+
+@test "connectInject/PartitionMappingConfigMap: fails if global.acls.manageSystemACLs=true outside the default partition" {
+  cd `chart_dir`
+  run helm template \
+      -s templates/connect-inject-partition-mapping-configmap.yaml  \
+      --set 'connectInject.enabled=true' \
+      --set 'global.adminPartitions.enabled=true' \
+      --set 'global.adminPartitions.name=ap0' \
+      --set 'global.acls.manageSystemACLs=true' \
+      --set 'connectInject.partitionMapping.team-a=ap1' \
+      .
+  [ "$status" -eq 1 ]
+  [[ "$output" =~ "global.adminPartitions.name must be \"default\" if connectInject.partitionMapping is set with ACLs" ]]
+}
+
+@test "connectInject/PartitionMappingConfigMap: fails if connectInject.overrideAuthMethodName is set outside the default partition" {
+  cd `chart_dir`
+  run helm template \
+      -s templates/connect-inject-partition-mapping-configmap.yaml  \
+      --set 'connectInject.enabled=true' \
+      --set 'global.adminPartitions.enabled=true' \
+      --set 'global.adminPartitions.name=ap0' \
+      --set 'connectInject.overrideAuthMethodName=my-auth-method' \
+      --set 'connectInject.partitionMapping.team-a=ap1' \
+      .
+  [ "$status" -eq 1 ]
+  [[ "$output" =~ "global.adminPartitions.name must be \"default\" if connectInject.partitionMapping is set with ACLs" ]]
+}
+
+@test "connectInject/PartitionMappingConfigMap: can be set with global.acls.manageSystemACLs=true in the default partition" {
+  cd `chart_dir`
+  local actual=$(helm template \
+      -s templates/connect-inject-partition-mapping-configmap.yaml  \
+      --set 'connectInject.enabled=true' \
+      --set 'global.adminPartitions.enabled=true' \
+      --set 'global.acls.manageSystemACLs=true' \
+      --set 'connectInject.partitionMapping.team-a=ap1' \
+      . | tee /dev/stderr |
+      yq -r '.data["team-a"]' | tee /dev/stderr)
+  [ "${actual}" = "ap1" ]
+}
+
+@test "connectInject/PartitionMappingConfigMap: contains the mapping" {
+  cd `chart_dir`
+  local data=$(helm template \
+      -s templates/connect-inject-partition-mapping-configmap.yaml  \
+      --set 'connectInject.enabled=true' \
+      --set 'global.adminPartitions.enabled=true' \
+      --set 'global.enableConsulNamespaces=true' \
+      --set 'connectInject.partitionMapping.team-a=ap1' \
+      --set 'connectInject.partitionMapping.team-b=ap2' \
+      . | tee /dev/stderr |
+      yq '.data' | tee /dev/stderr)
+
+  local actual=$(echo "$data" | yq -r '.["team-a"]' | tee /dev/stderr)
+  [ "${actual}" = "ap1" ]
+
+  actual=$(echo "$data" | yq -r '.["team-b"]' | tee /dev/stderr)
+  [ "${actual}" = "ap2" ]
+}
@@ -0,0 +1,56 @@
+#!/usr/bin/env bats
+
+load _helpers
+
+@test "connectInject/ValidatingWebhookConfiguration: enabled by default" {
+  cd `chart_dir`
+  local actual=$(helm template \
+      -s templates/connect-inject-validatingwebhookconfiguration.yaml  \
+      . | tee /dev/stderr |
+      yq 'length > 0' | tee /dev/stderr)
+  [ "${actual}" = "true" ]
+}
+
+@test "connectInject/ValidatingWebhookConfiguration: disable with connectInject.enabled" {
+  cd `chart_dir`
+  assert_empty helm template \
+      -s templates/connect-inject-validatingwebhookconfiguration.yaml  \
+      --set 'connectInject.enabled=false' \
+      .
+}
+
+#--------------------------------------------------------------------
+# validatePodAnnotations
+
+@test "connectInject/ValidatingWebhookConfiguration: pod webhook is not registered by default" {
+  cd `chart_dir`
+  local actual=$(helm template \
+      -s templates/connect-inject-validatingwebhookconfiguration.yaml  \
+      --set 'connectInject.enabled=true' \
+      . | tee /dev/stderr |
+      yq '[.webhooks[] | select(.name == "validate-pods.consul.hashicorp.com")] | length' | tee /dev/stderr)
+  [ "${actual}" = "0" ]
+}
+
+@test "connectInject/ValidatingWebhookConfiguration: pod webhook is registered with connectInject.validatePodAnnotations" {
+  cd `chart_dir`
+  local webhook=$(helm template \
+      -s templates/connect-inject-validatingwebhookconfiguration.yaml  \
+      --set 'connectInject.enabled=true' \
+      --set 'connectInject.validatePodAnnotations=true' \
+      --set 'connectInject.failurePolicy=Ignore' \
+      . | tee /dev/stderr |
+      yq '.webhooks[] | select(.name == "validate-pods.consul.hashicorp.com")' | tee /dev/stderr)
+
+  local actual=$(echo "$webhook" | yq -r '.clientConfig.service.path' | tee /dev/stderr)
+  [ "${actual}" = "/validate" ]
+
+  local actual=$(echo "$webhook" | yq -r '.rules[0].resources[0]' | tee /dev/stderr)
+  [ "${actual}" = "pods" ]
+
+  local actual=$(echo "$webhook" | yq -r '.failurePolicy' | tee /dev/stderr)
+  [ "${actual}" = "Ignore" ]
+
+  local actual=$(echo "$webhook" | yq -r '.namespaceSelector.matchExpressions[0].key' | tee /dev/stderr)
+  [ "${actual}" = "kubernetes.io/metadata.name" ]
+}
@@ -0,0 +1,20 @@
+#!/usr/bin/env bats
+
+load _helpers
+
+@test "routeratelimit-filters/CustomResourceDefinition: enabled by default" {
+    cd `chart_dir`
+    local actual=$(helm template \
+        -s templates/crd-routeratelimitfilters.yaml \
+        . | tee /dev/stderr |
+        yq 'length > 0' | tee /dev/stderr)
+    [ "$actual" = "true" ]
+}
+
+@test "routeratelimit-filter/CustomResourceDefinition: disabled with connectInject.enabled=false" {
+    cd `chart_dir`
+    assert_empty helm template \
+        -s templates/crd-routeratelimitfilters.yaml \
+        --set 'connectInject.enabled=false' \
+        .
+}
@@ -9,6 +9,19 @@ load _helpers
       .
 }
 
+@test "createFederationSecret/Job: disabled when global.federation.controller.enabled=true" {
+  cd `chart_dir`
+  assert_empty helm template \
+      -s templates/create-federation-secret-job.yaml  \
+      --set 'global.federation.enabled=true' \
+      --set 'global.federation.createFederationSecret=true' \
+      --set 'global.federation.controller.enabled=true' \
+      --set 'global.tls.enabled=true' \
+      --set 'meshGateway.enabled=true' \
+      --set 'connectInject.enabled=true' \
+      .
+}
+
 @test "createFederationSecret/Job: fails when global.federation.enabled=false" {
   cd `chart_dir`
   run helm template \
@@ -499,4 +499,34 @@ load _helpers
       yq '.spec.replicas' | tee /dev/stderr)
 
   [ "${actual}" = "3" ]
-}
\ No newline at end of file
+}
+
+#--------------------------------------------------------------------
+# nodeLocal
+
+@test "dnsProxy/Deployment: runs as a Deployment by default" {
+  cd `chart_dir`
+  local actual=$(helm template \
+      -s templates/dns-proxy-deployment.yaml  \
+      --set 'dns.proxy.enabled=true' \
+      . | tee /dev/stderr |
+      yq -r '.kind' | tee /dev/stderr)
+
+  [ "${actual}" = "Deployment" ]
+}
+
+@test "dnsProxy/Deployment: runs as a DaemonSet without replicas when dns.proxy.nodeLocal.enabled=true" {
+  cd `chart_dir`
+  local object=$(helm template \
+      -s templates/dns-proxy-deployment.yaml  \
+      --set 'dns.proxy.enabled=true' \
+      --set 'dns.proxy.nodeLocal.enabled=true' \
+      --set 'dns.proxy.replicas=3' \
+      . | tee /dev/stderr)
+
+  local actual=$(echo "$object" | yq -r '.kind' | tee /dev/stderr)
+  [ "${actual}" = "DaemonSet" ]
+
+  local actual=$(echo "$object" | yq '.spec | has("replicas")' | tee /dev/stderr)
+  [ "${actual}" = "false" ]
+}
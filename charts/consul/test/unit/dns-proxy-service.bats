@@ -36,3 +36,27 @@ load _helpers
       --set 'global.enabled=false' \
       .
 }
+
+#--------------------------------------------------------------------
+# nodeLocal
+
+@test "dnsProxy/Service: no internalTrafficPolicy by default" {
+  cd `chart_dir`
+  local actual=$(helm template \
+      -s templates/dns-proxy-service.yaml  \
+      --set 'dns.proxy.enabled=true' \
+      . | tee /dev/stderr |
+      yq '.spec | has("internalTrafficPolicy")' | tee /dev/stderr)
+  [ "${actual}" = "false" ]
+}
+
+@test "dnsProxy/Service: internalTrafficPolicy is Local when dns.proxy.nodeLocal.enabled=true" {
+  cd `chart_dir`
+  local actual=$(helm template \
+      -s templates/dns-proxy-service.yaml  \
+      --set 'dns.proxy.enabled=true' \
+      --set 'dns.proxy.nodeLocal.enabled=true' \
+      . | tee /dev/stderr |
+      yq -r '.spec.internalTrafficPolicy' | tee /dev/stderr)
+  [ "${actual}" = "Local" ]
+}
@@ -0,0 +1,133 @@
+#!/usr/bin/env bats
+
+load _helpers
+
+@test "federationController/Deployment: disabled by default" {
+  cd `chart_dir`
+  assert_empty helm template \
+      -s templates/federation-controller-deployment.yaml  \
+      .
+}
+
+@test "federationController/Deployment: fails when global.federation.enabled=false" {
+  cd `chart_dir`
+  run helm template \
+      -s templates/federation-controller-deployment.yaml  \
+      --set 'global.federation.controller.enabled=true' .
+  [ "$status" -eq 1 ]
+  [[ "$output" =~ "global.federation.enabled must be true when global.federation.controller.enabled is true" ]]
+}
+
+#--------------------------------------------------------------------
+# primary datacenter
+
+@test "federationController/Deployment: exports the federation secret in the primary datacenter" {
+  cd `chart_dir`
+  local command=$(helm template \
+      -s templates/federation-controller-deployment.yaml  \
+      --set 'global.federation.enabled=true' \
+      --set 'global.federation.createFederationSecret=true' \
+      --set 'global.federation.controller.enabled=true' \
+      --set 'global.tls.enabled=true' \
+      --set 'meshGateway.enabled=true' \
+      --set 'connectInject.enabled=true' \
+      . | tee /dev/stderr |
+      yq -r '.spec.template.spec.containers[0].command[2]' | tee /dev/stderr)
+
+  local actual=$(echo "$command" | yq 'contains("-role=primary")' | tee /dev/stderr)
+  [ "${actual}" = "true" ]
+
+  local actual=$(echo "$command" | yq 'contains("-server-ca-key-file=/consul/tls/server/ca/tls.key")' | tee /dev/stderr)
+  [ "${actual}" = "true" ]
+
+  local actual=$(echo "$command" | yq 'contains("-sync-interval=1m")' | tee /dev/stderr)
+  [ "${actual}" = "true" ]
+
+  local actual=$(echo "$command" | yq 'contains("-export-replication-token")' | tee /dev/stderr)
+  [ "${actual}" = "false" ]
+}
+
+@test "federationController/Deployment: exports the replication token with global.acls.createReplicationToken=true" {
+  cd `chart_dir`
+  local actual=$(helm template \
+      -s templates/federation-controller-deployment.yaml  \
+      --set 'global.federation.enabled=true' \
+      --set 'global.federation.createFederationSecret=true' \
+      --set 'global.federation.controller.enabled=true' \
+      --set 'global.tls.enabled=true' \
+      --set 'meshGateway.enabled=true' \
+      --set 'connectInject.enabled=true' \
+      --set 'global.acls.manageSystemACLs=true' \
+      --set 'global.acls.createReplicationToken=true' \
+      . | tee /dev/stderr |
+      yq '.spec.template.spec.containers[0].command[2] | contains("-export-replication-token=true")' | tee /dev/stderr)
+  [ "${actual}" = "true" ]
+}
+
+@test "federationController/Deployment: fails when global.acls.createReplicationToken is false but global.acls.manageSystemACLs is true" {
+  cd `chart_dir`
+  run helm template \
+      -s templates/federation-controller-deployment.yaml  \
+      --set 'global.federation.enabled=true' \
+      --set 'global.federation.createFederationSecret=true' \
+      --set 'global.federation.controller.enabled=true' \
+      --set 'global.tls.enabled=true' \
+      --set 'meshGateway.enabled=true' \
+      --set 'connectInject.enabled=true' \
+      --set 'global.acls.manageSystemACLs=true' .
+  [ "$status" -eq 1 ]
+  [[ "$output" =~ "global.acls.createReplicationToken must be true when global.acls.manageSystemACLs is true" ]]
+}
+
+#--------------------------------------------------------------------
+# secondary datacenters
+
+@test "federationController/Deployment: fails in secondary datacenters without a kubeconfig of the primary datacenter" {
+  cd `chart_dir`
+  run helm template \
+      -s templates/federation-controller-deployment.yaml  \
+      --set 'global.federation.enabled=true' \
+      --set 'global.federation.controller.enabled=true' \
+      --set 'global.tls.enabled=true' \
+      --set 'meshGateway.enabled=true' \
+      --set 'connectInject.enabled=true' .
+  [ "$status" -eq 1 ]
+  [[ "$output" =~ "global.federation.controller.primaryKubeconfigSecret.secretName and secretKey must be set in secondary datacenters" ]]
+}
+
+@test "federationController/Deployment: imports the federation secret in secondary datacenters" {
+  cd `chart_dir`
+  local spec=$(helm template \
+      -s templates/federation-controller-deployment.yaml  \
+      --set 'global.federation.enabled=true' \
+      --set 'global.federation.controller.enabled=true' \
+      --set 'global.federation.controller.primaryKubeconfigSecret.secretName=primary-kubeconfig' \
+      --set 'global.federation.controller.primaryKubeconfigSecret.secretKey=config' \
+      --set 'global.federation.controller.primaryFederationSecretName=consul-federation' \
+      --set 'global.federation.controller.primaryFederationSecretNamespace=consul' \
+      --set 'global.tls.enabled=true' \
+      --set 'meshGateway.enabled=true' \
+      --set 'connectInject.enabled=true' \
+      . | tee /dev/stderr |
+      yq '.spec.template.spec' | tee /dev/stderr)
+
+  local actual=$(echo "$spec" | yq -r '.volumes[0].secret.secretName' | tee /dev/stderr)
+  [ "${actual}" = "primary-kubeconfig" ]
+
+  local actual=$(echo "$spec" | yq -r '.volumes[0].secret.items[0].key' | tee /dev/stderr)
+  [ "${actual}" = "config" ]
+
+  local command=$(echo "$spec" | yq -r '.containers[0].command[2]' | tee /dev/stderr)
+
+  local actual=$(echo "$command" | yq 'contains("-role=secondary")' | tee /dev/stderr)
+  [ "${actual}" = "true" ]
+
+  local actual=$(echo "$command" | yq 'contains("-primary-kubeconfig-file=/consul/federation/primary/kubeconfig")' | tee /dev/stderr)
+  [ "${actual}" = "true" ]
+
+  local actual=$(echo "$command" | yq 'contains("-primary-federation-secret-name=consul-federation")' | tee /dev/stderr)
+  [ "${actual}" = "true" ]
+
+  local actual=$(echo "$command" | yq 'contains("-primary-k8s-namespace=consul")' | tee /dev/stderr)
+  [ "${actual}" = "true" ]
+}
@@ -0,0 +1,48 @@
+#!/usr/bin/env bats
+
+load _helpers
+
+@test "federationController/Role: disabled by default" {
+  cd `chart_dir`
+  assert_empty helm template \
+      -s templates/federation-controller-role.yaml  \
+      .
+}
+
+@test "federationController/Role: allows managing the federation secret and status" {
+  cd `chart_dir`
+  local rules=$(helm template \
+      -s templates/federation-controller-role.yaml  \
+      --set 'global.federation.enabled=true' \
+      --set 'global.federation.controller.enabled=true' \
+      --set 'global.federation.controller.primaryKubeconfigSecret.secretName=primary-kubeconfig' \
+      --set 'global.federation.controller.primaryKubeconfigSecret.secretKey=config' \
+      --set 'global.tls.enabled=true' \
+      --set 'meshGateway.enabled=true' \
+      --set 'connectInject.enabled=true' \
+      . | tee /dev/stderr |
+      yq -c '.rules' | tee /dev/stderr)
+
+  local actual=$(echo "$rules" | yq -r '.[1].resourceNames[0]' | tee /dev/stderr)
+  [ "${actual}" = "release-name-consul-federation" ]
+
+  local actual=$(echo "$rules" | yq -r '.[2].resourceNames[0]' | tee /dev/stderr)
+  [ "${actual}" = "release-name-consul-federation-status" ]
+}
+
+@test "federationController/Role: allows read access for replication token in the primary datacenter with global.acls.manageSystemACLs=true" {
+  cd `chart_dir`
+  local actual=$(helm template \
+      -s templates/federation-controller-role.yaml  \
+      --set 'global.federation.enabled=true' \
+      --set 'global.federation.controller.enabled=true' \
+      --set 'global.federation.createFederationSecret=true' \
+      --set 'global.tls.enabled=true' \
+      --set 'meshGateway.enabled=true' \
+      --set 'connectInject.enabled=true' \
+      --set 'global.acls.manageSystemACLs=true' \
+      --set 'global.acls.createReplicationToken=true' \
+      . | tee /dev/stderr |
+      yq -r '.rules[2].resourceNames[0]' | tee /dev/stderr)
+  [ "${actual}" = "release-name-consul-acl-replication-acl-token" ]
+}
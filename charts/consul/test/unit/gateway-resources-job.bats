@@ -109,6 +109,33 @@ target=templates/gateway-resources-job.yaml
   [ "${actual}" = "\"-openshift-scc-name=hello\"" ]
 }
 
+@test "apiGateway/GatewayClassConfig: load balancer class and external traffic policy" {
+  cd `chart_dir`
+  local spec=$(helm template \
+      -s $target  \
+      . | tee /dev/stderr |
+      yq '.spec.template.spec.containers[0].args' | tee /dev/stderr)
+
+  local actual=$(echo "$spec" | jq 'any(index("-service-load-balancer-class"))')
+  [ "${actual}" = "false" ]
+
+  local actual=$(echo "$spec" | jq 'any(index("-service-external-traffic-policy"))')
+  [ "${actual}" = "false" ]
+
+  local spec=$(helm template \
+      -s $target  \
+      --set 'connectInject.apiGateway.managedGatewayClass.loadBalancerClass=metallb' \
+      --set 'connectInject.apiGateway.managedGatewayClass.externalTrafficPolicy=Local' \
+      . | tee /dev/stderr |
+      yq '.spec.template.spec.containers[0].args' | tee /dev/stderr)
+
+  local actual=$(echo "$spec" | jq 'any(index("-service-load-balancer-class=metallb"))')
+  [ "${actual}" = "true" ]
+
+  local actual=$(echo "$spec" | jq 'any(index("-service-external-traffic-policy=Local"))')
+  [ "${actual}" = "true" ]
+}
+
 
 #--------------------------------------------------------------------
 # annotations
@@ -147,4 +174,57 @@ target=templates/gateway-resources-job.yaml
 
   local actual=$(echo $tolerations | yq 'contains(["tolerations","- \"operator\": \"Equal\" \n\"effect\": \"NoSchedule\" \n\"key\": \"node\" \n\"value\": \"clients\" \n- \"operator\": \"Equal\" \n\"effect\": \"NoSchedule\" \n\"key\": \"node2\" \n\"value\": \"clients2\"" ])')
   [ "${actual}" = "true" ]
-}
\ No newline at end of file
+}
+#--------------------------------------------------------------------
+# affinity
+
+@test "apiGateway/GatewayClassConfig: affinity" {
+  cd `chart_dir`
+  local args=$(helm template \
+      -s $target  \
+      --set 'connectInject.apiGateway.managedGatewayClass.affinity=nodeAffinity: {}' \
+      . | tee /dev/stderr |
+      yq '.spec.template.spec.containers[0].args' | tee /dev/stderr)
+
+  local actual=$(echo "$args" | jq 'index("-affinity") as $i | .[$i + 1]' | tee /dev/stderr)
+  [ "${actual}" = '"nodeAffinity: {}"' ]
+}
+
+#--------------------------------------------------------------------
+# autoscaling
+
+@test "apiGateway/GatewayClassConfig: no autoscaling by default" {
+  cd `chart_dir`
+  local actual=$(helm template \
+      -s $target  \
+      . | tee /dev/stderr |
+      yq '.spec.template.spec.containers[0].args | any(. == "-autoscaling")' | tee /dev/stderr)
+  [ "${actual}" = "false" ]
+}
+
+@test "apiGateway/GatewayClassConfig: autoscaling" {
+  cd `chart_dir`
+  local args=$(helm template \
+      -s $target  \
+      --set 'connectInject.apiGateway.managedGatewayClass.deployment.autoscaling=maxReplicas: 4' \
+      . | tee /dev/stderr |
+      yq '.spec.template.spec.containers[0].args' | tee /dev/stderr)
+
+  local actual=$(echo "$args" | jq 'index("-autoscaling") as $i | .[$i + 1]' | tee /dev/stderr)
+  [ "${actual}" = '"maxReplicas: 4"' ]
+}
+
+#--------------------------------------------------------------------
+# topologySpreadConstraints
+
+@test "apiGateway/GatewayClassConfig: topologySpreadConstraints" {
+  cd `chart_dir`
+  local args=$(helm template \
+      -s $target  \
+      --set 'connectInject.apiGateway.managedGatewayClass.topologySpreadConstraints=- maxSkew: 1' \
+      . | tee /dev/stderr |
+      yq '.spec.template.spec.containers[0].args' | tee /dev/stderr)
+
+  local actual=$(echo "$args" | jq 'index("-topology-spread-constraints") as $i | .[$i + 1]' | tee /dev/stderr)
+  [ "${actual}" = '"- maxSkew: 1"' ]
+}
@@ -1824,4 +1824,94 @@ key2: value2' \
       yq -r '.spec.template.spec.containers[0].securityContext' | tee /dev/stderr)
 
   [ $(echo "${actual}" | yq -r '.capabilities.drop[0]') = "ALL" ]
-}
\ No newline at end of file
+}
+#--------------------------------------------------------------------
+# zones
+
+@test "meshGateway/Deployment: creates a single deployment without zones" {
+  cd `chart_dir`
+  local object=$(helm template \
+      -s templates/mesh-gateway-deployment.yaml  \
+      --set 'meshGateway.enabled=true' \
+      --set 'connectInject.enabled=true' \
+      . | tee /dev/stderr |
+      yq -s -r '.' | tee /dev/stderr)
+
+  local actual=$(echo "$object" | yq -r 'length' | tee /dev/stderr)
+  [ "${actual}" = "1" ]
+
+  actual=$(echo "$object" | yq -r '.[0].metadata.name' | tee /dev/stderr)
+  [ "${actual}" = "release-name-consul-mesh-gateway" ]
+
+  actual=$(echo "$object" | yq -r '.[0].spec.selector.matchLabels["mesh-gateway-zone"]' | tee /dev/stderr)
+  [ "${actual}" = "null" ]
+}
+
+@test "meshGateway/Deployment: creates a deployment per zone" {
+  cd `chart_dir`
+  local object=$(helm template \
+      -s templates/mesh-gateway-deployment.yaml  \
+      --set 'meshGateway.enabled=true' \
+      --set 'connectInject.enabled=true' \
+      --set 'meshGateway.zones[0].name=us-east-1a' \
+      --set 'meshGateway.zones[1].name=us-east-1b' \
+      . | tee /dev/stderr |
+      yq -s -r '.' | tee /dev/stderr)
+
+  local actual=$(echo "$object" | yq -r 'length' | tee /dev/stderr)
+  [ "${actual}" = "2" ]
+
+  actual=$(echo "$object" | yq -r '.[1].metadata.name' | tee /dev/stderr)
+  [ "${actual}" = "release-name-consul-mesh-gateway-us-east-1b" ]
+
+  actual=$(echo "$object" | yq -r '.[1].spec.selector.matchLabels["mesh-gateway-zone"]' | tee /dev/stderr)
+  [ "${actual}" = "us-east-1b" ]
+
+  actual=$(echo "$object" | yq -r '.[1].spec.template.metadata.labels["mesh-gateway-zone"]' | tee /dev/stderr)
+  [ "${actual}" = "us-east-1b" ]
+
+  actual=$(echo "$object" | yq -r '.[1].spec.template.spec.nodeSelector["topology.kubernetes.io/zone"]' | tee /dev/stderr)
+  [ "${actual}" = "us-east-1b" ]
+}
+
+@test "meshGateway/Deployment: zone nodeSelector is merged with meshGateway.nodeSelector" {
+  cd `chart_dir`
+  local actual=$(helm template \
+      -s templates/mesh-gateway-deployment.yaml  \
+      --set 'meshGateway.enabled=true' \
+      --set 'connectInject.enabled=true' \
+      --set 'meshGateway.zones[0].name=us-east-1a' \
+      --set 'meshGateway.nodeSelector=key: value' \
+      . | tee /dev/stderr |
+      yq -r '.spec.template.spec.nodeSelector' | tee /dev/stderr)
+
+  [ $(echo "${actual}" | yq -r '.["topology.kubernetes.io/zone"]') = "us-east-1a" ]
+  [ $(echo "${actual}" | yq -r '.key') = "value" ]
+}
+
+@test "meshGateway/Deployment: fails if a zone name is not set" {
+  cd `chart_dir`
+  run helm template \
+      -s templates/mesh-gateway-deployment.yaml  \
+      --set 'meshGateway.enabled=true' \
+      --set 'connectInject.enabled=true' \
+      --set 'meshGateway.zones[0].serviceAnnotations=key: value' \
+      .
+  [ "$status" -eq 1 ]
+  [[ "$output" =~ "meshGateway.zones[].name must be set" ]]
+}
+
+@test "meshGateway/Deployment: fails if nodePort is set with multiple zones" {
+  cd `chart_dir`
+  run helm template \
+      -s templates/mesh-gateway-deployment.yaml  \
+      --set 'meshGateway.enabled=true' \
+      --set 'connectInject.enabled=true' \
+      --set 'meshGateway.service.type=NodePort' \
+      --set 'meshGateway.service.nodePort=30000' \
+      --set 'meshGateway.zones[0].name=us-east-1a' \
+      --set 'meshGateway.zones[1].name=us-east-1b' \
+      .
+  [ "$status" -eq 1 ]
+  [[ "$output" =~ "meshGateway.service.nodePort cannot be set when more than one zone is set in meshGateway.zones" ]]
+}
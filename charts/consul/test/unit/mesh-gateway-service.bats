@@ -175,3 +175,43 @@ load _helpers
       yq -r '.spec.key' | tee /dev/stderr)
   [ "${actual}" = "value" ]
 }
+
+#--------------------------------------------------------------------
+# zones
+
+@test "meshGateway/Service: creates a service per zone" {
+  cd `chart_dir`
+  local object=$(helm template \
+      -s templates/mesh-gateway-service.yaml  \
+      --set 'meshGateway.enabled=true' \
+      --set 'connectInject.enabled=true' \
+      --set 'meshGateway.zones[0].name=us-east-1a' \
+      --set 'meshGateway.zones[1].name=us-east-1b' \
+      . | tee /dev/stderr |
+      yq -s -r '.' | tee /dev/stderr)
+
+  local actual=$(echo "$object" | yq -r 'length' | tee /dev/stderr)
+  [ "${actual}" = "2" ]
+
+  actual=$(echo "$object" | yq -r '.[1].metadata.name' | tee /dev/stderr)
+  [ "${actual}" = "release-name-consul-mesh-gateway-us-east-1b" ]
+
+  actual=$(echo "$object" | yq -r '.[1].spec.selector["mesh-gateway-zone"]' | tee /dev/stderr)
+  [ "${actual}" = "us-east-1b" ]
+}
+
+@test "meshGateway/Service: can set zone annotations" {
+  cd `chart_dir`
+  local actual=$(helm template \
+      -s templates/mesh-gateway-service.yaml  \
+      --set 'meshGateway.enabled=true' \
+      --set 'connectInject.enabled=true' \
+      --set 'meshGateway.service.annotations=key: value' \
+      --set 'meshGateway.zones[0].name=us-east-1a' \
+      --set 'meshGateway.zones[0].serviceAnnotations=zonekey: zonevalue' \
+      . | tee /dev/stderr |
+      yq -r '.metadata.annotations' | tee /dev/stderr)
+
+  [ $(echo "${actual}" | yq -r '.key') = "value" ]
+  [ $(echo "${actual}" | yq -r '.zonekey') = "zonevalue" ]
+}
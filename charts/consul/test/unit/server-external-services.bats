@@ -0,0 +1,118 @@
+#!/usr/bin/env bats
+
+load _helpers
+
+@test "server/ExternalServices: disabled by default" {
+  cd `chart_dir`
+  assert_empty helm template \
+      -s templates/server-external-services.yaml  \
+      .
+}
+
+@test "server/ExternalServices: disabled with server.enabled=false" {
+  cd `chart_dir`
+  assert_empty helm template \
+      -s templates/server-external-services.yaml  \
+      --set 'server.enabled=false' \
+      --set 'server.externalServices.enabled=true' \
+      .
+}
+
+@test "server/ExternalServices: fails with an unsupported type" {
+  cd `chart_dir`
+  run helm template \
+      -s templates/server-external-services.yaml  \
+      --set 'server.externalServices.enabled=true' \
+      --set 'server.externalServices.type=ClusterIP' \
+      .
+  [ "$status" -eq 1 ]
+  [[ "$output" =~ "server.externalServices.type must be LoadBalancer or NodePort" ]]
+}
+
+@test "server/ExternalServices: fails if advertiseExternalAddress is set with NodePort Services without exposing the server ports" {
+  cd `chart_dir`
+  run helm template \
+      -s templates/server-external-services.yaml  \
+      --set 'server.externalServices.enabled=true' \
+      --set 'server.externalServices.type=NodePort' \
+      .
+  [ "$status" -eq 1 ]
+  [[ "$output" =~ "server.externalServices.advertiseExternalAddress with server.externalServices.type NodePort requires server.exposeGossipAndRPCPorts to be true" ]]
+}
+
+@test "server/ExternalServices: advertiseExternalAddress can be set with NodePort Services exposing the server ports" {
+  cd `chart_dir`
+  local actual=$(helm template \
+      -s templates/server-external-services.yaml  \
+      --set 'server.externalServices.enabled=true' \
+      --set 'server.externalServices.type=NodePort' \
+      --set 'server.exposeGossipAndRPCPorts=true' \
+      . | tee /dev/stderr |
+      yq -r '.spec.type' | tee /dev/stderr)
+  [ "${actual}" = "NodePort" ]
+}
+
+@test "server/ExternalServices: creates a LoadBalancer Service per server" {
+  cd `chart_dir`
+  local object=$(helm template \
+      -s templates/server-external-services.yaml  \
+      --set 'server.replicas=3' \
+      --set 'server.externalServices.enabled=true' \
+      . | tee /dev/stderr |
+      yq -s '.' | tee /dev/stderr)
+
+  local actual=$(echo "$object" | yq -r 'length' | tee /dev/stderr)
+  [ "${actual}" = "3" ]
+
+  actual=$(echo "$object" | yq -r 'map(.metadata.name) | join(",")' | tee /dev/stderr)
+  [ "${actual}" = "release-name-consul-server-0-external,release-name-consul-server-1-external,release-name-consul-server-2-external" ]
+
+  actual=$(echo "$object" | yq -r '.[1].spec.selector["statefulset.kubernetes.io/pod-name"]' | tee /dev/stderr)
+  [ "${actual}" = "release-name-consul-server-1" ]
+
+  actual=$(echo "$object" | yq -r '.[1].spec.type' | tee /dev/stderr)
+  [ "${actual}" = "LoadBalancer" ]
+
+  actual=$(echo "$object" | yq -r '.[1].spec.publishNotReadyAddresses' | tee /dev/stderr)
+  [ "${actual}" = "true" ]
+
+  actual=$(echo "$object" | yq -r '.[1].spec.ports | map(.name) | join(",")' | tee /dev/stderr)
+  [ "${actual}" = "server,grpc,serflan-tcp,serflan-udp,serfwan-tcp,serfwan-udp" ]
+}
+
+@test "server/ExternalServices: NodePort Services use consecutive nodePorts" {
+  cd `chart_dir`
+  local object=$(helm template \
+      -s templates/server-external-services.yaml  \
+      --set 'server.replicas=2' \
+      --set 'server.externalServices.enabled=true' \
+      --set 'server.externalServices.type=NodePort' \
+      --set 'server.externalServices.advertiseExternalAddress=false' \
+      --set 'server.externalServices.nodePort.rpc=30300' \
+      --set 'server.externalServices.nodePort.grpc=30502' \
+      . | tee /dev/stderr |
+      yq -s '.' | tee /dev/stderr)
+
+  local actual=$(echo "$object" | yq -r '.[0].spec.ports | map(select(.name == "server")) | .[0].nodePort' | tee /dev/stderr)
+  [ "${actual}" = "30300" ]
+
+  actual=$(echo "$object" | yq -r '.[1].spec.ports | map(select(.name == "server")) | .[0].nodePort' | tee /dev/stderr)
+  [ "${actual}" = "30301" ]
+
+  actual=$(echo "$object" | yq -r '.[1].spec.ports | map(select(.name == "grpc")) | .[0].nodePort' | tee /dev/stderr)
+  [ "${actual}" = "30503" ]
+
+  actual=$(echo "$object" | yq -r '.[1].spec.ports | map(select(.name == "serfwan-tcp")) | .[0].nodePort' | tee /dev/stderr)
+  [ "${actual}" = "null" ]
+}
+
+@test "server/ExternalServices: annotations can be set" {
+  cd `chart_dir`
+  local actual=$(helm template \
+      -s templates/server-external-services.yaml  \
+      --set 'server.externalServices.enabled=true' \
+      --set 'server.externalServices.annotations=key: value' \
+      . | tee /dev/stderr |
+      yq -r '.metadata.annotations.key' | tee /dev/stderr)
+  [ "${actual}" = "value" ]
+}
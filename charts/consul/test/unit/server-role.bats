@@ -105,3 +105,25 @@ load _helpers
   local psp_resource=$(echo $rules | jq -r '. | select(.resources==["podsecuritypolicies"]) | .resourceNames[0]')
   [ "${psp_resource}" = "release-name-consul-server" ]
 }
+
+@test "server/Role: allows getting services when server.externalServices.advertiseExternalAddress=true" {
+  cd `chart_dir`
+  local actual=$(helm template \
+      -s templates/server-role.yaml  \
+      --set 'server.externalServices.enabled=true' \
+      . | tee /dev/stderr |
+      yq -r '.rules | map(select(.resources[0] == "services")) | .[0].verbs | join(",")' | tee /dev/stderr)
+  [ "${actual}" = "get" ]
+}
+
+@test "server/Role: does not allow getting services when server.externalServices.advertiseExternalAddress=false" {
+  cd `chart_dir`
+  local actual=$(helm template \
+      -s templates/server-role.yaml  \
+      --set 'server.externalServices.enabled=true' \
+      --set 'server.externalServices.type=NodePort' \
+      --set 'server.externalServices.advertiseExternalAddress=false' \
+      . | tee /dev/stderr |
+      yq -r '.rules | length' | tee /dev/stderr)
+  [ "${actual}" = "0" ]
+}
@@ -3498,3 +3498,161 @@ MIICFjCCAZsCCQCdwLtdjbzlYzAKBggqhkjOPQQDAjB0MQswCQYDVQQGEwJDQTEL' \
   [ "${actual}" = "true" ]
 }
 
+
+#--------------------------------------------------------------------
+# configReloader
+
+@test "server/StatefulSet: config reloader is not added by default" {
+  cd `chart_dir`
+  local actual=$(helm template \
+      -s templates/server-statefulset.yaml  \
+      . | tee /dev/stderr |
+      yq '.spec.template.spec.containers[] | select(.name == "server-config-reloader")' | tee /dev/stderr)
+  [ "${actual}" = "" ]
+}
+
+@test "server/StatefulSet: config reloader is added with server.configReloader.enabled=true" {
+  cd `chart_dir`
+  local object=$(helm template \
+      -s templates/server-statefulset.yaml  \
+      --set 'server.configReloader.enabled=true' \
+      --set 'server.configReloader.interval=30s' \
+      . | tee /dev/stderr |
+      yq -r '.spec.template.spec.containers[] | select(.name == "server-config-reloader")' | tee /dev/stderr)
+
+  local actual=$(echo "$object" | yq -r '.command | any(contains("-source-file=/consul/tmp/extra-config/extra-from-values.json"))' | tee /dev/stderr)
+  [ "${actual}" = "true" ]
+  actual=$(echo "$object" | yq -r '.command | any(contains("-output-file=/consul/extra-config/extra-from-values.json"))' | tee /dev/stderr)
+  [ "${actual}" = "true" ]
+  actual=$(echo "$object" | yq -r '.command | any(contains("-interval=30s"))' | tee /dev/stderr)
+  [ "${actual}" = "true" ]
+  actual=$(echo "$object" | yq -r '.command | any(contains("-acl-auth-method"))' | tee /dev/stderr)
+  [ "${actual}" = "false" ]
+  actual=$(echo "$object" | yq -r '.env[] | select(.name == "CONSUL_HTTP_ADDR") | .value' | tee /dev/stderr)
+  [ "${actual}" = "http://127.0.0.1:8500" ]
+  actual=$(echo "$object" | yq -r '.volumeMounts | map(.name) | join(",")' | tee /dev/stderr)
+  [ "${actual}" = "extra-config,tmp-extra-config" ]
+}
+
+@test "server/StatefulSet: config reloader logs in with the component auth method when ACLs are managed" {
+  cd `chart_dir`
+  local actual=$(helm template \
+      -s templates/server-statefulset.yaml  \
+      --set 'server.configReloader.enabled=true' \
+      --set 'global.acls.manageSystemACLs=true' \
+      . | tee /dev/stderr |
+      yq -r '.spec.template.spec.containers[] | select(.name == "server-config-reloader") | .command | any(contains("-acl-auth-method=release-name-consul-k8s-component-auth-method"))' | tee /dev/stderr)
+  [ "${actual}" = "true" ]
+}
+
+@test "server/StatefulSet: config reloader uses the HTTPS port when TLS is enabled" {
+  cd `chart_dir`
+  local object=$(helm template \
+      -s templates/server-statefulset.yaml  \
+      --set 'server.configReloader.enabled=true' \
+      --set 'global.tls.enabled=true' \
+      . | tee /dev/stderr |
+      yq -r '.spec.template.spec.containers[] | select(.name == "server-config-reloader")' | tee /dev/stderr)
+
+  local actual=$(echo "$object" | yq -r '.env[] | select(.name == "CONSUL_HTTP_ADDR") | .value' | tee /dev/stderr)
+  [ "${actual}" = "https://127.0.0.1:8501" ]
+  actual=$(echo "$object" | yq -r '.env[] | select(.name == "CONSUL_CACERT") | .value' | tee /dev/stderr)
+  [ "${actual}" = "/consul/tls/ca/tls.crt" ]
+  actual=$(echo "$object" | yq -r '.volumeMounts[] | select(.name == "consul-ca-cert") | .mountPath' | tee /dev/stderr)
+  [ "${actual}" = "/consul/tls/ca" ]
+}
+
+@test "server/StatefulSet: config-checksum annotation ignores reloadable extraConfig keys with server.configReloader.enabled=true" {
+  cd `chart_dir`
+  local expected=$(helm template \
+      -s templates/server-statefulset.yaml  \
+      --set 'server.configReloader.enabled=true' \
+      --set 'server.extraConfig="{\"limits\": {\"http_max_conns_per_client\": 100}}"' \
+      . | tee /dev/stderr |
+      yq -r '.spec.template.metadata.annotations."consul.hashicorp.com/config-checksum"' | tee /dev/stderr)
+
+  local actual=$(helm template \
+      -s templates/server-statefulset.yaml  \
+      --set 'server.configReloader.enabled=true' \
+      --set 'server.extraConfig="{\"log_level\": \"DEBUG\"\, \"limits\": {\"http_max_conns_per_client\": 100\, \"rpc_rate\": 50}}"' \
+      . | tee /dev/stderr |
+      yq -r '.spec.template.metadata.annotations."consul.hashicorp.com/config-checksum"' | tee /dev/stderr)
+  [ "${actual}" = "${expected}" ]
+}
+
+@test "server/StatefulSet: config-checksum annotation changes with extraConfig keys that require a restart with server.configReloader.enabled=true" {
+  cd `chart_dir`
+  local expected=$(helm template \
+      -s templates/server-statefulset.yaml  \
+      --set 'server.configReloader.enabled=true' \
+      --set 'server.extraConfig="{\"limits\": {\"http_max_conns_per_client\": 100}}"' \
+      . | tee /dev/stderr |
+      yq -r '.spec.template.metadata.annotations."consul.hashicorp.com/config-checksum"' | tee /dev/stderr)
+
+  local actual=$(helm template \
+      -s templates/server-statefulset.yaml  \
+      --set 'server.configReloader.enabled=true' \
+      --set 'server.extraConfig="{\"limits\": {\"http_max_conns_per_client\": 200}}"' \
+      . | tee /dev/stderr |
+      yq -r '.spec.template.metadata.annotations."consul.hashicorp.com/config-checksum"' | tee /dev/stderr)
+  [ "${actual}" != "${expected}" ]
+}
+
+#--------------------------------------------------------------------
+# externalServices
+
+@test "server/StatefulSet: external-address-init is not added by default" {
+  cd `chart_dir`
+  local actual=$(helm template \
+      -s templates/server-statefulset.yaml  \
+      . | tee /dev/stderr |
+      yq -r '.spec.template.spec.initContainers | map(select(.name == "external-address-init")) | length' | tee /dev/stderr)
+  [ "${actual}" = "0" ]
+}
+
+@test "server/StatefulSet: external-address-init is not added when server.externalServices.advertiseExternalAddress=false" {
+  cd `chart_dir`
+  local actual=$(helm template \
+      -s templates/server-statefulset.yaml  \
+      --set 'server.externalServices.enabled=true' \
+      --set 'server.externalServices.type=NodePort' \
+      --set 'server.externalServices.advertiseExternalAddress=false' \
+      . | tee /dev/stderr |
+      yq -r '.spec.template.spec.initContainers | map(select(.name == "external-address-init")) | length' | tee /dev/stderr)
+  [ "${actual}" = "0" ]
+}
+
+@test "server/StatefulSet: external-address-init writes the address of the server's external Service" {
+  cd `chart_dir`
+  local actual=$(helm template \
+      -s templates/server-statefulset.yaml  \
+      --namespace foo \
+      --set 'server.externalServices.enabled=true' \
+      . | tee /dev/stderr |
+      yq -r '.spec.template.spec.initContainers | map(select(.name == "external-address-init")) | .[0].command[2]' | tee /dev/stderr)
+
+  [[ "$actual" =~ 'fetch-server-external-address' ]]
+  [[ "$actual" =~ '-service-name "${POD_NAME}-external"' ]]
+  [[ "$actual" =~ '-namespace foo' ]]
+  [[ "$actual" =~ '-node-name "${NODE_NAME}"' ]]
+  [[ "$actual" =~ '-output-file /consul/extra-config/external-address.json' ]]
+}
+
+@test "server/StatefulSet: the advertise flag is set by default" {
+  cd `chart_dir`
+  local actual=$(helm template \
+      -s templates/server-statefulset.yaml  \
+      . | tee /dev/stderr |
+      yq -r '.spec.template.spec.containers[0].command[2] | contains("-advertise=\"${ADVERTISE_IP}\"")' | tee /dev/stderr)
+  [ "${actual}" = "true" ]
+}
+
+@test "server/StatefulSet: the advertise flag is not set when the external address is advertised" {
+  cd `chart_dir`
+  local actual=$(helm template \
+      -s templates/server-statefulset.yaml  \
+      --set 'server.externalServices.enabled=true' \
+      . | tee /dev/stderr |
+      yq -r '.spec.template.spec.containers[0].command[2] | contains("-advertise=")' | tee /dev/stderr)
+  [ "${actual}" = "false" ]
+}
@@ -92,3 +92,52 @@ load _helpers
       yq -c '.rules[0].verbs' | tee /dev/stderr)
   [ "${actual}" = '["get","list","watch","update","patch","delete","create"]' ]
 }
+
+#--------------------------------------------------------------------
+# syncCatalog.toConsul={true,false}
+
+@test "syncCatalog/ClusterRole: can create events if toConsul=true" {
+  cd `chart_dir`
+  local actual=$(helm template \
+      -s templates/sync-catalog-clusterrole.yaml  \
+      --set 'syncCatalog.enabled=true' \
+      . | tee /dev/stderr |
+      yq -c '.rules | map(select(.resources[0] == "events")) | .[0].verbs' | tee /dev/stderr)
+  [ "${actual}" = '["create","patch"]' ]
+}
+
+@test "syncCatalog/ClusterRole: cannot create events if toConsul=false" {
+  cd `chart_dir`
+  local actual=$(helm template \
+      -s templates/sync-catalog-clusterrole.yaml  \
+      --set 'syncCatalog.enabled=true' \
+      --set 'syncCatalog.toConsul=false' \
+      . | tee /dev/stderr |
+      yq '.rules | map(select(.resources[0] == "events")) | length' | tee /dev/stderr)
+  [ "${actual}" = "0" ]
+}
+
+#--------------------------------------------------------------------
+# ingressHosts
+
+@test "syncCatalog/ClusterRole: no Gateway API permissions by default" {
+  cd `chart_dir`
+  local actual=$(helm template \
+      -s templates/sync-catalog-clusterrole.yaml  \
+      --set 'syncCatalog.enabled=true' \
+      --set 'syncCatalog.ingressHosts.enabled=true' \
+      . | tee /dev/stderr |
+      yq '.rules | map(select(.apiGroups[0] == "gateway.networking.k8s.io")) | length' | tee /dev/stderr)
+  [ "${actual}" = "0" ]
+}
+
+@test "syncCatalog/ClusterRole: can watch gateways and httproutes with syncCatalog.ingressHosts.httpRoutes=true" {
+  cd `chart_dir`
+  local actual=$(helm template \
+      -s templates/sync-catalog-clusterrole.yaml  \
+      --set 'syncCatalog.enabled=true' \
+      --set 'syncCatalog.ingressHosts.httpRoutes=true' \
+      . | tee /dev/stderr |
+      yq -c '.rules | map(select(.apiGroups[0] == "gateway.networking.k8s.io")) | .[0]' | tee /dev/stderr)
+  [ "${actual}" = '{"apiGroups":["gateway.networking.k8s.io"],"resources":["gateways","httproutes"],"verbs":["get","list","watch"]}' ]
+}
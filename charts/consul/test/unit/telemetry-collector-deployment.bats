@@ -1110,6 +1110,42 @@ load _helpers
   [ "${actual}" = "true" ]
 }
 
+@test "telemetryCollector/Deployment: config secret is mounted when configName is set" {
+  cd `chart_dir`
+  local object=$(helm template \
+      -s templates/telemetry-collector-deployment.yaml  \
+      --set 'telemetryCollector.enabled=true' \
+      --set 'telemetryCollector.image=bar' \
+      --set 'telemetryCollector.configName=exporters' \
+      . | tee /dev/stderr |
+      yq -r '.spec.template.spec' | tee /dev/stderr)
+
+  local actual=$(echo $object | yq -r '.volumes[] | select(.name == "config") | .secret.secretName' | tee /dev/stderr)
+  [ "${actual}" = "exporters-telemetry-collector-config" ]
+
+  actual=$(echo $object | yq -r '.volumes[] | select(.name == "config") | .configMap' | tee /dev/stderr)
+  [ "${actual}" = "null" ]
+
+  actual=$(echo $object | yq -r '.containers[0].volumeMounts[] | select(.name == "config") | .mountPath' | tee /dev/stderr)
+  [ "${actual}" = "/consul/config" ]
+
+  actual=$(echo $object | yq -r '.containers[0].command | any(contains("-config-file-path /consul/config/config.json"))' | tee /dev/stderr)
+  [ "${actual}" = "true" ]
+}
+
+@test "telemetryCollector/Deployment: fails if configName and customExporterConfig are both set" {
+  cd `chart_dir`
+  run helm template \
+      -s templates/telemetry-collector-deployment.yaml  \
+      --set 'telemetryCollector.enabled=true' \
+      --set 'telemetryCollector.image=bar' \
+      --set 'telemetryCollector.configName=exporters' \
+      --set 'telemetryCollector.customExporterConfig="foo"' \
+      .
+  [ "$status" -eq 1 ]
+  [[ "$output" =~ "telemetryCollector.customExporterConfig and telemetryCollector.configName cannot both be set" ]]
+}
+
 @test "telemetryCollector/Deployment: consul-ca-cert volume mount is not set on acl-init when using externalServers and useSystemRoots" {
   cd `chart_dir`
   local actual=$(helm template \
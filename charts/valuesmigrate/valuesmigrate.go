@@ -0,0 +1,392 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+// Package valuesmigrate detects Helm values of the Consul chart that have been moved, removed
+// or deprecated and migrates values files to the current layout of values.yaml.
+//
+// The moved, removed and deprecated values are listed in migrations.yaml so that both the
+// consul-k8s CLI and the hack/values-migrate tool share a single source of truth.
+package valuesmigrate
+
+import (
+	"bytes"
+	_ "embed"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+//go:embed migrations.yaml
+var migrationsYAML []byte
+
+// Migration describes a Helm value that was moved, removed or deprecated.
+type Migration struct {
+	// From is the dotted path of the old value, e.g. "server.enterpriseLicense".
+	From string `yaml:"from"`
+	// To is the dotted path the value was moved to. If it is empty, the value was removed.
+	To string `yaml:"to,omitempty"`
+	// Deprecated is true if the value is still supported but will be removed.
+	Deprecated bool `yaml:"deprecated,omitempty"`
+	// Version is the chart version the value was moved, removed or deprecated in.
+	// If it is empty, the migration applies to every version.
+	Version string `yaml:"version,omitempty"`
+	// Note tells users what they need to know about the migration.
+	Note string `yaml:"note,omitempty"`
+}
+
+// String returns a human-readable description of the migration.
+func (m Migration) String() string {
+	var s string
+	switch {
+	case m.Deprecated:
+		s = fmt.Sprintf("%s is deprecated", m.From)
+	case m.To != "":
+		s = fmt.Sprintf("%s has been moved to %s", m.From, m.To)
+	default:
+		s = fmt.Sprintf("%s has been removed", m.From)
+	}
+	if m.Version != "" && !m.Deprecated {
+		s += fmt.Sprintf(" in %s", m.Version)
+	}
+	if m.Note != "" {
+		s += ". " + m.Note
+	}
+	return s
+}
+
+// Action is what Migrate did with a value.
+type Action string
+
+const (
+	// ActionMoved means the value was moved to its new path.
+	ActionMoved Action = "moved"
+	// ActionRemoved means the value was removed.
+	ActionRemoved Action = "removed"
+	// ActionConflict means the value was removed because its new path was already set.
+	ActionConflict Action = "conflict"
+	// ActionDeprecated means the value is deprecated and was left as is.
+	ActionDeprecated Action = "deprecated"
+)
+
+// Change is a change made by Migrate.
+type Change struct {
+	Migration Migration
+	Action    Action
+}
+
+// String returns a human-readable description of the change.
+func (c Change) String() string {
+	if c.Action == ActionConflict {
+		return fmt.Sprintf("%s was removed because %s is already set; merge its value into %s if needed",
+			c.Migration.From, c.Migration.To, c.Migration.To)
+	}
+	return c.Migration.String()
+}
+
+// Load returns the migrations in the order they were made.
+func Load() ([]Migration, error) {
+	return Parse(migrationsYAML)
+}
+
+// Parse parses a list of migrations in the format of migrations.yaml.
+func Parse(data []byte) ([]Migration, error) {
+	var migrations []Migration
+	if err := yaml.Unmarshal(data, &migrations); err != nil {
+		return nil, fmt.Errorf("error parsing migrations: %w", err)
+	}
+	for _, m := range migrations {
+		if m.From == "" {
+			return nil, fmt.Errorf("migration is missing from: %+v", m)
+		}
+		if m.Deprecated && m.To != "" {
+			return nil, fmt.Errorf("deprecated migration of %s can't have a to path", m.From)
+		}
+		if m.Version != "" {
+			if _, err := parseVersion(m.Version); err != nil {
+				return nil, fmt.Errorf("migration of %s: %w", m.From, err)
+			}
+		}
+	}
+	return migrations, nil
+}
+
+// Validate checks migrations against the chart's default values: values that were moved or removed
+// must not be in the chart anymore and values that were moved must be in the chart, unless they were
+// removed by a later migration.
+func Validate(migrations []Migration, chartValues map[string]interface{}) error {
+	migratedLater := make(map[string]bool)
+	for _, m := range migrations {
+		migratedLater[m.From] = true
+	}
+	for _, m := range migrations {
+		if !m.Deprecated && hasPath(chartValues, strings.Split(m.From, ".")) {
+			return fmt.Errorf("%s was migrated but is still in the chart values", m.From)
+		}
+		if m.To != "" && !migratedLater[m.To] && !hasPath(chartValues, strings.Split(m.To, ".")) {
+			return fmt.Errorf("%s was migrated to %s which is not in the chart values", m.From, m.To)
+		}
+	}
+	return nil
+}
+
+// Between returns the migrations that were made after chart version from and up to and including
+// chart version to. An empty from or to doesn't limit the versions. Migrations without a version
+// are always returned.
+func Between(migrations []Migration, from, to string) ([]Migration, error) {
+	var fromVersion, toVersion []int
+	var err error
+	if from != "" {
+		if fromVersion, err = parseVersion(from); err != nil {
+			return nil, err
+		}
+	}
+	if to != "" {
+		if toVersion, err = parseVersion(to); err != nil {
+			return nil, err
+		}
+	}
+
+	var result []Migration
+	for _, m := range migrations {
+		if m.Version != "" {
+			// The versions of the migrations were validated when they were parsed.
+			version, _ := parseVersion(m.Version)
+			if fromVersion != nil && compareVersions(version, fromVersion) <= 0 {
+				continue
+			}
+			if toVersion != nil && compareVersions(version, toVersion) > 0 {
+				continue
+			}
+		}
+		result = append(result, m)
+	}
+	return result, nil
+}
+
+// Detect returns the migrations whose old value is set in values.
+func Detect(values map[string]interface{}, migrations []Migration) []Migration {
+	var detected []Migration
+	for _, m := range migrations {
+		if isSet(values, strings.Split(m.From, ".")) {
+			detected = append(detected, m)
+		}
+	}
+	return detected
+}
+
+// hasPath returns true if path is in values, even if its value is null.
+func hasPath(values map[string]interface{}, path []string) bool {
+	value, ok := values[path[0]]
+	if !ok {
+		return false
+	}
+	if len(path) == 1 {
+		return true
+	}
+	nested, ok := value.(map[string]interface{})
+	return ok && hasPath(nested, path[1:])
+}
+
+// isSet returns true if path is in values and its value isn't null.
+func isSet(values map[string]interface{}, path []string) bool {
+	value, ok := values[path[0]]
+	if !ok || value == nil {
+		return false
+	}
+	if len(path) == 1 {
+		return true
+	}
+	nested, ok := value.(map[string]interface{})
+	if !ok {
+		return false
+	}
+	return isSet(nested, path[1:])
+}
+
+// Migrate applies migrations in order to the values file data and returns the migrated values file
+// and the changes that were made. Moved values keep their comments. If the new path of a moved value is
+// already set, it is kept and the old value is removed. Mappings that become empty are removed.
+func Migrate(data []byte, migrations []Migration) ([]byte, []Change, error) {
+	var doc yaml.Node
+	if err := yaml.Unmarshal(data, &doc); err != nil {
+		return nil, nil, fmt.Errorf("error parsing values: %w", err)
+	}
+	if len(doc.Content) == 0 {
+		return data, nil, nil
+	}
+	root := doc.Content[0]
+	if root.Kind != yaml.MappingNode {
+		return nil, nil, fmt.Errorf("values must be a map")
+	}
+
+	var changes []Change
+	for _, m := range migrations {
+		from := strings.Split(m.From, ".")
+		parents, i := lookup(root, from)
+		if parents == nil || isNull(parents[len(parents)-1].Content[i+1]) {
+			continue
+		}
+		if m.Deprecated {
+			changes = append(changes, Change{Migration: m, Action: ActionDeprecated})
+			continue
+		}
+
+		parent := parents[len(parents)-1]
+		key, value := parent.Content[i], parent.Content[i+1]
+		action := ActionRemoved
+		if m.To != "" {
+			action = ActionMoved
+			if !set(root, strings.Split(m.To, "."), key, value) {
+				action = ActionConflict
+			}
+		}
+		remove(parents, from, i)
+		changes = append(changes, Change{Migration: m, Action: action})
+	}
+	if len(changes) == 0 {
+		return data, nil, nil
+	}
+
+	var buf bytes.Buffer
+	enc := yaml.NewEncoder(&buf)
+	enc.SetIndent(2)
+	if err := enc.Encode(&doc); err != nil {
+		return nil, nil, fmt.Errorf("error writing values: %w", err)
+	}
+	if err := enc.Close(); err != nil {
+		return nil, nil, fmt.Errorf("error writing values: %w", err)
+	}
+	return buf.Bytes(), changes, nil
+}
+
+// lookup returns the mappings along path, starting with root, and the index of the last key
+// of path in the last mapping. It returns nil if path is not in root.
+func lookup(root *yaml.Node, path []string) ([]*yaml.Node, int) {
+	parents := []*yaml.Node{root}
+	node := root
+	for n, name := range path {
+		i := keyIndex(node, name)
+		if i < 0 {
+			return nil, 0
+		}
+		if n == len(path)-1 {
+			return parents, i
+		}
+		node = node.Content[i+1]
+		parents = append(parents, node)
+	}
+	return nil, 0
+}
+
+// set sets path in root to value, using key for the comments of the new key, and creates the
+// mappings along path as needed. It returns false if path is already set.
+func set(root *yaml.Node, path []string, key, value *yaml.Node) bool {
+	node := root
+	for n, name := range path {
+		i := keyIndex(node, name)
+		if n == len(path)-1 {
+			if i >= 0 && !isNull(node.Content[i+1]) {
+				return false
+			}
+			newKey := &yaml.Node{
+				Kind:        yaml.ScalarNode,
+				Tag:         "!!str",
+				Value:       name,
+				HeadComment: key.HeadComment,
+				LineComment: key.LineComment,
+				FootComment: key.FootComment,
+			}
+			if i >= 0 {
+				node.Content[i], node.Content[i+1] = newKey, value
+			} else {
+				node.Content = append(node.Content, newKey, value)
+			}
+			return true
+		}
+
+		if i < 0 {
+			node.Content = append(node.Content,
+				&yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: name},
+				&yaml.Node{Kind: yaml.MappingNode, Tag: "!!map"})
+			i = len(node.Content) - 2
+		}
+		child := node.Content[i+1]
+		if isNull(child) {
+			child = &yaml.Node{Kind: yaml.MappingNode, Tag: "!!map"}
+			node.Content[i+1] = child
+		}
+		if child.Kind != yaml.MappingNode {
+			return false
+		}
+		node = child
+	}
+	return false
+}
+
+// remove removes the key at index i of the last mapping in parents, then removes the mappings
+// along path that are left empty.
+func remove(parents []*yaml.Node, path []string, i int) {
+	for n := len(parents) - 1; n >= 0; n-- {
+		parent := parents[n]
+		parent.Content = append(parent.Content[:i], parent.Content[i+2:]...)
+		if n == 0 || len(parent.Content) > 0 {
+			return
+		}
+		i = keyIndex(parents[n-1], path[n-1])
+	}
+}
+
+func keyIndex(mapping *yaml.Node, name string) int {
+	if mapping.Kind != yaml.MappingNode {
+		return -1
+	}
+	for i := 0; i+1 < len(mapping.Content); i += 2 {
+		if mapping.Content[i].Value == name {
+			return i
+		}
+	}
+	return -1
+}
+
+func isNull(node *yaml.Node) bool {
+	return node.Kind == yaml.ScalarNode && node.Tag == "!!null"
+}
+
+// parseVersion parses a chart version like 1.5.0 or v1.6.0-dev into its numeric parts.
+func parseVersion(version string) ([]int, error) {
+	v := strings.TrimPrefix(version, "v")
+	if i := strings.IndexAny(v, "-+"); i >= 0 {
+		v = v[:i]
+	}
+	parts := strings.Split(v, ".")
+	nums := make([]int, len(parts))
+	for i, part := range parts {
+		n, err := strconv.Atoi(part)
+		if err != nil {
+			return nil, fmt.Errorf("invalid version %q", version)
+		}
+		nums[i] = n
+	}
+	return nums, nil
+}
+
+func compareVersions(a, b []int) int {
+	for i := 0; i < len(a) || i < len(b); i++ {
+		var x, y int
+		if i < len(a) {
+			x = a[i]
+		}
+		if i < len(b) {
+			y = b[i]
+		}
+		if x != y {
+			if x < y {
+				return -1
+			}
+			return 1
+		}
+	}
+	return 0
+}
@@ -0,0 +1,243 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package valuesmigrate
+
+import (
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"gopkg.in/yaml.v3"
+)
+
+func TestLoad(t *testing.T) {
+	migrations, err := Load()
+	require.NoError(t, err)
+	require.NotEmpty(t, migrations)
+
+	// The migrations must be kept up to date with the chart's values.
+	data, err := os.ReadFile("../consul/values.yaml")
+	require.NoError(t, err)
+	var values map[string]interface{}
+	require.NoError(t, yaml.Unmarshal(data, &values))
+	require.NoError(t, Validate(migrations, values))
+}
+
+func TestValidate(t *testing.T) {
+	values := map[string]interface{}{
+		"global": map[string]interface{}{"enterpriseLicense": nil},
+		"server": map[string]interface{}{"enabled": true},
+	}
+	cases := map[string]struct {
+		migrations []Migration
+		expErr     string
+	}{
+		"valid": {
+			migrations: []Migration{
+				{From: "server.enterpriseLicense", To: "global.enterpriseLicense"},
+				{From: "global.lifecycleSidecarContainer", To: "global.consulSidecarContainer"},
+				{From: "global.consulSidecarContainer"},
+			},
+		},
+		"old value still in chart": {
+			migrations: []Migration{{From: "server.enabled"}},
+			expErr:     "server.enabled was migrated but is still in the chart values",
+		},
+		"new value not in chart": {
+			migrations: []Migration{{From: "server.enterpriseLicense", To: "global.license"}},
+			expErr:     "server.enterpriseLicense was migrated to global.license which is not in the chart values",
+		},
+	}
+	for name, c := range cases {
+		t.Run(name, func(t *testing.T) {
+			err := Validate(c.migrations, values)
+			if c.expErr == "" {
+				require.NoError(t, err)
+			} else {
+				require.EqualError(t, err, c.expErr)
+			}
+		})
+	}
+}
+
+func TestParse_Errors(t *testing.T) {
+	cases := map[string]string{
+		"missing from":         "- to: global.name",
+		"deprecated and moved": "- {from: a, to: b, deprecated: true}",
+		"invalid version":      "- {from: a, version: one}",
+	}
+	for name, data := range cases {
+		t.Run(name, func(t *testing.T) {
+			_, err := Parse([]byte(data))
+			require.Error(t, err)
+		})
+	}
+}
+
+func TestBetween(t *testing.T) {
+	migrations := []Migration{
+		{From: "a", Version: "0.37.0"},
+		{From: "b", Version: "1.0.0"},
+		{From: "c"},
+		{From: "d", Version: "1.5.0"},
+	}
+	cases := map[string]struct {
+		from, to string
+		exp      []string
+	}{
+		"all":               {exp: []string{"a", "b", "c", "d"}},
+		"from is exclusive": {from: "1.0.0", exp: []string{"c", "d"}},
+		"to is inclusive":   {to: "1.0.0", exp: []string{"a", "b", "c"}},
+		"prerelease":        {from: "v0.49.2", to: "1.5.0-dev", exp: []string{"b", "c", "d"}},
+	}
+	for name, c := range cases {
+		t.Run(name, func(t *testing.T) {
+			result, err := Between(migrations, c.from, c.to)
+			require.NoError(t, err)
+			var froms []string
+			for _, m := range result {
+				froms = append(froms, m.From)
+			}
+			require.Equal(t, c.exp, froms)
+		})
+	}
+
+	_, err := Between(migrations, "latest", "")
+	require.Error(t, err)
+}
+
+func TestDetect(t *testing.T) {
+	migrations := []Migration{
+		{From: "server.enterpriseLicense", To: "global.enterpriseLicense"},
+		{From: "global.imageEnvoy"},
+		{From: "controller"},
+		{From: "apiGateway"},
+	}
+	values := map[string]interface{}{
+		"server": map[string]interface{}{
+			"enterpriseLicense": map[string]interface{}{"secretName": "license"},
+		},
+		"global":     map[string]interface{}{"imageEnvoy": nil},
+		"controller": "not a map",
+	}
+	require.Equal(t, []Migration{migrations[0], migrations[2]}, Detect(values, migrations))
+}
+
+func TestMigrate(t *testing.T) {
+	migrations, err := Load()
+	require.NoError(t, err)
+
+	cases := map[string]struct {
+		values     string
+		expValues  string
+		expChanges []Action
+	}{
+		"moves values and keeps comments": {
+			values: `global:
+  name: consul
+server:
+  # The license secret.
+  enterpriseLicense:
+    secretName: license
+    secretKey: key
+`,
+			expValues: `global:
+  name: consul
+  # The license secret.
+  enterpriseLicense:
+    secretName: license
+    secretKey: key
+`,
+			expChanges: []Action{ActionMoved},
+		},
+		"removes values and empty maps": {
+			values: `global:
+  imageEnvoy: envoyproxy/envoy:v1.22.0
+meshGateway:
+  enabled: true
+  service:
+    enabled: true
+controller:
+  enabled: true
+`,
+			expValues: `meshGateway:
+  enabled: true
+`,
+			expChanges: []Action{ActionRemoved, ActionRemoved, ActionRemoved},
+		},
+		"applies migrations in order": {
+			values: `global:
+  lifecycleSidecarContainer:
+    resources: {}
+`,
+			expValues:  "{}\n",
+			expChanges: []Action{ActionMoved, ActionRemoved},
+		},
+		"keeps the new value on conflict": {
+			values: `global:
+  enterpriseLicense:
+    secretName: new
+server:
+  enterpriseLicense:
+    secretName: old
+`,
+			expValues: `global:
+  enterpriseLicense:
+    secretName: new
+`,
+			expChanges: []Action{ActionConflict},
+		},
+		"sets null parents": {
+			values: `apiGateway:
+  managedGatewayClass:
+    serviceType: NodePort
+connectInject:
+  apiGateway: null
+`,
+			expValues: `connectInject:
+  apiGateway:
+    managedGatewayClass:
+      serviceType: NodePort
+`,
+			expChanges: []Action{ActionMoved},
+		},
+		"reports deprecated values": {
+			values: `syncCatalog:
+  k8sSourceNamespace: default
+`,
+			expValues: `syncCatalog:
+  k8sSourceNamespace: default
+`,
+			expChanges: []Action{ActionDeprecated},
+		},
+		"ignores null values": {
+			values: `global:
+  imageEnvoy: null
+`,
+			expValues: `global:
+  imageEnvoy: null
+`,
+		},
+	}
+	for name, c := range cases {
+		t.Run(name, func(t *testing.T) {
+			migrated, changes, err := Migrate([]byte(c.values), migrations)
+			require.NoError(t, err)
+			require.Equal(t, c.expValues, string(migrated))
+			var actions []Action
+			for _, change := range changes {
+				actions = append(actions, change.Action)
+			}
+			require.Equal(t, c.expChanges, actions)
+		})
+	}
+}
+
+func TestMigrate_Errors(t *testing.T) {
+	_, _, err := Migrate([]byte("- global"), nil)
+	require.EqualError(t, err, "values must be a map")
+
+	_, _, err = Migrate([]byte("global: ["), nil)
+	require.Error(t, err)
+}
@@ -0,0 +1,29 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package adminpartition
+
+import (
+	"fmt"
+
+	"github.com/hashicorp/consul-k8s/cli/common"
+	"github.com/mitchellh/cli"
+)
+
+// AdminPartitionCommand provides a synopsis for the admin-partition subcommands (e.g. provision).
+type AdminPartitionCommand struct {
+	*common.BaseCommand
+}
+
+// Run prints out information about the subcommands.
+func (c *AdminPartitionCommand) Run([]string) int {
+	return cli.RunResultHelp
+}
+
+func (c *AdminPartitionCommand) Help() string {
+	return fmt.Sprintf("%s\n\nUsage: consul-k8s admin-partition <subcommand>", c.Synopsis())
+}
+
+func (c *AdminPartitionCommand) Synopsis() string {
+	return "Operate on Consul admin partitions"
+}
@@ -0,0 +1,641 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package provision
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/hashicorp/consul/api"
+	"github.com/posener/complete"
+	helmCLI "helm.sh/helm/v3/pkg/cli"
+	"helm.sh/helm/v3/pkg/cli/values"
+	"helm.sh/helm/v3/pkg/getter"
+	corev1 "k8s.io/api/core/v1"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/validation"
+	"k8s.io/client-go/kubernetes"
+	_ "k8s.io/client-go/plugin/pkg/client/auth"
+	"sigs.k8s.io/yaml"
+
+	consulChart "github.com/hashicorp/consul-k8s/charts"
+	"github.com/hashicorp/consul-k8s/cli/common"
+	"github.com/hashicorp/consul-k8s/cli/common/flag"
+	"github.com/hashicorp/consul-k8s/cli/common/terminal"
+	"github.com/hashicorp/consul-k8s/cli/helm"
+)
+
+const (
+	flagNameName          = "name"
+	flagNameDescription   = "description"
+	flagNameConsulAddress = "consul-address"
+	flagNameConsulToken   = "consul-token"
+	flagNameCAFile        = "ca-file"
+	flagNameTLSServerName = "tls-server-name"
+
+	flagNameInstall           = "install"
+	flagNameServerHosts       = "server-hosts"
+	flagNameK8sAuthMethodHost = "k8s-auth-method-host"
+	flagNameNamespace         = "namespace"
+	flagNameConfigFile        = "config-file"
+	flagNameSetValues         = "set"
+	flagNameAutoApprove       = "auto-approve"
+	flagNameTimeout           = "timeout"
+	flagNameWait              = "wait"
+
+	flagNameKubeConfig  = "kubeconfig"
+	flagNameKubeContext = "context"
+
+	defaultTimeout = "10m"
+
+	// tokenSecretName is the name of the Kubernetes secret that stores the ACL token the Helm
+	// release uses to set up ACLs in the partition.
+	tokenSecretName = "consul-partition-acl-token"
+	tokenSecretKey  = "token"
+	// caCertSecretName is the name of the Kubernetes secret that stores the CA certificate of the
+	// Consul servers.
+	caCertSecretName = "consul-ca-cert"
+	caCertSecretKey  = "tls.crt"
+)
+
+// partitionPolicyRules are the rules of the policy of the partition's ACL token. The token is used by the
+// Helm release in the partition's cluster to set up the partition's ACLs, auth method and namespaces, which
+// requires write access to the partition and operator access to read partitions and create namespaces.
+const partitionPolicyRules = `operator = "write"
+agent_prefix "" {
+  policy = "read"
+}
+partition "%s" {
+  acl     = "write"
+  mesh    = "write"
+  peering = "write"
+  node_prefix "" {
+    policy = "write"
+  }
+  namespace_prefix "" {
+    acl = "write"
+    service_prefix "" {
+      policy = "write"
+    }
+  }
+}`
+
+// ProvisionCommand creates an admin partition in Consul with an ACL token to manage it, and optionally
+// installs Consul into a Kubernetes cluster as a member of the partition.
+type ProvisionCommand struct {
+	*common.BaseCommand
+
+	consul     *api.Client
+	kubernetes kubernetes.Interface
+
+	helmActionsRunner helm.HelmActionsRunner
+
+	set *flag.Sets
+
+	flagName          string
+	flagDescription   string
+	flagConsulAddress string
+	flagConsulToken   string
+	flagCAFile        string
+	flagTLSServerName string
+
+	flagInstall           bool
+	flagServerHosts       []string
+	flagK8sAuthMethodHost string
+	flagNamespace         string
+	flagValueFiles        []string
+	flagSetValues         []string
+	flagAutoApprove       bool
+	flagTimeout           string
+	flagWait              bool
+
+	flagKubeConfig  string
+	flagKubeContext string
+
+	once sync.Once
+	help string
+}
+
+func (c *ProvisionCommand) init() {
+	c.set = flag.NewSets()
+
+	f := c.set.NewSet("Command Options")
+	f.StringVar(&flag.StringVar{
+		Name:   flagNameName,
+		Target: &c.flagName,
+		Usage:  "The name of the admin partition to provision.",
+	})
+	f.StringVar(&flag.StringVar{
+		Name:   flagNameDescription,
+		Target: &c.flagDescription,
+		Usage:  "A description of the admin partition.",
+	})
+	f.StringVar(&flag.StringVar{
+		Name:   flagNameConsulAddress,
+		Target: &c.flagConsulAddress,
+		Usage:  "The address of the Consul servers' HTTP API. Defaults to the CONSUL_HTTP_ADDR environment variable.",
+	})
+	f.StringVar(&flag.StringVar{
+		Name:   flagNameConsulToken,
+		Target: &c.flagConsulToken,
+		Usage: "The ACL token used to create the admin partition and its ACL token. It must have operator and acl write " +
+			"permissions. Defaults to the CONSUL_HTTP_TOKEN environment variable.",
+	})
+	f.StringVar(&flag.StringVar{
+		Name:   flagNameCAFile,
+		Target: &c.flagCAFile,
+		Usage: "The path to the CA certificate of the Consul servers. When installing, the certificate is stored in a " +
+			"Kubernetes secret and TLS is enabled for the release.",
+	})
+	f.StringVar(&flag.StringVar{
+		Name:   flagNameTLSServerName,
+		Target: &c.flagTLSServerName,
+		Usage:  "The server name to verify the TLS certificate of the Consul servers against.",
+	})
+	f.BoolVar(&flag.BoolVar{
+		Name:    flagNameInstall,
+		Target:  &c.flagInstall,
+		Default: false,
+		Usage:   "Install Consul into the Kubernetes cluster as a member of the admin partition after it is provisioned.",
+	})
+	f.StringSliceVar(&flag.StringSliceVar{
+		Name:   flagNameServerHosts,
+		Target: &c.flagServerHosts,
+		Usage: "The addresses of the Consul servers that the installation connects to. Required with -install. " +
+			"Can be specified multiple times.",
+	})
+	f.StringVar(&flag.StringVar{
+		Name:   flagNameK8sAuthMethodHost,
+		Target: &c.flagK8sAuthMethodHost,
+		Usage: "The address of the Kubernetes API server that the Consul servers use to validate service account " +
+			"tokens. Defaults to the address of the current Kubernetes context.",
+	})
+	f.StringVar(&flag.StringVar{
+		Name:    flagNameNamespace,
+		Target:  &c.flagNamespace,
+		Default: common.DefaultReleaseNamespace,
+		Usage:   "The namespace to install Consul into.",
+	})
+	f.StringSliceVar(&flag.StringSliceVar{
+		Name:    flagNameConfigFile,
+		Aliases: []string{"f"},
+		Target:  &c.flagValueFiles,
+		Usage:   "The path to a Helm values file that customizes the installation. Can be specified multiple times.",
+	})
+	f.StringSliceVar(&flag.StringSliceVar{
+		Name:   flagNameSetValues,
+		Target: &c.flagSetValues,
+		Usage:  "Set a Helm value to customize the installation. Can be specified multiple times.",
+	})
+	f.BoolVar(&flag.BoolVar{
+		Name:    flagNameAutoApprove,
+		Target:  &c.flagAutoApprove,
+		Default: false,
+		Usage:   "Skip confirmation prompt.",
+	})
+	f.StringVar(&flag.StringVar{
+		Name:    flagNameTimeout,
+		Target:  &c.flagTimeout,
+		Default: defaultTimeout,
+		Usage:   "Set a timeout to wait for the installation to be ready.",
+	})
+	f.BoolVar(&flag.BoolVar{
+		Name:    flagNameWait,
+		Target:  &c.flagWait,
+		Default: true,
+		Usage:   "Wait for Kubernetes resources in the installation to be ready before exiting command.",
+	})
+
+	f = c.set.NewSet("Global Options")
+	f.StringVar(&flag.StringVar{
+		Name:    flagNameKubeConfig,
+		Aliases: []string{"c"},
+		Target:  &c.flagKubeConfig,
+		Default: "",
+		Usage:   "Path to kubeconfig file.",
+	})
+	f.StringVar(&flag.StringVar{
+		Name:    flagNameKubeContext,
+		Target:  &c.flagKubeContext,
+		Default: "",
+		Usage:   "Kubernetes context to use.",
+	})
+
+	c.help = c.set.Help()
+}
+
+// Run provisions an admin partition.
+func (c *ProvisionCommand) Run(args []string) int {
+	c.once.Do(c.init)
+	if c.helmActionsRunner == nil {
+		c.helmActionsRunner = &helm.ActionRunner{}
+	}
+
+	c.Log.ResetNamed("admin-partition provision")
+	defer common.CloseWithError(c.BaseCommand)
+
+	if err := c.set.Parse(args); err != nil {
+		c.UI.Output(err.Error())
+		return 1
+	}
+
+	if err := c.validateFlags(); err != nil {
+		c.UI.Output(err.Error())
+		return 1
+	}
+
+	if err := c.setupConsulClient(); err != nil {
+		c.UI.Output(err.Error(), terminal.WithErrorStyle())
+		return 1
+	}
+
+	c.UI.Output("Provisioning admin partition %s", c.flagName, terminal.WithHeaderStyle())
+	if err := c.createPartition(); err != nil {
+		c.UI.Output(err.Error(), terminal.WithErrorStyle())
+		return 1
+	}
+	token, err := c.createToken()
+	if err != nil {
+		c.UI.Output(err.Error(), terminal.WithErrorStyle())
+		return 1
+	}
+
+	if !c.flagInstall {
+		c.UI.Output("Next steps", terminal.WithHeaderStyle())
+		c.UI.Output("Store the ACL token in the cluster of the admin partition:", terminal.WithInfoStyle())
+		c.UI.Output("kubectl create secret generic %s --namespace %s --from-literal=%s=%s",
+			tokenSecretName, c.flagNamespace, tokenSecretKey, token.SecretID, terminal.WithInfoStyle())
+		c.UI.Output("Then run this command again with -%s, or install Consul with these Helm values:", flagNameInstall, terminal.WithInfoStyle())
+		valuesYaml, err := yaml.Marshal(c.partitionValues(c.flagK8sAuthMethodHost))
+		if err != nil {
+			c.UI.Output(err.Error(), terminal.WithErrorStyle())
+			return 1
+		}
+		c.UI.Output(string(valuesYaml), terminal.WithInfoStyle())
+		return 0
+	}
+
+	if err := c.install(token); err != nil {
+		c.UI.Output(err.Error(), terminal.WithErrorStyle())
+		return 1
+	}
+	return 0
+}
+
+// validateFlags checks the command line flags and values for errors.
+func (c *ProvisionCommand) validateFlags() error {
+	if len(c.set.Args()) > 0 {
+		return errors.New("should have no non-flag arguments")
+	}
+	if c.flagName == "" {
+		return fmt.Errorf("-%s must be set", flagNameName)
+	}
+	if c.flagName == "default" {
+		return fmt.Errorf("-%s cannot be the default partition", flagNameName)
+	}
+	if errs := validation.IsDNS1123Label(c.flagName); len(errs) > 0 {
+		return fmt.Errorf("-%s %q is not a valid partition name: %s", flagNameName, c.flagName, errs[0])
+	}
+	if c.flagInstall && len(c.flagServerHosts) == 0 {
+		return fmt.Errorf("-%s must be set with -%s", flagNameServerHosts, flagNameInstall)
+	}
+	if _, err := time.ParseDuration(c.flagTimeout); err != nil {
+		return fmt.Errorf("unable to parse -%s: %s", flagNameTimeout, err)
+	}
+	return nil
+}
+
+// setupConsulClient creates the Consul client used to provision the partition unless one has
+// already been set.
+func (c *ProvisionCommand) setupConsulClient() error {
+	if c.consul != nil {
+		return nil
+	}
+
+	cfg := api.DefaultConfig()
+	if c.flagConsulAddress != "" {
+		cfg.Address = c.flagConsulAddress
+	}
+	if c.flagConsulToken != "" {
+		cfg.Token = c.flagConsulToken
+	}
+	if c.flagCAFile != "" {
+		cfg.TLSConfig.CAFile = c.flagCAFile
+		if !strings.HasPrefix(cfg.Address, "http://") {
+			cfg.Scheme = "https"
+		}
+	}
+	if c.flagTLSServerName != "" {
+		cfg.TLSConfig.Address = c.flagTLSServerName
+	}
+
+	var err error
+	c.consul, err = api.NewClient(cfg)
+	if err != nil {
+		return fmt.Errorf("error initializing Consul client: %v", err)
+	}
+	return nil
+}
+
+// createPartition creates the admin partition if it does not exist yet.
+func (c *ProvisionCommand) createPartition() error {
+	partition, _, err := c.consul.Partitions().Read(c.Ctx, c.flagName, nil)
+	if err != nil {
+		return fmt.Errorf("error reading admin partition %s: %v", c.flagName, err)
+	}
+	if partition != nil {
+		c.UI.Output("Admin partition %s already exists.", c.flagName, terminal.WithSuccessStyle())
+		return nil
+	}
+
+	_, _, err = c.consul.Partitions().Create(c.Ctx, &api.Partition{
+		Name:        c.flagName,
+		Description: c.flagDescription,
+	}, nil)
+	if err != nil {
+		return fmt.Errorf("error creating admin partition %s: %v", c.flagName, err)
+	}
+	c.UI.Output("Created admin partition %s.", c.flagName, terminal.WithSuccessStyle())
+	return nil
+}
+
+// createToken creates an ACL token for the Helm release of the partition. The policy of the token is
+// reused if it already exists so that a partition can be provisioned again, e.g. to rotate its token.
+func (c *ProvisionCommand) createToken() (*api.ACLToken, error) {
+	policyName := fmt.Sprintf("%s-partition-provisioner", c.flagName)
+	policy, _, err := c.consul.ACL().PolicyReadByName(policyName, nil)
+	if err != nil {
+		return nil, fmt.Errorf("error reading ACL policy %s: %v", policyName, err)
+	}
+	if policy == nil {
+		policy, _, err = c.consul.ACL().PolicyCreate(&api.ACLPolicy{
+			Name:        policyName,
+			Description: fmt.Sprintf("Policy to set up Consul on Kubernetes in admin partition %s", c.flagName),
+			Rules:       fmt.Sprintf(partitionPolicyRules, c.flagName),
+		}, nil)
+		if err != nil {
+			return nil, fmt.Errorf("error creating ACL policy %s: %v", policyName, err)
+		}
+		c.UI.Output("Created ACL policy %s.", policyName, terminal.WithSuccessStyle())
+	}
+
+	token, _, err := c.consul.ACL().TokenCreate(&api.ACLToken{
+		Description: fmt.Sprintf("Token to set up Consul on Kubernetes in admin partition %s", c.flagName),
+		Policies:    []*api.ACLTokenPolicyLink{{ID: policy.ID}},
+	}, nil)
+	if err != nil {
+		return nil, fmt.Errorf("error creating ACL token: %v", err)
+	}
+	c.UI.Output("Created ACL token with accessor ID %s.", token.AccessorID, terminal.WithSuccessStyle())
+	return token, nil
+}
+
+// install stores the partition's token and the Consul CA certificate in the Kubernetes cluster and installs
+// Consul as a member of the partition.
+func (c *ProvisionCommand) install(token *api.ACLToken) error {
+	// helmCLI.New() will create a settings object which is used by the Helm Go SDK calls.
+	settings := helmCLI.New()
+	if c.flagKubeConfig != "" {
+		settings.KubeConfig = c.flagKubeConfig
+	}
+	if c.flagKubeContext != "" {
+		settings.KubeContext = c.flagKubeContext
+	}
+
+	k8sAuthMethodHost := c.flagK8sAuthMethodHost
+	if c.kubernetes == nil || k8sAuthMethodHost == "" {
+		restConfig, err := settings.RESTClientGetter().ToRESTConfig()
+		if err != nil {
+			return fmt.Errorf("error retrieving Kubernetes authentication: %v", err)
+		}
+		if k8sAuthMethodHost == "" {
+			k8sAuthMethodHost = restConfig.Host
+		}
+		if c.kubernetes == nil {
+			c.kubernetes, err = kubernetes.NewForConfig(restConfig)
+			if err != nil {
+				return fmt.Errorf("error initializing Kubernetes client: %v", err)
+			}
+		}
+	}
+
+	uiLogger := func(s string, args ...interface{}) {
+		logMsg := fmt.Sprintf(s, args...)
+		if !strings.Contains(logMsg, "not ready") {
+			c.UI.Output(logMsg, terminal.WithLibraryStyle())
+		}
+	}
+
+	c.UI.Output("Checking if Consul can be installed", terminal.WithHeaderStyle())
+	if found, name, ns, _ := c.helmActionsRunner.CheckForInstallations(&helm.CheckForInstallationsOptions{
+		Settings:    settings,
+		ReleaseName: common.DefaultReleaseName,
+		DebugLog:    uiLogger,
+	}); found {
+		return fmt.Errorf("cannot install Consul. A Consul cluster is already installed in namespace %s with name %s", ns, name)
+	}
+	c.UI.Output("No existing Consul installations found.", terminal.WithSuccessStyle())
+
+	if err := c.createSecrets(token); err != nil {
+		return err
+	}
+
+	vals, err := c.mergeValues(settings, k8sAuthMethodHost)
+	if err != nil {
+		return err
+	}
+	valuesYaml, err := yaml.Marshal(vals)
+	if err != nil {
+		return err
+	}
+
+	c.UI.Output("Consul Installation Summary", terminal.WithHeaderStyle())
+	c.UI.Output("Name: %s", common.DefaultReleaseName, terminal.WithInfoStyle())
+	c.UI.Output("Namespace: %s", c.flagNamespace, terminal.WithInfoStyle())
+	c.UI.Output("\nHelm values\n-----------\n"+string(valuesYaml), terminal.WithInfoStyle())
+
+	timeout, err := time.ParseDuration(c.flagTimeout)
+	if err != nil {
+		return err
+	}
+	_, err = helm.InstallHelmRelease(&helm.InstallOptions{
+		ReleaseName:       common.DefaultReleaseName,
+		ReleaseType:       common.ReleaseTypeConsul,
+		Namespace:         c.flagNamespace,
+		Values:            vals,
+		Settings:          settings,
+		EmbeddedChart:     consulChart.ConsulHelmChart,
+		ChartDirName:      common.TopLevelChartDirName,
+		UILogger:          uiLogger,
+		AutoApprove:       c.flagAutoApprove,
+		Wait:              c.flagWait,
+		Timeout:           timeout,
+		UI:                c.UI,
+		HelmActionsRunner: c.helmActionsRunner,
+	})
+	return err
+}
+
+// createSecrets creates the namespace of the installation and the secrets with the partition's token
+// and the Consul CA certificate.
+func (c *ProvisionCommand) createSecrets(token *api.ACLToken) error {
+	_, err := c.kubernetes.CoreV1().Namespaces().Get(c.Ctx, c.flagNamespace, metav1.GetOptions{})
+	if k8serrors.IsNotFound(err) {
+		_, err = c.kubernetes.CoreV1().Namespaces().Create(c.Ctx, &corev1.Namespace{
+			ObjectMeta: metav1.ObjectMeta{Name: c.flagNamespace},
+		}, metav1.CreateOptions{})
+	}
+	if err != nil {
+		return fmt.Errorf("error creating namespace %s: %v", c.flagNamespace, err)
+	}
+
+	if err := c.createSecret(tokenSecretName, tokenSecretKey, []byte(token.SecretID)); err != nil {
+		return err
+	}
+	if c.flagCAFile != "" {
+		caCert, err := os.ReadFile(c.flagCAFile)
+		if err != nil {
+			return fmt.Errorf("error reading CA certificate: %v", err)
+		}
+		if err := c.createSecret(caCertSecretName, caCertSecretKey, caCert); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// createSecret creates or updates a secret in the namespace of the installation.
+func (c *ProvisionCommand) createSecret(name, key string, value []byte) error {
+	secret := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      name,
+			Namespace: c.flagNamespace,
+			Labels:    map[string]string{common.CLILabelKey: common.CLILabelValue},
+		},
+		Data: map[string][]byte{key: value},
+		Type: corev1.SecretTypeOpaque,
+	}
+	secrets := c.kubernetes.CoreV1().Secrets(c.flagNamespace)
+	_, err := secrets.Create(c.Ctx, secret, metav1.CreateOptions{})
+	if k8serrors.IsAlreadyExists(err) {
+		_, err = secrets.Update(c.Ctx, secret, metav1.UpdateOptions{})
+	}
+	if err != nil {
+		return fmt.Errorf("error creating secret %s: %v", name, err)
+	}
+	c.UI.Output("Stored secret %s in namespace %s.", name, c.flagNamespace, terminal.WithSuccessStyle())
+	return nil
+}
+
+// mergeValues merges the values of the partition with the values files and set values,
+// giving the latter precedence.
+func (c *ProvisionCommand) mergeValues(settings *helmCLI.EnvSettings, k8sAuthMethodHost string) (map[string]interface{}, error) {
+	options := values.Options{
+		ValueFiles: c.flagValueFiles,
+		Values:     c.flagSetValues,
+	}
+	overrides, err := options.MergeValues(getter.All(settings))
+	if err != nil {
+		return nil, err
+	}
+	return common.MergeMaps(c.partitionValues(k8sAuthMethodHost), overrides), nil
+}
+
+// partitionValues returns the Helm values that install Consul as a member of the partition without servers,
+// connecting to the external Consul servers.
+func (c *ProvisionCommand) partitionValues(k8sAuthMethodHost string) map[string]interface{} {
+	global := map[string]interface{}{
+		"name": common.DefaultReleaseName,
+		"adminPartitions": map[string]interface{}{
+			"enabled": true,
+			"name":    c.flagName,
+		},
+		"acls": map[string]interface{}{
+			"manageSystemACLs": true,
+			"bootstrapToken": map[string]interface{}{
+				"secretName": tokenSecretName,
+				"secretKey":  tokenSecretKey,
+			},
+		},
+	}
+	if c.flagCAFile != "" {
+		global["tls"] = map[string]interface{}{
+			"enabled": true,
+			"caCert": map[string]interface{}{
+				"secretName": caCertSecretName,
+				"secretKey":  caCertSecretKey,
+			},
+		}
+	}
+
+	externalServers := map[string]interface{}{
+		"enabled": true,
+		"hosts":   c.flagServerHosts,
+	}
+	if c.flagTLSServerName != "" {
+		externalServers["tlsServerName"] = c.flagTLSServerName
+	}
+	if k8sAuthMethodHost != "" {
+		externalServers["k8sAuthMethodHost"] = k8sAuthMethodHost
+	}
+
+	return map[string]interface{}{
+		"global":          global,
+		"externalServers": externalServers,
+		"server": map[string]interface{}{
+			"enabled": false,
+		},
+		"connectInject": map[string]interface{}{
+			"enabled": true,
+		},
+	}
+}
+
+// AutocompleteFlags returns a mapping of supported flags and autocomplete
+// options for this command. The map key for the Flags map should be the
+// complete flag such as "-foo" or "--foo".
+func (c *ProvisionCommand) AutocompleteFlags() complete.Flags {
+	return complete.Flags{
+		fmt.Sprintf("-%s", flagNameName):              complete.PredictNothing,
+		fmt.Sprintf("-%s", flagNameDescription):       complete.PredictNothing,
+		fmt.Sprintf("-%s", flagNameConsulAddress):     complete.PredictNothing,
+		fmt.Sprintf("-%s", flagNameConsulToken):       complete.PredictNothing,
+		fmt.Sprintf("-%s", flagNameCAFile):            complete.PredictFiles("*"),
+		fmt.Sprintf("-%s", flagNameTLSServerName):     complete.PredictNothing,
+		fmt.Sprintf("-%s", flagNameInstall):           complete.PredictNothing,
+		fmt.Sprintf("-%s", flagNameServerHosts):       complete.PredictNothing,
+		fmt.Sprintf("-%s", flagNameK8sAuthMethodHost): complete.PredictNothing,
+		fmt.Sprintf("-%s", flagNameNamespace):         complete.PredictNothing,
+		fmt.Sprintf("-%s", flagNameConfigFile):        complete.PredictFiles("*"),
+		fmt.Sprintf("-%s", flagNameSetValues):         complete.PredictNothing,
+		fmt.Sprintf("-%s", flagNameAutoApprove):       complete.PredictNothing,
+		fmt.Sprintf("-%s", flagNameTimeout):           complete.PredictNothing,
+		fmt.Sprintf("-%s", flagNameWait):              complete.PredictNothing,
+		fmt.Sprintf("-%s", flagNameKubeConfig):        complete.PredictFiles("*"),
+		fmt.Sprintf("-%s", flagNameKubeContext):       complete.PredictNothing,
+	}
+}
+
+// AutocompleteArgs returns the argument predictor for this command.
+// Since argument completion is not supported, this will return
+// complete.PredictNothing.
+func (c *ProvisionCommand) AutocompleteArgs() complete.Predictor {
+	return complete.PredictNothing
+}
+
+// Help returns a description of the command and how it is used.
+func (c *ProvisionCommand) Help() string {
+	c.once.Do(c.init)
+	return c.Synopsis() + "\n\nUsage: consul-k8s admin-partition provision [flags]\n\n" + c.help
+}
+
+// Synopsis returns a one-line command summary.
+func (c *ProvisionCommand) Synopsis() string {
+	return "Create an admin partition in Consul and optionally install Consul into a Kubernetes cluster as its member."
+}
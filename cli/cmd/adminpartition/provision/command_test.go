@@ -0,0 +1,266 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package provision
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"sync"
+	"testing"
+
+	"github.com/hashicorp/consul/api"
+	"github.com/hashicorp/go-hclog"
+	"github.com/stretchr/testify/require"
+	"helm.sh/helm/v3/pkg/action"
+	"helm.sh/helm/v3/pkg/chart"
+	helmRelease "helm.sh/helm/v3/pkg/release"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+
+	"github.com/hashicorp/consul-k8s/cli/common"
+	"github.com/hashicorp/consul-k8s/cli/common/terminal"
+	"github.com/hashicorp/consul-k8s/cli/helm"
+)
+
+func TestValidateFlags(t *testing.T) {
+	cases := map[string]struct {
+		input    []string
+		expError string
+	}{
+		"no name": {
+			input:    []string{},
+			expError: "-name must be set",
+		},
+		"default partition": {
+			input:    []string{"-name", "default"},
+			expError: "-name cannot be the default partition",
+		},
+		"invalid name": {
+			input:    []string{"-name", "Team_A"},
+			expError: `-name "Team_A" is not a valid partition name`,
+		},
+		"install without server hosts": {
+			input:    []string{"-name", "team-a", "-install"},
+			expError: "-server-hosts must be set with -install",
+		},
+		"invalid timeout": {
+			input:    []string{"-name", "team-a", "-timeout", "foo"},
+			expError: "unable to parse -timeout",
+		},
+		"non-flag argument": {
+			input:    []string{"-name", "team-a", "foo"},
+			expError: "should have no non-flag arguments",
+		},
+		"valid": {
+			input: []string{"-name", "team-a", "-install", "-server-hosts", "consul.example.com"},
+		},
+	}
+
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			c := getInitializedCommand(t, nil)
+			require.NoError(t, c.set.Parse(tc.input))
+			err := c.validateFlags()
+			if tc.expError != "" {
+				require.ErrorContains(t, err, tc.expError)
+				return
+			}
+			require.NoError(t, err)
+		})
+	}
+}
+
+func TestProvision(t *testing.T) {
+	cases := map[string]struct {
+		input                []string
+		partitionExists      bool
+		expPartitionsCreated int
+		expInstalled         bool
+		messages             []string
+	}{
+		"provisions partition without install": {
+			input:                []string{"-name", "team-a"},
+			expPartitionsCreated: 1,
+			messages: []string{
+				"Created admin partition team-a.",
+				"Created ACL token with accessor ID accessor-id.",
+				"kubectl create secret generic consul-partition-acl-token --namespace consul --from-literal=token=secret-id",
+				"name: team-a",
+			},
+		},
+		"reuses existing partition": {
+			input:           []string{"-name", "team-a"},
+			partitionExists: true,
+			messages: []string{
+				"Admin partition team-a already exists.",
+				"Created ACL token with accessor ID accessor-id.",
+			},
+		},
+		"provisions partition and installs": {
+			input: []string{
+				"-name", "team-a", "-install", "-auto-approve",
+				"-server-hosts", "consul.example.com", "-k8s-auth-method-host", "https://k8s.example.com",
+			},
+			expPartitionsCreated: 1,
+			expInstalled:         true,
+			messages: []string{
+				"Created admin partition team-a.",
+				"Stored secret consul-partition-acl-token in namespace consul.",
+				"Consul installed in namespace \"consul\".",
+			},
+		},
+	}
+
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			server := newFakeConsul(t, tc.partitionExists)
+
+			buf := new(bytes.Buffer)
+			c := getInitializedCommand(t, buf)
+			c.kubernetes = fake.NewSimpleClientset()
+			var installedValues map[string]interface{}
+			runner := &helm.MockActionRunner{
+				InstallFunc: func(install *action.Install, chrt *chart.Chart, vals map[string]interface{}) (*helmRelease.Release, error) {
+					installedValues = vals
+					return &helmRelease.Release{}, nil
+				},
+			}
+			c.helmActionsRunner = runner
+			consul, err := api.NewClient(&api.Config{Address: server.URL})
+			require.NoError(t, err)
+			c.consul = consul
+
+			returnCode := c.Run(tc.input)
+			require.Equal(t, 0, returnCode, buf.String())
+
+			output := buf.String()
+			for _, msg := range tc.messages {
+				require.Contains(t, output, msg)
+			}
+			require.Equal(t, tc.expPartitionsCreated, server.partitionsCreated)
+			require.Equal(t, tc.expInstalled, runner.ConsulInstalled)
+
+			if tc.expInstalled {
+				secret, err := c.kubernetes.CoreV1().Secrets("consul").Get(context.Background(), tokenSecretName, metav1.GetOptions{})
+				require.NoError(t, err)
+				require.Equal(t, "secret-id", string(secret.Data[tokenSecretKey]))
+				require.Equal(t, common.CLILabelValue, secret.Labels[common.CLILabelKey])
+
+				global := installedValues["global"].(map[string]interface{})
+				require.Equal(t, map[string]interface{}{"enabled": true, "name": "team-a"}, global["adminPartitions"])
+				externalServers := installedValues["externalServers"].(map[string]interface{})
+				require.Equal(t, []string{"consul.example.com"}, externalServers["hosts"])
+				require.Equal(t, "https://k8s.example.com", externalServers["k8sAuthMethodHost"])
+			}
+		})
+	}
+}
+
+func TestPartitionValues_TLS(t *testing.T) {
+	c := getInitializedCommand(t, nil)
+	require.NoError(t, c.set.Parse([]string{
+		"-name", "team-a", "-ca-file", "ca.pem", "-tls-server-name", "server.dc1.consul",
+		"-server-hosts", "consul.example.com",
+	}))
+
+	vals := c.partitionValues("")
+	global := vals["global"].(map[string]interface{})
+	require.Equal(t, map[string]interface{}{
+		"enabled": true,
+		"caCert": map[string]interface{}{
+			"secretName": caCertSecretName,
+			"secretKey":  caCertSecretKey,
+		},
+	}, global["tls"])
+	externalServers := vals["externalServers"].(map[string]interface{})
+	require.Equal(t, "server.dc1.consul", externalServers["tlsServerName"])
+	require.NotContains(t, externalServers, "k8sAuthMethodHost")
+}
+
+// fakeConsul is a Consul HTTP API that serves the endpoints used to provision a partition.
+type fakeConsul struct {
+	*httptest.Server
+
+	mu                sync.Mutex
+	partitionsCreated int
+}
+
+func newFakeConsul(t *testing.T, partitionExists bool) *fakeConsul {
+	t.Helper()
+	consul := &fakeConsul{}
+	mux := http.NewServeMux()
+	mux.HandleFunc("/v1/partition/team-a", func(w http.ResponseWriter, r *http.Request) {
+		if !partitionExists {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		writeJSON(t, w, api.Partition{Name: "team-a"})
+	})
+	mux.HandleFunc("/v1/partition", func(w http.ResponseWriter, r *http.Request) {
+		consul.mu.Lock()
+		consul.partitionsCreated++
+		consul.mu.Unlock()
+		var partition api.Partition
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&partition))
+		writeJSON(t, w, partition)
+	})
+	mux.HandleFunc("/v1/acl/policy/name/team-a-partition-provisioner", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNotFound)
+	})
+	mux.HandleFunc("/v1/acl/policy", func(w http.ResponseWriter, r *http.Request) {
+		var policy api.ACLPolicy
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&policy))
+		require.Contains(t, policy.Rules, `partition "team-a"`)
+		policy.ID = "policy-id"
+		writeJSON(t, w, policy)
+	})
+	mux.HandleFunc("/v1/acl/token", func(w http.ResponseWriter, r *http.Request) {
+		var token api.ACLToken
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&token))
+		require.Equal(t, "policy-id", token.Policies[0].ID)
+		token.AccessorID = "accessor-id"
+		token.SecretID = "secret-id"
+		writeJSON(t, w, token)
+	})
+	consul.Server = httptest.NewServer(mux)
+	t.Cleanup(consul.Close)
+	return consul
+}
+
+func writeJSON(t *testing.T, w http.ResponseWriter, v interface{}) {
+	t.Helper()
+	w.Header().Set("Content-Type", "application/json")
+	require.NoError(t, json.NewEncoder(w).Encode(v))
+}
+
+func getInitializedCommand(t *testing.T, buf io.Writer) *ProvisionCommand {
+	t.Helper()
+	log := hclog.New(&hclog.LoggerOptions{
+		Name:   "cli",
+		Level:  hclog.Info,
+		Output: os.Stdout,
+	})
+	var ui terminal.UI
+	if buf != nil {
+		ui = terminal.NewUI(context.Background(), buf)
+	} else {
+		ui = terminal.NewBasicUI(context.Background())
+	}
+	baseCommand := &common.BaseCommand{
+		Ctx: context.Background(),
+		Log: log,
+		UI:  ui,
+	}
+
+	c := &ProvisionCommand{
+		BaseCommand: baseCommand,
+	}
+	c.init()
+	return c
+}
@@ -0,0 +1,29 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package cert
+
+import (
+	"fmt"
+
+	"github.com/hashicorp/consul-k8s/cli/common"
+	"github.com/mitchellh/cli"
+)
+
+// CertCommand provides a synopsis for the cert subcommands (e.g. rotate).
+type CertCommand struct {
+	*common.BaseCommand
+}
+
+// Run prints out information about the subcommands.
+func (c *CertCommand) Run([]string) int {
+	return cli.RunResultHelp
+}
+
+func (c *CertCommand) Help() string {
+	return fmt.Sprintf("%s\n\nUsage: consul-k8s cert <subcommand>", c.Synopsis())
+}
+
+func (c *CertCommand) Synopsis() string {
+	return "Manage the TLS certificates of a Consul installation on Kubernetes."
+}
@@ -0,0 +1,122 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package rotate
+
+import (
+	"bytes"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"math/big"
+	"time"
+)
+
+// reissueCert signs a new certificate and private key with the CA for the same names
+// as the PEM encoded certificate current. The certificate is valid for validity.
+func reissueCert(current, caCertPEM, caKeyPEM []byte, validity time.Duration) ([]byte, []byte, error) {
+	old, err := parseCert(current)
+	if err != nil {
+		return nil, nil, fmt.Errorf("error parsing the current certificate: %w", err)
+	}
+	caCert, err := parseCert(caCertPEM)
+	if err != nil {
+		return nil, nil, fmt.Errorf("error parsing the CA certificate: %w", err)
+	}
+	caSigner, err := parseSigner(caKeyPEM)
+	if err != nil {
+		return nil, nil, fmt.Errorf("error parsing the CA private key: %w", err)
+	}
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		return nil, nil, err
+	}
+	keyBytes, err := x509.MarshalECPrivateKey(key)
+	if err != nil {
+		return nil, nil, err
+	}
+	sn, err := rand.Int(rand.Reader, new(big.Int).Lsh(big.NewInt(1), 128))
+	if err != nil {
+		return nil, nil, err
+	}
+
+	template := x509.Certificate{
+		SerialNumber:          sn,
+		Subject:               pkix.Name{CommonName: old.Subject.CommonName},
+		DNSNames:              old.DNSNames,
+		IPAddresses:           old.IPAddresses,
+		BasicConstraintsValid: true,
+		KeyUsage:              x509.KeyUsageDigitalSignature | x509.KeyUsageKeyEncipherment,
+		ExtKeyUsage:           []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth, x509.ExtKeyUsageClientAuth},
+		NotBefore:             time.Now().Add(-1 * time.Minute),
+		NotAfter:              time.Now().Add(validity),
+	}
+	der, err := x509.CreateCertificate(rand.Reader, &template, caCert, key.Public(), caSigner)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var certBuf, keyBuf bytes.Buffer
+	if err := pem.Encode(&certBuf, &pem.Block{Type: "CERTIFICATE", Bytes: der}); err != nil {
+		return nil, nil, err
+	}
+	if err := pem.Encode(&keyBuf, &pem.Block{Type: "EC PRIVATE KEY", Bytes: keyBytes}); err != nil {
+		return nil, nil, err
+	}
+	return certBuf.Bytes(), keyBuf.Bytes(), nil
+}
+
+// verifiesCert returns true if the PEM encoded CA bundle verifies the PEM encoded certificate.
+func verifiesCert(caBundle, certPEM []byte) bool {
+	cert, err := parseCert(certPEM)
+	if err != nil {
+		return false
+	}
+	pool := x509.NewCertPool()
+	if !pool.AppendCertsFromPEM(caBundle) {
+		return false
+	}
+	_, err = cert.Verify(x509.VerifyOptions{Roots: pool, KeyUsages: []x509.ExtKeyUsage{x509.ExtKeyUsageAny}})
+	return err == nil
+}
+
+func parseCert(pemValue []byte) (*x509.Certificate, error) {
+	block, _ := pem.Decode(pemValue)
+	if block == nil || block.Type != "CERTIFICATE" {
+		return nil, errors.New("no PEM-encoded certificate found")
+	}
+	return x509.ParseCertificate(block.Bytes)
+}
+
+func parseSigner(pemValue []byte) (crypto.Signer, error) {
+	block, _ := pem.Decode(pemValue)
+	if block == nil {
+		return nil, errors.New("no PEM-encoded data found")
+	}
+
+	switch block.Type {
+	case "EC PRIVATE KEY":
+		return x509.ParseECPrivateKey(block.Bytes)
+	case "RSA PRIVATE KEY":
+		return x509.ParsePKCS1PrivateKey(block.Bytes)
+	case "PRIVATE KEY":
+		key, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+		if err != nil {
+			return nil, err
+		}
+		signer, ok := key.(crypto.Signer)
+		if !ok {
+			return nil, errors.New("private key is not a valid format")
+		}
+		return signer, nil
+	default:
+		return nil, fmt.Errorf("unknown PEM block type for signing key: %s", block.Type)
+	}
+}
@@ -0,0 +1,665 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package rotate
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/posener/complete"
+	helmCLI "helm.sh/helm/v3/pkg/cli"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/client-go/kubernetes"
+
+	"github.com/hashicorp/consul-k8s/cli/common"
+	"github.com/hashicorp/consul-k8s/cli/common/flag"
+	"github.com/hashicorp/consul-k8s/cli/common/terminal"
+	"github.com/hashicorp/consul-k8s/cli/helm"
+)
+
+const (
+	componentAll     = "all"
+	componentServer  = "server"
+	componentWebhook = "webhook"
+
+	flagNameComponent   = "component"
+	flagNameDays        = "days"
+	flagNameTimeout     = "timeout"
+	flagNameAutoApprove = "auto-approve"
+	flagNameKubeConfig  = "kubeconfig"
+	flagNameKubeContext = "context"
+
+	// defaultDays is the validity of the server certificates issued by the tls-init job of the chart.
+	defaultDays    = 730
+	defaultTimeout = 10 * time.Minute
+
+	defaultPollInterval = 2 * time.Second
+
+	// serverCertVolume and caCertVolume are the volumes of the server StatefulSet that
+	// mount the server certificate and the CA certificate.
+	serverCertVolume = "consul-server-cert"
+	caCertVolume     = "consul-ca-cert"
+
+	// webhookCertVolume is the volume of the connect injector Deployment that mounts
+	// the serving certificate of its webhooks.
+	webhookCertVolume = "certs"
+
+	// certManagerAnnotation is set by cert-manager on the secrets of the certificates it issues.
+	certManagerAnnotation = "cert-manager.io/certificate-name"
+
+	// restartedAtAnnotation is the pod template annotation set by `kubectl rollout restart`.
+	restartedAtAnnotation = "kubectl.kubernetes.io/restartedAt"
+)
+
+type RotateCommand struct {
+	*common.BaseCommand
+
+	helmActionsRunner helm.HelmActionsRunner
+
+	kubernetes kubernetes.Interface
+
+	set *flag.Sets
+
+	// Command Flags
+	flagComponent   string
+	flagDays        int
+	flagTimeout     time.Duration
+	flagAutoApprove bool
+
+	// Global Flags
+	flagKubeConfig  string
+	flagKubeContext string
+
+	// pollInterval is how often the progress of the rotation is checked. It is set in tests.
+	pollInterval time.Duration
+
+	once sync.Once
+	help string
+}
+
+func (c *RotateCommand) init() {
+	c.set = flag.NewSets()
+	f := c.set.NewSet("Command Options")
+	f.EnumSingleVar(&flag.EnumSingleVar{
+		Name:    flagNameComponent,
+		Target:  &c.flagComponent,
+		Values:  []string{componentAll, componentServer, componentWebhook},
+		Default: componentAll,
+		Usage:   "The certificates to rotate: the TLS certificate of the Consul servers, the serving certificate of the connect injector webhooks, or all of them.",
+	})
+	f.IntVar(&flag.IntVar{
+		Name:    flagNameDays,
+		Target:  &c.flagDays,
+		Default: defaultDays,
+		Usage:   "The number of days the new server certificate is valid for.",
+	})
+	f.DurationVar(&flag.DurationVar{
+		Name:    flagNameTimeout,
+		Target:  &c.flagTimeout,
+		Default: defaultTimeout,
+		Usage:   "How long to wait for each new certificate to be issued and each restarted component to become ready.",
+	})
+	f.BoolVar(&flag.BoolVar{
+		Name:    flagNameAutoApprove,
+		Target:  &c.flagAutoApprove,
+		Default: false,
+		Usage:   "Skip confirmation prompt.",
+	})
+
+	f = c.set.NewSet("Global Options")
+	f.StringVar(&flag.StringVar{
+		Name:    flagNameKubeConfig,
+		Aliases: []string{"c"},
+		Target:  &c.flagKubeConfig,
+		Usage:   "Set the path to kubeconfig file.",
+	})
+	f.StringVar(&flag.StringVar{
+		Name:   flagNameKubeContext,
+		Target: &c.flagKubeContext,
+		Usage:  "Set the Kubernetes context to use.",
+	})
+
+	c.help = c.set.Help()
+}
+
+// Run rotates the TLS certificates of the Consul servers and the connect injector
+// webhooks and restarts the pods that use them.
+func (c *RotateCommand) Run(args []string) int {
+	c.once.Do(c.init)
+	if c.helmActionsRunner == nil {
+		c.helmActionsRunner = &helm.ActionRunner{}
+	}
+	if c.pollInterval == 0 {
+		c.pollInterval = defaultPollInterval
+	}
+	c.Log.ResetNamed("cert rotate")
+	defer common.CloseWithError(c.BaseCommand)
+
+	if err := c.set.Parse(args); err != nil {
+		c.UI.Output(err.Error(), terminal.WithErrorStyle())
+		c.UI.Output("\n" + c.Help())
+		return 1
+	}
+
+	if err := c.validateFlags(); err != nil {
+		c.UI.Output(err.Error(), terminal.WithErrorStyle())
+		c.UI.Output("\n" + c.Help())
+		return 1
+	}
+
+	settings := helmCLI.New()
+	if c.flagKubeConfig != "" {
+		settings.KubeConfig = c.flagKubeConfig
+	}
+	if c.flagKubeContext != "" {
+		settings.KubeContext = c.flagKubeContext
+	}
+
+	if err := c.initKubernetes(settings); err != nil {
+		c.UI.Output(err.Error(), terminal.WithErrorStyle())
+		return 1
+	}
+
+	// Setup logger to stream Helm library logs.
+	var uiLogger = func(s string, args ...interface{}) {
+		logMsg := fmt.Sprintf(s, args...)
+		c.UI.Output(logMsg, terminal.WithLibraryStyle())
+	}
+
+	_, releaseName, namespace, err := c.helmActionsRunner.CheckForInstallations(&helm.CheckForInstallationsOptions{
+		Settings:    settings,
+		ReleaseName: common.DefaultReleaseName,
+		DebugLog:    uiLogger,
+	})
+	if err != nil {
+		c.UI.Output(err.Error(), terminal.WithErrorStyle())
+		return 1
+	}
+
+	rotateServer := c.flagComponent == componentAll || c.flagComponent == componentServer
+	rotateWebhook := c.flagComponent == componentAll || c.flagComponent == componentWebhook
+
+	if !c.flagAutoApprove {
+		var certs []string
+		if rotateServer {
+			certs = append(certs, "the TLS certificate of the Consul servers, then restart the server pods one at a time")
+		}
+		if rotateWebhook {
+			certs = append(certs, "the serving certificate of the connect injector webhooks, then restart the connect injector")
+		}
+		confirmation, err := c.UI.Input(&terminal.Input{
+			Prompt: fmt.Sprintf("Rotate the certificates of the following installation? \n\n   Name: %s \n   Namespace: %s \n\n   - Rotate %s \n\n(y/N)",
+				releaseName, namespace, strings.Join(certs, "\n   - Rotate ")),
+			Style:  terminal.InfoStyle,
+			Secret: false,
+		})
+		if err != nil {
+			c.UI.Output(err.Error(), terminal.WithErrorStyle())
+			return 1
+		}
+		if common.Abort(confirmation) {
+			c.UI.Output("Certificate rotation aborted.", terminal.WithInfoStyle())
+			return 0
+		}
+	}
+
+	// The servers are rotated first so that the connect injector, which talks to
+	// them, is restarted last.
+	if rotateServer {
+		if err := c.rotateServerCert(c.Ctx, releaseName, namespace); err != nil {
+			c.UI.Output(err.Error(), terminal.WithErrorStyle())
+			return 1
+		}
+	}
+	if rotateWebhook {
+		if err := c.rotateWebhookCert(c.Ctx, releaseName, namespace); err != nil {
+			c.UI.Output(err.Error(), terminal.WithErrorStyle())
+			return 1
+		}
+	}
+	return 0
+}
+
+func (c *RotateCommand) Help() string {
+	c.once.Do(c.init)
+	return fmt.Sprintf("%s\n\nUsage: consul-k8s cert rotate [flags]\n\n"+
+		"The TLS certificate of the Consul servers is reissued with the CA created by the chart and the\n"+
+		"server pods are restarted one at a time, waiting for each to become ready. The serving certificate\n"+
+		"of the connect injector webhooks is then reissued by the webhook-cert-manager or cert-manager, its\n"+
+		"CA is set as the CA bundle of the webhook configurations and the connect injector is restarted.\n\n%s",
+		c.Synopsis(), c.help)
+}
+
+func (c *RotateCommand) Synopsis() string {
+	return "Rotate the TLS certificates of the Consul servers and the connect injector webhooks."
+}
+
+// AutocompleteFlags returns a mapping of supported flags and autocomplete
+// options for this command. The map key for the Flags map should be the
+// complete flag such as "-foo" or "--foo".
+func (c *RotateCommand) AutocompleteFlags() complete.Flags {
+	return complete.Flags{
+		fmt.Sprintf("-%s", flagNameComponent):   complete.PredictSet(componentAll, componentServer, componentWebhook),
+		fmt.Sprintf("-%s", flagNameDays):        complete.PredictNothing,
+		fmt.Sprintf("-%s", flagNameTimeout):     complete.PredictNothing,
+		fmt.Sprintf("-%s", flagNameAutoApprove): complete.PredictNothing,
+		fmt.Sprintf("-%s", flagNameKubeConfig):  complete.PredictFiles("*"),
+		fmt.Sprintf("-%s", flagNameKubeContext): complete.PredictNothing,
+	}
+}
+
+// AutocompleteArgs returns the argument predictor for this command.
+// Since argument completion is not supported, this will return
+// complete.PredictNothing.
+func (c *RotateCommand) AutocompleteArgs() complete.Predictor {
+	return complete.PredictNothing
+}
+
+func (c *RotateCommand) validateFlags() error {
+	if len(c.set.Args()) > 0 {
+		return errors.New("should have no non-flag arguments")
+	}
+	if c.flagDays <= 0 {
+		return fmt.Errorf("-%s must be positive.", flagNameDays)
+	}
+	if c.flagTimeout <= 0 {
+		return fmt.Errorf("-%s must be positive.", flagNameTimeout)
+	}
+	return nil
+}
+
+func (c *RotateCommand) initKubernetes(settings *helmCLI.EnvSettings) error {
+	if c.kubernetes != nil {
+		return nil
+	}
+	restConfig, err := settings.RESTClientGetter().ToRESTConfig()
+	if err != nil {
+		return fmt.Errorf("error creating Kubernetes REST config %v", err)
+	}
+	if c.kubernetes, err = kubernetes.NewForConfig(restConfig); err != nil {
+		return fmt.Errorf("error creating Kubernetes client %v", err)
+	}
+	return nil
+}
+
+// rotateServerCert reissues the server certificate created by the tls-init job of the
+// chart and restarts the server pods one at a time so that the servers keep a quorum.
+func (c *RotateCommand) rotateServerCert(ctx context.Context, releaseName, namespace string) error {
+	c.UI.Output("Consul Servers", terminal.WithHeaderStyle())
+
+	servers, err := c.kubernetes.AppsV1().StatefulSets(namespace).List(ctx, metav1.ListOptions{LabelSelector: releaseSelector(componentServer, releaseName)})
+	if err != nil {
+		return fmt.Errorf("error listing the Consul server StatefulSets: %w", err)
+	}
+	if len(servers.Items) == 0 {
+		c.UI.Output("Consul servers are not running in this Kubernetes cluster, skipping.", terminal.WithInfoStyle())
+		return nil
+	}
+	sts := servers.Items[0]
+
+	certSecretName := secretVolume(sts.Spec.Template.Spec.Volumes, serverCertVolume)
+	caSecretName := secretVolume(sts.Spec.Template.Spec.Volumes, caCertVolume)
+	if certSecretName == "" || caSecretName == "" {
+		c.UI.Output("The Consul servers don't read their TLS certificate from a Kubernetes secret, skipping.", terminal.WithInfoStyle())
+		return nil
+	}
+
+	certSecret, err := c.kubernetes.CoreV1().Secrets(namespace).Get(ctx, certSecretName, metav1.GetOptions{})
+	if err != nil {
+		return fmt.Errorf("error reading the server certificate secret %s: %w", certSecretName, err)
+	}
+	if certSecret.Labels[common.CLILabelKey] != common.CLILabelValue {
+		c.UI.Output(fmt.Sprintf("The server certificate in secret %s isn't issued by the chart, skipping. Update the secret and restart the server pods to rotate it.", certSecretName),
+			terminal.WithWarningStyle())
+		return nil
+	}
+
+	caCert, err := c.kubernetes.CoreV1().Secrets(namespace).Get(ctx, caSecretName, metav1.GetOptions{})
+	if err != nil {
+		return fmt.Errorf("error reading the CA certificate secret %s: %w", caSecretName, err)
+	}
+	caKeySecretName := strings.TrimSuffix(caSecretName, "-ca-cert") + "-ca-key"
+	caKey, err := c.kubernetes.CoreV1().Secrets(namespace).Get(ctx, caKeySecretName, metav1.GetOptions{})
+	if k8serrors.IsNotFound(err) {
+		return fmt.Errorf("the private key of the CA isn't stored in secret %s; if the CA is provided with global.tls.caKey, run `consul-k8s upgrade` to reissue the server certificate", caKeySecretName)
+	}
+	if err != nil {
+		return fmt.Errorf("error reading the CA private key secret %s: %w", caKeySecretName, err)
+	}
+
+	certPEM, keyPEM, err := reissueCert(certSecret.Data[corev1.TLSCertKey], caCert.Data[corev1.TLSCertKey], caKey.Data[corev1.TLSPrivateKeyKey],
+		time.Duration(c.flagDays)*24*time.Hour)
+	if err != nil {
+		return fmt.Errorf("error reissuing the server certificate: %w", err)
+	}
+	certSecret.Data = map[string][]byte{
+		corev1.TLSCertKey:       certPEM,
+		corev1.TLSPrivateKeyKey: keyPEM,
+	}
+	if _, err := c.kubernetes.CoreV1().Secrets(namespace).Update(ctx, certSecret, metav1.UpdateOptions{}); err != nil {
+		return fmt.Errorf("error updating the server certificate secret %s: %w", certSecretName, err)
+	}
+	c.UI.Output(fmt.Sprintf("Reissued the server certificate in secret %s", certSecretName), terminal.WithSuccessStyle())
+
+	return c.restartServerPods(ctx, &sts)
+}
+
+// restartServerPods deletes the pods of the server StatefulSet in reverse ordinal order,
+// one at a time. Each pod is only deleted once all server pods are ready.
+func (c *RotateCommand) restartServerPods(ctx context.Context, sts *appsv1.StatefulSet) error {
+	selector := metav1.FormatLabelSelector(sts.Spec.Selector)
+	pods, err := c.kubernetes.CoreV1().Pods(sts.Namespace).List(ctx, metav1.ListOptions{LabelSelector: selector})
+	if err != nil {
+		return fmt.Errorf("error listing the Consul server pods: %w", err)
+	}
+	sort.Slice(pods.Items, func(i, j int) bool {
+		return podOrdinal(pods.Items[i].Name) > podOrdinal(pods.Items[j].Name)
+	})
+
+	for _, pod := range pods.Items {
+		err := c.poll(ctx, func(ctx context.Context) (bool, error) {
+			all, err := c.kubernetes.CoreV1().Pods(sts.Namespace).List(ctx, metav1.ListOptions{LabelSelector: selector})
+			if err != nil {
+				return false, err
+			}
+			for _, p := range all.Items {
+				if !podReady(&p) {
+					return false, nil
+				}
+			}
+			return len(all.Items) >= len(pods.Items), nil
+		})
+		if err != nil {
+			return fmt.Errorf("error waiting for the Consul server pods to be ready before restarting %s: %w", pod.Name, err)
+		}
+
+		c.UI.Output(fmt.Sprintf("Restarting %s", pod.Name), terminal.WithInfoStyle())
+		err = c.kubernetes.CoreV1().Pods(pod.Namespace).Delete(ctx, pod.Name, metav1.DeleteOptions{})
+		if err != nil && !k8serrors.IsNotFound(err) {
+			return fmt.Errorf("error deleting pod %s: %w", pod.Name, err)
+		}
+		if err := c.waitForReplacement(ctx, &pod); err != nil {
+			return err
+		}
+		c.UI.Output(fmt.Sprintf("%s restarted and ready", pod.Name), terminal.WithSuccessStyle())
+	}
+	return nil
+}
+
+// waitForReplacement waits for the StatefulSet to replace the deleted pod with a ready pod.
+func (c *RotateCommand) waitForReplacement(ctx context.Context, pod *corev1.Pod) error {
+	err := c.poll(ctx, func(ctx context.Context) (bool, error) {
+		current, err := c.kubernetes.CoreV1().Pods(pod.Namespace).Get(ctx, pod.Name, metav1.GetOptions{})
+		if k8serrors.IsNotFound(err) {
+			return false, nil
+		}
+		if err != nil {
+			return false, err
+		}
+		return current.UID != pod.UID && podReady(current), nil
+	})
+	if err != nil {
+		return fmt.Errorf("error waiting for %s to be ready after its restart: %w", pod.Name, err)
+	}
+	return nil
+}
+
+// rotateWebhookCert has the serving certificate of the connect injector webhooks reissued,
+// sets its CA as the CA bundle of the webhook configurations of the release, and
+// restarts the connect injector.
+func (c *RotateCommand) rotateWebhookCert(ctx context.Context, releaseName, namespace string) error {
+	c.UI.Output("Connect Injector Webhooks", terminal.WithHeaderStyle())
+
+	injectors, err := c.kubernetes.AppsV1().Deployments(namespace).List(ctx, metav1.ListOptions{LabelSelector: releaseSelector("connect-injector", releaseName)})
+	if err != nil {
+		return fmt.Errorf("error listing the connect injector Deployments: %w", err)
+	}
+	if len(injectors.Items) == 0 {
+		c.UI.Output("The connect injector is not installed, skipping.", terminal.WithInfoStyle())
+		return nil
+	}
+	injector := injectors.Items[0]
+
+	secretName := secretVolume(injector.Spec.Template.Spec.Volumes, webhookCertVolume)
+	if secretName == "" {
+		c.UI.Output("The connect injector doesn't read its serving certificate from a Kubernetes secret, skipping.", terminal.WithInfoStyle())
+		return nil
+	}
+
+	var oldCert []byte
+	secret, err := c.kubernetes.CoreV1().Secrets(namespace).Get(ctx, secretName, metav1.GetOptions{})
+	if err != nil && !k8serrors.IsNotFound(err) {
+		return fmt.Errorf("error reading the webhook certificate secret %s: %w", secretName, err)
+	}
+	if err == nil {
+		oldCert = secret.Data[corev1.TLSCertKey]
+	}
+
+	if err == nil && secret.Annotations[certManagerAnnotation] != "" {
+		// cert-manager reissues the certificate of a secret that is deleted.
+		if err := c.kubernetes.CoreV1().Secrets(namespace).Delete(ctx, secretName, metav1.DeleteOptions{}); err != nil {
+			return fmt.Errorf("error deleting the webhook certificate secret %s: %w", secretName, err)
+		}
+		c.UI.Output(fmt.Sprintf("Deleted secret %s so that cert-manager reissues certificate %s", secretName, secret.Annotations[certManagerAnnotation]),
+			terminal.WithSuccessStyle())
+	} else {
+		// The webhook-cert-manager generates a new CA and certificate when it starts.
+		managers, err := c.kubernetes.AppsV1().Deployments(namespace).List(ctx, metav1.ListOptions{LabelSelector: releaseSelector("webhook-cert-manager", releaseName)})
+		if err != nil {
+			return fmt.Errorf("error listing the webhook-cert-manager Deployments: %w", err)
+		}
+		if len(managers.Items) == 0 {
+			return fmt.Errorf("the webhook certificate in secret %s is managed by neither the webhook-cert-manager nor cert-manager", secretName)
+		}
+		if err := c.restartDeployment(ctx, &managers.Items[0]); err != nil {
+			return err
+		}
+	}
+
+	var certPEM, caBundle []byte
+	err = c.poll(ctx, func(ctx context.Context) (bool, error) {
+		secret, err := c.kubernetes.CoreV1().Secrets(namespace).Get(ctx, secretName, metav1.GetOptions{})
+		if k8serrors.IsNotFound(err) {
+			return false, nil
+		}
+		if err != nil {
+			return false, err
+		}
+		certPEM = secret.Data[corev1.TLSCertKey]
+		caBundle = secret.Data["ca.crt"]
+		return len(certPEM) > 0 && !bytes.Equal(certPEM, oldCert), nil
+	})
+	if err != nil {
+		return fmt.Errorf("error waiting for a new certificate in secret %s: %w", secretName, err)
+	}
+	c.UI.Output(fmt.Sprintf("Reissued the webhook certificate in secret %s", secretName), terminal.WithSuccessStyle())
+
+	// The webhook-cert-manager doesn't store its CA in the secret. It sets it as the
+	// CA bundle of the webhook configurations it manages instead.
+	if len(caBundle) == 0 || !verifiesCert(caBundle, certPEM) {
+		err = c.poll(ctx, func(ctx context.Context) (bool, error) {
+			bundles, err := c.webhookCABundles(ctx, releaseName)
+			if err != nil {
+				return false, err
+			}
+			for _, bundle := range bundles {
+				if verifiesCert(bundle, certPEM) {
+					caBundle = bundle
+					return true, nil
+				}
+			}
+			return false, nil
+		})
+		if err != nil {
+			return fmt.Errorf("error waiting for the CA of the new webhook certificate to be set as the CA bundle of a webhook: %w", err)
+		}
+	}
+	if err := c.updateCABundles(ctx, releaseName, caBundle); err != nil {
+		return err
+	}
+
+	return c.restartDeployment(ctx, &injector)
+}
+
+// webhookCABundles returns the CA bundles of the connect injector webhooks of the release.
+func (c *RotateCommand) webhookCABundles(ctx context.Context, releaseName string) ([][]byte, error) {
+	opts := metav1.ListOptions{LabelSelector: releaseSelector("connect-injector", releaseName)}
+	var bundles [][]byte
+	mutating, err := c.kubernetes.AdmissionregistrationV1().MutatingWebhookConfigurations().List(ctx, opts)
+	if err != nil {
+		return nil, err
+	}
+	for _, config := range mutating.Items {
+		for _, webhook := range config.Webhooks {
+			bundles = append(bundles, webhook.ClientConfig.CABundle)
+		}
+	}
+	validating, err := c.kubernetes.AdmissionregistrationV1().ValidatingWebhookConfigurations().List(ctx, opts)
+	if err != nil {
+		return nil, err
+	}
+	for _, config := range validating.Items {
+		for _, webhook := range config.Webhooks {
+			bundles = append(bundles, webhook.ClientConfig.CABundle)
+		}
+	}
+	return bundles, nil
+}
+
+// updateCABundles sets caBundle as the CA bundle of each connect injector webhook of the release.
+func (c *RotateCommand) updateCABundles(ctx context.Context, releaseName string, caBundle []byte) error {
+	opts := metav1.ListOptions{LabelSelector: releaseSelector("connect-injector", releaseName)}
+	client := c.kubernetes.AdmissionregistrationV1()
+
+	mutating, err := client.MutatingWebhookConfigurations().List(ctx, opts)
+	if err != nil {
+		return fmt.Errorf("error listing the MutatingWebhookConfigurations: %w", err)
+	}
+	for _, config := range mutating.Items {
+		changed := false
+		for i := range config.Webhooks {
+			if !bytes.Equal(config.Webhooks[i].ClientConfig.CABundle, caBundle) {
+				config.Webhooks[i].ClientConfig.CABundle = caBundle
+				changed = true
+			}
+		}
+		if !changed {
+			continue
+		}
+		if _, err := client.MutatingWebhookConfigurations().Update(ctx, &config, metav1.UpdateOptions{}); err != nil {
+			return fmt.Errorf("error updating the CA bundle of MutatingWebhookConfiguration %s: %w", config.Name, err)
+		}
+		c.UI.Output(fmt.Sprintf("Updated the CA bundle of MutatingWebhookConfiguration %s", config.Name), terminal.WithSuccessStyle())
+	}
+
+	validating, err := client.ValidatingWebhookConfigurations().List(ctx, opts)
+	if err != nil {
+		return fmt.Errorf("error listing the ValidatingWebhookConfigurations: %w", err)
+	}
+	for _, config := range validating.Items {
+		changed := false
+		for i := range config.Webhooks {
+			if !bytes.Equal(config.Webhooks[i].ClientConfig.CABundle, caBundle) {
+				config.Webhooks[i].ClientConfig.CABundle = caBundle
+				changed = true
+			}
+		}
+		if !changed {
+			continue
+		}
+		if _, err := client.ValidatingWebhookConfigurations().Update(ctx, &config, metav1.UpdateOptions{}); err != nil {
+			return fmt.Errorf("error updating the CA bundle of ValidatingWebhookConfiguration %s: %w", config.Name, err)
+		}
+		c.UI.Output(fmt.Sprintf("Updated the CA bundle of ValidatingWebhookConfiguration %s", config.Name), terminal.WithSuccessStyle())
+	}
+	return nil
+}
+
+// restartDeployment restarts the pods of the Deployment like `kubectl rollout restart`
+// and waits for the rollout to complete.
+func (c *RotateCommand) restartDeployment(ctx context.Context, deployment *appsv1.Deployment) error {
+	c.UI.Output(fmt.Sprintf("Restarting %s", deployment.Name), terminal.WithInfoStyle())
+	patch := fmt.Sprintf(`{"spec":{"template":{"metadata":{"annotations":{%q:%q}}}}}`, restartedAtAnnotation, time.Now().Format(time.RFC3339))
+	_, err := c.kubernetes.AppsV1().Deployments(deployment.Namespace).Patch(ctx, deployment.Name, types.StrategicMergePatchType, []byte(patch), metav1.PatchOptions{})
+	if err != nil {
+		return fmt.Errorf("error restarting %s: %w", deployment.Name, err)
+	}
+
+	err = c.poll(ctx, func(ctx context.Context) (bool, error) {
+		current, err := c.kubernetes.AppsV1().Deployments(deployment.Namespace).Get(ctx, deployment.Name, metav1.GetOptions{})
+		if err != nil {
+			return false, err
+		}
+		return deploymentRolledOut(current), nil
+	})
+	if err != nil {
+		return fmt.Errorf("error waiting for the rollout of %s: %w", deployment.Name, err)
+	}
+	c.UI.Output(fmt.Sprintf("%s restarted and ready", deployment.Name), terminal.WithSuccessStyle())
+	return nil
+}
+
+func (c *RotateCommand) poll(ctx context.Context, condition wait.ConditionWithContextFunc) error {
+	return wait.PollUntilContextTimeout(ctx, c.pollInterval, c.flagTimeout, true, condition)
+}
+
+func releaseSelector(component, releaseName string) string {
+	return fmt.Sprintf("component=%s,release=%s", component, releaseName)
+}
+
+// secretVolume returns the name of the secret mounted by the volume with the name, if any.
+func secretVolume(volumes []corev1.Volume, name string) string {
+	for _, v := range volumes {
+		if v.Name == name && v.Secret != nil {
+			return v.Secret.SecretName
+		}
+	}
+	return ""
+}
+
+// podOrdinal returns the ordinal of a StatefulSet pod from its name.
+func podOrdinal(name string) int {
+	ordinal, err := strconv.Atoi(name[strings.LastIndex(name, "-")+1:])
+	if err != nil {
+		return -1
+	}
+	return ordinal
+}
+
+func podReady(pod *corev1.Pod) bool {
+	if pod.DeletionTimestamp != nil {
+		return false
+	}
+	for _, cond := range pod.Status.Conditions {
+		if cond.Type == corev1.PodReady {
+			return cond.Status == corev1.ConditionTrue
+		}
+	}
+	return false
+}
+
+// deploymentRolledOut returns true once every replica of the Deployment runs the latest
+// pod template and is available.
+func deploymentRolledOut(d *appsv1.Deployment) bool {
+	replicas := int32(1)
+	if d.Spec.Replicas != nil {
+		replicas = *d.Spec.Replicas
+	}
+	return d.Status.ObservedGeneration >= d.Generation &&
+		d.Status.UpdatedReplicas == replicas &&
+		d.Status.Replicas == replicas &&
+		d.Status.AvailableReplicas == replicas
+}
@@ -0,0 +1,450 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package rotate
+
+import (
+	"bytes"
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"flag"
+	"fmt"
+	"io"
+	"math/big"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/hashicorp/go-hclog"
+	"github.com/posener/complete"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	admissionv1 "k8s.io/api/admissionregistration/v1"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/kubernetes/fake"
+	k8stesting "k8s.io/client-go/testing"
+
+	"github.com/hashicorp/consul-k8s/cli/common"
+	cmnFlag "github.com/hashicorp/consul-k8s/cli/common/flag"
+	"github.com/hashicorp/consul-k8s/cli/common/terminal"
+	"github.com/hashicorp/consul-k8s/cli/helm"
+)
+
+const (
+	testRelease   = "consul"
+	testNamespace = "consul"
+)
+
+func TestFlagParsing(t *testing.T) {
+	cases := map[string]struct {
+		args []string
+		out  int
+	}{
+		"Non-flag argument passed": {
+			args: []string{"server"},
+			out:  1,
+		},
+		"Nonexistent flag passed, -foo bar": {
+			args: []string{"-foo", "bar"},
+			out:  1,
+		},
+		"Invalid component passed": {
+			args: []string{"-component", "client"},
+			out:  1,
+		},
+		"Zero days passed": {
+			args: []string{"-days", "0"},
+			out:  1,
+		},
+		"Negative timeout passed": {
+			args: []string{"-timeout", "-1s"},
+			out:  1,
+		},
+	}
+
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			c := setupCommand(new(bytes.Buffer))
+			c.kubernetes = fake.NewSimpleClientset()
+			out := c.Run(tc.args)
+			require.Equal(t, tc.out, out)
+		})
+	}
+}
+
+func TestRun_NothingToRotate(t *testing.T) {
+	buf := new(bytes.Buffer)
+	c := setupCommand(buf)
+	c.kubernetes = fake.NewSimpleClientset()
+
+	require.Equal(t, 0, c.Run([]string{"-auto-approve"}))
+	require.Contains(t, buf.String(), "Consul servers are not running in this Kubernetes cluster, skipping.")
+	require.Contains(t, buf.String(), "The connect injector is not installed, skipping.")
+}
+
+func TestRun_ServerCert(t *testing.T) {
+	caCert, caKey := generateCA(t)
+	oldCert, oldKey := generateCert(t, caCert, caKey, "server.dc1.consul", "consul-server", "*.consul-server.consul.svc")
+
+	serverCertSecret := secret("consul-server-cert", oldCert, oldKey)
+	serverCertSecret.Labels = map[string]string{common.CLILabelKey: common.CLILabelValue}
+	client := fake.NewSimpleClientset(
+		serverStatefulSet(),
+		secret("consul-ca-cert", caCert, nil),
+		secret("consul-ca-key", nil, caKey),
+		serverCertSecret,
+		serverPod(0), serverPod(1), serverPod(2),
+	)
+	var restarted []string
+	client.PrependReactor("delete", "pods", func(action k8stesting.Action) (bool, runtime.Object, error) {
+		// The StatefulSet controller replaces the deleted pod with a new one.
+		name := action.(k8stesting.DeleteAction).GetName()
+		restarted = append(restarted, name)
+		pod, err := client.Tracker().Get(corev1.SchemeGroupVersion.WithResource("pods"), testNamespace, name)
+		if err != nil {
+			return true, nil, err
+		}
+		replacement := pod.(*corev1.Pod).DeepCopy()
+		replacement.UID = types.UID(name + "-restarted")
+		return true, nil, client.Tracker().Update(corev1.SchemeGroupVersion.WithResource("pods"), replacement, testNamespace)
+	})
+
+	buf := new(bytes.Buffer)
+	c := setupCommand(buf)
+	c.kubernetes = client
+
+	require.Equal(t, 0, c.Run([]string{"-component", "server", "-auto-approve", "-days", "30"}), buf.String())
+	require.Equal(t, []string{"consul-server-2", "consul-server-1", "consul-server-0"}, restarted)
+
+	updated, err := client.CoreV1().Secrets(testNamespace).Get(context.Background(), "consul-server-cert", metav1.GetOptions{})
+	require.NoError(t, err)
+	require.NotEqual(t, oldCert, updated.Data[corev1.TLSCertKey])
+	require.NotEqual(t, oldKey, updated.Data[corev1.TLSPrivateKeyKey])
+	require.True(t, verifiesCert(caCert, updated.Data[corev1.TLSCertKey]))
+	cert, err := parseCert(updated.Data[corev1.TLSCertKey])
+	require.NoError(t, err)
+	require.Equal(t, "server.dc1.consul", cert.Subject.CommonName)
+	require.Equal(t, []string{"consul-server", "*.consul-server.consul.svc"}, cert.DNSNames)
+	require.WithinDuration(t, time.Now().Add(30*24*time.Hour), cert.NotAfter, time.Hour)
+}
+
+func TestRun_ServerCertNotIssuedByChart(t *testing.T) {
+	caCert, caKey := generateCA(t)
+	oldCert, oldKey := generateCert(t, caCert, caKey, "server.dc1.consul")
+
+	client := fake.NewSimpleClientset(
+		serverStatefulSet(),
+		secret("consul-ca-cert", caCert, nil),
+		secret("consul-server-cert", oldCert, oldKey),
+		serverPod(0),
+	)
+
+	buf := new(bytes.Buffer)
+	c := setupCommand(buf)
+	c.kubernetes = client
+
+	require.Equal(t, 0, c.Run([]string{"-component", "server", "-auto-approve"}))
+	require.Contains(t, buf.String(), "The server certificate in secret consul-server-cert isn't issued by the chart, skipping.")
+	pods, err := client.CoreV1().Pods(testNamespace).List(context.Background(), metav1.ListOptions{})
+	require.NoError(t, err)
+	require.Equal(t, types.UID("consul-server-0"), pods.Items[0].UID)
+}
+
+func TestRun_WebhookCert(t *testing.T) {
+	oldCA, oldCAKey := generateCA(t)
+	oldCert, oldKey := generateCert(t, oldCA, oldCAKey, "Consul Webhook Certificates", "consul-connect-injector.consul.svc")
+	newCA, newCAKey := generateCA(t)
+	newCert, newKey := generateCert(t, newCA, newCAKey, "Consul Webhook Certificates", "consul-connect-injector.consul.svc")
+
+	cases := map[string]struct {
+		// certManager is true if the secret is managed by cert-manager instead of the webhook-cert-manager.
+		certManager bool
+	}{
+		"webhook-cert-manager": {},
+		"cert-manager":         {certManager: true},
+	}
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			webhookSecret := secret("consul-connect-inject-webhook-cert", oldCert, oldKey)
+			objs := []runtime.Object{
+				injectorDeployment(),
+				webhookSecret,
+				&admissionv1.MutatingWebhookConfiguration{
+					ObjectMeta: clusterMeta("consul-connect-injector", "connect-injector"),
+					Webhooks:   []admissionv1.MutatingWebhook{{Name: "consul-connect-injector.consul.hashicorp.com", ClientConfig: admissionv1.WebhookClientConfig{CABundle: oldCA}}},
+				},
+				&admissionv1.ValidatingWebhookConfiguration{
+					ObjectMeta: clusterMeta("consul-connect-injector", "connect-injector"),
+					Webhooks:   []admissionv1.ValidatingWebhook{{Name: "validate-gatewaypolicy.consul.hashicorp.com", ClientConfig: admissionv1.WebhookClientConfig{CABundle: oldCA}}},
+				},
+			}
+			if tc.certManager {
+				webhookSecret.Annotations = map[string]string{certManagerAnnotation: "consul-connect-inject-webhook-cert"}
+			} else {
+				manager := injectorDeployment()
+				manager.ObjectMeta = releaseMeta("consul-webhook-cert-manager", "webhook-cert-manager")
+				manager.Spec.Template.Spec.Volumes = nil
+				objs = append(objs, manager)
+			}
+			client := fake.NewSimpleClientset(objs...)
+
+			reissued := webhookSecret.DeepCopy()
+			reissued.Data = map[string][]byte{corev1.TLSCertKey: newCert, corev1.TLSPrivateKeyKey: newKey}
+			if tc.certManager {
+				// cert-manager reissues the certificate when its secret is deleted and
+				// stores its CA alongside it.
+				reissued.Data["ca.crt"] = newCA
+				client.PrependReactor("delete", "secrets", func(k8stesting.Action) (bool, runtime.Object, error) {
+					return true, nil, client.Tracker().Update(corev1.SchemeGroupVersion.WithResource("secrets"), reissued, testNamespace)
+				})
+			} else {
+				// The webhook-cert-manager reissues the certificate when it restarts and sets
+				// its CA as the CA bundle of the mutating webhook.
+				client.PrependReactor("patch", "deployments", func(action k8stesting.Action) (bool, runtime.Object, error) {
+					if action.(k8stesting.PatchAction).GetName() != "consul-webhook-cert-manager" {
+						return false, nil, nil
+					}
+					obj, err := client.Tracker().Get(admissionv1.SchemeGroupVersion.WithResource("mutatingwebhookconfigurations"), "", "consul-connect-injector")
+					if err != nil {
+						return true, nil, err
+					}
+					mutating := obj.(*admissionv1.MutatingWebhookConfiguration).DeepCopy()
+					mutating.Webhooks[0].ClientConfig.CABundle = newCA
+					if err := client.Tracker().Update(admissionv1.SchemeGroupVersion.WithResource("mutatingwebhookconfigurations"), mutating, ""); err != nil {
+						return true, nil, err
+					}
+					return false, nil, client.Tracker().Update(corev1.SchemeGroupVersion.WithResource("secrets"), reissued, testNamespace)
+				})
+			}
+
+			buf := new(bytes.Buffer)
+			c := setupCommand(buf)
+			c.kubernetes = client
+
+			require.Equal(t, 0, c.Run([]string{"-component", "webhook", "-auto-approve"}), buf.String())
+			require.Contains(t, buf.String(), "Reissued the webhook certificate in secret consul-connect-inject-webhook-cert")
+
+			mutating, err := client.AdmissionregistrationV1().MutatingWebhookConfigurations().Get(context.Background(), "consul-connect-injector", metav1.GetOptions{})
+			require.NoError(t, err)
+			require.Equal(t, newCA, mutating.Webhooks[0].ClientConfig.CABundle)
+			validating, err := client.AdmissionregistrationV1().ValidatingWebhookConfigurations().Get(context.Background(), "consul-connect-injector", metav1.GetOptions{})
+			require.NoError(t, err)
+			require.Equal(t, newCA, validating.Webhooks[0].ClientConfig.CABundle)
+
+			injector, err := client.AppsV1().Deployments(testNamespace).Get(context.Background(), "consul-connect-injector", metav1.GetOptions{})
+			require.NoError(t, err)
+			require.Contains(t, injector.Spec.Template.Annotations, restartedAtAnnotation)
+		})
+	}
+}
+
+func TestRun_WebhookCertNotManaged(t *testing.T) {
+	caCert, caKey := generateCA(t)
+	cert, key := generateCert(t, caCert, caKey, "Consul Webhook Certificates")
+	client := fake.NewSimpleClientset(
+		injectorDeployment(),
+		secret("consul-connect-inject-webhook-cert", cert, key),
+	)
+
+	buf := new(bytes.Buffer)
+	c := setupCommand(buf)
+	c.kubernetes = client
+
+	require.Equal(t, 1, c.Run([]string{"-component", "webhook", "-auto-approve"}))
+	require.Contains(t, buf.String(), "the webhook certificate in secret consul-connect-inject-webhook-cert is managed by neither the webhook-cert-manager nor cert-manager")
+}
+
+func TestTaskCreateCommand_AutocompleteFlags(t *testing.T) {
+	t.Parallel()
+	buf := new(bytes.Buffer)
+	cmd := setupCommand(buf)
+
+	predictor := cmd.AutocompleteFlags()
+
+	// Test that we get the expected number of predictions
+	args := complete.Args{Last: "-"}
+	res := predictor.Predict(args)
+
+	// Grab the list of flags from the Flag object
+	flags := make([]string, 0)
+	cmd.set.VisitSets(func(name string, set *cmnFlag.Set) {
+		set.VisitAll(func(flag *flag.Flag) {
+			flags = append(flags, fmt.Sprintf("-%s", flag.Name))
+		})
+	})
+
+	// Verify that there is a prediction for each flag associated with the command
+	assert.Equal(t, len(flags), len(res))
+	assert.ElementsMatch(t, flags, res, "flags and predictions didn't match, make sure to add "+
+		"new flags to the command AutoCompleteFlags function")
+}
+
+func TestTaskCreateCommand_AutocompleteArgs(t *testing.T) {
+	buf := new(bytes.Buffer)
+	cmd := setupCommand(buf)
+	c := cmd.AutocompleteArgs()
+	assert.Equal(t, complete.PredictNothing, c)
+}
+
+func setupCommand(buf io.Writer) *RotateCommand {
+	// Log at a test level to standard out.
+	log := hclog.New(&hclog.LoggerOptions{
+		Name:   "test",
+		Level:  hclog.Debug,
+		Output: os.Stdout,
+	})
+
+	// Setup and initialize the command struct
+	command := &RotateCommand{
+		BaseCommand: &common.BaseCommand{
+			Ctx: context.Background(),
+			Log: log,
+			UI:  terminal.NewUI(context.Background(), buf),
+		},
+		helmActionsRunner: &helm.MockActionRunner{
+			CheckForInstallationsFunc: func(*helm.CheckForInstallationsOptions) (bool, string, string, error) {
+				return true, testRelease, testNamespace, nil
+			},
+		},
+		pollInterval: 10 * time.Millisecond,
+	}
+	command.init()
+
+	return command
+}
+
+func releaseMeta(name, component string) metav1.ObjectMeta {
+	return metav1.ObjectMeta{
+		Name:      name,
+		Namespace: testNamespace,
+		Labels:    map[string]string{"app": "consul", "component": component, "release": testRelease},
+	}
+}
+
+// clusterMeta returns the metadata of a cluster-scoped resource of the release.
+func clusterMeta(name, component string) metav1.ObjectMeta {
+	meta := releaseMeta(name, component)
+	meta.Namespace = ""
+	return meta
+}
+
+func serverStatefulSet() *appsv1.StatefulSet {
+	return &appsv1.StatefulSet{
+		ObjectMeta: releaseMeta("consul-server", "server"),
+		Spec: appsv1.StatefulSetSpec{
+			Selector: &metav1.LabelSelector{MatchLabels: map[string]string{"app": "consul", "component": "server", "release": testRelease}},
+			Template: corev1.PodTemplateSpec{
+				Spec: corev1.PodSpec{
+					Volumes: []corev1.Volume{
+						{Name: "data", VolumeSource: corev1.VolumeSource{EmptyDir: &corev1.EmptyDirVolumeSource{}}},
+						{Name: caCertVolume, VolumeSource: corev1.VolumeSource{Secret: &corev1.SecretVolumeSource{SecretName: "consul-ca-cert"}}},
+						{Name: serverCertVolume, VolumeSource: corev1.VolumeSource{Secret: &corev1.SecretVolumeSource{SecretName: "consul-server-cert"}}},
+					},
+				},
+			},
+		},
+	}
+}
+
+func serverPod(ordinal int) *corev1.Pod {
+	name := fmt.Sprintf("consul-server-%d", ordinal)
+	meta := releaseMeta(name, "server")
+	meta.UID = types.UID(name)
+	return &corev1.Pod{
+		ObjectMeta: meta,
+		Status: corev1.PodStatus{
+			Conditions: []corev1.PodCondition{{Type: corev1.PodReady, Status: corev1.ConditionTrue}},
+		},
+	}
+}
+
+func injectorDeployment() *appsv1.Deployment {
+	replicas := int32(1)
+	return &appsv1.Deployment{
+		ObjectMeta: releaseMeta("consul-connect-injector", "connect-injector"),
+		Spec: appsv1.DeploymentSpec{
+			Replicas: &replicas,
+			Template: corev1.PodTemplateSpec{
+				Spec: corev1.PodSpec{
+					Volumes: []corev1.Volume{
+						{Name: webhookCertVolume, VolumeSource: corev1.VolumeSource{Secret: &corev1.SecretVolumeSource{SecretName: "consul-connect-inject-webhook-cert"}}},
+					},
+				},
+			},
+		},
+		Status: appsv1.DeploymentStatus{Replicas: 1, UpdatedReplicas: 1, AvailableReplicas: 1},
+	}
+}
+
+func secret(name string, cert, key []byte) *corev1.Secret {
+	data := map[string][]byte{}
+	if cert != nil {
+		data[corev1.TLSCertKey] = cert
+	}
+	if key != nil {
+		data[corev1.TLSPrivateKeyKey] = key
+	}
+	return &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: testNamespace},
+		Data:       data,
+		Type:       corev1.SecretTypeTLS,
+	}
+}
+
+// generateCA returns the PEM encoded certificate and private key of a new CA.
+func generateCA(t *testing.T) ([]byte, []byte) {
+	t.Helper()
+	return generate(t, &x509.Certificate{
+		Subject:               pkix.Name{CommonName: "Consul Agent CA"},
+		IsCA:                  true,
+		BasicConstraintsValid: true,
+		KeyUsage:              x509.KeyUsageCertSign | x509.KeyUsageCRLSign | x509.KeyUsageDigitalSignature,
+	}, nil, nil)
+}
+
+// generateCert returns the PEM encoded certificate and private key of a leaf
+// certificate signed by the CA.
+func generateCert(t *testing.T, caCert, caKey []byte, commonName string, dnsNames ...string) ([]byte, []byte) {
+	t.Helper()
+	return generate(t, &x509.Certificate{
+		Subject:     pkix.Name{CommonName: commonName},
+		DNSNames:    dnsNames,
+		KeyUsage:    x509.KeyUsageDigitalSignature | x509.KeyUsageKeyEncipherment,
+		ExtKeyUsage: []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+	}, caCert, caKey)
+}
+
+func generate(t *testing.T, template *x509.Certificate, caCert, caKey []byte) ([]byte, []byte) {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	sn, err := rand.Int(rand.Reader, big.NewInt(1<<62))
+	require.NoError(t, err)
+	template.SerialNumber = sn
+	template.NotBefore = time.Now().Add(-time.Minute)
+	template.NotAfter = time.Now().Add(24 * time.Hour)
+
+	parent, signer := template, any(key)
+	if caCert != nil {
+		parent, err = parseCert(caCert)
+		require.NoError(t, err)
+		caSigner, err := parseSigner(caKey)
+		require.NoError(t, err)
+		signer = caSigner
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, parent, key.Public(), signer)
+	require.NoError(t, err)
+	keyBytes, err := x509.MarshalECPrivateKey(key)
+	require.NoError(t, err)
+	return pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}),
+		pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyBytes})
+}
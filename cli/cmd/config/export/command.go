@@ -0,0 +1,196 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package export
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+
+	"github.com/posener/complete"
+	helmCLI "helm.sh/helm/v3/pkg/cli"
+	"k8s.io/client-go/dynamic"
+
+	"github.com/hashicorp/consul-k8s/cli/common"
+	"github.com/hashicorp/consul-k8s/cli/common/flag"
+	"github.com/hashicorp/consul-k8s/cli/common/terminal"
+	"github.com/hashicorp/consul-k8s/cli/configentries"
+)
+
+const (
+	flagNameDir         = "dir"
+	flagNameNamespace   = "namespace"
+	flagNameKubeConfig  = "kubeconfig"
+	flagNameKubeContext = "context"
+
+	defaultDir = "consul-config"
+)
+
+// ExportCommand writes the custom resources that manage Consul config entries to a directory of YAML files.
+type ExportCommand struct {
+	*common.BaseCommand
+
+	dynamic dynamic.Interface
+
+	set *flag.Sets
+
+	flagDir         string
+	flagNamespace   string
+	flagKubeConfig  string
+	flagKubeContext string
+
+	once sync.Once
+	help string
+}
+
+func (c *ExportCommand) init() {
+	c.set = flag.NewSets()
+
+	f := c.set.NewSet("Command Options")
+	f.StringVar(&flag.StringVar{
+		Name:    flagNameDir,
+		Aliases: []string{"d"},
+		Target:  &c.flagDir,
+		Default: defaultDir,
+		Usage:   "The directory to write the custom resources to. Each resource is written to <namespace>/<kind>-<name>.yaml.",
+	})
+	f.StringVar(&flag.StringVar{
+		Name:    flagNameNamespace,
+		Aliases: []string{"n"},
+		Target:  &c.flagNamespace,
+		Default: "",
+		Usage:   "The Kubernetes namespace to export custom resources from. Defaults to all namespaces.",
+	})
+
+	f = c.set.NewSet("Global Options")
+	f.StringVar(&flag.StringVar{
+		Name:    flagNameKubeConfig,
+		Aliases: []string{"c"},
+		Target:  &c.flagKubeConfig,
+		Default: "",
+		Usage:   "Path to kubeconfig file.",
+	})
+	f.StringVar(&flag.StringVar{
+		Name:    flagNameKubeContext,
+		Target:  &c.flagKubeContext,
+		Default: "",
+		Usage:   "Kubernetes context to use.",
+	})
+
+	c.help = c.set.Help()
+}
+
+// Run exports the custom resources that manage Consul config entries.
+func (c *ExportCommand) Run(args []string) int {
+	c.once.Do(c.init)
+
+	c.Log.ResetNamed("config export")
+	defer common.CloseWithError(c.BaseCommand)
+
+	if err := c.set.Parse(args); err != nil {
+		c.UI.Output(err.Error())
+		return 1
+	}
+
+	if err := c.validateFlags(); err != nil {
+		c.UI.Output(err.Error())
+		return 1
+	}
+
+	// helmCLI.New() will create a settings object which is used to target the right cluster.
+	settings := helmCLI.New()
+	if c.flagKubeConfig != "" {
+		settings.KubeConfig = c.flagKubeConfig
+	}
+	if c.flagKubeContext != "" {
+		settings.KubeContext = c.flagKubeContext
+	}
+
+	if err := c.setupKubeClient(settings); err != nil {
+		c.UI.Output(err.Error(), terminal.WithErrorStyle())
+		return 1
+	}
+
+	crs, err := configentries.List(c.Ctx, c.dynamic, c.flagNamespace)
+	if err != nil {
+		c.UI.Output(err.Error(), terminal.WithErrorStyle())
+		return 1
+	}
+
+	c.UI.Output("Exporting Consul config entries", terminal.WithHeaderStyle())
+	if len(crs) == 0 {
+		c.UI.Output("No config entry custom resources found.", terminal.WithInfoStyle())
+		return 0
+	}
+
+	paths, err := configentries.WriteDir(c.flagDir, crs)
+	if err != nil {
+		c.UI.Output(fmt.Sprintf("error writing custom resources: %s", err), terminal.WithErrorStyle())
+		return 1
+	}
+	for _, path := range paths {
+		c.UI.Output(path, terminal.WithInfoStyle())
+	}
+	c.UI.Output(fmt.Sprintf("Exported %d custom resources to %s.", len(paths), c.flagDir), terminal.WithSuccessStyle())
+
+	return 0
+}
+
+// validateFlags checks the command line flags and values for errors.
+func (c *ExportCommand) validateFlags() error {
+	if len(c.set.Args()) > 0 {
+		return errors.New("should have no non-flag arguments")
+	}
+	if c.flagDir == "" {
+		return fmt.Errorf("-%s must be set", flagNameDir)
+	}
+	return nil
+}
+
+// setupKubeClient creates the Kubernetes client used to read custom resources unless one has
+// already been set.
+func (c *ExportCommand) setupKubeClient(settings *helmCLI.EnvSettings) error {
+	if c.dynamic == nil {
+		restConfig, err := settings.RESTClientGetter().ToRESTConfig()
+		if err != nil {
+			return fmt.Errorf("error retrieving Kubernetes authentication: %v", err)
+		}
+		c.dynamic, err = dynamic.NewForConfig(restConfig)
+		if err != nil {
+			return fmt.Errorf("error initializing Kubernetes client: %v", err)
+		}
+	}
+
+	return nil
+}
+
+// AutocompleteFlags returns a mapping of supported flags and autocomplete
+// options for this command. The map key for the Flags map should be the
+// complete flag such as "-foo" or "--foo".
+func (c *ExportCommand) AutocompleteFlags() complete.Flags {
+	return complete.Flags{
+		fmt.Sprintf("-%s", flagNameDir):         complete.PredictDirs("*"),
+		fmt.Sprintf("-%s", flagNameNamespace):   complete.PredictNothing,
+		fmt.Sprintf("-%s", flagNameKubeConfig):  complete.PredictFiles("*"),
+		fmt.Sprintf("-%s", flagNameKubeContext): complete.PredictNothing,
+	}
+}
+
+// AutocompleteArgs returns the argument predictor for this command.
+// Since argument completion is not supported, this will return
+// complete.PredictNothing.
+func (c *ExportCommand) AutocompleteArgs() complete.Predictor {
+	return complete.PredictNothing
+}
+
+// Help returns a description of the command and how it is used.
+func (c *ExportCommand) Help() string {
+	c.once.Do(c.init)
+	return c.Synopsis() + "\n\nUsage: consul-k8s config export [flags]\n\n" + c.help
+}
+
+// Synopsis returns a one-line command summary.
+func (c *ExportCommand) Synopsis() string {
+	return "Export the custom resources that manage Consul config entries to a directory of YAML files."
+}
@@ -0,0 +1,125 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package export
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/hashicorp/go-hclog"
+	"github.com/stretchr/testify/require"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	dynamicFake "k8s.io/client-go/dynamic/fake"
+
+	"github.com/hashicorp/consul-k8s/cli/common"
+	"github.com/hashicorp/consul-k8s/cli/common/terminal"
+	"github.com/hashicorp/consul-k8s/cli/configentries"
+)
+
+func TestExport(t *testing.T) {
+	cases := map[string]struct {
+		input              []string
+		crs                []string
+		files              []string
+		messages           []string
+		expectedReturnCode int
+	}{
+		"exports all namespaces": {
+			crs:      []string{"default/web", "consul/api"},
+			files:    []string{"default/servicedefaults-web.yaml", "consul/servicedefaults-api.yaml"},
+			messages: []string{"Exported 2 custom resources"},
+		},
+		"exports one namespace": {
+			input:    []string{"-namespace", "consul"},
+			crs:      []string{"default/web", "consul/api"},
+			files:    []string{"consul/servicedefaults-api.yaml"},
+			messages: []string{"Exported 1 custom resources"},
+		},
+		"no custom resources": {
+			messages: []string{"No config entry custom resources found."},
+		},
+		"unexpected argument": {
+			input:              []string{"foo"},
+			messages:           []string{"should have no non-flag arguments"},
+			expectedReturnCode: 1,
+		},
+	}
+
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			dir := t.TempDir()
+			buf := new(bytes.Buffer)
+			c := getInitializedCommand(t, buf)
+			c.dynamic = newFakeClient(t, tc.crs...)
+
+			returnCode := c.Run(append(tc.input, "-dir", dir))
+			require.Equal(t, tc.expectedReturnCode, returnCode)
+			output := buf.String()
+			for _, msg := range tc.messages {
+				require.Contains(t, output, msg)
+			}
+			for _, file := range tc.files {
+				require.FileExists(t, filepath.Join(dir, file))
+			}
+		})
+	}
+}
+
+// newFakeClient returns a dynamic client with a ServiceDefaults resource for each of the
+// <namespace>/<name> strings in crs.
+func newFakeClient(t *testing.T, crs ...string) *dynamicFake.FakeDynamicClient {
+	t.Helper()
+	gvrToListKind := make(map[schema.GroupVersionResource]string)
+	for _, kind := range configentries.Kinds() {
+		gvr, _ := configentries.GroupVersionResource(kind)
+		gvrToListKind[gvr] = kind + "List"
+	}
+	client := dynamicFake.NewSimpleDynamicClientWithCustomListKinds(runtime.NewScheme(), gvrToListKind)
+	gvr, _ := configentries.GroupVersionResource("ServiceDefaults")
+	for _, cr := range crs {
+		namespace, name := filepath.Split(cr)
+		obj := &unstructured.Unstructured{Object: map[string]interface{}{
+			"apiVersion": "consul.hashicorp.com/v1alpha1",
+			"kind":       "ServiceDefaults",
+			"spec":       map[string]interface{}{"protocol": "http"},
+		}}
+		obj.SetName(name)
+		obj.SetNamespace(filepath.Clean(namespace))
+		_, err := client.Resource(gvr).Namespace(obj.GetNamespace()).Create(context.Background(), obj, metav1.CreateOptions{})
+		require.NoError(t, err)
+	}
+	return client
+}
+
+func getInitializedCommand(t *testing.T, buf io.Writer) *ExportCommand {
+	t.Helper()
+	log := hclog.New(&hclog.LoggerOptions{
+		Name:   "cli",
+		Level:  hclog.Info,
+		Output: os.Stdout,
+	})
+	var ui terminal.UI
+	if buf != nil {
+		ui = terminal.NewUI(context.Background(), buf)
+	} else {
+		ui = terminal.NewBasicUI(context.Background())
+	}
+	baseCommand := &common.BaseCommand{
+		Log: log,
+		UI:  ui,
+	}
+
+	c := &ExportCommand{
+		BaseCommand: baseCommand,
+	}
+	c.init()
+	return c
+}
@@ -0,0 +1,242 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package importer
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+
+	"github.com/posener/complete"
+	helmCLI "helm.sh/helm/v3/pkg/cli"
+	"k8s.io/client-go/dynamic"
+
+	"github.com/hashicorp/consul-k8s/cli/common"
+	"github.com/hashicorp/consul-k8s/cli/common/flag"
+	"github.com/hashicorp/consul-k8s/cli/common/terminal"
+	"github.com/hashicorp/consul-k8s/cli/configentries"
+)
+
+const (
+	flagNameDir         = "dir"
+	flagNameNamespace   = "namespace"
+	flagNameDryRun      = "dry-run"
+	flagNameAutoApprove = "auto-approve"
+	flagNameKubeConfig  = "kubeconfig"
+	flagNameKubeContext = "context"
+)
+
+// ImportCommand applies the custom resources that manage Consul config entries from a directory of YAML files.
+type ImportCommand struct {
+	*common.BaseCommand
+
+	dynamic dynamic.Interface
+
+	set *flag.Sets
+
+	flagDir         string
+	flagNamespace   string
+	flagDryRun      bool
+	flagAutoApprove bool
+	flagKubeConfig  string
+	flagKubeContext string
+
+	once sync.Once
+	help string
+}
+
+func (c *ImportCommand) init() {
+	c.set = flag.NewSets()
+
+	f := c.set.NewSet("Command Options")
+	f.StringVar(&flag.StringVar{
+		Name:    flagNameDir,
+		Aliases: []string{"d"},
+		Target:  &c.flagDir,
+		Default: "",
+		Usage:   "The directory to read custom resources from, e.g. one written by 'consul-k8s config export'.",
+	})
+	f.StringVar(&flag.StringVar{
+		Name:    flagNameNamespace,
+		Aliases: []string{"n"},
+		Target:  &c.flagNamespace,
+		Default: "",
+		Usage:   "The Kubernetes namespace to import all custom resources to. Defaults to the namespace of each resource.",
+	})
+	f.BoolVar(&flag.BoolVar{
+		Name:    flagNameDryRun,
+		Target:  &c.flagDryRun,
+		Default: false,
+		Usage:   "Validate the custom resources with the Kubernetes API server without applying them.",
+	})
+	f.BoolVar(&flag.BoolVar{
+		Name:    flagNameAutoApprove,
+		Target:  &c.flagAutoApprove,
+		Default: false,
+		Usage:   "Skip confirmation prompt.",
+	})
+
+	f = c.set.NewSet("Global Options")
+	f.StringVar(&flag.StringVar{
+		Name:    flagNameKubeConfig,
+		Aliases: []string{"c"},
+		Target:  &c.flagKubeConfig,
+		Default: "",
+		Usage:   "Path to kubeconfig file.",
+	})
+	f.StringVar(&flag.StringVar{
+		Name:    flagNameKubeContext,
+		Target:  &c.flagKubeContext,
+		Default: "",
+		Usage:   "Kubernetes context to use.",
+	})
+
+	c.help = c.set.Help()
+}
+
+// Run imports the custom resources that manage Consul config entries.
+func (c *ImportCommand) Run(args []string) int {
+	c.once.Do(c.init)
+
+	c.Log.ResetNamed("config import")
+	defer common.CloseWithError(c.BaseCommand)
+
+	if err := c.set.Parse(args); err != nil {
+		c.UI.Output(err.Error())
+		return 1
+	}
+
+	if err := c.validateFlags(); err != nil {
+		c.UI.Output(err.Error())
+		return 1
+	}
+
+	crs, skipped, err := configentries.ReadDir(c.flagDir)
+	if err != nil {
+		c.UI.Output(fmt.Sprintf("error reading custom resources: %s", err), terminal.WithErrorStyle())
+		return 1
+	}
+
+	c.UI.Output("Importing Consul config entries", terminal.WithHeaderStyle())
+	for _, s := range skipped {
+		c.UI.Output("Skipping %s: not a Consul config entry custom resource", s, terminal.WithWarningStyle())
+	}
+	if len(crs) == 0 {
+		c.UI.Output("No config entry custom resources found in %s.", c.flagDir, terminal.WithInfoStyle())
+		return 0
+	}
+	c.UI.Output("Found %d custom resources in %s.", len(crs), c.flagDir, terminal.WithInfoStyle())
+
+	// helmCLI.New() will create a settings object which is used to target the right cluster.
+	settings := helmCLI.New()
+	if c.flagKubeConfig != "" {
+		settings.KubeConfig = c.flagKubeConfig
+	}
+	if c.flagKubeContext != "" {
+		settings.KubeContext = c.flagKubeContext
+	}
+
+	if err := c.setupKubeClient(settings); err != nil {
+		c.UI.Output(err.Error(), terminal.WithErrorStyle())
+		return 1
+	}
+
+	if !c.flagDryRun && !c.flagAutoApprove {
+		confirmation, err := c.UI.Input(&terminal.Input{
+			Prompt: "Proceed with creating or updating the custom resources? (y/N)",
+			Style:  terminal.InfoStyle,
+			Secret: false,
+		})
+		if err != nil {
+			c.UI.Output(err.Error(), terminal.WithErrorStyle())
+			return 1
+		}
+		if common.Abort(confirmation) {
+			c.UI.Output("Import aborted. No custom resources were applied.", terminal.WithInfoStyle())
+			return 1
+		}
+	}
+
+	failed := 0
+	for _, cr := range crs {
+		result, err := configentries.Apply(c.Ctx, c.dynamic, cr, c.flagNamespace, c.flagDryRun)
+		if err != nil {
+			failed++
+			c.UI.Output("error applying %s %q: %s", cr.GetKind(), cr.GetName(), err, terminal.WithErrorStyle())
+			continue
+		}
+		if c.flagDryRun {
+			result += " (dry run)"
+		}
+		c.UI.Output("%s %q %s", cr.GetKind(), cr.GetName(), result, terminal.WithInfoStyle())
+	}
+
+	if failed > 0 {
+		c.UI.Output("Failed to apply %d of %d custom resources.", failed, len(crs), terminal.WithErrorStyle())
+		return 1
+	}
+	c.UI.Output("Imported %d custom resources.", len(crs), terminal.WithSuccessStyle())
+
+	return 0
+}
+
+// validateFlags checks the command line flags and values for errors.
+func (c *ImportCommand) validateFlags() error {
+	if len(c.set.Args()) > 0 {
+		return errors.New("should have no non-flag arguments")
+	}
+	if c.flagDir == "" {
+		return fmt.Errorf("-%s must be set", flagNameDir)
+	}
+	return nil
+}
+
+// setupKubeClient creates the Kubernetes client used to apply custom resources unless one has
+// already been set.
+func (c *ImportCommand) setupKubeClient(settings *helmCLI.EnvSettings) error {
+	if c.dynamic == nil {
+		restConfig, err := settings.RESTClientGetter().ToRESTConfig()
+		if err != nil {
+			return fmt.Errorf("error retrieving Kubernetes authentication: %v", err)
+		}
+		c.dynamic, err = dynamic.NewForConfig(restConfig)
+		if err != nil {
+			return fmt.Errorf("error initializing Kubernetes client: %v", err)
+		}
+	}
+
+	return nil
+}
+
+// AutocompleteFlags returns a mapping of supported flags and autocomplete
+// options for this command. The map key for the Flags map should be the
+// complete flag such as "-foo" or "--foo".
+func (c *ImportCommand) AutocompleteFlags() complete.Flags {
+	return complete.Flags{
+		fmt.Sprintf("-%s", flagNameDir):         complete.PredictDirs("*"),
+		fmt.Sprintf("-%s", flagNameNamespace):   complete.PredictNothing,
+		fmt.Sprintf("-%s", flagNameDryRun):      complete.PredictNothing,
+		fmt.Sprintf("-%s", flagNameAutoApprove): complete.PredictNothing,
+		fmt.Sprintf("-%s", flagNameKubeConfig):  complete.PredictFiles("*"),
+		fmt.Sprintf("-%s", flagNameKubeContext): complete.PredictNothing,
+	}
+}
+
+// AutocompleteArgs returns the argument predictor for this command.
+// Since argument completion is not supported, this will return
+// complete.PredictNothing.
+func (c *ImportCommand) AutocompleteArgs() complete.Predictor {
+	return complete.PredictNothing
+}
+
+// Help returns a description of the command and how it is used.
+func (c *ImportCommand) Help() string {
+	c.once.Do(c.init)
+	return c.Synopsis() + "\n\nUsage: consul-k8s config import -dir <directory> [flags]\n\n" + c.help
+}
+
+// Synopsis returns a one-line command summary.
+func (c *ImportCommand) Synopsis() string {
+	return "Import the custom resources that manage Consul config entries from a directory of YAML files."
+}
@@ -0,0 +1,137 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package importer
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/hashicorp/go-hclog"
+	"github.com/stretchr/testify/require"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	dynamicFake "k8s.io/client-go/dynamic/fake"
+
+	"github.com/hashicorp/consul-k8s/cli/common"
+	"github.com/hashicorp/consul-k8s/cli/common/terminal"
+	"github.com/hashicorp/consul-k8s/cli/configentries"
+)
+
+const serviceDefaults = `apiVersion: consul.hashicorp.com/v1alpha1
+kind: ServiceDefaults
+metadata:
+  name: web
+  namespace: default
+spec:
+  protocol: http
+`
+
+func TestImport(t *testing.T) {
+	cases := map[string]struct {
+		input              []string
+		files              map[string]string
+		expNamespace       string
+		messages           []string
+		expectedReturnCode int
+	}{
+		"imports custom resources": {
+			input:        []string{"-auto-approve"},
+			files:        map[string]string{"default/servicedefaults-web.yaml": serviceDefaults},
+			expNamespace: "default",
+			messages:     []string{`ServiceDefaults "web" created`, "Imported 1 custom resources."},
+		},
+		"imports to namespace": {
+			input:        []string{"-auto-approve", "-namespace", "consul"},
+			files:        map[string]string{"default/servicedefaults-web.yaml": serviceDefaults},
+			expNamespace: "consul",
+			messages:     []string{`ServiceDefaults "web" created`},
+		},
+		"skips other resources": {
+			input: []string{"-auto-approve"},
+			files: map[string]string{"configmap.yaml": "apiVersion: v1\nkind: ConfigMap\nmetadata:\n  name: config\n"},
+			messages: []string{
+				"configmap.yaml (ConfigMap): not a Consul config entry custom resource",
+				"No config entry custom resources found",
+			},
+		},
+		"invalid yaml": {
+			files:              map[string]string{"invalid.yaml": "kind: [ServiceDefaults"},
+			messages:           []string{"error reading custom resources"},
+			expectedReturnCode: 1,
+		},
+	}
+
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			dir := t.TempDir()
+			for file, contents := range tc.files {
+				path := filepath.Join(dir, file)
+				require.NoError(t, os.MkdirAll(filepath.Dir(path), 0755))
+				require.NoError(t, os.WriteFile(path, []byte(contents), 0644))
+			}
+			buf := new(bytes.Buffer)
+			c := getInitializedCommand(t, buf)
+			client := newFakeClient()
+			c.dynamic = client
+
+			returnCode := c.Run(append(tc.input, "-dir", dir))
+			require.Equal(t, tc.expectedReturnCode, returnCode)
+			output := buf.String()
+			for _, msg := range tc.messages {
+				require.Contains(t, output, msg)
+			}
+			if tc.expNamespace != "" {
+				gvr, _ := configentries.GroupVersionResource("ServiceDefaults")
+				_, err := client.Resource(gvr).Namespace(tc.expNamespace).Get(context.Background(), "web", metav1.GetOptions{})
+				require.NoError(t, err)
+			}
+		})
+	}
+}
+
+func TestImport_MissingDir(t *testing.T) {
+	buf := new(bytes.Buffer)
+	c := getInitializedCommand(t, buf)
+	require.Equal(t, 1, c.Run(nil))
+	require.Contains(t, buf.String(), "-dir must be set")
+}
+
+func newFakeClient() *dynamicFake.FakeDynamicClient {
+	gvrToListKind := make(map[schema.GroupVersionResource]string)
+	for _, kind := range configentries.Kinds() {
+		gvr, _ := configentries.GroupVersionResource(kind)
+		gvrToListKind[gvr] = kind + "List"
+	}
+	return dynamicFake.NewSimpleDynamicClientWithCustomListKinds(runtime.NewScheme(), gvrToListKind)
+}
+
+func getInitializedCommand(t *testing.T, buf io.Writer) *ImportCommand {
+	t.Helper()
+	log := hclog.New(&hclog.LoggerOptions{
+		Name:   "cli",
+		Level:  hclog.Info,
+		Output: os.Stdout,
+	})
+	var ui terminal.UI
+	if buf != nil {
+		ui = terminal.NewUI(context.Background(), buf)
+	} else {
+		ui = terminal.NewBasicUI(context.Background())
+	}
+	baseCommand := &common.BaseCommand{
+		Log: log,
+		UI:  ui,
+	}
+
+	c := &ImportCommand{
+		BaseCommand: baseCommand,
+	}
+	c.init()
+	return c
+}
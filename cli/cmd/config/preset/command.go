@@ -0,0 +1,29 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package preset
+
+import (
+	"fmt"
+
+	"github.com/hashicorp/consul-k8s/cli/common"
+	"github.com/mitchellh/cli"
+)
+
+// PresetCommand provides a synopsis for the preset subcommands (e.g. show).
+type PresetCommand struct {
+	*common.BaseCommand
+}
+
+// Run prints out information about the subcommands.
+func (c *PresetCommand) Run([]string) int {
+	return cli.RunResultHelp
+}
+
+func (c *PresetCommand) Help() string {
+	return fmt.Sprintf("%s\n\nUsage: consul-k8s config preset <subcommand>", c.Synopsis())
+}
+
+func (c *PresetCommand) Synopsis() string {
+	return "Inspect installation presets"
+}
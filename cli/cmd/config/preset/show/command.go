@@ -0,0 +1,122 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package show
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+	"sync"
+
+	"github.com/posener/complete"
+	"sigs.k8s.io/yaml"
+
+	"github.com/hashicorp/consul-k8s/cli/common"
+	"github.com/hashicorp/consul-k8s/cli/common/flag"
+	"github.com/hashicorp/consul-k8s/cli/common/terminal"
+	"github.com/hashicorp/consul-k8s/cli/preset"
+)
+
+// ShowCommand prints the Helm values of an installation preset.
+type ShowCommand struct {
+	*common.BaseCommand
+
+	set *flag.Sets
+
+	flagPresetName string
+
+	once sync.Once
+	help string
+}
+
+func (c *ShowCommand) init() {
+	c.set = flag.NewSets()
+	c.help = c.set.Help()
+}
+
+// Run prints the values of the preset.
+func (c *ShowCommand) Run(args []string) int {
+	c.once.Do(c.init)
+
+	c.Log.ResetNamed("config preset show")
+	defer common.CloseWithError(c.BaseCommand)
+
+	if err := c.parseFlags(args); err != nil {
+		c.UI.Output(err.Error(), terminal.WithErrorStyle())
+		c.UI.Output("\n" + c.Help())
+		return 1
+	}
+
+	values, err := c.presetValues()
+	if err != nil {
+		c.UI.Output(err.Error(), terminal.WithErrorStyle())
+		return 1
+	}
+	out, err := yaml.Marshal(values)
+	if err != nil {
+		c.UI.Output(fmt.Sprintf("error marshaling the values of the %s preset: %s", c.flagPresetName, err), terminal.WithErrorStyle())
+		return 1
+	}
+	c.UI.Output(strings.TrimSpace(string(out)))
+	return 0
+}
+
+func (c *ShowCommand) parseFlags(args []string) error {
+	// Separate positional arguments from keyed arguments.
+	positional := []string{}
+	for _, arg := range args {
+		if strings.HasPrefix(arg, "-") {
+			break
+		}
+		positional = append(positional, arg)
+	}
+	keyed := args[len(positional):]
+
+	if len(positional) != 1 {
+		return fmt.Errorf("Exactly one positional argument is required: <preset-name>")
+	}
+	c.flagPresetName = positional[0]
+
+	return c.set.Parse(keyed)
+}
+
+// presetValues returns the values of the preset. The values of the cloud preset are fetched from HCP
+// during the installation, so they can't be shown.
+func (c *ShowCommand) presetValues() (map[string]interface{}, error) {
+	if !slices.Contains(preset.Presets, c.flagPresetName) {
+		return nil, fmt.Errorf("'%s' is not a valid preset (valid presets: %s)", c.flagPresetName, strings.Join(preset.Presets, ", "))
+	}
+	if c.flagPresetName == preset.PresetCloud {
+		return nil, fmt.Errorf("the values of the '%s' preset are fetched from HCP during the installation and can't be shown", preset.PresetCloud)
+	}
+	p, err := preset.GetPreset(&preset.GetPresetConfig{Name: c.flagPresetName})
+	if err != nil {
+		return nil, err
+	}
+	return p.GetValueMap()
+}
+
+func (c *ShowCommand) Help() string {
+	c.once.Do(c.init)
+	return fmt.Sprintf("%s\n\nUsage: consul-k8s config preset show <preset-name>\n\n"+
+		"Presets: %s\n\n"+
+		"The values of a preset can be overridden with the -f and -set flags of the install and upgrade commands.%s",
+		c.Synopsis(), strings.Join(preset.Presets, ", "), c.help)
+}
+
+func (c *ShowCommand) Synopsis() string {
+	return "Print the Helm values of an installation preset."
+}
+
+// AutocompleteFlags returns a mapping of supported flags and autocomplete
+// options for this command. The map key for the Flags map should be the
+// complete flag such as "-foo" or "--foo".
+func (c *ShowCommand) AutocompleteFlags() complete.Flags {
+	return complete.Flags{}
+}
+
+// AutocompleteArgs returns the argument predictor for this command.
+func (c *ShowCommand) AutocompleteArgs() complete.Predictor {
+	return complete.PredictSet(preset.Presets...)
+}
@@ -0,0 +1,87 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package show
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"os"
+	"testing"
+
+	"github.com/hashicorp/go-hclog"
+	"github.com/stretchr/testify/require"
+	"sigs.k8s.io/yaml"
+
+	"github.com/hashicorp/consul-k8s/cli/common"
+	"github.com/hashicorp/consul-k8s/cli/common/terminal"
+)
+
+func TestShowCommand(t *testing.T) {
+	buf := new(bytes.Buffer)
+	c := setupCommand(buf)
+
+	out := c.Run([]string{"multi-dc-primary"})
+	require.Equal(t, 0, out, buf.String())
+
+	var values map[string]interface{}
+	require.NoError(t, yaml.Unmarshal(buf.Bytes(), &values))
+	global := values["global"].(map[string]interface{})
+	require.Equal(t, true, global["federation"].(map[string]interface{})["createFederationSecret"])
+	require.Equal(t, true, global["acls"].(map[string]interface{})["createReplicationToken"])
+	require.Equal(t, true, values["meshGateway"].(map[string]interface{})["enabled"])
+}
+
+func TestShowCommand_Errors(t *testing.T) {
+	cases := map[string]struct {
+		args   []string
+		expErr string
+	}{
+		"no preset": {
+			args:   []string{},
+			expErr: "Exactly one positional argument is required: <preset-name>",
+		},
+		"multiple presets": {
+			args:   []string{"secure", "demo"},
+			expErr: "Exactly one positional argument is required: <preset-name>",
+		},
+		"invalid preset": {
+			args:   []string{"foo"},
+			expErr: "'foo' is not a valid preset (valid presets: cloud, demo, multi-dc-primary, quickstart, secure)",
+		},
+		"cloud preset": {
+			args:   []string{"cloud"},
+			expErr: "the values of the 'cloud' preset are fetched from HCP during the installation and can't be shown",
+		},
+	}
+
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			buf := new(bytes.Buffer)
+			c := setupCommand(buf)
+			require.Equal(t, 1, c.Run(tc.args))
+			require.Contains(t, buf.String(), tc.expErr)
+		})
+	}
+}
+
+func setupCommand(buf io.Writer) *ShowCommand {
+	// Log at a test level to standard out.
+	log := hclog.New(&hclog.LoggerOptions{
+		Name:   "test",
+		Level:  hclog.Debug,
+		Output: os.Stdout,
+	})
+
+	// Setup and initialize the command struct
+	command := &ShowCommand{
+		BaseCommand: &common.BaseCommand{
+			Log: log,
+			UI:  terminal.NewUI(context.Background(), buf),
+		},
+	}
+	command.init()
+
+	return command
+}
@@ -0,0 +1,29 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package debug
+
+import (
+	"fmt"
+
+	"github.com/hashicorp/consul-k8s/cli/common"
+	"github.com/mitchellh/cli"
+)
+
+// DebugCommand provides a synopsis for the debug subcommands (e.g. profile).
+type DebugCommand struct {
+	*common.BaseCommand
+}
+
+// Run prints out information about the subcommands.
+func (c *DebugCommand) Run([]string) int {
+	return cli.RunResultHelp
+}
+
+func (c *DebugCommand) Help() string {
+	return fmt.Sprintf("%s\n\nUsage: consul-k8s debug <subcommand>", c.Synopsis())
+}
+
+func (c *DebugCommand) Synopsis() string {
+	return "Collect diagnostics from the Consul on Kubernetes control plane."
+}
@@ -0,0 +1,298 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package profile
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"github.com/posener/complete"
+	helmCLI "helm.sh/helm/v3/pkg/cli"
+	"k8s.io/apimachinery/pkg/api/validation"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/rest"
+
+	"github.com/hashicorp/consul-k8s/cli/common"
+	"github.com/hashicorp/consul-k8s/cli/common/flag"
+	"github.com/hashicorp/consul-k8s/cli/common/terminal"
+)
+
+// debugPort is the port where the connect injector serves its pprof and runtime
+// diagnostics endpoints when it is run with -enable-pprof.
+const debugPort = 9446
+
+const (
+	flagNameNamespace   = "namespace"
+	flagNameSeconds     = "seconds"
+	flagNameOutputDir   = "output-dir"
+	flagNameKubeConfig  = "kubeconfig"
+	flagNameKubeContext = "context"
+)
+
+// profile is a file captured from the diagnostics endpoints.
+type profile struct {
+	// name describes the profile in the output.
+	name string
+	// path is the path of the endpoint, relative to the diagnostics port.
+	path string
+	// suffix is appended to the name of the pod to get the name of the file.
+	suffix string
+}
+
+type ProfileCommand struct {
+	*common.BaseCommand
+
+	kubernetes kubernetes.Interface
+
+	set *flag.Sets
+
+	// Command Flags
+	flagNamespace string
+	flagPodName   string
+	flagSeconds   int
+	flagOutputDir string
+
+	// Global Flags
+	flagKubeConfig  string
+	flagKubeContext string
+
+	// portForward is used to reach the diagnostics endpoints of the pod. It is set in tests.
+	portForward common.PortForwarder
+
+	restConfig *rest.Config
+
+	once sync.Once
+	help string
+}
+
+func (c *ProfileCommand) init() {
+	c.set = flag.NewSets()
+	f := c.set.NewSet("Command Options")
+	f.StringVar(&flag.StringVar{
+		Name:    flagNameNamespace,
+		Target:  &c.flagNamespace,
+		Usage:   "The namespace where the target Pod can be found.",
+		Aliases: []string{"n"},
+	})
+	f.IntVar(&flag.IntVar{
+		Name:    flagNameSeconds,
+		Target:  &c.flagSeconds,
+		Usage:   "The number of seconds to capture the CPU profile for.",
+		Default: 30,
+	})
+	f.StringVar(&flag.StringVar{
+		Name:    flagNameOutputDir,
+		Target:  &c.flagOutputDir,
+		Usage:   "The directory to write the profiles to. It is created if it does not exist.",
+		Default: ".",
+		Aliases: []string{"d"},
+	})
+
+	f = c.set.NewSet("Global Options")
+	f.StringVar(&flag.StringVar{
+		Name:    flagNameKubeConfig,
+		Aliases: []string{"c"},
+		Target:  &c.flagKubeConfig,
+		Usage:   "Set the path to kubeconfig file.",
+	})
+	f.StringVar(&flag.StringVar{
+		Name:   flagNameKubeContext,
+		Target: &c.flagKubeContext,
+		Usage:  "Set the Kubernetes context to use.",
+	})
+
+	c.help = c.set.Help()
+}
+
+func (c *ProfileCommand) Run(args []string) int {
+	c.once.Do(c.init)
+	c.Log.ResetNamed("profile")
+	defer common.CloseWithError(c.BaseCommand)
+
+	if err := c.parseFlags(args); err != nil {
+		c.UI.Output(err.Error(), terminal.WithErrorStyle())
+		c.UI.Output("\n" + c.Help())
+		return 1
+	}
+
+	if err := c.validateFlags(); err != nil {
+		c.UI.Output(err.Error(), terminal.WithErrorStyle())
+		c.UI.Output("\n" + c.Help())
+		return 1
+	}
+
+	if err := c.initKubernetes(); err != nil {
+		c.UI.Output(err.Error(), terminal.WithErrorStyle())
+		return 1
+	}
+
+	if err := os.MkdirAll(c.flagOutputDir, 0o755); err != nil {
+		c.UI.Output(fmt.Sprintf("error creating output directory: %s", err), terminal.WithErrorStyle())
+		return 1
+	}
+
+	if c.portForward == nil {
+		c.portForward = &common.PortForward{
+			Namespace:  c.flagNamespace,
+			PodName:    c.flagPodName,
+			RemotePort: debugPort,
+			KubeClient: c.kubernetes,
+			RestConfig: c.restConfig,
+		}
+	}
+	endpoint, err := c.portForward.Open(c.Ctx)
+	if err != nil {
+		c.UI.Output(fmt.Sprintf("error port forwarding to %s: %s", c.flagPodName, err), terminal.WithErrorStyle())
+		return 1
+	}
+	defer c.portForward.Close()
+
+	profiles := []profile{
+		{name: "runtime statistics", path: "/debug/runtime", suffix: "runtime.json"},
+		{name: "heap profile", path: "/debug/pprof/heap", suffix: "heap.pprof"},
+		{name: "goroutine profile", path: "/debug/pprof/goroutine", suffix: "goroutine.pprof"},
+		{name: fmt.Sprintf("%ds CPU profile", c.flagSeconds), path: fmt.Sprintf("/debug/pprof/profile?seconds=%d", c.flagSeconds), suffix: "cpu.pprof"},
+	}
+	for _, p := range profiles {
+		if p.suffix == "cpu.pprof" {
+			c.UI.Output(fmt.Sprintf("Capturing the %s of %s...", p.name, c.flagPodName), terminal.WithInfoStyle())
+		}
+		file := filepath.Join(c.flagOutputDir, fmt.Sprintf("%s-%s", c.flagPodName, p.suffix))
+		if err := c.capture(c.Ctx, endpoint, p.path, file); err != nil {
+			c.UI.Output(fmt.Sprintf("error capturing the %s of %s: %s", p.name, c.flagPodName, err), terminal.WithErrorStyle())
+			return 1
+		}
+		c.UI.Output(fmt.Sprintf("Wrote the %s to %s", p.name, file), terminal.WithSuccessStyle())
+	}
+
+	c.UI.Output(fmt.Sprintf("Inspect the profiles with `go tool pprof %s`.",
+		filepath.Join(c.flagOutputDir, c.flagPodName+"-cpu.pprof")), terminal.WithInfoStyle())
+	return 0
+}
+
+func (c *ProfileCommand) Help() string {
+	c.once.Do(c.init)
+	return fmt.Sprintf("%s\n\nUsage: consul-k8s debug profile <pod-name> [flags]\n\n"+
+		"The pod must be a connect injector pod run with -enable-pprof, i.e. installed with connectInject.enablePprof=true.\n\n%s",
+		c.Synopsis(), c.help)
+}
+
+func (c *ProfileCommand) Synopsis() string {
+	return "Capture CPU and heap profiles of a connect injector pod."
+}
+
+// AutocompleteFlags returns a mapping of supported flags and autocomplete
+// options for this command. The map key for the Flags map should be the
+// complete flag such as "-foo" or "--foo".
+func (c *ProfileCommand) AutocompleteFlags() complete.Flags {
+	return complete.Flags{
+		fmt.Sprintf("-%s", flagNameNamespace):   complete.PredictNothing,
+		fmt.Sprintf("-%s", flagNameSeconds):     complete.PredictNothing,
+		fmt.Sprintf("-%s", flagNameOutputDir):   complete.PredictDirs("*"),
+		fmt.Sprintf("-%s", flagNameKubeConfig):  complete.PredictFiles("*"),
+		fmt.Sprintf("-%s", flagNameKubeContext): complete.PredictNothing,
+	}
+}
+
+// AutocompleteArgs returns the argument predictor for this command.
+// Since argument completion is not supported, this will return
+// complete.PredictNothing.
+func (c *ProfileCommand) AutocompleteArgs() complete.Predictor {
+	return complete.PredictNothing
+}
+
+func (c *ProfileCommand) parseFlags(args []string) error {
+	// Separate positional arguments from keyed arguments.
+	positional := []string{}
+	for _, arg := range args {
+		if strings.HasPrefix(arg, "-") {
+			break
+		}
+		positional = append(positional, arg)
+	}
+	keyed := args[len(positional):]
+
+	if len(positional) != 1 {
+		return fmt.Errorf("Exactly one positional argument is required: <pod-name>")
+	}
+	c.flagPodName = positional[0]
+
+	return c.set.Parse(keyed)
+}
+
+func (c *ProfileCommand) validateFlags() error {
+	if errs := validation.ValidateNamespaceName(c.flagNamespace, false); c.flagNamespace != "" && len(errs) > 0 {
+		return fmt.Errorf("invalid namespace name passed for -namespace/-n: %v", strings.Join(errs, "; "))
+	}
+	if c.flagSeconds < 1 {
+		return fmt.Errorf("-seconds must be at least 1.")
+	}
+	if c.flagOutputDir == "" {
+		return fmt.Errorf("-output-dir must be set.")
+	}
+	return nil
+}
+
+func (c *ProfileCommand) initKubernetes() (err error) {
+	settings := helmCLI.New()
+
+	if c.flagKubeConfig != "" {
+		settings.KubeConfig = c.flagKubeConfig
+	}
+
+	if c.flagKubeContext != "" {
+		settings.KubeContext = c.flagKubeContext
+	}
+
+	if c.restConfig == nil {
+		if c.restConfig, err = settings.RESTClientGetter().ToRESTConfig(); err != nil {
+			return fmt.Errorf("error creating Kubernetes REST config %v", err)
+		}
+	}
+
+	if c.kubernetes == nil {
+		if c.kubernetes, err = kubernetes.NewForConfig(c.restConfig); err != nil {
+			return fmt.Errorf("error creating Kubernetes client %v", err)
+		}
+	}
+
+	if c.flagNamespace == "" {
+		c.flagNamespace = settings.Namespace()
+	}
+
+	return nil
+}
+
+// capture writes the response of the diagnostics endpoint at path to file.
+func (c *ProfileCommand) capture(ctx context.Context, endpoint, path, file string) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, fmt.Sprintf("http://%s%s", endpoint, path), nil)
+	if err != nil {
+		return err
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("%w; check that the pod is a connect injector run with -enable-pprof", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(resp.Body)
+		return fmt.Errorf("unexpected status %s: %s", resp.Status, strings.TrimSpace(string(body)))
+	}
+
+	out, err := os.Create(file)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(out, resp.Body); err != nil {
+		_ = out.Close()
+		return err
+	}
+	return out.Close()
+}
@@ -0,0 +1,204 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package profile
+
+import (
+	"bytes"
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/posener/complete"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"k8s.io/client-go/kubernetes/fake"
+	"k8s.io/client-go/rest"
+
+	"github.com/hashicorp/consul-k8s/cli/common"
+	cmnFlag "github.com/hashicorp/consul-k8s/cli/common/flag"
+	"github.com/hashicorp/consul-k8s/cli/common/terminal"
+	"github.com/hashicorp/go-hclog"
+)
+
+func TestFlagParsing(t *testing.T) {
+	cases := map[string]struct {
+		args []string
+		out  int
+	}{
+		"No args": {
+			args: []string{},
+			out:  1,
+		},
+		"Multiple podnames passed": {
+			args: []string{"podname", "podname2"},
+			out:  1,
+		},
+		"Nonexistent flag passed, -foo bar": {
+			args: []string{"podName", "-foo", "bar"},
+			out:  1,
+		},
+		"Invalid argument passed, -namespace YOLO": {
+			args: []string{"podName", "-namespace", "YOLO"},
+			out:  1,
+		},
+		"User passed zero seconds": {
+			args: []string{"podName", "-seconds", "0"},
+			out:  1,
+		},
+		"User passed an empty output directory": {
+			args: []string{"podName", "-output-dir", ""},
+			out:  1,
+		},
+	}
+
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			c := setupCommand(new(bytes.Buffer))
+			c.kubernetes = fake.NewSimpleClientset()
+			out := c.Run(tc.args)
+			require.Equal(t, tc.out, out)
+		})
+	}
+}
+
+func TestProfileCommand(t *testing.T) {
+	var requests []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests = append(requests, r.URL.RequestURI())
+		fmt.Fprintf(w, "contents of %s", r.URL.Path)
+	}))
+	defer server.Close()
+
+	outputDir := filepath.Join(t.TempDir(), "profiles")
+	buf := new(bytes.Buffer)
+	c := setupCommand(buf)
+	c.kubernetes = fake.NewSimpleClientset()
+	c.restConfig = &rest.Config{}
+	c.portForward = &mockPortForwarder{endpoint: strings.TrimPrefix(server.URL, "http://")}
+
+	out := c.Run([]string{"consul-connect-injector-abcde", "-n", "consul", "-seconds", "1", "-output-dir", outputDir})
+	require.Equal(t, 0, out, buf.String())
+	require.Equal(t, []string{
+		"/debug/runtime",
+		"/debug/pprof/heap",
+		"/debug/pprof/goroutine",
+		"/debug/pprof/profile?seconds=1",
+	}, requests)
+
+	expFiles := map[string]string{
+		"consul-connect-injector-abcde-runtime.json":    "contents of /debug/runtime",
+		"consul-connect-injector-abcde-heap.pprof":      "contents of /debug/pprof/heap",
+		"consul-connect-injector-abcde-goroutine.pprof": "contents of /debug/pprof/goroutine",
+		"consul-connect-injector-abcde-cpu.pprof":       "contents of /debug/pprof/profile",
+	}
+	for file, expContents := range expFiles {
+		contents, err := os.ReadFile(filepath.Join(outputDir, file))
+		require.NoError(t, err)
+		require.Equal(t, expContents, string(contents))
+		require.Contains(t, buf.String(), filepath.Join(outputDir, file))
+	}
+	require.True(t, c.portForward.(*mockPortForwarder).closed)
+}
+
+func TestProfileCommand_EndpointError(t *testing.T) {
+	server := httptest.NewServer(http.NotFoundHandler())
+	defer server.Close()
+
+	outputDir := t.TempDir()
+	buf := new(bytes.Buffer)
+	c := setupCommand(buf)
+	c.kubernetes = fake.NewSimpleClientset()
+	c.restConfig = &rest.Config{}
+	c.portForward = &mockPortForwarder{endpoint: strings.TrimPrefix(server.URL, "http://")}
+
+	out := c.Run([]string{"consul-connect-injector-abcde", "-output-dir", outputDir})
+	require.Equal(t, 1, out)
+	require.Contains(t, buf.String(), "error capturing the runtime statistics of consul-connect-injector-abcde: unexpected status 404 Not Found")
+
+	files, err := os.ReadDir(outputDir)
+	require.NoError(t, err)
+	require.Empty(t, files)
+}
+
+func TestProfileCommand_PortForwardError(t *testing.T) {
+	buf := new(bytes.Buffer)
+	c := setupCommand(buf)
+	c.kubernetes = fake.NewSimpleClientset()
+	c.restConfig = &rest.Config{}
+	c.portForward = &mockPortForwarder{openErr: fmt.Errorf("pod not found")}
+
+	out := c.Run([]string{"consul-connect-injector-abcde", "-output-dir", t.TempDir()})
+	require.Equal(t, 1, out)
+	require.Contains(t, buf.String(), "error port forwarding to consul-connect-injector-abcde: pod not found")
+}
+
+func TestTaskCreateCommand_AutocompleteFlags(t *testing.T) {
+	t.Parallel()
+	buf := new(bytes.Buffer)
+	cmd := setupCommand(buf)
+
+	predictor := cmd.AutocompleteFlags()
+
+	// Test that we get the expected number of predictions
+	args := complete.Args{Last: "-"}
+	res := predictor.Predict(args)
+
+	// Grab the list of flags from the Flag object
+	flags := make([]string, 0)
+	cmd.set.VisitSets(func(name string, set *cmnFlag.Set) {
+		set.VisitAll(func(flag *flag.Flag) {
+			flags = append(flags, fmt.Sprintf("-%s", flag.Name))
+		})
+	})
+
+	// Verify that there is a prediction for each flag associated with the command
+	assert.Equal(t, len(flags), len(res))
+	assert.ElementsMatch(t, flags, res, "flags and predictions didn't match, make sure to add "+
+		"new flags to the command AutoCompleteFlags function")
+}
+
+func TestTaskCreateCommand_AutocompleteArgs(t *testing.T) {
+	buf := new(bytes.Buffer)
+	cmd := setupCommand(buf)
+	c := cmd.AutocompleteArgs()
+	assert.Equal(t, complete.PredictNothing, c)
+}
+
+func setupCommand(buf io.Writer) *ProfileCommand {
+	// Log at a test level to standard out.
+	log := hclog.New(&hclog.LoggerOptions{
+		Name:   "test",
+		Level:  hclog.Debug,
+		Output: os.Stdout,
+	})
+
+	// Setup and initialize the command struct
+	command := &ProfileCommand{
+		BaseCommand: &common.BaseCommand{
+			Ctx: context.Background(),
+			Log: log,
+			UI:  terminal.NewUI(context.Background(), buf),
+		},
+	}
+	command.init()
+
+	return command
+}
+
+type mockPortForwarder struct {
+	endpoint string
+	openErr  error
+	closed   bool
+}
+
+func (m *mockPortForwarder) Open(context.Context) (string, error) { return m.endpoint, m.openErr }
+func (m *mockPortForwarder) Close()                               { m.closed = true }
+func (m *mockPortForwarder) GetLocalPort() int                    { return 0 }
@@ -0,0 +1,718 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package describe
+
+import (
+	"context"
+	"crypto/x509"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	helmcli "helm.sh/helm/v3/pkg/cli"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/rest"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	gwv1alpha2 "sigs.k8s.io/gateway-api/apis/v1alpha2"
+	gwv1beta1 "sigs.k8s.io/gateway-api/apis/v1beta1"
+
+	"github.com/hashicorp/consul-k8s/cli/common"
+	"github.com/hashicorp/consul-k8s/cli/common/flag"
+	"github.com/hashicorp/consul-k8s/cli/common/terminal"
+)
+
+const (
+	kindAPI  = "api"
+	kindMesh = "mesh"
+
+	outputTable = "table"
+	outputJSON  = "json"
+
+	// maxEvents is the number of the most recent events that are described.
+	maxEvents = 10
+)
+
+type Command struct {
+	*common.BaseCommand
+
+	kubernetes client.Client
+	restConfig *rest.Config
+
+	set *flag.Sets
+
+	flagGatewayKind      string
+	flagGatewayNamespace string
+	flagKubeConfig       string
+	flagKubeContext      string
+	flagOutput           string
+
+	gatewayName string
+
+	initOnce sync.Once
+	help     string
+}
+
+// description is a consolidated view of a gateway.
+type description struct {
+	Kind         string        `json:"kind"`
+	Name         string        `json:"name"`
+	Namespace    string        `json:"namespace"`
+	GatewayClass string        `json:"gatewayClass,omitempty"`
+	Addresses    []string      `json:"addresses,omitempty"`
+	Listeners    []listener    `json:"listeners"`
+	Routes       []route       `json:"routes,omitempty"`
+	Certificates []certificate `json:"certificates,omitempty"`
+	Deployment   *deployment   `json:"deployment,omitempty"`
+	Pods         []pod         `json:"pods,omitempty"`
+	Service      *service      `json:"service,omitempty"`
+	Events       []event       `json:"events"`
+}
+
+type listener struct {
+	Name           string `json:"name"`
+	Protocol       string `json:"protocol"`
+	Port           int32  `json:"port"`
+	Hostname       string `json:"hostname,omitempty"`
+	AttachedRoutes int32  `json:"attachedRoutes"`
+	Programmed     string `json:"programmed,omitempty"`
+}
+
+type route struct {
+	Kind      string   `json:"kind"`
+	Name      string   `json:"name"`
+	Namespace string   `json:"namespace"`
+	Listener  string   `json:"listener,omitempty"`
+	Hostnames []string `json:"hostnames,omitempty"`
+	Accepted  string   `json:"accepted"`
+}
+
+type certificate struct {
+	Listener  string    `json:"listener"`
+	Secret    string    `json:"secret"`
+	Subject   string    `json:"subject,omitempty"`
+	DNSNames  []string  `json:"dnsNames,omitempty"`
+	NotAfter  time.Time `json:"notAfter,omitempty"`
+	Error     string    `json:"error,omitempty"`
+	namespace string
+}
+
+type deployment struct {
+	Name              string `json:"name"`
+	Replicas          int32  `json:"replicas"`
+	ReadyReplicas     int32  `json:"readyReplicas"`
+	UpdatedReplicas   int32  `json:"updatedReplicas"`
+	AvailableReplicas int32  `json:"availableReplicas"`
+}
+
+type pod struct {
+	Name     string `json:"name"`
+	Phase    string `json:"phase"`
+	Ready    string `json:"ready"`
+	Restarts int32  `json:"restarts"`
+	Node     string `json:"node,omitempty"`
+}
+
+type service struct {
+	Name      string   `json:"name"`
+	Type      string   `json:"type"`
+	ClusterIP string   `json:"clusterIP,omitempty"`
+	Ports     []string `json:"ports,omitempty"`
+	Ingress   []string `json:"ingress,omitempty"`
+}
+
+type event struct {
+	Type     string    `json:"type"`
+	Reason   string    `json:"reason"`
+	Object   string    `json:"object"`
+	Message  string    `json:"message"`
+	Count    int32     `json:"count"`
+	LastSeen time.Time `json:"lastSeen"`
+}
+
+func (c *Command) Help() string {
+	c.initOnce.Do(c.init)
+	return fmt.Sprintf("%s\n\nUsage: consul-k8s gateway describe <gateway-name> [flags]\n\n%s", c.Synopsis(), c.help)
+}
+
+func (c *Command) Synopsis() string {
+	return "Describe the listeners, routes, certificates, workloads and recent events of a given gateway."
+}
+
+// init establishes the flags for Command
+func (c *Command) init() {
+	c.set = flag.NewSets()
+
+	f := c.set.NewSet("Command Options")
+	f.StringVar(&flag.StringVar{
+		Name:    "namespace",
+		Target:  &c.flagGatewayNamespace,
+		Usage:   "The Kubernetes namespace of the gateway to describe.",
+		Aliases: []string{"n"},
+	})
+	f.StringVar(&flag.StringVar{
+		Name:    "kind",
+		Target:  &c.flagGatewayKind,
+		Usage:   "The kind of the gateway: 'api' for the name of an API Gateway, or 'mesh' for the name of the Deployment of a mesh gateway.",
+		Default: kindAPI,
+	})
+	f.StringVar(&flag.StringVar{
+		Name:    "output",
+		Target:  &c.flagOutput,
+		Usage:   "Output the description as 'table' or 'json'.",
+		Default: outputTable,
+		Aliases: []string{"o"},
+	})
+
+	f = c.set.NewSet("Global Options")
+	f.StringVar(&flag.StringVar{
+		Name:    "kubeconfig",
+		Aliases: []string{"c"},
+		Target:  &c.flagKubeConfig,
+		Usage:   "Set the path to kubeconfig file.",
+	})
+	f.StringVar(&flag.StringVar{
+		Name:   "context",
+		Target: &c.flagKubeContext,
+		Usage:  "Set the Kubernetes context to use.",
+	})
+
+	c.help = c.set.Help()
+}
+
+// Run runs the command
+func (c *Command) Run(args []string) int {
+	c.initOnce.Do(c.init)
+	c.Log.ResetNamed("describe")
+	defer common.CloseWithError(c.BaseCommand)
+
+	if len(args) < 1 || strings.HasPrefix(args[0], "-") {
+		c.UI.Output("Usage: gateway describe <gateway-name>")
+		return 1
+	}
+
+	if err := c.set.Parse(args[1:]); err != nil {
+		c.UI.Output(err.Error(), terminal.WithErrorStyle())
+		return 1
+	}
+	if err := c.validateFlags(); err != nil {
+		c.UI.Output(err.Error(), terminal.WithErrorStyle())
+		return 1
+	}
+
+	c.gatewayName = args[0]
+
+	if err := c.initKubernetes(); err != nil {
+		c.UI.Output(err.Error(), terminal.WithErrorStyle())
+		return 1
+	}
+
+	var (
+		desc *description
+		err  error
+	)
+	if c.flagGatewayKind == kindMesh {
+		desc, err = c.describeMeshGateway(c.Ctx)
+	} else {
+		desc, err = c.describeAPIGateway(c.Ctx)
+	}
+	if err != nil {
+		c.UI.Output(err.Error(), terminal.WithErrorStyle())
+		return 1
+	}
+
+	if c.flagOutput == outputJSON {
+		output, err := json.MarshalIndent(desc, "", "\t")
+		if err != nil {
+			c.UI.Output(fmt.Sprintf("error writing description as JSON: %s", err), terminal.WithErrorStyle())
+			return 1
+		}
+		c.UI.Output(string(output))
+		return 0
+	}
+	c.output(desc)
+	return 0
+}
+
+func (c *Command) validateFlags() error {
+	if len(c.set.Args()) > 0 {
+		return errors.New("should have exactly one gateway name")
+	}
+	if c.flagGatewayKind != kindAPI && c.flagGatewayKind != kindMesh {
+		return fmt.Errorf("-kind must be either %q or %q", kindAPI, kindMesh)
+	}
+	if c.flagOutput != outputTable && c.flagOutput != outputJSON {
+		return fmt.Errorf("-output must be either %q or %q", outputTable, outputJSON)
+	}
+	return nil
+}
+
+// describeAPIGateway describes the API Gateway named gatewayName along with the routes attached to it,
+// the certificates of its listeners and the Deployment and Service the API Gateway controller created for it.
+func (c *Command) describeAPIGateway(ctx context.Context) (*description, error) {
+	var gateway gwv1beta1.Gateway
+	if err := c.kubernetes.Get(ctx, client.ObjectKey{Namespace: c.flagGatewayNamespace, Name: c.gatewayName}, &gateway); err != nil {
+		return nil, fmt.Errorf("error fetching Gateway %s/%s: %w", c.flagGatewayNamespace, c.gatewayName, err)
+	}
+
+	desc := &description{
+		Kind:         kindAPI,
+		Name:         gateway.Name,
+		Namespace:    gateway.Namespace,
+		GatewayClass: string(gateway.Spec.GatewayClassName),
+	}
+	for _, address := range gateway.Status.Addresses {
+		desc.Addresses = append(desc.Addresses, address.Value)
+	}
+
+	listenerStatuses := make(map[gwv1beta1.SectionName]gwv1beta1.ListenerStatus)
+	for _, status := range gateway.Status.Listeners {
+		listenerStatuses[status.Name] = status
+	}
+	for _, l := range gateway.Spec.Listeners {
+		desc.Listeners = append(desc.Listeners, listener{
+			Name:           string(l.Name),
+			Protocol:       string(l.Protocol),
+			Port:           int32(l.Port),
+			Hostname:       hostname(l.Hostname),
+			AttachedRoutes: listenerStatuses[l.Name].AttachedRoutes,
+			Programmed:     conditionStatus(listenerStatuses[l.Name].Conditions, "Programmed"),
+		})
+		if l.TLS == nil {
+			continue
+		}
+		for _, ref := range l.TLS.CertificateRefs {
+			if ref.Kind != nil && *ref.Kind != "Secret" {
+				continue
+			}
+			cert := certificate{Listener: string(l.Name), Secret: string(ref.Name), namespace: gateway.Namespace}
+			if ref.Namespace != nil {
+				cert.namespace = string(*ref.Namespace)
+				cert.Secret = fmt.Sprintf("%s/%s", cert.namespace, ref.Name)
+			}
+			c.resolveCertificate(ctx, &cert, string(ref.Name))
+			desc.Certificates = append(desc.Certificates, cert)
+		}
+	}
+
+	var httpRoutes gwv1beta1.HTTPRouteList
+	if err := c.kubernetes.List(ctx, &httpRoutes); err != nil {
+		return nil, fmt.Errorf("error fetching HTTPRoutes: %w", err)
+	}
+	for _, r := range httpRoutes.Items {
+		desc.Routes = append(desc.Routes, attachedRoutes(gateway, "HTTPRoute", r.ObjectMeta, r.Spec.CommonRouteSpec, r.Spec.Hostnames, r.Status.RouteStatus)...)
+	}
+	var tcpRoutes gwv1alpha2.TCPRouteList
+	if err := c.kubernetes.List(ctx, &tcpRoutes); err != nil {
+		return nil, fmt.Errorf("error fetching TCPRoutes: %w", err)
+	}
+	for _, r := range tcpRoutes.Items {
+		desc.Routes = append(desc.Routes, attachedRoutes(gateway, "TCPRoute", r.ObjectMeta, r.Spec.CommonRouteSpec, nil, r.Status.RouteStatus)...)
+	}
+
+	// The API Gateway controller names the Deployment and Service of a Gateway after it.
+	objects := []string{"Gateway/" + gateway.Name}
+	workloadObjects, _, err := c.describeWorkload(ctx, desc, gateway.Name)
+	if err != nil {
+		return nil, err
+	}
+	if err := c.describeEvents(ctx, desc, append(objects, workloadObjects...)); err != nil {
+		return nil, err
+	}
+	return desc, nil
+}
+
+// describeMeshGateway describes the mesh gateway whose Deployment is named gatewayName. Its listeners
+// are the ports of the Service with the same name.
+func (c *Command) describeMeshGateway(ctx context.Context) (*description, error) {
+	desc := &description{
+		Kind:      kindMesh,
+		Name:      c.gatewayName,
+		Namespace: c.flagGatewayNamespace,
+	}
+	objects, svc, err := c.describeWorkload(ctx, desc, c.gatewayName)
+	if err != nil {
+		return nil, err
+	}
+	if desc.Deployment == nil {
+		return nil, fmt.Errorf("error fetching Deployment %s/%s: not found", desc.Namespace, desc.Name)
+	}
+	desc.Listeners = []listener{}
+	if svc != nil {
+		desc.Addresses = desc.Service.Ingress
+		for _, port := range svc.Spec.Ports {
+			desc.Listeners = append(desc.Listeners, listener{
+				Name:     port.Name,
+				Protocol: string(port.Protocol),
+				Port:     port.Port,
+			})
+		}
+	}
+	if err := c.describeEvents(ctx, desc, objects); err != nil {
+		return nil, err
+	}
+	return desc, nil
+}
+
+// describeWorkload describes the Deployment, its pods, and the Service named name. It returns the
+// involved objects of the events of the workload, formatted as kind/name, and the Service if it exists.
+func (c *Command) describeWorkload(ctx context.Context, desc *description, name string) ([]string, *corev1.Service, error) {
+	var objects []string
+
+	var d appsv1.Deployment
+	err := c.kubernetes.Get(ctx, client.ObjectKey{Namespace: desc.Namespace, Name: name}, &d)
+	if err != nil && !k8serrors.IsNotFound(err) {
+		return nil, nil, fmt.Errorf("error fetching Deployment %s/%s: %w", desc.Namespace, name, err)
+	}
+	if err == nil {
+		objects = append(objects, "Deployment/"+d.Name)
+		desc.Deployment = &deployment{
+			Name:              d.Name,
+			ReadyReplicas:     d.Status.ReadyReplicas,
+			UpdatedReplicas:   d.Status.UpdatedReplicas,
+			AvailableReplicas: d.Status.AvailableReplicas,
+		}
+		if d.Spec.Replicas != nil {
+			desc.Deployment.Replicas = *d.Spec.Replicas
+		}
+
+		selector, err := metav1.LabelSelectorAsSelector(d.Spec.Selector)
+		if err != nil {
+			return nil, nil, fmt.Errorf("error parsing the selector of Deployment %s/%s: %w", d.Namespace, d.Name, err)
+		}
+		var pods corev1.PodList
+		if err := c.kubernetes.List(ctx, &pods, client.InNamespace(d.Namespace), client.MatchingLabelsSelector{Selector: selector}); err != nil {
+			return nil, nil, fmt.Errorf("error fetching pods of Deployment %s/%s: %w", d.Namespace, d.Name, err)
+		}
+		for _, p := range pods.Items {
+			objects = append(objects, "Pod/"+p.Name)
+			desc.Pods = append(desc.Pods, describePod(p))
+		}
+		sort.Slice(desc.Pods, func(i, j int) bool { return desc.Pods[i].Name < desc.Pods[j].Name })
+	}
+
+	var svc corev1.Service
+	err = c.kubernetes.Get(ctx, client.ObjectKey{Namespace: desc.Namespace, Name: name}, &svc)
+	if err != nil && !k8serrors.IsNotFound(err) {
+		return nil, nil, fmt.Errorf("error fetching Service %s/%s: %w", desc.Namespace, name, err)
+	}
+	if k8serrors.IsNotFound(err) {
+		return objects, nil, nil
+	}
+	objects = append(objects, "Service/"+svc.Name)
+	desc.Service = &service{
+		Name:      svc.Name,
+		Type:      string(svc.Spec.Type),
+		ClusterIP: svc.Spec.ClusterIP,
+	}
+	for _, port := range svc.Spec.Ports {
+		desc.Service.Ports = append(desc.Service.Ports, fmt.Sprintf("%d/%s", port.Port, port.Protocol))
+	}
+	for _, ingress := range svc.Status.LoadBalancer.Ingress {
+		if ingress.Hostname != "" {
+			desc.Service.Ingress = append(desc.Service.Ingress, ingress.Hostname)
+		} else {
+			desc.Service.Ingress = append(desc.Service.Ingress, ingress.IP)
+		}
+	}
+	return objects, &svc, nil
+}
+
+// describeEvents describes the most recent events of the given objects, formatted as kind/name.
+func (c *Command) describeEvents(ctx context.Context, desc *description, objects []string) error {
+	involved := make(map[string]bool, len(objects))
+	for _, object := range objects {
+		involved[object] = true
+	}
+
+	var events corev1.EventList
+	if err := c.kubernetes.List(ctx, &events, client.InNamespace(desc.Namespace)); err != nil {
+		return fmt.Errorf("error fetching events: %w", err)
+	}
+	desc.Events = []event{}
+	for _, e := range events.Items {
+		object := e.InvolvedObject.Kind + "/" + e.InvolvedObject.Name
+		if !involved[object] {
+			continue
+		}
+		desc.Events = append(desc.Events, event{
+			Type:     e.Type,
+			Reason:   e.Reason,
+			Object:   object,
+			Message:  strings.TrimSpace(e.Message),
+			Count:    e.Count,
+			LastSeen: lastSeen(e),
+		})
+	}
+	sort.SliceStable(desc.Events, func(i, j int) bool { return desc.Events[i].LastSeen.After(desc.Events[j].LastSeen) })
+	if len(desc.Events) > maxEvents {
+		desc.Events = desc.Events[:maxEvents]
+	}
+	return nil
+}
+
+// resolveCertificate reads the certificate from the kubernetes.io/tls Secret named name.
+// Errors are recorded in the certificate so that every certificate is described.
+func (c *Command) resolveCertificate(ctx context.Context, cert *certificate, name string) {
+	var secret corev1.Secret
+	if err := c.kubernetes.Get(ctx, client.ObjectKey{Namespace: cert.namespace, Name: name}, &secret); err != nil {
+		cert.Error = fmt.Sprintf("error fetching Secret: %s", err)
+		return
+	}
+	block, _ := pem.Decode(secret.Data[corev1.TLSCertKey])
+	if block == nil {
+		cert.Error = fmt.Sprintf("Secret has no PEM encoded certificate in %s", corev1.TLSCertKey)
+		return
+	}
+	parsed, err := x509.ParseCertificate(block.Bytes)
+	if err != nil {
+		cert.Error = fmt.Sprintf("error parsing certificate: %s", err)
+		return
+	}
+	cert.Subject = parsed.Subject.CommonName
+	cert.DNSNames = parsed.DNSNames
+	cert.NotAfter = parsed.NotAfter
+}
+
+// attachedRoutes returns the route once for each of its parent references to the gateway.
+func attachedRoutes(gateway gwv1beta1.Gateway, kind string, objectMeta metav1.ObjectMeta, spec gwv1beta1.CommonRouteSpec, hostnames []gwv1beta1.Hostname, status gwv1beta1.RouteStatus) []route {
+	var routes []route
+	for _, ref := range spec.ParentRefs {
+		if !referencesGateway(gateway, objectMeta.Namespace, ref) {
+			continue
+		}
+		r := route{
+			Kind:      kind,
+			Name:      objectMeta.Name,
+			Namespace: objectMeta.Namespace,
+			Accepted:  "Unknown",
+		}
+		if ref.SectionName != nil {
+			r.Listener = string(*ref.SectionName)
+		}
+		for _, h := range hostnames {
+			r.Hostnames = append(r.Hostnames, string(h))
+		}
+		for _, parent := range status.Parents {
+			if referencesGateway(gateway, objectMeta.Namespace, parent.ParentRef) && sameSection(ref.SectionName, parent.ParentRef.SectionName) {
+				if accepted := conditionStatus(parent.Conditions, "Accepted"); accepted != "" {
+					r.Accepted = accepted
+				}
+			}
+		}
+		routes = append(routes, r)
+	}
+	return routes
+}
+
+// referencesGateway returns whether the parent reference of a route in routeNamespace is the gateway.
+func referencesGateway(gateway gwv1beta1.Gateway, routeNamespace string, ref gwv1beta1.ParentReference) bool {
+	if ref.Kind != nil && *ref.Kind != "Gateway" {
+		return false
+	}
+	namespace := routeNamespace
+	if ref.Namespace != nil {
+		namespace = string(*ref.Namespace)
+	}
+	return string(ref.Name) == gateway.Name && namespace == gateway.Namespace
+}
+
+func sameSection(a, b *gwv1beta1.SectionName) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+	return *a == *b
+}
+
+func conditionStatus(conditions []metav1.Condition, conditionType string) string {
+	if condition := meta.FindStatusCondition(conditions, conditionType); condition != nil {
+		return string(condition.Status)
+	}
+	return ""
+}
+
+func hostname(h *gwv1beta1.Hostname) string {
+	if h == nil {
+		return ""
+	}
+	return string(*h)
+}
+
+func describePod(p corev1.Pod) pod {
+	var ready int
+	var restarts int32
+	for _, status := range p.Status.ContainerStatuses {
+		if status.Ready {
+			ready++
+		}
+		restarts += status.RestartCount
+	}
+	return pod{
+		Name:     p.Name,
+		Phase:    string(p.Status.Phase),
+		Ready:    fmt.Sprintf("%d/%d", ready, len(p.Spec.Containers)),
+		Restarts: restarts,
+		Node:     p.Spec.NodeName,
+	}
+}
+
+func lastSeen(e corev1.Event) time.Time {
+	switch {
+	case !e.LastTimestamp.IsZero():
+		return e.LastTimestamp.Time
+	case !e.EventTime.IsZero():
+		return e.EventTime.Time
+	default:
+		return e.CreationTimestamp.Time
+	}
+}
+
+// output prints the description in tables.
+func (c *Command) output(desc *description) {
+	c.UI.Output(fmt.Sprintf("%s gateway %s/%s", strings.ToUpper(desc.Kind[:1])+desc.Kind[1:], desc.Namespace, desc.Name), terminal.WithHeaderStyle())
+	if desc.GatewayClass != "" {
+		c.UI.Output("Gateway class: %s", desc.GatewayClass)
+	}
+	if len(desc.Addresses) > 0 {
+		c.UI.Output("Addresses: %s", strings.Join(desc.Addresses, ", "))
+	}
+
+	c.UI.Output("Listeners", terminal.WithHeaderStyle())
+	if len(desc.Listeners) == 0 {
+		c.UI.Output("No listeners.")
+	} else {
+		tbl := terminal.NewTable("Name", "Protocol", "Port", "Hostname", "Attached Routes", "Programmed")
+		for _, l := range desc.Listeners {
+			attached := ""
+			if desc.Kind == kindAPI {
+				attached = strconv.Itoa(int(l.AttachedRoutes))
+			}
+			tbl.AddRow([]string{l.Name, l.Protocol, strconv.Itoa(int(l.Port)), l.Hostname, attached, l.Programmed}, []string{})
+		}
+		c.UI.Table(tbl)
+	}
+
+	if desc.Kind == kindAPI {
+		c.UI.Output("Routes", terminal.WithHeaderStyle())
+		if len(desc.Routes) == 0 {
+			c.UI.Output("No routes attached.")
+		} else {
+			tbl := terminal.NewTable("Kind", "Namespace", "Name", "Listener", "Hostnames", "Accepted")
+			for _, r := range desc.Routes {
+				tbl.AddRow([]string{r.Kind, r.Namespace, r.Name, r.Listener, strings.Join(r.Hostnames, ", "), r.Accepted}, []string{})
+			}
+			c.UI.Table(tbl)
+		}
+
+		c.UI.Output("Certificates", terminal.WithHeaderStyle())
+		if len(desc.Certificates) == 0 {
+			c.UI.Output("No certificates.")
+		} else {
+			tbl := terminal.NewTable("Listener", "Secret", "Subject", "DNS Names", "Expires")
+			for _, cert := range desc.Certificates {
+				if cert.Error != "" {
+					tbl.AddRow([]string{cert.Listener, cert.Secret, cert.Error, "", ""}, []string{"", "", terminal.Red, "", ""})
+					continue
+				}
+				expires, color := cert.NotAfter.Format(time.RFC3339), ""
+				if time.Now().After(cert.NotAfter) {
+					expires, color = expires+" (expired)", terminal.Red
+				}
+				tbl.AddRow([]string{cert.Listener, cert.Secret, cert.Subject, strings.Join(cert.DNSNames, ", "), expires}, []string{"", "", "", "", color})
+			}
+			c.UI.Table(tbl)
+		}
+	}
+
+	c.UI.Output("Deployment", terminal.WithHeaderStyle())
+	if desc.Deployment == nil {
+		c.UI.Output("No Deployment found.")
+	} else {
+		d := desc.Deployment
+		c.UI.Output("%s: %d desired, %d updated, %d ready, %d available", d.Name, d.Replicas, d.UpdatedReplicas, d.ReadyReplicas, d.AvailableReplicas)
+		if len(desc.Pods) > 0 {
+			tbl := terminal.NewTable("Pod", "Phase", "Ready", "Restarts", "Node")
+			for _, p := range desc.Pods {
+				tbl.AddRow([]string{p.Name, p.Phase, p.Ready, strconv.Itoa(int(p.Restarts)), p.Node}, []string{})
+			}
+			c.UI.Table(tbl)
+		}
+	}
+
+	c.UI.Output("Service", terminal.WithHeaderStyle())
+	if desc.Service == nil {
+		c.UI.Output("No Service found.")
+	} else {
+		s := desc.Service
+		c.UI.Output("%s: %s %s, ports %s", s.Name, s.Type, s.ClusterIP, strings.Join(s.Ports, ", "))
+		if len(s.Ingress) > 0 {
+			c.UI.Output("Load balancer ingress: %s", strings.Join(s.Ingress, ", "))
+		}
+	}
+
+	c.UI.Output("Events", terminal.WithHeaderStyle())
+	if len(desc.Events) == 0 {
+		c.UI.Output("No recent events.")
+	} else {
+		tbl := terminal.NewTable("Last Seen", "Type", "Reason", "Object", "Message")
+		for _, e := range desc.Events {
+			color := ""
+			if e.Type == corev1.EventTypeWarning {
+				color = terminal.Yellow
+			}
+			tbl.AddRow([]string{e.LastSeen.Format(time.RFC3339), e.Type, e.Reason, e.Object, e.Message}, []string{"", color, "", "", ""})
+		}
+		c.UI.Table(tbl)
+	}
+}
+
+// initKubernetes initializes the REST config and uses it to initialize the k8s client.
+func (c *Command) initKubernetes() (err error) {
+	settings := helmcli.New()
+
+	// If a kubeconfig was specified, use it
+	if c.flagKubeConfig != "" {
+		settings.KubeConfig = c.flagKubeConfig
+	}
+
+	// If a kube context was specified, use it
+	if c.flagKubeContext != "" {
+		settings.KubeContext = c.flagKubeContext
+	}
+
+	// Create a REST config from the settings for our Kubernetes client
+	if c.restConfig == nil {
+		if c.restConfig, err = settings.RESTClientGetter().ToRESTConfig(); err != nil {
+			return fmt.Errorf("error creating Kubernetes REST config: %w", err)
+		}
+	}
+
+	// Create a controller-runtime client from c.restConfig
+	if c.kubernetes == nil {
+		if c.kubernetes, err = client.New(c.restConfig, client.Options{}); err != nil {
+			return fmt.Errorf("error creating controller-runtime client: %w", err)
+		}
+		_ = gwv1alpha2.AddToScheme(c.kubernetes.Scheme())
+		_ = gwv1beta1.AddToScheme(c.kubernetes.Scheme())
+	}
+
+	// If no namespace was specified, use the one from the kube context
+	if c.flagGatewayNamespace == "" {
+		if c.flagOutput != outputJSON {
+			c.UI.Output("No namespace specified, using current kube context namespace: %s", settings.Namespace())
+		}
+		c.flagGatewayNamespace = settings.Namespace()
+	}
+
+	return nil
+}
@@ -0,0 +1,371 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package describe
+
+import (
+	"bytes"
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/json"
+	"encoding/pem"
+	"io"
+	"math/big"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/hashicorp/go-hclog"
+	"github.com/stretchr/testify/require"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	"k8s.io/utils/ptr"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	gwv1alpha2 "sigs.k8s.io/gateway-api/apis/v1alpha2"
+	gwv1beta1 "sigs.k8s.io/gateway-api/apis/v1beta1"
+
+	"github.com/hashicorp/consul-k8s/cli/common"
+	"github.com/hashicorp/consul-k8s/cli/common/terminal"
+)
+
+func TestFlagParsing(t *testing.T) {
+	cases := map[string]struct {
+		args []string
+		out  int
+	}{
+		"No args": {
+			args: []string{},
+			out:  1,
+		},
+		"Flag instead of gateway name": {
+			args: []string{"-namespace", "default"},
+			out:  1,
+		},
+		"Multiple gateway names passed": {
+			args: []string{"gateway-1", "gateway-2"},
+			out:  1,
+		},
+		"Nonexistent flag passed, -foo bar": {
+			args: []string{"gateway-1", "-foo", "bar"},
+			out:  1,
+		},
+		"Invalid kind": {
+			args: []string{"gateway-1", "-kind", "ingress"},
+			out:  1,
+		},
+		"Invalid output": {
+			args: []string{"gateway-1", "-output", "yaml"},
+			out:  1,
+		},
+		"Gateway not found": {
+			args: []string{"gateway-1", "-namespace", "default"},
+			out:  1,
+		},
+	}
+
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			c := setupCommand(new(bytes.Buffer))
+			c.kubernetes = fake.NewClientBuilder().WithScheme(testScheme(t)).Build()
+
+			out := c.Run(tc.args)
+			require.Equal(t, tc.out, out)
+		})
+	}
+}
+
+func TestDescribeAPIGateway(t *testing.T) {
+	now := time.Now().Truncate(time.Second)
+	expiry := now.Add(24 * time.Hour).UTC()
+
+	gateway := &gwv1beta1.Gateway{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "api-gateway"},
+		Spec: gwv1beta1.GatewaySpec{
+			GatewayClassName: "consul",
+			Listeners: []gwv1beta1.Listener{
+				{Name: "http", Protocol: gwv1beta1.HTTPProtocolType, Port: 80},
+				{
+					Name:     "https",
+					Protocol: gwv1beta1.HTTPSProtocolType,
+					Port:     443,
+					Hostname: ptr.To(gwv1beta1.Hostname("*.example.com")),
+					TLS: &gwv1beta1.GatewayTLSConfig{
+						CertificateRefs: []gwv1beta1.SecretObjectReference{
+							{Name: "example-cert"},
+							{Name: "missing-cert", Namespace: ptr.To(gwv1beta1.Namespace("certs"))},
+						},
+					},
+				},
+			},
+		},
+		Status: gwv1beta1.GatewayStatus{
+			Addresses: []gwv1beta1.GatewayAddress{{Value: "10.0.0.1"}},
+			Listeners: []gwv1beta1.ListenerStatus{
+				{
+					Name:           "https",
+					AttachedRoutes: 1,
+					Conditions:     []metav1.Condition{{Type: "Programmed", Status: metav1.ConditionTrue}},
+				},
+			},
+		},
+	}
+	certSecret := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "example-cert"},
+		Data:       map[string][]byte{corev1.TLSCertKey: generateCertificate(t, expiry)},
+	}
+	httpRoute := &gwv1beta1.HTTPRoute{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "web"},
+		Spec: gwv1beta1.HTTPRouteSpec{
+			CommonRouteSpec: gwv1beta1.CommonRouteSpec{
+				ParentRefs: []gwv1beta1.ParentReference{{Name: "api-gateway", SectionName: ptr.To(gwv1beta1.SectionName("https"))}},
+			},
+			Hostnames: []gwv1beta1.Hostname{"web.example.com"},
+		},
+		Status: gwv1beta1.HTTPRouteStatus{
+			RouteStatus: gwv1beta1.RouteStatus{
+				Parents: []gwv1beta1.RouteParentStatus{
+					{
+						ParentRef:  gwv1beta1.ParentReference{Name: "api-gateway", SectionName: ptr.To(gwv1beta1.SectionName("https"))},
+						Conditions: []metav1.Condition{{Type: "Accepted", Status: metav1.ConditionTrue}},
+					},
+				},
+			},
+		},
+	}
+	otherNamespaceRoute := &gwv1beta1.HTTPRoute{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "other", Name: "web"},
+		Spec: gwv1beta1.HTTPRouteSpec{
+			CommonRouteSpec: gwv1beta1.CommonRouteSpec{
+				ParentRefs: []gwv1beta1.ParentReference{{Name: "api-gateway"}},
+			},
+		},
+	}
+	tcpRoute := &gwv1alpha2.TCPRoute{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "apps", Name: "db"},
+		Spec: gwv1alpha2.TCPRouteSpec{
+			CommonRouteSpec: gwv1beta1.CommonRouteSpec{
+				ParentRefs: []gwv1beta1.ParentReference{{Name: "api-gateway", Namespace: ptr.To(gwv1beta1.Namespace("default"))}},
+			},
+		},
+	}
+
+	objects := append([]client.Object{gateway, certSecret, httpRoute, otherNamespaceRoute, tcpRoute}, workloadObjects("api-gateway", now)...)
+	buf := new(bytes.Buffer)
+	c := setupCommand(buf)
+	c.kubernetes = fake.NewClientBuilder().WithScheme(testScheme(t)).WithObjects(objects...).Build()
+
+	require.Equal(t, 0, c.Run([]string{"api-gateway", "-namespace", "default", "-output", "json"}))
+
+	var desc description
+	require.NoErrorf(t, json.Unmarshal(buf.Bytes(), &desc), "failed to parse JSON output %s", buf.String())
+	require.Equal(t, "api", desc.Kind)
+	require.Equal(t, "consul", desc.GatewayClass)
+	require.Equal(t, []string{"10.0.0.1"}, desc.Addresses)
+	require.Equal(t, []listener{
+		{Name: "http", Protocol: "HTTP", Port: 80},
+		{Name: "https", Protocol: "HTTPS", Port: 443, Hostname: "*.example.com", AttachedRoutes: 1, Programmed: "True"},
+	}, desc.Listeners)
+	require.Equal(t, []route{
+		{Kind: "HTTPRoute", Name: "web", Namespace: "default", Listener: "https", Hostnames: []string{"web.example.com"}, Accepted: "True"},
+		{Kind: "TCPRoute", Name: "db", Namespace: "apps", Accepted: "Unknown"},
+	}, desc.Routes)
+
+	require.Len(t, desc.Certificates, 2)
+	require.Equal(t, "https", desc.Certificates[0].Listener)
+	require.Equal(t, "example-cert", desc.Certificates[0].Secret)
+	require.Equal(t, "web.example.com", desc.Certificates[0].Subject)
+	require.Equal(t, []string{"web.example.com", "*.example.com"}, desc.Certificates[0].DNSNames)
+	require.True(t, expiry.Equal(desc.Certificates[0].NotAfter))
+	require.Empty(t, desc.Certificates[0].Error)
+	require.Equal(t, "certs/missing-cert", desc.Certificates[1].Secret)
+	require.Contains(t, desc.Certificates[1].Error, "error fetching Secret")
+
+	requireWorkload(t, desc, "api-gateway")
+	require.Equal(t, []string{"Warning", "Normal", "Normal"}, eventTypes(desc.Events))
+	require.Equal(t, "Pod/api-gateway-abc", desc.Events[0].Object)
+	require.Equal(t, "Gateway/api-gateway", desc.Events[2].Object)
+}
+
+func TestDescribeMeshGateway(t *testing.T) {
+	now := time.Now().Truncate(time.Second)
+	buf := new(bytes.Buffer)
+	c := setupCommand(buf)
+	c.kubernetes = fake.NewClientBuilder().WithScheme(testScheme(t)).WithObjects(workloadObjects("consul-mesh-gateway", now)...).Build()
+
+	require.Equal(t, 0, c.Run([]string{"consul-mesh-gateway", "-namespace", "default", "-kind", "mesh", "-output", "json"}))
+
+	var desc description
+	require.NoErrorf(t, json.Unmarshal(buf.Bytes(), &desc), "failed to parse JSON output %s", buf.String())
+	require.Equal(t, "mesh", desc.Kind)
+	require.Equal(t, []string{"lb.example.com"}, desc.Addresses)
+	require.Equal(t, []listener{{Name: "gateway", Protocol: "TCP", Port: 443}}, desc.Listeners)
+	require.Empty(t, desc.Routes)
+	require.Empty(t, desc.Certificates)
+	requireWorkload(t, desc, "consul-mesh-gateway")
+	require.Equal(t, []string{"Warning", "Normal"}, eventTypes(desc.Events))
+}
+
+func TestDescribeTableOutput(t *testing.T) {
+	now := time.Now().Truncate(time.Second)
+	buf := new(bytes.Buffer)
+	c := setupCommand(buf)
+	c.kubernetes = fake.NewClientBuilder().WithScheme(testScheme(t)).WithObjects(workloadObjects("consul-mesh-gateway", now)...).Build()
+
+	require.Equal(t, 0, c.Run([]string{"consul-mesh-gateway", "-namespace", "default", "-kind", "mesh"}))
+
+	out := buf.String()
+	for _, expected := range []string{
+		"Mesh gateway default/consul-mesh-gateway",
+		"==> Listeners",
+		"consul-mesh-gateway: 2 desired, 2 updated, 1 ready, 1 available",
+		"consul-mesh-gateway-abc",
+		"consul-mesh-gateway: LoadBalancer 10.96.0.10, ports 443/TCP",
+		"Load balancer ingress: lb.example.com",
+		"BackOff",
+	} {
+		require.Contains(t, out, expected)
+	}
+	require.NotContains(t, out, "==> Routes")
+}
+
+// workloadObjects returns the Deployment, pods, Service and events of a gateway named name,
+// along with objects of another workload that must not be described.
+func workloadObjects(name string, now time.Time) []client.Object {
+	labels := map[string]string{"component": name}
+	return []client.Object{
+		&appsv1.Deployment{
+			ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: name},
+			Spec: appsv1.DeploymentSpec{
+				Replicas: ptr.To(int32(2)),
+				Selector: &metav1.LabelSelector{MatchLabels: labels},
+			},
+			Status: appsv1.DeploymentStatus{ReadyReplicas: 1, UpdatedReplicas: 2, AvailableReplicas: 1},
+		},
+		&corev1.Pod{
+			ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: name + "-abc", Labels: labels},
+			Spec:       corev1.PodSpec{NodeName: "node-1", Containers: []corev1.Container{{Name: "gateway"}}},
+			Status: corev1.PodStatus{
+				Phase:             corev1.PodRunning,
+				ContainerStatuses: []corev1.ContainerStatus{{Name: "gateway", Ready: false, RestartCount: 3}},
+			},
+		},
+		&corev1.Pod{
+			ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "other-pod", Labels: map[string]string{"component": "other"}},
+		},
+		&corev1.Service{
+			ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: name},
+			Spec: corev1.ServiceSpec{
+				Type:      corev1.ServiceTypeLoadBalancer,
+				ClusterIP: "10.96.0.10",
+				Ports:     []corev1.ServicePort{{Name: "gateway", Port: 443, Protocol: corev1.ProtocolTCP}},
+			},
+			Status: corev1.ServiceStatus{
+				LoadBalancer: corev1.LoadBalancerStatus{Ingress: []corev1.LoadBalancerIngress{{Hostname: "lb.example.com"}}},
+			},
+		},
+		&corev1.Event{
+			ObjectMeta:     metav1.ObjectMeta{Namespace: "default", Name: "gateway-event"},
+			InvolvedObject: corev1.ObjectReference{Kind: "Gateway", Name: name},
+			Type:           corev1.EventTypeNormal,
+			Reason:         "Programmed",
+			LastTimestamp:  metav1.NewTime(now.Add(-time.Hour)),
+		},
+		&corev1.Event{
+			ObjectMeta:     metav1.ObjectMeta{Namespace: "default", Name: "deployment-event"},
+			InvolvedObject: corev1.ObjectReference{Kind: "Deployment", Name: name},
+			Type:           corev1.EventTypeNormal,
+			Reason:         "ScalingReplicaSet",
+			LastTimestamp:  metav1.NewTime(now.Add(-time.Minute)),
+		},
+		&corev1.Event{
+			ObjectMeta:     metav1.ObjectMeta{Namespace: "default", Name: "pod-event"},
+			InvolvedObject: corev1.ObjectReference{Kind: "Pod", Name: name + "-abc"},
+			Type:           corev1.EventTypeWarning,
+			Reason:         "BackOff",
+			Message:        "Back-off restarting failed container",
+			LastTimestamp:  metav1.NewTime(now),
+		},
+		&corev1.Event{
+			ObjectMeta:     metav1.ObjectMeta{Namespace: "default", Name: "other-event"},
+			InvolvedObject: corev1.ObjectReference{Kind: "Pod", Name: "other-pod"},
+			Type:           corev1.EventTypeWarning,
+			Reason:         "Failed",
+			LastTimestamp:  metav1.NewTime(now),
+		},
+	}
+}
+
+func requireWorkload(t *testing.T, desc description, name string) {
+	t.Helper()
+	require.Equal(t, &deployment{Name: name, Replicas: 2, ReadyReplicas: 1, UpdatedReplicas: 2, AvailableReplicas: 1}, desc.Deployment)
+	require.Equal(t, []pod{{Name: name + "-abc", Phase: "Running", Ready: "0/1", Restarts: 3, Node: "node-1"}}, desc.Pods)
+	require.Equal(t, &service{
+		Name:      name,
+		Type:      "LoadBalancer",
+		ClusterIP: "10.96.0.10",
+		Ports:     []string{"443/TCP"},
+		Ingress:   []string{"lb.example.com"},
+	}, desc.Service)
+}
+
+func eventTypes(events []event) []string {
+	var types []string
+	for _, e := range events {
+		types = append(types, e.Type)
+	}
+	return types
+}
+
+func generateCertificate(t *testing.T, notAfter time.Time) []byte {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: "web.example.com"},
+		DNSNames:     []string{"web.example.com", "*.example.com"},
+		NotBefore:    notAfter.Add(-48 * time.Hour),
+		NotAfter:     notAfter,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	require.NoError(t, err)
+	return pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der})
+}
+
+func testScheme(t *testing.T) *runtime.Scheme {
+	t.Helper()
+	s := runtime.NewScheme()
+	require.NoError(t, clientgoscheme.AddToScheme(s))
+	require.NoError(t, gwv1beta1.AddToScheme(s))
+	require.NoError(t, gwv1alpha2.AddToScheme(s))
+	return s
+}
+
+func setupCommand(buf io.Writer) *Command {
+	// Log at a test level to standard out.
+	log := hclog.New(&hclog.LoggerOptions{
+		Name:   "test",
+		Level:  hclog.Debug,
+		Output: os.Stdout,
+	})
+
+	// Setup and initialize the command struct
+	command := &Command{
+		BaseCommand: &common.BaseCommand{
+			Ctx: context.Background(),
+			Log: log,
+			UI:  terminal.NewUI(context.Background(), buf),
+		},
+	}
+	command.init()
+
+	return command
+}
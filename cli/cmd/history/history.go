@@ -0,0 +1,205 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package history
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"sync"
+
+	"github.com/posener/complete"
+	helmCLI "helm.sh/helm/v3/pkg/cli"
+	"k8s.io/client-go/kubernetes"
+
+	"github.com/hashicorp/consul-k8s/cli/common"
+	"github.com/hashicorp/consul-k8s/cli/common/flag"
+	"github.com/hashicorp/consul-k8s/cli/common/terminal"
+	"github.com/hashicorp/consul-k8s/cli/release"
+)
+
+const (
+	flagNameNamespace   = "namespace"
+	flagNameOutput      = "output"
+	flagNameKubeConfig  = "kubeconfig"
+	flagNameKubeContext = "context"
+
+	outputTable = "table"
+	outputJSON  = "json"
+)
+
+type Command struct {
+	*common.BaseCommand
+
+	kubernetes kubernetes.Interface
+
+	set *flag.Sets
+
+	flagNamespace   string
+	flagOutput      string
+	flagKubeConfig  string
+	flagKubeContext string
+
+	once sync.Once
+	help string
+}
+
+func (c *Command) init() {
+	c.set = flag.NewSets()
+
+	f := c.set.NewSet("Command Options")
+	f.StringVar(&flag.StringVar{
+		Name:    flagNameNamespace,
+		Aliases: []string{"n"},
+		Target:  &c.flagNamespace,
+		Default: "",
+		Usage:   "The Kubernetes namespace to list receipts from. Defaults to all namespaces.",
+	})
+	f.StringVar(&flag.StringVar{
+		Name:    flagNameOutput,
+		Aliases: []string{"o"},
+		Target:  &c.flagOutput,
+		Default: outputTable,
+		Usage:   "Output the receipts as a 'table' or as 'json'. JSON output includes the images and CRD versions of each receipt.",
+	})
+
+	f = c.set.NewSet("Global Options")
+	f.StringVar(&flag.StringVar{
+		Name:    flagNameKubeConfig,
+		Aliases: []string{"c"},
+		Target:  &c.flagKubeConfig,
+		Default: "",
+		Usage:   "Path to kubeconfig file.",
+	})
+	f.StringVar(&flag.StringVar{
+		Name:    flagNameKubeContext,
+		Target:  &c.flagKubeContext,
+		Default: "",
+		Usage:   "Kubernetes context to use.",
+	})
+
+	c.help = c.set.Help()
+}
+
+// Run lists the receipts recorded by previous installs and upgrades.
+func (c *Command) Run(args []string) int {
+	c.once.Do(c.init)
+
+	c.Log.ResetNamed("history")
+	defer common.CloseWithError(c.BaseCommand)
+
+	if err := c.set.Parse(args); err != nil {
+		c.UI.Output(err.Error())
+		return 1
+	}
+
+	if err := c.validateFlags(); err != nil {
+		c.UI.Output(err.Error())
+		return 1
+	}
+
+	// helmCLI.New() will create a settings object which is used to target the right cluster.
+	settings := helmCLI.New()
+	if c.flagKubeConfig != "" {
+		settings.KubeConfig = c.flagKubeConfig
+	}
+	if c.flagKubeContext != "" {
+		settings.KubeContext = c.flagKubeContext
+	}
+
+	if err := c.setupKubeClient(settings); err != nil {
+		c.UI.Output(err.Error(), terminal.WithErrorStyle())
+		return 1
+	}
+
+	receipts, err := release.ListReceipts(c.Ctx, c.kubernetes, c.flagNamespace)
+	if err != nil {
+		c.UI.Output(err.Error(), terminal.WithErrorStyle())
+		return 1
+	}
+
+	if c.flagOutput == outputJSON {
+		out, err := json.MarshalIndent(receipts, "", "\t")
+		if err != nil {
+			c.UI.Output(err.Error(), terminal.WithErrorStyle())
+			return 1
+		}
+		c.UI.Output(string(out))
+		return 0
+	}
+
+	c.UI.Output("Consul Install History", terminal.WithHeaderStyle())
+	if len(receipts) == 0 {
+		c.UI.Output("No install receipts found.", terminal.WithInfoStyle())
+		return 0
+	}
+
+	tbl := terminal.NewTable("Timestamp", "Action", "Name", "Namespace", "Revision", "Chart Version", "Values Hash", "Operator")
+	for _, r := range receipts {
+		tbl.AddRow([]string{r.Timestamp.Format("2006/01/02 15:04:05 MST"), r.Action, r.ReleaseName, r.Namespace,
+			strconv.Itoa(r.Revision), r.ChartVersion, r.ValuesHash, r.Operator}, []string{})
+	}
+	c.UI.Table(tbl)
+
+	return 0
+}
+
+// validateFlags checks the command line flags and values for errors.
+func (c *Command) validateFlags() error {
+	if len(c.set.Args()) > 0 {
+		return errors.New("should have no non-flag arguments")
+	}
+	if c.flagOutput != outputTable && c.flagOutput != outputJSON {
+		return fmt.Errorf("-%s must be one of '%s' or '%s'", flagNameOutput, outputTable, outputJSON)
+	}
+	return nil
+}
+
+// setupKubeClient creates the Kubernetes client used to read receipts unless one has
+// already been set.
+func (c *Command) setupKubeClient(settings *helmCLI.EnvSettings) error {
+	if c.kubernetes == nil {
+		restConfig, err := settings.RESTClientGetter().ToRESTConfig()
+		if err != nil {
+			return fmt.Errorf("error retrieving Kubernetes authentication: %v", err)
+		}
+		c.kubernetes, err = kubernetes.NewForConfig(restConfig)
+		if err != nil {
+			return fmt.Errorf("error initializing Kubernetes client: %v", err)
+		}
+	}
+
+	return nil
+}
+
+// AutocompleteFlags returns a mapping of supported flags and autocomplete
+// options for this command. The map key for the Flags map should be the
+// complete flag such as "-foo" or "--foo".
+func (c *Command) AutocompleteFlags() complete.Flags {
+	return complete.Flags{
+		fmt.Sprintf("-%s", flagNameNamespace):   complete.PredictNothing,
+		fmt.Sprintf("-%s", flagNameOutput):      complete.PredictSet(outputTable, outputJSON),
+		fmt.Sprintf("-%s", flagNameKubeConfig):  complete.PredictFiles("*"),
+		fmt.Sprintf("-%s", flagNameKubeContext): complete.PredictNothing,
+	}
+}
+
+// AutocompleteArgs returns the argument predictor for this command.
+// Since argument completion is not supported, this will return
+// complete.PredictNothing.
+func (c *Command) AutocompleteArgs() complete.Predictor {
+	return complete.PredictNothing
+}
+
+// Help returns a description of the command and how it is used.
+func (c *Command) Help() string {
+	c.once.Do(c.init)
+	return c.Synopsis() + "\n\nUsage: consul-k8s history [flags]\n\n" + c.help
+}
+
+// Synopsis returns a one-line command summary.
+func (c *Command) Synopsis() string {
+	return "List the receipts recorded by previous Consul installs and upgrades."
+}
@@ -0,0 +1,155 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package history
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/hashicorp/go-hclog"
+	"github.com/stretchr/testify/require"
+	"k8s.io/client-go/kubernetes/fake"
+
+	"github.com/hashicorp/consul-k8s/cli/common"
+	"github.com/hashicorp/consul-k8s/cli/common/terminal"
+	"github.com/hashicorp/consul-k8s/cli/release"
+)
+
+func TestHistory(t *testing.T) {
+	receipts := []*release.Receipt{
+		{
+			Action:       release.ReceiptActionInstall,
+			ReleaseName:  "consul",
+			Namespace:    "consul",
+			Revision:     1,
+			ChartVersion: "1.5.0",
+			ValuesHash:   "sha256:aaaa",
+			Timestamp:    time.Date(2024, 5, 1, 10, 0, 0, 0, time.UTC),
+			Operator:     "admin",
+		},
+		{
+			Action:       release.ReceiptActionUpgrade,
+			ReleaseName:  "consul",
+			Namespace:    "consul",
+			Revision:     2,
+			ChartVersion: "1.6.0",
+			ValuesHash:   "sha256:bbbb",
+			Images:       map[string]string{"global.image": "hashicorp/consul:1.20.0"},
+			Timestamp:    time.Date(2024, 6, 1, 10, 0, 0, 0, time.UTC),
+			Operator:     "ops",
+		},
+	}
+
+	cases := map[string]struct {
+		input              []string
+		receipts           []*release.Receipt
+		messages           []string
+		expectedReturnCode int
+	}{
+		"lists receipts": {
+			receipts: receipts,
+			messages: []string{
+				"Consul Install History",
+				"2024/05/01 10:00:00 UTC",
+				"install",
+				"1.5.0",
+				"sha256:aaaa",
+				"upgrade",
+				"1.6.0",
+				"ops",
+			},
+		},
+		"no receipts": {
+			messages: []string{"No install receipts found."},
+		},
+		"namespace without receipts": {
+			input:    []string{"-namespace", "other"},
+			receipts: receipts,
+			messages: []string{"No install receipts found."},
+		},
+		"invalid output": {
+			input:              []string{"-output", "yaml"},
+			messages:           []string{"-output must be one of 'table' or 'json'"},
+			expectedReturnCode: 1,
+		},
+		"unexpected argument": {
+			input:              []string{"foo"},
+			messages:           []string{"should have no non-flag arguments"},
+			expectedReturnCode: 1,
+		},
+	}
+
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			buf := new(bytes.Buffer)
+			c := getInitializedCommand(t, buf)
+			c.kubernetes = fake.NewSimpleClientset()
+			for _, r := range tc.receipts {
+				require.NoError(t, release.SaveReceipt(context.Background(), c.kubernetes, r))
+			}
+
+			returnCode := c.Run(tc.input)
+			require.Equal(t, tc.expectedReturnCode, returnCode)
+			output := buf.String()
+			for _, msg := range tc.messages {
+				require.Contains(t, output, msg)
+			}
+		})
+	}
+}
+
+func TestHistory_JSONOutput(t *testing.T) {
+	receipt := &release.Receipt{
+		Action:       release.ReceiptActionInstall,
+		ReleaseName:  "consul",
+		Namespace:    "consul",
+		Revision:     1,
+		ChartVersion: "1.6.0",
+		Images:       map[string]string{"global.image": "hashicorp/consul:1.20.0"},
+		CRDVersions:  map[string][]string{"meshes.consul.hashicorp.com": {"v1alpha1"}},
+		Timestamp:    time.Date(2024, 5, 1, 10, 0, 0, 0, time.UTC),
+		Operator:     "admin",
+	}
+
+	buf := new(bytes.Buffer)
+	c := getInitializedCommand(t, buf)
+	c.kubernetes = fake.NewSimpleClientset()
+	require.NoError(t, release.SaveReceipt(context.Background(), c.kubernetes, receipt))
+
+	require.Equal(t, 0, c.Run([]string{"-o", "json"}))
+
+	var actual []release.Receipt
+	require.NoError(t, json.Unmarshal(buf.Bytes(), &actual))
+	require.Equal(t, []release.Receipt{*receipt}, actual)
+}
+
+func getInitializedCommand(t *testing.T, buf io.Writer) *Command {
+	t.Helper()
+	log := hclog.New(&hclog.LoggerOptions{
+		Name:   "cli",
+		Level:  hclog.Info,
+		Output: os.Stdout,
+	})
+	var ui terminal.UI
+	if buf != nil {
+		ui = terminal.NewUI(context.Background(), buf)
+	} else {
+		ui = terminal.NewBasicUI(context.Background())
+	}
+	baseCommand := &common.BaseCommand{
+		Log: log,
+		UI:  ui,
+	}
+
+	c := &Command{
+		BaseCommand: baseCommand,
+	}
+	c.init()
+	return c
+}
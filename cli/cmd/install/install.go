@@ -16,11 +16,13 @@ import (
 	"github.com/hashicorp/consul-k8s/cli/common"
 	"github.com/hashicorp/consul-k8s/cli/common/flag"
 	"github.com/hashicorp/consul-k8s/cli/common/terminal"
+	"github.com/hashicorp/consul-k8s/cli/compatibility"
 	"github.com/hashicorp/consul-k8s/cli/config"
 	"github.com/hashicorp/consul-k8s/cli/helm"
 	"github.com/hashicorp/consul-k8s/cli/preset"
 	"github.com/hashicorp/consul-k8s/cli/release"
 	"github.com/hashicorp/consul-k8s/cli/validation"
+	"github.com/hashicorp/consul-k8s/version"
 	"github.com/posener/complete"
 	"golang.org/x/text/cases"
 	"golang.org/x/text/language"
@@ -28,6 +30,8 @@ import (
 	helmCLI "helm.sh/helm/v3/pkg/cli"
 	"helm.sh/helm/v3/pkg/cli/values"
 	"helm.sh/helm/v3/pkg/getter"
+	helmRelease "helm.sh/helm/v3/pkg/release"
+	corev1 "k8s.io/api/core/v1"
 	k8serrors "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/client-go/kubernetes"
@@ -69,6 +73,16 @@ const (
 
 	flagNameDemo = "demo"
 	defaultDemo  = false
+
+	flagNameFromBundle = "from-bundle"
+
+	flagNameEnterpriseLicense     = "enterprise-license"
+	flagNameEnterpriseLicenseFile = "enterprise-license-file"
+
+	// enterpriseLicenseSecretName and enterpriseLicenseSecretKey are the name and key of the
+	// secret the license provided with -enterprise-license or -enterprise-license-file is stored in.
+	enterpriseLicenseSecretName = "consul-enterprise-license"
+	enterpriseLicenseSecretKey  = "key"
 )
 
 type Command struct {
@@ -96,6 +110,16 @@ type Command struct {
 	flagWait              bool
 	flagDemo              bool
 	flagNameHCPResourceID string
+	flagFromBundle        string
+
+	flagEnterpriseLicense     string
+	flagEnterpriseLicenseFile string
+
+	// enterpriseLicense is the license read from -enterprise-license or -enterprise-license-file.
+	enterpriseLicense string
+
+	// bundle is the air-gapped bundle loaded from -from-bundle.
+	bundle *helm.Bundle
 
 	flagKubeConfig  string
 	flagKubeContext string
@@ -135,7 +159,8 @@ func (c *Command) init() {
 		Name:    flagNamePreset,
 		Target:  &c.flagPreset,
 		Default: defaultPreset,
-		Usage:   fmt.Sprintf("Use an installation preset, one of %s. Defaults to none", strings.Join(preset.Presets, ", ")),
+		Usage: fmt.Sprintf("Use an installation preset, one of %s. Values files and set values take precedence over the preset. "+
+			"Run 'consul-k8s config preset show <name>' to print the values of a preset. Defaults to none", strings.Join(preset.Presets, ", ")),
 	})
 	f.StringSliceVar(&flag.StringSliceVar{
 		Name:   flagNameSetValues,
@@ -185,6 +210,23 @@ func (c *Command) init() {
 		Default: "",
 		Usage:   "Set the HCP resource_id when using the 'cloud' preset.",
 	})
+	f.StringVar(&flag.StringVar{
+		Name:   flagNameFromBundle,
+		Target: &c.flagFromBundle,
+		Usage: "Install from an air-gapped bundle (.tgz) containing the Consul Helm chart and digest pinned image references " +
+			"instead of the chart embedded in the CLI.",
+	})
+	f.StringVar(&flag.StringVar{
+		Name:   flagNameEnterpriseLicense,
+		Target: &c.flagEnterpriseLicense,
+		Usage: fmt.Sprintf("The Consul Enterprise license. It is stored in the %q secret in the installation namespace "+
+			"and global.enterpriseLicense is set to use it.", enterpriseLicenseSecretName),
+	})
+	f.StringVar(&flag.StringVar{
+		Name:   flagNameEnterpriseLicenseFile,
+		Target: &c.flagEnterpriseLicenseFile,
+		Usage:  fmt.Sprintf("Set the path to a file containing the Consul Enterprise license. Cannot be used with -%s.", flagNameEnterpriseLicense),
+	})
 
 	f = c.set.NewSet("Global Options")
 	f.StringVar(&flag.StringVar{
@@ -225,6 +267,17 @@ func (c *Command) Run(args []string) int {
 		c.UI.Output("Performing dry run install. No changes will be made to the cluster.", terminal.WithHeaderStyle())
 	}
 
+	if c.flagFromBundle != "" {
+		bundle, err := helm.LoadBundle(c.flagFromBundle)
+		if err != nil {
+			c.UI.Output(err.Error(), terminal.WithErrorStyle())
+			return 1
+		}
+		c.bundle = bundle
+		c.UI.Output("Loaded bundle for Consul Helm chart version %s with %d pinned images.",
+			bundle.Manifest.Version, len(bundle.Manifest.Images), terminal.WithSuccessStyle())
+	}
+
 	// helmCLI.New() will create a settings object which is used by the Helm Go SDK calls.
 	settings := helmCLI.New()
 
@@ -343,8 +396,14 @@ func (c *Command) Run(args []string) int {
 
 	release.Configuration = helmVals
 
-	// If an enterprise license secret was provided, check that the secret exists and that the enterprise Consul image is set.
-	if helmVals.Global.EnterpriseLicense.SecretName != "" {
+	// If an enterprise license was provided, the secret it is stored in is created once the installation
+	// is confirmed. Otherwise if an enterprise license secret was provided, check that the secret exists.
+	if c.enterpriseLicense != "" && helmVals.Global.EnterpriseLicense.SecretName == enterpriseLicenseSecretName {
+		if !version.IsEnterpriseImage(helmVals.Global.Image) {
+			c.UI.Output("The enterprise license is only used by Consul Enterprise images. Set global.image to a Consul Enterprise image "+
+				"such as hashicorp/consul-enterprise:<version>-ent to install Consul Enterprise.", terminal.WithWarningStyle())
+		}
+	} else if helmVals.Global.EnterpriseLicense.SecretName != "" {
 		if err := c.checkValidEnterprise(release.Configuration.Global.EnterpriseLicense.SecretName); err != nil {
 			c.UI.Output(err.Error(), terminal.WithErrorStyle())
 			return 1
@@ -352,6 +411,8 @@ func (c *Command) Run(args []string) int {
 		c.UI.Output("Valid enterprise Consul secret found.", terminal.WithSuccessStyle())
 	}
 
+	c.checkVersionCompatibility(vals)
+
 	err = c.installConsul(valuesYaml, vals, settings, uiLogger)
 	if err != nil {
 		c.UI.Output(err.Error(), terminal.WithErrorStyle())
@@ -432,15 +493,52 @@ func (c *Command) installConsul(valuesYaml []byte, vals map[string]interface{},
 		UI:                c.UI,
 		HelmActionsRunner: c.helmActionsRunner,
 	}
+	if c.enterpriseLicense != "" {
+		installOptions.PreInstall = c.createEnterpriseLicenseSecret
+	}
+	if c.bundle != nil {
+		installOptions.Chart = c.bundle.Chart
+	}
 
-	err = helm.InstallHelmRelease(installOptions)
+	rel, err := helm.InstallHelmRelease(installOptions)
 	if err != nil {
 		return err
 	}
+	if rel != nil {
+		c.saveReceipt(rel, settings)
+	}
 
 	return nil
 }
 
+// checkVersionCompatibility warns about versions of the chart to install, its images, the CLI and
+// Kubernetes that aren't supported together.
+func (c *Command) checkVersionCompatibility(vals map[string]interface{}) {
+	chrt, err := helm.LoadChart(consulChart.ConsulHelmChart, common.TopLevelChartDirName)
+	if c.bundle != nil {
+		chrt, err = c.bundle.Chart, nil
+	}
+	if err != nil {
+		c.UI.Output("Unable to check version compatibility: %v", err, terminal.WithWarningStyle())
+		return
+	}
+	compatibility.Preflight(c.UI, c.kubernetes, chrt, vals)
+}
+
+// saveReceipt records a receipt of the install in the cluster. Failing to record
+// the receipt does not fail the install since Consul has already been installed.
+func (c *Command) saveReceipt(rel *helmRelease.Release, settings *helmCLI.EnvSettings) {
+	receipt, err := release.NewReceipt(release.ReceiptActionInstall, rel, release.OperatorIdentity(settings), time.Now())
+	if err == nil {
+		err = release.SaveReceipt(c.Ctx, c.kubernetes, receipt)
+	}
+	if err != nil {
+		c.UI.Output("Unable to record install receipt: %v", err, terminal.WithWarningStyle())
+		return
+	}
+	c.UI.Output("Recorded install receipt. Use the command `consul-k8s history` to list receipts.", terminal.WithSuccessStyle())
+}
+
 // Help returns a description of the command and how it is used.
 func (c *Command) Help() string {
 	c.once.Do(c.init)
@@ -457,21 +555,24 @@ func (c *Command) Synopsis() string {
 // complete flag such as "-foo" or "--foo".
 func (c *Command) AutocompleteFlags() complete.Flags {
 	return complete.Flags{
-		fmt.Sprintf("-%s", flagNamePreset):          complete.PredictNothing,
-		fmt.Sprintf("-%s", flagNameNamespace):       complete.PredictNothing,
-		fmt.Sprintf("-%s", flagNameDryRun):          complete.PredictNothing,
-		fmt.Sprintf("-%s", flagNameAutoApprove):     complete.PredictNothing,
-		fmt.Sprintf("-%s", flagNameConfigFile):      complete.PredictFiles("*"),
-		fmt.Sprintf("-%s", flagNameSetStringValues): complete.PredictNothing,
-		fmt.Sprintf("-%s", flagNameSetValues):       complete.PredictNothing,
-		fmt.Sprintf("-%s", flagNameFileValues):      complete.PredictFiles("*"),
-		fmt.Sprintf("-%s", flagNameTimeout):         complete.PredictNothing,
-		fmt.Sprintf("-%s", flagNameVerbose):         complete.PredictNothing,
-		fmt.Sprintf("-%s", flagNameWait):            complete.PredictNothing,
-		fmt.Sprintf("-%s", flagNameContext):         complete.PredictNothing,
-		fmt.Sprintf("-%s", flagNameKubeconfig):      complete.PredictNothing,
-		fmt.Sprintf("-%s", flagNameDemo):            complete.PredictNothing,
-		fmt.Sprintf("-%s", flagNameHCPResourceID):   complete.PredictNothing,
+		fmt.Sprintf("-%s", flagNamePreset):                complete.PredictNothing,
+		fmt.Sprintf("-%s", flagNameNamespace):             complete.PredictNothing,
+		fmt.Sprintf("-%s", flagNameDryRun):                complete.PredictNothing,
+		fmt.Sprintf("-%s", flagNameAutoApprove):           complete.PredictNothing,
+		fmt.Sprintf("-%s", flagNameConfigFile):            complete.PredictFiles("*"),
+		fmt.Sprintf("-%s", flagNameSetStringValues):       complete.PredictNothing,
+		fmt.Sprintf("-%s", flagNameSetValues):             complete.PredictNothing,
+		fmt.Sprintf("-%s", flagNameFileValues):            complete.PredictFiles("*"),
+		fmt.Sprintf("-%s", flagNameTimeout):               complete.PredictNothing,
+		fmt.Sprintf("-%s", flagNameVerbose):               complete.PredictNothing,
+		fmt.Sprintf("-%s", flagNameWait):                  complete.PredictNothing,
+		fmt.Sprintf("-%s", flagNameContext):               complete.PredictNothing,
+		fmt.Sprintf("-%s", flagNameKubeconfig):            complete.PredictNothing,
+		fmt.Sprintf("-%s", flagNameDemo):                  complete.PredictNothing,
+		fmt.Sprintf("-%s", flagNameHCPResourceID):         complete.PredictNothing,
+		fmt.Sprintf("-%s", flagNameFromBundle):            complete.PredictFiles("*.tgz"),
+		fmt.Sprintf("-%s", flagNameEnterpriseLicense):     complete.PredictNothing,
+		fmt.Sprintf("-%s", flagNameEnterpriseLicenseFile): complete.PredictFiles("*"),
 	}
 }
 
@@ -511,6 +612,13 @@ func (c *Command) checkForPreviousSecrets(release release.Release) (string, erro
 	if err != nil {
 		return "", fmt.Errorf("Error listing Consul secrets: %s", err)
 	}
+	// The enterprise license secret is updated by the install, so it doesn't conflict.
+	for i := 0; i < len(secrets.Items); i++ {
+		if secrets.Items[i].Name == enterpriseLicenseSecretName {
+			secrets.Items = append(secrets.Items[:i], secrets.Items[i+1:]...)
+			i--
+		}
+	}
 
 	// If the Consul configuration is a secondary DC, only one secret should
 	// exist, the Consul federation secret.
@@ -546,11 +654,13 @@ func (c *Command) checkForPreviousSecrets(release release.Release) (string, erro
 
 // mergeValuesFlagsWithPrecedence is responsible for merging all the values to determine the values file for the
 // installation based on the following precedence order from lowest to highest:
-// 1. -preset
-// 2. -f values-file
-// 3. -set
-// 4. -set-string
-// 5. -set-file
+// 0. -from-bundle images
+// 1. -enterprise-license secret
+// 2. -preset
+// 3. -f values-file
+// 4. -set
+// 5. -set-string
+// 6. -set-file
 // For example, -set-file will override a value provided via -set.
 // Within each of these groups the rightmost flag value has the highest precedence.
 func (c *Command) mergeValuesFlagsWithPrecedence(settings *helmCLI.EnvSettings) (map[string]interface{}, error) {
@@ -577,6 +687,26 @@ func (c *Command) mergeValuesFlagsWithPrecedence(settings *helmCLI.EnvSettings)
 		}
 		vals = common.MergeMaps(presetMap, vals)
 	}
+	if c.enterpriseLicense != "" {
+		// The license secret has a lower precedence than the values so that another secret can still be used.
+		licenseVals := map[string]interface{}{
+			"global": map[string]interface{}{
+				"enterpriseLicense": map[string]interface{}{
+					"secretName": enterpriseLicenseSecretName,
+					"secretKey":  enterpriseLicenseSecretKey,
+				},
+			},
+		}
+		vals = common.MergeMaps(licenseVals, vals)
+	}
+	if c.bundle != nil {
+		// Bundle images have the lowest precedence so they can still be overridden with -set.
+		imageVals, err := c.bundle.ImageValues()
+		if err != nil {
+			return nil, err
+		}
+		vals = common.MergeMaps(imageVals, vals)
+	}
 	return vals, err
 }
 
@@ -588,9 +718,6 @@ func (c *Command) validateFlags(args []string) error {
 	if len(c.set.Args()) > 0 {
 		return errors.New("should have no non-flag arguments")
 	}
-	if len(c.flagValueFiles) != 0 && c.flagPreset != defaultPreset {
-		return fmt.Errorf("cannot set both -%s and -%s", flagNameConfigFile, flagNamePreset)
-	}
 	if ok := slices.Contains(preset.Presets, c.flagPreset); c.flagPreset != defaultPreset && !ok {
 		return fmt.Errorf("'%s' is not a valid preset (valid presets: %s)", c.flagPreset, strings.Join(preset.Presets, ", "))
 	}
@@ -625,7 +752,67 @@ func (c *Command) validateFlags(args []string) error {
 			}
 		}
 	}
+	if c.flagFromBundle != "" {
+		if c.flagDemo {
+			return fmt.Errorf("cannot set both -%s and -%s", flagNameFromBundle, flagNameDemo)
+		}
+		if _, err := os.Stat(c.flagFromBundle); err != nil && os.IsNotExist(err) {
+			return fmt.Errorf("file '%s' does not exist", c.flagFromBundle)
+		}
+	}
+
+	c.enterpriseLicense = strings.TrimSpace(c.flagEnterpriseLicense)
+	if c.flagEnterpriseLicenseFile != "" {
+		if c.flagEnterpriseLicense != "" {
+			return fmt.Errorf("cannot set both -%s and -%s", flagNameEnterpriseLicense, flagNameEnterpriseLicenseFile)
+		}
+		license, err := os.ReadFile(c.flagEnterpriseLicenseFile)
+		if err != nil {
+			return fmt.Errorf("unable to read -%s: %s", flagNameEnterpriseLicenseFile, err)
+		}
+		c.enterpriseLicense = strings.TrimSpace(string(license))
+		if c.enterpriseLicense == "" {
+			return fmt.Errorf("-%s %q is empty", flagNameEnterpriseLicenseFile, c.flagEnterpriseLicenseFile)
+		}
+	}
+
+	return nil
+}
+
+// createEnterpriseLicenseSecret stores the enterprise license in a secret in the installation namespace,
+// creating the namespace if it does not exist yet, or updates the secret left by a previous install. The
+// secret is labeled so that it is deleted on uninstall.
+func (c *Command) createEnterpriseLicenseSecret() error {
+	_, err := c.kubernetes.CoreV1().Namespaces().Get(c.Ctx, c.flagNamespace, metav1.GetOptions{})
+	if k8serrors.IsNotFound(err) {
+		_, err = c.kubernetes.CoreV1().Namespaces().Create(c.Ctx, &corev1.Namespace{
+			ObjectMeta: metav1.ObjectMeta{
+				Name: c.flagNamespace,
+			},
+		}, metav1.CreateOptions{})
+	}
+	if err != nil {
+		return fmt.Errorf("error creating the %q namespace: %s", c.flagNamespace, err)
+	}
 
+	secret := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:   enterpriseLicenseSecretName,
+			Labels: map[string]string{common.CLILabelKey: common.CLILabelValue},
+		},
+		StringData: map[string]string{
+			enterpriseLicenseSecretKey: c.enterpriseLicense,
+		},
+		Type: corev1.SecretTypeOpaque,
+	}
+	_, err = c.kubernetes.CoreV1().Secrets(c.flagNamespace).Create(c.Ctx, secret, metav1.CreateOptions{})
+	if k8serrors.IsAlreadyExists(err) {
+		_, err = c.kubernetes.CoreV1().Secrets(c.flagNamespace).Update(c.Ctx, secret, metav1.UpdateOptions{})
+	}
+	if err != nil {
+		return fmt.Errorf("error creating the enterprise license secret %q in the %q namespace: %s", enterpriseLicenseSecretName, c.flagNamespace, err)
+	}
+	c.UI.Output("Created enterprise license secret %q.", enterpriseLicenseSecretName, terminal.WithSuccessStyle())
 	return nil
 }
 
@@ -4,13 +4,16 @@
 package install
 
 import (
+	"archive/tar"
 	"bytes"
+	"compress/gzip"
 	"context"
 	"errors"
 	"flag"
 	"fmt"
 	"io"
 	"os"
+	"path/filepath"
 	"testing"
 
 	"github.com/hashicorp/consul-k8s/cli/common"
@@ -19,12 +22,14 @@ import (
 	"github.com/hashicorp/consul-k8s/cli/helm"
 	"github.com/hashicorp/consul-k8s/cli/preset"
 	"github.com/hashicorp/consul-k8s/cli/release"
+	"github.com/hashicorp/consul-k8s/version"
 	"github.com/hashicorp/go-hclog"
 	"github.com/posener/complete"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 	"helm.sh/helm/v3/pkg/action"
 	"helm.sh/helm/v3/pkg/chart"
+	helmCLI "helm.sh/helm/v3/pkg/cli"
 	helmRelease "helm.sh/helm/v3/pkg/release"
 	v1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
@@ -120,6 +125,18 @@ func TestCheckForPreviousSecrets(t *testing.T) {
 			expectMsg: true,
 			expectErr: false,
 		},
+		"Enterprise license secret, none expected": {
+			releaseName: "consul",
+			helmValues:  helm.Values{},
+			secret: &v1.Secret{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:   enterpriseLicenseSecretName,
+					Labels: map[string]string{common.CLILabelKey: common.CLILabelValue},
+				},
+			},
+			expectMsg: true,
+			expectErr: false,
+		},
 		"No federation secret, but expected": {
 			releaseName: "consul",
 			helmValues: helm.Values{
@@ -172,15 +189,10 @@ func TestValidateFlags(t *testing.T) {
 			[]string{"foo", "-auto-approve"},
 			"should have no non-flag arguments",
 		},
-		{
-			"Should disallow specifying both values file AND presets.",
-			[]string{"-f='f.txt'", "-preset=demo"},
-			"cannot set both -config-file and -preset",
-		},
 		{
 			"Should error on invalid presets.",
 			[]string{"-preset=foo"},
-			"'foo' is not a valid preset (valid presets: cloud, quickstart, secure)",
+			"'foo' is not a valid preset (valid presets: cloud, demo, multi-dc-primary, quickstart, secure)",
 		},
 		{
 			"Should error on invalid timeout.",
@@ -197,6 +209,26 @@ func TestValidateFlags(t *testing.T) {
 			[]string{"-f=\"does_not_exist.txt\""},
 			"file '\"does_not_exist.txt\"' does not exist",
 		},
+		{
+			"Should have errored on a non-existent bundle.",
+			[]string{"-from-bundle=does_not_exist.tgz"},
+			"file 'does_not_exist.tgz' does not exist",
+		},
+		{
+			"Should disallow installing the demo from a bundle.",
+			[]string{"-from-bundle=bundle.tgz", "-demo"},
+			"cannot set both -from-bundle and -demo",
+		},
+		{
+			"Should disallow setting the enterprise license and a license file.",
+			[]string{"-enterprise-license=license", "-enterprise-license-file=license.hclic"},
+			"cannot set both -enterprise-license and -enterprise-license-file",
+		},
+		{
+			"Should have errored on a non-existent license file.",
+			[]string{"-enterprise-license-file=does_not_exist.hclic"},
+			"unable to read -enterprise-license-file: open does_not_exist.hclic: no such file or directory",
+		},
 	}
 
 	for _, testCase := range testCases {
@@ -265,6 +297,46 @@ func TestCheckValidEnterprise(t *testing.T) {
 	require.Contains(t, err.Error(), "please make sure that the secret exists")
 }
 
+func TestCreateEnterpriseLicenseSecret(t *testing.T) {
+	c := getInitializedCommand(t, nil)
+	c.kubernetes = fake.NewSimpleClientset()
+	licenseFile := filepath.Join(t.TempDir(), "license.hclic")
+	require.NoError(t, os.WriteFile(licenseFile, []byte("license\n"), 0600))
+	require.NoError(t, c.validateFlags([]string{"-enterprise-license-file", licenseFile}))
+
+	require.NoError(t, c.createEnterpriseLicenseSecret())
+	_, err := c.kubernetes.CoreV1().Namespaces().Get(context.Background(), "consul", metav1.GetOptions{})
+	require.NoError(t, err)
+	secret, err := c.kubernetes.CoreV1().Secrets("consul").Get(context.Background(), enterpriseLicenseSecretName, metav1.GetOptions{})
+	require.NoError(t, err)
+	require.Equal(t, "license", secret.StringData[enterpriseLicenseSecretKey])
+	require.Equal(t, common.CLILabelValue, secret.Labels[common.CLILabelKey])
+
+	// The secret left by a previous install is updated.
+	c.enterpriseLicense = "new-license"
+	require.NoError(t, c.createEnterpriseLicenseSecret())
+	secret, err = c.kubernetes.CoreV1().Secrets("consul").Get(context.Background(), enterpriseLicenseSecretName, metav1.GetOptions{})
+	require.NoError(t, err)
+	require.Equal(t, "new-license", secret.StringData[enterpriseLicenseSecretKey])
+}
+
+func TestIsEnterpriseImage(t *testing.T) {
+	cases := map[string]bool{
+		"hashicorp/consul:1.18.0":                          false,
+		"hashicorp/consul-enterprise:1.18.0-ent":           true,
+		"docker.mirror.hashicorp.services/consul:1.18-dev": false,
+		"hashicorppreview/consul-enterprise:1.18-dev":      true,
+		"registry.example.com/consul:1.18.0-ent":           true,
+		"localhost:5000/consul@sha256:0123456789abcdef":    false,
+		"": false,
+	}
+	for image, expected := range cases {
+		t.Run(image, func(t *testing.T) {
+			require.Equal(t, expected, version.IsEnterpriseImage(image))
+		})
+	}
+}
+
 func TestTaskCreateCommand_AutocompleteFlags(t *testing.T) {
 	t.Parallel()
 	cmd := getInitializedCommand(t, nil)
@@ -403,6 +475,14 @@ func TestGetPreset(t *testing.T) {
 			"'secure' should return a SecurePreset'.",
 			preset.PresetSecure,
 		},
+		{
+			"'demo' should return a DemoPreset'.",
+			preset.PresetDemo,
+		},
+		{
+			"'multi-dc-primary' should return a MultiDCPrimaryPreset'.",
+			preset.PresetMultiDCPrimary,
+		},
 	}
 
 	for _, tc := range testCases {
@@ -417,11 +497,35 @@ func TestGetPreset(t *testing.T) {
 				require.Equal(t, preset.PresetQuickstart, tc.presetName)
 			case *preset.SecurePreset:
 				require.Equal(t, preset.PresetSecure, tc.presetName)
+			case *preset.DemoPreset:
+				require.Equal(t, preset.PresetDemo, tc.presetName)
+			case *preset.MultiDCPrimaryPreset:
+				require.Equal(t, preset.PresetMultiDCPrimary, tc.presetName)
+			default:
+				t.Fatalf("unexpected preset %T", p)
 			}
 		})
 	}
 }
 
+// TestMergeValuesFlagsWithPrecedence_Preset tests that values files and set values take precedence over presets.
+func TestMergeValuesFlagsWithPrecedence_Preset(t *testing.T) {
+	valuesFile := filepath.Join(t.TempDir(), "values.yaml")
+	require.NoError(t, os.WriteFile(valuesFile, []byte("server:\n  replicas: 3\nglobal:\n  name: from-file\n"), 0o600))
+
+	c := getInitializedCommand(t, nil)
+	require.NoError(t, c.validateFlags([]string{"-preset", preset.PresetSecure, "-f", valuesFile, "-set", "global.name=from-set"}))
+
+	vals, err := c.mergeValuesFlagsWithPrecedence(helmCLI.New())
+	require.NoError(t, err)
+
+	global := vals["global"].(map[string]interface{})
+	require.Equal(t, "from-set", global["name"])
+	require.Equal(t, true, global["tls"].(map[string]interface{})["enabled"])
+	require.Equal(t, true, global["acls"].(map[string]interface{})["manageSystemACLs"])
+	require.EqualValues(t, 3, vals["server"].(map[string]interface{})["replicas"])
+}
+
 func TestInstall(t *testing.T) {
 	var k8s kubernetes.Interface
 	licenseSecretName := "consul-license"
@@ -561,6 +665,38 @@ func TestInstall(t *testing.T) {
 			expectConsulInstalled:                   false,
 			expectConsulDemoInstalled:               false,
 		},
+		"enterprise install with a license creates the license secret and returns success": {
+			input: []string{
+				"-enterprise-license", "license",
+				"--set", "global.image=hashicorp/consul-enterprise:1.18.0-ent",
+			},
+			messages: []string{
+				"\n==> Checking if Consul can be installed\n ✓ No existing Consul installations found.\n ✓ No existing Consul persistent volume claims found\n ✓ No existing Consul secrets found.\n",
+				"\n==> Consul Installation Summary\n    Name: consul\n    Namespace: consul\n    \n    Helm value overrides\n    --------------------\n    global:\n      enterpriseLicense:\n        secretKey: key\n        secretName: consul-enterprise-license\n      image: hashicorp/consul-enterprise:1.18.0-ent\n    \n ✓ Created enterprise license secret \"consul-enterprise-license\".\n",
+				"\n==> Installing Consul\n ✓ Downloaded charts.\n ✓ Consul installed in namespace \"consul\".\n",
+			},
+			helmActionsRunner:                       &helm.MockActionRunner{},
+			expectedReturnCode:                      0,
+			expectCheckedForConsulInstallations:     true,
+			expectCheckedForConsulDemoInstallations: false,
+			expectConsulInstalled:                   true,
+			expectConsulDemoInstalled:               false,
+		},
+		"enterprise install with a license and a community image warns": {
+			input: []string{
+				"-enterprise-license", "license",
+			},
+			messages: []string{
+				" * The enterprise license is only used by Consul Enterprise images.",
+				" ✓ Created enterprise license secret \"consul-enterprise-license\".\n",
+			},
+			helmActionsRunner:                       &helm.MockActionRunner{},
+			expectedReturnCode:                      0,
+			expectCheckedForConsulInstallations:     true,
+			expectCheckedForConsulDemoInstallations: false,
+			expectConsulInstalled:                   true,
+			expectConsulDemoInstalled:               false,
+		},
 		"install for quickstart preset returns success": {
 			input: []string{
 				"-preset", "quickstart",
@@ -724,3 +860,108 @@ func createSecret(t *testing.T, secret *v1.Secret, namespace string, k8s kuberne
 	_, err := k8s.CoreV1().Secrets(namespace).Create(context.Background(), secret, metav1.CreateOptions{})
 	require.NoError(t, err)
 }
+
+func TestInstall_FromBundle(t *testing.T) {
+	digest := "sha256:0123456789abcdef0123456789abcdef0123456789abcdef0123456789abcdef"
+	image := "mirror.example.com/hashicorp/consul@" + digest
+	bundlePath := writeBundle(t, map[string]string{
+		"manifest.yaml":     "version: 1.6.0\nimages:\n  global.image: " + image + "\n",
+		"chart/Chart.yaml":  "apiVersion: v2\nname: consul\nversion: 1.6.0\n",
+		"chart/values.yaml": "global:\n  image: hashicorp/consul:1.20.0\n",
+	})
+
+	var (
+		installedChart *chart.Chart
+		installedVals  map[string]interface{}
+	)
+	mock := &helm.MockActionRunner{
+		InstallFunc: func(install *action.Install, chrt *chart.Chart, vals map[string]interface{}) (*helmRelease.Release, error) {
+			installedChart = chrt
+			installedVals = vals
+			return &helmRelease.Release{Name: install.ReleaseName}, nil
+		},
+	}
+
+	buf := new(bytes.Buffer)
+	c := getInitializedCommand(t, buf)
+	c.kubernetes = fake.NewSimpleClientset()
+	c.helmActionsRunner = mock
+
+	returnCode := c.Run([]string{"-auto-approve", "-from-bundle", bundlePath, "-set", "global.datacenter=dc2"})
+	require.Equal(t, 0, returnCode, buf.String())
+	require.Contains(t, buf.String(), "Loaded bundle for Consul Helm chart version 1.6.0 with 1 pinned images.")
+	require.NotContains(t, buf.String(), "Downloaded charts.")
+
+	require.NotNil(t, installedChart)
+	require.Equal(t, "1.6.0", installedChart.Metadata.Version)
+	global := installedVals["global"].(map[string]interface{})
+	require.Equal(t, image, global["image"])
+	require.Equal(t, "dc2", global["datacenter"])
+}
+
+func TestInstall_RecordsReceipt(t *testing.T) {
+	mock := &helm.MockActionRunner{
+		InstallFunc: func(install *action.Install, chrt *chart.Chart, vals map[string]interface{}) (*helmRelease.Release, error) {
+			return &helmRelease.Release{
+				Name:      install.ReleaseName,
+				Namespace: install.Namespace,
+				Version:   1,
+				Chart:     chrt,
+				Config:    vals,
+			}, nil
+		},
+	}
+
+	buf := new(bytes.Buffer)
+	c := getInitializedCommand(t, buf)
+	c.kubernetes = fake.NewSimpleClientset()
+	c.helmActionsRunner = mock
+
+	returnCode := c.Run([]string{"-auto-approve", "-set", "global.datacenter=dc2"})
+	require.Equal(t, 0, returnCode, buf.String())
+	require.Contains(t, buf.String(), "Recorded install receipt.")
+
+	receipts, err := release.ListReceipts(context.Background(), c.kubernetes, "consul")
+	require.NoError(t, err)
+	require.Len(t, receipts, 1)
+	require.Equal(t, release.ReceiptActionInstall, receipts[0].Action)
+	require.Equal(t, "consul", receipts[0].ReleaseName)
+	require.Equal(t, 1, receipts[0].Revision)
+	require.NotEmpty(t, receipts[0].ValuesHash)
+	require.NotEmpty(t, receipts[0].Operator)
+}
+
+func TestInstall_DryRunDoesNotRecordReceipt(t *testing.T) {
+	buf := new(bytes.Buffer)
+	c := getInitializedCommand(t, buf)
+	c.kubernetes = fake.NewSimpleClientset()
+	c.helmActionsRunner = &helm.MockActionRunner{}
+
+	returnCode := c.Run([]string{"-auto-approve", "-dry-run"})
+	require.Equal(t, 0, returnCode, buf.String())
+	require.NotContains(t, buf.String(), "Recorded install receipt.")
+
+	receipts, err := release.ListReceipts(context.Background(), c.kubernetes, "")
+	require.NoError(t, err)
+	require.Empty(t, receipts)
+}
+
+// writeBundle writes files to a gzipped tarball in a temporary directory and returns its path.
+func writeBundle(t *testing.T, files map[string]string) string {
+	t.Helper()
+	bundlePath := filepath.Join(t.TempDir(), "bundle.tgz")
+	f, err := os.Create(bundlePath)
+	require.NoError(t, err)
+	defer f.Close()
+
+	gz := gzip.NewWriter(f)
+	tw := tar.NewWriter(gz)
+	for name, contents := range files {
+		require.NoError(t, tw.WriteHeader(&tar.Header{Name: name, Mode: 0600, Size: int64(len(contents)), Typeflag: tar.TypeReg}))
+		_, err := tw.Write([]byte(contents))
+		require.NoError(t, err)
+	}
+	require.NoError(t, tw.Close())
+	require.NoError(t, gz.Close())
+	return bundlePath
+}
@@ -0,0 +1,131 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package check
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+
+	"github.com/hashicorp/consul/api"
+	"github.com/posener/complete"
+
+	"github.com/hashicorp/consul-k8s/cli/common"
+	"github.com/hashicorp/consul-k8s/cli/common/consul"
+	"github.com/hashicorp/consul-k8s/cli/common/flag"
+	"github.com/hashicorp/consul-k8s/cli/common/terminal"
+)
+
+// exitCodeDenied is returned when the connection is denied so that scripts
+// can tell it apart from errors.
+const exitCodeDenied = 2
+
+type Command struct {
+	*common.BaseCommand
+
+	set         *flag.Sets
+	consulFlags consul.Flags
+
+	source      string
+	destination string
+
+	// consulClient is created from the installed release when it is not set.
+	consulClient *api.Client
+
+	once sync.Once
+	help string
+}
+
+func (c *Command) init() {
+	c.set = flag.NewSets()
+	c.consulFlags.AddTo(c.set)
+
+	c.help = c.set.Help()
+}
+
+// Run checks whether the intentions allow the source service to connect to the
+// destination service. It exits with 0 if they do and with 2 if they don't.
+func (c *Command) Run(args []string) int {
+	c.once.Do(c.init)
+	c.Log.ResetNamed("intention check")
+	defer common.CloseWithError(c.BaseCommand)
+
+	if err := c.set.Parse(args); err != nil {
+		c.UI.Output(err.Error(), terminal.WithErrorStyle())
+		return 1
+	}
+	if err := c.validateFlags(); err != nil {
+		c.UI.Output(err.Error(), terminal.WithErrorStyle())
+		return 1
+	}
+
+	if c.consulClient == nil {
+		conn, err := c.consulFlags.NewConnection(c.uiLogger)
+		if err != nil {
+			c.UI.Output(err.Error(), terminal.WithErrorStyle())
+			return 1
+		}
+		defer conn.Close()
+		if c.consulClient, err = conn.Open(c.Ctx); err != nil {
+			c.UI.Output(err.Error(), terminal.WithErrorStyle())
+			return 1
+		}
+	}
+
+	allowed, _, err := c.consulClient.Connect().IntentionCheck(&api.IntentionCheck{
+		Source:      c.source,
+		Destination: c.destination,
+		SourceType:  api.IntentionSourceConsul,
+	}, (&api.QueryOptions{}).WithContext(c.Ctx))
+	if err != nil {
+		c.UI.Output(fmt.Sprintf("Error checking intentions from %s to %s: %s", c.source, c.destination, err), terminal.WithErrorStyle())
+		return 1
+	}
+
+	if !allowed {
+		c.UI.Output("Denied")
+		return exitCodeDenied
+	}
+	c.UI.Output("Allowed")
+	return 0
+}
+
+// validateFlags checks the command line flags and arguments for errors.
+func (c *Command) validateFlags() error {
+	if len(c.set.Args()) != 2 {
+		return errors.New("exactly two arguments are required: <source> <destination>")
+	}
+	c.source, c.destination = c.set.Args()[0], c.set.Args()[1]
+	return nil
+}
+
+// uiLogger streams the logs of the Helm library to the UI.
+func (c *Command) uiLogger(s string, args ...interface{}) {
+	c.UI.Output(fmt.Sprintf(s, args...), terminal.WithLibraryStyle())
+}
+
+// AutocompleteFlags returns a mapping of supported flags and autocomplete
+// options for this command. The map key for the Flags map should be the
+// complete flag such as "-foo" or "--foo".
+func (c *Command) AutocompleteFlags() complete.Flags {
+	return c.consulFlags.AutocompleteFlags()
+}
+
+// AutocompleteArgs returns the argument predictor for this command.
+// Since argument completion is not supported, this will return
+// complete.PredictNothing.
+func (c *Command) AutocompleteArgs() complete.Predictor {
+	return complete.PredictNothing
+}
+
+// Synopsis returns a one-line command summary.
+func (c *Command) Synopsis() string {
+	return "Check whether a service is allowed to connect to another service."
+}
+
+// Help returns the command's help text.
+func (c *Command) Help() string {
+	c.once.Do(c.init)
+	return fmt.Sprintf("%s\n\nUsage: consul-k8s intention check [flags] <source> <destination>\n\n%s", c.Synopsis(), c.help)
+}
@@ -0,0 +1,92 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package check
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+
+	"github.com/hashicorp/consul/api"
+	"github.com/hashicorp/go-hclog"
+	"github.com/stretchr/testify/require"
+
+	"github.com/hashicorp/consul-k8s/cli/common"
+	"github.com/hashicorp/consul-k8s/cli/common/terminal"
+)
+
+func TestRun(t *testing.T) {
+	cases := map[string]struct {
+		args           []string
+		allowed        bool
+		expectedCode   int
+		expectedOutput string
+	}{
+		"allowed": {
+			args:           []string{"web", "db"},
+			allowed:        true,
+			expectedCode:   0,
+			expectedOutput: "Allowed",
+		},
+		"denied": {
+			args:           []string{"web", "db"},
+			allowed:        false,
+			expectedCode:   2,
+			expectedOutput: "Denied",
+		},
+		"missing destination": {
+			args:           []string{"web"},
+			expectedCode:   1,
+			expectedOutput: "exactly two arguments are required: <source> <destination>",
+		},
+	}
+
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			consulServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				require.Equal(t, "/v1/connect/intentions/check", r.URL.Path)
+				require.Equal(t, "web", r.URL.Query().Get("source"))
+				require.Equal(t, "db", r.URL.Query().Get("destination"))
+				json.NewEncoder(w).Encode(map[string]bool{"Allowed": tc.allowed})
+			}))
+			defer consulServer.Close()
+
+			buf := new(bytes.Buffer)
+			c := setupCommand(t, buf, consulServer.URL)
+
+			require.Equal(t, tc.expectedCode, c.Run(tc.args))
+			require.Contains(t, buf.String(), tc.expectedOutput)
+		})
+	}
+}
+
+func setupCommand(t *testing.T, buf io.Writer, consulAddress string) *Command {
+	// Log at a test level to standard out.
+	log := hclog.New(&hclog.LoggerOptions{
+		Name:   "test",
+		Level:  hclog.Debug,
+		Output: os.Stdout,
+	})
+
+	consulClient, err := api.NewClient(&api.Config{Address: consulAddress})
+	require.NoError(t, err)
+
+	// Setup and initialize the command struct
+	command := &Command{
+		BaseCommand: &common.BaseCommand{
+			Ctx: context.Background(),
+			Log: log,
+			UI:  terminal.NewUI(context.Background(), buf),
+		},
+		consulClient: consulClient,
+	}
+	command.init()
+
+	return command
+}
@@ -0,0 +1,29 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package intention
+
+import (
+	"fmt"
+
+	"github.com/hashicorp/consul-k8s/cli/common"
+	"github.com/mitchellh/cli"
+)
+
+// IntentionCommand provides a synopsis for the intention subcommands (e.g. create).
+type IntentionCommand struct {
+	*common.BaseCommand
+}
+
+// Run prints out information about the subcommands.
+func (c *IntentionCommand) Run([]string) int {
+	return cli.RunResultHelp
+}
+
+func (c *IntentionCommand) Help() string {
+	return fmt.Sprintf("%s\n\nUsage: consul-k8s intention <subcommand>", c.Synopsis())
+}
+
+func (c *IntentionCommand) Synopsis() string {
+	return "Manage service intentions of the Consul installation."
+}
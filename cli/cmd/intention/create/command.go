@@ -0,0 +1,222 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package create
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+
+	"github.com/hashicorp/consul/api"
+	"github.com/posener/complete"
+
+	"github.com/hashicorp/consul-k8s/cli/common"
+	"github.com/hashicorp/consul-k8s/cli/common/consul"
+	"github.com/hashicorp/consul-k8s/cli/common/flag"
+	"github.com/hashicorp/consul-k8s/cli/common/terminal"
+)
+
+const (
+	flagNameAllow       = "allow"
+	flagNameDeny        = "deny"
+	flagNameDescription = "description"
+	flagNameReplace     = "replace"
+)
+
+type Command struct {
+	*common.BaseCommand
+
+	set         *flag.Sets
+	consulFlags consul.Flags
+
+	flagAllow       bool
+	flagDeny        bool
+	flagDescription string
+	flagReplace     bool
+
+	source      string
+	destination string
+
+	// consulClient is created from the installed release when it is not set.
+	consulClient *api.Client
+
+	once sync.Once
+	help string
+}
+
+func (c *Command) init() {
+	c.set = flag.NewSets()
+	f := c.set.NewSet("Command Options")
+	f.BoolVar(&flag.BoolVar{
+		Name:   flagNameAllow,
+		Target: &c.flagAllow,
+		Usage:  "Allow the source service to connect to the destination service. This is the default.",
+	})
+	f.BoolVar(&flag.BoolVar{
+		Name:   flagNameDeny,
+		Target: &c.flagDeny,
+		Usage:  "Deny the source service from connecting to the destination service.",
+	})
+	f.StringVar(&flag.StringVar{
+		Name:   flagNameDescription,
+		Target: &c.flagDescription,
+		Usage:  "A description of the intention.",
+	})
+	f.BoolVar(&flag.BoolVar{
+		Name:   flagNameReplace,
+		Target: &c.flagReplace,
+		Usage:  "Replace the intention between the source and destination services if it already exists.",
+	})
+	c.consulFlags.AddTo(c.set)
+
+	c.help = c.set.Help()
+}
+
+// Run creates an intention between two services.
+func (c *Command) Run(args []string) int {
+	c.once.Do(c.init)
+	c.Log.ResetNamed("intention create")
+	defer common.CloseWithError(c.BaseCommand)
+
+	if err := c.set.Parse(args); err != nil {
+		c.UI.Output(err.Error(), terminal.WithErrorStyle())
+		return 1
+	}
+	if err := c.validateFlags(); err != nil {
+		c.UI.Output(err.Error(), terminal.WithErrorStyle())
+		return 1
+	}
+
+	ixn, err := c.intention()
+	if err != nil {
+		c.UI.Output(err.Error(), terminal.WithErrorStyle())
+		return 1
+	}
+
+	if c.consulClient == nil {
+		conn, err := c.consulFlags.NewConnection(c.uiLogger)
+		if err != nil {
+			c.UI.Output(err.Error(), terminal.WithErrorStyle())
+			return 1
+		}
+		defer conn.Close()
+		if c.consulClient, err = conn.Open(c.Ctx); err != nil {
+			c.UI.Output(err.Error(), terminal.WithErrorStyle())
+			return 1
+		}
+	}
+
+	existing, _, err := c.consulClient.Connect().IntentionGetExact(c.source, c.destination, (&api.QueryOptions{}).WithContext(c.Ctx))
+	if err != nil {
+		c.UI.Output(fmt.Sprintf("Error reading intention from %s to %s: %s", c.source, c.destination, err), terminal.WithErrorStyle())
+		return 1
+	}
+	if existing != nil && !c.flagReplace {
+		c.UI.Output(fmt.Sprintf("An intention from %s to %s already exists, use -%s to replace it", c.source, c.destination, flagNameReplace), terminal.WithErrorStyle())
+		return 1
+	}
+
+	if _, err := c.consulClient.Connect().IntentionUpsert(ixn, (&api.WriteOptions{}).WithContext(c.Ctx)); err != nil {
+		c.UI.Output(fmt.Sprintf("Error creating intention %s: %s", ixn, err), terminal.WithErrorStyle())
+		return 1
+	}
+
+	c.UI.Output(fmt.Sprintf("Created: %s", ixn), terminal.WithSuccessStyle())
+	return 0
+}
+
+// validateFlags checks the command line flags and arguments for errors.
+func (c *Command) validateFlags() error {
+	if len(c.set.Args()) != 2 {
+		return errors.New("exactly two arguments are required: <source> <destination>")
+	}
+	c.source, c.destination = c.set.Args()[0], c.set.Args()[1]
+	if c.flagAllow && c.flagDeny {
+		return fmt.Errorf("only one of -%s or -%s can be set", flagNameAllow, flagNameDeny)
+	}
+	return nil
+}
+
+// intention returns the intention to create from the arguments.
+func (c *Command) intention() (*api.Intention, error) {
+	ixn := &api.Intention{
+		Action:      api.IntentionActionAllow,
+		Description: c.flagDescription,
+	}
+	if c.flagDeny {
+		ixn.Action = api.IntentionActionDeny
+	}
+
+	var err error
+	ixn.SourcePartition, ixn.SourceNS, ixn.SourceName, err = parseTarget(c.source)
+	if err != nil {
+		return nil, err
+	}
+	ixn.DestinationPartition, ixn.DestinationNS, ixn.DestinationName, err = parseTarget(c.destination)
+	if err != nil {
+		return nil, err
+	}
+	return ixn, nil
+}
+
+// parseTarget splits a [[partition/]namespace/]name intention target.
+func parseTarget(target string) (partition, namespace, name string, err error) {
+	parts := strings.Split(target, "/")
+	for _, part := range parts {
+		if part == "" {
+			return "", "", "", fmt.Errorf("invalid intention target %q, must be formatted as [[partition/]namespace/]name", target)
+		}
+	}
+	switch len(parts) {
+	case 1:
+		return "", "", parts[0], nil
+	case 2:
+		return "", parts[0], parts[1], nil
+	case 3:
+		return parts[0], parts[1], parts[2], nil
+	default:
+		return "", "", "", fmt.Errorf("invalid intention target %q, must be formatted as [[partition/]namespace/]name", target)
+	}
+}
+
+// uiLogger streams the logs of the Helm library to the UI.
+func (c *Command) uiLogger(s string, args ...interface{}) {
+	c.UI.Output(fmt.Sprintf(s, args...), terminal.WithLibraryStyle())
+}
+
+// AutocompleteFlags returns a mapping of supported flags and autocomplete
+// options for this command. The map key for the Flags map should be the
+// complete flag such as "-foo" or "--foo".
+func (c *Command) AutocompleteFlags() complete.Flags {
+	flags := complete.Flags{
+		fmt.Sprintf("-%s", flagNameAllow):       complete.PredictNothing,
+		fmt.Sprintf("-%s", flagNameDeny):        complete.PredictNothing,
+		fmt.Sprintf("-%s", flagNameDescription): complete.PredictNothing,
+		fmt.Sprintf("-%s", flagNameReplace):     complete.PredictNothing,
+	}
+	for name, predictor := range c.consulFlags.AutocompleteFlags() {
+		flags[name] = predictor
+	}
+	return flags
+}
+
+// AutocompleteArgs returns the argument predictor for this command.
+// Since argument completion is not supported, this will return
+// complete.PredictNothing.
+func (c *Command) AutocompleteArgs() complete.Predictor {
+	return complete.PredictNothing
+}
+
+// Synopsis returns a one-line command summary.
+func (c *Command) Synopsis() string {
+	return "Create an intention between two services."
+}
+
+// Help returns the command's help text. Services of other namespaces or
+// partitions are passed as [[partition/]namespace/]name.
+func (c *Command) Help() string {
+	c.once.Do(c.init)
+	return fmt.Sprintf("%s\n\nUsage: consul-k8s intention create [flags] <source> <destination>\n\n%s", c.Synopsis(), c.help)
+}
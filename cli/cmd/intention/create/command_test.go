@@ -0,0 +1,153 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package create
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+
+	"github.com/hashicorp/consul/api"
+	"github.com/hashicorp/go-hclog"
+	"github.com/stretchr/testify/require"
+
+	"github.com/hashicorp/consul-k8s/cli/common"
+	"github.com/hashicorp/consul-k8s/cli/common/terminal"
+)
+
+func TestRun(t *testing.T) {
+	cases := map[string]struct {
+		args              []string
+		existing          bool
+		expectedCode      int
+		expectedIntention *api.Intention
+		expectedOutput    string
+	}{
+		"allow by default": {
+			args:         []string{"web", "db"},
+			expectedCode: 0,
+			expectedIntention: &api.Intention{
+				SourceName:      "web",
+				DestinationName: "db",
+				Action:          api.IntentionActionAllow,
+			},
+			expectedOutput: "Created: web => db (allow)",
+		},
+		"deny with a description": {
+			args:         []string{"-deny", "-description", "no access", "web", "db"},
+			expectedCode: 0,
+			expectedIntention: &api.Intention{
+				SourceName:      "web",
+				DestinationName: "db",
+				Action:          api.IntentionActionDeny,
+				Description:     "no access",
+			},
+			expectedOutput: "Created: web => db (deny)",
+		},
+		"namespaces and partitions": {
+			args:         []string{"frontend/web", "ap1/backend/db"},
+			expectedCode: 0,
+			expectedIntention: &api.Intention{
+				SourceNS:             "frontend",
+				SourceName:           "web",
+				DestinationPartition: "ap1",
+				DestinationNS:        "backend",
+				DestinationName:      "db",
+				Action:               api.IntentionActionAllow,
+			},
+			expectedOutput: "Created: frontend/web => backend/db (allow)",
+		},
+		"existing intention": {
+			args:           []string{"web", "db"},
+			existing:       true,
+			expectedCode:   1,
+			expectedOutput: "An intention from web to db already exists, use -replace to replace it",
+		},
+		"existing intention with -replace": {
+			args:         []string{"-replace", "-deny", "web", "db"},
+			existing:     true,
+			expectedCode: 0,
+			expectedIntention: &api.Intention{
+				SourceName:      "web",
+				DestinationName: "db",
+				Action:          api.IntentionActionDeny,
+			},
+			expectedOutput: "Created: web => db (deny)",
+		},
+		"missing destination": {
+			args:           []string{"web"},
+			expectedCode:   1,
+			expectedOutput: "exactly two arguments are required: <source> <destination>",
+		},
+		"both -allow and -deny": {
+			args:           []string{"-allow", "-deny", "web", "db"},
+			expectedCode:   1,
+			expectedOutput: "only one of -allow or -deny can be set",
+		},
+		"invalid target": {
+			args:           []string{"web", "a/b/c/db"},
+			expectedCode:   1,
+			expectedOutput: `invalid intention target "a/b/c/db", must be formatted as [[partition/]namespace/]name`,
+		},
+	}
+
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			var upserted *api.Intention
+			consulServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				require.Equal(t, "/v1/connect/intentions/exact", r.URL.Path)
+				switch r.Method {
+				case http.MethodGet:
+					if !tc.existing {
+						w.WriteHeader(http.StatusNotFound)
+						return
+					}
+					json.NewEncoder(w).Encode(&api.Intention{SourceName: "web", DestinationName: "db", Action: api.IntentionActionAllow})
+				case http.MethodPut:
+					upserted = &api.Intention{}
+					require.NoError(t, json.NewDecoder(r.Body).Decode(upserted))
+					w.Write([]byte("true"))
+				}
+			}))
+			defer consulServer.Close()
+
+			buf := new(bytes.Buffer)
+			c := setupCommand(t, buf, consulServer.URL)
+
+			require.Equal(t, tc.expectedCode, c.Run(tc.args))
+			require.Contains(t, buf.String(), tc.expectedOutput)
+			require.Equal(t, tc.expectedIntention, upserted)
+		})
+	}
+}
+
+func setupCommand(t *testing.T, buf io.Writer, consulAddress string) *Command {
+	// Log at a test level to standard out.
+	log := hclog.New(&hclog.LoggerOptions{
+		Name:   "test",
+		Level:  hclog.Debug,
+		Output: os.Stdout,
+	})
+
+	consulClient, err := api.NewClient(&api.Config{Address: consulAddress})
+	require.NoError(t, err)
+
+	// Setup and initialize the command struct
+	command := &Command{
+		BaseCommand: &common.BaseCommand{
+			Ctx: context.Background(),
+			Log: log,
+			UI:  terminal.NewUI(context.Background(), buf),
+		},
+		consulClient: consulClient,
+	}
+	command.init()
+
+	return command
+}
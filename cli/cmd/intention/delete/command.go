@@ -0,0 +1,127 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package delete
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+
+	"github.com/hashicorp/consul/api"
+	"github.com/posener/complete"
+
+	"github.com/hashicorp/consul-k8s/cli/common"
+	"github.com/hashicorp/consul-k8s/cli/common/consul"
+	"github.com/hashicorp/consul-k8s/cli/common/flag"
+	"github.com/hashicorp/consul-k8s/cli/common/terminal"
+)
+
+type Command struct {
+	*common.BaseCommand
+
+	set         *flag.Sets
+	consulFlags consul.Flags
+
+	source      string
+	destination string
+
+	// consulClient is created from the installed release when it is not set.
+	consulClient *api.Client
+
+	once sync.Once
+	help string
+}
+
+func (c *Command) init() {
+	c.set = flag.NewSets()
+	c.consulFlags.AddTo(c.set)
+
+	c.help = c.set.Help()
+}
+
+// Run deletes the intention between two services.
+func (c *Command) Run(args []string) int {
+	c.once.Do(c.init)
+	c.Log.ResetNamed("intention delete")
+	defer common.CloseWithError(c.BaseCommand)
+
+	if err := c.set.Parse(args); err != nil {
+		c.UI.Output(err.Error(), terminal.WithErrorStyle())
+		return 1
+	}
+	if err := c.validateFlags(); err != nil {
+		c.UI.Output(err.Error(), terminal.WithErrorStyle())
+		return 1
+	}
+
+	if c.consulClient == nil {
+		conn, err := c.consulFlags.NewConnection(c.uiLogger)
+		if err != nil {
+			c.UI.Output(err.Error(), terminal.WithErrorStyle())
+			return 1
+		}
+		defer conn.Close()
+		if c.consulClient, err = conn.Open(c.Ctx); err != nil {
+			c.UI.Output(err.Error(), terminal.WithErrorStyle())
+			return 1
+		}
+	}
+
+	existing, _, err := c.consulClient.Connect().IntentionGetExact(c.source, c.destination, (&api.QueryOptions{}).WithContext(c.Ctx))
+	if err != nil {
+		c.UI.Output(fmt.Sprintf("Error reading intention from %s to %s: %s", c.source, c.destination, err), terminal.WithErrorStyle())
+		return 1
+	}
+	if existing == nil {
+		c.UI.Output(fmt.Sprintf("No intention found from %s to %s", c.source, c.destination), terminal.WithErrorStyle())
+		return 1
+	}
+
+	if _, err := c.consulClient.Connect().IntentionDeleteExact(c.source, c.destination, (&api.WriteOptions{}).WithContext(c.Ctx)); err != nil {
+		c.UI.Output(fmt.Sprintf("Error deleting intention %s: %s", existing, err), terminal.WithErrorStyle())
+		return 1
+	}
+
+	c.UI.Output(fmt.Sprintf("Deleted: %s", existing), terminal.WithSuccessStyle())
+	return 0
+}
+
+// validateFlags checks the command line flags and arguments for errors.
+func (c *Command) validateFlags() error {
+	if len(c.set.Args()) != 2 {
+		return errors.New("exactly two arguments are required: <source> <destination>")
+	}
+	c.source, c.destination = c.set.Args()[0], c.set.Args()[1]
+	return nil
+}
+
+// uiLogger streams the logs of the Helm library to the UI.
+func (c *Command) uiLogger(s string, args ...interface{}) {
+	c.UI.Output(fmt.Sprintf(s, args...), terminal.WithLibraryStyle())
+}
+
+// AutocompleteFlags returns a mapping of supported flags and autocomplete
+// options for this command. The map key for the Flags map should be the
+// complete flag such as "-foo" or "--foo".
+func (c *Command) AutocompleteFlags() complete.Flags {
+	return c.consulFlags.AutocompleteFlags()
+}
+
+// AutocompleteArgs returns the argument predictor for this command.
+// Since argument completion is not supported, this will return
+// complete.PredictNothing.
+func (c *Command) AutocompleteArgs() complete.Predictor {
+	return complete.PredictNothing
+}
+
+// Synopsis returns a one-line command summary.
+func (c *Command) Synopsis() string {
+	return "Delete the intention between two services."
+}
+
+// Help returns the command's help text.
+func (c *Command) Help() string {
+	c.once.Do(c.init)
+	return fmt.Sprintf("%s\n\nUsage: consul-k8s intention delete [flags] <source> <destination>\n\n%s", c.Synopsis(), c.help)
+}
@@ -0,0 +1,105 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package delete
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+
+	"github.com/hashicorp/consul/api"
+	"github.com/hashicorp/go-hclog"
+	"github.com/stretchr/testify/require"
+
+	"github.com/hashicorp/consul-k8s/cli/common"
+	"github.com/hashicorp/consul-k8s/cli/common/terminal"
+)
+
+func TestRun(t *testing.T) {
+	cases := map[string]struct {
+		args           []string
+		existing       bool
+		expectedCode   int
+		expectDelete   bool
+		expectedOutput string
+	}{
+		"existing intention": {
+			args:           []string{"web", "db"},
+			existing:       true,
+			expectedCode:   0,
+			expectDelete:   true,
+			expectedOutput: "Deleted: web => db (allow)",
+		},
+		"no intention": {
+			args:           []string{"web", "db"},
+			expectedCode:   1,
+			expectedOutput: "No intention found from web to db",
+		},
+		"missing destination": {
+			args:           []string{"web"},
+			expectedCode:   1,
+			expectedOutput: "exactly two arguments are required: <source> <destination>",
+		},
+	}
+
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			var deleted bool
+			consulServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				require.Equal(t, "/v1/connect/intentions/exact", r.URL.Path)
+				require.Equal(t, "web", r.URL.Query().Get("source"))
+				require.Equal(t, "db", r.URL.Query().Get("destination"))
+				switch r.Method {
+				case http.MethodGet:
+					if !tc.existing {
+						w.WriteHeader(http.StatusNotFound)
+						return
+					}
+					json.NewEncoder(w).Encode(&api.Intention{SourceName: "web", DestinationName: "db", Action: api.IntentionActionAllow})
+				case http.MethodDelete:
+					deleted = true
+					w.Write([]byte("true"))
+				}
+			}))
+			defer consulServer.Close()
+
+			buf := new(bytes.Buffer)
+			c := setupCommand(t, buf, consulServer.URL)
+
+			require.Equal(t, tc.expectedCode, c.Run(tc.args))
+			require.Contains(t, buf.String(), tc.expectedOutput)
+			require.Equal(t, tc.expectDelete, deleted)
+		})
+	}
+}
+
+func setupCommand(t *testing.T, buf io.Writer, consulAddress string) *Command {
+	// Log at a test level to standard out.
+	log := hclog.New(&hclog.LoggerOptions{
+		Name:   "test",
+		Level:  hclog.Debug,
+		Output: os.Stdout,
+	})
+
+	consulClient, err := api.NewClient(&api.Config{Address: consulAddress})
+	require.NoError(t, err)
+
+	// Setup and initialize the command struct
+	command := &Command{
+		BaseCommand: &common.BaseCommand{
+			Ctx: context.Background(),
+			Log: log,
+			UI:  terminal.NewUI(context.Background(), buf),
+		},
+		consulClient: consulClient,
+	}
+	command.init()
+
+	return command
+}
@@ -0,0 +1,134 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package list
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"sync"
+
+	"github.com/hashicorp/consul/api"
+	"github.com/posener/complete"
+
+	"github.com/hashicorp/consul-k8s/cli/common"
+	"github.com/hashicorp/consul-k8s/cli/common/consul"
+	"github.com/hashicorp/consul-k8s/cli/common/flag"
+	"github.com/hashicorp/consul-k8s/cli/common/terminal"
+)
+
+type Command struct {
+	*common.BaseCommand
+
+	set         *flag.Sets
+	consulFlags consul.Flags
+
+	// consulClient is created from the installed release when it is not set.
+	consulClient *api.Client
+
+	once sync.Once
+	help string
+}
+
+func (c *Command) init() {
+	c.set = flag.NewSets()
+	c.consulFlags.AddTo(c.set)
+
+	c.help = c.set.Help()
+}
+
+// Run lists the intentions in order of precedence.
+func (c *Command) Run(args []string) int {
+	c.once.Do(c.init)
+	c.Log.ResetNamed("intention list")
+	defer common.CloseWithError(c.BaseCommand)
+
+	if err := c.set.Parse(args); err != nil {
+		c.UI.Output(err.Error(), terminal.WithErrorStyle())
+		return 1
+	}
+	if err := c.validateFlags(); err != nil {
+		c.UI.Output(err.Error(), terminal.WithErrorStyle())
+		return 1
+	}
+
+	if c.consulClient == nil {
+		conn, err := c.consulFlags.NewConnection(c.uiLogger)
+		if err != nil {
+			c.UI.Output(err.Error(), terminal.WithErrorStyle())
+			return 1
+		}
+		defer conn.Close()
+		if c.consulClient, err = conn.Open(c.Ctx); err != nil {
+			c.UI.Output(err.Error(), terminal.WithErrorStyle())
+			return 1
+		}
+	}
+
+	ixns, _, err := c.consulClient.Connect().Intentions((&api.QueryOptions{}).WithContext(c.Ctx))
+	if err != nil {
+		c.UI.Output(fmt.Sprintf("Error listing intentions: %s", err), terminal.WithErrorStyle())
+		return 1
+	}
+	if len(ixns) == 0 {
+		c.UI.Output("No intentions found.")
+		return 0
+	}
+
+	tbl := terminal.NewTable("Source", "Destination", "Action", "Precedence", "Description")
+	for _, ixn := range ixns {
+		action := string(ixn.Action)
+		if len(ixn.Permissions) > 0 {
+			// L7 intentions don't have an action but per-request permissions.
+			action = fmt.Sprintf("%d permission(s)", len(ixn.Permissions))
+		}
+		tbl.AddRow([]string{
+			ixn.SourceString(),
+			ixn.DestinationString(),
+			action,
+			strconv.Itoa(ixn.Precedence),
+			ixn.Description,
+		}, []string{})
+	}
+	c.UI.Table(tbl)
+	return 0
+}
+
+// validateFlags checks the command line flags and arguments for errors.
+func (c *Command) validateFlags() error {
+	if len(c.set.Args()) > 0 {
+		return errors.New("should have no non-flag arguments")
+	}
+	return nil
+}
+
+// uiLogger streams the logs of the Helm library to the UI.
+func (c *Command) uiLogger(s string, args ...interface{}) {
+	c.UI.Output(fmt.Sprintf(s, args...), terminal.WithLibraryStyle())
+}
+
+// AutocompleteFlags returns a mapping of supported flags and autocomplete
+// options for this command. The map key for the Flags map should be the
+// complete flag such as "-foo" or "--foo".
+func (c *Command) AutocompleteFlags() complete.Flags {
+	return c.consulFlags.AutocompleteFlags()
+}
+
+// AutocompleteArgs returns the argument predictor for this command.
+// Since argument completion is not supported, this will return
+// complete.PredictNothing.
+func (c *Command) AutocompleteArgs() complete.Predictor {
+	return complete.PredictNothing
+}
+
+// Synopsis returns a one-line command summary.
+func (c *Command) Synopsis() string {
+	return "List the intentions between services."
+}
+
+// Help returns the command's help text.
+func (c *Command) Help() string {
+	c.once.Do(c.init)
+	return fmt.Sprintf("%s\n\nUsage: consul-k8s intention list [flags]\n\n%s", c.Synopsis(), c.help)
+}
@@ -0,0 +1,96 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package list
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+
+	"github.com/hashicorp/consul/api"
+	"github.com/hashicorp/go-hclog"
+	"github.com/stretchr/testify/require"
+
+	"github.com/hashicorp/consul-k8s/cli/common"
+	"github.com/hashicorp/consul-k8s/cli/common/terminal"
+)
+
+func TestRun(t *testing.T) {
+	cases := map[string]struct {
+		args           []string
+		intentions     []*api.Intention
+		expectedCode   int
+		expectedOutput []string
+	}{
+		"no intentions": {
+			expectedCode:   0,
+			expectedOutput: []string{"No intentions found."},
+		},
+		"intentions": {
+			intentions: []*api.Intention{
+				{SourceName: "web", DestinationName: "db", Action: api.IntentionActionAllow, Precedence: 9, Description: "web to db"},
+				{SourceNS: "frontend", SourceName: "*", DestinationName: "api", Permissions: []*api.IntentionPermission{{Action: api.IntentionActionAllow}}, Precedence: 8},
+			},
+			expectedCode: 0,
+			expectedOutput: []string{
+				"Source", "Destination", "Action", "Precedence", "Description",
+				"web", "db", "allow", "9", "web to db",
+				"frontend/*", "api", "1 permission(s)", "8",
+			},
+		},
+		"unexpected argument": {
+			args:           []string{"web"},
+			expectedCode:   1,
+			expectedOutput: []string{"should have no non-flag arguments"},
+		},
+	}
+
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			consulServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				require.Equal(t, "/v1/connect/intentions", r.URL.Path)
+				json.NewEncoder(w).Encode(tc.intentions)
+			}))
+			defer consulServer.Close()
+
+			buf := new(bytes.Buffer)
+			c := setupCommand(t, buf, consulServer.URL)
+
+			require.Equal(t, tc.expectedCode, c.Run(tc.args))
+			for _, expected := range tc.expectedOutput {
+				require.Contains(t, buf.String(), expected)
+			}
+		})
+	}
+}
+
+func setupCommand(t *testing.T, buf io.Writer, consulAddress string) *Command {
+	// Log at a test level to standard out.
+	log := hclog.New(&hclog.LoggerOptions{
+		Name:   "test",
+		Level:  hclog.Debug,
+		Output: os.Stdout,
+	})
+
+	consulClient, err := api.NewClient(&api.Config{Address: consulAddress})
+	require.NoError(t, err)
+
+	// Setup and initialize the command struct
+	command := &Command{
+		BaseCommand: &common.BaseCommand{
+			Ctx: context.Background(),
+			Log: log,
+			UI:  terminal.NewUI(context.Background(), buf),
+		},
+		consulClient: consulClient,
+	}
+	command.init()
+
+	return command
+}
@@ -0,0 +1,417 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package logs
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/fatih/color"
+	"github.com/posener/complete"
+	helmCLI "helm.sh/helm/v3/pkg/cli"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/validation"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+
+	"github.com/hashicorp/consul-k8s/cli/common"
+	"github.com/hashicorp/consul-k8s/cli/common/flag"
+	"github.com/hashicorp/consul-k8s/cli/common/terminal"
+	"github.com/hashicorp/consul-k8s/cli/helm"
+)
+
+const (
+	componentConnectInject = "connect-inject"
+	componentGateway       = "gateway"
+	componentServer        = "server"
+
+	flagNameComponent     = "component"
+	flagNameContainer     = "container"
+	flagNameSince         = "since"
+	flagNameFollow        = "follow"
+	flagNameNamespace     = "namespace"
+	flagNameAllNamespaces = "all-namespaces"
+	flagNameKubeConfig    = "kubeconfig"
+	flagNameKubeContext   = "context"
+)
+
+// componentSelectors are the label selectors of the pods of each component of a release.
+var componentSelectors = map[string]string{
+	componentConnectInject: "component=connect-injector,release=%s",
+	componentGateway:       "component in (mesh-gateway,ingress-gateway,terminating-gateway),release=%s",
+	componentServer:        "component=server,release=%s",
+}
+
+// apiGatewaySelector is the label selector of the pods of API gateways. They are deployed by the
+// connect injector rather than the Helm chart, so they don't have the release label.
+const apiGatewaySelector = "component=api-gateway,gateway.consul.hashicorp.com/managed=true"
+
+// podColors are the colors the pod names are printed in. The colors are
+// reused if there are more pods than colors.
+var podColors = []*color.Color{
+	color.New(color.FgCyan),
+	color.New(color.FgGreen),
+	color.New(color.FgMagenta),
+	color.New(color.FgYellow),
+	color.New(color.FgBlue),
+	color.New(color.FgHiCyan),
+	color.New(color.FgHiGreen),
+	color.New(color.FgHiMagenta),
+}
+
+// logLine is a line of the logs of a container.
+type logLine struct {
+	container int
+	line      string
+}
+
+// podContainer is a container of a pod whose logs are streamed.
+type podContainer struct {
+	pod       corev1.Pod
+	container string
+}
+
+type LogsCommand struct {
+	*common.BaseCommand
+
+	helmActionsRunner helm.HelmActionsRunner
+
+	kubernetes kubernetes.Interface
+
+	set *flag.Sets
+
+	// Command Flags
+	flagComponent     string
+	flagContainer     string
+	flagSince         time.Duration
+	flagFollow        bool
+	flagNamespace     string
+	flagAllNamespaces bool
+
+	// Global Flags
+	flagKubeConfig  string
+	flagKubeContext string
+
+	once sync.Once
+	help string
+}
+
+func (c *LogsCommand) init() {
+	c.set = flag.NewSets()
+	f := c.set.NewSet("Command Options")
+	f.EnumSingleVar(&flag.EnumSingleVar{
+		Name:   flagNameComponent,
+		Target: &c.flagComponent,
+		Values: []string{componentConnectInject, componentGateway, componentServer},
+		Usage:  "The component to show the logs of.",
+	})
+	f.StringVar(&flag.StringVar{
+		Name:   flagNameContainer,
+		Target: &c.flagContainer,
+		Usage:  "Only show the logs of the containers with this name, e.g. consul-dataplane. The logs of all containers of the pods are shown by default.",
+	})
+	f.DurationVar(&flag.DurationVar{
+		Name:   flagNameSince,
+		Target: &c.flagSince,
+		Usage:  "Only show logs newer than a relative duration like 10m or 1h. All logs are shown by default.",
+	})
+	f.BoolVar(&flag.BoolVar{
+		Name:    flagNameFollow,
+		Target:  &c.flagFollow,
+		Usage:   "Stream the logs as they are written.",
+		Aliases: []string{"f"},
+	})
+	f.StringVar(&flag.StringVar{
+		Name:    flagNameNamespace,
+		Target:  &c.flagNamespace,
+		Usage:   "The namespace where the pods of the component can be found. Defaults to the namespace of the Consul installation.",
+		Aliases: []string{"n"},
+	})
+	f.BoolVar(&flag.BoolVar{
+		Name:    flagNameAllNamespaces,
+		Target:  &c.flagAllNamespaces,
+		Usage:   "Show the logs of the pods of the component in all namespaces, e.g. of API gateways in application namespaces.",
+		Aliases: []string{"A"},
+	})
+
+	f = c.set.NewSet("Global Options")
+	f.StringVar(&flag.StringVar{
+		Name:    flagNameKubeConfig,
+		Aliases: []string{"c"},
+		Target:  &c.flagKubeConfig,
+		Usage:   "Set the path to kubeconfig file.",
+	})
+	f.StringVar(&flag.StringVar{
+		Name:   flagNameKubeContext,
+		Target: &c.flagKubeContext,
+		Usage:  "Set the Kubernetes context to use.",
+	})
+
+	c.help = c.set.Help()
+}
+
+// Run streams the logs of all pods of a component.
+func (c *LogsCommand) Run(args []string) int {
+	c.once.Do(c.init)
+	if c.helmActionsRunner == nil {
+		c.helmActionsRunner = &helm.ActionRunner{}
+	}
+	c.Log.ResetNamed("logs")
+	defer common.CloseWithError(c.BaseCommand)
+
+	if err := c.set.Parse(args); err != nil {
+		c.UI.Output(err.Error(), terminal.WithErrorStyle())
+		c.UI.Output("\n" + c.Help())
+		return 1
+	}
+
+	if err := c.validateFlags(); err != nil {
+		c.UI.Output(err.Error(), terminal.WithErrorStyle())
+		c.UI.Output("\n" + c.Help())
+		return 1
+	}
+
+	settings := helmCLI.New()
+	if c.flagKubeConfig != "" {
+		settings.KubeConfig = c.flagKubeConfig
+	}
+	if c.flagKubeContext != "" {
+		settings.KubeContext = c.flagKubeContext
+	}
+
+	if err := c.initKubernetes(settings); err != nil {
+		c.UI.Output(err.Error(), terminal.WithErrorStyle())
+		return 1
+	}
+
+	// Setup logger to stream Helm library logs.
+	var uiLogger = func(s string, args ...interface{}) {
+		logMsg := fmt.Sprintf(s, args...)
+		c.UI.Output(logMsg, terminal.WithLibraryStyle())
+	}
+
+	_, releaseName, releaseNamespace, err := c.helmActionsRunner.CheckForInstallations(&helm.CheckForInstallationsOptions{
+		Settings:    settings,
+		ReleaseName: common.DefaultReleaseName,
+		DebugLog:    uiLogger,
+	})
+	if err != nil {
+		c.UI.Output(err.Error(), terminal.WithErrorStyle())
+		return 1
+	}
+
+	namespace := c.flagNamespace
+	if namespace == "" {
+		namespace = releaseNamespace
+	}
+	if c.flagAllNamespaces {
+		namespace = ""
+	}
+	pods, err := c.listPods(c.Ctx, releaseName, namespace)
+	if err != nil {
+		c.UI.Output(err.Error(), terminal.WithErrorStyle())
+		return 1
+	}
+
+	containers := c.podContainers(pods)
+	if len(containers) == 0 {
+		if c.flagContainer != "" {
+			c.UI.Output(fmt.Sprintf("No %s pods with container %q found.", c.flagComponent, c.flagContainer), terminal.WithWarningStyle())
+		} else {
+			c.UI.Output(fmt.Sprintf("No %s pods found.", c.flagComponent), terminal.WithWarningStyle())
+		}
+		return 0
+	}
+
+	if err := c.streamLogs(c.Ctx, containers); err != nil {
+		c.UI.Output(err.Error(), terminal.WithErrorStyle())
+		return 1
+	}
+	return 0
+}
+
+func (c *LogsCommand) Help() string {
+	c.once.Do(c.init)
+	return fmt.Sprintf("%s\n\nUsage: consul-k8s logs -component <component> [flags]\n\n%s", c.Synopsis(), c.help)
+}
+
+func (c *LogsCommand) Synopsis() string {
+	return "Show the logs of all pods of a component."
+}
+
+// AutocompleteFlags returns a mapping of supported flags and autocomplete
+// options for this command. The map key for the Flags map should be the
+// complete flag such as "-foo" or "--foo".
+func (c *LogsCommand) AutocompleteFlags() complete.Flags {
+	return complete.Flags{
+		fmt.Sprintf("-%s", flagNameComponent):     complete.PredictSet(componentConnectInject, componentGateway, componentServer),
+		fmt.Sprintf("-%s", flagNameContainer):     complete.PredictNothing,
+		fmt.Sprintf("-%s", flagNameSince):         complete.PredictNothing,
+		fmt.Sprintf("-%s", flagNameFollow):        complete.PredictNothing,
+		fmt.Sprintf("-%s", flagNameNamespace):     complete.PredictNothing,
+		fmt.Sprintf("-%s", flagNameAllNamespaces): complete.PredictNothing,
+		fmt.Sprintf("-%s", flagNameKubeConfig):    complete.PredictFiles("*"),
+		fmt.Sprintf("-%s", flagNameKubeContext):   complete.PredictNothing,
+	}
+}
+
+// AutocompleteArgs returns the argument predictor for this command.
+// Since argument completion is not supported, this will return
+// complete.PredictNothing.
+func (c *LogsCommand) AutocompleteArgs() complete.Predictor {
+	return complete.PredictNothing
+}
+
+func (c *LogsCommand) validateFlags() error {
+	if len(c.set.Args()) > 0 {
+		return errors.New("should have no non-flag arguments")
+	}
+	if c.flagComponent == "" {
+		return fmt.Errorf("-%s must be set.", flagNameComponent)
+	}
+	if c.flagSince < 0 {
+		return fmt.Errorf("-%s must not be negative.", flagNameSince)
+	}
+	if errs := validation.ValidateNamespaceName(c.flagNamespace, false); c.flagNamespace != "" && len(errs) > 0 {
+		return fmt.Errorf("invalid namespace name passed for -namespace/-n: %v", strings.Join(errs, "; "))
+	}
+	return nil
+}
+
+func (c *LogsCommand) initKubernetes(settings *helmCLI.EnvSettings) (err error) {
+	if c.kubernetes == nil {
+		restConfig, err := settings.RESTClientGetter().ToRESTConfig()
+		if err != nil {
+			return fmt.Errorf("error creating Kubernetes REST config %v", err)
+		}
+		if c.kubernetes, err = kubernetes.NewForConfig(restConfig); err != nil {
+			return fmt.Errorf("error creating Kubernetes client %v", err)
+		}
+	}
+
+	return nil
+}
+
+// listPods returns the pods of the component of the release, sorted by namespace and name.
+func (c *LogsCommand) listPods(ctx context.Context, releaseName, namespace string) ([]corev1.Pod, error) {
+	selectors := []string{fmt.Sprintf(componentSelectors[c.flagComponent], releaseName)}
+	if c.flagComponent == componentGateway {
+		selectors = append(selectors, apiGatewaySelector)
+	}
+
+	var pods []corev1.Pod
+	for _, selector := range selectors {
+		list, err := c.kubernetes.CoreV1().Pods(namespace).List(ctx, metav1.ListOptions{LabelSelector: selector})
+		if err != nil {
+			return nil, fmt.Errorf("error listing the pods of %s: %w", c.flagComponent, err)
+		}
+		pods = append(pods, list.Items...)
+	}
+
+	sort.Slice(pods, func(i, j int) bool {
+		if pods[i].Namespace != pods[j].Namespace {
+			return pods[i].Namespace < pods[j].Namespace
+		}
+		return pods[i].Name < pods[j].Name
+	})
+	return pods, nil
+}
+
+// podContainers returns the containers of the pods whose logs are streamed: the container named by
+// -container, or else every container.
+func (c *LogsCommand) podContainers(pods []corev1.Pod) []podContainer {
+	var containers []podContainer
+	for _, pod := range pods {
+		for _, container := range pod.Spec.Containers {
+			if c.flagContainer == "" || container.Name == c.flagContainer {
+				containers = append(containers, podContainer{pod: pod, container: container.Name})
+			}
+		}
+	}
+	return containers
+}
+
+// streamLogs streams the logs of the containers concurrently and prints the lines as they arrive,
+// prefixed with the name of their pod, and of their container if the pod has more than one.
+func (c *LogsCommand) streamLogs(ctx context.Context, containers []podContainer) error {
+	// The containers of a pod are next to each other and are printed in the color of the pod.
+	prefixes := make([]string, len(containers))
+	pod := -1
+	for i, pc := range containers {
+		if i == 0 || pc.pod.Namespace != containers[i-1].pod.Namespace || pc.pod.Name != containers[i-1].pod.Name {
+			pod++
+		}
+		name := pc.pod.Name
+		if c.flagAllNamespaces {
+			name = pc.pod.Namespace + "/" + pc.pod.Name
+		}
+		if c.flagContainer == "" && len(pc.pod.Spec.Containers) > 1 {
+			name += "/" + pc.container
+		}
+		prefixes[i] = podColors[pod%len(podColors)].Sprintf("[%s]", name)
+	}
+
+	lines := make(chan logLine)
+	errs := make(chan error, len(containers))
+	var wg sync.WaitGroup
+	for i := range containers {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			if err := c.streamContainerLogs(ctx, containers[i], i, lines); err != nil {
+				errs <- fmt.Errorf("error streaming the logs of container %s of %s: %w", containers[i].container, containers[i].pod.Name, err)
+			}
+		}(i)
+	}
+	go func() {
+		wg.Wait()
+		close(lines)
+		close(errs)
+	}()
+
+	for l := range lines {
+		c.UI.Output("%s %s", prefixes[l.container], l.line)
+	}
+
+	var err error
+	for e := range errs {
+		err = errors.Join(err, e)
+	}
+	return err
+}
+
+// streamContainerLogs sends the log lines of the container to lines.
+func (c *LogsCommand) streamContainerLogs(ctx context.Context, pc podContainer, index int, lines chan<- logLine) error {
+	opts := &corev1.PodLogOptions{Container: pc.container, Follow: c.flagFollow}
+	if c.flagSince > 0 {
+		seconds := int64(c.flagSince.Seconds())
+		opts.SinceSeconds = &seconds
+	}
+
+	stream, err := c.kubernetes.CoreV1().Pods(pc.pod.Namespace).GetLogs(pc.pod.Name, opts).Stream(ctx)
+	if err != nil {
+		return err
+	}
+	defer stream.Close()
+
+	scanner := bufio.NewScanner(stream)
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		select {
+		case lines <- logLine{container: index, line: scanner.Text()}:
+		case <-ctx.Done():
+			return nil
+		}
+	}
+	if err := scanner.Err(); err != nil && ctx.Err() == nil {
+		return err
+	}
+	return nil
+}
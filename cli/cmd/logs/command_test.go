@@ -0,0 +1,173 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package logs
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"os"
+	"testing"
+
+	"github.com/hashicorp/go-hclog"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+
+	"github.com/hashicorp/consul-k8s/cli/common"
+	"github.com/hashicorp/consul-k8s/cli/common/terminal"
+	"github.com/hashicorp/consul-k8s/cli/helm"
+)
+
+func TestFlagParsing(t *testing.T) {
+	cases := map[string]struct {
+		args []string
+		out  int
+	}{
+		"No args": {
+			args: []string{},
+			out:  1,
+		},
+		"Unknown component": {
+			args: []string{"-component", "client"},
+			out:  1,
+		},
+		"Positional argument": {
+			args: []string{"-component", "server", "consul-server-0"},
+			out:  1,
+		},
+		"Negative since": {
+			args: []string{"-component", "server", "-since", "-10m"},
+			out:  1,
+		},
+		"Invalid namespace": {
+			args: []string{"-component", "server", "-namespace", "YOLO"},
+			out:  1,
+		},
+		"No pods": {
+			args: []string{"-component", "server", "-since", "10m"},
+			out:  0,
+		},
+	}
+
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			c := setupCommand(new(bytes.Buffer))
+			c.kubernetes = fake.NewSimpleClientset()
+			require.Equal(t, tc.out, c.Run(tc.args))
+		})
+	}
+}
+
+func TestLogs(t *testing.T) {
+	pods := []testPod{
+		{name: "consul-server-1", namespace: "consul", labels: map[string]string{"app": "consul", "component": "server", "release": "consul"}},
+		{name: "consul-server-0", namespace: "consul", labels: map[string]string{"app": "consul", "component": "server", "release": "consul"}},
+		{name: "other-server-0", namespace: "consul", labels: map[string]string{"app": "consul", "component": "server", "release": "other"}},
+		{name: "consul-connect-injector-0", namespace: "consul", labels: map[string]string{"app": "consul", "component": "connect-injector", "release": "consul"}},
+		{name: "api-gateway", namespace: "default", labels: map[string]string{"component": "api-gateway", "gateway.consul.hashicorp.com/managed": "true"}},
+		{name: "mesh-gateway", namespace: "consul", labels: map[string]string{"app": "consul", "component": "mesh-gateway", "release": "consul"}},
+		{name: "other-mesh-gateway", namespace: "consul", labels: map[string]string{"app": "consul", "component": "mesh-gateway", "release": "other"}},
+	}
+
+	cases := map[string]struct {
+		args        []string
+		expected    []string
+		notExpected []string
+	}{
+		"server": {
+			args:        []string{"-component", "server", "-namespace", "consul"},
+			expected:    []string{"[consul-server-0] fake logs", "[consul-server-1] fake logs"},
+			notExpected: []string{"connect-injector", "gateway", "other-server"},
+		},
+		"namespace of the release": {
+			args:     []string{"-component", "server"},
+			expected: []string{"[consul-server-0] fake logs", "[consul-server-1] fake logs"},
+		},
+		"connect-inject": {
+			args:        []string{"-component", "connect-inject", "-n", "consul"},
+			expected:    []string{"[consul-connect-injector-0/sidecar-injector] fake logs", "[consul-connect-injector-0/consul-dataplane] fake logs"},
+			notExpected: []string{"server", "gateway"},
+		},
+		"container of connect-inject": {
+			args:        []string{"-component", "connect-inject", "-n", "consul", "-container", "sidecar-injector"},
+			expected:    []string{"[consul-connect-injector-0] fake logs"},
+			notExpected: []string{"consul-dataplane"},
+		},
+		"no pods with the container": {
+			args:     []string{"-component", "server", "-n", "consul", "-container", "sidecar-injector"},
+			expected: []string{`No server pods with container "sidecar-injector" found.`},
+		},
+		"gateways in the namespace": {
+			args:        []string{"-component", "gateway", "-n", "consul"},
+			expected:    []string{"[mesh-gateway] fake logs"},
+			notExpected: []string{"api-gateway", "other-mesh-gateway"},
+		},
+		"gateways in all namespaces": {
+			args:     []string{"-component", "gateway", "-A", "-f"},
+			expected: []string{"[consul/mesh-gateway] fake logs", "[default/api-gateway] fake logs"},
+		},
+	}
+
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			buf := new(bytes.Buffer)
+			c := setupCommand(buf)
+			c.kubernetes = fake.NewSimpleClientset()
+			for _, p := range pods {
+				containers := []corev1.Container{{Name: "consul"}}
+				if p.labels["component"] == "connect-injector" {
+					containers = []corev1.Container{{Name: "sidecar-injector"}, {Name: "consul-dataplane"}}
+				}
+				_, err := c.kubernetes.CoreV1().Pods(p.namespace).Create(context.Background(), &corev1.Pod{
+					ObjectMeta: metav1.ObjectMeta{Name: p.name, Namespace: p.namespace, Labels: p.labels},
+					Spec:       corev1.PodSpec{Containers: containers},
+				}, metav1.CreateOptions{})
+				require.NoError(t, err)
+			}
+
+			require.Equal(t, 0, c.Run(tc.args))
+			for _, expected := range tc.expected {
+				require.Contains(t, buf.String(), expected)
+			}
+			for _, notExpected := range tc.notExpected {
+				require.NotContains(t, buf.String(), notExpected)
+			}
+		})
+	}
+}
+
+// testPod describes a pod of a component.
+type testPod struct {
+	name      string
+	namespace string
+	labels    map[string]string
+}
+
+func setupCommand(buf io.Writer) *LogsCommand {
+	// Log at a test level to standard out.
+	log := hclog.New(&hclog.LoggerOptions{
+		Name:   "test",
+		Level:  hclog.Debug,
+		Output: os.Stdout,
+	})
+
+	// Setup and initialize the command struct
+	command := &LogsCommand{
+		BaseCommand: &common.BaseCommand{
+			Ctx: context.Background(),
+			Log: log,
+			UI:  terminal.NewUI(context.Background(), buf),
+		},
+		helmActionsRunner: &helm.MockActionRunner{
+			CheckForInstallationsFunc: func(options *helm.CheckForInstallationsOptions) (bool, string, string, error) {
+				return true, "consul", "consul", nil
+			},
+		},
+	}
+	command.init()
+
+	return command
+}
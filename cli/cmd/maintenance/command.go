@@ -0,0 +1,29 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package maintenance
+
+import (
+	"fmt"
+
+	"github.com/hashicorp/consul-k8s/cli/common"
+	"github.com/mitchellh/cli"
+)
+
+// MaintenanceCommand provides a synopsis for the maintenance subcommands (e.g. enable).
+type MaintenanceCommand struct {
+	*common.BaseCommand
+}
+
+// Run prints out information about the subcommands.
+func (c *MaintenanceCommand) Run([]string) int {
+	return cli.RunResultHelp
+}
+
+func (c *MaintenanceCommand) Help() string {
+	return fmt.Sprintf("%s\n\nUsage: consul-k8s maintenance <subcommand>", c.Synopsis())
+}
+
+func (c *MaintenanceCommand) Synopsis() string {
+	return "Pause or resume the changes to the Consul catalog during a maintenance window of the Consul servers."
+}
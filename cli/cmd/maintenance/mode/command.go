@@ -0,0 +1,245 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package mode
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+
+	"github.com/posener/complete"
+	helmCLI "helm.sh/helm/v3/pkg/cli"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+
+	"github.com/hashicorp/consul-k8s/cli/common"
+	"github.com/hashicorp/consul-k8s/cli/common/flag"
+	"github.com/hashicorp/consul-k8s/cli/common/terminal"
+	"github.com/hashicorp/consul-k8s/cli/helm"
+)
+
+const (
+	flagNameKubeConfig  = "kubeconfig"
+	flagNameKubeContext = "context"
+
+	// maintenanceModeAnnotation enables maintenance mode when set to "true" on the
+	// ConfigMap of the connect injector.
+	maintenanceModeAnnotation = "consul.hashicorp.com/maintenance-mode"
+
+	// configMapSuffix is the suffix of the name of the ConfigMap of the connect injector.
+	configMapSuffix = "-connect-inject-config"
+)
+
+// Command enables or disables the maintenance mode of a Consul installation, which pauses
+// the registrations and deregistrations of the connect injector.
+type Command struct {
+	*common.BaseCommand
+
+	// Enable is whether the command enables or disables maintenance mode.
+	Enable bool
+
+	helmActionsRunner helm.HelmActionsRunner
+
+	kubernetes kubernetes.Interface
+
+	set *flag.Sets
+
+	flagKubeConfig  string
+	flagKubeContext string
+
+	once sync.Once
+	help string
+}
+
+func (c *Command) init() {
+	c.set = flag.NewSets()
+
+	f := c.set.NewSet("Global Options")
+	f.StringVar(&flag.StringVar{
+		Name:    flagNameKubeConfig,
+		Aliases: []string{"c"},
+		Target:  &c.flagKubeConfig,
+		Usage:   "Set the path to kubeconfig file.",
+	})
+	f.StringVar(&flag.StringVar{
+		Name:   flagNameKubeContext,
+		Target: &c.flagKubeContext,
+		Usage:  "Set the Kubernetes context to use.",
+	})
+
+	c.help = c.set.Help()
+}
+
+// Run annotates the ConfigMap of the connect injector to enable or disable maintenance mode.
+func (c *Command) Run(args []string) int {
+	c.once.Do(c.init)
+	if c.helmActionsRunner == nil {
+		c.helmActionsRunner = &helm.ActionRunner{}
+	}
+	c.Log.ResetNamed("maintenance " + c.verb())
+	defer common.CloseWithError(c.BaseCommand)
+
+	if err := c.set.Parse(args); err != nil {
+		c.UI.Output(err.Error(), terminal.WithErrorStyle())
+		c.UI.Output("\n" + c.Help())
+		return 1
+	}
+
+	if len(c.set.Args()) > 0 {
+		c.UI.Output("should have no non-flag arguments", terminal.WithErrorStyle())
+		c.UI.Output("\n" + c.Help())
+		return 1
+	}
+
+	settings := helmCLI.New()
+	if c.flagKubeConfig != "" {
+		settings.KubeConfig = c.flagKubeConfig
+	}
+	if c.flagKubeContext != "" {
+		settings.KubeContext = c.flagKubeContext
+	}
+
+	if err := c.initKubernetes(settings); err != nil {
+		c.UI.Output(err.Error(), terminal.WithErrorStyle())
+		return 1
+	}
+
+	// Setup logger to stream Helm library logs.
+	var uiLogger = func(s string, args ...interface{}) {
+		logMsg := fmt.Sprintf(s, args...)
+		c.UI.Output(logMsg, terminal.WithLibraryStyle())
+	}
+
+	_, releaseName, namespace, err := c.helmActionsRunner.CheckForInstallations(&helm.CheckForInstallationsOptions{
+		Settings:    settings,
+		ReleaseName: common.DefaultReleaseName,
+		DebugLog:    uiLogger,
+	})
+	if err != nil {
+		c.UI.Output(err.Error(), terminal.WithErrorStyle())
+		return 1
+	}
+
+	changed, err := c.setMaintenanceMode(c.Ctx, releaseName, namespace)
+	if err != nil {
+		c.UI.Output(err.Error(), terminal.WithErrorStyle())
+		return 1
+	}
+
+	if !changed {
+		c.UI.Output("Maintenance mode is already %s for installation %q in namespace %q.", c.verb()+"d", releaseName, namespace, terminal.WithInfoStyle())
+		return 0
+	}
+	if c.Enable {
+		c.UI.Output("Maintenance mode enabled for installation %q in namespace %q.", releaseName, namespace, terminal.WithSuccessStyle())
+		c.UI.Output("The connect injector pauses its registrations and deregistrations within 10 seconds. Pods are still injected.", terminal.WithInfoStyle())
+	} else {
+		c.UI.Output("Maintenance mode disabled for installation %q in namespace %q.", releaseName, namespace, terminal.WithSuccessStyle())
+		c.UI.Output("The connect injector resumes its registrations and deregistrations within 10 seconds.", terminal.WithInfoStyle())
+	}
+	return 0
+}
+
+// setMaintenanceMode sets or removes the maintenance mode annotation of the ConfigMap of the
+// connect injector of the release and returns whether it changed.
+func (c *Command) setMaintenanceMode(ctx context.Context, releaseName, namespace string) (bool, error) {
+	configMaps, err := c.kubernetes.CoreV1().ConfigMaps(namespace).List(ctx, metav1.ListOptions{
+		LabelSelector: fmt.Sprintf("release=%s,component=connect-injector", releaseName),
+	})
+	if err != nil {
+		return false, fmt.Errorf("error listing the ConfigMaps of the connect injector: %w", err)
+	}
+
+	var configMap *corev1.ConfigMap
+	for i := range configMaps.Items {
+		if strings.HasSuffix(configMaps.Items[i].Name, configMapSuffix) {
+			configMap = &configMaps.Items[i]
+			break
+		}
+	}
+	if configMap == nil {
+		return false, errors.New("the ConfigMap of the connect injector was not found, maintenance mode requires connectInject.enabled")
+	}
+
+	if c.Enable == (configMap.Annotations[maintenanceModeAnnotation] == "true") {
+		return false, nil
+	}
+
+	if c.Enable {
+		if configMap.Annotations == nil {
+			configMap.Annotations = make(map[string]string)
+		}
+		configMap.Annotations[maintenanceModeAnnotation] = "true"
+	} else {
+		delete(configMap.Annotations, maintenanceModeAnnotation)
+	}
+
+	if _, err := c.kubernetes.CoreV1().ConfigMaps(namespace).Update(ctx, configMap, metav1.UpdateOptions{}); err != nil {
+		return false, fmt.Errorf("error updating ConfigMap %s: %w", configMap.Name, err)
+	}
+	return true, nil
+}
+
+func (c *Command) initKubernetes(settings *helmCLI.EnvSettings) error {
+	if c.kubernetes != nil {
+		return nil
+	}
+	restConfig, err := settings.RESTClientGetter().ToRESTConfig()
+	if err != nil {
+		return fmt.Errorf("error creating Kubernetes REST config %v", err)
+	}
+	if c.kubernetes, err = kubernetes.NewForConfig(restConfig); err != nil {
+		return fmt.Errorf("error creating Kubernetes client %v", err)
+	}
+	return nil
+}
+
+func (c *Command) verb() string {
+	if c.Enable {
+		return "enable"
+	}
+	return "disable"
+}
+
+func (c *Command) Help() string {
+	c.once.Do(c.init)
+	if c.Enable {
+		return fmt.Sprintf("%s\n\nUsage: consul-k8s maintenance enable [flags]\n\n"+
+			"The connect injector stops registering and deregistering service instances in the Consul catalog,\n"+
+			"e.g. while the Consul servers are upgraded, so that restarting servers don't cause churn in the\n"+
+			"catalog. Pods are still injected. Run `consul-k8s maintenance disable` once the maintenance is over.\n\n%s",
+			c.Synopsis(), c.help)
+	}
+	return fmt.Sprintf("%s\n\nUsage: consul-k8s maintenance disable [flags]\n\n"+
+		"The connect injector resumes registering and deregistering service instances in the Consul catalog\n"+
+		"and makes the changes that were paused during the maintenance.\n\n%s",
+		c.Synopsis(), c.help)
+}
+
+func (c *Command) Synopsis() string {
+	if c.Enable {
+		return "Pause the changes to the Consul catalog during a maintenance window of the Consul servers."
+	}
+	return "Resume the changes to the Consul catalog after a maintenance window of the Consul servers."
+}
+
+// AutocompleteFlags returns a mapping of supported flags and autocomplete
+// options for this command. The map key for the Flags map should be the
+// complete flag such as "-foo" or "--foo".
+func (c *Command) AutocompleteFlags() complete.Flags {
+	return complete.Flags{
+		fmt.Sprintf("-%s", flagNameKubeConfig):  complete.PredictFiles("*"),
+		fmt.Sprintf("-%s", flagNameKubeContext): complete.PredictNothing,
+	}
+}
+
+// AutocompleteArgs returns the argument predictor for this command.
+// Since argument completion is not supported, this will return
+// complete.PredictNothing.
+func (c *Command) AutocompleteArgs() complete.Predictor {
+	return complete.PredictNothing
+}
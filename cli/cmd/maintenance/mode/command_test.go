@@ -0,0 +1,147 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package mode
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"os"
+	"testing"
+
+	"github.com/hashicorp/go-hclog"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+
+	"github.com/hashicorp/consul-k8s/cli/common"
+	"github.com/hashicorp/consul-k8s/cli/common/terminal"
+	"github.com/hashicorp/consul-k8s/cli/helm"
+)
+
+const (
+	testRelease   = "consul"
+	testNamespace = "consul"
+)
+
+func TestFlagParsing(t *testing.T) {
+	cases := map[string][]string{
+		"Non-flag argument passed":          {"now"},
+		"Nonexistent flag passed, -foo bar": {"-foo", "bar"},
+	}
+
+	for name, args := range cases {
+		t.Run(name, func(t *testing.T) {
+			c := setupCommand(new(bytes.Buffer), true)
+			c.kubernetes = fake.NewSimpleClientset()
+			require.Equal(t, 1, c.Run(args))
+		})
+	}
+}
+
+func TestRun(t *testing.T) {
+	cases := map[string]struct {
+		enable         bool
+		annotations    map[string]string
+		expAnnotations map[string]string
+		expOutput      string
+	}{
+		"enable": {
+			enable:         true,
+			expAnnotations: map[string]string{maintenanceModeAnnotation: "true"},
+			expOutput:      `Maintenance mode enabled for installation "consul" in namespace "consul".`,
+		},
+		"enable when already enabled": {
+			enable:         true,
+			annotations:    map[string]string{maintenanceModeAnnotation: "true"},
+			expAnnotations: map[string]string{maintenanceModeAnnotation: "true"},
+			expOutput:      `Maintenance mode is already enabled`,
+		},
+		"disable": {
+			annotations:    map[string]string{maintenanceModeAnnotation: "true", "other": "annotation"},
+			expAnnotations: map[string]string{"other": "annotation"},
+			expOutput:      `Maintenance mode disabled for installation "consul" in namespace "consul".`,
+		},
+		"disable when already disabled": {
+			expOutput: `Maintenance mode is already disabled`,
+		},
+	}
+
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			buf := new(bytes.Buffer)
+			c := setupCommand(buf, tc.enable)
+			c.kubernetes = fake.NewSimpleClientset(
+				&corev1.ConfigMap{
+					ObjectMeta: metav1.ObjectMeta{
+						Name:        "consul-connect-inject-config",
+						Namespace:   testNamespace,
+						Labels:      map[string]string{"release": testRelease, "component": "connect-injector"},
+						Annotations: tc.annotations,
+					},
+				},
+				// The ConfigMaps of other components are not changed.
+				&corev1.ConfigMap{
+					ObjectMeta: metav1.ObjectMeta{
+						Name:      "consul-server-config",
+						Namespace: testNamespace,
+						Labels:    map[string]string{"release": testRelease, "component": "server"},
+					},
+				},
+			)
+
+			require.Equal(t, 0, c.Run(nil))
+			require.Contains(t, buf.String(), tc.expOutput)
+
+			configMap, err := c.kubernetes.CoreV1().ConfigMaps(testNamespace).Get(context.Background(), "consul-connect-inject-config", metav1.GetOptions{})
+			require.NoError(t, err)
+			if len(tc.expAnnotations) == 0 {
+				require.Empty(t, configMap.Annotations)
+			} else {
+				require.Equal(t, tc.expAnnotations, configMap.Annotations)
+			}
+
+			serverConfigMap, err := c.kubernetes.CoreV1().ConfigMaps(testNamespace).Get(context.Background(), "consul-server-config", metav1.GetOptions{})
+			require.NoError(t, err)
+			require.Empty(t, serverConfigMap.Annotations)
+		})
+	}
+}
+
+func TestRun_ConnectInjectDisabled(t *testing.T) {
+	buf := new(bytes.Buffer)
+	c := setupCommand(buf, true)
+	c.kubernetes = fake.NewSimpleClientset()
+
+	require.Equal(t, 1, c.Run(nil))
+	require.Contains(t, buf.String(), "the ConfigMap of the connect injector was not found")
+}
+
+func setupCommand(buf io.Writer, enable bool) *Command {
+	// Log at a test level to standard out.
+	log := hclog.New(&hclog.LoggerOptions{
+		Name:   "test",
+		Level:  hclog.Debug,
+		Output: os.Stdout,
+	})
+
+	// Setup and initialize the command struct
+	command := &Command{
+		BaseCommand: &common.BaseCommand{
+			Ctx: context.Background(),
+			Log: log,
+			UI:  terminal.NewUI(context.Background(), buf),
+		},
+		Enable: enable,
+		helmActionsRunner: &helm.MockActionRunner{
+			CheckForInstallationsFunc: func(*helm.CheckForInstallationsOptions) (bool, string, string, error) {
+				return true, testRelease, testNamespace, nil
+			},
+		},
+	}
+	command.init()
+
+	return command
+}
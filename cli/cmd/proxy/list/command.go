@@ -23,6 +23,7 @@ import (
 	"github.com/hashicorp/consul-k8s/cli/common"
 	"github.com/hashicorp/consul-k8s/cli/common/flag"
 	"github.com/hashicorp/consul-k8s/cli/common/terminal"
+	"github.com/hashicorp/consul-k8s/cli/compatibility"
 )
 
 const (
@@ -31,6 +32,16 @@ const (
 	flagNameKubeConfig    = "kubeconfig"
 	flagNameKubeContext   = "context"
 	flagOutputFormat      = "output-format"
+	flagNameOutdatedOnly  = "outdated-only"
+
+	// dataplaneContainer is the name of the dataplane container injected into pods, which is
+	// suffixed with the name of the service for pods with multiple ports.
+	dataplaneContainer = "consul-dataplane"
+
+	// injectorSelector selects the connect-injector Deployments installed by the Helm chart.
+	injectorSelector = "component=connect-injector, chart=consul-helm"
+	// dataplaneImageFlag is the flag of the connect-injector that sets the injected dataplane image.
+	dataplaneImageFlag = "-consul-dataplane-image="
 )
 
 // ListCommand is the command struct for the proxy list command.
@@ -44,6 +55,7 @@ type ListCommand struct {
 	flagNamespace     string
 	flagAllNamespaces bool
 	flagOutputFormat  string
+	flagOutdatedOnly  bool
 
 	flagKubeConfig  string
 	flagKubeContext string
@@ -77,6 +89,13 @@ func (c *ListCommand) init() {
 		Usage:   "Output format",
 		Aliases: []string{"o"},
 	})
+	f.BoolVar(&flag.BoolVar{
+		Name:    flagNameOutdatedOnly,
+		Target:  &c.flagOutdatedOnly,
+		Default: false,
+		Usage: "Only list proxies whose dataplane image doesn't match the image injected by the installed release, " +
+			"e.g. to find the pods that must be restarted after an upgrade.",
+	})
 
 	f = c.set.NewSet("Global Options")
 	f.StringVar(&flag.StringVar{
@@ -127,7 +146,26 @@ func (c *ListCommand) Run(args []string) int {
 		return 1
 	}
 
-	c.output(pods)
+	expectedImages, err := c.fetchExpectedImages()
+	if err != nil {
+		c.UI.Output("Error fetching the dataplane image of the installed release:", err.Error(), terminal.WithErrorStyle())
+		return 1
+	}
+	if c.flagOutdatedOnly {
+		if len(expectedImages) == 0 {
+			c.UI.Output("Unable to find outdated proxies: no connect-injector of an installed release was found.", terminal.WithErrorStyle())
+			return 1
+		}
+		var outdated []v1.Pod
+		for _, pod := range pods {
+			if isOutdated(pod, expectedImages) {
+				outdated = append(outdated, pod)
+			}
+		}
+		pods = outdated
+	}
+
+	c.output(pods, expectedImages)
 	return 0
 }
 
@@ -152,6 +190,7 @@ func (c *ListCommand) AutocompleteFlags() complete.Flags {
 		fmt.Sprintf("-%s", flagNameKubeConfig):    complete.PredictFiles("*"),
 		fmt.Sprintf("-%s", flagNameKubeContext):   complete.PredictNothing,
 		fmt.Sprintf("-%s", flagOutputFormat):      complete.PredictNothing,
+		fmt.Sprintf("-%s", flagNameOutdatedOnly):  complete.PredictNothing,
 	}
 }
 
@@ -273,22 +312,96 @@ func (c *ListCommand) fetchPods() ([]v1.Pod, error) {
 	return pods, nil
 }
 
+// fetchExpectedImages returns the dataplane images injected by the connect-injectors of the releases
+// installed in the cluster. Proxies running other images were injected before the last upgrade.
+func (c *ListCommand) fetchExpectedImages() (map[string]struct{}, error) {
+	deployments, err := c.kubernetes.AppsV1().Deployments("").List(c.Ctx, metav1.ListOptions{
+		LabelSelector: injectorSelector,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	images := make(map[string]struct{})
+	for _, deployment := range deployments.Items {
+		for _, container := range deployment.Spec.Template.Spec.Containers {
+			for _, arg := range append(container.Command, container.Args...) {
+				// The flags are passed in a shell script, one or more per argument.
+				for _, field := range strings.Fields(arg) {
+					if image, ok := strings.CutPrefix(field, dataplaneImageFlag); ok {
+						images[strings.Trim(image, `"'`)] = struct{}{}
+					}
+				}
+			}
+		}
+	}
+	return images, nil
+}
+
+// proxyContainer returns the container of the pod that runs the dataplane, or nil if there is none.
+// Sidecars run it in the injected dataplane container, gateways deployed by the Helm chart in the
+// container named after their component, and API gateways in their only container.
+func proxyContainer(pod v1.Pod) *v1.Container {
+	containers := pod.Spec.Containers
+	for i, container := range containers {
+		if container.Name == dataplaneContainer || strings.HasPrefix(container.Name, dataplaneContainer+"-") {
+			return &containers[i]
+		}
+	}
+	component := pod.Labels["component"]
+	for i, container := range containers {
+		if component != "" && container.Name == component {
+			return &containers[i]
+		}
+	}
+	if isAPIGateway(pod) && len(containers) > 0 {
+		return &containers[0]
+	}
+	return nil
+}
+
+// imageVersion returns the version of the image, or the image itself if it isn't tagged.
+func imageVersion(image string) string {
+	if version := compatibility.ImageVersion(image); version != "" {
+		return version
+	}
+	return image
+}
+
+// isOutdated returns whether the dataplane image of the pod isn't one of the expected images.
+func isOutdated(pod v1.Pod, expectedImages map[string]struct{}) bool {
+	container := proxyContainer(pod)
+	if container == nil || len(expectedImages) == 0 {
+		return false
+	}
+	_, ok := expectedImages[container.Image]
+	return !ok
+}
+
+func isAPIGateway(pod v1.Pod) bool {
+	return pod.Labels["component"] == "api-gateway" || pod.Labels["api-gateway.consul.hashicorp.com/managed"] == "true"
+}
+
 // output prints a table of pods to the terminal.
-func (c *ListCommand) output(pods []v1.Pod) {
+func (c *ListCommand) output(pods []v1.Pod, expectedImages map[string]struct{}) {
 	if len(pods) == 0 {
+		proxies := "proxies"
+		if c.flagOutdatedOnly {
+			proxies = "outdated proxies"
+		}
 		if c.flagAllNamespaces {
-			c.UI.Output("No proxies found across all namespaces.")
+			c.UI.Output("No %s found across all namespaces.", proxies)
 		} else {
-			c.UI.Output("No proxies found in %s namespace.", c.namespace())
+			c.UI.Output("No %s found in %s namespace.", proxies, c.namespace())
 		}
 		return
 	}
 
 	var tbl *terminal.Table
 	if c.flagAllNamespaces {
-		tbl = terminal.NewTable("Namespace", "Name", "Type")
+		tbl = terminal.NewTable("Namespace", "Name", "Type", "Version", "Outdated")
 	} else {
-		tbl = terminal.NewTable("Name", "Type")
+		tbl = terminal.NewTable("Name", "Type", "Version", "Outdated")
 	}
 
 	for _, pod := range pods {
@@ -314,10 +427,27 @@ func (c *ListCommand) output(pods []v1.Pod) {
 			}
 		}
 
+		// The version is unknown if the dataplane container can't be found, and whether the proxy
+		// is outdated is unknown if the installed release can't be found.
+		var version, outdated string
+		var color string
+		if container := proxyContainer(pod); container != nil {
+			version = imageVersion(container.Image)
+			if len(expectedImages) > 0 {
+				outdated = "false"
+				if isOutdated(pod, expectedImages) {
+					outdated = "true"
+					color = terminal.Yellow
+				}
+			}
+		}
+
 		if c.flagAllNamespaces {
-			tbl.AddRow([]string{pod.Namespace, pod.Name, proxyType}, []string{})
+			tbl.AddRow([]string{pod.Namespace, pod.Name, proxyType, version, outdated},
+				[]string{color, color, color, color, color})
 		} else {
-			tbl.AddRow([]string{pod.Name, proxyType}, []string{})
+			tbl.AddRow([]string{pod.Name, proxyType, version, outdated},
+				[]string{color, color, color, color})
 		}
 	}
 
@@ -335,6 +465,11 @@ func (c *ListCommand) output(pods []v1.Pod) {
 		}
 
 		c.UI.Table(tbl)
+
+		if len(expectedImages) == 0 {
+			c.UI.Output("\nNo connect-injector of an installed release was found, so outdated proxies are not flagged.",
+				terminal.WithWarningStyle())
+		}
 	}
 
 }
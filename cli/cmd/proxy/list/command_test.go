@@ -17,8 +17,10 @@ import (
 	"github.com/posener/complete"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
+	appsv1 "k8s.io/api/apps/v1"
 	v1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/client-go/kubernetes/fake"
 
 	"github.com/hashicorp/consul-k8s/cli/common"
@@ -453,6 +455,160 @@ func TestListCommandOutputInJsonFormat(t *testing.T) {
 	assert.Equal(t, "pod1", actual[6].Name)
 }
 
+// TestListCommandVersions tests that the dataplane version of every proxy is listed and that the proxies
+// whose dataplane image doesn't match the image injected by the installed release are flagged as outdated.
+func TestListCommandVersions(t *testing.T) {
+	injector := &appsv1.Deployment{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "consul-connect-injector",
+			Namespace: "consul",
+			Labels:    map[string]string{"component": "connect-injector", "chart": "consul-helm"},
+		},
+		Spec: appsv1.DeploymentSpec{
+			Template: v1.PodTemplateSpec{
+				Spec: v1.PodSpec{
+					Containers: []v1.Container{{
+						Name:    "sidecar-injector",
+						Command: []string{"/bin/sh", "-ec", "exec consul-k8s-control-plane inject-connect \\\n  -consul-dataplane-image=\"hashicorp/consul-dataplane:1.4.0\" \\\n  -log-level=info"},
+					}},
+				},
+			},
+		},
+	}
+	pods := []v1.Pod{
+		{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      "mesh-gateway",
+				Namespace: "consul",
+				Labels:    map[string]string{"component": "mesh-gateway", "chart": "consul-helm"},
+			},
+			Spec: v1.PodSpec{Containers: []v1.Container{{Name: "mesh-gateway", Image: "hashicorp/consul-dataplane:1.4.0"}}},
+		},
+		{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      "api-gateway",
+				Namespace: "consul",
+				Labels:    map[string]string{"component": "api-gateway", "gateway.consul.hashicorp.com/managed": "true"},
+			},
+			Spec: v1.PodSpec{Containers: []v1.Container{{Name: "gateway", Image: "hashicorp/consul-dataplane:1.3.2"}}},
+		},
+		{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      "current",
+				Namespace: "default",
+				Labels:    map[string]string{"consul.hashicorp.com/connect-inject-status": "injected"},
+			},
+			Spec: v1.PodSpec{Containers: []v1.Container{
+				{Name: "app", Image: "app:2.0"},
+				{Name: "consul-dataplane", Image: "hashicorp/consul-dataplane:1.4.0"},
+			}},
+		},
+		{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      "outdated",
+				Namespace: "default",
+				Labels:    map[string]string{"consul.hashicorp.com/connect-inject-status": "injected"},
+			},
+			Spec: v1.PodSpec{Containers: []v1.Container{
+				{Name: "app", Image: "app:2.0"},
+				{Name: "consul-dataplane-web", Image: "registry.example.com:5000/consul-dataplane:1.3.2"},
+			}},
+		},
+	}
+
+	type row struct {
+		Name     string `json:"Name"`
+		Type     string `json:"Type"`
+		Version  string `json:"Version"`
+		Outdated string `json:"Outdated"`
+	}
+	cases := map[string]struct {
+		pods     []v1.Pod
+		objects  []runtime.Object
+		args     []string
+		expected []row
+		exitCode int
+		output   string
+	}{
+		"all proxies": {
+			objects: []runtime.Object{injector},
+			args:    []string{"-A", "-o", "json"},
+			expected: []row{
+				{Name: "api-gateway", Type: "API Gateway", Version: "1.3.2", Outdated: "true"},
+				{Name: "mesh-gateway", Type: "Mesh Gateway", Version: "1.4.0", Outdated: "false"},
+				{Name: "current", Type: "Sidecar", Version: "1.4.0", Outdated: "false"},
+				{Name: "outdated", Type: "Sidecar", Version: "1.3.2", Outdated: "true"},
+			},
+		},
+		"outdated proxies only": {
+			objects: []runtime.Object{injector},
+			args:    []string{"-A", "-o", "json", "-outdated-only"},
+			expected: []row{
+				{Name: "api-gateway", Type: "API Gateway", Version: "1.3.2", Outdated: "true"},
+				{Name: "outdated", Type: "Sidecar", Version: "1.3.2", Outdated: "true"},
+			},
+		},
+		"no installed release": {
+			args: []string{"-A", "-o", "json"},
+			expected: []row{
+				{Name: "api-gateway", Type: "API Gateway", Version: "1.3.2"},
+				{Name: "mesh-gateway", Type: "Mesh Gateway", Version: "1.4.0"},
+				{Name: "current", Type: "Sidecar", Version: "1.4.0"},
+				{Name: "outdated", Type: "Sidecar", Version: "1.3.2"},
+			},
+		},
+		"outdated proxies only without an installed release": {
+			args:     []string{"-A", "-outdated-only"},
+			exitCode: 1,
+			output:   "no connect-injector of an installed release was found",
+		},
+		"no outdated proxies in namespace": {
+			// Only the up-to-date mesh gateway runs in the consul namespace.
+			pods:     pods[:1],
+			objects:  []runtime.Object{injector},
+			args:     []string{"-n", "consul", "-outdated-only"},
+			exitCode: 0,
+			output:   "No outdated proxies found in consul namespace.",
+		},
+	}
+
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			if tc.pods == nil {
+				tc.pods = pods
+			}
+			objects := append([]runtime.Object{&v1.PodList{Items: tc.pods}}, tc.objects...)
+			buf := new(bytes.Buffer)
+			c := setupCommand(buf)
+			c.kubernetes = fake.NewSimpleClientset(objects...)
+
+			exitCode := c.Run(tc.args)
+			require.Equal(t, tc.exitCode, exitCode, buf.String())
+			if tc.output != "" {
+				require.Contains(t, buf.String(), tc.output)
+				return
+			}
+
+			var actual []row
+			require.NoErrorf(t, json.Unmarshal(buf.Bytes(), &actual), "failed to parse json output: %s", buf.String())
+			require.Equal(t, tc.expected, actual)
+		})
+	}
+}
+
+func TestImageVersion(t *testing.T) {
+	cases := map[string]string{
+		"hashicorp/consul-dataplane:1.4.0":                     "1.4.0",
+		"registry.example.com:5000/consul-dataplane:1.4.0":     "1.4.0",
+		"registry.example.com:5000/consul-dataplane":           "registry.example.com:5000/consul-dataplane",
+		"hashicorp/consul-dataplane@sha256:0123456789abcdef":   "hashicorp/consul-dataplane@sha256:0123456789abcdef",
+		"hashicorp/consul-dataplane:1.4.0@sha256:0123456789ab": "1.4.0",
+	}
+	for image, expected := range cases {
+		require.Equal(t, expected, imageVersion(image), image)
+	}
+}
+
 func TestNoPodsFound(t *testing.T) {
 	cases := map[string]struct {
 		args     []string
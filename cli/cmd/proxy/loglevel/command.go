@@ -7,8 +7,10 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"sort"
 	"strings"
 	"sync"
+	"time"
 
 	"github.com/posener/complete"
 	helmCLI "helm.sh/helm/v3/pkg/cli"
@@ -28,11 +30,16 @@ const (
 	flagNameNamespace   = "namespace"
 	flagNameUpdateLevel = "update-level"
 	flagNameReset       = "reset"
+	flagNameSelector    = "selector"
+	flagNameDuration    = "duration"
 	flagNameKubeConfig  = "kubeconfig"
 	flagNameKubeContext = "context"
+
+	// revertTimeout is the time allowed for reverting the log levels of all pods after -duration.
+	revertTimeout = 1 * time.Minute
 )
 
-var ErrIncorrectArgFormat = errors.New("Exactly one positional argument is required: <pod-name>")
+var ErrIncorrectArgFormat = errors.New("Exactly one positional argument <pod-name> or the -selector flag is required")
 
 type LoggerConfig map[string]string
 
@@ -55,8 +62,10 @@ type LogLevelCommand struct {
 	// Command Flags
 	podName     string
 	namespace   string
+	selector    string
 	level       string
 	reset       bool
+	duration    time.Duration
 	kubeConfig  string
 	kubeContext string
 
@@ -91,6 +100,20 @@ func (l *LogLevelCommand) init() {
 		Aliases: []string{"r"},
 	})
 
+	f.StringVar(&flag.StringVar{
+		Name:    flagNameSelector,
+		Target:  &l.selector,
+		Usage:   "A label selector of the Pods to inspect or modify the log levels of, e.g. `-selector app=web`. Cannot be used with a Pod name.",
+		Aliases: []string{"l"},
+	})
+
+	f.DurationVar(&flag.DurationVar{
+		Name:   flagNameDuration,
+		Target: &l.duration,
+		Usage: "Revert the log levels of the loggers to their previous levels after this duration, e.g. `-duration 5m`. " +
+			"Requires -update-level or -reset. The command waits for the duration, and the levels are not reverted if it is interrupted before then.",
+	})
+
 	f = l.set.NewSet("Global Options")
 	f.StringVar(&flag.StringVar{
 		Name:    flagNameKubeConfig,
@@ -134,16 +157,58 @@ func (l *LogLevelCommand) Run(args []string) int {
 		return l.logOutputAndDie(err)
 	}
 
-	adminPorts, err := l.fetchAdminPorts()
+	podNames, err := l.fetchPodNames()
 	if err != nil {
 		return l.logOutputAndDie(err)
 	}
 
-	err = l.fetchOrSetLogLevels(adminPorts)
+	params, err := parseParams(l.level)
 	if err != nil {
 		return l.logOutputAndDie(err)
 	}
 
+	// Save the current log levels so that they can be reverted after the duration.
+	previous := make(map[string]map[string]LoggerConfig, len(podNames))
+	if l.duration > 0 {
+		for _, podName := range podNames {
+			previous[podName], err = l.fetchOrSetLogLevels(l.Ctx, podName, envoy.NewLoggerParams())
+			if err != nil {
+				return l.logOutputAndDie(err)
+			}
+		}
+	}
+
+	for _, podName := range podNames {
+		loggers, err := l.fetchOrSetLogLevels(l.Ctx, podName, params)
+		if err != nil {
+			return l.logOutputAndDie(err)
+		}
+		l.outputLevels(podName, loggers)
+	}
+
+	if l.duration == 0 {
+		return 0
+	}
+
+	// The CLI exits when it is interrupted, so the levels are only reverted if the command runs for the whole duration.
+	l.UI.Output(fmt.Sprintf("Reverting log levels in %s. The levels are not reverted if the command is interrupted.", l.duration), terminal.WithInfoStyle())
+	time.Sleep(l.duration)
+
+	ctx, cancel := context.WithTimeout(l.Ctx, revertTimeout)
+	defer cancel()
+	var revertErrs error
+	for _, podName := range podNames {
+		for name, levels := range previous[podName] {
+			if _, err := l.fetchOrSetLogLevels(ctx, podName, envoy.LoggerParamsFromLevels(levels), name); err != nil {
+				revertErrs = errors.Join(revertErrs, fmt.Errorf("failed to revert log levels for %s in pod %s: %w", name, podName, err))
+			}
+		}
+	}
+	if revertErrs != nil {
+		l.UI.Output(revertErrs.Error(), terminal.WithErrorStyle())
+		return 1
+	}
+	l.UI.Output("Reverted log levels to their previous levels.", terminal.WithSuccessStyle())
 	return 0
 }
 
@@ -162,17 +227,22 @@ func (l *LogLevelCommand) parseFlags(args []string) error {
 	}
 	keyed := args[len(positional):]
 
-	if len(positional) != 1 {
+	if len(positional) > 1 {
 		return ErrIncorrectArgFormat
 	}
-
-	l.podName = positional[0]
+	if len(positional) == 1 {
+		l.podName = positional[0]
+	}
 
 	err := l.set.Parse(keyed)
 	if err != nil {
 		return err
 	}
 
+	if (l.podName == "") == (l.selector == "") {
+		return ErrIncorrectArgFormat
+	}
+
 	return nil
 }
 
@@ -180,6 +250,12 @@ func (l *LogLevelCommand) validateFlags() error {
 	if l.level != "" && l.reset {
 		return fmt.Errorf("cannot set log level to %q and reset to 'info' at the same time", l.level)
 	}
+	if l.duration < 0 {
+		return fmt.Errorf("-%s must not be negative", flagNameDuration)
+	}
+	if l.duration > 0 && l.level == "" && !l.reset {
+		return fmt.Errorf("-%s requires -%s or -%s", flagNameDuration, flagNameUpdateLevel, flagNameReset)
+	}
 	if l.namespace == "" {
 		return nil
 	}
@@ -225,10 +301,33 @@ func (l *LogLevelCommand) initKubernetes() error {
 	return nil
 }
 
+// fetchPodNames returns the name of the pod passed as argument or the names of the pods
+// matching the label selector, sorted by name.
+func (l *LogLevelCommand) fetchPodNames() ([]string, error) {
+	if l.selector == "" {
+		return []string{l.podName}, nil
+	}
+
+	pods, err := l.kubernetes.CoreV1().Pods(l.namespace).List(l.Ctx, metav1.ListOptions{LabelSelector: l.selector})
+	if err != nil {
+		return nil, err
+	}
+	if len(pods.Items) == 0 {
+		return nil, fmt.Errorf("no pods found in namespace %s matching selector %q", l.namespace, l.selector)
+	}
+
+	podNames := make([]string, 0, len(pods.Items))
+	for _, pod := range pods.Items {
+		podNames = append(podNames, pod.Name)
+	}
+	sort.Strings(podNames)
+	return podNames, nil
+}
+
 // fetchAdminPorts retrieves all admin ports for Envoy Proxies running in a pod given namespace.
-func (l *LogLevelCommand) fetchAdminPorts() (map[string]int, error) {
+func (l *LogLevelCommand) fetchAdminPorts(ctx context.Context, podName string) (map[string]int, error) {
 	adminPorts := make(map[string]int, 0)
-	pod, err := l.kubernetes.CoreV1().Pods(l.namespace).Get(l.Ctx, l.podName, metav1.GetOptions{})
+	pod, err := l.kubernetes.CoreV1().Pods(l.namespace).Get(ctx, podName, metav1.GetOptions{})
 	if err != nil {
 		return adminPorts, err
 	}
@@ -237,7 +336,7 @@ func (l *LogLevelCommand) fetchAdminPorts() (map[string]int, error) {
 
 	if !isMultiport {
 		// Return the default port configuration.
-		adminPorts[l.podName] = defaultAdminPort
+		adminPorts[podName] = defaultAdminPort
 		return adminPorts, nil
 	}
 
@@ -248,30 +347,41 @@ func (l *LogLevelCommand) fetchAdminPorts() (map[string]int, error) {
 	return adminPorts, nil
 }
 
-func (l *LogLevelCommand) fetchOrSetLogLevels(adminPorts map[string]int) error {
+// fetchOrSetLogLevels calls the logging endpoint of the Envoy proxies of the pod with the params and
+// returns the resulting log levels of each proxy. If proxy names are passed, only those proxies are called.
+func (l *LogLevelCommand) fetchOrSetLogLevels(ctx context.Context, podName string, params *envoy.LoggerParams, proxyNames ...string) (map[string]LoggerConfig, error) {
 	loggers := make(map[string]LoggerConfig, 0)
 
+	adminPorts, err := l.fetchAdminPorts(ctx, podName)
+	if err != nil {
+		return nil, err
+	}
+	if len(proxyNames) > 0 {
+		selected := make(map[string]int, len(proxyNames))
+		for _, name := range proxyNames {
+			if port, ok := adminPorts[name]; ok {
+				selected[name] = port
+			}
+		}
+		adminPorts = selected
+	}
+
 	for name, port := range adminPorts {
 		pf := common.PortForward{
 			Namespace:  l.namespace,
-			PodName:    l.podName,
+			PodName:    podName,
 			RemotePort: port,
 			KubeClient: l.kubernetes,
 			RestConfig: l.restConfig,
 		}
-		params, err := parseParams(l.level)
-		if err != nil {
-			return err
-		}
-		logLevels, err := l.envoyLoggingCaller(l.Ctx, &pf, params)
+		logLevels, err := l.envoyLoggingCaller(ctx, &pf, params)
 		if err != nil {
-			return err
+			return nil, err
 		}
 		loggers[name] = logLevels
 	}
 
-	l.outputLevels(loggers)
-	return nil
+	return loggers, nil
 }
 
 func parseParams(params string) (*envoy.LoggerParams, error) {
@@ -302,8 +412,8 @@ func parseParams(params string) (*envoy.LoggerParams, error) {
 	return loggerParams, nil
 }
 
-func (l *LogLevelCommand) outputLevels(logLevels map[string]LoggerConfig) {
-	l.UI.Output(fmt.Sprintf("Envoy log configuration for %s in namespace default:", l.podName))
+func (l *LogLevelCommand) outputLevels(podName string, logLevels map[string]LoggerConfig) {
+	l.UI.Output(fmt.Sprintf("Envoy log configuration for %s in namespace %s:", podName, l.namespace))
 	for n, levels := range logLevels {
 		l.UI.Output(fmt.Sprintf("Log Levels for %s", n), terminal.WithHeaderStyle())
 		table := terminal.NewTable("Name", "Level")
@@ -317,11 +427,11 @@ func (l *LogLevelCommand) outputLevels(logLevels map[string]LoggerConfig) {
 
 func (l *LogLevelCommand) Help() string {
 	l.once.Do(l.init)
-	return fmt.Sprintf("%s\n\nUsage: consul-k8s proxy log <pod-name> [flags]\n\n%s", l.Synopsis(), l.help)
+	return fmt.Sprintf("%s\n\nUsage: consul-k8s proxy log <pod-name> [flags]\n       consul-k8s proxy log -selector <label-selector> [flags]\n\n%s", l.Synopsis(), l.help)
 }
 
 func (l *LogLevelCommand) Synopsis() string {
-	return "Inspect and Modify the Envoy Log configuration for a given Pod."
+	return "Inspect and Modify the Envoy Log configuration for a given Pod or Pods matching a label selector."
 }
 
 // AutocompleteFlags returns a mapping of supported flags and autocomplete
@@ -330,6 +440,8 @@ func (l *LogLevelCommand) Synopsis() string {
 func (l *LogLevelCommand) AutocompleteFlags() complete.Flags {
 	return complete.Flags{
 		fmt.Sprintf("-%s", flagNameNamespace):   complete.PredictNothing,
+		fmt.Sprintf("-%s", flagNameSelector):    complete.PredictNothing,
+		fmt.Sprintf("-%s", flagNameDuration):    complete.PredictNothing,
 		fmt.Sprintf("-%s", flagNameKubeConfig):  complete.PredictFiles("*"),
 		fmt.Sprintf("-%s", flagNameKubeContext): complete.PredictNothing,
 	}
@@ -45,12 +45,29 @@ func TestFlagParsingFails(t *testing.T) {
 			args: []string{"podName", "-namespace", "YOLO"},
 			out:  1,
 		},
+		"Pod name and selector passed": {
+			args: []string{"podName", "-selector", "app=web"},
+			out:  1,
+		},
+		"Duration without level passed": {
+			args: []string{"podName", "-duration", "5m"},
+			out:  1,
+		},
+		"Negative duration passed": {
+			args: []string{"podName", "-u", "debug", "-duration", "-5m"},
+			out:  1,
+		},
+		"Selector matching no pods passed": {
+			args: []string{"-l", "app=api"},
+			out:  1,
+		},
 	}
 	podName := "now-this-is-pod-racing"
 	fakePod := v1.Pod{
 		ObjectMeta: metav1.ObjectMeta{
 			Name:      podName,
 			Namespace: "default",
+			Labels:    map[string]string{"app": "web"},
 		},
 	}
 
@@ -116,6 +133,16 @@ func TestFlagParsingSucceeds(t *testing.T) {
 			podNamespace: "default",
 			out:          0,
 		},
+		"With selector": {
+			args:         []string{"-l", "app=web", "-u", "warning"},
+			podNamespace: "default",
+			out:          0,
+		},
+		"With selector full flag and namespace": {
+			args:         []string{"-selector", "app=web", "-n", "another"},
+			podNamespace: "another",
+			out:          0,
+		},
 	}
 
 	for name, tc := range testCases {
@@ -124,6 +151,7 @@ func TestFlagParsingSucceeds(t *testing.T) {
 				ObjectMeta: metav1.ObjectMeta{
 					Name:      podName,
 					Namespace: tc.podNamespace,
+					Labels:    map[string]string{"app": "web"},
 				},
 			}
 
@@ -207,6 +235,61 @@ func TestOutputForSettingLogLevels(t *testing.T) {
 	}
 }
 
+func TestOutputForSettingLogLevelsWithSelector(t *testing.T) {
+	t.Parallel()
+	pods := []v1.Pod{
+		{ObjectMeta: metav1.ObjectMeta{Name: "web-1", Namespace: "default", Labels: map[string]string{"app": "web"}}},
+		{ObjectMeta: metav1.ObjectMeta{Name: "web-2", Namespace: "default", Labels: map[string]string{"app": "web"}}},
+		{ObjectMeta: metav1.ObjectMeta{Name: "api-1", Namespace: "default", Labels: map[string]string{"app": "api"}}},
+	}
+
+	buf := bytes.NewBuffer([]byte{})
+	c := setupCommand(buf)
+	var calledPods []string
+	c.envoyLoggingCaller = func(_ context.Context, pf common.PortForwarder, _ *envoy.LoggerParams) (map[string]string, error) {
+		calledPods = append(calledPods, pf.(*common.PortForward).PodName)
+		return testLogConfig, nil
+	}
+	c.kubernetes = fake.NewSimpleClientset(&v1.PodList{Items: pods})
+
+	out := c.Run([]string{"-l", "app=web", "-u", "warning"})
+	require.Equal(t, 0, out)
+	require.Equal(t, []string{"web-1", "web-2"}, calledPods)
+
+	actual := buf.String()
+	require.Regexp(t, "Envoy log configuration for web-1 in namespace default:", actual)
+	require.Regexp(t, "Envoy log configuration for web-2 in namespace default:", actual)
+	require.NotRegexp(t, "api-1", actual)
+}
+
+func TestSettingLogLevelsWithDuration(t *testing.T) {
+	t.Parallel()
+	podName := "now-this-is-pod-racing"
+	fakePod := v1.Pod{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      podName,
+			Namespace: "default",
+		},
+	}
+
+	buf := bytes.NewBuffer([]byte{})
+	c := setupCommand(buf)
+	c.Ctx = context.Background()
+	levels := map[string]string{"grpc": "info", "http": "debug"}
+	var calls []string
+	c.envoyLoggingCaller = func(_ context.Context, _ common.PortForwarder, params *envoy.LoggerParams) (map[string]string, error) {
+		calls = append(calls, params.String())
+		return levels, nil
+	}
+	c.kubernetes = fake.NewSimpleClientset(&v1.PodList{Items: []v1.Pod{fakePod}})
+
+	out := c.Run([]string{podName, "-u", "trace", "-duration", "10ms"})
+	require.Equal(t, 0, out)
+	// The levels are fetched, set and then reverted to the fetched levels.
+	require.Equal(t, []string{"", "?level=trace", "?paths=grpc:info,http:debug"}, calls)
+	require.Regexp(t, "Reverted log levels to their previous levels.", buf.String())
+}
+
 func TestHelp(t *testing.T) {
 	t.Parallel()
 	buf := bytes.NewBuffer([]byte{})
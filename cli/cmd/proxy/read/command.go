@@ -42,6 +42,7 @@ const (
 	flagNameFQDN        = "fqdn"
 	flagNameAddress     = "address"
 	flagNamePort        = "port"
+	flagNameDiff        = "diff"
 	flagNameKubeConfig  = "kubeconfig"
 	flagNameKubeContext = "context"
 )
@@ -68,6 +69,9 @@ type ReadCommand struct {
 	flagAddress   string
 	flagPort      int
 
+	// flagDiff is the name of a pod to compare the Envoy configuration with.
+	flagDiff string
+
 	// Global Flags
 	flagKubeConfig  string
 	flagKubeContext string
@@ -144,6 +148,14 @@ func (c *ReadCommand) init() {
 		Default: -1,
 	})
 
+	f = c.set.NewSet("Comparison Options")
+	f.StringVar(&flag.StringVar{
+		Name:   flagNameDiff,
+		Target: &c.flagDiff,
+		Usage: "Compare the clusters, listeners, and routes of the Envoy configuration with the given pod in the same namespace " +
+			"and only output what differs. May be combined with the output filtering options other than -endpoints and -secrets.",
+	})
+
 	f = c.set.NewSet("Global Options")
 	f.StringVar(&flag.StringVar{
 		Name:    flagNameKubeConfig,
@@ -182,6 +194,14 @@ func (c *ReadCommand) Run(args []string) int {
 		return 1
 	}
 
+	if c.flagDiff != "" {
+		if err := c.diff(); err != nil {
+			c.UI.Output(err.Error(), terminal.WithErrorStyle())
+			return 1
+		}
+		return 0
+	}
+
 	adminPorts, err := c.fetchAdminPorts()
 	if err != nil {
 		c.UI.Output(err.Error(), terminal.WithErrorStyle())
@@ -227,6 +247,7 @@ func (c *ReadCommand) AutocompleteFlags() complete.Flags {
 		fmt.Sprintf("-%s", flagNameFQDN):        complete.PredictNothing,
 		fmt.Sprintf("-%s", flagNameAddress):     complete.PredictNothing,
 		fmt.Sprintf("-%s", flagNamePort):        complete.PredictNothing,
+		fmt.Sprintf("-%s", flagNameDiff):        complete.PredictNothing,
 		fmt.Sprintf("-%s", flagNameKubeConfig):  complete.PredictFiles("*"),
 		fmt.Sprintf("-%s", flagNameKubeContext): complete.PredictNothing,
 	}
@@ -269,6 +290,17 @@ func (c *ReadCommand) validateFlags() error {
 	if outputs := []string{Table, JSON, Raw}; !slices.Contains(outputs, c.flagOutput) {
 		return fmt.Errorf("-output must be one of %s.", strings.Join(outputs, ", "))
 	}
+	if c.flagDiff != "" {
+		if c.flagDiff == c.flagPodName {
+			return fmt.Errorf("-diff must be a different pod than %s.", c.flagPodName)
+		}
+		if c.flagOutput == Raw {
+			return fmt.Errorf("-diff can only be output as 'table' or 'json'.")
+		}
+		if c.flagEndpoints || c.flagSecrets {
+			return fmt.Errorf("-diff only compares clusters, listeners, and routes.")
+		}
+	}
 	return nil
 }
 
@@ -303,35 +335,45 @@ func (c *ReadCommand) initKubernetes() (err error) {
 }
 
 func (c *ReadCommand) fetchAdminPorts() (map[string]int, error) {
+	adminPorts, _, err := c.fetchPodAdminPorts(c.flagPodName)
+	return adminPorts, err
+}
+
+// fetchPodAdminPorts returns the Envoy admin ports of the pod and its IP.
+func (c *ReadCommand) fetchPodAdminPorts(podName string) (map[string]int, string, error) {
 	adminPorts := make(map[string]int, 0)
 
-	pod, err := c.kubernetes.CoreV1().Pods(c.flagNamespace).Get(c.Ctx, c.flagPodName, metav1.GetOptions{})
+	pod, err := c.kubernetes.CoreV1().Pods(c.flagNamespace).Get(c.Ctx, podName, metav1.GetOptions{})
 	if err != nil {
-		return adminPorts, err
+		return adminPorts, "", err
 	}
 
 	connectService, isMultiport := pod.Annotations["consul.hashicorp.com/connect-service"]
 
 	if !isMultiport {
 		// Return the default port configuration.
-		adminPorts[c.flagPodName] = defaultAdminPort
-		return adminPorts, nil
+		adminPorts[podName] = defaultAdminPort
+		return adminPorts, pod.Status.PodIP, nil
 	}
 
 	for index, service := range strings.Split(connectService, ",") {
 		adminPorts[service] = defaultAdminPort + index
 	}
 
-	return adminPorts, nil
+	return adminPorts, pod.Status.PodIP, nil
 }
 
 func (c *ReadCommand) fetchConfigs(adminPorts map[string]int) (map[string]*envoy.EnvoyConfig, error) {
+	return c.fetchPodConfigs(c.flagPodName, adminPorts)
+}
+
+func (c *ReadCommand) fetchPodConfigs(podName string, adminPorts map[string]int) (map[string]*envoy.EnvoyConfig, error) {
 	configs := make(map[string]*envoy.EnvoyConfig, 0)
 
 	for name, adminPort := range adminPorts {
 		pf := common.PortForward{
 			Namespace:  c.flagNamespace,
-			PodName:    c.flagPodName,
+			PodName:    podName,
 			RemotePort: adminPort,
 			KubeClient: c.kubernetes,
 			RestConfig: c.restConfig,
@@ -51,6 +51,18 @@ func TestFlagParsing(t *testing.T) {
 			args: []string{"podName", "-output", "image"},
 			out:  1,
 		},
+		"User passed the same pod to diff": {
+			args: []string{"podName", "-diff", "podName"},
+			out:  1,
+		},
+		"User passed raw output with diff": {
+			args: []string{"podName", "-diff", "otherPod", "-output", "raw"},
+			out:  1,
+		},
+		"User passed secrets filter with diff": {
+			args: []string{"podName", "-diff", "otherPod", "-secrets"},
+			out:  1,
+		},
 	}
 
 	for name, tc := range cases {
@@ -159,6 +171,44 @@ func TestReadCommandOutput(t *testing.T) {
 	}
 }
 
+func TestReadCommandDiff(t *testing.T) {
+	pods := []v1.Pod{
+		{ObjectMeta: metav1.ObjectMeta{Name: "web-1", Namespace: "default"}, Status: v1.PodStatus{PodIP: "192.168.69.179"}},
+		{ObjectMeta: metav1.ObjectMeta{Name: "web-2", Namespace: "default"}, Status: v1.PodStatus{PodIP: "192.168.69.180"}},
+	}
+
+	// web-2 is missing the frontend cluster and its public listener is bound to its own IP.
+	otherConfig := *testEnvoyConfig
+	otherConfig.Clusters = nil
+	for _, cluster := range testEnvoyConfig.Clusters {
+		if cluster.Name != "frontend" {
+			otherConfig.Clusters = append(otherConfig.Clusters, cluster)
+		}
+	}
+	otherConfig.Listeners = append([]envoy.Listener{}, testEnvoyConfig.Listeners...)
+	otherConfig.Listeners[0].Address = "192.168.69.180:20000"
+
+	buf := new(bytes.Buffer)
+	c := setupCommand(buf)
+	c.kubernetes = fake.NewSimpleClientset(&v1.PodList{Items: pods})
+	c.fetchConfig = func(_ context.Context, pf common.PortForwarder) (*envoy.EnvoyConfig, error) {
+		if pf.(*common.PortForward).PodName == "web-2" {
+			return &otherConfig, nil
+		}
+		return testEnvoyConfig, nil
+	}
+
+	out := c.Run([]string{"web-1", "-diff", "web-2"})
+	require.Equal(t, 0, out)
+
+	actual := buf.String()
+	require.Contains(t, actual, "Envoy configuration differences between web-1 and web-2 in namespace default:")
+	require.Regexp(t, "Clusters \\(1 differences\\)", actual)
+	require.Regexp(t, "cluster.*frontend.*-.*present.*missing", actual)
+	require.Regexp(t, "Listeners \\(0 differences\\)", actual)
+	require.Regexp(t, "Routes \\(0 differences\\)", actual)
+}
+
 // TestFilterWarnings ensures that a warning is printed if the user applies a
 // field filter (e.g. -fqdn default) and a table filter (e.g. -secrets) where
 // the former does not affect the output of the latter.
@@ -0,0 +1,272 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package read
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/hashicorp/consul-k8s/cli/common/envoy"
+	"github.com/hashicorp/consul-k8s/cli/common/terminal"
+)
+
+// podIPPlaceholder replaces the IP of a pod in its Envoy configuration before it
+// is compared, since the listeners of each replica are bound to its own IP.
+const podIPPlaceholder = "<pod IP>"
+
+// Difference is a difference between the Envoy configurations of two pods.
+type Difference struct {
+	// Kind is the kind of Envoy resource, i.e. cluster, listener, or route.
+	Kind string `json:"kind"`
+	// Name is the name of the resource.
+	Name string `json:"name"`
+	// Field is the field of the resource that differs. It is empty if the
+	// resource is only in one of the configurations.
+	Field string `json:"field,omitempty"`
+	// Left and Right are the values of the field in each configuration. If the
+	// resource is only in one of the configurations, they are present or missing.
+	Left  string `json:"left"`
+	Right string `json:"right"`
+}
+
+// proxyPair is a proxy in each of the pods being compared.
+type proxyPair struct {
+	name        string
+	left, right *envoy.EnvoyConfig
+}
+
+// diff compares the Envoy configuration of the pod with the one of the -diff pod
+// and outputs the differences.
+func (c *ReadCommand) diff() error {
+	leftPorts, leftIP, err := c.fetchPodAdminPorts(c.flagPodName)
+	if err != nil {
+		return err
+	}
+	rightPorts, rightIP, err := c.fetchPodAdminPorts(c.flagDiff)
+	if err != nil {
+		return err
+	}
+	leftConfigs, err := c.fetchPodConfigs(c.flagPodName, leftPorts)
+	if err != nil {
+		return err
+	}
+	rightConfigs, err := c.fetchPodConfigs(c.flagDiff, rightPorts)
+	if err != nil {
+		return err
+	}
+
+	pairs, warnings := pairProxies(leftConfigs, rightConfigs, c.flagPodName, c.flagDiff)
+	for _, warning := range warnings {
+		c.UI.Output(warning, terminal.WithWarningStyle())
+	}
+
+	diffs := make(map[string]map[string][]Difference, len(pairs))
+	for _, pair := range pairs {
+		d := make(map[string][]Difference)
+		if c.shouldPrintTable(c.flagClusters) {
+			d["clusters"] = DiffClusters(
+				FilterClusters(pair.left.Clusters, c.flagFQDN, c.flagAddress, c.flagPort),
+				FilterClusters(pair.right.Clusters, c.flagFQDN, c.flagAddress, c.flagPort),
+				leftIP, rightIP)
+		}
+		if c.shouldPrintTable(c.flagListeners) {
+			d["listeners"] = DiffListeners(
+				FilterListeners(pair.left.Listeners, c.flagAddress, c.flagPort),
+				FilterListeners(pair.right.Listeners, c.flagAddress, c.flagPort),
+				leftIP, rightIP)
+		}
+		if c.shouldPrintTable(c.flagRoutes) {
+			d["routes"] = DiffRoutes(pair.left.Routes, pair.right.Routes)
+		}
+		diffs[pair.name] = d
+	}
+
+	if c.flagOutput == JSON {
+		out, err := json.MarshalIndent(diffs, "", "\t")
+		if err != nil {
+			return err
+		}
+		c.UI.Output(strings.ReplaceAll(string(out), "\\u003e", ">"))
+		return nil
+	}
+
+	for _, pair := range pairs {
+		if pair.name == c.flagPodName {
+			c.UI.Output(fmt.Sprintf("Envoy configuration differences between %s and %s in namespace %s:",
+				c.flagPodName, c.flagDiff, c.flagNamespace))
+		} else {
+			c.UI.Output(fmt.Sprintf("Envoy configuration differences for %s between %s and %s in namespace %s:",
+				pair.name, c.flagPodName, c.flagDiff, c.flagNamespace))
+		}
+		for _, kind := range []string{"clusters", "listeners", "routes"} {
+			d, ok := diffs[pair.name][kind]
+			if !ok {
+				continue
+			}
+			c.UI.Output(fmt.Sprintf("%s (%d differences)", strings.ToUpper(kind[:1])+kind[1:], len(d)), terminal.WithHeaderStyle())
+			if len(d) == 0 {
+				c.UI.Output("No differences.", terminal.WithInfoStyle())
+			} else {
+				c.UI.Table(formatDifferences(d, c.flagPodName, c.flagDiff))
+			}
+			c.UI.Output("")
+		}
+	}
+	return nil
+}
+
+// pairProxies pairs the proxies of the two pods being compared. The proxies of pods with a single
+// proxy are paired under the name of the first pod. Proxies of multi port pods are paired by service,
+// and a warning is returned for each service that only one of the pods has.
+func pairProxies(left, right map[string]*envoy.EnvoyConfig, leftPod, rightPod string) ([]proxyPair, []string) {
+	// The configurations of pods with a single proxy are mapped by the name of the pod.
+	if leftCfg, ok := left[leftPod]; ok && len(left) == 1 {
+		if rightCfg, ok := right[rightPod]; ok && len(right) == 1 {
+			return []proxyPair{{name: leftPod, left: leftCfg, right: rightCfg}}, nil
+		}
+	}
+
+	var pairs []proxyPair
+	var warnings []string
+	for name, leftCfg := range left {
+		rightCfg, ok := right[name]
+		if !ok {
+			warnings = append(warnings, fmt.Sprintf("The proxy for %s is only in %s.", name, leftPod))
+			continue
+		}
+		pairs = append(pairs, proxyPair{name: name, left: leftCfg, right: rightCfg})
+	}
+	for name := range right {
+		if _, ok := left[name]; !ok {
+			warnings = append(warnings, fmt.Sprintf("The proxy for %s is only in %s.", name, rightPod))
+		}
+	}
+	sort.Slice(pairs, func(i, j int) bool { return pairs[i].name < pairs[j].name })
+	sort.Strings(warnings)
+	return pairs, warnings
+}
+
+// DiffClusters returns the differences between two sets of clusters, ignoring when they were last updated.
+func DiffClusters(left, right []envoy.Cluster, leftIP, rightIP string) []Difference {
+	fields := func(c envoy.Cluster, podIP string) map[string]string {
+		endpoints := append([]string{}, c.Endpoints...)
+		sort.Strings(endpoints)
+		return map[string]string{
+			"fqdn":      replacePodIP(c.FullyQualifiedDomainName, podIP),
+			"endpoints": replacePodIP(strings.Join(endpoints, ", "), podIP),
+			"type":      c.Type,
+		}
+	}
+
+	l := make(map[string]map[string]string, len(left))
+	for _, c := range left {
+		l[replacePodIP(c.Name, leftIP)] = fields(c, leftIP)
+	}
+	r := make(map[string]map[string]string, len(right))
+	for _, c := range right {
+		r[replacePodIP(c.Name, rightIP)] = fields(c, rightIP)
+	}
+	return diffResources("cluster", l, r)
+}
+
+// DiffListeners returns the differences between two sets of listeners, including
+// their filter chains, ignoring when they were last updated.
+func DiffListeners(left, right []envoy.Listener, leftIP, rightIP string) []Difference {
+	fields := func(listener envoy.Listener, podIP string) map[string]string {
+		chains := make([]string, 0, len(listener.FilterChain))
+		for _, chain := range listener.FilterChain {
+			chains = append(chains, replacePodIP(chain.FilterChainMatch+" -> "+strings.Join(chain.Filters, ", "), podIP))
+		}
+		return map[string]string{
+			"address":      replacePodIP(listener.Address, podIP),
+			"direction":    listener.Direction,
+			"filter chain": strings.Join(chains, "\n"),
+		}
+	}
+
+	l := make(map[string]map[string]string, len(left))
+	for _, listener := range left {
+		l[replacePodIP(listener.Name, leftIP)] = fields(listener, leftIP)
+	}
+	r := make(map[string]map[string]string, len(right))
+	for _, listener := range right {
+		r[replacePodIP(listener.Name, rightIP)] = fields(listener, rightIP)
+	}
+	return diffResources("listener", l, r)
+}
+
+// DiffRoutes returns the differences between two sets of routes, ignoring when they were last updated.
+func DiffRoutes(left, right []envoy.Route) []Difference {
+	l := make(map[string]map[string]string, len(left))
+	for _, route := range left {
+		l[route.Name] = map[string]string{"destination cluster": route.DestinationCluster}
+	}
+	r := make(map[string]map[string]string, len(right))
+	for _, route := range right {
+		r[route.Name] = map[string]string{"destination cluster": route.DestinationCluster}
+	}
+	return diffResources("route", l, r)
+}
+
+// diffResources returns the differences between two sets of resources of a kind, mapped
+// by name to their fields. The differences are sorted by name and field.
+func diffResources(kind string, left, right map[string]map[string]string) []Difference {
+	names := make(map[string]struct{}, len(left)+len(right))
+	for name := range left {
+		names[name] = struct{}{}
+	}
+	for name := range right {
+		names[name] = struct{}{}
+	}
+	sorted := make([]string, 0, len(names))
+	for name := range names {
+		sorted = append(sorted, name)
+	}
+	sort.Strings(sorted)
+
+	var diffs []Difference
+	for _, name := range sorted {
+		l, inLeft := left[name]
+		r, inRight := right[name]
+		switch {
+		case !inRight:
+			diffs = append(diffs, Difference{Kind: kind, Name: name, Left: "present", Right: "missing"})
+		case !inLeft:
+			diffs = append(diffs, Difference{Kind: kind, Name: name, Left: "missing", Right: "present"})
+		default:
+			fields := make([]string, 0, len(l))
+			for field := range l {
+				fields = append(fields, field)
+			}
+			sort.Strings(fields)
+			for _, field := range fields {
+				if l[field] != r[field] {
+					diffs = append(diffs, Difference{Kind: kind, Name: name, Field: field, Left: l[field], Right: r[field]})
+				}
+			}
+		}
+	}
+	return diffs
+}
+
+func replacePodIP(s, podIP string) string {
+	if podIP == "" {
+		return s
+	}
+	return strings.ReplaceAll(s, podIP, podIPPlaceholder)
+}
+
+func formatDifferences(diffs []Difference, left, right string) *terminal.Table {
+	table := terminal.NewTable("Kind", "Name", "Field", left, right)
+	for _, diff := range diffs {
+		field := diff.Field
+		if field == "" {
+			field = "-"
+		}
+		table.AddRow([]string{diff.Kind, diff.Name, field, diff.Left, diff.Right}, []string{})
+	}
+	return table
+}
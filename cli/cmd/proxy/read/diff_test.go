@@ -0,0 +1,94 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package read
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/hashicorp/consul-k8s/cli/common/envoy"
+)
+
+func TestDiffClusters(t *testing.T) {
+	left := []envoy.Cluster{
+		{Name: "local_app", FullyQualifiedDomainName: "local_app", Endpoints: []string{"127.0.0.1:8080"}, Type: "STATIC", LastUpdated: "2022-05-13T04:22:39.655Z"},
+		{Name: "backend", FullyQualifiedDomainName: "backend.default.dc1.internal.consul", Endpoints: []string{"10.0.0.2:20000", "10.0.0.1:20000"}, Type: "EDS"},
+		{Name: "frontend", FullyQualifiedDomainName: "frontend.default.dc1.internal.consul", Type: "EDS"},
+	}
+	right := []envoy.Cluster{
+		{Name: "local_app", FullyQualifiedDomainName: "local_app", Endpoints: []string{"127.0.0.1:8080"}, Type: "STATIC", LastUpdated: "2022-05-14T04:22:39.655Z"},
+		{Name: "backend", FullyQualifiedDomainName: "backend.default.dc1.internal.consul", Endpoints: []string{"10.0.0.1:20000"}, Type: "EDS"},
+		{Name: "db", FullyQualifiedDomainName: "db.default.dc1.internal.consul", Type: "EDS"},
+	}
+
+	require.Equal(t, []Difference{
+		{Kind: "cluster", Name: "backend", Field: "endpoints", Left: "10.0.0.1:20000, 10.0.0.2:20000", Right: "10.0.0.1:20000"},
+		{Kind: "cluster", Name: "db", Left: "missing", Right: "present"},
+		{Kind: "cluster", Name: "frontend", Left: "present", Right: "missing"},
+	}, DiffClusters(left, right, "10.1.0.1", "10.1.0.2"))
+}
+
+func TestDiffListeners(t *testing.T) {
+	left := []envoy.Listener{
+		{Name: "public_listener:10.1.0.1:20000", Address: "10.1.0.1:20000", Direction: "INBOUND", FilterChain: []envoy.FilterChain{
+			{Filters: []string{"HTTP: * -> local_app/"}, FilterChainMatch: "Any"},
+		}},
+		{Name: "outbound_listener:127.0.0.1:15001", Address: "127.0.0.1:15001", Direction: "OUTBOUND", FilterChain: []envoy.FilterChain{
+			{Filters: []string{"TCP: -> backend"}, FilterChainMatch: "10.100.134.173/32"},
+			{Filters: []string{"TCP: -> original-destination"}, FilterChainMatch: "Any"},
+		}},
+	}
+	right := []envoy.Listener{
+		{Name: "public_listener:10.1.0.2:20000", Address: "10.1.0.2:20000", Direction: "INBOUND", FilterChain: []envoy.FilterChain{
+			{Filters: []string{"HTTP: * -> local_app/"}, FilterChainMatch: "Any"},
+		}},
+		{Name: "outbound_listener:127.0.0.1:15001", Address: "127.0.0.1:15001", Direction: "OUTBOUND", FilterChain: []envoy.FilterChain{
+			{Filters: []string{"TCP: -> original-destination"}, FilterChainMatch: "Any"},
+		}},
+	}
+
+	// The public listeners are bound to the IP of each pod, so they don't differ.
+	require.Equal(t, []Difference{
+		{
+			Kind:  "listener",
+			Name:  "outbound_listener:127.0.0.1:15001",
+			Field: "filter chain",
+			Left:  "10.100.134.173/32 -> TCP: -> backend\nAny -> TCP: -> original-destination",
+			Right: "Any -> TCP: -> original-destination",
+		},
+	}, DiffListeners(left, right, "10.1.0.1", "10.1.0.2"))
+}
+
+func TestDiffRoutes(t *testing.T) {
+	left := []envoy.Route{{Name: "public_listener", DestinationCluster: "local_app/", LastUpdated: "2022-08-10T12:30:47.141Z"}}
+	right := []envoy.Route{{Name: "public_listener", DestinationCluster: "local_app/"}}
+	require.Empty(t, DiffRoutes(left, right))
+
+	right[0].DestinationCluster = "local_app_v2/"
+	require.Equal(t, []Difference{
+		{Kind: "route", Name: "public_listener", Field: "destination cluster", Left: "local_app/", Right: "local_app_v2/"},
+	}, DiffRoutes(left, right))
+}
+
+func TestPairProxies(t *testing.T) {
+	cfg := &envoy.EnvoyConfig{}
+
+	pairs, warnings := pairProxies(
+		map[string]*envoy.EnvoyConfig{"web-1": cfg},
+		map[string]*envoy.EnvoyConfig{"web-2": cfg},
+		"web-1", "web-2")
+	require.Equal(t, []proxyPair{{name: "web-1", left: cfg, right: cfg}}, pairs)
+	require.Empty(t, warnings)
+
+	pairs, warnings = pairProxies(
+		map[string]*envoy.EnvoyConfig{"web": cfg, "web-admin": cfg},
+		map[string]*envoy.EnvoyConfig{"web": cfg, "web-metrics": cfg},
+		"web-1", "web-2")
+	require.Equal(t, []proxyPair{{name: "web", left: cfg, right: cfg}}, pairs)
+	require.Equal(t, []string{
+		"The proxy for web-admin is only in web-1.",
+		"The proxy for web-metrics is only in web-2.",
+	}, warnings)
+}
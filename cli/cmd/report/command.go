@@ -0,0 +1,29 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package report
+
+import (
+	"fmt"
+
+	"github.com/hashicorp/consul-k8s/cli/common"
+	"github.com/mitchellh/cli"
+)
+
+// ReportCommand provides a synopsis for the report subcommands (e.g. resources).
+type ReportCommand struct {
+	*common.BaseCommand
+}
+
+// Run prints out information about the subcommands.
+func (c *ReportCommand) Run([]string) int {
+	return cli.RunResultHelp
+}
+
+func (c *ReportCommand) Help() string {
+	return fmt.Sprintf("%s\n\nUsage: consul-k8s report <subcommand>", c.Synopsis())
+}
+
+func (c *ReportCommand) Synopsis() string {
+	return "Report on the Consul components running in the Kubernetes cluster."
+}
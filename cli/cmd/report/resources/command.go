@@ -0,0 +1,440 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package resources
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/posener/complete"
+	helmCLI "helm.sh/helm/v3/pkg/cli"
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	"k8s.io/apimachinery/pkg/api/validation"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/kubernetes"
+
+	"github.com/hashicorp/consul-k8s/cli/common"
+	"github.com/hashicorp/consul-k8s/cli/common/flag"
+	"github.com/hashicorp/consul-k8s/cli/common/terminal"
+)
+
+const (
+	flagNameNamespace   = "namespace"
+	flagNameKubeConfig  = "kubeconfig"
+	flagNameKubeContext = "context"
+	flagOutputFormat    = "output-format"
+
+	// dataplaneContainer is the name of the dataplane container injected into pods, which is
+	// suffixed with the name of the service for pods with multiple ports.
+	dataplaneContainer = "consul-dataplane"
+
+	// podMetricsPath is the path of the metrics-server API that lists the usage of the pods of a
+	// namespace, or of every namespace when it's empty.
+	podMetricsPath = "/apis/metrics.k8s.io/v1beta1"
+)
+
+// The types of mesh components resources are reported for.
+const (
+	componentSidecar    = "Sidecar"
+	componentGateway    = "Gateway"
+	componentServer     = "Server"
+	componentController = "Controller"
+)
+
+// componentSelectors select the pods of each type of component. A pod selected by more than one
+// selector is counted as the type of the first one.
+var componentSelectors = []struct {
+	Component string
+	Selector  string
+}{
+	{Component: componentGateway, Selector: "component=api-gateway, gateway.consul.hashicorp.com/managed=true"},
+	{Component: componentGateway, Selector: "component in (ingress-gateway, mesh-gateway, terminating-gateway), chart=consul-helm"},
+	{Component: componentServer, Selector: "component=server, chart=consul-helm"},
+	{Component: componentController, Selector: "component notin (server, client, ingress-gateway, mesh-gateway, terminating-gateway), chart=consul-helm"},
+	{Component: componentSidecar, Selector: "consul.hashicorp.com/connect-inject-status=injected"},
+}
+
+// ResourcesCommand is the command struct for the report resources command.
+type ResourcesCommand struct {
+	*common.BaseCommand
+
+	kubernetes kubernetes.Interface
+
+	// fetchPodMetrics returns the live usage of the pods of a namespace, or of every namespace
+	// when it's empty. It defaults to querying metrics-server.
+	fetchPodMetrics func(ctx context.Context, namespace string) ([]podMetrics, error)
+
+	set *flag.Sets
+
+	flagNamespace    string
+	flagOutputFormat string
+
+	flagKubeConfig  string
+	flagKubeContext string
+
+	once sync.Once
+	help string
+}
+
+// podMetrics is the usage of a pod reported by the metrics.k8s.io API.
+type podMetrics struct {
+	metav1.ObjectMeta `json:"metadata"`
+	Containers        []containerMetrics `json:"containers"`
+}
+
+type containerMetrics struct {
+	Name  string          `json:"name"`
+	Usage v1.ResourceList `json:"usage"`
+}
+
+// usage sums the requests, limits and live usage of the containers of a type of component in a namespace.
+type usage struct {
+	Namespace string
+	Component string
+	Pods      int
+
+	CPURequests    resource.Quantity
+	CPULimits      resource.Quantity
+	CPUUsage       resource.Quantity
+	MemoryRequests resource.Quantity
+	MemoryLimits   resource.Quantity
+	MemoryUsage    resource.Quantity
+}
+
+// init sets up flags and help text for the command.
+func (c *ResourcesCommand) init() {
+	c.set = flag.NewSets()
+
+	f := c.set.NewSet("Command Options")
+	f.StringVar(&flag.StringVar{
+		Name:    flagNameNamespace,
+		Target:  &c.flagNamespace,
+		Usage:   "The namespace to report the resources of. Defaults to all namespaces.",
+		Aliases: []string{"n"},
+	})
+	f.StringVar(&flag.StringVar{
+		Name:    flagOutputFormat,
+		Default: "table",
+		Target:  &c.flagOutputFormat,
+		Usage:   "Output format, table or json.",
+		Aliases: []string{"o"},
+	})
+
+	f = c.set.NewSet("Global Options")
+	f.StringVar(&flag.StringVar{
+		Name:    flagNameKubeConfig,
+		Aliases: []string{"c"},
+		Target:  &c.flagKubeConfig,
+		Default: "",
+		Usage:   "Set the path to kubeconfig file.",
+	})
+	f.StringVar(&flag.StringVar{
+		Name:    flagNameKubeContext,
+		Target:  &c.flagKubeContext,
+		Default: "",
+		Usage:   "Set the Kubernetes context to use.",
+	})
+
+	c.help = c.set.Help()
+}
+
+// Run executes the report resources command.
+func (c *ResourcesCommand) Run(args []string) int {
+	c.once.Do(c.init)
+	c.Log.ResetNamed("report resources")
+	defer common.CloseWithError(c.BaseCommand)
+
+	if err := c.set.Parse(args); err != nil {
+		c.UI.Output("Error parsing arguments: %v", err.Error(), terminal.WithErrorStyle())
+		return 1
+	}
+
+	if err := c.validateFlags(); err != nil {
+		c.UI.Output("Invalid argument: %v", err.Error(), terminal.WithErrorStyle())
+		return 1
+	}
+
+	if c.kubernetes == nil {
+		if err := c.initKubernetes(); err != nil {
+			c.UI.Output("Error initializing Kubernetes client", err.Error(), terminal.WithErrorStyle())
+			return 1
+		}
+	}
+	if c.fetchPodMetrics == nil {
+		c.fetchPodMetrics = c.metricsServerPodMetrics
+	}
+
+	pods, err := c.fetchPods()
+	if err != nil {
+		c.UI.Output("Error fetching pods:", err.Error(), terminal.WithErrorStyle())
+		return 1
+	}
+
+	// The requests and limits are still reported when metrics-server isn't installed.
+	metrics, err := c.fetchPodMetrics(c.Ctx, c.flagNamespace)
+	if err != nil {
+		c.Log.Debug("error fetching pod metrics", "err", err)
+	}
+
+	c.output(aggregate(pods, metrics), err == nil)
+	return 0
+}
+
+// Help returns a description of the command and how it is used.
+func (c *ResourcesCommand) Help() string {
+	c.once.Do(c.init)
+	return fmt.Sprintf("%s\n\nUsage: consul-k8s report resources [flags]\n\n"+
+		"Sums the CPU and memory requests, limits and live usage of the injected dataplane sidecars, the gateways,\n"+
+		"the Consul servers and the Consul controllers, per namespace. The live usage is read from metrics-server\n"+
+		"and is omitted if it isn't installed.\n\n%s", c.Synopsis(), c.help)
+}
+
+// Synopsis returns a one-line command summary.
+func (c *ResourcesCommand) Synopsis() string {
+	return "Report the resources used by the Consul service mesh components."
+}
+
+// AutocompleteFlags returns a mapping of supported flags and autocomplete
+// options for this command. The map key for the Flags map should be the
+// complete flag such as "-foo" or "--foo".
+func (c *ResourcesCommand) AutocompleteFlags() complete.Flags {
+	return complete.Flags{
+		fmt.Sprintf("-%s", flagNameNamespace):   complete.PredictNothing,
+		fmt.Sprintf("-%s", flagNameKubeConfig):  complete.PredictFiles("*"),
+		fmt.Sprintf("-%s", flagNameKubeContext): complete.PredictNothing,
+		fmt.Sprintf("-%s", flagOutputFormat):    complete.PredictSet("table", "json"),
+	}
+}
+
+// AutocompleteArgs returns the argument predictor for this command.
+// Since argument completion is not supported, this will return
+// complete.PredictNothing.
+func (c *ResourcesCommand) AutocompleteArgs() complete.Predictor {
+	return complete.PredictNothing
+}
+
+// validateFlags ensures that the flags passed in by the user can be used.
+func (c *ResourcesCommand) validateFlags() error {
+	if len(c.set.Args()) > 0 {
+		return errors.New("should have no non-flag arguments")
+	}
+	if errs := validation.ValidateNamespaceName(c.flagNamespace, false); c.flagNamespace != "" && len(errs) > 0 {
+		return fmt.Errorf("invalid namespace name passed for -namespace/-n: %v", strings.Join(errs, "; "))
+	}
+	if c.flagOutputFormat != "table" && c.flagOutputFormat != "json" {
+		return fmt.Errorf("-output-format must be table or json, got %q", c.flagOutputFormat)
+	}
+	return nil
+}
+
+// initKubernetes initializes the Kubernetes client.
+func (c *ResourcesCommand) initKubernetes() error {
+	settings := helmCLI.New()
+
+	if c.flagKubeConfig != "" {
+		settings.KubeConfig = c.flagKubeConfig
+	}
+
+	if c.flagKubeContext != "" {
+		settings.KubeContext = c.flagKubeContext
+	}
+
+	restConfig, err := settings.RESTClientGetter().ToRESTConfig()
+	if err != nil {
+		return fmt.Errorf("error retrieving Kubernetes authentication %v", err)
+	}
+	if c.kubernetes, err = kubernetes.NewForConfig(restConfig); err != nil {
+		return fmt.Errorf("error creating Kubernetes client %v", err)
+	}
+
+	return nil
+}
+
+// componentPod is a running pod of a mesh component.
+type componentPod struct {
+	v1.Pod
+	Component string
+}
+
+// fetchPods fetches the running pods of the mesh components, each pod only once even if it's
+// selected by more than one selector.
+func (c *ResourcesCommand) fetchPods() ([]componentPod, error) {
+	seen := make(map[types.NamespacedName]struct{})
+	var pods []componentPod
+	for _, selector := range componentSelectors {
+		list, err := c.kubernetes.CoreV1().Pods(c.flagNamespace).List(c.Ctx, metav1.ListOptions{
+			LabelSelector: selector.Selector,
+		})
+		if err != nil {
+			return nil, err
+		}
+
+		for _, pod := range list.Items {
+			// Completed pods, e.g. the pods of the Jobs of the Helm chart, don't use resources anymore.
+			if pod.Status.Phase == v1.PodSucceeded || pod.Status.Phase == v1.PodFailed {
+				continue
+			}
+			name := types.NamespacedName{Namespace: pod.Namespace, Name: pod.Name}
+			if _, ok := seen[name]; ok {
+				continue
+			}
+			seen[name] = struct{}{}
+			pods = append(pods, componentPod{Pod: pod, Component: selector.Component})
+		}
+	}
+	return pods, nil
+}
+
+// metricsServerPodMetrics lists the usage of the pods from the metrics.k8s.io API of metrics-server.
+func (c *ResourcesCommand) metricsServerPodMetrics(ctx context.Context, namespace string) ([]podMetrics, error) {
+	path := podMetricsPath + "/pods"
+	if namespace != "" {
+		path = fmt.Sprintf("%s/namespaces/%s/pods", podMetricsPath, namespace)
+	}
+	raw, err := c.kubernetes.Discovery().RESTClient().Get().AbsPath(path).DoRaw(ctx)
+	if err != nil {
+		return nil, err
+	}
+	var list struct {
+		Items []podMetrics `json:"items"`
+	}
+	if err := json.Unmarshal(raw, &list); err != nil {
+		return nil, err
+	}
+	return list.Items, nil
+}
+
+// meshContainer returns whether the container runs a mesh component. Only the dataplane containers
+// of injected pods are counted for sidecars since the other containers run the application.
+func meshContainer(component, name string) bool {
+	if component != componentSidecar {
+		return true
+	}
+	return name == dataplaneContainer || strings.HasPrefix(name, dataplaneContainer+"-")
+}
+
+// aggregate sums the requests, limits and usage of the mesh containers of the pods per namespace and
+// type of component, sorted by namespace and type.
+func aggregate(pods []componentPod, metrics []podMetrics) []*usage {
+	usageByPod := make(map[types.NamespacedName]map[string]v1.ResourceList, len(metrics))
+	for _, m := range metrics {
+		containers := make(map[string]v1.ResourceList, len(m.Containers))
+		for _, container := range m.Containers {
+			containers[container.Name] = container.Usage
+		}
+		usageByPod[types.NamespacedName{Namespace: m.Namespace, Name: m.Name}] = containers
+	}
+
+	type key struct{ namespace, component string }
+	usages := make(map[key]*usage)
+	for _, pod := range pods {
+		k := key{namespace: pod.Namespace, component: pod.Component}
+		u, ok := usages[k]
+		if !ok {
+			u = &usage{Namespace: pod.Namespace, Component: pod.Component}
+			usages[k] = u
+		}
+		u.Pods++
+
+		podUsage := usageByPod[types.NamespacedName{Namespace: pod.Namespace, Name: pod.Name}]
+		for _, container := range pod.Spec.Containers {
+			if !meshContainer(pod.Component, container.Name) {
+				continue
+			}
+			addQuantity(&u.CPURequests, container.Resources.Requests, v1.ResourceCPU)
+			addQuantity(&u.CPULimits, container.Resources.Limits, v1.ResourceCPU)
+			addQuantity(&u.CPUUsage, podUsage[container.Name], v1.ResourceCPU)
+			addQuantity(&u.MemoryRequests, container.Resources.Requests, v1.ResourceMemory)
+			addQuantity(&u.MemoryLimits, container.Resources.Limits, v1.ResourceMemory)
+			addQuantity(&u.MemoryUsage, podUsage[container.Name], v1.ResourceMemory)
+		}
+	}
+
+	result := make([]*usage, 0, len(usages))
+	for _, u := range usages {
+		result = append(result, u)
+	}
+	sort.Slice(result, func(i, j int) bool {
+		if result[i].Namespace != result[j].Namespace {
+			return result[i].Namespace < result[j].Namespace
+		}
+		return result[i].Component < result[j].Component
+	})
+	return result
+}
+
+func addQuantity(sum *resource.Quantity, resources v1.ResourceList, name v1.ResourceName) {
+	if q, ok := resources[name]; ok {
+		sum.Add(q)
+	}
+}
+
+// formatCPU formats a quantity of CPU in millicores.
+func formatCPU(q resource.Quantity) string {
+	return fmt.Sprintf("%dm", q.MilliValue())
+}
+
+// formatMemory formats a quantity of memory in mebibytes.
+func formatMemory(q resource.Quantity) string {
+	return fmt.Sprintf("%dMi", q.Value()/(1024*1024))
+}
+
+// output prints a table of the usages, with a row for their total, to the terminal.
+func (c *ResourcesCommand) output(usages []*usage, withUsage bool) {
+	if len(usages) == 0 {
+		if c.flagNamespace == "" {
+			c.UI.Output("No Consul components found across all namespaces.")
+		} else {
+			c.UI.Output("No Consul components found in %s namespace.", c.flagNamespace)
+		}
+		return
+	}
+
+	total := &usage{Namespace: "Total"}
+	for _, u := range usages {
+		total.Pods += u.Pods
+		total.CPURequests.Add(u.CPURequests)
+		total.CPULimits.Add(u.CPULimits)
+		total.CPUUsage.Add(u.CPUUsage)
+		total.MemoryRequests.Add(u.MemoryRequests)
+		total.MemoryLimits.Add(u.MemoryLimits)
+		total.MemoryUsage.Add(u.MemoryUsage)
+	}
+
+	tbl := terminal.NewTable("Namespace", "Component", "Pods",
+		"CPU Requests", "CPU Limits", "CPU Usage", "Memory Requests", "Memory Limits", "Memory Usage")
+	for _, u := range append(usages, total) {
+		cpuUsage, memoryUsage := "-", "-"
+		if withUsage {
+			cpuUsage, memoryUsage = formatCPU(u.CPUUsage), formatMemory(u.MemoryUsage)
+		}
+		tbl.AddRow([]string{
+			u.Namespace, u.Component, fmt.Sprint(u.Pods),
+			formatCPU(u.CPURequests), formatCPU(u.CPULimits), cpuUsage,
+			formatMemory(u.MemoryRequests), formatMemory(u.MemoryLimits), memoryUsage,
+		}, nil)
+	}
+
+	if c.flagOutputFormat == "json" {
+		jsonSt, err := json.MarshalIndent(tbl.ToJson(), "", "    ")
+		if err != nil {
+			c.UI.Output("Error converting table to json: %v", err.Error(), terminal.WithErrorStyle())
+		} else {
+			c.UI.Output(string(jsonSt))
+		}
+		return
+	}
+
+	c.UI.Table(tbl)
+	if !withUsage {
+		c.UI.Output("\nThe live usage is not reported since metrics-server is not available.", terminal.WithWarningStyle())
+	}
+}
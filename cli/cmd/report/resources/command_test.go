@@ -0,0 +1,206 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package resources
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"os"
+	"testing"
+
+	"github.com/hashicorp/go-hclog"
+	"github.com/stretchr/testify/require"
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+
+	"github.com/hashicorp/consul-k8s/cli/common"
+	"github.com/hashicorp/consul-k8s/cli/common/terminal"
+)
+
+func TestFlagParsing(t *testing.T) {
+	cases := map[string]struct {
+		args []string
+		out  int
+	}{
+		"No args": {
+			args: []string{},
+			out:  0,
+		},
+		"Nonexistent flag passed, -foo bar": {
+			args: []string{"-foo", "bar"},
+			out:  1,
+		},
+		"Invalid argument passed, -namespace YOLO": {
+			args: []string{"-namespace", "YOLO"},
+			out:  1,
+		},
+		"Invalid output format": {
+			args: []string{"-output-format", "yaml"},
+			out:  1,
+		},
+	}
+
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			c := setupCommand(new(bytes.Buffer))
+			c.kubernetes = fake.NewSimpleClientset()
+			c.fetchPodMetrics = noPodMetrics
+			require.Equal(t, tc.out, c.Run(tc.args))
+		})
+	}
+}
+
+func TestRun(t *testing.T) {
+	buf := new(bytes.Buffer)
+	c := setupCommand(buf)
+	c.kubernetes = fake.NewSimpleClientset(
+		// The dataplane of an injected pod is counted, but not the application.
+		pod("default", "web", map[string]string{"consul.hashicorp.com/connect-inject-status": "injected"},
+			container("web", "500m", "1000m", "512Mi"),
+			container("consul-dataplane", "100m", "200m", "128Mi")),
+		pod("default", "api", map[string]string{"consul.hashicorp.com/connect-inject-status": "injected"},
+			container("consul-dataplane-api", "100m", "", "64Mi")),
+		pod("consul", "consul-server-0", map[string]string{"component": "server", "chart": "consul-helm"},
+			container("consul", "1", "2", "1Gi")),
+		pod("consul", "consul-connect-injector-abc", map[string]string{"component": "connect-injector", "chart": "consul-helm"},
+			container("sidecar-injector", "50m", "50m", "50Mi")),
+		pod("consul", "consul-mesh-gateway-abc", map[string]string{"component": "mesh-gateway", "chart": "consul-helm"},
+			container("mesh-gateway", "100m", "100m", "100Mi")),
+		pod("default", "api-gateway-abc", map[string]string{"component": "api-gateway", "gateway.consul.hashicorp.com/managed": "true"},
+			container("consul-dataplane", "100m", "100m", "128Mi")),
+		// Completed pods and pods of other applications are not counted.
+		completed(pod("consul", "consul-server-acl-init-abc", map[string]string{"component": "server-acl-init", "chart": "consul-helm"},
+			container("server-acl-init", "50m", "50m", "50Mi"))),
+		pod("default", "frontend", nil, container("frontend", "1", "1", "1Gi")),
+	)
+	c.fetchPodMetrics = func(_ context.Context, namespace string) ([]podMetrics, error) {
+		require.Empty(t, namespace)
+		return []podMetrics{
+			{
+				ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "web"},
+				Containers: []containerMetrics{
+					{Name: "web", Usage: v1.ResourceList{v1.ResourceCPU: resource.MustParse("400m"), v1.ResourceMemory: resource.MustParse("300Mi")}},
+					{Name: "consul-dataplane", Usage: v1.ResourceList{v1.ResourceCPU: resource.MustParse("20m"), v1.ResourceMemory: resource.MustParse("40Mi")}},
+				},
+			},
+			{
+				ObjectMeta: metav1.ObjectMeta{Namespace: "consul", Name: "consul-server-0"},
+				Containers: []containerMetrics{
+					{Name: "consul", Usage: v1.ResourceList{v1.ResourceCPU: resource.MustParse("250m"), v1.ResourceMemory: resource.MustParse("600Mi")}},
+				},
+			},
+		}, nil
+	}
+
+	require.Equal(t, 0, c.Run([]string{"-output-format", "json"}))
+
+	var rows []map[string]string
+	require.NoError(t, json.Unmarshal(buf.Bytes(), &rows))
+	require.Equal(t, []map[string]string{
+		usageRow("consul", "Controller", "1", "50m", "50m", "0m", "50Mi", "50Mi", "0Mi"),
+		usageRow("consul", "Gateway", "1", "100m", "100m", "0m", "100Mi", "100Mi", "0Mi"),
+		usageRow("consul", "Server", "1", "1000m", "2000m", "250m", "1024Mi", "1024Mi", "600Mi"),
+		usageRow("default", "Gateway", "1", "100m", "100m", "0m", "128Mi", "128Mi", "0Mi"),
+		usageRow("default", "Sidecar", "2", "200m", "200m", "20m", "192Mi", "192Mi", "40Mi"),
+		usageRow("Total", "", "6", "1450m", "2450m", "270m", "1494Mi", "1494Mi", "640Mi"),
+	}, rows)
+}
+
+func TestRun_MetricsServerUnavailable(t *testing.T) {
+	buf := new(bytes.Buffer)
+	c := setupCommand(buf)
+	c.kubernetes = fake.NewSimpleClientset(
+		pod("default", "web", map[string]string{"consul.hashicorp.com/connect-inject-status": "injected"},
+			container("consul-dataplane", "100m", "200m", "128Mi")),
+	)
+	c.fetchPodMetrics = noPodMetrics
+
+	require.Equal(t, 0, c.Run([]string{"-namespace", "default"}))
+	require.Contains(t, buf.String(), "Sidecar")
+	require.Contains(t, buf.String(), "100m")
+	require.Contains(t, buf.String(), "The live usage is not reported since metrics-server is not available.")
+}
+
+func TestRun_NoComponents(t *testing.T) {
+	buf := new(bytes.Buffer)
+	c := setupCommand(buf)
+	c.kubernetes = fake.NewSimpleClientset()
+	c.fetchPodMetrics = noPodMetrics
+
+	require.Equal(t, 0, c.Run([]string{"-namespace", "default"}))
+	require.Contains(t, buf.String(), "No Consul components found in default namespace.")
+}
+
+func noPodMetrics(context.Context, string) ([]podMetrics, error) {
+	return nil, errors.New("the server could not find the requested resource")
+}
+
+func pod(namespace, name string, labels map[string]string, containers ...v1.Container) *v1.Pod {
+	return &v1.Pod{
+		ObjectMeta: metav1.ObjectMeta{Namespace: namespace, Name: name, Labels: labels},
+		Spec:       v1.PodSpec{Containers: containers},
+		Status:     v1.PodStatus{Phase: v1.PodRunning},
+	}
+}
+
+func completed(pod *v1.Pod) *v1.Pod {
+	pod.Status.Phase = v1.PodSucceeded
+	return pod
+}
+
+// container returns a container with the given CPU request and limit, and memory request and limit.
+// An empty CPU limit leaves the limit unset.
+func container(name, cpuRequest, cpuLimit, memory string) v1.Container {
+	c := v1.Container{
+		Name: name,
+		Resources: v1.ResourceRequirements{
+			Requests: v1.ResourceList{v1.ResourceCPU: resource.MustParse(cpuRequest), v1.ResourceMemory: resource.MustParse(memory)},
+			Limits:   v1.ResourceList{v1.ResourceMemory: resource.MustParse(memory)},
+		},
+	}
+	if cpuLimit != "" {
+		c.Resources.Limits[v1.ResourceCPU] = resource.MustParse(cpuLimit)
+	}
+	return c
+}
+
+func usageRow(namespace, component, pods, cpuRequests, cpuLimits, cpuUsage, memoryRequests, memoryLimits, memoryUsage string) map[string]string {
+	return map[string]string{
+		"Namespace":       namespace,
+		"Component":       component,
+		"Pods":            pods,
+		"CPU Requests":    cpuRequests,
+		"CPU Limits":      cpuLimits,
+		"CPU Usage":       cpuUsage,
+		"Memory Requests": memoryRequests,
+		"Memory Limits":   memoryLimits,
+		"Memory Usage":    memoryUsage,
+	}
+}
+
+func setupCommand(buf io.Writer) *ResourcesCommand {
+	// Log at a test level to standard out.
+	log := hclog.New(&hclog.LoggerOptions{
+		Name:   "test",
+		Level:  hclog.Debug,
+		Output: os.Stdout,
+	})
+
+	// Setup and initialize the command struct
+	command := &ResourcesCommand{
+		BaseCommand: &common.BaseCommand{
+			Ctx: context.Background(),
+			Log: log,
+			UI:  terminal.NewUI(context.Background(), buf),
+		},
+	}
+	command.init()
+
+	return command
+}
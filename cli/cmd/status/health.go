@@ -0,0 +1,333 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package status
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/hashicorp/consul/api"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/client-go/dynamic"
+	"k8s.io/client-go/kubernetes"
+
+	"github.com/hashicorp/consul-k8s/cli/common/health"
+	"github.com/hashicorp/consul-k8s/cli/configentries"
+)
+
+const (
+	// controllerLeaseName is the name of the Lease used for the leader election of the
+	// controllers of the connect injector.
+	controllerLeaseName = "consul-controller-lock"
+
+	// maxListedNames is the maximum number of unhealthy resources named in a result.
+	maxListedNames = 3
+)
+
+// healthChecker checks the health of the components of a Consul release.
+type healthChecker struct {
+	kubernetes  kubernetes.Interface
+	dynamic     dynamic.Interface
+	namespace   string
+	releaseName string
+
+	// consulClient returns a client for the Consul servers of the release.
+	consulClient func(ctx context.Context) (*api.Client, error)
+}
+
+// checks returns the health checks of the components of the release.
+func (h *healthChecker) checks() []health.Check {
+	return []health.Check{
+		{Name: "Consul servers", Run: h.checkRaft},
+		{Name: "Connect injector webhook", Run: h.checkWebhook},
+		{Name: "Controller leader election", Run: h.checkLeaderElection},
+		{Name: "Config entry sync", Run: h.checkConfigEntries},
+		{Name: "Gateways", Run: h.checkGateways},
+		{Name: "CNI", Run: h.checkCNI},
+	}
+}
+
+func (h *healthChecker) releaseSelector(component string) string {
+	return fmt.Sprintf("component=%s,release=%s", component, h.releaseName)
+}
+
+// checkRaft checks the raft health of the Consul servers as reported by autopilot.
+func (h *healthChecker) checkRaft(ctx context.Context) health.Result {
+	servers, err := h.kubernetes.AppsV1().StatefulSets(h.namespace).List(ctx, metav1.ListOptions{LabelSelector: h.releaseSelector("server")})
+	if err != nil {
+		return listFailed("Consul server StatefulSets", err)
+	}
+	if len(servers.Items) == 0 {
+		return health.Skip("Consul servers are not running in this Kubernetes cluster")
+	}
+
+	client, err := h.consulClient(ctx)
+	if err != nil {
+		return health.Warn("Set -token to an ACL token with operator:read permissions, and -ca-file if the server CA isn't stored in Kubernetes.",
+			"unable to connect to the Consul servers: %s", err)
+	}
+	reply, err := client.Operator().AutopilotServerHealth((&api.QueryOptions{}).WithContext(ctx))
+	if err != nil {
+		return health.Warn("Check that the ACL token has operator:read permissions.",
+			"unable to read the autopilot health of the Consul servers: %s", err)
+	}
+
+	var unhealthy []string
+	leader := ""
+	for _, s := range reply.Servers {
+		if !s.Healthy {
+			unhealthy = append(unhealthy, s.Name)
+		}
+		if s.Leader {
+			leader = s.Name
+		}
+	}
+	if leader == "" {
+		return health.Fail("Check the logs of the server pods with `kubectl logs`; a quorum of servers must be running to elect a leader.",
+			"the raft cluster has no leader")
+	}
+	if !reply.Healthy || len(unhealthy) > 0 {
+		return health.Fail("Check the logs of the unhealthy server pods with `kubectl logs` and that they can reach each other.",
+			"%d/%d servers are unhealthy: %s", len(unhealthy), len(reply.Servers), listNames(unhealthy))
+	}
+	if reply.FailureTolerance == 0 && len(reply.Servers) > 1 {
+		return health.Warn("Run an odd number of at least 3 servers so that the cluster tolerates the loss of a server.",
+			"%d servers are healthy but the cluster can't tolerate the loss of a server", len(reply.Servers))
+	}
+	return health.Pass("%d servers are healthy, the leader is %s and the cluster tolerates the loss of %d servers",
+		len(reply.Servers), leader, reply.FailureTolerance)
+}
+
+// checkWebhook checks that the mutating webhook of the connect injector is configured, that its
+// service has ready endpoints and that it handles a dry-run admission request.
+func (h *healthChecker) checkWebhook(ctx context.Context) health.Result {
+	configs, err := h.kubernetes.AdmissionregistrationV1().MutatingWebhookConfigurations().List(ctx, metav1.ListOptions{LabelSelector: h.releaseSelector("connect-injector")})
+	if err != nil {
+		return listFailed("MutatingWebhookConfigurations", err)
+	}
+	if len(configs.Items) == 0 {
+		return health.Skip("the connect injector is not installed")
+	}
+
+	for _, config := range configs.Items {
+		for _, webhook := range config.Webhooks {
+			if len(webhook.ClientConfig.CABundle) == 0 {
+				return health.Fail("Check the logs of the webhook-cert-manager pod, which sets the CA bundle of the webhook.",
+					"webhook %s of %s has no CA bundle", webhook.Name, config.Name)
+			}
+			svc := webhook.ClientConfig.Service
+			if svc == nil {
+				continue
+			}
+			endpoints, err := h.kubernetes.CoreV1().Endpoints(svc.Namespace).Get(ctx, svc.Name, metav1.GetOptions{})
+			if err != nil && !k8serrors.IsNotFound(err) {
+				return listFailed("webhook service endpoints", err)
+			}
+			if endpoints == nil || !hasReadyAddresses(endpoints.Subsets) {
+				return health.Fail("Check the status and logs of the connect-injector pods with `kubectl describe pod` and `kubectl logs`.",
+					"webhook service %s/%s has no ready endpoints", svc.Namespace, svc.Name)
+			}
+		}
+	}
+
+	// The pod opts out of injection so that the webhook accepts it without any side effects.
+	pod := &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{
+			GenerateName: "consul-k8s-status-",
+			Annotations:  map[string]string{"consul.hashicorp.com/connect-inject": "false"},
+		},
+		Spec: corev1.PodSpec{Containers: []corev1.Container{{Name: "status", Image: "busybox"}}},
+	}
+	_, err = h.kubernetes.CoreV1().Pods(h.namespace).Create(ctx, pod, metav1.CreateOptions{DryRun: []string{metav1.DryRunAll}})
+	if err != nil {
+		if strings.Contains(err.Error(), "failed calling webhook") {
+			return health.Fail("Check that the Kubernetes API server can reach the connect-injector service, e.g. that firewall rules allow traffic from the control plane to the webhook port.",
+				"dry-run admission request failed: %s", err)
+		}
+		return health.Warn("Check the admission webhooks and policies of the namespace.",
+			"dry-run admission request was rejected: %s", err)
+	}
+	return health.Pass("dry-run admission request succeeded")
+}
+
+// checkLeaderElection checks that a connect injector pod holds the leader election lease of the controllers.
+func (h *healthChecker) checkLeaderElection(ctx context.Context) health.Result {
+	injectors, err := h.kubernetes.AppsV1().Deployments(h.namespace).List(ctx, metav1.ListOptions{LabelSelector: h.releaseSelector("connect-injector")})
+	if err != nil {
+		return listFailed("connect injector Deployments", err)
+	}
+	if len(injectors.Items) == 0 {
+		return health.Skip("the connect injector is not installed")
+	}
+
+	lease, err := h.kubernetes.CoordinationV1().Leases(h.namespace).Get(ctx, controllerLeaseName, metav1.GetOptions{})
+	if k8serrors.IsNotFound(err) {
+		return health.Fail("Check the status and logs of the connect-injector pods with `kubectl describe pod` and `kubectl logs`.",
+			"no controller has acquired the leader election lease %s", controllerLeaseName)
+	}
+	if err != nil {
+		return listFailed("leader election lease", err)
+	}
+	holder := ""
+	if lease.Spec.HolderIdentity != nil {
+		holder = *lease.Spec.HolderIdentity
+	}
+	if holder == "" {
+		return health.Fail("Check the status and logs of the connect-injector pods with `kubectl describe pod` and `kubectl logs`.",
+			"the leader election lease %s has no holder", controllerLeaseName)
+	}
+	if lease.Spec.RenewTime != nil && lease.Spec.LeaseDurationSeconds != nil {
+		expiry := lease.Spec.RenewTime.Add(time.Duration(*lease.Spec.LeaseDurationSeconds) * time.Second)
+		if time.Now().After(expiry) {
+			return health.Fail("Check the logs of the connect-injector pods; the leader may be unable to reach the Kubernetes API server.",
+				"the leader election lease held by %s expired at %s", holder, expiry.Format(time.RFC3339))
+		}
+	}
+	return health.Pass("the controllers are led by %s", holder)
+}
+
+// checkConfigEntries counts the custom resources that manage Consul config entries by their Synced condition.
+func (h *healthChecker) checkConfigEntries(ctx context.Context) health.Result {
+	var synced, pending int
+	var failed []string
+	installed := false
+	for _, kind := range configentries.Kinds() {
+		gvr, _ := configentries.GroupVersionResource(kind)
+		list, err := h.dynamic.Resource(gvr).Namespace(metav1.NamespaceAll).List(ctx, metav1.ListOptions{})
+		if k8serrors.IsNotFound(err) {
+			continue
+		}
+		if err != nil {
+			return listFailed(gvr.Resource, err)
+		}
+		installed = true
+		for _, cr := range list.Items {
+			switch status, message := syncedCondition(cr); status {
+			case string(corev1.ConditionTrue):
+				synced++
+			case string(corev1.ConditionFalse):
+				failed = append(failed, fmt.Sprintf("%s %s/%s (%s)", kind, cr.GetNamespace(), cr.GetName(), message))
+			default:
+				pending++
+			}
+		}
+	}
+	if !installed {
+		return health.Skip("the config entry CRDs are not installed")
+	}
+	if len(failed) > 0 {
+		return health.Warn("Run `kubectl describe` on the resources to see their Synced and LastSyncError conditions and the Events of the failed syncs.",
+			"%d synced, %d pending, %d failed: %s", synced, pending, len(failed), listNames(failed))
+	}
+	return health.Pass("%d synced, %d pending, 0 failed", synced, pending)
+}
+
+// checkGateways checks that the gateway pods of the release are ready.
+func (h *healthChecker) checkGateways(ctx context.Context) health.Result {
+	selectors := map[string]string{
+		h.namespace: fmt.Sprintf("component in (mesh-gateway,ingress-gateway,terminating-gateway),release=%s", h.releaseName),
+		// API gateways are deployed in the namespaces of their Gateway resources.
+		metav1.NamespaceAll: "component=api-gateway,gateway.consul.hashicorp.com/managed=true",
+	}
+	var total int
+	var notReady []string
+	for namespace, selector := range selectors {
+		pods, err := h.kubernetes.CoreV1().Pods(namespace).List(ctx, metav1.ListOptions{LabelSelector: selector})
+		if err != nil {
+			return listFailed("gateway pods", err)
+		}
+		for _, pod := range pods.Items {
+			total++
+			if !podReady(pod) {
+				notReady = append(notReady, pod.Namespace+"/"+pod.Name)
+			}
+		}
+	}
+	if total == 0 {
+		return health.Skip("no gateways are deployed")
+	}
+	if len(notReady) > 0 {
+		return health.Fail("Check the status and logs of the gateway pods with `kubectl describe pod` and `kubectl logs`.",
+			"%d/%d gateway pods are ready, not ready: %s", total-len(notReady), total, listNames(notReady))
+	}
+	return health.Pass("%d/%d gateway pods are ready", total, total)
+}
+
+// checkCNI checks that the rollout of the CNI DaemonSet of the release is complete.
+func (h *healthChecker) checkCNI(ctx context.Context) health.Result {
+	// The CNI plugin can be deployed in a different namespace than the release.
+	daemonSets, err := h.kubernetes.AppsV1().DaemonSets(metav1.NamespaceAll).List(ctx, metav1.ListOptions{LabelSelector: h.releaseSelector("cni")})
+	if err != nil {
+		return listFailed("CNI DaemonSets", err)
+	}
+	if len(daemonSets.Items) == 0 {
+		return health.Skip("the CNI plugin is not installed")
+	}
+
+	ds := daemonSets.Items[0]
+	if rolloutComplete(ds) {
+		return health.Pass("%d/%d pods are ready", ds.Status.NumberReady, ds.Status.DesiredNumberScheduled)
+	}
+	return health.Fail("Check the status and logs of the CNI pods with `kubectl describe pod` and `kubectl logs`; pods with mesh sidecars can't start on nodes without a ready CNI pod.",
+		"rollout of DaemonSet %s/%s is incomplete: %d/%d pods are ready and %d are updated",
+		ds.Namespace, ds.Name, ds.Status.NumberReady, ds.Status.DesiredNumberScheduled, ds.Status.UpdatedNumberScheduled)
+}
+
+func listFailed(resource string, err error) health.Result {
+	return health.Fail("Check that your Kubernetes credentials have read access to the resources of the release.",
+		"unable to read %s: %s", resource, err)
+}
+
+// listNames joins the first few names, noting how many were left out.
+func listNames(names []string) string {
+	if len(names) <= maxListedNames {
+		return strings.Join(names, ", ")
+	}
+	return fmt.Sprintf("%s and %d more", strings.Join(names[:maxListedNames], ", "), len(names)-maxListedNames)
+}
+
+func hasReadyAddresses(subsets []corev1.EndpointSubset) bool {
+	for _, subset := range subsets {
+		if len(subset.Addresses) > 0 {
+			return true
+		}
+	}
+	return false
+}
+
+func podReady(pod corev1.Pod) bool {
+	for _, c := range pod.Status.Conditions {
+		if c.Type == corev1.PodReady {
+			return c.Status == corev1.ConditionTrue
+		}
+	}
+	return false
+}
+
+func rolloutComplete(ds appsv1.DaemonSet) bool {
+	return ds.Status.ObservedGeneration >= ds.Generation &&
+		ds.Status.UpdatedNumberScheduled == ds.Status.DesiredNumberScheduled &&
+		ds.Status.NumberReady == ds.Status.DesiredNumberScheduled
+}
+
+// syncedCondition returns the status and message of the Synced condition of a config entry custom resource.
+func syncedCondition(cr unstructured.Unstructured) (string, string) {
+	conditions, _, _ := unstructured.NestedSlice(cr.Object, "status", "conditions")
+	for _, c := range conditions {
+		condition, ok := c.(map[string]interface{})
+		if !ok || condition["type"] != "Synced" {
+			continue
+		}
+		status, _ := condition["status"].(string)
+		message, _ := condition["message"].(string)
+		return status, message
+	}
+	return "", ""
+}
@@ -0,0 +1,412 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package status
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/hashicorp/consul/api"
+	"github.com/stretchr/testify/require"
+	admissionv1 "k8s.io/api/admissionregistration/v1"
+	appsv1 "k8s.io/api/apps/v1"
+	coordinationv1 "k8s.io/api/coordination/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	dynamicFake "k8s.io/client-go/dynamic/fake"
+	"k8s.io/client-go/kubernetes/fake"
+	"k8s.io/utils/ptr"
+
+	"github.com/hashicorp/consul-k8s/cli/common/health"
+	"github.com/hashicorp/consul-k8s/cli/configentries"
+)
+
+const (
+	testNamespace = "consul"
+	testRelease   = "consul"
+)
+
+func TestCheckRaft(t *testing.T) {
+	cases := map[string]struct {
+		servers   bool
+		reply     api.OperatorHealthReply
+		expStatus health.Status
+		expMsg    string
+	}{
+		"no servers": {
+			expStatus: health.StatusSkip,
+			expMsg:    "Consul servers are not running in this Kubernetes cluster",
+		},
+		"healthy": {
+			servers: true,
+			reply: api.OperatorHealthReply{Healthy: true, FailureTolerance: 1, Servers: []api.ServerHealth{
+				{Name: "consul-server-0", Healthy: true, Leader: true},
+				{Name: "consul-server-1", Healthy: true},
+				{Name: "consul-server-2", Healthy: true},
+			}},
+			expStatus: health.StatusPass,
+			expMsg:    "3 servers are healthy, the leader is consul-server-0 and the cluster tolerates the loss of 1 servers",
+		},
+		"no failure tolerance": {
+			servers: true,
+			reply: api.OperatorHealthReply{Healthy: true, Servers: []api.ServerHealth{
+				{Name: "consul-server-0", Healthy: true, Leader: true},
+				{Name: "consul-server-1", Healthy: true},
+			}},
+			expStatus: health.StatusWarn,
+			expMsg:    "2 servers are healthy but the cluster can't tolerate the loss of a server",
+		},
+		"unhealthy server": {
+			servers: true,
+			reply: api.OperatorHealthReply{Servers: []api.ServerHealth{
+				{Name: "consul-server-0", Healthy: true, Leader: true},
+				{Name: "consul-server-1", Healthy: true},
+				{Name: "consul-server-2"},
+			}},
+			expStatus: health.StatusFail,
+			expMsg:    "1/3 servers are unhealthy: consul-server-2",
+		},
+		"no leader": {
+			servers: true,
+			reply: api.OperatorHealthReply{Servers: []api.ServerHealth{
+				{Name: "consul-server-0"},
+			}},
+			expStatus: health.StatusFail,
+			expMsg:    "the raft cluster has no leader",
+		},
+	}
+
+	for name, c := range cases {
+		t.Run(name, func(t *testing.T) {
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				require.Equal(t, "/v1/operator/autopilot/health", r.URL.Path)
+				require.NoError(t, json.NewEncoder(w).Encode(c.reply))
+			}))
+			defer server.Close()
+			client, err := api.NewClient(&api.Config{Address: server.URL})
+			require.NoError(t, err)
+
+			h := newTestHealthChecker()
+			h.consulClient = func(context.Context) (*api.Client, error) { return client, nil }
+			if c.servers {
+				createStatefulSet(t, h, &appsv1.StatefulSet{ObjectMeta: testMeta("consul-server", "server")})
+			}
+
+			result := h.checkRaft(context.Background())
+			require.Equal(t, c.expStatus, result.Status)
+			require.Equal(t, c.expMsg, result.Message)
+		})
+	}
+}
+
+func TestCheckWebhook(t *testing.T) {
+	webhookConfig := func(caBundle []byte) *admissionv1.MutatingWebhookConfiguration {
+		return &admissionv1.MutatingWebhookConfiguration{
+			ObjectMeta: testMeta("consul-connect-injector", "connect-injector"),
+			Webhooks: []admissionv1.MutatingWebhook{{
+				Name: "consul-connect-injector.consul.hashicorp.com",
+				ClientConfig: admissionv1.WebhookClientConfig{
+					CABundle: caBundle,
+					Service:  &admissionv1.ServiceReference{Namespace: testNamespace, Name: "consul-connect-injector"},
+				},
+			}},
+		}
+	}
+	endpoints := func(ready bool) *corev1.Endpoints {
+		ep := &corev1.Endpoints{ObjectMeta: metav1.ObjectMeta{Name: "consul-connect-injector", Namespace: testNamespace}}
+		subset := corev1.EndpointSubset{NotReadyAddresses: []corev1.EndpointAddress{{IP: "10.0.0.1"}}}
+		if ready {
+			subset.Addresses = subset.NotReadyAddresses
+		}
+		ep.Subsets = []corev1.EndpointSubset{subset}
+		return ep
+	}
+
+	cases := map[string]struct {
+		objects   []runtime.Object
+		expStatus health.Status
+		expMsg    string
+	}{
+		"not installed": {
+			expStatus: health.StatusSkip,
+			expMsg:    "the connect injector is not installed",
+		},
+		"no CA bundle": {
+			objects:   []runtime.Object{webhookConfig(nil), endpoints(true)},
+			expStatus: health.StatusFail,
+			expMsg:    "webhook consul-connect-injector.consul.hashicorp.com of consul-connect-injector has no CA bundle",
+		},
+		"no ready endpoints": {
+			objects:   []runtime.Object{webhookConfig([]byte("ca")), endpoints(false)},
+			expStatus: health.StatusFail,
+			expMsg:    "webhook service consul/consul-connect-injector has no ready endpoints",
+		},
+		"no endpoints": {
+			objects:   []runtime.Object{webhookConfig([]byte("ca"))},
+			expStatus: health.StatusFail,
+			expMsg:    "webhook service consul/consul-connect-injector has no ready endpoints",
+		},
+		"healthy": {
+			objects:   []runtime.Object{webhookConfig([]byte("ca")), endpoints(true)},
+			expStatus: health.StatusPass,
+			expMsg:    "dry-run admission request succeeded",
+		},
+	}
+
+	for name, c := range cases {
+		t.Run(name, func(t *testing.T) {
+			h := newTestHealthChecker(c.objects...)
+			result := h.checkWebhook(context.Background())
+			require.Equal(t, c.expStatus, result.Status)
+			require.Equal(t, c.expMsg, result.Message)
+		})
+	}
+}
+
+func TestCheckLeaderElection(t *testing.T) {
+	injector := &appsv1.Deployment{ObjectMeta: testMeta("consul-connect-injector", "connect-injector")}
+	lease := func(holder string, renewed time.Duration) *coordinationv1.Lease {
+		return &coordinationv1.Lease{
+			ObjectMeta: metav1.ObjectMeta{Name: controllerLeaseName, Namespace: testNamespace},
+			Spec: coordinationv1.LeaseSpec{
+				HolderIdentity:       ptr.To(holder),
+				LeaseDurationSeconds: ptr.To(int32(15)),
+				RenewTime:            &metav1.MicroTime{Time: time.Now().Add(-renewed)},
+			},
+		}
+	}
+
+	cases := map[string]struct {
+		objects   []runtime.Object
+		expStatus health.Status
+		expMsg    string
+	}{
+		"not installed": {
+			expStatus: health.StatusSkip,
+			expMsg:    "the connect injector is not installed",
+		},
+		"no lease": {
+			objects:   []runtime.Object{injector},
+			expStatus: health.StatusFail,
+			expMsg:    "no controller has acquired the leader election lease consul-controller-lock",
+		},
+		"no holder": {
+			objects:   []runtime.Object{injector, lease("", 0)},
+			expStatus: health.StatusFail,
+			expMsg:    "the leader election lease consul-controller-lock has no holder",
+		},
+		"expired": {
+			objects:   []runtime.Object{injector, lease("consul-connect-injector-abc", time.Minute)},
+			expStatus: health.StatusFail,
+		},
+		"held": {
+			objects:   []runtime.Object{injector, lease("consul-connect-injector-abc", time.Second)},
+			expStatus: health.StatusPass,
+			expMsg:    "the controllers are led by consul-connect-injector-abc",
+		},
+	}
+
+	for name, c := range cases {
+		t.Run(name, func(t *testing.T) {
+			h := newTestHealthChecker(c.objects...)
+			result := h.checkLeaderElection(context.Background())
+			require.Equal(t, c.expStatus, result.Status)
+			if c.expMsg != "" {
+				require.Equal(t, c.expMsg, result.Message)
+			}
+		})
+	}
+}
+
+func TestCheckConfigEntries(t *testing.T) {
+	cases := map[string]struct {
+		crs       []*unstructured.Unstructured
+		expStatus health.Status
+		expMsg    string
+	}{
+		"none": {
+			expStatus: health.StatusPass,
+			expMsg:    "0 synced, 0 pending, 0 failed",
+		},
+		"synced": {
+			crs: []*unstructured.Unstructured{
+				newConfigEntry("ServiceDefaults", "web", "True", ""),
+				newConfigEntry("ServiceDefaults", "api", "True", ""),
+				newConfigEntry("ServiceIntentions", "web", "", ""),
+			},
+			expStatus: health.StatusPass,
+			expMsg:    "2 synced, 1 pending, 0 failed",
+		},
+		"failed": {
+			crs: []*unstructured.Unstructured{
+				newConfigEntry("ServiceDefaults", "web", "True", ""),
+				newConfigEntry("ServiceResolver", "api", "False", "permission denied"),
+			},
+			expStatus: health.StatusWarn,
+			expMsg:    "1 synced, 0 pending, 1 failed: ServiceResolver default/api (permission denied)",
+		},
+	}
+
+	for name, c := range cases {
+		t.Run(name, func(t *testing.T) {
+			h := newTestHealthChecker()
+			for _, cr := range c.crs {
+				gvr, _ := configentries.GroupVersionResource(cr.GetKind())
+				_, err := h.dynamic.Resource(gvr).Namespace(cr.GetNamespace()).Create(context.Background(), cr, metav1.CreateOptions{})
+				require.NoError(t, err)
+			}
+			result := h.checkConfigEntries(context.Background())
+			require.Equal(t, c.expStatus, result.Status)
+			require.Equal(t, c.expMsg, result.Message)
+		})
+	}
+}
+
+func TestCheckGateways(t *testing.T) {
+	pod := func(name, namespace, component string, ready bool) *corev1.Pod {
+		p := &corev1.Pod{ObjectMeta: testMeta(name, component)}
+		p.Namespace = namespace
+		if component == "api-gateway" {
+			p.Labels = map[string]string{"component": component, "gateway.consul.hashicorp.com/managed": "true"}
+		}
+		status := corev1.ConditionFalse
+		if ready {
+			status = corev1.ConditionTrue
+		}
+		p.Status.Conditions = []corev1.PodCondition{{Type: corev1.PodReady, Status: status}}
+		return p
+	}
+
+	cases := map[string]struct {
+		objects   []runtime.Object
+		expStatus health.Status
+		expMsg    string
+	}{
+		"no gateways": {
+			objects:   []runtime.Object{pod("consul-server-0", testNamespace, "server", true)},
+			expStatus: health.StatusSkip,
+			expMsg:    "no gateways are deployed",
+		},
+		"ready": {
+			objects: []runtime.Object{
+				pod("consul-mesh-gateway-0", testNamespace, "mesh-gateway", true),
+				pod("api-gateway-0", "default", "api-gateway", true),
+			},
+			expStatus: health.StatusPass,
+			expMsg:    "2/2 gateway pods are ready",
+		},
+		"not ready": {
+			objects: []runtime.Object{
+				pod("consul-mesh-gateway-0", testNamespace, "mesh-gateway", true),
+				pod("consul-ingress-gateway-0", testNamespace, "ingress-gateway", false),
+			},
+			expStatus: health.StatusFail,
+			expMsg:    "1/2 gateway pods are ready, not ready: consul/consul-ingress-gateway-0",
+		},
+	}
+
+	for name, c := range cases {
+		t.Run(name, func(t *testing.T) {
+			h := newTestHealthChecker(c.objects...)
+			result := h.checkGateways(context.Background())
+			require.Equal(t, c.expStatus, result.Status)
+			require.Equal(t, c.expMsg, result.Message)
+		})
+	}
+}
+
+func TestCheckCNI(t *testing.T) {
+	daemonSet := func(desired, ready, updated int32) *appsv1.DaemonSet {
+		ds := &appsv1.DaemonSet{ObjectMeta: testMeta("consul-cni", "cni")}
+		ds.Namespace = "kube-system"
+		ds.Status = appsv1.DaemonSetStatus{DesiredNumberScheduled: desired, NumberReady: ready, UpdatedNumberScheduled: updated}
+		return ds
+	}
+
+	cases := map[string]struct {
+		objects   []runtime.Object
+		expStatus health.Status
+		expMsg    string
+	}{
+		"not installed": {
+			expStatus: health.StatusSkip,
+			expMsg:    "the CNI plugin is not installed",
+		},
+		"rolled out": {
+			objects:   []runtime.Object{daemonSet(3, 3, 3)},
+			expStatus: health.StatusPass,
+			expMsg:    "3/3 pods are ready",
+		},
+		"rolling out": {
+			objects:   []runtime.Object{daemonSet(3, 3, 1)},
+			expStatus: health.StatusFail,
+			expMsg:    "rollout of DaemonSet kube-system/consul-cni is incomplete: 3/3 pods are ready and 1 are updated",
+		},
+	}
+
+	for name, c := range cases {
+		t.Run(name, func(t *testing.T) {
+			h := newTestHealthChecker(c.objects...)
+			result := h.checkCNI(context.Background())
+			require.Equal(t, c.expStatus, result.Status)
+			require.Equal(t, c.expMsg, result.Message)
+		})
+	}
+}
+
+func newTestHealthChecker(objects ...runtime.Object) *healthChecker {
+	return &healthChecker{
+		kubernetes:  fake.NewSimpleClientset(objects...),
+		dynamic:     newFakeDynamicClient(),
+		namespace:   testNamespace,
+		releaseName: testRelease,
+	}
+}
+
+func testMeta(name, component string) metav1.ObjectMeta {
+	return metav1.ObjectMeta{
+		Name:      name,
+		Namespace: testNamespace,
+		Labels:    map[string]string{"component": component, "release": testRelease},
+	}
+}
+
+func createStatefulSet(t *testing.T, h *healthChecker, ss *appsv1.StatefulSet) {
+	t.Helper()
+	_, err := h.kubernetes.AppsV1().StatefulSets(ss.Namespace).Create(context.Background(), ss, metav1.CreateOptions{})
+	require.NoError(t, err)
+}
+
+// newFakeDynamicClient returns a fake dynamic client that serves the config entry custom resources.
+func newFakeDynamicClient() *dynamicFake.FakeDynamicClient {
+	gvrToListKind := make(map[schema.GroupVersionResource]string)
+	for _, kind := range configentries.Kinds() {
+		gvr, _ := configentries.GroupVersionResource(kind)
+		gvrToListKind[gvr] = kind + "List"
+	}
+	return dynamicFake.NewSimpleDynamicClientWithCustomListKinds(runtime.NewScheme(), gvrToListKind)
+}
+
+func newConfigEntry(kind, name, synced, message string) *unstructured.Unstructured {
+	cr := &unstructured.Unstructured{Object: map[string]interface{}{}}
+	cr.SetAPIVersion("consul.hashicorp.com/v1alpha1")
+	cr.SetKind(kind)
+	cr.SetName(name)
+	cr.SetNamespace("default")
+	if synced != "" {
+		cr.Object["status"] = map[string]interface{}{
+			"conditions": []interface{}{
+				map[string]interface{}{"type": "Synced", "status": synced, "message": message},
+			},
+		}
+	}
+	return cr
+}
@@ -4,29 +4,34 @@
 package status
 
 import (
+	"context"
 	"errors"
 	"fmt"
 	"strconv"
 	"sync"
+	"time"
 
+	"github.com/hashicorp/consul/api"
 	"github.com/posener/complete"
 	"helm.sh/helm/v3/pkg/release"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 
 	"github.com/hashicorp/consul-k8s/cli/common"
+	"github.com/hashicorp/consul-k8s/cli/common/consul"
 	"github.com/hashicorp/consul-k8s/cli/common/flag"
+	"github.com/hashicorp/consul-k8s/cli/common/health"
 	"github.com/hashicorp/consul-k8s/cli/common/terminal"
 	"github.com/hashicorp/consul-k8s/cli/helm"
 	"helm.sh/helm/v3/pkg/action"
 	helmCLI "helm.sh/helm/v3/pkg/cli"
+	"k8s.io/client-go/dynamic"
 	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/rest"
 	"sigs.k8s.io/yaml"
 )
 
-const (
-	flagNameKubeConfig  = "kubeconfig"
-	flagNameKubeContext = "context"
-)
+// healthCheckTimeout is the time each health check has to complete.
+const healthCheckTimeout = 30 * time.Second
 
 type Command struct {
 	*common.BaseCommand
@@ -34,11 +39,14 @@ type Command struct {
 	helmActionsRunner helm.HelmActionsRunner
 
 	kubernetes kubernetes.Interface
+	dynamic    dynamic.Interface
+	restConfig *rest.Config
 
-	set *flag.Sets
+	// consulClient is created from the installed release when it is not set.
+	consulClient *api.Client
 
-	flagKubeConfig  string
-	flagKubeContext string
+	set         *flag.Sets
+	consulFlags consul.Flags
 
 	once sync.Once
 	help string
@@ -46,21 +54,7 @@ type Command struct {
 
 func (c *Command) init() {
 	c.set = flag.NewSets()
-
-	f := c.set.NewSet("Global Options")
-	f.StringVar(&flag.StringVar{
-		Name:    "kubeconfig",
-		Aliases: []string{"c"},
-		Target:  &c.flagKubeConfig,
-		Default: "",
-		Usage:   "Path to kubeconfig file.",
-	})
-	f.StringVar(&flag.StringVar{
-		Name:    "context",
-		Target:  &c.flagKubeContext,
-		Default: "",
-		Usage:   "Kubernetes context to use.",
-	})
+	c.consulFlags.AddTo(c.set)
 
 	c.help = c.set.Help()
 }
@@ -87,11 +81,11 @@ func (c *Command) Run(args []string) int {
 
 	// helmCLI.New() will create a settings object which is used by the Helm Go SDK calls.
 	settings := helmCLI.New()
-	if c.flagKubeConfig != "" {
-		settings.KubeConfig = c.flagKubeConfig
+	if c.consulFlags.KubeConfig != "" {
+		settings.KubeConfig = c.consulFlags.KubeConfig
 	}
-	if c.flagKubeContext != "" {
-		settings.KubeContext = c.flagKubeContext
+	if c.consulFlags.KubeContext != "" {
+		settings.KubeContext = c.consulFlags.KubeContext
 	}
 
 	if err := c.setupKubeClient(settings); err != nil {
@@ -127,6 +121,40 @@ func (c *Command) Run(args []string) int {
 		return 1
 	}
 
+	c.UI.Output("Health Checks:", terminal.WithHeaderStyle())
+	var conn *consul.Connection
+	defer func() {
+		if conn != nil {
+			conn.Close()
+		}
+	}()
+	checker := &healthChecker{
+		kubernetes:  c.kubernetes,
+		dynamic:     c.dynamic,
+		namespace:   namespace,
+		releaseName: releaseName,
+		consulClient: func(ctx context.Context) (*api.Client, error) {
+			if c.consulClient != nil {
+				return c.consulClient, nil
+			}
+			conn = &consul.Connection{
+				Settings:          settings,
+				HelmActionsRunner: c.helmActionsRunner,
+				KubeClient:        c.kubernetes,
+				RestConfig:        c.restConfig,
+				Log:               uiLogger,
+				Token:             c.consulFlags.Token,
+				CAFile:            c.consulFlags.CAFile,
+			}
+			return conn.Open(ctx)
+		},
+	}
+	reports := health.Run(c.Ctx, healthCheckTimeout, checker.checks())
+	health.Print(c.UI, reports)
+	if !health.Healthy(reports) {
+		return 1
+	}
+
 	return 0
 }
 
@@ -142,10 +170,7 @@ func (c *Command) validateFlags() error {
 // options for this command. The map key for the Flags map should be the
 // complete flag such as "-foo" or "--foo".
 func (c *Command) AutocompleteFlags() complete.Flags {
-	return complete.Flags{
-		fmt.Sprintf("-%s", flagNameKubeConfig):  complete.PredictFiles("*"),
-		fmt.Sprintf("-%s", flagNameKubeContext): complete.PredictNothing,
-	}
+	return c.consulFlags.AutocompleteFlags()
 }
 
 // AutocompleteArgs returns the argument predictor for this command.
@@ -241,13 +266,25 @@ func (c *Command) checkConsulServers(namespace string) error {
 // settings.RESTClientGetter for its calls as well, so this will use a consistent method to
 // target the right cluster for both Helm SDK and non Helm SDK calls.
 func (c *Command) setupKubeClient(settings *helmCLI.EnvSettings) error {
-	if c.kubernetes == nil {
-		restConfig, err := settings.RESTClientGetter().ToRESTConfig()
+	if c.kubernetes == nil || c.dynamic == nil {
+		var err error
+		c.restConfig, err = settings.RESTClientGetter().ToRESTConfig()
 		if err != nil {
 			c.UI.Output("Error retrieving Kubernetes authentication: %v", err, terminal.WithErrorStyle())
 			return err
 		}
-		c.kubernetes, err = kubernetes.NewForConfig(restConfig)
+	}
+	if c.kubernetes == nil {
+		var err error
+		c.kubernetes, err = kubernetes.NewForConfig(c.restConfig)
+		if err != nil {
+			c.UI.Output("Error initializing Kubernetes client: %v", err, terminal.WithErrorStyle())
+			return err
+		}
+	}
+	if c.dynamic == nil {
+		var err error
+		c.dynamic, err = dynamic.NewForConfig(c.restConfig)
 		if err != nil {
 			c.UI.Output("Error initializing Kubernetes client: %v", err, terminal.WithErrorStyle())
 			return err
@@ -195,6 +195,7 @@ func TestStatus(t *testing.T) {
 			buf := new(bytes.Buffer)
 			c := getInitializedCommand(t, buf)
 			c.kubernetes = fake.NewSimpleClientset()
+			c.dynamic = newFakeDynamicClient()
 			c.helmActionsRunner = tc.helmActionsRunner
 			if tc.preProcessingFunc != nil {
 				err := tc.preProcessingFunc(c.kubernetes)
@@ -255,6 +256,7 @@ func getInitializedCommand(t *testing.T, buf io.Writer) *Command {
 		ui = terminal.NewBasicUI(context.Background())
 	}
 	baseCommand := &common.BaseCommand{
+		Ctx: context.Background(),
 		Log: log,
 		UI:  ui,
 	}
@@ -0,0 +1,100 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package uninstall
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"helm.sh/helm/v3/pkg/kube"
+	"helm.sh/helm/v3/pkg/releaseutil"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"sigs.k8s.io/yaml"
+)
+
+// serviceIntentionsCRD is the name of the CRD of the ServiceIntentions custom resources.
+const serviceIntentionsCRD = "serviceintentions.consul.hashicorp.com"
+
+// retention classifies the resources of a Consul installation into the ones that are deleted
+// by the uninstall and the ones that are preserved so that Consul can be reinstalled without
+// losing its data.
+type retention struct {
+	// pvcs preserves the PVCs of the installation, i.e. the data of the Consul servers.
+	pvcs bool
+	// secrets preserves the Secrets managed by consul-k8s, e.g. the ACL bootstrap token.
+	secrets bool
+	// crds preserves the Consul CRDs and their custom resources.
+	crds bool
+	// intentions preserves the ServiceIntentions CRD and custom resources.
+	intentions bool
+}
+
+// keepCRD returns whether the CRD with the given name and its custom resources are preserved.
+func (r retention) keepCRD(name string) bool {
+	return r.crds || (r.intentions && name == serviceIntentionsCRD)
+}
+
+// keepsCRDs returns whether any CRD is preserved.
+func (r retention) keepsCRDs() bool {
+	return r.crds || r.intentions
+}
+
+// deletedResources returns the kinds of the resources, other than the Helm release and the custom
+// resources, that are deleted, for the uninstall prompt.
+func (r retention) deletedResources() string {
+	var kinds []string
+	if !r.pvcs {
+		kinds = append(kinds, "PVCs")
+	}
+	if !r.secrets {
+		kinds = append(kinds, "Secrets")
+	}
+	kinds = append(kinds, "Service Accounts", "Roles", "Role Bindings", "Jobs", "Cluster Roles")
+	return strings.Join(kinds, ", ") + ", and Cluster Role Bindings"
+}
+
+// keepInManifest annotates the CRDs of a Helm release manifest that are preserved with
+// the "keep" resource policy so that Helm doesn't delete them when the release is uninstalled.
+func (r retention) keepInManifest(manifest string) (string, error) {
+	manifests := releaseutil.SplitManifests(manifest)
+	keys := make([]string, 0, len(manifests))
+	for key := range manifests {
+		keys = append(keys, key)
+	}
+	sort.Sort(releaseutil.BySplitManifestsOrder(keys))
+
+	var b strings.Builder
+	for _, key := range keys {
+		content := manifests[key]
+
+		var obj unstructured.Unstructured
+		if err := yaml.Unmarshal([]byte(content), &obj.Object); err != nil {
+			return "", fmt.Errorf("error parsing release manifest: %w", err)
+		}
+		if obj.GetKind() == "CustomResourceDefinition" && r.keepCRD(obj.GetName()) {
+			annotations := obj.GetAnnotations()
+			if annotations == nil {
+				annotations = make(map[string]string)
+			}
+			annotations[kube.ResourcePolicyAnno] = kube.KeepPolicy
+			obj.SetAnnotations(annotations)
+
+			out, err := yaml.Marshal(obj.Object)
+			if err != nil {
+				return "", fmt.Errorf("error encoding release manifest: %w", err)
+			}
+			// Keep the "# Source:" comment that Helm writes before each template.
+			var comments []string
+			for _, line := range strings.Split(content, "\n") {
+				if strings.HasPrefix(line, "#") {
+					comments = append(comments, line)
+				}
+			}
+			content = strings.Join(append(comments, string(out)), "\n")
+		}
+		fmt.Fprintf(&b, "---\n%s\n", strings.TrimSpace(content))
+	}
+	return b.String(), nil
+}
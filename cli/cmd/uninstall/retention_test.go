@@ -0,0 +1,82 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package uninstall
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"helm.sh/helm/v3/pkg/releaseutil"
+)
+
+const testReleaseManifest = `---
+# Source: consul/templates/crd-servicedefaults.yaml
+apiVersion: apiextensions.k8s.io/v1
+kind: CustomResourceDefinition
+metadata:
+  name: servicedefaults.consul.hashicorp.com
+  labels:
+    app: consul
+---
+# Source: consul/templates/crd-serviceintentions.yaml
+apiVersion: apiextensions.k8s.io/v1
+kind: CustomResourceDefinition
+metadata:
+  name: serviceintentions.consul.hashicorp.com
+  labels:
+    app: consul
+---
+# Source: consul/templates/server-statefulset.yaml
+apiVersion: apps/v1
+kind: StatefulSet
+metadata:
+  name: consul-server
+`
+
+func TestRetention_keepInManifest(t *testing.T) {
+	cases := map[string]struct {
+		retention retention
+		kept      []string
+	}{
+		"nothing preserved": {
+			retention: retention{pvcs: true, secrets: true},
+		},
+		"intentions preserved": {
+			retention: retention{intentions: true},
+			kept:      []string{"serviceintentions.consul.hashicorp.com"},
+		},
+		"CRDs preserved": {
+			retention: retention{crds: true},
+			kept:      []string{"servicedefaults.consul.hashicorp.com", "serviceintentions.consul.hashicorp.com"},
+		},
+	}
+
+	for name, c := range cases {
+		t.Run(name, func(t *testing.T) {
+			manifest, err := c.retention.keepInManifest(testReleaseManifest)
+			require.NoError(t, err)
+
+			manifests := releaseutil.SplitManifests(manifest)
+			require.Len(t, manifests, 3)
+			_, files, err := releaseutil.SortManifests(manifests, nil, releaseutil.UninstallOrder)
+			require.NoError(t, err)
+
+			var kept []string
+			for _, file := range files {
+				require.Contains(t, file.Content, "# Source: consul/templates/")
+				if file.Head.Metadata.Annotations["helm.sh/resource-policy"] == "keep" {
+					require.Equal(t, "CustomResourceDefinition", file.Head.Kind)
+					kept = append(kept, file.Head.Metadata.Name)
+				}
+			}
+			require.ElementsMatch(t, c.kept, kept)
+		})
+	}
+}
+
+func TestRetention_deletedResources(t *testing.T) {
+	require.Equal(t, "PVCs, Secrets, Service Accounts, Roles, Role Bindings, Jobs, Cluster Roles, and Cluster Role Bindings", retention{}.deletedResources())
+	require.Equal(t, "Secrets, Service Accounts, Roles, Role Bindings, Jobs, Cluster Roles, and Cluster Role Bindings", retention{pvcs: true}.deletedResources())
+	require.Equal(t, "Service Accounts, Roles, Role Bindings, Jobs, Cluster Roles, and Cluster Role Bindings", retention{pvcs: true, secrets: true, crds: true}.deletedResources())
+}
@@ -6,6 +6,7 @@ package uninstall
 import (
 	"fmt"
 	"os"
+	"slices"
 	"sync"
 	"time"
 
@@ -43,6 +44,12 @@ const (
 	flagWipeData    = "wipe-data"
 	defaultWipeData = false
 
+	flagPreserveData       = "preserve-data"
+	flagPreservePVCs       = "preserve-pvcs"
+	flagPreserveSecrets    = "preserve-secrets"
+	flagPreserveCRDs       = "preserve-crds"
+	flagPreserveIntentions = "preserve-intentions"
+
 	flagTimeout    = "timeout"
 	defaultTimeout = 10 * time.Minute
 
@@ -68,6 +75,12 @@ type Command struct {
 	flagWipeData    bool
 	flagTimeout     time.Duration
 
+	flagPreserveData       bool
+	flagPreservePVCs       bool
+	flagPreserveSecrets    bool
+	flagPreserveCRDs       bool
+	flagPreserveIntentions bool
+
 	flagKubeConfig  string
 	flagKubeContext string
 
@@ -90,6 +103,36 @@ func (c *Command) init() {
 		Default: defaultWipeData,
 		Usage:   "When used in combination with -auto-approve, all persisted data (PVCs and Secrets) from previous installations will be deleted. Only set this to true when data from previous installations is no longer necessary.",
 	})
+	f.BoolVar(&flag.BoolVar{
+		Name:    flagPreserveData,
+		Target:  &c.flagPreserveData,
+		Default: false,
+		Usage:   "Preserve the PVCs, Secrets, CRDs and intentions of the installation so that Consul can be reinstalled with its data. This is the same as setting all of the -preserve-* flags.",
+	})
+	f.BoolVar(&flag.BoolVar{
+		Name:    flagPreservePVCs,
+		Target:  &c.flagPreservePVCs,
+		Default: false,
+		Usage:   "Preserve the PVCs of the installation, i.e. the data of the Consul servers.",
+	})
+	f.BoolVar(&flag.BoolVar{
+		Name:    flagPreserveSecrets,
+		Target:  &c.flagPreserveSecrets,
+		Default: false,
+		Usage:   "Preserve the Secrets managed by consul-k8s, e.g. the ACL bootstrap token.",
+	})
+	f.BoolVar(&flag.BoolVar{
+		Name:    flagPreserveCRDs,
+		Target:  &c.flagPreserveCRDs,
+		Default: false,
+		Usage:   "Preserve the Consul CRDs and their custom resources.",
+	})
+	f.BoolVar(&flag.BoolVar{
+		Name:    flagPreserveIntentions,
+		Target:  &c.flagPreserveIntentions,
+		Default: false,
+		Usage:   "Preserve the ServiceIntentions CRD and custom resources.",
+	})
 	f.StringVar(&flag.StringVar{
 		Name:    flagNamespace,
 		Target:  &c.flagNamespace,
@@ -156,6 +199,10 @@ func (c *Command) Run(args []string) int {
 		c.UI.Output("Can't set -wipe-data alone. Omit this flag to interactively uninstall, or use it with -auto-approve to wipe all data during the uninstall.", terminal.WithErrorStyle())
 		return 1
 	}
+	if c.flagWipeData && c.flagPreserveData {
+		c.UI.Output("Can't set -wipe-data with -preserve-data. Use the -preserve-pvcs, -preserve-secrets, -preserve-crds or -preserve-intentions flags to preserve some of the data.", terminal.WithErrorStyle())
+		return 1
+	}
 
 	// helmCLI.New() will create a settings object which is used by the Helm Go SDK calls.
 	settings := helmCLI.New()
@@ -256,7 +303,7 @@ func (c *Command) Run(args []string) int {
 	// Jobs, Cluster Roles, and Cluster Role Bindings.
 	if !c.flagAutoApprove {
 		confirmation, err := c.UI.Input(&terminal.Input{
-			Prompt: fmt.Sprintf("WARNING: Proceed with deleting PVCs, Secrets, Service Accounts, Roles, Role Bindings, Jobs, Cluster Roles, and Cluster Role Bindings for the following installation? \n\n   Name: %s \n   Namespace: %s \n\n   Only approve if all data from this installation can be deleted. (y/N)", foundReleaseName, foundReleaseNamespace),
+			Prompt: fmt.Sprintf("WARNING: Proceed with deleting %s for the following installation? \n\n   Name: %s \n   Namespace: %s \n\n   Only approve if all data from this installation can be deleted. (y/N)", c.retention().deletedResources(), foundReleaseName, foundReleaseNamespace),
 			Style:  terminal.WarningStyle,
 			Secret: false,
 		})
@@ -270,12 +317,16 @@ func (c *Command) Run(args []string) int {
 		}
 	}
 
-	if err := c.deletePVCs(foundReleaseName, foundReleaseNamespace); err != nil {
+	if c.retention().pvcs {
+		c.UI.Output("Skipping deleting PVCs.", terminal.WithSuccessStyle())
+	} else if err := c.deletePVCs(foundReleaseName, foundReleaseNamespace); err != nil {
 		c.UI.Output(err.Error(), terminal.WithErrorStyle())
 		return 1
 	}
 
-	if err := c.deleteSecrets(foundReleaseNamespace); err != nil {
+	if c.retention().secrets {
+		c.UI.Output("Skipping deleting Consul secrets.", terminal.WithSuccessStyle())
+	} else if err := c.deleteSecrets(foundReleaseNamespace); err != nil {
 		c.UI.Output(err.Error(), terminal.WithErrorStyle())
 		return 1
 	}
@@ -380,6 +431,14 @@ func (c *Command) uninstallHelmRelease(releaseName, namespace, releaseType strin
 		return err
 	}
 
+	// Make Helm keep the CRDs that are preserved. Their custom resources are preserved by
+	// removeCustomResources.
+	if releaseType == common.ReleaseTypeConsul && c.retention().keepsCRDs() {
+		if err := c.keepCRDsInRelease(actionConfig, releaseName); err != nil {
+			return err
+		}
+	}
+
 	uninstall := action.NewUninstall(actionConfig)
 	uninstall.Timeout = c.flagTimeout
 
@@ -407,6 +466,13 @@ func (c *Command) removeCustomResources(uiLogger action.DebugLog) error {
 	if err != nil {
 		return fmt.Errorf("unable to fetch Custom Resource Definitions for Consul deployment: %v", err)
 	}
+	crds.Items = slices.DeleteFunc(crds.Items, func(crd apiextv1.CustomResourceDefinition) bool {
+		if c.retention().keepCRD(crd.Name) {
+			uiLogger(fmt.Sprintf("Preserving custom resources of %s", crd.Name))
+			return true
+		}
+		return false
+	})
 	kindToResource := mapCRKindToResourceName(crds)
 
 	crs, err := c.fetchCustomResources(crds)
@@ -461,6 +527,34 @@ func (c *Command) removeCustomResources(uiLogger action.DebugLog) error {
 	return nil
 }
 
+// keepCRDsInRelease annotates the CRDs that are preserved with the "keep" resource policy in the
+// manifest of the Helm release so that Helm doesn't delete them when it uninstalls the release.
+func (c *Command) keepCRDsInRelease(actionConfig *action.Configuration, releaseName string) error {
+	rel, err := actionConfig.Releases.Last(releaseName)
+	if err != nil {
+		return fmt.Errorf("unable to get Helm release %q: %v", releaseName, err)
+	}
+	manifest, err := c.retention().keepInManifest(rel.Manifest)
+	if err != nil {
+		return err
+	}
+	rel.Manifest = manifest
+	if err := actionConfig.Releases.Update(rel); err != nil {
+		return fmt.Errorf("unable to update Helm release %q: %v", releaseName, err)
+	}
+	return nil
+}
+
+// retention returns the classifier of the resources that are preserved by the uninstall.
+func (c *Command) retention() retention {
+	return retention{
+		pvcs:       c.flagPreserveData || c.flagPreservePVCs,
+		secrets:    c.flagPreserveData || c.flagPreserveSecrets,
+		crds:       c.flagPreserveData || c.flagPreserveCRDs,
+		intentions: c.flagPreserveData || c.flagPreserveIntentions,
+	}
+}
+
 // fetchCustomResourceDefinitions fetches all Custom Resource Definitions managed by Consul.
 func (c *Command) fetchCustomResourceDefinitions() (*apiextv1.CustomResourceDefinitionList, error) {
 	return c.apiextK8sClient.ApiextensionsV1().CustomResourceDefinitions().List(c.Ctx, metav1.ListOptions{
@@ -570,13 +664,18 @@ func (c *Command) Synopsis() string {
 // complete flag such as "-foo" or "--foo".
 func (c *Command) AutocompleteFlags() complete.Flags {
 	return complete.Flags{
-		fmt.Sprintf("-%s", flagAutoApprove): complete.PredictNothing,
-		fmt.Sprintf("-%s", flagNamespace):   complete.PredictNothing,
-		fmt.Sprintf("-%s", flagReleaseName): complete.PredictNothing,
-		fmt.Sprintf("-%s", flagWipeData):    complete.PredictNothing,
-		fmt.Sprintf("-%s", flagTimeout):     complete.PredictNothing,
-		fmt.Sprintf("-%s", flagContext):     complete.PredictNothing,
-		fmt.Sprintf("-%s", flagKubeconfig):  complete.PredictFiles("*"),
+		fmt.Sprintf("-%s", flagAutoApprove):        complete.PredictNothing,
+		fmt.Sprintf("-%s", flagNamespace):          complete.PredictNothing,
+		fmt.Sprintf("-%s", flagReleaseName):        complete.PredictNothing,
+		fmt.Sprintf("-%s", flagWipeData):           complete.PredictNothing,
+		fmt.Sprintf("-%s", flagPreserveData):       complete.PredictNothing,
+		fmt.Sprintf("-%s", flagPreservePVCs):       complete.PredictNothing,
+		fmt.Sprintf("-%s", flagPreserveSecrets):    complete.PredictNothing,
+		fmt.Sprintf("-%s", flagPreserveCRDs):       complete.PredictNothing,
+		fmt.Sprintf("-%s", flagPreserveIntentions): complete.PredictNothing,
+		fmt.Sprintf("-%s", flagTimeout):            complete.PredictNothing,
+		fmt.Sprintf("-%s", flagContext):            complete.PredictNothing,
+		fmt.Sprintf("-%s", flagKubeconfig):         complete.PredictFiles("*"),
 	}
 }
 
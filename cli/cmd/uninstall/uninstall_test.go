@@ -524,6 +524,34 @@ func TestDeleteCustomResources(t *testing.T) {
 	require.Len(t, actual, 0)
 }
 
+func TestRemoveCustomResources_preserveCRDs(t *testing.T) {
+	cr := unstructured.Unstructured{
+		Object: map[string]interface{}{
+			"apiVersion": "consul.hashicorp.com/v1alpha1",
+			"kind":       "ServiceDefaults",
+			"metadata": map[string]interface{}{
+				"name":      "server",
+				"namespace": "default",
+			},
+		},
+	}
+
+	c := getInitializedCommand(t, nil)
+	c.apiextK8sClient, c.dynamicK8sClient = createClientsWithCrds()
+	c.flagPreserveCRDs = true
+
+	_, err := c.dynamicK8sClient.Resource(serviceDefaultsGRV).Namespace("default").Create(context.Background(), &cr, metav1.CreateOptions{})
+	require.NoError(t, err)
+
+	require.NoError(t, c.removeCustomResources(fakeUILogger))
+
+	crds, err := c.fetchCustomResourceDefinitions()
+	require.NoError(t, err)
+	actual, err := c.fetchCustomResources(crds)
+	require.NoError(t, err)
+	require.Len(t, actual, 1)
+}
+
 func TestPatchCustomResources(t *testing.T) {
 	cr := unstructured.Unstructured{
 		Object: map[string]interface{}{
@@ -688,6 +716,38 @@ func TestUninstall(t *testing.T) {
 			expectConsulUninstalled:                 true,
 			expectConsulDemoUninstalled:             false,
 		},
+		"uninstall with -wipe-data and -preserve-pvcs and -preserve-secrets flags skips PVCs and secrets": {
+			input: []string{
+				"-wipe-data", "-preserve-pvcs", "-preserve-secrets",
+			},
+			messages: []string{
+				"\n==> Other Consul Resources\n    Deleting data for installation: \n    Name: consul\n    Namespace consul\n ✓ Skipping deleting PVCs.\n ✓ Skipping deleting Consul secrets.\n ✓ No Consul service accounts found.\n",
+			},
+			helmActionsRunner: &helm.MockActionRunner{
+				CheckForInstallationsFunc: func(options *helm.CheckForInstallationsOptions) (bool, string, string, error) {
+					if options.ReleaseName == "consul" {
+						return true, "consul", "consul", nil
+					} else {
+						return false, "", "", nil
+					}
+				},
+			},
+			expectedReturnCode:                      0,
+			expectCheckedForConsulInstallations:     true,
+			expectCheckedForConsulDemoInstallations: true,
+			expectConsulUninstalled:                 true,
+			expectConsulDemoUninstalled:             false,
+		},
+		"uninstall with -wipe-data and -preserve-data flags returns error": {
+			input: []string{
+				"-wipe-data", "-preserve-data",
+			},
+			messages: []string{
+				"Can't set -wipe-data with -preserve-data.",
+			},
+			helmActionsRunner:  &helm.MockActionRunner{},
+			expectedReturnCode: 1,
+		},
 		"uninstall when both consul and consul demo installations exist returns success": {
 			input: []string{},
 			messages: []string{
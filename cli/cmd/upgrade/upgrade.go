@@ -13,16 +13,20 @@ import (
 	"time"
 
 	consulChart "github.com/hashicorp/consul-k8s/charts"
+	"github.com/hashicorp/consul-k8s/charts/valuesmigrate"
 	"github.com/hashicorp/consul-k8s/cli/common"
 	"github.com/hashicorp/consul-k8s/cli/common/flag"
 	"github.com/hashicorp/consul-k8s/cli/common/terminal"
+	"github.com/hashicorp/consul-k8s/cli/compatibility"
 	"github.com/hashicorp/consul-k8s/cli/config"
 	"github.com/hashicorp/consul-k8s/cli/helm"
 	"github.com/hashicorp/consul-k8s/cli/preset"
+	"github.com/hashicorp/consul-k8s/cli/release"
 	"github.com/posener/complete"
 	helmCLI "helm.sh/helm/v3/pkg/cli"
 	"helm.sh/helm/v3/pkg/cli/values"
 	"helm.sh/helm/v3/pkg/getter"
+	helmRelease "helm.sh/helm/v3/pkg/release"
 	"k8s.io/client-go/kubernetes"
 	"k8s.io/utils/strings/slices"
 )
@@ -119,7 +123,8 @@ func (c *Command) init() {
 		Name:    flagNamePreset,
 		Target:  &c.flagPreset,
 		Default: defaultPreset,
-		Usage:   fmt.Sprintf("Use an upgrade preset, one of %s. Defaults to none", strings.Join(preset.Presets, ", ")),
+		Usage: fmt.Sprintf("Use an upgrade preset, one of %s. Values files and set values take precedence over the preset. "+
+			"Run 'consul-k8s config preset show <name>' to print the values of a preset. Defaults to none", strings.Join(preset.Presets, ", ")),
 	})
 	f.StringSliceVar(&flag.StringSliceVar{
 		Name:   flagNameSetValues,
@@ -288,6 +293,8 @@ func (c *Command) Run(args []string) int {
 		c.UI.Output(err.Error(), terminal.WithErrorStyle())
 		return 1
 	}
+	c.warnMigratedValues(chartValues)
+	c.checkVersionCompatibility(chartValues)
 
 	// Without informing the user, default global.name to consul if it hasn't been set already. We don't allow setting
 	// the release name, and since that is hardcoded to "consul", setting global.name to "consul" makes it so resources
@@ -317,11 +324,14 @@ func (c *Command) Run(args []string) int {
 		HelmActionsRunner: c.helmActionsRunner,
 	}
 
-	err = helm.UpgradeHelmRelease(options)
+	rel, err := helm.UpgradeHelmRelease(options)
 	if err != nil {
 		c.UI.Output(err.Error(), terminal.WithErrorStyle())
 		return 1
 	}
+	if rel != nil {
+		c.saveReceipt(rel, settings)
+	}
 
 	timeout, err = time.ParseDuration(c.flagTimeout)
 	if err != nil {
@@ -348,7 +358,7 @@ func (c *Command) Run(args []string) int {
 			HelmActionsRunner: c.helmActionsRunner,
 		}
 
-		err = helm.UpgradeHelmRelease(options)
+		_, err = helm.UpgradeHelmRelease(options)
 		if err != nil {
 			c.UI.Output(err.Error(), terminal.WithErrorStyle())
 			return 1
@@ -423,9 +433,6 @@ func (c *Command) validateFlags(args []string) error {
 	if len(c.set.Args()) > 0 {
 		return errors.New("should have no non-flag arguments")
 	}
-	if len(c.flagValueFiles) != 0 && c.flagPreset != defaultPreset {
-		return fmt.Errorf("cannot set both -%s and -%s", flagNameConfigFile, flagNamePreset)
-	}
 	if ok := slices.Contains(preset.Presets, c.flagPreset); c.flagPreset != defaultPreset && !ok {
 		return fmt.Errorf("'%s' is not a valid preset (valid presets: %s)", c.flagPreset, strings.Join(preset.Presets, ", "))
 	}
@@ -493,6 +500,50 @@ func (c *Command) mergeValuesFlagsWithPrecedence(settings *helmCLI.EnvSettings,
 	return vals, err
 }
 
+// warnMigratedValues warns about values that have been moved, removed or deprecated in the chart
+// so that they can be migrated with `consul-k8s values migrate` before they break the upgrade.
+func (c *Command) warnMigratedValues(vals map[string]interface{}) {
+	migrations, err := valuesmigrate.Load()
+	if err != nil {
+		c.UI.Output("Unable to check values for migrations: %v", err, terminal.WithWarningStyle())
+		return
+	}
+	detected := valuesmigrate.Detect(vals, migrations)
+	if len(detected) == 0 {
+		return
+	}
+	c.UI.Output("Values to migrate", terminal.WithHeaderStyle())
+	for _, m := range detected {
+		c.UI.Output(m.String(), terminal.WithWarningStyle())
+	}
+	c.UI.Output("Use the command `consul-k8s values migrate` to migrate your values file.", terminal.WithInfoStyle())
+}
+
+// checkVersionCompatibility warns about versions of the chart to upgrade to, its images, the CLI and
+// Kubernetes that aren't supported together.
+func (c *Command) checkVersionCompatibility(vals map[string]interface{}) {
+	chrt, err := helm.LoadChart(consulChart.ConsulHelmChart, common.TopLevelChartDirName)
+	if err != nil {
+		c.UI.Output("Unable to check version compatibility: %v", err, terminal.WithWarningStyle())
+		return
+	}
+	compatibility.Preflight(c.UI, c.kubernetes, chrt, vals)
+}
+
+// saveReceipt records a receipt of the upgrade in the cluster. Failing to record
+// the receipt does not fail the upgrade since Consul has already been upgraded.
+func (c *Command) saveReceipt(rel *helmRelease.Release, settings *helmCLI.EnvSettings) {
+	receipt, err := release.NewReceipt(release.ReceiptActionUpgrade, rel, release.OperatorIdentity(settings), time.Now())
+	if err == nil {
+		err = release.SaveReceipt(c.Ctx, c.kubernetes, receipt)
+	}
+	if err != nil {
+		c.UI.Output("Unable to record upgrade receipt: %v", err, terminal.WithWarningStyle())
+		return
+	}
+	c.UI.Output("Recorded upgrade receipt. Use the command `consul-k8s history` to list receipts.", terminal.WithSuccessStyle())
+}
+
 // Help returns a description of the command and how it is used.
 func (c *Command) Help() string {
 	c.once.Do(c.init)
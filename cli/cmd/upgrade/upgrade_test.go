@@ -40,10 +40,6 @@ func TestValidateFlags(t *testing.T) {
 			"Should disallow non-flag arguments.",
 			[]string{"foo", "-auto-approve"},
 		},
-		{
-			"Should disallow specifying both values file AND presets.",
-			[]string{"-f='f.txt'", "-preset=demo"},
-		},
 		{
 			"Should error on invalid presets.",
 			[]string{"-preset=foo"},
@@ -141,6 +137,14 @@ func TestGetPreset(t *testing.T) {
 			"'secure' should return a SecurePreset'.",
 			preset.PresetSecure,
 		},
+		{
+			"'demo' should return a DemoPreset'.",
+			preset.PresetDemo,
+		},
+		{
+			"'multi-dc-primary' should return a MultiDCPrimaryPreset'.",
+			preset.PresetMultiDCPrimary,
+		},
 	}
 
 	for _, tc := range testCases {
@@ -155,6 +159,12 @@ func TestGetPreset(t *testing.T) {
 				require.Equal(t, preset.PresetQuickstart, tc.presetName)
 			case *preset.SecurePreset:
 				require.Equal(t, preset.PresetSecure, tc.presetName)
+			case *preset.DemoPreset:
+				require.Equal(t, preset.PresetDemo, tc.presetName)
+			case *preset.MultiDCPrimaryPreset:
+				require.Equal(t, preset.PresetMultiDCPrimary, tc.presetName)
+			default:
+				t.Fatalf("unexpected preset %T", p)
 			}
 		})
 	}
@@ -251,6 +261,24 @@ func TestValidateCloudPresets(t *testing.T) {
 	}
 }
 
+func TestWarnMigratedValues(t *testing.T) {
+	buf := new(bytes.Buffer)
+	c := getInitializedCommand(t, buf)
+	c.warnMigratedValues(map[string]interface{}{
+		"global": map[string]interface{}{"name": "consul"},
+	})
+	require.Empty(t, buf.String())
+
+	c.warnMigratedValues(map[string]interface{}{
+		"server": map[string]interface{}{
+			"enterpriseLicense": map[string]interface{}{"secretName": "license"},
+		},
+	})
+	output := buf.String()
+	require.Contains(t, output, "server.enterpriseLicense has been moved to global.enterpriseLicense")
+	require.Contains(t, output, "consul-k8s values migrate")
+}
+
 func TestUpgrade(t *testing.T) {
 	var k8s kubernetes.Interface
 	cases := map[string]struct {
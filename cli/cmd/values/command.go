@@ -0,0 +1,29 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package values
+
+import (
+	"fmt"
+
+	"github.com/hashicorp/consul-k8s/cli/common"
+	"github.com/mitchellh/cli"
+)
+
+// ValuesCommand provides a synopsis for the values subcommands (e.g. migrate).
+type ValuesCommand struct {
+	*common.BaseCommand
+}
+
+// Run prints out information about the subcommands.
+func (c *ValuesCommand) Run([]string) int {
+	return cli.RunResultHelp
+}
+
+func (c *ValuesCommand) Help() string {
+	return fmt.Sprintf("%s\n\nUsage: consul-k8s values <subcommand>", c.Synopsis())
+}
+
+func (c *ValuesCommand) Synopsis() string {
+	return "Operate on Helm values files"
+}
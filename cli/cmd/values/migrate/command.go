@@ -0,0 +1,181 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package migrate
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"sync"
+
+	"github.com/posener/complete"
+
+	"github.com/hashicorp/consul-k8s/charts/valuesmigrate"
+	"github.com/hashicorp/consul-k8s/cli/common"
+	"github.com/hashicorp/consul-k8s/cli/common/flag"
+	"github.com/hashicorp/consul-k8s/cli/common/terminal"
+)
+
+const (
+	flagNameFile        = "file"
+	flagNameOutput      = "output"
+	flagNameFromVersion = "from-version"
+	flagNameToVersion   = "to-version"
+)
+
+// MigrateCommand migrates a Helm values file written for an older version of the Consul chart
+// by moving or removing the values that have changed since.
+type MigrateCommand struct {
+	*common.BaseCommand
+
+	set *flag.Sets
+
+	flagFile        string
+	flagOutput      string
+	flagFromVersion string
+	flagToVersion   string
+
+	once sync.Once
+	help string
+}
+
+func (c *MigrateCommand) init() {
+	c.set = flag.NewSets()
+
+	f := c.set.NewSet("Command Options")
+	f.StringVar(&flag.StringVar{
+		Name:    flagNameFile,
+		Aliases: []string{"f"},
+		Target:  &c.flagFile,
+		Default: "",
+		Usage:   "The values file to migrate.",
+	})
+	f.StringVar(&flag.StringVar{
+		Name:    flagNameOutput,
+		Aliases: []string{"o"},
+		Target:  &c.flagOutput,
+		Default: "",
+		Usage:   "The file to write the migrated values to. If not set, the migrated values are printed.",
+	})
+	f.StringVar(&flag.StringVar{
+		Name:    flagNameFromVersion,
+		Target:  &c.flagFromVersion,
+		Default: "",
+		Usage:   "The chart version the values file was written for. Only values changed after this version are migrated.",
+	})
+	f.StringVar(&flag.StringVar{
+		Name:    flagNameToVersion,
+		Target:  &c.flagToVersion,
+		Default: "",
+		Usage:   "The chart version to migrate the values file to. Defaults to the version of this CLI.",
+	})
+
+	c.help = c.set.Help()
+}
+
+// Run migrates the values file.
+func (c *MigrateCommand) Run(args []string) int {
+	c.once.Do(c.init)
+
+	c.Log.ResetNamed("values migrate")
+	defer common.CloseWithError(c.BaseCommand)
+
+	if err := c.set.Parse(args); err != nil {
+		c.UI.Output(err.Error())
+		return 1
+	}
+
+	if err := c.validateFlags(); err != nil {
+		c.UI.Output(err.Error())
+		return 1
+	}
+
+	migrations, err := valuesmigrate.Load()
+	if err == nil {
+		migrations, err = valuesmigrate.Between(migrations, c.flagFromVersion, c.flagToVersion)
+	}
+	if err != nil {
+		c.UI.Output(err.Error(), terminal.WithErrorStyle())
+		return 1
+	}
+
+	data, err := os.ReadFile(c.flagFile)
+	if err != nil {
+		c.UI.Output(fmt.Sprintf("error reading values file: %s", err), terminal.WithErrorStyle())
+		return 1
+	}
+	migrated, changes, err := valuesmigrate.Migrate(data, migrations)
+	if err != nil {
+		c.UI.Output(fmt.Sprintf("error migrating %s: %s", c.flagFile, err), terminal.WithErrorStyle())
+		return 1
+	}
+
+	c.UI.Output("Migrating Helm values", terminal.WithHeaderStyle())
+	if len(changes) == 0 {
+		c.UI.Output("%s has no values to migrate.", c.flagFile, terminal.WithSuccessStyle())
+		return 0
+	}
+	for _, change := range changes {
+		switch change.Action {
+		case valuesmigrate.ActionConflict, valuesmigrate.ActionDeprecated:
+			c.UI.Output(change.String(), terminal.WithWarningStyle())
+		default:
+			c.UI.Output(change.String(), terminal.WithInfoStyle())
+		}
+	}
+
+	if c.flagOutput == "" {
+		c.UI.Output("\nMigrated values:", terminal.WithHeaderStyle())
+		c.UI.Output(string(migrated))
+		return 0
+	}
+	if err := os.WriteFile(c.flagOutput, migrated, 0o644); err != nil {
+		c.UI.Output(fmt.Sprintf("error writing migrated values: %s", err), terminal.WithErrorStyle())
+		return 1
+	}
+	c.UI.Output("Migrated values written to %s.", c.flagOutput, terminal.WithSuccessStyle())
+
+	return 0
+}
+
+// validateFlags checks the command line flags and values for errors.
+func (c *MigrateCommand) validateFlags() error {
+	if len(c.set.Args()) > 0 {
+		return errors.New("should have no non-flag arguments")
+	}
+	if c.flagFile == "" {
+		return fmt.Errorf("-%s must be set", flagNameFile)
+	}
+	return nil
+}
+
+// AutocompleteFlags returns a mapping of supported flags and autocomplete
+// options for this command. The map key for the Flags map should be the
+// complete flag such as "-foo" or "--foo".
+func (c *MigrateCommand) AutocompleteFlags() complete.Flags {
+	return complete.Flags{
+		fmt.Sprintf("-%s", flagNameFile):        complete.PredictFiles("*"),
+		fmt.Sprintf("-%s", flagNameOutput):      complete.PredictFiles("*"),
+		fmt.Sprintf("-%s", flagNameFromVersion): complete.PredictNothing,
+		fmt.Sprintf("-%s", flagNameToVersion):   complete.PredictNothing,
+	}
+}
+
+// AutocompleteArgs returns the argument predictor for this command.
+// Since argument completion is not supported, this will return
+// complete.PredictNothing.
+func (c *MigrateCommand) AutocompleteArgs() complete.Predictor {
+	return complete.PredictNothing
+}
+
+// Help returns a description of the command and how it is used.
+func (c *MigrateCommand) Help() string {
+	c.once.Do(c.init)
+	return c.Synopsis() + "\n\nUsage: consul-k8s values migrate -file <values file> [flags]\n\n" + c.help
+}
+
+// Synopsis returns a one-line command summary.
+func (c *MigrateCommand) Synopsis() string {
+	return "Migrate a Helm values file from an older version of the Consul chart."
+}
@@ -0,0 +1,140 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package migrate
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/hashicorp/go-hclog"
+	"github.com/stretchr/testify/require"
+
+	"github.com/hashicorp/consul-k8s/cli/common"
+	"github.com/hashicorp/consul-k8s/cli/common/terminal"
+)
+
+const oldValues = `global:
+  imageEnvoy: envoyproxy/envoy:v1.22.0
+server:
+  enterpriseLicense:
+    secretName: license
+`
+
+func TestMigrate(t *testing.T) {
+	cases := map[string]struct {
+		input              []string
+		values             string
+		messages           []string
+		expValues          string
+		expectedReturnCode int
+	}{
+		"migrates values": {
+			values: oldValues,
+			messages: []string{
+				"server.enterpriseLicense has been moved to global.enterpriseLicense",
+				"global.imageEnvoy has been removed",
+				"Migrated values written to",
+			},
+			expValues: "global:\n  enterpriseLicense:\n    secretName: license\n",
+		},
+		"only migrates values changed after from-version": {
+			input:     []string{"-from-version", "0.49.0"},
+			values:    oldValues,
+			messages:  []string{"global.imageEnvoy has been removed"},
+			expValues: "server:\n  enterpriseLicense:\n    secretName: license\n",
+		},
+		"nothing to migrate": {
+			values:   "global:\n  name: consul\n",
+			messages: []string{"has no values to migrate."},
+		},
+		"invalid version": {
+			input:              []string{"-to-version", "latest"},
+			values:             oldValues,
+			messages:           []string{`invalid version "latest"`},
+			expectedReturnCode: 1,
+		},
+		"invalid values": {
+			values:             "- global",
+			messages:           []string{"values must be a map"},
+			expectedReturnCode: 1,
+		},
+	}
+
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			dir := t.TempDir()
+			valuesFile := filepath.Join(dir, "values.yaml")
+			outFile := filepath.Join(dir, "migrated.yaml")
+			require.NoError(t, os.WriteFile(valuesFile, []byte(tc.values), 0o644))
+
+			buf := new(bytes.Buffer)
+			c := getInitializedCommand(t, buf)
+			returnCode := c.Run(append(tc.input, "-file", valuesFile, "-output", outFile))
+			require.Equal(t, tc.expectedReturnCode, returnCode)
+			output := buf.String()
+			for _, msg := range tc.messages {
+				require.Contains(t, output, msg)
+			}
+			if tc.expValues != "" {
+				migrated, err := os.ReadFile(outFile)
+				require.NoError(t, err)
+				require.Equal(t, tc.expValues, string(migrated))
+			} else {
+				require.NoFileExists(t, outFile)
+			}
+		})
+	}
+}
+
+func TestMigrate_Flags(t *testing.T) {
+	cases := map[string]struct {
+		input   []string
+		message string
+	}{
+		"missing file": {
+			message: "-file must be set",
+		},
+		"unexpected argument": {
+			input:   []string{"-file", "values.yaml", "foo"},
+			message: "should have no non-flag arguments",
+		},
+	}
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			buf := new(bytes.Buffer)
+			c := getInitializedCommand(t, buf)
+			require.Equal(t, 1, c.Run(tc.input))
+			require.Contains(t, buf.String(), tc.message)
+		})
+	}
+}
+
+func getInitializedCommand(t *testing.T, buf io.Writer) *MigrateCommand {
+	t.Helper()
+	log := hclog.New(&hclog.LoggerOptions{
+		Name:   "cli",
+		Level:  hclog.Info,
+		Output: os.Stdout,
+	})
+	var ui terminal.UI
+	if buf != nil {
+		ui = terminal.NewUI(context.Background(), buf)
+	} else {
+		ui = terminal.NewBasicUI(context.Background())
+	}
+	baseCommand := &common.BaseCommand{
+		Log: log,
+		UI:  ui,
+	}
+
+	c := &MigrateCommand{
+		BaseCommand: baseCommand,
+	}
+	c.init()
+	return c
+}
@@ -0,0 +1,214 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package check
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+
+	"github.com/posener/complete"
+	"helm.sh/helm/v3/pkg/action"
+	"helm.sh/helm/v3/pkg/chart"
+	helmCLI "helm.sh/helm/v3/pkg/cli"
+	"k8s.io/client-go/kubernetes"
+
+	consulChart "github.com/hashicorp/consul-k8s/charts"
+	"github.com/hashicorp/consul-k8s/cli/common"
+	"github.com/hashicorp/consul-k8s/cli/common/flag"
+	"github.com/hashicorp/consul-k8s/cli/common/terminal"
+	"github.com/hashicorp/consul-k8s/cli/compatibility"
+	"github.com/hashicorp/consul-k8s/cli/helm"
+)
+
+const (
+	flagNameKubeConfig  = "kubeconfig"
+	flagNameKubeContext = "context"
+)
+
+// CheckCommand is the command struct for the version check command.
+type CheckCommand struct {
+	*common.BaseCommand
+
+	kubernetes kubernetes.Interface
+
+	helmActionsRunner helm.HelmActionsRunner
+
+	set *flag.Sets
+
+	flagKubeConfig  string
+	flagKubeContext string
+
+	once sync.Once
+	help string
+}
+
+// init sets up flags and help text for the command.
+func (c *CheckCommand) init() {
+	c.set = flag.NewSets()
+
+	f := c.set.NewSet("Global Options")
+	f.StringVar(&flag.StringVar{
+		Name:    flagNameKubeConfig,
+		Aliases: []string{"c"},
+		Target:  &c.flagKubeConfig,
+		Default: "",
+		Usage:   "Set the path to kubeconfig file.",
+	})
+	f.StringVar(&flag.StringVar{
+		Name:    flagNameKubeContext,
+		Target:  &c.flagKubeContext,
+		Default: "",
+		Usage:   "Set the Kubernetes context to use.",
+	})
+
+	c.help = c.set.Help()
+}
+
+// Run checks the versions of the CLI, of the installed release and of Kubernetes against the compatibility matrix.
+func (c *CheckCommand) Run(args []string) int {
+	c.once.Do(c.init)
+	c.Log.ResetNamed("check")
+	defer common.CloseWithError(c.BaseCommand)
+
+	if c.helmActionsRunner == nil {
+		c.helmActionsRunner = &helm.ActionRunner{}
+	}
+
+	if err := c.set.Parse(args); err != nil {
+		c.UI.Output("Error parsing arguments: %v", err.Error(), terminal.WithErrorStyle())
+		return 1
+	}
+	if len(c.set.Args()) > 0 {
+		c.UI.Output("Invalid argument: should have no non-flag arguments", terminal.WithErrorStyle())
+		return 1
+	}
+
+	// helmCLI.New() will create a settings object which is used by the Helm Go SDK calls.
+	settings := helmCLI.New()
+	if c.flagKubeConfig != "" {
+		settings.KubeConfig = c.flagKubeConfig
+	}
+	if c.flagKubeContext != "" {
+		settings.KubeContext = c.flagKubeContext
+	}
+
+	if c.kubernetes == nil {
+		restConfig, err := settings.RESTClientGetter().ToRESTConfig()
+		if err != nil {
+			c.UI.Output("Error retrieving Kubernetes authentication:\n%v", err, terminal.WithErrorStyle())
+			return 1
+		}
+		c.kubernetes, err = kubernetes.NewForConfig(restConfig)
+		if err != nil {
+			c.UI.Output("Error initializing Kubernetes client:\n%v", err, terminal.WithErrorStyle())
+			return 1
+		}
+	}
+
+	matrix, err := compatibility.EmbeddedMatrix()
+	if err != nil {
+		c.UI.Output(err.Error(), terminal.WithErrorStyle())
+		return 1
+	}
+
+	var uiLogger = func(s string, args ...interface{}) {
+		c.UI.Output(fmt.Sprintf(s, args...), terminal.WithLibraryStyle())
+	}
+	chrt, vals, err := c.releaseChart(settings, uiLogger)
+	if err != nil {
+		c.UI.Output(err.Error(), terminal.WithErrorStyle())
+		return 1
+	}
+	versions, err := compatibility.ReleaseVersions(chrt, vals)
+	if err != nil {
+		c.UI.Output("Error reading the values of the Helm chart: %v", err, terminal.WithErrorStyle())
+		return 1
+	}
+	versions.Kubernetes, err = compatibility.KubernetesVersion(c.kubernetes)
+	if err != nil {
+		c.UI.Output("Unable to retrieve the Kubernetes version: %v", err, terminal.WithWarningStyle())
+	}
+
+	c.UI.Output("Versions", terminal.WithHeaderStyle())
+	tbl := terminal.NewTable("Component", "Version")
+	for _, row := range [][]string{
+		{"consul-k8s CLI", versions.CLI},
+		{"Helm chart", versions.Chart},
+		{"Consul server image", versions.ConsulImage},
+		{"Consul Dataplane image", versions.DataplaneImage},
+		{"Kubernetes", versions.Kubernetes},
+	} {
+		tbl.AddRow(row, []string{})
+	}
+	c.UI.Table(tbl)
+
+	c.UI.Output("Compatibility", terminal.WithHeaderStyle())
+	compatibility.Print(c.UI, matrix.Check(versions))
+	return 0
+}
+
+// releaseChart returns the chart and the values of the installed release. If Consul isn't installed, it returns
+// the chart embedded in the CLI, which is what `consul-k8s install` would install.
+func (c *CheckCommand) releaseChart(settings *helmCLI.EnvSettings, uiLogger action.DebugLog) (*chart.Chart, map[string]interface{}, error) {
+	found, name, namespace, err := c.helmActionsRunner.CheckForInstallations(&helm.CheckForInstallationsOptions{
+		Settings:              settings,
+		ReleaseName:           common.DefaultReleaseName,
+		SkipErrorWhenNotFound: true,
+		DebugLog:              uiLogger,
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+	if !found {
+		c.UI.Output("No existing Consul installation found. Checking the versions `consul-k8s install` would install.", terminal.WithInfoStyle())
+		chrt, err := c.helmActionsRunner.LoadChart(consulChart.ConsulHelmChart, common.TopLevelChartDirName)
+		if err != nil {
+			return nil, nil, fmt.Errorf("error loading the embedded Helm chart: %w", err)
+		}
+		return chrt, nil, nil
+	}
+
+	statusConfig, err := helm.InitActionConfig(new(action.Configuration), namespace, settings, uiLogger)
+	if err != nil {
+		return nil, nil, err
+	}
+	rel, err := c.helmActionsRunner.GetStatus(action.NewStatus(statusConfig), name)
+	if err != nil {
+		return nil, nil, fmt.Errorf("couldn't get the installed release: %w", err)
+	}
+	if rel.Chart == nil {
+		return nil, nil, errors.New("the installed release has no chart")
+	}
+	c.UI.Output("Checking the versions of the Consul installation in namespace %s with name %s.", namespace, name, terminal.WithInfoStyle())
+	return rel.Chart, rel.Config, nil
+}
+
+// AutocompleteFlags returns a mapping of supported flags and autocomplete
+// options for this command. The map key for the Flags map should be the
+// complete flag such as "-foo" or "--foo".
+func (c *CheckCommand) AutocompleteFlags() complete.Flags {
+	return complete.Flags{
+		fmt.Sprintf("-%s", flagNameKubeConfig):  complete.PredictFiles("*"),
+		fmt.Sprintf("-%s", flagNameKubeContext): complete.PredictNothing,
+	}
+}
+
+// AutocompleteArgs returns the argument predictor for this command.
+// Since argument completion is not supported, this will return
+// complete.PredictNothing.
+func (c *CheckCommand) AutocompleteArgs() complete.Predictor {
+	return complete.PredictNothing
+}
+
+// Help returns a description of the command and how it is used.
+func (c *CheckCommand) Help() string {
+	c.once.Do(c.init)
+	return fmt.Sprintf("%s\n\nUsage: consul-k8s version check [flags]\n\n%s", c.Synopsis(), c.help)
+}
+
+// Synopsis returns a one-line command summary.
+func (c *CheckCommand) Synopsis() string {
+	return "Check the versions of the CLI, the Helm chart, the images and Kubernetes against the compatibility matrix."
+}
@@ -0,0 +1,129 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package check
+
+import (
+	"bytes"
+	"context"
+	"embed"
+	"io"
+	"os"
+	"testing"
+
+	"github.com/hashicorp/go-hclog"
+	"github.com/stretchr/testify/require"
+	"helm.sh/helm/v3/pkg/action"
+	"helm.sh/helm/v3/pkg/chart"
+	helmRelease "helm.sh/helm/v3/pkg/release"
+	"k8s.io/apimachinery/pkg/version"
+	fakediscovery "k8s.io/client-go/discovery/fake"
+	"k8s.io/client-go/kubernetes/fake"
+
+	"github.com/hashicorp/consul-k8s/cli/common"
+	"github.com/hashicorp/consul-k8s/cli/common/terminal"
+	"github.com/hashicorp/consul-k8s/cli/helm"
+	cliversion "github.com/hashicorp/consul-k8s/version"
+)
+
+func TestRun(t *testing.T) {
+	consulChart := func(chartVersion string) *chart.Chart {
+		return &chart.Chart{
+			Metadata: &chart.Metadata{Name: "consul", Version: chartVersion},
+			Values: map[string]interface{}{
+				"global": map[string]interface{}{
+					"image":                "hashicorp/consul:1.21.0",
+					"imageConsulDataplane": "hashicorp/consul-dataplane:1.7.0",
+				},
+			},
+		}
+	}
+
+	cases := map[string]struct {
+		installed        *helmRelease.Release
+		embeddedChart    *chart.Chart
+		expOutput        []string
+		unexpectedOutput []string
+	}{
+		"installed release": {
+			installed: &helmRelease.Release{
+				Chart:  consulChart(cliversion.Version),
+				Config: map[string]interface{}{"global": map[string]interface{}{"image": "hashicorp/consul:1.19.2"}},
+			},
+			expOutput: []string{
+				"Checking the versions of the Consul installation in namespace consul with name consul.",
+				"hashicorp/consul:1.19.2",
+				"Consul server image hashicorp/consul:1.19.2 is not supported by Helm chart version " + cliversion.Version,
+			},
+			unexpectedOutput: []string{"No unsupported version combinations found."},
+		},
+		"not installed": {
+			embeddedChart: consulChart(cliversion.Version),
+			expOutput: []string{
+				"No existing Consul installation found. Checking the versions `consul-k8s install` would install.",
+				"hashicorp/consul-dataplane:1.7.0",
+				"No unsupported version combinations found.",
+			},
+		},
+	}
+
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			buf := new(bytes.Buffer)
+			c := setupCommand(buf)
+			c.helmActionsRunner = &helm.MockActionRunner{
+				CheckForInstallationsFunc: func(options *helm.CheckForInstallationsOptions) (bool, string, string, error) {
+					if tc.installed == nil {
+						return false, "", "", nil
+					}
+					return true, common.DefaultReleaseName, "consul", nil
+				},
+				GetStatusFunc: func(status *action.Status, name string) (*helmRelease.Release, error) {
+					return tc.installed, nil
+				},
+				LoadChartFunc: func(chrt embed.FS, chartDirName string) (*chart.Chart, error) {
+					return tc.embeddedChart, nil
+				},
+			}
+			client := fake.NewSimpleClientset()
+			client.Discovery().(*fakediscovery.FakeDiscovery).FakedServerVersion = &version.Info{GitVersion: "v1.31.2"}
+			c.kubernetes = client
+
+			require.Equal(t, 0, c.Run(nil))
+			output := buf.String()
+			require.Contains(t, output, "v1.31.2")
+			for _, expected := range tc.expOutput {
+				require.Contains(t, output, expected)
+			}
+			for _, unexpected := range tc.unexpectedOutput {
+				require.NotContains(t, output, unexpected)
+			}
+		})
+	}
+}
+
+func TestRun_FlagParsing(t *testing.T) {
+	c := setupCommand(io.Discard)
+	require.Equal(t, 1, c.Run([]string{"foo"}))
+	require.Equal(t, 1, c.Run([]string{"-foo"}))
+}
+
+func setupCommand(buf io.Writer) *CheckCommand {
+	// Log at a test level to standard out.
+	log := hclog.New(&hclog.LoggerOptions{
+		Name:   "test",
+		Level:  hclog.Debug,
+		Output: os.Stdout,
+	})
+
+	// Setup and initialize the command struct
+	command := &CheckCommand{
+		BaseCommand: &common.BaseCommand{
+			Log: log,
+			UI:  terminal.NewUI(context.Background(), buf),
+		},
+	}
+	command.init()
+
+	return command
+}
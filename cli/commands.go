@@ -9,23 +9,48 @@ import (
 	"github.com/hashicorp/go-hclog"
 	"github.com/mitchellh/cli"
 
+	"github.com/hashicorp/consul-k8s/cli/cmd/adminpartition"
+	"github.com/hashicorp/consul-k8s/cli/cmd/adminpartition/provision"
+	"github.com/hashicorp/consul-k8s/cli/cmd/cert"
+	cert_rotate "github.com/hashicorp/consul-k8s/cli/cmd/cert/rotate"
 	"github.com/hashicorp/consul-k8s/cli/cmd/config"
+	config_export "github.com/hashicorp/consul-k8s/cli/cmd/config/export"
+	config_import "github.com/hashicorp/consul-k8s/cli/cmd/config/importer"
+	config_preset "github.com/hashicorp/consul-k8s/cli/cmd/config/preset"
+	config_preset_show "github.com/hashicorp/consul-k8s/cli/cmd/config/preset/show"
 	config_read "github.com/hashicorp/consul-k8s/cli/cmd/config/read"
+	"github.com/hashicorp/consul-k8s/cli/cmd/debug"
+	"github.com/hashicorp/consul-k8s/cli/cmd/debug/profile"
+	gwdescribe "github.com/hashicorp/consul-k8s/cli/cmd/gateway/describe"
 	gwlist "github.com/hashicorp/consul-k8s/cli/cmd/gateway/list"
 	gwread "github.com/hashicorp/consul-k8s/cli/cmd/gateway/read"
+	"github.com/hashicorp/consul-k8s/cli/cmd/history"
 	"github.com/hashicorp/consul-k8s/cli/cmd/install"
+	"github.com/hashicorp/consul-k8s/cli/cmd/intention"
+	intention_check "github.com/hashicorp/consul-k8s/cli/cmd/intention/check"
+	intention_create "github.com/hashicorp/consul-k8s/cli/cmd/intention/create"
+	intention_delete "github.com/hashicorp/consul-k8s/cli/cmd/intention/delete"
+	intention_list "github.com/hashicorp/consul-k8s/cli/cmd/intention/list"
+	"github.com/hashicorp/consul-k8s/cli/cmd/logs"
+	"github.com/hashicorp/consul-k8s/cli/cmd/maintenance"
+	maintenance_mode "github.com/hashicorp/consul-k8s/cli/cmd/maintenance/mode"
 	"github.com/hashicorp/consul-k8s/cli/cmd/proxy"
 	"github.com/hashicorp/consul-k8s/cli/cmd/proxy/list"
 	"github.com/hashicorp/consul-k8s/cli/cmd/proxy/loglevel"
 	"github.com/hashicorp/consul-k8s/cli/cmd/proxy/read"
 	"github.com/hashicorp/consul-k8s/cli/cmd/proxy/stats"
+	"github.com/hashicorp/consul-k8s/cli/cmd/report"
+	"github.com/hashicorp/consul-k8s/cli/cmd/report/resources"
 	"github.com/hashicorp/consul-k8s/cli/cmd/status"
 	"github.com/hashicorp/consul-k8s/cli/cmd/troubleshoot"
 	troubleshoot_proxy "github.com/hashicorp/consul-k8s/cli/cmd/troubleshoot/proxy"
 	"github.com/hashicorp/consul-k8s/cli/cmd/troubleshoot/upstreams"
 	"github.com/hashicorp/consul-k8s/cli/cmd/uninstall"
 	"github.com/hashicorp/consul-k8s/cli/cmd/upgrade"
+	"github.com/hashicorp/consul-k8s/cli/cmd/values"
+	values_migrate "github.com/hashicorp/consul-k8s/cli/cmd/values/migrate"
 	cmdversion "github.com/hashicorp/consul-k8s/cli/cmd/version"
+	version_check "github.com/hashicorp/consul-k8s/cli/cmd/version/check"
 	"github.com/hashicorp/consul-k8s/cli/common"
 	"github.com/hashicorp/consul-k8s/cli/common/terminal"
 	"github.com/hashicorp/consul-k8s/version"
@@ -54,6 +79,11 @@ func initializeCommands(ctx context.Context, log hclog.Logger) (*common.BaseComm
 				BaseCommand: baseCommand,
 			}, nil
 		},
+		"history": func() (cli.Command, error) {
+			return &history.Command{
+				BaseCommand: baseCommand,
+			}, nil
+		},
 		"upgrade": func() (cli.Command, error) {
 			return &upgrade.Command{
 				BaseCommand: baseCommand,
@@ -65,6 +95,16 @@ func initializeCommands(ctx context.Context, log hclog.Logger) (*common.BaseComm
 				Version:     version.GetHumanVersion(),
 			}, nil
 		},
+		"version check": func() (cli.Command, error) {
+			return &version_check.CheckCommand{
+				BaseCommand: baseCommand,
+			}, nil
+		},
+		"gateway describe": func() (cli.Command, error) {
+			return &gwdescribe.Command{
+				BaseCommand: baseCommand,
+			}, nil
+		},
 		"gateway list": func() (cli.Command, error) {
 			return &gwlist.Command{
 				BaseCommand: baseCommand,
@@ -100,6 +140,31 @@ func initializeCommands(ctx context.Context, log hclog.Logger) (*common.BaseComm
 				BaseCommand: baseCommand,
 			}, nil
 		},
+		"intention": func() (cli.Command, error) {
+			return &intention.IntentionCommand{
+				BaseCommand: baseCommand,
+			}, nil
+		},
+		"intention create": func() (cli.Command, error) {
+			return &intention_create.Command{
+				BaseCommand: baseCommand,
+			}, nil
+		},
+		"intention list": func() (cli.Command, error) {
+			return &intention_list.Command{
+				BaseCommand: baseCommand,
+			}, nil
+		},
+		"intention delete": func() (cli.Command, error) {
+			return &intention_delete.Command{
+				BaseCommand: baseCommand,
+			}, nil
+		},
+		"intention check": func() (cli.Command, error) {
+			return &intention_check.Command{
+				BaseCommand: baseCommand,
+			}, nil
+		},
 		"config": func() (cli.Command, error) {
 			return &config.ConfigCommand{
 				BaseCommand: baseCommand,
@@ -110,6 +175,97 @@ func initializeCommands(ctx context.Context, log hclog.Logger) (*common.BaseComm
 				BaseCommand: baseCommand,
 			}, nil
 		},
+		"config export": func() (cli.Command, error) {
+			return &config_export.ExportCommand{
+				BaseCommand: baseCommand,
+			}, nil
+		},
+		"config import": func() (cli.Command, error) {
+			return &config_import.ImportCommand{
+				BaseCommand: baseCommand,
+			}, nil
+		},
+		"config preset": func() (cli.Command, error) {
+			return &config_preset.PresetCommand{
+				BaseCommand: baseCommand,
+			}, nil
+		},
+		"config preset show": func() (cli.Command, error) {
+			return &config_preset_show.ShowCommand{
+				BaseCommand: baseCommand,
+			}, nil
+		},
+		"values": func() (cli.Command, error) {
+			return &values.ValuesCommand{
+				BaseCommand: baseCommand,
+			}, nil
+		},
+		"values migrate": func() (cli.Command, error) {
+			return &values_migrate.MigrateCommand{
+				BaseCommand: baseCommand,
+			}, nil
+		},
+		"admin-partition": func() (cli.Command, error) {
+			return &adminpartition.AdminPartitionCommand{
+				BaseCommand: baseCommand,
+			}, nil
+		},
+		"admin-partition provision": func() (cli.Command, error) {
+			return &provision.ProvisionCommand{
+				BaseCommand: baseCommand,
+			}, nil
+		},
+		"logs": func() (cli.Command, error) {
+			return &logs.LogsCommand{
+				BaseCommand: baseCommand,
+			}, nil
+		},
+		"cert": func() (cli.Command, error) {
+			return &cert.CertCommand{
+				BaseCommand: baseCommand,
+			}, nil
+		},
+		"cert rotate": func() (cli.Command, error) {
+			return &cert_rotate.RotateCommand{
+				BaseCommand: baseCommand,
+			}, nil
+		},
+		"maintenance": func() (cli.Command, error) {
+			return &maintenance.MaintenanceCommand{
+				BaseCommand: baseCommand,
+			}, nil
+		},
+		"maintenance enable": func() (cli.Command, error) {
+			return &maintenance_mode.Command{
+				BaseCommand: baseCommand,
+				Enable:      true,
+			}, nil
+		},
+		"maintenance disable": func() (cli.Command, error) {
+			return &maintenance_mode.Command{
+				BaseCommand: baseCommand,
+			}, nil
+		},
+		"report": func() (cli.Command, error) {
+			return &report.ReportCommand{
+				BaseCommand: baseCommand,
+			}, nil
+		},
+		"report resources": func() (cli.Command, error) {
+			return &resources.ResourcesCommand{
+				BaseCommand: baseCommand,
+			}, nil
+		},
+		"debug": func() (cli.Command, error) {
+			return &debug.DebugCommand{
+				BaseCommand: baseCommand,
+			}, nil
+		},
+		"debug profile": func() (cli.Command, error) {
+			return &profile.ProfileCommand{
+				BaseCommand: baseCommand,
+			}, nil
+		},
 		"troubleshoot": func() (cli.Command, error) {
 			return &troubleshoot.TroubleshootCommand{
 				BaseCommand: baseCommand,
@@ -0,0 +1,252 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+// Package consul connects the CLI to the Consul servers of the Consul release
+// installed on the Kubernetes cluster.
+package consul
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/hashicorp/consul/api"
+	"helm.sh/helm/v3/pkg/action"
+	helmCLI "helm.sh/helm/v3/pkg/cli"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/rest"
+
+	"github.com/hashicorp/consul-k8s/cli/common"
+	"github.com/hashicorp/consul-k8s/cli/helm"
+)
+
+const (
+	serverContainerName = "consul"
+	caCertVolumeName    = "consul-ca-cert"
+
+	defaultBootstrapTokenSecretSuffix = "-bootstrap-acl-token"
+	defaultBootstrapTokenSecretKey    = "token"
+)
+
+// Connection is a connection to the Consul servers of the installed Consul release,
+// opened through a port forward to one of the server pods.
+type Connection struct {
+	// Settings are the Helm settings used to find the installed release.
+	Settings *helmCLI.EnvSettings
+	// HelmActionsRunner is used to find the installed release and read its values.
+	HelmActionsRunner helm.HelmActionsRunner
+	// KubeClient is the Kubernetes client used to find the servers and read their secrets.
+	KubeClient kubernetes.Interface
+	// RestConfig is the REST client configuration used for port forwarding.
+	RestConfig *rest.Config
+	// Log streams the logs of the Helm library.
+	Log action.DebugLog
+
+	// Token is the ACL token to use. If it is empty and CONSUL_HTTP_TOKEN
+	// is not set, the bootstrap token of the release is read from its secret.
+	Token string
+	// CAFile is the path to the CA certificate used to verify the servers when
+	// TLS is enabled. If it is empty, the CA certificate mounted by the servers is used.
+	CAFile string
+
+	portForward    common.PortForwarder
+	newPortForward func(namespace, podName string, port int) common.PortForwarder
+}
+
+// server is a Consul server pod of the release.
+type server struct {
+	namespace string
+	podName   string
+	// fullName is the name prefix of the Kubernetes resources of the release.
+	fullName string
+	port     int
+	tls      bool
+	// caCertSecretName and caCertSecretKey locate the CA certificate mounted by the server.
+	caCertSecretName string
+	caCertSecretKey  string
+}
+
+// Open opens a port forward to a Consul server of the installed release and returns
+// a Consul API client for it. Close must be called once the client is no longer needed.
+func (c *Connection) Open(ctx context.Context) (*api.Client, error) {
+	_, releaseName, namespace, err := c.HelmActionsRunner.CheckForInstallations(&helm.CheckForInstallationsOptions{
+		Settings:    c.Settings,
+		ReleaseName: common.DefaultReleaseName,
+		DebugLog:    c.Log,
+	})
+	if err != nil {
+		return nil, err
+	}
+	values, err := helm.FetchChartValues(c.HelmActionsRunner, namespace, releaseName, c.Settings, c.Log)
+	if err != nil {
+		return nil, fmt.Errorf("unable to read the values of release %s: %w", releaseName, err)
+	}
+
+	srv, err := findServer(ctx, c.KubeClient, namespace, releaseName)
+	if err != nil {
+		return nil, err
+	}
+
+	cfg := api.DefaultConfig()
+	if srv.tls {
+		cfg.Scheme = "https"
+		// The port forward is to localhost which isn't in the certificate of the servers,
+		// so verify it against the name of the server service instead.
+		cfg.TLSConfig.Address = srv.fullName + "-server"
+		if c.CAFile != "" {
+			cfg.TLSConfig.CAFile = c.CAFile
+		} else {
+			cfg.TLSConfig.CAPem, err = c.caCert(ctx, srv)
+			if err != nil {
+				return nil, err
+			}
+		}
+	}
+	if c.Token != "" {
+		cfg.Token = c.Token
+	} else if cfg.Token == "" {
+		cfg.Token, err = c.bootstrapToken(ctx, srv, values)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if c.newPortForward == nil {
+		c.newPortForward = func(namespace, podName string, port int) common.PortForwarder {
+			return &common.PortForward{
+				Namespace:  namespace,
+				PodName:    podName,
+				RemotePort: port,
+				KubeClient: c.KubeClient,
+				RestConfig: c.RestConfig,
+			}
+		}
+	}
+	c.portForward = c.newPortForward(srv.namespace, srv.podName, srv.port)
+	cfg.Address, err = c.portForward.Open(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("unable to port forward to Consul server %s/%s: %w", srv.namespace, srv.podName, err)
+	}
+
+	client, err := api.NewClient(cfg)
+	if err != nil {
+		c.Close()
+		return nil, fmt.Errorf("unable to create Consul API client: %w", err)
+	}
+	return client, nil
+}
+
+// Close closes the port forward to the Consul server.
+func (c *Connection) Close() {
+	if c.portForward != nil {
+		c.portForward.Close()
+		c.portForward = nil
+	}
+}
+
+// findServer returns a running Consul server pod of the release.
+func findServer(ctx context.Context, kubeClient kubernetes.Interface, namespace, releaseName string) (*server, error) {
+	pods, err := kubeClient.CoreV1().Pods(namespace).List(ctx, metav1.ListOptions{
+		LabelSelector: fmt.Sprintf("component=server,release=%s", releaseName),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("unable to list Consul server pods: %w", err)
+	}
+	for _, pod := range pods.Items {
+		if pod.Status.Phase != corev1.PodRunning || pod.DeletionTimestamp != nil {
+			continue
+		}
+		srv := &server{namespace: pod.Namespace, podName: pod.Name}
+
+		for _, ref := range pod.OwnerReferences {
+			if ref.Kind == "StatefulSet" && ref.APIVersion == appsv1.SchemeGroupVersion.String() {
+				srv.fullName = strings.TrimSuffix(ref.Name, "-server")
+			}
+		}
+		if srv.fullName == "" {
+			return nil, fmt.Errorf("unable to find the StatefulSet of Consul server pod %s/%s", pod.Namespace, pod.Name)
+		}
+
+		for _, container := range pod.Spec.Containers {
+			if container.Name != serverContainerName {
+				continue
+			}
+			for _, port := range container.Ports {
+				switch port.Name {
+				case "https":
+					srv.port, srv.tls = int(port.ContainerPort), true
+				case "http":
+					if !srv.tls {
+						srv.port = int(port.ContainerPort)
+					}
+				}
+			}
+		}
+		if srv.port == 0 {
+			return nil, fmt.Errorf("unable to find the HTTP port of Consul server pod %s/%s", pod.Namespace, pod.Name)
+		}
+
+		for _, volume := range pod.Spec.Volumes {
+			if volume.Name == caCertVolumeName && volume.Secret != nil {
+				srv.caCertSecretName = volume.Secret.SecretName
+				srv.caCertSecretKey = "tls.crt"
+				if len(volume.Secret.Items) > 0 {
+					srv.caCertSecretKey = volume.Secret.Items[0].Key
+				}
+			}
+		}
+		return srv, nil
+	}
+	return nil, fmt.Errorf("no running Consul server pods found for release %s in namespace %s", releaseName, namespace)
+}
+
+// caCert returns the CA certificate that the server mounts from its Kubernetes secret.
+func (c *Connection) caCert(ctx context.Context, srv *server) ([]byte, error) {
+	if srv.caCertSecretName == "" {
+		return nil, errors.New("unable to find the CA certificate of the Consul servers in Kubernetes, the CA certificate must be passed with -ca-file")
+	}
+	secret, err := c.KubeClient.CoreV1().Secrets(srv.namespace).Get(ctx, srv.caCertSecretName, metav1.GetOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("unable to read the CA certificate from secret %s/%s: %w", srv.namespace, srv.caCertSecretName, err)
+	}
+	caCert, ok := secret.Data[srv.caCertSecretKey]
+	if !ok {
+		return nil, fmt.Errorf("secret %s/%s does not have the key %q", srv.namespace, srv.caCertSecretName, srv.caCertSecretKey)
+	}
+	return caCert, nil
+}
+
+// bootstrapToken returns the ACL bootstrap token of the release, or an empty token if ACLs
+// aren't enabled. The token is read from the secret set in global.acls.bootstrapToken or
+// from the secret that server-acl-init created when bootstrapping the ACLs.
+func (c *Connection) bootstrapToken(ctx context.Context, srv *server, values map[string]interface{}) (string, error) {
+	manageSystemACLs, _, _ := unstructured.NestedBool(values, "global", "acls", "manageSystemACLs")
+	secretName, _, _ := unstructured.NestedString(values, "global", "acls", "bootstrapToken", "secretName")
+	secretKey, _, _ := unstructured.NestedString(values, "global", "acls", "bootstrapToken", "secretKey")
+	if !manageSystemACLs && secretName == "" {
+		return "", nil
+	}
+	if secretName == "" || secretKey == "" {
+		secretName = srv.fullName + defaultBootstrapTokenSecretSuffix
+		secretKey = defaultBootstrapTokenSecretKey
+	}
+
+	secret, err := c.KubeClient.CoreV1().Secrets(srv.namespace).Get(ctx, secretName, metav1.GetOptions{})
+	if k8serrors.IsNotFound(err) {
+		return "", fmt.Errorf("unable to find the ACL bootstrap token secret %s/%s, an ACL token must be passed with -token", srv.namespace, secretName)
+	}
+	if err != nil {
+		return "", fmt.Errorf("unable to read the ACL bootstrap token from secret %s/%s: %w", srv.namespace, secretName, err)
+	}
+	token, ok := secret.Data[secretKey]
+	if !ok {
+		return "", fmt.Errorf("secret %s/%s does not have the key %q", srv.namespace, secretName, secretKey)
+	}
+	return strings.TrimSpace(string(token)), nil
+}
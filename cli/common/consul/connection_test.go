@@ -0,0 +1,272 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package consul
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"helm.sh/helm/v3/pkg/action"
+	helmCLI "helm.sh/helm/v3/pkg/cli"
+	"helm.sh/helm/v3/pkg/release"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+
+	"github.com/hashicorp/consul-k8s/cli/common"
+	"github.com/hashicorp/consul-k8s/cli/helm"
+)
+
+func TestOpen(t *testing.T) {
+	cases := map[string]struct {
+		values        map[string]interface{}
+		secrets       []*corev1.Secret
+		token         string
+		expectedToken string
+		expectedErr   string
+	}{
+		"ACLs disabled": {
+			values:        map[string]interface{}{},
+			expectedToken: "",
+		},
+		"bootstrap token of the release": {
+			values: map[string]interface{}{
+				"global": map[string]interface{}{"acls": map[string]interface{}{"manageSystemACLs": true}},
+			},
+			secrets:       []*corev1.Secret{secret("consul-bootstrap-acl-token", "token", "bootstrap-token\n")},
+			expectedToken: "bootstrap-token",
+		},
+		"bootstrap token from global.acls.bootstrapToken": {
+			values: map[string]interface{}{
+				"global": map[string]interface{}{"acls": map[string]interface{}{
+					"manageSystemACLs": true,
+					"bootstrapToken":   map[string]interface{}{"secretName": "my-token", "secretKey": "key"},
+				}},
+			},
+			secrets:       []*corev1.Secret{secret("my-token", "key", "my-bootstrap-token")},
+			expectedToken: "my-bootstrap-token",
+		},
+		"token flag takes precedence over the bootstrap token": {
+			values: map[string]interface{}{
+				"global": map[string]interface{}{"acls": map[string]interface{}{"manageSystemACLs": true}},
+			},
+			token:         "flag-token",
+			expectedToken: "flag-token",
+		},
+		"bootstrap token secret not found": {
+			values: map[string]interface{}{
+				"global": map[string]interface{}{"acls": map[string]interface{}{"manageSystemACLs": true}},
+			},
+			expectedErr: "unable to find the ACL bootstrap token secret consul/consul-bootstrap-acl-token, an ACL token must be passed with -token",
+		},
+		"bootstrap token secret without the key": {
+			values: map[string]interface{}{
+				"global": map[string]interface{}{"acls": map[string]interface{}{"manageSystemACLs": true}},
+			},
+			secrets:     []*corev1.Secret{secret("consul-bootstrap-acl-token", "other", "bootstrap-token")},
+			expectedErr: `secret consul/consul-bootstrap-acl-token does not have the key "token"`,
+		},
+	}
+
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			t.Setenv("CONSUL_HTTP_TOKEN", "")
+
+			var gotToken string
+			consulServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				gotToken = r.Header.Get("X-Consul-Token")
+				w.Write([]byte(`"10.0.0.1:8300"`))
+			}))
+			defer consulServer.Close()
+
+			kubeClient := fake.NewSimpleClientset(serverPod("consul-server-0", "http", 8500))
+			for _, s := range tc.secrets {
+				_, err := kubeClient.CoreV1().Secrets("consul").Create(context.Background(), s, metav1.CreateOptions{})
+				require.NoError(t, err)
+			}
+
+			pf := &mockPortForwarder{address: strings.TrimPrefix(consulServer.URL, "http://")}
+			conn := &Connection{
+				Settings:          helmCLI.New(),
+				HelmActionsRunner: mockActionRunner(tc.values),
+				KubeClient:        kubeClient,
+				Token:             tc.token,
+				newPortForward: func(namespace, podName string, port int) common.PortForwarder {
+					require.Equal(t, "consul", namespace)
+					require.Equal(t, "consul-server-0", podName)
+					require.Equal(t, 8500, port)
+					return pf
+				},
+			}
+			client, err := conn.Open(context.Background())
+			if tc.expectedErr != "" {
+				require.EqualError(t, err, tc.expectedErr)
+				return
+			}
+			require.NoError(t, err)
+
+			leader, err := client.Status().Leader()
+			require.NoError(t, err)
+			require.Equal(t, "10.0.0.1:8300", leader)
+			require.Equal(t, tc.expectedToken, gotToken)
+
+			conn.Close()
+			require.True(t, pf.closed)
+		})
+	}
+}
+
+func TestFindServer(t *testing.T) {
+	cases := map[string]struct {
+		pods        []*corev1.Pod
+		expected    *server
+		expectedErr string
+	}{
+		"no server pods": {
+			expectedErr: "no running Consul server pods found for release consul in namespace consul",
+		},
+		"pending server pod": {
+			pods: []*corev1.Pod{
+				func() *corev1.Pod {
+					pod := serverPod("consul-server-0", "http", 8500)
+					pod.Status.Phase = corev1.PodPending
+					return pod
+				}(),
+			},
+			expectedErr: "no running Consul server pods found for release consul in namespace consul",
+		},
+		"HTTP": {
+			pods: []*corev1.Pod{serverPod("consul-server-0", "http", 8500)},
+			expected: &server{
+				namespace: "consul",
+				podName:   "consul-server-0",
+				fullName:  "consul",
+				port:      8500,
+			},
+		},
+		"HTTPS with the CA certificate mounted from a secret": {
+			pods: []*corev1.Pod{
+				func() *corev1.Pod {
+					pod := serverPod("consul-server-0", "https", 8501)
+					pod.Spec.Containers[0].Ports = append(pod.Spec.Containers[0].Ports, corev1.ContainerPort{Name: "http", ContainerPort: 8500})
+					pod.Spec.Volumes = []corev1.Volume{{
+						Name: "consul-ca-cert",
+						VolumeSource: corev1.VolumeSource{Secret: &corev1.SecretVolumeSource{
+							SecretName: "consul-ca-cert",
+							Items:      []corev1.KeyToPath{{Key: "tls.crt", Path: "tls.crt"}},
+						}},
+					}}
+					return pod
+				}(),
+			},
+			expected: &server{
+				namespace:        "consul",
+				podName:          "consul-server-0",
+				fullName:         "consul",
+				port:             8501,
+				tls:              true,
+				caCertSecretName: "consul-ca-cert",
+				caCertSecretKey:  "tls.crt",
+			},
+		},
+		"server pod without a StatefulSet": {
+			pods: []*corev1.Pod{
+				func() *corev1.Pod {
+					pod := serverPod("consul-server-0", "http", 8500)
+					pod.OwnerReferences = nil
+					return pod
+				}(),
+			},
+			expectedErr: "unable to find the StatefulSet of Consul server pod consul/consul-server-0",
+		},
+	}
+
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			kubeClient := fake.NewSimpleClientset()
+			for _, pod := range tc.pods {
+				_, err := kubeClient.CoreV1().Pods(pod.Namespace).Create(context.Background(), pod, metav1.CreateOptions{})
+				require.NoError(t, err)
+			}
+
+			srv, err := findServer(context.Background(), kubeClient, "consul", "consul")
+			if tc.expectedErr != "" {
+				require.EqualError(t, err, tc.expectedErr)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, tc.expected, srv)
+		})
+	}
+}
+
+func TestCACert(t *testing.T) {
+	kubeClient := fake.NewSimpleClientset(secret("consul-ca-cert", "tls.crt", "ca-cert"))
+	conn := &Connection{KubeClient: kubeClient}
+
+	caCert, err := conn.caCert(context.Background(), &server{namespace: "consul", caCertSecretName: "consul-ca-cert", caCertSecretKey: "tls.crt"})
+	require.NoError(t, err)
+	require.Equal(t, []byte("ca-cert"), caCert)
+
+	// The CA certificate isn't in a Kubernetes secret when it is stored in Vault.
+	_, err = conn.caCert(context.Background(), &server{namespace: "consul"})
+	require.EqualError(t, err, "unable to find the CA certificate of the Consul servers in Kubernetes, the CA certificate must be passed with -ca-file")
+}
+
+func serverPod(name, portName string, port int32) *corev1.Pod {
+	return &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      name,
+			Namespace: "consul",
+			Labels: map[string]string{
+				"app":       "consul",
+				"component": "server",
+				"release":   "consul",
+			},
+			OwnerReferences: []metav1.OwnerReference{{
+				APIVersion: "apps/v1",
+				Kind:       "StatefulSet",
+				Name:       "consul-server",
+			}},
+		},
+		Spec: corev1.PodSpec{
+			Containers: []corev1.Container{{
+				Name:  "consul",
+				Ports: []corev1.ContainerPort{{Name: portName, ContainerPort: port}},
+			}},
+		},
+		Status: corev1.PodStatus{Phase: corev1.PodRunning},
+	}
+}
+
+func secret(name, key, value string) *corev1.Secret {
+	return &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "consul"},
+		Data:       map[string][]byte{key: []byte(value)},
+	}
+}
+
+func mockActionRunner(values map[string]interface{}) *helm.MockActionRunner {
+	return &helm.MockActionRunner{
+		CheckForInstallationsFunc: func(options *helm.CheckForInstallationsOptions) (bool, string, string, error) {
+			return true, "consul", "consul", nil
+		},
+		GetStatusFunc: func(status *action.Status, name string) (*release.Release, error) {
+			return &release.Release{Name: name, Config: values}, nil
+		},
+	}
+}
+
+type mockPortForwarder struct {
+	address string
+	closed  bool
+}
+
+func (m *mockPortForwarder) Open(context.Context) (string, error) { return m.address, nil }
+func (m *mockPortForwarder) Close()                               { m.closed = true }
+func (m *mockPortForwarder) GetLocalPort() int                    { return 0 }
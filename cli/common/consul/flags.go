@@ -0,0 +1,103 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package consul
+
+import (
+	"fmt"
+
+	"github.com/posener/complete"
+	"helm.sh/helm/v3/pkg/action"
+	helmCLI "helm.sh/helm/v3/pkg/cli"
+	"k8s.io/client-go/kubernetes"
+
+	"github.com/hashicorp/consul-k8s/cli/common/flag"
+	"github.com/hashicorp/consul-k8s/cli/helm"
+)
+
+const (
+	flagNameToken       = "token"
+	flagNameCAFile      = "ca-file"
+	flagNameKubeConfig  = "kubeconfig"
+	flagNameKubeContext = "context"
+)
+
+// Flags are the flags of the commands that connect to the Consul servers of the installed release.
+type Flags struct {
+	Token       string
+	CAFile      string
+	KubeConfig  string
+	KubeContext string
+}
+
+// AddTo adds the flags to the "Consul Options" and "Global Options" flag sets.
+func (f *Flags) AddTo(sets *flag.Sets) {
+	s := sets.NewSet("Consul Options")
+	s.StringVar(&flag.StringVar{
+		Name:   flagNameToken,
+		Target: &f.Token,
+		Usage: "The ACL token to use. Defaults to the CONSUL_HTTP_TOKEN environment variable, " +
+			"or to the ACL bootstrap token of the release when ACLs are managed by the Helm chart.",
+	})
+	s.StringVar(&flag.StringVar{
+		Name:   flagNameCAFile,
+		Target: &f.CAFile,
+		Usage: "The path to the CA certificate of the Consul servers when TLS is enabled. " +
+			"Defaults to the CA certificate mounted by the servers from Kubernetes.",
+	})
+
+	s = sets.NewSet("Global Options")
+	s.StringVar(&flag.StringVar{
+		Name:    flagNameKubeConfig,
+		Aliases: []string{"c"},
+		Target:  &f.KubeConfig,
+		Usage:   "Set the path to kubeconfig file.",
+	})
+	s.StringVar(&flag.StringVar{
+		Name:   flagNameKubeContext,
+		Target: &f.KubeContext,
+		Usage:  "Set the Kubernetes context to use.",
+	})
+}
+
+// AutocompleteFlags returns the autocomplete options of the flags.
+func (f *Flags) AutocompleteFlags() complete.Flags {
+	return complete.Flags{
+		fmt.Sprintf("-%s", flagNameToken):       complete.PredictNothing,
+		fmt.Sprintf("-%s", flagNameCAFile):      complete.PredictFiles("*"),
+		fmt.Sprintf("-%s", flagNameKubeConfig):  complete.PredictFiles("*"),
+		fmt.Sprintf("-%s", flagNameKubeContext): complete.PredictNothing,
+	}
+}
+
+// NewConnection returns a connection to the Consul servers of the installed release
+// using the Kubernetes configuration and the credentials of the flags.
+func (f *Flags) NewConnection(log action.DebugLog) (*Connection, error) {
+	// helmCLI.New() will create a settings object which is used by the Helm Go SDK calls.
+	settings := helmCLI.New()
+	if f.KubeConfig != "" {
+		settings.KubeConfig = f.KubeConfig
+	}
+	if f.KubeContext != "" {
+		settings.KubeContext = f.KubeContext
+	}
+
+	restConfig, err := settings.RESTClientGetter().ToRESTConfig()
+	if err != nil {
+		return nil, fmt.Errorf("error creating Kubernetes REST config: %w", err)
+	}
+	kubeClient, err := kubernetes.NewForConfig(restConfig)
+	if err != nil {
+		return nil, fmt.Errorf("error creating Kubernetes client: %w", err)
+	}
+
+	return &Connection{
+		Settings:          settings,
+		HelmActionsRunner: &helm.ActionRunner{},
+		KubeClient:        kubeClient,
+		RestConfig:        restConfig,
+		Log:               log,
+		Token:             f.Token,
+		CAFile:            f.CAFile,
+	}, nil
+}
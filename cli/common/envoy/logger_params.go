@@ -5,6 +5,7 @@ package envoy
 
 import (
 	"fmt"
+	"sort"
 	"strings"
 )
 
@@ -47,6 +48,34 @@ func (l *LoggerParams) SetGlobalLoggerLevel(level string) error {
 	return nil
 }
 
+// LoggerParamsFromLevels returns the params that set each logger to its level in levels, such as the
+// levels returned by the logging endpoint before they were changed. If all loggers have the same level,
+// the global level is set instead. Logger names aren't validated since they are returned by Envoy.
+func LoggerParamsFromLevels(levels map[string]string) *LoggerParams {
+	params := NewLoggerParams()
+	names := make([]string, 0, len(levels))
+	uniform := true
+	for name, level := range levels {
+		names = append(names, name)
+		if level != levels[names[0]] {
+			uniform = false
+		}
+	}
+	if len(names) == 0 {
+		return params
+	}
+	if uniform {
+		params.globalLevel = levels[names[0]]
+		return params
+	}
+
+	sort.Strings(names)
+	for _, name := range names {
+		params.individualLevels = append(params.individualLevels, logLevel{name: name, level: levels[name]})
+	}
+	return params
+}
+
 func validateLogLevel(level string) error {
 	if _, ok := envoyLevels[level]; !ok {
 		logLevels := []string{}
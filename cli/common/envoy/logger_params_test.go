@@ -173,3 +173,30 @@ func TestString(t *testing.T) {
 		})
 	}
 }
+
+func TestLoggerParamsFromLevels(t *testing.T) {
+	t.Parallel()
+	testCases := map[string]struct {
+		levels         map[string]string
+		expectedOutput string
+	}{
+		"when there are no levels": {
+			levels:         map[string]string{},
+			expectedOutput: "",
+		},
+		"when all loggers have the same level": {
+			levels:         map[string]string{"grpc": "info", "http": "info", "upstream": "info"},
+			expectedOutput: "?level=info",
+		},
+		"when loggers have different levels": {
+			levels:         map[string]string{"upstream": "info", "http": "debug", "grpc": "info"},
+			expectedOutput: "?paths=grpc:info,http:debug,upstream:info",
+		},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			require.Equal(t, tc.expectedOutput, LoggerParamsFromLevels(tc.levels).String())
+		})
+	}
+}
@@ -0,0 +1,151 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+// Package health runs health checks of the components of a Consul installation
+// and reports their results.
+package health
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/hashicorp/consul-k8s/cli/common/terminal"
+)
+
+// Status is the status of a health check.
+type Status string
+
+const (
+	// StatusPass means the component is healthy.
+	StatusPass Status = "Pass"
+	// StatusWarn means the component works but needs attention.
+	StatusWarn Status = "Warn"
+	// StatusFail means the component is unhealthy.
+	StatusFail Status = "Fail"
+	// StatusSkip means the component isn't installed, or can't be checked.
+	StatusSkip Status = "Skip"
+)
+
+// Result is the result of a health check.
+type Result struct {
+	Status Status
+	// Message describes the state of the component.
+	Message string
+	// Remediation suggests how to fix the component when the check doesn't pass.
+	Remediation string
+}
+
+// Pass returns a passing result.
+func Pass(format string, args ...interface{}) Result {
+	return Result{Status: StatusPass, Message: fmt.Sprintf(format, args...)}
+}
+
+// Skip returns the result of a check that doesn't apply.
+func Skip(format string, args ...interface{}) Result {
+	return Result{Status: StatusSkip, Message: fmt.Sprintf(format, args...)}
+}
+
+// Warn returns a warning result with a remediation hint.
+func Warn(remediation, format string, args ...interface{}) Result {
+	return Result{Status: StatusWarn, Message: fmt.Sprintf(format, args...), Remediation: remediation}
+}
+
+// Fail returns a failing result with a remediation hint.
+func Fail(remediation, format string, args ...interface{}) Result {
+	return Result{Status: StatusFail, Message: fmt.Sprintf(format, args...), Remediation: remediation}
+}
+
+// Check checks the health of a component of the installation.
+type Check struct {
+	// Name is the name of the checked component.
+	Name string
+	// Run runs the check. It must return when ctx is done.
+	Run func(ctx context.Context) Result
+}
+
+// Report is the result of a check.
+type Report struct {
+	Name string
+	Result
+}
+
+// Run runs the checks concurrently and returns their reports in the order of the checks.
+// Checks that don't finish within the timeout fail.
+func Run(ctx context.Context, timeout time.Duration, checks []Check) []Report {
+	reports := make([]Report, len(checks))
+	var wg sync.WaitGroup
+	for i, check := range checks {
+		wg.Add(1)
+		go func(i int, check Check) {
+			defer wg.Done()
+			checkCtx, cancel := context.WithTimeout(ctx, timeout)
+			defer cancel()
+
+			done := make(chan Result, 1)
+			go func() { done <- check.Run(checkCtx) }()
+			select {
+			case result := <-done:
+				reports[i] = Report{Name: check.Name, Result: result}
+			case <-checkCtx.Done():
+				reports[i] = Report{Name: check.Name, Result: Fail(
+					"Check that the Kubernetes API server and the component are reachable.",
+					"check did not complete within %s", timeout)}
+			}
+		}(i, check)
+	}
+	wg.Wait()
+	return reports
+}
+
+// Healthy returns true if none of the reports failed.
+func Healthy(reports []Report) bool {
+	for _, r := range reports {
+		if r.Status == StatusFail {
+			return false
+		}
+	}
+	return true
+}
+
+// Print writes the reports as a table to ui, followed by the remediation hints
+// of the checks that didn't pass.
+func Print(ui terminal.UI, reports []Report) {
+	tbl := terminal.NewTable("Check", "Status", "Message")
+	for _, r := range reports {
+		tbl.AddRow([]string{r.Name, string(r.Status), r.Message}, []string{"", statusColor(r.Status)})
+	}
+	ui.Table(tbl)
+
+	var hints []Report
+	for _, r := range reports {
+		if r.Remediation != "" && (r.Status == StatusWarn || r.Status == StatusFail) {
+			hints = append(hints, r)
+		}
+	}
+	if len(hints) == 0 {
+		return
+	}
+	ui.Output("Remediation:", terminal.WithHeaderStyle())
+	for _, r := range hints {
+		style := terminal.WithWarningStyle()
+		if r.Status == StatusFail {
+			style = terminal.WithErrorStyle()
+		}
+		ui.Output("%s: %s", r.Name, r.Remediation, style)
+	}
+}
+
+func statusColor(s Status) string {
+	switch s {
+	case StatusPass:
+		return terminal.Green
+	case StatusWarn:
+		return terminal.Yellow
+	case StatusFail:
+		return terminal.Red
+	default:
+		return ""
+	}
+}
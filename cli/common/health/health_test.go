@@ -0,0 +1,57 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package health
+
+import (
+	"bytes"
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/hashicorp/consul-k8s/cli/common/terminal"
+)
+
+func TestRun(t *testing.T) {
+	checks := []Check{
+		{Name: "pass", Run: func(context.Context) Result { return Pass("%d ok", 3) }},
+		{Name: "fail", Run: func(context.Context) Result { return Fail("fix it", "broken") }},
+		{Name: "slow", Run: func(ctx context.Context) Result {
+			<-ctx.Done()
+			return Pass("too late")
+		}},
+	}
+
+	reports := Run(context.Background(), 50*time.Millisecond, checks)
+	require.Equal(t, []Report{
+		{Name: "pass", Result: Result{Status: StatusPass, Message: "3 ok"}},
+		{Name: "fail", Result: Result{Status: StatusFail, Message: "broken", Remediation: "fix it"}},
+		{Name: "slow", Result: Result{
+			Status:      StatusFail,
+			Message:     "check did not complete within 50ms",
+			Remediation: "Check that the Kubernetes API server and the component are reachable.",
+		}},
+	}, reports)
+	require.False(t, Healthy(reports))
+	require.True(t, Healthy(reports[:1]))
+}
+
+func TestPrint(t *testing.T) {
+	buf := new(bytes.Buffer)
+	ui := terminal.NewUI(context.Background(), buf)
+
+	Print(ui, []Report{
+		{Name: "servers", Result: Pass("healthy")},
+		{Name: "cni", Result: Skip("not installed")},
+		{Name: "gateways", Result: Warn("restart them", "not ready")},
+		{Name: "webhook", Result: Fail("check the logs", "unreachable")},
+	})
+
+	output := buf.String()
+	for _, s := range []string{"servers", "healthy", "not installed", "Remediation:", "gateways: restart them", "webhook: check the logs"} {
+		require.Contains(t, output, s)
+	}
+	require.NotContains(t, output, "cni:")
+}
@@ -0,0 +1,191 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package compatibility
+
+import (
+	_ "embed"
+	"fmt"
+	"strings"
+
+	goversion "github.com/hashicorp/go-version"
+	"helm.sh/helm/v3/pkg/chart"
+	"helm.sh/helm/v3/pkg/chartutil"
+	"k8s.io/client-go/kubernetes"
+	"sigs.k8s.io/yaml"
+
+	"github.com/hashicorp/consul-k8s/cli/common/terminal"
+	"github.com/hashicorp/consul-k8s/version"
+)
+
+//go:embed matrix.yaml
+var embeddedMatrix []byte
+
+// Matrix lists the supported versions of each minor version of Consul on Kubernetes.
+type Matrix []Entry
+
+// Entry lists the minor versions of Consul, Consul Dataplane and Kubernetes supported by
+// a minor version of Consul on Kubernetes.
+type Entry struct {
+	ConsulK8s       string   `json:"consulK8s"`
+	Consul          []string `json:"consul"`
+	ConsulDataplane []string `json:"consulDataplane"`
+	Kubernetes      []string `json:"kubernetes"`
+}
+
+// Versions are the versions of the components of an installation to check against the matrix.
+// A version is empty when it is unknown.
+type Versions struct {
+	CLI            string
+	Chart          string
+	ConsulImage    string
+	DataplaneImage string
+	Kubernetes     string
+}
+
+// EmbeddedMatrix returns the compatibility matrix embedded in the CLI.
+func EmbeddedMatrix() (Matrix, error) {
+	var m Matrix
+	if err := yaml.Unmarshal(embeddedMatrix, &m); err != nil {
+		return nil, fmt.Errorf("error parsing compatibility matrix: %w", err)
+	}
+	return m, nil
+}
+
+// ReleaseVersions returns the versions of the CLI, of the chart, and of the Consul and Consul Dataplane
+// images set by the chart with the given values.
+func ReleaseVersions(chrt *chart.Chart, vals map[string]interface{}) (Versions, error) {
+	versions := Versions{CLI: version.Version}
+	if chrt == nil {
+		return versions, nil
+	}
+	if chrt.Metadata != nil {
+		versions.Chart = chrt.Metadata.Version
+	}
+	coalesced, err := chartutil.CoalesceValues(chrt, vals)
+	if err != nil {
+		return versions, err
+	}
+	if image, err := coalesced.PathValue("global.image"); err == nil {
+		versions.ConsulImage, _ = image.(string)
+	}
+	if image, err := coalesced.PathValue("global.imageConsulDataplane"); err == nil {
+		versions.DataplaneImage, _ = image.(string)
+	}
+	return versions, nil
+}
+
+// KubernetesVersion returns the version of the Kubernetes API server.
+func KubernetesVersion(client kubernetes.Interface) (string, error) {
+	info, err := client.Discovery().ServerVersion()
+	if err != nil {
+		return "", err
+	}
+	return info.GitVersion, nil
+}
+
+// Check returns a warning for each version that isn't supported with the version of the chart.
+func (m Matrix) Check(v Versions) []string {
+	var warnings []string
+
+	chartMinor, chartKnown := minorVersion(v.Chart)
+	if cliMinor, ok := minorVersion(v.CLI); ok && chartKnown && cliMinor != chartMinor {
+		warnings = append(warnings, fmt.Sprintf("consul-k8s CLI version %s does not match Helm chart version %s. "+
+			"Use a CLI of the same minor version as the Helm chart.", v.CLI, v.Chart))
+	}
+	if !chartKnown {
+		return append(warnings, fmt.Sprintf("Unable to determine the version of the Helm chart %q.", v.Chart))
+	}
+	entry := m.entry(chartMinor)
+	if entry == nil {
+		return append(warnings, fmt.Sprintf("Helm chart version %s is not in the compatibility matrix of this CLI. "+
+			"Use a CLI of the same minor version as the Helm chart to check its supported versions.", v.Chart))
+	}
+
+	warnings = appendUnsupported(warnings, "Consul server image", v.ConsulImage, ImageVersion(v.ConsulImage), v.Chart, "Consul", entry.Consul)
+	warnings = appendUnsupported(warnings, "Consul Dataplane image", v.DataplaneImage, ImageVersion(v.DataplaneImage), v.Chart, "Consul Dataplane", entry.ConsulDataplane)
+	warnings = appendUnsupported(warnings, "Kubernetes version", v.Kubernetes, v.Kubernetes, v.Chart, "Kubernetes", entry.Kubernetes)
+	return warnings
+}
+
+// entry returns the entry of the minor version of Consul on Kubernetes, or nil if there is none.
+func (m Matrix) entry(minor string) *Entry {
+	for i := range m {
+		if m[i].ConsulK8s == minor {
+			return &m[i]
+		}
+	}
+	return nil
+}
+
+// appendUnsupported appends a warning if the version of the component isn't one of the supported minor versions.
+func appendUnsupported(warnings []string, component, value, v, chartVersion, product string, supported []string) []string {
+	if value == "" {
+		return warnings
+	}
+	minor, ok := minorVersion(v)
+	if !ok {
+		return append(warnings, fmt.Sprintf("Unable to determine the version of %s %q.", component, value))
+	}
+	for _, s := range supported {
+		if s == minor {
+			return warnings
+		}
+	}
+	return append(warnings, fmt.Sprintf("%s %s is not supported by Helm chart version %s, which supports %s %s.",
+		component, value, chartVersion, product, strings.Join(supported, ", ")))
+}
+
+// minorVersion returns the major and minor segments of the version, e.g. 1.21 for v1.21.0-ent.
+func minorVersion(v string) (string, bool) {
+	if v == "" {
+		return "", false
+	}
+	parsed, err := goversion.NewVersion(v)
+	if err != nil {
+		return "", false
+	}
+	segments := parsed.Segments()
+	return fmt.Sprintf("%d.%d", segments[0], segments[1]), true
+}
+
+// ImageVersion returns the tag of the image, which is the version of the images published by HashiCorp,
+// or "" if the image isn't tagged.
+func ImageVersion(image string) string {
+	image, _, _ = strings.Cut(image, "@")
+	if i := strings.LastIndex(image, ":"); i > strings.LastIndex(image, "/") {
+		return image[i+1:]
+	}
+	return ""
+}
+
+// Print outputs the warnings, or that no unsupported versions were found.
+func Print(ui terminal.UI, warnings []string) {
+	if len(warnings) == 0 {
+		ui.Output("No unsupported version combinations found.", terminal.WithSuccessStyle())
+		return
+	}
+	for _, w := range warnings {
+		ui.Output(w, terminal.WithWarningStyle())
+	}
+}
+
+// Preflight outputs warnings for the versions of the chart with the given values, of the CLI and of Kubernetes
+// that aren't supported together. It is run before installing or upgrading, which unsupported versions don't fail.
+func Preflight(ui terminal.UI, client kubernetes.Interface, chrt *chart.Chart, vals map[string]interface{}) {
+	ui.Output("Checking version compatibility", terminal.WithHeaderStyle())
+	matrix, err := EmbeddedMatrix()
+	if err != nil {
+		ui.Output("Unable to check version compatibility: %v", err, terminal.WithWarningStyle())
+		return
+	}
+	versions, err := ReleaseVersions(chrt, vals)
+	if err != nil {
+		ui.Output("Unable to check version compatibility: %v", err, terminal.WithWarningStyle())
+		return
+	}
+	if versions.Kubernetes, err = KubernetesVersion(client); err != nil {
+		ui.Output("Unable to retrieve the Kubernetes version: %v", err, terminal.WithWarningStyle())
+	}
+	Print(ui, matrix.Check(versions))
+}
@@ -0,0 +1,123 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package compatibility
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"helm.sh/helm/v3/pkg/chart"
+
+	consulChart "github.com/hashicorp/consul-k8s/charts"
+	"github.com/hashicorp/consul-k8s/cli/helm"
+	"github.com/hashicorp/consul-k8s/version"
+)
+
+func TestEmbeddedMatrix(t *testing.T) {
+	matrix, err := EmbeddedMatrix()
+	require.NoError(t, err)
+	require.NotEmpty(t, matrix)
+
+	// The matrix must list the versions supported by this version of the CLI and its embedded chart.
+	chrt, err := helm.LoadChart(consulChart.ConsulHelmChart, "consul")
+	require.NoError(t, err)
+	versions, err := ReleaseVersions(chrt, nil)
+	require.NoError(t, err)
+	require.Equal(t, version.Version, versions.CLI)
+	require.Empty(t, matrix.Check(versions))
+}
+
+func TestReleaseVersions(t *testing.T) {
+	chrt := &chart.Chart{
+		Metadata: &chart.Metadata{Name: "consul", Version: "1.7.0"},
+		Values: map[string]interface{}{
+			"global": map[string]interface{}{
+				"image":                "hashicorp/consul:1.21.0",
+				"imageConsulDataplane": "hashicorp/consul-dataplane:1.7.0",
+			},
+		},
+	}
+	versions, err := ReleaseVersions(chrt, map[string]interface{}{
+		"global": map[string]interface{}{"image": "hashicorp/consul-enterprise:1.21.1-ent"},
+	})
+	require.NoError(t, err)
+	require.Equal(t, Versions{
+		CLI:            version.Version,
+		Chart:          "1.7.0",
+		ConsulImage:    "hashicorp/consul-enterprise:1.21.1-ent",
+		DataplaneImage: "hashicorp/consul-dataplane:1.7.0",
+	}, versions)
+}
+
+func TestCheck(t *testing.T) {
+	matrix := Matrix{
+		{ConsulK8s: "1.7", Consul: []string{"1.21"}, ConsulDataplane: []string{"1.7"}, Kubernetes: []string{"1.30", "1.31"}},
+	}
+	supported := Versions{
+		CLI:            "1.7.1",
+		Chart:          "1.7.0",
+		ConsulImage:    "registry.example.com:5000/hashicorp/consul-enterprise:1.21.2-ent",
+		DataplaneImage: "hashicorp/consul-dataplane:1.7.0@sha256:abc",
+		Kubernetes:     "v1.31.4-eks-2d5f260",
+	}
+
+	cases := map[string]struct {
+		versions    func(Versions) Versions
+		expWarnings []string
+	}{
+		"supported": {
+			versions: func(v Versions) Versions { return v },
+		},
+		"unknown versions are skipped": {
+			versions: func(v Versions) Versions {
+				v.ConsulImage, v.DataplaneImage, v.Kubernetes = "", "", ""
+				return v
+			},
+		},
+		"CLI and chart mismatch": {
+			versions: func(v Versions) Versions {
+				v.CLI = "1.6.3"
+				return v
+			},
+			expWarnings: []string{"consul-k8s CLI version 1.6.3 does not match Helm chart version 1.7.0. Use a CLI of the same minor version as the Helm chart."},
+		},
+		"chart not in matrix": {
+			versions: func(v Versions) Versions {
+				v.CLI, v.Chart = "1.8.0", "1.8.0"
+				return v
+			},
+			expWarnings: []string{"Helm chart version 1.8.0 is not in the compatibility matrix of this CLI. Use a CLI of the same minor version as the Helm chart to check its supported versions."},
+		},
+		"unsupported versions": {
+			versions: func(v Versions) Versions {
+				v.ConsulImage = "hashicorp/consul:1.19.0"
+				v.DataplaneImage = "hashicorp/consul-dataplane:1.5.2"
+				v.Kubernetes = "v1.28.0"
+				return v
+			},
+			expWarnings: []string{
+				"Consul server image hashicorp/consul:1.19.0 is not supported by Helm chart version 1.7.0, which supports Consul 1.21.",
+				"Consul Dataplane image hashicorp/consul-dataplane:1.5.2 is not supported by Helm chart version 1.7.0, which supports Consul Dataplane 1.7.",
+				"Kubernetes version v1.28.0 is not supported by Helm chart version 1.7.0, which supports Kubernetes 1.30, 1.31.",
+			},
+		},
+		"image without version": {
+			versions: func(v Versions) Versions {
+				v.ConsulImage = "hashicorp/consul:latest"
+				v.DataplaneImage = "registry.example.com:5000/consul-dataplane"
+				return v
+			},
+			expWarnings: []string{
+				`Unable to determine the version of Consul server image "hashicorp/consul:latest".`,
+				`Unable to determine the version of Consul Dataplane image "registry.example.com:5000/consul-dataplane".`,
+			},
+		},
+	}
+
+	for name, c := range cases {
+		t.Run(name, func(t *testing.T) {
+			require.Equal(t, c.expWarnings, matrix.Check(c.versions(supported)))
+		})
+	}
+}
@@ -3,7 +3,9 @@
 
 package config
 
-import "sigs.k8s.io/yaml"
+import (
+	"sigs.k8s.io/yaml"
+)
 
 // GlobalNameConsul is used to set the global name of an install to consul.
 const GlobalNameConsul = `
@@ -0,0 +1,254 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+// Package configentries exports the custom resources that manage Consul config entries
+// to YAML files and imports them back into a Kubernetes cluster.
+package configentries
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	k8syaml "k8s.io/apimachinery/pkg/util/yaml"
+	"k8s.io/client-go/dynamic"
+	"sigs.k8s.io/yaml"
+)
+
+const (
+	group   = "consul.hashicorp.com"
+	version = "v1alpha1"
+
+	defaultNamespace = "default"
+
+	// lastAppliedAnnotation is added by kubectl apply and would make imported
+	// resources look like they were last applied with the exported configuration.
+	lastAppliedAnnotation = "kubectl.kubernetes.io/last-applied-configuration"
+)
+
+// kindToResource maps the kinds of the custom resources that manage Consul config entries
+// to their resource names.
+var kindToResource = map[string]string{
+	"ControlPlaneRequestLimit": "controlplanerequestlimits",
+	"ExportedServices":         "exportedservices",
+	"IngressGateway":           "ingressgateways",
+	"JWTProvider":              "jwtproviders",
+	"Mesh":                     "meshes",
+	"ProxyDefaults":            "proxydefaults",
+	"SamenessGroup":            "samenessgroups",
+	"ServiceDefaults":          "servicedefaults",
+	"ServiceIntentions":        "serviceintentions",
+	"ServiceResolver":          "serviceresolvers",
+	"ServiceRouter":            "servicerouters",
+	"ServiceSplitter":          "servicesplitters",
+	"TerminatingGateway":       "terminatinggateways",
+}
+
+// Kinds returns the sorted kinds of the custom resources that manage Consul config entries.
+func Kinds() []string {
+	kinds := make([]string, 0, len(kindToResource))
+	for kind := range kindToResource {
+		kinds = append(kinds, kind)
+	}
+	sort.Strings(kinds)
+	return kinds
+}
+
+// GroupVersionResource returns the resource of kind, or false if kind does not manage a Consul config entry.
+func GroupVersionResource(kind string) (schema.GroupVersionResource, bool) {
+	resource, ok := kindToResource[kind]
+	return schema.GroupVersionResource{Group: group, Version: version, Resource: resource}, ok
+}
+
+// List returns the custom resources that manage Consul config entries in namespace, or in all
+// namespaces if namespace is empty. The resources are stripped of the fields set by Kubernetes
+// so that they can be applied to another cluster. Kinds whose CRD is not installed are skipped.
+func List(ctx context.Context, client dynamic.Interface, namespace string) ([]unstructured.Unstructured, error) {
+	var crs []unstructured.Unstructured
+	for _, kind := range Kinds() {
+		gvr, _ := GroupVersionResource(kind)
+		list, err := client.Resource(gvr).Namespace(namespace).List(ctx, metav1.ListOptions{})
+		if k8serrors.IsNotFound(err) {
+			continue
+		}
+		if err != nil {
+			return nil, fmt.Errorf("error listing %s: %w", gvr.Resource, err)
+		}
+		for _, cr := range list.Items {
+			crs = append(crs, Sanitize(cr))
+		}
+	}
+	return crs, nil
+}
+
+// Sanitize returns a copy of cr without its status and the metadata set by Kubernetes and
+// the Consul controllers, such as the resource version and finalizers.
+func Sanitize(cr unstructured.Unstructured) unstructured.Unstructured {
+	out := unstructured.Unstructured{Object: map[string]interface{}{
+		"apiVersion": cr.GetAPIVersion(),
+		"kind":       cr.GetKind(),
+	}}
+	out.SetName(cr.GetName())
+	out.SetNamespace(cr.GetNamespace())
+	if labels := cr.GetLabels(); len(labels) > 0 {
+		out.SetLabels(labels)
+	}
+	annotations := cr.GetAnnotations()
+	delete(annotations, lastAppliedAnnotation)
+	if len(annotations) > 0 {
+		out.SetAnnotations(annotations)
+	}
+	if spec, ok := cr.Object["spec"]; ok {
+		out.Object["spec"] = spec
+	}
+	return out
+}
+
+// FileName returns the path of the file cr is exported to, relative to the export directory.
+func FileName(cr unstructured.Unstructured) string {
+	namespace := cr.GetNamespace()
+	if namespace == "" {
+		namespace = defaultNamespace
+	}
+	return filepath.Join(namespace, fmt.Sprintf("%s-%s.yaml", strings.ToLower(cr.GetKind()), cr.GetName()))
+}
+
+// WriteDir writes each custom resource to its own YAML file in dir and returns the paths of the files.
+func WriteDir(dir string, crs []unstructured.Unstructured) ([]string, error) {
+	paths := make([]string, 0, len(crs))
+	for _, cr := range crs {
+		out, err := yaml.Marshal(cr.Object)
+		if err != nil {
+			return nil, fmt.Errorf("error marshaling %s %q: %w", cr.GetKind(), cr.GetName(), err)
+		}
+		path := filepath.Join(dir, FileName(cr))
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			return nil, err
+		}
+		if err := os.WriteFile(path, out, 0644); err != nil {
+			return nil, err
+		}
+		paths = append(paths, path)
+	}
+	return paths, nil
+}
+
+// ReadDir reads the custom resources from the YAML and JSON files in dir and its subdirectories.
+// Files may contain multiple documents. Documents that are not custom resources managing Consul
+// config entries are returned as skipped, described by their file and kind.
+func ReadDir(dir string) (crs []unstructured.Unstructured, skipped []string, err error) {
+	err = filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() {
+			return nil
+		}
+		switch filepath.Ext(path) {
+		case ".yaml", ".yml", ".json":
+		default:
+			return nil
+		}
+
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return err
+		}
+		decoder := k8syaml.NewYAMLOrJSONDecoder(bytes.NewReader(data), 4096)
+		for {
+			var obj map[string]interface{}
+			if err := decoder.Decode(&obj); err != nil {
+				if errors.Is(err, io.EOF) {
+					return nil
+				}
+				return fmt.Errorf("error decoding %s: %w", path, err)
+			}
+			if len(obj) == 0 {
+				continue
+			}
+			cr := unstructured.Unstructured{Object: obj}
+			if _, ok := GroupVersionResource(cr.GetKind()); !ok || cr.GroupVersionKind().Group != group {
+				skipped = append(skipped, fmt.Sprintf("%s (%s)", path, cr.GetKind()))
+				continue
+			}
+			crs = append(crs, cr)
+		}
+	})
+	return crs, skipped, err
+}
+
+// ApplyResult describes what Apply did with a custom resource.
+type ApplyResult string
+
+const (
+	ApplyResultCreated ApplyResult = "created"
+	ApplyResultUpdated ApplyResult = "updated"
+)
+
+// Apply creates cr in the cluster, or updates the spec, labels and annotations of the resource if it
+// already exists. If namespace is set, cr is applied to that namespace instead of its own. If dryRun is
+// true, the request is validated by the Kubernetes API server without persisting the resource.
+func Apply(ctx context.Context, client dynamic.Interface, cr unstructured.Unstructured, namespace string, dryRun bool) (ApplyResult, error) {
+	gvr, ok := GroupVersionResource(cr.GetKind())
+	if !ok {
+		return "", fmt.Errorf("%s does not manage a Consul config entry", cr.GetKind())
+	}
+	cr = Sanitize(cr)
+	if namespace != "" {
+		cr.SetNamespace(namespace)
+	}
+	if cr.GetNamespace() == "" {
+		cr.SetNamespace(defaultNamespace)
+	}
+
+	var dryRunOpt []string
+	if dryRun {
+		dryRunOpt = []string{metav1.DryRunAll}
+	}
+
+	resourceClient := client.Resource(gvr).Namespace(cr.GetNamespace())
+	existing, err := resourceClient.Get(ctx, cr.GetName(), metav1.GetOptions{})
+	if k8serrors.IsNotFound(err) {
+		if _, err := resourceClient.Create(ctx, &cr, metav1.CreateOptions{DryRun: dryRunOpt}); err != nil {
+			return "", err
+		}
+		return ApplyResultCreated, nil
+	}
+	if err != nil {
+		return "", err
+	}
+
+	// Keep the metadata managed by Kubernetes and the Consul controllers, such as finalizers.
+	updated := existing.DeepCopy()
+	updated.Object["spec"] = cr.Object["spec"]
+	updated.SetLabels(merge(existing.GetLabels(), cr.GetLabels()))
+	updated.SetAnnotations(merge(existing.GetAnnotations(), cr.GetAnnotations()))
+	if _, err := resourceClient.Update(ctx, updated, metav1.UpdateOptions{DryRun: dryRunOpt}); err != nil {
+		return "", err
+	}
+	return ApplyResultUpdated, nil
+}
+
+func merge(dst, src map[string]string) map[string]string {
+	if len(src) == 0 {
+		return dst
+	}
+	if dst == nil {
+		dst = make(map[string]string, len(src))
+	}
+	for k, v := range src {
+		dst[k] = v
+	}
+	return dst
+}
@@ -0,0 +1,155 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package configentries
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	dynamicFake "k8s.io/client-go/dynamic/fake"
+)
+
+func TestList(t *testing.T) {
+	existing := newCR("ServiceDefaults", "web", "default", map[string]interface{}{"protocol": "http"})
+	existing.SetResourceVersion("12")
+	existing.SetUID("1234")
+	existing.SetFinalizers([]string{"finalizers.consul.hashicorp.com"})
+	existing.SetAnnotations(map[string]string{
+		lastAppliedAnnotation: "{}",
+		"team":                "web",
+	})
+	existing.Object["status"] = map[string]interface{}{"conditions": []interface{}{}}
+
+	client := newFakeClient(t, existing, newCR("Mesh", "mesh", "consul", map[string]interface{}{}))
+
+	crs, err := List(context.Background(), client, "")
+	require.NoError(t, err)
+	require.Len(t, crs, 2)
+	require.Equal(t, "Mesh", crs[0].GetKind())
+
+	web := crs[1]
+	require.Equal(t, "ServiceDefaults", web.GetKind())
+	require.Empty(t, web.GetResourceVersion())
+	require.Empty(t, web.GetUID())
+	require.Empty(t, web.GetFinalizers())
+	require.Equal(t, map[string]string{"team": "web"}, web.GetAnnotations())
+	require.NotContains(t, web.Object, "status")
+	require.Equal(t, map[string]interface{}{"protocol": "http"}, web.Object["spec"])
+
+	crs, err = List(context.Background(), client, "consul")
+	require.NoError(t, err)
+	require.Len(t, crs, 1)
+	require.Equal(t, "mesh", crs[0].GetName())
+}
+
+func TestWriteDirReadDir(t *testing.T) {
+	dir := t.TempDir()
+	crs := []unstructured.Unstructured{
+		newCR("ServiceDefaults", "web", "default", map[string]interface{}{"protocol": "http"}),
+		newCR("ServiceIntentions", "api", "consul", map[string]interface{}{
+			"destination": map[string]interface{}{"name": "api"},
+		}),
+	}
+
+	paths, err := WriteDir(dir, crs)
+	require.NoError(t, err)
+	require.Equal(t, []string{
+		filepath.Join(dir, "default", "servicedefaults-web.yaml"),
+		filepath.Join(dir, "consul", "serviceintentions-api.yaml"),
+	}, paths)
+
+	// Other manifests and multi-document files may be mixed in with exported resources.
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "extra.yaml"), []byte(`apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: config
+---
+apiVersion: consul.hashicorp.com/v1alpha1
+kind: ProxyDefaults
+metadata:
+  name: global
+spec:
+  config:
+    protocol: http
+`), 0644))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "README.md"), []byte("# Config entries"), 0644))
+
+	read, skipped, err := ReadDir(dir)
+	require.NoError(t, err)
+	require.Equal(t, []string{filepath.Join(dir, "extra.yaml") + " (ConfigMap)"}, skipped)
+	require.Len(t, read, 3)
+	kinds := make(map[string]*unstructured.Unstructured)
+	for i := range read {
+		kinds[read[i].GetKind()] = &read[i]
+	}
+	require.Equal(t, crs[0].Object, kinds["ServiceDefaults"].Object)
+	require.Equal(t, crs[1].Object, kinds["ServiceIntentions"].Object)
+	require.Equal(t, "global", kinds["ProxyDefaults"].GetName())
+}
+
+func TestApply(t *testing.T) {
+	existing := newCR("ServiceDefaults", "web", "default", map[string]interface{}{"protocol": "tcp"})
+	existing.SetFinalizers([]string{"finalizers.consul.hashicorp.com"})
+	existing.SetLabels(map[string]string{"existing": "true"})
+	client := newFakeClient(t, existing)
+	gvr, _ := GroupVersionResource("ServiceDefaults")
+
+	// Updating keeps the finalizers and labels added to the existing resource.
+	cr := newCR("ServiceDefaults", "web", "other", map[string]interface{}{"protocol": "http"})
+	cr.SetLabels(map[string]string{"imported": "true"})
+	result, err := Apply(context.Background(), client, cr, "default", false)
+	require.NoError(t, err)
+	require.Equal(t, ApplyResultUpdated, result)
+
+	updated, err := client.Resource(gvr).Namespace("default").Get(context.Background(), "web", metav1.GetOptions{})
+	require.NoError(t, err)
+	require.Equal(t, map[string]interface{}{"protocol": "http"}, updated.Object["spec"])
+	require.Equal(t, []string{"finalizers.consul.hashicorp.com"}, updated.GetFinalizers())
+	require.Equal(t, map[string]string{"existing": "true", "imported": "true"}, updated.GetLabels())
+
+	// Resources without a namespace are created in the default namespace.
+	result, err = Apply(context.Background(), client, newCR("ServiceDefaults", "api", "", nil), "", false)
+	require.NoError(t, err)
+	require.Equal(t, ApplyResultCreated, result)
+	_, err = client.Resource(gvr).Namespace("default").Get(context.Background(), "api", metav1.GetOptions{})
+	require.NoError(t, err)
+
+	_, err = Apply(context.Background(), client, newCR("ConfigMap", "config", "default", nil), "", false)
+	require.EqualError(t, err, "ConfigMap does not manage a Consul config entry")
+}
+
+func newCR(kind, name, namespace string, spec map[string]interface{}) unstructured.Unstructured {
+	cr := unstructured.Unstructured{Object: map[string]interface{}{}}
+	cr.SetAPIVersion(group + "/" + version)
+	cr.SetKind(kind)
+	cr.SetName(name)
+	cr.SetNamespace(namespace)
+	if spec != nil {
+		cr.Object["spec"] = spec
+	}
+	return cr
+}
+
+func newFakeClient(t *testing.T, crs ...unstructured.Unstructured) *dynamicFake.FakeDynamicClient {
+	t.Helper()
+	gvrToListKind := make(map[schema.GroupVersionResource]string)
+	for _, kind := range Kinds() {
+		gvr, _ := GroupVersionResource(kind)
+		gvrToListKind[gvr] = kind + "List"
+	}
+	client := dynamicFake.NewSimpleDynamicClientWithCustomListKinds(runtime.NewScheme(), gvrToListKind)
+	for i := range crs {
+		gvr, _ := GroupVersionResource(crs[i].GetKind())
+		_, err := client.Resource(gvr).Namespace(crs[i].GetNamespace()).Create(context.Background(), &crs[i], metav1.CreateOptions{})
+		require.NoError(t, err)
+	}
+	return client
+}
@@ -0,0 +1,168 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package helm
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"regexp"
+	"sort"
+	"strings"
+
+	"helm.sh/helm/v3/pkg/chart"
+	"helm.sh/helm/v3/pkg/chart/loader"
+	"helm.sh/helm/v3/pkg/strvals"
+	"sigs.k8s.io/yaml"
+)
+
+const (
+	// BundleManifestFileName is the name of the manifest file at the root of an
+	// air-gapped bundle.
+	BundleManifestFileName = "manifest.yaml"
+	// BundleChartDirName is the directory within an air-gapped bundle that holds
+	// the Consul Helm chart, including its CRD templates.
+	BundleChartDirName = "chart"
+
+	// maxBundleFileSize limits the size of a single file read from a bundle so that
+	// a malformed archive cannot exhaust memory.
+	maxBundleFileSize = 64 << 20
+)
+
+// digestPinnedImage matches image references that are pinned to a sha256 digest,
+// e.g. "registry.example.com/hashicorp/consul@sha256:<64 hex characters>".
+var digestPinnedImage = regexp.MustCompile(`^[^@\s]+@sha256:[a-f0-9]{64}$`)
+
+// BundleManifest describes the contents of an air-gapped bundle.
+type BundleManifest struct {
+	// Version is the consul-k8s version the bundle was built for. It must match
+	// the version of the bundled chart.
+	Version string `json:"version"`
+	// Images maps Helm value paths, such as "global.image", to digest pinned image
+	// references that are set when installing from the bundle.
+	Images map[string]string `json:"images"`
+}
+
+// Bundle is an air-gapped bundle that has been read from disk.
+type Bundle struct {
+	Manifest BundleManifest
+	Chart    *chart.Chart
+}
+
+// LoadBundle reads an air-gapped bundle from a gzipped tarball. The bundle must contain
+// a manifest.yaml file and the Consul Helm chart under the chart/ directory. Every image
+// listed in the manifest must be pinned to a digest so that images pulled through a
+// registry mirror are guaranteed to match the images the bundle was built with.
+func LoadBundle(bundlePath string) (*Bundle, error) {
+	f, err := os.Open(bundlePath)
+	if err != nil {
+		return nil, fmt.Errorf("error opening bundle: %w", err)
+	}
+	defer f.Close()
+
+	gz, err := gzip.NewReader(f)
+	if err != nil {
+		return nil, fmt.Errorf("error reading bundle %q: %w", bundlePath, err)
+	}
+	defer gz.Close()
+
+	var (
+		manifest   []byte
+		chartFiles []*loader.BufferedFile
+	)
+	tr := tar.NewReader(gz)
+	for {
+		hdr, err := tr.Next()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("error reading bundle %q: %w", bundlePath, err)
+		}
+		if hdr.Typeflag != tar.TypeReg {
+			continue
+		}
+
+		name := path.Clean(strings.TrimPrefix(hdr.Name, "./"))
+		if path.IsAbs(name) || name == ".." || strings.HasPrefix(name, "../") {
+			return nil, fmt.Errorf("bundle %q contains an invalid file path %q", bundlePath, hdr.Name)
+		}
+		if hdr.Size > maxBundleFileSize {
+			return nil, fmt.Errorf("bundle %q contains file %q which exceeds the maximum size of %d bytes", bundlePath, name, maxBundleFileSize)
+		}
+
+		switch {
+		case name == BundleManifestFileName:
+			manifest, err = io.ReadAll(tr)
+		case strings.HasPrefix(name, BundleChartDirName+"/"):
+			var data []byte
+			data, err = io.ReadAll(tr)
+			chartFiles = append(chartFiles, &loader.BufferedFile{
+				Name: strings.TrimPrefix(name, BundleChartDirName+"/"),
+				Data: data,
+			})
+		}
+		if err != nil {
+			return nil, fmt.Errorf("error reading %q from bundle %q: %w", name, bundlePath, err)
+		}
+	}
+
+	if manifest == nil {
+		return nil, fmt.Errorf("bundle %q does not contain %s", bundlePath, BundleManifestFileName)
+	}
+	if len(chartFiles) == 0 {
+		return nil, fmt.Errorf("bundle %q does not contain a Helm chart in the %s/ directory", bundlePath, BundleChartDirName)
+	}
+
+	bundle := &Bundle{}
+	if err := yaml.UnmarshalStrict(manifest, &bundle.Manifest); err != nil {
+		return nil, fmt.Errorf("error parsing bundle manifest: %w", err)
+	}
+	bundle.Chart, err = loader.LoadFiles(chartFiles)
+	if err != nil {
+		return nil, fmt.Errorf("error loading chart from bundle: %w", err)
+	}
+
+	if err := bundle.validate(); err != nil {
+		return nil, err
+	}
+	return bundle, nil
+}
+
+// validate checks that the bundle manifest is consistent with the bundled chart.
+func (b *Bundle) validate() error {
+	if b.Chart.Metadata.Name != "consul" {
+		return fmt.Errorf("bundle contains chart %q, expected the consul chart", b.Chart.Metadata.Name)
+	}
+	if b.Manifest.Version != b.Chart.Metadata.Version {
+		return fmt.Errorf("bundle manifest version %q does not match chart version %q", b.Manifest.Version, b.Chart.Metadata.Version)
+	}
+
+	var unpinned []string
+	for key, image := range b.Manifest.Images {
+		if !digestPinnedImage.MatchString(image) {
+			unpinned = append(unpinned, fmt.Sprintf("%s=%s", key, image))
+		}
+	}
+	if len(unpinned) > 0 {
+		sort.Strings(unpinned)
+		return fmt.Errorf("bundle images must be pinned to a sha256 digest: %s", strings.Join(unpinned, ", "))
+	}
+	return nil
+}
+
+// ImageValues returns the bundle's image references as Helm values.
+func (b *Bundle) ImageValues() (map[string]interface{}, error) {
+	vals := make(map[string]interface{})
+	for key, image := range b.Manifest.Images {
+		if err := strvals.ParseIntoString(fmt.Sprintf("%s=%s", key, image), vals); err != nil {
+			return nil, fmt.Errorf("error parsing bundle image %q: %w", key, err)
+		}
+	}
+	return vals, nil
+}
@@ -0,0 +1,144 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package helm
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"os"
+	"path/filepath"
+	"sort"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+const (
+	testBundleChartYAML = `apiVersion: v2
+name: consul
+version: 1.6.0
+`
+	testBundleDigest = "sha256:0123456789abcdef0123456789abcdef0123456789abcdef0123456789abcdef"
+)
+
+func TestLoadBundle(t *testing.T) {
+	validChart := map[string]string{
+		"chart/Chart.yaml":                       testBundleChartYAML,
+		"chart/values.yaml":                      "global:\n  image: hashicorp/consul:1.20.0\n",
+		"chart/templates/crd-registrations.yaml": "kind: CustomResourceDefinition\n",
+	}
+
+	cases := map[string]struct {
+		files     map[string]string
+		expImages map[string]interface{}
+		expErr    string
+	}{
+		"valid bundle": {
+			files: withFiles(validChart, map[string]string{
+				"manifest.yaml": "version: 1.6.0\nimages:\n" +
+					"  global.image: mirror.example.com/hashicorp/consul@" + testBundleDigest + "\n" +
+					"  global.imageK8S: mirror.example.com/hashicorp/consul-k8s-control-plane@" + testBundleDigest + "\n",
+			}),
+			expImages: map[string]interface{}{
+				"global": map[string]interface{}{
+					"image":    "mirror.example.com/hashicorp/consul@" + testBundleDigest,
+					"imageK8S": "mirror.example.com/hashicorp/consul-k8s-control-plane@" + testBundleDigest,
+				},
+			},
+		},
+		"missing manifest": {
+			files:  validChart,
+			expErr: "does not contain manifest.yaml",
+		},
+		"missing chart": {
+			files:  map[string]string{"manifest.yaml": "version: 1.6.0\n"},
+			expErr: "does not contain a Helm chart in the chart/ directory",
+		},
+		"version mismatch": {
+			files:  withFiles(validChart, map[string]string{"manifest.yaml": "version: 1.5.0\n"}),
+			expErr: `bundle manifest version "1.5.0" does not match chart version "1.6.0"`,
+		},
+		"image not pinned to a digest": {
+			files: withFiles(validChart, map[string]string{
+				"manifest.yaml": "version: 1.6.0\nimages:\n  global.image: hashicorp/consul:1.20.0\n",
+			}),
+			expErr: "bundle images must be pinned to a sha256 digest: global.image=hashicorp/consul:1.20.0",
+		},
+		"unknown manifest field": {
+			files:  withFiles(validChart, map[string]string{"manifest.yaml": "version: 1.6.0\nchart: foo\n"}),
+			expErr: "error parsing bundle manifest",
+		},
+		"path traversal": {
+			files:  withFiles(validChart, map[string]string{"../manifest.yaml": "version: 1.6.0\n"}),
+			expErr: "contains an invalid file path",
+		},
+	}
+
+	for name, c := range cases {
+		t.Run(name, func(t *testing.T) {
+			bundle, err := LoadBundle(writeBundle(t, c.files))
+			if c.expErr != "" {
+				require.ErrorContains(t, err, c.expErr)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, "consul", bundle.Chart.Name())
+			require.Len(t, bundle.Chart.Templates, 1)
+
+			images, err := bundle.ImageValues()
+			require.NoError(t, err)
+			require.Equal(t, c.expImages, images)
+		})
+	}
+}
+
+func TestLoadBundle_NotGzipped(t *testing.T) {
+	bundlePath := filepath.Join(t.TempDir(), "bundle.tgz")
+	require.NoError(t, os.WriteFile(bundlePath, []byte("not a bundle"), 0600))
+
+	_, err := LoadBundle(bundlePath)
+	require.ErrorContains(t, err, "error reading bundle")
+}
+
+func withFiles(base, extra map[string]string) map[string]string {
+	out := make(map[string]string, len(base)+len(extra))
+	for k, v := range base {
+		out[k] = v
+	}
+	for k, v := range extra {
+		out[k] = v
+	}
+	return out
+}
+
+// writeBundle writes files to a gzipped tarball in a temporary directory and returns its path.
+func writeBundle(t *testing.T, files map[string]string) string {
+	t.Helper()
+	bundlePath := filepath.Join(t.TempDir(), "bundle.tgz")
+	f, err := os.Create(bundlePath)
+	require.NoError(t, err)
+	defer f.Close()
+
+	gz := gzip.NewWriter(f)
+	tw := tar.NewWriter(gz)
+
+	names := make([]string, 0, len(files))
+	for name := range files {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		require.NoError(t, tw.WriteHeader(&tar.Header{
+			Name:     name,
+			Mode:     0600,
+			Size:     int64(len(files[name])),
+			Typeflag: tar.TypeReg,
+		}))
+		_, err := tw.Write([]byte(files[name]))
+		require.NoError(t, err)
+	}
+	require.NoError(t, tw.Close())
+	require.NoError(t, gz.Close())
+	return bundlePath
+}
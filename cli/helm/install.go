@@ -13,7 +13,9 @@ import (
 	"golang.org/x/text/cases"
 	"golang.org/x/text/language"
 	"helm.sh/helm/v3/pkg/action"
+	"helm.sh/helm/v3/pkg/chart"
 	helmCLI "helm.sh/helm/v3/pkg/cli"
+	"helm.sh/helm/v3/pkg/release"
 )
 
 // InstallOptions is used when calling InstallHelmRelease.
@@ -34,6 +36,9 @@ type InstallOptions struct {
 	EmbeddedChart embed.FS
 	// ChartDirName is the top level directory name fo the EmbeddedChart.
 	ChartDirName string
+	// Chart is a Helm chart that has already been loaded, such as the chart
+	// from an air-gapped bundle. When set, EmbeddedChart is not used.
+	Chart *chart.Chart
 	// UILogger is a DebugLog used to return messages from Helm to the UI.
 	UILogger action.DebugLog
 	// DryRun specifies whether the install/upgrade should actually modify the
@@ -53,6 +58,9 @@ type InstallOptions struct {
 	// HelmActionsRunner is a thin interface around Helm actions for install,
 	// upgrade, and uninstall.
 	HelmActionsRunner HelmActionsRunner
+	// PreInstall, if set, is run once the installation is confirmed and before
+	// the chart is installed, e.g. to create resources the chart references.
+	PreInstall func() error
 }
 
 // InstallDemoApp will perform the following actions
@@ -69,7 +77,7 @@ func InstallDemoApp(options *InstallOptions) error {
 	options.UI.Output("Namespace: %s", options.Settings.Namespace(), terminal.WithInfoStyle())
 	options.UI.Output("\n", terminal.WithInfoStyle())
 
-	err := InstallHelmRelease(options)
+	_, err := InstallHelmRelease(options)
 	if err != nil {
 		return err
 	}
@@ -86,10 +94,11 @@ func InstallDemoApp(options *InstallOptions) error {
 }
 
 // InstallHelmRelease handles downloading the embedded helm chart, loading the
-// values and runnning the Helm install command.
-func InstallHelmRelease(options *InstallOptions) error {
+// values and runnning the Helm install command. It returns the installed release,
+// or nil if the install was a dry run or was aborted.
+func InstallHelmRelease(options *InstallOptions) (*release.Release, error) {
 	if options.DryRun {
-		return nil
+		return nil, nil
 	}
 
 	if !options.AutoApprove {
@@ -100,13 +109,19 @@ func InstallHelmRelease(options *InstallOptions) error {
 		})
 
 		if err != nil {
-			return err
+			return nil, err
 		}
 		// The install will proceed if the user presses enter or responds with "y"/"yes" (case-insensitive).
 		if confirmation != "" && common.Abort(confirmation) {
 			options.UI.Output("Install aborted. Use the command `consul-k8s install -help` to learn how to customize your installation.",
 				terminal.WithInfoStyle())
-			return err
+			return nil, err
+		}
+	}
+
+	if options.PreInstall != nil {
+		if err := options.PreInstall(); err != nil {
+			return nil, err
 		}
 	}
 
@@ -116,7 +131,7 @@ func InstallHelmRelease(options *InstallOptions) error {
 	actionConfig := new(action.Configuration)
 	actionConfig, err := InitActionConfig(actionConfig, options.Namespace, options.Settings, options.UILogger)
 	if err != nil {
-		return err
+		return nil, err
 	}
 
 	// Setup the installation action.
@@ -128,17 +143,21 @@ func InstallHelmRelease(options *InstallOptions) error {
 	install.Timeout = options.Timeout
 
 	// Load the Helm chart.
-	chart, err := options.HelmActionsRunner.LoadChart(options.EmbeddedChart, options.ChartDirName)
-	if err != nil {
-		return err
+	chart := options.Chart
+	if chart == nil {
+		chart, err = options.HelmActionsRunner.LoadChart(options.EmbeddedChart, options.ChartDirName)
+		if err != nil {
+			return nil, err
+		}
+		options.UI.Output("Downloaded charts.", terminal.WithSuccessStyle())
 	}
-	options.UI.Output("Downloaded charts.", terminal.WithSuccessStyle())
 
 	// Run the install.
-	if _, err = options.HelmActionsRunner.Install(install, chart, options.Values); err != nil {
-		return err
+	rel, err := options.HelmActionsRunner.Install(install, chart, options.Values)
+	if err != nil {
+		return nil, err
 	}
 
 	options.UI.Output("%s installed in namespace %q.", options.ReleaseType, options.Namespace, terminal.WithSuccessStyle())
-	return nil
+	return rel, nil
 }
@@ -14,6 +14,7 @@ import (
 	"golang.org/x/text/language"
 	"helm.sh/helm/v3/pkg/action"
 	helmCLI "helm.sh/helm/v3/pkg/cli"
+	"helm.sh/helm/v3/pkg/release"
 )
 
 // UpgradeOptions is used when calling UpgradeHelmRelease.
@@ -59,26 +60,27 @@ type UpgradeOptions struct {
 
 // UpgradeHelmRelease handles downloading the embedded helm chart, loading the
 // values, showing the diff between new and installed values, and runnning the
-// Helm install command.
-func UpgradeHelmRelease(options *UpgradeOptions) error {
+// Helm install command. It returns the upgraded release, or nil if the upgrade was
+// a dry run or was aborted.
+func UpgradeHelmRelease(options *UpgradeOptions) (*release.Release, error) {
 	options.UI.Output("%s Upgrade Summary", cases.Title(language.English).String(options.ReleaseTypeName), terminal.WithHeaderStyle())
 
 	chart, err := options.HelmActionsRunner.LoadChart(options.EmbeddedChart, options.ChartDirName)
 	if err != nil {
-		return err
+		return nil, err
 	}
 	options.UI.Output("Downloaded charts.", terminal.WithSuccessStyle())
 
 	currentChartValues, err := FetchChartValues(options.HelmActionsRunner,
 		options.Namespace, options.ReleaseName, options.Settings, options.UILogger)
 	if err != nil {
-		return err
+		return nil, err
 	}
 
 	// Print out the upgrade summary.
 	if err = printDiff(currentChartValues, options.Values, options.UI); err != nil {
 		options.UI.Output("Could not print the different between current and upgraded charts: %v", err, terminal.WithErrorStyle())
-		return err
+		return nil, err
 	}
 
 	// Check if the user is OK with the upgrade unless the auto approve or dry run flags are true.
@@ -90,13 +92,13 @@ func UpgradeHelmRelease(options *UpgradeOptions) error {
 		})
 
 		if err != nil {
-			return err
+			return nil, err
 		}
 		// The upgrade will proceed if the user presses enter or responds with "y"/"yes" (case-insensitive).
 		if confirmation != "" && common.Abort(confirmation) {
 			options.UI.Output("Upgrade aborted. Use the command `consul-k8s upgrade -help` to learn how to customize your upgrade.",
 				terminal.WithInfoStyle())
-			return err
+			return nil, err
 		}
 	}
 
@@ -104,14 +106,14 @@ func UpgradeHelmRelease(options *UpgradeOptions) error {
 		options.UI.Output("Upgrading %s", options.ReleaseTypeName, terminal.WithHeaderStyle())
 	} else {
 		options.UI.Output("Performing Dry Run Upgrade", terminal.WithHeaderStyle())
-		return nil
+		return nil, nil
 	}
 
 	// Setup action configuration for Helm Go SDK function calls.
 	actionConfig := new(action.Configuration)
 	actionConfig, err = InitActionConfig(actionConfig, options.Namespace, options.Settings, options.UILogger)
 	if err != nil {
-		return err
+		return nil, err
 	}
 
 	// Setup the upgrade action.
@@ -122,12 +124,12 @@ func UpgradeHelmRelease(options *UpgradeOptions) error {
 	upgrade.Timeout = options.Timeout
 
 	// Run the upgrade. Note that the dry run config is passed into the upgrade action, so upgrade.Run is called even during a dry run.
-	_, err = options.HelmActionsRunner.Upgrade(upgrade, options.ReleaseName, chart, options.Values)
+	rel, err := options.HelmActionsRunner.Upgrade(upgrade, options.ReleaseName, chart, options.Values)
 	if err != nil {
-		return err
+		return nil, err
 	}
 	options.UI.Output("%s upgraded in namespace %q.", cases.Title(language.English).String(options.ReleaseTypeName), options.Namespace, terminal.WithSuccessStyle())
-	return nil
+	return rel, nil
 }
 
 // printDiff marshals both maps to YAML and prints the diff between the two.
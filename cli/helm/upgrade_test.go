@@ -46,7 +46,7 @@ func TestUpgrade(t *testing.T) {
 		"\n==>  Upgrade Summary\n ✓ Downloaded charts.\n    \n    Difference between user overrides for current and upgraded charts\n    -----------------------------------------------------------------\n  \n",
 		"\n==> Upgrading \n ✓  upgraded in namespace \"consul-namespace\".\n",
 	}
-	err := UpgradeHelmRelease(options)
+	_, err := UpgradeHelmRelease(options)
 	require.NoError(t, err)
 	output := buf.String()
 	for _, msg := range expectedMessages {
@@ -105,7 +105,7 @@ func TestUpgradeHelmRelease(t *testing.T) {
 				Settings:          helmCLI.New(),
 				AutoApprove:       true,
 			}
-			err := UpgradeHelmRelease(options)
+			_, err := UpgradeHelmRelease(options)
 			if tc.expectError {
 				require.Error(t, err)
 			} else {
@@ -151,7 +151,8 @@ type Resources struct {
 }
 
 type Openshift struct {
-	Enabled bool `yaml:"enabled"`
+	Enabled    bool `yaml:"enabled"`
+	AutoDetect bool `yaml:"autoDetect"`
 }
 
 type Global struct {
@@ -383,6 +384,7 @@ type SyncCatalog struct {
 	PriorityClassName     string           `yaml:"priorityClassName"`
 	ToConsul              bool             `yaml:"toConsul"`
 	ToK8S                 bool             `yaml:"toK8S"`
+	ToK8SEndpointSlices   bool             `yaml:"toK8SEndpointSlices"`
 	K8SPrefix             interface{}      `yaml:"k8sPrefix"`
 	K8SAllowNamespaces    []string         `yaml:"k8sAllowNamespaces"`
 	K8SDenyNamespaces     []string         `yaml:"k8sDenyNamespaces"`
@@ -410,6 +412,10 @@ type TransparentProxy struct {
 	DefaultOverwriteProbes bool `yaml:"defaultOverwriteProbes"`
 }
 
+type ArgoRollouts struct {
+	Enabled bool `yaml:"enabled"`
+}
+
 type Metrics struct {
 	DefaultEnabled              bool   `yaml:"defaultEnabled"`
 	DefaultEnableMerging        bool   `yaml:"defaultEnableMerging"`
@@ -424,8 +430,9 @@ type ACLInjectToken struct {
 }
 
 type SidecarProxy struct {
-	Resources Resources `yaml:"resources"`
-	Lifecycle Lifecycle `yaml:"lifecycle"`
+	Resources      Resources   `yaml:"resources"`
+	Lifecycle      Lifecycle   `yaml:"lifecycle"`
+	ImageOverrides interface{} `yaml:"imageOverrides"`
 }
 
 type InitContainer struct {
@@ -443,31 +450,36 @@ type Lifecycle struct {
 }
 
 type ConnectInject struct {
-	Enabled                bool             `yaml:"enabled"`
-	Replicas               int              `yaml:"replicas"`
-	Image                  interface{}      `yaml:"image"`
-	Default                bool             `yaml:"default"`
-	TransparentProxy       TransparentProxy `yaml:"transparentProxy"`
-	Metrics                Metrics          `yaml:"metrics"`
-	EnvoyExtraArgs         interface{}      `yaml:"envoyExtraArgs"`
-	PriorityClassName      string           `yaml:"priorityClassName"`
-	ImageConsul            interface{}      `yaml:"imageConsul"`
-	LogLevel               string           `yaml:"logLevel"`
-	ServiceAccount         ServiceAccount   `yaml:"serviceAccount"`
-	Resources              Resources        `yaml:"resources"`
-	FailurePolicy          string           `yaml:"failurePolicy"`
-	NamespaceSelector      string           `yaml:"namespaceSelector"`
-	K8SAllowNamespaces     []string         `yaml:"k8sAllowNamespaces"`
-	K8SDenyNamespaces      []interface{}    `yaml:"k8sDenyNamespaces"`
-	ConsulNamespaces       ConsulNamespaces `yaml:"consulNamespaces"`
-	NodeSelector           interface{}      `yaml:"nodeSelector"`
-	Affinity               interface{}      `yaml:"affinity"`
-	Tolerations            interface{}      `yaml:"tolerations"`
-	ACLBindingRuleSelector string           `yaml:"aclBindingRuleSelector"`
-	OverrideAuthMethodName string           `yaml:"overrideAuthMethodName"`
-	ACLInjectToken         ACLInjectToken   `yaml:"aclInjectToken"`
-	SidecarProxy           SidecarProxy     `yaml:"sidecarProxy"`
-	InitContainer          InitContainer    `yaml:"initContainer"`
+	Enabled                    bool             `yaml:"enabled"`
+	Replicas                   int              `yaml:"replicas"`
+	Image                      interface{}      `yaml:"image"`
+	Default                    bool             `yaml:"default"`
+	TransparentProxy           TransparentProxy `yaml:"transparentProxy"`
+	EmitDeregistrationEvents   bool             `yaml:"emitDeregistrationEvents"`
+	ArgoRollouts               ArgoRollouts     `yaml:"argoRollouts"`
+	Metrics                    Metrics          `yaml:"metrics"`
+	EnvoyExtraArgs             interface{}      `yaml:"envoyExtraArgs"`
+	MaxUpstreams               int              `yaml:"maxUpstreams"`
+	MaxUpstreamsAnnotationSize int              `yaml:"maxUpstreamsAnnotationSize"`
+	PriorityClassName          string           `yaml:"priorityClassName"`
+	ImageConsul                interface{}      `yaml:"imageConsul"`
+	LogLevel                   string           `yaml:"logLevel"`
+	ServiceAccount             ServiceAccount   `yaml:"serviceAccount"`
+	Resources                  Resources        `yaml:"resources"`
+	FailurePolicy              string           `yaml:"failurePolicy"`
+	NamespaceSelector          string           `yaml:"namespaceSelector"`
+	K8SAllowNamespaces         []string         `yaml:"k8sAllowNamespaces"`
+	K8SDenyNamespaces          []interface{}    `yaml:"k8sDenyNamespaces"`
+	ConsulNamespaces           ConsulNamespaces `yaml:"consulNamespaces"`
+	PartitionMapping           interface{}      `yaml:"partitionMapping"`
+	NodeSelector               interface{}      `yaml:"nodeSelector"`
+	Affinity                   interface{}      `yaml:"affinity"`
+	Tolerations                interface{}      `yaml:"tolerations"`
+	ACLBindingRuleSelector     string           `yaml:"aclBindingRuleSelector"`
+	OverrideAuthMethodName     string           `yaml:"overrideAuthMethodName"`
+	ACLInjectToken             ACLInjectToken   `yaml:"aclInjectToken"`
+	SidecarProxy               SidecarProxy     `yaml:"sidecarProxy"`
+	InitContainer              InitContainer    `yaml:"initContainer"`
 }
 
 type ACLToken struct {
@@ -7,10 +7,10 @@ import "github.com/hashicorp/consul-k8s/cli/config"
 
 // DemoPreset struct is an implementation of the Preset interface that provides
 // a Helm values map that is used during installation and represents the
-// the quickstart configuration for Consul on Kubernetes.
+// the demo configuration for Consul on Kubernetes.
 type DemoPreset struct{}
 
-// GetValueMap returns the Helm value map representing the quickstart
+// GetValueMap returns the Helm value map representing the demo
 // configuration for Consul on Kubernetes. It does the following:
 // - server replicas equal to 1.
 // - enables the service mesh.
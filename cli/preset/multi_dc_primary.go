@@ -0,0 +1,49 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package preset
+
+import "github.com/hashicorp/consul-k8s/cli/config"
+
+// MultiDCPrimaryPreset struct is an implementation of the Preset interface that provides
+// a Helm values map that is used during installation and represents the
+// configuration of the primary datacenter of a WAN federation via mesh gateways.
+type MultiDCPrimaryPreset struct{}
+
+// GetValueMap returns the Helm value map representing the primary datacenter
+// configuration for Consul on Kubernetes. It does the following:
+// - server replicas equal to 1.
+// - enables the service mesh.
+// - enables tls.
+// - enables gossip encryption.
+// - enables ACLs and creates the replication token for secondary datacenters.
+// - enables federation and creates the federation secret that is imported
+// into secondary datacenters.
+// - enables mesh gateways.
+func (i *MultiDCPrimaryPreset) GetValueMap() (map[string]interface{}, error) {
+	values := `
+global:
+  name: consul
+  datacenter: dc1
+  gossipEncryption:
+    autoGenerate: true
+  tls:
+    enabled: true
+    enableAutoEncrypt: true
+  acls:
+    manageSystemACLs: true
+    createReplicationToken: true
+  federation:
+    enabled: true
+    createFederationSecret: true
+server:
+  replicas: 1
+connectInject:
+  enabled: true
+meshGateway:
+  enabled: true
+  replicas: 1
+`
+
+	return config.ConvertToMap(values), nil
+}
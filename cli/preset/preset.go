@@ -9,9 +9,11 @@ import (
 )
 
 const (
-	PresetSecure     = "secure"
-	PresetQuickstart = "quickstart"
-	PresetCloud      = "cloud"
+	PresetSecure         = "secure"
+	PresetQuickstart     = "quickstart"
+	PresetCloud          = "cloud"
+	PresetDemo           = "demo"
+	PresetMultiDCPrimary = "multi-dc-primary"
 
 	EnvHCPClientID     = "HCP_CLIENT_ID"
 	EnvHCPClientSecret = "HCP_CLIENT_SECRET"
@@ -22,7 +24,7 @@ const (
 
 // Presets is a list of all the available presets for use with CLI's install
 // and uninstall commands.
-var Presets = []string{PresetCloud, PresetQuickstart, PresetSecure}
+var Presets = []string{PresetCloud, PresetDemo, PresetMultiDCPrimary, PresetQuickstart, PresetSecure}
 
 // Preset is the interface that each instance must implement.  For demo and
 // secure presets, they merely return a pre-configred value map.  For cloud,
@@ -49,6 +51,10 @@ func GetPreset(config *GetPresetConfig) (Preset, error) {
 		return &QuickstartPreset{}, nil
 	case PresetSecure:
 		return &SecurePreset{}, nil
+	case PresetDemo:
+		return &DemoPreset{}, nil
+	case PresetMultiDCPrimary:
+		return &MultiDCPrimaryPreset{}, nil
 	}
 	return nil, fmt.Errorf("'%s' is not a valid preset", config.Name)
 }
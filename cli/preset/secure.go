@@ -7,14 +7,14 @@ import "github.com/hashicorp/consul-k8s/cli/config"
 
 // SecurePreset struct is an implementation of the Preset interface that provides
 // a Helm values map that is used during installation and represents the
-// the quickstart configuration for Consul on Kubernetes.
+// the secure configuration for Consul on Kubernetes.
 type SecurePreset struct{}
 
-// GetValueMap returns the Helm value map representing the quickstart
+// GetValueMap returns the Helm value map representing the secure
 // configuration for Consul on Kubernetes. It does the following:
 // - server replicas equal to 1.
 // - enables the service mesh.
-// - enables tls.
+// - enables tls, for the HTTPS and gRPC ports.
 // - enables gossip encryption.
 // - enables ACLs.
 func (i *SecurePreset) GetValueMap() (map[string]interface{}, error) {
@@ -22,7 +22,7 @@ func (i *SecurePreset) GetValueMap() (map[string]interface{}, error) {
 global:
   name: consul
   gossipEncryption:
-    autoGenerate: true 
+    autoGenerate: true
   tls:
     enabled: true
     enableAutoEncrypt: true
@@ -0,0 +1,219 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package release
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"os/user"
+	"sort"
+	"strings"
+	"time"
+
+	"helm.sh/helm/v3/pkg/chartutil"
+	helmCLI "helm.sh/helm/v3/pkg/cli"
+	helmRelease "helm.sh/helm/v3/pkg/release"
+	"helm.sh/helm/v3/pkg/releaseutil"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+	"sigs.k8s.io/yaml"
+
+	"github.com/hashicorp/consul-k8s/cli/common"
+)
+
+const (
+	// ReceiptActionInstall and ReceiptActionUpgrade are the actions that are
+	// recorded in a receipt.
+	ReceiptActionInstall = "install"
+	ReceiptActionUpgrade = "upgrade"
+
+	// receiptComponent is the value of the component label on receipt ConfigMaps.
+	receiptComponent = "install-receipt"
+	// receiptDataKey is the key in the ConfigMap data that holds the receipt.
+	receiptDataKey = "receipt.yaml"
+)
+
+// Receipt records what was applied to a cluster by an install or upgrade so that
+// it can be inspected later, e.g. during an audit or a support escalation.
+type Receipt struct {
+	// Action is either install or upgrade.
+	Action string `json:"action"`
+	// ReleaseName is the name of the Helm release.
+	ReleaseName string `json:"releaseName"`
+	// Namespace is the Kubernetes namespace of the Helm release.
+	Namespace string `json:"namespace"`
+	// Revision is the Helm revision created by the install or upgrade.
+	Revision int `json:"revision"`
+	// ChartVersion is the version of the Consul Helm chart.
+	ChartVersion string `json:"chartVersion"`
+	// Images maps Helm value paths, such as "global.image", to the image
+	// references that were deployed. Images installed from an air-gapped
+	// bundle are pinned to a digest.
+	Images map[string]string `json:"images,omitempty"`
+	// ValuesHash is the sha256 hash of the user supplied Helm values.
+	ValuesHash string `json:"valuesHash"`
+	// CRDVersions maps the names of the installed custom resource definitions
+	// to the API versions they serve.
+	CRDVersions map[string][]string `json:"crdVersions,omitempty"`
+	// Timestamp is when the install or upgrade completed.
+	Timestamp time.Time `json:"timestamp"`
+	// Operator identifies who ran the install or upgrade.
+	Operator string `json:"operator"`
+}
+
+// NewReceipt creates a receipt for a Helm release that has just been installed or upgraded.
+func NewReceipt(action string, rel *helmRelease.Release, operator string, now time.Time) (*Receipt, error) {
+	valuesYaml, err := yaml.Marshal(rel.Config)
+	if err != nil {
+		return nil, fmt.Errorf("error marshalling values: %w", err)
+	}
+	valuesHash := sha256.Sum256(valuesYaml)
+
+	receipt := &Receipt{
+		Action:      action,
+		ReleaseName: rel.Name,
+		Namespace:   rel.Namespace,
+		Revision:    rel.Version,
+		ValuesHash:  "sha256:" + hex.EncodeToString(valuesHash[:]),
+		Timestamp:   now.UTC(),
+		Operator:    operator,
+	}
+
+	// The deployed images are the chart defaults overridden by the user supplied values.
+	vals := rel.Config
+	if rel.Chart != nil && rel.Chart.Metadata != nil {
+		receipt.ChartVersion = rel.Chart.Metadata.Version
+		vals, err = chartutil.CoalesceValues(rel.Chart, rel.Config)
+		if err != nil {
+			return nil, fmt.Errorf("error merging values: %w", err)
+		}
+	}
+	receipt.Images = make(map[string]string)
+	collectImages("", vals, receipt.Images)
+
+	receipt.CRDVersions, err = crdVersions(rel.Manifest)
+	if err != nil {
+		return nil, err
+	}
+	return receipt, nil
+}
+
+// collectImages adds every non-empty image value under vals to images, keyed by its value path.
+func collectImages(prefix string, vals map[string]interface{}, images map[string]string) {
+	for key, val := range vals {
+		path := key
+		if prefix != "" {
+			path = prefix + "." + key
+		}
+		switch v := val.(type) {
+		case map[string]interface{}:
+			collectImages(path, v, images)
+		case string:
+			if strings.HasPrefix(key, "image") && !strings.HasPrefix(key, "imagePull") && v != "" {
+				images[path] = v
+			}
+		}
+	}
+}
+
+// crdVersions returns the served versions of each custom resource definition in a release manifest.
+func crdVersions(manifest string) (map[string][]string, error) {
+	versions := make(map[string][]string)
+	for _, doc := range releaseutil.SplitManifests(manifest) {
+		var crd struct {
+			Kind     string `json:"kind"`
+			Metadata struct {
+				Name string `json:"name"`
+			} `json:"metadata"`
+			Spec struct {
+				Versions []struct {
+					Name   string `json:"name"`
+					Served bool   `json:"served"`
+				} `json:"versions"`
+			} `json:"spec"`
+		}
+		if err := yaml.Unmarshal([]byte(doc), &crd); err != nil {
+			return nil, fmt.Errorf("error parsing release manifest: %w", err)
+		}
+		if crd.Kind != "CustomResourceDefinition" {
+			continue
+		}
+		for _, v := range crd.Spec.Versions {
+			if v.Served {
+				versions[crd.Metadata.Name] = append(versions[crd.Metadata.Name], v.Name)
+			}
+		}
+	}
+	return versions, nil
+}
+
+// SaveReceipt stores a receipt in a ConfigMap in the namespace of its release.
+func SaveReceipt(ctx context.Context, client kubernetes.Interface, receipt *Receipt) error {
+	data, err := yaml.Marshal(receipt)
+	if err != nil {
+		return fmt.Errorf("error marshalling receipt: %w", err)
+	}
+
+	cm := &corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      fmt.Sprintf("%s-%s-%d", receipt.ReleaseName, receiptComponent, receipt.Timestamp.Unix()),
+			Namespace: receipt.Namespace,
+			Labels: map[string]string{
+				"release":          receipt.ReleaseName,
+				"component":        receiptComponent,
+				common.CLILabelKey: common.CLILabelValue,
+			},
+		},
+		Data: map[string]string{receiptDataKey: string(data)},
+	}
+	if _, err := client.CoreV1().ConfigMaps(receipt.Namespace).Create(ctx, cm, metav1.CreateOptions{}); err != nil {
+		return fmt.Errorf("error saving receipt: %w", err)
+	}
+	return nil
+}
+
+// ListReceipts returns the receipts in a namespace, or in all namespaces if namespace
+// is empty, ordered from oldest to newest.
+func ListReceipts(ctx context.Context, client kubernetes.Interface, namespace string) ([]Receipt, error) {
+	cms, err := client.CoreV1().ConfigMaps(namespace).List(ctx, metav1.ListOptions{
+		LabelSelector: fmt.Sprintf("component=%s,%s=%s", receiptComponent, common.CLILabelKey, common.CLILabelValue),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("error listing receipts: %w", err)
+	}
+
+	receipts := make([]Receipt, 0, len(cms.Items))
+	for _, cm := range cms.Items {
+		var receipt Receipt
+		if err := yaml.Unmarshal([]byte(cm.Data[receiptDataKey]), &receipt); err != nil {
+			return nil, fmt.Errorf("error parsing receipt %s/%s: %w", cm.Namespace, cm.Name, err)
+		}
+		receipts = append(receipts, receipt)
+	}
+	sort.SliceStable(receipts, func(i, j int) bool {
+		return receipts[i].Timestamp.Before(receipts[j].Timestamp)
+	})
+	return receipts, nil
+}
+
+// OperatorIdentity returns the Kubernetes user of the current kube context, falling back
+// to the local user if it cannot be determined.
+func OperatorIdentity(settings *helmCLI.EnvSettings) string {
+	if rawConfig, err := settings.RESTClientGetter().ToRawKubeConfigLoader().RawConfig(); err == nil {
+		contextName := settings.KubeContext
+		if contextName == "" {
+			contextName = rawConfig.CurrentContext
+		}
+		if kubeContext, ok := rawConfig.Contexts[contextName]; ok && kubeContext.AuthInfo != "" {
+			return kubeContext.AuthInfo
+		}
+	}
+	if u, err := user.Current(); err == nil {
+		return u.Username
+	}
+	return "unknown"
+}
@@ -0,0 +1,109 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package release
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"helm.sh/helm/v3/pkg/chart"
+	helmRelease "helm.sh/helm/v3/pkg/release"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+const testManifest = `---
+# Source: consul/templates/crd-meshes.yaml
+apiVersion: apiextensions.k8s.io/v1
+kind: CustomResourceDefinition
+metadata:
+  name: meshes.consul.hashicorp.com
+spec:
+  versions:
+  - name: v1alpha1
+    served: true
+  - name: v1alpha0
+    served: false
+---
+# Source: consul/templates/server-config-configmap.yaml
+apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: consul-server-config
+`
+
+func TestNewReceipt(t *testing.T) {
+	now := time.Date(2024, 5, 1, 10, 0, 0, 0, time.UTC)
+	rel := &helmRelease.Release{
+		Name:      "consul",
+		Namespace: "consul",
+		Version:   2,
+		Chart: &chart.Chart{
+			Metadata: &chart.Metadata{Name: "consul", Version: "1.6.0"},
+			Values: map[string]interface{}{
+				"global": map[string]interface{}{
+					"image":           "hashicorp/consul:1.20.0",
+					"imageK8S":        "hashicorp/consul-k8s-control-plane:1.6.0",
+					"imagePullPolicy": "IfNotPresent",
+				},
+				"client": map[string]interface{}{
+					"image": nil,
+				},
+			},
+		},
+		Config: map[string]interface{}{
+			"global": map[string]interface{}{
+				"image": "mirror.example.com/hashicorp/consul:1.20.1",
+			},
+		},
+		Manifest: testManifest,
+	}
+
+	receipt, err := NewReceipt(ReceiptActionUpgrade, rel, "admin", now)
+	require.NoError(t, err)
+	require.Equal(t, &Receipt{
+		Action:       ReceiptActionUpgrade,
+		ReleaseName:  "consul",
+		Namespace:    "consul",
+		Revision:     2,
+		ChartVersion: "1.6.0",
+		Images: map[string]string{
+			"global.image":    "mirror.example.com/hashicorp/consul:1.20.1",
+			"global.imageK8S": "hashicorp/consul-k8s-control-plane:1.6.0",
+		},
+		ValuesHash:  "sha256:d02a3143a59c79a3612bd0c3aab496f11dd7f4fc2a32c9d1cb986c01dc71a24a",
+		CRDVersions: map[string][]string{"meshes.consul.hashicorp.com": {"v1alpha1"}},
+		Timestamp:   now,
+		Operator:    "admin",
+	}, receipt)
+}
+
+func TestSaveAndListReceipts(t *testing.T) {
+	ctx := context.Background()
+	client := fake.NewSimpleClientset()
+
+	first := &Receipt{Action: ReceiptActionInstall, ReleaseName: "consul", Namespace: "consul", Revision: 1,
+		Timestamp: time.Date(2024, 5, 1, 10, 0, 0, 0, time.UTC)}
+	second := &Receipt{Action: ReceiptActionUpgrade, ReleaseName: "consul", Namespace: "consul", Revision: 2,
+		Timestamp: time.Date(2024, 5, 2, 10, 0, 0, 0, time.UTC)}
+	other := &Receipt{Action: ReceiptActionInstall, ReleaseName: "consul", Namespace: "other", Revision: 1,
+		Timestamp: time.Date(2024, 4, 1, 10, 0, 0, 0, time.UTC)}
+	for _, r := range []*Receipt{second, first, other} {
+		require.NoError(t, SaveReceipt(ctx, client, r))
+	}
+
+	cm, err := client.CoreV1().ConfigMaps("consul").Get(ctx, "consul-install-receipt-1714557600", metav1.GetOptions{})
+	require.NoError(t, err)
+	require.Equal(t, "consul-k8s", cm.Labels["managed-by"])
+
+	receipts, err := ListReceipts(ctx, client, "consul")
+	require.NoError(t, err)
+	require.Equal(t, []Receipt{*first, *second}, receipts)
+
+	receipts, err = ListReceipts(ctx, client, "")
+	require.NoError(t, err)
+	require.Equal(t, []Receipt{*other, *first, *second}, receipts)
+}
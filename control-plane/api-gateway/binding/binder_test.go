@@ -3134,11 +3134,14 @@ func controlledBinder(config BinderConfig) BinderConfig {
 }
 
 func generateTestCertificate(t *testing.T, namespace, name string) (*api.FileSystemCertificateConfigEntry, corev1.Secret) {
+	return generateTestCertificateWithExpiration(t, namespace, name, time.Now().AddDate(10, 0, 0))
+}
+
+func generateTestCertificateWithExpiration(t *testing.T, namespace, name string, expiration time.Time) (*api.FileSystemCertificateConfigEntry, corev1.Secret) {
 	privateKey, err := rsa.GenerateKey(rand.Reader, common.MinKeyLength)
 	require.NoError(t, err)
 
 	usage := x509.KeyUsageCertSign
-	expiration := time.Now().AddDate(10, 0, 0)
 
 	cert := &x509.Certificate{
 		SerialNumber: big.NewInt(1),
@@ -52,7 +52,7 @@ func registrationForPod(metrics gatewaycommon.MetricsConfig, namespace string, g
 	}
 
 	return api.CatalogRegistration{
-		Node:    common.ConsulNodeNameFromK8sNode(pod.Spec.NodeName),
+		Node:    common.PodConsulNodeName(pod),
 		Address: pod.Status.HostIP,
 		NodeMeta: map[string]string{
 			metaKeySyntheticNode: "true",
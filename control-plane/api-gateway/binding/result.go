@@ -241,6 +241,7 @@ var (
 	errListenerInvalidCertificateRef_NotFound         = errors.New("certificate not found")
 	errListenerInvalidCertificateRef_NotSupported     = errors.New("certificate type is not supported")
 	errListenerInvalidCertificateRef_InvalidData      = errors.New("certificate is invalid or does not contain a supported server name")
+	errListenerInvalidCertificateRef_Expired          = errors.New("certificate has expired")
 	errListenerInvalidCertificateRef_NonFIPSRSAKeyLen = errors.New("certificate has an invalid length: RSA Keys must be at least 2048-bit")
 	errListenerInvalidCertificateRef_FIPSRSAKeyLen    = errors.New("certificate has an invalid length: RSA keys must be either 2048-bit, 3072-bit, or 4096-bit in FIPS mode")
 	errListenerJWTProviderNotFound                    = errors.New("policy referencing this listener references unknown JWT provider")
@@ -407,6 +408,7 @@ func (l listenerValidationResult) resolvedRefsConditions(generation int64) []met
 		case errListenerInvalidCertificateRef_NotFound,
 			errListenerInvalidCertificateRef_NotSupported,
 			errListenerInvalidCertificateRef_InvalidData,
+			errListenerInvalidCertificateRef_Expired,
 			errListenerInvalidCertificateRef_NonFIPSRSAKeyLen,
 			errListenerInvalidCertificateRef_FIPSRSAKeyLen:
 			conditions = append(conditions, metav1.Condition{
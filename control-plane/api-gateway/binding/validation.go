@@ -415,6 +415,14 @@ func validateCertificateData(secret corev1.Secret) error {
 		return errListenerInvalidCertificateRef_InvalidData
 	}
 
+	notAfter, err := common.CertificateNotAfter(secret)
+	if err != nil {
+		return errListenerInvalidCertificateRef_InvalidData
+	}
+	if !timeFunc().Time.Before(notAfter) {
+		return errListenerInvalidCertificateRef_Expired
+	}
+
 	err = common.ValidateKeyLength(privateKey)
 	if err != nil {
 		if version.IsFIPS() {
@@ -595,7 +603,7 @@ func checkIfReferencesMissingJWTProvider(filter gwv1beta1.HTTPRouteFilter, resou
 		return
 	}
 	authFilter, ok := externalFilter.(*v1alpha1.RouteAuthFilter)
-	if !ok {
+	if !ok || authFilter.Spec.JWT == nil {
 		return
 	}
 
@@ -714,9 +722,12 @@ func validateAuthFilters(authFilters []*v1alpha1.RouteAuthFilter, resources *com
 		}
 		var result authFilterValidationResult
 		missingJWTProviders := make([]string, 0)
-		for _, provider := range filter.Spec.JWT.Providers {
-			if _, ok := resources.GetJWTProviderForGatewayJWTProvider(provider); !ok {
-				missingJWTProviders = append(missingJWTProviders, provider.Name)
+		// A filter without JWT requirements doesn't reference any provider.
+		if filter.Spec.JWT != nil {
+			for _, provider := range filter.Spec.JWT.Providers {
+				if _, ok := resources.GetJWTProviderForGatewayJWTProvider(provider); !ok {
+					missingJWTProviders = append(missingJWTProviders, provider.Name)
+				}
 			}
 		}
 
@@ -6,6 +6,7 @@ package binding
 import (
 	"fmt"
 	"testing"
+	"time"
 
 	logrtest "github.com/go-logr/logr/testing"
 	"github.com/stretchr/testify/require"
@@ -569,6 +570,26 @@ func TestValidateTLS(t *testing.T) {
 	}
 }
 
+// TestValidateCertificateData_Expired is not run in parallel since it overrides the time
+// that the binding tests otherwise set to the zero time.
+func TestValidateCertificateData_Expired(t *testing.T) {
+	prevTimeFunc := timeFunc
+	t.Cleanup(func() { timeFunc = prevTimeFunc })
+	timeFunc = metav1.Now
+
+	_, valid := generateTestCertificateWithExpiration(t, "default", "valid", time.Now().Add(time.Hour))
+	require.NoError(t, validateCertificateData(valid))
+
+	_, expired := generateTestCertificateWithExpiration(t, "default", "expired", time.Now().Add(-time.Minute))
+	require.ErrorIs(t, validateCertificateData(expired), errListenerInvalidCertificateRef_Expired)
+
+	conditions := listenerValidationResult{refErrs: []error{errListenerInvalidCertificateRef_Expired}}.resolvedRefsConditions(1)
+	require.Len(t, conditions, 1)
+	require.Equal(t, metav1.ConditionFalse, conditions[0].Status)
+	require.Equal(t, "InvalidCertificateRef", conditions[0].Reason)
+	require.Equal(t, "certificate has expired", conditions[0].Message)
+}
+
 func TestValidateListeners(t *testing.T) {
 	t.Parallel()
 
@@ -1565,9 +1586,69 @@ func TestValidateAuthFilters(t *testing.T) {
 				},
 			},
 		},
+		"auth filter without JWT requirements": {
+			authFilters: []*v1alpha1.RouteAuthFilter{
+				{
+					Spec: v1alpha1.RouteAuthFilterSpec{},
+				},
+			},
+			resources: newTestResourceMap(t, resourceMapResources{}),
+			expected:  authFilterValidationResults{authFilterValidationResult{}},
+		},
 	} {
 		t.Run(name, func(t *testing.T) {
 			require.Equal(t, tc.expected, validateAuthFilters(tc.authFilters, tc.resources))
 		})
 	}
 }
+
+func TestAuthFilterReferencesMissingJWTProvider(t *testing.T) {
+	authFilter := func(name string, jwt *v1alpha1.GatewayJWTRequirement) *v1alpha1.RouteAuthFilter {
+		return &v1alpha1.RouteAuthFilter{
+			TypeMeta:   metav1.TypeMeta{Kind: v1alpha1.RouteAuthFilterKind},
+			ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "default"},
+			Spec:       v1alpha1.RouteAuthFilterSpec{JWT: jwt},
+		}
+	}
+	extensionRef := func(name string) gwv1beta1.HTTPRouteFilter {
+		return gwv1beta1.HTTPRouteFilter{
+			Type: gwv1beta1.HTTPRouteFilterExtensionRef,
+			ExtensionRef: &gwv1beta1.LocalObjectReference{
+				Group: gwv1beta1.Group(v1alpha1.ConsulHashicorpGroup),
+				Kind:  gwv1beta1.Kind(v1alpha1.RouteAuthFilterKind),
+				Name:  gwv1beta1.ObjectName(name),
+			},
+		}
+	}
+
+	resources := newTestResourceMap(t, resourceMapResources{
+		jwtProviders: []*v1alpha1.JWTProvider{
+			{
+				TypeMeta:   metav1.TypeMeta{Kind: "JWTProvider"},
+				ObjectMeta: metav1.ObjectMeta{Name: "okta"},
+				Spec:       v1alpha1.JWTProviderSpec{Issuer: "okta"},
+			},
+		},
+		externalAuthFilters: []*v1alpha1.RouteAuthFilter{
+			authFilter("valid", &v1alpha1.GatewayJWTRequirement{Providers: []*v1alpha1.GatewayJWTProvider{{Name: "okta"}}}),
+			authFilter("missing-provider", &v1alpha1.GatewayJWTRequirement{Providers: []*v1alpha1.GatewayJWTProvider{{Name: "auth0"}}}),
+			authFilter("no-jwt", nil),
+		},
+	})
+
+	route := &gwv1beta1.HTTPRoute{
+		ObjectMeta: metav1.ObjectMeta{Name: "route", Namespace: "default"},
+		Spec: gwv1beta1.HTTPRouteSpec{
+			Rules: []gwv1beta1.HTTPRouteRule{
+				{
+					Filters: []gwv1beta1.HTTPRouteFilter{extensionRef("valid"), extensionRef("no-jwt")},
+					BackendRefs: []gwv1beta1.HTTPBackendRef{
+						{Filters: []gwv1beta1.HTTPRouteFilter{extensionRef("missing-provider")}},
+					},
+				},
+			},
+		},
+	}
+
+	require.Equal(t, []string{"default/missing-provider"}, authFilterReferencesMissingJWTProvider(route, resources))
+}
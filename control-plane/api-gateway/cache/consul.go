@@ -93,6 +93,12 @@ type Cache struct {
 	gatewayNameToACLBindingRule map[string]*api.ACLBindingRule
 	bindingRuleMutex            *sync.Mutex
 
+	// routeRateLimitServices are the service-defaults that hold route rate limits written
+	// by SyncRouteRateLimits, keyed by their normalized reference. It is nil until the
+	// service-defaults are listed by the first sync.
+	routeRateLimitServices map[api.ResourceReference]api.ResourceReference
+	routeRateLimitsMutex   *sync.Mutex
+
 	namespacesEnabled       bool
 	crossNamespaceACLPolicy string
 
@@ -125,6 +131,7 @@ func New(config Config) *Cache {
 		aclRoleMutex:                &sync.Mutex{},
 		gatewayNameToACLBindingRule: make(map[string]*api.ACLBindingRule),
 		bindingRuleMutex:            &sync.Mutex{},
+		routeRateLimitsMutex:        &sync.Mutex{},
 		kinds:                       Kinds,
 		synced:                      make(chan struct{}, len(Kinds)),
 		logger:                      config.Logger,
@@ -0,0 +1,226 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package cache
+
+import (
+	"context"
+
+	"golang.org/x/exp/slices"
+
+	"github.com/hashicorp/consul-k8s/control-plane/api-gateway/common"
+	apicommon "github.com/hashicorp/consul-k8s/control-plane/api/common"
+	"github.com/hashicorp/consul-k8s/control-plane/consul"
+	"github.com/hashicorp/consul-k8s/control-plane/namespaces"
+	"github.com/hashicorp/consul/api"
+)
+
+const (
+	// MetaKeyRouteRateLimits is the meta key of the service-defaults config entries
+	// whose route rate limits are managed by the gateway controller.
+	MetaKeyRouteRateLimits = apicommon.RouteRateLimitsKey
+
+	// routeRateLimitsCreated marks the service-defaults that were created by the
+	// gateway controller to hold route rate limits, these are deleted when their
+	// service isn't rate limited anymore. A ServiceDefaults resource for the service
+	// takes them over.
+	routeRateLimitsCreated = apicommon.RouteRateLimitsCreated
+
+	// routeRateLimitsManaged marks the existing service-defaults that the gateway
+	// controller added route rate limits to.
+	routeRateLimitsManaged = "managed"
+)
+
+// SyncRouteRateLimits writes the given instance-level route rate limits to the service-defaults
+// of the services they apply to. The route rate limits previously written to services that are
+// not part of limits anymore are removed. Service-defaults managed by ServiceDefaults resources
+// are left untouched since their controller owns the whole config entry: these services are
+// returned so that the limits that couldn't be applied are reported.
+//
+// The limits are per backend service: instance-level limits apply to all the inbound traffic of
+// the instances on the matched paths, not only the requests routed through the gateway. They are
+// only enforced for services with an HTTP-family protocol, which the created service-defaults
+// don't set so that the protocol set by the proxy-defaults applies.
+//
+// The service-defaults are listed once to find the ones that hold route rate limits. Afterwards,
+// only the service-defaults of the rate limited services and of the services that were rate
+// limited before are read.
+func (c *Cache) SyncRouteRateLimits(ctx context.Context, limits map[api.ResourceReference][]api.InstanceLevelRouteRateLimits) ([]api.ResourceReference, error) {
+	c.routeRateLimitsMutex.Lock()
+	defer c.routeRateLimitsMutex.Unlock()
+
+	// Avoid any request when there is nothing to add or remove.
+	if len(limits) == 0 && c.routeRateLimitServices != nil && len(c.routeRateLimitServices) == 0 {
+		return nil, nil
+	}
+
+	client, err := consul.NewClientFromConnMgr(c.config, c.serverMgr)
+	if err != nil {
+		return nil, err
+	}
+
+	if c.routeRateLimitServices == nil {
+		services, err := c.listRouteRateLimitServices(ctx, client)
+		if err != nil {
+			return nil, err
+		}
+		c.routeRateLimitServices = services
+	}
+
+	desired := make(map[api.ResourceReference]api.ResourceReference, len(limits))
+	for ref := range limits {
+		desired[common.NormalizeMeta(ref)] = ref
+	}
+
+	// Remove the limits of the services that aren't rate limited anymore.
+	for key, ref := range c.routeRateLimitServices {
+		if _, ok := desired[key]; ok {
+			continue
+		}
+		serviceDefaults, err := c.getServiceDefaults(ctx, client, ref)
+		if err != nil {
+			return nil, err
+		}
+		if serviceDefaults != nil {
+			if err := c.removeRouteRateLimits(ctx, client, serviceDefaults); err != nil {
+				return nil, err
+			}
+		}
+		delete(c.routeRateLimitServices, key)
+	}
+
+	var unapplied []api.ResourceReference
+	for key, ref := range desired {
+		serviceDefaults, err := c.getServiceDefaults(ctx, client, ref)
+		if err != nil {
+			return nil, err
+		}
+
+		// The service doesn't have service-defaults yet.
+		if serviceDefaults == nil {
+			serviceDefaults = &api.ServiceConfigEntry{
+				Kind:      api.ServiceDefaults,
+				Name:      ref.Name,
+				Namespace: ref.Namespace,
+				Partition: ref.Partition,
+				RateLimits: &api.RateLimits{
+					InstanceLevel: api.InstanceLevelRateLimits{Routes: limits[ref]},
+				},
+				Meta: map[string]string{
+					apicommon.SourceKey:    apicommon.SourceValue,
+					MetaKeyRouteRateLimits: routeRateLimitsCreated,
+				},
+			}
+			if err := c.writeServiceDefaults(ctx, client, serviceDefaults); err != nil {
+				return nil, err
+			}
+			c.routeRateLimitServices[key] = ref
+			continue
+		}
+
+		marker := serviceDefaults.Meta[MetaKeyRouteRateLimits]
+		if marker == "" && serviceDefaults.Meta[apicommon.SourceKey] == apicommon.SourceValue {
+			c.logger.Info("not setting route rate limits on service-defaults managed by a ServiceDefaults resource",
+				"namespace", serviceDefaults.Namespace, "name", serviceDefaults.Name)
+			unapplied = append(unapplied, ref)
+			continue
+		}
+
+		rateLimits := serviceDefaults.RateLimits
+		if rateLimits == nil {
+			rateLimits = &api.RateLimits{}
+		}
+		if marker != "" && slices.Equal(rateLimits.InstanceLevel.Routes, limits[ref]) {
+			c.routeRateLimitServices[key] = ref
+			continue
+		}
+		if marker == "" {
+			marker = routeRateLimitsManaged
+		}
+
+		rateLimits.InstanceLevel.Routes = limits[ref]
+		serviceDefaults.RateLimits = rateLimits
+		serviceDefaults.Meta = withMeta(serviceDefaults.Meta, MetaKeyRouteRateLimits, marker)
+		if err := c.writeServiceDefaults(ctx, client, serviceDefaults); err != nil {
+			return nil, err
+		}
+		c.routeRateLimitServices[key] = ref
+	}
+
+	return unapplied, nil
+}
+
+// listRouteRateLimitServices returns the service-defaults that hold route rate limits written by
+// SyncRouteRateLimits, keyed by their normalized reference.
+func (c *Cache) listRouteRateLimitServices(ctx context.Context, client *api.Client) (map[api.ResourceReference]api.ResourceReference, error) {
+	queryOptions := &api.QueryOptions{}
+	if c.namespacesEnabled {
+		queryOptions.Namespace = apicommon.WildcardNamespace
+	}
+	entries, _, err := client.ConfigEntries().List(api.ServiceDefaults, queryOptions.WithContext(ctx))
+	if err != nil {
+		return nil, err
+	}
+
+	services := make(map[api.ResourceReference]api.ResourceReference)
+	for _, entry := range entries {
+		if entry.GetMeta()[MetaKeyRouteRateLimits] == "" {
+			continue
+		}
+		ref := common.EntryToReference(entry)
+		services[common.NormalizeMeta(ref)] = ref
+	}
+	return services, nil
+}
+
+// getServiceDefaults returns the service-defaults of ref, or nil if there are none.
+func (c *Cache) getServiceDefaults(ctx context.Context, client *api.Client, ref api.ResourceReference) (*api.ServiceConfigEntry, error) {
+	options := &api.QueryOptions{Namespace: ref.Namespace, Partition: ref.Partition}
+	entry, _, err := client.ConfigEntries().Get(api.ServiceDefaults, ref.Name, options.WithContext(ctx))
+	if err != nil {
+		return nil, ignoreNotFound(err)
+	}
+	serviceDefaults, ok := entry.(*api.ServiceConfigEntry)
+	if !ok {
+		return nil, nil
+	}
+	return serviceDefaults, nil
+}
+
+// removeRouteRateLimits removes the route rate limits written by SyncRouteRateLimits from
+// serviceDefaults, deleting it if it was created for them.
+func (c *Cache) removeRouteRateLimits(ctx context.Context, client *api.Client, serviceDefaults *api.ServiceConfigEntry) error {
+	switch serviceDefaults.Meta[MetaKeyRouteRateLimits] {
+	case routeRateLimitsCreated:
+		options := &api.WriteOptions{Namespace: serviceDefaults.Namespace, Partition: serviceDefaults.Partition}
+		_, err := client.ConfigEntries().Delete(api.ServiceDefaults, serviceDefaults.Name, options.WithContext(ctx))
+		return ignoreNotFound(err)
+	case routeRateLimitsManaged:
+		if serviceDefaults.RateLimits != nil {
+			serviceDefaults.RateLimits.InstanceLevel.Routes = nil
+		}
+		delete(serviceDefaults.Meta, MetaKeyRouteRateLimits)
+		return c.writeServiceDefaults(ctx, client, serviceDefaults)
+	}
+	return nil
+}
+
+func (c *Cache) writeServiceDefaults(ctx context.Context, client *api.Client, serviceDefaults *api.ServiceConfigEntry) error {
+	if c.namespacesEnabled {
+		if _, err := namespaces.EnsureExists(client, serviceDefaults.Namespace, c.crossNamespaceACLPolicy); err != nil {
+			return err
+		}
+	}
+
+	options := &api.WriteOptions{}
+	_, _, err := client.ConfigEntries().Set(serviceDefaults, options.WithContext(ctx))
+	return err
+}
+
+func withMeta(meta map[string]string, key, value string) map[string]string {
+	if meta == nil {
+		meta = make(map[string]string)
+	}
+	meta[key] = value
+	return meta
+}
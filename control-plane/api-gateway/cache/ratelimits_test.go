@@ -0,0 +1,146 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package cache
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+
+	logrtest "github.com/go-logr/logr/testing"
+	"github.com/stretchr/testify/require"
+
+	"github.com/hashicorp/consul/api"
+
+	apicommon "github.com/hashicorp/consul-k8s/control-plane/api/common"
+	"github.com/hashicorp/consul-k8s/control-plane/consul"
+	"github.com/hashicorp/consul-k8s/control-plane/helper/test"
+)
+
+func TestCache_SyncRouteRateLimits(t *testing.T) {
+	t.Parallel()
+
+	var (
+		mu       sync.Mutex
+		lists    int
+		reads    = make(map[string]int)
+		defaults = map[string]*api.ServiceConfigEntry{
+			"crd": {
+				Kind: api.ServiceDefaults,
+				Name: "crd",
+				Meta: map[string]string{apicommon.SourceKey: apicommon.SourceValue},
+			},
+			"plain": {Kind: api.ServiceDefaults, Name: "plain", Protocol: "http"},
+			// Written by a previous run of the controller.
+			"stale": {
+				Kind: api.ServiceDefaults,
+				Name: "stale",
+				Meta: map[string]string{MetaKeyRouteRateLimits: routeRateLimitsCreated},
+			},
+			"unrelated": {Kind: api.ServiceDefaults, Name: "unrelated"},
+		}
+	)
+	consulServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+		name := strings.TrimPrefix(r.URL.Path, "/v1/config/service-defaults/")
+		switch {
+		case r.Method == http.MethodGet && r.URL.Path == "/v1/config/service-defaults":
+			lists++
+			entries := make([]*api.ServiceConfigEntry, 0, len(defaults))
+			for _, entry := range defaults {
+				entries = append(entries, entry)
+			}
+			require.NoError(t, json.NewEncoder(w).Encode(entries))
+		case r.Method == http.MethodGet && name != r.URL.Path:
+			reads[name]++
+			entry, ok := defaults[name]
+			if !ok {
+				w.WriteHeader(http.StatusNotFound)
+				return
+			}
+			require.NoError(t, json.NewEncoder(w).Encode(entry))
+		case r.Method == http.MethodPut && r.URL.Path == "/v1/config":
+			var entry api.ServiceConfigEntry
+			require.NoError(t, json.NewDecoder(r.Body).Decode(&entry))
+			defaults[entry.Name] = &entry
+			w.Write([]byte("true"))
+		case r.Method == http.MethodDelete && name != r.URL.Path:
+			delete(defaults, name)
+			w.Write([]byte("{}"))
+		default:
+			t.Errorf("unexpected request %s %s", r.Method, r.URL.Path)
+		}
+	}))
+	defer consulServer.Close()
+
+	serverURL, err := url.Parse(consulServer.URL)
+	require.NoError(t, err)
+	port, err := strconv.Atoi(serverURL.Port())
+	require.NoError(t, err)
+	c := New(Config{
+		ConsulClientConfig: &consul.Config{
+			APIClientConfig: &api.Config{},
+			HTTPPort:        port,
+			GRPCPort:        port,
+		},
+		ConsulServerConnMgr: test.MockConnMgrForIPAndPort(t, serverURL.Hostname(), port, false),
+		Logger:              logrtest.NewTestLogger(t),
+	})
+
+	ref := func(name string) api.ResourceReference {
+		return api.ResourceReference{Kind: api.ServiceDefaults, Name: name}
+	}
+	routes := []api.InstanceLevelRouteRateLimits{{PathPrefix: "/", RequestsPerSecond: 10}}
+	ctx := context.Background()
+
+	// The limits aren't applied to the service with a ServiceDefaults resource, and the
+	// limits written by the previous run are removed.
+	unapplied, err := c.SyncRouteRateLimits(ctx, map[api.ResourceReference][]api.InstanceLevelRouteRateLimits{
+		ref("web"):   routes,
+		ref("plain"): routes,
+		ref("crd"):   routes,
+	})
+	require.NoError(t, err)
+	require.Equal(t, []api.ResourceReference{ref("crd")}, unapplied)
+	mu.Lock()
+	require.Equal(t, routes, defaults["web"].RateLimits.InstanceLevel.Routes)
+	require.Equal(t, routeRateLimitsCreated, defaults["web"].Meta[MetaKeyRouteRateLimits])
+	require.Equal(t, routes, defaults["plain"].RateLimits.InstanceLevel.Routes)
+	require.Equal(t, routeRateLimitsManaged, defaults["plain"].Meta[MetaKeyRouteRateLimits])
+	require.Equal(t, "http", defaults["plain"].Protocol)
+	require.Nil(t, defaults["crd"].RateLimits)
+	require.NotContains(t, defaults, "stale")
+	mu.Unlock()
+
+	// Only the service-defaults of the rate limited services are read after the first sync.
+	unapplied, err = c.SyncRouteRateLimits(ctx, map[api.ResourceReference][]api.InstanceLevelRouteRateLimits{
+		ref("web"): routes,
+	})
+	require.NoError(t, err)
+	require.Empty(t, unapplied)
+	mu.Lock()
+	require.Nil(t, defaults["plain"].RateLimits.InstanceLevel.Routes)
+	require.NotContains(t, defaults["plain"].Meta, MetaKeyRouteRateLimits)
+	mu.Unlock()
+
+	_, err = c.SyncRouteRateLimits(ctx, nil)
+	require.NoError(t, err)
+	_, err = c.SyncRouteRateLimits(ctx, nil)
+	require.NoError(t, err)
+
+	mu.Lock()
+	defer mu.Unlock()
+	require.NotContains(t, defaults, "web")
+	require.Equal(t, 1, lists)
+	require.Equal(t, 0, reads["unrelated"])
+	require.Equal(t, 1, reads["crd"])
+	require.Equal(t, 3, reads["web"])
+}
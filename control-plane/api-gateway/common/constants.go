@@ -8,6 +8,11 @@ const (
 
 	AnnotationGatewayClassConfig = "consul.hashicorp.com/gateway-class-config"
 
+	// The following annotation keys are used on a v1beta1.Gateway to configure its Service.
+	AnnotationLoadBalancerIP        = "consul.hashicorp.com/gateway-load-balancer-ip"
+	AnnotationLoadBalancerClass     = "consul.hashicorp.com/gateway-load-balancer-class"
+	AnnotationExternalTrafficPolicy = "consul.hashicorp.com/gateway-external-traffic-policy"
+
 	// The following annotation keys are used in the v1beta1.GatewayTLSConfig's Options on a v1beta1.Listener.
 	TLSCipherSuitesAnnotationKey = "api-gateway.consul.hashicorp.com/tls_cipher_suites"
 	TLSMaxVersionAnnotationKey   = "api-gateway.consul.hashicorp.com/tls_max_version"
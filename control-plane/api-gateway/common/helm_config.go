@@ -8,6 +8,8 @@ import (
 	"time"
 
 	v1 "k8s.io/api/core/v1"
+
+	ctrlCommon "github.com/hashicorp/consul-k8s/control-plane/connect-inject/common"
 )
 
 const componentAuthMethod = "k8s-component-auth-method"
@@ -58,6 +60,9 @@ type HelmConfig struct {
 	DefaultPrometheusScrapePort string
 
 	InitContainerResources *v1.ResourceRequirements
+
+	// NodeNamingStrategy decides which synthetic Consul node gateway pods are registered on.
+	NodeNamingStrategy ctrlCommon.NodeNamingStrategy
 }
 
 type ConsulConfig struct {
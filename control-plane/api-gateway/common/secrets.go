@@ -9,6 +9,7 @@ import (
 	"encoding/pem"
 	"errors"
 	"fmt"
+	"time"
 
 	"github.com/miekg/dns"
 	corev1 "k8s.io/api/core/v1"
@@ -57,6 +58,20 @@ func ParseCertificateData(secret corev1.Secret) (cert string, privateKey string,
 	return string(decodedCertificate), string(decodedPrivateKey), nil
 }
 
+// CertificateNotAfter returns when the certificate of the secret expires. Certificates managed by
+// cert-manager are renewed before they expire, so an expired certificate means renewal failed.
+func CertificateNotAfter(secret corev1.Secret) (time.Time, error) {
+	certificateBlock, _ := pem.Decode(secret.Data[corev1.TLSCertKey])
+	if certificateBlock == nil {
+		return time.Time{}, errors.New("failed to parse certificate PEM")
+	}
+	certificate, err := x509.ParseCertificate(certificateBlock.Bytes)
+	if err != nil {
+		return time.Time{}, err
+	}
+	return certificate.NotAfter, nil
+}
+
 func validateCertificateHosts(certificate *x509.Certificate) error {
 	hosts := []string{certificate.Subject.CommonName}
 
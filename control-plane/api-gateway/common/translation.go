@@ -7,6 +7,7 @@ import (
 	"fmt"
 	"strings"
 
+	"golang.org/x/exp/slices"
 	corev1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/types"
 	gwv1alpha2 "sigs.k8s.io/gateway-api/apis/v1alpha2"
@@ -475,6 +476,111 @@ func (t ResourceTranslator) translateHTTPFilters(filters []gwv1beta1.HTTPRouteFi
 	return requestFilter, responseFilter
 }
 
+// ToRouteRateLimits translates the RouteRateLimitFilters referenced by the rules of the given
+// HTTPRoutes into instance-level route rate limits, keyed by the backend service they apply to.
+// A filter on a backend ref takes precedence over a filter on its rule.
+func (t ResourceTranslator) ToRouteRateLimits(routes []gwv1beta1.HTTPRoute, resources *ResourceMap) map[api.ResourceReference][]api.InstanceLevelRouteRateLimits {
+	limits := make(map[api.ResourceReference][]api.InstanceLevelRouteRateLimits)
+	t.forEachRouteRateLimitFilter(routes, resources, func(matches []gwv1beta1.HTTPRouteMatch, service api.ResourceReference, filter *v1alpha1.RouteRateLimitFilter) {
+		limits[service] = append(limits[service], t.translateRouteRateLimits(matches, filter)...)
+	})
+	return limits
+}
+
+// ToRouteRateLimitFilterServices returns the backend services that each RouteRateLimitFilter
+// referenced by the rules of the given HTTPRoutes applies its limits to.
+func (t ResourceTranslator) ToRouteRateLimitFilterServices(routes []gwv1beta1.HTTPRoute, resources *ResourceMap) map[types.NamespacedName][]api.ResourceReference {
+	services := make(map[types.NamespacedName][]api.ResourceReference)
+	t.forEachRouteRateLimitFilter(routes, resources, func(_ []gwv1beta1.HTTPRouteMatch, service api.ResourceReference, filter *v1alpha1.RouteRateLimitFilter) {
+		name := types.NamespacedName{Namespace: filter.Namespace, Name: filter.Name}
+		if !slices.Contains(services[name], service) {
+			services[name] = append(services[name], service)
+		}
+	})
+	return services
+}
+
+// forEachRouteRateLimitFilter calls fn for every backend service of the rules of the given
+// HTTPRoutes that a RouteRateLimitFilter applies to.
+func (t ResourceTranslator) forEachRouteRateLimitFilter(routes []gwv1beta1.HTTPRoute, resources *ResourceMap, fn func(matches []gwv1beta1.HTTPRouteMatch, service api.ResourceReference, filter *v1alpha1.RouteRateLimitFilter)) {
+	for _, route := range routes {
+		for _, rule := range route.Spec.Rules {
+			ruleFilter := t.routeRateLimitFilter(rule.Filters, resources, route.Namespace)
+
+			for _, ref := range rule.BackendRefs {
+				filter := t.routeRateLimitFilter(ref.Filters, resources, route.Namespace)
+				if filter == nil {
+					filter = ruleFilter
+				}
+				if filter == nil {
+					continue
+				}
+
+				id := types.NamespacedName{
+					Name:      string(ref.Name),
+					Namespace: DerefStringOr(ref.Namespace, route.Namespace),
+				}
+
+				isServiceRef := NilOrEqual(ref.Group, "") && NilOrEqual(ref.Kind, "Service")
+				if !isServiceRef || !resources.HasService(id) || !resources.HTTPRouteCanReferenceBackend(route, ref.BackendRef) {
+					continue
+				}
+
+				service := resources.Service(id)
+				service.Kind = api.ServiceDefaults
+				fn(rule.Matches, service, filter)
+			}
+		}
+	}
+}
+
+// routeRateLimitFilter returns the last RouteRateLimitFilter referenced by filters, if any.
+func (t ResourceTranslator) routeRateLimitFilter(filters []gwv1beta1.HTTPRouteFilter, resources *ResourceMap, namespace string) *v1alpha1.RouteRateLimitFilter {
+	var rateLimitFilter *v1alpha1.RouteRateLimitFilter
+	for _, filter := range filters {
+		if filter.ExtensionRef == nil || filter.ExtensionRef.Kind != v1alpha1.RouteRateLimitFilterKind {
+			continue
+		}
+
+		crdFilter, exists := resources.GetExternalFilter(*filter.ExtensionRef, namespace)
+		if !exists {
+			continue
+		}
+		rateLimitFilter = crdFilter.(*v1alpha1.RouteRateLimitFilter)
+	}
+	return rateLimitFilter
+}
+
+func (t ResourceTranslator) translateRouteRateLimits(matches []gwv1beta1.HTTPRouteMatch, filter *v1alpha1.RouteRateLimitFilter) []api.InstanceLevelRouteRateLimits {
+	// A rule without matches matches every request.
+	if len(matches) == 0 {
+		matches = []gwv1beta1.HTTPRouteMatch{{}}
+	}
+
+	return ConvertSliceFunc(matches, func(match gwv1beta1.HTTPRouteMatch) api.InstanceLevelRouteRateLimits {
+		limit := api.InstanceLevelRouteRateLimits{
+			RequestsPerSecond: filter.Spec.RequestsPerSecond,
+			RequestsMaxBurst:  filter.Spec.RequestsMaxBurst,
+		}
+
+		if match.Path == nil {
+			limit.PathPrefix = "/"
+			return limit
+		}
+
+		value := DerefStringOr(match.Path.Value, "/")
+		switch gwv1beta1.PathMatchType(DerefStringOr(match.Path.Type, gwv1beta1.PathMatchPathPrefix)) {
+		case gwv1beta1.PathMatchExact:
+			limit.PathExact = value
+		case gwv1beta1.PathMatchRegularExpression:
+			limit.PathRegex = value
+		default:
+			limit.PathPrefix = value
+		}
+		return limit
+	})
+}
+
 func (t ResourceTranslator) ToTCPRoute(route gwv1alpha2.TCPRoute, resources *ResourceMap) *api.TCPRouteConfigEntry {
 	namespace := t.Namespace(route.Namespace)
 
@@ -1537,6 +1537,167 @@ func TestTranslator_ToHTTPRoute(t *testing.T) {
 	}
 }
 
+func TestTranslator_ToRouteRateLimits(t *testing.T) {
+	t.Parallel()
+
+	rateLimitFilter := func(name string, perSecond, maxBurst int) *v1alpha1.RouteRateLimitFilter {
+		return &v1alpha1.RouteRateLimitFilter{
+			TypeMeta:   metav1.TypeMeta{Kind: v1alpha1.RouteRateLimitFilterKind},
+			ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "k8s-ns"},
+			Spec: v1alpha1.RouteRateLimitFilterSpec{
+				RequestsPerSecond: perSecond,
+				RequestsMaxBurst:  maxBurst,
+			},
+		}
+	}
+	extensionRef := func(name string) gwv1beta1.HTTPRouteFilter {
+		return gwv1beta1.HTTPRouteFilter{
+			Type: gwv1beta1.HTTPRouteFilterExtensionRef,
+			ExtensionRef: &gwv1beta1.LocalObjectReference{
+				Group: gwv1beta1.Group(v1alpha1.ConsulHashicorpGroup),
+				Kind:  v1alpha1.RouteRateLimitFilterKind,
+				Name:  gwv1beta1.ObjectName(name),
+			},
+		}
+	}
+	backendRef := func(name string, filters ...gwv1beta1.HTTPRouteFilter) gwv1beta1.HTTPBackendRef {
+		return gwv1beta1.HTTPBackendRef{
+			BackendRef: gwv1beta1.BackendRef{
+				BackendObjectReference: gwv1beta1.BackendObjectReference{Name: gwv1beta1.ObjectName(name)},
+			},
+			Filters: filters,
+		}
+	}
+	serviceDefaults := func(name string) api.ResourceReference {
+		return api.ResourceReference{Kind: api.ServiceDefaults, Name: name, Namespace: "k8s-ns"}
+	}
+
+	tests := map[string]struct {
+		rules              []gwv1beta1.HTTPRouteRule
+		services           []types.NamespacedName
+		filters            []client.Object
+		want               map[api.ResourceReference][]api.InstanceLevelRouteRateLimits
+		wantFilterServices map[types.NamespacedName][]api.ResourceReference
+	}{
+		"rule without rate limit filter": {
+			rules: []gwv1beta1.HTTPRouteRule{
+				{BackendRefs: []gwv1beta1.HTTPBackendRef{backendRef("service-one")}},
+			},
+			services:           []types.NamespacedName{{Name: "service-one", Namespace: "k8s-ns"}},
+			filters:            []client.Object{rateLimitFilter("limit", 10, 0)},
+			want:               map[api.ResourceReference][]api.InstanceLevelRouteRateLimits{},
+			wantFilterServices: map[types.NamespacedName][]api.ResourceReference{},
+		},
+		"rule filter applies to each path match of every backend": {
+			rules: []gwv1beta1.HTTPRouteRule{
+				{
+					Matches: []gwv1beta1.HTTPRouteMatch{
+						{Path: &gwv1beta1.HTTPPathMatch{Type: ptr.To(gwv1beta1.PathMatchExact), Value: ptr.To("/exact")}},
+						{Path: &gwv1beta1.HTTPPathMatch{Type: ptr.To(gwv1beta1.PathMatchPathPrefix), Value: ptr.To("/prefix")}},
+						{Path: &gwv1beta1.HTTPPathMatch{Type: ptr.To(gwv1beta1.PathMatchRegularExpression), Value: ptr.To("/regex/.*")}},
+						{Method: ptr.To(gwv1beta1.HTTPMethodGet)},
+					},
+					Filters:     []gwv1beta1.HTTPRouteFilter{extensionRef("limit")},
+					BackendRefs: []gwv1beta1.HTTPBackendRef{backendRef("service-one"), backendRef("service-two")},
+				},
+			},
+			services: []types.NamespacedName{
+				{Name: "service-one", Namespace: "k8s-ns"},
+				{Name: "service-two", Namespace: "k8s-ns"},
+			},
+			filters: []client.Object{rateLimitFilter("limit", 10, 20)},
+			want: map[api.ResourceReference][]api.InstanceLevelRouteRateLimits{
+				serviceDefaults("service-one"): {
+					{PathExact: "/exact", RequestsPerSecond: 10, RequestsMaxBurst: 20},
+					{PathPrefix: "/prefix", RequestsPerSecond: 10, RequestsMaxBurst: 20},
+					{PathRegex: "/regex/.*", RequestsPerSecond: 10, RequestsMaxBurst: 20},
+					{PathPrefix: "/", RequestsPerSecond: 10, RequestsMaxBurst: 20},
+				},
+				serviceDefaults("service-two"): {
+					{PathExact: "/exact", RequestsPerSecond: 10, RequestsMaxBurst: 20},
+					{PathPrefix: "/prefix", RequestsPerSecond: 10, RequestsMaxBurst: 20},
+					{PathRegex: "/regex/.*", RequestsPerSecond: 10, RequestsMaxBurst: 20},
+					{PathPrefix: "/", RequestsPerSecond: 10, RequestsMaxBurst: 20},
+				},
+			},
+			wantFilterServices: map[types.NamespacedName][]api.ResourceReference{
+				{Name: "limit", Namespace: "k8s-ns"}: {serviceDefaults("service-one"), serviceDefaults("service-two")},
+			},
+		},
+		"backend ref filter takes precedence over rule filter": {
+			rules: []gwv1beta1.HTTPRouteRule{
+				{
+					Filters: []gwv1beta1.HTTPRouteFilter{extensionRef("rule-limit")},
+					BackendRefs: []gwv1beta1.HTTPBackendRef{
+						backendRef("service-one", extensionRef("backend-limit")),
+						backendRef("service-two"),
+					},
+				},
+			},
+			services: []types.NamespacedName{
+				{Name: "service-one", Namespace: "k8s-ns"},
+				{Name: "service-two", Namespace: "k8s-ns"},
+			},
+			filters: []client.Object{rateLimitFilter("rule-limit", 10, 0), rateLimitFilter("backend-limit", 5, 0)},
+			want: map[api.ResourceReference][]api.InstanceLevelRouteRateLimits{
+				serviceDefaults("service-one"): {{PathPrefix: "/", RequestsPerSecond: 5}},
+				serviceDefaults("service-two"): {{PathPrefix: "/", RequestsPerSecond: 10}},
+			},
+			wantFilterServices: map[types.NamespacedName][]api.ResourceReference{
+				{Name: "backend-limit", Namespace: "k8s-ns"}: {serviceDefaults("service-one")},
+				{Name: "rule-limit", Namespace: "k8s-ns"}:    {serviceDefaults("service-two")},
+			},
+		},
+		"missing filters and services are ignored": {
+			rules: []gwv1beta1.HTTPRouteRule{
+				{
+					Filters:     []gwv1beta1.HTTPRouteFilter{extensionRef("missing")},
+					BackendRefs: []gwv1beta1.HTTPBackendRef{backendRef("service-one")},
+				},
+				{
+					Filters:     []gwv1beta1.HTTPRouteFilter{extensionRef("limit")},
+					BackendRefs: []gwv1beta1.HTTPBackendRef{backendRef("missing")},
+				},
+			},
+			services:           []types.NamespacedName{{Name: "service-one", Namespace: "k8s-ns"}},
+			filters:            []client.Object{rateLimitFilter("limit", 10, 0)},
+			want:               map[api.ResourceReference][]api.InstanceLevelRouteRateLimits{},
+			wantFilterServices: map[types.NamespacedName][]api.ResourceReference{},
+		},
+	}
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			tr := ResourceTranslator{
+				EnableConsulNamespaces: true,
+				EnableK8sMirroring:     true,
+			}
+
+			resources := NewResourceMap(tr, fakeReferenceValidator{}, logrtest.NewTestLogger(t))
+			for _, service := range tc.services {
+				resources.AddService(service, service.Name)
+			}
+			for _, filterToAdd := range tc.filters {
+				resources.AddExternalFilter(filterToAdd)
+			}
+
+			route := gwv1beta1.HTTPRoute{
+				ObjectMeta: metav1.ObjectMeta{Name: "http-route", Namespace: "k8s-ns"},
+				Spec:       gwv1beta1.HTTPRouteSpec{Rules: tc.rules},
+			}
+
+			got := tr.ToRouteRateLimits([]gwv1beta1.HTTPRoute{route}, resources)
+			if diff := cmp.Diff(tc.want, got); diff != "" {
+				t.Errorf("Translator.ToRouteRateLimits() mismatch (-want +got):\n%s", diff)
+			}
+
+			gotFilterServices := tr.ToRouteRateLimitFilterServices([]gwv1beta1.HTTPRoute{route}, resources)
+			if diff := cmp.Diff(tc.wantFilterServices, gotFilterServices); diff != "" {
+				t.Errorf("Translator.ToRouteRateLimitFilterServices() mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
 func TestTranslator_ToTCPRoute(t *testing.T) {
 	t.Parallel()
 	type args struct {
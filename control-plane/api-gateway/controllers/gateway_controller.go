@@ -9,6 +9,7 @@ import (
 	"reflect"
 	"strconv"
 	"strings"
+	"time"
 
 	mapset "github.com/deckarep/golang-set"
 
@@ -16,8 +17,10 @@ import (
 
 	"github.com/go-logr/logr"
 	appsv1 "k8s.io/api/apps/v1"
+	autoscalingv2 "k8s.io/api/autoscaling/v2"
 	corev1 "k8s.io/api/core/v1"
 	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/fields"
 	"k8s.io/apimachinery/pkg/types"
@@ -323,7 +326,14 @@ func (r *GatewayController) Reconcile(ctx context.Context, req ctrl.Request) (ct
 		}
 	}
 
-	return ctrl.Result{}, nil
+	if err := r.syncRouteRateLimits(ctx, log, grants); err != nil {
+		log.Error(err, "error syncing route rate limits")
+		return ctrl.Result{}, err
+	}
+
+	// Reconcile again when a certificate expires so that it's reflected in the status of the listeners,
+	// since nothing else triggers a reconcile if, e.g., cert-manager fails to renew the certificate.
+	return ctrl.Result{RequeueAfter: certificateExpiryRequeue(gateway, resources)}, nil
 }
 
 func (r *GatewayController) deregisterAllServices(ctx context.Context, consulKey api.ResourceReference) error {
@@ -396,7 +406,7 @@ func SetupGatewayControllerWithManager(ctx context.Context, mgr ctrl.Manager, co
 	c := cache.New(cacheConfig)
 	gwc := cache.NewGatewayCache(ctx, cacheConfig)
 
-	predicate, _ := predicate.LabelSelectorPredicate(
+	managedPredicate, _ := predicate.LabelSelectorPredicate(
 		*metav1.SetAsLabelSelector(map[string]string{
 			common.ManagedLabel: "true",
 		}),
@@ -430,6 +440,7 @@ func SetupGatewayControllerWithManager(ctx context.Context, mgr ctrl.Manager, co
 	return c, cleaner, ctrl.NewControllerManagedBy(mgr).
 		For(&gwv1beta1.Gateway{}).
 		Owns(&appsv1.Deployment{}).
+		Owns(&autoscalingv2.HorizontalPodAutoscaler{}).
 		Owns(&corev1.Service{}).
 		Owns(&corev1.Pod{}).
 		Watches(
@@ -463,7 +474,7 @@ func SetupGatewayControllerWithManager(ctx context.Context, mgr ctrl.Manager, co
 		Watches(
 			&corev1.Pod{},
 			handler.EnqueueRequestsFromMapFunc(r.transformPods),
-			builder.WithPredicates(predicate),
+			builder.WithPredicates(managedPredicate),
 		).
 		WatchesRawSource(
 			// Subscribe to changes from Consul for APIGateways
@@ -506,6 +517,13 @@ func SetupGatewayControllerWithManager(ctx context.Context, mgr ctrl.Manager, co
 			&v1alpha1.RouteAuthFilter{},
 			handler.EnqueueRequestsFromMapFunc(r.transformRouteAuthFilter),
 		).
+		Watches(
+			// Subscribe to changes in RouteRateLimitFilter custom resources referenced by HTTPRoutes.
+			&v1alpha1.RouteRateLimitFilter{},
+			handler.EnqueueRequestsFromMapFunc(r.transformRouteRateLimitFilter),
+			// The status of the filters is written by the reconciles.
+			builder.WithPredicates(predicate.GenerationChangedPredicate{}),
+		).
 		Complete(r)
 }
 
@@ -642,6 +660,10 @@ func (r *GatewayController) transformRouteAuthFilter(ctx context.Context, o clie
 	return r.gatewaysForRoutesReferencing(ctx, "", HTTPRoute_RouteAuthFilterIndex, client.ObjectKeyFromObject(o).String())
 }
 
+func (r *GatewayController) transformRouteRateLimitFilter(ctx context.Context, o client.Object) []reconcile.Request {
+	return r.gatewaysForRoutesReferencing(ctx, "", HTTPRoute_RouteRateLimitFilterIndex, client.ObjectKeyFromObject(o).String())
+}
+
 func (r *GatewayController) transformConsulTCPRoute(ctx context.Context) func(entry api.ConfigEntry) []types.NamespacedName {
 	return func(entry api.ConfigEntry) []types.NamespacedName {
 		parents := mapset.NewSet()
@@ -931,6 +953,8 @@ func (c *GatewayController) filterFiltersForExternalRefs(ctx context.Context, ro
 			externalFilter = &v1alpha1.RouteTimeoutFilter{}
 		case v1alpha1.RouteAuthFilterKind:
 			externalFilter = &v1alpha1.RouteAuthFilter{}
+		case v1alpha1.RouteRateLimitFilterKind:
+			externalFilter = &v1alpha1.RouteRateLimitFilter{}
 		default:
 			continue
 		}
@@ -1085,6 +1109,35 @@ func (c *GatewayController) fetchCertificatesForGateway(ctx context.Context, res
 	return nil
 }
 
+// certificateExpiryRequeue returns how long until the first of the certificates referenced by the gateway expires,
+// or 0 if none of them expire in the future.
+func certificateExpiryRequeue(gateway gwv1beta1.Gateway, resources *common.ResourceMap) time.Duration {
+	var requeueAfter time.Duration
+	for _, listener := range gateway.Spec.Listeners {
+		if listener.TLS == nil {
+			continue
+		}
+		for _, cert := range listener.TLS.CertificateRefs {
+			if !common.NilOrEqual(cert.Group, "") || !common.NilOrEqual(cert.Kind, common.KindSecret) {
+				continue
+			}
+			secret := resources.Certificate(common.IndexedNamespacedNameWithDefault(cert.Name, cert.Namespace, gateway.Namespace))
+			if secret == nil {
+				continue
+			}
+			notAfter, err := common.CertificateNotAfter(*secret)
+			if err != nil {
+				continue
+			}
+			// Requeue just after the certificate expires.
+			if until := time.Until(notAfter) + time.Second; until > 0 && (requeueAfter == 0 || until < requeueAfter) {
+				requeueAfter = until
+			}
+		}
+	}
+	return requeueAfter
+}
+
 func (c *GatewayController) fetchSecret(ctx context.Context, resources *common.ResourceMap, key types.NamespacedName) error {
 	var secret corev1.Secret
 	if err := c.Client.Get(ctx, key, &secret); err != nil {
@@ -1140,6 +1193,101 @@ func (c *GatewayController) fetchServicesForRoutes(ctx context.Context, resource
 	return nil
 }
 
+// routeRateLimitReasonServiceDefaultsManaged is the reason of the Synced condition of a
+// RouteRateLimitFilter whose limits aren't applied to services with ServiceDefaults resources.
+const routeRateLimitReasonServiceDefaultsManaged = "ServiceDefaultsManaged"
+
+// syncRouteRateLimits writes the rate limits of the RouteRateLimitFilters referenced by HTTPRoutes
+// to the service-defaults of their backend services. The routes accepted by any gateway of this
+// controller are considered, not only the routes of the reconciled gateway, so that a service
+// referenced by the routes of several gateways always gets the same limits. The Synced condition
+// of each filter reports whether its limits were applied to all its backend services.
+func (c *GatewayController) syncRouteRateLimits(ctx context.Context, log logr.Logger, grants []gwv1beta1.ReferenceGrant) error {
+	var filters v1alpha1.RouteRateLimitFilterList
+	if err := c.Client.List(ctx, &filters); err != nil {
+		return err
+	}
+
+	resources := common.NewResourceMap(c.Translator, binding.NewReferenceValidator(grants), log)
+	for i := range filters.Items {
+		resources.AddExternalFilter(&filters.Items[i])
+	}
+
+	var routes []gwv1beta1.HTTPRoute
+	if len(filters.Items) > 0 {
+		var list gwv1beta1.HTTPRouteList
+		if err := c.Client.List(ctx, &list); err != nil {
+			return err
+		}
+
+		for _, route := range list.Items {
+			if routeAcceptedByController(route) {
+				routes = append(routes, route)
+			}
+		}
+	}
+
+	if err := c.fetchServicesForRoutes(ctx, resources, nil, routes); err != nil {
+		return err
+	}
+
+	unapplied, err := c.cache.SyncRouteRateLimits(ctx, c.Translator.ToRouteRateLimits(routes, resources))
+	if err != nil {
+		return err
+	}
+
+	filterServices := c.Translator.ToRouteRateLimitFilterServices(routes, resources)
+	for i := range filters.Items {
+		filter := &filters.Items[i]
+		if err := c.updateRouteRateLimitFilterStatus(ctx, filter, filterServices[client.ObjectKeyFromObject(filter)], unapplied); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// updateRouteRateLimitFilterStatus sets the Synced condition of the filter to False if its limits
+// couldn't be applied to some of its backend services because their service-defaults are managed
+// by ServiceDefaults resources, and to True otherwise. The status is only written when it changed.
+func (c *GatewayController) updateRouteRateLimitFilterStatus(ctx context.Context, filter *v1alpha1.RouteRateLimitFilter, services, unapplied []api.ResourceReference) error {
+	var skipped []string
+	for _, service := range services {
+		for _, ref := range unapplied {
+			if common.NormalizeMeta(ref) == common.NormalizeMeta(service) {
+				skipped = append(skipped, service.Name)
+			}
+		}
+	}
+
+	previous := filter.Status.DeepCopy()
+	if len(skipped) > 0 {
+		filter.Status.SetSyncedCondition(corev1.ConditionFalse, routeRateLimitReasonServiceDefaultsManaged,
+			fmt.Sprintf("the limits are not applied to the services %s since their service-defaults are managed by ServiceDefaults resources, set the limits in these resources instead", strings.Join(skipped, ", ")))
+	} else {
+		filter.Status.SetSyncedCondition(corev1.ConditionTrue, "", "")
+	}
+	filter.Status.Conditions.KeepTransitionTimes(previous.Conditions)
+	if reflect.DeepEqual(previous.Conditions, filter.Status.Conditions) {
+		return nil
+	}
+	if len(skipped) == 0 {
+		now := metav1.Now()
+		filter.Status.LastSyncedTime = &now
+	}
+	return client.IgnoreNotFound(c.Client.Status().Update(ctx, filter))
+}
+
+// routeAcceptedByController returns whether a gateway of this controller accepted the route.
+func routeAcceptedByController(route gwv1beta1.HTTPRoute) bool {
+	for _, parent := range route.Status.Parents {
+		if parent.ControllerName == common.GatewayClassControllerName &&
+			meta.IsStatusConditionTrue(parent.Conditions, string(gwv1beta1.RouteConditionAccepted)) {
+			return true
+		}
+	}
+	return false
+}
+
 func (c *GatewayController) fetchMeshService(ctx context.Context, resources *common.ResourceMap, key types.NamespacedName) error {
 	var service v1alpha1.MeshService
 	if err := c.Client.Get(ctx, key, &service); err != nil {
@@ -5,10 +5,21 @@ package controllers
 
 import (
 	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
 	"testing"
+	"time"
 
 	mapset "github.com/deckarep/golang-set"
+	logrtest "github.com/go-logr/logr/testr"
+	"github.com/hashicorp/consul/api"
 
+	"github.com/hashicorp/consul-k8s/control-plane/api-gateway/binding"
 	"github.com/hashicorp/consul-k8s/control-plane/api-gateway/common"
 	"github.com/hashicorp/consul-k8s/control-plane/api/v1alpha1"
 	"github.com/hashicorp/consul-k8s/control-plane/connect-inject/constants"
@@ -637,3 +648,95 @@ func TestTransformSecret(t *testing.T) {
 		})
 	}
 }
+
+func TestCertificateExpiryRequeue(t *testing.T) {
+	t.Parallel()
+
+	gateway := gwv1beta1.Gateway{
+		ObjectMeta: metav1.ObjectMeta{Name: "gateway", Namespace: "test"},
+		Spec: gwv1beta1.GatewaySpec{
+			Listeners: []gwv1beta1.Listener{
+				{Name: "https", TLS: &gwv1beta1.GatewayTLSConfig{
+					CertificateRefs: []gwv1beta1.SecretObjectReference{
+						{Name: "expires-in-a-day"},
+						{Name: "expires-in-an-hour", Namespace: common.PointerTo(gwv1beta1.Namespace("other"))},
+						{Name: "expired"},
+						{Name: "missing"},
+					},
+				}},
+				{Name: "http"},
+			},
+		},
+	}
+
+	resources := common.NewResourceMap(common.ResourceTranslator{}, binding.NewReferenceValidator(nil), logrtest.New(t))
+	require.Zero(t, certificateExpiryRequeue(gateway, resources))
+
+	resources.ReferenceCountCertificate(certificateSecret(t, "test", "expires-in-a-day", time.Now().Add(24*time.Hour)))
+	resources.ReferenceCountCertificate(certificateSecret(t, "test", "expired", time.Now().Add(-time.Hour)))
+	requeueAfter := certificateExpiryRequeue(gateway, resources)
+	require.Greater(t, requeueAfter, 23*time.Hour)
+	require.LessOrEqual(t, requeueAfter, 24*time.Hour+time.Second)
+
+	resources.ReferenceCountCertificate(certificateSecret(t, "other", "expires-in-an-hour", time.Now().Add(time.Hour)))
+	requeueAfter = certificateExpiryRequeue(gateway, resources)
+	require.Greater(t, requeueAfter, 59*time.Minute)
+	require.LessOrEqual(t, requeueAfter, time.Hour+time.Second)
+}
+
+// certificateSecret returns a TLS Secret with a self-signed certificate that expires at notAfter.
+func certificateSecret(t *testing.T, namespace, name string, notAfter time.Time) corev1.Secret {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: "consul.test"},
+		NotBefore:    notAfter.Add(-48 * time.Hour),
+		NotAfter:     notAfter,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	require.NoError(t, err)
+	return corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: namespace},
+		Data: map[string][]byte{
+			corev1.TLSCertKey: pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}),
+		},
+	}
+}
+
+func TestUpdateRouteRateLimitFilterStatus(t *testing.T) {
+	t.Parallel()
+
+	s := runtime.NewScheme()
+	require.NoError(t, v1alpha1.AddToScheme(s))
+	filter := &v1alpha1.RouteRateLimitFilter{ObjectMeta: metav1.ObjectMeta{Name: "limit", Namespace: "default"}}
+	fakeClient := fake.NewClientBuilder().WithScheme(s).WithObjects(filter).WithStatusSubresource(filter).Build()
+	controller := GatewayController{Client: fakeClient}
+	ctx := context.Background()
+	web := api.ResourceReference{Kind: api.ServiceDefaults, Name: "web"}
+	apiService := api.ResourceReference{Kind: api.ServiceDefaults, Name: "api", Namespace: "default"}
+
+	fetch := func() *v1alpha1.RouteRateLimitFilter {
+		var fetched v1alpha1.RouteRateLimitFilter
+		require.NoError(t, fakeClient.Get(ctx, types.NamespacedName{Name: "limit", Namespace: "default"}, &fetched))
+		return &fetched
+	}
+
+	// The services whose service-defaults are managed by ServiceDefaults resources are reported.
+	unapplied := []api.ResourceReference{{Kind: api.ServiceDefaults, Name: "api"}}
+	require.NoError(t, controller.updateRouteRateLimitFilterStatus(ctx, fetch(), []api.ResourceReference{web, apiService}, unapplied))
+	synced := fetch().Status.GetCondition(v1alpha1.ConditionSynced)
+	require.True(t, synced.IsFalse())
+	require.Equal(t, routeRateLimitReasonServiceDefaultsManaged, synced.Reason)
+	require.Contains(t, synced.Message, "the services api since")
+
+	// The status isn't written again when it doesn't change.
+	resourceVersion := fetch().ResourceVersion
+	require.NoError(t, controller.updateRouteRateLimitFilterStatus(ctx, fetch(), []api.ResourceReference{web, apiService}, unapplied))
+	require.Equal(t, resourceVersion, fetch().ResourceVersion)
+
+	require.NoError(t, controller.updateRouteRateLimitFilterStatus(ctx, fetch(), []api.ResourceReference{web}, unapplied))
+	fetched := fetch()
+	require.True(t, fetched.Status.GetCondition(v1alpha1.ConditionSynced).IsTrue())
+	require.NotNil(t, fetched.Status.LastSyncedTime)
+}
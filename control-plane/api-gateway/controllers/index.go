@@ -24,12 +24,13 @@ const (
 
 	Gateway_GatewayClassIndex = "__gateway_referencing_gatewayclass"
 
-	HTTPRoute_GatewayIndex            = "__httproute_referencing_gateway"
-	HTTPRoute_ServiceIndex            = "__httproute_referencing_service"
-	HTTPRoute_MeshServiceIndex        = "__httproute_referencing_mesh_service"
-	HTTPRoute_RouteRetryFilterIndex   = "__httproute_referencing_retryfilter"
-	HTTPRoute_RouteTimeoutFilterIndex = "__httproute_referencing_timeoutfilter"
-	HTTPRoute_RouteAuthFilterIndex    = "__httproute_referencing_routeauthfilter"
+	HTTPRoute_GatewayIndex              = "__httproute_referencing_gateway"
+	HTTPRoute_ServiceIndex              = "__httproute_referencing_service"
+	HTTPRoute_MeshServiceIndex          = "__httproute_referencing_mesh_service"
+	HTTPRoute_RouteRetryFilterIndex     = "__httproute_referencing_retryfilter"
+	HTTPRoute_RouteTimeoutFilterIndex   = "__httproute_referencing_timeoutfilter"
+	HTTPRoute_RouteAuthFilterIndex      = "__httproute_referencing_routeauthfilter"
+	HTTPRoute_RouteRateLimitFilterIndex = "__httproute_referencing_ratelimitfilter"
 
 	TCPRoute_GatewayIndex     = "__tcproute_referencing_gateway"
 	TCPRoute_ServiceIndex     = "__tcproute_referencing_service"
@@ -129,6 +130,11 @@ var indexes = []index{
 		target:      &gwv1beta1.HTTPRoute{},
 		indexerFunc: filtersForHTTPRoute,
 	},
+	{
+		name:        HTTPRoute_RouteRateLimitFilterIndex,
+		target:      &gwv1beta1.HTTPRoute{},
+		indexerFunc: filtersForHTTPRoute,
+	},
 	{
 		name:        Gatewaypolicy_GatewayIndex,
 		target:      &v1alpha1.GatewayPolicy{},
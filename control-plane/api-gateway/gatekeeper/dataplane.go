@@ -76,7 +76,7 @@ func consulDataplaneContainer(metrics common.MetricsConfig, config common.HelmCo
 			},
 			{
 				Name:  "DP_SERVICE_NODE_NAME",
-				Value: "$(NODE_NAME)-virtual",
+				Value: config.NodeNamingStrategy.ConsulNodeNameEnv(namespace),
 			},
 		},
 		VolumeMounts:   mounts,
@@ -10,6 +10,7 @@ import (
 	"github.com/google/go-cmp/cmp"
 	appsv1 "k8s.io/api/apps/v1"
 	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
 	k8serrors "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
@@ -20,6 +21,7 @@ import (
 
 	"github.com/hashicorp/consul-k8s/control-plane/api-gateway/common"
 	"github.com/hashicorp/consul-k8s/control-plane/api/v1alpha1"
+	ctrlCommon "github.com/hashicorp/consul-k8s/control-plane/connect-inject/common"
 	"github.com/hashicorp/consul-k8s/control-plane/connect-inject/constants"
 )
 
@@ -99,6 +101,10 @@ func (g *Gatekeeper) deployment(gateway gwv1beta1.Gateway, gcc v1alpha1.GatewayC
 		constants.AnnotationGatewayKind:              "api-gateway",
 	}
 
+	if config.NodeNamingStrategy != "" && config.NodeNamingStrategy != ctrlCommon.NodeNamingPerNode {
+		annotations[constants.AnnotationNodeNamingStrategy] = string(config.NodeNamingStrategy)
+	}
+
 	metrics := common.GatewayMetricsConfig(gateway, gcc, config)
 
 	if metrics.Enabled {
@@ -138,24 +144,11 @@ func (g *Gatekeeper) deployment(gateway gwv1beta1.Gateway, gcc v1alpha1.GatewayC
 					Containers: []corev1.Container{
 						container,
 					},
-					Affinity: &corev1.Affinity{
-						PodAntiAffinity: &corev1.PodAntiAffinity{
-							PreferredDuringSchedulingIgnoredDuringExecution: []corev1.WeightedPodAffinityTerm{
-								{
-									Weight: 1,
-									PodAffinityTerm: corev1.PodAffinityTerm{
-										LabelSelector: &metav1.LabelSelector{
-											MatchLabels: common.LabelsForGateway(&gateway),
-										},
-										TopologyKey: "kubernetes.io/hostname",
-									},
-								},
-							},
-						},
-					},
-					NodeSelector:       gcc.Spec.NodeSelector,
-					Tolerations:        gcc.Spec.Tolerations,
-					ServiceAccountName: g.serviceAccountName(gateway, config),
+					Affinity:                  deploymentAffinity(gcc, gateway),
+					TopologySpreadConstraints: deploymentTopologySpreadConstraints(gcc, gateway),
+					NodeSelector:              gcc.Spec.NodeSelector,
+					Tolerations:               gcc.Spec.Tolerations,
+					ServiceAccountName:        g.serviceAccountName(gateway, config),
 				},
 			},
 		},
@@ -211,6 +204,13 @@ func compareDeployments(a, b *appsv1.Deployment) bool {
 		}
 	}
 
+	if !equality.Semantic.DeepEqual(a.Spec.Template.Spec.NodeSelector, b.Spec.Template.Spec.NodeSelector) ||
+		!equality.Semantic.DeepEqual(a.Spec.Template.Spec.Tolerations, b.Spec.Template.Spec.Tolerations) ||
+		!equality.Semantic.DeepEqual(a.Spec.Template.Spec.Affinity, b.Spec.Template.Spec.Affinity) ||
+		!equality.Semantic.DeepEqual(a.Spec.Template.Spec.TopologySpreadConstraints, b.Spec.Template.Spec.TopologySpreadConstraints) {
+		return false
+	}
+
 	if b.Spec.Replicas == nil && a.Spec.Replicas == nil {
 		return true
 	} else if b.Spec.Replicas == nil {
@@ -229,7 +229,60 @@ func newDeploymentMutator(deployment, mutated *appsv1.Deployment, gcc v1alpha1.G
 	}
 }
 
+// deploymentAffinity returns the affinity of the gateway pods. Unless the GatewayClassConfig
+// sets one, the pods prefer to be scheduled on different nodes than the other pods of the gateway.
+func deploymentAffinity(gcc v1alpha1.GatewayClassConfig, gateway gwv1beta1.Gateway) *corev1.Affinity {
+	if gcc.Spec.Affinity != nil {
+		return gcc.Spec.Affinity.DeepCopy()
+	}
+
+	return &corev1.Affinity{
+		PodAntiAffinity: &corev1.PodAntiAffinity{
+			PreferredDuringSchedulingIgnoredDuringExecution: []corev1.WeightedPodAffinityTerm{
+				{
+					Weight: 1,
+					PodAffinityTerm: corev1.PodAffinityTerm{
+						LabelSelector: &metav1.LabelSelector{
+							MatchLabels: common.LabelsForGateway(&gateway),
+						},
+						TopologyKey: "kubernetes.io/hostname",
+					},
+				},
+			},
+		},
+	}
+}
+
+// deploymentTopologySpreadConstraints returns the topology spread constraints of the gateway pods.
+// A constraint of the GatewayClassConfig without a label selector spreads the pods of the gateway.
+func deploymentTopologySpreadConstraints(gcc v1alpha1.GatewayClassConfig, gateway gwv1beta1.Gateway) []corev1.TopologySpreadConstraint {
+	if len(gcc.Spec.TopologySpreadConstraints) == 0 {
+		return nil
+	}
+
+	constraints := make([]corev1.TopologySpreadConstraint, 0, len(gcc.Spec.TopologySpreadConstraints))
+	for _, constraint := range gcc.Spec.TopologySpreadConstraints {
+		constraint := *constraint.DeepCopy()
+		if constraint.LabelSelector == nil {
+			constraint.LabelSelector = &metav1.LabelSelector{
+				MatchLabels: common.LabelsForGateway(&gateway),
+			}
+		}
+		constraints = append(constraints, constraint)
+	}
+	return constraints
+}
+
 func deploymentReplicas(gcc v1alpha1.GatewayClassConfig, currentReplicas *int32) *int32 {
+	// The HorizontalPodAutoscaler manages the replicas if autoscaling is enabled,
+	// so only the initial number of replicas is set.
+	if gcc.Spec.DeploymentSpec.Autoscaling != nil {
+		if currentReplicas != nil {
+			return currentReplicas
+		}
+		return autoscalingMinReplicas(gcc)
+	}
+
 	instanceValue := defaultInstances
 
 	// If currentReplicas is not nil use current value when building deployment...
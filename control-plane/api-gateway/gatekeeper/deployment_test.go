@@ -7,10 +7,14 @@ import (
 	"testing"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 	appsv1 "k8s.io/api/apps/v1"
 	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	gwv1beta1 "sigs.k8s.io/gateway-api/apis/v1beta1"
 
 	"github.com/hashicorp/consul-k8s/control-plane/api-gateway/common"
+	"github.com/hashicorp/consul-k8s/control-plane/api/v1alpha1"
 )
 
 func Test_compareDeployments(t *testing.T) {
@@ -205,6 +209,58 @@ func Test_compareDeployments(t *testing.T) {
 			},
 			shouldBeEqual: true,
 		},
+		{
+			name: "different topology spread constraints",
+			a: &appsv1.Deployment{
+				Spec: appsv1.DeploymentSpec{
+					Template: corev1.PodTemplateSpec{
+						Spec: corev1.PodSpec{
+							TopologySpreadConstraints: []corev1.TopologySpreadConstraint{
+								{MaxSkew: 1, TopologyKey: "topology.kubernetes.io/zone"},
+							},
+						},
+					},
+				},
+			},
+			b:             &appsv1.Deployment{},
+			shouldBeEqual: false,
+		},
+		{
+			name: "different affinity",
+			a: &appsv1.Deployment{
+				Spec: appsv1.DeploymentSpec{
+					Template: corev1.PodTemplateSpec{
+						Spec: corev1.PodSpec{
+							Affinity: &corev1.Affinity{NodeAffinity: &corev1.NodeAffinity{}},
+						},
+					},
+				},
+			},
+			b: &appsv1.Deployment{
+				Spec: appsv1.DeploymentSpec{
+					Template: corev1.PodTemplateSpec{
+						Spec: corev1.PodSpec{
+							Affinity: &corev1.Affinity{PodAntiAffinity: &corev1.PodAntiAffinity{}},
+						},
+					},
+				},
+			},
+			shouldBeEqual: false,
+		},
+		{
+			name: "empty and nil node selectors",
+			a: &appsv1.Deployment{
+				Spec: appsv1.DeploymentSpec{
+					Template: corev1.PodTemplateSpec{
+						Spec: corev1.PodSpec{
+							NodeSelector: map[string]string{},
+						},
+					},
+				},
+			},
+			b:             &appsv1.Deployment{},
+			shouldBeEqual: true,
+		},
 	}
 
 	for _, testCase := range testCases {
@@ -217,3 +273,52 @@ func Test_compareDeployments(t *testing.T) {
 		})
 	}
 }
+
+func Test_deploymentTopologySpreadConstraints(t *testing.T) {
+	gateway := gwv1beta1.Gateway{ObjectMeta: metav1.ObjectMeta{Name: "gateway", Namespace: "default"}}
+	selector := &metav1.LabelSelector{MatchLabels: map[string]string{"app": "gateway"}}
+	gcc := v1alpha1.GatewayClassConfig{
+		Spec: v1alpha1.GatewayClassConfigSpec{
+			TopologySpreadConstraints: []corev1.TopologySpreadConstraint{
+				{MaxSkew: 1, TopologyKey: "topology.kubernetes.io/zone", WhenUnsatisfiable: corev1.DoNotSchedule},
+				{MaxSkew: 2, TopologyKey: "kubernetes.io/hostname", WhenUnsatisfiable: corev1.ScheduleAnyway, LabelSelector: selector},
+			},
+		},
+	}
+
+	constraints := deploymentTopologySpreadConstraints(gcc, gateway)
+	require.Len(t, constraints, 2)
+	require.Equal(t, &metav1.LabelSelector{MatchLabels: common.LabelsForGateway(&gateway)}, constraints[0].LabelSelector)
+	require.Equal(t, "topology.kubernetes.io/zone", constraints[0].TopologyKey)
+	require.Equal(t, selector, constraints[1].LabelSelector)
+
+	// The GatewayClassConfig must not be modified since it is shared by the gateways of its class.
+	require.Nil(t, gcc.Spec.TopologySpreadConstraints[0].LabelSelector)
+
+	require.Nil(t, deploymentTopologySpreadConstraints(v1alpha1.GatewayClassConfig{}, gateway))
+}
+
+func Test_deploymentAffinity(t *testing.T) {
+	gateway := gwv1beta1.Gateway{ObjectMeta: metav1.ObjectMeta{Name: "gateway", Namespace: "default"}}
+
+	// By default, the pods of a gateway prefer to be scheduled on different nodes.
+	affinity := deploymentAffinity(v1alpha1.GatewayClassConfig{}, gateway)
+	require.NotNil(t, affinity.PodAntiAffinity)
+	terms := affinity.PodAntiAffinity.PreferredDuringSchedulingIgnoredDuringExecution
+	require.Len(t, terms, 1)
+	require.Equal(t, "kubernetes.io/hostname", terms[0].PodAffinityTerm.TopologyKey)
+	require.Equal(t, common.LabelsForGateway(&gateway), terms[0].PodAffinityTerm.LabelSelector.MatchLabels)
+
+	// The affinity of the GatewayClassConfig replaces the default.
+	nodeAffinity := &corev1.Affinity{
+		NodeAffinity: &corev1.NodeAffinity{
+			RequiredDuringSchedulingIgnoredDuringExecution: &corev1.NodeSelector{
+				NodeSelectorTerms: []corev1.NodeSelectorTerm{
+					{MatchExpressions: []corev1.NodeSelectorRequirement{{Key: "gateway", Operator: corev1.NodeSelectorOpExists}}},
+				},
+			},
+		},
+	}
+	gcc := v1alpha1.GatewayClassConfig{Spec: v1alpha1.GatewayClassConfigSpec{Affinity: nodeAffinity}}
+	require.Equal(t, nodeAffinity, deploymentAffinity(gcc, gateway))
+}
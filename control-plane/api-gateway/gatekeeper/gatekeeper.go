@@ -59,6 +59,10 @@ func (g *Gatekeeper) Upsert(ctx context.Context, gateway gwv1beta1.Gateway, gcc
 		return err
 	}
 
+	if err := g.upsertHorizontalPodAutoscaler(ctx, gateway, gcc); err != nil {
+		return err
+	}
+
 	return nil
 }
 
@@ -68,6 +72,10 @@ func (g *Gatekeeper) Delete(ctx context.Context, gateway gwv1beta1.Gateway) erro
 	gatewayName := g.namespacedName(gateway)
 	g.Log.V(1).Info(fmt.Sprintf("Delete Gateway Deployment %s/%s", gatewayName.Namespace, gatewayName.Name))
 
+	if err := g.deleteHorizontalPodAutoscaler(ctx, gateway); err != nil {
+		return err
+	}
+
 	if err := g.deleteDeployment(ctx, gatewayName); err != nil {
 		return err
 	}
@@ -0,0 +1,142 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package gatekeeper
+
+import (
+	"context"
+
+	autoscalingv2 "k8s.io/api/autoscaling/v2"
+	corev1 "k8s.io/api/core/v1"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+	gwv1beta1 "sigs.k8s.io/gateway-api/apis/v1beta1"
+
+	"github.com/hashicorp/consul-k8s/control-plane/api-gateway/common"
+	"github.com/hashicorp/consul-k8s/control-plane/api/v1alpha1"
+)
+
+const (
+	defaultMinReplicas int32 = 1
+	// defaultCPUUtilization is the target CPU utilization the Kubernetes API server
+	// defaults HorizontalPodAutoscalers without metrics to.
+	defaultCPUUtilization int32 = 80
+)
+
+func (g *Gatekeeper) upsertHorizontalPodAutoscaler(ctx context.Context, gateway gwv1beta1.Gateway, gcc v1alpha1.GatewayClassConfig) error {
+	if gcc.Spec.DeploymentSpec.Autoscaling == nil {
+		return g.deleteHorizontalPodAutoscaler(ctx, gateway)
+	}
+
+	desired := g.horizontalPodAutoscaler(gateway, gcc)
+
+	existing := &autoscalingv2.HorizontalPodAutoscaler{
+		ObjectMeta: metav1.ObjectMeta{Name: desired.Name, Namespace: desired.Namespace},
+	}
+	result, err := controllerutil.CreateOrUpdate(ctx, g.Client, existing, func() error {
+		existing.Labels = desired.Labels
+		existing.Spec = desired.Spec
+		return ctrl.SetControllerReference(&gateway, existing, g.Client.Scheme())
+	})
+	if err != nil {
+		return err
+	}
+
+	switch result {
+	case controllerutil.OperationResultCreated:
+		g.Log.V(1).Info("Created HorizontalPodAutoscaler")
+	case controllerutil.OperationResultUpdated:
+		g.Log.V(1).Info("Updated HorizontalPodAutoscaler")
+	case controllerutil.OperationResultNone:
+		g.Log.V(1).Info("No change to HorizontalPodAutoscaler")
+	}
+
+	return nil
+}
+
+// deleteHorizontalPodAutoscaler deletes the autoscaler of the gateway. An autoscaler with the gateway's name
+// that isn't owned by the gateway was created by someone else and is left alone.
+func (g *Gatekeeper) deleteHorizontalPodAutoscaler(ctx context.Context, gw gwv1beta1.Gateway) error {
+	hpa := &autoscalingv2.HorizontalPodAutoscaler{}
+	if err := g.Client.Get(ctx, g.namespacedName(gw), hpa); err != nil {
+		if k8serrors.IsNotFound(err) {
+			return nil
+		}
+		return err
+	}
+
+	if !isOwnedByGateway(hpa, gw) {
+		return nil
+	}
+
+	if err := g.Client.Delete(ctx, hpa); err != nil {
+		if k8serrors.IsNotFound(err) {
+			return nil
+		}
+		return err
+	}
+
+	return nil
+}
+
+// horizontalPodAutoscaler returns the HorizontalPodAutoscaler of the gateway's deployment. The fields the
+// Kubernetes API server defaults are set explicitly so that the autoscaler isn't updated on every reconcile.
+func (g *Gatekeeper) horizontalPodAutoscaler(gateway gwv1beta1.Gateway, gcc v1alpha1.GatewayClassConfig) *autoscalingv2.HorizontalPodAutoscaler {
+	autoscaling := gcc.Spec.DeploymentSpec.Autoscaling
+
+	var metrics []autoscalingv2.MetricSpec
+	if autoscaling.TargetCPUUtilizationPercentage != nil {
+		metrics = append(metrics, resourceUtilizationMetric(corev1.ResourceCPU, *autoscaling.TargetCPUUtilizationPercentage))
+	}
+	if autoscaling.TargetMemoryUtilizationPercentage != nil {
+		metrics = append(metrics, resourceUtilizationMetric(corev1.ResourceMemory, *autoscaling.TargetMemoryUtilizationPercentage))
+	}
+	for _, metric := range autoscaling.Metrics {
+		metrics = append(metrics, *metric.DeepCopy())
+	}
+	if len(metrics) == 0 {
+		metrics = append(metrics, resourceUtilizationMetric(corev1.ResourceCPU, defaultCPUUtilization))
+	}
+
+	return &autoscalingv2.HorizontalPodAutoscaler{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      gateway.Name,
+			Namespace: gateway.Namespace,
+			Labels:    common.LabelsForGateway(&gateway),
+		},
+		Spec: autoscalingv2.HorizontalPodAutoscalerSpec{
+			ScaleTargetRef: autoscalingv2.CrossVersionObjectReference{
+				APIVersion: "apps/v1",
+				Kind:       "Deployment",
+				Name:       gateway.Name,
+			},
+			MinReplicas: autoscalingMinReplicas(gcc),
+			MaxReplicas: autoscaling.MaxReplicas,
+			Metrics:     metrics,
+		},
+	}
+}
+
+// autoscalingMinReplicas returns the minimum number of replicas of the gateway's autoscaler.
+func autoscalingMinReplicas(gcc v1alpha1.GatewayClassConfig) *int32 {
+	minReplicas := defaultMinReplicas
+	if gcc.Spec.DeploymentSpec.Autoscaling.MinReplicas != nil {
+		minReplicas = *gcc.Spec.DeploymentSpec.Autoscaling.MinReplicas
+	}
+	return &minReplicas
+}
+
+func resourceUtilizationMetric(name corev1.ResourceName, utilization int32) autoscalingv2.MetricSpec {
+	return autoscalingv2.MetricSpec{
+		Type: autoscalingv2.ResourceMetricSourceType,
+		Resource: &autoscalingv2.ResourceMetricSource{
+			Name: name,
+			Target: autoscalingv2.MetricTarget{
+				Type:               autoscalingv2.UtilizationMetricType,
+				AverageUtilization: &utilization,
+			},
+		},
+	}
+}
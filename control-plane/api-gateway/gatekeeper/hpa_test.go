@@ -0,0 +1,129 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package gatekeeper
+
+import (
+	"context"
+	"testing"
+
+	logrtest "github.com/go-logr/logr/testr"
+	"github.com/stretchr/testify/require"
+	appsv1 "k8s.io/api/apps/v1"
+	autoscalingv2 "k8s.io/api/autoscaling/v2"
+	corev1 "k8s.io/api/core/v1"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	gwv1beta1 "sigs.k8s.io/gateway-api/apis/v1beta1"
+
+	"github.com/hashicorp/consul-k8s/control-plane/api-gateway/common"
+	"github.com/hashicorp/consul-k8s/control-plane/api/v1alpha1"
+)
+
+func TestHorizontalPodAutoscaler(t *testing.T) {
+	t.Parallel()
+
+	cpu := resourceUtilizationMetric(corev1.ResourceCPU, 60)
+	memory := resourceUtilizationMetric(corev1.ResourceMemory, 70)
+	custom := autoscalingv2.MetricSpec{
+		Type: autoscalingv2.PodsMetricSourceType,
+		Pods: &autoscalingv2.PodsMetricSource{
+			Metric: autoscalingv2.MetricIdentifier{Name: "envoy_http_downstream_rq_active"},
+			Target: autoscalingv2.MetricTarget{Type: autoscalingv2.AverageValueMetricType},
+		},
+	}
+
+	cases := map[string]struct {
+		autoscaling    v1alpha1.AutoscalingSpec
+		expMinReplicas int32
+		expMetrics     []autoscalingv2.MetricSpec
+	}{
+		"defaults": {
+			autoscaling:    v1alpha1.AutoscalingSpec{MaxReplicas: 4},
+			expMinReplicas: 1,
+			expMetrics:     []autoscalingv2.MetricSpec{resourceUtilizationMetric(corev1.ResourceCPU, defaultCPUUtilization)},
+		},
+		"targets and custom metrics": {
+			autoscaling: v1alpha1.AutoscalingSpec{
+				MinReplicas:                       common.PointerTo(int32(2)),
+				MaxReplicas:                       10,
+				TargetCPUUtilizationPercentage:    common.PointerTo(int32(60)),
+				TargetMemoryUtilizationPercentage: common.PointerTo(int32(70)),
+				Metrics:                           []autoscalingv2.MetricSpec{custom},
+			},
+			expMinReplicas: 2,
+			expMetrics:     []autoscalingv2.MetricSpec{cpu, memory, custom},
+		},
+	}
+
+	for name, c := range cases {
+		t.Run(name, func(t *testing.T) {
+			gateway := gwv1beta1.Gateway{ObjectMeta: metav1.ObjectMeta{Name: "gw", Namespace: "default"}}
+			gcc := v1alpha1.GatewayClassConfig{Spec: v1alpha1.GatewayClassConfigSpec{
+				DeploymentSpec: v1alpha1.DeploymentSpec{Autoscaling: &c.autoscaling},
+			}}
+
+			hpa := (&Gatekeeper{}).horizontalPodAutoscaler(gateway, gcc)
+			require.Equal(t, autoscalingv2.CrossVersionObjectReference{APIVersion: "apps/v1", Kind: "Deployment", Name: "gw"}, hpa.Spec.ScaleTargetRef)
+			require.Equal(t, c.expMinReplicas, *hpa.Spec.MinReplicas)
+			require.Equal(t, c.autoscaling.MaxReplicas, hpa.Spec.MaxReplicas)
+			require.Equal(t, c.expMetrics, hpa.Spec.Metrics)
+
+			// The initial replicas of the deployment are the minimum replicas, after
+			// that the replicas are left to the autoscaler.
+			require.Equal(t, c.expMinReplicas, *deploymentReplicas(gcc, nil))
+			require.Equal(t, int32(7), *deploymentReplicas(gcc, common.PointerTo(int32(7))))
+		})
+	}
+}
+
+func TestUpsertHorizontalPodAutoscaler(t *testing.T) {
+	t.Parallel()
+
+	s := runtime.NewScheme()
+	require.NoError(t, gwv1beta1.Install(s))
+	require.NoError(t, appsv1.AddToScheme(s))
+	require.NoError(t, autoscalingv2.AddToScheme(s))
+
+	gateway := gwv1beta1.Gateway{ObjectMeta: metav1.ObjectMeta{Name: "gw", Namespace: "default", UID: "uid"}}
+	gcc := v1alpha1.GatewayClassConfig{Spec: v1alpha1.GatewayClassConfigSpec{
+		DeploymentSpec: v1alpha1.DeploymentSpec{Autoscaling: &v1alpha1.AutoscalingSpec{MaxReplicas: 4}},
+	}}
+	client := fake.NewClientBuilder().WithScheme(s).WithObjects(&gateway).Build()
+	g := New(logrtest.New(t), client)
+	key := types.NamespacedName{Name: "gw", Namespace: "default"}
+
+	require.NoError(t, g.upsertHorizontalPodAutoscaler(context.Background(), gateway, gcc))
+	var hpa autoscalingv2.HorizontalPodAutoscaler
+	require.NoError(t, client.Get(context.Background(), key, &hpa))
+	require.Equal(t, int32(4), hpa.Spec.MaxReplicas)
+	require.Len(t, hpa.OwnerReferences, 1)
+	require.Equal(t, "gw", hpa.OwnerReferences[0].Name)
+
+	// The autoscaler is only updated when the GatewayClassConfig changes.
+	require.NoError(t, g.upsertHorizontalPodAutoscaler(context.Background(), gateway, gcc))
+	var unchanged autoscalingv2.HorizontalPodAutoscaler
+	require.NoError(t, client.Get(context.Background(), key, &unchanged))
+	require.Equal(t, hpa.ResourceVersion, unchanged.ResourceVersion)
+
+	gcc.Spec.DeploymentSpec.Autoscaling.MaxReplicas = 6
+	require.NoError(t, g.upsertHorizontalPodAutoscaler(context.Background(), gateway, gcc))
+	require.NoError(t, client.Get(context.Background(), key, &hpa))
+	require.Equal(t, int32(6), hpa.Spec.MaxReplicas)
+
+	// The autoscaler is deleted when autoscaling is disabled.
+	gcc.Spec.DeploymentSpec.Autoscaling = nil
+	require.NoError(t, g.upsertHorizontalPodAutoscaler(context.Background(), gateway, gcc))
+	err := client.Get(context.Background(), key, &hpa)
+	require.True(t, k8serrors.IsNotFound(err))
+
+	// An autoscaler with the gateway's name that isn't owned by the gateway isn't deleted.
+	unowned := &autoscalingv2.HorizontalPodAutoscaler{ObjectMeta: metav1.ObjectMeta{Name: "gw", Namespace: "default"}}
+	require.NoError(t, client.Create(context.Background(), unowned))
+	require.NoError(t, g.upsertHorizontalPodAutoscaler(context.Background(), gateway, gcc))
+	require.NoError(t, g.deleteHorizontalPodAutoscaler(context.Background(), gateway))
+	require.NoError(t, client.Get(context.Background(), key, &hpa))
+}
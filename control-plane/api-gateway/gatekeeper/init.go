@@ -115,7 +115,7 @@ func (g *Gatekeeper) initContainer(config common.HelmConfig, name, namespace str
 			},
 			{
 				Name:  "CONSUL_NODE_NAME",
-				Value: "$(NODE_NAME)-virtual",
+				Value: config.NodeNamingStrategy.ConsulNodeNameEnv(namespace),
 			},
 		},
 		VolumeMounts: volMounts,
@@ -95,7 +95,7 @@ func (g *Gatekeeper) service(gateway gwv1beta1.Gateway, gcc v1alpha1.GatewayClas
 		}
 	}
 
-	return &corev1.Service{
+	service := &corev1.Service{
 		ObjectMeta: metav1.ObjectMeta{
 			Name:        gateway.Name,
 			Namespace:   gateway.Namespace,
@@ -108,6 +108,37 @@ func (g *Gatekeeper) service(gateway gwv1beta1.Gateway, gcc v1alpha1.GatewayClas
 			Ports:    ports,
 		},
 	}
+	configureExternalTraffic(service, gateway, gcc)
+
+	return service
+}
+
+// configureExternalTraffic sets the load balancer class, the static load balancer IP and the external traffic policy
+// of the Service from the Gateway's annotations, falling back to the GatewayClassConfig.
+func configureExternalTraffic(service *corev1.Service, gateway gwv1beta1.Gateway, gcc v1alpha1.GatewayClassConfig) {
+	if service.Spec.Type != corev1.ServiceTypeLoadBalancer && service.Spec.Type != corev1.ServiceTypeNodePort {
+		return
+	}
+
+	policy := corev1.ServiceExternalTrafficPolicy(gateway.Annotations[common.AnnotationExternalTrafficPolicy])
+	if policy == corev1.ServiceExternalTrafficPolicyCluster || policy == corev1.ServiceExternalTrafficPolicyLocal {
+		service.Spec.ExternalTrafficPolicy = policy
+	} else if gcc.Spec.ExternalTrafficPolicy != nil {
+		service.Spec.ExternalTrafficPolicy = *gcc.Spec.ExternalTrafficPolicy
+	}
+
+	if service.Spec.Type != corev1.ServiceTypeLoadBalancer {
+		return
+	}
+
+	// An empty class isn't valid, so it is ignored.
+	if class := gateway.Annotations[common.AnnotationLoadBalancerClass]; class != "" {
+		service.Spec.LoadBalancerClass = &class
+	} else if gcc.Spec.LoadBalancerClass != nil && *gcc.Spec.LoadBalancerClass != "" {
+		service.Spec.LoadBalancerClass = gcc.Spec.LoadBalancerClass
+	}
+
+	service.Spec.LoadBalancerIP = gateway.Annotations[common.AnnotationLoadBalancerIP]
 }
 
 // mergeService is used to keep annotations and ports from the `existing` Service
@@ -126,6 +157,12 @@ func mergeServiceInto(existing, desired *corev1.Service) {
 		existing.Spec.Ports = duplicate.Spec.Ports
 	}
 
+	// The load balancer class of a LoadBalancer Service can't be changed once it is created, so the
+	// class the Service was created with is kept until it is no longer a LoadBalancer Service.
+	if duplicate.Spec.Type == corev1.ServiceTypeLoadBalancer && existing.Spec.Type == corev1.ServiceTypeLoadBalancer {
+		existing.Spec.LoadBalancerClass = duplicate.Spec.LoadBalancerClass
+	}
+
 	// If the Service already exists, add any desired annotations + labels to existing set
 
 	// Note: the annotations could be empty if an external controller decided to remove them all
@@ -0,0 +1,171 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package gatekeeper
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	gwv1beta1 "sigs.k8s.io/gateway-api/apis/v1beta1"
+
+	"github.com/hashicorp/consul-k8s/control-plane/api-gateway/common"
+	"github.com/hashicorp/consul-k8s/control-plane/api/v1alpha1"
+)
+
+func TestService_ExternalTraffic(t *testing.T) {
+	t.Parallel()
+
+	local := corev1.ServiceExternalTrafficPolicyLocal
+	cases := map[string]struct {
+		serviceType          corev1.ServiceType
+		gccSpec              v1alpha1.GatewayClassConfigSpec
+		annotations          map[string]string
+		expLoadBalancerClass *string
+		expLoadBalancerIP    string
+		expTrafficPolicy     corev1.ServiceExternalTrafficPolicy
+	}{
+		"not set by default": {
+			serviceType: corev1.ServiceTypeLoadBalancer,
+		},
+		"set from the GatewayClassConfig": {
+			serviceType: corev1.ServiceTypeLoadBalancer,
+			gccSpec: v1alpha1.GatewayClassConfigSpec{
+				LoadBalancerClass:     common.PointerTo("metallb"),
+				ExternalTrafficPolicy: &local,
+			},
+			expLoadBalancerClass: common.PointerTo("metallb"),
+			expTrafficPolicy:     corev1.ServiceExternalTrafficPolicyLocal,
+		},
+		"Gateway annotations override the GatewayClassConfig": {
+			serviceType: corev1.ServiceTypeLoadBalancer,
+			gccSpec: v1alpha1.GatewayClassConfigSpec{
+				LoadBalancerClass:     common.PointerTo("metallb"),
+				ExternalTrafficPolicy: &local,
+			},
+			annotations: map[string]string{
+				common.AnnotationLoadBalancerClass:     "service.k8s.aws/nlb",
+				common.AnnotationLoadBalancerIP:        "10.0.0.10",
+				common.AnnotationExternalTrafficPolicy: "Cluster",
+			},
+			expLoadBalancerClass: common.PointerTo("service.k8s.aws/nlb"),
+			expLoadBalancerIP:    "10.0.0.10",
+			expTrafficPolicy:     corev1.ServiceExternalTrafficPolicyCluster,
+		},
+		"empty load balancer class annotation is ignored": {
+			serviceType: corev1.ServiceTypeLoadBalancer,
+			gccSpec: v1alpha1.GatewayClassConfigSpec{
+				LoadBalancerClass: common.PointerTo("metallb"),
+			},
+			annotations: map[string]string{
+				common.AnnotationLoadBalancerClass: "",
+			},
+			expLoadBalancerClass: common.PointerTo("metallb"),
+		},
+		"empty load balancer class of the GatewayClassConfig is ignored": {
+			serviceType: corev1.ServiceTypeLoadBalancer,
+			gccSpec: v1alpha1.GatewayClassConfigSpec{
+				LoadBalancerClass: common.PointerTo(""),
+			},
+		},
+		"invalid external traffic policy annotation is ignored": {
+			serviceType: corev1.ServiceTypeLoadBalancer,
+			gccSpec: v1alpha1.GatewayClassConfigSpec{
+				ExternalTrafficPolicy: &local,
+			},
+			annotations: map[string]string{
+				common.AnnotationExternalTrafficPolicy: "Nearest",
+			},
+			expTrafficPolicy: corev1.ServiceExternalTrafficPolicyLocal,
+		},
+		"only the external traffic policy is set for NodePort Services": {
+			serviceType: corev1.ServiceTypeNodePort,
+			gccSpec: v1alpha1.GatewayClassConfigSpec{
+				LoadBalancerClass:     common.PointerTo("metallb"),
+				ExternalTrafficPolicy: &local,
+			},
+			annotations: map[string]string{
+				common.AnnotationLoadBalancerIP: "10.0.0.10",
+			},
+			expTrafficPolicy: corev1.ServiceExternalTrafficPolicyLocal,
+		},
+		"nothing is set for ClusterIP Services": {
+			serviceType: corev1.ServiceTypeClusterIP,
+			gccSpec: v1alpha1.GatewayClassConfigSpec{
+				LoadBalancerClass:     common.PointerTo("metallb"),
+				ExternalTrafficPolicy: &local,
+			},
+			annotations: map[string]string{
+				common.AnnotationLoadBalancerIP: "10.0.0.10",
+			},
+		},
+	}
+
+	for name, tc := range cases {
+		name, tc := name, tc
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			gateway := gwv1beta1.Gateway{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:        name,
+					Namespace:   namespace,
+					Annotations: tc.annotations,
+				},
+				Spec: gwv1beta1.GatewaySpec{
+					Listeners: listeners,
+				},
+			}
+			gcc := v1alpha1.GatewayClassConfig{Spec: tc.gccSpec}
+			gcc.Spec.ServiceType = &tc.serviceType
+
+			service := (&Gatekeeper{}).service(gateway, gcc)
+			require.Equal(t, tc.expLoadBalancerClass, service.Spec.LoadBalancerClass)
+			require.Equal(t, tc.expLoadBalancerIP, service.Spec.LoadBalancerIP)
+			require.Equal(t, tc.expTrafficPolicy, service.Spec.ExternalTrafficPolicy)
+		})
+	}
+}
+
+func TestMergeServiceInto_LoadBalancerClass(t *testing.T) {
+	t.Parallel()
+
+	service := func(serviceType corev1.ServiceType, class *string) *corev1.Service {
+		return &corev1.Service{Spec: corev1.ServiceSpec{Type: serviceType, LoadBalancerClass: class}}
+	}
+	cases := map[string]struct {
+		existing *corev1.Service
+		desired  *corev1.Service
+		expClass *string
+	}{
+		"the class of a LoadBalancer Service is kept": {
+			existing: service(corev1.ServiceTypeLoadBalancer, common.PointerTo("metallb")),
+			desired:  service(corev1.ServiceTypeLoadBalancer, common.PointerTo("service.k8s.aws/nlb")),
+			expClass: common.PointerTo("metallb"),
+		},
+		"a class isn't added to a LoadBalancer Service": {
+			existing: service(corev1.ServiceTypeLoadBalancer, nil),
+			desired:  service(corev1.ServiceTypeLoadBalancer, common.PointerTo("metallb")),
+		},
+		"the class is removed when the Service is no longer a LoadBalancer Service": {
+			existing: service(corev1.ServiceTypeLoadBalancer, common.PointerTo("metallb")),
+			desired:  service(corev1.ServiceTypeClusterIP, nil),
+		},
+		"the class is set when the Service becomes a LoadBalancer Service": {
+			existing: service(corev1.ServiceTypeClusterIP, nil),
+			desired:  service(corev1.ServiceTypeLoadBalancer, common.PointerTo("metallb")),
+			expClass: common.PointerTo("metallb"),
+		},
+	}
+	for name, tc := range cases {
+		name, tc := name, tc
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			mergeServiceInto(tc.existing, tc.desired)
+			require.Equal(t, tc.expClass, tc.existing.Spec.LoadBalancerClass)
+		})
+	}
+}
@@ -29,6 +29,10 @@ const (
 	RouteAuthFilter          string = "routeauthfilter"
 	GatewayPolicy            string = "gatewaypolicy"
 	Registration             string = "registration"
+	ExternalService          string = "externalservice"
+	ConsulSnapshotSchedule   string = "consulsnapshotschedule"
+	ConnectCARotation        string = "connectcarotation"
+	TelemetryCollectorConfig string = "telemetrycollectorconfig"
 
 	Global                 string = "global"
 	Mesh                   string = "mesh"
@@ -40,8 +44,15 @@ const (
 	DatacenterKey    string = "consul.hashicorp.com/source-datacenter"
 	MigrateEntryKey  string = "consul.hashicorp.com/migrate-entry"
 	MigrateEntryTrue string = "true"
+	DryRunKey        string = "consul.hashicorp.com/dry-run"
 	SourceValue      string = "kubernetes"
 
+	// RouteRateLimitsKey is the meta key of the service-defaults config entries whose route rate
+	// limits are managed by the API gateway controller. RouteRateLimitsCreated marks the entries it
+	// created to hold them, which ServiceDefaults resources take over.
+	RouteRateLimitsKey     string = "consul.hashicorp.com/route-rate-limits"
+	RouteRateLimitsCreated string = "created"
+
 	DefaultPartitionName = "default"
 	DefaultNamespaceName = "default"
 	DefaultPeerName      = "local"
@@ -49,6 +49,11 @@ type ConfigEntryResource interface {
 	SyncedCondition() (status corev1.ConditionStatus, reason, message string)
 	// SyncedConditionStatus returns the status of the synced condition.
 	SyncedConditionStatus() corev1.ConditionStatus
+	// SetDryRun records the write to Consul, Create, Update or None, and the config entry
+	// that the resource would be written as in dry-run mode. It returns whether the recorded result changed.
+	SetDryRun(operation, configEntry string) bool
+	// ClearDryRun removes the dry-run result once the resource is written to Consul.
+	ClearDryRun()
 	// ToConsul converts the resource to the corresponding Consul API definition.
 	// Its return type is the generic ConfigEntry but a specific config entry
 	// type should be constructed e.g. ServiceConfigEntry.
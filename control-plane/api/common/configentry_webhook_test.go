@@ -321,6 +321,12 @@ func (in *mockConfigEntry) SyncedConditionStatus() corev1.ConditionStatus {
 	return corev1.ConditionTrue
 }
 
+func (in *mockConfigEntry) SetDryRun(_ string, _ string) bool {
+	return false
+}
+
+func (in *mockConfigEntry) ClearDryRun() {}
+
 func (in *mockConfigEntry) ToConsul(string) capi.ConfigEntry {
 	return &capi.ServiceConfigEntry{}
 }
@@ -4,6 +4,7 @@
 package v1alpha1
 
 import (
+	autoscalingv2 "k8s.io/api/autoscaling/v2"
 	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 )
@@ -42,6 +43,18 @@ type GatewayClassConfigSpec struct {
 	// +kubebuilder:validation:Enum=ClusterIP;NodePort;LoadBalancer
 	ServiceType *corev1.ServiceType `json:"serviceType,omitempty"`
 
+	// LoadBalancerClass is the class of the load balancer implementation of LoadBalancer Services
+	// created for gateways, e.g. to select MetalLB or a cloud load balancer controller.
+	// It can be overridden per Gateway with the consul.hashicorp.com/gateway-load-balancer-class annotation.
+	// The class of a Service can't be changed, so changes only apply to the Services created afterwards.
+	LoadBalancerClass *string `json:"loadBalancerClass,omitempty"`
+
+	// ExternalTrafficPolicy is the external traffic policy of LoadBalancer and NodePort Services
+	// created for gateways. Set it to Local to preserve the client source IP.
+	// It can be overridden per Gateway with the consul.hashicorp.com/gateway-external-traffic-policy annotation.
+	// +kubebuilder:validation:Enum=Cluster;Local
+	ExternalTrafficPolicy *corev1.ServiceExternalTrafficPolicy `json:"externalTrafficPolicy,omitempty"`
+
 	// NodeSelector is a selector which must be true for the pod to fit on a node.
 	// Selector which must match a node's labels for the pod to be scheduled on that node.
 	// More info: https://kubernetes.io/docs/concepts/configuration/assign-pod-node/
@@ -51,6 +64,16 @@ type GatewayClassConfigSpec struct {
 	// More Info: https://kubernetes.io/docs/concepts/scheduling-eviction/taint-and-toleration/
 	Tolerations []corev1.Toleration `json:"tolerations,omitempty"`
 
+	// Affinity is the scheduling constraints of gateway pods. If it is not set, gateway pods
+	// prefer to be scheduled on different nodes than the other pods of the same gateway.
+	// More info: https://kubernetes.io/docs/concepts/scheduling-eviction/assign-pod-node/#affinity-and-anti-affinity
+	Affinity *corev1.Affinity `json:"affinity,omitempty"`
+
+	// TopologySpreadConstraints describe how gateway pods are spread across topology domains, e.g. zones.
+	// A constraint without a labelSelector selects the pods of the same gateway.
+	// More info: https://kubernetes.io/docs/concepts/scheduling-eviction/topology-spread-constraints/
+	TopologySpreadConstraints []corev1.TopologySpreadConstraint `json:"topologySpreadConstraints,omitempty"`
+
 	// Deployment defines the deployment configuration for the gateway.
 	DeploymentSpec DeploymentSpec `json:"deployment,omitempty"`
 
@@ -91,6 +114,32 @@ type DeploymentSpec struct {
 
 	// Resources defines the resource requirements for the gateway.
 	Resources *corev1.ResourceRequirements `json:"resources,omitempty"`
+
+	// Autoscaling, if set, creates a HorizontalPodAutoscaler for the deployment of each gateway that
+	// manages its number of replicas instead of the instance counts above.
+	Autoscaling *AutoscalingSpec `json:"autoscaling,omitempty"`
+}
+
+// +k8s:deepcopy-gen=true
+
+// AutoscalingSpec configures the HorizontalPodAutoscaler of the deployment of a gateway.
+type AutoscalingSpec struct {
+	// +kubebuilder:validation:Minimum=1
+	// Minimum number of gateway instances the autoscaler scales down to. Defaults to 1.
+	MinReplicas *int32 `json:"minReplicas,omitempty"`
+	// +kubebuilder:validation:Minimum=1
+	// Maximum number of gateway instances the autoscaler scales up to.
+	MaxReplicas int32 `json:"maxReplicas"`
+	// +kubebuilder:validation:Minimum=1
+	// Target average CPU utilization of the gateway pods, as a percentage of their CPU requests.
+	TargetCPUUtilizationPercentage *int32 `json:"targetCPUUtilizationPercentage,omitempty"`
+	// +kubebuilder:validation:Minimum=1
+	// Target average memory utilization of the gateway pods, as a percentage of their memory requests.
+	TargetMemoryUtilizationPercentage *int32 `json:"targetMemoryUtilizationPercentage,omitempty"`
+	// Additional metrics to scale on, e.g. custom or external metrics. If no metrics or targets are set,
+	// the autoscaler targets an average CPU utilization of 80%.
+	// More info: https://kubernetes.io/docs/tasks/run-application/horizontal-pod-autoscale/
+	Metrics []autoscalingv2.MetricSpec `json:"metrics,omitempty"`
 }
 
 // +k8s:deepcopy-gen=true
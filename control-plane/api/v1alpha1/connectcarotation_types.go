@@ -0,0 +1,154 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package v1alpha1
+
+import (
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/validation/field"
+)
+
+const (
+	// ConditionWorkloadsRotated specifies that every selected workload has been restarted
+	// since the active Connect CA root was observed, so that its sidecars use leaf
+	// certificates signed by the active root.
+	ConditionWorkloadsRotated ConditionType = "WorkloadsRotated"
+
+	// DefaultCARotationPollInterval is how often the Connect CA roots are read from Consul
+	// if PollInterval is not set.
+	DefaultCARotationPollInterval = time.Minute
+)
+
+func init() {
+	SchemeBuilder.Register(&ConnectCARotation{}, &ConnectCARotationList{})
+}
+
+// +genclient
+// +kubebuilder:object:root=true
+// +kubebuilder:subresource:status
+// +kubebuilder:printcolumn:name="Synced",type="string",JSONPath=".status.conditions[?(@.type==\"Synced\")].status",description="The sync status of the resource"
+// +kubebuilder:printcolumn:name="Rotated",type="string",JSONPath=".status.conditions[?(@.type==\"WorkloadsRotated\")].status",description="Whether all workloads use the active CA root"
+// +kubebuilder:printcolumn:name="Active Root",type="string",JSONPath=".status.activeRootID",description="The ID of the active Connect CA root"
+// +kubebuilder:printcolumn:name="Age",type="date",JSONPath=".metadata.creationTimestamp"
+
+// ConnectCARotation watches the Connect CA roots in Consul and, when the active root
+// rotates, performs a staged rolling restart of the injected workloads in its namespace
+// so that their sidecars request new leaf certificates signed by the active root.
+type ConnectCARotation struct {
+	// Standard Kubernetes resource metadata.
+	metav1.TypeMeta `json:",inline"`
+
+	// Standard object's metadata.
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	// Spec defines the desired state of ConnectCARotation.
+	Spec ConnectCARotationSpec `json:"spec,omitempty"`
+
+	Status ConnectCARotationStatus `json:"status,omitempty"`
+}
+
+// +k8s:deepcopy-gen=true
+
+// ConnectCARotationSpec specifies the desired state of the ConnectCARotation CRD.
+type ConnectCARotationSpec struct {
+	// Selector selects the workloads to restart by the labels of their pods.
+	// If it is not set, all workloads with injected pods in the namespace are restarted.
+	// +optional
+	Selector *metav1.LabelSelector `json:"selector,omitempty"`
+	// MaxConcurrentRestarts is the number of workloads that are restarted at the same time.
+	// The next workload is restarted once a restarted workload has finished rolling out.
+	// Defaults to 1.
+	// +optional
+	MaxConcurrentRestarts *int32 `json:"maxConcurrentRestarts,omitempty"`
+	// PollInterval is how often the Connect CA roots are read from Consul, e.g. "30s".
+	// Defaults to "1m".
+	// +optional
+	PollInterval string `json:"pollInterval,omitempty"`
+	// Paused stops restarting workloads. Rotations are still detected and reported in the status,
+	// and restarts resume from where they stopped once the rotation is unpaused.
+	// +optional
+	Paused bool `json:"paused,omitempty"`
+}
+
+// ConnectCARotationStatus defines the observed state of ConnectCARotation.
+type ConnectCARotationStatus struct {
+	// Conditions indicate the latest available observations of a resource's current state.
+	// +optional
+	// +patchMergeKey=type
+	// +patchStrategy=merge
+	Conditions Conditions `json:"conditions,omitempty" patchStrategy:"merge" patchMergeKey:"type"`
+	// ActiveRootID is the ID of the active Connect CA root.
+	// +optional
+	ActiveRootID string `json:"activeRootID,omitempty"`
+	// PreviousRootID is the ID of the active Connect CA root before the last rotation.
+	// +optional
+	PreviousRootID string `json:"previousRootID,omitempty"`
+	// LastRotationTime is when the last rotation of the active root was observed.
+	// Workloads with pods created before this time are restarted.
+	// +optional
+	LastRotationTime *metav1.Time `json:"lastRotationTime,omitempty"`
+	// PendingWorkloads are the workloads that are waiting to be restarted, e.g. "Deployment/web".
+	// +optional
+	PendingWorkloads []string `json:"pendingWorkloads,omitempty"`
+	// RestartingWorkloads are the workloads that have been restarted and are still rolling out.
+	// +optional
+	RestartingWorkloads []string `json:"restartingWorkloads,omitempty"`
+}
+
+// +kubebuilder:object:root=true
+
+// ConnectCARotationList is a list of ConnectCARotation resources.
+type ConnectCARotationList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata"`
+
+	// Items is the list of ConnectCARotations.
+	Items []ConnectCARotation `json:"items"`
+}
+
+// MaxConcurrentRestarts returns the number of workloads that are restarted at the same time.
+func (in *ConnectCARotation) MaxConcurrentRestarts() int {
+	if in.Spec.MaxConcurrentRestarts == nil {
+		return 1
+	}
+	return int(*in.Spec.MaxConcurrentRestarts)
+}
+
+// PollInterval returns how often the Connect CA roots are read from Consul.
+// It must only be called on a valid ConnectCARotation.
+func (in *ConnectCARotation) PollInterval() time.Duration {
+	if in.Spec.PollInterval == "" {
+		return DefaultCARotationPollInterval
+	}
+	interval, _ := time.ParseDuration(in.Spec.PollInterval)
+	return interval
+}
+
+// Validate checks that workloads can be restarted for the ConnectCARotation.
+func (in *ConnectCARotation) Validate() error {
+	var errs field.ErrorList
+	path := field.NewPath("spec")
+
+	if in.Spec.MaxConcurrentRestarts != nil && *in.Spec.MaxConcurrentRestarts < 1 {
+		errs = append(errs, field.Invalid(path.Child("maxConcurrentRestarts"), *in.Spec.MaxConcurrentRestarts, "maxConcurrentRestarts must be at least 1"))
+	}
+	errs = append(errs, validateDuration(path.Child("pollInterval"), in.Spec.PollInterval)...)
+	if in.Spec.PollInterval != "" {
+		if interval, err := time.ParseDuration(in.Spec.PollInterval); err == nil && interval <= 0 {
+			errs = append(errs, field.Invalid(path.Child("pollInterval"), in.Spec.PollInterval, "pollInterval must be positive"))
+		}
+	}
+	if in.Spec.Selector != nil {
+		if _, err := metav1.LabelSelectorAsSelector(in.Spec.Selector); err != nil {
+			errs = append(errs, field.Invalid(path.Child("selector"), in.Spec.Selector, err.Error()))
+		}
+	}
+
+	return errs.ToAggregate()
+}
+
+func (in *ConnectCARotation) KubernetesName() string {
+	return in.ObjectMeta.Name
+}
@@ -0,0 +1,66 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package v1alpha1
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/utils/ptr"
+)
+
+func TestConnectCARotation_Defaults(t *testing.T) {
+	rotation := &ConnectCARotation{}
+	require.Equal(t, 1, rotation.MaxConcurrentRestarts())
+	require.Equal(t, DefaultCARotationPollInterval, rotation.PollInterval())
+
+	rotation.Spec = ConnectCARotationSpec{MaxConcurrentRestarts: ptr.To(int32(3)), PollInterval: "30s"}
+	require.Equal(t, 3, rotation.MaxConcurrentRestarts())
+	require.Equal(t, 30*time.Second, rotation.PollInterval())
+}
+
+func TestConnectCARotation_Validate(t *testing.T) {
+	cases := map[string]struct {
+		spec   ConnectCARotationSpec
+		expErr string
+	}{
+		"empty spec": {},
+		"valid": {
+			spec: ConnectCARotationSpec{
+				Selector:              &metav1.LabelSelector{MatchLabels: map[string]string{"app": "web"}},
+				MaxConcurrentRestarts: ptr.To(int32(2)),
+				PollInterval:          "30s",
+			},
+		},
+		"invalid max concurrent restarts": {
+			spec:   ConnectCARotationSpec{MaxConcurrentRestarts: ptr.To(int32(0))},
+			expErr: "spec.maxConcurrentRestarts: Invalid value: 0: maxConcurrentRestarts must be at least 1",
+		},
+		"invalid poll interval": {
+			spec:   ConnectCARotationSpec{PollInterval: "often"},
+			expErr: `spec.pollInterval: Invalid value: "often": time: invalid duration "often"`,
+		},
+		"negative poll interval": {
+			spec:   ConnectCARotationSpec{PollInterval: "-1m"},
+			expErr: `spec.pollInterval: Invalid value: "-1m": pollInterval must be positive`,
+		},
+	}
+
+	for name, c := range cases {
+		t.Run(name, func(t *testing.T) {
+			rotation := &ConnectCARotation{
+				ObjectMeta: metav1.ObjectMeta{Name: "rotation", Namespace: "default"},
+				Spec:       c.spec,
+			}
+			err := rotation.Validate()
+			if c.expErr == "" {
+				require.NoError(t, err)
+			} else {
+				require.EqualError(t, err, c.expErr)
+			}
+		})
+	}
+}
@@ -0,0 +1,235 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package v1alpha1
+
+import (
+	"fmt"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/validation/field"
+)
+
+const (
+	// ConditionSnapshotHealthy specifies that the snapshot agent reports to Consul
+	// that snapshots are being taken successfully.
+	ConditionSnapshotHealthy ConditionType = "SnapshotHealthy"
+)
+
+func init() {
+	SchemeBuilder.Register(&ConsulSnapshotSchedule{}, &ConsulSnapshotScheduleList{})
+}
+
+// +genclient
+// +kubebuilder:object:root=true
+// +kubebuilder:subresource:status
+// +kubebuilder:printcolumn:name="Synced",type="string",JSONPath=".status.conditions[?(@.type==\"Synced\")].status",description="The sync status of the resource"
+// +kubebuilder:printcolumn:name="Last Snapshot",type="date",JSONPath=".status.lastSuccessfulSnapshotTime",description="The last time a successful snapshot was observed"
+// +kubebuilder:printcolumn:name="Age",type="date",JSONPath=".metadata.creationTimestamp"
+
+// ConsulSnapshotSchedule deploys a Consul Enterprise snapshot agent that periodically
+// saves snapshots of the Consul servers' state to S3, Google Cloud Storage or Azure Blob Storage.
+type ConsulSnapshotSchedule struct {
+	// Standard Kubernetes resource metadata.
+	metav1.TypeMeta `json:",inline"`
+
+	// Standard object's metadata.
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	// Spec defines the desired state of ConsulSnapshotSchedule.
+	Spec ConsulSnapshotScheduleSpec `json:"spec,omitempty"`
+
+	Status ConsulSnapshotScheduleStatus `json:"status,omitempty"`
+}
+
+// ConsulSnapshotScheduleStatus defines the observed state of ConsulSnapshotSchedule.
+type ConsulSnapshotScheduleStatus struct {
+	// Conditions indicate the latest available observations of a resource's current state.
+	// +optional
+	// +patchMergeKey=type
+	// +patchStrategy=merge
+	Conditions Conditions `json:"conditions,omitempty" patchStrategy:"merge" patchMergeKey:"type"`
+	// LastSyncedTime is the last time the snapshot agent deployment was successfully synced.
+	// +optional
+	LastSyncedTime *metav1.Time `json:"lastSyncedTime,omitempty"`
+	// LastSuccessfulSnapshotTime is the last time the controller recorded the snapshot agent
+	// reporting to Consul that snapshots are being taken successfully. The status is only
+	// written when it changes, so it is when the snapshot agent last became healthy.
+	// +optional
+	LastSuccessfulSnapshotTime *metav1.Time `json:"lastSuccessfulSnapshotTime,omitempty"`
+}
+
+// +k8s:deepcopy-gen=true
+
+// ConsulSnapshotScheduleSpec specifies the desired state of the ConsulSnapshotSchedule CRD.
+type ConsulSnapshotScheduleSpec struct {
+	// Interval at which to take snapshots, e.g. "1h".
+	Interval string `json:"interval"`
+	// Retain is the number of snapshots to keep in storage. Older snapshots are deleted.
+	// Set to 0 to keep all snapshots. Defaults to 30.
+	// +optional
+	Retain *int `json:"retain,omitempty"`
+	// Stale allows any Consul server, rather than only the leader, to take snapshots.
+	// This is useful if the leader is under heavy load.
+	// +optional
+	Stale bool `json:"stale,omitempty"`
+	// Token references the key of a Kubernetes secret holding the ACL token
+	// the snapshot agent uses to talk to Consul. It is required if ACLs are enabled.
+	// +optional
+	Token *corev1.SecretKeySelector `json:"token,omitempty"`
+	// Storage configures where snapshots are saved. Exactly one backend must be set.
+	Storage SnapshotStorage `json:"storage"`
+	// Resources are the resource requirements of the snapshot agent container.
+	// +optional
+	Resources corev1.ResourceRequirements `json:"resources,omitempty"`
+}
+
+// SnapshotStorage configures where snapshots are saved.
+type SnapshotStorage struct {
+	// S3 saves snapshots in an Amazon S3 or S3-compatible bucket.
+	// +optional
+	S3 *S3SnapshotStorage `json:"s3,omitempty"`
+	// GCS saves snapshots in a Google Cloud Storage bucket.
+	// +optional
+	GCS *GCSSnapshotStorage `json:"gcs,omitempty"`
+	// AzureBlob saves snapshots in an Azure Blob Storage container.
+	// +optional
+	AzureBlob *AzureBlobSnapshotStorage `json:"azureBlob,omitempty"`
+}
+
+// S3SnapshotStorage saves snapshots in an Amazon S3 or S3-compatible bucket.
+type S3SnapshotStorage struct {
+	// Region is the AWS region of the bucket.
+	Region string `json:"region"`
+	// Bucket is the name of the bucket.
+	Bucket string `json:"bucket"`
+	// KeyPrefix is the prefix of the snapshot object keys. Defaults to "consul-snapshot".
+	// +optional
+	KeyPrefix string `json:"keyPrefix,omitempty"`
+	// Endpoint is the URL of an S3-compatible API to use instead of Amazon S3.
+	// +optional
+	Endpoint string `json:"endpoint,omitempty"`
+	// ServerSideEncryption enables server-side encryption with an S3-managed key.
+	// +optional
+	ServerSideEncryption bool `json:"serverSideEncryption,omitempty"`
+	// AccessKeyID references the key of a Kubernetes secret holding the AWS access key ID.
+	// If it is not set, the default AWS credential chain is used, e.g. IAM roles for service accounts.
+	// +optional
+	AccessKeyID *corev1.SecretKeySelector `json:"accessKeyID,omitempty"`
+	// SecretAccessKey references the key of a Kubernetes secret holding the AWS secret access key.
+	// It must be set if AccessKeyID is set.
+	// +optional
+	SecretAccessKey *corev1.SecretKeySelector `json:"secretAccessKey,omitempty"`
+}
+
+// GCSSnapshotStorage saves snapshots in a Google Cloud Storage bucket.
+type GCSSnapshotStorage struct {
+	// Bucket is the name of the bucket.
+	Bucket string `json:"bucket"`
+	// Credentials references the key of a Kubernetes secret holding a Google service account key file.
+	// If it is not set, the default Google credentials are used, e.g. Workload Identity.
+	// +optional
+	Credentials *corev1.SecretKeySelector `json:"credentials,omitempty"`
+}
+
+// AzureBlobSnapshotStorage saves snapshots in an Azure Blob Storage container.
+type AzureBlobSnapshotStorage struct {
+	// AccountName is the name of the storage account.
+	AccountName string `json:"accountName"`
+	// AccountKey references the key of a Kubernetes secret holding the storage account key.
+	AccountKey corev1.SecretKeySelector `json:"accountKey"`
+	// ContainerName is the name of the container snapshots are saved in.
+	ContainerName string `json:"containerName"`
+	// Environment is the Azure environment, e.g. "AZUREUSGOVERNMENTCLOUD".
+	// Defaults to the public cloud.
+	// +optional
+	Environment string `json:"environment,omitempty"`
+}
+
+// +kubebuilder:object:root=true
+
+// ConsulSnapshotScheduleList is a list of ConsulSnapshotSchedule resources.
+type ConsulSnapshotScheduleList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata"`
+
+	// Items is the list of ConsulSnapshotSchedules.
+	Items []ConsulSnapshotSchedule `json:"items"`
+}
+
+// DeploymentName returns the name of the Deployment running the snapshot agent.
+func (in *ConsulSnapshotSchedule) DeploymentName() string {
+	return in.Name + "-snapshot-agent"
+}
+
+// ServiceName returns the name the snapshot agent registers itself as in Consul.
+// It is unique per schedule so that the health of each agent can be told apart.
+func (in *ConsulSnapshotSchedule) ServiceName() string {
+	return fmt.Sprintf("consul-snapshot-%s-%s", in.Namespace, in.Name)
+}
+
+// LockKey returns the Consul KV key snapshot agents of this schedule use for leader election.
+// It is unique per schedule so that agents of different schedules don't block each other.
+func (in *ConsulSnapshotSchedule) LockKey() string {
+	return fmt.Sprintf("consul-snapshot/%s/%s/lock", in.Namespace, in.Name)
+}
+
+// Validate checks that a snapshot agent can be deployed for the ConsulSnapshotSchedule.
+func (in *ConsulSnapshotSchedule) Validate() error {
+	var errs field.ErrorList
+	path := field.NewPath("spec")
+
+	if in.Spec.Interval == "" {
+		errs = append(errs, field.Required(path.Child("interval"), "interval must be set"))
+	}
+	errs = append(errs, validateDuration(path.Child("interval"), in.Spec.Interval)...)
+	if in.Spec.Retain != nil && *in.Spec.Retain < 0 {
+		errs = append(errs, field.Invalid(path.Child("retain"), *in.Spec.Retain, "retain must not be negative"))
+	}
+
+	storagePath := path.Child("storage")
+	storage := in.Spec.Storage
+	backends := 0
+	if s3 := storage.S3; s3 != nil {
+		backends++
+		s3Path := storagePath.Child("s3")
+		if s3.Region == "" {
+			errs = append(errs, field.Required(s3Path.Child("region"), "region must be set"))
+		}
+		if s3.Bucket == "" {
+			errs = append(errs, field.Required(s3Path.Child("bucket"), "bucket must be set"))
+		}
+		if (s3.AccessKeyID == nil) != (s3.SecretAccessKey == nil) {
+			errs = append(errs, field.Invalid(s3Path, "", "accessKeyID and secretAccessKey must be set together"))
+		}
+	}
+	if gcs := storage.GCS; gcs != nil {
+		backends++
+		if gcs.Bucket == "" {
+			errs = append(errs, field.Required(storagePath.Child("gcs", "bucket"), "bucket must be set"))
+		}
+	}
+	if azure := storage.AzureBlob; azure != nil {
+		backends++
+		azurePath := storagePath.Child("azureBlob")
+		if azure.AccountName == "" {
+			errs = append(errs, field.Required(azurePath.Child("accountName"), "accountName must be set"))
+		}
+		if azure.AccountKey.Name == "" || azure.AccountKey.Key == "" {
+			errs = append(errs, field.Required(azurePath.Child("accountKey"), "accountKey must reference a secret key"))
+		}
+		if azure.ContainerName == "" {
+			errs = append(errs, field.Required(azurePath.Child("containerName"), "containerName must be set"))
+		}
+	}
+	if backends != 1 {
+		errs = append(errs, field.Invalid(storagePath, backends, "exactly one of s3, gcs or azureBlob must be set"))
+	}
+
+	return errs.ToAggregate()
+}
+
+func (in *ConsulSnapshotSchedule) KubernetesName() string {
+	return in.ObjectMeta.Name
+}
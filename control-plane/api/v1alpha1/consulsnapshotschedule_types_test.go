@@ -0,0 +1,107 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package v1alpha1
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/utils/ptr"
+)
+
+func TestConsulSnapshotSchedule_Names(t *testing.T) {
+	schedule := &ConsulSnapshotSchedule{
+		ObjectMeta: metav1.ObjectMeta{Name: "backup", Namespace: "consul"},
+	}
+	require.Equal(t, "backup-snapshot-agent", schedule.DeploymentName())
+	require.Equal(t, "consul-snapshot-consul-backup", schedule.ServiceName())
+	require.Equal(t, "consul-snapshot/consul/backup/lock", schedule.LockKey())
+}
+
+func TestConsulSnapshotSchedule_Validate(t *testing.T) {
+	secretKey := corev1.SecretKeySelector{LocalObjectReference: corev1.LocalObjectReference{Name: "secret"}, Key: "key"}
+	cases := map[string]struct {
+		spec   ConsulSnapshotScheduleSpec
+		expErr string
+	}{
+		"valid s3": {
+			spec: ConsulSnapshotScheduleSpec{
+				Interval: "1h",
+				Retain:   ptr.To(0),
+				Storage:  SnapshotStorage{S3: &S3SnapshotStorage{Region: "us-east-1", Bucket: "backups"}},
+			},
+		},
+		"valid gcs": {
+			spec: ConsulSnapshotScheduleSpec{
+				Interval: "1h",
+				Storage:  SnapshotStorage{GCS: &GCSSnapshotStorage{Bucket: "backups"}},
+			},
+		},
+		"valid azure blob": {
+			spec: ConsulSnapshotScheduleSpec{
+				Interval: "1h",
+				Storage: SnapshotStorage{AzureBlob: &AzureBlobSnapshotStorage{
+					AccountName:   "account",
+					AccountKey:    secretKey,
+					ContainerName: "backups",
+				}},
+			},
+		},
+		"invalid interval and retain": {
+			spec: ConsulSnapshotScheduleSpec{
+				Interval: "hourly",
+				Retain:   ptr.To(-1),
+				Storage:  SnapshotStorage{GCS: &GCSSnapshotStorage{Bucket: "backups"}},
+			},
+			expErr: `[spec.interval: Invalid value: "hourly": time: invalid duration "hourly", spec.retain: Invalid value: -1: retain must not be negative]`,
+		},
+		"no storage": {
+			spec: ConsulSnapshotScheduleSpec{
+				Interval: "1h",
+			},
+			expErr: "spec.storage: Invalid value: 0: exactly one of s3, gcs or azureBlob must be set",
+		},
+		"multiple storage backends": {
+			spec: ConsulSnapshotScheduleSpec{
+				Interval: "1h",
+				Storage: SnapshotStorage{
+					S3:  &S3SnapshotStorage{Region: "us-east-1", Bucket: "backups"},
+					GCS: &GCSSnapshotStorage{Bucket: "backups"},
+				},
+			},
+			expErr: "spec.storage: Invalid value: 2: exactly one of s3, gcs or azureBlob must be set",
+		},
+		"incomplete s3": {
+			spec: ConsulSnapshotScheduleSpec{
+				Interval: "1h",
+				Storage:  SnapshotStorage{S3: &S3SnapshotStorage{AccessKeyID: &secretKey}},
+			},
+			expErr: `[spec.storage.s3.region: Required value: region must be set, spec.storage.s3.bucket: Required value: bucket must be set, spec.storage.s3: Invalid value: "": accessKeyID and secretAccessKey must be set together]`,
+		},
+		"incomplete azure blob": {
+			spec: ConsulSnapshotScheduleSpec{
+				Interval: "1h",
+				Storage:  SnapshotStorage{AzureBlob: &AzureBlobSnapshotStorage{}},
+			},
+			expErr: "[spec.storage.azureBlob.accountName: Required value: accountName must be set, spec.storage.azureBlob.accountKey: Required value: accountKey must reference a secret key, spec.storage.azureBlob.containerName: Required value: containerName must be set]",
+		},
+	}
+
+	for name, c := range cases {
+		t.Run(name, func(t *testing.T) {
+			schedule := &ConsulSnapshotSchedule{
+				ObjectMeta: metav1.ObjectMeta{Name: "backup", Namespace: "default"},
+				Spec:       c.spec,
+			}
+			err := schedule.Validate()
+			if c.expErr == "" {
+				require.NoError(t, err)
+			} else {
+				require.EqualError(t, err, c.expErr)
+			}
+		})
+	}
+}
@@ -161,15 +161,7 @@ func (c *ControlPlaneRequestLimit) KubernetesName() string {
 
 // SetSyncedCondition updates the synced condition.
 func (c *ControlPlaneRequestLimit) SetSyncedCondition(status corev1.ConditionStatus, reason, message string) {
-	c.Status.Conditions = Conditions{
-		{
-			Type:               ConditionSynced,
-			Status:             status,
-			LastTransitionTime: metav1.Now(),
-			Reason:             reason,
-			Message:            message,
-		},
-	}
+	c.Status.SetSyncedCondition(status, reason, message)
 }
 
 // SetLastSyncedTime updates the last synced time.
@@ -0,0 +1,38 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package v1alpha1
+
+import (
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/webhook/conversion"
+)
+
+// ConversionWebhookPath is the path of the webhook that converts config entry resources between the
+// versions of their CRDs.
+const ConversionWebhookPath = "/convert"
+
+// The v1alpha1 config entries are the hub versions that the conversion webhook converts through.
+// When a CRD gets a new version, the types of the new version implement conversion.Convertible by
+// converting to and from the v1alpha1 type, the CRD sets its conversion strategy to Webhook with
+// ConversionWebhookPath, and the new version becomes the storage version once all the stored
+// resources have been migrated.
+func (*ControlPlaneRequestLimit) Hub() {}
+func (*ExportedServices) Hub()         {}
+func (*IngressGateway) Hub()           {}
+func (*JWTProvider) Hub()              {}
+func (*Mesh) Hub()                     {}
+func (*ProxyDefaults) Hub()            {}
+func (*SamenessGroup) Hub()            {}
+func (*ServiceDefaults) Hub()          {}
+func (*ServiceIntentions) Hub()        {}
+func (*ServiceResolver) Hub()          {}
+func (*ServiceRouter) Hub()            {}
+func (*ServiceSplitter) Hub()          {}
+func (*TerminatingGateway) Hub()       {}
+
+// RegisterConversionWebhook registers the conversion webhook of the types of the manager's scheme
+// on the webhook server of the manager.
+func RegisterConversionWebhook(mgr ctrl.Manager) {
+	mgr.GetWebhookServer().Register(ConversionWebhookPath, conversion.NewWebhookHandler(mgr.GetScheme()))
+}
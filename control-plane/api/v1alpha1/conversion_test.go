@@ -0,0 +1,163 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package v1alpha1
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	apiextensionsv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	ctrlconversion "sigs.k8s.io/controller-runtime/pkg/conversion"
+	"sigs.k8s.io/controller-runtime/pkg/webhook/conversion"
+
+	"github.com/hashicorp/consul-k8s/control-plane/api/common"
+)
+
+// Test that every config entry is a hub so that new versions of its CRD can be converted through it.
+func TestConfigEntriesAreHubs(t *testing.T) {
+	s := runtime.NewScheme()
+	require.NoError(t, AddToScheme(s))
+
+	for gvk := range s.KnownTypes(GroupVersion) {
+		obj, err := s.New(GroupVersion.WithKind(gvk))
+		require.NoError(t, err)
+		if _, ok := obj.(common.ConfigEntryResource); !ok {
+			continue
+		}
+		_, isHub := obj.(ctrlconversion.Hub)
+		require.True(t, isHub, "%s must implement conversion.Hub", gvk)
+
+		// The CRDs have a single version, so there is nothing to convert yet.
+		convertible, err := conversion.IsConvertible(s, obj)
+		require.NoError(t, err)
+		require.False(t, convertible)
+	}
+}
+
+// Test that a config entry converted to a newer version of its CRD and back is unchanged, using a
+// version of ServiceDefaults that lists its destinations.
+func TestConversionWebhook_RoundTrip(t *testing.T) {
+	s := runtime.NewScheme()
+	require.NoError(t, AddToScheme(s))
+	s.AddKnownTypeWithName(testServiceDefaultsGVK, &testServiceDefaults{})
+	server := httptest.NewServer(conversion.NewWebhookHandler(s))
+	defer server.Close()
+
+	original := &ServiceDefaults{
+		TypeMeta: metav1.TypeMeta{APIVersion: GroupVersion.String(), Kind: "ServiceDefaults"},
+		ObjectMeta: metav1.ObjectMeta{
+			Name:        "web",
+			Namespace:   "default",
+			Annotations: map[string]string{"consul.hashicorp.com/migrate-entry": "true"},
+			Finalizers:  []string{"finalizers.consul.hashicorp.com"},
+		},
+		Spec: ServiceDefaultsSpec{
+			Protocol: "http",
+			Destination: &ServiceDefaultsDestination{
+				Addresses: []string{"api.example.com"},
+				Port:      443,
+			},
+		},
+		Status: Status{
+			Conditions: Conditions{{Type: ConditionSynced, Status: "True"}},
+		},
+	}
+
+	var converted testServiceDefaults
+	convert(t, server.URL, original, testServiceDefaultsGVK.GroupVersion().String(), &converted)
+	require.Equal(t, testServiceDefaultsGVK.GroupVersion().String(), converted.APIVersion)
+	require.Equal(t, original.ObjectMeta, converted.ObjectMeta)
+	require.Nil(t, converted.Spec.Destination)
+	require.Equal(t, []ServiceDefaultsDestination{*original.Spec.Destination}, converted.Spec.Destinations)
+
+	var roundTripped ServiceDefaults
+	convert(t, server.URL, &converted, GroupVersion.String(), &roundTripped)
+	require.Equal(t, original, &roundTripped)
+}
+
+// convert converts the object to the API version with the conversion webhook and decodes the result into out.
+func convert(t *testing.T, url string, obj runtime.Object, apiVersion string, out interface{}) {
+	t.Helper()
+	raw, err := json.Marshal(obj)
+	require.NoError(t, err)
+	review, err := json.Marshal(apiextensionsv1.ConversionReview{
+		TypeMeta: metav1.TypeMeta{APIVersion: apiextensionsv1.SchemeGroupVersion.String(), Kind: "ConversionReview"},
+		Request: &apiextensionsv1.ConversionRequest{
+			UID:               types.UID("uid"),
+			DesiredAPIVersion: apiVersion,
+			Objects:           []runtime.RawExtension{{Raw: raw}},
+		},
+	})
+	require.NoError(t, err)
+
+	resp, err := http.Post(url, "application/json", bytes.NewReader(review))
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+
+	var result apiextensionsv1.ConversionReview
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&result))
+	require.Equal(t, metav1.StatusSuccess, result.Response.Result.Status, result.Response.Result.Message)
+	require.Len(t, result.Response.ConvertedObjects, 1)
+	require.NoError(t, json.Unmarshal(result.Response.ConvertedObjects[0].Raw, out))
+}
+
+var testServiceDefaultsGVK = schema.GroupVersionKind{Group: ConsulHashicorpGroup, Version: "v1alpha2", Kind: "ServiceDefaults"}
+
+// testServiceDefaults is a version of ServiceDefaults that replaces its destination with a list of destinations.
+type testServiceDefaults struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec   testServiceDefaultsSpec `json:"spec,omitempty"`
+	Status `json:"status,omitempty"`
+}
+
+type testServiceDefaultsSpec struct {
+	ServiceDefaultsSpec `json:",inline"`
+
+	Destinations []ServiceDefaultsDestination `json:"destinations,omitempty"`
+}
+
+func (in *testServiceDefaults) ConvertTo(dst ctrlconversion.Hub) error {
+	hub := dst.(*ServiceDefaults)
+	hub.ObjectMeta = *in.ObjectMeta.DeepCopy()
+	in.Spec.ServiceDefaultsSpec.DeepCopyInto(&hub.Spec)
+	if len(in.Spec.Destinations) > 0 {
+		hub.Spec.Destination = in.Spec.Destinations[0].DeepCopy()
+	}
+	in.Status.DeepCopyInto(&hub.Status)
+	return nil
+}
+
+func (in *testServiceDefaults) ConvertFrom(src ctrlconversion.Hub) error {
+	hub := src.(*ServiceDefaults)
+	in.ObjectMeta = *hub.ObjectMeta.DeepCopy()
+	hub.Spec.DeepCopyInto(&in.Spec.ServiceDefaultsSpec)
+	in.Spec.Destination = nil
+	if hub.Spec.Destination != nil {
+		in.Spec.Destinations = []ServiceDefaultsDestination{*hub.Spec.Destination.DeepCopy()}
+	}
+	hub.Status.DeepCopyInto(&in.Status)
+	return nil
+}
+
+func (in *testServiceDefaults) DeepCopyObject() runtime.Object {
+	out := &testServiceDefaults{TypeMeta: in.TypeMeta}
+	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
+	in.Spec.ServiceDefaultsSpec.DeepCopyInto(&out.Spec.ServiceDefaultsSpec)
+	for _, d := range in.Spec.Destinations {
+		out.Spec.Destinations = append(out.Spec.Destinations, *d.DeepCopy())
+	}
+	in.Status.DeepCopyInto(&out.Status)
+	return out
+}
@@ -126,15 +126,7 @@ func (in *ExportedServices) KubernetesName() string {
 }
 
 func (in *ExportedServices) SetSyncedCondition(status corev1.ConditionStatus, reason, message string) {
-	in.Status.Conditions = Conditions{
-		{
-			Type:               ConditionSynced,
-			Status:             status,
-			LastTransitionTime: metav1.Now(),
-			Reason:             reason,
-			Message:            message,
-		},
-	}
+	in.Status.SetSyncedCondition(status, reason, message)
 }
 
 func (in *ExportedServices) SetLastSyncedTime(time *metav1.Time) {
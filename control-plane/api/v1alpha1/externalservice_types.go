@@ -0,0 +1,329 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package v1alpha1
+
+import (
+	"fmt"
+	"maps"
+	"net"
+	"slices"
+	"time"
+
+	capi "github.com/hashicorp/consul/api"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/validation/field"
+)
+
+const (
+	// ExternalServiceCheckTCP checks that a TCP connection can be made to each endpoint.
+	ExternalServiceCheckTCP = "tcp"
+	// ExternalServiceCheckHTTP makes an HTTP request to each endpoint.
+	ExternalServiceCheckHTTP = "http"
+
+	// defaultExternalServiceCheckInterval is used when the check interval is not set.
+	defaultExternalServiceCheckInterval = "10s"
+)
+
+func init() {
+	SchemeBuilder.Register(&ExternalService{}, &ExternalServiceList{})
+}
+
+// +genclient
+// +kubebuilder:object:root=true
+// +kubebuilder:subresource:status
+
+// ExternalService registers endpoints that run outside of Kubernetes, such as
+// databases or legacy VMs, into the Consul catalog and optionally links them
+// to a terminating gateway.
+type ExternalService struct {
+	// Standard Kubernetes resource metadata.
+	metav1.TypeMeta `json:",inline"`
+
+	// Standard object's metadata.
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	// Spec defines the desired state of ExternalService.
+	Spec ExternalServiceSpec `json:"spec,omitempty"`
+
+	Status ExternalServiceStatus `json:"status,omitempty"`
+}
+
+// ExternalServiceStatus defines the observed state of ExternalService.
+type ExternalServiceStatus struct {
+	// Conditions indicate the latest available observations of a resource's current state.
+	// +optional
+	// +patchMergeKey=type
+	// +patchStrategy=merge
+	Conditions Conditions `json:"conditions,omitempty" patchStrategy:"merge" patchMergeKey:"type"`
+	// LastSyncedTime is the last time the resource successfully synced with Consul.
+	// +optional
+	LastSyncedTime *metav1.Time `json:"lastSyncedTime,omitempty" description:"last time the condition transitioned from one status to another"`
+	// TerminatingGateway is the name of the TerminatingGateway resource the service
+	// is currently linked to. It is used to unlink the service when the spec changes.
+	// +optional
+	TerminatingGateway string `json:"terminatingGateway,omitempty"`
+}
+
+// +k8s:deepcopy-gen=true
+
+// ExternalServiceSpec specifies the desired state of the ExternalService CRD.
+type ExternalServiceSpec struct {
+	// Name is the name of the service in the Consul catalog.
+	// Defaults to the name of the ExternalService resource.
+	Name string `json:"name,omitempty"`
+	// Namespace is the Consul namespace the service is registered in.
+	Namespace string `json:"namespace,omitempty"`
+	// Partition is the Consul admin partition the service is registered in.
+	Partition string `json:"partition,omitempty"`
+	// Datacenter is the Consul datacenter the service is registered in.
+	// Defaults to the datacenter of the Consul servers.
+	Datacenter string `json:"datacenter,omitempty"`
+	// Node is the name of the Consul node the endpoints are registered on.
+	// Defaults to "<name>-external-node".
+	Node string `json:"node,omitempty"`
+	// NodeAddress is the address of the Consul node the endpoints are registered on.
+	// Defaults to the address of the first endpoint.
+	NodeAddress string `json:"nodeAddress,omitempty"`
+	// Tags are added to every service instance.
+	Tags []string `json:"tags,omitempty"`
+	// Meta is added to every service instance.
+	Meta map[string]string `json:"meta,omitempty"`
+	// Endpoints are the addresses the service is reachable on. Each endpoint is
+	// registered as a separate instance of the service.
+	Endpoints []ExternalServiceEndpoint `json:"endpoints"`
+	// Check is an optional health check run against every endpoint. Consul does not
+	// run checks for services on external nodes itself, so consul-esm must be
+	// deployed for the check status to be updated.
+	Check *ExternalServiceCheck `json:"check,omitempty"`
+	// TerminatingGateway is the name of a TerminatingGateway resource in the same
+	// Kubernetes namespace. When set, the service is added to the gateway's
+	// linked services so that mesh services can reach it through the gateway.
+	TerminatingGateway string `json:"terminatingGateway,omitempty"`
+	// TLS configures the terminating gateway to originate TLS connections to the endpoints.
+	// It requires terminatingGateway to be set.
+	TLS *ExternalServiceTLS `json:"tls,omitempty"`
+}
+
+// ExternalServiceTLS configures the TLS connections from the terminating gateway to the
+// endpoints of an ExternalService.
+type ExternalServiceTLS struct {
+	// CASecretRef is the key of a Kubernetes Secret holding the CA certificate that the
+	// endpoints' certificates are verified with. The Secret must be mounted into the
+	// terminating gateway pods with the terminatingGateways extraVolumes Helm value.
+	CASecretRef *corev1.SecretKeySelector `json:"caSecretRef,omitempty"`
+	// SNI is the server name to send during the TLS handshake with the endpoints.
+	SNI string `json:"sni,omitempty"`
+}
+
+// ExternalServiceEndpoint is a single address the external service is reachable on.
+type ExternalServiceEndpoint struct {
+	// Address is the IP address or hostname of the endpoint.
+	Address string `json:"address"`
+	// Port is the port of the endpoint.
+	Port int `json:"port"`
+}
+
+// ExternalServiceCheck defines a health check run against every endpoint of an ExternalService.
+type ExternalServiceCheck struct {
+	// Type is the type of check, either "tcp" or "http".
+	Type string `json:"type"`
+	// Path is the path requested by "http" checks. Defaults to "/".
+	Path string `json:"path,omitempty"`
+	// Interval is how often the check is run, e.g. "10s". Defaults to "10s".
+	Interval string `json:"interval,omitempty"`
+	// Timeout is how long to wait for the check to complete, e.g. "5s".
+	Timeout string `json:"timeout,omitempty"`
+	// DeregisterCriticalServiceAfter is how long an endpoint may be critical before
+	// it is removed from the catalog, e.g. "30m".
+	DeregisterCriticalServiceAfter string `json:"deregisterCriticalServiceAfter,omitempty"`
+}
+
+// +kubebuilder:object:root=true
+
+// ExternalServiceList is a list of ExternalService resources.
+type ExternalServiceList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata"`
+
+	// Items is the list of ExternalServices.
+	Items []ExternalService `json:"items"`
+}
+
+// ServiceName returns the name of the service in the Consul catalog.
+func (in *ExternalService) ServiceName() string {
+	if in.Spec.Name != "" {
+		return in.Spec.Name
+	}
+	return in.Name
+}
+
+// NodeName returns the name of the Consul node the endpoints are registered on.
+func (in *ExternalService) NodeName() string {
+	if in.Spec.Node != "" {
+		return in.Spec.Node
+	}
+	return in.ServiceName() + "-external-node"
+}
+
+// ServiceID returns the Consul service ID of the endpoint at index i.
+func (in *ExternalService) ServiceID(i int) string {
+	return fmt.Sprintf("%s-%d", in.ServiceName(), i)
+}
+
+// Validate checks that the ExternalService can be registered in Consul.
+func (in *ExternalService) Validate() error {
+	var errs field.ErrorList
+	path := field.NewPath("spec")
+
+	if len(in.Spec.Endpoints) == 0 {
+		errs = append(errs, field.Required(path.Child("endpoints"), "at least one endpoint must be defined"))
+	}
+	for i, e := range in.Spec.Endpoints {
+		if e.Address == "" {
+			errs = append(errs, field.Required(path.Child("endpoints").Index(i).Child("address"), "address must be set"))
+		}
+		if e.Port < 1 || e.Port > 65535 {
+			errs = append(errs, field.Invalid(path.Child("endpoints").Index(i).Child("port"), e.Port, "port must be between 1 and 65535"))
+		}
+	}
+
+	if c := in.Spec.Check; c != nil {
+		checkPath := path.Child("check")
+		if c.Type != ExternalServiceCheckTCP && c.Type != ExternalServiceCheckHTTP {
+			errs = append(errs, field.NotSupported(checkPath.Child("type"), c.Type, []string{ExternalServiceCheckTCP, ExternalServiceCheckHTTP}))
+		}
+		errs = append(errs, validateDuration(checkPath.Child("interval"), c.Interval)...)
+		errs = append(errs, validateDuration(checkPath.Child("timeout"), c.Timeout)...)
+		errs = append(errs, validateDuration(checkPath.Child("deregisterCriticalServiceAfter"), c.DeregisterCriticalServiceAfter)...)
+	}
+
+	if t := in.Spec.TLS; t != nil {
+		tlsPath := path.Child("tls")
+		if in.Spec.TerminatingGateway == "" {
+			errs = append(errs, field.Required(path.Child("terminatingGateway"), "terminatingGateway must be set to configure tls"))
+		}
+		if t.CASecretRef != nil && (t.CASecretRef.Name == "" || t.CASecretRef.Key == "") {
+			errs = append(errs, field.Required(tlsPath.Child("caSecretRef"), "name and key must be set"))
+		}
+	}
+
+	return errs.ToAggregate()
+}
+
+func validateDuration(path *field.Path, d string) field.ErrorList {
+	if d == "" {
+		return nil
+	}
+	if _, err := time.ParseDuration(d); err != nil {
+		return field.ErrorList{field.Invalid(path, d, err.Error())}
+	}
+	return nil
+}
+
+// ToCatalogRegistrations converts an ExternalService into one Consul CatalogRegistration per endpoint.
+func (in *ExternalService) ToCatalogRegistrations() ([]*capi.CatalogRegistration, error) {
+	if err := in.Validate(); err != nil {
+		return nil, err
+	}
+
+	nodeAddress := in.Spec.NodeAddress
+	if nodeAddress == "" {
+		nodeAddress = in.Spec.Endpoints[0].Address
+	}
+
+	regs := make([]*capi.CatalogRegistration, 0, len(in.Spec.Endpoints))
+	for i, e := range in.Spec.Endpoints {
+		reg := &capi.CatalogRegistration{
+			Node:    in.NodeName(),
+			Address: nodeAddress,
+			// consul-esm only runs checks for nodes with this metadata.
+			NodeMeta: map[string]string{
+				"external-node":  "true",
+				"external-probe": "true",
+			},
+			Datacenter: in.Spec.Datacenter,
+			Service: &capi.AgentService{
+				ID:        in.ServiceID(i),
+				Service:   in.ServiceName(),
+				Tags:      slices.Clone(in.Spec.Tags),
+				Meta:      maps.Clone(in.Spec.Meta),
+				Port:      e.Port,
+				Address:   e.Address,
+				Namespace: in.Spec.Namespace,
+				Partition: in.Spec.Partition,
+			},
+			Check:     in.catalogCheck(i, e),
+			Partition: in.Spec.Partition,
+		}
+		regs = append(regs, reg)
+	}
+	return regs, nil
+}
+
+// catalogCheck returns the health check for the endpoint at index i, or nil if no check is defined.
+// The durations have already been validated by Validate.
+func (in *ExternalService) catalogCheck(i int, e ExternalServiceEndpoint) *capi.AgentCheck {
+	c := in.Spec.Check
+	if c == nil {
+		return nil
+	}
+
+	interval := c.Interval
+	if interval == "" {
+		interval = defaultExternalServiceCheckInterval
+	}
+	intervalDuration, _ := time.ParseDuration(interval)
+	timeoutDuration, _ := time.ParseDuration(c.Timeout)
+	deregisterAfter, _ := time.ParseDuration(c.DeregisterCriticalServiceAfter)
+
+	hostPort := net.JoinHostPort(e.Address, fmt.Sprint(e.Port))
+	definition := capi.HealthCheckDefinition{
+		IntervalDuration:                       intervalDuration,
+		TimeoutDuration:                        timeoutDuration,
+		DeregisterCriticalServiceAfterDuration: deregisterAfter,
+	}
+	switch c.Type {
+	case ExternalServiceCheckTCP:
+		definition.TCP = hostPort
+	case ExternalServiceCheckHTTP:
+		path := c.Path
+		if path == "" {
+			path = "/"
+		}
+		definition.HTTP = fmt.Sprintf("http://%s%s", hostPort, path)
+	}
+
+	serviceID := in.ServiceID(i)
+	return &capi.AgentCheck{
+		Node:        in.NodeName(),
+		CheckID:     serviceID + "-check",
+		Name:        fmt.Sprintf("%s %s check", in.ServiceName(), c.Type),
+		Status:      capi.HealthCritical,
+		ServiceID:   serviceID,
+		ServiceName: in.ServiceName(),
+		Namespace:   in.Spec.Namespace,
+		Partition:   in.Spec.Partition,
+		Definition:  definition,
+	}
+}
+
+// LinkedService returns the service as a linked service of its terminating gateway.
+func (in *ExternalService) LinkedService() LinkedService {
+	linked := LinkedService{
+		Name:      in.ServiceName(),
+		Namespace: in.Spec.Namespace,
+	}
+	if t := in.Spec.TLS; t != nil {
+		linked.SNI = t.SNI
+		if t.CASecretRef != nil {
+			linked.CASecretRef = t.CASecretRef.DeepCopy()
+		}
+	}
+	return linked
+}
+
+func (in *ExternalService) KubernetesName() string {
+	return in.ObjectMeta.Name
+}
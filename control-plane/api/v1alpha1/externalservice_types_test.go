@@ -0,0 +1,210 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package v1alpha1
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	capi "github.com/hashicorp/consul/api"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestExternalService_ToCatalogRegistrations(t *testing.T) {
+	cases := map[string]struct {
+		svc      *ExternalService
+		expected []*capi.CatalogRegistration
+	}{
+		"minimal": {
+			svc: &ExternalService{
+				ObjectMeta: metav1.ObjectMeta{Name: "db", Namespace: "default"},
+				Spec: ExternalServiceSpec{
+					Endpoints: []ExternalServiceEndpoint{{Address: "10.0.0.1", Port: 5432}},
+				},
+			},
+			expected: []*capi.CatalogRegistration{
+				{
+					Node:     "db-external-node",
+					Address:  "10.0.0.1",
+					NodeMeta: map[string]string{"external-node": "true", "external-probe": "true"},
+					Service: &capi.AgentService{
+						ID:      "db-0",
+						Service: "db",
+						Port:    5432,
+						Address: "10.0.0.1",
+					},
+				},
+			},
+		},
+		"multiple endpoints with tcp check": {
+			svc: &ExternalService{
+				ObjectMeta: metav1.ObjectMeta{Name: "db", Namespace: "default"},
+				Spec: ExternalServiceSpec{
+					Name:        "legacy-db",
+					Namespace:   "ns1",
+					Partition:   "ap1",
+					Datacenter:  "dc2",
+					Node:        "legacy",
+					NodeAddress: "10.0.0.100",
+					Tags:        []string{"primary"},
+					Meta:        map[string]string{"team": "storage"},
+					Endpoints: []ExternalServiceEndpoint{
+						{Address: "10.0.0.1", Port: 5432},
+						{Address: "db.example.com", Port: 5433},
+					},
+					Check: &ExternalServiceCheck{
+						Type:                           ExternalServiceCheckTCP,
+						Timeout:                        "2s",
+						DeregisterCriticalServiceAfter: "30m",
+					},
+				},
+			},
+			expected: []*capi.CatalogRegistration{
+				externalReg("10.0.0.1", 5432, 0, "10.0.0.1:5432"),
+				externalReg("db.example.com", 5433, 1, "db.example.com:5433"),
+			},
+		},
+		"http check": {
+			svc: &ExternalService{
+				ObjectMeta: metav1.ObjectMeta{Name: "api", Namespace: "default"},
+				Spec: ExternalServiceSpec{
+					Endpoints: []ExternalServiceEndpoint{{Address: "10.0.0.1", Port: 8080}},
+					Check: &ExternalServiceCheck{
+						Type:     ExternalServiceCheckHTTP,
+						Path:     "/health",
+						Interval: "5s",
+					},
+				},
+			},
+			expected: []*capi.CatalogRegistration{
+				{
+					Node:     "api-external-node",
+					Address:  "10.0.0.1",
+					NodeMeta: map[string]string{"external-node": "true", "external-probe": "true"},
+					Service: &capi.AgentService{
+						ID:      "api-0",
+						Service: "api",
+						Port:    8080,
+						Address: "10.0.0.1",
+					},
+					Check: &capi.AgentCheck{
+						Node:        "api-external-node",
+						CheckID:     "api-0-check",
+						Name:        "api http check",
+						Status:      capi.HealthCritical,
+						ServiceID:   "api-0",
+						ServiceName: "api",
+						Definition: capi.HealthCheckDefinition{
+							HTTP:             "http://10.0.0.1:8080/health",
+							IntervalDuration: 5 * time.Second,
+						},
+					},
+				},
+			},
+		},
+	}
+
+	for name, c := range cases {
+		t.Run(name, func(t *testing.T) {
+			regs, err := c.svc.ToCatalogRegistrations()
+			require.NoError(t, err)
+			require.Equal(t, c.expected, regs)
+		})
+	}
+}
+
+func TestExternalService_Validate(t *testing.T) {
+	cases := map[string]struct {
+		spec   ExternalServiceSpec
+		expErr string
+	}{
+		"valid": {
+			spec: ExternalServiceSpec{
+				Endpoints: []ExternalServiceEndpoint{{Address: "10.0.0.1", Port: 80}},
+				Check:     &ExternalServiceCheck{Type: ExternalServiceCheckTCP, Interval: "10s"},
+			},
+		},
+		"no endpoints": {
+			spec:   ExternalServiceSpec{},
+			expErr: "spec.endpoints: Required value: at least one endpoint must be defined",
+		},
+		"invalid endpoint": {
+			spec: ExternalServiceSpec{
+				Endpoints: []ExternalServiceEndpoint{{Port: 70000}},
+			},
+			expErr: "[spec.endpoints[0].address: Required value: address must be set, spec.endpoints[0].port: Invalid value: 70000: port must be between 1 and 65535]",
+		},
+		"invalid check": {
+			spec: ExternalServiceSpec{
+				Endpoints: []ExternalServiceEndpoint{{Address: "10.0.0.1", Port: 80}},
+				Check:     &ExternalServiceCheck{Type: "grpc", Timeout: "soon"},
+			},
+			expErr: `[spec.check.type: Unsupported value: "grpc": supported values: "tcp", "http", spec.check.timeout: Invalid value: "soon": time: invalid duration "soon"]`,
+		},
+		"tls without terminating gateway": {
+			spec: ExternalServiceSpec{
+				Endpoints: []ExternalServiceEndpoint{{Address: "10.0.0.1", Port: 443}},
+				TLS: &ExternalServiceTLS{
+					CASecretRef: &corev1.SecretKeySelector{LocalObjectReference: corev1.LocalObjectReference{Name: "ca"}},
+				},
+			},
+			expErr: "[spec.terminatingGateway: Required value: terminatingGateway must be set to configure tls, spec.tls.caSecretRef: Required value: name and key must be set]",
+		},
+	}
+
+	for name, c := range cases {
+		t.Run(name, func(t *testing.T) {
+			svc := &ExternalService{
+				ObjectMeta: metav1.ObjectMeta{Name: "svc", Namespace: "default"},
+				Spec:       c.spec,
+			}
+			err := svc.Validate()
+			if c.expErr == "" {
+				require.NoError(t, err)
+			} else {
+				require.EqualError(t, err, c.expErr)
+			}
+		})
+	}
+}
+
+func externalReg(address string, port, i int, hostPort string) *capi.CatalogRegistration {
+	id := fmt.Sprintf("legacy-db-%d", i)
+	return &capi.CatalogRegistration{
+		Node:       "legacy",
+		Address:    "10.0.0.100",
+		NodeMeta:   map[string]string{"external-node": "true", "external-probe": "true"},
+		Datacenter: "dc2",
+		Service: &capi.AgentService{
+			ID:        id,
+			Service:   "legacy-db",
+			Tags:      []string{"primary"},
+			Meta:      map[string]string{"team": "storage"},
+			Port:      port,
+			Address:   address,
+			Namespace: "ns1",
+			Partition: "ap1",
+		},
+		Check: &capi.AgentCheck{
+			Node:        "legacy",
+			CheckID:     id + "-check",
+			Name:        "legacy-db tcp check",
+			Status:      capi.HealthCritical,
+			ServiceID:   id,
+			ServiceName: "legacy-db",
+			Namespace:   "ns1",
+			Partition:   "ap1",
+			Definition: capi.HealthCheckDefinition{
+				TCP:                                    hostPort,
+				IntervalDuration:                       10 * time.Second,
+				TimeoutDuration:                        2 * time.Second,
+				DeregisterCriticalServiceAfterDuration: 30 * time.Minute,
+			},
+		},
+		Partition: "ap1",
+	}
+}
@@ -219,15 +219,7 @@ func (in *IngressGateway) KubernetesName() string {
 }
 
 func (in *IngressGateway) SetSyncedCondition(status corev1.ConditionStatus, reason, message string) {
-	in.Status.Conditions = Conditions{
-		{
-			Type:               ConditionSynced,
-			Status:             status,
-			LastTransitionTime: metav1.Now(),
-			Reason:             reason,
-			Message:            message,
-		},
-	}
+	in.Status.SetSyncedCondition(status, reason, message)
 }
 
 func (in *IngressGateway) SetLastSyncedTime(time *metav1.Time) {
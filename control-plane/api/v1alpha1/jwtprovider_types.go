@@ -759,15 +759,7 @@ func (j *JWTProvider) KubernetesName() string {
 }
 
 func (j *JWTProvider) SetSyncedCondition(status corev1.ConditionStatus, reason, message string) {
-	j.Status.Conditions = Conditions{
-		{
-			Type:               ConditionSynced,
-			Status:             status,
-			LastTransitionTime: metav1.Now(),
-			Reason:             reason,
-			Message:            message,
-		},
-	}
+	j.Status.SetSyncedCondition(status, reason, message)
 }
 
 func (j *JWTProvider) SetLastSyncedTime(time *metav1.Time) {
@@ -54,6 +54,12 @@ type PeeringAcceptorSpec struct {
 type Peer struct {
 	// Secret describes how to store the generated peering token.
 	Secret *Secret `json:"secret,omitempty"`
+	// TokenExchange describes a store shared with the peer cluster that the peering token
+	// is exchanged through. A PeeringAcceptor publishes the token in its secret to the store
+	// and a PeeringDialer copies the token from the store into its secret, which re-establishes
+	// the peering whenever the acceptor generates a new token.
+	// +optional
+	TokenExchange *TokenExchange `json:"tokenExchange,omitempty"`
 }
 
 type Secret struct {
@@ -65,6 +71,109 @@ type Secret struct {
 	Backend string `json:"backend,omitempty"`
 }
 
+// TokenExchange is a store shared between peered clusters. Exactly one of Kubernetes or Vault must be set.
+type TokenExchange struct {
+	// Kubernetes exchanges the token through a Secret in a Kubernetes cluster that both
+	// peered clusters can access, such as a management cluster.
+	// +optional
+	Kubernetes *KubernetesTokenExchange `json:"kubernetes,omitempty"`
+	// Vault exchanges the token through a Vault KV version 2 secret.
+	// +optional
+	Vault *VaultTokenExchange `json:"vault,omitempty"`
+	// SyncInterval is how often the token is compared with the token in the store.
+	// Defaults to 1m.
+	// +optional
+	SyncInterval metav1.Duration `json:"syncInterval,omitempty"`
+}
+
+// KubernetesTokenExchange is a Secret in another Kubernetes cluster.
+type KubernetesTokenExchange struct {
+	// KubeconfigSecret is the Secret in the namespace of the resource with the kubeconfig
+	// used to access the Kubernetes cluster.
+	KubeconfigSecret SecretKeySelector `json:"kubeconfigSecret"`
+	// Namespace is the namespace of the Secret in the Kubernetes cluster.
+	Namespace string `json:"namespace"`
+	// Name is the name of the Secret in the Kubernetes cluster.
+	Name string `json:"name"`
+	// Key is the key of the token in the Secret.
+	Key string `json:"key"`
+}
+
+// SecretKeySelector selects a key of a Secret.
+type SecretKeySelector struct {
+	// Name is the name of the Secret.
+	Name string `json:"name"`
+	// Key is the key in the Secret.
+	Key string `json:"key"`
+}
+
+// VaultTokenExchange is a Vault KV version 2 secret. The controller logs in to Vault with
+// its Kubernetes service account token.
+type VaultTokenExchange struct {
+	// Address is the address of the Vault server, e.g. https://vault.example.com:8200.
+	Address string `json:"address"`
+	// Mount is the path the KV version 2 secrets engine is mounted at. Defaults to "secret".
+	// +optional
+	Mount string `json:"mount,omitempty"`
+	// Path is the path of the secret in the secrets engine, e.g. peering/cluster-02.
+	Path string `json:"path"`
+	// Key is the key of the token in the secret.
+	Key string `json:"key"`
+	// AuthMethodPath is the path the Kubernetes auth method is mounted at. Defaults to "kubernetes".
+	// +optional
+	AuthMethodPath string `json:"authMethodPath,omitempty"`
+	// Role is the Vault role of the Kubernetes auth method to log in with.
+	Role string `json:"role"`
+	// Namespace is the Vault Enterprise namespace of the secret.
+	// +optional
+	Namespace string `json:"namespace,omitempty"`
+	// CACertSecret is the Secret in the namespace of the resource with the CA certificate
+	// used to verify the Vault server's certificate.
+	// +optional
+	CACertSecret *SecretKeySelector `json:"caCertSecret,omitempty"`
+}
+
+// validate returns the errors in the token exchange at path.
+func (t *TokenExchange) validate(path *field.Path) field.ErrorList {
+	var errs field.ErrorList
+	if t == nil {
+		return errs
+	}
+	if (t.Kubernetes == nil) == (t.Vault == nil) {
+		errs = append(errs, field.Invalid(path, t, "exactly one of kubernetes or vault must be set"))
+		return errs
+	}
+	if t.SyncInterval.Duration < 0 {
+		errs = append(errs, field.Invalid(path.Child("syncInterval"), t.SyncInterval, "syncInterval must not be negative"))
+	}
+	if k := t.Kubernetes; k != nil {
+		kPath := path.Child("kubernetes")
+		if k.KubeconfigSecret.Name == "" || k.KubeconfigSecret.Key == "" {
+			errs = append(errs, field.Required(kPath.Child("kubeconfigSecret"), "name and key must be set"))
+		}
+		errs = append(errs, requiredFields(kPath, "namespace", k.Namespace, "name", k.Name, "key", k.Key)...)
+	}
+	if v := t.Vault; v != nil {
+		vPath := path.Child("vault")
+		errs = append(errs, requiredFields(vPath, "address", v.Address, "path", v.Path, "key", v.Key, "role", v.Role)...)
+		if v.CACertSecret != nil && (v.CACertSecret.Name == "" || v.CACertSecret.Key == "") {
+			errs = append(errs, field.Required(vPath.Child("caCertSecret"), "name and key must be set"))
+		}
+	}
+	return errs
+}
+
+// requiredFields returns an error for each empty value in the name and value pairs of fields.
+func requiredFields(path *field.Path, fields ...string) field.ErrorList {
+	var errs field.ErrorList
+	for i := 0; i+1 < len(fields); i += 2 {
+		if fields[i+1] == "" {
+			errs = append(errs, field.Required(path.Child(fields[i]), fields[i]+" must be set"))
+		}
+	}
+	return errs
+}
+
 // PeeringAcceptorStatus defines the observed state of PeeringAcceptor.
 type PeeringAcceptorStatus struct {
 	// LatestPeeringVersion is the latest version of the resource that was reconciled.
@@ -92,6 +201,13 @@ func (pa *PeeringAcceptor) Secret() *Secret {
 	return pa.Spec.Peer.Secret
 }
 
+func (pa *PeeringAcceptor) TokenExchange() *TokenExchange {
+	if pa.Spec.Peer == nil {
+		return nil
+	}
+	return pa.Spec.Peer.TokenExchange
+}
+
 func (pa *PeeringAcceptor) SecretRef() *SecretRefStatus {
 	return pa.Status.SecretRef
 }
@@ -120,6 +236,7 @@ func (pa *PeeringAcceptor) Validate() error {
 	if pa.Spec.Peer.Secret.Backend != SecretBackendTypeKubernetes {
 		errs = append(errs, field.Invalid(field.NewPath("spec").Child("peer").Child("secret").Child("backend"), pa.Spec.Peer.Secret.Backend, `backend must be "kubernetes"`))
 	}
+	errs = append(errs, pa.Spec.Peer.TokenExchange.validate(field.NewPath("spec").Child("peer").Child("tokenExchange"))...)
 	if len(errs) > 0 {
 		return apierrors.NewInvalid(
 			schema.GroupKind{Group: ConsulHashicorpGroup, Kind: PeeringAcceptorKubeKind},
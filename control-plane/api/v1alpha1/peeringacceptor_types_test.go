@@ -31,6 +31,101 @@ func TestPeeringAcceptor_Validate(t *testing.T) {
 				},
 			},
 		},
+		"valid with kubernetes token exchange": {
+			acceptor: &PeeringAcceptor{
+				ObjectMeta: metav1.ObjectMeta{
+					Name: "api",
+				},
+				Spec: PeeringAcceptorSpec{
+					Peer: &Peer{
+						Secret: &Secret{
+							Name:    "api-token",
+							Key:     "data",
+							Backend: SecretBackendTypeKubernetes,
+						},
+						TokenExchange: &TokenExchange{
+							Kubernetes: &KubernetesTokenExchange{
+								KubeconfigSecret: SecretKeySelector{Name: "mgmt-kubeconfig", Key: "kubeconfig"},
+								Namespace:        "peering",
+								Name:             "api-token",
+								Key:              "data",
+							},
+						},
+					},
+				},
+			},
+		},
+		"valid with vault token exchange": {
+			acceptor: &PeeringAcceptor{
+				ObjectMeta: metav1.ObjectMeta{
+					Name: "api",
+				},
+				Spec: PeeringAcceptorSpec{
+					Peer: &Peer{
+						Secret: &Secret{
+							Name:    "api-token",
+							Key:     "data",
+							Backend: SecretBackendTypeKubernetes,
+						},
+						TokenExchange: &TokenExchange{
+							Vault: &VaultTokenExchange{
+								Address: "https://vault:8200",
+								Path:    "peering/api",
+								Key:     "token",
+								Role:    "peering",
+							},
+						},
+					},
+				},
+			},
+		},
+		"token exchange without a store": {
+			acceptor: &PeeringAcceptor{
+				ObjectMeta: metav1.ObjectMeta{
+					Name: "api",
+				},
+				Spec: PeeringAcceptorSpec{
+					Peer: &Peer{
+						Secret: &Secret{
+							Name:    "api-token",
+							Key:     "data",
+							Backend: SecretBackendTypeKubernetes,
+						},
+						TokenExchange: &TokenExchange{},
+					},
+				},
+			},
+			expectedErrMsgs: []string{
+				`spec.peer.tokenExchange: Invalid value: `,
+				`exactly one of kubernetes or vault must be set`,
+			},
+		},
+		"token exchange with missing fields": {
+			acceptor: &PeeringAcceptor{
+				ObjectMeta: metav1.ObjectMeta{
+					Name: "api",
+				},
+				Spec: PeeringAcceptorSpec{
+					Peer: &Peer{
+						Secret: &Secret{
+							Name:    "api-token",
+							Key:     "data",
+							Backend: SecretBackendTypeKubernetes,
+						},
+						TokenExchange: &TokenExchange{
+							Vault: &VaultTokenExchange{
+								Address: "https://vault:8200",
+								Path:    "peering/api",
+							},
+						},
+					},
+				},
+			},
+			expectedErrMsgs: []string{
+				`spec.peer.tokenExchange.vault.key: Required value: key must be set`,
+				`spec.peer.tokenExchange.vault.role: Required value: role must be set`,
+			},
+		},
 		"no peer specified": {
 			acceptor: &PeeringAcceptor{
 				ObjectMeta: metav1.ObjectMeta{
@@ -74,6 +74,13 @@ func (pd *PeeringDialer) Secret() *Secret {
 	return pd.Spec.Peer.Secret
 }
 
+func (pd *PeeringDialer) TokenExchange() *TokenExchange {
+	if pd.Spec.Peer == nil {
+		return nil
+	}
+	return pd.Spec.Peer.TokenExchange
+}
+
 func (pd *PeeringDialer) SecretRef() *SecretRefStatus {
 	return pd.Status.SecretRef
 }
@@ -102,6 +109,7 @@ func (pd *PeeringDialer) Validate() error {
 	if pd.Spec.Peer.Secret.Backend != "kubernetes" {
 		errs = append(errs, field.Invalid(field.NewPath("spec").Child("peer").Child("secret").Child("backend"), pd.Spec.Peer.Secret.Backend, `backend must be "kubernetes"`))
 	}
+	errs = append(errs, pd.Spec.Peer.TokenExchange.validate(field.NewPath("spec").Child("peer").Child("tokenExchange"))...)
 	if len(errs) > 0 {
 		return apierrors.NewInvalid(
 			schema.GroupKind{Group: ConsulHashicorpGroup, Kind: PeeringDialerKubeKind},
@@ -165,15 +165,7 @@ func (in *ProxyDefaults) KubernetesName() string {
 }
 
 func (in *ProxyDefaults) SetSyncedCondition(status corev1.ConditionStatus, reason string, message string) {
-	in.Status.Conditions = Conditions{
-		{
-			Type:               ConditionSynced,
-			Status:             status,
-			LastTransitionTime: metav1.Now(),
-			Reason:             reason,
-			Message:            message,
-		},
-	}
+	in.Status.SetSyncedCondition(status, reason, message)
 }
 
 func (in *ProxyDefaults) SetLastSyncedTime(time *metav1.Time) {
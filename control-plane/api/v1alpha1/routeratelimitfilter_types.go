@@ -0,0 +1,65 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package v1alpha1
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func init() {
+	SchemeBuilder.Register(&RouteRateLimitFilter{}, &RouteRateLimitFilterList{})
+}
+
+const RouteRateLimitFilterKind = "RouteRateLimitFilter"
+
+//+kubebuilder:object:root=true
+//+kubebuilder:subresource:status
+
+// RouteRateLimitFilter is the Schema for the routeratelimitfilters API.
+// It limits the rate of the requests matched by the rules of the HTTPRoutes that reference it.
+// +kubebuilder:printcolumn:name="Synced",type="string",JSONPath=".status.conditions[?(@.type==\"Synced\")].status",description="The sync status of the resource with Consul"
+// +kubebuilder:printcolumn:name="Last Synced",type="date",JSONPath=".status.lastSyncedTime",description="The last successful synced time of the resource with Consul"
+// +kubebuilder:printcolumn:name="Age",type="date",JSONPath=".metadata.creationTimestamp",description="The age of the resource"
+type RouteRateLimitFilter struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec   RouteRateLimitFilterSpec `json:"spec,omitempty"`
+	Status `json:"status,omitempty"`
+}
+
+//+kubebuilder:object:root=true
+
+// RouteRateLimitFilterList contains a list of RouteRateLimitFilter.
+type RouteRateLimitFilterList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []RouteRateLimitFilter `json:"items"`
+}
+
+// RouteRateLimitFilterSpec defines the desired state of RouteRateLimitFilter.
+// The limits are per backend service, not per route: they are written to the
+// service-defaults of the backend services of the route rule and enforced locally
+// by each of their instances, for the paths matched by the rule. They apply to all
+// inbound traffic of the instances on those paths, not only to the requests routed
+// through the gateway, and only to services with an HTTP-family protocol. They are
+// not applied to services with a ServiceDefaults resource, which the Synced condition
+// of the filter reports. Rate limiting is a Consul Enterprise feature.
+type RouteRateLimitFilterSpec struct {
+	// RequestsPerSecond is the average number of requests per second that can be
+	// made to each instance of the backend services without being throttled.
+	// +kubebuilder:validation:Minimum:=1
+	RequestsPerSecond int `json:"requestsPerSecond"`
+
+	// RequestsMaxBurst is the maximum number of requests that can be sent
+	// in a burst. Should be equal to or greater than RequestsPerSecond.
+	// If unset, defaults to RequestsPerSecond.
+	// +kubebuilder:validation:Minimum:=0
+	// +kubebuilder:validation:Optional
+	RequestsMaxBurst int `json:"requestsMaxBurst,omitempty"`
+}
+
+func (h *RouteRateLimitFilter) GetNamespace() string {
+	return h.Namespace
+}
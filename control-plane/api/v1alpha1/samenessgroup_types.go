@@ -121,15 +121,7 @@ func (in *SamenessGroup) KubernetesName() string {
 }
 
 func (in *SamenessGroup) SetSyncedCondition(status corev1.ConditionStatus, reason, message string) {
-	in.Status.Conditions = Conditions{
-		{
-			Type:               ConditionSynced,
-			Status:             status,
-			LastTransitionTime: metav1.Now(),
-			Reason:             reason,
-			Message:            message,
-		},
-	}
+	in.Status.SetSyncedCondition(status, reason, message)
 }
 
 func (in *SamenessGroup) SetLastSyncedTime(time *metav1.Time) {
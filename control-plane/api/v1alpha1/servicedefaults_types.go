@@ -407,15 +407,7 @@ func (in *ServiceDefaults) KubernetesName() string {
 }
 
 func (in *ServiceDefaults) SetSyncedCondition(status corev1.ConditionStatus, reason string, message string) {
-	in.Status.Conditions = Conditions{
-		{
-			Type:               ConditionSynced,
-			Status:             status,
-			LastTransitionTime: metav1.Now(),
-			Reason:             reason,
-			Message:            message,
-		},
-	}
+	in.Status.SetSyncedCondition(status, reason, message)
 }
 
 func (in *ServiceDefaults) SetLastSyncedTime(time *metav1.Time) {
@@ -221,15 +221,7 @@ func (in *ServiceIntentions) KubernetesName() string {
 }
 
 func (in *ServiceIntentions) SetSyncedCondition(status corev1.ConditionStatus, reason, message string) {
-	in.Status.Conditions = Conditions{
-		{
-			Type:               ConditionSynced,
-			Status:             status,
-			LastTransitionTime: metav1.Now(),
-			Reason:             reason,
-			Message:            message,
-		},
-	}
+	in.Status.SetSyncedCondition(status, reason, message)
 }
 
 func (in *ServiceIntentions) SetLastSyncedTime(time *metav1.Time) {
@@ -116,15 +116,7 @@ func (in *ServiceSplitter) ConsulName() string {
 }
 
 func (in *ServiceSplitter) SetSyncedCondition(status corev1.ConditionStatus, reason, message string) {
-	in.Status.Conditions = Conditions{
-		{
-			Type:               ConditionSynced,
-			Status:             status,
-			LastTransitionTime: metav1.Now(),
-			Reason:             reason,
-			Message:            message,
-		},
-	}
+	in.Status.SetSyncedCondition(status, reason, message)
 }
 
 func (in *ServiceSplitter) SetLastSyncedTime(time *metav1.Time) {
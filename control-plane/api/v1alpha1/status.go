@@ -17,6 +17,10 @@ type ConditionType string
 const (
 	// ConditionSynced specifies that the resource has been synced with Consul.
 	ConditionSynced ConditionType = "Synced"
+	// ConditionLastSyncError holds the last error syncing the resource with Consul.
+	// It is True while the resource fails to sync and becomes False, keeping
+	// the error, once the resource syncs successfully again.
+	ConditionLastSyncError ConditionType = "LastSyncError"
 )
 
 // Conditions define a readiness condition for a Consul resource.
@@ -45,6 +49,19 @@ type Condition struct {
 	Message string `json:"message,omitempty" description:"human-readable message indicating details about last transition"`
 }
 
+// KeepTransitionTimes sets the LastTransitionTime of the conditions that have the same status in
+// previous to the time they transitioned in previous, since a condition only transitions when its
+// status changes. This keeps the conditions equal when a resource is reconciled without changes.
+func (c Conditions) KeepTransitionTimes(previous Conditions) {
+	for i := range c {
+		for _, p := range previous {
+			if p.Type == c[i].Type && p.Status == c[i].Status {
+				c[i].LastTransitionTime = p.LastTransitionTime
+			}
+		}
+	}
+}
+
 // IsTrue is true if the condition is True.
 func (c *Condition) IsTrue() bool {
 	if c == nil {
@@ -81,6 +98,28 @@ type Status struct {
 	// LastSyncedTime is the last time the resource successfully synced with Consul.
 	// +optional
 	LastSyncedTime *metav1.Time `json:"lastSyncedTime,omitempty" description:"last time the condition transitioned from one status to another"`
+
+	// DryRun is the config entry the resource would be written to Consul as, recorded instead of
+	// writing it while the controller or the resource is in dry-run mode.
+	// +optional
+	DryRun *DryRunStatus `json:"dryRun,omitempty"`
+}
+
+// DryRunStatus is the result of reconciling a resource in dry-run mode.
+// +k8s:deepcopy-gen=true
+// +k8s:openapi-gen=true
+type DryRunStatus struct {
+	// Operation is the write to Consul the resource would cause: Create, Update, or None if the
+	// config entry in Consul already matches the resource.
+	Operation string `json:"operation"`
+
+	// ConfigEntry is the JSON of the config entry that would be written to Consul.
+	// +optional
+	ConfigEntry string `json:"configEntry,omitempty"`
+
+	// LastComputedTime is the last time the dry run was computed.
+	// +optional
+	LastComputedTime *metav1.Time `json:"lastComputedTime,omitempty"`
 }
 
 func (s *Status) GetCondition(t ConditionType) *Condition {
@@ -91,3 +130,88 @@ func (s *Status) GetCondition(t ConditionType) *Condition {
 	}
 	return nil
 }
+
+// SyncedReasonInvalidSpec is the reason of the Synced condition of a resource that failed
+// validation. Controllers don't requeue such a resource: an invalid spec won't become valid
+// without an update, which triggers a new reconcile.
+const SyncedReasonInvalidSpec = "InvalidSpec"
+
+// SyncedCondition returns the Synced condition for the result of syncing a resource: True if err
+// is nil, otherwise False with reason and err as its message.
+func SyncedCondition(reason string, err error) Condition {
+	if err != nil {
+		return Condition{
+			Type:               ConditionSynced,
+			Status:             corev1.ConditionFalse,
+			LastTransitionTime: metav1.Now(),
+			Reason:             reason,
+			Message:            err.Error(),
+		}
+	}
+	return Condition{
+		Type:               ConditionSynced,
+		Status:             corev1.ConditionTrue,
+		LastTransitionTime: metav1.Now(),
+	}
+}
+
+// SetSyncedCondition sets the synced condition. If the resource failed to sync,
+// i.e. the condition isn't True and has a message, the error is also recorded in
+// the LastSyncError condition so that it is kept once the resource syncs again.
+func (s *Status) SetSyncedCondition(status corev1.ConditionStatus, reason, message string) {
+	now := metav1.Now()
+	s.setCondition(Condition{
+		Type:               ConditionSynced,
+		Status:             status,
+		LastTransitionTime: now,
+		Reason:             reason,
+		Message:            message,
+	})
+
+	lastSyncError := s.GetCondition(ConditionLastSyncError)
+	switch {
+	case status != corev1.ConditionTrue && message != "":
+		s.setCondition(Condition{
+			Type:               ConditionLastSyncError,
+			Status:             corev1.ConditionTrue,
+			LastTransitionTime: now,
+			Reason:             reason,
+			Message:            message,
+		})
+	case status == corev1.ConditionTrue && lastSyncError.IsTrue():
+		lastSyncError.Status = corev1.ConditionFalse
+		lastSyncError.LastTransitionTime = now
+		s.setCondition(*lastSyncError)
+	}
+}
+
+// SetDryRun records the result of reconciling the resource in dry-run mode. It returns whether the
+// result changed, and keeps the recorded result, including when it was computed, if it didn't.
+func (s *Status) SetDryRun(operation, configEntry string) bool {
+	if s.DryRun != nil && s.DryRun.Operation == operation && s.DryRun.ConfigEntry == configEntry {
+		return false
+	}
+	now := metav1.Now()
+	s.DryRun = &DryRunStatus{
+		Operation:        operation,
+		ConfigEntry:      configEntry,
+		LastComputedTime: &now,
+	}
+	return true
+}
+
+// ClearDryRun removes the result of reconciling the resource in dry-run mode.
+func (s *Status) ClearDryRun() {
+	s.DryRun = nil
+}
+
+// setCondition replaces the condition of the same type, or adds it if there is none.
+func (s *Status) setCondition(cond Condition) {
+	for idx, c := range s.Conditions {
+		if c.Type == cond.Type {
+			s.Conditions[idx] = cond
+			return
+		}
+	}
+	s.Conditions = append(s.Conditions, cond)
+}
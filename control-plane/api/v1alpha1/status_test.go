@@ -0,0 +1,90 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package v1alpha1
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestStatus_SetSyncedCondition(t *testing.T) {
+	status := &Status{
+		Conditions: Conditions{
+			{Type: ConsulACLStatus, Status: corev1.ConditionTrue},
+		},
+	}
+
+	// A failed sync records the error in the LastSyncError condition.
+	status.SetSyncedCondition(corev1.ConditionFalse, "ConsulAgentError", "connection refused")
+	require.Equal(t, corev1.ConditionTrue, status.GetCondition(ConsulACLStatus).Status)
+	require.Equal(t, corev1.ConditionFalse, status.GetCondition(ConditionSynced).Status)
+	lastSyncError := status.GetCondition(ConditionLastSyncError)
+	require.Equal(t, corev1.ConditionTrue, lastSyncError.Status)
+	require.Equal(t, "ConsulAgentError", lastSyncError.Reason)
+	require.Equal(t, "connection refused", lastSyncError.Message)
+
+	// A sync whose status is unknown without an error doesn't change the last error.
+	status.SetSyncedCondition(corev1.ConditionUnknown, "", "")
+	require.Equal(t, *lastSyncError, *status.GetCondition(ConditionLastSyncError))
+
+	// A successful sync keeps the last error but marks it as resolved.
+	status.SetSyncedCondition(corev1.ConditionTrue, "", "")
+	require.Equal(t, corev1.ConditionTrue, status.GetCondition(ConditionSynced).Status)
+	lastSyncError = status.GetCondition(ConditionLastSyncError)
+	require.Equal(t, corev1.ConditionFalse, lastSyncError.Status)
+	require.Equal(t, "ConsulAgentError", lastSyncError.Reason)
+	require.Equal(t, "connection refused", lastSyncError.Message)
+	require.Len(t, status.Conditions, 3)
+}
+
+func TestSyncedCondition(t *testing.T) {
+	synced := SyncedCondition("", nil)
+	require.Equal(t, ConditionSynced, synced.Type)
+	require.True(t, synced.IsTrue())
+	require.Empty(t, synced.Reason)
+
+	failed := SyncedCondition(SyncedReasonInvalidSpec, errors.New("invalid schedule"))
+	require.True(t, failed.IsFalse())
+	require.Equal(t, SyncedReasonInvalidSpec, failed.Reason)
+	require.Equal(t, "invalid schedule", failed.Message)
+}
+
+func TestStatus_SetDryRun(t *testing.T) {
+	status := &Status{}
+	require.True(t, status.SetDryRun("Create", `{"Kind":"service-defaults"}`))
+	computed := status.DryRun.LastComputedTime
+
+	// The same result isn't recorded again.
+	require.False(t, status.SetDryRun("Create", `{"Kind":"service-defaults"}`))
+	require.Same(t, computed, status.DryRun.LastComputedTime)
+
+	require.True(t, status.SetDryRun("Update", `{"Kind":"service-defaults"}`))
+	require.Equal(t, "Update", status.DryRun.Operation)
+	require.True(t, status.SetDryRun("Update", `{"Kind":"service-defaults","Protocol":"http"}`))
+	require.Equal(t, `{"Kind":"service-defaults","Protocol":"http"}`, status.DryRun.ConfigEntry)
+}
+
+func TestConditions_KeepTransitionTimes(t *testing.T) {
+	transitioned := metav1.NewTime(metav1.Now().Add(-time.Hour))
+	now := metav1.Now()
+	previous := Conditions{
+		{Type: ConditionSynced, Status: corev1.ConditionTrue, LastTransitionTime: transitioned},
+		{Type: ConsulACLStatus, Status: corev1.ConditionTrue, LastTransitionTime: transitioned},
+	}
+	conditions := Conditions{
+		{Type: ConditionSynced, Status: corev1.ConditionTrue, LastTransitionTime: now, Message: "synced"},
+		{Type: ConsulACLStatus, Status: corev1.ConditionFalse, LastTransitionTime: now},
+		{Type: ConditionLastSyncError, Status: corev1.ConditionTrue, LastTransitionTime: now},
+	}
+	conditions.KeepTransitionTimes(previous)
+	require.Equal(t, transitioned, conditions[0].LastTransitionTime)
+	require.Equal(t, "synced", conditions[0].Message)
+	require.Equal(t, now, conditions[1].LastTransitionTime)
+	require.Equal(t, now, conditions[2].LastTransitionTime)
+}
@@ -0,0 +1,175 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package v1alpha1
+
+import (
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/validation/field"
+)
+
+const (
+	// TelemetryExporterProtocolHTTP exports telemetry with OTLP over HTTP.
+	TelemetryExporterProtocolHTTP = "http"
+	// TelemetryExporterProtocolGRPC exports telemetry with OTLP over gRPC.
+	TelemetryExporterProtocolGRPC = "grpc"
+)
+
+func init() {
+	SchemeBuilder.Register(&TelemetryCollectorConfig{}, &TelemetryCollectorConfigList{})
+}
+
+// +genclient
+// +kubebuilder:object:root=true
+// +kubebuilder:subresource:status
+// +kubebuilder:printcolumn:name="Synced",type="string",JSONPath=".status.conditions[?(@.type==\"Synced\")].status",description="The sync status of the resource"
+// +kubebuilder:printcolumn:name="Last Synced",type="date",JSONPath=".status.lastSyncedTime",description="The last successful synced time of the resource with the collector configuration"
+// +kubebuilder:printcolumn:name="Age",type="date",JSONPath=".metadata.creationTimestamp"
+
+// TelemetryCollectorConfig configures the exporters of the consul-telemetry-collector.
+// The collector configuration is saved in a Secret named after the resource, and the
+// Deployments that mount it are restarted when it changes.
+type TelemetryCollectorConfig struct {
+	// Standard Kubernetes resource metadata.
+	metav1.TypeMeta `json:",inline"`
+
+	// Standard object's metadata.
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	// Spec defines the desired state of TelemetryCollectorConfig.
+	Spec TelemetryCollectorConfigSpec `json:"spec,omitempty"`
+
+	Status TelemetryCollectorConfigStatus `json:"status,omitempty"`
+}
+
+// TelemetryCollectorConfigStatus defines the observed state of TelemetryCollectorConfig.
+type TelemetryCollectorConfigStatus struct {
+	// Conditions indicate the latest available observations of a resource's current state.
+	// +optional
+	// +patchMergeKey=type
+	// +patchStrategy=merge
+	Conditions Conditions `json:"conditions,omitempty" patchStrategy:"merge" patchMergeKey:"type"`
+	// LastSyncedTime is the last time the collector configuration was successfully synced.
+	// +optional
+	LastSyncedTime *metav1.Time `json:"lastSyncedTime,omitempty"`
+	// ConfigChecksum is the checksum of the collector configuration that was last synced.
+	// +optional
+	ConfigChecksum string `json:"configChecksum,omitempty"`
+}
+
+// +k8s:deepcopy-gen=true
+
+// TelemetryCollectorConfigSpec specifies the desired state of the TelemetryCollectorConfig CRD.
+type TelemetryCollectorConfigSpec struct {
+	// Exporters are the OTLP endpoints the collector exports telemetry to. The collector exports to a
+	// single endpoint, so exactly one exporter must be set.
+	// +kubebuilder:validation:MinItems=1
+	// +kubebuilder:validation:MaxItems=1
+	Exporters []TelemetryExporter `json:"exporters"`
+}
+
+// TelemetryExporter exports telemetry to an OTLP endpoint.
+type TelemetryExporter struct {
+	// Name of the exporter. It must be unique within the TelemetryCollectorConfig.
+	Name string `json:"name"`
+	// Protocol used to export telemetry, either "http" or "grpc". Defaults to "http".
+	// +optional
+	Protocol string `json:"protocol,omitempty"`
+	// Endpoint is the URL of the OTLP endpoint, e.g. "https://otel-collector.example.com:4318".
+	Endpoint string `json:"endpoint"`
+	// Headers are sent with every export request, e.g. to authenticate to the endpoint.
+	// +optional
+	Headers []TelemetryExporterHeader `json:"headers,omitempty"`
+	// Timeout of export requests, e.g. "10s".
+	// +optional
+	Timeout string `json:"timeout,omitempty"`
+}
+
+// TelemetryExporterHeader is a header sent with export requests.
+type TelemetryExporterHeader struct {
+	// Name of the header.
+	Name string `json:"name"`
+	// Value of the header. Exactly one of value or secretRef must be set.
+	// +optional
+	Value string `json:"value,omitempty"`
+	// SecretRef references the key of a Kubernetes secret holding the value of the header,
+	// e.g. an API key. The secret must be in the namespace of the TelemetryCollectorConfig.
+	// +optional
+	SecretRef *corev1.SecretKeySelector `json:"secretRef,omitempty"`
+}
+
+// +kubebuilder:object:root=true
+
+// TelemetryCollectorConfigList is a list of TelemetryCollectorConfig resources.
+type TelemetryCollectorConfigList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata"`
+
+	// Items is the list of TelemetryCollectorConfigs.
+	Items []TelemetryCollectorConfig `json:"items"`
+}
+
+// SecretName returns the name of the Secret holding the collector configuration.
+func (in *TelemetryCollectorConfig) SecretName() string {
+	return in.Name + "-telemetry-collector-config"
+}
+
+// ReferencesSecret returns whether a header of an exporter takes its value from the named secret.
+func (in *TelemetryCollectorConfig) ReferencesSecret(name string) bool {
+	for _, exporter := range in.Spec.Exporters {
+		for _, header := range exporter.Headers {
+			if header.SecretRef != nil && header.SecretRef.Name == name {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// Validate checks that a collector configuration can be generated from the TelemetryCollectorConfig.
+func (in *TelemetryCollectorConfig) Validate() error {
+	var errs field.ErrorList
+	path := field.NewPath("spec", "exporters")
+
+	if len(in.Spec.Exporters) == 0 {
+		errs = append(errs, field.Required(path, "an exporter must be set"))
+	} else if len(in.Spec.Exporters) > 1 {
+		errs = append(errs, field.TooMany(path, len(in.Spec.Exporters), 1))
+	}
+	for i, exporter := range in.Spec.Exporters {
+		exporterPath := path.Index(i)
+		if exporter.Name == "" {
+			errs = append(errs, field.Required(exporterPath.Child("name"), "name must be set"))
+		}
+
+		switch exporter.Protocol {
+		case "", TelemetryExporterProtocolHTTP, TelemetryExporterProtocolGRPC:
+		default:
+			errs = append(errs, field.NotSupported(exporterPath.Child("protocol"), exporter.Protocol,
+				[]string{TelemetryExporterProtocolHTTP, TelemetryExporterProtocolGRPC}))
+		}
+		if exporter.Endpoint == "" {
+			errs = append(errs, field.Required(exporterPath.Child("endpoint"), "endpoint must be set"))
+		}
+		errs = append(errs, validateDuration(exporterPath.Child("timeout"), exporter.Timeout)...)
+
+		for j, header := range exporter.Headers {
+			headerPath := exporterPath.Child("headers").Index(j)
+			if header.Name == "" {
+				errs = append(errs, field.Required(headerPath.Child("name"), "name must be set"))
+			}
+			if (header.Value == "") == (header.SecretRef == nil) {
+				errs = append(errs, field.Invalid(headerPath, header.Name, "exactly one of value or secretRef must be set"))
+			} else if header.SecretRef != nil && (header.SecretRef.Name == "" || header.SecretRef.Key == "") {
+				errs = append(errs, field.Required(headerPath.Child("secretRef"), "secretRef must reference a secret key"))
+			}
+		}
+	}
+
+	return errs.ToAggregate()
+}
+
+func (in *TelemetryCollectorConfig) KubernetesName() string {
+	return in.ObjectMeta.Name
+}
@@ -0,0 +1,118 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package v1alpha1
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestTelemetryCollectorConfig_Secrets(t *testing.T) {
+	config := &TelemetryCollectorConfig{
+		ObjectMeta: metav1.ObjectMeta{Name: "exporters", Namespace: "consul"},
+		Spec: TelemetryCollectorConfigSpec{
+			Exporters: []TelemetryExporter{
+				{
+					Name:     "vendor",
+					Endpoint: "https://otlp.example.com:4318",
+					Headers: []TelemetryExporterHeader{
+						{Name: "x-team", Value: "platform"},
+						{Name: "x-api-key", SecretRef: &corev1.SecretKeySelector{LocalObjectReference: corev1.LocalObjectReference{Name: "otel"}, Key: "api-key"}},
+					},
+				},
+			},
+		},
+	}
+	require.Equal(t, "exporters-telemetry-collector-config", config.SecretName())
+	require.True(t, config.ReferencesSecret("otel"))
+	require.False(t, config.ReferencesSecret("platform"))
+}
+
+func TestTelemetryCollectorConfig_Validate(t *testing.T) {
+	secretKey := &corev1.SecretKeySelector{LocalObjectReference: corev1.LocalObjectReference{Name: "otel"}, Key: "api-key"}
+	cases := map[string]struct {
+		spec   TelemetryCollectorConfigSpec
+		expErr string
+	}{
+		"valid": {
+			spec: TelemetryCollectorConfigSpec{
+				Exporters: []TelemetryExporter{
+					{
+						Name:     "vendor",
+						Endpoint: "https://otlp.example.com:4318",
+						Timeout:  "10s",
+						Headers:  []TelemetryExporterHeader{{Name: "x-api-key", SecretRef: secretKey}},
+					},
+				},
+			},
+		},
+		"valid grpc": {
+			spec: TelemetryCollectorConfigSpec{
+				Exporters: []TelemetryExporter{
+					{
+						Name:     "internal",
+						Protocol: TelemetryExporterProtocolGRPC,
+						Endpoint: "otel-collector.monitoring.svc:4317",
+					},
+				},
+			},
+		},
+		"no exporters": {
+			spec:   TelemetryCollectorConfigSpec{},
+			expErr: "spec.exporters: Required value: an exporter must be set",
+		},
+		"multiple exporters": {
+			spec: TelemetryCollectorConfigSpec{
+				Exporters: []TelemetryExporter{
+					{Name: "vendor", Endpoint: "https://a.example.com"},
+					{Name: "internal", Endpoint: "https://b.example.com"},
+				},
+			},
+			expErr: "spec.exporters: Too many: 2: must have at most 1 items",
+		},
+		"invalid exporter": {
+			spec: TelemetryCollectorConfigSpec{
+				Exporters: []TelemetryExporter{
+					{Protocol: "udp", Timeout: "soon"},
+				},
+			},
+			expErr: `[spec.exporters[0].name: Required value: name must be set, spec.exporters[0].protocol: Unsupported value: "udp": supported values: "http", "grpc", spec.exporters[0].endpoint: Required value: endpoint must be set, spec.exporters[0].timeout: Invalid value: "soon": time: invalid duration "soon"]`,
+		},
+		"invalid headers": {
+			spec: TelemetryCollectorConfigSpec{
+				Exporters: []TelemetryExporter{
+					{
+						Name:     "vendor",
+						Endpoint: "https://otlp.example.com:4318",
+						Headers: []TelemetryExporterHeader{
+							{Name: "x-api-key"},
+							{Name: "x-team", Value: "platform", SecretRef: secretKey},
+							{Value: "value"},
+							{Name: "x-token", SecretRef: &corev1.SecretKeySelector{}},
+						},
+					},
+				},
+			},
+			expErr: `[spec.exporters[0].headers[0]: Invalid value: "x-api-key": exactly one of value or secretRef must be set, spec.exporters[0].headers[1]: Invalid value: "x-team": exactly one of value or secretRef must be set, spec.exporters[0].headers[2].name: Required value: name must be set, spec.exporters[0].headers[3].secretRef: Required value: secretRef must reference a secret key]`,
+		},
+	}
+
+	for name, c := range cases {
+		t.Run(name, func(t *testing.T) {
+			config := &TelemetryCollectorConfig{
+				ObjectMeta: metav1.ObjectMeta{Name: "exporters", Namespace: "consul"},
+				Spec:       c.spec,
+			}
+			err := config.Validate()
+			if c.expErr == "" {
+				require.NoError(t, err)
+			} else {
+				require.EqualError(t, err, c.expErr)
+			}
+		})
+	}
+}
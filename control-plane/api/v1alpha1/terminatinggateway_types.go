@@ -26,6 +26,11 @@ const (
 	TerminatingGatewayFailedToSetACLs string = "FailedToSetACLs"
 )
 
+// TerminatingGatewayUserConfigPath is the directory that the Helm chart mounts the
+// terminatingGateways extraVolumes under in the terminating gateway pods. A Secret is
+// mounted at <path>/<secret name>.
+const TerminatingGatewayUserConfigPath = "/consul/userconfig"
+
 // Condition Type.
 const ConsulACLStatus ConditionType = "ConsulACLsSynced"
 
@@ -76,6 +81,12 @@ type LinkedService struct {
 	// from the gateway to the linked service.
 	CAFile string `json:"caFile,omitempty"`
 
+	// CASecretRef is the optional key of a Kubernetes Secret holding the CA certificate to use
+	// for TLS connections from the gateway to the linked service. The Secret must be mounted into
+	// the terminating gateway pods with the terminatingGateways extraVolumes Helm value, and
+	// caFile is set to its path. It cannot be set together with caFile.
+	CASecretRef *corev1.SecretKeySelector `json:"caSecretRef,omitempty"`
+
 	// CertFile is the optional path to a client certificate to use for TLS connections
 	// from the gateway to the linked service.
 	CertFile string `json:"certFile,omitempty"`
@@ -149,22 +160,7 @@ func (in *TerminatingGateway) KubernetesName() string {
 }
 
 func (in *TerminatingGateway) SetSyncedCondition(status corev1.ConditionStatus, reason, message string) {
-	cond := Condition{
-		Type:               ConditionSynced,
-		Status:             status,
-		LastTransitionTime: metav1.Now(),
-		Reason:             reason,
-		Message:            message,
-	}
-
-	for idx, c := range in.Status.Conditions {
-		if c.Type == ConditionSynced {
-			in.Status.Conditions[idx] = cond
-			return
-		}
-	}
-
-	in.Status.Conditions = append(in.Status.Conditions, cond)
+	in.Status.SetSyncedCondition(status, reason, message)
 }
 
 func (in *TerminatingGateway) SetACLStatusConditon(status corev1.ConditionStatus, reason, message string) {
@@ -267,7 +263,7 @@ func (in LinkedService) toConsul() capi.LinkedService {
 	return capi.LinkedService{
 		Namespace:              in.Namespace,
 		Name:                   in.Name,
-		CAFile:                 in.CAFile,
+		CAFile:                 in.caFile(),
 		CertFile:               in.CertFile,
 		KeyFile:                in.KeyFile,
 		SNI:                    in.SNI,
@@ -275,8 +271,24 @@ func (in LinkedService) toConsul() capi.LinkedService {
 	}
 }
 
+// caFile returns the path of the CA certificate in the terminating gateway pods.
+func (in LinkedService) caFile() string {
+	if in.CASecretRef != nil {
+		return TerminatingGatewayUserConfigPath + "/" + in.CASecretRef.Name + "/" + in.CASecretRef.Key
+	}
+	return in.CAFile
+}
+
 func (in LinkedService) validate(path *field.Path) field.ErrorList {
 	var errs field.ErrorList
+	if in.CASecretRef != nil {
+		if in.CAFile != "" {
+			errs = append(errs, field.Invalid(path.Child("caSecretRef"), in.CASecretRef.Name, "caFile and caSecretRef cannot both be set"))
+		}
+		if in.CASecretRef.Name == "" || in.CASecretRef.Key == "" {
+			errs = append(errs, field.Required(path.Child("caSecretRef"), "name and key must be set"))
+		}
+	}
 	if (in.CertFile != "" && in.KeyFile == "") || (in.KeyFile != "" && in.CertFile == "") {
 		asJSON, _ := json.Marshal(in)
 		errs = append(errs, field.Invalid(path,
@@ -151,6 +151,10 @@ func TestTerminatingGateway_ToConsul(t *testing.T) {
 						{
 							Name: "*",
 						},
+						{
+							Name:        "external",
+							CASecretRef: &corev1.SecretKeySelector{LocalObjectReference: corev1.LocalObjectReference{Name: "external-ca"}, Key: "ca.crt"},
+						},
 					},
 				},
 			},
@@ -168,6 +172,10 @@ func TestTerminatingGateway_ToConsul(t *testing.T) {
 					{
 						Name: "*",
 					},
+					{
+						Name:   "external",
+						CAFile: "/consul/userconfig/external-ca/ca.crt",
+					},
 				},
 				Meta: map[string]string{
 					common.SourceKey:     common.SourceValue,
@@ -233,6 +241,45 @@ func TestTerminatingGateway_Validate(t *testing.T) {
 				`spec.services[0]: Invalid value: "{\"name\":\"foo\",\"keyFile\":\"keyFile\"}": if certFile or keyFile is set, the other must also be set`,
 			},
 		},
+		"caFile and caSecretRef set": {
+			input: &TerminatingGateway{
+				ObjectMeta: metav1.ObjectMeta{
+					Name: "foo",
+				},
+				Spec: TerminatingGatewaySpec{
+					Services: []LinkedService{
+						{
+							Name:        "foo",
+							CAFile:      "caFile",
+							CASecretRef: &corev1.SecretKeySelector{LocalObjectReference: corev1.LocalObjectReference{Name: "ca"}, Key: "ca.crt"},
+						},
+					},
+				},
+			},
+			namespacesEnabled: false,
+			expectedErrMsgs: []string{
+				`spec.services[0].caSecretRef: Invalid value: "ca": caFile and caSecretRef cannot both be set`,
+			},
+		},
+		"caSecretRef without key": {
+			input: &TerminatingGateway{
+				ObjectMeta: metav1.ObjectMeta{
+					Name: "foo",
+				},
+				Spec: TerminatingGatewaySpec{
+					Services: []LinkedService{
+						{
+							Name:        "foo",
+							CASecretRef: &corev1.SecretKeySelector{LocalObjectReference: corev1.LocalObjectReference{Name: "ca"}},
+						},
+					},
+				},
+			},
+			namespacesEnabled: false,
+			expectedErrMsgs: []string{
+				`spec.services[0].caSecretRef: Required value: name and key must be set`,
+			},
+		},
 		"service.namespace set when namespaces disabled": {
 			input: &TerminatingGateway{
 				ObjectMeta: metav1.ObjectMeta{
@@ -0,0 +1,103 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package v1alpha1
+
+import (
+	"net"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/validation/field"
+)
+
+func init() {
+	SchemeBuilder.Register(&TrafficRedirectionDefaults{}, &TrafficRedirectionDefaultsList{})
+}
+
+// +genclient
+// +kubebuilder:object:root=true
+// +kubebuilder:resource:shortName="traffic-redirection-defaults"
+// +kubebuilder:printcolumn:name="Age",type="date",JSONPath=".metadata.creationTimestamp"
+
+// TrafficRedirectionDefaults configures the traffic that is excluded from transparent proxy
+// redirection for every injected pod in its namespace. The exclusions are merged with the
+// exclusions of the pod's transparent proxy annotations when the pod is injected, so existing
+// pods must be restarted to pick up changes.
+type TrafficRedirectionDefaults struct {
+	// Standard Kubernetes resource metadata.
+	metav1.TypeMeta `json:",inline"`
+
+	// Standard object's metadata.
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	// Spec defines the desired state of TrafficRedirectionDefaults.
+	Spec TrafficRedirectionDefaultsSpec `json:"spec,omitempty"`
+}
+
+// +k8s:deepcopy-gen=true
+
+// TrafficRedirectionDefaultsSpec specifies the desired state of the TrafficRedirectionDefaults CRD.
+type TrafficRedirectionDefaultsSpec struct {
+	// ExcludeOutboundCIDRs are the IPs and CIDRs that outbound traffic to is not redirected
+	// to the proxy, e.g. "169.254.169.254" for cloud metadata or "169.254.20.10" for node-local DNS.
+	// +optional
+	ExcludeOutboundCIDRs []string `json:"excludeOutboundCIDRs,omitempty"`
+	// ExcludeOutboundPorts are the ports that outbound traffic to is not redirected to the proxy.
+	// +optional
+	ExcludeOutboundPorts []int32 `json:"excludeOutboundPorts,omitempty"`
+	// ExcludeInboundPorts are the ports that inbound traffic to is not redirected to the proxy.
+	// +optional
+	ExcludeInboundPorts []int32 `json:"excludeInboundPorts,omitempty"`
+	// ExcludeUIDs are the user IDs whose outbound traffic is not redirected to the proxy.
+	// +optional
+	ExcludeUIDs []int64 `json:"excludeUIDs,omitempty"`
+}
+
+// +kubebuilder:object:root=true
+
+// TrafficRedirectionDefaultsList is a list of TrafficRedirectionDefaults resources.
+type TrafficRedirectionDefaultsList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata"`
+
+	// Items is the list of TrafficRedirectionDefaults.
+	Items []TrafficRedirectionDefaults `json:"items"`
+}
+
+// Validate checks that the exclusions of the TrafficRedirectionDefaults can be applied.
+func (in *TrafficRedirectionDefaults) Validate() error {
+	var errs field.ErrorList
+	path := field.NewPath("spec")
+
+	for i, cidr := range in.Spec.ExcludeOutboundCIDRs {
+		if net.ParseIP(cidr) != nil {
+			continue
+		}
+		if _, _, err := net.ParseCIDR(cidr); err != nil {
+			errs = append(errs, field.Invalid(path.Child("excludeOutboundCIDRs").Index(i), cidr, "must be an IP or CIDR"))
+		}
+	}
+	errs = append(errs, validatePorts(path.Child("excludeOutboundPorts"), in.Spec.ExcludeOutboundPorts)...)
+	errs = append(errs, validatePorts(path.Child("excludeInboundPorts"), in.Spec.ExcludeInboundPorts)...)
+	for i, uid := range in.Spec.ExcludeUIDs {
+		if uid < 0 {
+			errs = append(errs, field.Invalid(path.Child("excludeUIDs").Index(i), uid, "must not be negative"))
+		}
+	}
+
+	return errs.ToAggregate()
+}
+
+func (in *TrafficRedirectionDefaults) KubernetesName() string {
+	return in.ObjectMeta.Name
+}
+
+func validatePorts(path *field.Path, ports []int32) field.ErrorList {
+	var errs field.ErrorList
+	for i, port := range ports {
+		if port < 1 || port > 65535 {
+			errs = append(errs, field.Invalid(path.Index(i), port, "must be between 1 and 65535"))
+		}
+	}
+	return errs
+}
@@ -0,0 +1,59 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package v1alpha1
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestTrafficRedirectionDefaults_Validate(t *testing.T) {
+	cases := map[string]struct {
+		spec   TrafficRedirectionDefaultsSpec
+		expErr string
+	}{
+		"empty spec": {},
+		"valid": {
+			spec: TrafficRedirectionDefaultsSpec{
+				ExcludeOutboundCIDRs: []string{"169.254.169.254", "10.0.0.0/8", "fd00::/8"},
+				ExcludeOutboundPorts: []int32{53},
+				ExcludeInboundPorts:  []int32{9100, 65535},
+				ExcludeUIDs:          []int64{0, 1234},
+			},
+		},
+		"invalid CIDR": {
+			spec:   TrafficRedirectionDefaultsSpec{ExcludeOutboundCIDRs: []string{"10.0.0.0/8", "10.0.0.0/33"}},
+			expErr: `spec.excludeOutboundCIDRs[1]: Invalid value: "10.0.0.0/33": must be an IP or CIDR`,
+		},
+		"invalid ports": {
+			spec: TrafficRedirectionDefaultsSpec{
+				ExcludeOutboundPorts: []int32{0},
+				ExcludeInboundPorts:  []int32{80, 65536},
+			},
+			expErr: "[spec.excludeOutboundPorts[0]: Invalid value: 0: must be between 1 and 65535, " +
+				"spec.excludeInboundPorts[1]: Invalid value: 65536: must be between 1 and 65535]",
+		},
+		"invalid UID": {
+			spec:   TrafficRedirectionDefaultsSpec{ExcludeUIDs: []int64{-1}},
+			expErr: "spec.excludeUIDs[0]: Invalid value: -1: must not be negative",
+		},
+	}
+
+	for name, c := range cases {
+		t.Run(name, func(t *testing.T) {
+			defaults := &TrafficRedirectionDefaults{
+				ObjectMeta: metav1.ObjectMeta{Name: "defaults", Namespace: "default"},
+				Spec:       c.spec,
+			}
+			err := defaults.Validate()
+			if c.expErr == "" {
+				require.NoError(t, err)
+			} else {
+				require.EqualError(t, err, c.expErr)
+			}
+		})
+	}
+}
@@ -16,7 +16,7 @@ import (
 	"sigs.k8s.io/controller-runtime/pkg/client"
 )
 
-const NotInServiceMeshFilter = "ServiceMeta[\"managed-by\"] != \"consul-k8s-endpoints-controller\""
+const NotInServiceMeshFilter = "ServiceMeta[\"managed-by\"] != \"consul-k8s-endpoints-controller\" and ServiceMeta[\"managed-by\"] != \"" + externalServiceManagedByValue + "\""
 
 type RegistrationCache struct {
 	// we include the context here so that we can use it for cancellation of `run` invocations that are scheduled after the cache is started
@@ -0,0 +1,390 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package registration
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"time"
+
+	"github.com/go-logr/logr"
+	capi "github.com/hashicorp/consul/api"
+	"k8s.io/apimachinery/pkg/api/equality"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	"github.com/hashicorp/consul-k8s/control-plane/api/v1alpha1"
+	"github.com/hashicorp/consul-k8s/control-plane/connect-inject/constants"
+	"github.com/hashicorp/consul-k8s/control-plane/consul"
+	"github.com/hashicorp/consul-k8s/control-plane/controllers/configentries"
+)
+
+const (
+	ExternalServiceFinalizer = "externalservice.finalizers.consul.hashicorp.com"
+
+	// externalServiceManagedByValue is the value of the managed-by service meta key for
+	// services registered by the ExternalServicesController.
+	externalServiceManagedByValue = "consul-k8s-external-services-controller"
+
+	externalServiceByTerminatingGatewayIndex = "externalServiceTerminatingGateway"
+)
+
+var (
+	ErrLinkingTerminatingGateway   = fmt.Errorf("error linking service to terminating gateway")
+	ErrUnlinkingTerminatingGateway = fmt.Errorf("error unlinking service from terminating gateway")
+)
+
+// ExternalServicesController is the controller for ExternalService resources. It registers
+// the endpoints of each ExternalService in the Consul catalog and links the service to a
+// TerminatingGateway resource so that mesh services can reach it through the gateway.
+type ExternalServicesController struct {
+	client.Client
+	configentries.FinalizerPatcher
+	ConsulClientConfig  *consul.Config
+	ConsulServerConnMgr consul.ServerConnectionManager
+	Scheme              *runtime.Scheme
+	Log                 logr.Logger
+}
+
+// +kubebuilder:rbac:groups=consul.hashicorp.com,resources=externalservices,verbs=get;list;watch;create;update;patch;delete
+// +kubebuilder:rbac:groups=consul.hashicorp.com,resources=externalservices/status,verbs=get;update;patch
+
+func (r *ExternalServicesController) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
+	log := r.Log.V(1).WithValues("externalservice", req.NamespacedName)
+	log.Info("Reconciling ExternalService")
+
+	svc := &v1alpha1.ExternalService{}
+	if err := r.Client.Get(ctx, req.NamespacedName, svc); err != nil {
+		if !k8serrors.IsNotFound(err) {
+			log.Error(err, "unable to get external service")
+		}
+		return ctrl.Result{}, client.IgnoreNotFound(err)
+	}
+
+	// deletion request
+	if !svc.ObjectMeta.DeletionTimestamp.IsZero() {
+		result := r.handleDeletion(ctx, log, svc)
+
+		if result.hasErrors() {
+			err := r.UpdateStatus(ctx, svc, result)
+			if err != nil {
+				log.Error(err, "failed to update ExternalService status", "name", svc.Name, "namespace", svc.Namespace)
+			}
+			return ctrl.Result{}, result.errors()
+		}
+		return ctrl.Result{}, nil
+	}
+
+	// registration request
+	result := r.handleRegistration(ctx, log, svc)
+	err := r.UpdateStatus(ctx, svc, result)
+	if err != nil {
+		log.Error(err, "failed to update ExternalService status", "name", svc.Name, "namespace", svc.Namespace)
+	}
+	if result.hasErrors() {
+		return ctrl.Result{}, result.errors()
+	}
+
+	return ctrl.Result{}, nil
+}
+
+func (r *ExternalServicesController) handleRegistration(ctx context.Context, log logr.Logger, svc *v1alpha1.ExternalService) Result {
+	log.Info("Registering external service")
+
+	result := Result{Registering: true}
+
+	patch := r.AddFinalizersPatch(svc, ExternalServiceFinalizer)
+	err := r.Patch(ctx, svc, patch)
+	if err != nil {
+		err = fmt.Errorf("error adding finalizer: %w", err)
+		result.Finalizer = err
+		return result
+	}
+
+	regs, err := svc.ToCatalogRegistrations()
+	if err != nil {
+		result.Sync = err
+		result.Registration = fmt.Errorf("%w: %s", ErrRegisteringService, err)
+		return result
+	}
+
+	consulClient, err := consul.NewClientFromConnMgr(r.ConsulClientConfig, r.ConsulServerConnMgr)
+	if err != nil {
+		result.Sync = err
+		result.Registration = fmt.Errorf("%w: %s", ErrRegisteringService, err)
+		return result
+	}
+
+	for _, reg := range regs {
+		reg.Service.Meta = externalServiceMeta(reg.Service.Meta, svc)
+		if _, err := consulClient.Catalog().Register(reg, (&capi.WriteOptions{}).WithContext(ctx)); err != nil {
+			result.Sync = err
+			result.Registration = fmt.Errorf("%w: %s", ErrRegisteringService, err)
+			return result
+		}
+	}
+
+	// Remove instances that were registered for endpoints or nodes that are no longer in the spec.
+	registered := make([]string, 0, len(regs))
+	for _, reg := range regs {
+		registered = append(registered, reg.Node+"/"+reg.Service.ID)
+	}
+	err = r.deregisterInstances(consulClient, svc, func(s *capi.CatalogService) bool {
+		return !slices.Contains(registered, s.Node+"/"+s.ServiceID)
+	})
+	if err != nil {
+		result.Sync = err
+		result.Registration = fmt.Errorf("%w: %s", ErrRegisteringService, err)
+		return result
+	}
+
+	if svc.Status.TerminatingGateway != "" && svc.Status.TerminatingGateway != svc.Spec.TerminatingGateway {
+		if err := r.unlinkTerminatingGateway(ctx, svc, svc.Status.TerminatingGateway); err != nil {
+			result.Sync = err
+			result.Registration = fmt.Errorf("%w: %s", ErrUnlinkingTerminatingGateway, err)
+			return result
+		}
+		svc.Status.TerminatingGateway = ""
+	}
+
+	if svc.Spec.TerminatingGateway != "" {
+		if err := r.linkTerminatingGateway(ctx, svc); err != nil {
+			result.Sync = err
+			result.Registration = fmt.Errorf("%w: %s", ErrLinkingTerminatingGateway, err)
+			return result
+		}
+		svc.Status.TerminatingGateway = svc.Spec.TerminatingGateway
+	}
+
+	return result
+}
+
+func (r *ExternalServicesController) handleDeletion(ctx context.Context, log logr.Logger, svc *v1alpha1.ExternalService) Result {
+	log.Info("Deregistering external service")
+	result := Result{Registering: false}
+
+	if svc.Status.TerminatingGateway != "" {
+		if err := r.unlinkTerminatingGateway(ctx, svc, svc.Status.TerminatingGateway); err != nil {
+			result.Sync = err
+			result.Deregistration = fmt.Errorf("%w: %s", ErrUnlinkingTerminatingGateway, err)
+			return result
+		}
+	}
+
+	consulClient, err := consul.NewClientFromConnMgr(r.ConsulClientConfig, r.ConsulServerConnMgr)
+	if err != nil {
+		result.Sync = err
+		result.Deregistration = fmt.Errorf("%w: %s", ErrDeregisteringService, err)
+		return result
+	}
+
+	err = r.deregisterInstances(consulClient, svc, func(*capi.CatalogService) bool { return true })
+	if err != nil {
+		result.Sync = err
+		result.Deregistration = fmt.Errorf("%w: %s", ErrDeregisteringService, err)
+		return result
+	}
+
+	patch := r.RemoveFinalizersPatch(svc, ExternalServiceFinalizer)
+	err = r.Patch(ctx, svc, patch)
+	if err != nil {
+		result.Finalizer = err
+		return result
+	}
+
+	return result
+}
+
+// deregisterInstances deregisters the Consul service instances registered for svc for which
+// shouldDeregister returns true. Nodes that are left without any services are deregistered too.
+func (r *ExternalServicesController) deregisterInstances(consulClient *capi.Client, svc *v1alpha1.ExternalService, shouldDeregister func(*capi.CatalogService) bool) error {
+	opts := &capi.QueryOptions{
+		Filter: fmt.Sprintf(`ServiceMeta[%q] == %q and ServiceMeta[%q] == %q and ServiceMeta[%q] == %q`,
+			constants.MetaKeyManagedBy, externalServiceManagedByValue,
+			constants.MetaKeyKubeNS, svc.Namespace,
+			constants.MetaKeyKubeName, svc.Name),
+		Namespace:  svc.Spec.Namespace,
+		Partition:  svc.Spec.Partition,
+		Datacenter: svc.Spec.Datacenter,
+	}
+	instances, _, err := consulClient.Catalog().Service(svc.ServiceName(), "", opts)
+	if err != nil {
+		return err
+	}
+
+	var errs error
+	nodes := make(map[string]struct{})
+	for _, instance := range instances {
+		if !shouldDeregister(instance) {
+			continue
+		}
+		_, err := consulClient.Catalog().Deregister(&capi.CatalogDeregistration{
+			Node:       instance.Node,
+			ServiceID:  instance.ServiceID,
+			Datacenter: svc.Spec.Datacenter,
+			Namespace:  instance.Namespace,
+			Partition:  instance.Partition,
+		}, nil)
+		if err != nil {
+			errs = errors.Join(errs, err)
+			continue
+		}
+		nodes[instance.Node] = struct{}{}
+	}
+
+	for node := range nodes {
+		nodeOpts := &capi.QueryOptions{Partition: svc.Spec.Partition, Datacenter: svc.Spec.Datacenter}
+		if svc.Spec.Namespace != "" {
+			// Other services on the node may be in any namespace.
+			nodeOpts.Namespace = "*"
+		}
+		services, _, err := consulClient.Catalog().NodeServiceList(node, nodeOpts)
+		if err != nil {
+			errs = errors.Join(errs, err)
+			continue
+		}
+		if services != nil && len(services.Services) > 0 {
+			continue
+		}
+		_, err = consulClient.Catalog().Deregister(&capi.CatalogDeregistration{
+			Node:       node,
+			Datacenter: svc.Spec.Datacenter,
+			Partition:  svc.Spec.Partition,
+		}, nil)
+		if err != nil {
+			errs = errors.Join(errs, err)
+		}
+	}
+
+	return errs
+}
+
+// linkTerminatingGateway adds the service to the linked services of the TerminatingGateway
+// resource referenced by svc if it is not already linked, and updates the TLS settings of the
+// linked service if svc configures TLS.
+func (r *ExternalServicesController) linkTerminatingGateway(ctx context.Context, svc *v1alpha1.ExternalService) error {
+	termGW := &v1alpha1.TerminatingGateway{}
+	if err := r.Client.Get(ctx, types.NamespacedName{Name: svc.Spec.TerminatingGateway, Namespace: svc.Namespace}, termGW); err != nil {
+		return err
+	}
+
+	linked := svc.LinkedService()
+	patch := client.MergeFrom(termGW.DeepCopy())
+	i := slices.IndexFunc(termGW.Spec.Services, linkedServiceMatcher(svc))
+	switch {
+	case i < 0:
+		termGW.Spec.Services = append(termGW.Spec.Services, linked)
+	case svc.Spec.TLS != nil && !linkedTLSEqual(termGW.Spec.Services[i], linked):
+		// The TLS settings of the ExternalService replace any that were written by hand.
+		termGW.Spec.Services[i].CAFile = ""
+		termGW.Spec.Services[i].CASecretRef = linked.CASecretRef
+		termGW.Spec.Services[i].SNI = linked.SNI
+	default:
+		return nil
+	}
+	return r.Patch(ctx, termGW, patch)
+}
+
+func linkedTLSEqual(a, b v1alpha1.LinkedService) bool {
+	return a.CAFile == b.CAFile && a.SNI == b.SNI && equality.Semantic.DeepEqual(a.CASecretRef, b.CASecretRef)
+}
+
+// unlinkTerminatingGateway removes the service from the linked services of the named
+// TerminatingGateway resource. A missing TerminatingGateway is not an error.
+func (r *ExternalServicesController) unlinkTerminatingGateway(ctx context.Context, svc *v1alpha1.ExternalService, name string) error {
+	termGW := &v1alpha1.TerminatingGateway{}
+	if err := r.Client.Get(ctx, types.NamespacedName{Name: name, Namespace: svc.Namespace}, termGW); err != nil {
+		return client.IgnoreNotFound(err)
+	}
+
+	if !slices.ContainsFunc(termGW.Spec.Services, linkedServiceMatcher(svc)) {
+		return nil
+	}
+
+	patch := client.MergeFrom(termGW.DeepCopy())
+	termGW.Spec.Services = slices.DeleteFunc(termGW.Spec.Services, linkedServiceMatcher(svc))
+	return r.Patch(ctx, termGW, patch)
+}
+
+func linkedServiceMatcher(svc *v1alpha1.ExternalService) func(v1alpha1.LinkedService) bool {
+	return func(l v1alpha1.LinkedService) bool {
+		return l.Name == svc.ServiceName() && l.Namespace == svc.Spec.Namespace
+	}
+}
+
+// externalServiceMeta adds the metadata used to find the instances registered for svc.
+func externalServiceMeta(meta map[string]string, svc *v1alpha1.ExternalService) map[string]string {
+	if meta == nil {
+		meta = make(map[string]string, 3)
+	}
+	meta[constants.MetaKeyManagedBy] = externalServiceManagedByValue
+	meta[constants.MetaKeyKubeNS] = svc.Namespace
+	meta[constants.MetaKeyKubeName] = svc.Name
+	return meta
+}
+
+func (r *ExternalServicesController) UpdateStatus(ctx context.Context, svc *v1alpha1.ExternalService, result Result) error {
+	svc.Status.LastSyncedTime = &metav1.Time{Time: time.Now()}
+	svc.Status.Conditions = v1alpha1.Conditions{
+		syncedCondition(result),
+	}
+
+	if result.Registering {
+		svc.Status.Conditions = append(svc.Status.Conditions, registrationCondition(result))
+	} else {
+		svc.Status.Conditions = append(svc.Status.Conditions, deregistrationCondition(result))
+	}
+
+	return r.Status().Update(ctx, svc)
+}
+
+func (r *ExternalServicesController) Logger(name types.NamespacedName) logr.Logger {
+	return r.Log.WithValues("request", name)
+}
+
+func (r *ExternalServicesController) SetupWithManager(ctx context.Context, mgr ctrl.Manager) error {
+	// setup the index to lookup external services by the terminating gateway they are linked to
+	if err := mgr.GetFieldIndexer().IndexField(ctx, &v1alpha1.ExternalService{}, externalServiceByTerminatingGatewayIndex, func(o client.Object) []string {
+		return []string{o.(*v1alpha1.ExternalService).Spec.TerminatingGateway}
+	}); err != nil {
+		return err
+	}
+
+	return ctrl.NewControllerManagedBy(mgr).
+		For(&v1alpha1.ExternalService{}).
+		Watches(&v1alpha1.TerminatingGateway{}, handler.EnqueueRequestsFromMapFunc(r.transformTerminatingGateway)).
+		Complete(r)
+}
+
+// transformTerminatingGateway requeues the ExternalServices that reference a TerminatingGateway
+// so that links removed from the gateway, e.g. by re-applying its manifest, are restored.
+func (r *ExternalServicesController) transformTerminatingGateway(ctx context.Context, o client.Object) []reconcile.Request {
+	termGW := o.(*v1alpha1.TerminatingGateway)
+
+	list := &v1alpha1.ExternalServiceList{}
+	err := r.Client.List(ctx, list, client.InNamespace(termGW.Namespace), client.MatchingFields{externalServiceByTerminatingGatewayIndex: termGW.Name})
+	if err != nil {
+		r.Log.Error(err, "error listing external services by terminating gateway", "terminatingGateway", termGW.Name)
+		return nil
+	}
+
+	reqs := make([]reconcile.Request, 0, len(list.Items))
+	for _, svc := range list.Items {
+		reqs = append(reqs, reconcile.Request{
+			NamespacedName: types.NamespacedName{
+				Name:      svc.Name,
+				Namespace: svc.Namespace,
+			},
+		})
+	}
+	return reqs
+}
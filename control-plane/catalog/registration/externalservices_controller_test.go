@@ -0,0 +1,320 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package registration_test
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+
+	logrtest "github.com/go-logr/logr/testing"
+	capi "github.com/hashicorp/consul/api"
+	"github.com/stretchr/testify/require"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	"github.com/hashicorp/consul-k8s/control-plane/api/v1alpha1"
+	"github.com/hashicorp/consul-k8s/control-plane/catalog/registration"
+	"github.com/hashicorp/consul-k8s/control-plane/consul"
+	"github.com/hashicorp/consul-k8s/control-plane/helper/test"
+)
+
+func TestExternalServicesReconcile(t *testing.T) {
+	deletionTime := metav1.Now()
+	cases := map[string]struct {
+		externalService *v1alpha1.ExternalService
+		termGWServices  []v1alpha1.LinkedService
+		// catalog is the list of instances returned by Consul for the service.
+		catalog              []*capi.CatalogService
+		errOnRegister        bool
+		expRegistered        []string
+		expDeregistered      []string
+		expFinalizers        []string
+		expTermGWServices    []v1alpha1.LinkedService
+		expStatusTermGW      string
+		expRegisteredCond    v1.ConditionStatus
+		expErr               string
+		skipStatusConditions bool
+	}{
+		"registers endpoints and links terminating gateway": {
+			externalService: &v1alpha1.ExternalService{
+				ObjectMeta: metav1.ObjectMeta{Name: "db", Namespace: "default"},
+				Spec: v1alpha1.ExternalServiceSpec{
+					Endpoints: []v1alpha1.ExternalServiceEndpoint{
+						{Address: "10.0.0.1", Port: 5432},
+						{Address: "10.0.0.2", Port: 5432},
+					},
+					TerminatingGateway: "terminating-gateway",
+				},
+			},
+			termGWServices:    []v1alpha1.LinkedService{{Name: "other"}},
+			expRegistered:     []string{"db-0", "db-1"},
+			expFinalizers:     []string{registration.ExternalServiceFinalizer},
+			expTermGWServices: []v1alpha1.LinkedService{{Name: "other"}, {Name: "db"}},
+			expStatusTermGW:   "terminating-gateway",
+			expRegisteredCond: v1.ConditionTrue,
+		},
+		"links terminating gateway with tls": {
+			externalService: &v1alpha1.ExternalService{
+				ObjectMeta: metav1.ObjectMeta{Name: "db", Namespace: "default"},
+				Spec: v1alpha1.ExternalServiceSpec{
+					Endpoints:          []v1alpha1.ExternalServiceEndpoint{{Address: "10.0.0.1", Port: 5432}},
+					TerminatingGateway: "terminating-gateway",
+					TLS: &v1alpha1.ExternalServiceTLS{
+						CASecretRef: &v1.SecretKeySelector{LocalObjectReference: v1.LocalObjectReference{Name: "db-ca"}, Key: "ca.crt"},
+						SNI:         "db.example.com",
+					},
+				},
+			},
+			expRegistered: []string{"db-0"},
+			expFinalizers: []string{registration.ExternalServiceFinalizer},
+			expTermGWServices: []v1alpha1.LinkedService{{
+				Name:        "db",
+				CASecretRef: &v1.SecretKeySelector{LocalObjectReference: v1.LocalObjectReference{Name: "db-ca"}, Key: "ca.crt"},
+				SNI:         "db.example.com",
+			}},
+			expStatusTermGW:   "terminating-gateway",
+			expRegisteredCond: v1.ConditionTrue,
+		},
+		"updates tls of linked service": {
+			externalService: &v1alpha1.ExternalService{
+				ObjectMeta: metav1.ObjectMeta{Name: "db", Namespace: "default"},
+				Spec: v1alpha1.ExternalServiceSpec{
+					Endpoints:          []v1alpha1.ExternalServiceEndpoint{{Address: "10.0.0.1", Port: 5432}},
+					TerminatingGateway: "terminating-gateway",
+					TLS: &v1alpha1.ExternalServiceTLS{
+						CASecretRef: &v1.SecretKeySelector{LocalObjectReference: v1.LocalObjectReference{Name: "db-ca"}, Key: "ca.crt"},
+					},
+				},
+			},
+			termGWServices: []v1alpha1.LinkedService{{Name: "db", CAFile: "/etc/ssl/ca.pem", SNI: "old.example.com", DisableAutoHostRewrite: true}},
+			expRegistered:  []string{"db-0"},
+			expFinalizers:  []string{registration.ExternalServiceFinalizer},
+			expTermGWServices: []v1alpha1.LinkedService{{
+				Name:                   "db",
+				CASecretRef:            &v1.SecretKeySelector{LocalObjectReference: v1.LocalObjectReference{Name: "db-ca"}, Key: "ca.crt"},
+				DisableAutoHostRewrite: true,
+			}},
+			expStatusTermGW:   "terminating-gateway",
+			expRegisteredCond: v1.ConditionTrue,
+		},
+		"deregisters stale endpoints": {
+			externalService: &v1alpha1.ExternalService{
+				ObjectMeta: metav1.ObjectMeta{Name: "db", Namespace: "default"},
+				Spec: v1alpha1.ExternalServiceSpec{
+					Endpoints: []v1alpha1.ExternalServiceEndpoint{{Address: "10.0.0.1", Port: 5432}},
+				},
+			},
+			catalog: []*capi.CatalogService{
+				{Node: "db-external-node", ServiceID: "db-0"},
+				{Node: "db-external-node", ServiceID: "db-1"},
+			},
+			expRegistered:     []string{"db-0"},
+			expDeregistered:   []string{"db-1"},
+			expFinalizers:     []string{registration.ExternalServiceFinalizer},
+			expRegisteredCond: v1.ConditionTrue,
+		},
+		"unlinks previous terminating gateway": {
+			externalService: &v1alpha1.ExternalService{
+				ObjectMeta: metav1.ObjectMeta{Name: "db", Namespace: "default"},
+				Spec: v1alpha1.ExternalServiceSpec{
+					Endpoints: []v1alpha1.ExternalServiceEndpoint{{Address: "10.0.0.1", Port: 5432}},
+				},
+				Status: v1alpha1.ExternalServiceStatus{TerminatingGateway: "terminating-gateway"},
+			},
+			termGWServices:    []v1alpha1.LinkedService{{Name: "other"}, {Name: "db"}},
+			expRegistered:     []string{"db-0"},
+			expFinalizers:     []string{registration.ExternalServiceFinalizer},
+			expTermGWServices: []v1alpha1.LinkedService{{Name: "other"}},
+			expRegisteredCond: v1.ConditionTrue,
+		},
+		"invalid spec": {
+			externalService: &v1alpha1.ExternalService{
+				ObjectMeta: metav1.ObjectMeta{Name: "db", Namespace: "default"},
+			},
+			expFinalizers:     []string{registration.ExternalServiceFinalizer},
+			expRegisteredCond: v1.ConditionFalse,
+			expErr:            "spec.endpoints: Required value: at least one endpoint must be defined",
+		},
+		"error registering": {
+			externalService: &v1alpha1.ExternalService{
+				ObjectMeta: metav1.ObjectMeta{Name: "db", Namespace: "default"},
+				Spec: v1alpha1.ExternalServiceSpec{
+					Endpoints: []v1alpha1.ExternalServiceEndpoint{{Address: "10.0.0.1", Port: 5432}},
+				},
+			},
+			errOnRegister:     true,
+			expFinalizers:     []string{registration.ExternalServiceFinalizer},
+			expRegisteredCond: v1.ConditionFalse,
+			expErr:            "Unexpected response code: 500",
+		},
+		"deletion deregisters endpoints and unlinks terminating gateway": {
+			externalService: &v1alpha1.ExternalService{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:              "db",
+					Namespace:         "default",
+					Finalizers:        []string{registration.ExternalServiceFinalizer},
+					DeletionTimestamp: &deletionTime,
+				},
+				Spec: v1alpha1.ExternalServiceSpec{
+					Endpoints:          []v1alpha1.ExternalServiceEndpoint{{Address: "10.0.0.1", Port: 5432}},
+					TerminatingGateway: "terminating-gateway",
+				},
+				Status: v1alpha1.ExternalServiceStatus{TerminatingGateway: "terminating-gateway"},
+			},
+			termGWServices: []v1alpha1.LinkedService{{Name: "db"}},
+			catalog: []*capi.CatalogService{
+				{Node: "db-external-node", ServiceID: "db-0"},
+			},
+			// The node has no services left so it is deregistered as well.
+			expDeregistered:      []string{"db-0", ""},
+			expTermGWServices:    []v1alpha1.LinkedService{},
+			skipStatusConditions: true,
+		},
+	}
+
+	for name, c := range cases {
+		t.Run(name, func(t *testing.T) {
+			s := runtime.NewScheme()
+			s.AddKnownTypes(v1alpha1.GroupVersion, &v1alpha1.ExternalService{}, &v1alpha1.TerminatingGateway{}, &v1alpha1.TerminatingGatewayList{})
+			ctx := context.Background()
+
+			consulServer := newFakeCatalogServer(t, c.catalog, c.errOnRegister)
+			defer consulServer.Close()
+
+			termGW := &v1alpha1.TerminatingGateway{
+				ObjectMeta: metav1.ObjectMeta{Name: "terminating-gateway", Namespace: "default"},
+				Spec:       v1alpha1.TerminatingGatewaySpec{Services: c.termGWServices},
+			}
+			fakeClient := fake.NewClientBuilder().
+				WithScheme(s).
+				WithRuntimeObjects(c.externalService, termGW).
+				WithStatusSubresource(&v1alpha1.ExternalService{}).
+				Build()
+
+			controller := &registration.ExternalServicesController{
+				Client:              fakeClient,
+				ConsulClientConfig:  consulServer.cfg,
+				ConsulServerConnMgr: consulServer.watcher,
+				Log:                 logrtest.NewTestLogger(t),
+				Scheme:              s,
+			}
+
+			_, err := controller.Reconcile(ctx, ctrl.Request{
+				NamespacedName: types.NamespacedName{Name: c.externalService.Name, Namespace: c.externalService.Namespace},
+			})
+			if c.expErr != "" {
+				require.ErrorContains(t, err, c.expErr)
+			} else {
+				require.NoError(t, err)
+			}
+
+			require.ElementsMatch(t, c.expRegistered, consulServer.registered)
+			require.ElementsMatch(t, c.expDeregistered, consulServer.deregistered)
+
+			fetchedTermGW := &v1alpha1.TerminatingGateway{}
+			require.NoError(t, fakeClient.Get(ctx, types.NamespacedName{Name: termGW.Name, Namespace: termGW.Namespace}, fetchedTermGW))
+			if c.expTermGWServices != nil {
+				require.ElementsMatch(t, c.expTermGWServices, fetchedTermGW.Spec.Services)
+			} else {
+				require.Equal(t, c.termGWServices, fetchedTermGW.Spec.Services)
+			}
+
+			fetched := &v1alpha1.ExternalService{}
+			err = fakeClient.Get(ctx, types.NamespacedName{Name: c.externalService.Name, Namespace: c.externalService.Namespace}, fetched)
+			if c.skipStatusConditions {
+				// The resource is deleted by the fake client once its last finalizer is removed.
+				require.True(t, err != nil || len(fetched.Finalizers) == 0)
+				return
+			}
+			require.NoError(t, err)
+			require.ElementsMatch(t, c.expFinalizers, fetched.Finalizers)
+			require.Equal(t, c.expStatusTermGW, fetched.Status.TerminatingGateway)
+			require.Len(t, fetched.Status.Conditions, 2)
+			require.Equal(t, registration.ConditionRegistered, string(fetched.Status.Conditions[1].Type))
+			require.Equal(t, c.expRegisteredCond, fetched.Status.Conditions[1].Status)
+		})
+	}
+}
+
+type fakeCatalogServer struct {
+	*httptest.Server
+	cfg     *consul.Config
+	watcher consul.ServerConnectionManager
+
+	mu           sync.Mutex
+	registered   []string
+	deregistered []string
+}
+
+// newFakeCatalogServer returns a Consul HTTP API server that records the service IDs that are
+// registered and deregistered. A deregistration of a whole node is recorded as an empty string.
+func newFakeCatalogServer(t *testing.T, catalog []*capi.CatalogService, errOnRegister bool) *fakeCatalogServer {
+	t.Helper()
+	f := &fakeCatalogServer{}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/v1/catalog/register", func(w http.ResponseWriter, r *http.Request) {
+		if errOnRegister {
+			w.WriteHeader(500)
+			return
+		}
+		var reg capi.CatalogRegistration
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&reg))
+		f.mu.Lock()
+		f.registered = append(f.registered, reg.Service.ID)
+		f.mu.Unlock()
+		w.WriteHeader(200)
+	})
+	mux.HandleFunc("/v1/catalog/deregister", func(w http.ResponseWriter, r *http.Request) {
+		var dereg capi.CatalogDeregistration
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&dereg))
+		f.mu.Lock()
+		f.deregistered = append(f.deregistered, dereg.ServiceID)
+		f.mu.Unlock()
+		w.WriteHeader(200)
+	})
+	mux.HandleFunc("/v1/catalog/service/", func(w http.ResponseWriter, r *http.Request) {
+		require.Contains(t, r.URL.Query().Get("filter"), "consul-k8s-external-services-controller")
+		val, err := json.Marshal(catalog)
+		require.NoError(t, err)
+		w.Write(val)
+	})
+	mux.HandleFunc("/v1/catalog/node-services/", func(w http.ResponseWriter, r *http.Request) {
+		// Return the instances from the catalog that have not been deregistered yet.
+		list := &capi.CatalogNodeServiceList{}
+		f.mu.Lock()
+		for _, svc := range catalog {
+			if !slices.Contains(f.deregistered, svc.ServiceID) {
+				list.Services = append(list.Services, &capi.AgentService{ID: svc.ServiceID})
+			}
+		}
+		f.mu.Unlock()
+		val, err := json.Marshal(list)
+		require.NoError(t, err)
+		w.Write(val)
+	})
+	f.Server = httptest.NewServer(mux)
+
+	parsedURL, err := url.Parse(f.URL)
+	require.NoError(t, err)
+	host := strings.Split(parsedURL.Host, ":")[0]
+	port, err := strconv.Atoi(parsedURL.Port())
+	require.NoError(t, err)
+
+	f.cfg = &consul.Config{APIClientConfig: &capi.Config{Address: host}, HTTPPort: port}
+	f.watcher = test.MockConnMgrForIPAndPort(t, host, port, false)
+	return f
+}
@@ -18,6 +18,12 @@ const (
 	// service or an integer value.
 	annotationServicePort = "consul.hashicorp.com/service-port"
 
+	// annotationServiceProtocol specifies the protocol of the service, one of
+	// tcp, http, http2 or grpc. It is set in a service-defaults config entry
+	// written for the service, unless the config entry is managed otherwise,
+	// e.g. by a ServiceDefaults custom resource.
+	annotationServiceProtocol = "consul.hashicorp.com/service-protocol"
+
 	// annotationServiceTags specifies the tags for the registered service
 	// instance. Multiple tags should be comma separated. Whitespace around
 	// the tags is automatically trimmed.
@@ -0,0 +1,293 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package catalog
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/armon/go-metrics"
+	mapset "github.com/deckarep/golang-set"
+	"github.com/hashicorp/consul/api"
+	corev1 "k8s.io/api/core/v1"
+
+	"github.com/hashicorp/consul-k8s/control-plane/consul"
+)
+
+// ConflictPolicy decides what the syncer does with a Kubernetes service
+// whose Consul service name is also used by services registered in Consul
+// natively, i.e. not by this sync.
+type ConflictPolicy string
+
+const (
+	// ConflictPolicyK8sWins registers the Kubernetes service under the
+	// conflicting name, alongside the native instances. This is the default.
+	ConflictPolicyK8sWins ConflictPolicy = "k8s-wins"
+
+	// ConflictPolicyConsulWins doesn't register the Kubernetes service
+	// until the native service is deregistered from Consul.
+	ConflictPolicyConsulWins ConflictPolicy = "consul-wins"
+
+	// ConflictPolicySuffixK8s registers the Kubernetes service under its
+	// name with the ConflictSuffix appended.
+	ConflictPolicySuffixK8s ConflictPolicy = "suffix-k8s"
+
+	// ConflictSuffix is the suffix appended to the Consul service name and ID
+	// of conflicting Kubernetes services with ConflictPolicySuffixK8s.
+	ConflictSuffix = "-k8s"
+
+	// reasonServiceConflict is the reason of the Events recorded on the
+	// Kubernetes services that conflict with native Consul services.
+	reasonServiceConflict = "ConsulServiceConflict"
+)
+
+// ConflictPolicies are the valid values of ConflictPolicy.
+var ConflictPolicies = []ConflictPolicy{ConflictPolicyK8sWins, ConflictPolicyConsulWins, ConflictPolicySuffixK8s}
+
+// ValidConflictPolicy returns whether policy is a known ConflictPolicy.
+func ValidConflictPolicy(policy string) bool {
+	for _, p := range ConflictPolicies {
+		if string(p) == policy {
+			return true
+		}
+	}
+	return false
+}
+
+// watchConflicts is a long-running task started by Run that periodically
+// looks for native Consul services with the same name as synced services.
+func (s *ConsulSyncer) watchConflicts(ctx context.Context) {
+	// The set of synced services is only known after the initial sync.
+	<-s.initialSync
+
+	waitCh := time.After(0)
+	waitBeforeRetry := s.SyncPeriod / 4
+
+	for {
+		select {
+		case <-waitCh:
+			s.detectConflicts(ctx)
+			waitCh = time.After(waitBeforeRetry)
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+// detectConflicts queries the Consul catalog for the services that have
+// instances without the sync tag, records the ones that conflict with
+// synced services and applies the conflict policy to them.
+func (s *ConsulSyncer) detectConflicts(ctx context.Context) {
+	s.lock.Lock()
+	requested := s.requestedServiceNamesLocked()
+	s.lock.Unlock()
+
+	consulClient, err := consul.NewClientFromConnMgr(s.ConsulClientConfig, s.ConsulServerConnMgr)
+	if err != nil {
+		s.Log.Error("failed to create Consul API client", "err", err)
+		return
+	}
+
+	conflicts := make(map[string]mapset.Set)
+	for ns, names := range requested {
+		opts := &api.QueryOptions{
+			AllowStale: true,
+			Filter:     fmt.Sprintf("%q not in ServiceTags", s.ConsulK8STag),
+		}
+		if s.EnableNamespaces {
+			opts.Namespace = ns
+		}
+		services, _, err := consulClient.Catalog().Services(opts.WithContext(ctx))
+		if err != nil {
+			// Keep the conflicts we knew about so that the registrations don't flap.
+			s.Log.Warn("error querying services for conflicts", "consul-namespace-name", ns, "err", err)
+			s.lock.Lock()
+			conflicts[ns] = s.conflicts[ns]
+			s.lock.Unlock()
+			continue
+		}
+		for name := range services {
+			if !names.Contains(name) {
+				continue
+			}
+			if conflicts[ns] == nil {
+				conflicts[ns] = mapset.NewSet()
+			}
+			conflicts[ns].Add(name)
+		}
+	}
+
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	changed := false
+	for ns, names := range conflicts {
+		if names == nil {
+			continue
+		}
+		for name := range names.Iter() {
+			if s.conflicts[ns] == nil || !s.conflicts[ns].Contains(name) {
+				changed = true
+				s.recordConflictLocked(ns, name.(string))
+			}
+		}
+	}
+	for ns, names := range s.conflicts {
+		for name := range names.Iter() {
+			if conflicts[ns] == nil || !conflicts[ns].Contains(name) {
+				changed = true
+				s.Log.Info("service no longer conflicts with a native Consul service",
+					"service-name", name, "consul-namespace-name", ns)
+			}
+		}
+	}
+
+	s.conflicts = conflicts
+	if changed {
+		s.indexRegistrationsLocked()
+	}
+}
+
+// requestedServiceNamesLocked returns the Consul service names of the
+// registrations given to Sync by Consul namespace, before the conflict policy
+// is applied. It must be called with the lock held.
+func (s *ConsulSyncer) requestedServiceNamesLocked() map[string]mapset.Set {
+	names := make(map[string]mapset.Set)
+	for _, r := range s.registrations {
+		ns := r.Service.Namespace
+		if names[ns] == nil {
+			names[ns] = mapset.NewSet()
+		}
+		names[ns].Add(r.Service.Service)
+	}
+	return names
+}
+
+// recordConflictLocked logs, counts and records Events for a newly
+// detected conflict. It must be called with the lock held.
+func (s *ConsulSyncer) recordConflictLocked(ns, name string) {
+	policy := s.conflictPolicy()
+	s.Log.Warn("service conflicts with a native Consul service",
+		"service-name", name, "consul-namespace-name", ns, "policy", policy)
+
+	labels := []metrics.Label{
+		{Name: "service", Value: name},
+		{Name: "namespace", Value: ns},
+		{Name: "policy", Value: string(policy)},
+	}
+	s.PrometheusSink.IncrCounterWithLabels(conflictName, 1, labels)
+
+	if s.EventRecorder == nil {
+		return
+	}
+
+	var message string
+	switch policy {
+	case ConflictPolicyConsulWins:
+		message = fmt.Sprintf("Consul service %q is registered natively in Consul, not syncing this service", name)
+	case ConflictPolicySuffixK8s:
+		message = fmt.Sprintf("Consul service %q is registered natively in Consul, syncing this service as %q", name, name+ConflictSuffix)
+	default:
+		message = fmt.Sprintf("Consul service %q is registered natively in Consul, syncing this service alongside it", name)
+	}
+
+	// Record a single Event per Kubernetes service, not one per instance.
+	recorded := make(map[string]struct{})
+	for _, r := range s.registrations {
+		if r.Service.Namespace != ns || r.Service.Service != name {
+			continue
+		}
+		k8sNS, k8sName := r.Service.Meta[ConsulK8SNS], r.Service.Meta[ConsulK8SService]
+		if k8sName == "" {
+			continue
+		}
+		if _, ok := recorded[k8sNS+"/"+k8sName]; ok {
+			continue
+		}
+		recorded[k8sNS+"/"+k8sName] = struct{}{}
+
+		service := &corev1.ObjectReference{
+			APIVersion: "v1",
+			Kind:       "Service",
+			Name:       k8sName,
+			Namespace:  k8sNS,
+		}
+		s.EventRecorder.Event(service, corev1.EventTypeWarning, reasonServiceConflict, message)
+	}
+}
+
+// indexRegistrationsLocked rebuilds the service names and registrations to
+// sync from the registrations given to Sync, applying the conflict policy.
+// It must be called with the lock held.
+func (s *ConsulSyncer) indexRegistrationsLocked() {
+	s.serviceNames = make(map[string]mapset.Set)
+	s.namespaces = make(map[string]map[string]*api.CatalogRegistration)
+
+	for _, r := range s.registrations {
+		// Determine the namespace the service is in to use for indexing
+		// against the s.serviceNames and s.namespaces maps.
+		// This will be "" for OSS.
+		ns := r.Service.Namespace
+
+		if s.conflicts[ns] != nil && s.conflicts[ns].Contains(r.Service.Service) {
+			switch s.conflictPolicy() {
+			case ConflictPolicyConsulWins:
+				s.Log.Debug("[Sync] not syncing service conflicting with a native Consul service", "service name", r.Service.Service)
+				continue
+			case ConflictPolicySuffixK8s:
+				r = withConflictSuffix(r)
+			}
+		}
+
+		// Mark this as a valid service, initializing state if necessary
+		if _, ok := s.serviceNames[ns]; !ok {
+			s.serviceNames[ns] = mapset.NewSet()
+		}
+		s.serviceNames[ns].Add(r.Service.Service)
+		s.Log.Debug("[Sync] adding service to serviceNames set", "service", r.Service, "service name", r.Service.Service)
+
+		// Add service to namespaces map, initializing if necessary
+		if _, ok := s.namespaces[ns]; !ok {
+			s.namespaces[ns] = make(map[string]*api.CatalogRegistration)
+		}
+		s.namespaces[ns][r.Service.ID] = r
+		s.Log.Debug("[Sync] adding service to namespaces map", "service", r.Service)
+	}
+}
+
+// conflictPolicy returns the configured ConflictPolicy, defaulting to
+// ConflictPolicyK8sWins.
+func (s *ConsulSyncer) conflictPolicy() ConflictPolicy {
+	if s.ConflictPolicy == "" {
+		return ConflictPolicyK8sWins
+	}
+	return s.ConflictPolicy
+}
+
+// withConflictSuffix returns a copy of r with the ConflictSuffix appended to
+// the name of the service. The ID of the service is renamed too so that
+// deregistering the instances of the unsuffixed service doesn't remove it.
+func withConflictSuffix(r *api.CatalogRegistration) *api.CatalogRegistration {
+	name := r.Service.Service
+
+	service := *r.Service
+	service.Service = name + ConflictSuffix
+	if strings.HasPrefix(service.ID, name) {
+		service.ID = service.Service + strings.TrimPrefix(service.ID, name)
+	} else {
+		service.ID = service.ID + ConflictSuffix
+	}
+
+	reg := *r
+	reg.Service = &service
+	if r.Check != nil {
+		check := *r.Check
+		check.ServiceID = service.ID
+		check.CheckID = strings.Replace(check.CheckID, r.Service.ID, service.ID, 1)
+		reg.Check = &check
+	}
+	return &reg
+}
@@ -0,0 +1,133 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package catalog
+
+import (
+	"testing"
+
+	"github.com/armon/go-metrics/prometheus"
+	mapset "github.com/deckarep/golang-set"
+	"github.com/hashicorp/consul/api"
+	"github.com/hashicorp/go-hclog"
+	"github.com/stretchr/testify/require"
+	"k8s.io/client-go/tools/record"
+)
+
+func TestConsulSyncer_indexRegistrationsConflictPolicy(t *testing.T) {
+	t.Parallel()
+
+	cases := map[string]struct {
+		policy      ConflictPolicy
+		expServices []string
+		expIDs      []string
+	}{
+		"default": {
+			expServices: []string{"bar", "baz"},
+			expIDs:      []string{serviceID(ConsulSyncNodeName, "bar"), serviceID(ConsulSyncNodeName, "baz")},
+		},
+		"k8s-wins": {
+			policy:      ConflictPolicyK8sWins,
+			expServices: []string{"bar", "baz"},
+			expIDs:      []string{serviceID(ConsulSyncNodeName, "bar"), serviceID(ConsulSyncNodeName, "baz")},
+		},
+		"consul-wins": {
+			policy:      ConflictPolicyConsulWins,
+			expServices: []string{"baz"},
+			expIDs:      []string{serviceID(ConsulSyncNodeName, "baz")},
+		},
+		"suffix-k8s": {
+			policy:      ConflictPolicySuffixK8s,
+			expServices: []string{"bar-k8s", "baz"},
+			expIDs:      []string{serviceID(ConsulSyncNodeName, "bar") + ConflictSuffix, serviceID(ConsulSyncNodeName, "baz")},
+		},
+	}
+	for name, c := range cases {
+		t.Run(name, func(t *testing.T) {
+			s := &ConsulSyncer{
+				Log:            hclog.NewNullLogger(),
+				ConflictPolicy: c.policy,
+			}
+			s.init()
+			s.conflicts[""] = mapset.NewSetWith("bar")
+
+			bar := testRegistration(ConsulSyncNodeName, "bar", "default")
+			s.Sync([]*api.CatalogRegistration{
+				bar,
+				testRegistration(ConsulSyncNodeName, "baz", "default"),
+			})
+
+			var services, ids []string
+			for name := range s.serviceNames[""].Iter() {
+				services = append(services, name.(string))
+			}
+			for id := range s.namespaces[""] {
+				ids = append(ids, id)
+			}
+			require.ElementsMatch(t, c.expServices, services)
+			require.ElementsMatch(t, c.expIDs, ids)
+
+			// The registrations given to Sync must not be modified.
+			require.Equal(t, "bar", bar.Service.Service)
+			require.Equal(t, serviceID(ConsulSyncNodeName, "bar"), bar.Service.ID)
+		})
+	}
+}
+
+func TestWithConflictSuffix(t *testing.T) {
+	t.Parallel()
+
+	r := testRegistration(ConsulSyncNodeName, "bar", "default")
+	r.Service.ID = serviceID("bar", "1.2.3.4")
+	r.Check = &api.AgentCheck{
+		CheckID:   consulHealthCheckID("default", r.Service.ID),
+		ServiceID: r.Service.ID,
+	}
+
+	actual := withConflictSuffix(r)
+	require.Equal(t, "bar-k8s", actual.Service.Service)
+	require.Equal(t, "bar-k8s"+serviceID("bar", "1.2.3.4")[len("bar"):], actual.Service.ID)
+	require.Equal(t, actual.Service.ID, actual.Check.ServiceID)
+	require.Equal(t, consulHealthCheckID("default", actual.Service.ID), actual.Check.CheckID)
+
+	require.Equal(t, "bar", r.Service.Service)
+	require.Equal(t, serviceID("bar", "1.2.3.4"), r.Check.ServiceID)
+}
+
+func TestConsulSyncer_recordConflict(t *testing.T) {
+	t.Parallel()
+
+	recorder := record.NewFakeRecorder(10)
+	s := &ConsulSyncer{
+		Log:            hclog.NewNullLogger(),
+		ConflictPolicy: ConflictPolicySuffixK8s,
+		EventRecorder:  recorder,
+		PrometheusSink: &prometheus.PrometheusSink{},
+	}
+	s.init()
+
+	var rs []*api.CatalogRegistration
+	for _, addr := range []string{"1.1.1.1", "2.2.2.2"} {
+		r := testRegistration(ConsulSyncNodeName, "bar", "default")
+		r.Service.ID = serviceID("bar", addr)
+		r.Service.Meta[ConsulK8SService] = "bar"
+		rs = append(rs, r)
+	}
+	s.Sync(rs)
+	s.recordConflictLocked("", "bar")
+
+	// A single Event is recorded for both instances of the service.
+	require.Len(t, recorder.Events, 1)
+	require.Equal(t, `Warning ConsulServiceConflict Consul service "bar" is registered natively in Consul, syncing this service as "bar-k8s"`,
+		<-recorder.Events)
+}
+
+func TestValidConflictPolicy(t *testing.T) {
+	t.Parallel()
+
+	for _, policy := range ConflictPolicies {
+		require.True(t, ValidConflictPolicy(string(policy)))
+	}
+	require.False(t, ValidConflictPolicy(""))
+	require.False(t, ValidConflictPolicy("k8s-loses"))
+}
@@ -0,0 +1,144 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package catalog
+
+import (
+	"context"
+	"net"
+	"slices"
+	"sort"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+)
+
+// hostResolver resolves hostnames to IP addresses. It is implemented by net.Resolver.
+type hostResolver interface {
+	LookupHost(ctx context.Context, host string) ([]string, error)
+}
+
+// loadBalancerIngressAddrs returns the addresses to register for an ingress of a LoadBalancer service.
+// If LBHostnameResolvePeriod is set, the hostname of the ingress is registered as the
+// addresses it resolved to, or as is until it has been resolved.
+//
+// Precondition: assumes t.serviceLock is held.
+func (t *ServiceResource) loadBalancerIngressAddrs(ingress corev1.LoadBalancerIngress) []string {
+	if ingress.IP != "" {
+		return []string{ingress.IP}
+	}
+	if ingress.Hostname == "" {
+		return nil
+	}
+	if t.LBHostnameResolvePeriod > 0 {
+		if addrs, ok := t.resolvedHostnames[ingress.Hostname]; ok {
+			return addrs
+		}
+		t.triggerHostnameResolution()
+	}
+	return []string{ingress.Hostname}
+}
+
+// runHostnameResolver resolves the hostnames of the ingresses of the synced LoadBalancer services
+// every LBHostnameResolvePeriod, or sooner if a hostname hasn't been resolved yet,
+// until ch is closed.
+func (t *ServiceResource) runHostnameResolver(ch <-chan struct{}) {
+	ticker := time.NewTicker(t.LBHostnameResolvePeriod)
+	defer ticker.Stop()
+	for {
+		t.resolveHostnames()
+		select {
+		case <-ch:
+			return
+		case <-ticker.C:
+		case <-t.hostnameResolutionCh():
+		}
+	}
+}
+
+// resolveHostnames resolves the hostnames of the ingresses of the synced LoadBalancer services and
+// updates the registrations of the services whose hostnames resolved to different addresses.
+// If a hostname can't be resolved, the services keep the addresses it last resolved to.
+func (t *ServiceResource) resolveHostnames() {
+	// Collect the hostnames and the services that use them. DNS is queried without holding
+	// the lock so that a slow resolution doesn't block syncing other services.
+	t.serviceLock.RLock()
+	hostnames := make(map[string][]string)
+	for key, svc := range t.serviceMap {
+		if svc.Spec.Type != corev1.ServiceTypeLoadBalancer || t.LoadBalancerEndpointsSync {
+			continue
+		}
+		for _, ingress := range svc.Status.LoadBalancer.Ingress {
+			if ingress.IP == "" && ingress.Hostname != "" {
+				hostnames[ingress.Hostname] = append(hostnames[ingress.Hostname], key)
+			}
+		}
+	}
+	t.serviceLock.RUnlock()
+
+	resolved := make(map[string][]string)
+	for hostname := range hostnames {
+		ctx, cancel := context.WithTimeout(t.Ctx, t.LBHostnameResolvePeriod)
+		addrs, err := t.hostResolver().LookupHost(ctx, hostname)
+		cancel()
+		if err != nil {
+			t.Log.Warn("unable to resolve load balancer hostname", "hostname", hostname, "err", err)
+			continue
+		}
+		sort.Strings(addrs)
+		resolved[hostname] = addrs
+	}
+
+	t.serviceLock.Lock()
+	defer t.serviceLock.Unlock()
+
+	if t.resolvedHostnames == nil {
+		t.resolvedHostnames = make(map[string][]string)
+	}
+	changed := false
+	for hostname, addrs := range resolved {
+		if prev, ok := t.resolvedHostnames[hostname]; ok && slices.Equal(prev, addrs) {
+			continue
+		}
+		t.Log.Info("load balancer hostname resolved to new addresses", "hostname", hostname, "addresses", addrs)
+		t.resolvedHostnames[hostname] = addrs
+		for _, key := range hostnames[hostname] {
+			if _, ok := t.serviceMap[key]; ok {
+				t.generateRegistrations(key)
+				changed = true
+			}
+		}
+	}
+	// Forget the hostnames that are no longer used by any service.
+	for hostname := range t.resolvedHostnames {
+		if _, ok := hostnames[hostname]; !ok {
+			delete(t.resolvedHostnames, hostname)
+		}
+	}
+	if changed {
+		t.sync()
+	}
+}
+
+// triggerHostnameResolution makes the hostname resolver resolve the hostnames without waiting for
+// the next interval.
+func (t *ServiceResource) triggerHostnameResolution() {
+	select {
+	case t.hostnameResolutionCh() <- struct{}{}:
+	default:
+	}
+}
+
+func (t *ServiceResource) hostnameResolutionCh() chan struct{} {
+	t.resolveOnce.Do(func() {
+		t.resolveCh = make(chan struct{}, 1)
+	})
+	return t.resolveCh
+}
+
+func (t *ServiceResource) hostResolver() hostResolver {
+	if t.resolver != nil {
+		return t.resolver
+	}
+	return net.DefaultResolver
+}
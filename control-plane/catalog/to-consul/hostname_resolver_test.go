@@ -0,0 +1,150 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package catalog
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/hashicorp/consul-k8s/control-plane/helper/controller"
+	"github.com/hashicorp/consul/sdk/testutil/retry"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+// Test that the hostname of a LoadBalancer is registered as is if it isn't resolved.
+func TestServiceResource_lbHostname(t *testing.T) {
+	t.Parallel()
+	client := fake.NewSimpleClientset()
+	syncer := newTestSyncer()
+	serviceResource := defaultServiceResource(client, syncer)
+
+	// Start the controller
+	closer := controller.TestControllerRun(&serviceResource)
+	defer closer()
+
+	// Insert an LB service
+	svc := lbHostnameService("foo", metav1.NamespaceDefault, "lb.example.com")
+	_, err := client.CoreV1().Services(metav1.NamespaceDefault).Create(context.Background(), svc, metav1.CreateOptions{})
+	require.NoError(t, err)
+
+	// Verify what we got
+	retry.Run(t, func(r *retry.R) {
+		syncer.Lock()
+		defer syncer.Unlock()
+		actual := syncer.Registrations
+		require.Len(r, actual, 1)
+		require.Equal(r, "foo", actual[0].Service.Service)
+		require.Equal(r, "lb.example.com", actual[0].Service.Address)
+	})
+}
+
+// Test that the hostname of a LoadBalancer is registered as the addresses it resolves to,
+// and that the registrations are updated when it resolves to different addresses.
+func TestServiceResource_lbHostnameResolved(t *testing.T) {
+	t.Parallel()
+	client := fake.NewSimpleClientset()
+	syncer := newTestSyncer()
+	resolver := &fakeResolver{hosts: map[string][]string{"lb.example.com": {"2.2.2.2", "1.1.1.1"}}}
+	serviceResource := defaultServiceResource(client, syncer)
+	serviceResource.LBHostnameResolvePeriod = 50 * time.Millisecond
+	serviceResource.resolver = resolver
+
+	// Start the controller
+	closer := controller.TestControllerRun(&serviceResource)
+	defer closer()
+
+	// Insert an LB service
+	svc := lbHostnameService("foo", metav1.NamespaceDefault, "lb.example.com")
+	_, err := client.CoreV1().Services(metav1.NamespaceDefault).Create(context.Background(), svc, metav1.CreateOptions{})
+	require.NoError(t, err)
+
+	// Verify that the resolved addresses are registered.
+	retry.Run(t, func(r *retry.R) {
+		syncer.Lock()
+		defer syncer.Unlock()
+		actual := syncer.Registrations
+		require.Len(r, actual, 2)
+		require.Equal(r, "foo", actual[0].Service.Service)
+		require.Equal(r, "1.1.1.1", actual[0].Service.Address)
+		require.Equal(r, "foo", actual[1].Service.Service)
+		require.Equal(r, "2.2.2.2", actual[1].Service.Address)
+		require.NotEqual(r, actual[1].Service.ID, actual[0].Service.ID)
+	})
+
+	// Verify that the registrations follow the load balancer when its addresses change.
+	resolver.set("lb.example.com", []string{"3.3.3.3"}, nil)
+	retry.Run(t, func(r *retry.R) {
+		syncer.Lock()
+		defer syncer.Unlock()
+		actual := syncer.Registrations
+		require.Len(r, actual, 1)
+		require.Equal(r, "3.3.3.3", actual[0].Service.Address)
+	})
+
+	// Verify that the last resolved addresses are kept if the hostname can't be resolved.
+	resolver.set("lb.example.com", nil, errors.New("no such host"))
+	retry.Run(t, func(r *retry.R) {
+		require.GreaterOrEqual(r, resolver.lookups("lb.example.com"), 5)
+	})
+	syncer.Lock()
+	defer syncer.Unlock()
+	actual := syncer.Registrations
+	require.Len(t, actual, 1)
+	require.Equal(t, "3.3.3.3", actual[0].Service.Address)
+}
+
+// lbHostnameService returns a Kubernetes service of type LoadBalancer whose ingress has a hostname.
+func lbHostnameService(name, namespace, hostname string) *corev1.Service {
+	svc := lbService(name, namespace, "")
+	svc.Status.LoadBalancer.Ingress[0].Hostname = hostname
+	return svc
+}
+
+// fakeResolver resolves hostnames to the addresses it is configured with.
+type fakeResolver struct {
+	mu      sync.Mutex
+	hosts   map[string][]string
+	errs    map[string]error
+	counter map[string]int
+}
+
+func (f *fakeResolver) LookupHost(_ context.Context, host string) ([]string, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.counter == nil {
+		f.counter = make(map[string]int)
+	}
+	f.counter[host]++
+	if err := f.errs[host]; err != nil {
+		return nil, err
+	}
+	addrs, ok := f.hosts[host]
+	if !ok {
+		return nil, errors.New("no such host")
+	}
+	return append([]string(nil), addrs...), nil
+}
+
+func (f *fakeResolver) set(host string, addrs []string, err error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.errs == nil {
+		f.errs = make(map[string]error)
+	}
+	f.hosts[host] = addrs
+	f.errs[host] = err
+	f.counter = nil
+}
+
+func (f *fakeResolver) lookups(host string) int {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.counter[host]
+}
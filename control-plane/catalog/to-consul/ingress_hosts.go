@@ -0,0 +1,447 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package catalog
+
+import (
+	"strings"
+
+	consulapi "github.com/hashicorp/consul/api"
+	"github.com/hashicorp/go-hclog"
+	networkingv1 "k8s.io/api/networking/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/watch"
+	"k8s.io/client-go/tools/cache"
+	gwv1beta1 "sigs.k8s.io/gateway-api/apis/v1beta1"
+
+	"github.com/hashicorp/consul-k8s/control-plane/helper/controller"
+	"github.com/hashicorp/consul-k8s/control-plane/namespaces"
+)
+
+const (
+	// ConsulK8SHost is the key used in the meta to record the host of the Ingress rule
+	// or HTTPRoute that a service was registered for.
+	ConsulK8SHost = "external-k8s-host"
+
+	// ingressHostKeyPrefix and httpRouteHostKeyPrefix prefix the keys of the Ingresses and
+	// HTTPRoutes in consulMap so that they don't collide with the keys of services.
+	ingressHostKeyPrefix   = "ingress:"
+	httpRouteHostKeyPrefix = "httproute:"
+)
+
+// ingressHostResource implements controller.Resource to register the hosts of the
+// rules of Ingress resources as Consul services.
+type ingressHostResource struct {
+	Service *ServiceResource
+	Log     hclog.Logger
+}
+
+// Informer implements the controller.Resource interface.
+func (t *ingressHostResource) Informer() cache.SharedIndexInformer {
+	return cache.NewSharedIndexInformer(
+		&cache.ListWatch{
+			ListFunc: func(options metav1.ListOptions) (runtime.Object, error) {
+				return t.Service.Client.NetworkingV1().Ingresses(metav1.NamespaceAll).List(t.Service.Ctx, options)
+			},
+
+			WatchFunc: func(options metav1.ListOptions) (watch.Interface, error) {
+				return t.Service.Client.NetworkingV1().Ingresses(metav1.NamespaceAll).Watch(t.Service.Ctx, options)
+			},
+		},
+		&networkingv1.Ingress{},
+		0,
+		cache.Indexers{},
+	)
+}
+
+// Upsert implements the controller.Resource interface.
+func (t *ingressHostResource) Upsert(key string, raw interface{}) error {
+	ingress, ok := raw.(*networkingv1.Ingress)
+	if !ok {
+		t.Log.Warn("upsert got invalid type", "raw", raw)
+		return nil
+	}
+
+	svc := t.Service
+	svc.serviceLock.Lock()
+	defer svc.serviceLock.Unlock()
+
+	if svc.ingressMap == nil {
+		svc.ingressMap = make(map[string]*networkingv1.Ingress)
+	}
+	if svc.shouldSyncHosts(ingress.ObjectMeta) {
+		svc.ingressMap[key] = ingress
+	} else {
+		delete(svc.ingressMap, key)
+	}
+	svc.generateIngressHostRegistrations(key)
+	svc.sync()
+	t.Log.Info("upsert ingress hosts", "key", key)
+	return nil
+}
+
+// Delete implements the controller.Resource interface.
+func (t *ingressHostResource) Delete(key string, _ interface{}) error {
+	svc := t.Service
+	svc.serviceLock.Lock()
+	defer svc.serviceLock.Unlock()
+
+	delete(svc.ingressMap, key)
+	if _, ok := svc.consulMap[ingressHostKeyPrefix+key]; ok {
+		delete(svc.consulMap, ingressHostKeyPrefix+key)
+		svc.sync()
+	}
+	t.Log.Info("delete ingress hosts", "key", key)
+	return nil
+}
+
+// httpRouteHostResource implements controller.Resource to register the hostnames of
+// Gateway API HTTPRoutes as Consul services.
+type httpRouteHostResource struct {
+	Service *ServiceResource
+	Log     hclog.Logger
+}
+
+// Informer implements the controller.Resource interface.
+func (t *httpRouteHostResource) Informer() cache.SharedIndexInformer {
+	return cache.NewSharedIndexInformer(
+		&cache.ListWatch{
+			ListFunc: func(options metav1.ListOptions) (runtime.Object, error) {
+				return t.Service.GatewayClient.GatewayV1beta1().HTTPRoutes(metav1.NamespaceAll).List(t.Service.Ctx, options)
+			},
+
+			WatchFunc: func(options metav1.ListOptions) (watch.Interface, error) {
+				return t.Service.GatewayClient.GatewayV1beta1().HTTPRoutes(metav1.NamespaceAll).Watch(t.Service.Ctx, options)
+			},
+		},
+		&gwv1beta1.HTTPRoute{},
+		0,
+		cache.Indexers{},
+	)
+}
+
+// Upsert implements the controller.Resource interface.
+func (t *httpRouteHostResource) Upsert(key string, raw interface{}) error {
+	route, ok := raw.(*gwv1beta1.HTTPRoute)
+	if !ok {
+		t.Log.Warn("upsert got invalid type", "raw", raw)
+		return nil
+	}
+
+	svc := t.Service
+	svc.serviceLock.Lock()
+	defer svc.serviceLock.Unlock()
+
+	if svc.httpRouteMap == nil {
+		svc.httpRouteMap = make(map[string]*gwv1beta1.HTTPRoute)
+	}
+	if svc.shouldSyncHosts(route.ObjectMeta) {
+		svc.httpRouteMap[key] = route
+	} else {
+		delete(svc.httpRouteMap, key)
+	}
+	svc.generateHTTPRouteHostRegistrations(key)
+	svc.sync()
+	t.Log.Info("upsert httproute hosts", "key", key)
+	return nil
+}
+
+// Delete implements the controller.Resource interface.
+func (t *httpRouteHostResource) Delete(key string, _ interface{}) error {
+	svc := t.Service
+	svc.serviceLock.Lock()
+	defer svc.serviceLock.Unlock()
+
+	delete(svc.httpRouteMap, key)
+	if _, ok := svc.consulMap[httpRouteHostKeyPrefix+key]; ok {
+		delete(svc.consulMap, httpRouteHostKeyPrefix+key)
+		svc.sync()
+	}
+	t.Log.Info("delete httproute hosts", "key", key)
+	return nil
+}
+
+// gatewayHostResource implements controller.Resource to keep track of the addresses of
+// the Gateways that HTTPRoutes are attached to.
+type gatewayHostResource struct {
+	Service *ServiceResource
+	Log     hclog.Logger
+}
+
+// Informer implements the controller.Resource interface.
+func (t *gatewayHostResource) Informer() cache.SharedIndexInformer {
+	return cache.NewSharedIndexInformer(
+		&cache.ListWatch{
+			ListFunc: func(options metav1.ListOptions) (runtime.Object, error) {
+				return t.Service.GatewayClient.GatewayV1beta1().Gateways(metav1.NamespaceAll).List(t.Service.Ctx, options)
+			},
+
+			WatchFunc: func(options metav1.ListOptions) (watch.Interface, error) {
+				return t.Service.GatewayClient.GatewayV1beta1().Gateways(metav1.NamespaceAll).Watch(t.Service.Ctx, options)
+			},
+		},
+		&gwv1beta1.Gateway{},
+		0,
+		cache.Indexers{},
+	)
+}
+
+// Upsert implements the controller.Resource interface.
+func (t *gatewayHostResource) Upsert(key string, raw interface{}) error {
+	gateway, ok := raw.(*gwv1beta1.Gateway)
+	if !ok {
+		t.Log.Warn("upsert got invalid type", "raw", raw)
+		return nil
+	}
+
+	svc := t.Service
+	svc.serviceLock.Lock()
+	defer svc.serviceLock.Unlock()
+
+	if svc.gatewayMap == nil {
+		svc.gatewayMap = make(map[string]*gwv1beta1.Gateway)
+	}
+	svc.gatewayMap[key] = gateway
+	svc.generateGatewayRouteRegistrations(key)
+	t.Log.Info("upsert gateway", "key", key)
+	return nil
+}
+
+// Delete implements the controller.Resource interface.
+func (t *gatewayHostResource) Delete(key string, _ interface{}) error {
+	svc := t.Service
+	svc.serviceLock.Lock()
+	defer svc.serviceLock.Unlock()
+
+	delete(svc.gatewayMap, key)
+	svc.generateGatewayRouteRegistrations(key)
+	t.Log.Info("delete gateway", "key", key)
+	return nil
+}
+
+// runHostControllers starts the controllers that register the hosts of Ingresses and HTTPRoutes,
+// if they are enabled. They stop when ch is closed.
+func (t *ServiceResource) runHostControllers(ch <-chan struct{}) {
+	if t.SyncIngressHosts {
+		t.Log.Info("starting runner for ingress hosts")
+		go (&controller.Controller{
+			Log:      t.Log.Named("controller/ingress-hosts"),
+			Resource: &ingressHostResource{Service: t, Log: t.Log.Named("ingress-hosts")},
+		}).Run(ch)
+	}
+	if t.SyncHTTPRouteHosts && t.GatewayClient != nil {
+		t.Log.Info("starting runners for httproute hosts")
+		go (&controller.Controller{
+			Log:      t.Log.Named("controller/gateways"),
+			Resource: &gatewayHostResource{Service: t, Log: t.Log.Named("httproute-hosts")},
+		}).Run(ch)
+		go (&controller.Controller{
+			Log:      t.Log.Named("controller/httproute-hosts"),
+			Resource: &httpRouteHostResource{Service: t, Log: t.Log.Named("httproute-hosts")},
+		}).Run(ch)
+	}
+}
+
+// generateIngressHostRegistrations generates a registration of a Consul service for each host of
+// the rules of the Ingress with the given key. Its instances are the load balancer addresses of the
+// Ingress, on port 443 if the host is in the TLS configuration of the Ingress and port 80 otherwise.
+//
+// Precondition: the lock t.serviceLock is held.
+func (t *ServiceResource) generateIngressHostRegistrations(key string) {
+	consulKey := ingressHostKeyPrefix + key
+	if t.consulMap == nil {
+		t.consulMap = make(map[string][]*consulapi.CatalogRegistration)
+	}
+	delete(t.consulMap, consulKey)
+
+	ingress, ok := t.ingressMap[key]
+	if !ok {
+		return
+	}
+
+	var addrs []string
+	for _, lb := range ingress.Status.LoadBalancer.Ingress {
+		if lb.IP != "" {
+			addrs = append(addrs, lb.IP)
+		} else if lb.Hostname != "" {
+			addrs = append(addrs, lb.Hostname)
+		}
+	}
+	tlsHosts := make(map[string]struct{})
+	for _, tls := range ingress.Spec.TLS {
+		for _, host := range tls.Hosts {
+			tlsHosts[host] = struct{}{}
+		}
+	}
+
+	seen := make(map[string]struct{})
+	for _, rule := range ingress.Spec.Rules {
+		if _, ok := seen[rule.Host]; ok {
+			continue
+		}
+		seen[rule.Host] = struct{}{}
+
+		port := 80
+		if _, ok := tlsHosts[rule.Host]; ok {
+			port = 443
+		}
+		t.consulMap[consulKey] = append(t.consulMap[consulKey],
+			t.hostRegistrations(ingress.ObjectMeta, "Ingress", rule.Host, port, addrs)...)
+	}
+}
+
+// generateHTTPRouteHostRegistrations generates a registration of a Consul service for each hostname
+// of the HTTPRoute with the given key. Its instances are the addresses of the Gateways the route is
+// attached to, on the port of the HTTP or HTTPS listener of the Gateway that the route is attached to.
+// A route without hostnames is registered with the hostname of the listener, if it has one.
+//
+// Precondition: the lock t.serviceLock is held.
+func (t *ServiceResource) generateHTTPRouteHostRegistrations(key string) {
+	consulKey := httpRouteHostKeyPrefix + key
+	if t.consulMap == nil {
+		t.consulMap = make(map[string][]*consulapi.CatalogRegistration)
+	}
+	delete(t.consulMap, consulKey)
+
+	route, ok := t.httpRouteMap[key]
+	if !ok {
+		return
+	}
+
+	for _, ref := range route.Spec.ParentRefs {
+		gateway, ok := t.gatewayMap[parentGatewayKey(route.Namespace, ref)]
+		if !ok {
+			continue
+		}
+		listener, ok := attachedHTTPListener(gateway, ref)
+		if !ok {
+			continue
+		}
+
+		var addrs []string
+		for _, addr := range gateway.Status.Addresses {
+			addrs = append(addrs, addr.Value)
+		}
+		hostnames := route.Spec.Hostnames
+		if len(hostnames) == 0 && listener.Hostname != nil {
+			hostnames = []gwv1beta1.Hostname{*listener.Hostname}
+		}
+		for _, host := range hostnames {
+			t.consulMap[consulKey] = append(t.consulMap[consulKey],
+				t.hostRegistrations(route.ObjectMeta, "HTTPRoute", string(host), int(listener.Port), addrs)...)
+		}
+	}
+}
+
+// generateGatewayRouteRegistrations regenerates the registrations of the HTTPRoutes attached to the
+// Gateway with the given key and triggers a sync if there are any.
+//
+// Precondition: the lock t.serviceLock is held.
+func (t *ServiceResource) generateGatewayRouteRegistrations(key string) {
+	regenerated := false
+	for routeKey, route := range t.httpRouteMap {
+		for _, ref := range route.Spec.ParentRefs {
+			if parentGatewayKey(route.Namespace, ref) == key {
+				t.generateHTTPRouteHostRegistrations(routeKey)
+				regenerated = true
+				break
+			}
+		}
+	}
+	if regenerated {
+		t.sync()
+	}
+}
+
+// hostRegistrations returns the registrations of the Consul service for the host of an Ingress or
+// HTTPRoute, one for each address. The service is named after the host with its dots replaced by dashes
+// so that it can be looked up through Consul DNS. Wildcard hosts aren't registered.
+func (t *ServiceResource) hostRegistrations(meta metav1.ObjectMeta, kind, host string, port int, addrs []string) []*consulapi.CatalogRegistration {
+	if host == "" || strings.HasPrefix(host, "*") {
+		return nil
+	}
+
+	baseService := consulapi.AgentService{
+		Service: t.ConsulServicePrefix + strings.ReplaceAll(host, ".", "-"),
+		Tags:    []string{t.ConsulK8STag},
+		Port:    port,
+		Meta: map[string]string{
+			ConsulSourceKey:   ConsulSourceValue,
+			ConsulK8SNS:       meta.Namespace,
+			ConsulK8SRefKind:  kind,
+			ConsulK8SRefValue: meta.Name,
+			ConsulK8SHost:     host,
+		},
+	}
+	baseService.Namespace = namespaces.ConsulNamespace(meta.Namespace,
+		t.EnableNamespaces,
+		t.ConsulDestinationNamespace,
+		t.EnableK8SNSMirroring,
+		t.K8SNSMirroringPrefix)
+
+	var registrations []*consulapi.CatalogRegistration
+	seen := make(map[string]struct{})
+	for _, addr := range addrs {
+		if _, ok := seen[addr]; ok {
+			continue
+		}
+		seen[addr] = struct{}{}
+
+		rs := baseService
+		rs.ID = serviceID(rs.Service, addr)
+		rs.Address = addr
+		registrations = append(registrations, &consulapi.CatalogRegistration{
+			SkipNodeUpdate: true,
+			Node:           t.ConsulNodeName,
+			Address:        "127.0.0.1",
+			NodeMeta: map[string]string{
+				ConsulSourceKey: ConsulSourceValue,
+			},
+			Service: &rs,
+		})
+	}
+	return registrations
+}
+
+// shouldSyncHosts returns true if the hosts of the Ingress or HTTPRoute with the given metadata
+// should be registered. It follows the namespace and annotation rules of services.
+func (t *ServiceResource) shouldSyncHosts(meta metav1.ObjectMeta) bool {
+	if !t.namespaceAllowed(meta.Namespace) {
+		return false
+	}
+	return t.syncEnabled(meta.Annotations, meta.Namespace+"/"+meta.Name)
+}
+
+// parentGatewayKey returns the key of the Gateway that a parent reference of a route in the given
+// namespace refers to, or an empty string if it doesn't refer to a Gateway.
+func parentGatewayKey(routeNamespace string, ref gwv1beta1.ParentReference) string {
+	if ref.Kind != nil && *ref.Kind != "Gateway" {
+		return ""
+	}
+	if ref.Group != nil && *ref.Group != gwv1beta1.GroupName {
+		return ""
+	}
+	namespace := routeNamespace
+	if ref.Namespace != nil {
+		namespace = string(*ref.Namespace)
+	}
+	return namespace + "/" + string(ref.Name)
+}
+
+// attachedHTTPListener returns the first HTTP or HTTPS listener of the Gateway that the parent
+// reference of a route attaches to.
+func attachedHTTPListener(gateway *gwv1beta1.Gateway, ref gwv1beta1.ParentReference) (gwv1beta1.Listener, bool) {
+	for _, listener := range gateway.Spec.Listeners {
+		if ref.SectionName != nil && listener.Name != *ref.SectionName {
+			continue
+		}
+		if ref.Port != nil && listener.Port != *ref.Port {
+			continue
+		}
+		if listener.Protocol == gwv1beta1.HTTPProtocolType || listener.Protocol == gwv1beta1.HTTPSProtocolType {
+			return listener, true
+		}
+	}
+	return gwv1beta1.Listener{}, false
+}
@@ -0,0 +1,250 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package catalog
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"testing"
+
+	mapset "github.com/deckarep/golang-set"
+	"github.com/hashicorp/consul/sdk/testutil/retry"
+	"github.com/stretchr/testify/require"
+	networkingv1 "k8s.io/api/networking/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+	"k8s.io/utils/ptr"
+	gwv1beta1 "sigs.k8s.io/gateway-api/apis/v1beta1"
+	gatewayfake "sigs.k8s.io/gateway-api/pkg/client/clientset/versioned/fake"
+
+	"github.com/hashicorp/consul-k8s/control-plane/helper/controller"
+)
+
+// Test that the hosts of Ingress rules are registered as services with the load balancer addresses of the Ingress.
+func TestServiceResource_ingressHosts(t *testing.T) {
+	t.Parallel()
+	client := fake.NewSimpleClientset()
+	syncer := newTestSyncer()
+	serviceResource := defaultServiceResource(client, syncer)
+	serviceResource.ConsulK8STag = TestConsulK8STag
+	serviceResource.SyncIngressHosts = true
+
+	closer := controller.TestControllerRun(&serviceResource)
+	defer closer()
+
+	ingress := &networkingv1.Ingress{
+		ObjectMeta: metav1.ObjectMeta{Name: "web", Namespace: metav1.NamespaceDefault},
+		Spec: networkingv1.IngressSpec{
+			TLS: []networkingv1.IngressTLS{{Hosts: []string{"secure.example.com"}}},
+			Rules: []networkingv1.IngressRule{
+				{Host: "web.example.com"},
+				{Host: "secure.example.com"},
+				{Host: "*.example.com"},
+				{Host: "web.example.com"},
+			},
+		},
+		Status: networkingv1.IngressStatus{
+			LoadBalancer: networkingv1.IngressLoadBalancerStatus{
+				Ingress: []networkingv1.IngressLoadBalancerIngress{{IP: "1.2.3.4"}, {Hostname: "lb.example.com"}},
+			},
+		},
+	}
+	_, err := client.NetworkingV1().Ingresses(metav1.NamespaceDefault).Create(context.Background(), ingress, metav1.CreateOptions{})
+	require.NoError(t, err)
+
+	retry.Run(t, func(r *retry.R) {
+		syncer.Lock()
+		defer syncer.Unlock()
+		require.Equal(r, []string{
+			"secure-example-com 1.2.3.4:443",
+			"secure-example-com lb.example.com:443",
+			"web-example-com 1.2.3.4:80",
+			"web-example-com lb.example.com:80",
+		}, hostRegistrations(syncer))
+		for _, reg := range syncer.Registrations {
+			require.Equal(r, ConsulSyncNodeName, reg.Node)
+			require.Equal(r, []string{TestConsulK8STag}, reg.Service.Tags)
+			require.Equal(r, "Ingress", reg.Service.Meta[ConsulK8SRefKind])
+			require.Equal(r, "web", reg.Service.Meta[ConsulK8SRefValue])
+			require.Equal(r, metav1.NamespaceDefault, reg.Service.Meta[ConsulK8SNS])
+		}
+	})
+
+	// Disabling the sync of the Ingress deregisters its hosts.
+	ingress.Annotations = map[string]string{annotationServiceSync: "false"}
+	_, err = client.NetworkingV1().Ingresses(metav1.NamespaceDefault).Update(context.Background(), ingress, metav1.UpdateOptions{})
+	require.NoError(t, err)
+	retry.Run(t, func(r *retry.R) {
+		syncer.Lock()
+		defer syncer.Unlock()
+		require.Empty(r, syncer.Registrations)
+	})
+
+	// Re-enabling it and then deleting the Ingress deregisters its hosts.
+	ingress.Annotations = nil
+	_, err = client.NetworkingV1().Ingresses(metav1.NamespaceDefault).Update(context.Background(), ingress, metav1.UpdateOptions{})
+	require.NoError(t, err)
+	retry.Run(t, func(r *retry.R) {
+		syncer.Lock()
+		defer syncer.Unlock()
+		require.Len(r, syncer.Registrations, 4)
+	})
+	require.NoError(t, client.NetworkingV1().Ingresses(metav1.NamespaceDefault).Delete(context.Background(), "web", metav1.DeleteOptions{}))
+	retry.Run(t, func(r *retry.R) {
+		syncer.Lock()
+		defer syncer.Unlock()
+		require.Empty(r, syncer.Registrations)
+	})
+}
+
+// Test that the hosts of Ingresses in denied namespaces aren't registered.
+func TestServiceResource_ingressHostsDeniedNamespace(t *testing.T) {
+	t.Parallel()
+	client := fake.NewSimpleClientset()
+	syncer := newTestSyncer()
+	serviceResource := defaultServiceResource(client, syncer)
+	serviceResource.DenyK8sNamespacesSet = mapset.NewSet("denied")
+	serviceResource.SyncIngressHosts = true
+
+	closer := controller.TestControllerRun(&serviceResource)
+	defer closer()
+
+	for _, namespace := range []string{"denied", metav1.NamespaceDefault} {
+		ingress := &networkingv1.Ingress{
+			ObjectMeta: metav1.ObjectMeta{Name: "web", Namespace: namespace},
+			Spec: networkingv1.IngressSpec{
+				Rules: []networkingv1.IngressRule{{Host: namespace + ".example.com"}},
+			},
+			Status: networkingv1.IngressStatus{
+				LoadBalancer: networkingv1.IngressLoadBalancerStatus{
+					Ingress: []networkingv1.IngressLoadBalancerIngress{{IP: "1.2.3.4"}},
+				},
+			},
+		}
+		_, err := client.NetworkingV1().Ingresses(namespace).Create(context.Background(), ingress, metav1.CreateOptions{})
+		require.NoError(t, err)
+	}
+
+	retry.Run(t, func(r *retry.R) {
+		syncer.Lock()
+		defer syncer.Unlock()
+		require.Equal(r, []string{"default-example-com 1.2.3.4:80"}, hostRegistrations(syncer))
+	})
+}
+
+// Test that the hostnames of HTTPRoutes are registered as services with the addresses of their Gateways.
+func TestServiceResource_httpRouteHosts(t *testing.T) {
+	t.Parallel()
+	client := fake.NewSimpleClientset()
+	gatewayClient := gatewayfake.NewSimpleClientset()
+	syncer := newTestSyncer()
+	serviceResource := defaultServiceResource(client, syncer)
+	serviceResource.SyncHTTPRouteHosts = true
+	serviceResource.GatewayClient = gatewayClient
+
+	closer := controller.TestControllerRun(&serviceResource)
+	defer closer()
+
+	gateway := &gwv1beta1.Gateway{
+		ObjectMeta: metav1.ObjectMeta{Name: "gateway", Namespace: "infra"},
+		Spec: gwv1beta1.GatewaySpec{
+			GatewayClassName: "consul",
+			Listeners: []gwv1beta1.Listener{
+				{Name: "tcp", Protocol: gwv1beta1.TCPProtocolType, Port: 9000},
+				{Name: "http", Protocol: gwv1beta1.HTTPProtocolType, Port: 8080},
+				{Name: "https", Protocol: gwv1beta1.HTTPSProtocolType, Port: 8443, Hostname: ptr.To(gwv1beta1.Hostname("api.example.com"))},
+			},
+		},
+		Status: gwv1beta1.GatewayStatus{
+			Addresses: []gwv1beta1.GatewayAddress{{Value: "10.0.0.1"}},
+		},
+	}
+	_, err := gatewayClient.GatewayV1beta1().Gateways("infra").Create(context.Background(), gateway, metav1.CreateOptions{})
+	require.NoError(t, err)
+
+	routes := []*gwv1beta1.HTTPRoute{
+		{
+			ObjectMeta: metav1.ObjectMeta{Name: "web", Namespace: metav1.NamespaceDefault},
+			Spec: gwv1beta1.HTTPRouteSpec{
+				CommonRouteSpec: gwv1beta1.CommonRouteSpec{
+					ParentRefs: []gwv1beta1.ParentReference{{Name: "gateway", Namespace: ptr.To(gwv1beta1.Namespace("infra"))}},
+				},
+				Hostnames: []gwv1beta1.Hostname{"web.example.com", "www.example.com"},
+			},
+		},
+		{
+			ObjectMeta: metav1.ObjectMeta{Name: "api", Namespace: metav1.NamespaceDefault},
+			Spec: gwv1beta1.HTTPRouteSpec{
+				CommonRouteSpec: gwv1beta1.CommonRouteSpec{
+					ParentRefs: []gwv1beta1.ParentReference{{
+						Name:        "gateway",
+						Namespace:   ptr.To(gwv1beta1.Namespace("infra")),
+						SectionName: ptr.To(gwv1beta1.SectionName("https")),
+					}},
+				},
+			},
+		},
+		{
+			ObjectMeta: metav1.ObjectMeta{Name: "missing-gateway", Namespace: metav1.NamespaceDefault},
+			Spec: gwv1beta1.HTTPRouteSpec{
+				CommonRouteSpec: gwv1beta1.CommonRouteSpec{
+					ParentRefs: []gwv1beta1.ParentReference{{Name: "gateway"}},
+				},
+				Hostnames: []gwv1beta1.Hostname{"missing.example.com"},
+			},
+		},
+	}
+	for _, route := range routes {
+		_, err := gatewayClient.GatewayV1beta1().HTTPRoutes(route.Namespace).Create(context.Background(), route, metav1.CreateOptions{})
+		require.NoError(t, err)
+	}
+
+	retry.Run(t, func(r *retry.R) {
+		syncer.Lock()
+		defer syncer.Unlock()
+		require.Equal(r, []string{
+			"api-example-com 10.0.0.1:8443",
+			"web-example-com 10.0.0.1:8080",
+			"www-example-com 10.0.0.1:8080",
+		}, hostRegistrations(syncer))
+		for _, reg := range syncer.Registrations {
+			require.Equal(r, "HTTPRoute", reg.Service.Meta[ConsulK8SRefKind])
+		}
+	})
+
+	// The registrations follow the addresses of the Gateway.
+	gateway.Status.Addresses = []gwv1beta1.GatewayAddress{{Value: "10.0.0.2"}}
+	_, err = gatewayClient.GatewayV1beta1().Gateways("infra").Update(context.Background(), gateway, metav1.UpdateOptions{})
+	require.NoError(t, err)
+	retry.Run(t, func(r *retry.R) {
+		syncer.Lock()
+		defer syncer.Unlock()
+		require.Equal(r, []string{
+			"api-example-com 10.0.0.2:8443",
+			"web-example-com 10.0.0.2:8080",
+			"www-example-com 10.0.0.2:8080",
+		}, hostRegistrations(syncer))
+	})
+
+	// Deleting the Gateway deregisters the hosts of its routes.
+	require.NoError(t, gatewayClient.GatewayV1beta1().Gateways("infra").Delete(context.Background(), "gateway", metav1.DeleteOptions{}))
+	retry.Run(t, func(r *retry.R) {
+		syncer.Lock()
+		defer syncer.Unlock()
+		require.Empty(r, syncer.Registrations)
+	})
+}
+
+// hostRegistrations returns the registrations of the syncer as sorted "<service> <address>:<port>" strings.
+//
+// Precondition: the syncer lock is held.
+func hostRegistrations(syncer *testSyncer) []string {
+	var registrations []string
+	for _, r := range syncer.Registrations {
+		registrations = append(registrations, fmt.Sprintf("%s %s:%d", r.Service.Service, r.Service.Address, r.Service.Port))
+	}
+	sort.Strings(registrations)
+	return registrations
+}
@@ -9,6 +9,7 @@ import (
 	"strconv"
 	"strings"
 	"sync"
+	"time"
 
 	mapset "github.com/deckarep/golang-set"
 	"github.com/hashicorp/consul-k8s/control-plane/catalog/metrics"
@@ -25,6 +26,8 @@ import (
 	"k8s.io/apimachinery/pkg/watch"
 	"k8s.io/client-go/kubernetes"
 	"k8s.io/client-go/tools/cache"
+	gwv1beta1 "sigs.k8s.io/gateway-api/apis/v1beta1"
+	gatewayclient "sigs.k8s.io/gateway-api/pkg/client/clientset/versioned"
 )
 
 const (
@@ -36,11 +39,16 @@ const (
 	// ConsulK8SNS is the key used in the meta to record the namespace
 	// of the service/node registration.
 	ConsulK8SNS           = "external-k8s-ns"
+	ConsulK8SService      = "external-k8s-service-name"
 	ConsulK8SRefKind      = "external-k8s-ref-kind"
 	ConsulK8SRefValue     = "external-k8s-ref-name"
 	ConsulK8SNodeName     = "external-k8s-node-name"
 	ConsulK8STopologyZone = "external-k8s-topology-zone"
 
+	// ConsulK8SProtocol is the key used in the meta to record the protocol
+	// of the service from its consul.hashicorp.com/service-protocol annotation.
+	ConsulK8SProtocol = "external-k8s-protocol"
+
 	// consulKubernetesCheckType is the type of health check in Consul for Kubernetes readiness status.
 	consulKubernetesCheckType = "kubernetes-readiness"
 	// consulKubernetesCheckName is the name of health check in Consul for Kubernetes readiness status.
@@ -104,6 +112,12 @@ type ServiceResource struct {
 	// LoadBalancerEndpointsSync set to true (default false) will sync ServiceTypeLoadBalancer endpoints.
 	LoadBalancerEndpointsSync bool
 
+	// LBHostnameResolvePeriod, if set, registers the hostnames of LoadBalancer ingresses
+	// as the IP addresses they resolve to, and re-resolves them on this interval so that the
+	// registrations follow the load balancer when its addresses change. If it is not set, the
+	// hostnames are registered as is.
+	LBHostnameResolvePeriod time.Duration
+
 	// MetricsConfig contains metrics configuration and has methods to determine whether
 	// configuration should come from the default flags or annotations. The syncCatalog uses this to configure prometheus
 	// annotations.
@@ -166,6 +180,26 @@ type ServiceResource struct {
 	// if we do not want to sync the hostname from the Ingress resource.
 	SyncLoadBalancerIPs bool
 
+	// SyncIngressHosts registers each host of the rules of Ingress resources as a Consul
+	// service whose instances are the load balancer addresses of the Ingress, so that
+	// workloads outside Kubernetes can discover the ingress endpoints through Consul DNS.
+	SyncIngressHosts bool
+
+	// SyncHTTPRouteHosts registers each hostname of Gateway API HTTPRoutes as a Consul
+	// service whose instances are the addresses of the Gateways the route is attached to.
+	// It requires GatewayClient to be set.
+	SyncHTTPRouteHosts bool
+
+	// GatewayClient is the Gateway API client used to watch HTTPRoutes and Gateways.
+	GatewayClient gatewayclient.Interface
+
+	// ingressMap, httpRouteMap and gatewayMap hold the Ingresses and HTTPRoutes whose hosts
+	// are registered and the Gateways the HTTPRoutes may be attached to, keyed by
+	// <kube namespace>/<kube name>.
+	ingressMap   map[string]*networkingv1.Ingress
+	httpRouteMap map[string]*gwv1beta1.HTTPRoute
+	gatewayMap   map[string]*gwv1beta1.Gateway
+
 	// ingressServiceMap uses the same keys as serviceMap but maps to the ingress
 	// of each service if it exists.
 	ingressServiceMap map[string]map[string]string
@@ -178,6 +212,17 @@ type ServiceResource struct {
 	// It's populated via Consul's API and lets us diff what is actually in
 	// Consul vs. what we expect to be there.
 	consulMap map[string][]*consulapi.CatalogRegistration
+
+	// resolvedHostnames maps the hostnames of LoadBalancer ingresses to the
+	// sorted IP addresses they last resolved to.
+	resolvedHostnames map[string][]string
+
+	// resolver resolves the hostnames of LoadBalancer ingresses. It defaults to net.DefaultResolver.
+	resolver hostResolver
+
+	// resolveCh triggers the hostname resolver when a hostname hasn't been resolved yet.
+	resolveCh   chan struct{}
+	resolveOnce sync.Once
 }
 
 type serviceAddress struct {
@@ -313,6 +358,13 @@ func (t *ServiceResource) doDelete(key string) {
 
 // Run implements the controller.Backgrounder interface.
 func (t *ServiceResource) Run(ch <-chan struct{}) {
+	if t.LBHostnameResolvePeriod > 0 {
+		t.Log.Info("starting resolver for load balancer hostnames", "interval", t.LBHostnameResolvePeriod)
+		go t.runHostnameResolver(ch)
+	}
+
+	t.runHostControllers(ch)
+
 	t.Log.Info("starting runner for endpoints")
 	// Register a controller for Endpoints which subsequently registers a
 	// controller for the Ingress resource.
@@ -335,15 +387,8 @@ func (t *ServiceResource) Run(ch <-chan struct{}) {
 // shouldSync returns true if resyncing should be enabled for the given service.
 func (t *ServiceResource) shouldSync(svc *corev1.Service) bool {
 	// Namespace logic
-	// If in deny list, don't sync
-	if t.DenyK8sNamespacesSet.Contains(svc.Namespace) {
-		t.Log.Debug("[shouldSync] service is in the deny list", "svc.Namespace", svc.Namespace, "service", svc)
-		return false
-	}
-
-	// If not in allow list or allow list is not *, don't sync
-	if !t.AllowK8sNamespacesSet.Contains("*") && !t.AllowK8sNamespacesSet.Contains(svc.Namespace) {
-		t.Log.Debug("[shouldSync] service not in allow list", "svc.Namespace", svc.Namespace, "service", svc)
+	if !t.namespaceAllowed(svc.Namespace) {
+		t.Log.Debug("[shouldSync] service namespace is denied or not allowed", "svc.Namespace", svc.Namespace, "service", svc)
 		return false
 	}
 
@@ -353,7 +398,22 @@ func (t *ServiceResource) shouldSync(svc *corev1.Service) bool {
 		return false
 	}
 
-	raw, ok := svc.Annotations[annotationServiceSync]
+	return t.syncEnabled(svc.Annotations, t.addPrefixAndK8SNamespace(svc.Name, svc.Namespace))
+}
+
+// namespaceAllowed returns true if the k8s namespace is not in the deny list and is in the allow list,
+// or the allow list is `*`.
+func (t *ServiceResource) namespaceAllowed(namespace string) bool {
+	if t.DenyK8sNamespacesSet.Contains(namespace) {
+		return false
+	}
+	return t.AllowK8sNamespacesSet.Contains("*") || t.AllowK8sNamespacesSet.Contains(namespace)
+}
+
+// syncEnabled returns the value of the service-sync annotation, or the default if it isn't set or
+// is invalid. name identifies the resource in the logs.
+func (t *ServiceResource) syncEnabled(annotations map[string]string, name string) bool {
+	raw, ok := annotations[annotationServiceSync]
 	if !ok {
 		// If there is no explicit value, then set it to our current default.
 		return !t.ExplicitEnable
@@ -362,7 +422,7 @@ func (t *ServiceResource) shouldSync(svc *corev1.Service) bool {
 	v, err := strconv.ParseBool(raw)
 	if err != nil {
 		t.Log.Warn("error parsing service-sync annotation",
-			"service-name", t.addPrefixAndK8SNamespace(svc.Name, svc.Namespace),
+			"service-name", name,
 			"err", err)
 
 		// Fallback to default
@@ -433,8 +493,9 @@ func (t *ServiceResource) generateRegistrations(key string) {
 		Service: t.addPrefixAndK8SNamespace(svc.Name, svc.Namespace),
 		Tags:    []string{t.ConsulK8STag},
 		Meta: map[string]string{
-			ConsulSourceKey: ConsulSourceValue,
-			ConsulK8SNS:     svc.Namespace,
+			ConsulSourceKey:  ConsulSourceValue,
+			ConsulK8SNS:      svc.Namespace,
+			ConsulK8SService: svc.Name,
 		},
 	}
 
@@ -516,6 +577,16 @@ func (t *ServiceResource) generateRegistrations(key string) {
 		}
 	}
 
+	// Record the protocol so that the syncer writes it in a service-defaults config entry.
+	if protocol, ok := svc.Annotations[annotationServiceProtocol]; ok {
+		protocol = strings.ToLower(strings.TrimSpace(protocol))
+		if validServiceProtocol(protocol) {
+			baseService.Meta[ConsulK8SProtocol] = protocol
+		} else {
+			t.Log.Warn("ignoring invalid service protocol annotation", "key", key, "protocol", protocol)
+		}
+	}
+
 	// Parse any additional tags
 	if rawTags, ok := svc.Annotations[annotationServiceTags]; ok {
 		baseService.Tags = append(baseService.Tags, parsetags.ParseTags(rawTags)...)
@@ -568,23 +639,19 @@ func (t *ServiceResource) generateRegistrations(key string) {
 
 	switch svc.Spec.Type {
 	// For LoadBalancer type services, we create a service instance for
-	// each LoadBalancer entry. We only support entries that have an IP
-	// address assigned (not hostnames).
+	// each LoadBalancer entry, or for each address its hostname resolves to
+	// if LBHostnameResolvePeriod is set.
 	// If LoadBalancerEndpointsSync is true sync LB endpoints instead of loadbalancer ingress.
 	case corev1.ServiceTypeLoadBalancer:
 		if t.LoadBalancerEndpointsSync {
 			t.registerServiceInstance(baseNode, baseService, key, overridePortName, overridePortNumber, false)
 		} else {
 			seen := map[string]struct{}{}
+			var addrs []string
 			for _, ingress := range svc.Status.LoadBalancer.Ingress {
-				addr := ingress.IP
-				if addr == "" {
-					addr = ingress.Hostname
-				}
-				if addr == "" {
-					continue
-				}
-
+				addrs = append(addrs, t.loadBalancerIngressAddrs(ingress)...)
+			}
+			for _, addr := range addrs {
 				if _, ok = seen[addr]; ok {
 					continue
 				}
@@ -752,6 +752,47 @@ func TestServiceResource_lbAnnotatedMeta(t *testing.T) {
 	})
 }
 
+// Test that the protocol annotation is recorded in the meta and that invalid protocols are ignored.
+func TestServiceResource_lbAnnotatedProtocol(t *testing.T) {
+	t.Parallel()
+	cases := map[string]struct {
+		protocol    string
+		expProtocol string
+	}{
+		"http":             {protocol: "http", expProtocol: "http"},
+		"grpc upper case":  {protocol: "GRPC", expProtocol: "grpc"},
+		"invalid protocol": {protocol: "udp"},
+	}
+
+	for name, c := range cases {
+		c := c
+		t.Run(name, func(t *testing.T) {
+			client := fake.NewSimpleClientset()
+			syncer := newTestSyncer()
+			serviceResource := defaultServiceResource(client, syncer)
+
+			// Start the controller
+			closer := controller.TestControllerRun(&serviceResource)
+			defer closer()
+
+			// Insert an LB service
+			svc := lbService("foo", metav1.NamespaceDefault, "1.2.3.4")
+			svc.Annotations[annotationServiceProtocol] = c.protocol
+			_, err := client.CoreV1().Services(metav1.NamespaceDefault).Create(context.Background(), svc, metav1.CreateOptions{})
+			require.NoError(t, err)
+
+			// Verify what we got
+			retry.Run(t, func(r *retry.R) {
+				syncer.Lock()
+				defer syncer.Unlock()
+				actual := syncer.Registrations
+				require.Len(r, actual, 1)
+				require.Equal(r, c.expProtocol, actual[0].Service.Meta[ConsulK8SProtocol])
+			})
+		})
+	}
+}
+
 // Test that with LoadBalancerEndpointsSync set to true we track the IP of the endpoints not the LB IP/name.
 func TestServiceResource_lbRegisterEndpoints(t *testing.T) {
 	t.Parallel()
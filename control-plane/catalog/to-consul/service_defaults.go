@@ -0,0 +1,150 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package catalog
+
+import (
+	"context"
+
+	"github.com/hashicorp/consul/api"
+)
+
+const (
+	// ConsulSyncCatalogKey is the key used in the meta of the service-defaults
+	// config entries written by the syncer, so that they can be told apart from
+	// config entries managed otherwise, e.g. by ServiceDefaults custom resources.
+	// ConsulSyncCatalogValue is the value of the key.
+	ConsulSyncCatalogKey   = "consul.hashicorp.com/sync-catalog"
+	ConsulSyncCatalogValue = "true"
+)
+
+// validServiceProtocol returns whether protocol can be set in a service-defaults
+// config entry from the consul.hashicorp.com/service-protocol annotation.
+func validServiceProtocol(protocol string) bool {
+	switch protocol {
+	case "tcp", "http", "http2", "grpc":
+		return true
+	}
+	return false
+}
+
+// serviceProtocols returns the protocol of each synced service that has one,
+// mapped by Consul namespace and service name. It must be called with the lock held.
+func (s *ConsulSyncer) serviceProtocols() map[string]map[string]string {
+	protocols := make(map[string]map[string]string)
+	for ns, services := range s.namespaces {
+		for _, r := range services {
+			protocol := r.Service.Meta[ConsulK8SProtocol]
+			if protocol == "" {
+				continue
+			}
+			if protocols[ns] == nil {
+				protocols[ns] = make(map[string]string)
+			}
+			if existing, ok := protocols[ns][r.Service.Service]; ok && existing != protocol {
+				// Kubernetes services in different namespaces can be synced to the same
+				// Consul service. Pick a protocol deterministically so that it doesn't flap.
+				s.Log.Warn("Kubernetes services synced to the same Consul service have conflicting protocols",
+					"service-name", r.Service.Service,
+					"consul-namespace-name", ns,
+					"protocols", []string{existing, protocol})
+				if existing < protocol {
+					continue
+				}
+			}
+			protocols[ns][r.Service.Service] = protocol
+		}
+	}
+	return protocols
+}
+
+// syncServiceDefaults writes a service-defaults config entry with the protocol
+// of each synced service that has one. Config entries that weren't written by the
+// syncer are left as is, and the ones that were are deleted once their service is
+// no longer synced or no longer has a protocol. It must be called with the lock held.
+func (s *ConsulSyncer) syncServiceDefaults(ctx context.Context, consulClient *api.Client) {
+	protocols := s.serviceProtocols()
+
+	queryOpts := &api.QueryOptions{}
+	if s.EnableNamespaces {
+		queryOpts.Namespace = "*"
+	}
+	entries, _, err := consulClient.ConfigEntries().List(api.ServiceDefaults, queryOpts.WithContext(ctx))
+	if err != nil {
+		s.Log.Warn("error listing service-defaults config entries", "err", err)
+		return
+	}
+
+	existing := make(map[string]map[string]*api.ServiceConfigEntry)
+	for _, entry := range entries {
+		svcDefaults, ok := entry.(*api.ServiceConfigEntry)
+		if !ok {
+			continue
+		}
+		ns := svcDefaults.Namespace
+		if !s.EnableNamespaces {
+			ns = ""
+		}
+		if existing[ns] == nil {
+			existing[ns] = make(map[string]*api.ServiceConfigEntry)
+		}
+		existing[ns][svcDefaults.Name] = svcDefaults
+
+		if svcDefaults.Meta[ConsulSyncCatalogKey] != ConsulSyncCatalogValue {
+			continue
+		}
+		if _, ok := protocols[ns][svcDefaults.Name]; ok {
+			continue
+		}
+		_, err = consulClient.ConfigEntries().Delete(api.ServiceDefaults, svcDefaults.Name,
+			(&api.WriteOptions{Namespace: svcDefaults.Namespace}).WithContext(ctx))
+		if err != nil {
+			s.Log.Warn("error deleting service-defaults config entry",
+				"service-name", svcDefaults.Name,
+				"consul-namespace-name", svcDefaults.Namespace,
+				"err", err)
+			continue
+		}
+		s.Log.Info("deleted service-defaults config entry",
+			"service-name", svcDefaults.Name,
+			"consul-namespace-name", svcDefaults.Namespace)
+	}
+
+	for ns, services := range protocols {
+		for name, protocol := range services {
+			entry, ok := existing[ns][name]
+			if ok && entry.Meta[ConsulSyncCatalogKey] != ConsulSyncCatalogValue {
+				s.Log.Debug("service-defaults config entry is not managed by sync-catalog, not setting its protocol",
+					"service-name", name,
+					"consul-namespace-name", ns)
+				continue
+			}
+			if ok && entry.Protocol == protocol {
+				continue
+			}
+
+			_, _, err = consulClient.ConfigEntries().Set(&api.ServiceConfigEntry{
+				Kind:      api.ServiceDefaults,
+				Name:      name,
+				Namespace: ns,
+				Protocol:  protocol,
+				Meta: map[string]string{
+					ConsulSourceKey:      ConsulSourceValue,
+					ConsulSyncCatalogKey: ConsulSyncCatalogValue,
+				},
+			}, (&api.WriteOptions{Namespace: ns}).WithContext(ctx))
+			if err != nil {
+				s.Log.Warn("error writing service-defaults config entry",
+					"service-name", name,
+					"consul-namespace-name", ns,
+					"protocol", protocol,
+					"err", err)
+				continue
+			}
+			s.Log.Info("wrote service-defaults config entry",
+				"service-name", name,
+				"consul-namespace-name", ns,
+				"protocol", protocol)
+		}
+	}
+}
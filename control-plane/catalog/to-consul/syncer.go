@@ -15,6 +15,7 @@ import (
 	mapset "github.com/deckarep/golang-set"
 	"github.com/hashicorp/consul/api"
 	"github.com/hashicorp/go-hclog"
+	"k8s.io/client-go/tools/record"
 
 	"github.com/hashicorp/consul-k8s/control-plane/consul"
 	"github.com/hashicorp/consul-k8s/control-plane/namespaces"
@@ -27,6 +28,7 @@ var (
 	registerErrorName   = append(baseName, "register", "error")
 	deregisterErrorName = append(baseName, "deregister", "error")
 	syncCatalogStatus   = append(baseName, "status")
+	conflictName        = append(baseName, "conflict")
 )
 
 var SyncToConsulCounters = []prometheus.CounterDefinition{
@@ -46,6 +48,10 @@ var SyncToConsulCounters = []prometheus.CounterDefinition{
 		Name: deregisterErrorName,
 		Help: "Increments whenever a Consul API client returns an error for a catalog sync deregister request request",
 	},
+	{
+		Name: conflictName,
+		Help: "Increments whenever a synced service is found to conflict with a service registered natively in Consul",
+	},
 }
 
 var SyncCatalogGauge = []prometheus.GaugeDefinition{
@@ -116,6 +122,15 @@ type ConsulSyncer struct {
 	// The Consul node name to register services with.
 	ConsulNodeName string
 
+	// ConflictPolicy decides what happens to services whose names are also
+	// used by services registered natively in Consul. Defaults to
+	// ConflictPolicyK8sWins.
+	ConflictPolicy ConflictPolicy
+
+	// EventRecorder, if set, records a Warning Event on the Kubernetes
+	// services that conflict with native Consul services.
+	EventRecorder record.EventRecorder
+
 	lock sync.Mutex
 	once sync.Once
 
@@ -127,6 +142,14 @@ type ConsulSyncer struct {
 	// to ensure it isn't closed more than once.
 	initialSyncOnce sync.Once
 
+	// registrations are the registrations given to the last Sync, before
+	// the conflict policy is applied.
+	registrations []*api.CatalogRegistration
+
+	// conflicts is all namespaces mapped to the set of synced Consul
+	// service names that are also registered natively in Consul.
+	conflicts map[string]mapset.Set
+
 	// serviceNames is all namespaces mapped to a set of valid
 	// Consul service names
 	serviceNames map[string]mapset.Set
@@ -149,29 +172,8 @@ func (s *ConsulSyncer) Sync(rs []*api.CatalogRegistration) {
 	s.lock.Lock()
 	defer s.lock.Unlock()
 
-	s.serviceNames = make(map[string]mapset.Set)
-	s.namespaces = make(map[string]map[string]*api.CatalogRegistration)
-
-	for _, r := range rs {
-		// Determine the namespace the service is in to use for indexing
-		// against the s.serviceNames and s.namespaces maps.
-		// This will be "" for OSS.
-		ns := r.Service.Namespace
-
-		// Mark this as a valid service, initializing state if necessary
-		if _, ok := s.serviceNames[ns]; !ok {
-			s.serviceNames[ns] = mapset.NewSet()
-		}
-		s.serviceNames[ns].Add(r.Service.Service)
-		s.Log.Debug("[Sync] adding service to serviceNames set", "service", r.Service, "service name", r.Service.Service)
-
-		// Add service to namespaces map, initializing if necessary
-		if _, ok := s.namespaces[ns]; !ok {
-			s.namespaces[ns] = make(map[string]*api.CatalogRegistration)
-		}
-		s.namespaces[ns][r.Service.ID] = r
-		s.Log.Debug("[Sync] adding service to namespaces map", "service", r.Service)
-	}
+	s.registrations = rs
+	s.indexRegistrationsLocked()
 
 	// Signal that the initial sync is complete and our maps have been populated.
 	// We can now safely reap untracked services.
@@ -185,6 +187,7 @@ func (s *ConsulSyncer) Run(ctx context.Context) {
 
 	// Start the background watchers
 	go s.watchReapableServices(ctx)
+	go s.watchConflicts(ctx)
 
 	reconcileTimer := time.NewTimer(s.SyncPeriod)
 	defer reconcileTimer.Stop()
@@ -490,7 +493,7 @@ func (s *ConsulSyncer) syncFull(ctx context.Context) {
 			"service-id", r.ServiceID,
 			"service-consul-namespace", r.Namespace)
 
-		_, err = consulClient.Catalog().Deregister(r, nil)
+		_, err = consulClient.Catalog().Deregister(r, (&api.WriteOptions{}).WithContext(ctx))
 		if err != nil {
 			// metric count for error deregistering k8s services from Consul
 			labels := []metrics.Label{
@@ -535,7 +538,7 @@ func (s *ConsulSyncer) syncFull(ctx context.Context) {
 			}
 
 			// Register the service.
-			_, err = consulClient.Catalog().Register(r, nil)
+			_, err = consulClient.Catalog().Register(r, (&api.WriteOptions{}).WithContext(ctx))
 			if err != nil {
 				// metric count for error syncing K8S services to Consul
 				label := []metrics.Label{
@@ -579,6 +582,14 @@ func (s *ConsulSyncer) syncFull(ctx context.Context) {
 			s.PrometheusSink.SetGauge(syncCatalogStatus, 1)
 		}
 	}
+
+	// Only reconcile service-defaults once the initial list of services is known,
+	// otherwise the config entries of services that are still synced would be deleted.
+	select {
+	case <-s.initialSync:
+		s.syncServiceDefaults(ctx, consulClient)
+	default:
+	}
 }
 
 func (s *ConsulSyncer) init() {
@@ -590,6 +601,9 @@ func (s *ConsulSyncer) init() {
 	if s.namespaces == nil {
 		s.namespaces = make(map[string]map[string]*api.CatalogRegistration)
 	}
+	if s.conflicts == nil {
+		s.conflicts = make(map[string]mapset.Set)
+	}
 	if s.deregs == nil {
 		s.deregs = make(map[string]*api.CatalogDeregistration)
 	}
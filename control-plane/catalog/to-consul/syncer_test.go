@@ -254,6 +254,63 @@ func TestConsulSyncer_stopsGracefully(t *testing.T) {
 	require.LessOrEqual(t, callCount-beforeStopAPICount, 2)
 }
 
+// Test that service-defaults config entries are written for services with a
+// protocol, and deleted once they no longer have one, while config entries not
+// written by the syncer are left as is.
+func TestConsulSyncer_serviceDefaults(t *testing.T) {
+	t.Parallel()
+
+	// Set up server, client, syncer
+	testClient := test.TestServerWithMockConnMgrWatcher(t, nil)
+	client := testClient.APIClient
+
+	// A config entry that isn't managed by the syncer.
+	_, _, err := client.ConfigEntries().Set(&api.ServiceConfigEntry{
+		Kind:     api.ServiceDefaults,
+		Name:     "baz",
+		Protocol: "tcp",
+	}, nil)
+	require.NoError(t, err)
+
+	s, closer := testConsulSyncer(testClient)
+	defer closer()
+
+	withProtocol := func(r *api.CatalogRegistration, protocol string) *api.CatalogRegistration {
+		r.Service.Meta[ConsulK8SProtocol] = protocol
+		return r
+	}
+
+	// Sync
+	s.Sync([]*api.CatalogRegistration{
+		withProtocol(testRegistration(ConsulSyncNodeName, "bar", "default"), "http"),
+		withProtocol(testRegistration(ConsulSyncNodeName, "baz", "default"), "grpc"),
+	})
+
+	retry.Run(t, func(r *retry.R) {
+		entry, _, err := client.ConfigEntries().Get(api.ServiceDefaults, "bar", nil)
+		require.NoError(r, err)
+		svcDefaults := entry.(*api.ServiceConfigEntry)
+		require.Equal(r, "http", svcDefaults.Protocol)
+		require.Equal(r, ConsulSyncCatalogValue, svcDefaults.Meta[ConsulSyncCatalogKey])
+	})
+	entry, _, err := client.ConfigEntries().Get(api.ServiceDefaults, "baz", nil)
+	require.NoError(t, err)
+	require.Equal(t, "tcp", entry.(*api.ServiceConfigEntry).Protocol)
+
+	// Remove the protocol
+	s.Sync([]*api.CatalogRegistration{
+		testRegistration(ConsulSyncNodeName, "bar", "default"),
+		testRegistration(ConsulSyncNodeName, "baz", "default"),
+	})
+
+	retry.Run(t, func(r *retry.R) {
+		entries, _, err := client.ConfigEntries().List(api.ServiceDefaults, nil)
+		require.NoError(r, err)
+		require.Len(r, entries, 1)
+		require.Equal(r, "baz", entries[0].GetName())
+	})
+}
+
 func testRegistration(node, service, k8sSrcNamespace string) *api.CatalogRegistration {
 	return &api.CatalogRegistration{
 		Node:           node,
@@ -0,0 +1,164 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package catalog
+
+import (
+	"fmt"
+	"net"
+	"sort"
+	"strings"
+
+	"github.com/hashicorp/consul/api"
+	apiv1 "k8s.io/api/core/v1"
+	discoveryv1 "k8s.io/api/discovery/v1"
+	apiequality "k8s.io/apimachinery/pkg/api/equality"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
+	"k8s.io/utils/ptr"
+)
+
+const (
+	// endpointSliceManagedBy is the value of the managed-by label of the EndpointSlices created by the sink.
+	endpointSliceManagedBy = "consul-k8s-catalog-sync"
+
+	// maxEndpointsPerSlice is the maximum number of endpoints in an EndpointSlice. It matches the
+	// default of the Kubernetes EndpointSlice controller.
+	maxEndpointsPerSlice = 100
+)
+
+// Endpoint is an instance of a Consul service that is synced to an EndpointSlice.
+type Endpoint struct {
+	// Address is the IP address of the instance.
+	Address string
+	// Port is the port of the instance.
+	Port int
+	// Status is the aggregated status of the health checks of the instance, e.g. "passing".
+	Status string
+}
+
+// endpointConditions returns the conditions of the EndpointSlice endpoint of an instance
+// whose health checks have the aggregated status.
+func endpointConditions(status string) discoveryv1.EndpointConditions {
+	switch status {
+	case api.HealthPassing, api.HealthWarning:
+		// Consul DNS also returns instances with warning checks.
+		return discoveryv1.EndpointConditions{Ready: ptr.To(true), Serving: ptr.To(true), Terminating: ptr.To(false)}
+	case api.HealthMaint:
+		// Instances in maintenance mode are drained like terminating pods: they aren't sent new
+		// connections, but they can still serve the ones they have.
+		return discoveryv1.EndpointConditions{Ready: ptr.To(false), Serving: ptr.To(true), Terminating: ptr.To(true)}
+	default:
+		return discoveryv1.EndpointConditions{Ready: ptr.To(false), Serving: ptr.To(false), Terminating: ptr.To(false)}
+	}
+}
+
+// endpointPortName returns the name of the Service and EndpointSlice port of instances listening on port.
+func endpointPortName(port int) string {
+	return fmt.Sprintf("port-%d", port)
+}
+
+// addressType returns the EndpointSlice address type of address, or false if address is not an IP address.
+func addressType(address string) (discoveryv1.AddressType, bool) {
+	ip := net.ParseIP(address)
+	switch {
+	case ip == nil:
+		return "", false
+	case ip.To4() != nil:
+		return discoveryv1.AddressTypeIPv4, true
+	default:
+		return discoveryv1.AddressTypeIPv6, true
+	}
+}
+
+// routable returns the endpoints that can be added to an EndpointSlice, i.e. those with
+// an IP address and a port.
+func routable(endpoints []Endpoint) []Endpoint {
+	var result []Endpoint
+	for _, e := range endpoints {
+		if _, ok := addressType(e.Address); ok && e.Port > 0 {
+			result = append(result, e)
+		}
+	}
+	return result
+}
+
+// servicePorts returns a port for each port the endpoints listen on, sorted by port.
+func servicePorts(endpoints []Endpoint) []apiv1.ServicePort {
+	seen := make(map[int]struct{})
+	var ports []apiv1.ServicePort
+	for _, e := range endpoints {
+		if _, ok := seen[e.Port]; ok {
+			continue
+		}
+		seen[e.Port] = struct{}{}
+		ports = append(ports, apiv1.ServicePort{
+			Name:       endpointPortName(e.Port),
+			Protocol:   apiv1.ProtocolTCP,
+			Port:       int32(e.Port),
+			TargetPort: intstr.FromInt(e.Port),
+		})
+	}
+	sort.Slice(ports, func(i, j int) bool { return ports[i].Port < ports[j].Port })
+	return ports
+}
+
+// endpointSlices returns the EndpointSlices of the Service name with the endpoints. There is an
+// EndpointSlice for each address type and port, split so that no EndpointSlice has more than
+// maxEndpointsPerSlice endpoints.
+func endpointSlices(name string, endpoints []Endpoint) []*discoveryv1.EndpointSlice {
+	type sliceKey struct {
+		addressType discoveryv1.AddressType
+		port        int
+	}
+	grouped := make(map[sliceKey][]discoveryv1.Endpoint)
+	for _, e := range endpoints {
+		addrType, ok := addressType(e.Address)
+		if !ok || e.Port <= 0 {
+			continue
+		}
+		key := sliceKey{addressType: addrType, port: e.Port}
+		grouped[key] = append(grouped[key], discoveryv1.Endpoint{
+			Addresses:  []string{e.Address},
+			Conditions: endpointConditions(e.Status),
+		})
+	}
+
+	var slices []*discoveryv1.EndpointSlice
+	for key, sliceEndpoints := range grouped {
+		sort.Slice(sliceEndpoints, func(i, j int) bool { return sliceEndpoints[i].Addresses[0] < sliceEndpoints[j].Addresses[0] })
+		for i := 0; i*maxEndpointsPerSlice < len(sliceEndpoints); i++ {
+			end := (i + 1) * maxEndpointsPerSlice
+			if end > len(sliceEndpoints) {
+				end = len(sliceEndpoints)
+			}
+			slices = append(slices, &discoveryv1.EndpointSlice{
+				ObjectMeta: metav1.ObjectMeta{
+					Name: fmt.Sprintf("%s-%s-%d-%d", name, strings.ToLower(string(key.addressType)), key.port, i),
+					Labels: map[string]string{
+						"consul":                     "true",
+						discoveryv1.LabelServiceName: name,
+						discoveryv1.LabelManagedBy:   endpointSliceManagedBy,
+					},
+				},
+				AddressType: key.addressType,
+				Ports: []discoveryv1.EndpointPort{{
+					Name:     ptr.To(endpointPortName(key.port)),
+					Protocol: ptr.To(apiv1.ProtocolTCP),
+					Port:     ptr.To(int32(key.port)),
+				}},
+				Endpoints: sliceEndpoints[i*maxEndpointsPerSlice : end],
+			})
+		}
+	}
+	sort.Slice(slices, func(i, j int) bool { return slices[i].Name < slices[j].Name })
+	return slices
+}
+
+// endpointSliceEqual returns whether the existing EndpointSlice has the endpoints and ports of the desired one.
+func endpointSliceEqual(existing, desired *discoveryv1.EndpointSlice) bool {
+	return existing.AddressType == desired.AddressType &&
+		apiequality.Semantic.DeepEqual(existing.Labels, desired.Labels) &&
+		apiequality.Semantic.DeepEqual(existing.Ports, desired.Ports) &&
+		apiequality.Semantic.DeepEqual(existing.Endpoints, desired.Endpoints)
+}
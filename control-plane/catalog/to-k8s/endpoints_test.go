@@ -0,0 +1,88 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package catalog
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/hashicorp/consul/api"
+	"github.com/stretchr/testify/require"
+	discoveryv1 "k8s.io/api/discovery/v1"
+	"k8s.io/utils/ptr"
+)
+
+func TestEndpointConditions(t *testing.T) {
+	cases := map[string]struct {
+		ready, serving, terminating bool
+	}{
+		api.HealthPassing:  {ready: true, serving: true},
+		api.HealthWarning:  {ready: true, serving: true},
+		api.HealthCritical: {},
+		api.HealthMaint:    {serving: true, terminating: true},
+	}
+	for status, c := range cases {
+		t.Run(status, func(t *testing.T) {
+			require.Equal(t, discoveryv1.EndpointConditions{
+				Ready:       ptr.To(c.ready),
+				Serving:     ptr.To(c.serving),
+				Terminating: ptr.To(c.terminating),
+			}, endpointConditions(status))
+		})
+	}
+}
+
+func TestEndpointSlices(t *testing.T) {
+	endpoints := []Endpoint{
+		{Address: "10.0.0.2", Port: 8080, Status: api.HealthCritical},
+		{Address: "10.0.0.1", Port: 8080, Status: api.HealthPassing},
+		{Address: "10.0.0.3", Port: 9090, Status: api.HealthPassing},
+		{Address: "fd00::1", Port: 8080, Status: api.HealthPassing},
+		// Instances without an IP address or port can't be routed to.
+		{Address: "web.example.com", Port: 8080, Status: api.HealthPassing},
+		{Address: "10.0.0.4", Status: api.HealthPassing},
+	}
+
+	slices := endpointSlices("web", endpoints)
+	var names []string
+	for _, slice := range slices {
+		names = append(names, slice.Name)
+	}
+	require.Equal(t, []string{"web-ipv4-8080-0", "web-ipv4-9090-0", "web-ipv6-8080-0"}, names)
+
+	ipv4 := slices[0]
+	require.Equal(t, discoveryv1.AddressTypeIPv4, ipv4.AddressType)
+	require.Equal(t, map[string]string{
+		"consul":                     "true",
+		discoveryv1.LabelServiceName: "web",
+		discoveryv1.LabelManagedBy:   endpointSliceManagedBy,
+	}, ipv4.Labels)
+	require.Equal(t, "port-8080", *ipv4.Ports[0].Name)
+	require.Equal(t, int32(8080), *ipv4.Ports[0].Port)
+	require.Len(t, ipv4.Endpoints, 2)
+	require.Equal(t, []string{"10.0.0.1"}, ipv4.Endpoints[0].Addresses)
+	require.True(t, *ipv4.Endpoints[0].Conditions.Ready)
+	require.Equal(t, []string{"10.0.0.2"}, ipv4.Endpoints[1].Addresses)
+	require.False(t, *ipv4.Endpoints[1].Conditions.Ready)
+	require.Equal(t, discoveryv1.AddressTypeIPv6, slices[2].AddressType)
+
+	ports := servicePorts(routable(endpoints))
+	require.Len(t, ports, 2)
+	require.Equal(t, "port-8080", ports[0].Name)
+	require.Equal(t, "port-9090", ports[1].Name)
+}
+
+func TestEndpointSlices_split(t *testing.T) {
+	var endpoints []Endpoint
+	for i := 0; i < maxEndpointsPerSlice+1; i++ {
+		endpoints = append(endpoints, Endpoint{Address: fmt.Sprintf("10.0.%d.%d", i/256, i%256), Port: 8080, Status: api.HealthPassing})
+	}
+
+	slices := endpointSlices("web", endpoints)
+	require.Len(t, slices, 2)
+	require.Equal(t, "web-ipv4-8080-0", slices[0].Name)
+	require.Len(t, slices[0].Endpoints, maxEndpointsPerSlice)
+	require.Equal(t, "web-ipv4-8080-1", slices[1].Name)
+	require.Len(t, slices[1].Endpoints, 1)
+}
@@ -15,6 +15,8 @@ import (
 	"github.com/hashicorp/consul-k8s/control-plane/helper/coalesce"
 	"github.com/hashicorp/go-hclog"
 	apiv1 "k8s.io/api/core/v1"
+	discoveryv1 "k8s.io/api/discovery/v1"
+	apiequality "k8s.io/apimachinery/pkg/api/equality"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/watch"
@@ -28,6 +30,7 @@ var (
 	deregisterName      = append(baseName, "deregister")
 	registerErrorName   = append(baseName, "register", "error")
 	deregisterErrorName = append(baseName, "deregister", "error")
+	conflictName        = append(baseName, "conflict")
 )
 
 var SyncToK8sCounters = []prometheus.CounterDefinition{
@@ -47,6 +50,10 @@ var SyncToK8sCounters = []prometheus.CounterDefinition{
 		Name: deregisterErrorName,
 		Help: "Increments whenever a Consul API client returns an error for a catalog sync deregister request request",
 	},
+	{
+		Name: conflictName,
+		Help: "Increments whenever a Consul service is found to conflict with a Kubernetes service not created by catalog sync",
+	},
 }
 
 const (
@@ -67,6 +74,10 @@ type Sink interface {
 	// The key is the service name and the destination is the external DNS
 	// entry to point to.
 	SetServices(map[string]string)
+
+	// SetEndpoints is called with the instances of the services that should be
+	// created. The key is the service name.
+	SetEndpoints(map[string][]Endpoint)
 }
 
 // K8SSink is a Sink implementation that registers services with Kubernetes.
@@ -84,6 +95,12 @@ type K8SSink struct {
 	// done if there are no changes.
 	SyncPeriod time.Duration
 
+	// SyncEndpointSlices makes services with instances that have an IP address
+	// and a port ClusterIP services without a selector, and syncs the health of
+	// their instances to EndpointSlices so that Kubernetes only routes to healthy
+	// instances. Other services point to Consul DNS with an ExternalName.
+	SyncEndpointSlices bool
+
 	// Ctx is used to cancel the Sink.
 	Ctx context.Context
 
@@ -97,6 +114,11 @@ type K8SSink struct {
 	// because Kube names must be lowercase.
 	sourceServices map[string]string
 
+	// sourceEndpoints holds the instances of the Consul services that should be
+	// synced to Kube. It maps from lowercased Consul service names to instances.
+	// It's only populated if SyncEndpointSlices is true.
+	sourceEndpoints map[string][]Endpoint
+
 	// keyToName maps from Kube controller keys to Kube service names.
 	// Controller keys are in the form <kube namespace>/<kube svc name>
 	// e.g. default/foo, and are the keys Kube uses to inform that something
@@ -114,6 +136,11 @@ type K8SSink struct {
 	serviceMapConsul map[string]*apiv1.Service
 	triggerCh        chan struct{}
 
+	// conflicts holds the names of the Consul services that aren't synced
+	// because a Kubernetes service not created by this sync process has
+	// the same name, so that each conflict is only reported once.
+	conflicts map[string]struct{}
+
 	PrometheusSink *prometheus.PrometheusSink
 }
 
@@ -137,6 +164,20 @@ func (s *K8SSink) SetServices(svcs map[string]string) {
 	s.trigger() // Any service change probably requires syncing
 }
 
+// SetEndpoints implements Sink.
+func (s *K8SSink) SetEndpoints(endpoints map[string][]Endpoint) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	lowercasedEndpoints := make(map[string][]Endpoint, len(endpoints))
+	for consulName, instances := range endpoints {
+		lowercasedEndpoints[strings.ToLower(consulName)] = instances
+	}
+
+	s.sourceEndpoints = lowercasedEndpoints
+	s.trigger()
+}
+
 // Informer implements the controller.Resource interface.
 // It tells Kubernetes that we want to watch for changes to Services.
 func (s *K8SSink) Informer() cache.SharedIndexInformer {
@@ -257,6 +298,10 @@ func (s *K8SSink) Run(ch <-chan struct{}) {
 
 		s.lock.Lock()
 		create, update, delete := s.crudList()
+		var endpointSlices []*discoveryv1.EndpointSlice
+		if s.SyncEndpointSlices {
+			endpointSlices = s.endpointSliceList()
+		}
 		s.lock.Unlock()
 		s.Log.Debug("sync triggered", "create", len(create), "update", len(update), "delete", len(delete))
 
@@ -294,6 +339,10 @@ func (s *K8SSink) Run(ch <-chan struct{}) {
 			// metric count for registering Consul services to k8s
 			s.PrometheusSink.IncrCounterWithLabels(registerName, 1, metricsutil.ServiceNameLabel(svc.Name))
 		}
+
+		if s.SyncEndpointSlices {
+			s.syncEndpointSlices(endpointSlices)
+		}
 	}
 }
 
@@ -301,21 +350,25 @@ func (s *K8SSink) Run(ch <-chan struct{}) {
 func (s *K8SSink) crudList() ([]*apiv1.Service, []*apiv1.Service, []string) {
 	var create, update []*apiv1.Service
 	var delete []string
+	conflicts := make(map[string]struct{})
 
 	// Determine what needs to be created or updated
 	for consulName, consulDNS := range s.sourceServices {
 		// If this is an already registered service, then update it
 		if s.serviceMapConsul != nil {
 			if svc, ok := s.serviceMapConsul[consulName]; ok {
-				if svc.Spec.ExternalName == consulDNS {
+				spec := s.serviceSpec(consulName, consulDNS)
+				if serviceSpecEqual(svc.Spec, spec) {
 					// Matching service, no update required.
 					continue
 				}
 
-				svc.Spec = apiv1.ServiceSpec{
-					Type:         apiv1.ServiceTypeExternalName,
-					ExternalName: consulDNS,
+				if spec.Type == apiv1.ServiceTypeClusterIP && svc.Spec.Type == apiv1.ServiceTypeClusterIP {
+					// The cluster IP of a service can't be changed.
+					spec.ClusterIP, spec.ClusterIPs = svc.Spec.ClusterIP, svc.Spec.ClusterIPs
+					spec.IPFamilies, spec.IPFamilyPolicy = svc.Spec.IPFamilies, svc.Spec.IPFamilyPolicy
 				}
+				svc.Spec = spec
 
 				update = append(update, svc)
 				continue
@@ -324,7 +377,11 @@ func (s *K8SSink) crudList() ([]*apiv1.Service, []*apiv1.Service, []string) {
 
 		// If this is a registered K8S service, ignore.
 		if _, ok := s.serviceMap[consulName]; ok {
-			s.Log.Warn("service already registered in K8S, not registering", "name", consulName)
+			conflicts[consulName] = struct{}{}
+			if _, ok := s.conflicts[consulName]; !ok {
+				s.Log.Warn("service already registered in K8S, not registering", "name", consulName)
+				s.PrometheusSink.IncrCounterWithLabels(conflictName, 1, metricsutil.ServiceNameLabel(consulName))
+			}
 			continue
 		}
 
@@ -339,13 +396,12 @@ func (s *K8SSink) crudList() ([]*apiv1.Service, []*apiv1.Service, []string) {
 				},
 			},
 
-			Spec: apiv1.ServiceSpec{
-				Type:         apiv1.ServiceTypeExternalName,
-				ExternalName: consulDNS,
-			},
+			Spec: s.serviceSpec(consulName, consulDNS),
 		})
 	}
 
+	s.conflicts = conflicts
+
 	// Determine what needs to be deleted
 	for k := range s.serviceMapConsul {
 		if _, ok := s.sourceServices[k]; !ok {
@@ -356,6 +412,96 @@ func (s *K8SSink) crudList() ([]*apiv1.Service, []*apiv1.Service, []string) {
 	return create, update, delete
 }
 
+// serviceSpec returns the spec of the K8S service of the Consul service
+// consulName. lock must be held.
+func (s *K8SSink) serviceSpec(consulName, consulDNS string) apiv1.ServiceSpec {
+	if s.SyncEndpointSlices {
+		if ports := servicePorts(routable(s.sourceEndpoints[consulName])); len(ports) > 0 {
+			// The service has no selector so that Kube routes to the endpoints
+			// of the EndpointSlices synced from Consul.
+			return apiv1.ServiceSpec{
+				Type:  apiv1.ServiceTypeClusterIP,
+				Ports: ports,
+			}
+		}
+	}
+
+	return apiv1.ServiceSpec{
+		Type:         apiv1.ServiceTypeExternalName,
+		ExternalName: consulDNS,
+	}
+}
+
+// serviceSpecEqual returns whether the fields of the existing service spec
+// that are set by the sink match the desired spec.
+func serviceSpecEqual(existing, desired apiv1.ServiceSpec) bool {
+	if existing.Type != desired.Type {
+		return false
+	}
+	if desired.Type == apiv1.ServiceTypeExternalName {
+		return existing.ExternalName == desired.ExternalName
+	}
+	return apiequality.Semantic.DeepEqual(existing.Ports, desired.Ports)
+}
+
+// endpointSliceList returns the EndpointSlices of the services that are
+// synced from Consul. lock must be held.
+func (s *K8SSink) endpointSliceList() []*discoveryv1.EndpointSlice {
+	var slices []*discoveryv1.EndpointSlice
+	for consulName := range s.sourceServices {
+		// If this is a registered K8S service, ignore.
+		if _, ok := s.serviceMap[consulName]; ok {
+			if _, ok := s.serviceMapConsul[consulName]; !ok {
+				continue
+			}
+		}
+		slices = append(slices, endpointSlices(consulName, s.sourceEndpoints[consulName])...)
+	}
+	return slices
+}
+
+// syncEndpointSlices creates, updates and deletes the EndpointSlices managed
+// by the sink so that they match desired.
+func (s *K8SSink) syncEndpointSlices(desired []*discoveryv1.EndpointSlice) {
+	sliceClient := s.Client.DiscoveryV1().EndpointSlices(s.namespace())
+	list, err := sliceClient.List(s.Ctx, metav1.ListOptions{
+		LabelSelector: discoveryv1.LabelManagedBy + "=" + endpointSliceManagedBy,
+	})
+	if err != nil {
+		s.Log.Warn("error listing endpoint slices", "error", err)
+		return
+	}
+	existing := make(map[string]*discoveryv1.EndpointSlice, len(list.Items))
+	for i := range list.Items {
+		existing[list.Items[i].Name] = &list.Items[i]
+	}
+
+	for _, slice := range desired {
+		current, ok := existing[slice.Name]
+		if !ok {
+			if _, err := sliceClient.Create(s.Ctx, slice, metav1.CreateOptions{}); err != nil {
+				s.Log.Warn("error creating endpoint slice", "name", slice.Name, "error", err)
+			}
+			continue
+		}
+
+		delete(existing, slice.Name)
+		if endpointSliceEqual(current, slice) {
+			continue
+		}
+		slice.ResourceVersion = current.ResourceVersion
+		if _, err := sliceClient.Update(s.Ctx, slice, metav1.UpdateOptions{}); err != nil {
+			s.Log.Warn("error updating endpoint slice", "name", slice.Name, "error", err)
+		}
+	}
+
+	for name := range existing {
+		if err := sliceClient.Delete(s.Ctx, name, metav1.DeleteOptions{}); err != nil {
+			s.Log.Warn("error deleting endpoint slice", "name", name, "error", err)
+		}
+	}
+}
+
 // namespace returns the K8S namespace to setup the resource watchers in.
 func (s *K8SSink) namespace() string {
 	if s.Namespace != "" {
@@ -9,11 +9,14 @@ import (
 
 	"github.com/armon/go-metrics/prometheus"
 	"github.com/hashicorp/consul-k8s/control-plane/helper/controller"
+	"github.com/hashicorp/consul/api"
 	"github.com/hashicorp/consul/sdk/testutil/retry"
 	"github.com/hashicorp/go-hclog"
 	"github.com/stretchr/testify/require"
 	apiv1 "k8s.io/api/core/v1"
+	discoveryv1 "k8s.io/api/discovery/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
 	"k8s.io/client-go/kubernetes"
 	"k8s.io/client-go/kubernetes/fake"
 )
@@ -160,6 +163,29 @@ func TestK8SSink_createExists(t *testing.T) {
 	})
 }
 
+// Test that Consul services conflicting with Kubernetes services not created
+// by the sync are tracked until the conflict is resolved.
+func TestK8SSink_crudListConflicts(t *testing.T) {
+	t.Parallel()
+
+	sink := &K8SSink{
+		Log:            hclog.NewNullLogger(),
+		PrometheusSink: &prometheus.PrometheusSink{},
+		sourceServices: map[string]string{"web": "web.service.local.", "db": "db.service.local."},
+		serviceMap:     map[string]struct{}{"web": {}},
+	}
+
+	create, _, _ := sink.crudList()
+	require.Len(t, create, 1)
+	require.Equal(t, "db", create[0].Name)
+	require.Equal(t, map[string]struct{}{"web": {}}, sink.conflicts)
+
+	delete(sink.serviceMap, "web")
+	create, _, _ = sink.crudList()
+	require.Len(t, create, 2)
+	require.Empty(t, sink.conflicts)
+}
+
 // Test that if the service is updated remotely, that we change it back.
 func TestK8SSink_updateReconcile(t *testing.T) {
 	t.Parallel()
@@ -397,13 +423,131 @@ func TestK8SSink_deleteReconcileLocal(t *testing.T) {
 	})
 }
 
+// Test that the health of instances is synced to EndpointSlices.
+func TestK8SSink_endpointSlices(t *testing.T) {
+	t.Parallel()
+	client := fake.NewSimpleClientset()
+
+	// Start the controller
+	sink, closer := testSinkWithConfig(t, client, func(sink *K8SSink) {
+		sink.SyncEndpointSlices = true
+	})
+	defer closer()
+
+	// Set a service with a healthy and an unhealthy instance
+	sink.SetEndpoints(map[string][]Endpoint{"web": {
+		{Address: "10.0.0.1", Port: 8080, Status: api.HealthPassing},
+		{Address: "10.0.0.2", Port: 8080, Status: api.HealthCritical},
+	}})
+	sink.SetServices(map[string]string{"web": "web.service.local."})
+
+	// Verify service and endpoint slice get registered
+	retry.Run(t, func(r *retry.R) {
+		svc, err := client.CoreV1().Services(metav1.NamespaceDefault).Get(context.Background(), "web", metav1.GetOptions{})
+		if err != nil {
+			r.Fatalf("err: %s", err)
+		}
+		require.Equal(r, apiv1.ServiceTypeClusterIP, svc.Spec.Type)
+		require.Equal(r, []apiv1.ServicePort{{
+			Name:       "port-8080",
+			Protocol:   apiv1.ProtocolTCP,
+			Port:       8080,
+			TargetPort: intstr.FromInt(8080),
+		}}, svc.Spec.Ports)
+
+		slice, err := client.DiscoveryV1().EndpointSlices(metav1.NamespaceDefault).Get(context.Background(), "web-ipv4-8080-0", metav1.GetOptions{})
+		if err != nil {
+			r.Fatalf("err: %s", err)
+		}
+		require.Equal(r, "web", slice.Labels[discoveryv1.LabelServiceName])
+		require.Len(r, slice.Endpoints, 2)
+		require.True(r, *slice.Endpoints[0].Conditions.Ready)
+		require.False(r, *slice.Endpoints[1].Conditions.Ready)
+	})
+
+	// Make the unhealthy instance healthy
+	sink.SetEndpoints(map[string][]Endpoint{"web": {
+		{Address: "10.0.0.1", Port: 8080, Status: api.HealthPassing},
+		{Address: "10.0.0.2", Port: 8080, Status: api.HealthPassing},
+	}})
+
+	// Verify endpoint slice gets updated
+	retry.Run(t, func(r *retry.R) {
+		slice, err := client.DiscoveryV1().EndpointSlices(metav1.NamespaceDefault).Get(context.Background(), "web-ipv4-8080-0", metav1.GetOptions{})
+		if err != nil {
+			r.Fatalf("err: %s", err)
+		}
+		require.True(r, *slice.Endpoints[1].Conditions.Ready)
+	})
+
+	// Clear
+	sink.SetServices(map[string]string{})
+
+	// Verify services and endpoint slices get cleared
+	retry.Run(t, func(r *retry.R) {
+		list, err := client.CoreV1().Services(metav1.NamespaceAll).List(context.Background(), metav1.ListOptions{})
+		if err != nil {
+			r.Fatalf("err: %s", err)
+		}
+		if len(list.Items) > 0 {
+			r.Fatal("services")
+		}
+		slices, err := client.DiscoveryV1().EndpointSlices(metav1.NamespaceAll).List(context.Background(), metav1.ListOptions{})
+		if err != nil {
+			r.Fatalf("err: %s", err)
+		}
+		if len(slices.Items) > 0 {
+			r.Fatal("endpoint slices")
+		}
+	})
+}
+
+// Test that services without routable instances point to Consul DNS.
+func TestK8SSink_endpointSlicesWithoutPorts(t *testing.T) {
+	t.Parallel()
+	client := fake.NewSimpleClientset()
+
+	// Start the controller
+	sink, closer := testSinkWithConfig(t, client, func(sink *K8SSink) {
+		sink.SyncEndpointSlices = true
+	})
+	defer closer()
+
+	// Set a service without a port
+	sink.SetEndpoints(map[string][]Endpoint{"web": {
+		{Address: "10.0.0.1", Status: api.HealthPassing},
+	}})
+	sink.SetServices(map[string]string{"web": "web.service.local."})
+
+	// Verify service gets registered
+	retry.Run(t, func(r *retry.R) {
+		svc, err := client.CoreV1().Services(metav1.NamespaceDefault).Get(context.Background(), "web", metav1.GetOptions{})
+		if err != nil {
+			r.Fatalf("err: %s", err)
+		}
+		require.Equal(r, apiv1.ServiceTypeExternalName, svc.Spec.Type)
+		require.Equal(r, "web.service.local.", svc.Spec.ExternalName)
+	})
+
+	slices, err := client.DiscoveryV1().EndpointSlices(metav1.NamespaceAll).List(context.Background(), metav1.ListOptions{})
+	require.NoError(t, err)
+	require.Empty(t, slices.Items)
+}
+
 func testSink(t *testing.T, client kubernetes.Interface) (*K8SSink, func()) {
+	return testSinkWithConfig(t, client, func(sink *K8SSink) {})
+}
+
+// testSinkWithConfig starts a Sink that can be configured
+// prior to starting via the configurator method.
+func testSinkWithConfig(t *testing.T, client kubernetes.Interface, configurator func(*K8SSink)) (*K8SSink, func()) {
 	sink := &K8SSink{
 		Client:         client,
 		Log:            hclog.Default(),
 		Ctx:            context.Background(),
 		PrometheusSink: &prometheus.PrometheusSink{},
 	}
+	configurator(sink)
 
 	closer := controller.TestControllerRun(sink)
 	return sink, closer
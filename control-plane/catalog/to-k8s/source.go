@@ -26,6 +26,10 @@ type Source struct {
 	Prefix              string       // Prefix is a prefix to prepend to services
 	Log                 hclog.Logger // Logger
 	ConsulK8STag        string       // The tag value for services registered
+
+	// SyncEndpoints makes the source watch the health of the instances of the
+	// services and update the Sink with them.
+	SyncEndpoints bool
 }
 
 // Run is the long-running runloop for watching Consul services and
@@ -45,8 +49,26 @@ func (s *Source) Run(ctx context.Context) {
 
 		// Get all services with tags.
 		var serviceMap map[string][]string
+		var endpoints map[string][]Endpoint
 		var meta *api.QueryMeta
 		err = backoff.Retry(func() error {
+			if s.SyncEndpoints {
+				// The health of instances changes more often than the set of
+				// services, so block until a health check changes instead.
+				// Changes to services without checks are picked up when the
+				// query times out.
+				_, meta, err = consulClient.Health().State(api.HealthAny, opts)
+				if err != nil {
+					return err
+				}
+				serviceMap, _, err = consulClient.Catalog().Services(
+					(&api.QueryOptions{AllowStale: true}).WithContext(ctx))
+				if err != nil {
+					return err
+				}
+				endpoints, err = s.endpoints(ctx, consulClient, serviceMap)
+				return err
+			}
 			serviceMap, meta, err = consulClient.Catalog().Services(opts)
 			return err
 		}, backoff.WithContext(backoff.NewExponentialBackOff(), ctx))
@@ -72,20 +94,60 @@ func (s *Source) Run(ctx context.Context) {
 			// circular syncing. Realistically this shouldn't happen since
 			// we won't register services that already exist but we double
 			// check here.
-			k8s := false
-			for _, t := range tags {
-				if t == s.ConsulK8STag {
-					k8s = true
-					break
-				}
-			}
-
-			if !k8s {
+			if !s.syncedFromK8S(tags) {
 				services[s.Prefix+name] = fmt.Sprintf("%s.service.%s", name, s.Domain)
 			}
 		}
 		s.Log.Info("received services from Consul", "count", len(services))
 
+		if s.SyncEndpoints {
+			s.Sink.SetEndpoints(endpoints)
+		}
+
 		s.Sink.SetServices(services)
 	}
 }
+
+// syncedFromK8S returns whether a service with the given tags was synced
+// from k8s.
+func (s *Source) syncedFromK8S(tags []string) bool {
+	for _, t := range tags {
+		if t == s.ConsulK8STag {
+			return true
+		}
+	}
+	return false
+}
+
+// endpoints returns the instances of the services in serviceMap that aren't
+// synced from k8s, with the aggregated status of their health checks.
+func (s *Source) endpoints(ctx context.Context, consulClient *api.Client, serviceMap map[string][]string) (map[string][]Endpoint, error) {
+	opts := (&api.QueryOptions{AllowStale: true}).WithContext(ctx)
+	endpoints := make(map[string][]Endpoint, len(serviceMap))
+	for name, tags := range serviceMap {
+		if s.syncedFromK8S(tags) {
+			continue
+		}
+
+		entries, _, err := consulClient.Health().Service(name, "", false, opts)
+		if err != nil {
+			return nil, err
+		}
+
+		instances := make([]Endpoint, 0, len(entries))
+		for _, entry := range entries {
+			address := entry.Service.Address
+			if address == "" {
+				address = entry.Node.Address
+			}
+			instances = append(instances, Endpoint{
+				Address: address,
+				Port:    entry.Service.Port,
+				Status:  entry.Checks.AggregatedStatus(),
+			})
+		}
+		endpoints[s.Prefix+name] = instances
+	}
+
+	return endpoints, nil
+}
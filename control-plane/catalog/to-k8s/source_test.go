@@ -228,6 +228,50 @@ func TestSource_deleteServiceInstance(t *testing.T) {
 	})
 }
 
+// Test that the health of service instances is synced when enabled.
+func TestSource_syncEndpoints(t *testing.T) {
+	t.Parallel()
+
+	// Set up server, client
+	testClient := test.TestServerWithMockConnMgrWatcher(t, nil)
+	client := testClient.APIClient
+
+	// Create an instance with a failing check before the source is running
+	reg := testRegistration("hostA", "svcA", nil)
+	reg.Service.Port = 8080
+	reg.Check = &api.AgentCheck{
+		Node:      "hostA",
+		CheckID:   "svcA-check",
+		Name:      "svcA-check",
+		ServiceID: "svcA",
+		Status:    api.HealthCritical,
+	}
+	_, err := client.Catalog().Register(reg, nil)
+	require.NoError(t, err)
+
+	_, sink, closer := testSourceWithConfig(testClient.Cfg, testClient.Watcher, func(s *Source) {
+		s.SyncEndpoints = true
+	})
+	defer closer()
+
+	retry.Run(t, func(r *retry.R) {
+		sink.Lock()
+		defer sink.Unlock()
+		require.Equal(r, []Endpoint{{Address: "127.0.0.1", Port: 8080, Status: api.HealthCritical}}, sink.Endpoints["svcA"])
+	})
+
+	// Make the check pass
+	reg.Check.Status = api.HealthPassing
+	_, err = client.Catalog().Register(reg, nil)
+	require.NoError(t, err)
+
+	retry.Run(t, func(r *retry.R) {
+		sink.Lock()
+		defer sink.Unlock()
+		require.Equal(r, []Endpoint{{Address: "127.0.0.1", Port: 8080, Status: api.HealthPassing}}, sink.Endpoints["svcA"])
+	})
+}
+
 // testRegistration creates a Consul test registration.
 func testRegistration(node, service string, tags []string) *api.CatalogRegistration {
 	return &api.CatalogRegistration{
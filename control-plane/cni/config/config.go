@@ -3,6 +3,8 @@
 
 package config
 
+import "fmt"
+
 const (
 	DefaultPluginName = "consul-cni"
 	DefaultPluginType = "consul-cni"
@@ -12,6 +14,13 @@ const (
 	// defaultKubeconfig is named ZZZ-.. as part of a convention that other CNI plugins use.
 	DefaultKubeconfig = "ZZZ-consul-cni-kubeconfig"
 	DefaultLogLevel   = "info"
+	// DefaultLogFile is the suggested location of the plugin's log file on the node. The plugin only
+	// logs to a file if one is configured.
+	DefaultLogFile = "/var/log/consul-cni/consul-cni.log"
+	// DefaultLogRotateMaxBytes is the size a log file can reach before it is rotated.
+	DefaultLogRotateMaxBytes = 10 * 1024 * 1024
+	// DefaultLogRotateMaxFiles is the number of rotated log files to keep.
+	DefaultLogRotateMaxFiles = 5
 )
 
 // CNIConfig is the configuration that both the CNI installer and plugin will use.
@@ -30,6 +39,13 @@ type CNIConfig struct {
 	LogLevel string `json:"log_level"   mapstructure:"log_level"`
 	// Multus is if the plugin is a multus plugin. Can be set as a cli flag.
 	Multus bool `json:"multus"      mapstructure:"multus"`
+	// LogFile is the file on the node the plugin writes its logs to in JSON. If it is empty, the plugin
+	// logs to stderr, which is captured by the container runtime. Can be set as a cli flag.
+	LogFile string `json:"log_file,omitempty" mapstructure:"log_file,omitempty"`
+	// LogRotateMaxBytes is the size the log file can reach before it is rotated. Can be set as a cli flag.
+	LogRotateMaxBytes int64 `json:"log_rotate_max_bytes,omitempty" mapstructure:"log_rotate_max_bytes,omitempty"`
+	// LogRotateMaxFiles is the number of rotated log files to keep. Can be set as a cli flag.
+	LogRotateMaxFiles int `json:"log_rotate_max_files,omitempty" mapstructure:"log_rotate_max_files,omitempty"`
 }
 
 func NewDefaultCNIConfig() *CNIConfig {
@@ -43,3 +59,8 @@ func NewDefaultCNIConfig() *CNIConfig {
 		Multus:     DefaultMultus,
 	}
 }
+
+// RotatedLogFile returns the path of the n-th most recently rotated log file of logFile.
+func RotatedLogFile(logFile string, n int) string {
+	return fmt.Sprintf("%s.%d", logFile, n)
+}
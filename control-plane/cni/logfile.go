@@ -0,0 +1,86 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package main
+
+import (
+	"errors"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+
+	"github.com/hashicorp/go-hclog"
+
+	"github.com/hashicorp/consul-k8s/control-plane/cni/config"
+)
+
+// newLogger returns the logger for a plugin invocation and a function that closes its output.
+// If a log file is configured, logs are written to it in JSON so that they can be read from the
+// node with the installer's logs command. If the log file can't be opened, logs are written to
+// stderr, which is captured by the container runtime.
+func newLogger(cfg *PluginConf, name string) (hclog.Logger, func()) {
+	opts := &hclog.LoggerOptions{
+		Name:  name,
+		Level: hclog.LevelFromString(cfg.LogLevel),
+	}
+	if cfg.LogFile == "" {
+		return hclog.New(opts), func() {}
+	}
+
+	f, err := openLogFile(cfg.LogFile, cfg.LogRotateMaxBytes, cfg.LogRotateMaxFiles)
+	if err != nil {
+		logger := hclog.New(opts)
+		logger.Warn("unable to open log file, logging to stderr", "file", cfg.LogFile, "error", err)
+		return logger, func() {}
+	}
+	opts.Output = f
+	opts.JSONFormat = true
+	return hclog.New(opts), func() { _ = f.Close() }
+}
+
+// openLogFile opens logFile for appending, creating it and its directory if needed. If the file
+// is larger than maxBytes, it is rotated first: logFile is renamed to logFile.1, logFile.1 to
+// logFile.2 and so on, and only maxFiles rotated files are kept. Each plugin invocation is short-lived,
+// so rotating when the file is opened keeps it close to maxBytes without coordinating between
+// concurrent invocations. Rotation is done with renames, so concurrent invocations never lose logs
+// that were already written, although they may rotate the file more than once.
+func openLogFile(logFile string, maxBytes int64, maxFiles int) (io.WriteCloser, error) {
+	if maxBytes <= 0 {
+		maxBytes = config.DefaultLogRotateMaxBytes
+	}
+	if maxFiles <= 0 {
+		maxFiles = config.DefaultLogRotateMaxFiles
+	}
+
+	if err := os.MkdirAll(filepath.Dir(logFile), 0o755); err != nil {
+		return nil, err
+	}
+	info, err := os.Stat(logFile)
+	if err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return nil, err
+	}
+	if err == nil && info.Size() >= maxBytes {
+		if err := rotateLogFile(logFile, maxFiles); err != nil {
+			return nil, err
+		}
+	}
+	return os.OpenFile(logFile, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o644)
+}
+
+// rotateLogFile shifts the rotated files of logFile by one, dropping the oldest, and renames
+// logFile to logFile.1.
+func rotateLogFile(logFile string, maxFiles int) error {
+	if err := os.Remove(config.RotatedLogFile(logFile, maxFiles)); err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return err
+	}
+	for i := maxFiles - 1; i >= 1; i-- {
+		if err := os.Rename(config.RotatedLogFile(logFile, i), config.RotatedLogFile(logFile, i+1)); err != nil && !errors.Is(err, fs.ErrNotExist) {
+			return err
+		}
+	}
+	if err := os.Rename(logFile, config.RotatedLogFile(logFile, 1)); err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return err
+	}
+	return nil
+}
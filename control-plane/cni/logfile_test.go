@@ -0,0 +1,71 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package main
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/hashicorp/consul-k8s/control-plane/cni/config"
+)
+
+func TestNewLogger_LogFile(t *testing.T) {
+	logFile := filepath.Join(t.TempDir(), "consul-cni", "consul-cni.log")
+	cfg := &PluginConf{LogLevel: "info", LogFile: logFile}
+
+	logger, closeLog := newLogger(cfg, "default/pod")
+	logger.Info("traffic redirect rules applied", "pod", "pod")
+	logger.Debug("not logged at info level")
+	closeLog()
+
+	contents, err := os.ReadFile(logFile)
+	require.NoError(t, err)
+	lines := strings.Split(strings.TrimSpace(string(contents)), "\n")
+	require.Len(t, lines, 1)
+
+	var entry map[string]interface{}
+	require.NoError(t, json.Unmarshal([]byte(lines[0]), &entry))
+	require.Equal(t, "default/pod", entry["@module"])
+	require.Equal(t, "traffic redirect rules applied", entry["@message"])
+	require.Equal(t, "pod", entry["pod"])
+}
+
+func TestOpenLogFile_Rotation(t *testing.T) {
+	logFile := filepath.Join(t.TempDir(), "consul-cni.log")
+	const maxBytes = 10
+	const maxFiles = 2
+
+	// Each write fills the log file so that the next open rotates it.
+	for _, contents := range []string{"first-log-", "second-log", "third-log-", "fourth-log"} {
+		f, err := openLogFile(logFile, maxBytes, maxFiles)
+		require.NoError(t, err)
+		_, err = f.Write([]byte(contents))
+		require.NoError(t, err)
+		require.NoError(t, f.Close())
+	}
+
+	requireContents := func(path, expected string) {
+		t.Helper()
+		contents, err := os.ReadFile(path)
+		require.NoError(t, err)
+		require.Equal(t, expected, string(contents))
+	}
+	requireContents(logFile, "fourth-log")
+	requireContents(config.RotatedLogFile(logFile, 1), "third-log-")
+	requireContents(config.RotatedLogFile(logFile, 2), "second-log")
+	require.NoFileExists(t, config.RotatedLogFile(logFile, 3))
+
+	// Files smaller than the maximum size are appended to.
+	f, err := openLogFile(logFile, 100, maxFiles)
+	require.NoError(t, err)
+	_, err = f.Write([]byte("-fifth-log"))
+	require.NoError(t, err)
+	require.NoError(t, f.Close())
+	requireContents(logFile, "fourth-log-fifth-log")
+}
@@ -16,7 +16,6 @@ import (
 	cniv "github.com/containernetworking/cni/pkg/version"
 	"github.com/hashicorp/consul-k8s/version"
 	"github.com/hashicorp/consul/sdk/iptables"
-	"github.com/hashicorp/go-hclog"
 	corev1 "k8s.io/api/core/v1"
 	"k8s.io/client-go/kubernetes"
 	"k8s.io/client-go/tools/clientcmd"
@@ -102,6 +101,12 @@ type PluginConf struct {
 	Kubeconfig string `json:"kubeconfig"`
 	// LogLevel is the logging level. Can be set as a cli flag.
 	LogLevel string `json:"log_level"`
+	// LogFile is the file the plugin writes its logs to. If it is empty, logs are written to stderr.
+	LogFile string `json:"log_file"`
+	// LogRotateMaxBytes is the size the log file can reach before it is rotated.
+	LogRotateMaxBytes int64 `json:"log_rotate_max_bytes"`
+	// LogRotateMaxFiles is the number of rotated log files to keep.
+	LogRotateMaxFiles int `json:"log_rotate_max_files"`
 }
 
 // parseConfig parses the supplied CNI configuration (and prevResult) from stdin.
@@ -122,7 +127,7 @@ func parseConfig(stdin []byte) (*PluginConf, error) {
 }
 
 // cmdAdd is called for ADD requests.
-func (c *Command) cmdAdd(args *skel.CmdArgs) error {
+func (c *Command) cmdAdd(args *skel.CmdArgs) (err error) {
 	cfg, err := parseConfig(args.StdinData)
 	if err != nil {
 		return err
@@ -145,10 +150,13 @@ func (c *Command) cmdAdd(args *skel.CmdArgs) error {
 	}
 
 	logPrefix := fmt.Sprintf("%s/%s", podNamespace, podName)
-	logger := hclog.New(&hclog.LoggerOptions{
-		Name:  logPrefix,
-		Level: hclog.LevelFromString(cfg.LogLevel),
-	})
+	logger, closeLog := newLogger(cfg, logPrefix)
+	defer closeLog()
+	defer func() {
+		if err != nil {
+			logger.Error("consul-cni plugin failed", "error", err)
+		}
+	}()
 
 	logger.Debug("consul-cni plugin config", "config", cfg)
 
@@ -173,7 +181,7 @@ func (c *Command) cmdAdd(args *skel.CmdArgs) error {
 		result = prevResult
 	}
 
-	var iptablesCfg iptables.Config
+	var iptablesCfg redirectTrafficConfig
 
 	// If cniArgsIPTablesCfg is populated we're on Nomad, otherwise we're on K8s
 	if cniArgsIPTablesCfg != "" {
@@ -210,7 +218,7 @@ func (c *Command) cmdAdd(args *skel.CmdArgs) error {
 			logger.Info("unable to update %s pod annotation to waiting", keyTransparentProxyStatus)
 		}
 
-		// Parse the cni-proxy-config annotation into an iptables.Config object.
+		// Parse the cni-proxy-config annotation into a redirectTrafficConfig object.
 		iptablesCfg, err = parseAnnotation(*pod, annotationRedirectTraffic)
 		if err != nil {
 			return err
@@ -227,7 +235,7 @@ func (c *Command) cmdAdd(args *skel.CmdArgs) error {
 	}
 
 	// Apply the iptables rules.
-	err = iptables.Setup(iptablesCfg)
+	err = iptables.SetupWithAdditionalRules(iptablesCfg.Config, iptablesCfg.additionalRules())
 	if err != nil {
 		return fmt.Errorf("could not apply iptables setup: %v", err)
 	}
@@ -301,8 +309,8 @@ func skipTrafficRedirection(pod corev1.Pod) bool {
 	return false
 }
 
-func parseIPTablesFromCNIArgs(args string) (iptables.Config, error) {
-	cfg := iptables.Config{}
+func parseIPTablesFromCNIArgs(args string) (redirectTrafficConfig, error) {
+	cfg := redirectTrafficConfig{}
 	err := json.Unmarshal([]byte(args), &cfg)
 	if err != nil {
 		return cfg, fmt.Errorf("could not unmarshal CNI args: %w", err)
@@ -310,16 +318,16 @@ func parseIPTablesFromCNIArgs(args string) (iptables.Config, error) {
 	return cfg, nil
 }
 
-// parseAnnotation parses the cni-proxy-config annotation into an iptables.Config object.
-func parseAnnotation(pod corev1.Pod, annotation string) (iptables.Config, error) {
+// parseAnnotation parses the cni-proxy-config annotation into a redirectTrafficConfig object.
+func parseAnnotation(pod corev1.Pod, annotation string) (redirectTrafficConfig, error) {
 	anno, ok := pod.Annotations[annotation]
 	if !ok {
-		return iptables.Config{}, fmt.Errorf("could not find %s annotation for %s pod", annotation, pod.Name)
+		return redirectTrafficConfig{}, fmt.Errorf("could not find %s annotation for %s pod", annotation, pod.Name)
 	}
-	cfg := iptables.Config{}
+	cfg := redirectTrafficConfig{}
 	err := json.Unmarshal([]byte(anno), &cfg)
 	if err != nil {
-		return iptables.Config{}, fmt.Errorf("could not unmarshal %s annotation for %s pod", annotation, pod.Name)
+		return redirectTrafficConfig{}, fmt.Errorf("could not unmarshal %s annotation for %s pod", annotation, pod.Name)
 	}
 	return cfg, nil
 }
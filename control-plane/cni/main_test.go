@@ -54,6 +54,7 @@ func Test_cmdAdd(t *testing.T) {
 		cmdArgs       *skel.CmdArgs
 		configuredPod func(*corev1.Pod, *Command) *corev1.Pod
 		expectedRules bool
+		expectedRule  string
 		expectedErr   error
 	}{
 		{
@@ -128,6 +129,27 @@ func Test_cmdAdd(t *testing.T) {
 			expectedErr:   nil,
 			expectedRules: true, // Rules will be applied
 		},
+		{
+			name: "Pod with DNS capture, should create DNS capture rules",
+			cmd: &Command{
+				client:           fake.NewSimpleClientset(),
+				iptablesProvider: &fakeIptablesProvider{},
+			},
+			podName:   "pod-dns-capture",
+			stdInData: goodStdinData,
+			configuredPod: func(pod *corev1.Pod, cmd *Command) *corev1.Pod {
+				pod.Annotations[keyInjectStatus] = "true"
+				pod.Annotations[keyTransparentProxyStatus] = "enabled"
+				pod.Annotations[annotationRedirectTraffic] = `{"ProxyUserID":"123","ProxyInboundPort":20000,"ConsulDNSIP":"127.0.0.1","ConsulDNSPort":8600,"CaptureDNS":true}`
+				_, err := cmd.client.CoreV1().Pods(defaultNamespace).Create(context.Background(), pod, metav1.CreateOptions{})
+				require.NoError(t, err)
+
+				return pod
+			},
+			expectedErr:   nil,
+			expectedRules: true, // Rules will be applied
+			expectedRule:  "iptables -t nat -I OUTPUT -p udp --dport 53 -j CONSUL_DNS_CAPTURE",
+		},
 		{
 			name: "Parsing iptables from CNI_ARGs as in Nomad",
 			cmd: &Command{
@@ -160,6 +182,9 @@ func Test_cmdAdd(t *testing.T) {
 			if c.expectedErr == nil && c.expectedRules {
 				require.NotEmpty(t, c.cmd.iptablesProvider.Rules())
 			}
+			if c.expectedRule != "" {
+				require.Contains(t, c.cmd.iptablesProvider.Rules(), c.expectedRule)
+			}
 		})
 	}
 }
@@ -227,7 +252,7 @@ func TestParseAnnotation(t *testing.T) {
 		name         string
 		annotation   string
 		configurePod func(*corev1.Pod) *corev1.Pod
-		expected     iptables.Config
+		expected     redirectTrafficConfig
 		err          error
 	}{
 		{
@@ -243,8 +268,22 @@ func TestParseAnnotation(t *testing.T) {
 				pod.Annotations[annotationRedirectTraffic] = string(j)
 				return pod
 			},
-			expected: iptables.Config{
-				ProxyUserID: "1234",
+			expected: redirectTrafficConfig{
+				Config: iptables.Config{ProxyUserID: "1234"},
+			},
+			err: nil,
+		},
+		{
+			name:       "Pod with DNS capture in the annotation",
+			annotation: annotationRedirectTraffic,
+			configurePod: func(pod *corev1.Pod) *corev1.Pod {
+				pod.Annotations[annotationRedirectTraffic] = `{"ProxyUserID":"1234","CaptureDNS":true,"ExcludeDNSCaptureCIDRs":["169.254.20.10/32"]}`
+				return pod
+			},
+			expected: redirectTrafficConfig{
+				Config:                 iptables.Config{ProxyUserID: "1234"},
+				CaptureDNS:             true,
+				ExcludeDNSCaptureCIDRs: []string{"169.254.20.10/32"},
 			},
 			err: nil,
 		},
@@ -254,7 +293,7 @@ func TestParseAnnotation(t *testing.T) {
 			configurePod: func(pod *corev1.Pod) *corev1.Pod {
 				return pod
 			},
-			expected: iptables.Config{},
+			expected: redirectTrafficConfig{},
 			err:      fmt.Errorf("could not find %s annotation for %s pod", annotationRedirectTraffic, defaultPodName),
 		},
 	}
@@ -0,0 +1,65 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package main
+
+import (
+	"fmt"
+
+	"github.com/hashicorp/consul/sdk/iptables"
+)
+
+// dnsCaptureChain is the chain that redirects outbound DNS traffic to every nameserver to Consul DNS.
+const dnsCaptureChain = "CONSUL_DNS_CAPTURE"
+
+// redirectTrafficConfig is duplicated from control-plane/connect-inject/common/redirect_traffic.go in
+// order to prevent pulling in dependencies. It extends iptables.Config with rules that iptables.Setup
+// doesn't support.
+type redirectTrafficConfig struct {
+	iptables.Config
+
+	// CaptureDNS redirects outbound DNS traffic over UDP and TCP port 53 to Consul DNS at ConsulDNSIP:ConsulDNSPort
+	// regardless of the nameserver it is sent to, so the pod's nameservers don't have to point at Consul DNS.
+	// DNS traffic from the proxy and ExcludeUIDs isn't captured.
+	CaptureDNS bool `json:",omitempty"`
+
+	// ExcludeDNSCaptureCIDRs is the list of nameserver CIDRs whose DNS traffic is not captured when
+	// CaptureDNS is set. Unlike ExcludeOutboundCIDRs, it applies to UDP as well as TCP.
+	ExcludeDNSCaptureCIDRs []string `json:",omitempty"`
+}
+
+// additionalRules returns the rules to apply with iptables.SetupWithAdditionalRules
+// for the fields that iptables.Config doesn't have.
+func (c redirectTrafficConfig) additionalRules() iptables.AdditionalRulesFn {
+	return func(provider iptables.Provider) {
+		if !c.CaptureDNS {
+			return
+		}
+		dnsIP := c.ConsulDNSIP
+		if dnsIP == "" {
+			dnsIP = "127.0.0.1"
+		}
+		destination := dnsIP
+		if c.ConsulDNSPort != 0 {
+			destination = fmt.Sprintf("%s:%d", dnsIP, c.ConsulDNSPort)
+		}
+
+		provider.AddRule("iptables", "-t", "nat", "-N", dnsCaptureChain)
+
+		// Don't capture the DNS traffic of the proxy so that it can resolve upstream addresses itself.
+		provider.AddRule("iptables", "-t", "nat", "-A", dnsCaptureChain, "-m", "owner", "--uid-owner", c.ProxyUserID, "-j", "RETURN")
+		for _, uid := range c.ExcludeUIDs {
+			provider.AddRule("iptables", "-t", "nat", "-A", dnsCaptureChain, "-m", "owner", "--uid-owner", uid, "-j", "RETURN")
+		}
+		for _, cidr := range c.ExcludeDNSCaptureCIDRs {
+			provider.AddRule("iptables", "-t", "nat", "-A", dnsCaptureChain, "-d", cidr, "-j", "RETURN")
+		}
+		provider.AddRule("iptables", "-t", "nat", "-A", dnsCaptureChain, "-p", "udp", "-j", "DNAT", "--to-destination", destination)
+		provider.AddRule("iptables", "-t", "nat", "-A", dnsCaptureChain, "-p", "tcp", "-j", "DNAT", "--to-destination", destination)
+
+		// The DNS traffic is captured before the rules that redirect all outbound TCP traffic
+		// to the proxy, so the rules are inserted at the start of the OUTPUT chain.
+		provider.AddRule("iptables", "-t", "nat", "-I", "OUTPUT", "-p", "udp", "--dport", "53", "-j", dnsCaptureChain)
+		provider.AddRule("iptables", "-t", "nat", "-I", "OUTPUT", "-p", "tcp", "--dport", "53", "-j", dnsCaptureChain)
+	}
+}
@@ -13,6 +13,8 @@ import (
 	cmdConsulLogout "github.com/hashicorp/consul-k8s/control-plane/subcommand/consul-logout"
 	cmdCreateFederationSecret "github.com/hashicorp/consul-k8s/control-plane/subcommand/create-federation-secret"
 	cmdDeleteCompletedJob "github.com/hashicorp/consul-k8s/control-plane/subcommand/delete-completed-job"
+	cmdFederationController "github.com/hashicorp/consul-k8s/control-plane/subcommand/federation-controller"
+	cmdFetchServerExternalAddress "github.com/hashicorp/consul-k8s/control-plane/subcommand/fetch-server-external-address"
 	cmdFetchServerRegion "github.com/hashicorp/consul-k8s/control-plane/subcommand/fetch-server-region"
 	cmdGatewayCleanup "github.com/hashicorp/consul-k8s/control-plane/subcommand/gateway-cleanup"
 	cmdGatewayResources "github.com/hashicorp/consul-k8s/control-plane/subcommand/gateway-resources"
@@ -22,6 +24,7 @@ import (
 	cmdInstallCNI "github.com/hashicorp/consul-k8s/control-plane/subcommand/install-cni"
 	cmdPartitionInit "github.com/hashicorp/consul-k8s/control-plane/subcommand/partition-init"
 	cmdServerACLInit "github.com/hashicorp/consul-k8s/control-plane/subcommand/server-acl-init"
+	cmdServerConfigReloader "github.com/hashicorp/consul-k8s/control-plane/subcommand/server-config-reloader"
 	cmdSyncCatalog "github.com/hashicorp/consul-k8s/control-plane/subcommand/sync-catalog"
 	cmdTLSInit "github.com/hashicorp/consul-k8s/control-plane/subcommand/tls-init"
 	cmdVersion "github.com/hashicorp/consul-k8s/control-plane/subcommand/version"
@@ -88,6 +91,10 @@ func init() {
 			return &cmdCreateFederationSecret.Command{UI: ui}, nil
 		},
 
+		"federation-controller": func() (cli.Command, error) {
+			return &cmdFederationController.Command{UI: ui}, nil
+		},
+
 		"webhook-cert-manager": func() (cli.Command, error) {
 			return &webhookCertManager.Command{UI: ui}, nil
 		},
@@ -102,9 +109,18 @@ func init() {
 		"install-cni": func() (cli.Command, error) {
 			return &cmdInstallCNI.Command{UI: ui}, nil
 		},
+		"install-cni logs": func() (cli.Command, error) {
+			return &cmdInstallCNI.LogsCommand{UI: ui}, nil
+		},
 		"fetch-server-region": func() (cli.Command, error) {
 			return &cmdFetchServerRegion.Command{UI: ui}, nil
 		},
+		"fetch-server-external-address": func() (cli.Command, error) {
+			return &cmdFetchServerExternalAddress.Command{UI: ui}, nil
+		},
+		"server-config-reloader": func() (cli.Command, error) {
+			return &cmdServerConfigReloader.Command{UI: ui}, nil
+		},
 	}
 }
 
@@ -115,6 +115,16 @@ func ShouldIgnore(namespace string, denySet, allowSet mapset.Set) bool {
 	return false
 }
 
+// WorkloadPortName returns the name of the Consul workload port for a container port. Consul
+// requires every workload port to be named, so unnamed container ports are named after their
+// port number.
+func WorkloadPortName(port *corev1.ContainerPort) string {
+	if port.Name != "" {
+		return port.Name
+	}
+	return constants.UnnamedWorkloadPortNamePrefix + strconv.Itoa(int(port.ContainerPort))
+}
+
 func ConsulNodeNameFromK8sNode(nodeName string) string {
 	return fmt.Sprintf("%s-virtual", nodeName)
 }
@@ -318,6 +318,11 @@ func TestShouldIgnore(t *testing.T) {
 	}
 }
 
+func TestWorkloadPortName(t *testing.T) {
+	require.Equal(t, "http", WorkloadPortName(&corev1.ContainerPort{Name: "http", ContainerPort: 8080}))
+	require.Equal(t, "cslport-8080", WorkloadPortName(&corev1.ContainerPort{ContainerPort: 8080}))
+}
+
 func Test_ConsulNamespaceIsNotFound(t *testing.T) {
 	t.Parallel()
 
@@ -0,0 +1,56 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package common
+
+import (
+	"context"
+	"time"
+
+	"github.com/go-logr/logr"
+)
+
+// ConfigMapPoller refreshes the state read from a ConfigMap every Interval until its context is
+// cancelled, so that changes to the ConfigMap are picked up without caching ConfigMaps in the manager.
+//
+// ConfigMapPoller implements manager.Runnable. It runs on every replica unless LeaderElection is set.
+type ConfigMapPoller struct {
+	// Refresh reads the ConfigMap and applies it. Errors are logged and Refresh is retried after Interval.
+	Refresh func(ctx context.Context) error
+	// Interval is how often Refresh is called.
+	Interval time.Duration
+	// RefreshOnStart is whether Refresh is called when the poller starts rather than after the first
+	// Interval, for state that isn't read before the manager starts.
+	RefreshOnStart bool
+	// LeaderElection is whether the poller only runs on the leader, e.g. because Refresh writes the ConfigMap.
+	LeaderElection bool
+	Log            logr.Logger
+}
+
+// Start calls Refresh every Interval until ctx is cancelled.
+func (p *ConfigMapPoller) Start(ctx context.Context) error {
+	ticker := time.NewTicker(p.Interval)
+	defer ticker.Stop()
+	if p.RefreshOnStart {
+		p.refresh(ctx)
+	}
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+			p.refresh(ctx)
+		}
+	}
+}
+
+// NeedLeaderElection implements manager.LeaderElectionRunnable.
+func (p *ConfigMapPoller) NeedLeaderElection() bool {
+	return p.LeaderElection
+}
+
+func (p *ConfigMapPoller) refresh(ctx context.Context) {
+	if err := p.Refresh(ctx); err != nil {
+		p.Log.Error(err, "failed to refresh from ConfigMap")
+	}
+}
@@ -0,0 +1,63 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package common
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	logrtest "github.com/go-logr/logr/testr"
+	"github.com/stretchr/testify/require"
+)
+
+func TestConfigMapPoller(t *testing.T) {
+	cases := map[string]struct {
+		refreshOnStart bool
+		interval       time.Duration
+		expRefreshed   bool
+	}{
+		"refreshes every interval": {
+			interval:     10 * time.Millisecond,
+			expRefreshed: true,
+		},
+		"refreshes on start": {
+			refreshOnStart: true,
+			interval:       time.Hour,
+			expRefreshed:   true,
+		},
+		"waits for the first interval": {
+			interval: time.Hour,
+		},
+	}
+	for name, c := range cases {
+		t.Run(name, func(t *testing.T) {
+			var refreshes atomic.Int32
+			poller := &ConfigMapPoller{
+				Refresh: func(context.Context) error {
+					refreshes.Add(1)
+					// Errors are logged and the poller keeps refreshing.
+					return errors.New("invalid ConfigMap")
+				},
+				Interval:       c.interval,
+				RefreshOnStart: c.refreshOnStart,
+				Log:            logrtest.New(t),
+			}
+			require.False(t, poller.NeedLeaderElection())
+
+			ctx, cancel := context.WithCancel(context.Background())
+			done := make(chan error)
+			go func() { done <- poller.Start(ctx) }()
+			if c.expRefreshed {
+				require.Eventually(t, func() bool { return refreshes.Load() > 0 }, time.Second, 5*time.Millisecond)
+			} else {
+				require.Never(t, func() bool { return refreshes.Load() > 0 }, 50*time.Millisecond, 5*time.Millisecond)
+			}
+			cancel()
+			require.NoError(t, <-done)
+		})
+	}
+}
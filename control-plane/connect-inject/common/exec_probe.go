@@ -0,0 +1,96 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package common
+
+import (
+	"net"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/google/shlex"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
+
+	"github.com/hashicorp/consul-k8s/control-plane/connect-inject/constants"
+)
+
+// ShouldTranslateExecProbes returns true if exec probes that make HTTP requests should be
+// translated into HTTP probes for this pod. It returns an error when the annotation value
+// cannot be parsed by strconv.ParseBool.
+func ShouldTranslateExecProbes(pod corev1.Pod) (bool, error) {
+	if raw, ok := pod.Annotations[constants.AnnotationTransparentProxyTranslateExecProbes]; ok {
+		return strconv.ParseBool(raw)
+	}
+	return false, nil
+}
+
+// HTTPGetActionFromExecProbe returns the HTTPGetAction equivalent of an exec probe whose command
+// makes an HTTP request to the application over the loopback interface, for example
+// `curl -f http://localhost:8080/healthz` or `sh -c "wget -q -O- http://127.0.0.1:8080/ready"`.
+// The second return value is false if the probe is not an exec probe or no such request is found.
+func HTTPGetActionFromExecProbe(probe *corev1.Probe) (*corev1.HTTPGetAction, bool) {
+	if probe == nil || probe.Exec == nil {
+		return nil, false
+	}
+
+	for _, arg := range probe.Exec.Command {
+		// Commands are commonly wrapped in a shell, e.g. ["sh", "-c", "curl ..."], so split
+		// every argument into its own tokens before looking for a URL.
+		tokens, err := shlex.Split(arg)
+		if err != nil {
+			continue
+		}
+		for _, token := range tokens {
+			if action, ok := httpGetActionFromURL(token); ok {
+				return action, true
+			}
+		}
+	}
+	return nil, false
+}
+
+// httpGetActionFromURL parses raw as a plain HTTP URL on a loopback address and converts it into
+// an HTTPGetAction. Requests to other hosts are not translated because the kubelet always sends
+// HTTP probes to the pod IP. HTTPS requests are not translated because Envoy's exposed paths
+// only proxy plain HTTP to the application.
+func httpGetActionFromURL(raw string) (*corev1.HTTPGetAction, bool) {
+	if !strings.HasPrefix(raw, "http://") {
+		return nil, false
+	}
+	u, err := url.Parse(raw)
+	if err != nil {
+		return nil, false
+	}
+
+	host := u.Hostname()
+	if host != "localhost" {
+		ip := net.ParseIP(host)
+		if ip == nil || !ip.IsLoopback() {
+			return nil, false
+		}
+	}
+
+	port := 80
+	if u.Port() != "" {
+		port, err = strconv.Atoi(u.Port())
+		if err != nil {
+			return nil, false
+		}
+	}
+
+	path := u.EscapedPath()
+	if path == "" {
+		path = "/"
+	}
+	if u.RawQuery != "" {
+		path += "?" + u.RawQuery
+	}
+
+	return &corev1.HTTPGetAction{
+		Path:   path,
+		Port:   intstr.FromInt(port),
+		Scheme: corev1.URISchemeHTTP,
+	}, true
+}
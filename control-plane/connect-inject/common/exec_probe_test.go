@@ -0,0 +1,77 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package common
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
+)
+
+func TestHTTPGetActionFromExecProbe(t *testing.T) {
+	cases := map[string]struct {
+		probe     *corev1.Probe
+		expAction *corev1.HTTPGetAction
+	}{
+		"nil probe": {},
+		"http probe": {
+			probe: &corev1.Probe{
+				ProbeHandler: corev1.ProbeHandler{
+					HTTPGet: &corev1.HTTPGetAction{Port: intstr.FromInt(8080)},
+				},
+			},
+		},
+		"exec probe without a URL": {
+			probe: execProbe("cat", "/tmp/healthy"),
+		},
+		"curl to localhost": {
+			probe: execProbe("curl", "-f", "http://localhost:8080/healthz"),
+			expAction: &corev1.HTTPGetAction{
+				Path:   "/healthz",
+				Port:   intstr.FromInt(8080),
+				Scheme: corev1.URISchemeHTTP,
+			},
+		},
+		"wget to loopback IP in a shell": {
+			probe: execProbe("/bin/sh", "-c", "wget -q -O- 'http://127.0.0.1:9090/ready?verbose=1' || exit 1"),
+			expAction: &corev1.HTTPGetAction{
+				Path:   "/ready?verbose=1",
+				Port:   intstr.FromInt(9090),
+				Scheme: corev1.URISchemeHTTP,
+			},
+		},
+		"default port and path": {
+			probe: execProbe("curl", "http://[::1]"),
+			expAction: &corev1.HTTPGetAction{
+				Path:   "/",
+				Port:   intstr.FromInt(80),
+				Scheme: corev1.URISchemeHTTP,
+			},
+		},
+		"non-loopback host": {
+			probe: execProbe("curl", "http://backend:8080/healthz"),
+		},
+		"https is not translated": {
+			probe: execProbe("curl", "-k", "https://localhost:8443/healthz"),
+		},
+	}
+
+	for name, c := range cases {
+		t.Run(name, func(t *testing.T) {
+			action, ok := HTTPGetActionFromExecProbe(c.probe)
+			require.Equal(t, c.expAction != nil, ok)
+			require.Equal(t, c.expAction, action)
+		})
+	}
+}
+
+func execProbe(cmd ...string) *corev1.Probe {
+	return &corev1.Probe{
+		ProbeHandler: corev1.ProbeHandler{
+			Exec: &corev1.ExecAction{Command: cmd},
+		},
+	}
+}
@@ -0,0 +1,66 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package common
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+
+	"github.com/hashicorp/consul-k8s/control-plane/connect-inject/constants"
+)
+
+// sidecarContainerPrefix is the prefix of the names of the injected consul-dataplane containers.
+const sidecarContainerPrefix = "consul-dataplane"
+
+// IsJob returns true if the pod runs to completion, i.e. it is owned by a Kubernetes Job
+// or CronJob. The consul.hashicorp.com/job annotation overrides it, e.g. for pods created
+// by other batch controllers. It returns an error when the annotation value cannot be
+// parsed by strconv.ParseBool.
+func IsJob(pod corev1.Pod) (bool, error) {
+	if raw, ok := pod.Annotations[constants.AnnotationJob]; ok {
+		job, err := strconv.ParseBool(raw)
+		if err != nil {
+			return false, fmt.Errorf("%s annotation value of %q is not a valid boolean", constants.AnnotationJob, raw)
+		}
+		return job, nil
+	}
+	// Pods of a CronJob are owned by the Job it creates.
+	for _, owner := range pod.OwnerReferences {
+		if owner.Kind == "Job" && owner.APIVersion == batchv1.SchemeGroupVersion.String() {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// JobCompleted returns true if the pod of a job has run to completion. Besides the pods that
+// succeeded or failed, this includes the pods whose application containers have all exited
+// while their sidecar proxies, which aren't native sidecars, are still running.
+func JobCompleted(pod corev1.Pod) bool {
+	if pod.Status.Phase == corev1.PodSucceeded || pod.Status.Phase == corev1.PodFailed {
+		return true
+	}
+
+	// Containers that failed are restarted unless the restart policy of the pod is Never.
+	exited := make(map[string]bool, len(pod.Status.ContainerStatuses))
+	for _, status := range pod.Status.ContainerStatuses {
+		terminated := status.State.Terminated
+		exited[status.Name] = terminated != nil && (terminated.ExitCode == 0 || pod.Spec.RestartPolicy == corev1.RestartPolicyNever)
+	}
+	apps := 0
+	for _, c := range pod.Spec.Containers {
+		if strings.HasPrefix(c.Name, sidecarContainerPrefix) {
+			continue
+		}
+		if !exited[c.Name] {
+			return false
+		}
+		apps++
+	}
+	return apps > 0
+}
@@ -0,0 +1,127 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package common
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/hashicorp/consul-k8s/control-plane/connect-inject/constants"
+)
+
+func TestIsJob(t *testing.T) {
+	jobOwner := metav1.OwnerReference{APIVersion: "batch/v1", Kind: "Job", Name: "job"}
+	cases := map[string]struct {
+		annotations map[string]string
+		owners      []metav1.OwnerReference
+		expected    bool
+		expErr      string
+	}{
+		"no owner": {},
+		"owned by a Job": {
+			owners:   []metav1.OwnerReference{jobOwner},
+			expected: true,
+		},
+		"owned by a ReplicaSet": {
+			owners: []metav1.OwnerReference{{APIVersion: "apps/v1", Kind: "ReplicaSet", Name: "rs"}},
+		},
+		"owned by a Job of another API group": {
+			owners: []metav1.OwnerReference{{APIVersion: "example.com/v1", Kind: "Job", Name: "job"}},
+		},
+		"annotation true": {
+			annotations: map[string]string{constants.AnnotationJob: "true"},
+			expected:    true,
+		},
+		"annotation false overrides the owner": {
+			annotations: map[string]string{constants.AnnotationJob: "false"},
+			owners:      []metav1.OwnerReference{jobOwner},
+		},
+		"invalid annotation": {
+			annotations: map[string]string{constants.AnnotationJob: "yes please"},
+			expErr:      `consul.hashicorp.com/job annotation value of "yes please" is not a valid boolean`,
+		},
+	}
+	for name, c := range cases {
+		t.Run(name, func(t *testing.T) {
+			pod := corev1.Pod{
+				ObjectMeta: metav1.ObjectMeta{
+					Annotations:     c.annotations,
+					OwnerReferences: c.owners,
+				},
+			}
+			actual, err := IsJob(pod)
+			if c.expErr != "" {
+				require.EqualError(t, err, c.expErr)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, c.expected, actual)
+		})
+	}
+}
+
+func TestJobCompleted(t *testing.T) {
+	running := corev1.ContainerState{Running: &corev1.ContainerStateRunning{}}
+	succeeded := corev1.ContainerState{Terminated: &corev1.ContainerStateTerminated{ExitCode: 0}}
+	failed := corev1.ContainerState{Terminated: &corev1.ContainerStateTerminated{ExitCode: 1}}
+
+	cases := map[string]struct {
+		phase         corev1.PodPhase
+		restartPolicy corev1.RestartPolicy
+		statuses      map[string]corev1.ContainerState
+		expected      bool
+	}{
+		"running": {
+			phase:    corev1.PodRunning,
+			statuses: map[string]corev1.ContainerState{"app": running, "consul-dataplane": running},
+		},
+		"pending": {
+			phase: corev1.PodPending,
+		},
+		"succeeded": {
+			phase:    corev1.PodSucceeded,
+			expected: true,
+		},
+		"failed": {
+			phase:    corev1.PodFailed,
+			expected: true,
+		},
+		"application exited while the sidecar is running": {
+			phase:    corev1.PodRunning,
+			statuses: map[string]corev1.ContainerState{"app": succeeded, "consul-dataplane": running},
+			expected: true,
+		},
+		"application failed and is restarted": {
+			phase:         corev1.PodRunning,
+			restartPolicy: corev1.RestartPolicyOnFailure,
+			statuses:      map[string]corev1.ContainerState{"app": failed, "consul-dataplane": running},
+		},
+		"application failed and is not restarted": {
+			phase:         corev1.PodRunning,
+			restartPolicy: corev1.RestartPolicyNever,
+			statuses:      map[string]corev1.ContainerState{"app": failed, "consul-dataplane": running},
+			expected:      true,
+		},
+	}
+	for name, c := range cases {
+		t.Run(name, func(t *testing.T) {
+			pod := corev1.Pod{
+				Spec: corev1.PodSpec{
+					RestartPolicy: c.restartPolicy,
+					Containers:    []corev1.Container{{Name: "app"}, {Name: "consul-dataplane"}},
+				},
+				Status: corev1.PodStatus{Phase: c.phase},
+			}
+			for _, container := range pod.Spec.Containers {
+				if state, ok := c.statuses[container.Name]; ok {
+					pod.Status.ContainerStatuses = append(pod.Status.ContainerStatuses, corev1.ContainerStatus{Name: container.Name, State: state})
+				}
+			}
+			require.Equal(t, c.expected, JobCompleted(pod))
+		})
+	}
+}
@@ -0,0 +1,29 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package common
+
+import (
+	"fmt"
+
+	"k8s.io/apimachinery/pkg/util/version"
+	"k8s.io/client-go/discovery"
+)
+
+// minSleepActionVersion is the first Kubernetes version that enables the Sleep lifecycle handler by
+// default. Kubernetes 1.29 only serves it behind the PodLifecycleSleepAction feature gate.
+var minSleepActionVersion = version.MajorMinor(1, 30)
+
+// SupportsSleepAction returns true if the version of the Kubernetes API server enables the Sleep
+// lifecycle handler by default.
+func SupportsSleepAction(client discovery.DiscoveryInterface) (bool, error) {
+	info, err := client.ServerVersion()
+	if err != nil {
+		return false, fmt.Errorf("unable to get the Kubernetes version: %w", err)
+	}
+	serverVersion, err := version.ParseGeneric(info.GitVersion)
+	if err != nil {
+		return false, fmt.Errorf("unable to parse the Kubernetes version %q: %w", info.GitVersion, err)
+	}
+	return serverVersion.AtLeast(minSleepActionVersion), nil
+}
@@ -0,0 +1,52 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package common
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"k8s.io/apimachinery/pkg/version"
+	fakediscovery "k8s.io/client-go/discovery/fake"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+func TestSupportsSleepAction(t *testing.T) {
+	cases := map[string]struct {
+		gitVersion string
+		expected   bool
+		expErr     string
+	}{
+		"1.29": {
+			gitVersion: "v1.29.4",
+			expected:   false,
+		},
+		"1.30": {
+			gitVersion: "v1.30.0",
+			expected:   true,
+		},
+		"managed provider version": {
+			gitVersion: "v1.31.2-eks-7f9249a",
+			expected:   true,
+		},
+		"invalid version": {
+			gitVersion: "unknown",
+			expErr:     `unable to parse the Kubernetes version "unknown"`,
+		},
+	}
+	for name, tt := range cases {
+		t.Run(name, func(t *testing.T) {
+			discovery := fake.NewSimpleClientset().Discovery().(*fakediscovery.FakeDiscovery)
+			discovery.FakedServerVersion = &version.Info{GitVersion: tt.gitVersion}
+
+			actual, err := SupportsSleepAction(discovery)
+			if tt.expErr != "" {
+				require.ErrorContains(t, err, tt.expErr)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, tt.expected, actual)
+		})
+	}
+}
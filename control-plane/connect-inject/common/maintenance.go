@@ -0,0 +1,77 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package common
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"sync/atomic"
+	"time"
+
+	"github.com/go-logr/logr"
+	corev1 "k8s.io/api/core/v1"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"github.com/hashicorp/consul-k8s/control-plane/connect-inject/constants"
+)
+
+// maintenanceModeRefreshInterval is how often the maintenance mode is re-read from its ConfigMap.
+const maintenanceModeRefreshInterval = 10 * time.Second
+
+// MaintenanceMode reads whether the Consul servers are in a maintenance window from the
+// consul.hashicorp.com/maintenance-mode annotation of a ConfigMap. While it is enabled, the
+// controllers don't change the Consul catalog. If the ConfigMap doesn't exist, it is disabled.
+type MaintenanceMode struct {
+	// Client reads the ConfigMap. It should not be cached.
+	Client client.Reader
+	// Name is the name of the ConfigMap.
+	Name string
+	// Namespace is the namespace of the ConfigMap.
+	Namespace string
+	Log       logr.Logger
+
+	enabled atomic.Bool
+}
+
+// Enabled returns whether maintenance mode is enabled. It is always disabled for a nil MaintenanceMode.
+func (m *MaintenanceMode) Enabled() bool {
+	return m != nil && m.enabled.Load()
+}
+
+// Refresh reads the maintenance mode from the ConfigMap. If the annotation is invalid, the
+// current mode is kept.
+func (m *MaintenanceMode) Refresh(ctx context.Context) error {
+	var configMap corev1.ConfigMap
+	err := m.Client.Get(ctx, types.NamespacedName{Name: m.Name, Namespace: m.Namespace}, &configMap)
+	if err != nil && !k8serrors.IsNotFound(err) {
+		return fmt.Errorf("failed to get maintenance mode ConfigMap %s/%s: %w", m.Namespace, m.Name, err)
+	}
+
+	enabled := false
+	if raw, ok := configMap.Annotations[constants.AnnotationMaintenanceMode]; err == nil && ok {
+		enabled, err = strconv.ParseBool(raw)
+		if err != nil {
+			return fmt.Errorf("maintenance mode ConfigMap %s/%s has invalid annotation %s=%q: %w",
+				m.Namespace, m.Name, constants.AnnotationMaintenanceMode, raw, err)
+		}
+	}
+
+	if m.enabled.Swap(enabled) != enabled {
+		if enabled {
+			m.Log.Info("maintenance mode enabled, pausing changes to the Consul catalog")
+		} else {
+			m.Log.Info("maintenance mode disabled, resuming changes to the Consul catalog")
+		}
+	}
+	return nil
+}
+
+// Poller returns the runnable that refreshes the maintenance mode. It runs on every replica since
+// every replica runs the controllers when sharded.
+func (m *MaintenanceMode) Poller() *ConfigMapPoller {
+	return &ConfigMapPoller{Refresh: m.Refresh, Interval: maintenanceModeRefreshInterval, Log: m.Log}
+}
@@ -0,0 +1,63 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package common
+
+import (
+	"context"
+	"testing"
+
+	logrtest "github.com/go-logr/logr/testr"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	"github.com/hashicorp/consul-k8s/control-plane/connect-inject/constants"
+)
+
+func TestMaintenanceMode(t *testing.T) {
+	configMap := &corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{Name: "connect-inject-config", Namespace: "consul"},
+	}
+	k8sClient := fake.NewClientBuilder().WithObjects(configMap).Build()
+	mode := &MaintenanceMode{
+		Client:    k8sClient,
+		Name:      "connect-inject-config",
+		Namespace: "consul",
+		Log:       logrtest.New(t),
+	}
+
+	// A nil maintenance mode is never enabled.
+	var unset *MaintenanceMode
+	require.False(t, unset.Enabled())
+
+	// Without the annotation maintenance mode is disabled.
+	require.NoError(t, mode.Refresh(context.Background()))
+	require.False(t, mode.Enabled())
+
+	configMap.Annotations = map[string]string{constants.AnnotationMaintenanceMode: "true"}
+	require.NoError(t, k8sClient.Update(context.Background(), configMap))
+	require.NoError(t, mode.Refresh(context.Background()))
+	require.True(t, mode.Enabled())
+
+	// An invalid annotation is rejected and the current mode is kept.
+	configMap.Annotations = map[string]string{constants.AnnotationMaintenanceMode: "maybe"}
+	require.NoError(t, k8sClient.Update(context.Background(), configMap))
+	require.ErrorContains(t, mode.Refresh(context.Background()),
+		`maintenance mode ConfigMap consul/connect-inject-config has invalid annotation consul.hashicorp.com/maintenance-mode="maybe"`)
+	require.True(t, mode.Enabled())
+
+	configMap.Annotations = map[string]string{constants.AnnotationMaintenanceMode: "false"}
+	require.NoError(t, k8sClient.Update(context.Background(), configMap))
+	require.NoError(t, mode.Refresh(context.Background()))
+	require.False(t, mode.Enabled())
+
+	// A missing ConfigMap disables maintenance mode.
+	configMap.Annotations = map[string]string{constants.AnnotationMaintenanceMode: "true"}
+	require.NoError(t, k8sClient.Update(context.Background(), configMap))
+	require.NoError(t, mode.Refresh(context.Background()))
+	require.NoError(t, k8sClient.Delete(context.Background(), configMap))
+	require.NoError(t, mode.Refresh(context.Background()))
+	require.False(t, mode.Enabled())
+}
@@ -0,0 +1,83 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package common
+
+import (
+	"fmt"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/hashicorp/consul-k8s/control-plane/connect-inject/constants"
+)
+
+// NodeNamingStrategy decides which synthetic Consul node the service instances of a pod are registered on.
+type NodeNamingStrategy string
+
+const (
+	// NodeNamingPerNode registers the service instances of the pods of each Kubernetes node on a
+	// Consul node named after it. It is the default.
+	NodeNamingPerNode NodeNamingStrategy = "per-node"
+	// NodeNamingPerCluster registers every service instance on a single Consul node.
+	NodeNamingPerCluster NodeNamingStrategy = "per-cluster"
+	// NodeNamingPerNamespace registers the service instances of the pods of each Kubernetes
+	// namespace on a Consul node named after it.
+	NodeNamingPerNamespace NodeNamingStrategy = "per-namespace"
+
+	// clusterConsulNodeName is the name of the Consul node with the NodeNamingPerCluster strategy.
+	clusterConsulNodeName = "k8s-cluster-virtual"
+)
+
+// ParseNodeNamingStrategy returns the NodeNamingStrategy named s. An empty string is NodeNamingPerNode.
+func ParseNodeNamingStrategy(s string) (NodeNamingStrategy, error) {
+	switch strategy := NodeNamingStrategy(s); strategy {
+	case "":
+		return NodeNamingPerNode, nil
+	case NodeNamingPerNode, NodeNamingPerCluster, NodeNamingPerNamespace:
+		return strategy, nil
+	default:
+		return "", fmt.Errorf("node naming strategy %q must be one of %q, %q or %q", s, NodeNamingPerNode, NodeNamingPerCluster, NodeNamingPerNamespace)
+	}
+}
+
+// ConsulNodeName returns the name of the Consul node the service instances of the pod are registered on.
+func (s NodeNamingStrategy) ConsulNodeName(pod corev1.Pod) string {
+	switch s {
+	case NodeNamingPerCluster:
+		return clusterConsulNodeName
+	case NodeNamingPerNamespace:
+		return fmt.Sprintf("k8s-namespace-%s-virtual", pod.Namespace)
+	default:
+		return ConsulNodeNameFromK8sNode(pod.Spec.NodeName)
+	}
+}
+
+// ConsulNodeNameEnv returns the value of the environment variables that tell connect-init and
+// consul-dataplane the name of the Consul node the service instances of a pod in the namespace are
+// registered on. The per-node name refers to the NODE_NAME environment variable since the node of
+// the pod is not known when it is created.
+func (s NodeNamingStrategy) ConsulNodeNameEnv(namespace string) string {
+	switch s {
+	case NodeNamingPerCluster, NodeNamingPerNamespace:
+		return s.ConsulNodeName(corev1.Pod{ObjectMeta: metav1.ObjectMeta{Namespace: namespace}})
+	default:
+		return "$(NODE_NAME)-virtual"
+	}
+}
+
+// PodNodeNamingStrategy returns the node naming strategy the pod was created with. The service instances
+// of a pod must stay on the node its consul-dataplane was configured with, so pods that were created
+// before the strategy changed keep the strategy of their annotation until they are recreated.
+func PodNodeNamingStrategy(pod corev1.Pod) NodeNamingStrategy {
+	strategy, err := ParseNodeNamingStrategy(pod.Annotations[constants.AnnotationNodeNamingStrategy])
+	if err != nil {
+		return NodeNamingPerNode
+	}
+	return strategy
+}
+
+// PodConsulNodeName returns the name of the Consul node the service instances of the pod are registered on.
+func PodConsulNodeName(pod corev1.Pod) string {
+	return PodNodeNamingStrategy(pod).ConsulNodeName(pod)
+}
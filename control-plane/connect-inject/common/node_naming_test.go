@@ -0,0 +1,107 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package common
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/hashicorp/consul-k8s/control-plane/connect-inject/constants"
+)
+
+func TestParseNodeNamingStrategy(t *testing.T) {
+	cases := map[string]struct {
+		value       string
+		expStrategy NodeNamingStrategy
+		expErr      string
+	}{
+		"empty": {
+			value:       "",
+			expStrategy: NodeNamingPerNode,
+		},
+		"per-node": {
+			value:       "per-node",
+			expStrategy: NodeNamingPerNode,
+		},
+		"per-cluster": {
+			value:       "per-cluster",
+			expStrategy: NodeNamingPerCluster,
+		},
+		"per-namespace": {
+			value:       "per-namespace",
+			expStrategy: NodeNamingPerNamespace,
+		},
+		"invalid": {
+			value:  "per-pod",
+			expErr: `node naming strategy "per-pod" must be one of "per-node", "per-cluster" or "per-namespace"`,
+		},
+	}
+	for name, c := range cases {
+		t.Run(name, func(t *testing.T) {
+			strategy, err := ParseNodeNamingStrategy(c.value)
+			if c.expErr != "" {
+				require.EqualError(t, err, c.expErr)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, c.expStrategy, strategy)
+		})
+	}
+}
+
+func TestNodeNamingStrategy_ConsulNodeName(t *testing.T) {
+	pod := corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{Name: "web", Namespace: "apps"},
+		Spec:       corev1.PodSpec{NodeName: "worker-1"},
+	}
+	require.Equal(t, "worker-1-virtual", NodeNamingStrategy("").ConsulNodeName(pod))
+	require.Equal(t, "worker-1-virtual", NodeNamingPerNode.ConsulNodeName(pod))
+	require.Equal(t, "k8s-cluster-virtual", NodeNamingPerCluster.ConsulNodeName(pod))
+	require.Equal(t, "k8s-namespace-apps-virtual", NodeNamingPerNamespace.ConsulNodeName(pod))
+}
+
+func TestNodeNamingStrategy_ConsulNodeNameEnv(t *testing.T) {
+	require.Equal(t, "$(NODE_NAME)-virtual", NodeNamingStrategy("").ConsulNodeNameEnv("apps"))
+	require.Equal(t, "$(NODE_NAME)-virtual", NodeNamingPerNode.ConsulNodeNameEnv("apps"))
+	require.Equal(t, "k8s-cluster-virtual", NodeNamingPerCluster.ConsulNodeNameEnv("apps"))
+	require.Equal(t, "k8s-namespace-apps-virtual", NodeNamingPerNamespace.ConsulNodeNameEnv("apps"))
+}
+
+func TestPodConsulNodeName(t *testing.T) {
+	cases := map[string]struct {
+		annotation string
+		expNode    string
+	}{
+		"no annotation": {
+			expNode: "worker-1-virtual",
+		},
+		"per-namespace": {
+			annotation: "per-namespace",
+			expNode:    "k8s-namespace-apps-virtual",
+		},
+		"per-cluster": {
+			annotation: "per-cluster",
+			expNode:    "k8s-cluster-virtual",
+		},
+		"invalid": {
+			annotation: "per-pod",
+			expNode:    "worker-1-virtual",
+		},
+	}
+	for name, c := range cases {
+		t.Run(name, func(t *testing.T) {
+			pod := corev1.Pod{
+				ObjectMeta: metav1.ObjectMeta{Name: "web", Namespace: "apps", Annotations: map[string]string{}},
+				Spec:       corev1.PodSpec{NodeName: "worker-1"},
+			}
+			if c.annotation != "" {
+				pod.Annotations[constants.AnnotationNodeNamingStrategy] = c.annotation
+			}
+			require.Equal(t, c.expNode, PodConsulNodeName(pod))
+		})
+	}
+}
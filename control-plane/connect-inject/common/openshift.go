@@ -19,10 +19,29 @@ import (
 	"golang.org/x/exp/maps"
 	"golang.org/x/exp/slices"
 	corev1 "k8s.io/api/core/v1"
+	"k8s.io/client-go/discovery"
 
 	"github.com/hashicorp/consul-k8s/control-plane/connect-inject/constants"
 )
 
+// openShiftSecurityAPIGroup is the API group that serves SecurityContextConstraints. It is only
+// served by OpenShift clusters.
+const openShiftSecurityAPIGroup = "security.openshift.io"
+
+// IsOpenShift returns true if the cluster serves the OpenShift security API group.
+func IsOpenShift(client discovery.DiscoveryInterface) (bool, error) {
+	groups, err := client.ServerGroups()
+	if err != nil {
+		return false, fmt.Errorf("unable to list API groups: %w", err)
+	}
+	for _, group := range groups.Groups {
+		if group.Name == openShiftSecurityAPIGroup {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
 // GetDataplaneUID returns the UID to use for the Dataplane container in the given namespace.
 // The UID is based on the namespace annotation and avoids conflicting with any application container UIDs.
 // Containers with dataplaneImage and k8sImage are not considered application containers.
@@ -10,6 +10,8 @@ import (
 	"github.com/stretchr/testify/require"
 	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	fakediscovery "k8s.io/client-go/discovery/fake"
+	"k8s.io/client-go/kubernetes/fake"
 	"k8s.io/utils/ptr"
 )
 
@@ -480,3 +482,35 @@ func TestGetIDsInRange(t *testing.T) {
 		})
 	}
 }
+
+func TestIsOpenShift(t *testing.T) {
+	cases := map[string]struct {
+		resources []*metav1.APIResourceList
+		expected  bool
+	}{
+		"kubernetes": {
+			resources: []*metav1.APIResourceList{
+				{GroupVersion: "apps/v1"},
+			},
+			expected: false,
+		},
+		"openshift": {
+			resources: []*metav1.APIResourceList{
+				{GroupVersion: "apps/v1"},
+				{GroupVersion: "security.openshift.io/v1"},
+			},
+			expected: true,
+		},
+	}
+	for name, tt := range cases {
+		t.Run(name, func(t *testing.T) {
+			client := fake.NewSimpleClientset()
+			discovery := client.Discovery().(*fakediscovery.FakeDiscovery)
+			discovery.Resources = tt.resources
+
+			actual, err := IsOpenShift(discovery)
+			require.NoError(t, err)
+			require.Equal(t, tt.expected, actual)
+		})
+	}
+}
@@ -0,0 +1,176 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package common
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+
+	"github.com/go-logr/logr"
+	"github.com/hashicorp/consul/api"
+	"github.com/hashicorp/go-multierror"
+
+	"github.com/hashicorp/consul-k8s/control-plane/consul"
+	"github.com/hashicorp/consul-k8s/control-plane/namespaces"
+)
+
+// PartitionAuthMethods copies the auth methods that injected pods log in with, and their binding
+// rules, from the Admin Partition of the installation to every partition of a PartitionMapping, so
+// that pods in mapped namespaces can log in to their partition. The copies are updated when the
+// auth method changes. Binding rules are only added, and rules that bind to roles or policies are
+// not copied since roles and policies belong to a single partition.
+type PartitionAuthMethods struct {
+	// ConsulClientConfig is the config of the Consul API client of the installation's partition.
+	ConsulClientConfig  *consul.Config
+	ConsulServerConnMgr consul.ServerConnectionManager
+	// AuthMethods are the names of the auth methods to copy.
+	AuthMethods []string
+	// Namespace is the Consul namespace of the auth methods. It is empty if namespaces are disabled.
+	Namespace string
+	// CrossNamespaceACLPolicy is the policy added to Namespace if it is created in a partition.
+	CrossNamespaceACLPolicy string
+	Mapping                 *PartitionMapping
+	Log                     logr.Logger
+}
+
+// Refresh copies the auth methods and their binding rules to the mapped partitions.
+func (a *PartitionAuthMethods) Refresh(_ context.Context) error {
+	serverState, err := a.ConsulServerConnMgr.State()
+	if err != nil {
+		return fmt.Errorf("failed to get Consul server state: %w", err)
+	}
+	source, err := consul.NewClientFromConnMgrState(a.ConsulClientConfig, serverState)
+	if err != nil {
+		return fmt.Errorf("failed to create Consul API client: %w", err)
+	}
+
+	var errs error
+	for _, name := range a.AuthMethods {
+		queryOpts := &api.QueryOptions{Namespace: a.Namespace}
+		authMethod, _, err := source.ACL().AuthMethodRead(name, queryOpts)
+		if err != nil {
+			errs = multierror.Append(errs, fmt.Errorf("failed to read auth method %q: %w", name, err))
+			continue
+		}
+		if authMethod == nil {
+			errs = multierror.Append(errs, fmt.Errorf("auth method %q not found", name))
+			continue
+		}
+		rules, _, err := source.ACL().BindingRuleList(name, queryOpts)
+		if err != nil {
+			errs = multierror.Append(errs, fmt.Errorf("failed to list binding rules of auth method %q: %w", name, err))
+			continue
+		}
+
+		for _, partition := range a.Mapping.Partitions() {
+			if partition == a.ConsulClientConfig.APIClientConfig.Partition {
+				continue
+			}
+			apiClient, err := consul.NewClientFromConnMgrState(a.consulClientConfigForPartition(partition), serverState)
+			if err != nil {
+				errs = multierror.Append(errs, fmt.Errorf("failed to create Consul API client for partition %q: %w", partition, err))
+				continue
+			}
+			if err := a.copyAuthMethod(apiClient, partition, authMethod, rules); err != nil {
+				errs = multierror.Append(errs, fmt.Errorf("failed to copy auth method %q to partition %q: %w", name, partition, err))
+			}
+		}
+	}
+	return errs
+}
+
+// copyAuthMethod creates or updates the auth method in the partition, and creates its binding rules
+// that don't exist there yet.
+func (a *PartitionAuthMethods) copyAuthMethod(apiClient *api.Client, partition string, authMethod *api.ACLAuthMethod, rules []*api.ACLBindingRule) error {
+	if a.Namespace != "" {
+		if _, err := namespaces.EnsureExists(apiClient, a.Namespace, a.CrossNamespaceACLPolicy); err != nil {
+			return fmt.Errorf("failed to create namespace %q: %w", a.Namespace, err)
+		}
+	}
+
+	queryOpts := &api.QueryOptions{Namespace: a.Namespace}
+	writeOpts := &api.WriteOptions{Namespace: a.Namespace}
+	existing, _, err := apiClient.ACL().AuthMethodRead(authMethod.Name, queryOpts)
+	if err != nil {
+		return err
+	}
+	copied := *authMethod
+	copied.Partition = partition
+	copied.CreateIndex, copied.ModifyIndex = 0, 0
+	switch {
+	case existing == nil:
+		if _, _, err := apiClient.ACL().AuthMethodCreate(&copied, writeOpts); err != nil {
+			return err
+		}
+		a.Log.Info("created auth method in partition", "name", authMethod.Name, "partition", partition)
+	case authMethodChanged(existing, authMethod):
+		if _, _, err := apiClient.ACL().AuthMethodUpdate(&copied, writeOpts); err != nil {
+			return err
+		}
+		a.Log.Info("updated auth method in partition", "name", authMethod.Name, "partition", partition)
+	}
+
+	existingRules, _, err := apiClient.ACL().BindingRuleList(authMethod.Name, queryOpts)
+	if err != nil {
+		return err
+	}
+	for _, rule := range rules {
+		if rule.BindType == api.BindingRuleBindTypeRole || rule.BindType == api.BindingRuleBindTypePolicy {
+			continue
+		}
+		if containsBindingRule(existingRules, rule) {
+			continue
+		}
+		copiedRule := *rule
+		copiedRule.ID = ""
+		copiedRule.Partition = partition
+		copiedRule.CreateIndex, copiedRule.ModifyIndex = 0, 0
+		if _, _, err := apiClient.ACL().BindingRuleCreate(&copiedRule, writeOpts); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (a *PartitionAuthMethods) consulClientConfigForPartition(partition string) *consul.Config {
+	cfg := *a.ConsulClientConfig
+	apiClientConfig := *cfg.APIClientConfig
+	apiClientConfig.Partition = partition
+	cfg.APIClientConfig = &apiClientConfig
+	return &cfg
+}
+
+// Poller returns the runnable that copies the auth methods. It only runs on the leader since it
+// writes to Consul, and also copies them when the leader starts since the mapping has been read by then.
+func (a *PartitionAuthMethods) Poller() *ConfigMapPoller {
+	return &ConfigMapPoller{
+		Refresh:        a.Refresh,
+		Interval:       partitionMappingRefreshInterval,
+		RefreshOnStart: true,
+		LeaderElection: true,
+		Log:            a.Log,
+	}
+}
+
+// authMethodChanged returns whether the copy of the auth method differs from the auth method.
+func authMethodChanged(copied, authMethod *api.ACLAuthMethod) bool {
+	return copied.Type != authMethod.Type ||
+		copied.DisplayName != authMethod.DisplayName ||
+		copied.Description != authMethod.Description ||
+		copied.MaxTokenTTL != authMethod.MaxTokenTTL ||
+		copied.TokenLocality != authMethod.TokenLocality ||
+		!reflect.DeepEqual(copied.Config, authMethod.Config) ||
+		!reflect.DeepEqual(copied.NamespaceRules, authMethod.NamespaceRules)
+}
+
+// containsBindingRule returns whether one of the rules binds the same way as the rule.
+func containsBindingRule(rules []*api.ACLBindingRule, rule *api.ACLBindingRule) bool {
+	for _, r := range rules {
+		if r.Selector == rule.Selector && r.BindType == rule.BindType && r.BindName == rule.BindName {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,135 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package common
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+
+	logrtest "github.com/go-logr/logr/testr"
+	"github.com/hashicorp/consul/api"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	"github.com/hashicorp/consul-k8s/control-plane/consul"
+	"github.com/hashicorp/consul-k8s/control-plane/helper/test"
+)
+
+func TestPartitionAuthMethods(t *testing.T) {
+	var (
+		mu          sync.Mutex
+		authMethods = map[string]*api.ACLAuthMethod{
+			"default": {
+				Name: "consul-k8s-auth-method",
+				Type: "kubernetes",
+				Config: map[string]interface{}{
+					"Host": "https://kubernetes.default.svc",
+				},
+				CreateIndex: 10,
+				ModifyIndex: 10,
+			},
+		}
+		bindingRules = map[string][]*api.ACLBindingRule{
+			"default": {
+				{ID: "1", AuthMethod: "consul-k8s-auth-method", BindType: api.BindingRuleBindTypeService, BindName: "${serviceaccount.name}"},
+				{ID: "2", AuthMethod: "consul-k8s-auth-method", BindType: api.BindingRuleBindTypeRole, BindName: "admin"},
+			},
+		}
+		ruleWrites int
+	)
+	consulServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+		partition := r.URL.Query().Get("partition")
+		switch {
+		case r.URL.Path == "/v1/acl/auth-method/consul-k8s-auth-method" && r.Method == http.MethodGet:
+			authMethod, ok := authMethods[partition]
+			if !ok {
+				w.WriteHeader(http.StatusNotFound)
+				return
+			}
+			require.NoError(t, json.NewEncoder(w).Encode(authMethod))
+		case strings.HasPrefix(r.URL.Path, "/v1/acl/auth-method") && r.Method == http.MethodPut:
+			var authMethod api.ACLAuthMethod
+			require.NoError(t, json.NewDecoder(r.Body).Decode(&authMethod))
+			require.Equal(t, partition, authMethod.Partition)
+			require.Zero(t, authMethod.CreateIndex)
+			authMethods[partition] = &authMethod
+			require.NoError(t, json.NewEncoder(w).Encode(authMethod))
+		case r.URL.Path == "/v1/acl/binding-rules" && r.Method == http.MethodGet:
+			require.Equal(t, "consul-k8s-auth-method", r.URL.Query().Get("authmethod"))
+			require.NoError(t, json.NewEncoder(w).Encode(bindingRules[partition]))
+		case r.URL.Path == "/v1/acl/binding-rule" && r.Method == http.MethodPut:
+			var rule api.ACLBindingRule
+			require.NoError(t, json.NewDecoder(r.Body).Decode(&rule))
+			require.Empty(t, rule.ID)
+			require.Equal(t, partition, rule.Partition)
+			ruleWrites++
+			bindingRules[partition] = append(bindingRules[partition], &rule)
+			require.NoError(t, json.NewEncoder(w).Encode(rule))
+		default:
+			t.Errorf("unexpected request %s %s", r.Method, r.URL.Path)
+		}
+	}))
+	defer consulServer.Close()
+
+	serverURL, err := url.Parse(consulServer.URL)
+	require.NoError(t, err)
+	port, err := strconv.Atoi(serverURL.Port())
+	require.NoError(t, err)
+
+	k8sClient := fake.NewClientBuilder().WithObjects(&corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{Name: "partition-mapping", Namespace: "consul"},
+		Data:       map[string]string{"team-a": "ap1"},
+	}).Build()
+	mapping := &PartitionMapping{
+		Client:           k8sClient,
+		Name:             "partition-mapping",
+		Namespace:        "consul",
+		DefaultPartition: "default",
+		Log:              logrtest.New(t),
+	}
+	require.NoError(t, mapping.Refresh(context.Background()))
+
+	partitionAuthMethods := &PartitionAuthMethods{
+		ConsulClientConfig: &consul.Config{
+			APIClientConfig: &api.Config{Partition: "default"},
+			HTTPPort:        port,
+		},
+		ConsulServerConnMgr: test.MockConnMgrForIPAndPort(t, serverURL.Hostname(), port, false),
+		AuthMethods:         []string{"consul-k8s-auth-method"},
+		Mapping:             mapping,
+		Log:                 logrtest.New(t),
+	}
+
+	// The auth method and the binding rules that don't bind to roles are copied to the mapped partition.
+	require.NoError(t, partitionAuthMethods.Refresh(context.Background()))
+	mu.Lock()
+	require.Equal(t, authMethods["default"].Config, authMethods["ap1"].Config)
+	require.Len(t, bindingRules["ap1"], 1)
+	require.Equal(t, api.BindingRuleBindTypeService, bindingRules["ap1"][0].BindType)
+	require.Equal(t, "${serviceaccount.name}", bindingRules["ap1"][0].BindName)
+	mu.Unlock()
+
+	// Copies are only written again when the auth method changes.
+	require.NoError(t, partitionAuthMethods.Refresh(context.Background()))
+	mu.Lock()
+	require.Equal(t, 1, ruleWrites)
+	authMethods["default"].Config = map[string]interface{}{"Host": "https://kubernetes.example.com"}
+	mu.Unlock()
+	require.NoError(t, partitionAuthMethods.Refresh(context.Background()))
+	mu.Lock()
+	defer mu.Unlock()
+	require.Equal(t, "https://kubernetes.example.com", authMethods["ap1"].Config["Host"])
+	require.Equal(t, 1, ruleWrites)
+}
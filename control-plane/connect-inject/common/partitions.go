@@ -0,0 +1,157 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package common
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/go-logr/logr"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/validation"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// partitionMappingRefreshInterval is how often the partition mapping is re-read from its ConfigMap.
+const partitionMappingRefreshInterval = 30 * time.Second
+
+// PartitionMapping resolves the Consul Admin Partition of a Kubernetes namespace from a ConfigMap.
+// Every key of the ConfigMap data is the name of a Kubernetes namespace and its value is the name of
+// the Admin Partition that services in the namespace are registered in. Namespaces that are not in the
+// ConfigMap use DefaultPartition.
+type PartitionMapping struct {
+	// Client reads the ConfigMap. It should not be cached.
+	Client client.Reader
+	// Name is the name of the ConfigMap.
+	Name string
+	// Namespace is the namespace of the ConfigMap.
+	Namespace string
+	// DefaultPartition is the partition of Kubernetes namespaces that are not mapped.
+	DefaultPartition string
+	Log              logr.Logger
+
+	mu       sync.RWMutex
+	mappings map[string]string
+	// previous are the partitions each Kubernetes namespace was mapped to before the mapping changed,
+	// other than its current partition, since the mapping was first read.
+	previous map[string]map[string]struct{}
+}
+
+// Partition returns the Consul Admin Partition of the Kubernetes namespace.
+func (p *PartitionMapping) Partition(k8sNamespace string) string {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	if partition, ok := p.mappings[k8sNamespace]; ok {
+		return partition
+	}
+	return p.DefaultPartition
+}
+
+// Partitions returns the default partition and every partition that a namespace is, or was, mapped to, sorted.
+func (p *PartitionMapping) Partitions() []string {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	partitions := []string{p.DefaultPartition}
+	for _, partition := range p.mappings {
+		if !slices.Contains(partitions, partition) {
+			partitions = append(partitions, partition)
+		}
+	}
+	for _, previous := range p.previous {
+		for partition := range previous {
+			if !slices.Contains(partitions, partition) {
+				partitions = append(partitions, partition)
+			}
+		}
+	}
+	slices.Sort(partitions)
+	return partitions
+}
+
+// PreviousPartitions returns the partitions the Kubernetes namespace was mapped to before the mapping
+// changed, sorted, so that the services registered in them can be deregistered. It doesn't include
+// the current partition of the namespace.
+func (p *PartitionMapping) PreviousPartitions(k8sNamespace string) []string {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	var partitions []string
+	for partition := range p.previous[k8sNamespace] {
+		partitions = append(partitions, partition)
+	}
+	slices.Sort(partitions)
+	return partitions
+}
+
+// Refresh reads the mapping from the ConfigMap. If the ConfigMap is invalid, the previous mapping is kept.
+func (p *PartitionMapping) Refresh(ctx context.Context) error {
+	var configMap corev1.ConfigMap
+	if err := p.Client.Get(ctx, types.NamespacedName{Name: p.Name, Namespace: p.Namespace}, &configMap); err != nil {
+		return fmt.Errorf("failed to get partition mapping ConfigMap %s/%s: %w", p.Namespace, p.Name, err)
+	}
+
+	mappings := make(map[string]string, len(configMap.Data))
+	for k8sNamespace, partition := range configMap.Data {
+		if errs := validation.IsDNS1123Label(partition); len(errs) > 0 {
+			return fmt.Errorf("partition mapping ConfigMap %s/%s has invalid partition %q for namespace %q: %s",
+				p.Namespace, p.Name, partition, k8sNamespace, errs[0])
+		}
+		mappings[k8sNamespace] = partition
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	// Record the partitions of the namespaces whose partition changed. The first mapping read has
+	// nothing to compare to.
+	if p.mappings != nil {
+		if p.previous == nil {
+			p.previous = make(map[string]map[string]struct{})
+		}
+		for _, k8sNamespace := range changedNamespaces(p.mappings, mappings) {
+			old, current := p.DefaultPartition, p.DefaultPartition
+			if partition, ok := p.mappings[k8sNamespace]; ok {
+				old = partition
+			}
+			if partition, ok := mappings[k8sNamespace]; ok {
+				current = partition
+			}
+			if old == current {
+				continue
+			}
+			if p.previous[k8sNamespace] == nil {
+				p.previous[k8sNamespace] = make(map[string]struct{})
+			}
+			p.previous[k8sNamespace][old] = struct{}{}
+			delete(p.previous[k8sNamespace], current)
+			p.Log.Info("partition of namespace changed", "ns", k8sNamespace, "from", old, "to", current)
+		}
+	}
+	p.mappings = mappings
+	return nil
+}
+
+// changedNamespaces returns the namespaces whose partition differs between the two mappings.
+func changedNamespaces(old, current map[string]string) []string {
+	var changed []string
+	for k8sNamespace, partition := range old {
+		if currentPartition, ok := current[k8sNamespace]; !ok || currentPartition != partition {
+			changed = append(changed, k8sNamespace)
+		}
+	}
+	for k8sNamespace := range current {
+		if _, ok := old[k8sNamespace]; !ok {
+			changed = append(changed, k8sNamespace)
+		}
+	}
+	return changed
+}
+
+// Poller returns the runnable that refreshes the mapping. It runs on every replica since every
+// replica serves the webhook.
+func (p *PartitionMapping) Poller() *ConfigMapPoller {
+	return &ConfigMapPoller{Refresh: p.Refresh, Interval: partitionMappingRefreshInterval, Log: p.Log}
+}
@@ -0,0 +1,75 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package common
+
+import (
+	"context"
+	"testing"
+
+	logrtest "github.com/go-logr/logr/testr"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+func TestPartitionMapping(t *testing.T) {
+	configMap := &corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{Name: "partition-mapping", Namespace: "consul"},
+		Data: map[string]string{
+			"team-a": "ap1",
+			"team-b": "ap2",
+		},
+	}
+	k8sClient := fake.NewClientBuilder().WithObjects(configMap).Build()
+	mapping := &PartitionMapping{
+		Client:           k8sClient,
+		Name:             "partition-mapping",
+		Namespace:        "consul",
+		DefaultPartition: "default",
+		Log:              logrtest.New(t),
+	}
+
+	// Before the mapping is read, all namespaces use the default partition.
+	require.Equal(t, "default", mapping.Partition("team-a"))
+
+	require.NoError(t, mapping.Refresh(context.Background()))
+	require.Equal(t, "ap1", mapping.Partition("team-a"))
+	require.Equal(t, "ap2", mapping.Partition("team-b"))
+	require.Equal(t, "default", mapping.Partition("team-c"))
+	require.Equal(t, []string{"ap1", "ap2", "default"}, mapping.Partitions())
+	require.Empty(t, mapping.PreviousPartitions("team-a"))
+
+	// An invalid mapping is rejected and the previous mapping is kept.
+	configMap.Data = map[string]string{"team-a": "AP_1"}
+	require.NoError(t, k8sClient.Update(context.Background(), configMap))
+	err := mapping.Refresh(context.Background())
+	require.ErrorContains(t, err, `partition mapping ConfigMap consul/partition-mapping has invalid partition "AP_1" for namespace "team-a"`)
+	require.Equal(t, "ap1", mapping.Partition("team-a"))
+	require.Equal(t, "ap2", mapping.Partition("team-b"))
+
+	// Namespaces removed from the mapping use the default partition again.
+	configMap.Data = map[string]string{"team-b": "ap3"}
+	require.NoError(t, k8sClient.Update(context.Background(), configMap))
+	require.NoError(t, mapping.Refresh(context.Background()))
+	require.Equal(t, "default", mapping.Partition("team-a"))
+	require.Equal(t, "ap3", mapping.Partition("team-b"))
+
+	// The partitions namespaces were mapped to before are kept so that their services can be deregistered.
+	require.Equal(t, []string{"ap1"}, mapping.PreviousPartitions("team-a"))
+	require.Equal(t, []string{"ap2"}, mapping.PreviousPartitions("team-b"))
+	require.Empty(t, mapping.PreviousPartitions("team-c"))
+	require.Equal(t, []string{"ap1", "ap2", "ap3", "default"}, mapping.Partitions())
+
+	// Mapping a namespace back to a previous partition forgets it.
+	configMap.Data = map[string]string{"team-a": "ap1", "team-b": "ap3"}
+	require.NoError(t, k8sClient.Update(context.Background(), configMap))
+	require.NoError(t, mapping.Refresh(context.Background()))
+	require.Equal(t, []string{"default"}, mapping.PreviousPartitions("team-a"))
+	require.Equal(t, []string{"ap2"}, mapping.PreviousPartitions("team-b"))
+
+	// A missing ConfigMap is an error.
+	require.NoError(t, k8sClient.Delete(context.Background(), configMap))
+	require.ErrorContains(t, mapping.Refresh(context.Background()), "failed to get partition mapping ConfigMap consul/partition-mapping")
+}
@@ -0,0 +1,346 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package common
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/go-logr/logr"
+	"github.com/prometheus/client_golang/prometheus"
+	corev1 "k8s.io/api/core/v1"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/util/retry"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/event"
+	ctrlmetrics "sigs.k8s.io/controller-runtime/pkg/metrics"
+	"sigs.k8s.io/controller-runtime/pkg/source"
+)
+
+// pendingRegistrationsFlushInterval is how often the queue checks whether the Consul servers are
+// reachable again.
+const pendingRegistrationsFlushInterval = time.Second
+
+// pendingRegistrationsTotal is the number of Endpoints in the queue of this replica.
+var pendingRegistrationsTotal = prometheus.NewGauge(prometheus.GaugeOpts{
+	Name: "consul_k8s_endpoints_pending_registrations",
+	Help: "Number of Endpoints whose registrations are queued until the Consul servers are reachable again.",
+})
+
+func init() {
+	ctrlmetrics.Registry.MustRegister(pendingRegistrationsTotal)
+}
+
+// PendingRegistrations is a durable queue of the Endpoints that couldn't be reconciled because the
+// Consul servers were unreachable. The queue is stored in a ConfigMap, with a key per Endpoints
+// object, so that it survives restarts of the injector. The Endpoints are reconciled as soon as the
+// servers are reachable again rather than after their backoff, which bounds how long pods stay
+// unroutable after an outage.
+//
+// When the endpoints controller is sharded, every replica shares the ConfigMap but only queues the
+// Endpoints of the namespaces it owns. The Endpoints of the namespaces a replica gains are reconciled
+// by the endpoints controller when its shard changes.
+//
+// The keys of Endpoints that no longer exist are pruned from the ConfigMap when it is loaded and when
+// the queue is flushed. Such Endpoints are still enqueued once so that their service instances are
+// deregistered.
+//
+// Registrations are only queued: they aren't written to another datacenter while the Consul servers
+// are unreachable, since the service instances of the pods belong to the catalog of the local datacenter.
+//
+// PendingRegistrations implements manager.Runnable and runs on every replica.
+type PendingRegistrations struct {
+	// Reader reads the ConfigMap and the queued Endpoints. It should not be cached.
+	Reader client.Reader
+	// Writer creates and updates the ConfigMap.
+	Writer client.Writer
+	// Name is the name of the ConfigMap.
+	Name string
+	// Namespace is the namespace of the ConfigMap.
+	Namespace string
+	// Reachable returns whether requests are sent to the Consul servers.
+	Reachable func() bool
+	// Owns, if set, returns whether the Endpoints of the namespace are reconciled by this replica.
+	Owns func(namespace string) bool
+	Log  logr.Logger
+
+	once   sync.Once
+	events chan event.GenericEvent
+
+	mu sync.Mutex
+	// pending are the Endpoints in the queue and when they were added.
+	pending map[types.NamespacedName]time.Time
+	// flushed is whether the pending Endpoints were enqueued since the last one was added.
+	flushed bool
+}
+
+// Add queues the Endpoints until the Consul servers are reachable. It does nothing for a nil
+// PendingRegistrations.
+func (p *PendingRegistrations) Add(ctx context.Context, name types.NamespacedName) {
+	if p == nil {
+		return
+	}
+	p.init()
+	now := time.Now()
+	p.mu.Lock()
+	p.flushed = false
+	_, queued := p.pending[name]
+	if !queued {
+		p.pending[name] = now
+	}
+	pendingRegistrationsTotal.Set(float64(len(p.pending)))
+	p.mu.Unlock()
+	if queued {
+		return
+	}
+
+	err := p.update(ctx, func(data map[string]string) {
+		data[pendingRegistrationKey(name)] = now.UTC().Format(time.RFC3339)
+	})
+	if err != nil {
+		p.Log.Error(err, "failed to persist pending registration", "name", name.Name, "ns", name.Namespace)
+	}
+}
+
+// Done removes the Endpoints from the queue once they were reconciled. It does nothing for a nil
+// PendingRegistrations.
+func (p *PendingRegistrations) Done(ctx context.Context, name types.NamespacedName) {
+	if p == nil {
+		return
+	}
+	p.init()
+	p.mu.Lock()
+	queuedAt, queued := p.pending[name]
+	delete(p.pending, name)
+	pendingRegistrationsTotal.Set(float64(len(p.pending)))
+	p.mu.Unlock()
+	if !queued {
+		return
+	}
+
+	p.Log.Info("pending registration reconciled", "name", name.Name, "ns", name.Namespace, "pendingFor", time.Since(queuedAt).Round(time.Second))
+	err := p.update(ctx, func(data map[string]string) {
+		delete(data, pendingRegistrationKey(name))
+	})
+	if err != nil {
+		p.Log.Error(err, "failed to remove pending registration", "name", name.Name, "ns", name.Namespace)
+	}
+}
+
+// Len returns the number of Endpoints in the queue.
+func (p *PendingRegistrations) Len() int {
+	p.init()
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return len(p.pending)
+}
+
+// Source returns a source of events for the Endpoints in the queue, sent once the Consul servers
+// are reachable again.
+func (p *PendingRegistrations) Source() source.Source {
+	p.init()
+	return &source.Channel{Source: p.events}
+}
+
+// Start loads the queue left by a previous run from the ConfigMap and enqueues the pending
+// Endpoints whenever the Consul servers are reachable, until ctx is cancelled.
+func (p *PendingRegistrations) Start(ctx context.Context) error {
+	p.init()
+	if err := p.load(ctx); err != nil {
+		p.Log.Error(err, "failed to load pending registrations")
+	}
+	poller := &ConfigMapPoller{
+		Refresh: func(ctx context.Context) error {
+			p.flush(ctx)
+			return nil
+		},
+		Interval:       pendingRegistrationsFlushInterval,
+		RefreshOnStart: true,
+		Log:            p.Log,
+	}
+	return poller.Start(ctx)
+}
+
+// NeedLeaderElection implements manager.LeaderElectionRunnable so that the queue is flushed on all
+// replicas, since every replica runs the controllers when sharded.
+func (p *PendingRegistrations) NeedLeaderElection() bool {
+	return false
+}
+
+func (p *PendingRegistrations) init() {
+	p.once.Do(func() {
+		p.events = make(chan event.GenericEvent)
+		p.mu.Lock()
+		p.pending = make(map[types.NamespacedName]time.Time)
+		p.mu.Unlock()
+	})
+}
+
+// load adds the Endpoints of the owned namespaces stored in the ConfigMap to the queue.
+func (p *PendingRegistrations) load(ctx context.Context) error {
+	var configMap corev1.ConfigMap
+	err := p.Reader.Get(ctx, types.NamespacedName{Name: p.Name, Namespace: p.Namespace}, &configMap)
+	if k8serrors.IsNotFound(err) {
+		return nil
+	} else if err != nil {
+		return fmt.Errorf("failed to get pending registrations ConfigMap %s/%s: %w", p.Namespace, p.Name, err)
+	}
+
+	p.mu.Lock()
+	var loaded []types.NamespacedName
+	for key, raw := range configMap.Data {
+		name, ok := parsePendingRegistrationKey(key)
+		if !ok {
+			p.Log.Info("ignoring invalid pending registration", "key", key)
+			continue
+		}
+		if !p.owns(name.Namespace) {
+			continue
+		}
+		queuedAt, err := time.Parse(time.RFC3339, raw)
+		if err != nil {
+			queuedAt = time.Now()
+		}
+		if _, queued := p.pending[name]; !queued {
+			p.pending[name] = queuedAt
+		}
+		loaded = append(loaded, name)
+	}
+	if len(p.pending) > 0 {
+		p.Log.Info("loaded pending registrations", "count", len(p.pending))
+		p.flushed = false
+	}
+	pendingRegistrationsTotal.Set(float64(len(p.pending)))
+	p.mu.Unlock()
+
+	// The Endpoints that no longer exist stay in the queue so that they are enqueued once.
+	_, err = p.prune(ctx, loaded)
+	return err
+}
+
+// flush enqueues the pending Endpoints if the Consul servers are reachable and they weren't
+// enqueued since the last one was added. The Endpoints of namespaces that are no longer owned are
+// dropped from the queue but kept in the ConfigMap. The Endpoints that no longer exist are enqueued
+// and dropped from the queue and the ConfigMap.
+func (p *PendingRegistrations) flush(ctx context.Context) {
+	p.mu.Lock()
+	for name := range p.pending {
+		if !p.owns(name.Namespace) {
+			delete(p.pending, name)
+		}
+	}
+	pendingRegistrationsTotal.Set(float64(len(p.pending)))
+	p.mu.Unlock()
+
+	if !p.Reachable() {
+		return
+	}
+	p.mu.Lock()
+	if p.flushed || len(p.pending) == 0 {
+		p.mu.Unlock()
+		return
+	}
+	p.flushed = true
+	names := make([]types.NamespacedName, 0, len(p.pending))
+	for name := range p.pending {
+		names = append(names, name)
+	}
+	p.mu.Unlock()
+
+	deleted, err := p.prune(ctx, names)
+	if err != nil {
+		p.Log.Error(err, "failed to prune pending registrations")
+	}
+	p.mu.Lock()
+	for _, name := range deleted {
+		delete(p.pending, name)
+	}
+	pendingRegistrationsTotal.Set(float64(len(p.pending)))
+	p.mu.Unlock()
+
+	p.Log.Info("Consul servers are reachable, reconciling pending registrations", "count", len(names))
+	for _, name := range names {
+		select {
+		case p.events <- event.GenericEvent{Object: &corev1.Endpoints{ObjectMeta: metav1.ObjectMeta{Name: name.Name, Namespace: name.Namespace}}}:
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+// prune removes the keys of the Endpoints in names that no longer exist from the ConfigMap and
+// returns these Endpoints.
+func (p *PendingRegistrations) prune(ctx context.Context, names []types.NamespacedName) ([]types.NamespacedName, error) {
+	var deleted []types.NamespacedName
+	for _, name := range names {
+		err := p.Reader.Get(ctx, name, &corev1.Endpoints{})
+		if k8serrors.IsNotFound(err) {
+			deleted = append(deleted, name)
+		} else if err != nil {
+			return nil, fmt.Errorf("failed to get Endpoints %s: %w", name, err)
+		}
+	}
+	if len(deleted) == 0 {
+		return nil, nil
+	}
+	p.Log.Info("pruning pending registrations of deleted Endpoints", "count", len(deleted))
+	err := p.update(ctx, func(data map[string]string) {
+		for _, name := range deleted {
+			delete(data, pendingRegistrationKey(name))
+		}
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to remove pending registrations of deleted Endpoints: %w", err)
+	}
+	return deleted, nil
+}
+
+func (p *PendingRegistrations) owns(namespace string) bool {
+	return p.Owns == nil || p.Owns(namespace)
+}
+
+// update applies mutate to the data of the ConfigMap, creating it if it doesn't exist.
+func (p *PendingRegistrations) update(ctx context.Context, mutate func(data map[string]string)) error {
+	retriable := func(err error) bool {
+		return k8serrors.IsConflict(err) || k8serrors.IsAlreadyExists(err)
+	}
+	return retry.OnError(retry.DefaultRetry, retriable, func() error {
+		var configMap corev1.ConfigMap
+		err := p.Reader.Get(ctx, types.NamespacedName{Name: p.Name, Namespace: p.Namespace}, &configMap)
+		if k8serrors.IsNotFound(err) {
+			configMap = corev1.ConfigMap{
+				ObjectMeta: metav1.ObjectMeta{Name: p.Name, Namespace: p.Namespace},
+				Data:       make(map[string]string),
+			}
+			mutate(configMap.Data)
+			return p.Writer.Create(ctx, &configMap)
+		} else if err != nil {
+			return err
+		}
+		if configMap.Data == nil {
+			configMap.Data = make(map[string]string)
+		}
+		mutate(configMap.Data)
+		return p.Writer.Update(ctx, &configMap)
+	})
+}
+
+// pendingRegistrationKey returns the key of the Endpoints in the ConfigMap. Namespaces can't contain
+// dots, so the key is split at its first dot.
+func pendingRegistrationKey(name types.NamespacedName) string {
+	return name.Namespace + "." + name.Name
+}
+
+func parsePendingRegistrationKey(key string) (types.NamespacedName, bool) {
+	namespace, name, ok := strings.Cut(key, ".")
+	if !ok || namespace == "" || name == "" {
+		return types.NamespacedName{}, false
+	}
+	return types.NamespacedName{Namespace: namespace, Name: name}, true
+}
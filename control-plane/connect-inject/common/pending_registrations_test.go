@@ -0,0 +1,200 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package common
+
+import (
+	"context"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	logrtest "github.com/go-logr/logr/testr"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/event"
+)
+
+func TestPendingRegistrations(t *testing.T) {
+	ctx := context.Background()
+	k8sClient := fake.NewClientBuilder().Build()
+	queue := newPendingRegistrations(t, k8sClient, func() bool { return true })
+	web := types.NamespacedName{Namespace: "default", Name: "web"}
+	api := types.NamespacedName{Namespace: "team-a", Name: "api.v1"}
+
+	// The ConfigMap is created with the first Endpoints.
+	queue.Add(ctx, web)
+	queue.Add(ctx, api)
+	queue.Add(ctx, web)
+	require.Equal(t, 2, queue.Len())
+	require.ElementsMatch(t, []string{"default.web", "team-a.api.v1"}, configMapKeys(t, k8sClient))
+
+	queue.Done(ctx, web)
+	require.Equal(t, 1, queue.Len())
+	require.Equal(t, []string{"team-a.api.v1"}, configMapKeys(t, k8sClient))
+
+	// Endpoints that aren't queued are ignored.
+	queue.Done(ctx, web)
+	require.Equal(t, 1, queue.Len())
+}
+
+func TestPendingRegistrations_Nil(t *testing.T) {
+	var queue *PendingRegistrations
+	queue.Add(context.Background(), types.NamespacedName{Namespace: "default", Name: "web"})
+	queue.Done(context.Background(), types.NamespacedName{Namespace: "default", Name: "web"})
+}
+
+func TestPendingRegistrations_Load(t *testing.T) {
+	configMap := &corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{Name: "pending-registrations", Namespace: "consul"},
+		Data: map[string]string{
+			"default.web":   "2024-01-01T00:00:00Z",
+			"team-a.api.v1": "not a time",
+			"invalid":       "2024-01-01T00:00:00Z",
+		},
+	}
+	queue := newPendingRegistrations(t, fake.NewClientBuilder().WithObjects(configMap).Build(), func() bool { return true })
+	queue.init()
+
+	require.NoError(t, queue.load(context.Background()))
+	require.Equal(t, 2, queue.Len())
+	require.Contains(t, queue.pending, types.NamespacedName{Namespace: "default", Name: "web"})
+	require.Contains(t, queue.pending, types.NamespacedName{Namespace: "team-a", Name: "api.v1"})
+}
+
+func TestPendingRegistrations_Owns(t *testing.T) {
+	ctx := context.Background()
+	configMap := &corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{Name: "pending-registrations", Namespace: "consul"},
+		Data: map[string]string{
+			"default.web":   "2024-01-01T00:00:00Z",
+			"team-a.api.v1": "2024-01-01T00:00:00Z",
+		},
+	}
+	k8sClient := fake.NewClientBuilder().WithObjects(configMap, endpoints("default", "web"), endpoints("team-a", "api.v1")).Build()
+	var ownsTeamA atomic.Bool
+	ownsTeamA.Store(true)
+	queue := newPendingRegistrations(t, k8sClient, func() bool { return false })
+	queue.Owns = func(namespace string) bool { return namespace == "team-a" && ownsTeamA.Load() }
+	queue.init()
+
+	// Only the Endpoints of the owned namespaces are loaded.
+	require.NoError(t, queue.load(ctx))
+	require.Equal(t, 1, queue.Len())
+	require.Contains(t, queue.pending, types.NamespacedName{Namespace: "team-a", Name: "api.v1"})
+
+	// The Endpoints of a namespace that moved to another shard are dropped from the queue but
+	// kept in the ConfigMap for the replica that owns it now.
+	ownsTeamA.Store(false)
+	queue.flush(ctx)
+	require.Equal(t, 0, queue.Len())
+	require.ElementsMatch(t, []string{"default.web", "team-a.api.v1"}, configMapKeys(t, k8sClient))
+}
+
+func TestPendingRegistrations_Flush(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	t.Cleanup(cancel)
+	var reachable atomic.Bool
+	queue := newPendingRegistrations(t, fake.NewClientBuilder().Build(), reachable.Load)
+	queue.init()
+	web := types.NamespacedName{Namespace: "default", Name: "web"}
+	queue.Add(ctx, web)
+
+	events := make(chan event.GenericEvent, 10)
+	go func() {
+		for e := range queue.events {
+			events <- e
+		}
+	}()
+
+	// Nothing is enqueued while the servers are unreachable.
+	queue.flush(ctx)
+	require.Never(t, func() bool { return len(events) > 0 }, 100*time.Millisecond, 10*time.Millisecond)
+
+	reachable.Store(true)
+	queue.flush(ctx)
+	e := <-events
+	require.Equal(t, "web", e.Object.GetName())
+	require.Equal(t, "default", e.Object.GetNamespace())
+
+	// The Endpoints are enqueued once until they are added again.
+	queue.flush(ctx)
+	require.Never(t, func() bool { return len(events) > 0 }, 100*time.Millisecond, 10*time.Millisecond)
+	queue.Add(ctx, web)
+	queue.flush(ctx)
+	require.Equal(t, "web", (<-events).Object.GetName())
+}
+
+func TestPendingRegistrations_Prune(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	t.Cleanup(cancel)
+	configMap := &corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{Name: "pending-registrations", Namespace: "consul"},
+		Data: map[string]string{
+			"default.web":   "2024-01-01T00:00:00Z",
+			"default.db":    "2024-01-01T00:00:00Z",
+			"team-a.api.v1": "2024-01-01T00:00:00Z",
+		},
+	}
+	k8sClient := fake.NewClientBuilder().WithObjects(configMap, endpoints("default", "web")).Build()
+	var reachable atomic.Bool
+	queue := newPendingRegistrations(t, k8sClient, reachable.Load)
+	queue.Owns = func(namespace string) bool { return namespace == "default" }
+	queue.init()
+
+	// The keys of the owned Endpoints that were deleted are pruned from the ConfigMap but the
+	// Endpoints stay in the queue until they are enqueued.
+	require.NoError(t, queue.load(ctx))
+	require.Equal(t, 2, queue.Len())
+	require.ElementsMatch(t, []string{"default.web", "team-a.api.v1"}, configMapKeys(t, k8sClient))
+
+	events := make(chan event.GenericEvent, 10)
+	go func() {
+		for e := range queue.events {
+			events <- e
+		}
+	}()
+
+	// Endpoints deleted while they are queued are enqueued once and dropped from the queue.
+	queue.Add(ctx, types.NamespacedName{Namespace: "default", Name: "cache"})
+	require.ElementsMatch(t, []string{"default.web", "default.cache", "team-a.api.v1"}, configMapKeys(t, k8sClient))
+	reachable.Store(true)
+	queue.flush(ctx)
+	var enqueued []string
+	for i := 0; i < 3; i++ {
+		enqueued = append(enqueued, (<-events).Object.GetName())
+	}
+	require.ElementsMatch(t, []string{"web", "db", "cache"}, enqueued)
+	require.Equal(t, 1, queue.Len())
+	require.Contains(t, queue.pending, types.NamespacedName{Namespace: "default", Name: "web"})
+	require.ElementsMatch(t, []string{"default.web", "team-a.api.v1"}, configMapKeys(t, k8sClient))
+}
+
+func endpoints(namespace, name string) *corev1.Endpoints {
+	return &corev1.Endpoints{ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: namespace}}
+}
+
+func newPendingRegistrations(t *testing.T, k8sClient client.Client, reachable func() bool) *PendingRegistrations {
+	return &PendingRegistrations{
+		Reader:    k8sClient,
+		Writer:    k8sClient,
+		Name:      "pending-registrations",
+		Namespace: "consul",
+		Reachable: reachable,
+		Log:       logrtest.New(t),
+	}
+}
+
+func configMapKeys(t *testing.T, k8sClient client.Client) []string {
+	var configMap corev1.ConfigMap
+	require.NoError(t, k8sClient.Get(context.Background(), types.NamespacedName{Name: "pending-registrations", Namespace: "consul"}, &configMap))
+	var keys []string
+	for key := range configMap.Data {
+		keys = append(keys, key)
+	}
+	return keys
+}
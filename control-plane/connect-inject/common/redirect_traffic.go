@@ -0,0 +1,68 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package common
+
+import (
+	"fmt"
+
+	"github.com/hashicorp/consul/sdk/iptables"
+)
+
+// DNSCaptureChain is the chain that redirects outbound DNS traffic to every nameserver to Consul DNS.
+const DNSCaptureChain = "CONSUL_DNS_CAPTURE"
+
+// RedirectTrafficConfig is the traffic redirection config of a pod that is passed to the CNI plugin in the
+// consul.hashicorp.com/redirect-traffic-config annotation and to connect-init in CONSUL_REDIRECT_TRAFFIC_CONFIG.
+// It extends iptables.Config with rules that iptables.Setup doesn't support. It is serialized with the fields
+// of iptables.Config inlined so that it can still be read as an iptables.Config.
+//
+// This type is duplicated in control-plane/cni so that the plugin doesn't depend on the control-plane module.
+type RedirectTrafficConfig struct {
+	iptables.Config
+
+	// CaptureDNS redirects outbound DNS traffic over UDP and TCP port 53 to Consul DNS at ConsulDNSIP:ConsulDNSPort
+	// regardless of the nameserver it is sent to, so the pod's nameservers don't have to point at Consul DNS.
+	// DNS traffic from the proxy and ExcludeUIDs isn't captured.
+	CaptureDNS bool `json:",omitempty"`
+
+	// ExcludeDNSCaptureCIDRs is the list of nameserver CIDRs whose DNS traffic is not captured when
+	// CaptureDNS is set. Unlike ExcludeOutboundCIDRs, it applies to UDP as well as TCP.
+	ExcludeDNSCaptureCIDRs []string `json:",omitempty"`
+}
+
+// AdditionalRules returns the rules to apply with iptables.SetupWithAdditionalRules
+// for the fields that iptables.Config doesn't have.
+func (c RedirectTrafficConfig) AdditionalRules() iptables.AdditionalRulesFn {
+	return func(provider iptables.Provider) {
+		if !c.CaptureDNS {
+			return
+		}
+		dnsIP := c.ConsulDNSIP
+		if dnsIP == "" {
+			dnsIP = "127.0.0.1"
+		}
+		destination := dnsIP
+		if c.ConsulDNSPort != 0 {
+			destination = fmt.Sprintf("%s:%d", dnsIP, c.ConsulDNSPort)
+		}
+
+		provider.AddRule("iptables", "-t", "nat", "-N", DNSCaptureChain)
+
+		// Don't capture the DNS traffic of the proxy so that it can resolve upstream addresses itself.
+		provider.AddRule("iptables", "-t", "nat", "-A", DNSCaptureChain, "-m", "owner", "--uid-owner", c.ProxyUserID, "-j", "RETURN")
+		for _, uid := range c.ExcludeUIDs {
+			provider.AddRule("iptables", "-t", "nat", "-A", DNSCaptureChain, "-m", "owner", "--uid-owner", uid, "-j", "RETURN")
+		}
+		for _, cidr := range c.ExcludeDNSCaptureCIDRs {
+			provider.AddRule("iptables", "-t", "nat", "-A", DNSCaptureChain, "-d", cidr, "-j", "RETURN")
+		}
+		provider.AddRule("iptables", "-t", "nat", "-A", DNSCaptureChain, "-p", "udp", "-j", "DNAT", "--to-destination", destination)
+		provider.AddRule("iptables", "-t", "nat", "-A", DNSCaptureChain, "-p", "tcp", "-j", "DNAT", "--to-destination", destination)
+
+		// The DNS traffic is captured before the rules that redirect all outbound TCP traffic
+		// to the proxy, so the rules are inserted at the start of the OUTPUT chain.
+		provider.AddRule("iptables", "-t", "nat", "-I", "OUTPUT", "-p", "udp", "--dport", "53", "-j", DNSCaptureChain)
+		provider.AddRule("iptables", "-t", "nat", "-I", "OUTPUT", "-p", "tcp", "--dport", "53", "-j", DNSCaptureChain)
+	}
+}
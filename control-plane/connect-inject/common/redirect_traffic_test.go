@@ -0,0 +1,100 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package common
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/hashicorp/consul/sdk/iptables"
+	"github.com/stretchr/testify/require"
+)
+
+type fakeIptablesProvider struct {
+	rules []string
+}
+
+func (f *fakeIptablesProvider) AddRule(_ string, args ...string) {
+	f.rules = append(f.rules, "iptables "+strings.Join(args, " "))
+}
+
+func (f *fakeIptablesProvider) ApplyRules() error { return nil }
+
+func (f *fakeIptablesProvider) Rules() []string { return f.rules }
+
+func TestRedirectTrafficConfig_AdditionalRules(t *testing.T) {
+	cases := map[string]struct {
+		cfg      RedirectTrafficConfig
+		expRules []string
+	}{
+		"dns capture disabled": {
+			cfg: RedirectTrafficConfig{
+				Config: iptables.Config{ProxyUserID: "5995", ConsulDNSIP: "127.0.0.1", ConsulDNSPort: 8600},
+			},
+		},
+		"dns capture": {
+			cfg: RedirectTrafficConfig{
+				Config:     iptables.Config{ProxyUserID: "5995", ConsulDNSIP: "127.0.0.1", ConsulDNSPort: 8600},
+				CaptureDNS: true,
+			},
+			expRules: []string{
+				"iptables -t nat -N CONSUL_DNS_CAPTURE",
+				"iptables -t nat -A CONSUL_DNS_CAPTURE -m owner --uid-owner 5995 -j RETURN",
+				"iptables -t nat -A CONSUL_DNS_CAPTURE -p udp -j DNAT --to-destination 127.0.0.1:8600",
+				"iptables -t nat -A CONSUL_DNS_CAPTURE -p tcp -j DNAT --to-destination 127.0.0.1:8600",
+				"iptables -t nat -I OUTPUT -p udp --dport 53 -j CONSUL_DNS_CAPTURE",
+				"iptables -t nat -I OUTPUT -p tcp --dport 53 -j CONSUL_DNS_CAPTURE",
+			},
+		},
+		"dns capture with exclusions": {
+			cfg: RedirectTrafficConfig{
+				Config: iptables.Config{
+					ProxyUserID: "5995",
+					ConsulDNSIP: "10.0.0.10",
+					ExcludeUIDs: []string{"5996"},
+				},
+				CaptureDNS:             true,
+				ExcludeDNSCaptureCIDRs: []string{"169.254.20.10/32"},
+			},
+			expRules: []string{
+				"iptables -t nat -N CONSUL_DNS_CAPTURE",
+				"iptables -t nat -A CONSUL_DNS_CAPTURE -m owner --uid-owner 5995 -j RETURN",
+				"iptables -t nat -A CONSUL_DNS_CAPTURE -m owner --uid-owner 5996 -j RETURN",
+				"iptables -t nat -A CONSUL_DNS_CAPTURE -d 169.254.20.10/32 -j RETURN",
+				"iptables -t nat -A CONSUL_DNS_CAPTURE -p udp -j DNAT --to-destination 10.0.0.10",
+				"iptables -t nat -A CONSUL_DNS_CAPTURE -p tcp -j DNAT --to-destination 10.0.0.10",
+				"iptables -t nat -I OUTPUT -p udp --dport 53 -j CONSUL_DNS_CAPTURE",
+				"iptables -t nat -I OUTPUT -p tcp --dport 53 -j CONSUL_DNS_CAPTURE",
+			},
+		},
+	}
+
+	for name, c := range cases {
+		t.Run(name, func(t *testing.T) {
+			provider := &fakeIptablesProvider{}
+			c.cfg.AdditionalRules()(provider)
+			require.Equal(t, c.expRules, provider.Rules())
+		})
+	}
+}
+
+// Test that the config can still be read as an iptables.Config, e.g. by older CNI plugins.
+func TestRedirectTrafficConfig_JSON(t *testing.T) {
+	cfg := RedirectTrafficConfig{
+		Config:                 iptables.Config{ProxyUserID: "5995", ProxyInboundPort: 20000},
+		CaptureDNS:             true,
+		ExcludeDNSCaptureCIDRs: []string{"169.254.20.10/32"},
+	}
+	raw, err := json.Marshal(&cfg)
+	require.NoError(t, err)
+
+	var iptablesCfg iptables.Config
+	require.NoError(t, json.Unmarshal(raw, &iptablesCfg))
+	require.Equal(t, cfg.Config, iptablesCfg)
+
+	var actual RedirectTrafficConfig
+	require.NoError(t, json.Unmarshal(raw, &actual))
+	require.Equal(t, cfg, actual)
+}
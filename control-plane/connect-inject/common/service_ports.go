@@ -0,0 +1,134 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package common
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+
+	"github.com/hashicorp/consul-k8s/control-plane/connect-inject/constants"
+)
+
+// ServicePort maps a port of a container of the pod to the Consul service registered for it.
+type ServicePort struct {
+	// Service is the name of the Consul service.
+	Service string `json:"service"`
+	// Port is the name or number of the port the service listens on.
+	Port string `json:"port"`
+	// Container is the name of the container that declares the port. If it is not set,
+	// a named port is looked up in all containers of the pod.
+	Container string `json:"container,omitempty"`
+}
+
+// ServicePorts returns the services of the connect-service-ports annotation of the pod in order,
+// with their ports resolved to port numbers. It returns nil if the annotation is not set and an
+// error if the annotation is invalid.
+func ServicePorts(pod corev1.Pod) ([]ServicePort, error) {
+	raw, ok := pod.Annotations[constants.AnnotationServicePorts]
+	if !ok {
+		return nil, nil
+	}
+
+	var servicePorts []ServicePort
+	if err := json.Unmarshal([]byte(raw), &servicePorts); err != nil {
+		return nil, fmt.Errorf("%s annotation value is not a valid JSON list of service ports: %w", constants.AnnotationServicePorts, err)
+	}
+	if len(servicePorts) == 0 {
+		return nil, fmt.Errorf("%s annotation value must contain at least one service port", constants.AnnotationServicePorts)
+	}
+
+	services := make(map[string]struct{})
+	ports := make(map[int32]string)
+	for i, sp := range servicePorts {
+		if sp.Service == "" {
+			return nil, fmt.Errorf("%s annotation value has no service name at index %d", constants.AnnotationServicePorts, i)
+		}
+		if strings.Contains(sp.Service, ",") {
+			return nil, fmt.Errorf("%s annotation value has invalid service name %q", constants.AnnotationServicePorts, sp.Service)
+		}
+		if _, ok := services[sp.Service]; ok {
+			return nil, fmt.Errorf("%s annotation value has duplicate service %q", constants.AnnotationServicePorts, sp.Service)
+		}
+		services[sp.Service] = struct{}{}
+
+		port, err := containerPort(pod, sp)
+		if err != nil {
+			return nil, fmt.Errorf("%s annotation value has invalid port for service %q: %w", constants.AnnotationServicePorts, sp.Service, err)
+		}
+		if other, ok := ports[port]; ok {
+			return nil, fmt.Errorf("%s annotation value maps port %d to both services %q and %q", constants.AnnotationServicePorts, port, other, sp.Service)
+		}
+		ports[port] = sp.Service
+		servicePorts[i].Port = strconv.Itoa(int(port))
+	}
+	return servicePorts, nil
+}
+
+// containerPort resolves the port of the service port to a port number.
+func containerPort(pod corev1.Pod, sp ServicePort) (int32, error) {
+	if sp.Port == "" {
+		return 0, fmt.Errorf("no port set")
+	}
+
+	containers := pod.Spec.Containers
+	if sp.Container != "" {
+		containers = nil
+		for _, c := range pod.Spec.Containers {
+			if c.Name == sp.Container {
+				containers = append(containers, c)
+			}
+		}
+		if len(containers) == 0 {
+			return 0, fmt.Errorf("container %q not found", sp.Container)
+		}
+	}
+
+	number, err := strconv.ParseInt(sp.Port, 10, 32)
+	if err != nil {
+		for _, c := range containers {
+			for _, p := range c.Ports {
+				if p.Name == sp.Port {
+					return p.ContainerPort, nil
+				}
+			}
+		}
+		return 0, fmt.Errorf("named port %q not found", sp.Port)
+	}
+	if number < 1 || number > 65535 {
+		return 0, fmt.Errorf("port %d is not in the valid port range 1-65535", number)
+	}
+	// Ports don't have to be declared by containers, but a port of a specific container must be.
+	if sp.Container != "" {
+		for _, p := range containers[0].Ports {
+			if int64(p.ContainerPort) == number {
+				return int32(number), nil
+			}
+		}
+		return 0, fmt.Errorf("port %d is not declared by container %q", number, sp.Container)
+	}
+	return int32(number), nil
+}
+
+// ApplyServicePorts sets the connect-service and connect-service-port annotations of the pod to the
+// services and ports of its connect-service-ports annotation, so that their order always matches.
+// It does nothing if the connect-service-ports annotation is not set.
+func ApplyServicePorts(pod *corev1.Pod) error {
+	servicePorts, err := ServicePorts(*pod)
+	if err != nil || servicePorts == nil {
+		return err
+	}
+	services := make([]string, 0, len(servicePorts))
+	ports := make([]string, 0, len(servicePorts))
+	for _, sp := range servicePorts {
+		services = append(services, sp.Service)
+		ports = append(ports, sp.Port)
+	}
+	pod.Annotations[constants.AnnotationService] = strings.Join(services, ",")
+	pod.Annotations[constants.AnnotationPort] = strings.Join(ports, ",")
+	return nil
+}
@@ -0,0 +1,122 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package common
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/hashicorp/consul-k8s/control-plane/connect-inject/constants"
+)
+
+func TestServicePorts(t *testing.T) {
+	spec := corev1.PodSpec{
+		Containers: []corev1.Container{
+			{
+				Name:  "web",
+				Ports: []corev1.ContainerPort{{Name: "http", ContainerPort: 8080}},
+			},
+			{
+				Name:  "admin",
+				Ports: []corev1.ContainerPort{{Name: "http", ContainerPort: 9090}, {ContainerPort: 9091}},
+			},
+		},
+	}
+
+	cases := map[string]struct {
+		annotation string
+		expected   []ServicePort
+		expErr     string
+	}{
+		"not set": {},
+		"named and numbered ports": {
+			annotation: `[{"service":"web","port":"http"},{"service":"web-admin","port":"9091"}]`,
+			expected: []ServicePort{
+				{Service: "web", Port: "8080"},
+				{Service: "web-admin", Port: "9091"},
+			},
+		},
+		"named port of container": {
+			annotation: `[{"service":"web-admin","port":"http","container":"admin"},{"service":"web","port":"http","container":"web"}]`,
+			expected: []ServicePort{
+				{Service: "web-admin", Port: "9090", Container: "admin"},
+				{Service: "web", Port: "8080", Container: "web"},
+			},
+		},
+		"invalid JSON": {
+			annotation: `web:http`,
+			expErr:     "consul.hashicorp.com/connect-service-ports annotation value is not a valid JSON list of service ports: invalid character 'w' looking for beginning of value",
+		},
+		"empty list": {
+			annotation: `[]`,
+			expErr:     "consul.hashicorp.com/connect-service-ports annotation value must contain at least one service port",
+		},
+		"missing service": {
+			annotation: `[{"port":"http"}]`,
+			expErr:     "consul.hashicorp.com/connect-service-ports annotation value has no service name at index 0",
+		},
+		"duplicate service": {
+			annotation: `[{"service":"web","port":"8080"},{"service":"web","port":"9090"}]`,
+			expErr:     `consul.hashicorp.com/connect-service-ports annotation value has duplicate service "web"`,
+		},
+		"missing port": {
+			annotation: `[{"service":"web"}]`,
+			expErr:     `consul.hashicorp.com/connect-service-ports annotation value has invalid port for service "web": no port set`,
+		},
+		"unknown named port": {
+			annotation: `[{"service":"web","port":"grpc"}]`,
+			expErr:     `consul.hashicorp.com/connect-service-ports annotation value has invalid port for service "web": named port "grpc" not found`,
+		},
+		"unknown container": {
+			annotation: `[{"service":"web","port":"http","container":"app"}]`,
+			expErr:     `consul.hashicorp.com/connect-service-ports annotation value has invalid port for service "web": container "app" not found`,
+		},
+		"port not declared by container": {
+			annotation: `[{"service":"web","port":"9091","container":"web"}]`,
+			expErr:     `consul.hashicorp.com/connect-service-ports annotation value has invalid port for service "web": port 9091 is not declared by container "web"`,
+		},
+		"port out of range": {
+			annotation: `[{"service":"web","port":"70000"}]`,
+			expErr:     `consul.hashicorp.com/connect-service-ports annotation value has invalid port for service "web": port 70000 is not in the valid port range 1-65535`,
+		},
+		"port mapped to two services": {
+			annotation: `[{"service":"web","port":"http"},{"service":"web-admin","port":"8080"}]`,
+			expErr:     `consul.hashicorp.com/connect-service-ports annotation value maps port 8080 to both services "web" and "web-admin"`,
+		},
+	}
+
+	for name, c := range cases {
+		t.Run(name, func(t *testing.T) {
+			pod := corev1.Pod{Spec: spec}
+			if c.annotation != "" {
+				pod.Annotations = map[string]string{constants.AnnotationServicePorts: c.annotation}
+			}
+			servicePorts, err := ServicePorts(pod)
+			if c.expErr != "" {
+				require.EqualError(t, err, c.expErr)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, c.expected, servicePorts)
+		})
+	}
+}
+
+func TestApplyServicePorts(t *testing.T) {
+	pod := corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{
+			Annotations: map[string]string{
+				constants.AnnotationServicePorts: `[{"service":"web","port":"8080"},{"service":"web-admin","port":"9090"}]`,
+				constants.AnnotationService:      "web-admin,web",
+				constants.AnnotationPort:         "8080",
+			},
+		},
+	}
+	require.NoError(t, ApplyServicePorts(&pod))
+	require.Equal(t, "web,web-admin", pod.Annotations[constants.AnnotationService])
+	require.Equal(t, "8080,9090", pod.Annotations[constants.AnnotationPort])
+}
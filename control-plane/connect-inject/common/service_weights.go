@@ -0,0 +1,60 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package common
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/hashicorp/consul/api"
+	corev1 "k8s.io/api/core/v1"
+
+	"github.com/hashicorp/consul-k8s/control-plane/connect-inject/constants"
+)
+
+// ServiceWeights returns the weights of the service-weight annotation of the pod. It returns nil
+// if the annotation is not set and an error if the annotation is invalid. The weights that the
+// annotation doesn't set default to 1, like they do in Consul.
+func ServiceWeights(pod corev1.Pod) (*api.AgentWeights, error) {
+	raw, ok := pod.Annotations[constants.AnnotationServiceWeight]
+	if !ok {
+		return nil, nil
+	}
+
+	weights := &api.AgentWeights{Passing: 1, Warning: 1}
+	seen := make(map[string]struct{})
+	for _, pair := range strings.Split(raw, ",") {
+		state, value, ok := strings.Cut(strings.TrimSpace(pair), "=")
+		if !ok {
+			return nil, fmt.Errorf("%s annotation value %q is invalid, must be a comma separated list of <state>=<weight>", constants.AnnotationServiceWeight, raw)
+		}
+		state = strings.TrimSpace(state)
+		if _, ok := seen[state]; ok {
+			return nil, fmt.Errorf("%s annotation value has duplicate state %q", constants.AnnotationServiceWeight, state)
+		}
+		seen[state] = struct{}{}
+
+		weight, err := strconv.Atoi(strings.TrimSpace(value))
+		if err != nil {
+			return nil, fmt.Errorf("%s annotation value has invalid weight %q for state %q", constants.AnnotationServiceWeight, value, state)
+		}
+		switch state {
+		case "passing":
+			// Consul requires instances that are passing to receive traffic.
+			if weight < 1 {
+				return nil, fmt.Errorf("%s annotation value has invalid passing weight %d, must be at least 1", constants.AnnotationServiceWeight, weight)
+			}
+			weights.Passing = weight
+		case "warning":
+			if weight < 0 {
+				return nil, fmt.Errorf("%s annotation value has invalid warning weight %d, must be at least 0", constants.AnnotationServiceWeight, weight)
+			}
+			weights.Warning = weight
+		default:
+			return nil, fmt.Errorf("%s annotation value has unknown state %q, must be passing or warning", constants.AnnotationServiceWeight, state)
+		}
+	}
+	return weights, nil
+}
@@ -0,0 +1,82 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package common
+
+import (
+	"testing"
+
+	"github.com/hashicorp/consul/api"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/utils/ptr"
+
+	"github.com/hashicorp/consul-k8s/control-plane/connect-inject/constants"
+)
+
+func TestServiceWeights(t *testing.T) {
+	cases := map[string]struct {
+		annotation *string
+		expected   *api.AgentWeights
+		expErr     string
+	}{
+		"not set": {},
+		"passing and warning": {
+			annotation: ptr.To("passing=10, warning=2"),
+			expected:   &api.AgentWeights{Passing: 10, Warning: 2},
+		},
+		"passing only": {
+			annotation: ptr.To("passing=5"),
+			expected:   &api.AgentWeights{Passing: 5, Warning: 1},
+		},
+		"warning only": {
+			annotation: ptr.To("warning=0"),
+			expected:   &api.AgentWeights{Passing: 1, Warning: 0},
+		},
+		"empty": {
+			annotation: ptr.To(""),
+			expErr:     `consul.hashicorp.com/service-weight annotation value "" is invalid, must be a comma separated list of <state>=<weight>`,
+		},
+		"missing weight": {
+			annotation: ptr.To("10"),
+			expErr:     `consul.hashicorp.com/service-weight annotation value "10" is invalid, must be a comma separated list of <state>=<weight>`,
+		},
+		"invalid weight": {
+			annotation: ptr.To("passing=ten"),
+			expErr:     `consul.hashicorp.com/service-weight annotation value has invalid weight "ten" for state "passing"`,
+		},
+		"zero passing weight": {
+			annotation: ptr.To("passing=0"),
+			expErr:     "consul.hashicorp.com/service-weight annotation value has invalid passing weight 0, must be at least 1",
+		},
+		"negative warning weight": {
+			annotation: ptr.To("warning=-1"),
+			expErr:     "consul.hashicorp.com/service-weight annotation value has invalid warning weight -1, must be at least 0",
+		},
+		"unknown state": {
+			annotation: ptr.To("critical=1"),
+			expErr:     `consul.hashicorp.com/service-weight annotation value has unknown state "critical", must be passing or warning`,
+		},
+		"duplicate state": {
+			annotation: ptr.To("passing=1,passing=2"),
+			expErr:     `consul.hashicorp.com/service-weight annotation value has duplicate state "passing"`,
+		},
+	}
+
+	for name, c := range cases {
+		t.Run(name, func(t *testing.T) {
+			pod := corev1.Pod{ObjectMeta: metav1.ObjectMeta{Annotations: map[string]string{}}}
+			if c.annotation != nil {
+				pod.Annotations[constants.AnnotationServiceWeight] = *c.annotation
+			}
+			weights, err := ServiceWeights(pod)
+			if c.expErr != "" {
+				require.EqualError(t, err, c.expErr)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, c.expected, weights)
+		})
+	}
+}
@@ -0,0 +1,125 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package common
+
+import (
+	"fmt"
+	"strconv"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+
+	"github.com/hashicorp/consul-k8s/control-plane/connect-inject/constants"
+)
+
+// Keys of the opaque upstream config that Consul translates into the Envoy cluster of the upstream.
+const (
+	upstreamConfigConnectTimeoutMs        = "connect_timeout_ms"
+	upstreamConfigLimits                  = "limits"
+	upstreamConfigMaxConnections          = "max_connections"
+	upstreamConfigMaxPendingRequests      = "max_pending_requests"
+	upstreamConfigMaxConcurrentRequests   = "max_concurrent_requests"
+	upstreamConfigPassiveHealthCheck      = "passive_health_check"
+	upstreamConfigInterval                = "interval"
+	upstreamConfigMaxFailures             = "max_failures"
+	upstreamConfigEnforcingConsecutive5xx = "enforcing_consecutive_5xx"
+	upstreamConfigMaxEjectionPercent      = "max_ejection_percent"
+	upstreamConfigBaseEjectionTime        = "base_ejection_time"
+)
+
+// UpstreamConfig returns the upstream config set with the upstream and outlier annotations of the pod,
+// in the format of the opaque config of a proxy upstream. It returns nil if none of the annotations are set
+// and an error if an annotation has an invalid value.
+func UpstreamConfig(pod corev1.Pod) (map[string]interface{}, error) {
+	config := make(map[string]interface{})
+
+	if raw, ok := pod.Annotations[constants.AnnotationUpstreamConnectTimeout]; ok {
+		timeout, err := parsePositiveDuration(constants.AnnotationUpstreamConnectTimeout, raw)
+		if err != nil {
+			return nil, err
+		}
+		config[upstreamConfigConnectTimeoutMs] = timeout.Milliseconds()
+	}
+
+	limits := make(map[string]interface{})
+	for annotation, key := range map[string]string{
+		constants.AnnotationUpstreamMaxConnections:        upstreamConfigMaxConnections,
+		constants.AnnotationUpstreamMaxPendingRequests:    upstreamConfigMaxPendingRequests,
+		constants.AnnotationUpstreamMaxConcurrentRequests: upstreamConfigMaxConcurrentRequests,
+	} {
+		if raw, ok := pod.Annotations[annotation]; ok {
+			value, err := parseUint(annotation, raw, 0)
+			if err != nil {
+				return nil, err
+			}
+			limits[key] = value
+		}
+	}
+	if len(limits) > 0 {
+		config[upstreamConfigLimits] = limits
+	}
+
+	passiveHealthCheck := make(map[string]interface{})
+	for annotation, key := range map[string]string{
+		constants.AnnotationOutlierInterval:         upstreamConfigInterval,
+		constants.AnnotationOutlierBaseEjectionTime: upstreamConfigBaseEjectionTime,
+	} {
+		if raw, ok := pod.Annotations[annotation]; ok {
+			value, err := parsePositiveDuration(annotation, raw)
+			if err != nil {
+				return nil, err
+			}
+			passiveHealthCheck[key] = value.String()
+		}
+	}
+	if raw, ok := pod.Annotations[constants.AnnotationOutlierMaxFailures]; ok {
+		value, err := parseUint(constants.AnnotationOutlierMaxFailures, raw, 0)
+		if err != nil {
+			return nil, err
+		}
+		passiveHealthCheck[upstreamConfigMaxFailures] = value
+	}
+	for annotation, key := range map[string]string{
+		constants.AnnotationOutlierEnforcingConsecutive5xx: upstreamConfigEnforcingConsecutive5xx,
+		constants.AnnotationOutlierMaxEjectionPercent:      upstreamConfigMaxEjectionPercent,
+	} {
+		if raw, ok := pod.Annotations[annotation]; ok {
+			value, err := parseUint(annotation, raw, 100)
+			if err != nil {
+				return nil, err
+			}
+			passiveHealthCheck[key] = value
+		}
+	}
+	if len(passiveHealthCheck) > 0 {
+		config[upstreamConfigPassiveHealthCheck] = passiveHealthCheck
+	}
+
+	if len(config) == 0 {
+		return nil, nil
+	}
+	return config, nil
+}
+
+// parsePositiveDuration parses the value of annotation as a duration greater than zero.
+func parsePositiveDuration(annotation, raw string) (time.Duration, error) {
+	value, err := time.ParseDuration(raw)
+	if err != nil || value <= 0 {
+		return 0, fmt.Errorf("%s annotation value of %s is not a valid positive duration", annotation, raw)
+	}
+	return value, nil
+}
+
+// parseUint parses the value of annotation as an unsigned integer. If max is greater than zero,
+// the value must not be greater than max.
+func parseUint(annotation, raw string, max uint64) (uint64, error) {
+	value, err := strconv.ParseUint(raw, 10, 32)
+	if err != nil {
+		return 0, fmt.Errorf("%s annotation value of %s is not a valid unsigned integer", annotation, raw)
+	}
+	if max > 0 && value > max {
+		return 0, fmt.Errorf("%s annotation value of %d must not be greater than %d", annotation, value, max)
+	}
+	return value, nil
+}
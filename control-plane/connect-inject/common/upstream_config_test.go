@@ -0,0 +1,93 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package common
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/hashicorp/consul-k8s/control-plane/connect-inject/constants"
+)
+
+func TestUpstreamConfig(t *testing.T) {
+	cases := map[string]struct {
+		annotations map[string]string
+		expected    map[string]interface{}
+		expErr      string
+	}{
+		"no annotations": {},
+		"all annotations": {
+			annotations: map[string]string{
+				constants.AnnotationUpstreamConnectTimeout:         "1500ms",
+				constants.AnnotationUpstreamMaxConnections:         "100",
+				constants.AnnotationUpstreamMaxPendingRequests:     "200",
+				constants.AnnotationUpstreamMaxConcurrentRequests:  "300",
+				constants.AnnotationOutlierInterval:                "10s",
+				constants.AnnotationOutlierMaxFailures:             "5",
+				constants.AnnotationOutlierEnforcingConsecutive5xx: "100",
+				constants.AnnotationOutlierMaxEjectionPercent:      "50",
+				constants.AnnotationOutlierBaseEjectionTime:        "1m",
+			},
+			expected: map[string]interface{}{
+				"connect_timeout_ms": int64(1500),
+				"limits": map[string]interface{}{
+					"max_connections":         uint64(100),
+					"max_pending_requests":    uint64(200),
+					"max_concurrent_requests": uint64(300),
+				},
+				"passive_health_check": map[string]interface{}{
+					"interval":                  "10s",
+					"max_failures":              uint64(5),
+					"enforcing_consecutive_5xx": uint64(100),
+					"max_ejection_percent":      uint64(50),
+					"base_ejection_time":        "1m0s",
+				},
+			},
+		},
+		"zero values": {
+			annotations: map[string]string{
+				constants.AnnotationOutlierMaxFailures:             "0",
+				constants.AnnotationOutlierEnforcingConsecutive5xx: "0",
+			},
+			expected: map[string]interface{}{
+				"passive_health_check": map[string]interface{}{
+					"max_failures":              uint64(0),
+					"enforcing_consecutive_5xx": uint64(0),
+				},
+			},
+		},
+		"invalid duration": {
+			annotations: map[string]string{constants.AnnotationUpstreamConnectTimeout: "5"},
+			expErr:      "consul.hashicorp.com/upstream-connect-timeout annotation value of 5 is not a valid positive duration",
+		},
+		"negative duration": {
+			annotations: map[string]string{constants.AnnotationOutlierInterval: "-10s"},
+			expErr:      "consul.hashicorp.com/outlier-interval annotation value of -10s is not a valid positive duration",
+		},
+		"invalid integer": {
+			annotations: map[string]string{constants.AnnotationUpstreamMaxConnections: "many"},
+			expErr:      "consul.hashicorp.com/upstream-max-connections annotation value of many is not a valid unsigned integer",
+		},
+		"percent out of range": {
+			annotations: map[string]string{constants.AnnotationOutlierMaxEjectionPercent: "101"},
+			expErr:      "consul.hashicorp.com/outlier-max-ejection-percent annotation value of 101 must not be greater than 100",
+		},
+	}
+
+	for name, c := range cases {
+		t.Run(name, func(t *testing.T) {
+			pod := corev1.Pod{ObjectMeta: metav1.ObjectMeta{Annotations: c.annotations}}
+			config, err := UpstreamConfig(pod)
+			if c.expErr != "" {
+				require.EqualError(t, err, c.expErr)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, c.expected, config)
+		})
+	}
+}
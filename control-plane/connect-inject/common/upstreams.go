@@ -0,0 +1,136 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package common
+
+import (
+	"fmt"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+
+	"github.com/hashicorp/consul-k8s/control-plane/connect-inject/constants"
+)
+
+// ValidateUpstreams returns an error if the upstreams annotation of the pod is longer than maxSize bytes,
+// has more than maxCount upstreams, or has an upstream that is malformed. A maxCount or maxSize of zero
+// means there is no limit. The checks match the parsing of the annotation when the pod is registered so
+// that pods that would fail to be registered are rejected.
+func ValidateUpstreams(pod corev1.Pod, maxCount, maxSize int) error {
+	raw, ok := pod.Annotations[constants.AnnotationUpstreams]
+	if !ok || raw == "" {
+		return nil
+	}
+
+	if maxSize > 0 && len(raw) > maxSize {
+		return fmt.Errorf("%s annotation is %d bytes long, which exceeds the maximum of %d bytes", constants.AnnotationUpstreams, len(raw), maxSize)
+	}
+
+	upstreams := strings.Split(raw, ",")
+	if maxCount > 0 && len(upstreams) > maxCount {
+		return fmt.Errorf("%s annotation has %d upstreams, which exceeds the maximum of %d upstreams", constants.AnnotationUpstreams, len(upstreams), maxCount)
+	}
+
+	for _, upstream := range upstreams {
+		if err := validateUpstream(pod, upstream); err != nil {
+			return fmt.Errorf("%s annotation has malformed upstream %q: %w", constants.AnnotationUpstreams, strings.TrimSpace(upstream), err)
+		}
+	}
+	return nil
+}
+
+// validateUpstream returns an error if the upstream isn't in one of the formats:
+// prepared_query:[query-name]:[port]
+// [service-name].[service-namespace].[service-partition]:[port]:[optional datacenter]
+// [service-name].svc.[service-namespace].ns.[service-peer].peer:[port]
+// [service-name].svc.[service-namespace].ns.[service-partition].ap:[port]
+// [service-name].svc.[service-namespace].ns.[service-datacenter].dc:[port].
+func validateUpstream(pod corev1.Pod, upstream string) error {
+	upstream = strings.TrimSpace(upstream)
+	if upstream == "" {
+		return fmt.Errorf("upstream is empty")
+	}
+
+	// The parts and the pieces of the service name are trimmed like when the upstreams are parsed,
+	// so only whitespace within them is invalid.
+	parts := strings.SplitN(upstream, ":", 3)
+	for i := range parts {
+		parts[i] = strings.TrimSpace(parts[i])
+		if i > 0 && containsWhitespace(parts[i]) {
+			return fmt.Errorf("upstreams must be separated by commas")
+		}
+	}
+	if parts[0] == "prepared_query" {
+		if len(parts) != 3 || parts[1] == "" {
+			return fmt.Errorf("prepared query upstreams must have the format prepared_query:[query-name]:[port]")
+		}
+		return validateUpstreamPort(pod, parts[2])
+	}
+
+	if len(parts) < 2 {
+		return fmt.Errorf("no port set")
+	}
+	if err := validateUpstreamPort(pod, parts[1]); err != nil {
+		return err
+	}
+
+	pieces := strings.Split(parts[0], ".")
+	for i := range pieces {
+		pieces[i] = strings.TrimSpace(pieces[i])
+		if pieces[i] == "" {
+			return fmt.Errorf("service name has an empty part")
+		}
+		if containsWhitespace(pieces[i]) {
+			return fmt.Errorf("upstreams must be separated by commas")
+		}
+	}
+	if len(pieces) < 2 || pieces[1] != "svc" {
+		// Unlabeled upstreams have at most a service name, namespace and partition.
+		if len(pieces) > 3 {
+			return fmt.Errorf("service name has too many parts")
+		}
+		return nil
+	}
+
+	// Labeled upstreams must have label pairs. Which labels are supported depends on whether
+	// Consul namespaces and partitions are enabled, which is checked when the pod is registered.
+	switch len(pieces) {
+	case 2:
+		return nil
+	case 4:
+		switch pieces[3] {
+		case "ns", "peer", "dc":
+			return nil
+		}
+		return fmt.Errorf("unknown label %q", pieces[3])
+	case 6:
+		if pieces[3] != "ns" {
+			return fmt.Errorf("expected label \"ns\" but got %q", pieces[3])
+		}
+		switch pieces[5] {
+		case "peer", "ap", "dc":
+			return nil
+		}
+		return fmt.Errorf("unknown label %q", pieces[5])
+	default:
+		return fmt.Errorf("labeled upstreams must have the format [service-name].svc.[service-namespace].ns.[label-value].[peer|ap|dc]")
+	}
+}
+
+// containsWhitespace returns whether s contains whitespace, e.g. between upstreams that are separated
+// by spaces rather than commas.
+func containsWhitespace(s string) bool {
+	return strings.ContainsAny(s, " \t\n")
+}
+
+// validateUpstreamPort returns an error if the port is not a named port of the pod or a valid port number.
+func validateUpstreamPort(pod corev1.Pod, port string) error {
+	value, err := PortValue(pod, port)
+	if err != nil {
+		return fmt.Errorf("port %q is not a named port of the pod or a valid port number", port)
+	}
+	if value < 1 || value > 65535 {
+		return fmt.Errorf("port %d is not in the valid port range 1-65535", value)
+	}
+	return nil
+}
@@ -0,0 +1,114 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package common
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/hashicorp/consul-k8s/control-plane/connect-inject/constants"
+)
+
+func TestValidateUpstreams(t *testing.T) {
+	cases := map[string]struct {
+		upstreams string
+		maxCount  int
+		maxSize   int
+		expErr    string
+	}{
+		"no annotation": {},
+		"all formats": {
+			upstreams: "db:1234, api.ns1.ap1:1235:dc2,prepared_query:query:1236,cache:http," +
+				"web.svc:1237,web.svc.ns1.ns:1238,web.svc.peer1.peer:1239,web.svc.ns1.ns.ap1.ap:1240,web.svc.ns1.ns.dc2.dc:1241",
+		},
+		"whitespace around parts is ignored like when parsing": {
+			upstreams: "db : 1234, api . ns1:1235 : dc2, prepared_query: query :1236, web . svc . ns1 . ns:1238",
+		},
+		"within limits": {
+			upstreams: "db:1234,api:1235",
+			maxCount:  2,
+			maxSize:   16,
+		},
+		"too many upstreams": {
+			upstreams: "db:1234,api:1235,web:1236",
+			maxCount:  2,
+			expErr:    "consul.hashicorp.com/connect-service-upstreams annotation has 3 upstreams, which exceeds the maximum of 2 upstreams",
+		},
+		"annotation too large": {
+			upstreams: "db:1234,api:1235",
+			maxSize:   15,
+			expErr:    "consul.hashicorp.com/connect-service-upstreams annotation is 16 bytes long, which exceeds the maximum of 15 bytes",
+		},
+		"empty upstream": {
+			upstreams: "db:1234,,api:1235",
+			expErr:    `consul.hashicorp.com/connect-service-upstreams annotation has malformed upstream "": upstream is empty`,
+		},
+		"space separated upstreams": {
+			upstreams: "db:1234 api:1235",
+			expErr:    `consul.hashicorp.com/connect-service-upstreams annotation has malformed upstream "db:1234 api:1235": upstreams must be separated by commas`,
+		},
+		"whitespace within a service name": {
+			upstreams: "my db:1234",
+			expErr:    `consul.hashicorp.com/connect-service-upstreams annotation has malformed upstream "my db:1234": upstreams must be separated by commas`,
+		},
+		"missing port": {
+			upstreams: "db",
+			expErr:    `consul.hashicorp.com/connect-service-upstreams annotation has malformed upstream "db": no port set`,
+		},
+		"invalid port": {
+			upstreams: "db:grpc",
+			expErr:    `consul.hashicorp.com/connect-service-upstreams annotation has malformed upstream "db:grpc": port "grpc" is not a named port of the pod or a valid port number`,
+		},
+		"port out of range": {
+			upstreams: "db:0",
+			expErr:    `consul.hashicorp.com/connect-service-upstreams annotation has malformed upstream "db:0": port 0 is not in the valid port range 1-65535`,
+		},
+		"malformed prepared query": {
+			upstreams: "prepared_query:1234",
+			expErr:    `consul.hashicorp.com/connect-service-upstreams annotation has malformed upstream "prepared_query:1234": prepared query upstreams must have the format prepared_query:[query-name]:[port]`,
+		},
+		"empty service name part": {
+			upstreams: "db..ap1:1234",
+			expErr:    `consul.hashicorp.com/connect-service-upstreams annotation has malformed upstream "db..ap1:1234": service name has an empty part`,
+		},
+		"too many unlabeled parts": {
+			upstreams: "db.ns1.ap1.dc1:1234",
+			expErr:    `consul.hashicorp.com/connect-service-upstreams annotation has malformed upstream "db.ns1.ap1.dc1:1234": service name has too many parts`,
+		},
+		"unknown label": {
+			upstreams: "db.svc.ns1.namespace:1234",
+			expErr:    `consul.hashicorp.com/connect-service-upstreams annotation has malformed upstream "db.svc.ns1.namespace:1234": unknown label "namespace"`,
+		},
+		"labeled upstream with odd parts": {
+			upstreams: "db.svc.ns1:1234",
+			expErr:    `consul.hashicorp.com/connect-service-upstreams annotation has malformed upstream "db.svc.ns1:1234": labeled upstreams must have the format [service-name].svc.[service-namespace].ns.[label-value].[peer|ap|dc]`,
+		},
+		"labeled upstream without namespace label": {
+			upstreams: "db.svc.ns1.ap.ap1.ap:1234",
+			expErr:    `consul.hashicorp.com/connect-service-upstreams annotation has malformed upstream "db.svc.ns1.ap.ap1.ap:1234": expected label "ns" but got "ap"`,
+		},
+	}
+
+	for name, c := range cases {
+		t.Run(name, func(t *testing.T) {
+			pod := corev1.Pod{
+				Spec: corev1.PodSpec{
+					Containers: []corev1.Container{{Ports: []corev1.ContainerPort{{Name: "http", ContainerPort: 8080}}}},
+				},
+			}
+			if c.upstreams != "" {
+				pod.ObjectMeta = metav1.ObjectMeta{Annotations: map[string]string{constants.AnnotationUpstreams: c.upstreams}}
+			}
+			err := ValidateUpstreams(pod, c.maxCount, c.maxSize)
+			if c.expErr != "" {
+				require.EqualError(t, err, c.expErr)
+				return
+			}
+			require.NoError(t, err)
+		})
+	}
+}
@@ -12,6 +12,11 @@ const (
 	// a pod when transparent proxy is done.
 	KeyTransparentProxyStatus = "consul.hashicorp.com/transparent-proxy-status"
 
+	// KeyConsulCACert is the key of the annotation that the webhook adds to a pod with the Consul CA
+	// certificate when consul-dataplane logs in without the connect-inject-init container. It is
+	// projected into a file in the consul-dataplane container with a downward API volume.
+	KeyConsulCACert = "consul.hashicorp.com/consul-ca-cert"
+
 	// KeyManagedBy is the key of the label that is added to pods managed
 	// by the Endpoints controller. This is to support upgrading from consul-k8s
 	// without Endpoints controller to consul-k8s with Endpoints controller
@@ -61,6 +66,24 @@ const (
 	// to mount the volume on. It will be mounted at the path `/consul/connect-inject`.
 	AnnotationInjectMountVolumes = "consul.hashicorp.com/connect-inject-mount-volume"
 
+	// AnnotationInjectInitFirst is the key of the annotation that controls whether the init containers
+	// injected for the mesh, e.g. consul-connect-inject-init, run before the init containers of the pod.
+	// By default they run after them, so the init containers of the pod run before the service is
+	// registered and before its traffic is redirected to the sidecar proxy.
+	AnnotationInjectInitFirst = "consul.hashicorp.com/inject-init-first"
+
+	// AnnotationInitContainersBeforeMesh and AnnotationInitContainersAfterMesh are comma separated lists
+	// of the names of the init containers of the pod that run before, or after, the init containers
+	// injected for the mesh, regardless of AnnotationInjectInitFirst.
+	AnnotationInitContainersBeforeMesh = "consul.hashicorp.com/init-containers-before-mesh"
+	AnnotationInitContainersAfterMesh  = "consul.hashicorp.com/init-containers-after-mesh"
+
+	// AnnotationJob overrides whether the pod runs to completion like the pods of a Kubernetes Job.
+	// By default only pods owned by a Job are. The sidecar proxies of these pods can be injected as
+	// native sidecars so they stop when the application exits, their Consul health check doesn't
+	// depend on the readiness of the pod, and they are deregistered as soon as they complete.
+	AnnotationJob = "consul.hashicorp.com/job"
+
 	// AnnotationService is the name of the service to proxy.
 	// This defaults to the name of the Kubernetes service associated with the pod.
 	AnnotationService = "consul.hashicorp.com/connect-service"
@@ -74,6 +97,14 @@ const (
 	// connections to.
 	AnnotationPort = "consul.hashicorp.com/connect-service-port"
 
+	// AnnotationServicePorts explicitly maps the ports of the pod's containers to the Consul services
+	// registered for them. The value is a JSON list of objects with a "service" name, a "port" name or
+	// number and an optional "container" that declares the port, e.g.
+	// `[{"service":"web","port":"http"},{"service":"web-admin","port":"9090","container":"admin"}]`.
+	// When set, it takes precedence over the connect-service and connect-service-port annotations,
+	// which are then set from it by the webhook.
+	AnnotationServicePorts = "consul.hashicorp.com/connect-service-ports"
+
 	// AnnotationProxyConfigMap allows for default values to be set in the opaque config map
 	// during proxy registration. The value for this annotation is expected to be valid json.
 	// Other annotations / configuration may overwrite the values in the map.
@@ -86,6 +117,22 @@ const (
 	// be a named port.
 	AnnotationUpstreams = "consul.hashicorp.com/connect-service-upstreams"
 
+	// annotations for the config of the upstreams in AnnotationUpstreams. They are
+	// set on every upstream of the proxy, overriding the upstream config from
+	// ServiceDefaults and ProxyDefaults.
+	AnnotationUpstreamConnectTimeout        = "consul.hashicorp.com/upstream-connect-timeout"
+	AnnotationUpstreamMaxConnections        = "consul.hashicorp.com/upstream-max-connections"
+	AnnotationUpstreamMaxPendingRequests    = "consul.hashicorp.com/upstream-max-pending-requests"
+	AnnotationUpstreamMaxConcurrentRequests = "consul.hashicorp.com/upstream-max-concurrent-requests"
+
+	// annotations for the outlier detection (passive health checks) of the upstreams
+	// in AnnotationUpstreams.
+	AnnotationOutlierInterval                = "consul.hashicorp.com/outlier-interval"
+	AnnotationOutlierMaxFailures             = "consul.hashicorp.com/outlier-max-failures"
+	AnnotationOutlierEnforcingConsecutive5xx = "consul.hashicorp.com/outlier-enforcing-consecutive-5xx"
+	AnnotationOutlierMaxEjectionPercent      = "consul.hashicorp.com/outlier-max-ejection-percent"
+	AnnotationOutlierBaseEjectionTime        = "consul.hashicorp.com/outlier-base-ejection-time"
+
 	// AnnotationTags is a list of tags to register with the service
 	// this is specified as a comma separated list e.g. abc,123.
 	AnnotationTags = "consul.hashicorp.com/service-tags"
@@ -95,6 +142,12 @@ const (
 	// e.g. consul.hashicorp.com/service-meta-foo:bar.
 	AnnotationMeta = "consul.hashicorp.com/service-meta-"
 
+	// AnnotationServiceWeight sets the weights of the service instance that are used for weighted
+	// load balancing across the instances of the service. This is specified as a comma separated
+	// list of `<state>=<weight>` pairs e.g. passing=10,warning=1. The weights that aren't set
+	// default to 1.
+	AnnotationServiceWeight = "consul.hashicorp.com/service-weight"
+
 	// AnnotationUseProxyHealthCheck creates a readiness listener on the sidecar proxy and
 	// queries this instead of the application health check for the status of the application.
 	// Enable this only if the application does not support health checks.
@@ -123,10 +176,26 @@ const (
 	AnnotationSidecarProxyLifecycleGracefulShutdownPath         = "consul.hashicorp.com/sidecar-proxy-lifecycle-graceful-shutdown-path"
 	AnnotationSidecarProxyLifecycleGracefulStartupPath          = "consul.hashicorp.com/sidecar-proxy-lifecycle-graceful-startup-path"
 
+	// AnnotationSidecarProxyLifecyclePreStopDrainSeconds is the number of seconds that the application
+	// containers and the sidecar proxy sleep in a preStop hook when the pod is deleted, so that in-flight
+	// requests complete while the proxy drains its listeners and before the traffic redirection is removed.
+	AnnotationSidecarProxyLifecyclePreStopDrainSeconds = "consul.hashicorp.com/sidecar-proxy-lifecycle-pre-stop-drain-seconds"
+
+	// AnnotationSidecarProxyTerminationDrainDuration is how long the sidecar proxy drains its listeners once
+	// it receives SIGTERM, as a duration such as "30s". It can be set on a pod or on its namespace, in which
+	// case it applies to every pod of the namespace that doesn't set it. The termination grace period of the
+	// pod is raised when it is too short for the drain to complete.
+	AnnotationSidecarProxyTerminationDrainDuration = "consul.hashicorp.com/sidecar-proxy-termination-drain-duration"
+
 	// annotations for sidecar volumes.
 	AnnotationConsulSidecarUserVolume      = "consul.hashicorp.com/consul-sidecar-user-volume"
 	AnnotationConsulSidecarUserVolumeMount = "consul.hashicorp.com/consul-sidecar-user-volume-mount"
 
+	// AnnotationConsulDataplaneImage is the name of a consul-dataplane image override to use for the sidecar
+	// instead of the default image. The override must be in the allowlist of image overrides configured for
+	// the connect injector. It takes precedence over LabelConsulDataplaneImage on the pod's namespace.
+	AnnotationConsulDataplaneImage = "consul.hashicorp.com/consul-dataplane-image"
+
 	// annotations for sidecar concurrency.
 	AnnotationEnvoyProxyConcurrency = "consul.hashicorp.com/consul-envoy-proxy-concurrency"
 
@@ -141,6 +210,14 @@ const (
 	AnnotationServiceMetricsPort   = "consul.hashicorp.com/service-metrics-port"
 	AnnotationServiceMetricsPath   = "consul.hashicorp.com/service-metrics-path"
 
+	// annotations for scraping the service metrics over TLS when metrics merging is enabled.
+	// AnnotationMergedMetricsAppScheme is the scheme used to scrape the service metrics, "http" or "https".
+	// AnnotationMergedMetricsAppTLSSecret is the name of a Secret in the pod's namespace with the
+	// ca.crt used, in addition to the system roots, to verify the service's certificate.
+	// consul-dataplane doesn't present a client certificate to the service.
+	AnnotationMergedMetricsAppScheme    = "consul.hashicorp.com/merged-metrics-app-scheme"
+	AnnotationMergedMetricsAppTLSSecret = "consul.hashicorp.com/merged-metrics-app-tls-secret"
+
 	// annotations for configuring TLS for Prometheus.
 	AnnotationPrometheusCAFile   = "consul.hashicorp.com/prometheus-ca-file"
 	AnnotationPrometheusCAPath   = "consul.hashicorp.com/prometheus-ca-path"
@@ -157,12 +234,27 @@ const (
 	// AnnotationConsulNamespace is the Consul namespace the service is registered into.
 	AnnotationConsulNamespace = "consul.hashicorp.com/consul-namespace"
 
+	// AnnotationConsulNamespaceOverride is the Consul namespace to register the services of the pod into
+	// instead of the namespace that its Kubernetes namespace is mirrored into or the destination namespace.
+	// It requires Consul namespaces and isn't supported with ACLs, since the ACL tokens of pods are bound
+	// to the Consul namespace of their Kubernetes namespace.
+	AnnotationConsulNamespaceOverride = "consul.hashicorp.com/consul-namespace-override"
+
 	// KeyConsulDNS enables or disables Consul DNS for a given pod. It can also be set as a label
 	// on a namespace to define the default behaviour for connect-injected pods which do not otherwise override this setting
 	// with their own annotation.
 	// This annotation/label takes a boolean value (true/false).
 	KeyConsulDNS = "consul.hashicorp.com/consul-dns"
 
+	// AnnotationConsulDNSRedirectionMode controls how DNS queries from a pod are sent to Consul DNS
+	// when Consul DNS is enabled. With "iptables", DNS traffic is redirected to consul-dataplane's DNS proxy
+	// with traffic redirection rules and requires transparent proxy. With "dns-config", the pod's dnsConfig
+	// is rewritten to use consul-dataplane's DNS proxy as its nameserver and transparent proxy is not required.
+	// With "capture", DNS traffic to every nameserver is redirected to consul-dataplane's DNS proxy with traffic
+	// redirection rules so that the pod's nameservers are left as is. It requires transparent proxy and Consul DNS
+	// must be configured with recursors to resolve names outside of Consul.
+	AnnotationConsulDNSRedirectionMode = "consul.hashicorp.com/consul-dns-redirection-mode"
+
 	// KeyTransparentProxy enables or disables transparent proxy for a given pod. It can also be set as a label
 	// on a namespace to define the default behaviour for connect-injected pods which do not otherwise override this setting
 	// with their own annotation.
@@ -181,10 +273,27 @@ const (
 	// AnnotationTProxyExcludeUIDs is a comma-separated list of additional user IDs to exclude from traffic redirection.
 	AnnotationTProxyExcludeUIDs = "consul.hashicorp.com/transparent-proxy-exclude-uids"
 
+	// AnnotationTProxyExcludeDNSCaptureCIDRs is a comma-separated list of nameserver CIDRs whose DNS traffic
+	// is not redirected to Consul DNS with the "capture" DNS redirection mode.
+	AnnotationTProxyExcludeDNSCaptureCIDRs = "consul.hashicorp.com/transparent-proxy-exclude-dns-capture-cidrs"
+
 	// AnnotationTransparentProxyOverwriteProbes controls whether the Kubernetes probes should be overwritten
 	// to point to the Envoy proxy when running in Transparent Proxy mode.
 	AnnotationTransparentProxyOverwriteProbes = "consul.hashicorp.com/transparent-proxy-overwrite-probes"
 
+	// AnnotationTransparentProxyTranslateExecProbes controls whether exec probes that make an HTTP request
+	// to the application over the loopback interface (e.g. `curl http://localhost:8080/health`) should be
+	// translated into HTTP probes, so that they are overwritten to point to the Envoy proxy like other
+	// HTTP probes when running in Transparent Proxy mode.
+	AnnotationTransparentProxyTranslateExecProbes = "consul.hashicorp.com/transparent-proxy-translate-exec-probes"
+
+	// AnnotationTransparentProxyExcludeProbePorts is a comma-separated list of container ports, by number or
+	// name, whose probes are not overwritten to point to the Envoy proxy. The ports are not excluded from
+	// inbound traffic redirection: for the kubelet to reach these probes, and HTTPS probes, which are never
+	// overwritten, the ports must also be listed in AnnotationTProxyExcludeInboundPorts. Excluding an
+	// application port bypasses mTLS for all traffic to it, not just the probes.
+	AnnotationTransparentProxyExcludeProbePorts = "consul.hashicorp.com/transparent-proxy-exclude-probe-ports"
+
 	// AnnotationRedirectTraffic stores iptables.Config information so that the CNI plugin can use it to apply
 	// iptables rules.
 	AnnotationRedirectTraffic = "consul.hashicorp.com/redirect-traffic-config"
@@ -204,10 +313,83 @@ const (
 	// AnnotationConsulK8sVersion is the current version of this binary.
 	AnnotationConsulK8sVersion = "consul.hashicorp.com/consul-k8s-version"
 
+	// AnnotationArgoRolloutsServiceResolver, when set to "true" on the pods of an Argo Rollout, makes the
+	// endpoints controller create a service-resolver with "stable" and "canary" subsets for the service
+	// if the service does not have a service-resolver yet, or add them to the service-resolver the
+	// endpoints controller created. The default subset is "stable".
+	AnnotationArgoRolloutsServiceResolver = "consul.hashicorp.com/argo-rollouts-service-resolver"
+
+	// AnnotationPrioritizeByLocality, when set to "true", makes the endpoints controller create a
+	// service-resolver for the service with the "failover" PrioritizeByLocality mode so that traffic
+	// prefers the instances in the same zone as the caller and fails over to the other zones. Setting
+	// it to "false" removes the mode again. Service-resolvers that were not created by the endpoints
+	// controller, e.g. from ServiceResolver custom resources, are not modified.
+	AnnotationPrioritizeByLocality = "consul.hashicorp.com/service-prioritize-by-locality"
+
+	// AnnotationServiceLocality overrides the locality of the service instance, which otherwise is
+	// read from the topology.kubernetes.io/region and topology.kubernetes.io/zone labels of the node.
+	// This is specified as a comma separated list of `<field>=<value>` pairs, e.g.
+	// region=dc-east,zone=rack-7. A field that isn't set keeps the value from the node.
+	AnnotationServiceLocality = "consul.hashicorp.com/service-locality"
+
+	// AnnotationPrimaryIPFamily is the IP family, IPv4 or IPv6, of the pod IP that is registered as the
+	// address of the service instances of a pod in a dual-stack cluster. It overrides the
+	// -primary-ip-family flag of the connect injector. The IPs of both families are registered as the
+	// lan_ipv4 and lan_ipv6 tagged addresses.
+	AnnotationPrimaryIPFamily = "consul.hashicorp.com/primary-ip-family"
+
+	// AnnotationNodeNamingStrategy is the node naming strategy a pod was created with. It is set by the
+	// connect injector and the API gateway controller, and decides which synthetic Consul node the service
+	// instances of the pod are registered on, since its consul-dataplane looks them up on that node.
+	// It is only set for strategies other than per-node, so pods without it use the per-node strategy.
+	AnnotationNodeNamingStrategy = "consul.hashicorp.com/node-naming-strategy"
+
+	// AnnotationPodConditionChecks is a comma-separated list of pod conditions, e.g. PodReadyToStartContainers
+	// or the condition of a readiness gate, that the endpoints controller registers as additional checks of the
+	// service instance. A check passes while its condition is True. Otherwise its status is critical, or the
+	// status following the condition, e.g. "example.com/warmed-up=warning". The supported statuses are
+	// "critical" and "warning".
+	AnnotationPodConditionChecks = "consul.hashicorp.com/pod-condition-checks"
+
+	// AnnotationServiceNamedPorts is a comma-separated list of additional ports of the service instance that
+	// the endpoints controller registers next to its port, so that they can be discovered from Consul. Each item
+	// is the name of a container port, e.g. "metrics", or a name and a port name or number, e.g. "grpc=9090".
+	// Each port is registered as the "port-<name>" tagged address and service meta key of the service instance.
+	AnnotationServiceNamedPorts = "consul.hashicorp.com/service-named-ports"
+
+	// LabelArgoRolloutsPodTemplateHash is the label Argo Rollouts adds to the pods of a Rollout. Its value
+	// is the hash of the pod template of the ReplicaSet the pod belongs to.
+	LabelArgoRolloutsPodTemplateHash = "rollouts-pod-template-hash"
+
+	// AnnotationConnectCARootID is set on the pod template of a workload by the ConnectCARotation controller
+	// to the ID of the active Connect CA root when it restarts the workload after a CA rotation.
+	AnnotationConnectCARootID = "consul.hashicorp.com/connect-ca-root-id"
+
+	// AnnotationTelemetryCollectorConfigChecksum is set on the pod template of a Deployment that mounts the
+	// collector configuration of a TelemetryCollectorConfig to the checksum of the configuration, so that
+	// the Deployment is restarted when the configuration changes.
+	AnnotationTelemetryCollectorConfigChecksum = "consul.hashicorp.com/telemetry-collector-config-checksum"
+
+	// AnnotationServiceIgnore is an annotation that can be added to a Kubernetes Service to prevent
+	// the instances of its endpoints from being registered with Consul, e.g. for injected pods that
+	// are only clients of their upstreams. Unlike LabelServiceIgnore, which is copied from the Service
+	// to its Endpoints by Kubernetes, the annotation is read from the Service itself.
+	AnnotationServiceIgnore = "consul.hashicorp.com/service-ignore"
+
 	// LabelServiceIgnore is a label that can be added to a service to prevent it from being
 	// registered with Consul.
 	LabelServiceIgnore = "consul.hashicorp.com/service-ignore"
 
+	// LabelDefaultInject is a label that can be added to a namespace to set whether its pods are injected
+	// by default, overriding the default of the connect injector. Pods can still opt in or out with the
+	// AnnotationInject annotation. This label takes a boolean value (true/false).
+	LabelDefaultInject = "consul.hashicorp.com/default-inject"
+
+	// LabelConsulDataplaneImage is a label that can be added to a namespace to use a consul-dataplane image
+	// override for the sidecars of all pods in the namespace. Its value is the name of an override in the
+	// allowlist of image overrides configured for the connect injector.
+	LabelConsulDataplaneImage = "consul.hashicorp.com/consul-dataplane-image"
+
 	// LabelPeeringToken is a label that can be added to a secret to allow it to be watched
 	// by the peering controllers.
 	LabelPeeringToken = "consul.hashicorp.com/peering-token"
@@ -217,12 +399,23 @@ const (
 	// This is only meant to be used by Deployment/consul-telemetry-collector.
 	LabelTelemetryCollector = "consul.hashicorp.com/telemetry-collector"
 
+	// AnnotationMaintenanceMode, when set to "true" on the ConfigMap of the connect injector, pauses the
+	// registration and deregistration of service instances in the Consul catalog, e.g. while the Consul
+	// servers are upgraded, so that restarting servers don't cause churn in the catalog. Pods are still injected.
+	AnnotationMaintenanceMode = "consul.hashicorp.com/maintenance-mode"
+
 	// Injected is used as the annotation value for keyInjectStatus and annotationInjected.
 	Injected = "injected"
 
 	// Enabled is used as the annotation value for keyTransparentProxyStatus.
 	Enabled = "enabled"
 
+	// DNSRedirectionModeIPTables, DNSRedirectionModeDNSConfig and DNSRedirectionModeCapture
+	// are the supported values for AnnotationConsulDNSRedirectionMode.
+	DNSRedirectionModeIPTables  = "iptables"
+	DNSRedirectionModeDNSConfig = "dns-config"
+	DNSRedirectionModeCapture   = "capture"
+
 	// ManagedByValue is the value for keyManagedBy.
 	//TODO(zalimeni) rename this to ManagedByLegacyEndpointsValue.
 	ManagedByValue = "consul-k8s-endpoints-controller"
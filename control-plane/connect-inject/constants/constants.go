@@ -56,6 +56,14 @@ const (
 	// MetaKeyPodUID is the meta key name for Kubernetes pod uid used for the Consul services.
 	MetaKeyPodUID = "pod-uid"
 
+	// MetaKeyRolloutsRole is the meta key name for the role of the pod in an Argo Rollout.
+	// The value is either "stable" or "canary".
+	MetaKeyRolloutsRole = "rollouts-role"
+
+	// MetaKeyRolloutsPodTemplateHash is the meta key name for the pod template hash of the
+	// Argo Rollouts ReplicaSet the pod belongs to.
+	MetaKeyRolloutsPodTemplateHash = "rollouts-pod-template-hash"
+
 	// DefaultGracefulPort is the default port that consul-dataplane uses for graceful shutdown.
 	DefaultGracefulPort = 20600
 
@@ -76,6 +84,18 @@ const (
 
 	KubernetesSuccessReasonMsg = "Kubernetes health checks passing"
 
+	// ConsulKubernetesPodConditionCheckType is the type of health check in Consul for the status of a
+	// pod condition other than Ready.
+	ConsulKubernetesPodConditionCheckType = "kubernetes-pod-condition"
+
+	// ProxyDefaultMeshPortName is the name of the workload port that the proxy accepts mesh
+	// traffic on when Consul resource APIs are enabled.
+	ProxyDefaultMeshPortName = "mesh"
+
+	// UnnamedWorkloadPortNamePrefix is prepended to the port number of a container port without
+	// a name to build a workload port name when Consul resource APIs are enabled.
+	UnnamedWorkloadPortNamePrefix = "cslport-"
+
 	// MeshV2VolumePath is the name of the volume that contains the proxy ID.
 	MeshV2VolumePath = "/consul/mesh-inject"
 
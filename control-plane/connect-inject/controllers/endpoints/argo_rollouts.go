@@ -0,0 +1,92 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package endpoints
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/hashicorp/consul/api"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+
+	"github.com/hashicorp/consul-k8s/control-plane/connect-inject/constants"
+)
+
+const (
+	rolloutsRoleStable = "stable"
+	rolloutsRoleCanary = "canary"
+)
+
+// rolloutGVK is the kind of the Argo Rollouts custom resource.
+var rolloutGVK = schema.GroupVersionKind{Group: "argoproj.io", Version: "v1alpha1", Kind: "Rollout"}
+
+// rolloutsRole returns the role of pod in its Argo Rollout and the pod template hash of the pod's
+// ReplicaSet. The role is "stable" if the ReplicaSet is the stable ReplicaSet of the Rollout and
+// "canary" otherwise. An empty role is returned if the pod is not part of a Rollout or the Rollout
+// can't be read.
+func (r *Controller) rolloutsRole(ctx context.Context, pod corev1.Pod) (string, string) {
+	hash := pod.Labels[constants.LabelArgoRolloutsPodTemplateHash]
+	if hash == "" {
+		return "", ""
+	}
+
+	// Argo Rollouts names the ReplicaSets of a Rollout <rollout name>-<pod template hash>,
+	// so the Rollout can be found without reading the ReplicaSet.
+	var rolloutName string
+	for _, ref := range pod.OwnerReferences {
+		if ref.Kind == "ReplicaSet" && strings.HasSuffix(ref.Name, "-"+hash) {
+			rolloutName = strings.TrimSuffix(ref.Name, "-"+hash)
+			break
+		}
+	}
+	if rolloutName == "" {
+		return "", ""
+	}
+
+	rollout := &unstructured.Unstructured{}
+	rollout.SetGroupVersionKind(rolloutGVK)
+	if err := r.Client.Get(ctx, types.NamespacedName{Name: rolloutName, Namespace: pod.Namespace}, rollout); err != nil {
+		r.Log.Error(err, "failed to get Argo Rollout for pod", "name", pod.Name, "ns", pod.Namespace, "rollout", rolloutName)
+		return "", ""
+	}
+
+	stableRS, _, _ := unstructured.NestedString(rollout.Object, "status", "stableRS")
+	if stableRS == hash {
+		return rolloutsRoleStable, hash
+	}
+	return rolloutsRoleCanary, hash
+}
+
+// ensureRolloutsServiceResolver creates a service-resolver for service with a subset for each
+// Argo Rollouts role, unless the service already has a service-resolver. Existing service-resolvers
+// are only given the subsets if they were created by the endpoints controller, e.g. to prioritize
+// by locality, so that they can be managed with ServiceResolver custom resources instead.
+func (r *Controller) ensureRolloutsServiceResolver(apiClient *api.Client, service *api.AgentService) error {
+	return r.updateServiceResolver(apiClient, service, func(entry *api.ServiceResolverConfigEntry) bool {
+		_, hasStable := entry.Subsets[rolloutsRoleStable]
+		_, hasCanary := entry.Subsets[rolloutsRoleCanary]
+		if hasStable && hasCanary {
+			return false
+		}
+		if entry.Subsets == nil {
+			entry.Subsets = make(map[string]api.ServiceResolverSubset)
+		}
+		entry.Subsets[rolloutsRoleStable] = api.ServiceResolverSubset{Filter: rolloutsSubsetFilter(rolloutsRoleStable)}
+		entry.Subsets[rolloutsRoleCanary] = api.ServiceResolverSubset{Filter: rolloutsSubsetFilter(rolloutsRoleCanary)}
+		if entry.DefaultSubset == "" {
+			entry.DefaultSubset = rolloutsRoleStable
+		}
+		r.Log.Info("adding Argo Rollouts subsets to service-resolver", "name", service.Service, "ns", service.Namespace)
+		return true
+	})
+}
+
+// rolloutsSubsetFilter returns the service-resolver subset filter selecting the instances with role.
+func rolloutsSubsetFilter(role string) string {
+	return fmt.Sprintf(`Service.Meta[%q] == %q`, constants.MetaKeyRolloutsRole, role)
+}
@@ -0,0 +1,183 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package endpoints
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	logrtest "github.com/go-logr/logr/testr"
+	"github.com/hashicorp/consul/api"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	"github.com/hashicorp/consul-k8s/control-plane/connect-inject/constants"
+)
+
+func TestRolloutsRole(t *testing.T) {
+	t.Parallel()
+	rollout := &unstructured.Unstructured{}
+	rollout.SetGroupVersionKind(rolloutGVK)
+	rollout.SetName("web")
+	rollout.SetNamespace("default")
+	require.NoError(t, unstructured.SetNestedField(rollout.Object, "stablehash", "status", "stableRS"))
+
+	cases := map[string]struct {
+		hash       string
+		replicaSet string
+		expRole    string
+		expHash    string
+	}{
+		"stable pod": {
+			hash:       "stablehash",
+			replicaSet: "web-stablehash",
+			expRole:    rolloutsRoleStable,
+			expHash:    "stablehash",
+		},
+		"canary pod": {
+			hash:       "canaryhash",
+			replicaSet: "web-canaryhash",
+			expRole:    rolloutsRoleCanary,
+			expHash:    "canaryhash",
+		},
+		"pod not managed by a rollout": {
+			replicaSet: "web-7d4b9c",
+		},
+		"rollout does not exist": {
+			hash:       "stablehash",
+			replicaSet: "api-stablehash",
+		},
+	}
+
+	for name, c := range cases {
+		t.Run(name, func(t *testing.T) {
+			pod := createServicePod("pod1", "1.2.3.4", true, true)
+			if c.hash != "" {
+				pod.Labels[constants.LabelArgoRolloutsPodTemplateHash] = c.hash
+			}
+			pod.OwnerReferences = []metav1.OwnerReference{{APIVersion: "apps/v1", Kind: "ReplicaSet", Name: c.replicaSet}}
+
+			fakeClient := fake.NewClientBuilder().WithObjects(rollout.DeepCopy()).Build()
+			ep := &Controller{Client: fakeClient, Log: logrtest.New(t)}
+
+			role, hash := ep.rolloutsRole(context.Background(), *pod)
+			require.Equal(t, c.expRole, role)
+			require.Equal(t, c.expHash, hash)
+		})
+	}
+}
+
+func TestEnsureRolloutsServiceResolver(t *testing.T) {
+	t.Parallel()
+	service := &api.AgentService{Service: "web"}
+
+	t.Run("creates a service-resolver", func(t *testing.T) {
+		var written *api.ServiceResolverConfigEntry
+		consulServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			switch {
+			case r.Method == http.MethodGet && r.URL.Path == "/v1/config/service-resolver/web":
+				w.WriteHeader(http.StatusNotFound)
+			case r.Method == http.MethodPut && r.URL.Path == "/v1/config":
+				require.Equal(t, "0", r.URL.Query().Get("cas"))
+				written = &api.ServiceResolverConfigEntry{}
+				require.NoError(t, json.NewDecoder(r.Body).Decode(written))
+				w.Write([]byte("true"))
+			default:
+				t.Errorf("unexpected request %s %s", r.Method, r.URL.Path)
+			}
+		}))
+		defer consulServer.Close()
+		apiClient, err := api.NewClient(&api.Config{Address: consulServer.URL})
+		require.NoError(t, err)
+
+		ep := &Controller{Log: logrtest.New(t)}
+		require.NoError(t, ep.ensureRolloutsServiceResolver(apiClient, service))
+		require.NotNil(t, written)
+		require.Equal(t, "web", written.Name)
+		require.Equal(t, rolloutsRoleStable, written.DefaultSubset)
+		require.Equal(t, map[string]api.ServiceResolverSubset{
+			"stable": {Filter: `Service.Meta["rollouts-role"] == "stable"`},
+			"canary": {Filter: `Service.Meta["rollouts-role"] == "canary"`},
+		}, written.Subsets)
+		require.Equal(t, metaValueServiceResolverManagedBy, written.Meta[metaKeyManagedBy])
+	})
+
+	t.Run("keeps an existing service-resolver", func(t *testing.T) {
+		consulServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if r.Method != http.MethodGet {
+				t.Errorf("unexpected request %s %s", r.Method, r.URL.Path)
+				return
+			}
+			w.Write([]byte(`{"Kind": "service-resolver", "Name": "web"}`))
+		}))
+		defer consulServer.Close()
+		apiClient, err := api.NewClient(&api.Config{Address: consulServer.URL})
+		require.NoError(t, err)
+
+		ep := &Controller{Log: logrtest.New(t)}
+		require.NoError(t, ep.ensureRolloutsServiceResolver(apiClient, service))
+	})
+
+	t.Run("adds the subsets to a service-resolver created by the endpoints controller", func(t *testing.T) {
+		var written *api.ServiceResolverConfigEntry
+		consulServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			switch {
+			case r.Method == http.MethodGet && r.URL.Path == "/v1/config/service-resolver/web":
+				w.Write([]byte(`{"Kind": "service-resolver", "Name": "web", "PrioritizeByLocality": {"Mode": "failover"}, "Meta": {"managed-by": "consul-k8s-endpoints-controller"}, "ModifyIndex": 10}`))
+			case r.Method == http.MethodPut && r.URL.Path == "/v1/config":
+				require.Equal(t, "10", r.URL.Query().Get("cas"))
+				written = &api.ServiceResolverConfigEntry{}
+				require.NoError(t, json.NewDecoder(r.Body).Decode(written))
+				w.Write([]byte("true"))
+			default:
+				t.Errorf("unexpected request %s %s", r.Method, r.URL.Path)
+			}
+		}))
+		defer consulServer.Close()
+		apiClient, err := api.NewClient(&api.Config{Address: consulServer.URL})
+		require.NoError(t, err)
+
+		ep := &Controller{Log: logrtest.New(t)}
+		require.NoError(t, ep.ensureRolloutsServiceResolver(apiClient, service))
+		require.NotNil(t, written)
+		require.Equal(t, rolloutsRoleStable, written.DefaultSubset)
+		require.Len(t, written.Subsets, 2)
+		require.Equal(t, &api.ServiceResolverPrioritizeByLocality{Mode: "failover"}, written.PrioritizeByLocality)
+	})
+}
+
+func TestCreateServiceRegistrations_ArgoRollouts(t *testing.T) {
+	t.Parallel()
+	rollout := &unstructured.Unstructured{}
+	rollout.SetGroupVersionKind(rolloutGVK)
+	rollout.SetName("web")
+	rollout.SetNamespace("default")
+	require.NoError(t, unstructured.SetNestedField(rollout.Object, "stablehash", "status", "stableRS"))
+
+	pod := createServicePod("pod1", "1.2.3.4", true, true)
+	pod.Labels[constants.LabelArgoRolloutsPodTemplateHash] = "canaryhash"
+	pod.OwnerReferences = []metav1.OwnerReference{{APIVersion: "apps/v1", Kind: "ReplicaSet", Name: "web-canaryhash"}}
+	endpoints := corev1.Endpoints{ObjectMeta: metav1.ObjectMeta{Name: "web", Namespace: "default"}}
+	ns := corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "default"}}
+
+	for _, enabled := range []bool{false, true} {
+		fakeClient := fake.NewClientBuilder().WithObjects(rollout.DeepCopy(), &ns).Build()
+		ep := &Controller{Client: fakeClient, Log: logrtest.New(t), EnableArgoRollouts: enabled}
+
+		serviceRegistration, _, err := ep.createServiceRegistrations(*pod, endpoints, api.HealthPassing)
+		require.NoError(t, err)
+		if enabled {
+			require.Equal(t, rolloutsRoleCanary, serviceRegistration.Service.Meta[constants.MetaKeyRolloutsRole])
+			require.Equal(t, "canaryhash", serviceRegistration.Service.Meta[constants.MetaKeyRolloutsPodTemplateHash])
+		} else {
+			require.NotContains(t, serviceRegistration.Service.Meta, constants.MetaKeyRolloutsRole)
+		}
+	}
+}
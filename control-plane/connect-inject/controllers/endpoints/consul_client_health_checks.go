@@ -86,7 +86,7 @@ func (r *Controller) consulClientCfgForNodeAgent(serverClient *api.Client, pod c
 		}
 	}
 	if r.EnableConsulNamespaces {
-		ccCfg.Namespace = r.consulNamespace(pod.Namespace)
+		ccCfg.Namespace = r.podConsulNamespace(pod)
 	}
 	return ccCfg, nil
 }
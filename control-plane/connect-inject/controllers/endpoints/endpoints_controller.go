@@ -9,26 +9,37 @@ import (
 	"math"
 	"net"
 	"regexp"
+	"sort"
 	"strconv"
 	"strings"
+	"sync"
 	"time"
 
 	mapset "github.com/deckarep/golang-set"
 	"github.com/go-logr/logr"
+	"github.com/hashicorp/consul-server-connection-manager/discovery"
 	"github.com/hashicorp/consul/api"
 	"github.com/hashicorp/go-multierror"
+	"golang.org/x/time/rate"
 	corev1 "k8s.io/api/core/v1"
 	k8serrors "k8s.io/apimachinery/pkg/api/errors"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/types"
 	"k8s.io/apimachinery/pkg/util/intstr"
+	"k8s.io/client-go/tools/record"
 	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/builder"
 	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/event"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	"sigs.k8s.io/controller-runtime/pkg/manager"
+	"sigs.k8s.io/controller-runtime/pkg/predicate"
 
 	"github.com/hashicorp/consul-k8s/control-plane/connect-inject/common"
 	"github.com/hashicorp/consul-k8s/control-plane/connect-inject/constants"
 	"github.com/hashicorp/consul-k8s/control-plane/connect-inject/lifecycle"
 	"github.com/hashicorp/consul-k8s/control-plane/connect-inject/metrics"
+	"github.com/hashicorp/consul-k8s/control-plane/connect-inject/sharding"
 	"github.com/hashicorp/consul-k8s/control-plane/consul"
 	"github.com/hashicorp/consul-k8s/control-plane/helper/parsetags"
 	"github.com/hashicorp/consul-k8s/control-plane/namespaces"
@@ -36,6 +47,10 @@ import (
 
 const (
 	metaKeyKubeServiceName = "k8s-service-name"
+	// metaKeyKubeSessionAffinity and metaKeyKubeSessionAffinityTimeout record the session affinity
+	// of the Kubernetes Service so that it can be mirrored by the load balancing of the mesh.
+	metaKeyKubeSessionAffinity        = "k8s-session-affinity"
+	metaKeyKubeSessionAffinityTimeout = "k8s-session-affinity-timeout-seconds"
 
 	metaKeyManagedBy           = "managed-by"
 	metaKeySyntheticNode       = "synthetic-node"
@@ -56,10 +71,77 @@ const (
 	// in Consul. Note: This value should not be changed without a corresponding change in Consul.
 	clusterIPTaggedAddressName = "virtual"
 
+	// lanIPv4TaggedAddressName and lanIPv6TaggedAddressName are the keys of the tagged addresses that store
+	// the IPs of each family of dual-stack pods. These are the keys Consul uses for the addresses of a family.
+	lanIPv4TaggedAddressName = "lan_ipv4"
+	lanIPv6TaggedAddressName = "lan_ipv6"
+
 	// consulNodeAddress is the address of the consul node (defined by ConsulNodeName).
 	// This address does not need to be routable as this node is ephemeral, and we're only providing it because
 	// Consul's API currently requires node address to be provided when registering a node.
 	consulNodeAddress = "127.0.0.1"
+
+	// grpcHealthCheckPath is the path of the Check method of the standard gRPC health service that gRPC probes call.
+	grpcHealthCheckPath = "/grpc.health.v1.Health/Check"
+
+	// maintenanceModeRequeue is how long reconciles are delayed while maintenance mode is enabled.
+	maintenanceModeRequeue = 30 * time.Second
+)
+
+// deregisterReason explains why a service instance was deregistered from Consul. It is
+// included in the audit log and used as the reason of the Event recorded on the Kubernetes Service.
+type deregisterReason string
+
+const (
+	// reasonEndpointsDeleted is used when the Endpoints object of the Kubernetes Service was deleted.
+	reasonEndpointsDeleted deregisterReason = "EndpointsDeleted"
+	// reasonServiceIgnored is used when the Endpoints object is labeled, or its Service is annotated,
+	// with consul.hashicorp.com/service-ignore.
+	reasonServiceIgnored deregisterReason = "ServiceIgnored"
+	// reasonEndpointRemoved is used when the address of the instance is no longer in the Endpoints object.
+	reasonEndpointRemoved deregisterReason = "EndpointRemoved"
+	// reasonPodDeleted is used when the pod backing the instance no longer exists.
+	reasonPodDeleted deregisterReason = "PodDeleted"
+	// reasonPodUIDChanged is used when a pod with the same name but a different UID replaced the pod
+	// backing the instance, e.g. during a StatefulSet rollout.
+	reasonPodUIDChanged deregisterReason = "PodUIDChanged"
+	// reasonNodeChanged is used when the pod backing the instance is now scheduled on a different node.
+	reasonNodeChanged deregisterReason = "NodeChanged"
+	// reasonServiceAnnotationMismatch is used when the pod's consul.hashicorp.com/kubernetes-service
+	// annotation names a different Kubernetes Service.
+	reasonServiceAnnotationMismatch deregisterReason = "ServiceAnnotationMismatch"
+	// reasonMeshAnnotationRemoved is used when the pod backing the instance is no longer part of the mesh.
+	reasonMeshAnnotationRemoved deregisterReason = "MeshAnnotationRemoved"
+	// reasonPodCompleted is used when the pod backing the instance runs to completion, e.g. the pod of a
+	// Kubernetes Job, and has completed.
+	reasonPodCompleted deregisterReason = "PodCompleted"
+	// reasonOrphaned is used when the orphan reaper finds an instance whose pod no longer exists,
+	// e.g. because a watch event was missed or the controller crashed before deregistering it.
+	reasonOrphaned deregisterReason = "Orphaned"
+	// reasonPartitionChanged is used when the partition mapping moved the Kubernetes namespace of the
+	// instance to another Admin Partition.
+	reasonPartitionChanged deregisterReason = "PartitionChanged"
+)
+
+const (
+	// reasonInvalidServicePorts is the reason of the Warning Event recorded on a pod whose
+	// consul.hashicorp.com/connect-service-ports annotation is invalid.
+	reasonInvalidServicePorts = "InvalidServicePorts"
+	// reasonInvalidServiceWeight is the reason of the Warning Event recorded on a pod whose
+	// consul.hashicorp.com/service-weight annotation is invalid.
+	reasonInvalidServiceWeight = "InvalidServiceWeight"
+	// reasonInvalidPodConditionChecks is the reason of the Warning Event recorded on a pod whose
+	// consul.hashicorp.com/pod-condition-checks annotation is invalid.
+	reasonInvalidPodConditionChecks = "InvalidPodConditionChecks"
+	// reasonInvalidServiceNamedPorts is the reason of the Warning Event recorded on a pod whose
+	// consul.hashicorp.com/service-named-ports annotation is invalid.
+	reasonInvalidServiceNamedPorts = "InvalidServiceNamedPorts"
+	// reasonInvalidServiceLocality is the reason of the Warning Event recorded on a pod whose
+	// consul.hashicorp.com/service-locality annotation is invalid.
+	reasonInvalidServiceLocality = "InvalidServiceLocality"
+	// reasonInvalidPrimaryIPFamily is the reason of the Warning Event recorded on a pod whose
+	// consul.hashicorp.com/primary-ip-family annotation is invalid.
+	reasonInvalidPrimaryIPFamily = "InvalidPrimaryIPFamily"
 )
 
 type Controller struct {
@@ -75,6 +157,9 @@ type Controller struct {
 	// EnableConsulPartitions indicates that a user is running Consul Enterprise
 	// with version 1.11+ which supports Admin Partitions.
 	EnableConsulPartitions bool
+	// PartitionMapping, if set, maps Kubernetes namespaces to the Admin Partitions their
+	// services are registered in. Unmapped namespaces use the partition of ConsulClientConfig.
+	PartitionMapping *common.PartitionMapping
 	// EnableConsulNamespaces indicates that a user is running Consul Enterprise
 	// with version 1.7+ which supports namespaces.
 	EnableConsulNamespaces bool
@@ -123,8 +208,65 @@ type Controller struct {
 	// with config to enable telemetry forwarding.
 	EnableTelemetryCollector bool
 
+	// ServiceMetaFromLabels maps the keys of pod labels to the keys of the service metadata that
+	// are set to the values of the labels. It doesn't override the metadata set by consul-k8s, and
+	// the metadata set by the consul.hashicorp.com/service-meta- annotations takes precedence.
+	ServiceMetaFromLabels map[string]string
+	// ServiceMetaFromNodeLabels maps the keys of the labels of the node a pod runs on, e.g.
+	// topology.kubernetes.io/rack, to the keys of the service metadata that are set to the values
+	// of the labels. The metadata from pod labels and annotations takes precedence.
+	ServiceMetaFromNodeLabels map[string]string
+	// ServiceTagsFromLabels maps the keys of pod labels to the prefixes of the tags that are added
+	// to the service with the values of the labels, e.g. "version-" to add the tag "version-1.2.3".
+	ServiceTagsFromLabels map[string]string
+
+	// EnableArgoRollouts controls whether the service instances of pods managed by an Argo Rollout
+	// are registered with their role in the Rollout ("stable" or "canary") in their metadata.
+	EnableArgoRollouts bool
+
+	// MaxConcurrentReconciles is the number of Services whose endpoints are reconciled concurrently.
+	// Defaults to 1.
+	MaxConcurrentReconciles int
+	// ConsulWriteLimiter, if set, limits the rate of catalog and ACL writes to Consul.
+	ConsulWriteLimiter *rate.Limiter
+
+	// GatewayWANAddressResolvePeriod, if set, registers the hostnames of the LoadBalancer Services of
+	// gateways whose WAN address source is Service as the IP addresses they resolve to, and resolves
+	// them again on this interval.
+	GatewayWANAddressResolvePeriod time.Duration
+	// GatewayWANAddressHealthCheckTimeout, if set, is the timeout of the TCP health checks of the
+	// LoadBalancer ingress addresses of gateways whose WAN address source is Service. The first
+	// healthy address is registered as the WAN address.
+	GatewayWANAddressHealthCheckTimeout time.Duration
+
+	// OrphanReapInterval, if set, is how often the leader lists every service instance registered by
+	// consul-k8s in the Consul catalog and deregisters the instances whose pods no longer exist.
+	OrphanReapInterval time.Duration
+	// OrphanReapDryRun causes the orphan reaper to only log the instances it would deregister.
+	OrphanReapDryRun bool
+
+	// MaintenanceMode, if set, pauses the registrations and deregistrations of service instances
+	// and the orphan reaper while it is enabled, e.g. while the Consul servers are upgraded. The
+	// Endpoints are reconciled once it is disabled.
+	MaintenanceMode *common.MaintenanceMode
+
+	// PendingRegistrations, if set, durably queues the Endpoints that can't be reconciled while the
+	// Consul servers are unreachable, and reconciles them as soon as the servers are reachable again.
+	PendingRegistrations *common.PendingRegistrations
+
+	// PrimaryIPFamily is the IP family of the pod IP that is registered as the address of service
+	// instances in dual-stack clusters. If empty, the primary IP of the pod is registered.
+	PrimaryIPFamily corev1.IPFamily
+
+	// Shard, if set, is the shard of Kubernetes namespaces this replica owns. Only the endpoints in
+	// those namespaces are reconciled, and every replica runs the controller instead of only the leader.
+	Shard *sharding.Shard
+
 	MetricsConfig metrics.Config
 	Log           logr.Logger
+	// EventRecorder, if set, records an Event on the Kubernetes Service every time
+	// a service instance is deregistered from Consul.
+	EventRecorder record.EventRecorder
 
 	Scheme *runtime.Scheme
 	context.Context
@@ -132,11 +274,43 @@ type Controller struct {
 	// consulClientHttpPort is only used in tests.
 	consulClientHttpPort int
 	NodeMeta             map[string]string
+
+	// resolver and dial are only set in tests.
+	resolver hostResolver
+	dial     dialFunc
+
+	// orphanSuspects are the keys of the service instances whose pods didn't exist in the last
+	// sweep of the orphan reaper. It is only accessed by the orphan reaper.
+	orphanSuspects map[string]struct{}
+
+	// consulNamespaceOverrides are the Consul namespaces, other than the namespace of their
+	// Kubernetes namespace, that the pods of each Kubernetes Service registered their service
+	// instances in with the override annotation, so that those instances are deregistered too.
+	// Instances left behind by a previous run of the controller are deregistered by the orphan reaper.
+	consulNamespaceOverrides   map[types.NamespacedName]map[string]struct{}
+	consulNamespaceOverridesMu sync.Mutex
+
+	// nodeLocks are the locks of the Consul nodes that reconciles are registering service instances
+	// on or deregistering.
+	nodeLocks   map[string]*nodeLock
+	nodeLocksMu sync.Mutex
 }
 
 // Reconcile reads the state of an Endpoints object for a Kubernetes Service and reconciles Consul services which
 // correspond to the Kubernetes Service. These events are driven by changes to the Pods backing the Kube service.
+// Endpoints that can't be reconciled because the Consul servers are unreachable are added to the pending
+// registrations and removed once they are reconciled.
 func (r *Controller) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
+	result, err := r.reconcile(ctx, req)
+	if _, unreachable := consul.RetryAfterOpenCircuit(err); unreachable {
+		r.PendingRegistrations.Add(ctx, req.NamespacedName)
+	} else if err == nil && r.ownsNamespace(req.Namespace) && !r.MaintenanceMode.Enabled() {
+		r.PendingRegistrations.Done(ctx, req.NamespacedName)
+	}
+	return consul.ReconcileBackoff(result, err)
+}
+
+func (r *Controller) reconcile(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
 	var errs error
 	var serviceEndpoints corev1.Endpoints
 
@@ -144,6 +318,20 @@ func (r *Controller) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Resu
 	if common.ShouldIgnore(req.Namespace, r.DenyK8sNamespacesSet, r.AllowK8sNamespacesSet) {
 		return ctrl.Result{}, nil
 	}
+	// Ignore the request if the namespace is owned by another replica.
+	if !r.ownsNamespace(req.Namespace) {
+		return ctrl.Result{}, nil
+	}
+	// Don't change the catalog during a maintenance window of the Consul servers.
+	if r.MaintenanceMode.Enabled() {
+		r.Log.V(1).Info("maintenance mode is enabled, retrying later", "name", req.Name, "ns", req.Namespace)
+		return ctrl.Result{RequeueAfter: maintenanceModeRequeue}, nil
+	}
+	// Back off while the Consul servers are unreachable rather than failing every request of the reconcile.
+	if retryAfter := r.consulClientConfig(req.Namespace).RetryAfter(); retryAfter > 0 {
+		r.Log.V(1).Info("Consul servers are unreachable, retrying later", "name", req.Name, "ns", req.Namespace, "retryAfter", retryAfter)
+		return ctrl.Result{}, &consul.CircuitOpenError{RetryAfter: retryAfter}
+	}
 
 	// Create Consul client for this reconcile.
 	serverState, err := r.ConsulServerConnMgr.State()
@@ -151,12 +339,17 @@ func (r *Controller) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Resu
 		r.Log.Error(err, "failed to get Consul server state", "name", req.Name, "ns", req.Namespace)
 		return ctrl.Result{}, err
 	}
-	apiClient, err := consul.NewClientFromConnMgrState(r.ConsulClientConfig, serverState)
+	apiClient, err := consul.NewClientFromConnMgrState(r.consulClientConfig(req.Namespace), serverState)
 	if err != nil {
 		r.Log.Error(err, "failed to create Consul API client", "name", req.Name, "ns", req.Namespace)
 		return ctrl.Result{}, err
 	}
 
+	// Deregister the instances left in the partitions the namespace was mapped to before.
+	if requeueAfter, err := r.deregisterFromPreviousPartitions(ctx, serverState, req.NamespacedName); err != nil || requeueAfter > 0 {
+		return ctrl.Result{RequeueAfter: requeueAfter}, err
+	}
+
 	err = r.Client.Get(ctx, req.NamespacedName, &serviceEndpoints)
 
 	// If the endpoints object has been deleted (and we get an IsNotFound
@@ -164,7 +357,10 @@ func (r *Controller) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Resu
 	if k8serrors.IsNotFound(err) {
 		// Deregister all instances in Consul for this service. The function deregisterService handles
 		// the case where the Consul service name is different from the Kubernetes service name.
-		requeueAfter, err := r.deregisterService(ctx, apiClient, req.Name, req.Namespace, nil)
+		requeueAfter, err := r.deregisterService(ctx, apiClient, req.Name, req.Namespace, nil, reasonEndpointsDeleted)
+		if err == nil && requeueAfter == 0 {
+			r.forgetConsulNamespaceOverrides(req.NamespacedName)
+		}
 		return ctrl.Result{RequeueAfter: requeueAfter}, err
 	} else if err != nil {
 		r.Log.Error(err, "failed to get Endpoints", "name", req.Name, "ns", req.Namespace)
@@ -178,10 +374,26 @@ func (r *Controller) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Resu
 	if isLabeledIgnore(serviceEndpoints.Labels) {
 		// We always deregister the service to handle the case where a user has registered the service, then added the label later.
 		r.Log.Info("ignoring endpoint labeled with `consul.hashicorp.com/service-ignore: \"true\"`", "name", req.Name, "namespace", req.Namespace)
-		requeueAfter, err := r.deregisterService(ctx, apiClient, req.Name, req.Namespace, nil)
+		requeueAfter, err := r.deregisterService(ctx, apiClient, req.Name, req.Namespace, nil, reasonServiceIgnored)
+		return ctrl.Result{RequeueAfter: requeueAfter}, err
+	}
+
+	// The Service can be annotated to ignore its endpoints too. Endpoints without a Service, e.g. those
+	// managed by hand, are registered as usual.
+	ignored, err := r.isServiceAnnotatedIgnore(ctx, req.NamespacedName)
+	if err != nil {
+		r.Log.Error(err, "failed to get Service", "name", req.Name, "ns", req.Namespace)
+		return ctrl.Result{}, err
+	}
+	if ignored {
+		r.Log.Info("ignoring endpoint of service annotated with `consul.hashicorp.com/service-ignore: \"true\"`", "name", req.Name, "namespace", req.Namespace)
+		requeueAfter, err := r.deregisterService(ctx, apiClient, req.Name, req.Namespace, nil, reasonServiceIgnored)
 		return ctrl.Result{RequeueAfter: requeueAfter}, err
 	}
 
+	// wanAddressResync is how long to wait before resolving the WAN addresses of the gateways again.
+	var wanAddressResync time.Duration
+
 	// deregisterEndpointAddress stores every IP that corresponds to a Pod in the Endpoints object. It is used to compare
 	// against service instances in Consul to deregister them if they are not in the map.
 	deregisterEndpointAddress := map[string]bool{}
@@ -215,6 +427,23 @@ func (r *Controller) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Resu
 					continue
 				}
 
+				// The service instances of pods that run to completion, e.g. the pods of Kubernetes Jobs, are
+				// deregistered as soon as they complete. Until then they are healthy regardless of the readiness
+				// of the pod since the application doesn't need to serve traffic to make progress.
+				job, err := common.IsJob(pod)
+				if err != nil {
+					r.Log.Error(err, "failed to determine if pod is a job", "name", pod.Name, "ns", pod.Namespace)
+					errs = multierror.Append(errs, err)
+				}
+				if job {
+					if common.JobCompleted(pod) {
+						r.Log.Info("deregistering completed job pod", "name", pod.Name, "ns", pod.Namespace)
+						deregisterEndpointAddress[address.IP] = true
+						continue
+					}
+					healthStatus = api.HealthPassing
+				}
+
 				if isTelemetryCollector(pod) {
 					if err = r.ensureNamespaceExists(apiClient, pod); err != nil {
 						r.Log.Error(err, "failed to ensure a namespace exists for Consul Telemetry Collector")
@@ -229,7 +458,9 @@ func (r *Controller) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Resu
 							errs = multierror.Append(errs, err)
 						}
 						// Build the deregisterEndpointAddress map up for deregistering service instances later.
-						deregisterEndpointAddress[pod.Status.PodIP] = false
+						for _, ip := range podIPs(pod) {
+							deregisterEndpointAddress[ip] = false
+						}
 					} else {
 						r.Log.Info("detected an update to pre-consul-dataplane service", "name", serviceEndpoints.Name, "ns", serviceEndpoints.Namespace)
 						nodeAgentClientCfg, err := r.consulClientCfgForNodeAgent(apiClient, pod, serverState)
@@ -257,7 +488,12 @@ func (r *Controller) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Resu
 						errs = multierror.Append(errs, err)
 					}
 					// Build the deregisterEndpointAddress map up for deregistering service instances later.
-					deregisterEndpointAddress[pod.Status.PodIP] = false
+					for _, ip := range podIPs(pod) {
+						deregisterEndpointAddress[ip] = false
+					}
+					if period := r.wanAddressResyncPeriod(pod); period > 0 {
+						wanAddressResync = period
+					}
 				}
 			}
 		}
@@ -266,11 +502,14 @@ func (r *Controller) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Resu
 	// Compare service instances in Consul with addresses in Endpoints. If an address is not in Endpoints, deregister
 	// from Consul. This uses deregisterEndpointAddress which is populated with the addresses in the Endpoints object to
 	// either deregister or keep during the registration codepath.
-	requeueAfter, err := r.deregisterService(ctx, apiClient, serviceEndpoints.Name, serviceEndpoints.Namespace, deregisterEndpointAddress)
+	requeueAfter, err := r.deregisterService(ctx, apiClient, serviceEndpoints.Name, serviceEndpoints.Namespace, deregisterEndpointAddress, reasonEndpointRemoved)
 	if err != nil {
 		r.Log.Error(err, "failed to deregister endpoints", "name", serviceEndpoints.Name, "ns", serviceEndpoints.Namespace)
 		errs = multierror.Append(errs, err)
 	}
+	if wanAddressResync > 0 && (requeueAfter == 0 || wanAddressResync < requeueAfter) {
+		requeueAfter = wanAddressResync
+	}
 
 	return ctrl.Result{RequeueAfter: requeueAfter}, errs
 }
@@ -280,36 +519,80 @@ func (r *Controller) Logger(name types.NamespacedName) logr.Logger {
 }
 
 func (r *Controller) SetupWithManager(mgr ctrl.Manager) error {
-	return ctrl.NewControllerManagedBy(mgr).
+	if r.OrphanReapInterval > 0 {
+		// Runnables that don't implement LeaderElectionRunnable only run on the leader. When sharded,
+		// every replica reaps the orphans of its own namespaces.
+		var reaper manager.Runnable = manager.RunnableFunc(r.reapOrphansPeriodically)
+		if r.Shard != nil {
+			reaper = replicaRunnable{reaper}
+		}
+		if err := mgr.Add(reaper); err != nil {
+			return err
+		}
+	}
+	b := ctrl.NewControllerManagedBy(mgr).
 		For(&corev1.Endpoints{}).
-		Complete(r)
+		// The WAN address of gateways exposed by a LoadBalancer Service is read from the Service,
+		// so the gateways are registered again when its load balancer changes.
+		Watches(&corev1.Service{}, handler.EnqueueRequestsFromMapFunc(r.transformLoadBalancerService),
+			builder.WithPredicates(loadBalancerIngressChanged)).
+		// The endpoints of a Service are registered or deregistered when its ignore annotation changes.
+		Watches(&corev1.Service{}, &handler.EnqueueRequestForObject{},
+			builder.WithPredicates(serviceIgnoreAnnotationChanged)).
+		// Pod conditions other than Ready don't change the Endpoints object, so the checks that
+		// reflect them are updated when the pods change.
+		Watches(&corev1.Pod{}, handler.EnqueueRequestsFromMapFunc(r.transformPodConditions),
+			builder.WithPredicates(podConditionsChanged))
+	if r.Shard != nil {
+		// The endpoints of the namespaces this replica gains are reconciled when its shard changes.
+		b = b.WatchesRawSource(r.Shard.Source(), handler.EnqueueRequestsFromMapFunc(r.endpointsInShard))
+	}
+	if r.PendingRegistrations != nil {
+		// The pending registrations are reconciled as soon as the Consul servers are reachable again.
+		b = b.WatchesRawSource(r.PendingRegistrations.Source(), &handler.EnqueueRequestForObject{})
+	}
+	return b.WithOptions(r.controllerOptions()).Complete(r)
 }
 
 // registerServicesAndHealthCheck creates Consul registrations for the service and proxy and registers them with Consul.
 // It also upserts a Kubernetes health check for the service based on whether the endpoint address is ready.
-func (r *Controller) registerServicesAndHealthCheck(apiClient *api.Client, pod corev1.Pod, serviceEndpoints corev1.Endpoints, healthStatus string) error {
+func (r *Controller) registerServicesAndHealthCheck(apiClient *api.Client, pod corev1.Pod, serviceEndpoints corev1.Endpoints, healthStatus string) (err error) {
 	var managedByEndpointsController bool
 	if raw, ok := pod.Labels[constants.KeyManagedBy]; ok && raw == constants.ManagedByValue {
 		managedByEndpointsController = true
 	}
 	// For pods managed by this controller, create and register the service instance.
 	if managedByEndpointsController {
+		start := time.Now()
+		defer func() { observeRegistration(registrationKindService, start, err) }()
+
 		// Get information from the pod to create service instance registrations.
 		serviceRegistration, proxyServiceRegistration, err := r.createServiceRegistrations(pod, serviceEndpoints, healthStatus)
 		if err != nil {
 			r.Log.Error(err, "failed to create service registrations for endpoints", "name", serviceEndpoints.Name, "ns", serviceEndpoints.Namespace)
 			return err
 		}
+		r.recordConsulNamespaceOverride(serviceEndpoints, serviceRegistration.Service.Namespace)
 
 		// Register the service instance with Consul.
 		r.Log.Info("registering service with Consul", "name", serviceRegistration.Service.Service,
 			"id", serviceRegistration.Service.ID)
-		_, err = apiClient.Catalog().Register(serviceRegistration, nil)
+		if err = r.waitForConsulWrite(); err != nil {
+			return err
+		}
+		err = countConsulAPIError(consulOpRegister, r.registerOnNode(apiClient, serviceRegistration, nil))
 		if err != nil {
 			r.Log.Error(err, "failed to register service", "name", serviceRegistration.Service.Service)
 			return err
 		}
 
+		// Registering doesn't remove checks, so deregister the checks of pod conditions that are no
+		// longer listed in the pod's annotation.
+		if err = r.deregisterStalePodConditionChecks(apiClient, serviceRegistration); err != nil {
+			r.Log.Error(err, "failed to deregister stale pod condition checks", "name", serviceRegistration.Service.Service)
+			return err
+		}
+
 		// Add manual ip to the VIP table
 		r.Log.Info("adding manual ip to virtual ip table in Consul", "name", serviceRegistration.Service.Service,
 			"id", serviceRegistration.ID)
@@ -318,9 +601,26 @@ func (r *Controller) registerServicesAndHealthCheck(apiClient *api.Client, pod c
 			r.Log.Error(err, "failed to add ip to virtual ip table", "name", serviceRegistration.Service.Service)
 		}
 
+		// Create a service-resolver with subsets for the Argo Rollouts roles if requested.
+		if serviceRegistration.Service.Meta[constants.MetaKeyRolloutsRole] != "" && pod.Annotations[constants.AnnotationArgoRolloutsServiceResolver] == "true" {
+			if err := r.ensureRolloutsServiceResolver(apiClient, serviceRegistration.Service); err != nil {
+				r.Log.Error(err, "failed to create service-resolver for Argo Rollout", "name", serviceRegistration.Service.Service)
+			}
+		}
+
+		// Prioritize the instances in the same locality in the service-resolver if requested.
+		if raw, ok := pod.Annotations[constants.AnnotationPrioritizeByLocality]; ok {
+			if err := r.updateLocalityServiceResolver(apiClient, serviceRegistration.Service, raw); err != nil {
+				r.Log.Error(err, "failed to update service-resolver locality prioritization", "name", serviceRegistration.Service.Service)
+			}
+		}
+
 		// Register the proxy service instance with Consul.
 		r.Log.Info("registering proxy service with Consul", "name", proxyServiceRegistration.Service.Service, "id", proxyServiceRegistration.Service.ID)
-		_, err = apiClient.Catalog().Register(proxyServiceRegistration, nil)
+		if err = r.waitForConsulWrite(); err != nil {
+			return err
+		}
+		err = countConsulAPIError(consulOpRegister, r.registerOnNode(apiClient, proxyServiceRegistration, nil))
 		if err != nil {
 			r.Log.Error(err, "failed to register proxy service", "name", proxyServiceRegistration.Service.Service)
 			return err
@@ -329,6 +629,18 @@ func (r *Controller) registerServicesAndHealthCheck(apiClient *api.Client, pod c
 	return nil
 }
 
+// addSessionAffinityMeta adds the session affinity of the Kubernetes Service to the service meta
+// if the Service uses ClientIP session affinity.
+func addSessionAffinityMeta(meta map[string]string, k8sSvc corev1.Service) {
+	if k8sSvc.Spec.SessionAffinity != corev1.ServiceAffinityClientIP {
+		return
+	}
+	meta[metaKeyKubeSessionAffinity] = string(corev1.ServiceAffinityClientIP)
+	if cfg := k8sSvc.Spec.SessionAffinityConfig; cfg != nil && cfg.ClientIP != nil && cfg.ClientIP.TimeoutSeconds != nil {
+		meta[metaKeyKubeSessionAffinityTimeout] = strconv.Itoa(int(*cfg.ClientIP.TimeoutSeconds))
+	}
+}
+
 func parseLocality(node corev1.Node) *api.Locality {
 	region := node.Labels[corev1.LabelTopologyRegion]
 	zone := node.Labels[corev1.LabelTopologyZone]
@@ -343,15 +655,136 @@ func parseLocality(node corev1.Node) *api.Locality {
 	}
 }
 
+// podIPs returns the IPs of the pod, which are one IP per IP family in dual-stack clusters.
+func podIPs(pod corev1.Pod) []string {
+	ips := make([]string, 0, len(pod.Status.PodIPs))
+	for _, podIP := range pod.Status.PodIPs {
+		if podIP.IP != "" {
+			ips = append(ips, podIP.IP)
+		}
+	}
+	if len(ips) == 0 && pod.Status.PodIP != "" {
+		ips = append(ips, pod.Status.PodIP)
+	}
+	return ips
+}
+
+// podAddress returns the pod IP that is registered as the address of the service instances of the pod:
+// its IP of the primary IP family, set by the consul.hashicorp.com/primary-ip-family annotation or
+// PrimaryIPFamily. The primary IP of the pod is used if no family is set or the pod has no IP of it.
+func (r *Controller) podAddress(pod corev1.Pod) (string, error) {
+	family := r.PrimaryIPFamily
+	if raw, ok := pod.Annotations[constants.AnnotationPrimaryIPFamily]; ok {
+		family = corev1.IPFamily(raw)
+	}
+	switch family {
+	case "":
+		return pod.Status.PodIP, nil
+	case corev1.IPv4Protocol, corev1.IPv6Protocol:
+	default:
+		return "", fmt.Errorf("%s must be %q or %q, got %q", constants.AnnotationPrimaryIPFamily, corev1.IPv4Protocol, corev1.IPv6Protocol, family)
+	}
+	for _, ip := range podIPs(pod) {
+		if ipFamily(ip) == family {
+			return ip, nil
+		}
+	}
+	return pod.Status.PodIP, nil
+}
+
+func ipFamily(ip string) corev1.IPFamily {
+	parsed := net.ParseIP(ip)
+	switch {
+	case parsed == nil:
+		return corev1.IPFamilyUnknown
+	case parsed.To4() != nil:
+		return corev1.IPv4Protocol
+	default:
+		return corev1.IPv6Protocol
+	}
+}
+
+// addDualStackTaggedAddresses registers the IPs of a dual-stack pod as the lan_ipv4 and lan_ipv6 tagged
+// addresses of the service so that the addresses of both families are known to Consul. The services of
+// pods with a single IP are not changed.
+func addDualStackTaggedAddresses(service *api.AgentService, pod corev1.Pod, port int) {
+	ips := podIPs(pod)
+	if len(ips) < 2 {
+		return
+	}
+	// Copy the tagged addresses since the tagged addresses of the service and its proxy may be shared.
+	taggedAddresses := make(map[string]api.ServiceAddress, len(service.TaggedAddresses)+len(ips))
+	for name, taggedAddress := range service.TaggedAddresses {
+		taggedAddresses[name] = taggedAddress
+	}
+	for _, ip := range ips {
+		switch ipFamily(ip) {
+		case corev1.IPv4Protocol:
+			taggedAddresses[lanIPv4TaggedAddressName] = api.ServiceAddress{Address: ip, Port: port}
+		case corev1.IPv6Protocol:
+			taggedAddresses[lanIPv6TaggedAddressName] = api.ServiceAddress{Address: ip, Port: port}
+		}
+	}
+	service.TaggedAddresses = taggedAddresses
+}
+
+// serviceLocality returns the locality of the service instance of the pod: the locality of its node,
+// overridden by the consul.hashicorp.com/service-locality annotation if it's set.
+func serviceLocality(pod corev1.Pod, node corev1.Node) (*api.Locality, error) {
+	raw, ok := pod.Annotations[constants.AnnotationServiceLocality]
+	if !ok {
+		return parseLocality(node), nil
+	}
+
+	region := node.Labels[corev1.LabelTopologyRegion]
+	zone := node.Labels[corev1.LabelTopologyZone]
+	for _, item := range strings.Split(raw, ",") {
+		item = strings.TrimSpace(item)
+		if item == "" {
+			continue
+		}
+		field, value, found := strings.Cut(item, "=")
+		value = strings.TrimSpace(value)
+		if !found || value == "" {
+			return nil, fmt.Errorf("%s annotation value of %q is invalid: must be formatted as <field>=<value>",
+				constants.AnnotationServiceLocality, item)
+		}
+		switch strings.TrimSpace(field) {
+		case "region":
+			region = value
+		case "zone":
+			zone = value
+		default:
+			return nil, fmt.Errorf("%s annotation value of %q is invalid: field must be \"region\" or \"zone\"",
+				constants.AnnotationServiceLocality, item)
+		}
+	}
+
+	if region == "" {
+		if zone != "" {
+			return nil, fmt.Errorf("%s annotation value %q is invalid: a zone requires a region, and the node has no %s label",
+				constants.AnnotationServiceLocality, raw, corev1.LabelTopologyRegion)
+		}
+		return nil, nil
+	}
+	return &api.Locality{
+		Region: region,
+		Zone:   zone,
+	}, nil
+}
+
 // registerGateway creates Consul registrations for the Connect Gateways and registers them with Consul.
 // It also upserts a Kubernetes health check for the service based on whether the endpoint address is ready.
-func (r *Controller) registerGateway(apiClient *api.Client, pod corev1.Pod, serviceEndpoints corev1.Endpoints, healthStatus string) error {
+func (r *Controller) registerGateway(apiClient *api.Client, pod corev1.Pod, serviceEndpoints corev1.Endpoints, healthStatus string) (err error) {
 	var managedByEndpointsController bool
 	if raw, ok := pod.Labels[constants.KeyManagedBy]; ok && raw == constants.ManagedByValue {
 		managedByEndpointsController = true
 	}
 	// For pods managed by this controller, create and register the service instance.
 	if managedByEndpointsController {
+		start := time.Now()
+		defer func() { observeRegistration(registrationKindGateway, start, err) }()
+
 		// Get information from the pod to create service instance registrations.
 		serviceRegistration, err := r.createGatewayRegistrations(pod, serviceEndpoints, healthStatus)
 		if err != nil {
@@ -360,7 +793,8 @@ func (r *Controller) registerGateway(apiClient *api.Client, pod corev1.Pod, serv
 		}
 
 		if r.EnableConsulNamespaces {
-			if _, err := namespaces.EnsureExists(apiClient, serviceRegistration.Service.Namespace, r.CrossNSACLPolicy); err != nil {
+			_, err := namespaces.EnsureExists(apiClient, serviceRegistration.Service.Namespace, r.CrossNSACLPolicy)
+			if err = countConsulAPIError(consulOpNamespace, err); err != nil {
 				r.Log.Error(err, "failed to ensure Consul namespace exists", "name", serviceEndpoints.Name, "ns", serviceEndpoints.Namespace, "consul ns", serviceRegistration.Service.Namespace)
 				return err
 			}
@@ -369,7 +803,10 @@ func (r *Controller) registerGateway(apiClient *api.Client, pod corev1.Pod, serv
 		// Register the service instance with Consul.
 		r.Log.Info("registering gateway with Consul", "name", serviceRegistration.Service.Service,
 			"id", serviceRegistration.ID)
-		_, err = apiClient.Catalog().Register(serviceRegistration, nil)
+		if err = r.waitForConsulWrite(); err != nil {
+			return err
+		}
+		err = countConsulAPIError(consulOpRegister, r.registerOnNode(apiClient, serviceRegistration, nil))
 		if err != nil {
 			r.Log.Error(err, "failed to register gateway", "name", serviceRegistration.Service.Service)
 			return err
@@ -425,7 +862,22 @@ func (r *Controller) createServiceRegistrations(pod corev1.Pod, serviceEndpoints
 	// and register that port for the host service.
 	// The meshWebhook will always set the port annotation if one is not provided on the pod.
 	var consulServicePort int
-	if raw, ok := pod.Annotations[constants.AnnotationPort]; ok && raw != "" {
+	servicePorts, err := common.ServicePorts(pod)
+	if err != nil {
+		r.recordPodWarning(pod, reasonInvalidServicePorts, err)
+		return nil, nil, err
+	}
+	if servicePorts != nil {
+		svcName := serviceName(pod, serviceEndpoints)
+		idx := getMultiPortIdx(pod, serviceEndpoints)
+		if idx < 0 {
+			err := fmt.Errorf("service %q is not mapped to a port by the %s annotation", svcName, constants.AnnotationServicePorts)
+			r.recordPodWarning(pod, reasonInvalidServicePorts, err)
+			return nil, nil, err
+		}
+		// The ports of the annotation have already been resolved to port numbers.
+		consulServicePort, _ = strconv.Atoi(servicePorts[idx].Port)
+	} else if raw, ok := pod.Annotations[constants.AnnotationPort]; ok && raw != "" {
 		if multiPort := strings.Split(raw, ","); len(multiPort) > 1 {
 			// Figure out which index of the ports annotation to use by
 			// finding the index of the service names annotation.
@@ -439,10 +891,40 @@ func (r *Controller) createServiceRegistrations(pod corev1.Pod, serviceEndpoints
 		}
 	}
 
+	// The weights are left unset, and default to Consul's defaults, if the annotation isn't set.
+	var weights api.AgentWeights
+	if w, err := common.ServiceWeights(pod); err != nil {
+		r.recordPodWarning(pod, reasonInvalidServiceWeight, err)
+		return nil, nil, err
+	} else if w != nil {
+		weights = *w
+	}
+
+	conditionChecks, err := parsePodConditionChecks(pod.Annotations[constants.AnnotationPodConditionChecks])
+	if err != nil {
+		r.recordPodWarning(pod, reasonInvalidPodConditionChecks, err)
+		return nil, nil, err
+	}
+
+	namedPorts, err := parseServiceNamedPorts(pod)
+	if err != nil {
+		r.recordPodWarning(pod, reasonInvalidServiceNamedPorts, err)
+		return nil, nil, err
+	}
+
 	var node corev1.Node
 	// Ignore errors because we don't want failures to block running services.
 	_ = r.Client.Get(context.Background(), types.NamespacedName{Name: pod.Spec.NodeName, Namespace: pod.Namespace}, &node)
-	locality := parseLocality(node)
+	locality, err := serviceLocality(pod, node)
+	if err != nil {
+		r.recordPodWarning(pod, reasonInvalidServiceLocality, err)
+		return nil, nil, err
+	}
+	address, err := r.podAddress(pod)
+	if err != nil {
+		r.recordPodWarning(pod, reasonInvalidPrimaryIPFamily, err)
+		return nil, nil, err
+	}
 
 	// We only want that annotation to be present when explicitly overriding the consul svc name
 	// Otherwise, the Consul service name should equal the Kubernetes Service name.
@@ -460,6 +942,33 @@ func (r *Controller) createServiceRegistrations(pod corev1.Pod, serviceEndpoints
 		constants.MetaKeyPodUID:  string(pod.UID),
 		metaKeySyntheticNode:     "true",
 	}
+	if r.EnableArgoRollouts {
+		if role, hash := r.rolloutsRole(context.Background(), pod); role != "" {
+			meta[constants.MetaKeyRolloutsRole] = role
+			meta[constants.MetaKeyRolloutsPodTemplateHash] = hash
+		}
+	}
+	// Ignore errors because we don't want failures to block running services.
+	var k8sSvc corev1.Service
+	if err := r.Client.Get(context.Background(), types.NamespacedName{Name: serviceEndpoints.Name, Namespace: serviceEndpoints.Namespace}, &k8sSvc); err == nil {
+		addSessionAffinityMeta(meta, k8sSvc)
+	}
+	for label, key := range r.ServiceMetaFromLabels {
+		if _, ok := meta[key]; ok {
+			continue
+		}
+		if v, ok := pod.Labels[label]; ok {
+			meta[key] = v
+		}
+	}
+	for label, key := range r.ServiceMetaFromNodeLabels {
+		if _, ok := meta[key]; ok {
+			continue
+		}
+		if v, ok := node.Labels[label]; ok {
+			meta[key] = v
+		}
+	}
 	for k, v := range pod.Annotations {
 		if strings.HasPrefix(k, constants.AnnotationMeta) && strings.TrimPrefix(k, constants.AnnotationMeta) != "" {
 			if v == "$POD_NAME" {
@@ -469,22 +978,23 @@ func (r *Controller) createServiceRegistrations(pod corev1.Pod, serviceEndpoints
 			}
 		}
 	}
-	tags := consulTags(pod)
+	tags := append(consulTags(pod), r.labelTags(pod)...)
 
-	consulNS := r.consulNamespace(pod.Namespace)
+	consulNS := r.podConsulNamespace(pod)
 
 	service := &api.AgentService{
 		ID:        svcID,
 		Service:   svcName,
 		Port:      consulServicePort,
-		Address:   pod.Status.PodIP,
+		Address:   address,
 		Meta:      meta,
 		Namespace: consulNS,
 		Tags:      tags,
 		Locality:  locality,
+		Weights:   weights,
 	}
 	serviceRegistration := &api.CatalogRegistration{
-		Node:    common.ConsulNodeNameFromK8sNode(pod.Spec.NodeName),
+		Node:    common.PodConsulNodeName(pod),
 		Address: pod.Status.HostIP,
 		NodeMeta: map[string]string{
 			metaKeySyntheticNode: "true",
@@ -499,6 +1009,7 @@ func (r *Controller) createServiceRegistrations(pod corev1.Pod, serviceEndpoints
 			Output:    getHealthCheckStatusReason(healthStatus, pod.Name, pod.Namespace),
 			Namespace: consulNS,
 		},
+		Checks:         podConditionHealthChecks(pod, conditionChecks, svcID, consulNS),
 		SkipNodeUpdate: true,
 	}
 	r.appendNodeMeta(serviceRegistration)
@@ -559,13 +1070,15 @@ func (r *Controller) createServiceRegistrations(pod corev1.Pod, serviceEndpoints
 		ID:        proxySvcID,
 		Service:   proxySvcName,
 		Port:      proxyPort,
-		Address:   pod.Status.PodIP,
+		Address:   address,
 		Meta:      meta,
 		Namespace: consulNS,
 		Proxy:     proxyConfig,
 		Tags:      tags,
 		// Sidecar locality (not proxied service locality) is used for locality-aware routing.
 		Locality: locality,
+		// The weights of the sidecar are the ones used for load balancing in the mesh.
+		Weights: weights,
 	}
 
 	// A user can enable/disable tproxy for an entire namespace.
@@ -643,39 +1156,21 @@ func (r *Controller) createServiceRegistrations(pod corev1.Pod, serviceEndpoints
 
 			for _, mutatedContainer := range pod.Spec.Containers {
 				for _, originalContainer := range originalPod.Spec.Containers {
-					if originalContainer.Name == mutatedContainer.Name {
-						if mutatedContainer.LivenessProbe != nil && mutatedContainer.LivenessProbe.HTTPGet != nil {
-							originalLivenessPort, err := portValueFromIntOrString(originalPod, originalContainer.LivenessProbe.HTTPGet.Port)
-							if err != nil {
-								return nil, nil, err
-							}
-							proxyConfig.Expose.Paths = append(proxyConfig.Expose.Paths, api.ExposePath{
-								ListenerPort:  mutatedContainer.LivenessProbe.HTTPGet.Port.IntValue(),
-								LocalPathPort: originalLivenessPort,
-								Path:          mutatedContainer.LivenessProbe.HTTPGet.Path,
-							})
-						}
-						if mutatedContainer.ReadinessProbe != nil && mutatedContainer.ReadinessProbe.HTTPGet != nil {
-							originalReadinessPort, err := portValueFromIntOrString(originalPod, originalContainer.ReadinessProbe.HTTPGet.Port)
-							if err != nil {
-								return nil, nil, err
-							}
-							proxyConfig.Expose.Paths = append(proxyConfig.Expose.Paths, api.ExposePath{
-								ListenerPort:  mutatedContainer.ReadinessProbe.HTTPGet.Port.IntValue(),
-								LocalPathPort: originalReadinessPort,
-								Path:          mutatedContainer.ReadinessProbe.HTTPGet.Path,
-							})
+					if originalContainer.Name != mutatedContainer.Name {
+						continue
+					}
+					probes := [][2]*corev1.Probe{
+						{originalContainer.LivenessProbe, mutatedContainer.LivenessProbe},
+						{originalContainer.ReadinessProbe, mutatedContainer.ReadinessProbe},
+						{originalContainer.StartupProbe, mutatedContainer.StartupProbe},
+					}
+					for _, p := range probes {
+						path, ok, err := exposePath(originalPod, p[0], p[1])
+						if err != nil {
+							return nil, nil, err
 						}
-						if mutatedContainer.StartupProbe != nil && mutatedContainer.StartupProbe.HTTPGet != nil {
-							originalStartupPort, err := portValueFromIntOrString(originalPod, originalContainer.StartupProbe.HTTPGet.Port)
-							if err != nil {
-								return nil, nil, err
-							}
-							proxyConfig.Expose.Paths = append(proxyConfig.Expose.Paths, api.ExposePath{
-								ListenerPort:  mutatedContainer.StartupProbe.HTTPGet.Port.IntValue(),
-								LocalPathPort: originalStartupPort,
-								Path:          mutatedContainer.StartupProbe.HTTPGet.Path,
-							})
+						if ok {
+							proxyConfig.Expose.Paths = append(proxyConfig.Expose.Paths, path)
 						}
 					}
 				}
@@ -684,7 +1179,7 @@ func (r *Controller) createServiceRegistrations(pod corev1.Pod, serviceEndpoints
 	}
 
 	proxyServiceRegistration := &api.CatalogRegistration{
-		Node:    common.ConsulNodeNameFromK8sNode(pod.Spec.NodeName),
+		Node:    common.PodConsulNodeName(pod),
 		Address: pod.Status.HostIP,
 		NodeMeta: map[string]string{
 			metaKeySyntheticNode: "true",
@@ -703,6 +1198,12 @@ func (r *Controller) createServiceRegistrations(pod corev1.Pod, serviceEndpoints
 	}
 	r.appendNodeMeta(proxyServiceRegistration)
 
+	addDualStackTaggedAddresses(service, pod, consulServicePort)
+	addDualStackTaggedAddresses(proxyService, pod, proxyPort)
+
+	// The named ports are only registered with the service, since the proxy only proxies its port.
+	addServiceNamedPorts(service, namedPorts)
+
 	return serviceRegistration, proxyServiceRegistration, nil
 }
 
@@ -723,13 +1224,26 @@ func (r *Controller) createGatewayRegistrations(pod corev1.Pod, serviceEndpoints
 		r.Log.Error(err, "annotation unable to be applied")
 	}
 
+	var node corev1.Node
+	// Ignore errors because we don't want failures to block running gateways.
+	_ = r.Client.Get(context.Background(), types.NamespacedName{Name: pod.Spec.NodeName, Namespace: pod.Namespace}, &node)
+
+	address, err := r.podAddress(pod)
+	if err != nil {
+		r.recordPodWarning(pod, reasonInvalidPrimaryIPFamily, err)
+		return nil, err
+	}
+
 	service := &api.AgentService{
 		ID:      pod.Name,
-		Address: pod.Status.PodIP,
+		Address: address,
 		Meta:    meta,
 		Proxy: &api.AgentServiceConnectProxyConfig{
 			Config: baseConfig,
 		},
+		// Gateways deployed per zone are registered with the zone of their node so that
+		// Consul can prefer gateways in the same zone.
+		Locality: parseLocality(node),
 	}
 
 	gatewayServiceName, ok := pod.Annotations[constants.AnnotationGatewayConsulServiceName]
@@ -765,7 +1279,7 @@ func (r *Controller) createGatewayRegistrations(pod corev1.Pod, serviceEndpoints
 		}
 		service.TaggedAddresses = map[string]api.ServiceAddress{
 			"lan": {
-				Address: pod.Status.PodIP,
+				Address: address,
 				Port:    port,
 			},
 			"wan": {
@@ -794,7 +1308,7 @@ func (r *Controller) createGatewayRegistrations(pod corev1.Pod, serviceEndpoints
 		service.Port = 21000
 		service.TaggedAddresses = map[string]api.ServiceAddress{
 			"lan": {
-				Address: pod.Status.PodIP,
+				Address: address,
 				Port:    21000,
 			},
 			"wan": {
@@ -816,15 +1330,16 @@ func (r *Controller) createGatewayRegistrations(pod corev1.Pod, serviceEndpoints
 	}
 
 	if r.MetricsConfig.DefaultEnableMetrics && r.MetricsConfig.EnableGatewayMetrics {
-		service.Proxy.Config["envoy_prometheus_bind_addr"] = fmt.Sprintf("%s:20200", pod.Status.PodIP)
+		service.Proxy.Config["envoy_prometheus_bind_addr"] = net.JoinHostPort(address, "20200")
 	}
+	addDualStackTaggedAddresses(service, pod, service.Port)
 
 	if r.EnableTelemetryCollector && service.Proxy != nil && service.Proxy.Config != nil {
 		service.Proxy.Config[envoyTelemetryCollectorBindSocketDir] = "/consul/service"
 	}
 
 	serviceRegistration := &api.CatalogRegistration{
-		Node:    common.ConsulNodeNameFromK8sNode(pod.Spec.NodeName),
+		Node:    common.PodConsulNodeName(pod),
 		Address: pod.Status.HostIP,
 		NodeMeta: map[string]string{
 			metaKeySyntheticNode: "true",
@@ -852,6 +1367,10 @@ func (r *Controller) getWanData(pod corev1.Pod, endpoints corev1.Endpoints) (str
 	if !ok {
 		return "", 0, fmt.Errorf("failed to read annotation %s", constants.AnnotationGatewayWANSource)
 	}
+	wanPort, err := strconv.Atoi(pod.Annotations[constants.AnnotationGatewayWANPort])
+	if err != nil {
+		return "", 0, fmt.Errorf("failed to parse WAN port from value %s", pod.Annotations[constants.AnnotationGatewayWANPort])
+	}
 	switch source {
 	case "NodeName":
 		wanAddr = pod.Spec.NodeName
@@ -873,22 +1392,10 @@ func (r *Controller) getWanData(pod corev1.Pod, endpoints corev1.Endpoints) (str
 			if len(svc.Status.LoadBalancer.Ingress) == 0 {
 				return "", 0, fmt.Errorf("failed to read ingress config for loadbalancer for service %s in namespace %s", endpoints.Name, endpoints.Namespace)
 			}
-			for _, ingr := range svc.Status.LoadBalancer.Ingress {
-				if ingr.IP != "" {
-					wanAddr = ingr.IP
-					break
-				} else if ingr.Hostname != "" {
-					wanAddr = ingr.Hostname
-					break
-				}
-			}
+			wanAddr = r.loadBalancerWANAddress(svc.Status.LoadBalancer.Ingress, wanPort)
 		}
 	}
 
-	wanPort, err := strconv.Atoi(pod.Annotations[constants.AnnotationGatewayWANPort])
-	if err != nil {
-		return "", 0, fmt.Errorf("failed to parse WAN port from value %s", pod.Annotations[constants.AnnotationGatewayWANPort])
-	}
 	return wanAddr, wanPort, nil
 }
 
@@ -916,6 +1423,61 @@ func portValueFromIntOrString(pod corev1.Pod, port intstr.IntOrString) (int, err
 	return int(portVal), nil
 }
 
+// exposePath returns the path Envoy exposes for a probe the webhook overwrote to point to the proxy.
+// It returns false if the probe wasn't overwritten, e.g. because it is an HTTPS probe or its port is
+// in the exclude probe ports annotation.
+func exposePath(originalPod corev1.Pod, originalProbe, mutatedProbe *corev1.Probe) (api.ExposePath, bool, error) {
+	if originalProbe == nil || mutatedProbe == nil {
+		return api.ExposePath{}, false, nil
+	}
+	switch {
+	case mutatedProbe.HTTPGet != nil:
+		// Probes on named ports that don't resolve aren't overwritten, so compare them before resolving.
+		if mutatedProbe.HTTPGet.Port == originalProbeHTTPGetPort(originalProbe) {
+			return api.ExposePath{}, false, nil
+		}
+		originalPort, err := portValueFromIntOrString(originalPod, originalProbeHTTPGetPort(originalProbe))
+		if err != nil {
+			return api.ExposePath{}, false, err
+		}
+		if mutatedProbe.HTTPGet.Port.IntValue() == originalPort {
+			return api.ExposePath{}, false, nil
+		}
+		return api.ExposePath{
+			ListenerPort:  mutatedProbe.HTTPGet.Port.IntValue(),
+			LocalPathPort: originalPort,
+			Path:          mutatedProbe.HTTPGet.Path,
+		}, true, nil
+	case mutatedProbe.GRPC != nil:
+		if originalProbe.GRPC == nil || mutatedProbe.GRPC.Port == originalProbe.GRPC.Port {
+			return api.ExposePath{}, false, nil
+		}
+		return api.ExposePath{
+			ListenerPort:  int(mutatedProbe.GRPC.Port),
+			LocalPathPort: int(originalProbe.GRPC.Port),
+			Path:          grpcHealthCheckPath,
+			Protocol:      "http2",
+		}, true, nil
+	}
+	return api.ExposePath{}, false, nil
+}
+
+// originalProbeHTTPGetPort returns the port of a probe on the original pod before it was mutated
+// by the webhook. Exec probes are translated into HTTP probes by the webhook when requested via
+// annotation, so the same translation is applied here to find the application's port.
+func originalProbeHTTPGetPort(probe *corev1.Probe) intstr.IntOrString {
+	if probe == nil {
+		return intstr.IntOrString{}
+	}
+	if probe.HTTPGet != nil {
+		return probe.HTTPGet.Port
+	}
+	if action, ok := common.HTTPGetActionFromExecProbe(probe); ok {
+		return action.Port
+	}
+	return intstr.IntOrString{}
+}
+
 // consulHealthCheckID deterministically generates a health check ID based on service ID and Kubernetes namespace.
 func consulHealthCheckID(k8sNS string, serviceID string) string {
 	return fmt.Sprintf("%s/%s", k8sNS, serviceID)
@@ -944,12 +1506,15 @@ func getHealthCheckStatusReason(healthCheckStatus, podName, podNamespace string)
 // will not be deregistered. Instead, its health check will be updated to Critical in order to drain incoming traffic and
 // this function will return a requeueAfter duration. This can be used to requeue the event at the longest shutdown time
 // interval to clean up these instances after they have exited.
+// Every deregistration is audited with the given reason. When selectively deregistering, the reason is refined
+// based on the state of the pod backing each instance.
 func (r *Controller) deregisterService(
 	ctx context.Context,
 	apiClient *api.Client,
 	k8sSvcName string,
 	k8sSvcNamespace string,
-	deregisterEndpointAddress map[string]bool) (time.Duration, error) {
+	deregisterEndpointAddress map[string]bool,
+	reason deregisterReason) (time.Duration, error) {
 
 	// Get services matching metadata from Consul
 	serviceInstances, err := r.serviceInstances(apiClient, k8sSvcName, k8sSvcNamespace)
@@ -966,7 +1531,16 @@ func (r *Controller) deregisterService(
 		// every service instance.
 		var serviceDeregistered bool
 
-		if deregister(svc.ServiceAddress, deregisterEndpointAddress) {
+		deregisterInstance := deregister(svc, deregisterEndpointAddress)
+		// The instances of pods that are still in the Endpoints object but were registered on another node,
+		// e.g. because the node naming strategy changed, have already been registered again on their current
+		// node. Only the instance on the old node is deregistered; the ACL token of the pod is still in use.
+		movedNode := !deregisterInstance && deregisterEndpointAddress != nil && r.registeredOnOtherNode(ctx, svc, k8sSvcNamespace)
+		if movedNode {
+			deregisterInstance = true
+		}
+
+		if deregisterInstance {
 			// If graceful shutdown is enabled, continue to the next service instance and
 			// mark that an event requeue is needed. We should requeue at the longest time interval
 			// to prevent excessive re-queues. Also, updating the health status in Consul to Critical
@@ -985,23 +1559,33 @@ func (r *Controller) deregisterService(
 				continue
 			}
 
+			instanceReason := reason
+			if deregisterEndpointAddress != nil {
+				instanceReason = r.instanceDeregisterReason(ctx, svc, k8sSvcName, k8sSvcNamespace, reason)
+			}
+
 			// If the service address is not in the Endpoints addresses, deregister it.
-			r.Log.Info("deregistering service from consul", "svc", svc.ServiceID)
+			r.Log.Info("deregistering service from consul", "svc", svc.ServiceID, "reason", instanceReason)
+			if err = r.waitForConsulWrite(); err != nil {
+				return 0, err
+			}
 			_, err = apiClient.Catalog().Deregister(&api.CatalogDeregistration{
 				Node:      svc.Node,
 				ServiceID: svc.ServiceID,
 				Namespace: svc.Namespace,
-			}, nil)
+			}, (&api.WriteOptions{}).WithContext(ctx))
+			err = countConsulAPIError(consulOpDeregister, err)
 			if err != nil {
 				// Do not exit right away as there might be other services that need to be deregistered.
 				r.Log.Error(err, "failed to deregister service instance", "id", svc.ServiceID)
 				errs = multierror.Append(errs, err)
 			} else {
 				serviceDeregistered = true
+				r.auditDeregistration(svc, k8sSvcName, k8sSvcNamespace, instanceReason)
 			}
 		}
 
-		if r.AuthMethod != "" && serviceDeregistered {
+		if r.AuthMethod != "" && serviceDeregistered && !movedNode {
 			r.Log.Info("reconciling ACL tokens for service", "svc", svc.ServiceName)
 			err := r.deleteACLTokensForServiceInstance(apiClient, svc, k8sSvcNamespace, svc.ServiceMeta[constants.MetaKeyPodName], svc.ServiceMeta[constants.MetaKeyPodUID])
 			if err != nil {
@@ -1026,6 +1610,100 @@ func (r *Controller) deregisterService(
 	return requeueAfter, errs
 }
 
+// instanceDeregisterReason returns why a service instance is being deregistered based on the pod backing it.
+// If the pod doesn't explain the deregistration, defaultReason is returned.
+func (r *Controller) instanceDeregisterReason(ctx context.Context, svc *api.CatalogService, k8sSvcName, k8sNamespace string, defaultReason deregisterReason) deregisterReason {
+	podName := svc.ServiceMeta[constants.MetaKeyPodName]
+	if podName == "" {
+		return defaultReason
+	}
+
+	var pod corev1.Pod
+	err := r.Client.Get(ctx, types.NamespacedName{Name: podName, Namespace: k8sNamespace}, &pod)
+	if k8serrors.IsNotFound(err) {
+		return r.podDeregisterReason(nil, svc, k8sSvcName, defaultReason)
+	}
+	if err != nil {
+		r.Log.Error(err, "failed to get pod to determine deregistration reason", "name", podName, "k8sNamespace", k8sNamespace)
+		return defaultReason
+	}
+	return r.podDeregisterReason(&pod, svc, k8sSvcName, defaultReason)
+}
+
+// registeredOnOtherNode returns whether the service instance is registered on a different node than the one
+// its pod is registered on with the current node naming strategy.
+func (r *Controller) registeredOnOtherNode(ctx context.Context, svc *api.CatalogService, k8sNamespace string) bool {
+	podName := svc.ServiceMeta[constants.MetaKeyPodName]
+	if podName == "" {
+		return false
+	}
+	var pod corev1.Pod
+	if err := r.Client.Get(ctx, types.NamespacedName{Name: podName, Namespace: k8sNamespace}, &pod); err != nil {
+		return false
+	}
+	// Older consul-k8s versions did not set the pod UID in the service metadata.
+	if podUID := svc.ServiceMeta[constants.MetaKeyPodUID]; podUID != "" && podUID != string(pod.UID) {
+		return false
+	}
+	return common.PodConsulNodeName(pod) != svc.Node
+}
+
+// podDeregisterReason returns why a service instance backed by the given pod is being deregistered.
+// A nil pod means the pod no longer exists.
+func (r *Controller) podDeregisterReason(pod *corev1.Pod, svc *api.CatalogService, k8sSvcName string, defaultReason deregisterReason) deregisterReason {
+	if pod == nil {
+		return reasonPodDeleted
+	}
+	// Older consul-k8s versions did not set the pod UID in the service metadata.
+	if podUID := svc.ServiceMeta[constants.MetaKeyPodUID]; podUID != "" && podUID != string(pod.UID) {
+		return reasonPodUIDChanged
+	}
+	if common.PodConsulNodeName(*pod) != svc.Node {
+		return reasonNodeChanged
+	}
+	if svcName, ok := pod.Annotations[constants.AnnotationKubernetesService]; ok && svcName != k8sSvcName {
+		return reasonServiceAnnotationMismatch
+	}
+	if !hasBeenInjected(*pod) && !isGateway(*pod) {
+		return reasonMeshAnnotationRemoved
+	}
+	if job, _ := common.IsJob(*pod); job && common.JobCompleted(*pod) {
+		return reasonPodCompleted
+	}
+	return defaultReason
+}
+
+// auditDeregistration logs the deregistration of a service instance along with the reason for it, counts it
+// in the deregistrations metric and, if an EventRecorder is configured, records an Event on the Kubernetes Service.
+func (r *Controller) auditDeregistration(svc *api.CatalogService, k8sSvcName, k8sSvcNamespace string, reason deregisterReason) {
+	deregistrations.WithLabelValues(string(reason)).Inc()
+
+	podName := svc.ServiceMeta[constants.MetaKeyPodName]
+	r.Log.Info("audit: deregistered service instance from consul",
+		"svc", svc.ServiceName, "id", svc.ServiceID, "node", svc.Node, "consulNamespace", svc.Namespace,
+		"pod", podName, "k8sService", k8sSvcName, "k8sNamespace", k8sSvcNamespace, "reason", reason)
+
+	if r.EventRecorder == nil {
+		return
+	}
+	service := &corev1.ObjectReference{
+		APIVersion: "v1",
+		Kind:       "Service",
+		Name:       k8sSvcName,
+		Namespace:  k8sSvcNamespace,
+	}
+	r.EventRecorder.Eventf(service, corev1.EventTypeNormal, string(reason),
+		"Deregistered service instance %q of pod %q on node %q from Consul", svc.ServiceID, podName, svc.Node)
+}
+
+// recordPodWarning records a Warning Event on the pod if an EventRecorder is configured.
+func (r *Controller) recordPodWarning(pod corev1.Pod, reason string, err error) {
+	if r.EventRecorder == nil {
+		return
+	}
+	r.EventRecorder.Event(&pod, corev1.EventTypeWarning, reason, err.Error())
+}
+
 // getGracefulShutdownAndUpdatePodCheck checks if the pod is in the process of being terminated and if so, updates the
 // health status of the service to critical. It returns the duration for which the pod should be re-queued (which is the pods
 // gracefulShutdownPeriod setting).
@@ -1051,7 +1729,12 @@ func (r *Controller) getGracefulShutdownAndUpdatePodCheck(ctx context.Context, a
 	// or node name. In older consul-k8s patches, the pod uid may not be set, so to account for that we also check if
 	// the node changed, since newer service instances should exist for the new node. In that case, it's not the old pod
 	// that is gracefully shutting down; the old pod is gone and we should deregister that old instance from Consul.
-	if string(pod.UID) != svc.ServiceMeta[constants.MetaKeyPodUID] || common.ConsulNodeNameFromK8sNode(pod.Spec.NodeName) != svc.Node {
+	if string(pod.UID) != svc.ServiceMeta[constants.MetaKeyPodUID] || common.PodConsulNodeName(pod) != svc.Node {
+		return 0, nil
+	}
+
+	// A completed job pod has already exited, so there is nothing to drain.
+	if job, _ := common.IsJob(pod); job && common.JobCompleted(pod) {
 		return 0, nil
 	}
 
@@ -1075,14 +1758,17 @@ func (r *Controller) getGracefulShutdownAndUpdatePodCheck(ctx context.Context, a
 				Status:    api.HealthCritical,
 				ServiceID: svc.ServiceID,
 				Output:    fmt.Sprintf("Pod \"%s/%s\" is terminating", pod.Namespace, podName),
-				Namespace: r.consulNamespace(pod.Namespace),
+				Namespace: svc.Namespace,
 			},
 			SkipNodeUpdate: true,
 		}
 
 		r.Log.Info("updating health status of service with Consul to critical in order to drain inbound traffic", "name", svc.ServiceName,
 			"id", svc.ServiceID, "pod", podName, "k8sNamespace", pod.Namespace)
-		_, err = apiClient.Catalog().Register(serviceRegistration, nil)
+		if err = r.waitForConsulWrite(); err != nil {
+			return 0, err
+		}
+		err = countConsulAPIError(consulOpRegister, r.registerOnNode(apiClient, serviceRegistration, (&api.WriteOptions{}).WithContext(ctx)))
 		if err != nil {
 			r.Log.Error(err, "failed to update service health status to critical", "name", svc.ServiceName, "pod", podName)
 			return 0, fmt.Errorf("failed to update service health status for pod %s/%s to critical: %w", pod.Namespace, podName, err)
@@ -1115,11 +1801,37 @@ func (r *Controller) getGracefulShutdownPeriodSecondsForPod(pod corev1.Pod) (int
 // (wildcard) to determine if there any other services associated with the Node. We also only search for nodes that have
 // the correct kubernetes metadata (managed-by-endpoints-controller and synthetic-node).
 func (r *Controller) deregisterNode(apiClient *api.Client, nodeName string) error {
+	empty, err := r.nodeHasNoServices(apiClient, nodeName)
+	if err != nil || !empty {
+		return err
+	}
+	if err := r.waitForConsulWrite(); err != nil {
+		return err
+	}
+
+	// The node is locked so that no service instance is registered on it between listing its
+	// service instances and deregistering it. They are listed again since one may have been
+	// registered while waiting for the rate limiter.
+	unlockNode := r.lockNode(nodeName)
+	defer unlockNode()
+	empty, err = r.nodeHasNoServices(apiClient, nodeName)
+	if err != nil || !empty {
+		return err
+	}
+	r.Log.Info("deregistering node from consul", "node", nodeName)
+	_, err = apiClient.Catalog().Deregister(&api.CatalogDeregistration{Node: nodeName}, nil)
+	if err = countConsulAPIError(consulOpDeregister, err); err != nil {
+		r.Log.Error(err, "failed to deregister node", "name", nodeName)
+	}
+	return nil
+}
+
+// nodeHasNoServices returns whether the synthetic node has no service instances registered by this controller.
+func (r *Controller) nodeHasNoServices(apiClient *api.Client, nodeName string) (bool, error) {
 	var (
 		serviceList *api.CatalogNodeServiceList
 		err         error
 	)
-
 	filter := fmt.Sprintf(`Meta[%q] == %q and Meta[%q] == %q`,
 		"synthetic-node", "true", metaKeyManagedBy, constants.ManagedByValue)
 	if r.EnableConsulNamespaces {
@@ -1127,18 +1839,11 @@ func (r *Controller) deregisterNode(apiClient *api.Client, nodeName string) erro
 	} else {
 		serviceList, _, err = apiClient.Catalog().NodeServiceList(nodeName, &api.QueryOptions{Filter: filter})
 	}
-	if err != nil {
-		return fmt.Errorf("failed to get a list of node services: %s", err)
+	if err = countConsulAPIError(consulOpCatalogRead, err); err != nil {
+		return false, fmt.Errorf("failed to get a list of node services: %s", err)
 	}
-
-	if len(serviceList.Services) == 0 {
-		r.Log.Info("deregistering node from consul", "node", nodeName, "services", serviceList.Services)
-		_, err := apiClient.Catalog().Deregister(&api.CatalogDeregistration{Node: nodeName}, nil)
-		if err != nil {
-			r.Log.Error(err, "failed to deregister node", "name", nodeName)
-		}
-	}
-	return nil
+	// The list is nil if the node was deregistered, e.g. by a concurrent reconcile.
+	return serviceList == nil || len(serviceList.Services) == 0, nil
 }
 
 // deleteACLTokensForServiceInstance finds the ACL tokens that belongs to the service instance and deletes it from Consul.
@@ -1162,7 +1867,7 @@ func (r *Controller) deleteACLTokensForServiceInstance(apiClient *api.Client, sv
 		&api.QueryOptions{
 			Namespace: svc.Namespace,
 		})
-	if err != nil {
+	if err = countConsulAPIError(consulOpACL, err); err != nil {
 		return fmt.Errorf("failed to get a list of tokens from Consul: %s", err)
 	}
 
@@ -1186,7 +1891,11 @@ func (r *Controller) deleteACLTokensForServiceInstance(apiClient *api.Client, sv
 			// If we can't find token's pod, delete it.
 			if tokenPodName == podName && podUIDMatched {
 				r.Log.Info("deleting ACL token for pod", "name", podName)
-				if _, err := apiClient.ACL().TokenDelete(token.AccessorID, &api.WriteOptions{Namespace: svc.Namespace}); err != nil {
+				if err := r.waitForConsulWrite(); err != nil {
+					return err
+				}
+				_, err := apiClient.ACL().TokenDelete(token.AccessorID, &api.WriteOptions{Namespace: svc.Namespace})
+				if err = countConsulAPIError(consulOpACL, err); err != nil {
 					return fmt.Errorf("failed to delete token from Consul: %s", err)
 				}
 			}
@@ -1207,6 +1916,11 @@ func (r *Controller) processUpstreams(pod corev1.Pod, endpoints corev1.Endpoints
 
 	var upstreams []api.Upstream
 	if raw, ok := pod.Annotations[constants.AnnotationUpstreams]; ok && raw != "" {
+		upstreamConfig, err := common.UpstreamConfig(pod)
+		if err != nil {
+			return []api.Upstream{}, err
+		}
+
 		for _, raw := range strings.Split(raw, ",") {
 			var upstream api.Upstream
 
@@ -1244,6 +1958,9 @@ func (r *Controller) processUpstreams(pod corev1.Pod, endpoints corev1.Endpoints
 				}
 			}
 
+			if upstreamConfig != nil {
+				upstream.Config = upstreamConfig
+			}
 			upstreams = append(upstreams, upstream)
 		}
 	}
@@ -1302,16 +2019,17 @@ func (r *Controller) serviceInstances(apiClient *api.Client, k8sServiceName, k8s
 		// This request uses the service index of the services table (does not perform a full table scan), then decorates each
 		// result with a single node fetched by ID index from the nodes table.
 		is, _, err = apiClient.Catalog().Service(service, "", &api.QueryOptions{Filter: filter})
+		err = countConsulAPIError(consulOpCatalogRead, err)
 		if err != nil {
 			errs = multierror.Append(errs, err)
 		} else {
 			instances = append(instances, is...)
 		}
-		// If namespaces are enabled a non-default NS is targeted, also query by target Consul NS.
+		// If namespaces are enabled and non-default NSs are targeted, also query by target Consul NSs.
 		if r.EnableConsulNamespaces {
-			nonDefaultNamespace := namespaces.NonDefaultConsulNamespace(r.consulNamespace(k8sServiceNamespace))
-			if nonDefaultNamespace != "" {
+			for _, nonDefaultNamespace := range r.nonDefaultConsulNamespaces(k8sServiceName, k8sServiceNamespace) {
 				is, _, err = apiClient.Catalog().Service(service, "", &api.QueryOptions{Filter: filter, Namespace: nonDefaultNamespace})
+				err = countConsulAPIError(consulOpCatalogRead, err)
 				if err != nil {
 					errs = multierror.Append(errs, err)
 				} else {
@@ -1339,14 +2057,15 @@ func (r *Controller) servicesForK8SServiceNameAndNamespace(apiClient *api.Client
 	// This request performs a NS-bound scan of the services table. If needed in the future, its performance
 	// could be improved by adding an index on ServiceMeta to Consul's state store.
 	services, _, err = apiClient.Catalog().Services(&api.QueryOptions{Filter: filter})
+	err = countConsulAPIError(consulOpCatalogRead, err)
 	if err != nil {
 		return nil, err
 	}
-	// If namespaces are enabled a non-default NS is targeted, also query by target Consul NS.
+	// If namespaces are enabled and non-default NSs are targeted, also query by target Consul NSs.
 	if r.EnableConsulNamespaces {
-		nonDefaultNamespace := namespaces.NonDefaultConsulNamespace(r.consulNamespace(k8sServiceNamespace))
-		if nonDefaultNamespace != "" {
+		for _, nonDefaultNamespace := range r.nonDefaultConsulNamespaces(k8sServiceName, k8sServiceNamespace) {
 			ss, _, err := apiClient.Catalog().Services(&api.QueryOptions{Filter: filter, Namespace: nonDefaultNamespace})
+			err = countConsulAPIError(consulOpCatalogRead, err)
 			if err != nil {
 				return nil, err
 			}
@@ -1520,6 +2239,104 @@ func (r *Controller) consulNamespace(namespace string) string {
 	return namespaces.ConsulNamespace(namespace, r.EnableConsulNamespaces, r.ConsulDestinationNamespace, r.EnableNSMirroring, r.NSMirroringPrefix)
 }
 
+// podConsulNamespace returns the Consul namespace that the services of the pod are registered in,
+// which is the namespace of its override annotation if it has one.
+func (r *Controller) podConsulNamespace(pod corev1.Pod) string {
+	if override := pod.Annotations[constants.AnnotationConsulNamespaceOverride]; r.EnableConsulNamespaces && override != "" {
+		return override
+	}
+	return r.consulNamespace(pod.Namespace)
+}
+
+// recordConsulNamespaceOverride records the Consul namespace that a pod of the Kubernetes Service
+// registered its service instance in if it isn't the namespace of the Kubernetes namespace.
+func (r *Controller) recordConsulNamespaceOverride(serviceEndpoints corev1.Endpoints, consulNS string) {
+	if consulNS == r.consulNamespace(serviceEndpoints.Namespace) {
+		return
+	}
+	r.consulNamespaceOverridesMu.Lock()
+	defer r.consulNamespaceOverridesMu.Unlock()
+	if r.consulNamespaceOverrides == nil {
+		r.consulNamespaceOverrides = make(map[types.NamespacedName]map[string]struct{})
+	}
+	key := types.NamespacedName{Name: serviceEndpoints.Name, Namespace: serviceEndpoints.Namespace}
+	if r.consulNamespaceOverrides[key] == nil {
+		r.consulNamespaceOverrides[key] = make(map[string]struct{})
+	}
+	r.consulNamespaceOverrides[key][consulNS] = struct{}{}
+}
+
+// forgetConsulNamespaceOverrides forgets the Consul namespaces recorded for the Kubernetes Service
+// once all of its service instances are deregistered.
+func (r *Controller) forgetConsulNamespaceOverrides(k8sService types.NamespacedName) {
+	r.consulNamespaceOverridesMu.Lock()
+	defer r.consulNamespaceOverridesMu.Unlock()
+	delete(r.consulNamespaceOverrides, k8sService)
+}
+
+// nonDefaultConsulNamespaces returns the non-default Consul namespaces that the service instances
+// of the Kubernetes Service may be registered in.
+func (r *Controller) nonDefaultConsulNamespaces(k8sServiceName, k8sServiceNamespace string) []string {
+	var result []string
+	if ns := namespaces.NonDefaultConsulNamespace(r.consulNamespace(k8sServiceNamespace)); ns != "" {
+		result = append(result, ns)
+	}
+
+	r.consulNamespaceOverridesMu.Lock()
+	defer r.consulNamespaceOverridesMu.Unlock()
+	var overrides []string
+	for ns := range r.consulNamespaceOverrides[types.NamespacedName{Name: k8sServiceName, Namespace: k8sServiceNamespace}] {
+		if ns = namespaces.NonDefaultConsulNamespace(ns); ns != "" {
+			overrides = append(overrides, ns)
+		}
+	}
+	sort.Strings(overrides)
+	return append(result, overrides...)
+}
+
+// consulClientConfig returns the config for the Consul API client used to reconcile endpoints in the
+// Kubernetes namespace. If a partition mapping is set, the client is scoped to the namespace's Admin
+// Partition so that registrations, deregistrations and ACL token clean up all happen in that partition.
+func (r *Controller) consulClientConfig(k8sNamespace string) *consul.Config {
+	if r.PartitionMapping == nil {
+		return r.ConsulClientConfig
+	}
+	return r.consulClientConfigForPartition(r.PartitionMapping.Partition(k8sNamespace))
+}
+
+// deregisterFromPreviousPartitions deregisters every service instance of the Kubernetes Service from the
+// Admin Partitions its namespace was mapped to before the partition mapping changed. Its pods are
+// registered in the current partition, and must be restarted for their proxies to log in to it.
+func (r *Controller) deregisterFromPreviousPartitions(ctx context.Context, serverState discovery.State, k8sService types.NamespacedName) (time.Duration, error) {
+	if r.PartitionMapping == nil {
+		return 0, nil
+	}
+	var errs error
+	var requeueAfter time.Duration
+	for _, partition := range r.PartitionMapping.PreviousPartitions(k8sService.Namespace) {
+		apiClient, err := consul.NewClientFromConnMgrState(r.consulClientConfigForPartition(partition), serverState)
+		if err != nil {
+			errs = multierror.Append(errs, fmt.Errorf("failed to create Consul API client for partition %q: %w", partition, err))
+			continue
+		}
+		after, err := r.deregisterService(ctx, apiClient, k8sService.Name, k8sService.Namespace, nil, reasonPartitionChanged)
+		if err != nil {
+			errs = multierror.Append(errs, fmt.Errorf("failed to deregister from partition %q: %w", partition, err))
+		}
+		requeueAfter = max(requeueAfter, after)
+	}
+	return requeueAfter, errs
+}
+
+// consulClientConfigForPartition returns the config for a Consul API client scoped to the Admin Partition.
+func (r *Controller) consulClientConfigForPartition(partition string) *consul.Config {
+	cfg := *r.ConsulClientConfig
+	apiClientConfig := *cfg.APIClientConfig
+	apiClientConfig.Partition = partition
+	cfg.APIClientConfig = &apiClientConfig
+	return &cfg
+}
+
 func (r *Controller) appendNodeMeta(registration *api.CatalogRegistration) {
 	for k, v := range r.NodeMeta {
 		registration.NodeMeta[k] = v
@@ -1534,7 +2351,7 @@ func assignServiceVirtualIP(ctx context.Context, apiClient *api.Client, svc *api
 	}
 
 	_, _, err := apiClient.Internal().AssignServiceVirtualIP(ctx, svc.Service, []string{ip}, &api.WriteOptions{Namespace: svc.Namespace, Partition: svc.Partition})
-	if err != nil {
+	if err = countConsulAPIError(consulOpAssignVirtualIP, err); err != nil {
 		// Maintain backwards compatibility with older versions of Consul that do not support the VIP improvements. Tproxy
 		// will not work 100% correctly but the mesh will still work
 		if strings.Contains(err.Error(), "404") {
@@ -1574,8 +2391,9 @@ func isTelemetryCollector(pod corev1.Pod) bool {
 // pod deployed to a namespace. If it is, it's connect-inject will fail for lack of a namespace.
 func (r *Controller) ensureNamespaceExists(apiClient *api.Client, pod corev1.Pod) error {
 	if r.EnableConsulNamespaces {
-		consulNS := r.consulNamespace(pod.Namespace)
-		if _, err := namespaces.EnsureExists(apiClient, consulNS, r.CrossNSACLPolicy); err != nil {
+		consulNS := r.podConsulNamespace(pod)
+		_, err := namespaces.EnsureExists(apiClient, consulNS, r.CrossNSACLPolicy)
+		if err = countConsulAPIError(consulOpNamespace, err); err != nil {
 			r.Log.Error(err, "failed to ensure Consul namespace exists", "ns", pod.Namespace, "consul ns", consulNS)
 			return err
 		}
@@ -1597,6 +2415,19 @@ func mapAddresses(addresses corev1.EndpointSubset) map[corev1.EndpointAddress]st
 	return m
 }
 
+// labelTags returns the tags that are added to the Consul service and proxy registrations
+// from the labels of the pod, sorted.
+func (r *Controller) labelTags(pod corev1.Pod) []string {
+	var tags []string
+	for label, prefix := range r.ServiceTagsFromLabels {
+		if v, ok := pod.Labels[label]; ok && v != "" {
+			tags = append(tags, prefix+v)
+		}
+	}
+	sort.Strings(tags)
+	return tags
+}
+
 // isLabeledIgnore checks the value of the label `consul.hashicorp.com/service-ignore` and returns true if the
 // label exists and is "truthy". Otherwise, it returns false.
 func isLabeledIgnore(labels map[string]string) bool {
@@ -1606,6 +2437,28 @@ func isLabeledIgnore(labels map[string]string) bool {
 	return shouldIgnore && labelExists && err == nil
 }
 
+// isServiceAnnotatedIgnore returns true if the Service of the Endpoints object has the annotation
+// `consul.hashicorp.com/service-ignore` set to a "truthy" value. A missing Service is not ignored.
+func (r *Controller) isServiceAnnotatedIgnore(ctx context.Context, name types.NamespacedName) (bool, error) {
+	var svc corev1.Service
+	if err := r.Client.Get(ctx, name, &svc); err != nil {
+		return false, client.IgnoreNotFound(err)
+	}
+	shouldIgnore, err := strconv.ParseBool(svc.Annotations[constants.AnnotationServiceIgnore])
+	return shouldIgnore && err == nil, nil
+}
+
+// serviceIgnoreAnnotationChanged only passes updates of Services that change the value of the
+// `consul.hashicorp.com/service-ignore` annotation.
+var serviceIgnoreAnnotationChanged = predicate.Funcs{
+	CreateFunc:  func(event.CreateEvent) bool { return false },
+	DeleteFunc:  func(event.DeleteEvent) bool { return false },
+	GenericFunc: func(event.GenericEvent) bool { return false },
+	UpdateFunc: func(e event.UpdateEvent) bool {
+		return e.ObjectOld.GetAnnotations()[constants.AnnotationServiceIgnore] != e.ObjectNew.GetAnnotations()[constants.AnnotationServiceIgnore]
+	},
+}
+
 // consulTags returns tags that should be added to the Consul service and proxy registrations.
 func consulTags(pod corev1.Pod) []string {
 	var tags []string
@@ -1629,7 +2482,15 @@ func consulTags(pod corev1.Pod) []string {
 }
 
 func getMultiPortIdx(pod corev1.Pod, serviceEndpoints corev1.Endpoints) int {
-	for i, name := range strings.Split(pod.Annotations[constants.AnnotationService], ",") {
+	names := strings.Split(pod.Annotations[constants.AnnotationService], ",")
+	// The connect-service-ports annotation, if valid, is the source of truth for the order of the services.
+	if servicePorts, err := common.ServicePorts(pod); err == nil && servicePorts != nil {
+		names = names[:0]
+		for _, sp := range servicePorts {
+			names = append(names, sp.Service)
+		}
+	}
+	for i, name := range names {
 		if name == serviceName(pod, serviceEndpoints) {
 			return i
 		}
@@ -1639,13 +2500,22 @@ func getMultiPortIdx(pod corev1.Pod, serviceEndpoints corev1.Endpoints) int {
 
 // deregister returns that the address is marked for deregistration if the map is nil or if the address is explicitly
 // marked in the map for deregistration.
-func deregister(address string, deregisterEndpointAddress map[string]bool) bool {
+func deregister(svc *api.CatalogService, deregisterEndpointAddress map[string]bool) bool {
 	if deregisterEndpointAddress == nil {
 		return true
 	}
-	deregister, ok := deregisterEndpointAddress[address]
+	deregister, ok := deregisterEndpointAddress[svc.ServiceAddress]
 	if ok {
 		return deregister
 	}
+	// The Endpoints object of a Service of the other IP family than the primary IP family lists the
+	// pods by the IP that is registered as a tagged address of dual-stack instances.
+	for _, name := range []string{lanIPv4TaggedAddressName, lanIPv6TaggedAddressName} {
+		if taggedAddress, ok := svc.ServiceTaggedAddresses[name]; ok {
+			if deregister, ok := deregisterEndpointAddress[taggedAddress.Address]; ok {
+				return deregister
+			}
+		}
+	}
 	return true
 }
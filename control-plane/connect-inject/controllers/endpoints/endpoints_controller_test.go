@@ -7,6 +7,8 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"net/http"
+	"strconv"
 	"strings"
 	"testing"
 	"time"
@@ -24,12 +26,17 @@ import (
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/types"
 	"k8s.io/apimachinery/pkg/util/intstr"
+	"k8s.io/client-go/tools/record"
+	"k8s.io/utils/ptr"
 	ctrl "sigs.k8s.io/controller-runtime"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/event"
 
+	"github.com/hashicorp/consul-k8s/control-plane/connect-inject/common"
 	"github.com/hashicorp/consul-k8s/control-plane/connect-inject/constants"
 	"github.com/hashicorp/consul-k8s/control-plane/connect-inject/metrics"
+	"github.com/hashicorp/consul-k8s/control-plane/consul"
 	"github.com/hashicorp/consul-k8s/control-plane/helper/test"
 )
 
@@ -100,6 +107,46 @@ func TestProcessUpstreams(t *testing.T) {
 			consulNamespacesEnabled: false,
 			consulPartitionsEnabled: false,
 		},
+		{
+			name: "annotated upstreams with upstream config",
+			pod: func() *corev1.Pod {
+				pod1 := createServicePod("pod1", "1.2.3.4", true, true)
+				pod1.Annotations[constants.AnnotationUpstreams] = "upstream1.svc:1234,upstream2:2234"
+				pod1.Annotations[constants.AnnotationUpstreamConnectTimeout] = "2s"
+				pod1.Annotations[constants.AnnotationOutlierMaxEjectionPercent] = "50"
+				return pod1
+			},
+			expected: []api.Upstream{
+				{
+					DestinationType: api.UpstreamDestTypeService,
+					DestinationName: "upstream1",
+					LocalBindPort:   1234,
+					Config: map[string]interface{}{
+						"connect_timeout_ms":   int64(2000),
+						"passive_health_check": map[string]interface{}{"max_ejection_percent": uint64(50)},
+					},
+				},
+				{
+					DestinationType: api.UpstreamDestTypeService,
+					DestinationName: "upstream2",
+					LocalBindPort:   2234,
+					Config: map[string]interface{}{
+						"connect_timeout_ms":   int64(2000),
+						"passive_health_check": map[string]interface{}{"max_ejection_percent": uint64(50)},
+					},
+				},
+			},
+		},
+		{
+			name: "annotated upstream with invalid upstream config",
+			pod: func() *corev1.Pod {
+				pod1 := createServicePod("pod1", "1.2.3.4", true, true)
+				pod1.Annotations[constants.AnnotationUpstreams] = "upstream1.svc:1234"
+				pod1.Annotations[constants.AnnotationOutlierMaxFailures] = "-1"
+				return pod1
+			},
+			expErr: "consul.hashicorp.com/outlier-max-failures annotation value of -1 is not a valid unsigned integer",
+		},
 		{
 			name: "annotated upstream with svc and dc",
 			pod: func() *corev1.Pod {
@@ -1072,6 +1119,120 @@ func TestReconcileCreateEndpoint(t *testing.T) {
 				},
 			},
 		},
+		{
+			name:          "Job pod that is not ready",
+			svcName:       "service-created",
+			consulSvcName: "service-created",
+			k8sObjects: func() []runtime.Object {
+				pod1 := createServicePod("pod1", "1.2.3.4", true, true)
+				pod1.OwnerReferences = []metav1.OwnerReference{{APIVersion: "batch/v1", Kind: "Job", Name: "job"}}
+				endpoint := &corev1.Endpoints{
+					ObjectMeta: metav1.ObjectMeta{
+						Name:      "service-created",
+						Namespace: "default",
+					},
+					Subsets: []corev1.EndpointSubset{
+						{
+							NotReadyAddresses: []corev1.EndpointAddress{
+								{
+									IP: "1.2.3.4",
+									TargetRef: &corev1.ObjectReference{
+										Kind:      "Pod",
+										Name:      "pod1",
+										Namespace: "default",
+									},
+								},
+							},
+						},
+					},
+				}
+				return []runtime.Object{pod1, endpoint}
+			},
+			expectedConsulSvcInstances: []*api.CatalogService{
+				{
+					ServiceID:      "pod1-service-created",
+					ServiceName:    "service-created",
+					ServiceAddress: "1.2.3.4",
+					ServicePort:    0,
+					ServiceMeta:    map[string]string{constants.MetaKeyPodName: "pod1", metaKeyKubeServiceName: "service-created", constants.MetaKeyKubeNS: "default", metaKeyManagedBy: constants.ManagedByValue, metaKeySyntheticNode: "true", constants.MetaKeyPodUID: ""},
+					ServiceTags:    []string{},
+					ServiceProxy:   &api.AgentServiceConnectProxyConfig{},
+				},
+			},
+			expectedProxySvcInstances: []*api.CatalogService{
+				{
+					ServiceID:      "pod1-service-created-sidecar-proxy",
+					ServiceName:    "service-created-sidecar-proxy",
+					ServiceAddress: "1.2.3.4",
+					ServicePort:    20000,
+					ServiceProxy: &api.AgentServiceConnectProxyConfig{
+						DestinationServiceName: "service-created",
+						DestinationServiceID:   "pod1-service-created",
+						LocalServiceAddress:    "",
+						LocalServicePort:       0,
+						Config: map[string]any{
+							"envoy_telemetry_collector_bind_socket_dir": string("/consul/connect-inject"),
+						},
+					},
+					ServiceMeta: map[string]string{constants.MetaKeyPodName: "pod1", metaKeyKubeServiceName: "service-created", constants.MetaKeyKubeNS: "default", metaKeyManagedBy: constants.ManagedByValue, metaKeySyntheticNode: "true", constants.MetaKeyPodUID: ""},
+					ServiceTags: []string{},
+				},
+			},
+			expectedHealthChecks: []*api.HealthCheck{
+				{
+					CheckID:     "default/pod1-service-created",
+					ServiceName: "service-created",
+					ServiceID:   "pod1-service-created",
+					Name:        constants.ConsulKubernetesCheckName,
+					Status:      api.HealthPassing,
+					Output:      constants.KubernetesSuccessReasonMsg,
+					Type:        constants.ConsulKubernetesCheckType,
+				},
+				{
+					CheckID:     "default/pod1-service-created-sidecar-proxy",
+					ServiceName: "service-created-sidecar-proxy",
+					ServiceID:   "pod1-service-created-sidecar-proxy",
+					Name:        constants.ConsulKubernetesCheckName,
+					Status:      api.HealthPassing,
+					Output:      constants.KubernetesSuccessReasonMsg,
+					Type:        constants.ConsulKubernetesCheckType,
+				},
+			},
+		},
+		{
+			name:          "Completed job pod",
+			svcName:       "service-created",
+			consulSvcName: "service-created",
+			k8sObjects: func() []runtime.Object {
+				pod1 := createServicePod("pod1", "1.2.3.4", true, true)
+				pod1.OwnerReferences = []metav1.OwnerReference{{APIVersion: "batch/v1", Kind: "Job", Name: "job"}}
+				pod1.Status.Phase = corev1.PodSucceeded
+				endpoint := &corev1.Endpoints{
+					ObjectMeta: metav1.ObjectMeta{
+						Name:      "service-created",
+						Namespace: "default",
+					},
+					Subsets: []corev1.EndpointSubset{
+						{
+							NotReadyAddresses: []corev1.EndpointAddress{
+								{
+									IP: "1.2.3.4",
+									TargetRef: &corev1.ObjectReference{
+										Kind:      "Pod",
+										Name:      "pod1",
+										Namespace: "default",
+									},
+								},
+							},
+						},
+					},
+				}
+				return []runtime.Object{pod1, endpoint}
+			},
+			expectedConsulSvcInstances: nil,
+			expectedProxySvcInstances:  nil,
+			expectedHealthChecks:       nil,
+		},
 		{
 			name:          "Mesh Gateway",
 			svcName:       "mesh-gateway",
@@ -1152,6 +1313,100 @@ func TestReconcileCreateEndpoint(t *testing.T) {
 				},
 			},
 		},
+		{
+			name:          "Mesh Gateway with locality",
+			svcName:       "mesh-gateway",
+			consulSvcName: "mesh-gateway",
+			nodeMeta: map[string]string{
+				"test-node": "true",
+			},
+			k8sObjects: func() []runtime.Object {
+				gateway := createGatewayPod("mesh-gateway", "1.2.3.4", map[string]string{
+					constants.AnnotationGatewayConsulServiceName: "mesh-gateway",
+					constants.AnnotationGatewayWANSource:         "Static",
+					constants.AnnotationGatewayWANAddress:        "2.3.4.5",
+					constants.AnnotationGatewayWANPort:           "443",
+					constants.AnnotationMeshGatewayContainerPort: "8443",
+					constants.AnnotationProxyConfigMap:           `{ "xds_fetch_timeout_ms": 9999 }`,
+					constants.AnnotationGatewayKind:              meshGateway})
+				gateway.Spec.NodeName = "my-node"
+				node := &corev1.Node{
+					ObjectMeta: metav1.ObjectMeta{
+						Name: "my-node",
+						Labels: map[string]string{
+							corev1.LabelTopologyRegion: "us-west-1",
+							corev1.LabelTopologyZone:   "us-west-1a",
+						},
+					},
+				}
+				endpoint := &corev1.Endpoints{
+					ObjectMeta: metav1.ObjectMeta{
+						Name:      "mesh-gateway",
+						Namespace: "default",
+					},
+					Subsets: []corev1.EndpointSubset{
+						{
+							Addresses: []corev1.EndpointAddress{
+								{
+									IP: "1.2.3.4",
+									TargetRef: &corev1.ObjectReference{
+										Kind:      "Pod",
+										Name:      "mesh-gateway",
+										Namespace: "default",
+									},
+								},
+							},
+						},
+					},
+				}
+				return []runtime.Object{gateway, node, endpoint}
+			},
+			expectedConsulSvcInstances: []*api.CatalogService{
+				{
+					ServiceID:      "mesh-gateway",
+					ServiceName:    "mesh-gateway",
+					ServiceAddress: "1.2.3.4",
+					ServicePort:    8443,
+					ServiceMeta:    map[string]string{constants.MetaKeyPodName: "mesh-gateway", metaKeyKubeServiceName: "mesh-gateway", constants.MetaKeyKubeNS: "default", metaKeyManagedBy: constants.ManagedByValue, metaKeySyntheticNode: "true", constants.MetaKeyPodUID: ""},
+					ServiceTags:    []string{},
+					ServiceTaggedAddresses: map[string]api.ServiceAddress{
+						"lan": {
+							Address: "1.2.3.4",
+							Port:    8443,
+						},
+						"wan": {
+							Address: "2.3.4.5",
+							Port:    443,
+						},
+					},
+					ServiceProxy: &api.AgentServiceConnectProxyConfig{
+						Config: map[string]any{
+							"envoy_telemetry_collector_bind_socket_dir": string("/consul/service"),
+							"xds_fetch_timeout_ms":                      float64(9999),
+						},
+					},
+					NodeMeta: map[string]string{
+						"synthetic-node": "true",
+						"test-node":      "true",
+					},
+					ServiceLocality: &api.Locality{
+						Region: "us-west-1",
+						Zone:   "us-west-1a",
+					},
+				},
+			},
+			expectedHealthChecks: []*api.HealthCheck{
+				{
+					CheckID:     "default/mesh-gateway",
+					ServiceName: "mesh-gateway",
+					ServiceID:   "mesh-gateway",
+					Name:        constants.ConsulKubernetesCheckName,
+					Status:      api.HealthPassing,
+					Output:      constants.KubernetesSuccessReasonMsg,
+					Type:        constants.ConsulKubernetesCheckType,
+				},
+			},
+		},
 		{
 			name:          "Mesh Gateway with Metrics enabled",
 			svcName:       "mesh-gateway",
@@ -2264,36 +2519,212 @@ func TestParseLocality(t *testing.T) {
 	})
 }
 
-func TestReconcile_PodErrorPreservesToken(t *testing.T) {
-	t.Parallel()
-	cases := []struct {
-		name                       string
-		svcName                    string
-		consulSvcName              string
-		k8sObjects                 func() []runtime.Object
-		expectedConsulSvcInstances []*api.CatalogService
-		expectedProxySvcInstances  []*api.CatalogService
-		expectedHealthChecks       []*api.HealthCheck
-		metricsEnabled             bool
-		telemetryCollectorDisabled bool
-		nodeMeta                   map[string]string
-		pod1Err                    string
-	}{
-		{
-			name:          "Error when fetching pod results in not deregistering the service instances in consul",
-			svcName:       "service-created",
-			consulSvcName: "service-created",
-			nodeMeta: map[string]string{
-				"test-node": "true",
+func TestServiceLocality(t *testing.T) {
+	node := corev1.Node{
+		ObjectMeta: metav1.ObjectMeta{
+			Labels: map[string]string{
+				corev1.LabelTopologyRegion: "us-west-1",
+				corev1.LabelTopologyZone:   "us-west-1a",
 			},
-			pod1Err: "some fake error while fetching pod",
-			k8sObjects: func() []runtime.Object {
-				pod1 := createServicePod("pod1", "1.2.3.4", true, true)
-				endpoint := &corev1.Endpoints{
-					ObjectMeta: metav1.ObjectMeta{
-						Name:      "service-created",
-						Namespace: "default",
-					},
+		},
+	}
+
+	cases := map[string]struct {
+		annotation  *string
+		node        corev1.Node
+		expLocality *api.Locality
+		expErr      string
+	}{
+		"no annotation": {
+			node:        node,
+			expLocality: &api.Locality{Region: "us-west-1", Zone: "us-west-1a"},
+		},
+		"zone override": {
+			annotation:  ptr.To("zone=rack-7"),
+			node:        node,
+			expLocality: &api.Locality{Region: "us-west-1", Zone: "rack-7"},
+		},
+		"region and zone override": {
+			annotation:  ptr.To(" region=dc-east , zone=rack-7 "),
+			node:        node,
+			expLocality: &api.Locality{Region: "dc-east", Zone: "rack-7"},
+		},
+		"region override without node labels": {
+			annotation:  ptr.To("region=dc-east"),
+			expLocality: &api.Locality{Region: "dc-east"},
+		},
+		"empty annotation": {
+			annotation:  ptr.To(""),
+			node:        node,
+			expLocality: &api.Locality{Region: "us-west-1", Zone: "us-west-1a"},
+		},
+		"zone override without region": {
+			annotation: ptr.To("zone=rack-7"),
+			expErr:     `consul.hashicorp.com/service-locality annotation value "zone=rack-7" is invalid: a zone requires a region, and the node has no topology.kubernetes.io/region label`,
+		},
+		"unknown field": {
+			annotation: ptr.To("rack=rack-7"),
+			node:       node,
+			expErr:     `consul.hashicorp.com/service-locality annotation value of "rack=rack-7" is invalid: field must be "region" or "zone"`,
+		},
+		"missing value": {
+			annotation: ptr.To("zone="),
+			node:       node,
+			expErr:     `consul.hashicorp.com/service-locality annotation value of "zone=" is invalid: must be formatted as <field>=<value>`,
+		},
+	}
+	for name, c := range cases {
+		t.Run(name, func(t *testing.T) {
+			pod := corev1.Pod{ObjectMeta: metav1.ObjectMeta{Annotations: map[string]string{}}}
+			if c.annotation != nil {
+				pod.Annotations[constants.AnnotationServiceLocality] = *c.annotation
+			}
+			locality, err := serviceLocality(pod, c.node)
+			if c.expErr != "" {
+				require.EqualError(t, err, c.expErr)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, c.expLocality, locality)
+		})
+	}
+}
+
+func TestPodAddress(t *testing.T) {
+	dualStackStatus := corev1.PodStatus{
+		PodIP:  "10.0.0.1",
+		PodIPs: []corev1.PodIP{{IP: "10.0.0.1"}, {IP: "fd00::1"}},
+	}
+
+	cases := map[string]struct {
+		primaryIPFamily corev1.IPFamily
+		annotation      *string
+		status          corev1.PodStatus
+		expAddress      string
+		expErr          string
+	}{
+		"no primary family": {
+			status:     dualStackStatus,
+			expAddress: "10.0.0.1",
+		},
+		"IPv6 primary family": {
+			primaryIPFamily: corev1.IPv6Protocol,
+			status:          dualStackStatus,
+			expAddress:      "fd00::1",
+		},
+		"annotation overrides primary family": {
+			primaryIPFamily: corev1.IPv6Protocol,
+			annotation:      ptr.To("IPv4"),
+			status:          dualStackStatus,
+			expAddress:      "10.0.0.1",
+		},
+		"single-stack pod without an IP of the primary family": {
+			primaryIPFamily: corev1.IPv6Protocol,
+			status:          corev1.PodStatus{PodIP: "10.0.0.1"},
+			expAddress:      "10.0.0.1",
+		},
+		"invalid annotation": {
+			annotation: ptr.To("ipv6"),
+			status:     dualStackStatus,
+			expErr:     `consul.hashicorp.com/primary-ip-family must be "IPv4" or "IPv6", got "ipv6"`,
+		},
+	}
+	for name, c := range cases {
+		t.Run(name, func(t *testing.T) {
+			pod := corev1.Pod{ObjectMeta: metav1.ObjectMeta{Annotations: map[string]string{}}, Status: c.status}
+			if c.annotation != nil {
+				pod.Annotations[constants.AnnotationPrimaryIPFamily] = *c.annotation
+			}
+			r := Controller{PrimaryIPFamily: c.primaryIPFamily}
+			address, err := r.podAddress(pod)
+			if c.expErr != "" {
+				require.EqualError(t, err, c.expErr)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, c.expAddress, address)
+		})
+	}
+}
+
+func TestAddDualStackTaggedAddresses(t *testing.T) {
+	virtual := map[string]api.ServiceAddress{
+		clusterIPTaggedAddressName: {Address: "10.96.0.10", Port: 80},
+	}
+	service := &api.AgentService{Port: 8080, TaggedAddresses: virtual}
+	proxyService := &api.AgentService{Port: 20000, TaggedAddresses: virtual}
+	pod := corev1.Pod{Status: corev1.PodStatus{
+		PodIP:  "fd00::1",
+		PodIPs: []corev1.PodIP{{IP: "fd00::1"}, {IP: "10.0.0.1"}},
+	}}
+
+	addDualStackTaggedAddresses(service, pod, service.Port)
+	addDualStackTaggedAddresses(proxyService, pod, proxyService.Port)
+
+	require.Equal(t, map[string]api.ServiceAddress{
+		clusterIPTaggedAddressName: {Address: "10.96.0.10", Port: 80},
+		lanIPv4TaggedAddressName:   {Address: "10.0.0.1", Port: 8080},
+		lanIPv6TaggedAddressName:   {Address: "fd00::1", Port: 8080},
+	}, service.TaggedAddresses)
+	require.Equal(t, map[string]api.ServiceAddress{
+		clusterIPTaggedAddressName: {Address: "10.96.0.10", Port: 80},
+		lanIPv4TaggedAddressName:   {Address: "10.0.0.1", Port: 20000},
+		lanIPv6TaggedAddressName:   {Address: "fd00::1", Port: 20000},
+	}, proxyService.TaggedAddresses)
+
+	// The tagged addresses of single-stack pods are not changed.
+	singleStack := &api.AgentService{Port: 8080}
+	addDualStackTaggedAddresses(singleStack, corev1.Pod{Status: corev1.PodStatus{PodIP: "10.0.0.1"}}, singleStack.Port)
+	require.Nil(t, singleStack.TaggedAddresses)
+}
+
+func TestDeregister_dualStack(t *testing.T) {
+	svc := &api.CatalogService{
+		ServiceAddress: "10.0.0.1",
+		ServiceTaggedAddresses: map[string]api.ServiceAddress{
+			lanIPv4TaggedAddressName: {Address: "10.0.0.1"},
+			lanIPv6TaggedAddressName: {Address: "fd00::1"},
+		},
+	}
+
+	require.True(t, deregister(svc, nil))
+	require.True(t, deregister(svc, map[string]bool{"10.0.0.2": false}))
+	require.False(t, deregister(svc, map[string]bool{"10.0.0.1": false}))
+	// The Endpoints of an IPv6 Service list the pod by its IPv6 address.
+	require.False(t, deregister(svc, map[string]bool{"fd00::1": false}))
+	require.True(t, deregister(svc, map[string]bool{"fd00::1": true}))
+}
+
+func TestReconcile_PodErrorPreservesToken(t *testing.T) {
+	t.Parallel()
+	cases := []struct {
+		name                       string
+		svcName                    string
+		consulSvcName              string
+		k8sObjects                 func() []runtime.Object
+		expectedConsulSvcInstances []*api.CatalogService
+		expectedProxySvcInstances  []*api.CatalogService
+		expectedHealthChecks       []*api.HealthCheck
+		metricsEnabled             bool
+		telemetryCollectorDisabled bool
+		nodeMeta                   map[string]string
+		pod1Err                    string
+	}{
+		{
+			name:          "Error when fetching pod results in not deregistering the service instances in consul",
+			svcName:       "service-created",
+			consulSvcName: "service-created",
+			nodeMeta: map[string]string{
+				"test-node": "true",
+			},
+			pod1Err: "some fake error while fetching pod",
+			k8sObjects: func() []runtime.Object {
+				pod1 := createServicePod("pod1", "1.2.3.4", true, true)
+				endpoint := &corev1.Endpoints{
+					ObjectMeta: metav1.ObjectMeta{
+						Name:      "service-created",
+						Namespace: "default",
+					},
 					Subsets: []corev1.EndpointSubset{
 						{
 							Addresses: []corev1.EndpointAddress{
@@ -3933,6 +4364,108 @@ func TestReconcileUpdateEndpoint(t *testing.T) {
 }
 
 // TestReconcileUpdateEndpoint_LegacyService tests that we can update health checks on a consul client.
+// Tests that the service instances of a Service are moved to the nodes of the new node naming strategy
+// and the nodes of the old strategy are deregistered once they have no instances left.
+func TestReconcileUpdateEndpoint_NodeNamingStrategyChanged(t *testing.T) {
+	t.Parallel()
+	pod1 := createServicePod("pod1", "1.2.3.4", true, true)
+	pod1.Annotations[constants.AnnotationNodeNamingStrategy] = string(common.NodeNamingPerCluster)
+	endpoint := &corev1.Endpoints{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "service-updated",
+			Namespace: "default",
+		},
+		Subsets: []corev1.EndpointSubset{
+			{
+				Addresses: []corev1.EndpointAddress{
+					{
+						IP: "1.2.3.4",
+						TargetRef: &corev1.ObjectReference{
+							Kind:      "Pod",
+							Name:      "pod1",
+							Namespace: "default",
+						},
+					},
+				},
+			},
+		},
+	}
+	ns := corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "default"}}
+	node := corev1.Node{ObjectMeta: metav1.ObjectMeta{Name: nodeName}}
+	fakeClient := fake.NewClientBuilder().WithRuntimeObjects(pod1, endpoint, &ns, &node).Build()
+
+	testClient := test.TestServerWithMockConnMgrWatcher(t, nil)
+	consulClient := testClient.APIClient
+	testClient.TestServer.WaitForActiveCARoot(t)
+
+	// Register the service and proxy on the node of the per-node strategy.
+	meta := map[string]string{
+		constants.MetaKeyKubeNS:  "default",
+		constants.MetaKeyPodName: "pod1",
+		metaKeyKubeServiceName:   "service-updated",
+		metaKeyManagedBy:         constants.ManagedByValue,
+		metaKeySyntheticNode:     "true",
+		constants.MetaKeyPodUID:  string(pod1.UID),
+	}
+	for _, svc := range []*api.AgentService{
+		{
+			ID:      "pod1-service-updated",
+			Service: "service-updated",
+			Port:    80,
+			Address: "1.2.3.4",
+			Meta:    meta,
+		},
+		{
+			Kind:    api.ServiceKindConnectProxy,
+			ID:      "pod1-service-updated-sidecar-proxy",
+			Service: "service-updated-sidecar-proxy",
+			Port:    20000,
+			Address: "1.2.3.4",
+			Meta:    meta,
+			Proxy: &api.AgentServiceConnectProxyConfig{
+				DestinationServiceName: "service-updated",
+				DestinationServiceID:   "pod1-service-updated",
+			},
+		},
+	} {
+		_, err := consulClient.Catalog().Register(&api.CatalogRegistration{
+			Node:     consulNodeName,
+			Address:  consulNodeAddress,
+			NodeMeta: map[string]string{metaKeySyntheticNode: "true"},
+			Service:  svc,
+		}, nil)
+		require.NoError(t, err)
+	}
+
+	ep := &Controller{
+		Client:                fakeClient,
+		Log:                   logrtest.New(t),
+		ConsulClientConfig:    testClient.Cfg,
+		ConsulServerConnMgr:   testClient.Watcher,
+		AllowK8sNamespacesSet: mapset.NewSetWith("*"),
+		DenyK8sNamespacesSet:  mapset.NewSetWith(),
+		ReleaseName:           "consul",
+		ReleaseNamespace:      "default",
+	}
+	namespacedName := types.NamespacedName{Namespace: "default", Name: "service-updated"}
+	resp, err := ep.Reconcile(context.Background(), ctrl.Request{NamespacedName: namespacedName})
+	require.NoError(t, err)
+	require.False(t, resp.Requeue)
+
+	for _, name := range []string{"service-updated", "service-updated-sidecar-proxy"} {
+		instances, _, err := consulClient.Catalog().Service(name, "", nil)
+		require.NoError(t, err)
+		require.Len(t, instances, 1)
+		require.Equal(t, "k8s-cluster-virtual", instances[0].Node)
+		require.Equal(t, "1.2.3.4", instances[0].ServiceAddress)
+	}
+
+	// The node of the old strategy has no instances left.
+	oldNode, _, err := consulClient.Catalog().Node(consulNodeName, nil)
+	require.NoError(t, err)
+	require.Nil(t, oldNode)
+}
+
 func TestReconcileUpdateEndpoint_LegacyService(t *testing.T) {
 	t.Parallel()
 	cases := []struct {
@@ -4742,8 +5275,8 @@ func TestReconcileDeleteEndpoint(t *testing.T) {
 }
 
 // TestReconcileIgnoresServiceIgnoreLabel tests that the endpoints controller correctly ignores services
-// with the service-ignore label and deregisters services previously registered if the service-ignore
-// label is added.
+// with the service-ignore label or annotation and deregisters services previously registered if the
+// service-ignore label or annotation is added.
 func TestReconcileIgnoresServiceIgnoreLabel(t *testing.T) {
 	t.Parallel()
 	svcName := "service-ignored"
@@ -4752,6 +5285,7 @@ func TestReconcileIgnoresServiceIgnoreLabel(t *testing.T) {
 	cases := map[string]struct {
 		svcInitiallyRegistered  bool
 		serviceLabels           map[string]string
+		serviceAnnotations      map[string]string
 		expectedNumSvcInstances int
 	}{
 		"Registered endpoint with label is deregistered.": {
@@ -4778,6 +5312,27 @@ func TestReconcileIgnoresServiceIgnoreLabel(t *testing.T) {
 			serviceLabels:           map[string]string{},
 			expectedNumSvcInstances: 1,
 		},
+		"Registered endpoint of annotated service is deregistered": {
+			svcInitiallyRegistered: true,
+			serviceAnnotations: map[string]string{
+				constants.AnnotationServiceIgnore: "true",
+			},
+			expectedNumSvcInstances: 0,
+		},
+		"Not registered endpoint of annotated service is never registered": {
+			svcInitiallyRegistered: false,
+			serviceAnnotations: map[string]string{
+				constants.AnnotationServiceIgnore: "true",
+			},
+			expectedNumSvcInstances: 0,
+		},
+		"Endpoint of service annotated with false is registered": {
+			svcInitiallyRegistered: false,
+			serviceAnnotations: map[string]string{
+				constants.AnnotationServiceIgnore: "false",
+			},
+			expectedNumSvcInstances: 1,
+		},
 	}
 
 	for name, tt := range cases {
@@ -4804,10 +5359,17 @@ func TestReconcileIgnoresServiceIgnoreLabel(t *testing.T) {
 					},
 				},
 			}
+			service := &corev1.Service{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:        svcName,
+					Namespace:   namespace,
+					Annotations: tt.serviceAnnotations,
+				},
+			}
 			pod1 := createServicePod("pod1", "1.2.3.4", true, true)
 			ns := corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: namespace}}
 			node := corev1.Node{ObjectMeta: metav1.ObjectMeta{Name: nodeName}}
-			k8sObjects := []runtime.Object{endpoint, pod1, &ns, &node}
+			k8sObjects := []runtime.Object{endpoint, service, pod1, &ns, &node}
 			fakeClient := fake.NewClientBuilder().WithRuntimeObjects(k8sObjects...).Build()
 
 			// Create test consulServer server
@@ -4871,6 +5433,38 @@ func TestReconcileIgnoresServiceIgnoreLabel(t *testing.T) {
 	}
 }
 
+func TestServiceIgnoreAnnotationChanged(t *testing.T) {
+	svc := func(annotations map[string]string) *corev1.Service {
+		return &corev1.Service{ObjectMeta: metav1.ObjectMeta{Name: "svc", Namespace: "default", Annotations: annotations}}
+	}
+	cases := map[string]struct {
+		oldSvc, newSvc *corev1.Service
+		exp            bool
+	}{
+		"annotation added": {
+			oldSvc: svc(nil),
+			newSvc: svc(map[string]string{constants.AnnotationServiceIgnore: "true"}),
+			exp:    true,
+		},
+		"annotation removed": {
+			oldSvc: svc(map[string]string{constants.AnnotationServiceIgnore: "true"}),
+			newSvc: svc(nil),
+			exp:    true,
+		},
+		"annotation unchanged": {
+			oldSvc: svc(map[string]string{constants.AnnotationServiceIgnore: "true"}),
+			newSvc: svc(map[string]string{constants.AnnotationServiceIgnore: "true", "other": "value"}),
+			exp:    false,
+		},
+	}
+	for name, c := range cases {
+		t.Run(name, func(t *testing.T) {
+			require.Equal(t, c.exp, serviceIgnoreAnnotationChanged.Update(event.UpdateEvent{ObjectOld: c.oldSvc, ObjectNew: c.newSvc}))
+		})
+	}
+	require.False(t, serviceIgnoreAnnotationChanged.Create(event.CreateEvent{Object: svc(nil)}))
+}
+
 // Test that when an endpoints pod specifies the name for the Kubernetes service it wants to use
 // for registration, all other endpoints for that pod are skipped.
 func TestReconcile_podSpecifiesExplicitService(t *testing.T) {
@@ -5802,6 +6396,66 @@ func TestCreateServiceRegistrations_withTransparentProxy(t *testing.T) {
 			},
 			expErr: "",
 		},
+		"overwrite probes enabled globally, exec probe translated via annotation": {
+			tproxyGlobalEnabled: true,
+			overwriteProbes:     true,
+			podAnnotations: map[string]string{
+				constants.AnnotationTransparentProxyTranslateExecProbes: "true",
+				constants.AnnotationOriginalPod:                         "{\"metadata\":{\"name\":\"test-pod-1\",\"namespace\":\"default\",\"creationTimestamp\":null,\"annotations\":{\"consul.hashicorp.com/transparent-proxy-translate-exec-probes\":\"true\"}},\"spec\":{\"containers\":[{\"name\":\"test\",\"ports\":[{\"name\":\"tcp\",\"containerPort\":8081},{\"name\":\"http\",\"containerPort\":8080}],\"resources\":{},\"livenessProbe\":{\"exec\":{\"command\":[\"sh\",\"-c\",\"curl -f http://localhost:8080/healthz\"]}}}]},\"status\":{\"hostIP\":\"127.0.0.1\",\"podIP\":\"1.2.3.4\"}}\n",
+			},
+			podContainers: []corev1.Container{
+				{
+					Name: "test",
+					Ports: []corev1.ContainerPort{
+						{
+							Name:          "tcp",
+							ContainerPort: 8081,
+						},
+						{
+							Name:          "http",
+							ContainerPort: 8080,
+						},
+					},
+					LivenessProbe: &corev1.Probe{
+						ProbeHandler: corev1.ProbeHandler{
+							HTTPGet: &corev1.HTTPGetAction{
+								Path: "/healthz",
+								Port: intstr.FromInt(20300),
+							},
+						},
+					},
+				},
+			},
+			service: &corev1.Service{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      serviceName,
+					Namespace: "default",
+				},
+				Spec: corev1.ServiceSpec{
+					ClusterIP: "10.0.0.1",
+					Ports: []corev1.ServicePort{
+						{
+							Port: 8081,
+						},
+					},
+				},
+			},
+			expProxyMode: api.ProxyModeTransparent,
+			expTaggedAddresses: map[string]api.ServiceAddress{
+				"virtual": {
+					Address: "10.0.0.1",
+					Port:    8081,
+				},
+			},
+			expExposePaths: []api.ExposePath{
+				{
+					ListenerPort:  20300,
+					LocalPathPort: 8080,
+					Path:          "/healthz",
+				},
+			},
+			expErr: "",
+		},
 		"overwrite probes disabled globally, enabled via annotation": {
 			tproxyGlobalEnabled: true,
 			overwriteProbes:     false,
@@ -6526,6 +7180,672 @@ func TestMapAddresses(t *testing.T) {
 	}
 }
 
+func TestPodDeregisterReason(t *testing.T) {
+	t.Parallel()
+	cases := map[string]struct {
+		pod       func() *corev1.Pod
+		svcMeta   map[string]string
+		svcNode   string
+		strategy  common.NodeNamingStrategy
+		expReason deregisterReason
+	}{
+		"pod deleted": {
+			pod:       func() *corev1.Pod { return nil },
+			svcNode:   consulNodeName,
+			expReason: reasonPodDeleted,
+		},
+		"pod UID changed": {
+			pod: func() *corev1.Pod {
+				pod := createServicePod("pod1", "1.2.3.4", true, true)
+				pod.UID = "new-uid"
+				return pod
+			},
+			svcMeta:   map[string]string{constants.MetaKeyPodUID: "old-uid"},
+			svcNode:   consulNodeName,
+			expReason: reasonPodUIDChanged,
+		},
+		"pod UID not in service metadata": {
+			pod: func() *corev1.Pod {
+				pod := createServicePod("pod1", "1.2.3.4", true, true)
+				pod.UID = "new-uid"
+				return pod
+			},
+			svcMeta:   map[string]string{constants.MetaKeyPodUID: ""},
+			svcNode:   consulNodeName,
+			expReason: reasonEndpointRemoved,
+		},
+		"node changed": {
+			pod: func() *corev1.Pod {
+				return createServicePod("pod1", "1.2.3.4", true, true)
+			},
+			svcNode:   "other-node-virtual",
+			expReason: reasonNodeChanged,
+		},
+		"node naming strategy changed": {
+			pod: func() *corev1.Pod {
+				return createServicePod("pod1", "1.2.3.4", true, true)
+			},
+			svcNode:   consulNodeName,
+			strategy:  common.NodeNamingPerCluster,
+			expReason: reasonNodeChanged,
+		},
+		"per-namespace node": {
+			pod: func() *corev1.Pod {
+				return createServicePod("pod1", "1.2.3.4", true, true)
+			},
+			svcNode:   "k8s-namespace-default-virtual",
+			strategy:  common.NodeNamingPerNamespace,
+			expReason: reasonEndpointRemoved,
+		},
+		"explicit service annotation does not match": {
+			pod: func() *corev1.Pod {
+				pod := createServicePod("pod1", "1.2.3.4", true, true)
+				pod.Annotations[constants.AnnotationKubernetesService] = "other-service"
+				return pod
+			},
+			svcNode:   consulNodeName,
+			expReason: reasonServiceAnnotationMismatch,
+		},
+		"mesh annotation removed": {
+			pod: func() *corev1.Pod {
+				return createServicePod("pod1", "1.2.3.4", false, true)
+			},
+			svcNode:   consulNodeName,
+			expReason: reasonMeshAnnotationRemoved,
+		},
+		"gateway pod": {
+			pod: func() *corev1.Pod {
+				return createGatewayPod("mesh-gateway", "1.2.3.4", map[string]string{
+					constants.AnnotationGatewayKind: meshGateway,
+				})
+			},
+			svcNode:   consulNodeName,
+			expReason: reasonEndpointRemoved,
+		},
+		"job completed": {
+			pod: func() *corev1.Pod {
+				pod := createServicePod("pod1", "1.2.3.4", true, true)
+				pod.OwnerReferences = []metav1.OwnerReference{{APIVersion: "batch/v1", Kind: "Job", Name: "job"}}
+				pod.Status.Phase = corev1.PodSucceeded
+				return pod
+			},
+			svcNode:   consulNodeName,
+			expReason: reasonPodCompleted,
+		},
+		"job still running": {
+			pod: func() *corev1.Pod {
+				pod := createServicePod("pod1", "1.2.3.4", true, true)
+				pod.OwnerReferences = []metav1.OwnerReference{{APIVersion: "batch/v1", Kind: "Job", Name: "job"}}
+				return pod
+			},
+			svcNode:   consulNodeName,
+			expReason: reasonEndpointRemoved,
+		},
+		"pod still part of the mesh": {
+			pod: func() *corev1.Pod {
+				return createServicePod("pod1", "1.2.3.4", true, true)
+			},
+			svcNode:   consulNodeName,
+			expReason: reasonEndpointRemoved,
+		},
+	}
+	for name, c := range cases {
+		t.Run(name, func(t *testing.T) {
+			svc := &api.CatalogService{Node: c.svcNode, ServiceMeta: c.svcMeta}
+			pod := c.pod()
+			if c.strategy != "" {
+				pod.Annotations[constants.AnnotationNodeNamingStrategy] = string(c.strategy)
+			}
+			r := &Controller{}
+			require.Equal(t, c.expReason, r.podDeregisterReason(pod, svc, "service-created", reasonEndpointRemoved))
+		})
+	}
+}
+
+func TestAuditDeregistration(t *testing.T) {
+	t.Parallel()
+	svc := &api.CatalogService{
+		Node:        consulNodeName,
+		ServiceID:   "pod1-service-created",
+		ServiceName: "service-created",
+		ServiceMeta: map[string]string{constants.MetaKeyPodName: "pod1"},
+	}
+
+	t.Run("without an event recorder", func(t *testing.T) {
+		ep := &Controller{Log: logrtest.New(t)}
+		ep.auditDeregistration(svc, "service-created", "default", reasonEndpointRemoved)
+	})
+
+	t.Run("with an event recorder", func(t *testing.T) {
+		recorder := record.NewFakeRecorder(1)
+		ep := &Controller{Log: logrtest.New(t), EventRecorder: recorder}
+		ep.auditDeregistration(svc, "service-created", "default", reasonPodUIDChanged)
+
+		require.Len(t, recorder.Events, 1)
+		require.Equal(t, `Normal PodUIDChanged Deregistered service instance "pod1-service-created" of pod "pod1" on node "test-node-virtual" from Consul`, <-recorder.Events)
+	})
+}
+
+func TestCreateServiceRegistrations_withServicePorts(t *testing.T) {
+	t.Parallel()
+
+	cases := map[string]struct {
+		annotation     string
+		endpointsName  string
+		expPort        int
+		expProxyPort   int
+		expHasUpstream bool
+		expErr         string
+		expEvent       string
+	}{
+		"first service": {
+			annotation:     `[{"service":"web","port":"http","container":"web"},{"service":"web-admin","port":"http","container":"admin"}]`,
+			endpointsName:  "web",
+			expPort:        8080,
+			expProxyPort:   20000,
+			expHasUpstream: true,
+		},
+		"second service": {
+			annotation:    `[{"service":"web","port":"http","container":"web"},{"service":"web-admin","port":"http","container":"admin"}]`,
+			endpointsName: "web-admin",
+			expPort:       9090,
+			expProxyPort:  20001,
+		},
+		"invalid annotation": {
+			annotation:    `[{"service":"web","port":"grpc"}]`,
+			endpointsName: "web",
+			expErr:        `consul.hashicorp.com/connect-service-ports annotation value has invalid port for service "web": named port "grpc" not found`,
+			expEvent:      `Warning InvalidServicePorts consul.hashicorp.com/connect-service-ports annotation value has invalid port for service "web": named port "grpc" not found`,
+		},
+		"service not in annotation": {
+			annotation:    `[{"service":"web","port":"http","container":"web"},{"service":"web-admin","port":"http","container":"admin"}]`,
+			endpointsName: "web-metrics",
+			expErr:        `service "web-metrics" is not mapped to a port by the consul.hashicorp.com/connect-service-ports annotation`,
+			expEvent:      `Warning InvalidServicePorts service "web-metrics" is not mapped to a port by the consul.hashicorp.com/connect-service-ports annotation`,
+		},
+	}
+
+	for name, c := range cases {
+		t.Run(name, func(t *testing.T) {
+			pod := createServicePod("pod1", "1.2.3.4", true, true)
+			pod.Spec.Containers = []corev1.Container{
+				{Name: "web", Ports: []corev1.ContainerPort{{Name: "http", ContainerPort: 8080}}},
+				{Name: "admin", Ports: []corev1.ContainerPort{{Name: "http", ContainerPort: 9090}}},
+			}
+			pod.Annotations[constants.AnnotationServicePorts] = c.annotation
+			// These annotations are set from the service ports annotation by the meshWebhook, but
+			// the endpoints controller must not depend on them.
+			pod.Annotations[constants.AnnotationService] = "web-admin,web"
+			pod.Annotations[constants.AnnotationPort] = "9090,8080"
+			pod.Annotations[constants.AnnotationUpstreams] = "upstream1:1234"
+
+			endpoints := &corev1.Endpoints{ObjectMeta: metav1.ObjectMeta{Name: c.endpointsName, Namespace: "default"}}
+			ns := corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: pod.Namespace}}
+			recorder := record.NewFakeRecorder(1)
+			epCtrl := Controller{
+				Client:        fake.NewClientBuilder().WithRuntimeObjects(pod, endpoints, &ns).Build(),
+				Log:           logrtest.New(t),
+				EventRecorder: recorder,
+			}
+
+			serviceRegistration, proxyServiceRegistration, err := epCtrl.createServiceRegistrations(*pod, *endpoints, api.HealthPassing)
+			if c.expErr != "" {
+				require.EqualError(t, err, c.expErr)
+				require.Len(t, recorder.Events, 1)
+				require.Equal(t, c.expEvent, <-recorder.Events)
+				return
+			}
+			require.NoError(t, err)
+			require.Empty(t, recorder.Events)
+			require.Equal(t, c.endpointsName, serviceRegistration.Service.Service)
+			require.Equal(t, c.expPort, serviceRegistration.Service.Port)
+			require.Equal(t, c.expPort, proxyServiceRegistration.Service.Proxy.LocalServicePort)
+			require.Equal(t, c.expProxyPort, proxyServiceRegistration.Service.Port)
+			require.Equal(t, c.expHasUpstream, len(proxyServiceRegistration.Service.Proxy.Upstreams) > 0)
+		})
+	}
+}
+
+func TestCreateServiceRegistrations_fromLabels(t *testing.T) {
+	t.Parallel()
+
+	pod := createServicePod("pod1", "1.2.3.4", true, true)
+	pod.Labels["app.kubernetes.io/version"] = "1.2.3"
+	pod.Labels["app.kubernetes.io/part-of"] = "shop"
+	pod.Labels["team"] = "payments"
+	pod.Labels["empty"] = ""
+	pod.Annotations[constants.AnnotationMeta+"team"] = "checkout"
+	pod.Annotations[constants.AnnotationTags] = "abc"
+
+	endpoints := &corev1.Endpoints{ObjectMeta: metav1.ObjectMeta{Name: "web", Namespace: "default"}}
+	ns := corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: pod.Namespace}}
+	epCtrl := Controller{
+		Client: fake.NewClientBuilder().WithRuntimeObjects(pod, endpoints, &ns).Build(),
+		Log:    logrtest.New(t),
+		ServiceMetaFromLabels: map[string]string{
+			"app.kubernetes.io/version": "version",
+			"team":                      "team",
+			"missing":                   "missing",
+			// The metadata set by consul-k8s isn't overridden.
+			"app.kubernetes.io/part-of": constants.MetaKeyPodName,
+		},
+		ServiceTagsFromLabels: map[string]string{
+			"app.kubernetes.io/version": "version-",
+			"app.kubernetes.io/part-of": "",
+			"missing":                   "missing-",
+			"empty":                     "empty-",
+		},
+	}
+
+	serviceRegistration, proxyServiceRegistration, err := epCtrl.createServiceRegistrations(*pod, *endpoints, api.HealthPassing)
+	require.NoError(t, err)
+	for _, registration := range []*api.CatalogRegistration{serviceRegistration, proxyServiceRegistration} {
+		meta := registration.Service.Meta
+		require.Equal(t, "1.2.3", meta["version"])
+		// The annotation takes precedence over the label.
+		require.Equal(t, "checkout", meta["team"])
+		require.Equal(t, "pod1", meta[constants.MetaKeyPodName])
+		require.NotContains(t, meta, "missing")
+		require.Equal(t, []string{"abc", "shop", "version-1.2.3"}, registration.Service.Tags)
+	}
+}
+
+func TestCreateServiceRegistrations_fromNodeLabels(t *testing.T) {
+	t.Parallel()
+
+	pod := createServicePod("pod1", "1.2.3.4", true, true)
+	pod.Labels["rack"] = "pod-rack"
+	pod.Annotations[constants.AnnotationServiceLocality] = "zone=rack-7"
+	node := &corev1.Node{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      nodeName,
+			Namespace: "default",
+			Labels: map[string]string{
+				corev1.LabelTopologyRegion:    "us-west-1",
+				corev1.LabelTopologyZone:      "us-west-1a",
+				"topology.kubernetes.io/rack": "rack-7",
+				"example.com/row":             "row-2",
+				"example.com/pod-rack":        "node-rack",
+			},
+		},
+	}
+
+	endpoints := &corev1.Endpoints{ObjectMeta: metav1.ObjectMeta{Name: "web", Namespace: "default"}}
+	ns := corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: pod.Namespace}}
+	epCtrl := Controller{
+		Client: fake.NewClientBuilder().WithRuntimeObjects(pod, node, endpoints, &ns).Build(),
+		Log:    logrtest.New(t),
+		ServiceMetaFromLabels: map[string]string{
+			"rack": "pod-rack",
+		},
+		ServiceMetaFromNodeLabels: map[string]string{
+			"topology.kubernetes.io/rack": "rack",
+			"example.com/row":             "row",
+			"missing":                     "missing",
+			// The metadata from pod labels takes precedence.
+			"example.com/pod-rack": "pod-rack",
+		},
+	}
+
+	serviceRegistration, proxyServiceRegistration, err := epCtrl.createServiceRegistrations(*pod, *endpoints, api.HealthPassing)
+	require.NoError(t, err)
+	for _, registration := range []*api.CatalogRegistration{serviceRegistration, proxyServiceRegistration} {
+		meta := registration.Service.Meta
+		require.Equal(t, "rack-7", meta["rack"])
+		require.Equal(t, "row-2", meta["row"])
+		require.Equal(t, "pod-rack", meta["pod-rack"])
+		require.NotContains(t, meta, "missing")
+		require.Equal(t, &api.Locality{Region: "us-west-1", Zone: "rack-7"}, registration.Service.Locality)
+	}
+}
+
+func TestCreateServiceRegistrations_withServiceWeight(t *testing.T) {
+	t.Parallel()
+
+	cases := map[string]struct {
+		annotation string
+		expWeights api.AgentWeights
+		expErr     string
+		expEvent   string
+	}{
+		"not set": {},
+		"passing and warning": {
+			annotation: "passing=10,warning=2",
+			expWeights: api.AgentWeights{Passing: 10, Warning: 2},
+		},
+		"invalid annotation": {
+			annotation: "passing=0",
+			expErr:     "consul.hashicorp.com/service-weight annotation value has invalid passing weight 0, must be at least 1",
+			expEvent:   "Warning InvalidServiceWeight consul.hashicorp.com/service-weight annotation value has invalid passing weight 0, must be at least 1",
+		},
+	}
+
+	for name, c := range cases {
+		t.Run(name, func(t *testing.T) {
+			pod := createServicePod("pod1", "1.2.3.4", true, true)
+			if c.annotation != "" {
+				pod.Annotations[constants.AnnotationServiceWeight] = c.annotation
+			}
+			endpoints := &corev1.Endpoints{ObjectMeta: metav1.ObjectMeta{Name: "web", Namespace: "default"}}
+			ns := corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: pod.Namespace}}
+			recorder := record.NewFakeRecorder(1)
+			epCtrl := Controller{
+				Client:        fake.NewClientBuilder().WithRuntimeObjects(pod, endpoints, &ns).Build(),
+				Log:           logrtest.New(t),
+				EventRecorder: recorder,
+			}
+
+			serviceRegistration, proxyServiceRegistration, err := epCtrl.createServiceRegistrations(*pod, *endpoints, api.HealthPassing)
+			if c.expErr != "" {
+				require.EqualError(t, err, c.expErr)
+				require.Len(t, recorder.Events, 1)
+				require.Equal(t, c.expEvent, <-recorder.Events)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, c.expWeights, serviceRegistration.Service.Weights)
+			require.Equal(t, c.expWeights, proxyServiceRegistration.Service.Weights)
+		})
+	}
+}
+
+func TestCreateServiceRegistrations_withPodConditionChecks(t *testing.T) {
+	t.Parallel()
+
+	cases := map[string]struct {
+		annotation string
+		expChecks  int
+		expErr     string
+		expEvent   string
+	}{
+		"not set": {},
+		"conditions": {
+			annotation: "PodReadyToStartContainers,example.com/warmed-up=warning",
+			expChecks:  2,
+		},
+		"invalid annotation": {
+			annotation: "Initialized=passing",
+			expErr:     `consul.hashicorp.com/pod-condition-checks annotation value of "Initialized=passing" is invalid: status must be "critical" or "warning"`,
+			expEvent:   `Warning InvalidPodConditionChecks consul.hashicorp.com/pod-condition-checks annotation value of "Initialized=passing" is invalid: status must be "critical" or "warning"`,
+		},
+	}
+
+	for name, c := range cases {
+		t.Run(name, func(t *testing.T) {
+			pod := createServicePod("pod1", "1.2.3.4", true, true)
+			if c.annotation != "" {
+				pod.Annotations[constants.AnnotationPodConditionChecks] = c.annotation
+			}
+			endpoints := &corev1.Endpoints{ObjectMeta: metav1.ObjectMeta{Name: "web", Namespace: "default"}}
+			ns := corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: pod.Namespace}}
+			recorder := record.NewFakeRecorder(1)
+			epCtrl := Controller{
+				Client:        fake.NewClientBuilder().WithRuntimeObjects(pod, endpoints, &ns).Build(),
+				Log:           logrtest.New(t),
+				EventRecorder: recorder,
+			}
+
+			serviceRegistration, proxyServiceRegistration, err := epCtrl.createServiceRegistrations(*pod, *endpoints, api.HealthPassing)
+			if c.expErr != "" {
+				require.EqualError(t, err, c.expErr)
+				require.Len(t, recorder.Events, 1)
+				require.Equal(t, c.expEvent, <-recorder.Events)
+				return
+			}
+			require.NoError(t, err)
+			require.Len(t, serviceRegistration.Checks, c.expChecks)
+			for _, check := range serviceRegistration.Checks {
+				require.Equal(t, serviceRegistration.Service.ID, check.ServiceID)
+			}
+			// The checks only apply to the service instance, the proxy keeps reflecting the readiness of the pod.
+			require.Empty(t, proxyServiceRegistration.Checks)
+		})
+	}
+}
+
+func TestCreateServiceRegistrations_withNamedPorts(t *testing.T) {
+	t.Parallel()
+
+	cases := map[string]struct {
+		annotation string
+		tproxy     bool
+		expMeta    map[string]string
+		expAddrs   map[string]api.ServiceAddress
+		expErr     string
+		expEvent   string
+	}{
+		"not set": {},
+		"named ports": {
+			annotation: "metrics, grpc=9090",
+			expMeta:    map[string]string{"port-metrics": "9102", "port-grpc": "9090"},
+			expAddrs: map[string]api.ServiceAddress{
+				"port-metrics": {Address: "1.2.3.4", Port: 9102},
+				"port-grpc":    {Address: "1.2.3.4", Port: 9090},
+			},
+		},
+		"named ports with transparent proxy": {
+			annotation: "metrics",
+			tproxy:     true,
+			expMeta:    map[string]string{"port-metrics": "9102"},
+			expAddrs: map[string]api.ServiceAddress{
+				"port-metrics":             {Address: "1.2.3.4", Port: 9102},
+				clusterIPTaggedAddressName: {Address: "10.0.0.1", Port: 80},
+			},
+		},
+		"invalid annotation": {
+			annotation: "admin",
+			expErr:     `consul.hashicorp.com/service-named-ports annotation value of "admin" is invalid: "admin" is not a container port name or a port number`,
+			expEvent:   `Warning InvalidServiceNamedPorts consul.hashicorp.com/service-named-ports annotation value of "admin" is invalid: "admin" is not a container port name or a port number`,
+		},
+	}
+
+	for name, c := range cases {
+		t.Run(name, func(t *testing.T) {
+			pod := createServicePod("pod1", "1.2.3.4", true, true)
+			pod.Annotations[constants.AnnotationPort] = "http"
+			pod.Spec.Containers = []corev1.Container{{
+				Name:  "web",
+				Ports: []corev1.ContainerPort{{Name: "http", ContainerPort: 8080}, {Name: "metrics", ContainerPort: 9102}},
+			}}
+			if c.annotation != "" {
+				pod.Annotations[constants.AnnotationServiceNamedPorts] = c.annotation
+			}
+			pod.Annotations[constants.KeyTransparentProxy] = strconv.FormatBool(c.tproxy)
+			endpoints := &corev1.Endpoints{ObjectMeta: metav1.ObjectMeta{Name: "web", Namespace: "default"}}
+			service := &corev1.Service{
+				ObjectMeta: metav1.ObjectMeta{Name: "web", Namespace: "default"},
+				Spec: corev1.ServiceSpec{
+					ClusterIP: "10.0.0.1",
+					Ports:     []corev1.ServicePort{{Port: 80, TargetPort: intstr.FromInt(8080)}},
+				},
+			}
+			ns := corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: pod.Namespace}}
+			recorder := record.NewFakeRecorder(1)
+			epCtrl := Controller{
+				Client:        fake.NewClientBuilder().WithRuntimeObjects(pod, endpoints, service, &ns).Build(),
+				Log:           logrtest.New(t),
+				EventRecorder: recorder,
+			}
+
+			serviceRegistration, proxyServiceRegistration, err := epCtrl.createServiceRegistrations(*pod, *endpoints, api.HealthPassing)
+			if c.expErr != "" {
+				require.EqualError(t, err, c.expErr)
+				require.Len(t, recorder.Events, 1)
+				require.Equal(t, c.expEvent, <-recorder.Events)
+				return
+			}
+			require.NoError(t, err)
+			for k, v := range c.expMeta {
+				require.Equal(t, v, serviceRegistration.Service.Meta[k])
+			}
+			if c.expAddrs != nil {
+				require.Equal(t, c.expAddrs, serviceRegistration.Service.TaggedAddresses)
+			}
+			// The named ports are only registered with the service instance.
+			for k := range proxyServiceRegistration.Service.Meta {
+				require.NotContains(t, k, namedPortPrefix)
+			}
+			for k := range proxyServiceRegistration.Service.TaggedAddresses {
+				require.NotContains(t, k, namedPortPrefix)
+			}
+		})
+	}
+}
+
+func TestCreateServiceRegistrations_sessionAffinity(t *testing.T) {
+	t.Parallel()
+
+	cases := map[string]struct {
+		spec    corev1.ServiceSpec
+		expMeta map[string]string
+	}{
+		"no session affinity": {
+			spec: corev1.ServiceSpec{SessionAffinity: corev1.ServiceAffinityNone},
+		},
+		"client IP": {
+			spec: corev1.ServiceSpec{SessionAffinity: corev1.ServiceAffinityClientIP},
+			expMeta: map[string]string{
+				metaKeyKubeSessionAffinity: "ClientIP",
+			},
+		},
+		"client IP with timeout": {
+			spec: corev1.ServiceSpec{
+				SessionAffinity: corev1.ServiceAffinityClientIP,
+				SessionAffinityConfig: &corev1.SessionAffinityConfig{
+					ClientIP: &corev1.ClientIPConfig{TimeoutSeconds: ptr.To(int32(600))},
+				},
+			},
+			expMeta: map[string]string{
+				metaKeyKubeSessionAffinity:        "ClientIP",
+				metaKeyKubeSessionAffinityTimeout: "600",
+			},
+		},
+	}
+
+	for name, c := range cases {
+		t.Run(name, func(t *testing.T) {
+			pod := createServicePod("pod1", "1.2.3.4", true, true)
+			endpoints := &corev1.Endpoints{ObjectMeta: metav1.ObjectMeta{Name: "web", Namespace: "default"}}
+			svc := &corev1.Service{ObjectMeta: metav1.ObjectMeta{Name: "web", Namespace: "default"}, Spec: c.spec}
+			ns := corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: pod.Namespace}}
+			epCtrl := Controller{
+				Client: fake.NewClientBuilder().WithRuntimeObjects(pod, endpoints, svc, &ns).Build(),
+				Log:    logrtest.New(t),
+			}
+
+			serviceRegistration, proxyServiceRegistration, err := epCtrl.createServiceRegistrations(*pod, *endpoints, api.HealthPassing)
+			require.NoError(t, err)
+			for _, registration := range []*api.CatalogRegistration{serviceRegistration, proxyServiceRegistration} {
+				for _, key := range []string{metaKeyKubeSessionAffinity, metaKeyKubeSessionAffinityTimeout} {
+					expected, ok := c.expMeta[key]
+					if !ok {
+						require.NotContains(t, registration.Service.Meta, key)
+						continue
+					}
+					require.Equal(t, expected, registration.Service.Meta[key])
+				}
+			}
+		})
+	}
+}
+
+func TestReconcile_maintenanceMode(t *testing.T) {
+	t.Parallel()
+
+	// The Endpoints are not even read and the Consul servers, which are not set, are not called.
+	ep := &Controller{
+		Client:                fake.NewClientBuilder().Build(),
+		Log:                   logrtest.New(t),
+		AllowK8sNamespacesSet: mapset.NewSetWith("*"),
+		DenyK8sNamespacesSet:  mapset.NewSetWith(),
+		MaintenanceMode:       enabledMaintenanceMode(t),
+	}
+
+	resp, err := ep.Reconcile(context.Background(), ctrl.Request{
+		NamespacedName: types.NamespacedName{Name: "service-deleted", Namespace: "default"},
+	})
+	require.NoError(t, err)
+	require.Equal(t, maintenanceModeRequeue, resp.RequeueAfter)
+}
+
+func TestReconcile_pendingRegistrations(t *testing.T) {
+	t.Parallel()
+
+	// Open the circuit breaker as if the Consul servers were unreachable.
+	breaker := consul.NewCircuitBreaker(1, time.Minute)
+	req, err := http.NewRequest(http.MethodGet, "http://127.0.0.1:8500/v1/status/leader", nil)
+	require.NoError(t, err)
+	_, err = breaker.RoundTripper(unreachableRoundTripper{}).RoundTrip(req)
+	require.Error(t, err)
+	require.Greater(t, breaker.RetryAfter(), time.Duration(0))
+
+	k8sClient := fake.NewClientBuilder().Build()
+	queue := &common.PendingRegistrations{
+		Reader:    k8sClient,
+		Writer:    k8sClient,
+		Name:      "consul-connect-inject-pending-registrations",
+		Namespace: "consul",
+		Reachable: func() bool { return breaker.RetryAfter() == 0 },
+		Log:       logrtest.New(t),
+	}
+	ep := &Controller{
+		Client:                k8sClient,
+		Log:                   logrtest.New(t),
+		AllowK8sNamespacesSet: mapset.NewSetWith("*"),
+		DenyK8sNamespacesSet:  mapset.NewSetWith(),
+		ConsulClientConfig:    &consul.Config{APIClientConfig: &api.Config{}, CircuitBreaker: breaker},
+		PendingRegistrations:  queue,
+	}
+
+	resp, err := ep.Reconcile(context.Background(), ctrl.Request{
+		NamespacedName: types.NamespacedName{Name: "web", Namespace: "default"},
+	})
+	require.NoError(t, err)
+	require.Greater(t, resp.RequeueAfter, time.Duration(0))
+	require.Equal(t, 1, queue.Len())
+
+	var configMap corev1.ConfigMap
+	require.NoError(t, k8sClient.Get(context.Background(), types.NamespacedName{Name: queue.Name, Namespace: queue.Namespace}, &configMap))
+	require.Contains(t, configMap.Data, "default.web")
+}
+
+type unreachableRoundTripper struct{}
+
+func (unreachableRoundTripper) RoundTrip(*http.Request) (*http.Response, error) {
+	return nil, errors.New("connection refused")
+}
+
+func TestConsulClientConfig_PartitionMapping(t *testing.T) {
+	configMap := &corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{Name: "partition-mapping", Namespace: "consul"},
+		Data:       map[string]string{"team-a": "ap1"},
+	}
+	mapping := &common.PartitionMapping{
+		Client:           fake.NewClientBuilder().WithObjects(configMap).Build(),
+		Name:             configMap.Name,
+		Namespace:        configMap.Namespace,
+		DefaultPartition: "default",
+	}
+	require.NoError(t, mapping.Refresh(context.Background()))
+
+	consulClientConfig := &consul.Config{
+		APIClientConfig: &api.Config{Partition: "default", Token: "token"},
+		HTTPPort:        8500,
+	}
+	ep := &Controller{ConsulClientConfig: consulClientConfig}
+	// Without a mapping, the shared config is used for all namespaces.
+	require.Same(t, consulClientConfig, ep.consulClientConfig("team-a"))
+
+	ep.PartitionMapping = mapping
+	cfg := ep.consulClientConfig("team-a")
+	require.Equal(t, "ap1", cfg.APIClientConfig.Partition)
+	require.Equal(t, "token", cfg.APIClientConfig.Token)
+	require.Equal(t, 8500, cfg.HTTPPort)
+	require.Equal(t, "default", ep.consulClientConfig("team-b").APIClientConfig.Partition)
+	// The shared config is not modified.
+	require.Equal(t, "default", consulClientConfig.APIClientConfig.Partition)
+}
+
 func Test_GetWANData(t *testing.T) {
 	cases := map[string]struct {
 		gatewayPod      corev1.Pod
@@ -7169,3 +8489,109 @@ func TestReconcileAssignServiceVirtualIP(t *testing.T) {
 		})
 	}
 }
+
+func TestExposePath(t *testing.T) {
+	t.Parallel()
+	originalPod := corev1.Pod{
+		Spec: corev1.PodSpec{
+			Containers: []corev1.Container{
+				{
+					Name:  "app",
+					Ports: []corev1.ContainerPort{{Name: "http", ContainerPort: 8080}},
+				},
+			},
+		},
+	}
+	httpProbe := func(port intstr.IntOrString, scheme corev1.URIScheme) *corev1.Probe {
+		return &corev1.Probe{ProbeHandler: corev1.ProbeHandler{HTTPGet: &corev1.HTTPGetAction{Port: port, Path: "/health", Scheme: scheme}}}
+	}
+	grpcProbe := func(port int32) *corev1.Probe {
+		return &corev1.Probe{ProbeHandler: corev1.ProbeHandler{GRPC: &corev1.GRPCAction{Port: port}}}
+	}
+
+	cases := map[string]struct {
+		original *corev1.Probe
+		mutated  *corev1.Probe
+		expPath  api.ExposePath
+		expOK    bool
+	}{
+		"no probe": {},
+		"http probe overwritten": {
+			original: httpProbe(intstr.FromInt(8080), ""),
+			mutated:  httpProbe(intstr.FromInt(20300), ""),
+			expPath:  api.ExposePath{ListenerPort: 20300, LocalPathPort: 8080, Path: "/health"},
+			expOK:    true,
+		},
+		"http probe on a named port overwritten": {
+			original: httpProbe(intstr.FromString("http"), ""),
+			mutated:  httpProbe(intstr.FromInt(20400), ""),
+			expPath:  api.ExposePath{ListenerPort: 20400, LocalPathPort: 8080, Path: "/health"},
+			expOK:    true,
+		},
+		"https probe left as is": {
+			original: httpProbe(intstr.FromInt(8443), corev1.URISchemeHTTPS),
+			mutated:  httpProbe(intstr.FromInt(8443), corev1.URISchemeHTTPS),
+		},
+		"http probe on an unknown named port left as is": {
+			original: httpProbe(intstr.FromString("unknown"), ""),
+			mutated:  httpProbe(intstr.FromString("unknown"), ""),
+		},
+		"grpc probe overwritten": {
+			original: grpcProbe(9090),
+			mutated:  grpcProbe(20500),
+			expPath: api.ExposePath{
+				ListenerPort:  20500,
+				LocalPathPort: 9090,
+				Path:          "/grpc.health.v1.Health/Check",
+				Protocol:      "http2",
+			},
+			expOK: true,
+		},
+		"grpc probe left as is": {
+			original: grpcProbe(9090),
+			mutated:  grpcProbe(9090),
+		},
+		"tcp probe": {
+			original: &corev1.Probe{ProbeHandler: corev1.ProbeHandler{TCPSocket: &corev1.TCPSocketAction{Port: intstr.FromInt(8080)}}},
+			mutated:  &corev1.Probe{ProbeHandler: corev1.ProbeHandler{TCPSocket: &corev1.TCPSocketAction{Port: intstr.FromInt(8080)}}},
+		},
+	}
+	for name, c := range cases {
+		c := c
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+			path, ok, err := exposePath(originalPod, c.original, c.mutated)
+			require.NoError(t, err)
+			require.Equal(t, c.expOK, ok)
+			require.Equal(t, c.expPath, path)
+		})
+	}
+}
+
+// Test that the Consul namespaces that pods override are recorded so that their service instances are deregistered.
+func TestNonDefaultConsulNamespaces_Overrides(t *testing.T) {
+	r := &Controller{
+		EnableConsulNamespaces: true,
+		EnableNSMirroring:      true,
+		NSMirroringPrefix:      "{k8s-namespace}-east",
+	}
+	endpoints := corev1.Endpoints{ObjectMeta: metav1.ObjectMeta{Name: "web", Namespace: "apps"}}
+	overridePod := corev1.Pod{ObjectMeta: metav1.ObjectMeta{
+		Namespace:   "apps",
+		Annotations: map[string]string{constants.AnnotationConsulNamespaceOverride: "shared"},
+	}}
+	pod := corev1.Pod{ObjectMeta: metav1.ObjectMeta{Namespace: "apps"}}
+
+	require.Equal(t, "shared", r.podConsulNamespace(overridePod))
+	require.Equal(t, "apps-east", r.podConsulNamespace(pod))
+	require.Equal(t, []string{"apps-east"}, r.nonDefaultConsulNamespaces("web", "apps"))
+
+	r.recordConsulNamespaceOverride(endpoints, r.podConsulNamespace(pod))
+	r.recordConsulNamespaceOverride(endpoints, r.podConsulNamespace(overridePod))
+	r.recordConsulNamespaceOverride(endpoints, "default")
+	require.Equal(t, []string{"apps-east", "shared"}, r.nonDefaultConsulNamespaces("web", "apps"))
+	require.Equal(t, []string{"apps-east"}, r.nonDefaultConsulNamespaces("api", "apps"))
+
+	r.forgetConsulNamespaceOverrides(types.NamespacedName{Name: "web", Namespace: "apps"})
+	require.Equal(t, []string{"apps-east"}, r.nonDefaultConsulNamespaces("web", "apps"))
+}
@@ -0,0 +1,80 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package endpoints
+
+import (
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	ctrlmetrics "sigs.k8s.io/controller-runtime/pkg/metrics"
+)
+
+// Kinds of registrations of the registration metrics.
+const (
+	registrationKindService = "service"
+	registrationKindGateway = "gateway"
+)
+
+// Operations of the Consul API errors metric.
+const (
+	consulOpRegister        = "register"
+	consulOpDeregister      = "deregister"
+	consulOpCatalogRead     = "catalog_read"
+	consulOpACL             = "acl"
+	consulOpNamespace       = "namespace"
+	consulOpConfigEntry     = "config_entry"
+	consulOpAssignVirtualIP = "assign_virtual_ip"
+)
+
+var (
+	// registrationDuration is how long it takes to register a service instance, including its proxy, with Consul.
+	registrationDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "consul_k8s_registration_duration_seconds",
+		Help:    "Time taken by the endpoints controller to register a service instance and its proxy with Consul.",
+		Buckets: []float64{.005, .01, .025, .05, .1, .25, .5, 1, 2.5, 5, 10},
+	}, []string{"kind"})
+	// registrationFailures is the number of service instances that failed to be registered with Consul.
+	registrationFailures = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "consul_k8s_registration_failures_total",
+		Help: "Number of service instances the endpoints controller failed to register with Consul.",
+	}, []string{"kind"})
+	// deregistrations is the number of service instances deregistered from Consul by reason.
+	deregistrations = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "consul_k8s_deregistrations_total",
+		Help: "Number of service instances the endpoints controller deregistered from Consul.",
+	}, []string{"reason"})
+	// consulAPIErrors is the number of failed requests to the Consul API by operation.
+	consulAPIErrors = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "consul_k8s_consul_api_errors_total",
+		Help: "Number of requests of the endpoints controller to the Consul API that failed.",
+	}, []string{"operation"})
+	// orphanedInstances is the number of orphaned service instances found in the last sweep of the orphan reaper.
+	orphanedInstances = prometheus.NewGauge(prometheus.GaugeOpts{
+		Name: "consul_k8s_orphaned_service_instances",
+		Help: "Number of service instances whose pods no longer exist found by the last sweep of the orphan reaper of the endpoints controller.",
+	})
+)
+
+func init() {
+	ctrlmetrics.Registry.MustRegister(registrationDuration, registrationFailures, deregistrations, consulAPIErrors, orphanedInstances)
+}
+
+// observeRegistration records the duration of a registration of the kind that started at start,
+// or counts it as a failure if err is set.
+func observeRegistration(kind string, start time.Time, err error) {
+	if err != nil {
+		registrationFailures.WithLabelValues(kind).Inc()
+		return
+	}
+	registrationDuration.WithLabelValues(kind).Observe(time.Since(start).Seconds())
+}
+
+// countConsulAPIError counts the error of a request to the Consul API for the operation, if there is one.
+// It returns the error so that it can wrap the error of a request.
+func countConsulAPIError(operation string, err error) error {
+	if err != nil {
+		consulAPIErrors.WithLabelValues(operation).Inc()
+	}
+	return err
+}
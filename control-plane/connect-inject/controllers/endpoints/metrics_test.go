@@ -0,0 +1,57 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package endpoints
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	logrtest "github.com/go-logr/logr/testr"
+	"github.com/hashicorp/consul/api"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	dto "github.com/prometheus/client_model/go"
+	"github.com/stretchr/testify/require"
+)
+
+func TestObserveRegistration(t *testing.T) {
+	failures := testutil.ToFloat64(registrationFailures.WithLabelValues(registrationKindGateway))
+	observations := registrationCount(t, registrationKindGateway)
+
+	// Failed registrations are counted but their duration is not observed.
+	observeRegistration(registrationKindGateway, time.Now(), errors.New("failed"))
+	require.Equal(t, failures+1, testutil.ToFloat64(registrationFailures.WithLabelValues(registrationKindGateway)))
+	require.Equal(t, observations, registrationCount(t, registrationKindGateway))
+
+	observeRegistration(registrationKindGateway, time.Now().Add(-time.Second), nil)
+	require.Equal(t, failures+1, testutil.ToFloat64(registrationFailures.WithLabelValues(registrationKindGateway)))
+	require.Equal(t, observations+1, registrationCount(t, registrationKindGateway))
+}
+
+// registrationCount returns the number of registrations of the kind observed by the registration duration metric.
+func registrationCount(t *testing.T, kind string) uint64 {
+	var m dto.Metric
+	require.NoError(t, registrationDuration.WithLabelValues(kind).(prometheus.Histogram).Write(&m))
+	return m.GetHistogram().GetSampleCount()
+}
+
+func TestCountConsulAPIError(t *testing.T) {
+	errs := testutil.ToFloat64(consulAPIErrors.WithLabelValues(consulOpACL))
+
+	require.NoError(t, countConsulAPIError(consulOpACL, nil))
+	require.Equal(t, errs, testutil.ToFloat64(consulAPIErrors.WithLabelValues(consulOpACL)))
+
+	err := errors.New("Unexpected response code: 500")
+	require.Equal(t, err, countConsulAPIError(consulOpACL, err))
+	require.Equal(t, errs+1, testutil.ToFloat64(consulAPIErrors.WithLabelValues(consulOpACL)))
+}
+
+func TestAuditDeregistration_Metrics(t *testing.T) {
+	deregistered := testutil.ToFloat64(deregistrations.WithLabelValues(string(reasonNodeChanged)))
+
+	ep := &Controller{Log: logrtest.New(t)}
+	ep.auditDeregistration(&api.CatalogService{ServiceID: "pod1-service-created"}, "service-created", "default", reasonNodeChanged)
+	require.Equal(t, deregistered+1, testutil.ToFloat64(deregistrations.WithLabelValues(string(reasonNodeChanged))))
+}
@@ -0,0 +1,88 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package endpoints
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/hashicorp/consul/api"
+	corev1 "k8s.io/api/core/v1"
+
+	"github.com/hashicorp/consul-k8s/control-plane/connect-inject/common"
+	"github.com/hashicorp/consul-k8s/control-plane/connect-inject/constants"
+)
+
+// namedPortPrefix prefixes the tagged address and the meta key of each named port so that they can't
+// collide with the tagged addresses and meta keys that Consul and the endpoints controller set.
+const namedPortPrefix = "port-"
+
+// namedPortName matches the names allowed as part of a service meta key by Consul.
+var namedPortName = regexp.MustCompile(`^[a-zA-Z0-9_-]+$`)
+
+// namedPort is an additional port of a service instance.
+type namedPort struct {
+	name string
+	port int
+}
+
+// parseServiceNamedPorts parses the value of the consul.hashicorp.com/service-named-ports annotation
+// and resolves the ports of the pod it references.
+func parseServiceNamedPorts(pod corev1.Pod) ([]namedPort, error) {
+	var ports []namedPort
+	seen := make(map[string]struct{})
+	for _, item := range strings.Split(pod.Annotations[constants.AnnotationServiceNamedPorts], ",") {
+		item = strings.TrimSpace(item)
+		if item == "" {
+			continue
+		}
+		name, raw, ok := strings.Cut(item, "=")
+		name, raw = strings.TrimSpace(name), strings.TrimSpace(raw)
+		if !ok {
+			// The name is the name of a container port.
+			raw = name
+		}
+
+		if !namedPortName.MatchString(name) {
+			return nil, fmt.Errorf("%s annotation value of %q is invalid: name must only contain alphanumeric characters, '-' and '_'",
+				constants.AnnotationServiceNamedPorts, item)
+		}
+		port, err := common.PortValue(pod, raw)
+		if err != nil || port <= 0 || port > 65535 {
+			return nil, fmt.Errorf("%s annotation value of %q is invalid: %q is not a container port name or a port number",
+				constants.AnnotationServiceNamedPorts, item, raw)
+		}
+		if _, ok := seen[name]; ok {
+			return nil, fmt.Errorf("%s annotation value of %q is invalid: port %s is listed more than once",
+				constants.AnnotationServiceNamedPorts, item, name)
+		}
+		seen[name] = struct{}{}
+		ports = append(ports, namedPort{name: name, port: int(port)})
+	}
+	return ports, nil
+}
+
+// addServiceNamedPorts registers the named ports as tagged addresses and meta of the service. The maps
+// of the service are copied first because they are shared with the proxy service.
+func addServiceNamedPorts(service *api.AgentService, ports []namedPort) {
+	if len(ports) == 0 {
+		return
+	}
+	meta := make(map[string]string, len(service.Meta)+len(ports))
+	for k, v := range service.Meta {
+		meta[k] = v
+	}
+	taggedAddresses := make(map[string]api.ServiceAddress, len(service.TaggedAddresses)+len(ports))
+	for k, v := range service.TaggedAddresses {
+		taggedAddresses[k] = v
+	}
+	for _, p := range ports {
+		meta[namedPortPrefix+p.name] = strconv.Itoa(p.port)
+		taggedAddresses[namedPortPrefix+p.name] = api.ServiceAddress{Address: service.Address, Port: p.port}
+	}
+	service.Meta = meta
+	service.TaggedAddresses = taggedAddresses
+}
@@ -0,0 +1,96 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package endpoints
+
+import (
+	"testing"
+
+	"github.com/hashicorp/consul/api"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/hashicorp/consul-k8s/control-plane/connect-inject/constants"
+)
+
+func TestParseServiceNamedPorts(t *testing.T) {
+	t.Parallel()
+	cases := map[string]struct {
+		raw      string
+		expPorts []namedPort
+		expErr   string
+	}{
+		"empty": {},
+		"container port names and numbers": {
+			raw: " metrics, grpc = 9090,admin=http-admin ,",
+			expPorts: []namedPort{
+				{name: "metrics", port: 9102},
+				{name: "grpc", port: 9090},
+				{name: "admin", port: 8081},
+			},
+		},
+		"unknown container port": {
+			raw:    "debug",
+			expErr: `consul.hashicorp.com/service-named-ports annotation value of "debug" is invalid: "debug" is not a container port name or a port number`,
+		},
+		"invalid port number": {
+			raw:    "grpc=70000",
+			expErr: `"70000" is not a container port name or a port number`,
+		},
+		"invalid name": {
+			raw:    "grpc.web=9090",
+			expErr: `consul.hashicorp.com/service-named-ports annotation value of "grpc.web=9090" is invalid: name must only contain alphanumeric characters, '-' and '_'`,
+		},
+		"missing name": {
+			raw:    "=9090",
+			expErr: "name must only contain alphanumeric characters",
+		},
+		"duplicate name": {
+			raw:    "metrics,metrics=9090",
+			expErr: "port metrics is listed more than once",
+		},
+	}
+	for name, c := range cases {
+		c := c
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+			pod := corev1.Pod{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:        "pod1",
+					Annotations: map[string]string{constants.AnnotationServiceNamedPorts: c.raw},
+				},
+				Spec: corev1.PodSpec{
+					Containers: []corev1.Container{
+						{Name: "web", Ports: []corev1.ContainerPort{{Name: "metrics", ContainerPort: 9102}}},
+						{Name: "sidecar", Ports: []corev1.ContainerPort{{Name: "http-admin", ContainerPort: 8081}}},
+					},
+				},
+			}
+			ports, err := parseServiceNamedPorts(pod)
+			if c.expErr != "" {
+				require.ErrorContains(t, err, c.expErr)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, c.expPorts, ports)
+		})
+	}
+}
+
+func TestAddServiceNamedPorts(t *testing.T) {
+	t.Parallel()
+	meta := map[string]string{"pod-name": "pod1"}
+	taggedAddresses := map[string]api.ServiceAddress{"virtual": {Address: "10.0.0.1", Port: 80}}
+	service := &api.AgentService{Address: "1.2.3.4", Meta: meta, TaggedAddresses: taggedAddresses}
+
+	addServiceNamedPorts(service, []namedPort{{name: "metrics", port: 9102}})
+	require.Equal(t, map[string]string{"pod-name": "pod1", "port-metrics": "9102"}, service.Meta)
+	require.Equal(t, map[string]api.ServiceAddress{
+		"virtual":      {Address: "10.0.0.1", Port: 80},
+		"port-metrics": {Address: "1.2.3.4", Port: 9102},
+	}, service.TaggedAddresses)
+	// The maps that the proxy service shares are unchanged.
+	require.Equal(t, map[string]string{"pod-name": "pod1"}, meta)
+	require.Len(t, taggedAddresses, 1)
+}
@@ -0,0 +1,56 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package endpoints
+
+import (
+	"sync"
+
+	"github.com/hashicorp/consul/api"
+)
+
+// nodeLock is the lock of a Consul node and the number of reconciles holding or waiting for it.
+type nodeLock struct {
+	mu   sync.Mutex
+	refs int
+}
+
+// lockNode locks the Consul node and returns the function that unlocks it. Registrations of service
+// instances on a node are serialized with the deregistration of the node once it has no service
+// instances left, so that a registration by a concurrent reconcile isn't lost when the node is
+// deregistered between the check for its service instances and its deregistration.
+//
+// The lock is only held around these catalog requests. Callers wait for the Consul write rate
+// limiter before taking it, so that reconciles of other services on the node aren't blocked behind it.
+func (r *Controller) lockNode(nodeName string) func() {
+	r.nodeLocksMu.Lock()
+	if r.nodeLocks == nil {
+		r.nodeLocks = make(map[string]*nodeLock)
+	}
+	lock, ok := r.nodeLocks[nodeName]
+	if !ok {
+		lock = &nodeLock{}
+		r.nodeLocks[nodeName] = lock
+	}
+	lock.refs++
+	r.nodeLocksMu.Unlock()
+
+	lock.mu.Lock()
+	return func() {
+		lock.mu.Unlock()
+		r.nodeLocksMu.Lock()
+		defer r.nodeLocksMu.Unlock()
+		lock.refs--
+		if lock.refs == 0 {
+			delete(r.nodeLocks, nodeName)
+		}
+	}
+}
+
+// registerOnNode registers reg in the catalog while holding the lock of its node.
+func (r *Controller) registerOnNode(apiClient *api.Client, reg *api.CatalogRegistration, opts *api.WriteOptions) error {
+	unlockNode := r.lockNode(reg.Node)
+	defer unlockNode()
+	_, err := apiClient.Catalog().Register(reg, opts)
+	return err
+}
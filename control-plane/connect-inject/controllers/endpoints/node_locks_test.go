@@ -0,0 +1,101 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package endpoints
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	logrtest "github.com/go-logr/logr/testr"
+	"github.com/hashicorp/consul/api"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLockNode(t *testing.T) {
+	t.Parallel()
+	r := &Controller{}
+
+	// Different nodes don't block each other.
+	unlockA := r.lockNode("node-a")
+	unlockB := r.lockNode("node-b")
+	unlockB()
+
+	// The same node is locked until it is unlocked.
+	locked := make(chan struct{})
+	go func() {
+		unlock := r.lockNode("node-a")
+		close(locked)
+		unlock()
+	}()
+	require.Never(t, func() bool {
+		select {
+		case <-locked:
+			return true
+		default:
+			return false
+		}
+	}, 100*time.Millisecond, 10*time.Millisecond)
+	unlockA()
+	<-locked
+
+	// The locks of nodes that aren't locked are removed.
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			r.lockNode("node-a")()
+		}()
+	}
+	wg.Wait()
+	require.Empty(t, r.nodeLocks)
+}
+
+func TestDeregisterNode_WaitsForLimiterWithoutLock(t *testing.T) {
+	t.Parallel()
+	var deregistered atomic.Bool
+	consulServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.Method == http.MethodGet && r.URL.Path == "/v1/catalog/node-services/k8s-sync":
+			require.NoError(t, json.NewEncoder(w).Encode(api.CatalogNodeServiceList{}))
+		case r.Method == http.MethodPut && r.URL.Path == "/v1/catalog/deregister":
+			deregistered.Store(true)
+			w.Write([]byte("true"))
+		default:
+			t.Errorf("unexpected request %s %s", r.Method, r.URL.Path)
+		}
+	}))
+	defer consulServer.Close()
+	apiClient, err := api.NewClient(&api.Config{Address: consulServer.URL})
+	require.NoError(t, err)
+
+	// The limiter allows a single write per second and its burst is used up.
+	r := &Controller{Log: logrtest.New(t), ConsulWriteLimiter: NewConsulWriteLimiter(1, 1)}
+	require.NoError(t, r.waitForConsulWrite())
+
+	done := make(chan error)
+	go func() { done <- r.deregisterNode(apiClient, "k8s-sync") }()
+
+	// The node can be locked while the deregistration waits for the limiter.
+	time.Sleep(100 * time.Millisecond)
+	locked := make(chan struct{})
+	go func() {
+		r.lockNode("k8s-sync")()
+		close(locked)
+	}()
+	select {
+	case <-locked:
+	case <-time.After(200 * time.Millisecond):
+		t.Fatal("node is locked while waiting for the rate limiter")
+	}
+	require.False(t, deregistered.Load())
+
+	require.NoError(t, <-done)
+	require.True(t, deregistered.Load())
+}
@@ -0,0 +1,193 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package endpoints
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/hashicorp/consul/api"
+	"github.com/hashicorp/go-multierror"
+	corev1 "k8s.io/api/core/v1"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/types"
+
+	"github.com/hashicorp/consul-k8s/control-plane/connect-inject/common"
+	"github.com/hashicorp/consul-k8s/control-plane/connect-inject/constants"
+	"github.com/hashicorp/consul-k8s/control-plane/consul"
+	"github.com/hashicorp/consul-k8s/control-plane/namespaces"
+)
+
+// reapOrphansPeriodically runs the orphan reaper every OrphanReapInterval until ctx is cancelled.
+func (r *Controller) reapOrphansPeriodically(ctx context.Context) error {
+	ticker := time.NewTicker(r.OrphanReapInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+			if err := r.reapOrphans(ctx); err != nil {
+				r.Log.Error(err, "failed to reap orphaned service instances")
+			}
+		}
+	}
+}
+
+// reapOrphans lists the service instances registered by the endpoints controller on every synthetic node
+// in the Consul catalog and deregisters the instances whose pods no longer exist. These orphans are left
+// behind when a watch event is missed or the controller crashes before it deregisters them, since the
+// Endpoints object that would be reconciled to deregister them may be gone.
+//
+// An instance is only deregistered if its pod was also missing in the previous sweep so that pods that
+// are being created or deleted, and may not be in the cache yet, are left to the reconciles.
+func (r *Controller) reapOrphans(ctx context.Context) error {
+	// Pods that are deleted during maintenance are deregistered by the reconciles once it ends.
+	// The suspects are forgotten so that the first sweep afterwards doesn't deregister anything.
+	if r.MaintenanceMode.Enabled() {
+		r.Log.V(1).Info("maintenance mode is enabled, skipping orphan reaping")
+		r.orphanSuspects = nil
+		return nil
+	}
+
+	serverState, err := r.ConsulServerConnMgr.State()
+	if err != nil {
+		return fmt.Errorf("failed to get Consul server state: %w", err)
+	}
+
+	partitions := []string{r.ConsulClientConfig.APIClientConfig.Partition}
+	if r.PartitionMapping != nil {
+		partitions = r.PartitionMapping.Partitions()
+	}
+
+	var errs error
+	var orphans int
+	suspects := make(map[string]struct{})
+	for _, partition := range partitions {
+		apiClient, err := consul.NewClientFromConnMgrState(r.consulClientConfigForPartition(partition), serverState)
+		if err != nil {
+			errs = multierror.Append(errs, fmt.Errorf("failed to create Consul API client for partition %q: %w", partition, err))
+			continue
+		}
+
+		instances, err := r.managedServiceInstances(apiClient)
+		if err != nil {
+			errs = multierror.Append(errs, fmt.Errorf("failed to list service instances in partition %q: %w", partition, err))
+			continue
+		}
+
+		for _, svc := range instances {
+			podName := svc.ServiceMeta[constants.MetaKeyPodName]
+			k8sNamespace := svc.ServiceMeta[constants.MetaKeyKubeNS]
+			if podName == "" || k8sNamespace == "" {
+				continue
+			}
+			if common.ShouldIgnore(k8sNamespace, r.DenyK8sNamespacesSet, r.AllowK8sNamespacesSet) || !r.ownsNamespace(k8sNamespace) {
+				continue
+			}
+
+			var pod corev1.Pod
+			err := r.Client.Get(ctx, types.NamespacedName{Name: podName, Namespace: k8sNamespace}, &pod)
+			if err == nil {
+				continue
+			}
+			if !k8serrors.IsNotFound(err) {
+				errs = multierror.Append(errs, fmt.Errorf("failed to get pod %s/%s: %w", k8sNamespace, podName, err))
+				continue
+			}
+
+			key := fmt.Sprintf("%s/%s/%s/%s", partition, svc.Namespace, svc.Node, svc.ServiceID)
+			suspects[key] = struct{}{}
+			if _, ok := r.orphanSuspects[key]; !ok {
+				r.Log.Info("found service instance whose pod no longer exists, it is deregistered if it is still orphaned on the next sweep",
+					"svc", svc.ServiceName, "id", svc.ServiceID, "node", svc.Node, "pod", podName, "k8sNamespace", k8sNamespace)
+				continue
+			}
+			orphans++
+
+			if r.OrphanReapDryRun {
+				r.Log.Info("dry run: would deregister orphaned service instance from consul",
+					"svc", svc.ServiceName, "id", svc.ServiceID, "node", svc.Node, "pod", podName, "k8sNamespace", k8sNamespace)
+				continue
+			}
+			if err := r.deregisterOrphan(ctx, apiClient, svc); err != nil {
+				errs = multierror.Append(errs, err)
+			}
+		}
+	}
+
+	r.orphanSuspects = suspects
+	orphanedInstances.Set(float64(orphans))
+	return errs
+}
+
+// managedServiceInstances returns the service instances registered by the endpoints controller on
+// every synthetic node in the partition of the client, across all Consul namespaces.
+func (r *Controller) managedServiceInstances(apiClient *api.Client) ([]*api.CatalogService, error) {
+	nodes, _, err := apiClient.Catalog().Nodes(&api.QueryOptions{NodeMeta: map[string]string{metaKeySyntheticNode: "true"}})
+	if err = countConsulAPIError(consulOpCatalogRead, err); err != nil {
+		return nil, err
+	}
+
+	opts := &api.QueryOptions{Filter: fmt.Sprintf(`Meta[%q] == %q`, metaKeyManagedBy, constants.ManagedByValue)}
+	if r.EnableConsulNamespaces {
+		opts.Namespace = namespaces.WildcardNamespace
+	}
+
+	var instances []*api.CatalogService
+	for _, node := range nodes {
+		serviceList, _, err := apiClient.Catalog().NodeServiceList(node.Node, opts)
+		if err = countConsulAPIError(consulOpCatalogRead, err); err != nil {
+			return nil, err
+		}
+		if serviceList == nil {
+			continue
+		}
+		for _, svc := range serviceList.Services {
+			instances = append(instances, &api.CatalogService{
+				Node:        node.Node,
+				ServiceID:   svc.ID,
+				ServiceName: svc.Service,
+				ServiceMeta: svc.Meta,
+				Namespace:   svc.Namespace,
+				Partition:   svc.Partition,
+			})
+		}
+	}
+	return instances, nil
+}
+
+// deregisterOrphan deregisters an orphaned service instance along with its ACL tokens and,
+// if it has no other services, its synthetic node.
+func (r *Controller) deregisterOrphan(ctx context.Context, apiClient *api.Client, svc *api.CatalogService) error {
+	k8sSvcName := svc.ServiceMeta[metaKeyKubeServiceName]
+	k8sNamespace := svc.ServiceMeta[constants.MetaKeyKubeNS]
+
+	r.Log.Info("deregistering orphaned service instance from consul", "svc", svc.ServiceID, "reason", reasonOrphaned)
+	if err := r.waitForConsulWrite(); err != nil {
+		return err
+	}
+	_, err := apiClient.Catalog().Deregister(&api.CatalogDeregistration{
+		Node:      svc.Node,
+		ServiceID: svc.ServiceID,
+		Namespace: svc.Namespace,
+	}, (&api.WriteOptions{}).WithContext(ctx))
+	if err = countConsulAPIError(consulOpDeregister, err); err != nil {
+		return fmt.Errorf("failed to deregister orphaned service instance %s: %w", svc.ServiceID, err)
+	}
+	r.auditDeregistration(svc, k8sSvcName, k8sNamespace, reasonOrphaned)
+
+	var errs error
+	if r.AuthMethod != "" {
+		err := r.deleteACLTokensForServiceInstance(apiClient, svc, k8sNamespace, svc.ServiceMeta[constants.MetaKeyPodName], svc.ServiceMeta[constants.MetaKeyPodUID])
+		if err != nil {
+			errs = multierror.Append(errs, fmt.Errorf("failed to delete ACL tokens of orphaned service instance %s: %w", svc.ServiceID, err))
+		}
+	}
+	if err := r.deregisterNode(apiClient, svc.Node); err != nil {
+		errs = multierror.Append(errs, fmt.Errorf("failed to deregister node %s: %w", svc.Node, err))
+	}
+	return errs
+}
@@ -0,0 +1,136 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package endpoints
+
+import (
+	"context"
+	"testing"
+
+	mapset "github.com/deckarep/golang-set"
+	logrtest "github.com/go-logr/logr/testr"
+	"github.com/hashicorp/consul/api"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	"github.com/hashicorp/consul-k8s/control-plane/connect-inject/common"
+	"github.com/hashicorp/consul-k8s/control-plane/connect-inject/constants"
+	"github.com/hashicorp/consul-k8s/control-plane/helper/test"
+)
+
+func TestReapOrphans(t *testing.T) {
+	t.Parallel()
+	cases := map[string]struct {
+		dryRun        bool
+		expServiceIDs []string
+	}{
+		"orphans are deregistered": {
+			expServiceIDs: []string{"pod1-service", "pod3-service"},
+		},
+		"orphans are only logged in dry run mode": {
+			dryRun:        true,
+			expServiceIDs: []string{"pod1-service", "pod2-service", "pod3-service"},
+		},
+	}
+
+	for name, c := range cases {
+		t.Run(name, func(t *testing.T) {
+			fakeClient := fake.NewClientBuilder().WithRuntimeObjects(createServicePod("pod1", "1.2.3.4", true, true)).Build()
+
+			testClient := test.TestServerWithMockConnMgrWatcher(t, nil)
+			consulClient := testClient.APIClient
+
+			// pod1 exists, pod2 no longer exists, and pod3 no longer exists but
+			// its instance wasn't registered by the endpoints controller.
+			for pod, managedBy := range map[string]string{
+				"pod1": constants.ManagedByValue,
+				"pod2": constants.ManagedByValue,
+				"pod3": "someone-else",
+			} {
+				_, err := consulClient.Catalog().Register(&api.CatalogRegistration{
+					Node:     consulNodeName,
+					Address:  consulNodeAddress,
+					NodeMeta: map[string]string{metaKeySyntheticNode: "true"},
+					Service: &api.AgentService{
+						ID:      pod + "-service",
+						Service: "service",
+						Meta: map[string]string{
+							constants.MetaKeyPodName: pod,
+							constants.MetaKeyKubeNS:  "default",
+							metaKeyKubeServiceName:   "service",
+							metaKeyManagedBy:         managedBy,
+						},
+					},
+				}, nil)
+				require.NoError(t, err)
+			}
+
+			ep := &Controller{
+				Client:                fakeClient,
+				Log:                   logrtest.New(t),
+				ConsulClientConfig:    testClient.Cfg,
+				ConsulServerConnMgr:   testClient.Watcher,
+				AllowK8sNamespacesSet: mapset.NewSetWith("*"),
+				DenyK8sNamespacesSet:  mapset.NewSetWith(),
+				OrphanReapDryRun:      c.dryRun,
+			}
+
+			// The first sweep only records the orphans.
+			require.NoError(t, ep.reapOrphans(context.Background()))
+			require.ElementsMatch(t, []string{"pod1-service", "pod2-service", "pod3-service"}, serviceIDs(t, consulClient))
+
+			require.NoError(t, ep.reapOrphans(context.Background()))
+			require.ElementsMatch(t, c.expServiceIDs, serviceIDs(t, consulClient))
+		})
+	}
+}
+
+func TestReapOrphans_maintenanceMode(t *testing.T) {
+	t.Parallel()
+
+	// The Consul servers are not reachable, so the sweep must not talk to them.
+	ep := &Controller{
+		Client:                fake.NewClientBuilder().Build(),
+		Log:                   logrtest.New(t),
+		AllowK8sNamespacesSet: mapset.NewSetWith("*"),
+		DenyK8sNamespacesSet:  mapset.NewSetWith(),
+		MaintenanceMode:       enabledMaintenanceMode(t),
+		orphanSuspects:        map[string]struct{}{"default//node/pod2-service": {}},
+	}
+
+	require.NoError(t, ep.reapOrphans(context.Background()))
+	// The suspects of the sweeps before the maintenance are forgotten.
+	require.Empty(t, ep.orphanSuspects)
+}
+
+// enabledMaintenanceMode returns a MaintenanceMode read from a ConfigMap annotated to enable it.
+func enabledMaintenanceMode(t *testing.T) *common.MaintenanceMode {
+	configMap := &corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:        "consul-connect-inject-config",
+			Namespace:   "consul",
+			Annotations: map[string]string{constants.AnnotationMaintenanceMode: "true"},
+		},
+	}
+	mode := &common.MaintenanceMode{
+		Client:    fake.NewClientBuilder().WithObjects(configMap).Build(),
+		Name:      configMap.Name,
+		Namespace: configMap.Namespace,
+		Log:       logrtest.New(t),
+	}
+	require.NoError(t, mode.Refresh(context.Background()))
+	require.True(t, mode.Enabled())
+	return mode
+}
+
+func serviceIDs(t *testing.T, consulClient *api.Client) []string {
+	instances, _, err := consulClient.Catalog().Service("service", "", nil)
+	require.NoError(t, err)
+	var ids []string
+	for _, instance := range instances {
+		ids = append(ids, instance.ServiceID)
+	}
+	return ids
+}
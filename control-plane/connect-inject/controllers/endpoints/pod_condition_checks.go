@@ -0,0 +1,223 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package endpoints
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/hashicorp/consul/api"
+	corev1 "k8s.io/api/core/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/event"
+	"sigs.k8s.io/controller-runtime/pkg/predicate"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	"github.com/hashicorp/consul-k8s/control-plane/connect-inject/constants"
+)
+
+// podConditionCheck maps a pod condition to an additional check of the service instance.
+type podConditionCheck struct {
+	conditionType corev1.PodConditionType
+	// failingStatus is the status of the check while the condition is not True.
+	failingStatus string
+}
+
+// parsePodConditionChecks parses the value of the consul.hashicorp.com/pod-condition-checks annotation.
+func parsePodConditionChecks(raw string) ([]podConditionCheck, error) {
+	var checks []podConditionCheck
+	seen := make(map[corev1.PodConditionType]struct{})
+	for _, item := range strings.Split(raw, ",") {
+		item = strings.TrimSpace(item)
+		if item == "" {
+			continue
+		}
+		conditionType, failingStatus, _ := strings.Cut(item, "=")
+		check := podConditionCheck{
+			conditionType: corev1.PodConditionType(strings.TrimSpace(conditionType)),
+			failingStatus: strings.TrimSpace(failingStatus),
+		}
+		if check.failingStatus == "" {
+			check.failingStatus = api.HealthCritical
+		}
+
+		switch {
+		case check.conditionType == "":
+			return nil, fmt.Errorf("%s annotation value of %q is invalid: missing condition type", constants.AnnotationPodConditionChecks, item)
+		case check.conditionType == corev1.PodReady:
+			// The Ready condition already backs the Kubernetes readiness check of the service instance.
+			return nil, fmt.Errorf("%s annotation value of %q is invalid: the %s condition is always registered as a check",
+				constants.AnnotationPodConditionChecks, item, corev1.PodReady)
+		case check.failingStatus != api.HealthCritical && check.failingStatus != api.HealthWarning:
+			return nil, fmt.Errorf("%s annotation value of %q is invalid: status must be %q or %q",
+				constants.AnnotationPodConditionChecks, item, api.HealthCritical, api.HealthWarning)
+		}
+		if _, ok := seen[check.conditionType]; ok {
+			return nil, fmt.Errorf("%s annotation value of %q is invalid: condition %s is listed more than once",
+				constants.AnnotationPodConditionChecks, item, check.conditionType)
+		}
+		seen[check.conditionType] = struct{}{}
+		checks = append(checks, check)
+	}
+	return checks, nil
+}
+
+// podConditionHealthChecks returns the checks of the service instance that reflect the status of the
+// pod conditions. A condition that the pod doesn't report yet, e.g. a readiness gate no controller has
+// set, is not True, so its check fails.
+func podConditionHealthChecks(pod corev1.Pod, checks []podConditionCheck, serviceID, consulNS string) api.HealthChecks {
+	if len(checks) == 0 {
+		return nil
+	}
+	healthChecks := make(api.HealthChecks, 0, len(checks))
+	for _, check := range checks {
+		status := check.failingStatus
+		output := fmt.Sprintf("Pod \"%s/%s\" does not report the %s condition", pod.Namespace, pod.Name, check.conditionType)
+		for _, cond := range pod.Status.Conditions {
+			if cond.Type != check.conditionType {
+				continue
+			}
+			if cond.Status == corev1.ConditionTrue {
+				status = api.HealthPassing
+				output = fmt.Sprintf("Pod condition %s is True", check.conditionType)
+			} else {
+				output = fmt.Sprintf("Pod \"%s/%s\" condition %s is %s", pod.Namespace, pod.Name, check.conditionType, cond.Status)
+				if cond.Reason != "" {
+					output = fmt.Sprintf("%s: %s", output, cond.Reason)
+				}
+				if cond.Message != "" {
+					output = fmt.Sprintf("%s: %s", output, cond.Message)
+				}
+			}
+			break
+		}
+		healthChecks = append(healthChecks, &api.HealthCheck{
+			CheckID:   podConditionCheckID(pod.Namespace, serviceID, check.conditionType),
+			Name:      fmt.Sprintf("Kubernetes Pod Condition %s", check.conditionType),
+			Type:      constants.ConsulKubernetesPodConditionCheckType,
+			Status:    status,
+			ServiceID: serviceID,
+			Output:    output,
+			Namespace: consulNS,
+		})
+	}
+	return healthChecks
+}
+
+// podConditionCheckID deterministically generates the ID of the check of a pod condition. It is
+// prefixed with the ID of the readiness check so that both are listed together.
+func podConditionCheckID(k8sNS, serviceID string, conditionType corev1.PodConditionType) string {
+	return fmt.Sprintf("%s/%s", consulHealthCheckID(k8sNS, serviceID), conditionType)
+}
+
+// deregisterStalePodConditionChecks deregisters the checks of pod conditions of the service instance
+// that aren't part of its registration, because they were removed from the
+// consul.hashicorp.com/pod-condition-checks annotation or the annotation was removed.
+func (r *Controller) deregisterStalePodConditionChecks(apiClient *api.Client, registration *api.CatalogRegistration) error {
+	registered, _, err := apiClient.Health().Node(registration.Node, &api.QueryOptions{
+		Namespace: registration.Service.Namespace,
+		Partition: registration.Partition,
+		Filter: fmt.Sprintf("ServiceID == %q and Type == %q",
+			registration.Service.ID, constants.ConsulKubernetesPodConditionCheckType),
+	})
+	err = countConsulAPIError(consulOpCatalogRead, err)
+	if err != nil {
+		return err
+	}
+	current := make(map[string]struct{}, len(registration.Checks))
+	for _, check := range registration.Checks {
+		current[check.CheckID] = struct{}{}
+	}
+	for _, check := range registered {
+		if _, ok := current[check.CheckID]; ok {
+			continue
+		}
+		r.Log.Info("deregistering stale pod condition check", "id", check.CheckID)
+		_, err = apiClient.Catalog().Deregister(&api.CatalogDeregistration{
+			Node:      registration.Node,
+			CheckID:   check.CheckID,
+			Namespace: check.Namespace,
+			Partition: registration.Partition,
+		}, nil)
+		if err = countConsulAPIError(consulOpDeregister, err); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// transformPodConditions maps a pod to the Endpoints objects that address it, so that the checks of
+// its pod conditions are updated. Unlike readiness, the other pod conditions don't change Endpoints.
+func (r *Controller) transformPodConditions(ctx context.Context, o client.Object) []reconcile.Request {
+	pod, ok := o.(*corev1.Pod)
+	if !ok {
+		return nil
+	}
+	var endpointsList corev1.EndpointsList
+	if err := r.Client.List(ctx, &endpointsList, client.InNamespace(pod.Namespace)); err != nil {
+		r.Log.Error(err, "failed to list endpoints for pod", "name", pod.Name, "ns", pod.Namespace)
+		return nil
+	}
+	var requests []reconcile.Request
+	for _, endpoints := range endpointsList.Items {
+		if endpointsAddressPod(endpoints, pod.Name) {
+			requests = append(requests, reconcile.Request{NamespacedName: client.ObjectKeyFromObject(&endpoints)})
+		}
+	}
+	return requests
+}
+
+// endpointsAddressPod returns whether any address of the Endpoints object targets the pod.
+func endpointsAddressPod(endpoints corev1.Endpoints, podName string) bool {
+	for _, subset := range endpoints.Subsets {
+		for _, addresses := range [][]corev1.EndpointAddress{subset.Addresses, subset.NotReadyAddresses} {
+			for _, address := range addresses {
+				if address.TargetRef != nil && address.TargetRef.Kind == "Pod" && address.TargetRef.Name == podName {
+					return true
+				}
+			}
+		}
+	}
+	return false
+}
+
+// podConditionsChanged only passes updates of pods that change the status of a condition listed in
+// their consul.hashicorp.com/pod-condition-checks annotation.
+var podConditionsChanged = predicate.Funcs{
+	CreateFunc:  func(event.CreateEvent) bool { return false },
+	DeleteFunc:  func(event.DeleteEvent) bool { return false },
+	GenericFunc: func(event.GenericEvent) bool { return false },
+	UpdateFunc: func(e event.UpdateEvent) bool {
+		oldPod, ok := e.ObjectOld.(*corev1.Pod)
+		if !ok {
+			return false
+		}
+		newPod, ok := e.ObjectNew.(*corev1.Pod)
+		if !ok {
+			return false
+		}
+		checks, err := parsePodConditionChecks(newPod.Annotations[constants.AnnotationPodConditionChecks])
+		if err != nil {
+			return false
+		}
+		for _, check := range checks {
+			if podConditionStatus(*oldPod, check.conditionType) != podConditionStatus(*newPod, check.conditionType) {
+				return true
+			}
+		}
+		return false
+	},
+}
+
+// podConditionStatus returns the status of the condition of the pod, or an empty status if the pod
+// doesn't report the condition.
+func podConditionStatus(pod corev1.Pod, conditionType corev1.PodConditionType) corev1.ConditionStatus {
+	for _, cond := range pod.Status.Conditions {
+		if cond.Type == conditionType {
+			return cond.Status
+		}
+	}
+	return ""
+}
@@ -0,0 +1,246 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package endpoints
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	logrtest "github.com/go-logr/logr/testr"
+	"github.com/hashicorp/consul/api"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/event"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	"github.com/hashicorp/consul-k8s/control-plane/connect-inject/constants"
+)
+
+func TestParsePodConditionChecks(t *testing.T) {
+	t.Parallel()
+	cases := map[string]struct {
+		raw       string
+		expChecks []podConditionCheck
+		expErr    string
+	}{
+		"empty": {},
+		"default status": {
+			raw:       "PodReadyToStartContainers",
+			expChecks: []podConditionCheck{{conditionType: corev1.PodReadyToStartContainers, failingStatus: api.HealthCritical}},
+		},
+		"statuses and readiness gates": {
+			raw: " PodReadyToStartContainers=critical, example.com/warmed-up = warning ,",
+			expChecks: []podConditionCheck{
+				{conditionType: corev1.PodReadyToStartContainers, failingStatus: api.HealthCritical},
+				{conditionType: "example.com/warmed-up", failingStatus: api.HealthWarning},
+			},
+		},
+		"missing condition type": {
+			raw:    "=warning",
+			expErr: `consul.hashicorp.com/pod-condition-checks annotation value of "=warning" is invalid: missing condition type`,
+		},
+		"ready condition": {
+			raw:    "Ready",
+			expErr: "the Ready condition is always registered as a check",
+		},
+		"invalid status": {
+			raw:    "Initialized=passing",
+			expErr: `status must be "critical" or "warning"`,
+		},
+		"duplicate condition": {
+			raw:    "Initialized,Initialized=warning",
+			expErr: "condition Initialized is listed more than once",
+		},
+	}
+	for name, c := range cases {
+		c := c
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+			checks, err := parsePodConditionChecks(c.raw)
+			if c.expErr != "" {
+				require.ErrorContains(t, err, c.expErr)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, c.expChecks, checks)
+		})
+	}
+}
+
+func TestPodConditionHealthChecks(t *testing.T) {
+	t.Parallel()
+	pod := corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{Name: "pod1", Namespace: "default"},
+		Status: corev1.PodStatus{
+			Conditions: []corev1.PodCondition{
+				{Type: corev1.PodReadyToStartContainers, Status: corev1.ConditionTrue},
+				{Type: "example.com/warmed-up", Status: corev1.ConditionFalse, Reason: "CacheCold", Message: "loading"},
+			},
+		},
+	}
+	checks := []podConditionCheck{
+		{conditionType: corev1.PodReadyToStartContainers, failingStatus: api.HealthCritical},
+		{conditionType: "example.com/warmed-up", failingStatus: api.HealthWarning},
+		{conditionType: "example.com/registered", failingStatus: api.HealthCritical},
+	}
+
+	require.Nil(t, podConditionHealthChecks(pod, nil, "pod1-web", "ns"))
+	require.Equal(t, api.HealthChecks{
+		{
+			CheckID:   "default/pod1-web/PodReadyToStartContainers",
+			Name:      "Kubernetes Pod Condition PodReadyToStartContainers",
+			Type:      constants.ConsulKubernetesPodConditionCheckType,
+			Status:    api.HealthPassing,
+			ServiceID: "pod1-web",
+			Output:    "Pod condition PodReadyToStartContainers is True",
+			Namespace: "ns",
+		},
+		{
+			CheckID:   "default/pod1-web/example.com/warmed-up",
+			Name:      "Kubernetes Pod Condition example.com/warmed-up",
+			Type:      constants.ConsulKubernetesPodConditionCheckType,
+			Status:    api.HealthWarning,
+			ServiceID: "pod1-web",
+			Output:    `Pod "default/pod1" condition example.com/warmed-up is False: CacheCold: loading`,
+			Namespace: "ns",
+		},
+		{
+			CheckID:   "default/pod1-web/example.com/registered",
+			Name:      "Kubernetes Pod Condition example.com/registered",
+			Type:      constants.ConsulKubernetesPodConditionCheckType,
+			Status:    api.HealthCritical,
+			ServiceID: "pod1-web",
+			Output:    `Pod "default/pod1" does not report the example.com/registered condition`,
+			Namespace: "ns",
+		},
+	}, podConditionHealthChecks(pod, checks, "pod1-web", "ns"))
+}
+
+func TestPodConditionsChanged(t *testing.T) {
+	t.Parallel()
+	pod := func(annotation string, status corev1.ConditionStatus) *corev1.Pod {
+		p := &corev1.Pod{ObjectMeta: metav1.ObjectMeta{Name: "pod1", Annotations: map[string]string{}}}
+		if annotation != "" {
+			p.Annotations[constants.AnnotationPodConditionChecks] = annotation
+		}
+		if status != "" {
+			p.Status.Conditions = []corev1.PodCondition{{Type: "example.com/warmed-up", Status: status}}
+		}
+		return p
+	}
+	cases := map[string]struct {
+		oldPod, newPod *corev1.Pod
+		exp            bool
+	}{
+		"no annotation": {
+			oldPod: pod("", corev1.ConditionFalse),
+			newPod: pod("", corev1.ConditionTrue),
+		},
+		"condition unchanged": {
+			oldPod: pod("example.com/warmed-up", corev1.ConditionFalse),
+			newPod: pod("example.com/warmed-up", corev1.ConditionFalse),
+		},
+		"condition changed": {
+			oldPod: pod("example.com/warmed-up", corev1.ConditionFalse),
+			newPod: pod("example.com/warmed-up", corev1.ConditionTrue),
+			exp:    true,
+		},
+		"condition reported": {
+			oldPod: pod("example.com/warmed-up", ""),
+			newPod: pod("example.com/warmed-up", corev1.ConditionFalse),
+			exp:    true,
+		},
+		"other condition changed": {
+			oldPod: pod("Initialized", corev1.ConditionFalse),
+			newPod: pod("Initialized", corev1.ConditionTrue),
+		},
+		"invalid annotation": {
+			oldPod: pod("Ready", corev1.ConditionFalse),
+			newPod: pod("Ready", corev1.ConditionTrue),
+		},
+	}
+	for name, c := range cases {
+		c := c
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+			require.Equal(t, c.exp, podConditionsChanged.Update(event.UpdateEvent{ObjectOld: c.oldPod, ObjectNew: c.newPod}))
+		})
+	}
+}
+
+func TestTransformPodConditions(t *testing.T) {
+	t.Parallel()
+	podRef := func(name string) *corev1.ObjectReference {
+		return &corev1.ObjectReference{Kind: "Pod", Name: name, Namespace: "default"}
+	}
+	k8sClient := fake.NewClientBuilder().WithObjects(
+		&corev1.Endpoints{
+			ObjectMeta: metav1.ObjectMeta{Name: "web", Namespace: "default"},
+			Subsets:    []corev1.EndpointSubset{{Addresses: []corev1.EndpointAddress{{IP: "1.1.1.1", TargetRef: podRef("pod1")}}}},
+		},
+		&corev1.Endpoints{
+			ObjectMeta: metav1.ObjectMeta{Name: "web-admin", Namespace: "default"},
+			Subsets:    []corev1.EndpointSubset{{NotReadyAddresses: []corev1.EndpointAddress{{IP: "1.1.1.1", TargetRef: podRef("pod1")}}}},
+		},
+		&corev1.Endpoints{
+			ObjectMeta: metav1.ObjectMeta{Name: "api", Namespace: "default"},
+			Subsets:    []corev1.EndpointSubset{{Addresses: []corev1.EndpointAddress{{IP: "2.2.2.2", TargetRef: podRef("pod2")}}}},
+		},
+		&corev1.Endpoints{
+			ObjectMeta: metav1.ObjectMeta{Name: "web", Namespace: "other"},
+			Subsets:    []corev1.EndpointSubset{{Addresses: []corev1.EndpointAddress{{IP: "3.3.3.3", TargetRef: podRef("pod1")}}}},
+		},
+	).Build()
+	r := &Controller{Client: k8sClient, Log: logrtest.New(t)}
+
+	requests := r.transformPodConditions(context.Background(), &corev1.Pod{ObjectMeta: metav1.ObjectMeta{Name: "pod1", Namespace: "default"}})
+	require.ElementsMatch(t, []reconcile.Request{
+		{NamespacedName: types.NamespacedName{Name: "web", Namespace: "default"}},
+		{NamespacedName: types.NamespacedName{Name: "web-admin", Namespace: "default"}},
+	}, requests)
+}
+
+func TestDeregisterStalePodConditionChecks(t *testing.T) {
+	t.Parallel()
+	registration := &api.CatalogRegistration{
+		Node:    "k8s-sync",
+		Service: &api.AgentService{ID: "pod1-web", Service: "web"},
+		Checks: api.HealthChecks{
+			{CheckID: "default/pod1-web/PodScheduled", Type: constants.ConsulKubernetesPodConditionCheckType},
+		},
+	}
+	var deregistered []string
+	consulServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.Method == http.MethodGet && r.URL.Path == "/v1/health/node/k8s-sync":
+			require.Equal(t, `ServiceID == "pod1-web" and Type == "kubernetes-pod-condition"`, r.URL.Query().Get("filter"))
+			require.NoError(t, json.NewEncoder(w).Encode(api.HealthChecks{
+				{CheckID: "default/pod1-web/PodScheduled"},
+				{CheckID: "default/pod1-web/Initialized"},
+			}))
+		case r.Method == http.MethodPut && r.URL.Path == "/v1/catalog/deregister":
+			var dereg api.CatalogDeregistration
+			require.NoError(t, json.NewDecoder(r.Body).Decode(&dereg))
+			require.Equal(t, "k8s-sync", dereg.Node)
+			deregistered = append(deregistered, dereg.CheckID)
+			w.Write([]byte("true"))
+		default:
+			t.Errorf("unexpected request %s %s", r.Method, r.URL.Path)
+		}
+	}))
+	defer consulServer.Close()
+	apiClient, err := api.NewClient(&api.Config{Address: consulServer.URL})
+	require.NoError(t, err)
+
+	r := &Controller{Log: logrtest.New(t)}
+	require.NoError(t, r.deregisterStalePodConditionChecks(apiClient, registration))
+	// The check of the condition that is no longer listed in the annotation is deregistered.
+	require.Equal(t, []string{"default/pod1-web/Initialized"}, deregistered)
+}
@@ -0,0 +1,76 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package endpoints
+
+import (
+	"context"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"golang.org/x/time/rate"
+	"k8s.io/utils/ptr"
+	"sigs.k8s.io/controller-runtime/pkg/controller"
+	ctrlmetrics "sigs.k8s.io/controller-runtime/pkg/metrics"
+)
+
+var (
+	// consulWritesWaiting is the number of Consul catalog and ACL writes waiting for the write rate limiter.
+	consulWritesWaiting = prometheus.NewGauge(prometheus.GaugeOpts{
+		Name: "consul_endpoints_controller_consul_writes_waiting",
+		Help: "Number of Consul writes of the endpoints controller waiting for the write rate limiter.",
+	})
+	// consulWriteWaitSeconds is how long Consul writes wait for the write rate limiter.
+	consulWriteWaitSeconds = prometheus.NewHistogram(prometheus.HistogramOpts{
+		Name:    "consul_endpoints_controller_consul_write_wait_seconds",
+		Help:    "Time Consul writes of the endpoints controller waited for the write rate limiter.",
+		Buckets: []float64{.001, .005, .01, .05, .1, .5, 1, 5, 10, 30},
+	})
+)
+
+func init() {
+	// The queue depth of the controller is exported by controller-runtime as workqueue_depth{name="endpoints"}
+	// on the same registry.
+	ctrlmetrics.Registry.MustRegister(consulWritesWaiting, consulWriteWaitSeconds)
+}
+
+// NewConsulWriteLimiter returns a token bucket rate limiter that allows writesPerSecond writes to Consul
+// with bursts of up to burst writes. It returns nil, i.e. no rate limiting, if writesPerSecond is zero.
+func NewConsulWriteLimiter(writesPerSecond float64, burst int) *rate.Limiter {
+	if writesPerSecond <= 0 {
+		return nil
+	}
+	return rate.NewLimiter(rate.Limit(writesPerSecond), burst)
+}
+
+// waitForConsulWrite blocks until the write rate limiter allows a write to Consul.
+func (r *Controller) waitForConsulWrite() error {
+	if r.ConsulWriteLimiter == nil {
+		return nil
+	}
+	ctx := r.Context
+	if ctx == nil {
+		ctx = context.Background()
+	}
+
+	consulWritesWaiting.Inc()
+	defer consulWritesWaiting.Dec()
+	start := time.Now()
+	err := r.ConsulWriteLimiter.Wait(ctx)
+	consulWriteWaitSeconds.Observe(time.Since(start).Seconds())
+	return err
+}
+
+// controllerOptions returns the options of the controller. Endpoints of the same Service are never
+// reconciled concurrently, so each of the MaxConcurrentReconciles workers reconciles a different Service,
+// and failed reconciles are retried with the default per-Service exponential backoff. When sharded,
+// the controller runs on every replica rather than only on the leader.
+func (r *Controller) controllerOptions() controller.Options {
+	opts := controller.Options{
+		MaxConcurrentReconciles: r.MaxConcurrentReconciles,
+	}
+	if r.Shard != nil {
+		opts.NeedLeaderElection = ptr.To(false)
+	}
+	return opts
+}
@@ -0,0 +1,44 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package endpoints
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewConsulWriteLimiter(t *testing.T) {
+	require.Nil(t, NewConsulWriteLimiter(0, 10))
+
+	limiter := NewConsulWriteLimiter(2.5, 5)
+	require.NotNil(t, limiter)
+	require.Equal(t, 2.5, float64(limiter.Limit()))
+	require.Equal(t, 5, limiter.Burst())
+}
+
+func TestWaitForConsulWrite(t *testing.T) {
+	// Writes are not limited without a limiter.
+	r := &Controller{}
+	for i := 0; i < 100; i++ {
+		require.NoError(t, r.waitForConsulWrite())
+	}
+
+	// Writes above the burst wait for the limiter.
+	r = &Controller{ConsulWriteLimiter: NewConsulWriteLimiter(20, 2)}
+	start := time.Now()
+	for i := 0; i < 4; i++ {
+		require.NoError(t, r.waitForConsulWrite())
+	}
+	require.GreaterOrEqual(t, time.Since(start), 90*time.Millisecond)
+
+	// Waiting stops when the controller's context is canceled.
+	ctx, cancel := context.WithCancel(context.Background())
+	r = &Controller{ConsulWriteLimiter: NewConsulWriteLimiter(0.001, 1), Context: ctx}
+	require.NoError(t, r.waitForConsulWrite())
+	cancel()
+	require.ErrorIs(t, r.waitForConsulWrite(), context.Canceled)
+}
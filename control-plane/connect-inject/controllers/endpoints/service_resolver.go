@@ -0,0 +1,95 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package endpoints
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/hashicorp/consul/api"
+
+	"github.com/hashicorp/consul-k8s/control-plane/connect-inject/constants"
+)
+
+const (
+	// metaValueServiceResolverManagedBy is the managed-by meta value of the service-resolvers
+	// created by the endpoints controller.
+	metaValueServiceResolverManagedBy = "consul-k8s-endpoints-controller"
+
+	// prioritizeByLocalityFailover is the service-resolver PrioritizeByLocality mode that
+	// prefers the instances in the same locality and fails over to the other localities.
+	prioritizeByLocalityFailover = "failover"
+)
+
+// updateServiceResolver applies mutate to the service-resolver of service. If the service doesn't have
+// a service-resolver yet, one is created if mutate changes it. Existing service-resolvers are only
+// modified if they were created by the endpoints controller so that service-resolvers managed with
+// ServiceResolver custom resources are left alone. mutate returns whether it changed the entry.
+func (r *Controller) updateServiceResolver(apiClient *api.Client, service *api.AgentService, mutate func(entry *api.ServiceResolverConfigEntry) bool) error {
+	var entry *api.ServiceResolverConfigEntry
+	var index uint64
+	existing, _, err := apiClient.ConfigEntries().Get(api.ServiceResolver, service.Service, &api.QueryOptions{Namespace: service.Namespace})
+	if err != nil {
+		if !strings.Contains(err.Error(), "404") {
+			return countConsulAPIError(consulOpConfigEntry, err)
+		}
+		entry = &api.ServiceResolverConfigEntry{
+			Kind:      api.ServiceResolver,
+			Name:      service.Service,
+			Namespace: service.Namespace,
+			Meta: map[string]string{
+				metaKeyManagedBy: metaValueServiceResolverManagedBy,
+			},
+		}
+	} else {
+		resolver, ok := existing.(*api.ServiceResolverConfigEntry)
+		if !ok || resolver.Meta[metaKeyManagedBy] != metaValueServiceResolverManagedBy {
+			return nil
+		}
+		entry = resolver
+		index = resolver.ModifyIndex
+	}
+
+	if !mutate(entry) {
+		return nil
+	}
+	// Use check-and-set so that a service-resolver written concurrently is not overwritten.
+	if _, _, err := apiClient.ConfigEntries().CAS(entry, index, &api.WriteOptions{Namespace: service.Namespace}); err != nil {
+		return countConsulAPIError(consulOpConfigEntry, err)
+	}
+	return nil
+}
+
+// updateLocalityServiceResolver sets the PrioritizeByLocality mode of the service-resolver of service to
+// failover if prioritizeByLocality is "true" so that traffic to the service prefers the instances in the
+// same locality as the caller, e.g. the same zone, and fails over to the other localities. The mode is
+// removed again if it is "false".
+func (r *Controller) updateLocalityServiceResolver(apiClient *api.Client, service *api.AgentService, prioritizeByLocality string) error {
+	enabled, err := strconv.ParseBool(prioritizeByLocality)
+	if err != nil {
+		return fmt.Errorf("invalid %s annotation %q: %w", constants.AnnotationPrioritizeByLocality, prioritizeByLocality, err)
+	}
+	mode := ""
+	if enabled {
+		mode = prioritizeByLocalityFailover
+	}
+
+	return r.updateServiceResolver(apiClient, service, func(entry *api.ServiceResolverConfigEntry) bool {
+		current := ""
+		if entry.PrioritizeByLocality != nil {
+			current = entry.PrioritizeByLocality.Mode
+		}
+		if current == mode {
+			return false
+		}
+		if mode == "" {
+			entry.PrioritizeByLocality = nil
+		} else {
+			entry.PrioritizeByLocality = &api.ServiceResolverPrioritizeByLocality{Mode: mode}
+		}
+		r.Log.Info("updating service-resolver locality prioritization", "name", service.Service, "ns", service.Namespace, "mode", mode)
+		return true
+	})
+}
@@ -0,0 +1,115 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package endpoints
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	logrtest "github.com/go-logr/logr/testr"
+	"github.com/hashicorp/consul/api"
+	"github.com/stretchr/testify/require"
+)
+
+func TestUpdateLocalityServiceResolver(t *testing.T) {
+	t.Parallel()
+	service := &api.AgentService{Service: "web"}
+
+	cases := map[string]struct {
+		annotation string
+		// existing is the JSON of the existing service-resolver, if any.
+		existing string
+		expCAS   string
+		// expWritten is the service-resolver that is expected to be written, if any.
+		expWritten *api.ServiceResolverConfigEntry
+		expErr     string
+	}{
+		"creates a service-resolver": {
+			annotation: "true",
+			expCAS:     "0",
+			expWritten: &api.ServiceResolverConfigEntry{
+				Kind:                 api.ServiceResolver,
+				Name:                 "web",
+				PrioritizeByLocality: &api.ServiceResolverPrioritizeByLocality{Mode: "failover"},
+				Meta:                 map[string]string{metaKeyManagedBy: metaValueServiceResolverManagedBy},
+			},
+		},
+		"doesn't create a service-resolver if disabled": {
+			annotation: "false",
+		},
+		"updates a service-resolver created by the endpoints controller": {
+			annotation: "true",
+			existing:   `{"Kind": "service-resolver", "Name": "web", "DefaultSubset": "stable", "Meta": {"managed-by": "consul-k8s-endpoints-controller"}, "ModifyIndex": 10}`,
+			expCAS:     "10",
+			expWritten: &api.ServiceResolverConfigEntry{
+				Kind:                 api.ServiceResolver,
+				Name:                 "web",
+				DefaultSubset:        "stable",
+				PrioritizeByLocality: &api.ServiceResolverPrioritizeByLocality{Mode: "failover"},
+				Meta:                 map[string]string{metaKeyManagedBy: metaValueServiceResolverManagedBy},
+				ModifyIndex:          10,
+			},
+		},
+		"removes the mode if disabled": {
+			annotation: "false",
+			existing:   `{"Kind": "service-resolver", "Name": "web", "PrioritizeByLocality": {"Mode": "failover"}, "Meta": {"managed-by": "consul-k8s-endpoints-controller"}, "ModifyIndex": 10}`,
+			expCAS:     "10",
+			expWritten: &api.ServiceResolverConfigEntry{
+				Kind:        api.ServiceResolver,
+				Name:        "web",
+				Meta:        map[string]string{metaKeyManagedBy: metaValueServiceResolverManagedBy},
+				ModifyIndex: 10,
+			},
+		},
+		"doesn't update a service-resolver that is up to date": {
+			annotation: "true",
+			existing:   `{"Kind": "service-resolver", "Name": "web", "PrioritizeByLocality": {"Mode": "failover"}, "Meta": {"managed-by": "consul-k8s-endpoints-controller"}, "ModifyIndex": 10}`,
+		},
+		"keeps a service-resolver created by someone else": {
+			annotation: "true",
+			existing:   `{"Kind": "service-resolver", "Name": "web", "Meta": {"consul.hashicorp.com/source-datacenter": "dc1"}, "ModifyIndex": 10}`,
+		},
+		"invalid annotation": {
+			annotation: "yes",
+			expErr:     `invalid consul.hashicorp.com/service-prioritize-by-locality annotation "yes"`,
+		},
+	}
+
+	for name, c := range cases {
+		t.Run(name, func(t *testing.T) {
+			var written *api.ServiceResolverConfigEntry
+			consulServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				switch {
+				case r.Method == http.MethodGet && r.URL.Path == "/v1/config/service-resolver/web":
+					if c.existing == "" {
+						w.WriteHeader(http.StatusNotFound)
+						return
+					}
+					w.Write([]byte(c.existing))
+				case r.Method == http.MethodPut && r.URL.Path == "/v1/config":
+					require.Equal(t, c.expCAS, r.URL.Query().Get("cas"))
+					written = &api.ServiceResolverConfigEntry{}
+					require.NoError(t, json.NewDecoder(r.Body).Decode(written))
+					w.Write([]byte("true"))
+				default:
+					t.Errorf("unexpected request %s %s", r.Method, r.URL.Path)
+				}
+			}))
+			defer consulServer.Close()
+			apiClient, err := api.NewClient(&api.Config{Address: consulServer.URL})
+			require.NoError(t, err)
+
+			ep := &Controller{Log: logrtest.New(t)}
+			err = ep.updateLocalityServiceResolver(apiClient, service, c.annotation)
+			if c.expErr != "" {
+				require.ErrorContains(t, err, c.expErr)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, c.expWritten, written)
+		})
+	}
+}
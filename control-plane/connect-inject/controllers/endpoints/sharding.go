@@ -0,0 +1,48 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package endpoints
+
+import (
+	"context"
+
+	corev1 "k8s.io/api/core/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/manager"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	"github.com/hashicorp/consul-k8s/control-plane/connect-inject/common"
+)
+
+// ownsNamespace returns whether the endpoints of the namespace are reconciled by this replica.
+// Every namespace is owned when the controller isn't sharded.
+func (r *Controller) ownsNamespace(namespace string) bool {
+	return r.Shard == nil || r.Shard.Owns(namespace)
+}
+
+// endpointsInShard maps a change of the shard of this replica to a request for every Endpoints
+// object in the namespaces it owns.
+func (r *Controller) endpointsInShard(ctx context.Context, _ client.Object) []reconcile.Request {
+	var endpointsList corev1.EndpointsList
+	if err := r.Client.List(ctx, &endpointsList); err != nil {
+		r.Log.Error(err, "failed to list endpoints of shard")
+		return nil
+	}
+	var requests []reconcile.Request
+	for _, endpoints := range endpointsList.Items {
+		if common.ShouldIgnore(endpoints.Namespace, r.DenyK8sNamespacesSet, r.AllowK8sNamespacesSet) || !r.ownsNamespace(endpoints.Namespace) {
+			continue
+		}
+		requests = append(requests, reconcile.Request{NamespacedName: client.ObjectKeyFromObject(&endpoints)})
+	}
+	return requests
+}
+
+// replicaRunnable runs a runnable on every replica instead of only on the leader.
+type replicaRunnable struct {
+	manager.Runnable
+}
+
+func (replicaRunnable) NeedLeaderElection() bool {
+	return false
+}
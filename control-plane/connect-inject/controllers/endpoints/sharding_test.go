@@ -0,0 +1,84 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package endpoints
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	mapset "github.com/deckarep/golang-set"
+	logrtest "github.com/go-logr/logr/testr"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	"github.com/hashicorp/consul-k8s/control-plane/connect-inject/sharding"
+)
+
+func TestEndpointsInShard(t *testing.T) {
+	configMap := &corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{Name: "shards", Namespace: "consul"},
+		Data:       map[string]string{"shards": "1", "shard-0": "team-a,team-b,denied"},
+	}
+	objs := []*corev1.Endpoints{
+		{ObjectMeta: metav1.ObjectMeta{Name: "web", Namespace: "team-a"}},
+		{ObjectMeta: metav1.ObjectMeta{Name: "api", Namespace: "team-b"}},
+		{ObjectMeta: metav1.ObjectMeta{Name: "db", Namespace: "denied"}},
+	}
+	builder := fake.NewClientBuilder().WithObjects(configMap)
+	for _, obj := range objs {
+		builder = builder.WithObjects(obj)
+	}
+	k8sClient := builder.Build()
+
+	shard := &sharding.Shard{
+		Reader:        k8sClient,
+		Writer:        k8sClient,
+		ConfigMapName: "shards",
+		Namespace:     "consul",
+		Identity:      "replica",
+		RetryPeriod:   10 * time.Millisecond,
+		Log:           logrtest.New(t),
+	}
+	r := &Controller{
+		Client:                k8sClient,
+		AllowK8sNamespacesSet: mapset.NewSet("*"),
+		DenyK8sNamespacesSet:  mapset.NewSet("denied"),
+		Shard:                 shard,
+		Log:                   logrtest.New(t),
+	}
+
+	// Nothing is owned until the shard is claimed.
+	require.Empty(t, r.endpointsInShard(context.Background(), configMap))
+	result, err := r.Reconcile(context.Background(), ctrl.Request{NamespacedName: types.NamespacedName{Name: "web", Namespace: "team-a"}})
+	require.NoError(t, err)
+	require.Equal(t, ctrl.Result{}, result)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go shard.Start(ctx)
+	require.Eventually(t, func() bool { return shard.Index() == 0 }, 5*time.Second, 10*time.Millisecond)
+
+	require.ElementsMatch(t, []reconcile.Request{
+		{NamespacedName: types.NamespacedName{Name: "web", Namespace: "team-a"}},
+		{NamespacedName: types.NamespacedName{Name: "api", Namespace: "team-b"}},
+	}, r.endpointsInShard(context.Background(), configMap))
+}
+
+func TestControllerOptions_Sharded(t *testing.T) {
+	r := &Controller{MaxConcurrentReconciles: 4}
+	require.Nil(t, r.controllerOptions().NeedLeaderElection)
+
+	r.Shard = &sharding.Shard{}
+	opts := r.controllerOptions()
+	require.Equal(t, 4, opts.MaxConcurrentReconciles)
+	require.NotNil(t, opts.NeedLeaderElection)
+	require.False(t, *opts.NeedLeaderElection)
+	require.False(t, replicaRunnable{}.NeedLeaderElection())
+}
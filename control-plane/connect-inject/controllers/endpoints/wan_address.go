@@ -0,0 +1,163 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package endpoints
+
+import (
+	"context"
+	"net"
+	"slices"
+	"sort"
+	"strconv"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/event"
+	"sigs.k8s.io/controller-runtime/pkg/predicate"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	"github.com/hashicorp/consul-k8s/control-plane/connect-inject/constants"
+)
+
+// defaultWANAddressHealthCheckPeriod is how often the WAN address of a gateway is health checked
+// again when GatewayWANAddressHealthCheckTimeout is set but GatewayWANAddressResolvePeriod isn't.
+const defaultWANAddressHealthCheckPeriod = time.Minute
+
+// hostResolver resolves hostnames to IP addresses. It is implemented by net.Resolver.
+type hostResolver interface {
+	LookupHost(ctx context.Context, host string) ([]string, error)
+}
+
+// dialFunc opens a connection to an address. It is implemented by net.Dialer.
+type dialFunc func(ctx context.Context, network, address string) (net.Conn, error)
+
+// loadBalancerWANAddress returns the WAN address of a gateway exposed by a LoadBalancer Service.
+//
+// By default it is the IP or hostname of the first ingress of the Service. If GatewayWANAddressResolvePeriod
+// is set, hostnames are resolved to their IP addresses so that the registration follows the load balancer
+// when the addresses behind its hostname change. If GatewayWANAddressHealthCheckTimeout is set, the address
+// is the first candidate that accepts TCP connections on the WAN port, failing over to the next ingress
+// address when the load balancer is re-provisioned. If no candidate is healthy the first one is used.
+func (r *Controller) loadBalancerWANAddress(ingresses []corev1.LoadBalancerIngress, port int) string {
+	var candidates []string
+	for _, ingress := range ingresses {
+		switch {
+		case ingress.IP != "":
+			candidates = append(candidates, ingress.IP)
+		case ingress.Hostname != "":
+			candidates = append(candidates, r.resolveHostname(ingress.Hostname)...)
+		}
+	}
+	if len(candidates) == 0 {
+		return ""
+	}
+	if r.GatewayWANAddressHealthCheckTimeout <= 0 {
+		return candidates[0]
+	}
+
+	for _, addr := range candidates {
+		if r.wanAddressHealthy(addr, port) {
+			return addr
+		}
+	}
+	r.Log.Info("no load balancer address passed its health check, using the first address", "addresses", candidates, "port", port)
+	return candidates[0]
+}
+
+// resolveHostname returns the sorted IP addresses of a hostname if GatewayWANAddressResolvePeriod is set,
+// or the hostname itself if it isn't or if the hostname can't be resolved.
+func (r *Controller) resolveHostname(hostname string) []string {
+	if r.GatewayWANAddressResolvePeriod <= 0 {
+		return []string{hostname}
+	}
+	ctx, cancel := context.WithTimeout(r.context(), r.GatewayWANAddressResolvePeriod)
+	defer cancel()
+	addrs, err := r.hostResolver().LookupHost(ctx, hostname)
+	if err != nil || len(addrs) == 0 {
+		r.Log.Info("unable to resolve load balancer hostname, using the hostname as the WAN address", "hostname", hostname, "err", err)
+		return []string{hostname}
+	}
+	addrs = slices.Clone(addrs)
+	sort.Strings(addrs)
+	return addrs
+}
+
+// wanAddressHealthy returns true if the address accepts TCP connections on the port
+// within GatewayWANAddressHealthCheckTimeout.
+func (r *Controller) wanAddressHealthy(addr string, port int) bool {
+	ctx, cancel := context.WithTimeout(r.context(), r.GatewayWANAddressHealthCheckTimeout)
+	defer cancel()
+	conn, err := r.dialer()(ctx, "tcp", net.JoinHostPort(addr, strconv.Itoa(port)))
+	if err != nil {
+		r.Log.Info("load balancer address failed its health check", "address", addr, "port", port, "err", err)
+		return false
+	}
+	_ = conn.Close()
+	return true
+}
+
+// wanAddressResyncPeriod returns how long to wait before reconciling the endpoints of a gateway again so that
+// its WAN address is resolved again, or 0 if the WAN address of the gateway doesn't need to be resolved again.
+func (r *Controller) wanAddressResyncPeriod(pod corev1.Pod) time.Duration {
+	if pod.Annotations[constants.AnnotationGatewayWANSource] != "Service" {
+		return 0
+	}
+	if r.GatewayWANAddressResolvePeriod > 0 {
+		return r.GatewayWANAddressResolvePeriod
+	}
+	if r.GatewayWANAddressHealthCheckTimeout > 0 {
+		return defaultWANAddressHealthCheckPeriod
+	}
+	return 0
+}
+
+// transformLoadBalancerService requeues the endpoints of a LoadBalancer Service so that gateways
+// whose WAN address is read from the Service are registered with its new ingress addresses.
+func (r *Controller) transformLoadBalancerService(_ context.Context, o client.Object) []reconcile.Request {
+	svc, ok := o.(*corev1.Service)
+	if !ok || svc.Spec.Type != corev1.ServiceTypeLoadBalancer {
+		return nil
+	}
+	return []reconcile.Request{{NamespacedName: client.ObjectKeyFromObject(svc)}}
+}
+
+// loadBalancerIngressChanged only passes updates of Services that change their load balancer ingresses.
+var loadBalancerIngressChanged = predicate.Funcs{
+	CreateFunc:  func(event.CreateEvent) bool { return false },
+	DeleteFunc:  func(event.DeleteEvent) bool { return false },
+	GenericFunc: func(event.GenericEvent) bool { return false },
+	UpdateFunc: func(e event.UpdateEvent) bool {
+		oldSvc, ok := e.ObjectOld.(*corev1.Service)
+		if !ok {
+			return false
+		}
+		newSvc, ok := e.ObjectNew.(*corev1.Service)
+		if !ok || newSvc.Spec.Type != corev1.ServiceTypeLoadBalancer {
+			return false
+		}
+		return !slices.EqualFunc(oldSvc.Status.LoadBalancer.Ingress, newSvc.Status.LoadBalancer.Ingress,
+			func(a, b corev1.LoadBalancerIngress) bool { return a.IP == b.IP && a.Hostname == b.Hostname })
+	},
+}
+
+func (r *Controller) context() context.Context {
+	if r.Context != nil {
+		return r.Context
+	}
+	return context.Background()
+}
+
+func (r *Controller) hostResolver() hostResolver {
+	if r.resolver != nil {
+		return r.resolver
+	}
+	return net.DefaultResolver
+}
+
+func (r *Controller) dialer() dialFunc {
+	if r.dial != nil {
+		return r.dial
+	}
+	return (&net.Dialer{}).DialContext
+}
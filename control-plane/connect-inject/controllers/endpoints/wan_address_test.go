@@ -0,0 +1,168 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package endpoints
+
+import (
+	"context"
+	"errors"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/go-logr/logr"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/event"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	"github.com/hashicorp/consul-k8s/control-plane/connect-inject/constants"
+)
+
+type fakeResolver map[string][]string
+
+func (f fakeResolver) LookupHost(_ context.Context, host string) ([]string, error) {
+	addrs, ok := f[host]
+	if !ok {
+		return nil, errors.New("no such host")
+	}
+	return addrs, nil
+}
+
+func TestLoadBalancerWANAddress(t *testing.T) {
+	resolver := fakeResolver{"lb.example.com": {"10.0.0.2", "10.0.0.1"}}
+
+	cases := map[string]struct {
+		ingresses          []corev1.LoadBalancerIngress
+		resolvePeriod      time.Duration
+		healthCheckTimeout time.Duration
+		healthy            []string
+		expAddr            string
+	}{
+		"no ingress": {},
+		"IP": {
+			ingresses: []corev1.LoadBalancerIngress{{IP: "1.2.3.4"}, {IP: "5.6.7.8"}},
+			expAddr:   "1.2.3.4",
+		},
+		"hostname": {
+			ingresses: []corev1.LoadBalancerIngress{{Hostname: "lb.example.com"}},
+			expAddr:   "lb.example.com",
+		},
+		"resolved hostname": {
+			ingresses:     []corev1.LoadBalancerIngress{{Hostname: "lb.example.com"}},
+			resolvePeriod: time.Minute,
+			expAddr:       "10.0.0.1",
+		},
+		"unresolvable hostname": {
+			ingresses:     []corev1.LoadBalancerIngress{{Hostname: "old-lb.example.com"}},
+			resolvePeriod: time.Minute,
+			expAddr:       "old-lb.example.com",
+		},
+		"first address healthy": {
+			ingresses:          []corev1.LoadBalancerIngress{{IP: "1.2.3.4"}, {IP: "5.6.7.8"}},
+			healthCheckTimeout: time.Second,
+			healthy:            []string{"1.2.3.4:443", "5.6.7.8:443"},
+			expAddr:            "1.2.3.4",
+		},
+		"fails over to healthy address": {
+			ingresses:          []corev1.LoadBalancerIngress{{IP: "1.2.3.4"}, {IP: "5.6.7.8"}},
+			healthCheckTimeout: time.Second,
+			healthy:            []string{"5.6.7.8:443"},
+			expAddr:            "5.6.7.8",
+		},
+		"fails over to healthy resolved address": {
+			ingresses:          []corev1.LoadBalancerIngress{{Hostname: "old-lb.example.com"}, {Hostname: "lb.example.com"}},
+			resolvePeriod:      time.Minute,
+			healthCheckTimeout: time.Second,
+			healthy:            []string{"10.0.0.2:443"},
+			expAddr:            "10.0.0.2",
+		},
+		"no healthy address": {
+			ingresses:          []corev1.LoadBalancerIngress{{IP: "1.2.3.4"}, {IP: "5.6.7.8"}},
+			healthCheckTimeout: time.Second,
+			expAddr:            "1.2.3.4",
+		},
+	}
+
+	for name, c := range cases {
+		t.Run(name, func(t *testing.T) {
+			r := &Controller{
+				Log:                                 logr.Discard(),
+				GatewayWANAddressResolvePeriod:      c.resolvePeriod,
+				GatewayWANAddressHealthCheckTimeout: c.healthCheckTimeout,
+				resolver:                            resolver,
+				dial: func(_ context.Context, _, address string) (net.Conn, error) {
+					for _, h := range c.healthy {
+						if h == address {
+							client, server := net.Pipe()
+							_ = server.Close()
+							return client, nil
+						}
+					}
+					return nil, errors.New("connection refused")
+				},
+			}
+			require.Equal(t, c.expAddr, r.loadBalancerWANAddress(c.ingresses, 443))
+		})
+	}
+}
+
+func TestWANAddressResyncPeriod(t *testing.T) {
+	pod := func(source string) corev1.Pod {
+		return corev1.Pod{ObjectMeta: metav1.ObjectMeta{Annotations: map[string]string{constants.AnnotationGatewayWANSource: source}}}
+	}
+
+	r := &Controller{}
+	require.Zero(t, r.wanAddressResyncPeriod(pod("Service")))
+
+	r.GatewayWANAddressHealthCheckTimeout = time.Second
+	require.Equal(t, defaultWANAddressHealthCheckPeriod, r.wanAddressResyncPeriod(pod("Service")))
+	require.Zero(t, r.wanAddressResyncPeriod(pod("NodeIP")))
+
+	r.GatewayWANAddressResolvePeriod = 30 * time.Second
+	require.Equal(t, 30*time.Second, r.wanAddressResyncPeriod(pod("Service")))
+}
+
+func TestLoadBalancerIngressChanged(t *testing.T) {
+	svc := func(svcType corev1.ServiceType, ingresses ...corev1.LoadBalancerIngress) *corev1.Service {
+		s := &corev1.Service{ObjectMeta: metav1.ObjectMeta{Name: "mesh-gateway", Namespace: "consul"}}
+		s.Spec.Type = svcType
+		s.Status.LoadBalancer.Ingress = ingresses
+		return s
+	}
+	old := svc(corev1.ServiceTypeLoadBalancer, corev1.LoadBalancerIngress{Hostname: "old-lb.example.com"})
+
+	cases := map[string]struct {
+		newSvc *corev1.Service
+		exp    bool
+	}{
+		"hostname changed": {
+			newSvc: svc(corev1.ServiceTypeLoadBalancer, corev1.LoadBalancerIngress{Hostname: "new-lb.example.com"}),
+			exp:    true,
+		},
+		"ingress added": {
+			newSvc: svc(corev1.ServiceTypeLoadBalancer, corev1.LoadBalancerIngress{Hostname: "old-lb.example.com"}, corev1.LoadBalancerIngress{IP: "1.2.3.4"}),
+			exp:    true,
+		},
+		"unchanged": {
+			newSvc: svc(corev1.ServiceTypeLoadBalancer, corev1.LoadBalancerIngress{Hostname: "old-lb.example.com"}),
+		},
+		"not a load balancer": {
+			newSvc: svc(corev1.ServiceTypeClusterIP),
+		},
+	}
+
+	for name, c := range cases {
+		t.Run(name, func(t *testing.T) {
+			require.Equal(t, c.exp, loadBalancerIngressChanged.Update(event.UpdateEvent{ObjectOld: old, ObjectNew: c.newSvc}))
+		})
+	}
+	require.False(t, loadBalancerIngressChanged.Create(event.CreateEvent{Object: old}))
+
+	r := &Controller{}
+	require.Equal(t, []reconcile.Request{{NamespacedName: types.NamespacedName{Name: "mesh-gateway", Namespace: "consul"}}},
+		r.transformLoadBalancerService(context.Background(), old))
+	require.Empty(t, r.transformLoadBalancerService(context.Background(), svc(corev1.ServiceTypeNodePort)))
+}
@@ -47,6 +47,10 @@ type AcceptorController struct {
 	// Scheme is the API scheme that this controller should have.
 	Scheme *runtime.Scheme
 	context.Context
+
+	// tokenStoreFn returns the store the peering token is published to. It defaults to newTokenStore
+	// and is overridden in tests.
+	tokenStoreFn tokenStoreFunc
 }
 
 const (
@@ -70,17 +74,18 @@ const (
 //   - If the resource exists, and a peering does exist in Consul, it should be reconciled.
 //   - If the status of the resource does not match the current state of the specified secret, generate a new token
 //     and store it according to the spec.
+//   - If the resource has a token exchange, publish the token in the secret to the token exchange store.
 //
 // NOTE: It is possible that Reconcile is called multiple times concurrently because we're watching
 // two different resource kinds. As a result, we need to make sure that the code in this method
 // is thread-safe. For example, we may need to fetch the resource again before writing because another
 // call to Reconcile could have modified it, and so we need to make sure that we're updating the latest version.
-func (r *AcceptorController) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
+func (r *AcceptorController) Reconcile(ctx context.Context, req ctrl.Request) (result ctrl.Result, err error) {
 	r.Log.Info("received request for PeeringAcceptor", "name", req.Name, "ns", req.Namespace)
 
 	// Get the PeeringAcceptor resource.
 	acceptor := &consulv1alpha1.PeeringAcceptor{}
-	err := r.Client.Get(ctx, req.NamespacedName, acceptor)
+	err = r.Client.Get(ctx, req.NamespacedName, acceptor)
 
 	// This can be safely ignored as a resource will only ever be not found if it has never been reconciled
 	// since we add finalizers to our resources.
@@ -92,6 +97,14 @@ func (r *AcceptorController) Reconcile(ctx context.Context, req ctrl.Request) (c
 		return ctrl.Result{}, err
 	}
 
+	// Once the token in the secret is up-to-date, publish it to the token exchange store, if any, so that
+	// the dialing cluster can read it.
+	defer func() {
+		if err == nil && acceptor.TokenExchange() != nil && acceptor.GetDeletionTimestamp().IsZero() {
+			result, err = r.publishToken(ctx, acceptor)
+		}
+	}()
+
 	// Create Consul client for this reconcile.
 	serverState, err := r.ConsulServerConnMgr.State()
 	if err != nil {
@@ -124,6 +137,9 @@ func (r *AcceptorController) Reconcile(ctx context.Context, req ctrl.Request) (c
 			if err != nil {
 				return ctrl.Result{}, err
 			}
+			if acceptor.TokenExchange() != nil {
+				r.unpublishToken(ctx, acceptor)
+			}
 			controllerutil.RemoveFinalizer(acceptor, finalizerName)
 			err = r.Update(ctx, acceptor)
 			return ctrl.Result{}, err
@@ -214,6 +230,61 @@ func (r *AcceptorController) Reconcile(ctx context.Context, req ctrl.Request) (c
 	return ctrl.Result{}, nil
 }
 
+// publishToken writes the token in the secret of the acceptor to its token exchange store if the store
+// has a different token, and requeues the acceptor to keep the store in sync.
+func (r *AcceptorController) publishToken(ctx context.Context, acceptor *consulv1alpha1.PeeringAcceptor) (ctrl.Result, error) {
+	exchange := acceptor.TokenExchange()
+	secret, err := r.getExistingSecret(ctx, acceptor.Secret().Name, acceptor.Namespace)
+	if err != nil {
+		r.updateStatusError(ctx, acceptor, kubernetesError, err)
+		return ctrl.Result{}, err
+	}
+	if secret == nil || len(secret.Data[acceptor.Secret().Key]) == 0 {
+		// The secret is re-created when the acceptor is reconciled because of the deletion.
+		return ctrl.Result{RequeueAfter: syncInterval(exchange)}, nil
+	}
+	token := string(secret.Data[acceptor.Secret().Key])
+
+	store, err := r.tokenStore(ctx, acceptor.Namespace, exchange)
+	if err != nil {
+		r.updateStatusError(ctx, acceptor, tokenExchangeError, err)
+		return ctrl.Result{}, err
+	}
+	published, err := store.Read(ctx)
+	if err != nil {
+		r.updateStatusError(ctx, acceptor, tokenExchangeError, err)
+		return ctrl.Result{}, err
+	}
+	if published != token {
+		r.Log.Info("publishing peering token to the token exchange store", "name", acceptor.Name, "ns", acceptor.Namespace)
+		if err := store.Write(ctx, token); err != nil {
+			r.updateStatusError(ctx, acceptor, tokenExchangeError, err)
+			return ctrl.Result{}, err
+		}
+	}
+	return ctrl.Result{RequeueAfter: syncInterval(exchange)}, nil
+}
+
+// unpublishToken deletes the token of the acceptor from its token exchange store. Errors are logged
+// rather than returned so that they don't block the deletion of the acceptor.
+func (r *AcceptorController) unpublishToken(ctx context.Context, acceptor *consulv1alpha1.PeeringAcceptor) {
+	store, err := r.tokenStore(ctx, acceptor.Namespace, acceptor.TokenExchange())
+	if err == nil {
+		err = store.Delete(ctx)
+	}
+	if err != nil {
+		r.Log.Error(err, "failed to delete peering token from the token exchange store", "name", acceptor.Name, "ns", acceptor.Namespace)
+	}
+}
+
+// tokenStore returns the token exchange store of the acceptor.
+func (r *AcceptorController) tokenStore(ctx context.Context, namespace string, exchange *consulv1alpha1.TokenExchange) (tokenStore, error) {
+	if r.tokenStoreFn != nil {
+		return r.tokenStoreFn(ctx, r.Client, namespace, exchange)
+	}
+	return newTokenStore(ctx, r.Client, namespace, exchange)
+}
+
 // shouldGenerateToken returns whether a token should be generated, and whether the name of the secret has changed. It
 // compares the spec secret's name/key/backend and resource version with the name/key/backend and resource version of the status secret's.
 func shouldGenerateToken(acceptor *consulv1alpha1.PeeringAcceptor, existingSecret *corev1.Secret) (shouldGenerate bool, nameChanged bool, err error) {
@@ -43,6 +43,10 @@ type PeeringDialerController struct {
 	// Scheme is the API scheme that this controller should have.
 	Scheme *runtime.Scheme
 	context.Context
+
+	// tokenStoreFn returns the store the peering token is read from. It defaults to newTokenStore
+	// and is overridden in tests.
+	tokenStoreFn tokenStoreFunc
 }
 
 //+kubebuilder:rbac:groups=consul.hashicorp.com,resources=peeringdialers,verbs=get;list;watch;create;update;patch;delete
@@ -50,12 +54,14 @@ type PeeringDialerController struct {
 
 // Reconcile is part of the main kubernetes reconciliation loop which aims to
 // move the current state of the cluster closer to the desired state.
-func (r *PeeringDialerController) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
+// If the PeeringDialer has a token exchange, the token is first copied from the token exchange store
+// into spec.peer.secret, so that the peering is re-established whenever the acceptor publishes a new token.
+func (r *PeeringDialerController) Reconcile(ctx context.Context, req ctrl.Request) (result ctrl.Result, err error) {
 	r.Log.Info("received request for PeeringDialer:", "name", req.Name, "ns", req.Namespace)
 
 	// Get the PeeringDialer resource.
 	dialer := &consulv1alpha1.PeeringDialer{}
-	err := r.Client.Get(ctx, req.NamespacedName, dialer)
+	err = r.Client.Get(ctx, req.NamespacedName, dialer)
 
 	// This can be safely ignored as a resource will only ever be not found if it has never been reconciled
 	// since we add finalizers to our resources.
@@ -101,6 +107,25 @@ func (r *PeeringDialerController) Reconcile(ctx context.Context, req ctrl.Reques
 		}
 	}
 
+	if exchange := dialer.TokenExchange(); exchange != nil {
+		published, err := r.syncTokenFromStore(ctx, dialer)
+		if err != nil {
+			r.updateStatusError(ctx, dialer, tokenExchangeError, err)
+			return ctrl.Result{}, err
+		}
+		if !published {
+			// Wait for the acceptor to publish its token.
+			r.updateStatusError(ctx, dialer, tokenExchangeError, errors.New("peering token has not been published to the token exchange store"))
+			return ctrl.Result{RequeueAfter: syncInterval(exchange)}, nil
+		}
+		// Poll the store so that a rotated token is picked up.
+		defer func() {
+			if err == nil {
+				result = ctrl.Result{RequeueAfter: syncInterval(exchange)}
+			}
+		}()
+	}
+
 	// specSecret will be nil if the secret specified by the spec doesn't exist.
 	var specSecret *corev1.Secret
 	specSecret, err = r.getSecret(ctx, dialer.Secret().Name, dialer.Namespace)
@@ -204,6 +229,47 @@ func (r *PeeringDialerController) Reconcile(ctx context.Context, req ctrl.Reques
 	return ctrl.Result{}, nil
 }
 
+// syncTokenFromStore copies the token in the token exchange store of the dialer into its spec secret
+// if the tokens are different. It returns false if the store has no token and the spec secret doesn't exist.
+func (r *PeeringDialerController) syncTokenFromStore(ctx context.Context, dialer *consulv1alpha1.PeeringDialer) (bool, error) {
+	var store tokenStore
+	var err error
+	if r.tokenStoreFn != nil {
+		store, err = r.tokenStoreFn(ctx, r.Client, dialer.Namespace, dialer.TokenExchange())
+	} else {
+		store, err = newTokenStore(ctx, r.Client, dialer.Namespace, dialer.TokenExchange())
+	}
+	if err != nil {
+		return false, err
+	}
+	token, err := store.Read(ctx)
+	if err != nil {
+		return false, err
+	}
+	specSecret, err := r.getSecret(ctx, dialer.Secret().Name, dialer.Namespace)
+	if err != nil {
+		return false, err
+	}
+	if token == "" {
+		// Keep using the existing token, e.g. if the acceptor was deleted.
+		return specSecret != nil, nil
+	}
+
+	if specSecret == nil {
+		r.Log.Info("creating spec.peer.secret with the token from the token exchange store", "secret-name", dialer.Secret().Name, "secret-namespace", dialer.Namespace)
+		return true, r.Client.Create(ctx, createSecret(dialer.Secret().Name, dialer.Namespace, dialer.Secret().Key, token))
+	}
+	if string(specSecret.Data[dialer.Secret().Key]) != token {
+		r.Log.Info("updating spec.peer.secret with the token from the token exchange store", "secret-name", dialer.Secret().Name, "secret-namespace", dialer.Namespace)
+		if specSecret.Data == nil {
+			specSecret.Data = make(map[string][]byte)
+		}
+		specSecret.Data[dialer.Secret().Key] = []byte(token)
+		return true, r.Client.Update(ctx, specSecret)
+	}
+	return true, nil
+}
+
 func (r *PeeringDialerController) specStatusSecretsDifferent(dialer *consulv1alpha1.PeeringDialer, existingSpecSecret *corev1.Secret) bool {
 	if dialer.SecretRef().Name != dialer.Secret().Name {
 		return true
@@ -0,0 +1,266 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package peering
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"time"
+
+	vaultapi "github.com/hashicorp/vault/api"
+	corev1 "k8s.io/api/core/v1"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/clientcmd"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	consulv1alpha1 "github.com/hashicorp/consul-k8s/control-plane/api/v1alpha1"
+)
+
+const (
+	tokenExchangeError = "tokenExchangeError"
+
+	defaultTokenExchangeSyncInterval = time.Minute
+	defaultVaultMount                = "secret"
+	defaultVaultAuthMethodPath       = "kubernetes"
+)
+
+// serviceAccountTokenPath is the path of the token the controller logs in to Vault with.
+var serviceAccountTokenPath = "/var/run/secrets/kubernetes.io/serviceaccount/token"
+
+// tokenStore is a store shared with the peer cluster that a peering token is exchanged through.
+type tokenStore interface {
+	// Read returns the token in the store, or an empty string if there is no token.
+	Read(ctx context.Context) (string, error)
+	// Write writes the token to the store.
+	Write(ctx context.Context, token string) error
+	// Delete deletes the token from the store.
+	Delete(ctx context.Context) error
+}
+
+// tokenStoreFunc returns the token store of a token exchange of a resource in namespace.
+type tokenStoreFunc func(ctx context.Context, c client.Client, namespace string, exchange *consulv1alpha1.TokenExchange) (tokenStore, error)
+
+// syncInterval returns how often the token of exchange is synced with the store.
+func syncInterval(exchange *consulv1alpha1.TokenExchange) time.Duration {
+	if exchange.SyncInterval.Duration > 0 {
+		return exchange.SyncInterval.Duration
+	}
+	return defaultTokenExchangeSyncInterval
+}
+
+// newTokenStore returns the token store of the token exchange. Secrets referenced by the token
+// exchange are read from namespace with c.
+func newTokenStore(ctx context.Context, c client.Client, namespace string, exchange *consulv1alpha1.TokenExchange) (tokenStore, error) {
+	switch {
+	case exchange.Kubernetes != nil:
+		return newKubernetesTokenStore(ctx, c, namespace, exchange.Kubernetes)
+	case exchange.Vault != nil:
+		return newVaultTokenStore(ctx, c, namespace, exchange.Vault)
+	default:
+		return nil, errors.New("token exchange has no store")
+	}
+}
+
+// readSecretKey returns the value of a key of a Secret in namespace.
+func readSecretKey(ctx context.Context, c client.Client, namespace string, selector consulv1alpha1.SecretKeySelector) ([]byte, error) {
+	secret := &corev1.Secret{}
+	if err := c.Get(ctx, types.NamespacedName{Name: selector.Name, Namespace: namespace}, secret); err != nil {
+		return nil, fmt.Errorf("error reading secret %s/%s: %w", namespace, selector.Name, err)
+	}
+	value, ok := secret.Data[selector.Key]
+	if !ok || len(value) == 0 {
+		return nil, fmt.Errorf("secret %s/%s has no key %q", namespace, selector.Name, selector.Key)
+	}
+	return value, nil
+}
+
+// kubernetesTokenStore stores the token in a Secret of another Kubernetes cluster.
+type kubernetesTokenStore struct {
+	client client.Client
+	name   types.NamespacedName
+	key    string
+}
+
+func newKubernetesTokenStore(ctx context.Context, c client.Client, namespace string, exchange *consulv1alpha1.KubernetesTokenExchange) (*kubernetesTokenStore, error) {
+	kubeconfig, err := readSecretKey(ctx, c, namespace, exchange.KubeconfigSecret)
+	if err != nil {
+		return nil, err
+	}
+	restConfig, err := clientcmd.RESTConfigFromKubeConfig(kubeconfig)
+	if err != nil {
+		return nil, fmt.Errorf("error parsing kubeconfig: %w", err)
+	}
+	remoteClient, err := client.New(restConfig, client.Options{})
+	if err != nil {
+		return nil, fmt.Errorf("error creating Kubernetes client: %w", err)
+	}
+	return &kubernetesTokenStore{
+		client: remoteClient,
+		name:   types.NamespacedName{Name: exchange.Name, Namespace: exchange.Namespace},
+		key:    exchange.Key,
+	}, nil
+}
+
+func (s *kubernetesTokenStore) Read(ctx context.Context) (string, error) {
+	secret := &corev1.Secret{}
+	err := s.client.Get(ctx, s.name, secret)
+	if k8serrors.IsNotFound(err) {
+		return "", nil
+	} else if err != nil {
+		return "", err
+	}
+	return string(secret.Data[s.key]), nil
+}
+
+func (s *kubernetesTokenStore) Write(ctx context.Context, token string) error {
+	secret := &corev1.Secret{}
+	err := s.client.Get(ctx, s.name, secret)
+	if k8serrors.IsNotFound(err) {
+		return s.client.Create(ctx, createSecret(s.name.Name, s.name.Namespace, s.key, token))
+	} else if err != nil {
+		return err
+	}
+	// Keep the other keys of the secret so that it can be shared by several peerings.
+	if secret.Data == nil {
+		secret.Data = make(map[string][]byte)
+	}
+	secret.Data[s.key] = []byte(token)
+	return s.client.Update(ctx, secret)
+}
+
+func (s *kubernetesTokenStore) Delete(ctx context.Context) error {
+	secret := &corev1.Secret{}
+	err := s.client.Get(ctx, s.name, secret)
+	if k8serrors.IsNotFound(err) {
+		return nil
+	} else if err != nil {
+		return err
+	}
+	if _, ok := secret.Data[s.key]; !ok {
+		return nil
+	}
+	delete(secret.Data, s.key)
+	if len(secret.Data) > 0 {
+		return s.client.Update(ctx, secret)
+	}
+	return client.IgnoreNotFound(s.client.Delete(ctx, secret))
+}
+
+// vaultTokenStore stores the token in a Vault KV version 2 secret.
+type vaultTokenStore struct {
+	kv   *vaultapi.KVv2
+	path string
+	key  string
+}
+
+func newVaultTokenStore(ctx context.Context, c client.Client, namespace string, exchange *consulv1alpha1.VaultTokenExchange) (*vaultTokenStore, error) {
+	config := vaultapi.DefaultConfig()
+	config.Address = exchange.Address
+	if exchange.CACertSecret != nil {
+		caCert, err := readSecretKey(ctx, c, namespace, *exchange.CACertSecret)
+		if err != nil {
+			return nil, err
+		}
+		if err := config.ConfigureTLS(&vaultapi.TLSConfig{CACertBytes: caCert}); err != nil {
+			return nil, fmt.Errorf("error configuring Vault TLS: %w", err)
+		}
+	}
+	vaultClient, err := vaultapi.NewClient(config)
+	if err != nil {
+		return nil, fmt.Errorf("error creating Vault client: %w", err)
+	}
+	if exchange.Namespace != "" {
+		vaultClient.SetNamespace(exchange.Namespace)
+	}
+
+	jwt, err := os.ReadFile(serviceAccountTokenPath)
+	if err != nil {
+		return nil, fmt.Errorf("error reading service account token: %w", err)
+	}
+	authMethodPath := exchange.AuthMethodPath
+	if authMethodPath == "" {
+		authMethodPath = defaultVaultAuthMethodPath
+	}
+	login, err := vaultClient.Logical().WriteWithContext(ctx, fmt.Sprintf("auth/%s/login", authMethodPath), map[string]interface{}{
+		"role": exchange.Role,
+		"jwt":  string(jwt),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("error logging in to Vault: %w", err)
+	}
+	if login == nil || login.Auth == nil {
+		return nil, errors.New("error logging in to Vault: no auth info returned")
+	}
+	vaultClient.SetToken(login.Auth.ClientToken)
+
+	mount := exchange.Mount
+	if mount == "" {
+		mount = defaultVaultMount
+	}
+	return &vaultTokenStore{
+		kv:   vaultClient.KVv2(mount),
+		path: exchange.Path,
+		key:  exchange.Key,
+	}, nil
+}
+
+// data returns the data of the secret, or nil if the secret doesn't exist.
+func (s *vaultTokenStore) data(ctx context.Context) (map[string]interface{}, error) {
+	secret, err := s.kv.Get(ctx, s.path)
+	if errors.Is(err, vaultapi.ErrSecretNotFound) {
+		return nil, nil
+	} else if err != nil {
+		return nil, err
+	}
+	return secret.Data, nil
+}
+
+func (s *vaultTokenStore) Read(ctx context.Context) (string, error) {
+	data, err := s.data(ctx)
+	if err != nil {
+		return "", err
+	}
+	raw, ok := data[s.key]
+	if !ok {
+		return "", nil
+	}
+	token, ok := raw.(string)
+	if !ok {
+		return "", fmt.Errorf("key %q of Vault secret %s is not a string", s.key, s.path)
+	}
+	return token, nil
+}
+
+func (s *vaultTokenStore) Write(ctx context.Context, token string) error {
+	data, err := s.data(ctx)
+	if err != nil {
+		return err
+	}
+	// Keep the other keys of the secret so that it can be shared by several peerings.
+	if data == nil {
+		data = make(map[string]interface{})
+	}
+	data[s.key] = token
+	_, err = s.kv.Put(ctx, s.path, data)
+	return err
+}
+
+func (s *vaultTokenStore) Delete(ctx context.Context) error {
+	data, err := s.data(ctx)
+	if err != nil {
+		return err
+	}
+	if _, ok := data[s.key]; !ok {
+		return nil
+	}
+	delete(data, s.key)
+	if len(data) > 0 {
+		_, err = s.kv.Put(ctx, s.path, data)
+		return err
+	}
+	return s.kv.DeleteMetadata(ctx, s.path)
+}
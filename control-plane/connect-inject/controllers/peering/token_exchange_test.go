@@ -0,0 +1,334 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package peering
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	logrtest "github.com/go-logr/logr/testr"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	"github.com/hashicorp/consul-k8s/control-plane/api/v1alpha1"
+	"github.com/hashicorp/consul-k8s/control-plane/connect-inject/constants"
+)
+
+func TestKubernetesTokenStore(t *testing.T) {
+	ctx := context.Background()
+	s := runtime.NewScheme()
+	require.NoError(t, corev1.AddToScheme(s))
+	remoteClient := fake.NewClientBuilder().WithScheme(s).Build()
+	name := types.NamespacedName{Name: "peering-tokens", Namespace: "management"}
+	store := &kubernetesTokenStore{client: remoteClient, name: name, key: "cluster-02"}
+	other := &kubernetesTokenStore{client: remoteClient, name: name, key: "cluster-03"}
+
+	// Read returns an empty token when the secret doesn't exist.
+	token, err := store.Read(ctx)
+	require.NoError(t, err)
+	require.Empty(t, token)
+
+	// Write creates the secret.
+	require.NoError(t, store.Write(ctx, "token-1"))
+	secret := &corev1.Secret{}
+	require.NoError(t, remoteClient.Get(ctx, name, secret))
+	require.Equal(t, "true", secret.Labels[constants.LabelPeeringToken])
+	token, err = store.Read(ctx)
+	require.NoError(t, err)
+	require.Equal(t, "token-1", token)
+
+	// Writing another key keeps the first key.
+	require.NoError(t, other.Write(ctx, "token-2"))
+	require.NoError(t, store.Write(ctx, "token-3"))
+	token, err = store.Read(ctx)
+	require.NoError(t, err)
+	require.Equal(t, "token-3", token)
+	token, err = other.Read(ctx)
+	require.NoError(t, err)
+	require.Equal(t, "token-2", token)
+
+	// Delete removes the key, and the secret once it has no keys left.
+	require.NoError(t, store.Delete(ctx))
+	token, err = store.Read(ctx)
+	require.NoError(t, err)
+	require.Empty(t, token)
+	require.NoError(t, remoteClient.Get(ctx, name, secret))
+	require.NoError(t, other.Delete(ctx))
+	err = remoteClient.Get(ctx, name, secret)
+	require.True(t, k8serrors.IsNotFound(err))
+	require.NoError(t, other.Delete(ctx))
+}
+
+func TestVaultTokenStore(t *testing.T) {
+	ctx := context.Background()
+	var mu sync.Mutex
+	var data map[string]interface{}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+		switch {
+		case r.URL.Path == "/v1/auth/k8s/login":
+			var body map[string]string
+			require.NoError(t, json.NewDecoder(r.Body).Decode(&body))
+			require.Equal(t, "peering", body["role"])
+			require.Equal(t, "service-account-jwt", body["jwt"])
+			writeJSON(t, w, map[string]interface{}{"auth": map[string]interface{}{"client_token": "vault-token"}})
+		case r.Header.Get("X-Vault-Token") != "vault-token":
+			w.WriteHeader(http.StatusForbidden)
+		case r.URL.Path == "/v1/kv/data/peering/cluster-02" && r.Method == http.MethodGet:
+			if data == nil {
+				w.WriteHeader(http.StatusNotFound)
+				return
+			}
+			writeJSON(t, w, map[string]interface{}{"data": map[string]interface{}{"data": data, "metadata": map[string]interface{}{"version": 1}}})
+		case r.URL.Path == "/v1/kv/data/peering/cluster-02":
+			var body map[string]map[string]interface{}
+			require.NoError(t, json.NewDecoder(r.Body).Decode(&body))
+			data = body["data"]
+			writeJSON(t, w, map[string]interface{}{"data": map[string]interface{}{"version": 1}})
+		case r.URL.Path == "/v1/kv/metadata/peering/cluster-02" && r.Method == http.MethodDelete:
+			data = nil
+			w.WriteHeader(http.StatusNoContent)
+		default:
+			t.Errorf("unexpected request %s %s", r.Method, r.URL.Path)
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	t.Cleanup(srv.Close)
+
+	jwtFile := filepath.Join(t.TempDir(), "token")
+	require.NoError(t, os.WriteFile(jwtFile, []byte("service-account-jwt"), 0o600))
+	oldPath := serviceAccountTokenPath
+	serviceAccountTokenPath = jwtFile
+	t.Cleanup(func() { serviceAccountTokenPath = oldPath })
+
+	exchange := &v1alpha1.VaultTokenExchange{
+		Address:        srv.URL,
+		Mount:          "kv",
+		Path:           "peering/cluster-02",
+		Key:            "token",
+		AuthMethodPath: "k8s",
+		Role:           "peering",
+	}
+	store, err := newVaultTokenStore(ctx, nil, "default", exchange)
+	require.NoError(t, err)
+
+	token, err := store.Read(ctx)
+	require.NoError(t, err)
+	require.Empty(t, token)
+
+	require.NoError(t, store.Write(ctx, "token-1"))
+	token, err = store.Read(ctx)
+	require.NoError(t, err)
+	require.Equal(t, "token-1", token)
+
+	// Other keys are kept when the token is written or deleted.
+	data["other"] = "value"
+	require.NoError(t, store.Write(ctx, "token-2"))
+	require.Equal(t, map[string]interface{}{"token": "token-2", "other": "value"}, data)
+	require.NoError(t, store.Delete(ctx))
+	require.Equal(t, map[string]interface{}{"other": "value"}, data)
+
+	delete(data, "other")
+	require.NoError(t, store.Write(ctx, "token-3"))
+	require.NoError(t, store.Delete(ctx))
+	require.Nil(t, data)
+}
+
+func TestAcceptorPublishToken(t *testing.T) {
+	cases := map[string]struct {
+		secret       *corev1.Secret
+		published    string
+		expPublished string
+	}{
+		"publishes the token": {
+			secret:       createSecret("acceptor-secret", "default", "data", "token-1"),
+			expPublished: "token-1",
+		},
+		"publishes a rotated token": {
+			secret:       createSecret("acceptor-secret", "default", "data", "token-2"),
+			published:    "token-1",
+			expPublished: "token-2",
+		},
+		"doesn't publish before the secret exists": {
+			published:    "token-1",
+			expPublished: "token-1",
+		},
+	}
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			acceptor := &v1alpha1.PeeringAcceptor{
+				ObjectMeta: metav1.ObjectMeta{Name: "acceptor", Namespace: "default"},
+				Spec: v1alpha1.PeeringAcceptorSpec{
+					Peer: &v1alpha1.Peer{
+						Secret: &v1alpha1.Secret{Name: "acceptor-secret", Key: "data", Backend: "kubernetes"},
+						TokenExchange: &v1alpha1.TokenExchange{
+							Vault:        &v1alpha1.VaultTokenExchange{Address: "https://vault", Path: "peering", Key: "token", Role: "peering"},
+							SyncInterval: metav1.Duration{Duration: 30 * time.Second},
+						},
+					},
+				},
+			}
+			k8sObjects := []runtime.Object{acceptor}
+			if tc.secret != nil {
+				k8sObjects = append(k8sObjects, tc.secret)
+			}
+			s := runtime.NewScheme()
+			corev1.AddToScheme(s)
+			s.AddKnownTypes(v1alpha1.GroupVersion, &v1alpha1.PeeringAcceptor{}, &v1alpha1.PeeringAcceptorList{})
+			fakeClient := fake.NewClientBuilder().WithScheme(s).
+				WithRuntimeObjects(k8sObjects...).
+				WithStatusSubresource(&v1alpha1.PeeringAcceptor{}).
+				Build()
+
+			store := &fakeTokenStore{token: tc.published}
+			controller := &AcceptorController{
+				Client:       fakeClient,
+				Log:          logrtest.New(t),
+				Scheme:       s,
+				tokenStoreFn: store.storeFunc(t, acceptor.TokenExchange()),
+			}
+
+			result, err := controller.publishToken(context.Background(), acceptor)
+			require.NoError(t, err)
+			require.Equal(t, ctrl.Result{RequeueAfter: 30 * time.Second}, result)
+			require.Equal(t, tc.expPublished, store.token)
+
+			// The token is removed from the store when the acceptor is deleted.
+			controller.unpublishToken(context.Background(), acceptor)
+			require.Empty(t, store.token)
+		})
+	}
+}
+
+func TestDialerSyncTokenFromStore(t *testing.T) {
+	cases := map[string]struct {
+		secret       *corev1.Secret
+		published    string
+		expPublished bool
+		expToken     string
+	}{
+		"creates the secret": {
+			published:    "token-1",
+			expPublished: true,
+			expToken:     "token-1",
+		},
+		"updates the secret with a rotated token": {
+			secret:       createSecret("dialer-secret", "default", "data", "token-1"),
+			published:    "token-2",
+			expPublished: true,
+			expToken:     "token-2",
+		},
+		"keeps the secret if the token is unchanged": {
+			secret:       createSecret("dialer-secret", "default", "data", "token-1"),
+			published:    "token-1",
+			expPublished: true,
+			expToken:     "token-1",
+		},
+		"keeps the secret if the store has no token": {
+			secret:       createSecret("dialer-secret", "default", "data", "token-1"),
+			expPublished: true,
+			expToken:     "token-1",
+		},
+		"token not published yet": {
+			expPublished: false,
+		},
+	}
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			dialer := &v1alpha1.PeeringDialer{
+				ObjectMeta: metav1.ObjectMeta{Name: "dialer", Namespace: "default"},
+				Spec: v1alpha1.PeeringDialerSpec{
+					Peer: &v1alpha1.Peer{
+						Secret: &v1alpha1.Secret{Name: "dialer-secret", Key: "data", Backend: "kubernetes"},
+						TokenExchange: &v1alpha1.TokenExchange{
+							Kubernetes: &v1alpha1.KubernetesTokenExchange{
+								KubeconfigSecret: v1alpha1.SecretKeySelector{Name: "kubeconfig", Key: "config"},
+								Namespace:        "management",
+								Name:             "peering-tokens",
+								Key:              "cluster-02",
+							},
+						},
+					},
+				},
+			}
+			k8sObjects := []runtime.Object{dialer}
+			if tc.secret != nil {
+				k8sObjects = append(k8sObjects, tc.secret)
+			}
+			s := runtime.NewScheme()
+			corev1.AddToScheme(s)
+			s.AddKnownTypes(v1alpha1.GroupVersion, &v1alpha1.PeeringDialer{}, &v1alpha1.PeeringDialerList{})
+			fakeClient := fake.NewClientBuilder().WithScheme(s).WithRuntimeObjects(k8sObjects...).Build()
+
+			store := &fakeTokenStore{token: tc.published}
+			controller := &PeeringDialerController{
+				Client:       fakeClient,
+				Log:          logrtest.New(t),
+				Scheme:       s,
+				tokenStoreFn: store.storeFunc(t, dialer.TokenExchange()),
+			}
+
+			published, err := controller.syncTokenFromStore(context.Background(), dialer)
+			require.NoError(t, err)
+			require.Equal(t, tc.expPublished, published)
+
+			secret := &corev1.Secret{}
+			err = fakeClient.Get(context.Background(), types.NamespacedName{Name: "dialer-secret", Namespace: "default"}, secret)
+			if tc.expToken == "" {
+				require.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, tc.expToken, string(secret.Data["data"]))
+		})
+	}
+}
+
+// fakeTokenStore is an in-memory tokenStore.
+type fakeTokenStore struct {
+	token string
+}
+
+func (s *fakeTokenStore) Read(context.Context) (string, error) {
+	return s.token, nil
+}
+
+func (s *fakeTokenStore) Write(_ context.Context, token string) error {
+	s.token = token
+	return nil
+}
+
+func (s *fakeTokenStore) Delete(context.Context) error {
+	s.token = ""
+	return nil
+}
+
+// storeFunc returns a tokenStoreFunc that returns the store for the expected token exchange.
+func (s *fakeTokenStore) storeFunc(t *testing.T, expExchange *v1alpha1.TokenExchange) tokenStoreFunc {
+	return func(_ context.Context, _ client.Client, namespace string, exchange *v1alpha1.TokenExchange) (tokenStore, error) {
+		require.Equal(t, "default", namespace)
+		require.Equal(t, expExchange, exchange)
+		return s, nil
+	}
+}
+
+func writeJSON(t *testing.T, w http.ResponseWriter, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	require.NoError(t, json.NewEncoder(w).Encode(v))
+}
@@ -0,0 +1,143 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package workload
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	pbcatalog "github.com/hashicorp/consul/proto-public/pbcatalog/v2beta1"
+	pbmesh "github.com/hashicorp/consul/proto-public/pbmesh/v2beta1"
+	"github.com/hashicorp/consul/proto-public/pbresource"
+	corev1 "k8s.io/api/core/v1"
+
+	"github.com/hashicorp/consul-k8s/control-plane/connect-inject/common"
+	"github.com/hashicorp/consul-k8s/control-plane/connect-inject/constants"
+)
+
+// writeDestinations translates the upstreams annotation of a pod into Destinations for its
+// workload, deleting them if the pod has no upstreams.
+func (r *Controller) writeDestinations(ctx context.Context, resourceClient pbresource.ResourceServiceClient, pod corev1.Pod) error {
+	id := r.resourceID(pbmesh.DestinationsType, pod.Name, pod.Namespace)
+
+	raw, ok := pod.Annotations[constants.AnnotationUpstreams]
+	if !ok || raw == "" {
+		return deleteResource(ctx, resourceClient, id)
+	}
+
+	var destinations []*pbmesh.Destination
+	for _, rawUpstream := range strings.Split(raw, ",") {
+		destination, err := r.parseDestination(pod, strings.TrimSpace(rawUpstream))
+		if err != nil {
+			return err
+		}
+		destination.DestinationPort, err = destinationPort(ctx, resourceClient, destination.DestinationRef)
+		if err != nil {
+			return err
+		}
+		destinations = append(destinations, destination)
+	}
+
+	return writeResource(ctx, resourceClient, id, r.resourceMeta(pod.Namespace, pod.Name), "", &pbmesh.Destinations{
+		Workloads:    &pbcatalog.WorkloadSelector{Names: []string{pod.Name}},
+		Destinations: destinations,
+	})
+}
+
+// parseDestination parses an upstream in one of the formats:
+// [service-name].[service-namespace].[service-partition]:[port]
+// [service-name].svc.[service-namespace].ns.[service-partition].ap:[port]
+// Prepared query, peer and datacenter upstreams are not supported by Consul resource APIs.
+func (r *Controller) parseDestination(pod corev1.Pod, rawUpstream string) (*pbmesh.Destination, error) {
+	parts := strings.SplitN(rawUpstream, ":", 3)
+	if len(parts) < 2 {
+		return nil, fmt.Errorf("upstream structured incorrectly: %s", rawUpstream)
+	}
+	if strings.TrimSpace(parts[0]) == "prepared_query" {
+		return nil, fmt.Errorf("prepared query upstreams are not supported with Consul resource APIs: %s", rawUpstream)
+	}
+	if len(parts) > 2 && strings.TrimSpace(parts[2]) != "" {
+		return nil, fmt.Errorf("datacenter upstreams are not supported with Consul resource APIs: %s", rawUpstream)
+	}
+
+	port, err := common.PortValue(pod, strings.TrimSpace(parts[1]))
+	if err != nil || port <= 0 {
+		return nil, fmt.Errorf("upstream port is invalid: %s", rawUpstream)
+	}
+
+	tenancy := r.tenancy(pod.Namespace)
+	var svcName string
+	pieces := strings.Split(strings.TrimSpace(parts[0]), ".")
+	if len(pieces) >= 2 && pieces[1] == "svc" {
+		if len(pieces)%2 != 0 {
+			return nil, fmt.Errorf("upstream structured incorrectly: %s", rawUpstream)
+		}
+		svcName = pieces[0]
+		for i := 2; i < len(pieces); i += 2 {
+			value, label := pieces[i], pieces[i+1]
+			switch label {
+			case "ns":
+				tenancy.Namespace = value
+			case "ap":
+				tenancy.Partition = value
+			case "peer", "dc":
+				return nil, fmt.Errorf("%s upstreams are not supported with Consul resource APIs: %s", label, rawUpstream)
+			default:
+				return nil, fmt.Errorf("upstream structured incorrectly: %s", rawUpstream)
+			}
+		}
+	} else if r.EnableConsulNamespaces || r.EnableConsulPartitions {
+		switch len(pieces) {
+		case 3:
+			tenancy.Partition = pieces[2]
+			fallthrough
+		case 2:
+			tenancy.Namespace = pieces[1]
+			fallthrough
+		default:
+			svcName = pieces[0]
+		}
+	} else {
+		svcName = strings.TrimSpace(parts[0])
+	}
+
+	return &pbmesh.Destination{
+		DestinationRef: &pbresource.Reference{
+			Type:    pbcatalog.ServiceType,
+			Tenancy: tenancy,
+			Name:    svcName,
+		},
+		ListenAddr: &pbmesh.Destination_IpPort{
+			IpPort: &pbmesh.IPPortAddress{
+				Ip:   "127.0.0.1",
+				Port: uint32(port),
+			},
+		},
+	}, nil
+}
+
+// destinationPort returns the port of a destination service that an upstream connects to.
+// Upstream annotations do not name a port, so this is the first port of the service that
+// is not the mesh port.
+func destinationPort(ctx context.Context, resourceClient pbresource.ResourceServiceClient, ref *pbresource.Reference) (string, error) {
+	res, err := readResource(ctx, resourceClient, &pbresource.ID{Type: ref.Type, Tenancy: ref.Tenancy, Name: ref.Name})
+	if err != nil {
+		return "", err
+	}
+	if res == nil {
+		return "", fmt.Errorf("destination service %q does not exist", ref.Name)
+	}
+
+	var service pbcatalog.Service
+	if err := res.Data.UnmarshalTo(&service); err != nil {
+		return "", fmt.Errorf("failed to decode service %q: %w", ref.Name, err)
+	}
+	for _, port := range service.Ports {
+		if port.Protocol != pbcatalog.Protocol_PROTOCOL_MESH {
+			return port.TargetPort, nil
+		}
+	}
+	return "", fmt.Errorf("destination service %q does not have any ports", ref.Name)
+}
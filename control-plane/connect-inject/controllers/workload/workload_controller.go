@@ -0,0 +1,569 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package workload
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	mapset "github.com/deckarep/golang-set"
+	"github.com/go-logr/logr"
+	pbcatalog "github.com/hashicorp/consul/proto-public/pbcatalog/v2beta1"
+	pbmesh "github.com/hashicorp/consul/proto-public/pbmesh/v2beta1"
+	"github.com/hashicorp/consul/proto-public/pbresource"
+	"golang.org/x/exp/slices"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/anypb"
+	corev1 "k8s.io/api/core/v1"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/intstr"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	"github.com/hashicorp/consul-k8s/control-plane/connect-inject/common"
+	"github.com/hashicorp/consul-k8s/control-plane/connect-inject/constants"
+	"github.com/hashicorp/consul-k8s/control-plane/consul"
+	"github.com/hashicorp/consul-k8s/control-plane/namespaces"
+)
+
+const (
+	// workloadManagedByValue is the value of the managed-by metadata key on the resources
+	// written by this controller.
+	workloadManagedByValue = "consul-k8s-workload-controller"
+
+	// metaKeyServiceNames is the metadata key on a Workload that lists the Consul services
+	// selecting it, so that they can be updated once the pod is gone.
+	metaKeyServiceNames = "service-names"
+
+	// sidecarContainerPrefix is the name prefix of the consul-dataplane containers added by the
+	// webhook. Their ports are not part of the workload.
+	sidecarContainerPrefix = "consul-dataplane"
+)
+
+// Controller writes Consul v2 resources for injected pods. Every pod is written as a Workload,
+// the services it is part of as Services selecting the pods by name, and its upstreams
+// annotation as Destinations. This is experimental and only runs when Consul resource APIs
+// are enabled.
+type Controller struct {
+	client.Client
+	// ConsulServerConnMgr is the watcher for the Consul server addresses.
+	ConsulServerConnMgr consul.ServerConnectionManager
+	// Only pods in the AllowK8sNamespacesSet are reconciled.
+	AllowK8sNamespacesSet mapset.Set
+	// Pods in the DenyK8sNamespacesSet are ignored.
+	DenyK8sNamespacesSet mapset.Set
+	// EnableConsulPartitions indicates that a user is running Consul Enterprise
+	// with version 1.11+ which supports Admin Partitions.
+	EnableConsulPartitions bool
+	// ConsulPartition is the Consul partition resources are written to.
+	ConsulPartition string
+	// EnableConsulNamespaces indicates that a user is running Consul Enterprise
+	// with version 1.7+ which supports namespaces.
+	EnableConsulNamespaces bool
+	// ConsulDestinationNamespace is the name of the Consul namespace to write
+	// all resources to. If EnableNSMirroring is true this is ignored.
+	ConsulDestinationNamespace string
+	// EnableNSMirroring causes resources to be written to the Consul namespace
+	// matching the Kubernetes namespace of the pod.
+	EnableNSMirroring bool
+	// NSMirroringPrefix is an optional prefix that can be added to the Consul
+	// namespaces when mirroring.
+	NSMirroringPrefix string
+
+	Log logr.Logger
+
+	// resourceClient is only used in tests.
+	resourceClient pbresource.ResourceServiceClient
+}
+
+// serviceSpec is a Consul service that a pod is part of.
+type serviceSpec struct {
+	name       string
+	ports      []*pbcatalog.ServicePort
+	virtualIPs []string
+}
+
+// Reconcile writes the Consul resources for an injected pod, or deletes them once the pod
+// is gone.
+func (r *Controller) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
+	// Ignore the request if the namespace of the pod is not allowed.
+	if common.ShouldIgnore(req.Namespace, r.DenyK8sNamespacesSet, r.AllowK8sNamespacesSet) {
+		return ctrl.Result{}, nil
+	}
+
+	resourceClient, err := r.getResourceClient()
+	if err != nil {
+		r.Log.Error(err, "failed to create Consul resource client", "name", req.Name, "ns", req.Namespace)
+		return ctrl.Result{}, err
+	}
+
+	var pod corev1.Pod
+	err = r.Client.Get(ctx, req.NamespacedName, &pod)
+	if k8serrors.IsNotFound(err) {
+		return ctrl.Result{}, r.deleteWorkload(ctx, resourceClient, req.NamespacedName)
+	} else if err != nil {
+		r.Log.Error(err, "failed to get Pod", "name", req.Name, "ns", req.Namespace)
+		return ctrl.Result{}, err
+	}
+
+	if !hasBeenInjected(pod) {
+		return ctrl.Result{}, nil
+	}
+	if pod.DeletionTimestamp != nil || pod.Status.Phase == corev1.PodSucceeded || pod.Status.Phase == corev1.PodFailed {
+		return ctrl.Result{}, r.deleteWorkload(ctx, resourceClient, req.NamespacedName)
+	}
+	if pod.Status.PodIP == "" {
+		// The pod is reconciled again once it has been assigned an IP.
+		return ctrl.Result{}, nil
+	}
+
+	if err := r.writeWorkload(ctx, resourceClient, pod); err != nil {
+		r.Log.Error(err, "failed to write Consul resources for Pod", "name", req.Name, "ns", req.Namespace)
+		return ctrl.Result{}, err
+	}
+	return ctrl.Result{}, nil
+}
+
+func (r *Controller) Logger(name types.NamespacedName) logr.Logger {
+	return r.Log.WithValues("request", name)
+}
+
+func (r *Controller) SetupWithManager(mgr ctrl.Manager) error {
+	return ctrl.NewControllerManagedBy(mgr).
+		For(&corev1.Pod{}).
+		Watches(&corev1.Service{}, handler.EnqueueRequestsFromMapFunc(r.transformService)).
+		Complete(r)
+}
+
+// transformService requeues the pods selected by a Kubernetes Service so that changes to
+// its ports are reflected in the Consul service.
+func (r *Controller) transformService(ctx context.Context, o client.Object) []reconcile.Request {
+	svc := o.(*corev1.Service)
+	if len(svc.Spec.Selector) == 0 {
+		return nil
+	}
+
+	var pods corev1.PodList
+	if err := r.Client.List(ctx, &pods, client.InNamespace(svc.Namespace), client.MatchingLabels(svc.Spec.Selector)); err != nil {
+		r.Log.Error(err, "error listing pods for service", "name", svc.Name, "ns", svc.Namespace)
+		return nil
+	}
+
+	var reqs []reconcile.Request
+	for _, pod := range pods.Items {
+		if hasBeenInjected(pod) {
+			reqs = append(reqs, reconcile.Request{
+				NamespacedName: types.NamespacedName{Name: pod.Name, Namespace: pod.Namespace},
+			})
+		}
+	}
+	return reqs
+}
+
+// writeWorkload writes the Workload for a pod along with the Services selecting it and its
+// Destinations.
+func (r *Controller) writeWorkload(ctx context.Context, resourceClient pbresource.ResourceServiceClient, pod corev1.Pod) error {
+	var k8sServices corev1.ServiceList
+	if err := r.Client.List(ctx, &k8sServices, client.InNamespace(pod.Namespace)); err != nil {
+		return fmt.Errorf("failed to list services: %w", err)
+	}
+
+	ports := workloadPorts(pod)
+	services, err := servicesForPod(pod, ports, k8sServices.Items)
+	if err != nil {
+		return err
+	}
+	serviceNames := make([]string, 0, len(services))
+	for _, svc := range services {
+		serviceNames = append(serviceNames, svc.name)
+	}
+
+	workloadID := r.resourceID(pbcatalog.WorkloadType, pod.Name, pod.Namespace)
+	previous, err := readResource(ctx, resourceClient, workloadID)
+	if err != nil {
+		return err
+	}
+
+	meta := r.resourceMeta(pod.Namespace, pod.Name)
+	meta[metaKeyServiceNames] = strings.Join(serviceNames, ",")
+	workload := &pbcatalog.Workload{
+		Addresses: []*pbcatalog.WorkloadAddress{{Host: pod.Status.PodIP}},
+		Ports:     ports,
+		Identity:  pod.Spec.ServiceAccountName,
+	}
+	if err := writeResource(ctx, resourceClient, workloadID, meta, "", workload); err != nil {
+		return err
+	}
+
+	for _, svc := range services {
+		if err := r.writeService(ctx, resourceClient, pod, svc, k8sServices.Items); err != nil {
+			return err
+		}
+	}
+
+	// Remove the pod from the services that no longer select it.
+	if previous != nil {
+		for _, name := range splitServiceNames(previous.Metadata[metaKeyServiceNames]) {
+			if !slices.Contains(serviceNames, name) {
+				if err := r.removeFromService(ctx, resourceClient, pod.Namespace, name, pod.Name); err != nil {
+					return err
+				}
+			}
+		}
+	}
+
+	return r.writeDestinations(ctx, resourceClient, pod)
+}
+
+// writeService writes a Consul service selecting all injected pods in the namespace that
+// are part of it.
+func (r *Controller) writeService(ctx context.Context, resourceClient pbresource.ResourceServiceClient, pod corev1.Pod, svc serviceSpec, k8sServices []corev1.Service) error {
+	var pods corev1.PodList
+	if err := r.Client.List(ctx, &pods, client.InNamespace(pod.Namespace)); err != nil {
+		return fmt.Errorf("failed to list pods: %w", err)
+	}
+
+	names := []string{pod.Name}
+	for _, p := range pods.Items {
+		if p.Name == pod.Name || !hasBeenInjected(p) || p.DeletionTimestamp != nil || p.Status.PodIP == "" {
+			continue
+		}
+		podServices, err := servicesForPod(p, workloadPorts(p), k8sServices)
+		if err != nil {
+			continue
+		}
+		for _, s := range podServices {
+			if s.name == svc.name {
+				names = append(names, p.Name)
+				break
+			}
+		}
+	}
+	sort.Strings(names)
+
+	service := &pbcatalog.Service{
+		Workloads: &pbcatalog.WorkloadSelector{Names: names},
+		Ports: append(svc.ports, &pbcatalog.ServicePort{
+			TargetPort: constants.ProxyDefaultMeshPortName,
+			Protocol:   pbcatalog.Protocol_PROTOCOL_MESH,
+		}),
+		VirtualIps: svc.virtualIPs,
+	}
+	id := r.resourceID(pbcatalog.ServiceType, svc.name, pod.Namespace)
+	return writeResource(ctx, resourceClient, id, r.resourceMeta(pod.Namespace, ""), "", service)
+}
+
+// removeFromService removes a pod from the workloads selected by a Consul service, deleting the
+// service once it does not select any workloads.
+func (r *Controller) removeFromService(ctx context.Context, resourceClient pbresource.ResourceServiceClient, k8sNamespace, serviceName, podName string) error {
+	id := r.resourceID(pbcatalog.ServiceType, serviceName, k8sNamespace)
+	res, err := readResource(ctx, resourceClient, id)
+	if err != nil || res == nil || res.Metadata[constants.MetaKeyManagedBy] != workloadManagedByValue {
+		return err
+	}
+
+	var service pbcatalog.Service
+	if err := res.Data.UnmarshalTo(&service); err != nil {
+		return fmt.Errorf("failed to decode service %q: %w", serviceName, err)
+	}
+	if service.Workloads == nil {
+		service.Workloads = &pbcatalog.WorkloadSelector{}
+	}
+	var names []string
+	for _, name := range service.Workloads.Names {
+		if name != podName {
+			names = append(names, name)
+		}
+	}
+	if len(names) == 0 {
+		return deleteResource(ctx, resourceClient, id)
+	}
+	service.Workloads.Names = names
+
+	// Write with the version that was read so that a concurrent update of the service by
+	// another pod is not overwritten.
+	return writeResource(ctx, resourceClient, res.Id, res.Metadata, res.Version, &service)
+}
+
+// deleteWorkload deletes the Workload and Destinations of a pod and removes it from the
+// services that selected it.
+func (r *Controller) deleteWorkload(ctx context.Context, resourceClient pbresource.ResourceServiceClient, name types.NamespacedName) error {
+	workloadID := r.resourceID(pbcatalog.WorkloadType, name.Name, name.Namespace)
+	res, err := readResource(ctx, resourceClient, workloadID)
+	if err != nil {
+		return err
+	}
+	if res == nil || res.Metadata[constants.MetaKeyManagedBy] != workloadManagedByValue {
+		return nil
+	}
+
+	r.Log.Info("deleting workload", "name", name.Name, "ns", name.Namespace)
+	if err := deleteResource(ctx, resourceClient, r.resourceID(pbmesh.DestinationsType, name.Name, name.Namespace)); err != nil {
+		return err
+	}
+	if err := deleteResource(ctx, resourceClient, workloadID); err != nil {
+		return err
+	}
+	for _, svc := range splitServiceNames(res.Metadata[metaKeyServiceNames]) {
+		if err := r.removeFromService(ctx, resourceClient, name.Namespace, svc, name.Name); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// workloadPorts returns the workload ports for the application containers of a pod along
+// with the port the proxy accepts mesh traffic on.
+func workloadPorts(pod corev1.Pod) map[string]*pbcatalog.WorkloadPort {
+	ports := map[string]*pbcatalog.WorkloadPort{
+		constants.ProxyDefaultMeshPortName: {
+			Port:     constants.ProxyDefaultInboundPort,
+			Protocol: pbcatalog.Protocol_PROTOCOL_MESH,
+		},
+	}
+	for _, container := range pod.Spec.Containers {
+		if strings.HasPrefix(container.Name, sidecarContainerPrefix) {
+			continue
+		}
+		for _, port := range container.Ports {
+			if port.Protocol != "" && port.Protocol != corev1.ProtocolTCP {
+				continue
+			}
+			ports[common.WorkloadPortName(&port)] = &pbcatalog.WorkloadPort{
+				Port:     uint32(port.ContainerPort),
+				Protocol: pbcatalog.Protocol_PROTOCOL_TCP,
+			}
+		}
+	}
+	return ports
+}
+
+// servicesForPod returns the Consul services a pod is part of. Services are taken from the
+// connect-service annotation, where a comma-separated list registers a multiport pod, and
+// otherwise from the Kubernetes Services selecting the pod. Ports referenced by number that
+// are not container ports of the pod are added to ports.
+func servicesForPod(pod corev1.Pod, ports map[string]*pbcatalog.WorkloadPort, k8sServices []corev1.Service) ([]serviceSpec, error) {
+	if raw, ok := pod.Annotations[constants.AnnotationService]; ok && raw != "" {
+		names := strings.Split(raw, ",")
+		var portValues []string
+		if rawPorts, ok := pod.Annotations[constants.AnnotationPort]; ok && rawPorts != "" {
+			portValues = strings.Split(rawPorts, ",")
+			if len(portValues) != len(names) {
+				return nil, fmt.Errorf("annotations %q and %q must have the same number of entries",
+					constants.AnnotationService, constants.AnnotationPort)
+			}
+		}
+
+		var services []serviceSpec
+		for i, name := range names {
+			svc := serviceSpec{name: strings.TrimSpace(name)}
+			if portValues != nil {
+				target, err := workloadPortForTarget(intstr.Parse(strings.TrimSpace(portValues[i])), ports)
+				if err != nil {
+					return nil, err
+				}
+				svc.ports = []*pbcatalog.ServicePort{{TargetPort: target, Protocol: pbcatalog.Protocol_PROTOCOL_TCP}}
+			} else if name := firstContainerPortName(pod); name != "" {
+				svc.ports = []*pbcatalog.ServicePort{{TargetPort: name, Protocol: pbcatalog.Protocol_PROTOCOL_TCP}}
+			}
+			services = append(services, svc)
+		}
+		return services, nil
+	}
+
+	var services []serviceSpec
+	for _, k8sSvc := range k8sServices {
+		if len(k8sSvc.Spec.Selector) == 0 || k8sSvc.Labels[constants.LabelServiceIgnore] == "true" {
+			continue
+		}
+		if !labels.SelectorFromSet(k8sSvc.Spec.Selector).Matches(labels.Set(pod.Labels)) {
+			continue
+		}
+
+		svc := serviceSpec{name: k8sSvc.Name}
+		seen := make(map[string]bool)
+		for _, port := range k8sSvc.Spec.Ports {
+			if port.Protocol != "" && port.Protocol != corev1.ProtocolTCP {
+				continue
+			}
+			targetPort := port.TargetPort
+			if targetPort.Type == intstr.Int && targetPort.IntVal == 0 {
+				targetPort = intstr.FromInt(int(port.Port))
+			}
+			target, err := workloadPortForTarget(targetPort, ports)
+			if err != nil {
+				return nil, err
+			}
+			if seen[target] {
+				continue
+			}
+			seen[target] = true
+			svc.ports = append(svc.ports, &pbcatalog.ServicePort{
+				VirtualPort: uint32(port.Port),
+				TargetPort:  target,
+				Protocol:    pbcatalog.Protocol_PROTOCOL_TCP,
+			})
+		}
+		if k8sSvc.Spec.ClusterIP != "" && k8sSvc.Spec.ClusterIP != corev1.ClusterIPNone {
+			svc.virtualIPs = []string{k8sSvc.Spec.ClusterIP}
+		}
+		services = append(services, svc)
+	}
+	return services, nil
+}
+
+// workloadPortForTarget returns the name of the workload port matching a port name or number.
+// A port number that is not a container port of the pod is added to ports.
+func workloadPortForTarget(target intstr.IntOrString, ports map[string]*pbcatalog.WorkloadPort) (string, error) {
+	if target.Type == intstr.String {
+		if _, ok := ports[target.StrVal]; ok && target.StrVal != constants.ProxyDefaultMeshPortName {
+			return target.StrVal, nil
+		}
+		return "", fmt.Errorf("port %q is not a named container port of the pod", target.StrVal)
+	}
+
+	names := make([]string, 0, len(ports))
+	for name := range ports {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		if name != constants.ProxyDefaultMeshPortName && ports[name].Port == uint32(target.IntVal) {
+			return name, nil
+		}
+	}
+
+	if target.IntVal <= 0 || target.IntVal > 65535 {
+		return "", fmt.Errorf("port %d is out of range", target.IntVal)
+	}
+	name := constants.UnnamedWorkloadPortNamePrefix + strconv.Itoa(int(target.IntVal))
+	ports[name] = &pbcatalog.WorkloadPort{Port: uint32(target.IntVal), Protocol: pbcatalog.Protocol_PROTOCOL_TCP}
+	return name, nil
+}
+
+// firstContainerPortName returns the workload port name of the first TCP port of the
+// application containers, matching the port the webhook proxies by default.
+func firstContainerPortName(pod corev1.Pod) string {
+	for _, container := range pod.Spec.Containers {
+		if strings.HasPrefix(container.Name, sidecarContainerPrefix) {
+			continue
+		}
+		for _, port := range container.Ports {
+			if port.Protocol == "" || port.Protocol == corev1.ProtocolTCP {
+				return common.WorkloadPortName(&port)
+			}
+		}
+	}
+	return ""
+}
+
+// getResourceClient returns the client used to read and write Consul resources.
+func (r *Controller) getResourceClient() (pbresource.ResourceServiceClient, error) {
+	if r.resourceClient != nil {
+		return r.resourceClient, nil
+	}
+	return consul.NewResourceServiceClient(r.ConsulServerConnMgr)
+}
+
+// resourceID returns the ID of a Consul resource in the tenancy of a Kubernetes namespace.
+func (r *Controller) resourceID(resourceType *pbresource.Type, name, k8sNamespace string) *pbresource.ID {
+	return &pbresource.ID{
+		Name:    name,
+		Type:    resourceType,
+		Tenancy: r.tenancy(k8sNamespace),
+	}
+}
+
+// tenancy returns the Consul tenancy for a Kubernetes namespace.
+func (r *Controller) tenancy(k8sNamespace string) *pbresource.Tenancy {
+	partition := ""
+	if r.EnableConsulPartitions {
+		partition = r.ConsulPartition
+	}
+	return &pbresource.Tenancy{
+		Partition: constants.GetNormalizedConsulPartition(partition),
+		Namespace: constants.GetNormalizedConsulNamespace(r.consulNamespace(k8sNamespace)),
+	}
+}
+
+// consulNamespace returns the Consul destination namespace for a provided Kubernetes namespace
+// depending on Consul Namespaces being enabled and the value of namespace mirroring.
+func (r *Controller) consulNamespace(namespace string) string {
+	return namespaces.ConsulNamespace(namespace, r.EnableConsulNamespaces, r.ConsulDestinationNamespace, r.EnableNSMirroring, r.NSMirroringPrefix)
+}
+
+// resourceMeta returns the metadata of the resources written for a Kubernetes object.
+func (r *Controller) resourceMeta(k8sNamespace, k8sName string) map[string]string {
+	meta := map[string]string{
+		constants.MetaKeyManagedBy: workloadManagedByValue,
+		constants.MetaKeyKubeNS:    k8sNamespace,
+	}
+	if k8sName != "" {
+		meta[constants.MetaKeyKubeName] = k8sName
+	}
+	return meta
+}
+
+// readResource reads a Consul resource, returning nil if it does not exist.
+func readResource(ctx context.Context, resourceClient pbresource.ResourceServiceClient, id *pbresource.ID) (*pbresource.Resource, error) {
+	rsp, err := resourceClient.Read(ctx, &pbresource.ReadRequest{Id: id})
+	if status.Code(err) == codes.NotFound {
+		return nil, nil
+	} else if err != nil {
+		return nil, fmt.Errorf("failed to read %s %q: %w", id.Type.Kind, id.Name, err)
+	}
+	return rsp.Resource, nil
+}
+
+// writeResource writes a Consul resource. If version is set, the write only succeeds if the
+// stored resource has not changed since it was read.
+func writeResource(ctx context.Context, resourceClient pbresource.ResourceServiceClient, id *pbresource.ID, meta map[string]string, version string, data proto.Message) error {
+	payload, err := anypb.New(data)
+	if err != nil {
+		return fmt.Errorf("failed to encode %s %q: %w", id.Type.Kind, id.Name, err)
+	}
+	_, err = resourceClient.Write(ctx, &pbresource.WriteRequest{
+		Resource: &pbresource.Resource{
+			Id:       id,
+			Metadata: meta,
+			Version:  version,
+			Data:     payload,
+		},
+	})
+	if err != nil {
+		return fmt.Errorf("failed to write %s %q: %w", id.Type.Kind, id.Name, err)
+	}
+	return nil
+}
+
+// deleteResource deletes a Consul resource. Deleting a resource that does not exist is not an error.
+func deleteResource(ctx context.Context, resourceClient pbresource.ResourceServiceClient, id *pbresource.ID) error {
+	_, err := resourceClient.Delete(ctx, &pbresource.DeleteRequest{Id: id})
+	if err != nil && status.Code(err) != codes.NotFound {
+		return fmt.Errorf("failed to delete %s %q: %w", id.Type.Kind, id.Name, err)
+	}
+	return nil
+}
+
+// hasBeenInjected checks the value of the status annotation and returns true if the Pod has been injected.
+func hasBeenInjected(pod corev1.Pod) bool {
+	anno, ok := pod.Annotations[constants.KeyInjectStatus]
+	return ok && anno == constants.Injected
+}
+
+func splitServiceNames(raw string) []string {
+	if raw == "" {
+		return nil
+	}
+	return strings.Split(raw, ",")
+}
@@ -0,0 +1,467 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package workload
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"sync"
+	"testing"
+
+	mapset "github.com/deckarep/golang-set"
+	logrtest "github.com/go-logr/logr/testr"
+	"github.com/google/go-cmp/cmp"
+	pbcatalog "github.com/hashicorp/consul/proto-public/pbcatalog/v2beta1"
+	pbmesh "github.com/hashicorp/consul/proto-public/pbmesh/v2beta1"
+	"github.com/hashicorp/consul/proto-public/pbresource"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/testing/protocmp"
+	"google.golang.org/protobuf/types/known/anypb"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/intstr"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	"github.com/hashicorp/consul-k8s/control-plane/connect-inject/constants"
+)
+
+func TestReconcile_WriteResources(t *testing.T) {
+	t.Parallel()
+
+	cases := map[string]struct {
+		k8sObjects           []runtime.Object
+		existingResources    []*pbresource.Resource
+		expectedWorkload     *pbcatalog.Workload
+		expectedServices     map[string]*pbcatalog.Service
+		expectedDestinations *pbmesh.Destinations
+		expErr               string
+	}{
+		"pod selected by a Kubernetes service": {
+			k8sObjects: []runtime.Object{
+				createPod("web-1", "10.0.0.1", nil),
+				createService("web", "10.96.0.10", corev1.ServicePort{Port: 80, TargetPort: intstr.FromString("http")}),
+			},
+			expectedWorkload: &pbcatalog.Workload{
+				Addresses: []*pbcatalog.WorkloadAddress{{Host: "10.0.0.1"}},
+				Ports: map[string]*pbcatalog.WorkloadPort{
+					"mesh": {Port: 20000, Protocol: pbcatalog.Protocol_PROTOCOL_MESH},
+					"http": {Port: 8080, Protocol: pbcatalog.Protocol_PROTOCOL_TCP},
+				},
+				Identity: "web",
+			},
+			expectedServices: map[string]*pbcatalog.Service{
+				"web": {
+					Workloads: &pbcatalog.WorkloadSelector{Names: []string{"web-1"}},
+					Ports: []*pbcatalog.ServicePort{
+						{VirtualPort: 80, TargetPort: "http", Protocol: pbcatalog.Protocol_PROTOCOL_TCP},
+						{TargetPort: "mesh", Protocol: pbcatalog.Protocol_PROTOCOL_MESH},
+					},
+					VirtualIps: []string{"10.96.0.10"},
+				},
+			},
+		},
+		"service selects all injected pods": {
+			k8sObjects: []runtime.Object{
+				createPod("web-1", "10.0.0.1", nil),
+				createPod("web-2", "10.0.0.2", nil),
+				createService("web", "10.96.0.10", corev1.ServicePort{Port: 80, TargetPort: intstr.FromInt(8080)}),
+			},
+			expectedWorkload: &pbcatalog.Workload{
+				Addresses: []*pbcatalog.WorkloadAddress{{Host: "10.0.0.1"}},
+				Ports: map[string]*pbcatalog.WorkloadPort{
+					"mesh": {Port: 20000, Protocol: pbcatalog.Protocol_PROTOCOL_MESH},
+					"http": {Port: 8080, Protocol: pbcatalog.Protocol_PROTOCOL_TCP},
+				},
+				Identity: "web",
+			},
+			expectedServices: map[string]*pbcatalog.Service{
+				"web": {
+					Workloads: &pbcatalog.WorkloadSelector{Names: []string{"web-1", "web-2"}},
+					Ports: []*pbcatalog.ServicePort{
+						{VirtualPort: 80, TargetPort: "http", Protocol: pbcatalog.Protocol_PROTOCOL_TCP},
+						{TargetPort: "mesh", Protocol: pbcatalog.Protocol_PROTOCOL_MESH},
+					},
+					VirtualIps: []string{"10.96.0.10"},
+				},
+			},
+		},
+		"multiport pod from annotations": {
+			k8sObjects: []runtime.Object{
+				createPod("web-1", "10.0.0.1", map[string]string{
+					constants.AnnotationService: "web,web-admin",
+					constants.AnnotationPort:    "http,9090",
+				}),
+			},
+			expectedWorkload: &pbcatalog.Workload{
+				Addresses: []*pbcatalog.WorkloadAddress{{Host: "10.0.0.1"}},
+				Ports: map[string]*pbcatalog.WorkloadPort{
+					"mesh":         {Port: 20000, Protocol: pbcatalog.Protocol_PROTOCOL_MESH},
+					"http":         {Port: 8080, Protocol: pbcatalog.Protocol_PROTOCOL_TCP},
+					"cslport-9090": {Port: 9090, Protocol: pbcatalog.Protocol_PROTOCOL_TCP},
+				},
+				Identity: "web",
+			},
+			expectedServices: map[string]*pbcatalog.Service{
+				"web": {
+					Workloads: &pbcatalog.WorkloadSelector{Names: []string{"web-1"}},
+					Ports: []*pbcatalog.ServicePort{
+						{TargetPort: "http", Protocol: pbcatalog.Protocol_PROTOCOL_TCP},
+						{TargetPort: "mesh", Protocol: pbcatalog.Protocol_PROTOCOL_MESH},
+					},
+				},
+				"web-admin": {
+					Workloads: &pbcatalog.WorkloadSelector{Names: []string{"web-1"}},
+					Ports: []*pbcatalog.ServicePort{
+						{TargetPort: "cslport-9090", Protocol: pbcatalog.Protocol_PROTOCOL_TCP},
+						{TargetPort: "mesh", Protocol: pbcatalog.Protocol_PROTOCOL_MESH},
+					},
+				},
+			},
+		},
+		"upstreams are written as destinations": {
+			k8sObjects: []runtime.Object{
+				createPod("web-1", "10.0.0.1", map[string]string{
+					constants.AnnotationService:   "web",
+					constants.AnnotationUpstreams: "api:1234, db.svc:5432",
+				}),
+			},
+			existingResources: []*pbresource.Resource{
+				serviceResource(t, "api", "grpc"),
+				serviceResource(t, "db", "tcp"),
+			},
+			expectedWorkload: &pbcatalog.Workload{
+				Addresses: []*pbcatalog.WorkloadAddress{{Host: "10.0.0.1"}},
+				Ports: map[string]*pbcatalog.WorkloadPort{
+					"mesh": {Port: 20000, Protocol: pbcatalog.Protocol_PROTOCOL_MESH},
+					"http": {Port: 8080, Protocol: pbcatalog.Protocol_PROTOCOL_TCP},
+				},
+				Identity: "web",
+			},
+			expectedServices: map[string]*pbcatalog.Service{
+				"web": {
+					Workloads: &pbcatalog.WorkloadSelector{Names: []string{"web-1"}},
+					Ports: []*pbcatalog.ServicePort{
+						{TargetPort: "http", Protocol: pbcatalog.Protocol_PROTOCOL_TCP},
+						{TargetPort: "mesh", Protocol: pbcatalog.Protocol_PROTOCOL_MESH},
+					},
+				},
+			},
+			expectedDestinations: &pbmesh.Destinations{
+				Workloads: &pbcatalog.WorkloadSelector{Names: []string{"web-1"}},
+				Destinations: []*pbmesh.Destination{
+					{
+						DestinationRef:  &pbresource.Reference{Type: pbcatalog.ServiceType, Tenancy: defaultTenancy(), Name: "api"},
+						DestinationPort: "grpc",
+						ListenAddr:      &pbmesh.Destination_IpPort{IpPort: &pbmesh.IPPortAddress{Ip: "127.0.0.1", Port: 1234}},
+					},
+					{
+						DestinationRef:  &pbresource.Reference{Type: pbcatalog.ServiceType, Tenancy: defaultTenancy(), Name: "db"},
+						DestinationPort: "tcp",
+						ListenAddr:      &pbmesh.Destination_IpPort{IpPort: &pbmesh.IPPortAddress{Ip: "127.0.0.1", Port: 5432}},
+					},
+				},
+			},
+		},
+		"upstream to a service that does not exist": {
+			k8sObjects: []runtime.Object{
+				createPod("web-1", "10.0.0.1", map[string]string{
+					constants.AnnotationService:   "web",
+					constants.AnnotationUpstreams: "api:1234",
+				}),
+			},
+			expErr: `destination service "api" does not exist`,
+		},
+		"peer upstreams are not supported": {
+			k8sObjects: []runtime.Object{
+				createPod("web-1", "10.0.0.1", map[string]string{
+					constants.AnnotationService:   "web",
+					constants.AnnotationUpstreams: "api.svc.other.peer:1234",
+				}),
+			},
+			expErr: "peer upstreams are not supported with Consul resource APIs",
+		},
+		"mismatched service and port annotations": {
+			k8sObjects: []runtime.Object{
+				createPod("web-1", "10.0.0.1", map[string]string{
+					constants.AnnotationService: "web,web-admin",
+					constants.AnnotationPort:    "http",
+				}),
+			},
+			expErr: "must have the same number of entries",
+		},
+	}
+
+	for name, c := range cases {
+		c := c
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			resourceClient := newFakeResourceClient()
+			for _, res := range c.existingResources {
+				_, err := resourceClient.Write(context.Background(), &pbresource.WriteRequest{Resource: res})
+				require.NoError(t, err)
+			}
+			controller := newController(t, resourceClient, c.k8sObjects...)
+
+			_, err := controller.Reconcile(context.Background(), ctrl.Request{
+				NamespacedName: types.NamespacedName{Name: "web-1", Namespace: "default"},
+			})
+			if c.expErr != "" {
+				require.ErrorContains(t, err, c.expErr)
+				return
+			}
+			require.NoError(t, err)
+
+			workload := &pbcatalog.Workload{}
+			res := resourceClient.get(t, pbcatalog.WorkloadType, "web-1", workload)
+			require.NotNil(t, res)
+			requireProtoEqual(t, c.expectedWorkload, workload)
+			require.Equal(t, workloadManagedByValue, res.Metadata[constants.MetaKeyManagedBy])
+
+			for name, expected := range c.expectedServices {
+				service := &pbcatalog.Service{}
+				require.NotNil(t, resourceClient.get(t, pbcatalog.ServiceType, name, service))
+				requireProtoEqual(t, expected, service)
+			}
+
+			destinations := &pbmesh.Destinations{}
+			res = resourceClient.get(t, pbmesh.DestinationsType, "web-1", destinations)
+			if c.expectedDestinations == nil {
+				require.Nil(t, res)
+			} else {
+				require.NotNil(t, res)
+				requireProtoEqual(t, c.expectedDestinations, destinations)
+			}
+		})
+	}
+}
+
+func TestReconcile_PodNotInjected(t *testing.T) {
+	t.Parallel()
+
+	pod := createPod("web-1", "10.0.0.1", nil)
+	delete(pod.Annotations, constants.KeyInjectStatus)
+	resourceClient := newFakeResourceClient()
+	controller := newController(t, resourceClient, pod)
+
+	_, err := controller.Reconcile(context.Background(), ctrl.Request{
+		NamespacedName: types.NamespacedName{Name: "web-1", Namespace: "default"},
+	})
+	require.NoError(t, err)
+	require.Empty(t, resourceClient.resources)
+}
+
+func TestReconcile_DeleteResources(t *testing.T) {
+	t.Parallel()
+
+	cases := map[string]struct {
+		remainingPods    []runtime.Object
+		expectedWorkload []string
+	}{
+		"last pod of the service": {},
+		"other pods remain": {
+			remainingPods:    []runtime.Object{createPod("web-2", "10.0.0.2", nil)},
+			expectedWorkload: []string{"web-2"},
+		},
+	}
+
+	for name, c := range cases {
+		c := c
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			resourceClient := newFakeResourceClient()
+			svc := createService("web", "10.96.0.10", corev1.ServicePort{Port: 80, TargetPort: intstr.FromString("http")})
+			pod := createPod("web-1", "10.0.0.1", map[string]string{})
+			pod.Annotations[constants.AnnotationUpstreams] = "api:1234"
+			require.NoError(t, writeResource(context.Background(), resourceClient, &pbresource.ID{
+				Name: "api", Type: pbcatalog.ServiceType, Tenancy: defaultTenancy(),
+			}, nil, "", &pbcatalog.Service{Ports: []*pbcatalog.ServicePort{{TargetPort: "grpc"}}}))
+
+			// Write the resources for all pods.
+			objects := append([]runtime.Object{svc, pod}, c.remainingPods...)
+			controller := newController(t, resourceClient, objects...)
+			for _, name := range []string{"web-1", "web-2"} {
+				_, err := controller.Reconcile(context.Background(), ctrl.Request{
+					NamespacedName: types.NamespacedName{Name: name, Namespace: "default"},
+				})
+				require.NoError(t, err)
+			}
+			require.NotNil(t, resourceClient.get(t, pbmesh.DestinationsType, "web-1", &pbmesh.Destinations{}))
+
+			// Reconcile again once the pod is gone.
+			controller = newController(t, resourceClient, append([]runtime.Object{svc}, c.remainingPods...)...)
+			_, err := controller.Reconcile(context.Background(), ctrl.Request{
+				NamespacedName: types.NamespacedName{Name: "web-1", Namespace: "default"},
+			})
+			require.NoError(t, err)
+
+			require.Nil(t, resourceClient.get(t, pbcatalog.WorkloadType, "web-1", &pbcatalog.Workload{}))
+			require.Nil(t, resourceClient.get(t, pbmesh.DestinationsType, "web-1", &pbmesh.Destinations{}))
+
+			service := &pbcatalog.Service{}
+			res := resourceClient.get(t, pbcatalog.ServiceType, "web", service)
+			if c.expectedWorkload == nil {
+				require.Nil(t, res)
+			} else {
+				require.NotNil(t, res)
+				require.Equal(t, c.expectedWorkload, service.Workloads.Names)
+			}
+		})
+	}
+}
+
+func newController(t *testing.T, resourceClient pbresource.ResourceServiceClient, k8sObjects ...runtime.Object) *Controller {
+	fakeClient := fake.NewClientBuilder().WithRuntimeObjects(k8sObjects...).Build()
+	return &Controller{
+		Client:                fakeClient,
+		AllowK8sNamespacesSet: mapset.NewSetWith("*"),
+		DenyK8sNamespacesSet:  mapset.NewSetWith(),
+		Log:                   logrtest.New(t),
+		resourceClient:        resourceClient,
+	}
+}
+
+func createPod(name, ip string, annotations map[string]string) *corev1.Pod {
+	podAnnotations := map[string]string{
+		constants.KeyInjectStatus: constants.Injected,
+	}
+	for k, v := range annotations {
+		podAnnotations[k] = v
+	}
+	return &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:        name,
+			Namespace:   "default",
+			Labels:      map[string]string{"app": "web"},
+			Annotations: podAnnotations,
+		},
+		Spec: corev1.PodSpec{
+			ServiceAccountName: "web",
+			Containers: []corev1.Container{
+				{
+					Name:  "web",
+					Ports: []corev1.ContainerPort{{Name: "http", ContainerPort: 8080}},
+				},
+				{
+					Name:  "consul-dataplane",
+					Ports: []corev1.ContainerPort{{Name: "prometheus", ContainerPort: 20200}},
+				},
+			},
+		},
+		Status: corev1.PodStatus{
+			PodIP: ip,
+			Phase: corev1.PodRunning,
+		},
+	}
+}
+
+func createService(name, clusterIP string, ports ...corev1.ServicePort) *corev1.Service {
+	return &corev1.Service{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      name,
+			Namespace: "default",
+		},
+		Spec: corev1.ServiceSpec{
+			Selector:  map[string]string{"app": "web"},
+			ClusterIP: clusterIP,
+			Ports:     ports,
+		},
+	}
+}
+
+func serviceResource(t *testing.T, name, port string) *pbresource.Resource {
+	data, err := anypb.New(&pbcatalog.Service{
+		Ports: []*pbcatalog.ServicePort{
+			{TargetPort: "mesh", Protocol: pbcatalog.Protocol_PROTOCOL_MESH},
+			{TargetPort: port, Protocol: pbcatalog.Protocol_PROTOCOL_TCP},
+		},
+	})
+	require.NoError(t, err)
+	return &pbresource.Resource{
+		Id:   &pbresource.ID{Name: name, Type: pbcatalog.ServiceType, Tenancy: defaultTenancy()},
+		Data: data,
+	}
+}
+
+func defaultTenancy() *pbresource.Tenancy {
+	return &pbresource.Tenancy{Partition: constants.DefaultConsulPartition, Namespace: constants.DefaultConsulNS}
+}
+
+func requireProtoEqual(t *testing.T, expected, actual proto.Message) {
+	t.Helper()
+	if diff := cmp.Diff(expected, actual, protocmp.Transform()); diff != "" {
+		t.Fatalf("unexpected resource (-want +got):\n%s", diff)
+	}
+}
+
+// fakeResourceClient is an in-memory implementation of the Consul resource service.
+type fakeResourceClient struct {
+	pbresource.ResourceServiceClient
+
+	mu        sync.Mutex
+	resources map[string]*pbresource.Resource
+	version   int
+}
+
+func newFakeResourceClient() *fakeResourceClient {
+	return &fakeResourceClient{resources: make(map[string]*pbresource.Resource)}
+}
+
+func resourceKey(id *pbresource.ID) string {
+	return fmt.Sprintf("%s/%s/%s/%s/%s", id.Type.Group, id.Type.Kind, id.Tenancy.Partition, id.Tenancy.Namespace, id.Name)
+}
+
+func (f *fakeResourceClient) Read(_ context.Context, req *pbresource.ReadRequest, _ ...grpc.CallOption) (*pbresource.ReadResponse, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	res, ok := f.resources[resourceKey(req.Id)]
+	if !ok {
+		return nil, status.Error(codes.NotFound, "resource not found")
+	}
+	return &pbresource.ReadResponse{Resource: proto.Clone(res).(*pbresource.Resource)}, nil
+}
+
+func (f *fakeResourceClient) Write(_ context.Context, req *pbresource.WriteRequest, _ ...grpc.CallOption) (*pbresource.WriteResponse, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	key := resourceKey(req.Resource.Id)
+	if existing, ok := f.resources[key]; ok && req.Resource.Version != "" && req.Resource.Version != existing.Version {
+		return nil, status.Error(codes.Aborted, "CAS operation failed")
+	}
+	f.version++
+	res := proto.Clone(req.Resource).(*pbresource.Resource)
+	res.Version = strconv.Itoa(f.version)
+	f.resources[key] = res
+	return &pbresource.WriteResponse{Resource: res}, nil
+}
+
+func (f *fakeResourceClient) Delete(_ context.Context, req *pbresource.DeleteRequest, _ ...grpc.CallOption) (*pbresource.DeleteResponse, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	delete(f.resources, resourceKey(req.Id))
+	return &pbresource.DeleteResponse{}, nil
+}
+
+// get decodes a stored resource into data, returning nil if it does not exist.
+func (f *fakeResourceClient) get(t *testing.T, resourceType *pbresource.Type, name string, data proto.Message) *pbresource.Resource {
+	t.Helper()
+	rsp, err := f.Read(context.Background(), &pbresource.ReadRequest{
+		Id: &pbresource.ID{Name: name, Type: resourceType, Tenancy: defaultTenancy()},
+	})
+	if status.Code(err) == codes.NotFound {
+		return nil
+	}
+	require.NoError(t, err)
+	require.NoError(t, rsp.Resource.Data.UnmarshalTo(data))
+	return rsp.Resource
+}
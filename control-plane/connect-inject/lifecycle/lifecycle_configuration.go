@@ -21,6 +21,7 @@ type Config struct {
 	DefaultGracefulPort                 string
 	DefaultGracefulShutdownPath         string
 	DefaultGracefulStartupPath          string
+	DefaultPreStopDrainSeconds          int
 }
 
 // EnableProxyLifecycle returns whether proxy lifecycle management is enabled either via the default value in the meshWebhook, or if it's been
@@ -65,6 +66,21 @@ func (lc Config) ShutdownGracePeriodSeconds(pod corev1.Pod) (int, error) {
 	return shutdownGracePeriodSeconds, nil
 }
 
+// PreStopDrainSeconds returns how long the application containers and the sidecar proxy should sleep in their preStop hooks
+// when the pod is deleted, either via the default value in the meshWebhook, or if it's been overridden via the annotation.
+// Zero means that no preStop hooks are added.
+func (lc Config) PreStopDrainSeconds(pod corev1.Pod) (int, error) {
+	preStopDrainSeconds := lc.DefaultPreStopDrainSeconds
+	if preStopDrainSecondsAnnotation, ok := pod.Annotations[constants.AnnotationSidecarProxyLifecyclePreStopDrainSeconds]; ok {
+		val, err := strconv.ParseUint(preStopDrainSecondsAnnotation, 10, 64)
+		if err != nil {
+			return 0, fmt.Errorf("unable to parse annotation %q: %w", constants.AnnotationSidecarProxyLifecyclePreStopDrainSeconds, err)
+		}
+		preStopDrainSeconds = int(val)
+	}
+	return preStopDrainSeconds, nil
+}
+
 // StartupGracePeriodSeconds returns how long to block application startup waiting for the sidecar proxy to be ready, either via the default value in the meshWebhook, or if it's been
 // overridden via the annotation.
 func (lc Config) StartupGracePeriodSeconds(pod corev1.Pod) (int, error) {
@@ -200,6 +200,72 @@ func TestLifecycleConfig_ShutdownGracePeriodSeconds(t *testing.T) {
 	}
 }
 
+func TestLifecycleConfig_PreStopDrainSeconds(t *testing.T) {
+	cases := []struct {
+		Name            string
+		Pod             func(*corev1.Pod) *corev1.Pod
+		LifecycleConfig Config
+		Expected        int
+		Err             string
+	}{
+		{
+			Name: "Pre-stop drain set via meshWebhook",
+			Pod: func(pod *corev1.Pod) *corev1.Pod {
+				return pod
+			},
+			LifecycleConfig: Config{
+				DefaultPreStopDrainSeconds: 10,
+			},
+			Expected: 10,
+			Err:      "",
+		},
+		{
+			Name: "Pre-stop drain set via annotation",
+			Pod: func(pod *corev1.Pod) *corev1.Pod {
+				pod.Annotations[constants.AnnotationSidecarProxyLifecyclePreStopDrainSeconds] = "20"
+				return pod
+			},
+			LifecycleConfig: Config{
+				DefaultPreStopDrainSeconds: 10,
+			},
+			Expected: 20,
+			Err:      "",
+		},
+		{
+			Name: "Pre-stop drain configured via invalid annotation, negative number",
+			Pod: func(pod *corev1.Pod) *corev1.Pod {
+				pod.Annotations[constants.AnnotationSidecarProxyLifecyclePreStopDrainSeconds] = "-1"
+				return pod
+			},
+			Err: "unable to parse annotation \"consul.hashicorp.com/sidecar-proxy-lifecycle-pre-stop-drain-seconds\": strconv.ParseUint: parsing \"-1\": invalid syntax",
+		},
+		{
+			Name: "Pre-stop drain configured via invalid annotation, not-parseable string",
+			Pod: func(pod *corev1.Pod) *corev1.Pod {
+				pod.Annotations[constants.AnnotationSidecarProxyLifecyclePreStopDrainSeconds] = "not-int"
+				return pod
+			},
+			Err: "unable to parse annotation \"consul.hashicorp.com/sidecar-proxy-lifecycle-pre-stop-drain-seconds\": strconv.ParseUint: parsing \"not-int\": invalid syntax",
+		},
+	}
+
+	for _, tt := range cases {
+		t.Run(tt.Name, func(t *testing.T) {
+			require := require.New(t)
+			lc := tt.LifecycleConfig
+
+			actual, err := lc.PreStopDrainSeconds(*tt.Pod(minimal()))
+
+			if tt.Err == "" {
+				require.Equal(tt.Expected, actual)
+				require.NoError(err)
+			} else {
+				require.EqualError(err, tt.Err)
+			}
+		})
+	}
+}
+
 func TestLifecycleConfig_StartupGracePeriodSeconds(t *testing.T) {
 	cases := []struct {
 		Name            string
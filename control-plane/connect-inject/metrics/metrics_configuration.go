@@ -31,7 +31,8 @@ type metricsPorts struct {
 }
 
 const (
-	defaultServiceMetricsPath = "/metrics"
+	defaultServiceMetricsPath   = "/metrics"
+	defaultServiceMetricsScheme = "http"
 )
 
 // MergedMetricsServerConfiguration is called when running a merged metrics server and used to return ports necessary to
@@ -154,6 +155,37 @@ func (mc Config) ServiceMetricsPath(pod corev1.Pod) string {
 	return defaultServiceMetricsPath
 }
 
+// ServiceMetricsScheme returns the scheme used to scrape the service metrics. It defaults to
+// http, and can be overridden with the annotation to scrape services that only expose metrics over TLS.
+func (mc Config) ServiceMetricsScheme(pod corev1.Pod) (string, error) {
+	raw, ok := pod.Annotations[constants.AnnotationMergedMetricsAppScheme]
+	if !ok || raw == "" {
+		return defaultServiceMetricsScheme, nil
+	}
+	if raw != "http" && raw != "https" {
+		return "", fmt.Errorf("%s annotation value of %s must be one of \"http\" or \"https\"", constants.AnnotationMergedMetricsAppScheme, raw)
+	}
+	return raw, nil
+}
+
+// ServiceMetricsTLSSecret returns the name of the Secret with the CA certificate used to scrape the
+// service metrics over TLS, or an empty string if it isn't set. It can only be set when the service
+// metrics are scraped over https.
+func (mc Config) ServiceMetricsTLSSecret(pod corev1.Pod) (string, error) {
+	raw, ok := pod.Annotations[constants.AnnotationMergedMetricsAppTLSSecret]
+	if !ok || raw == "" {
+		return "", nil
+	}
+	scheme, err := mc.ServiceMetricsScheme(pod)
+	if err != nil {
+		return "", err
+	}
+	if scheme != "https" {
+		return "", fmt.Errorf("%s annotation requires %s to be \"https\"", constants.AnnotationMergedMetricsAppTLSSecret, constants.AnnotationMergedMetricsAppScheme)
+	}
+	return raw, nil
+}
+
 // ShouldRunMergedMetricsServer returns whether we need to run a merged metrics
 // server. This is used to configure the consul sidecar command, and the init
 // container, so it can pass appropriate arguments to the consul connect envoy
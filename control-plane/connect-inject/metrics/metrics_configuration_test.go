@@ -217,6 +217,105 @@ func TestMetricsConfigServiceMetricsPath(t *testing.T) {
 	}
 }
 
+func TestMetricsConfigServiceMetricsScheme(t *testing.T) {
+	cases := []struct {
+		Name     string
+		Pod      func(*corev1.Pod) *corev1.Pod
+		Expected string
+		Err      string
+	}{
+		{
+			Name: "Defaults to http",
+			Pod: func(pod *corev1.Pod) *corev1.Pod {
+				return pod
+			},
+			Expected: "http",
+		},
+		{
+			Name: "Uses annotationMergedMetricsAppScheme when set",
+			Pod: func(pod *corev1.Pod) *corev1.Pod {
+				pod.Annotations[constants.AnnotationMergedMetricsAppScheme] = "https"
+				return pod
+			},
+			Expected: "https",
+		},
+		{
+			Name: "Invalid annotationMergedMetricsAppScheme",
+			Pod: func(pod *corev1.Pod) *corev1.Pod {
+				pod.Annotations[constants.AnnotationMergedMetricsAppScheme] = "HTTPS"
+				return pod
+			},
+			Err: `consul.hashicorp.com/merged-metrics-app-scheme annotation value of HTTPS must be one of "http" or "https"`,
+		},
+	}
+
+	for _, tt := range cases {
+		t.Run(tt.Name, func(t *testing.T) {
+			require := require.New(t)
+			mc := Config{}
+
+			actual, err := mc.ServiceMetricsScheme(*tt.Pod(minimal()))
+
+			if tt.Err == "" {
+				require.NoError(err)
+				require.Equal(tt.Expected, actual)
+			} else {
+				require.EqualError(err, tt.Err)
+			}
+		})
+	}
+}
+
+func TestMetricsConfigServiceMetricsTLSSecret(t *testing.T) {
+	cases := []struct {
+		Name     string
+		Pod      func(*corev1.Pod) *corev1.Pod
+		Expected string
+		Err      string
+	}{
+		{
+			Name: "Not set by default",
+			Pod: func(pod *corev1.Pod) *corev1.Pod {
+				return pod
+			},
+			Expected: "",
+		},
+		{
+			Name: "Uses annotationMergedMetricsAppTLSSecret when set with https",
+			Pod: func(pod *corev1.Pod) *corev1.Pod {
+				pod.Annotations[constants.AnnotationMergedMetricsAppScheme] = "https"
+				pod.Annotations[constants.AnnotationMergedMetricsAppTLSSecret] = "web-metrics-tls"
+				return pod
+			},
+			Expected: "web-metrics-tls",
+		},
+		{
+			Name: "annotationMergedMetricsAppTLSSecret set without https",
+			Pod: func(pod *corev1.Pod) *corev1.Pod {
+				pod.Annotations[constants.AnnotationMergedMetricsAppTLSSecret] = "web-metrics-tls"
+				return pod
+			},
+			Err: `consul.hashicorp.com/merged-metrics-app-tls-secret annotation requires consul.hashicorp.com/merged-metrics-app-scheme to be "https"`,
+		},
+	}
+
+	for _, tt := range cases {
+		t.Run(tt.Name, func(t *testing.T) {
+			require := require.New(t)
+			mc := Config{}
+
+			actual, err := mc.ServiceMetricsTLSSecret(*tt.Pod(minimal()))
+
+			if tt.Err == "" {
+				require.NoError(err)
+				require.Equal(tt.Expected, actual)
+			} else {
+				require.EqualError(err, tt.Err)
+			}
+		})
+	}
+}
+
 func TestMetricsConfigPrometheusScrapePath(t *testing.T) {
 	cases := []struct {
 		Name          string
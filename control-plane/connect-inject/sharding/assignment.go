@@ -0,0 +1,103 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package sharding
+
+import (
+	"fmt"
+	"hash/fnv"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+const (
+	// ShardsKey is the key of the number of shards in the coordination ConfigMap.
+	ShardsKey = "shards"
+
+	// ShardNamespacesKeyPrefix prefixes the keys of the coordination ConfigMap whose values are the
+	// comma-separated namespaces explicitly assigned to a shard, e.g. "shard-0: team-a,team-b".
+	ShardNamespacesKeyPrefix = "shard-"
+)
+
+// Assignment assigns Kubernetes namespaces to shards. Namespaces are assigned explicitly
+// or, if they aren't, by the hash of their name.
+type Assignment struct {
+	// Shards is the number of shards.
+	Shards int
+	// Namespaces maps the explicitly assigned namespaces to the index of their shard.
+	Namespaces map[string]int
+}
+
+// ParseAssignment parses the assignment from the data of the coordination ConfigMap.
+func ParseAssignment(data map[string]string) (Assignment, error) {
+	shards, err := strconv.Atoi(strings.TrimSpace(data[ShardsKey]))
+	if err != nil {
+		return Assignment{}, fmt.Errorf("%q must be a number: %w", ShardsKey, err)
+	}
+	if shards < 1 {
+		return Assignment{}, fmt.Errorf("%q must be at least 1", ShardsKey)
+	}
+
+	assignment := Assignment{Shards: shards, Namespaces: make(map[string]int)}
+	for key, value := range data {
+		if !strings.HasPrefix(key, ShardNamespacesKeyPrefix) {
+			continue
+		}
+		index, err := strconv.Atoi(strings.TrimPrefix(key, ShardNamespacesKeyPrefix))
+		if err != nil || index < 0 || index >= shards {
+			return Assignment{}, fmt.Errorf("%q is not a shard between 0 and %d", key, shards-1)
+		}
+		for _, namespace := range strings.Split(value, ",") {
+			namespace = strings.TrimSpace(namespace)
+			if namespace == "" {
+				continue
+			}
+			if other, ok := assignment.Namespaces[namespace]; ok && other != index {
+				return Assignment{}, fmt.Errorf("namespace %q is assigned to shards %d and %d", namespace, other, index)
+			}
+			assignment.Namespaces[namespace] = index
+		}
+	}
+	return assignment, nil
+}
+
+// ShardOf returns the index of the shard of the namespace.
+func (a Assignment) ShardOf(namespace string) int {
+	if index, ok := a.Namespaces[namespace]; ok {
+		return index
+	}
+	return hashIndex(namespace, a.Shards)
+}
+
+// Equal returns whether both assignments assign every namespace to the same shard.
+func (a Assignment) Equal(other Assignment) bool {
+	if a.Shards != other.Shards || len(a.Namespaces) != len(other.Namespaces) {
+		return false
+	}
+	for namespace, index := range a.Namespaces {
+		if otherIndex, ok := other.Namespaces[namespace]; !ok || otherIndex != index {
+			return false
+		}
+	}
+	return true
+}
+
+// Version returns a hash of the assignment that is equal for equal assignments.
+func (a Assignment) Version() string {
+	namespaces := make([]string, 0, len(a.Namespaces))
+	for namespace, index := range a.Namespaces {
+		namespaces = append(namespaces, fmt.Sprintf("%s=%d", namespace, index))
+	}
+	sort.Strings(namespaces)
+	h := fnv.New64a()
+	_, _ = fmt.Fprintf(h, "%d;%s", a.Shards, strings.Join(namespaces, ","))
+	return strconv.FormatUint(h.Sum64(), 16)
+}
+
+// hashIndex returns the index between 0 and n-1 that s hashes to.
+func hashIndex(s string, n int) int {
+	h := fnv.New32a()
+	_, _ = h.Write([]byte(s))
+	return int(h.Sum32() % uint32(n))
+}
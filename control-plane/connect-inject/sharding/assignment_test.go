@@ -0,0 +1,85 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package sharding
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseAssignment(t *testing.T) {
+	cases := map[string]struct {
+		data     map[string]string
+		expected Assignment
+		expErr   string
+	}{
+		"hashed only": {
+			data:     map[string]string{"shards": "3"},
+			expected: Assignment{Shards: 3, Namespaces: map[string]int{}},
+		},
+		"explicit namespaces": {
+			data:     map[string]string{"shards": "2", "shard-0": "team-a, team-b", "shard-1": "team-c,", "other": "ignored"},
+			expected: Assignment{Shards: 2, Namespaces: map[string]int{"team-a": 0, "team-b": 0, "team-c": 1}},
+		},
+		"missing shards": {
+			data:   map[string]string{},
+			expErr: `"shards" must be a number`,
+		},
+		"zero shards": {
+			data:   map[string]string{"shards": "0"},
+			expErr: `"shards" must be at least 1`,
+		},
+		"shard out of range": {
+			data:   map[string]string{"shards": "2", "shard-2": "team-a"},
+			expErr: `"shard-2" is not a shard between 0 and 1`,
+		},
+		"shard not a number": {
+			data:   map[string]string{"shards": "2", "shard-a": "team-a"},
+			expErr: `"shard-a" is not a shard between 0 and 1`,
+		},
+		"namespace in two shards": {
+			data:   map[string]string{"shards": "2", "shard-0": "team-a", "shard-1": "team-a"},
+			expErr: `namespace "team-a" is assigned to shards`,
+		},
+	}
+	for name, c := range cases {
+		t.Run(name, func(t *testing.T) {
+			assignment, err := ParseAssignment(c.data)
+			if c.expErr != "" {
+				require.ErrorContains(t, err, c.expErr)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, c.expected, assignment)
+		})
+	}
+}
+
+func TestAssignment_ShardOf(t *testing.T) {
+	assignment := Assignment{Shards: 4, Namespaces: map[string]int{"team-a": 3}}
+	require.Equal(t, 3, assignment.ShardOf("team-a"))
+
+	// Namespaces that aren't assigned explicitly are spread across the shards by their hash.
+	counts := make([]int, assignment.Shards)
+	for i := 0; i < 400; i++ {
+		namespace := fmt.Sprintf("namespace-%d", i)
+		index := assignment.ShardOf(namespace)
+		require.Equal(t, index, assignment.ShardOf(namespace))
+		counts[index]++
+	}
+	for index, count := range counts {
+		require.NotZero(t, count, "shard %d has no namespaces", index)
+	}
+}
+
+func TestAssignment_Equal(t *testing.T) {
+	a := Assignment{Shards: 2, Namespaces: map[string]int{"team-a": 0}}
+	require.True(t, a.Equal(Assignment{Shards: 2, Namespaces: map[string]int{"team-a": 0}}))
+	require.False(t, a.Equal(Assignment{Shards: 3, Namespaces: map[string]int{"team-a": 0}}))
+	require.False(t, a.Equal(Assignment{Shards: 2, Namespaces: map[string]int{"team-a": 1}}))
+	require.False(t, a.Equal(Assignment{Shards: 2, Namespaces: map[string]int{"team-b": 0}}))
+	require.False(t, a.Equal(Assignment{Shards: 2}))
+}
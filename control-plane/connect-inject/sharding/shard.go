@@ -0,0 +1,423 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package sharding
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/go-logr/logr"
+	coordinationv1 "k8s.io/api/coordination/v1"
+	corev1 "k8s.io/api/core/v1"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/utils/ptr"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/event"
+	"sigs.k8s.io/controller-runtime/pkg/source"
+)
+
+const (
+	// DefaultLeaseDuration is how long a shard stays claimed by a replica that stops renewing its Lease.
+	DefaultLeaseDuration = 15 * time.Second
+
+	// DefaultRenewDeadline is how long a replica keeps owning the namespaces of its shard without
+	// renewing the Lease. It is shorter than the Lease duration so that the replica stops reconciling
+	// before another one can claim the expired Lease.
+	DefaultRenewDeadline = 10 * time.Second
+
+	// DefaultRetryPeriod is how often the Lease is renewed and, while no shard is claimed, how often
+	// the replica tries to claim one.
+	DefaultRetryPeriod = 5 * time.Second
+
+	// releaseTimeout bounds how long releasing the Lease on shutdown takes.
+	releaseTimeout = 5 * time.Second
+
+	// AssignmentVersionAnnotation is the annotation of a shard Lease with the version of the assignment
+	// its holder reconciles. A replica only starts owning the namespaces moved to its shard once every
+	// other Lease that is held has the version, since their holders then no longer own them.
+	AssignmentVersionAnnotation = "consul.hashicorp.com/shard-assignment-version"
+)
+
+// Shard is the shard of Kubernetes namespaces that a replica owns. Shards are assigned namespaces by
+// the coordination ConfigMap, and a replica owns a shard while it holds the Lease of the shard, named
+// after the ConfigMap with the index of the shard as a suffix. A replica claims the first shard whose
+// Lease is free and owns no namespaces until it does, so replicas beyond the number of shards are
+// standbys that take over the shard of a replica that stops.
+//
+// When the assignment changes, a replica stops owning the namespaces moved out of its shard right away,
+// and only starts owning the namespaces moved into it once the holders of the other Leases have
+// acknowledged the change, so that no namespace is reconciled by two replicas at once.
+//
+// Shard implements manager.Runnable and runs on every replica.
+type Shard struct {
+	// Reader reads the coordination ConfigMap and the Leases. It should not be cached.
+	Reader client.Reader
+	// Writer creates and updates the Leases.
+	Writer client.Writer
+	// ConfigMapName is the name of the coordination ConfigMap.
+	ConfigMapName string
+	// Namespace is the namespace of the coordination ConfigMap and the Leases.
+	Namespace string
+	// Identity identifies the replica in the Leases, e.g. its pod name.
+	Identity string
+	// LeaseDuration defaults to DefaultLeaseDuration.
+	LeaseDuration time.Duration
+	// RenewDeadline defaults to DefaultRenewDeadline. It must be shorter than LeaseDuration.
+	RenewDeadline time.Duration
+	// RetryPeriod defaults to DefaultRetryPeriod.
+	RetryPeriod time.Duration
+	Log         logr.Logger
+
+	once   sync.Once
+	events chan event.GenericEvent
+
+	mu         sync.RWMutex
+	index      int
+	assignment Assignment
+	// handedOver is whether the holders of the other Leases acknowledged the assignment. Until they
+	// do, the replica only owns the namespaces that were in its shard in the previous assignment too.
+	handedOver bool
+	// previous is the last assignment that was handed over while the replica held its shard, or nil
+	// if it claimed the shard since.
+	previous *Assignment
+	// renewed is the last time the Lease of the claimed shard was renewed.
+	renewed time.Time
+}
+
+// Owns returns whether the namespace is in the shard claimed by the replica. The replica owns no
+// namespaces once its Lease wasn't renewed within the renew deadline.
+func (s *Shard) Owns(namespace string) bool {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	if s.assignment.Shards == 0 || s.index < 0 || time.Since(s.renewed) > s.renewDeadline() {
+		return false
+	}
+	if s.assignment.ShardOf(namespace) != s.index {
+		return false
+	}
+	return s.handedOver || (s.previous != nil && s.previous.ShardOf(namespace) == s.index)
+}
+
+// Index returns the index of the shard claimed by the replica, or -1 if it hasn't claimed one.
+func (s *Shard) Index() int {
+	s.init()
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.index
+}
+
+// Source returns a source of events sent when the namespaces owned by the replica change, so that
+// controllers can reconcile the objects of the namespaces they gained.
+func (s *Shard) Source() source.Source {
+	s.init()
+	return &source.Channel{Source: s.events}
+}
+
+// Start claims a shard and keeps its Lease until ctx is cancelled. It returns an error if the Lease is
+// lost so that the manager stops, and the replica restarts without reconciling namespaces it no longer owns.
+func (s *Shard) Start(ctx context.Context) error {
+	s.init()
+	ticker := time.NewTicker(s.retryPeriod())
+	defer ticker.Stop()
+	for {
+		if err := s.refresh(ctx); err != nil {
+			return err
+		}
+		select {
+		case <-ctx.Done():
+			s.release()
+			return nil
+		case <-ticker.C:
+		}
+	}
+}
+
+// NeedLeaderElection implements manager.LeaderElectionRunnable so that every replica claims a shard.
+func (s *Shard) NeedLeaderElection() bool {
+	return false
+}
+
+func (s *Shard) init() {
+	s.once.Do(func() {
+		s.events = make(chan event.GenericEvent, 1)
+		s.mu.Lock()
+		s.index = -1
+		s.mu.Unlock()
+	})
+}
+
+// refresh re-reads the assignment and renews the Lease of the claimed shard or, if no shard is
+// claimed, tries to claim one.
+func (s *Shard) refresh(ctx context.Context) error {
+	changed := false
+	defer func() {
+		if changed {
+			s.notify()
+		}
+	}()
+
+	var configMap corev1.ConfigMap
+	err := s.Reader.Get(ctx, types.NamespacedName{Name: s.ConfigMapName, Namespace: s.Namespace}, &configMap)
+	if err != nil {
+		s.Log.Error(err, "failed to get coordination ConfigMap", "name", s.ConfigMapName, "namespace", s.Namespace)
+	} else if assignment, err := ParseAssignment(configMap.Data); err != nil {
+		s.Log.Error(err, "invalid coordination ConfigMap, keeping the current assignment", "name", s.ConfigMapName)
+	} else {
+		s.mu.Lock()
+		if !assignment.Equal(s.assignment) {
+			s.Log.Info("assignment of namespaces to shards changed", "shards", assignment.Shards)
+			// Keep owning the namespaces that stay in the shard until the change is handed over.
+			if s.handedOver && s.index >= 0 {
+				previous := s.assignment
+				s.previous = &previous
+			}
+			s.assignment = assignment
+			s.handedOver = false
+			changed = true
+		}
+		s.mu.Unlock()
+	}
+
+	s.mu.RLock()
+	index, shards, renewed, version := s.index, s.assignment.Shards, s.renewed, s.assignment.Version()
+	s.mu.RUnlock()
+	if shards == 0 {
+		// The assignment hasn't been read yet.
+		return nil
+	}
+
+	now := time.Now()
+	if index >= shards {
+		s.Log.Info("claimed shard no longer exists, releasing it", "shard", index, "shards", shards)
+		s.release()
+		changed = true
+		index = -1
+	}
+	if index >= 0 {
+		held, err := s.tryAcquire(ctx, index, version, now)
+		if err != nil {
+			s.Log.Error(err, "failed to renew shard lease", "shard", index)
+			if now.Sub(renewed) > s.renewDeadline() {
+				return fmt.Errorf("lost the lease of shard %d: not renewed for %s", index, now.Sub(renewed))
+			}
+			return nil
+		}
+		if !held {
+			return fmt.Errorf("lost the lease of shard %d to another replica", index)
+		}
+		s.mu.Lock()
+		s.renewed = now
+		s.mu.Unlock()
+		if s.handOver(ctx, index, version, now) {
+			changed = true
+		}
+		return nil
+	}
+
+	// Start with a different shard on each replica so that they don't all contend for the same Lease.
+	start := hashIndex(s.Identity, shards)
+	for i := 0; i < shards; i++ {
+		candidate := (start + i) % shards
+		acquired, err := s.tryAcquire(ctx, candidate, version, now)
+		if err != nil {
+			s.Log.Error(err, "failed to claim shard", "shard", candidate)
+			continue
+		}
+		if acquired {
+			s.Log.Info("claimed shard", "shard", candidate, "shards", shards)
+			s.mu.Lock()
+			s.index = candidate
+			s.renewed = now
+			s.handedOver = false
+			s.previous = nil
+			s.mu.Unlock()
+			s.handOver(ctx, candidate, version, now)
+			changed = true
+			return nil
+		}
+	}
+	s.Log.V(1).Info("no shard is free, waiting for one to be released", "shards", shards)
+	return nil
+}
+
+// handOver completes the hand over of the assignment with the version once the holders of the other
+// Leases acknowledged it. It returns whether the replica gained namespaces.
+func (s *Shard) handOver(ctx context.Context, index int, version string, now time.Time) bool {
+	s.mu.RLock()
+	handedOver := s.handedOver
+	s.mu.RUnlock()
+	if handedOver {
+		return false
+	}
+
+	var leases coordinationv1.LeaseList
+	if err := s.Reader.List(ctx, &leases, client.InNamespace(s.Namespace)); err != nil {
+		s.Log.Error(err, "failed to list shard leases")
+		return false
+	}
+	for _, lease := range leases.Items {
+		if lease.Name == s.leaseName(index) || !s.isShardLease(lease.Name) {
+			continue
+		}
+		if ptr.Deref(lease.Spec.HolderIdentity, "") == "" || leaseExpired(lease, now) {
+			continue
+		}
+		if lease.Annotations[AssignmentVersionAnnotation] != version {
+			s.Log.V(1).Info("waiting for the assignment to be handed over", "lease", lease.Name)
+			return false
+		}
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	// The assignment may have changed while the Leases were listed.
+	if s.index != index || s.assignment.Version() != version {
+		return false
+	}
+	s.handedOver = true
+	s.previous = nil
+	s.Log.Info("assignment handed over", "shard", index)
+	return true
+}
+
+// tryAcquire acquires or renews the Lease of the shard, with the version of the assignment the replica
+// reconciles. It returns false if another replica holds it.
+func (s *Shard) tryAcquire(ctx context.Context, index int, version string, now time.Time) (bool, error) {
+	name := s.leaseName(index)
+	microNow := metav1.NewMicroTime(now)
+	durationSeconds := int32(s.leaseDuration().Seconds())
+
+	var lease coordinationv1.Lease
+	err := s.Reader.Get(ctx, types.NamespacedName{Name: name, Namespace: s.Namespace}, &lease)
+	if k8serrors.IsNotFound(err) {
+		lease = coordinationv1.Lease{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:        name,
+				Namespace:   s.Namespace,
+				Annotations: map[string]string{AssignmentVersionAnnotation: version},
+			},
+			Spec: coordinationv1.LeaseSpec{
+				HolderIdentity:       ptr.To(s.Identity),
+				LeaseDurationSeconds: ptr.To(durationSeconds),
+				AcquireTime:          &microNow,
+				RenewTime:            &microNow,
+				LeaseTransitions:     ptr.To(int32(0)),
+			},
+		}
+		err = s.Writer.Create(ctx, &lease)
+		if k8serrors.IsAlreadyExists(err) {
+			return false, nil
+		}
+		return err == nil, err
+	} else if err != nil {
+		return false, err
+	}
+
+	holder := ptr.Deref(lease.Spec.HolderIdentity, "")
+	if holder != s.Identity && holder != "" && !leaseExpired(lease, now) {
+		return false, nil
+	}
+	if holder != s.Identity {
+		lease.Spec.AcquireTime = &microNow
+		lease.Spec.LeaseTransitions = ptr.To(ptr.Deref(lease.Spec.LeaseTransitions, 0) + 1)
+	}
+	if lease.Annotations == nil {
+		lease.Annotations = make(map[string]string)
+	}
+	lease.Annotations[AssignmentVersionAnnotation] = version
+	lease.Spec.HolderIdentity = ptr.To(s.Identity)
+	lease.Spec.LeaseDurationSeconds = ptr.To(durationSeconds)
+	lease.Spec.RenewTime = &microNow
+	err = s.Writer.Update(ctx, &lease)
+	if k8serrors.IsConflict(err) {
+		return false, nil
+	}
+	return err == nil, err
+}
+
+// release gives up the claimed shard and clears the holder of its Lease so that a standby replica
+// can claim it without waiting for the Lease to expire.
+func (s *Shard) release() {
+	s.mu.Lock()
+	index := s.index
+	s.index = -1
+	s.mu.Unlock()
+	if index < 0 {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), releaseTimeout)
+	defer cancel()
+	var lease coordinationv1.Lease
+	if err := s.Reader.Get(ctx, types.NamespacedName{Name: s.leaseName(index), Namespace: s.Namespace}, &lease); err != nil {
+		s.Log.Error(err, "failed to release shard lease", "shard", index)
+		return
+	}
+	if ptr.Deref(lease.Spec.HolderIdentity, "") != s.Identity {
+		return
+	}
+	lease.Spec.HolderIdentity = nil
+	if err := s.Writer.Update(ctx, &lease); err != nil {
+		s.Log.Error(err, "failed to release shard lease", "shard", index)
+		return
+	}
+	s.Log.Info("released shard", "shard", index)
+}
+
+// notify sends an event to the Source unless one is already pending.
+func (s *Shard) notify() {
+	select {
+	case s.events <- event.GenericEvent{Object: &corev1.ConfigMap{ObjectMeta: metav1.ObjectMeta{Name: s.ConfigMapName, Namespace: s.Namespace}}}:
+	default:
+	}
+}
+
+func (s *Shard) leaseName(index int) string {
+	return fmt.Sprintf("%s-%d", s.ConfigMapName, index)
+}
+
+// isShardLease returns whether the Lease is the Lease of a shard, including shards that no longer exist.
+func (s *Shard) isShardLease(name string) bool {
+	suffix, ok := strings.CutPrefix(name, s.ConfigMapName+"-")
+	if !ok {
+		return false
+	}
+	_, err := strconv.Atoi(suffix)
+	return err == nil
+}
+
+func (s *Shard) renewDeadline() time.Duration {
+	if s.RenewDeadline > 0 {
+		return s.RenewDeadline
+	}
+	return min(DefaultRenewDeadline, s.leaseDuration()*2/3)
+}
+
+func (s *Shard) leaseDuration() time.Duration {
+	if s.LeaseDuration > 0 {
+		return s.LeaseDuration
+	}
+	return DefaultLeaseDuration
+}
+
+func (s *Shard) retryPeriod() time.Duration {
+	if s.RetryPeriod > 0 {
+		return s.RetryPeriod
+	}
+	return DefaultRetryPeriod
+}
+
+// leaseExpired returns whether the holder of the Lease hasn't renewed it within its duration.
+func leaseExpired(lease coordinationv1.Lease, now time.Time) bool {
+	if lease.Spec.RenewTime == nil || lease.Spec.LeaseDurationSeconds == nil {
+		return true
+	}
+	return now.After(lease.Spec.RenewTime.Add(time.Duration(*lease.Spec.LeaseDurationSeconds) * time.Second))
+}
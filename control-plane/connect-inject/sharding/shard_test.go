@@ -0,0 +1,240 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package sharding
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	logrtest "github.com/go-logr/logr/testr"
+	"github.com/stretchr/testify/require"
+	coordinationv1 "k8s.io/api/coordination/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/utils/ptr"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+func TestShard_ClaimAndRelease(t *testing.T) {
+	configMap := &corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{Name: "shards", Namespace: "consul"},
+		Data:       map[string]string{"shards": "2", "shard-0": "team-a", "shard-1": "team-b"},
+	}
+	k8sClient := fake.NewClientBuilder().WithObjects(configMap).Build()
+	newShard := func(identity string) *Shard {
+		shard := &Shard{
+			Reader:        k8sClient,
+			Writer:        k8sClient,
+			ConfigMapName: "shards",
+			Namespace:     "consul",
+			Identity:      identity,
+			Log:           logrtest.New(t),
+		}
+		shard.init()
+		return shard
+	}
+	first, second, standby := newShard("first"), newShard("second"), newShard("standby")
+
+	// Each replica claims a different shard, and replicas beyond the number of shards own nothing.
+	for _, shard := range []*Shard{first, second, standby} {
+		require.NoError(t, shard.refresh(context.Background()))
+	}
+	require.ElementsMatch(t, []int{0, 1}, []int{first.Index(), second.Index()})
+	require.Equal(t, -1, standby.Index())
+	require.NotEqual(t, first.Owns("team-a"), second.Owns("team-a"))
+	require.NotEqual(t, first.Owns("team-b"), second.Owns("team-b"))
+	require.False(t, standby.Owns("team-a"))
+	require.False(t, standby.Owns("team-b"))
+	require.Equal(t, "first", leaseHolder(t, k8sClient, first.Index()))
+	require.Len(t, first.events, 1, "claiming a shard must resync the controllers")
+
+	// Renewing keeps the shard.
+	require.NoError(t, first.refresh(context.Background()))
+	require.Equal(t, "first", leaseHolder(t, k8sClient, first.Index()))
+
+	// The standby takes over the shard of a replica that stops.
+	index := first.Index()
+	first.release()
+	require.Equal(t, -1, first.Index())
+	require.Equal(t, "", leaseHolder(t, k8sClient, index))
+	require.NoError(t, standby.refresh(context.Background()))
+	require.Equal(t, index, standby.Index())
+	require.Equal(t, "standby", leaseHolder(t, k8sClient, index))
+}
+
+func TestShard_ExpiredLease(t *testing.T) {
+	expired := metav1.NewMicroTime(time.Now().Add(-time.Minute))
+	lease := &coordinationv1.Lease{
+		ObjectMeta: metav1.ObjectMeta{Name: "shards-0", Namespace: "consul"},
+		Spec: coordinationv1.LeaseSpec{
+			HolderIdentity:       ptr.To("gone"),
+			LeaseDurationSeconds: ptr.To(int32(15)),
+			RenewTime:            &expired,
+			LeaseTransitions:     ptr.To(int32(1)),
+		},
+	}
+	configMap := &corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{Name: "shards", Namespace: "consul"},
+		Data:       map[string]string{"shards": "1"},
+	}
+	k8sClient := fake.NewClientBuilder().WithObjects(configMap, lease).Build()
+	shard := &Shard{
+		Reader:        k8sClient,
+		Writer:        k8sClient,
+		ConfigMapName: "shards",
+		Namespace:     "consul",
+		Identity:      "replica",
+		Log:           logrtest.New(t),
+	}
+	shard.init()
+
+	require.NoError(t, shard.refresh(context.Background()))
+	require.Equal(t, 0, shard.Index())
+	require.True(t, shard.Owns("any"))
+	var actual coordinationv1.Lease
+	require.NoError(t, k8sClient.Get(context.Background(), types.NamespacedName{Name: "shards-0", Namespace: "consul"}, &actual))
+	require.Equal(t, "replica", *actual.Spec.HolderIdentity)
+	require.Equal(t, int32(2), *actual.Spec.LeaseTransitions)
+
+	// Another replica taking over the Lease stops this one.
+	actual.Spec.HolderIdentity = ptr.To("other")
+	require.NoError(t, k8sClient.Update(context.Background(), &actual))
+	require.ErrorContains(t, shard.refresh(context.Background()), "lost the lease of shard 0 to another replica")
+}
+
+func TestShard_AssignmentChanged(t *testing.T) {
+	configMap := &corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{Name: "shards", Namespace: "consul"},
+		Data:       map[string]string{"shards": "2", "shard-0": "team-a", "shard-1": "team-b"},
+	}
+	k8sClient := fake.NewClientBuilder().WithObjects(configMap).Build()
+	shard := &Shard{
+		Reader:        k8sClient,
+		Writer:        k8sClient,
+		ConfigMapName: "shards",
+		Namespace:     "consul",
+		Identity:      "replica",
+		Log:           logrtest.New(t),
+	}
+	shard.init()
+	require.NoError(t, shard.refresh(context.Background()))
+	<-shard.events
+	index := shard.Index()
+	other := []string{"team-a", "team-b"}[1-index]
+	require.False(t, shard.Owns(other))
+
+	// Moving a namespace into the shard resyncs the controllers.
+	configMap.Data = map[string]string{"shards": "2", fmt.Sprintf("shard-%d", index): "team-a,team-b"}
+	require.NoError(t, k8sClient.Update(context.Background(), configMap))
+	require.NoError(t, shard.refresh(context.Background()))
+	require.True(t, shard.Owns(other))
+	require.Len(t, shard.events, 1)
+	<-shard.events
+
+	// An invalid assignment keeps the current one.
+	configMap.Data = map[string]string{"shards": "none"}
+	require.NoError(t, k8sClient.Update(context.Background(), configMap))
+	require.NoError(t, shard.refresh(context.Background()))
+	require.True(t, shard.Owns(other))
+	require.Empty(t, shard.events)
+
+	// Reducing the number of shards releases a shard that no longer exists.
+	configMap.Data = map[string]string{"shards": "1"}
+	require.NoError(t, k8sClient.Update(context.Background(), configMap))
+	require.NoError(t, shard.refresh(context.Background()))
+	require.Equal(t, 0, shard.Index())
+	require.True(t, shard.Owns("team-a"))
+	require.True(t, shard.Owns("team-b"))
+}
+
+func TestShard_RenewDeadline(t *testing.T) {
+	configMap := &corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{Name: "shards", Namespace: "consul"},
+		Data:       map[string]string{"shards": "1"},
+	}
+	k8sClient := fake.NewClientBuilder().WithObjects(configMap).Build()
+	shard := &Shard{
+		Reader:        k8sClient,
+		Writer:        k8sClient,
+		ConfigMapName: "shards",
+		Namespace:     "consul",
+		Identity:      "replica",
+		Log:           logrtest.New(t),
+	}
+	shard.init()
+	require.NoError(t, shard.refresh(context.Background()))
+	require.True(t, shard.Owns("team-a"))
+
+	// The replica stops owning its namespaces before its Lease expires.
+	shard.mu.Lock()
+	shard.renewed = time.Now().Add(-DefaultRenewDeadline - time.Second)
+	shard.mu.Unlock()
+	require.False(t, shard.Owns("team-a"))
+	require.Equal(t, 0, shard.Index())
+}
+
+func TestShard_HandOver(t *testing.T) {
+	configMap := &corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{Name: "shards", Namespace: "consul"},
+		Data:       map[string]string{"shards": "2"},
+	}
+	k8sClient := fake.NewClientBuilder().WithObjects(configMap).Build()
+	newShard := func(identity string) *Shard {
+		shard := &Shard{
+			Reader:        k8sClient,
+			Writer:        k8sClient,
+			ConfigMapName: "shards",
+			Namespace:     "consul",
+			Identity:      identity,
+			Log:           logrtest.New(t),
+		}
+		shard.init()
+		return shard
+	}
+	first, second := newShard("first"), newShard("second")
+	require.NoError(t, first.refresh(context.Background()))
+	require.NoError(t, second.refresh(context.Background()))
+
+	assign := func(firstNamespaces, secondNamespaces string) {
+		configMap.Data = map[string]string{
+			"shards":                                "2",
+			fmt.Sprintf("shard-%d", first.Index()):  firstNamespaces,
+			fmt.Sprintf("shard-%d", second.Index()): secondNamespaces,
+		}
+		require.NoError(t, k8sClient.Update(context.Background(), configMap))
+	}
+	assign("team-a", "team-b")
+	require.NoError(t, first.refresh(context.Background()))
+	require.NoError(t, second.refresh(context.Background()))
+	require.NoError(t, first.refresh(context.Background()))
+	require.True(t, first.Owns("team-a"))
+	require.True(t, second.Owns("team-b"))
+
+	// The replica gaining a namespace waits for the replica losing it to acknowledge the change.
+	assign("team-a,team-b", "")
+	require.NoError(t, first.refresh(context.Background()))
+	require.True(t, first.Owns("team-a"))
+	require.False(t, first.Owns("team-b"))
+	require.True(t, second.Owns("team-b"))
+
+	// The replica losing the namespace stops owning it before it acknowledges the change.
+	require.NoError(t, second.refresh(context.Background()))
+	require.False(t, second.Owns("team-b"))
+	require.False(t, first.Owns("team-b"))
+
+	require.NoError(t, first.refresh(context.Background()))
+	require.True(t, first.Owns("team-a"))
+	require.True(t, first.Owns("team-b"))
+}
+
+func leaseHolder(t *testing.T, k8sClient client.Client, index int) string {
+	t.Helper()
+	var lease coordinationv1.Lease
+	require.NoError(t, k8sClient.Get(context.Background(), types.NamespacedName{Name: fmt.Sprintf("shards-%d", index), Namespace: "consul"}, &lease))
+	return ptr.Deref(lease.Spec.HolderIdentity, "")
+}
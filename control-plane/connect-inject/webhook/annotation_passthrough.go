@@ -0,0 +1,52 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package webhook
+
+import (
+	"sort"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/util/validation"
+)
+
+// annotationPassthroughEnvVars returns the environment variables of the injected containers that are
+// set to the values of the pod annotations in AnnotationsToEnv, sorted by name so that the containers
+// don't change between admissions. Annotations the pod doesn't have are skipped.
+func (w *MeshWebhook) annotationPassthroughEnvVars(pod corev1.Pod) []corev1.EnvVar {
+	var envVars []corev1.EnvVar
+	for annotation, name := range w.AnnotationsToEnv {
+		if value, ok := pod.Annotations[annotation]; ok {
+			envVars = append(envVars, corev1.EnvVar{Name: name, Value: value})
+		}
+	}
+	sort.Slice(envVars, func(i, j int) bool {
+		return envVars[i].Name < envVars[j].Name
+	})
+	return envVars
+}
+
+// passthroughAnnotationLabels adds the labels in AnnotationsToLabels to the pod with the values of its
+// annotations. The labels the pod already has are not overwritten, and annotations whose values aren't
+// valid label values are skipped.
+func (w *MeshWebhook) passthroughAnnotationLabels(pod *corev1.Pod) {
+	for annotation, label := range w.AnnotationsToLabels {
+		value, ok := pod.Annotations[annotation]
+		if !ok {
+			continue
+		}
+		if _, ok := pod.Labels[label]; ok {
+			continue
+		}
+		if errs := validation.IsValidLabelValue(value); len(errs) > 0 {
+			w.Log.Info("skipping annotation that isn't a valid label value", "annotation", annotation,
+				"label", label, "reason", strings.Join(errs, "; "))
+			continue
+		}
+		if pod.Labels == nil {
+			pod.Labels = make(map[string]string)
+		}
+		pod.Labels[label] = value
+	}
+}
@@ -0,0 +1,73 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package webhook
+
+import (
+	"testing"
+
+	logrtest "github.com/go-logr/logr/testr"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestAnnotationPassthroughEnvVars(t *testing.T) {
+	w := MeshWebhook{
+		AnnotationsToEnv: map[string]string{
+			"example.com/trace-tag":   "TRACE_TAG",
+			"example.com/cost-center": "COST_CENTER",
+			"example.com/team":        "TEAM",
+		},
+	}
+	pod := corev1.Pod{ObjectMeta: metav1.ObjectMeta{Annotations: map[string]string{
+		"example.com/trace-tag":   "checkout",
+		"example.com/cost-center": "cc-42",
+		"example.com/other":       "not copied",
+	}}}
+
+	require.Equal(t, []corev1.EnvVar{
+		{Name: "COST_CENTER", Value: "cc-42"},
+		{Name: "TRACE_TAG", Value: "checkout"},
+	}, w.annotationPassthroughEnvVars(pod))
+
+	require.Empty(t, (&MeshWebhook{}).annotationPassthroughEnvVars(pod))
+}
+
+func TestPassthroughAnnotationLabels(t *testing.T) {
+	cases := map[string]struct {
+		annotations map[string]string
+		labels      map[string]string
+		expLabels   map[string]string
+	}{
+		"no annotations": {
+			labels:    map[string]string{"app": "web"},
+			expLabels: map[string]string{"app": "web"},
+		},
+		"annotations are copied into labels": {
+			annotations: map[string]string{"example.com/cost-center": "cc-42", "example.com/other": "not copied"},
+			expLabels:   map[string]string{"cost-center": "cc-42"},
+		},
+		"existing labels are not overwritten": {
+			annotations: map[string]string{"example.com/cost-center": "cc-42"},
+			labels:      map[string]string{"cost-center": "cc-7"},
+			expLabels:   map[string]string{"cost-center": "cc-7"},
+		},
+		"invalid label values are skipped": {
+			annotations: map[string]string{"example.com/cost-center": "cost center 42"},
+			labels:      map[string]string{"app": "web"},
+			expLabels:   map[string]string{"app": "web"},
+		},
+	}
+	for name, c := range cases {
+		t.Run(name, func(t *testing.T) {
+			w := MeshWebhook{
+				AnnotationsToLabels: map[string]string{"example.com/cost-center": "cost-center"},
+				Log:                 logrtest.New(t),
+			}
+			pod := corev1.Pod{ObjectMeta: metav1.ObjectMeta{Annotations: c.annotations, Labels: c.labels}}
+			w.passthroughAnnotationLabels(&pod)
+			require.Equal(t, c.expLabels, pod.Labels)
+		})
+	}
+}
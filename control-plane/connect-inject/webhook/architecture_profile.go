@@ -0,0 +1,64 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package webhook
+
+import (
+	corev1 "k8s.io/api/core/v1"
+)
+
+// ArchitectureProfile configures the sidecars of pods that can only be scheduled on nodes of a CPU
+// architecture, e.g. arm64, so that clusters with nodes of several architectures don't need an
+// annotation on every workload.
+type ArchitectureProfile struct {
+	// Image is the consul-dataplane image of the sidecars. The default image is used if it is empty.
+	Image string `json:"image"`
+	// ImagePullSecrets are added to the pods that use Image.
+	ImagePullSecrets []corev1.LocalObjectReference `json:"image_pull_secrets"`
+	// Resources are the default resources of the sidecars. Each request and limit that is set replaces
+	// the default of the injector; the resource annotations of a pod still take precedence.
+	Resources corev1.ResourceRequirements `json:"resources"`
+}
+
+// architectureProfile returns the profile of the architecture that the pod is restricted to by its
+// node selector or required node affinity, or nil if it isn't restricted to a single architecture
+// or there is no profile for it.
+func (w *MeshWebhook) architectureProfile(pod corev1.Pod) *ArchitectureProfile {
+	if len(w.ArchitectureProfiles) == 0 {
+		return nil
+	}
+	profile, ok := w.ArchitectureProfiles[podArchitecture(pod)]
+	if !ok {
+		return nil
+	}
+	return &profile
+}
+
+// podArchitecture returns the only CPU architecture of the nodes that the pod can be scheduled on,
+// or "" if the pod can be scheduled on nodes of several or any architectures.
+func podArchitecture(pod corev1.Pod) string {
+	if arch, ok := pod.Spec.NodeSelector[corev1.LabelArchStable]; ok {
+		return arch
+	}
+
+	affinity := pod.Spec.Affinity
+	if affinity == nil || affinity.NodeAffinity == nil || affinity.NodeAffinity.RequiredDuringSchedulingIgnoredDuringExecution == nil {
+		return ""
+	}
+	// The node selector terms are ORed, so every term must restrict the pod to the same architecture.
+	arch := ""
+	terms := affinity.NodeAffinity.RequiredDuringSchedulingIgnoredDuringExecution.NodeSelectorTerms
+	for _, term := range terms {
+		termArch := ""
+		for _, expr := range term.MatchExpressions {
+			if expr.Key == corev1.LabelArchStable && expr.Operator == corev1.NodeSelectorOpIn && len(expr.Values) == 1 {
+				termArch = expr.Values[0]
+			}
+		}
+		if termArch == "" || (arch != "" && termArch != arch) {
+			return ""
+		}
+		arch = termArch
+	}
+	return arch
+}
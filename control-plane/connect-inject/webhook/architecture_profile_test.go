@@ -0,0 +1,80 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package webhook
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+)
+
+func TestPodArchitecture(t *testing.T) {
+	archTerm := func(op corev1.NodeSelectorOperator, values ...string) corev1.NodeSelectorTerm {
+		return corev1.NodeSelectorTerm{
+			MatchExpressions: []corev1.NodeSelectorRequirement{
+				{Key: "topology.kubernetes.io/zone", Operator: corev1.NodeSelectorOpIn, Values: []string{"us-east-1a"}},
+				{Key: corev1.LabelArchStable, Operator: op, Values: values},
+			},
+		}
+	}
+	affinity := func(terms ...corev1.NodeSelectorTerm) *corev1.Affinity {
+		return &corev1.Affinity{
+			NodeAffinity: &corev1.NodeAffinity{
+				RequiredDuringSchedulingIgnoredDuringExecution: &corev1.NodeSelector{NodeSelectorTerms: terms},
+			},
+		}
+	}
+
+	cases := map[string]struct {
+		spec    corev1.PodSpec
+		expArch string
+	}{
+		"unrestricted": {
+			spec: corev1.PodSpec{},
+		},
+		"node selector": {
+			spec:    corev1.PodSpec{NodeSelector: map[string]string{corev1.LabelArchStable: "arm64"}},
+			expArch: "arm64",
+		},
+		"node selector of another label": {
+			spec: corev1.PodSpec{NodeSelector: map[string]string{corev1.LabelOSStable: "linux"}},
+		},
+		"required node affinity": {
+			spec:    corev1.PodSpec{Affinity: affinity(archTerm(corev1.NodeSelectorOpIn, "arm64"))},
+			expArch: "arm64",
+		},
+		"required node affinity with the same architecture in every term": {
+			spec:    corev1.PodSpec{Affinity: affinity(archTerm(corev1.NodeSelectorOpIn, "arm64"), archTerm(corev1.NodeSelectorOpIn, "arm64"))},
+			expArch: "arm64",
+		},
+		"required node affinity with several architectures": {
+			spec: corev1.PodSpec{Affinity: affinity(archTerm(corev1.NodeSelectorOpIn, "amd64", "arm64"))},
+		},
+		"required node affinity with different architectures in its terms": {
+			spec: corev1.PodSpec{Affinity: affinity(archTerm(corev1.NodeSelectorOpIn, "arm64"), archTerm(corev1.NodeSelectorOpIn, "amd64"))},
+		},
+		"required node affinity with a term that doesn't restrict the architecture": {
+			spec: corev1.PodSpec{Affinity: affinity(archTerm(corev1.NodeSelectorOpIn, "arm64"), corev1.NodeSelectorTerm{})},
+		},
+		"required node affinity excluding an architecture": {
+			spec: corev1.PodSpec{Affinity: affinity(archTerm(corev1.NodeSelectorOpNotIn, "amd64"))},
+		},
+		"preferred node affinity": {
+			spec: corev1.PodSpec{Affinity: &corev1.Affinity{
+				NodeAffinity: &corev1.NodeAffinity{
+					PreferredDuringSchedulingIgnoredDuringExecution: []corev1.PreferredSchedulingTerm{
+						{Weight: 1, Preference: archTerm(corev1.NodeSelectorOpIn, "arm64")},
+					},
+				},
+			}},
+		},
+	}
+
+	for name, c := range cases {
+		t.Run(name, func(t *testing.T) {
+			require.Equal(t, c.expArch, podArchitecture(corev1.Pod{Spec: c.spec}))
+		})
+	}
+}
@@ -28,12 +28,20 @@ func (w *MeshWebhook) consulDataplaneSidecar(namespace corev1.Namespace, pod cor
 	if err != nil {
 		return corev1.Container{}, err
 	}
+	if err := w.autoSizeSidecarResources(namespace, pod, &resources); err != nil {
+		return corev1.Container{}, err
+	}
+
+	image, err := w.consulDataplaneImage(namespace, pod)
+	if err != nil {
+		return corev1.Container{}, err
+	}
 
 	// Extract the service account token's volume mount.
 	var bearerTokenFile string
 	var saTokenVolumeMount corev1.VolumeMount
 	if w.AuthMethod != "" {
-		saTokenVolumeMount, bearerTokenFile, err = findServiceAccountVolumeMount(pod, mpi.serviceName)
+		saTokenVolumeMount, bearerTokenFile, err = w.loginTokenVolumeMount(pod, mpi.serviceName)
 		if err != nil {
 			return corev1.Container{}, err
 		}
@@ -97,7 +105,7 @@ func (w *MeshWebhook) consulDataplaneSidecar(namespace corev1.Namespace, pod cor
 
 	container := corev1.Container{
 		Name:            containerName,
-		Image:           w.ImageConsulDataplane,
+		Image:           image,
 		ImagePullPolicy: corev1.PullPolicy(w.GlobalImagePullPolicy),
 		Resources:       resources,
 		// We need to set tmp dir to an ephemeral volume that we're mounting so that
@@ -118,7 +126,7 @@ func (w *MeshWebhook) consulDataplaneSidecar(namespace corev1.Namespace, pod cor
 			},
 			{
 				Name:  "DP_SERVICE_NODE_NAME",
-				Value: "$(NODE_NAME)-virtual",
+				Value: w.NodeNamingStrategy.ConsulNodeNameEnv(namespace.Name),
 			},
 			// The pod name isn't known currently, so we must rely on the environment variable to fill it in rather than using args.
 			{
@@ -172,10 +180,29 @@ func (w *MeshWebhook) consulDataplaneSidecar(namespace corev1.Namespace, pod cor
 		LivenessProbe:  livenessProbe,
 	}
 
+	// Keep the proxy running while the application containers drain their in-flight requests.
+	preStopDrainHook, err := w.preStopDrainHook(pod)
+	if err != nil {
+		return corev1.Container{}, fmt.Errorf("unable to determine proxy lifecycle pre-stop drain duration: %w", err)
+	}
+	container.Lifecycle = preStopDrainHook
+
 	if w.AuthMethod != "" {
 		container.VolumeMounts = append(container.VolumeMounts, saTokenVolumeMount)
 	}
 
+	dataplaneLogin, err := w.useDataplaneLogin(namespace, pod)
+	if err != nil {
+		return corev1.Container{}, err
+	}
+	if dataplaneLogin && w.TLSEnabled && w.ConsulCACert != "" {
+		container.VolumeMounts = append(container.VolumeMounts, corev1.VolumeMount{
+			Name:      caCertVolumeName,
+			MountPath: caCertMountPath,
+			ReadOnly:  true,
+		})
+	}
+
 	if useProxyHealthCheck(pod) {
 		// Configure the Readiness Address for the proxy's health check to be the Pod IP.
 		container.Env = append(container.Env, corev1.EnvVar{
@@ -201,6 +228,20 @@ func (w *MeshWebhook) consulDataplaneSidecar(namespace corev1.Namespace, pod cor
 		container.VolumeMounts = append(container.VolumeMounts, volumeMounts...)
 	}
 
+	// Mount the CA certificate used to scrape the service metrics over TLS and trust it.
+	metricsTLSSecret, err := w.serviceMetricsTLSSecret(pod)
+	if err != nil {
+		return corev1.Container{}, err
+	}
+	if metricsTLSSecret != "" {
+		container.VolumeMounts = append(container.VolumeMounts, corev1.VolumeMount{
+			Name:      serviceMetricsTLSVolumeName,
+			MountPath: serviceMetricsTLSMountPath,
+			ReadOnly:  true,
+		})
+		container.Env = append(container.Env, corev1.EnvVar{Name: "SSL_CERT_DIR", Value: serviceMetricsCertDirs})
+	}
+
 	// Container Ports
 	metricsPorts, err := w.getMetricsPorts(pod)
 	if err != nil {
@@ -238,7 +279,7 @@ func (w *MeshWebhook) consulDataplaneSidecar(namespace corev1.Namespace, pod cor
 				// User container and consul-dataplane container cannot have the same UID.
 				if c.SecurityContext != nil && c.SecurityContext.RunAsUser != nil &&
 					*c.SecurityContext.RunAsUser == sidecarUserAndGroupID &&
-					c.Image != w.ImageConsulDataplane {
+					c.Image != image {
 					return corev1.Container{}, fmt.Errorf("container %q has runAsUser set to the same UID \"%d\" as consul-dataplane which is not allowed", c.Name, sidecarUserAndGroupID)
 				}
 			}
@@ -249,11 +290,11 @@ func (w *MeshWebhook) consulDataplaneSidecar(namespace corev1.Namespace, pod cor
 		// Transparent proxy is set in OpenShift. There is an annotation on the namespace that tells us what
 		// the user and group ids should be for the sidecar.
 		var err error
-		uid, err = common.GetDataplaneUID(namespace, pod, w.ImageConsulDataplane, w.ImageConsulK8S)
+		uid, err = common.GetDataplaneUID(namespace, pod, image, w.ImageConsulK8S)
 		if err != nil {
 			return corev1.Container{}, err
 		}
-		group, err = common.GetDataplaneGroupID(namespace, pod, w.ImageConsulDataplane, w.ImageConsulK8S)
+		group, err = common.GetDataplaneGroupID(namespace, pod, image, w.ImageConsulK8S)
 		if err != nil {
 			return corev1.Container{}, err
 		}
@@ -271,6 +312,13 @@ func (w *MeshWebhook) consulDataplaneSidecar(namespace corev1.Namespace, pod cor
 		},
 		ReadOnlyRootFilesystem: ptr.To(true),
 	}
+	if w.EnableOpenShift {
+		// Match the restricted-v2 SCC so that pods can be admitted without granting them a more
+		// privileged SCC. NET_BIND_SERVICE is the only capability restricted-v2 allows to be added.
+		container.SecurityContext.Capabilities.Drop = []corev1.Capability{"ALL"}
+		container.SecurityContext.SeccompProfile = &corev1.SeccompProfile{Type: corev1.SeccompProfileTypeRuntimeDefault}
+	}
+	container.Env = append(container.Env, w.annotationPassthroughEnvVars(pod)...)
 	return container, nil
 }
 
@@ -291,10 +339,24 @@ func (w *MeshWebhook) getContainerSidecarArgs(namespace corev1.Namespace, mpi mu
 		envoyConcurrency = int(val)
 	}
 
+	dataplaneLogin, err := w.useDataplaneLogin(namespace, pod)
+	if err != nil {
+		return nil, err
+	}
+
+	proxyIDArg := "-proxy-service-id-path=" + proxyIDFileName
+	if w.EnableResourceAPIs {
+		// With resource APIs the proxy is identified by the workload, which is named after the pod.
+		proxyIDArg = "-proxy-id=$(POD_NAME)"
+	} else if dataplaneLogin {
+		// Without the init container, there is no file with the ID of the proxy registration.
+		proxyIDArg = "-proxy-service-id=" + dataplaneProxyServiceID(pod, mpi)
+	}
+
 	args := []string{
 		"-addresses", w.ConsulAddress,
 		"-grpc-port=" + strconv.Itoa(w.ConsulConfig.GRPCPort),
-		"-proxy-service-id-path=" + proxyIDFileName,
+		proxyIDArg,
 		"-log-level=" + w.LogLevel,
 		"-log-json=" + strconv.FormatBool(w.LogJSON),
 		"-envoy-concurrency=" + strconv.Itoa(envoyConcurrency),
@@ -304,6 +366,8 @@ func (w *MeshWebhook) getContainerSidecarArgs(namespace corev1.Namespace, mpi mu
 		args = append(args, "-server-watch-disabled=true")
 	}
 
+	partition := w.consulPartition(namespace.Name)
+
 	if w.AuthMethod != "" {
 		args = append(args,
 			"-credential-type=login",
@@ -318,21 +382,23 @@ func (w *MeshWebhook) getContainerSidecarArgs(namespace corev1.Namespace, mpi mu
 				args = append(args, "-login-namespace="+w.consulNamespace(namespace.Name))
 			}
 		}
-		if w.ConsulPartition != "" {
-			args = append(args, "-login-partition="+w.ConsulPartition)
+		if partition != "" {
+			args = append(args, "-login-partition="+partition)
 		}
 	}
 	if w.EnableNamespaces {
-		args = append(args, "-service-namespace="+w.consulNamespace(namespace.Name))
+		args = append(args, "-service-namespace="+w.podConsulNamespace(pod, namespace.Name))
 	}
-	if w.ConsulPartition != "" {
-		args = append(args, "-service-partition="+w.ConsulPartition)
+	if partition != "" {
+		args = append(args, "-service-partition="+partition)
 	}
 	if w.TLSEnabled {
 		if w.ConsulTLSServerName != "" {
 			args = append(args, "-tls-server-name="+w.ConsulTLSServerName)
 		}
-		if w.ConsulCACert != "" {
+		if w.ConsulCACert != "" && dataplaneLogin {
+			args = append(args, "-ca-certs="+caCertMountPath+"/"+caCertPath)
+		} else if w.ConsulCACert != "" {
 			args = append(args, "-ca-certs="+constants.LegacyConsulCAFile)
 		}
 	} else {
@@ -365,12 +431,20 @@ func (w *MeshWebhook) getContainerSidecarArgs(namespace corev1.Namespace, mpi mu
 	if err != nil {
 		return nil, fmt.Errorf("unable to determine if proxy lifecycle management is enabled: %w", err)
 	}
+	preStopDrainSeconds, err := w.LifecycleConfig.PreStopDrainSeconds(pod)
+	if err != nil {
+		return nil, fmt.Errorf("unable to determine proxy lifecycle pre-stop drain duration: %w", err)
+	}
+	terminationDrainSeconds, err := w.terminationDrainSeconds(namespace, pod)
+	if err != nil {
+		return nil, fmt.Errorf("unable to determine sidecar proxy termination drain duration: %w", err)
+	}
 	if enableProxyLifecycle {
 		shutdownDrainListeners, err := w.LifecycleConfig.EnableShutdownDrainListeners(pod)
 		if err != nil {
 			return nil, fmt.Errorf("unable to determine if proxy lifecycle shutdown listener draining is enabled: %w", err)
 		}
-		if shutdownDrainListeners {
+		if shutdownDrainListeners || preStopDrainSeconds > 0 || terminationDrainSeconds > 0 {
 			args = append(args, "-shutdown-drain-listeners")
 		}
 
@@ -378,6 +452,8 @@ func (w *MeshWebhook) getContainerSidecarArgs(namespace corev1.Namespace, mpi mu
 		if err != nil {
 			return nil, fmt.Errorf("unable to determine proxy lifecycle shutdown grace period: %w", err)
 		}
+		// Keep the proxy up for at least the termination drain duration.
+		shutdownGracePeriodSeconds = max(shutdownGracePeriodSeconds, terminationDrainSeconds)
 		args = append(args, fmt.Sprintf("-shutdown-grace-period-seconds=%d", shutdownGracePeriodSeconds))
 
 		gracefulShutdownPath := w.LifecycleConfig.GracefulShutdownPath(pod)
@@ -391,6 +467,12 @@ func (w *MeshWebhook) getContainerSidecarArgs(namespace corev1.Namespace, mpi mu
 
 		gracefulStartupPath := w.LifecycleConfig.GracefulStartupPath(pod)
 		args = append(args, fmt.Sprintf("-graceful-startup-path=%s", gracefulStartupPath))
+	} else if preStopDrainSeconds > 0 || terminationDrainSeconds > 0 {
+		// Drain the listeners once the pre-stop drain hook completes even without proxy lifecycle management.
+		args = append(args, "-shutdown-drain-listeners")
+		if terminationDrainSeconds > 0 {
+			args = append(args, fmt.Sprintf("-shutdown-grace-period-seconds=%d", terminationDrainSeconds))
+		}
 	}
 
 	// Set a default scrape path that can be overwritten by the annotation.
@@ -414,8 +496,13 @@ func (w *MeshWebhook) getContainerSidecarArgs(namespace corev1.Namespace, mpi mu
 			return nil, fmt.Errorf("unable to determine if service metrics port: %w", err)
 		}
 
+		serviceMetricsScheme, err := w.MetricsConfig.ServiceMetricsScheme(pod)
+		if err != nil {
+			return nil, fmt.Errorf("unable to determine service metrics scheme: %w", err)
+		}
+
 		if serviceMetricsPath != "" && serviceMetricsPort != "" {
-			args = append(args, "-telemetry-prom-service-metrics-url="+fmt.Sprintf("http://127.0.0.1:%s%s", serviceMetricsPort, serviceMetricsPath))
+			args = append(args, "-telemetry-prom-service-metrics-url="+fmt.Sprintf("%s://127.0.0.1:%s%s", serviceMetricsScheme, serviceMetricsPort, serviceMetricsPath))
 		}
 
 		// Pull the TLS config from the relevant annotations.
@@ -460,12 +547,14 @@ func (w *MeshWebhook) getContainerSidecarArgs(namespace corev1.Namespace, mpi mu
 
 	// If Consul DNS is enabled, we want to configure consul-dataplane to be the DNS proxy
 	// for Consul DNS in the pod.
-	dnsEnabled, err := consulDNSEnabled(namespace, pod, w.EnableConsulDNS, w.EnableTransparentProxy)
+	dnsEnabled, dnsMode, err := w.consulDNS(namespace, pod)
 	if err != nil {
 		return nil, err
 	}
-	if dnsEnabled {
-		args = append(args, "-consul-dns-bind-port="+strconv.Itoa(consulDataplaneDNSBindPort))
+	// For multi port pods, only the first consul-dataplane serves DNS since all of them share
+	// the pod's network namespace.
+	if dnsEnabled && mpi.serviceIndex == 0 {
+		args = append(args, "-consul-dns-bind-port="+strconv.Itoa(consulDataplaneDNSPort(dnsMode)))
 	}
 
 	var envoyExtraArgs []string
@@ -505,6 +594,28 @@ func (w *MeshWebhook) getContainerSidecarArgs(namespace corev1.Namespace, mpi mu
 	return args, nil
 }
 
+// defaultSidecarResources returns the default limits and requests of the sidecar proxy. The pod's
+// architecture profile replaces the defaults that it sets.
+func (w *MeshWebhook) defaultSidecarResources(pod corev1.Pod) (cpuLimit, cpuRequest, memoryLimit, memoryRequest resource.Quantity) {
+	cpuLimit, cpuRequest = w.DefaultProxyCPULimit, w.DefaultProxyCPURequest
+	memoryLimit, memoryRequest = w.DefaultProxyMemoryLimit, w.DefaultProxyMemoryRequest
+	if profile := w.architectureProfile(pod); profile != nil {
+		if q, ok := profile.Resources.Limits[corev1.ResourceCPU]; ok {
+			cpuLimit = q
+		}
+		if q, ok := profile.Resources.Requests[corev1.ResourceCPU]; ok {
+			cpuRequest = q
+		}
+		if q, ok := profile.Resources.Limits[corev1.ResourceMemory]; ok {
+			memoryLimit = q
+		}
+		if q, ok := profile.Resources.Requests[corev1.ResourceMemory]; ok {
+			memoryRequest = q
+		}
+	}
+	return cpuLimit, cpuRequest, memoryLimit, memoryRequest
+}
+
 func (w *MeshWebhook) sidecarResources(pod corev1.Pod) (corev1.ResourceRequirements, error) {
 	resources := corev1.ResourceRequirements{
 		Limits:   corev1.ResourceList{},
@@ -514,6 +625,8 @@ func (w *MeshWebhook) sidecarResources(pod corev1.Pod) (corev1.ResourceRequireme
 	// set.
 	var zeroQuantity resource.Quantity
 
+	defaultCPULimit, defaultCPURequest, defaultMemoryLimit, defaultMemoryRequest := w.defaultSidecarResources(pod)
+
 	// NOTE: We only want to set the limit/request if the default or annotation
 	// was explicitly set. If it's not explicitly set, it will be the zero value
 	// which would show up in the pod spec as being explicitly set to zero if we
@@ -531,8 +644,8 @@ func (w *MeshWebhook) sidecarResources(pod corev1.Pod) (corev1.ResourceRequireme
 			return corev1.ResourceRequirements{}, fmt.Errorf("parsing annotation %s:%q: %s", constants.AnnotationSidecarProxyCPULimit, anno, err)
 		}
 		resources.Limits[corev1.ResourceCPU] = cpuLimit
-	} else if w.DefaultProxyCPULimit != zeroQuantity {
-		resources.Limits[corev1.ResourceCPU] = w.DefaultProxyCPULimit
+	} else if defaultCPULimit != zeroQuantity {
+		resources.Limits[corev1.ResourceCPU] = defaultCPULimit
 	}
 
 	// CPU Request.
@@ -542,8 +655,8 @@ func (w *MeshWebhook) sidecarResources(pod corev1.Pod) (corev1.ResourceRequireme
 			return corev1.ResourceRequirements{}, fmt.Errorf("parsing annotation %s:%q: %s", constants.AnnotationSidecarProxyCPURequest, anno, err)
 		}
 		resources.Requests[corev1.ResourceCPU] = cpuRequest
-	} else if w.DefaultProxyCPURequest != zeroQuantity {
-		resources.Requests[corev1.ResourceCPU] = w.DefaultProxyCPURequest
+	} else if defaultCPURequest != zeroQuantity {
+		resources.Requests[corev1.ResourceCPU] = defaultCPURequest
 	}
 
 	// Memory Limit.
@@ -553,8 +666,8 @@ func (w *MeshWebhook) sidecarResources(pod corev1.Pod) (corev1.ResourceRequireme
 			return corev1.ResourceRequirements{}, fmt.Errorf("parsing annotation %s:%q: %s", constants.AnnotationSidecarProxyMemoryLimit, anno, err)
 		}
 		resources.Limits[corev1.ResourceMemory] = memoryLimit
-	} else if w.DefaultProxyMemoryLimit != zeroQuantity {
-		resources.Limits[corev1.ResourceMemory] = w.DefaultProxyMemoryLimit
+	} else if defaultMemoryLimit != zeroQuantity {
+		resources.Limits[corev1.ResourceMemory] = defaultMemoryLimit
 	}
 
 	// Memory Request.
@@ -564,8 +677,8 @@ func (w *MeshWebhook) sidecarResources(pod corev1.Pod) (corev1.ResourceRequireme
 			return corev1.ResourceRequirements{}, fmt.Errorf("parsing annotation %s:%q: %s", constants.AnnotationSidecarProxyMemoryRequest, anno, err)
 		}
 		resources.Requests[corev1.ResourceMemory] = memoryRequest
-	} else if w.DefaultProxyMemoryRequest != zeroQuantity {
-		resources.Requests[corev1.ResourceMemory] = w.DefaultProxyMemoryRequest
+	} else if defaultMemoryRequest != zeroQuantity {
+		resources.Requests[corev1.ResourceMemory] = defaultMemoryRequest
 	}
 
 	return resources, nil
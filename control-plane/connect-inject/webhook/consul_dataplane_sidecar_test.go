@@ -16,6 +16,7 @@ import (
 	"k8s.io/apimachinery/pkg/util/intstr"
 	"k8s.io/utils/ptr"
 
+	"github.com/hashicorp/consul-k8s/control-plane/connect-inject/common"
 	"github.com/hashicorp/consul-k8s/control-plane/connect-inject/constants"
 	"github.com/hashicorp/consul-k8s/control-plane/connect-inject/lifecycle"
 	"github.com/hashicorp/consul-k8s/control-plane/connect-inject/metrics"
@@ -115,6 +116,22 @@ func TestHandlerConsulDataplaneSidecar(t *testing.T) {
 			},
 			additionalExpCmdArgs: " -service-partition=partition-1 -tls-disabled -graceful-port=20600 -telemetry-prom-scrape-path=/metrics",
 		},
+		"with ACLs and partition mapping": {
+			webhookSetupFunc: func(w *MeshWebhook) {
+				w.AuthMethod = "test-auth-method"
+				w.ConsulPartition = "test-part"
+				w.PartitionMapping = testPartitionMapping(t, "test-part", map[string]string{k8sNamespace: "mapped-part"})
+			},
+			additionalExpCmdArgs: " -credential-type=login -login-auth-method=test-auth-method -login-bearer-token-path=/var/run/secrets/kubernetes.io/serviceaccount/token " +
+				"-login-partition=mapped-part -service-partition=mapped-part -tls-disabled -graceful-port=20600 -telemetry-prom-scrape-path=/metrics",
+		},
+		"with partition mapping and unmapped namespace": {
+			webhookSetupFunc: func(w *MeshWebhook) {
+				w.ConsulPartition = "test-part"
+				w.PartitionMapping = testPartitionMapping(t, "test-part", map[string]string{"other-namespace": "mapped-part"})
+			},
+			additionalExpCmdArgs: " -service-partition=test-part -tls-disabled -graceful-port=20600 -telemetry-prom-scrape-path=/metrics",
+		},
 		"with different log level": {
 			webhookSetupFunc: func(w *MeshWebhook) {
 				w.LogLevel = "debug"
@@ -303,6 +320,90 @@ func TestHandlerConsulDataplaneSidecar_Concurrency(t *testing.T) {
 	}
 }
 
+// Test that consul-dataplane looks up its service on the node of the node naming strategy, and that
+// the strategy is recorded on the pod for the endpoints controller.
+func TestHandlerConsulDataplaneSidecar_NodeNamingStrategy(t *testing.T) {
+	cases := map[string]struct {
+		strategy      common.NodeNamingStrategy
+		expNodeName   string
+		expAnnotation string
+	}{
+		"default": {
+			expNodeName: "$(NODE_NAME)-virtual",
+		},
+		"per-node": {
+			strategy:    common.NodeNamingPerNode,
+			expNodeName: "$(NODE_NAME)-virtual",
+		},
+		"per-cluster": {
+			strategy:      common.NodeNamingPerCluster,
+			expNodeName:   "k8s-cluster-virtual",
+			expAnnotation: "per-cluster",
+		},
+		"per-namespace": {
+			strategy:      common.NodeNamingPerNamespace,
+			expNodeName:   fmt.Sprintf("k8s-namespace-%s-virtual", testNS.Name),
+			expAnnotation: "per-namespace",
+		},
+	}
+	for name, c := range cases {
+		t.Run(name, func(t *testing.T) {
+			h := MeshWebhook{
+				ConsulConfig:       &consul.Config{HTTPPort: 8500, GRPCPort: 8502},
+				NodeNamingStrategy: c.strategy,
+			}
+			pod := corev1.Pod{
+				Spec: corev1.PodSpec{
+					Containers: []corev1.Container{
+						{
+							Name: "web",
+						},
+					},
+				},
+			}
+			require.NoError(t, h.defaultAnnotations(&pod, "{}"))
+			require.Equal(t, c.expAnnotation, pod.Annotations[constants.AnnotationNodeNamingStrategy])
+
+			container, err := h.consulDataplaneSidecar(testNS, pod, multiPortInfo{})
+			require.NoError(t, err)
+			require.Equal(t, "DP_SERVICE_NODE_NAME", container.Env[2].Name)
+			require.Equal(t, c.expNodeName, container.Env[2].Value)
+		})
+	}
+}
+
+// Test that consul-dataplane logs in with the projected, audience-bound token when a login token audience is set.
+func TestHandlerConsulDataplaneSidecar_LoginTokenAudience(t *testing.T) {
+	h := MeshWebhook{
+		ConsulConfig:       &consul.Config{HTTPPort: 8500, GRPCPort: 8502},
+		AuthMethod:         "test-auth-method",
+		LoginTokenAudience: "consul",
+	}
+	pod := corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{
+			Annotations: map[string]string{
+				constants.AnnotationService: "foo",
+			},
+		},
+		Spec: corev1.PodSpec{
+			Containers: []corev1.Container{
+				{
+					Name: "web",
+				},
+			},
+		},
+	}
+	container, err := h.consulDataplaneSidecar(testNS, pod, multiPortInfo{})
+	require.NoError(t, err)
+	require.Contains(t, strings.Join(container.Args, " "),
+		"-credential-type=login -login-auth-method=test-auth-method -login-bearer-token-path=/consul/login-token/token")
+	require.Contains(t, container.VolumeMounts, corev1.VolumeMount{
+		Name:      loginTokenVolumeName,
+		ReadOnly:  true,
+		MountPath: "/consul/login-token",
+	})
+}
+
 // Test that we pass the dns proxy flag to dataplane correctly.
 func TestHandlerConsulDataplaneSidecar_DNSProxy(t *testing.T) {
 	// We only want the flag passed when DNS and tproxy are both enabled. DNS/tproxy can
@@ -417,6 +518,55 @@ func TestHandlerConsulDataplaneSidecar_DNSProxy(t *testing.T) {
 	}
 }
 
+// Test that with the dns-config redirection mode the DNS proxy binds to the standard
+// DNS port and does not require transparent proxy.
+func TestHandlerConsulDataplaneSidecar_DNSProxyDNSConfigMode(t *testing.T) {
+	cases := map[string]struct {
+		globalMode string
+		podMode    string
+		expArg     string
+	}{
+		"global mode": {
+			globalMode: constants.DNSRedirectionModeDNSConfig,
+			expArg:     "-consul-dns-bind-port=53",
+		},
+		"pod annotation overrides global mode": {
+			globalMode: constants.DNSRedirectionModeIPTables,
+			podMode:    constants.DNSRedirectionModeDNSConfig,
+			expArg:     "-consul-dns-bind-port=53",
+		},
+	}
+	for name, c := range cases {
+		t.Run(name, func(t *testing.T) {
+			h := MeshWebhook{
+				ConsulConfig:             &consul.Config{HTTPPort: 8500, GRPCPort: 8502},
+				EnableTransparentProxy:   false,
+				EnableConsulDNS:          true,
+				ConsulDNSRedirectionMode: c.globalMode,
+			}
+			pod := corev1.Pod{
+				ObjectMeta: metav1.ObjectMeta{
+					Annotations: map[string]string{},
+				},
+				Spec: corev1.PodSpec{
+					Containers: []corev1.Container{
+						{
+							Name: "web",
+						},
+					},
+				},
+			}
+			if c.podMode != "" {
+				pod.Annotations[constants.AnnotationConsulDNSRedirectionMode] = c.podMode
+			}
+
+			container, err := h.consulDataplaneSidecar(testNS, pod, multiPortInfo{})
+			require.NoError(t, err)
+			require.Contains(t, container.Args, c.expArg)
+		})
+	}
+}
+
 func TestHandlerConsulDataplaneSidecar_ProxyHealthCheck(t *testing.T) {
 	tests := map[string]struct {
 		changeHook        func(*MeshWebhook)
@@ -792,6 +942,36 @@ func TestHandlerConsulDataplaneSidecar_Multiport(t *testing.T) {
 	}
 }
 
+func TestHandlerConsulDataplaneSidecar_ResourceAPIs(t *testing.T) {
+	w := MeshWebhook{
+		ConsulAddress:      "1.1.1.1",
+		ConsulConfig:       &consul.Config{GRPCPort: 8502},
+		LogLevel:           "info",
+		EnableResourceAPIs: true,
+	}
+	pod := corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{
+			Name: "test-pod",
+			Annotations: map[string]string{
+				constants.AnnotationService: "web,web-admin",
+			},
+		},
+		Spec: corev1.PodSpec{
+			Containers: []corev1.Container{
+				{
+					Name: "web",
+				},
+			},
+		},
+	}
+
+	container, err := w.consulDataplaneSidecar(testNS, pod, multiPortInfo{})
+	require.NoError(t, err)
+	require.Equal(t, "-addresses 1.1.1.1 -grpc-port=8502 -proxy-id=$(POD_NAME) "+
+		"-log-level=info -log-json=false -envoy-concurrency=0 -tls-disabled -graceful-port=20600 -telemetry-prom-scrape-path=/metrics",
+		strings.Join(container.Args, " "))
+}
+
 func TestHandlerConsulDataplaneSidecar_withSecurityContext(t *testing.T) {
 	cases := map[string]struct {
 		tproxyEnabled      bool
@@ -836,8 +1016,10 @@ func TestHandlerConsulDataplaneSidecar_withSecurityContext(t *testing.T) {
 				ReadOnlyRootFilesystem:   ptr.To(true),
 				AllowPrivilegeEscalation: ptr.To(false),
 				Capabilities: &corev1.Capabilities{
-					Add: []corev1.Capability{"NET_BIND_SERVICE"},
+					Add:  []corev1.Capability{"NET_BIND_SERVICE"},
+					Drop: []corev1.Capability{"ALL"},
 				},
+				SeccompProfile: &corev1.SeccompProfile{Type: corev1.SeccompProfileTypeRuntimeDefault},
 			},
 		},
 		"tproxy enabled; openshift enabled": {
@@ -850,8 +1032,10 @@ func TestHandlerConsulDataplaneSidecar_withSecurityContext(t *testing.T) {
 				ReadOnlyRootFilesystem:   ptr.To(true),
 				AllowPrivilegeEscalation: ptr.To(false),
 				Capabilities: &corev1.Capabilities{
-					Add: []corev1.Capability{"NET_BIND_SERVICE"},
+					Add:  []corev1.Capability{"NET_BIND_SERVICE"},
+					Drop: []corev1.Capability{"ALL"},
 				},
+				SeccompProfile: &corev1.SeccompProfile{Type: corev1.SeccompProfileTypeRuntimeDefault},
 			},
 		},
 	}
@@ -1078,6 +1262,153 @@ func TestHandlerConsulDataplaneSidecar_EnvoyExtraArgs(t *testing.T) {
 	}
 }
 
+func TestHandlerConsulDataplaneSidecar_ImageOverride(t *testing.T) {
+	overrides := map[string]ConsulDataplaneImageOverride{
+		"pinned": {Image: "hashicorp/consul-dataplane@sha256:1234"},
+		"canary": {Image: "hashicorp/consul-dataplane:canary"},
+	}
+	cases := map[string]struct {
+		podAnnotations  map[string]string
+		namespaceLabels map[string]string
+		expImage        string
+		expErr          string
+	}{
+		"default image": {
+			expImage: "hashicorp/consul-dataplane:latest",
+		},
+		"override via annotation": {
+			podAnnotations: map[string]string{constants.AnnotationConsulDataplaneImage: "pinned"},
+			expImage:       "hashicorp/consul-dataplane@sha256:1234",
+		},
+		"override via namespace label": {
+			namespaceLabels: map[string]string{constants.LabelConsulDataplaneImage: "canary"},
+			expImage:        "hashicorp/consul-dataplane:canary",
+		},
+		"annotation takes precedence over namespace label": {
+			podAnnotations:  map[string]string{constants.AnnotationConsulDataplaneImage: "pinned"},
+			namespaceLabels: map[string]string{constants.LabelConsulDataplaneImage: "canary"},
+			expImage:        "hashicorp/consul-dataplane@sha256:1234",
+		},
+		"override not in allowlist": {
+			podAnnotations: map[string]string{constants.AnnotationConsulDataplaneImage: "unknown"},
+			expErr:         `consul.hashicorp.com/consul-dataplane-image annotation value "unknown" is not an allowed consul-dataplane image override`,
+		},
+	}
+
+	for name, c := range cases {
+		t.Run(name, func(t *testing.T) {
+			h := MeshWebhook{
+				ImageConsul:                   "hashicorp/consul:latest",
+				ImageConsulDataplane:          "hashicorp/consul-dataplane:latest",
+				ConsulDataplaneImageOverrides: overrides,
+				ConsulConfig:                  &consul.Config{HTTPPort: 8500, GRPCPort: 8502},
+			}
+			ns := corev1.Namespace{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:   "default",
+					Labels: c.namespaceLabels,
+				},
+			}
+			pod := corev1.Pod{
+				ObjectMeta: metav1.ObjectMeta{
+					Annotations: c.podAnnotations,
+				},
+			}
+
+			container, err := h.consulDataplaneSidecar(ns, pod, multiPortInfo{})
+			if c.expErr != "" {
+				require.EqualError(t, err, c.expErr)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, c.expImage, container.Image)
+		})
+	}
+}
+
+func TestHandlerConsulDataplaneSidecar_ArchitectureProfile(t *testing.T) {
+	profiles := map[string]ArchitectureProfile{
+		"arm64": {
+			Image: "hashicorp/consul-dataplane:arm64",
+			Resources: corev1.ResourceRequirements{
+				Requests: corev1.ResourceList{corev1.ResourceMemory: resource.MustParse("64Mi")},
+				Limits:   corev1.ResourceList{corev1.ResourceMemory: resource.MustParse("128Mi")},
+			},
+		},
+	}
+	cases := map[string]struct {
+		nodeSelector   map[string]string
+		podAnnotations map[string]string
+		expImage       string
+		expResources   corev1.ResourceRequirements
+	}{
+		"pod without an architecture uses the defaults": {
+			expImage: "hashicorp/consul-dataplane:latest",
+			expResources: corev1.ResourceRequirements{
+				Requests: corev1.ResourceList{corev1.ResourceCPU: resource.MustParse("100m"), corev1.ResourceMemory: resource.MustParse("100Mi")},
+				Limits:   corev1.ResourceList{},
+			},
+		},
+		"pod of an architecture without a profile uses the defaults": {
+			nodeSelector: map[string]string{corev1.LabelArchStable: "amd64"},
+			expImage:     "hashicorp/consul-dataplane:latest",
+			expResources: corev1.ResourceRequirements{
+				Requests: corev1.ResourceList{corev1.ResourceCPU: resource.MustParse("100m"), corev1.ResourceMemory: resource.MustParse("100Mi")},
+				Limits:   corev1.ResourceList{},
+			},
+		},
+		"pod of an architecture with a profile": {
+			nodeSelector: map[string]string{corev1.LabelArchStable: "arm64"},
+			expImage:     "hashicorp/consul-dataplane:arm64",
+			expResources: corev1.ResourceRequirements{
+				Requests: corev1.ResourceList{corev1.ResourceCPU: resource.MustParse("100m"), corev1.ResourceMemory: resource.MustParse("64Mi")},
+				Limits:   corev1.ResourceList{corev1.ResourceMemory: resource.MustParse("128Mi")},
+			},
+		},
+		"annotations take precedence over the profile": {
+			nodeSelector: map[string]string{corev1.LabelArchStable: "arm64"},
+			podAnnotations: map[string]string{
+				constants.AnnotationConsulDataplaneImage:      "pinned",
+				constants.AnnotationSidecarProxyMemoryRequest: "32Mi",
+			},
+			expImage: "hashicorp/consul-dataplane@sha256:1234",
+			expResources: corev1.ResourceRequirements{
+				Requests: corev1.ResourceList{corev1.ResourceCPU: resource.MustParse("100m"), corev1.ResourceMemory: resource.MustParse("32Mi")},
+				Limits:   corev1.ResourceList{corev1.ResourceMemory: resource.MustParse("128Mi")},
+			},
+		},
+	}
+
+	for name, c := range cases {
+		t.Run(name, func(t *testing.T) {
+			h := MeshWebhook{
+				ImageConsul:          "hashicorp/consul:latest",
+				ImageConsulDataplane: "hashicorp/consul-dataplane:latest",
+				ConsulDataplaneImageOverrides: map[string]ConsulDataplaneImageOverride{
+					"pinned": {Image: "hashicorp/consul-dataplane@sha256:1234"},
+				},
+				ArchitectureProfiles:      profiles,
+				DefaultProxyCPURequest:    resource.MustParse("100m"),
+				DefaultProxyMemoryRequest: resource.MustParse("100Mi"),
+				ConsulConfig:              &consul.Config{HTTPPort: 8500, GRPCPort: 8502},
+			}
+			pod := corev1.Pod{
+				ObjectMeta: metav1.ObjectMeta{
+					Annotations: c.podAnnotations,
+				},
+				Spec: corev1.PodSpec{
+					NodeSelector: c.nodeSelector,
+				},
+			}
+
+			container, err := h.consulDataplaneSidecar(testNS, pod, multiPortInfo{})
+			require.NoError(t, err)
+			require.Equal(t, c.expImage, container.Image)
+			require.Equal(t, c.expResources, container.Resources)
+		})
+	}
+}
+
 func TestHandlerConsulDataplaneSidecar_UserVolumeMounts(t *testing.T) {
 	cases := []struct {
 		name                          string
@@ -1324,11 +1655,13 @@ func TestHandlerConsulDataplaneSidecar_Resources(t *testing.T) {
 
 func TestHandlerConsulDataplaneSidecar_Metrics(t *testing.T) {
 	cases := []struct {
-		name       string
-		pod        corev1.Pod
-		expCmdArgs string
-		expPorts   []corev1.ContainerPort
-		expErr     string
+		name            string
+		pod             corev1.Pod
+		expCmdArgs      string
+		expPorts        []corev1.ContainerPort
+		expVolumeMounts []corev1.VolumeMount
+		expEnv          []corev1.EnvVar
+		expErr          string
 	}{
 		{
 			name:       "default",
@@ -1470,6 +1803,77 @@ func TestHandlerConsulDataplaneSidecar_Metrics(t *testing.T) {
 			expCmdArgs: "",
 			expErr:     fmt.Sprintf("must set %q when providing prometheus TLS config", constants.AnnotationPrometheusKeyFile),
 		},
+		{
+			name: "merged metrics scraping the service over https",
+			pod: corev1.Pod{
+				ObjectMeta: metav1.ObjectMeta{
+					Annotations: map[string]string{
+						constants.AnnotationService:                "web",
+						constants.AnnotationEnableMetrics:          "true",
+						constants.AnnotationEnableMetricsMerging:   "true",
+						constants.AnnotationPort:                   "1234",
+						constants.AnnotationMergedMetricsAppScheme: "https",
+					},
+				},
+			},
+			expCmdArgs: "-telemetry-prom-merge-port=20100 -telemetry-prom-service-metrics-url=https://127.0.0.1:1234/metrics",
+		},
+		{
+			name: "merged metrics scraping the service over https with a TLS secret",
+			pod: corev1.Pod{
+				ObjectMeta: metav1.ObjectMeta{
+					Annotations: map[string]string{
+						constants.AnnotationService:                   "web",
+						constants.AnnotationEnableMetrics:             "true",
+						constants.AnnotationEnableMetricsMerging:      "true",
+						constants.AnnotationPort:                      "1234",
+						constants.AnnotationMergedMetricsAppScheme:    "https",
+						constants.AnnotationMergedMetricsAppTLSSecret: "web-metrics-tls",
+					},
+				},
+			},
+			expCmdArgs: "-telemetry-prom-service-metrics-url=https://127.0.0.1:1234/metrics",
+			expVolumeMounts: []corev1.VolumeMount{
+				{
+					Name:      "consul-merged-metrics-app-tls",
+					MountPath: "/consul/merged-metrics-app-tls",
+					ReadOnly:  true,
+				},
+			},
+			expEnv: []corev1.EnvVar{
+				{Name: "SSL_CERT_DIR", Value: "/etc/ssl/certs:/etc/pki/tls/certs:/consul/merged-metrics-app-tls"},
+			},
+		},
+		{
+			name: "merged metrics with an invalid service metrics scheme gives an error",
+			pod: corev1.Pod{
+				ObjectMeta: metav1.ObjectMeta{
+					Annotations: map[string]string{
+						constants.AnnotationService:                "web",
+						constants.AnnotationEnableMetrics:          "true",
+						constants.AnnotationEnableMetricsMerging:   "true",
+						constants.AnnotationPort:                   "1234",
+						constants.AnnotationMergedMetricsAppScheme: "grpc",
+					},
+				},
+			},
+			expErr: `consul.hashicorp.com/merged-metrics-app-scheme annotation value of grpc must be one of "http" or "https"`,
+		},
+		{
+			name: "merged metrics with a TLS secret and the http scheme gives an error",
+			pod: corev1.Pod{
+				ObjectMeta: metav1.ObjectMeta{
+					Annotations: map[string]string{
+						constants.AnnotationService:                   "web",
+						constants.AnnotationEnableMetrics:             "true",
+						constants.AnnotationEnableMetricsMerging:      "true",
+						constants.AnnotationPort:                      "1234",
+						constants.AnnotationMergedMetricsAppTLSSecret: "web-metrics-tls",
+					},
+				},
+			},
+			expErr: `consul.hashicorp.com/merged-metrics-app-tls-secret annotation requires consul.hashicorp.com/merged-metrics-app-scheme to be "https"`,
+		},
 	}
 
 	for _, c := range cases {
@@ -1493,6 +1897,12 @@ func TestHandlerConsulDataplaneSidecar_Metrics(t *testing.T) {
 				if c.expPorts != nil {
 					require.ElementsMatch(t, container.Ports, c.expPorts)
 				}
+				if c.expVolumeMounts != nil {
+					require.Subset(t, container.VolumeMounts, c.expVolumeMounts)
+				}
+				if c.expEnv != nil {
+					require.Subset(t, container.Env, c.expEnv)
+				}
 			}
 		})
 	}
@@ -1662,6 +2072,183 @@ func TestHandlerConsulDataplaneSidecar_Lifecycle(t *testing.T) {
 	}
 }
 
+func TestHandlerConsulDataplaneSidecar_PreStopDrain(t *testing.T) {
+	cases := []struct {
+		name         string
+		webhook      MeshWebhook
+		annotations  map[string]string
+		expLifecycle *corev1.Lifecycle
+		expDrain     bool
+		expErr       string
+	}{
+		{
+			name:    "no default, no annotation",
+			webhook: MeshWebhook{},
+		},
+		{
+			name: "default",
+			webhook: MeshWebhook{
+				LifecycleConfig:   lifecycle.Config{DefaultPreStopDrainSeconds: 10},
+				EnableSleepAction: true,
+			},
+			expLifecycle: &corev1.Lifecycle{
+				PreStop: &corev1.LifecycleHandler{Sleep: &corev1.SleepAction{Seconds: 10}},
+			},
+			expDrain: true,
+		},
+		{
+			name:    "annotation",
+			webhook: MeshWebhook{},
+			annotations: map[string]string{
+				constants.AnnotationSidecarProxyLifecyclePreStopDrainSeconds: "5",
+			},
+			expLifecycle: &corev1.Lifecycle{
+				PreStop: &corev1.LifecycleHandler{Exec: &corev1.ExecAction{Command: []string{"sleep", "5"}}},
+			},
+			expDrain: true,
+		},
+		{
+			name: "annotation disables default",
+			webhook: MeshWebhook{
+				LifecycleConfig: lifecycle.Config{DefaultPreStopDrainSeconds: 10},
+			},
+			annotations: map[string]string{
+				constants.AnnotationSidecarProxyLifecyclePreStopDrainSeconds: "0",
+			},
+		},
+		{
+			name: "lifecycle enabled with draining disabled",
+			webhook: MeshWebhook{
+				LifecycleConfig: lifecycle.Config{
+					DefaultEnableProxyLifecycle: true,
+					DefaultPreStopDrainSeconds:  10,
+				},
+			},
+			expLifecycle: &corev1.Lifecycle{
+				PreStop: &corev1.LifecycleHandler{Exec: &corev1.ExecAction{Command: []string{"sleep", "10"}}},
+			},
+			expDrain: true,
+		},
+		{
+			name:    "invalid annotation",
+			webhook: MeshWebhook{},
+			annotations: map[string]string{
+				constants.AnnotationSidecarProxyLifecyclePreStopDrainSeconds: "-1",
+			},
+			expErr: "unable to determine proxy lifecycle pre-stop drain duration: unable to parse annotation \"consul.hashicorp.com/sidecar-proxy-lifecycle-pre-stop-drain-seconds\"",
+		},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			c.webhook.ConsulConfig = &consul.Config{HTTPPort: 8500, GRPCPort: 8502}
+			pod := corev1.Pod{
+				ObjectMeta: metav1.ObjectMeta{
+					Annotations: c.annotations,
+				},
+				Spec: corev1.PodSpec{
+					Containers: []corev1.Container{
+						{
+							Name: "web",
+						},
+					},
+				},
+			}
+			container, err := c.webhook.consulDataplaneSidecar(testNS, pod, multiPortInfo{})
+			if c.expErr != "" {
+				require.ErrorContains(t, err, c.expErr)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, c.expLifecycle, container.Lifecycle)
+			if c.expDrain {
+				require.Contains(t, container.Args, "-shutdown-drain-listeners")
+			} else {
+				require.NotContains(t, container.Args, "-shutdown-drain-listeners")
+			}
+		})
+	}
+}
+
+func TestHandlerConsulDataplaneSidecar_TerminationDrain(t *testing.T) {
+	cases := []struct {
+		name             string
+		lifecycleConfig  lifecycle.Config
+		nsAnnotations    map[string]string
+		podAnnotations   map[string]string
+		expArgs          []string
+		expNotContaining []string
+		expErr           string
+	}{
+		{
+			name:             "no drain duration",
+			expNotContaining: []string{"-shutdown-drain-listeners", "-shutdown-grace-period-seconds"},
+		},
+		{
+			name:           "pod annotation",
+			podAnnotations: map[string]string{constants.AnnotationSidecarProxyTerminationDrainDuration: "45s"},
+			expArgs:        []string{"-shutdown-drain-listeners", "-shutdown-grace-period-seconds=45"},
+		},
+		{
+			name:          "namespace annotation",
+			nsAnnotations: map[string]string{constants.AnnotationSidecarProxyTerminationDrainDuration: "1m"},
+			expArgs:       []string{"-shutdown-drain-listeners", "-shutdown-grace-period-seconds=60"},
+		},
+		{
+			name: "lifecycle enabled with a shorter shutdown grace period",
+			lifecycleConfig: lifecycle.Config{
+				DefaultEnableProxyLifecycle:       true,
+				DefaultShutdownGracePeriodSeconds: 10,
+			},
+			podAnnotations: map[string]string{constants.AnnotationSidecarProxyTerminationDrainDuration: "45s"},
+			expArgs:        []string{"-shutdown-drain-listeners", "-shutdown-grace-period-seconds=45"},
+		},
+		{
+			name: "lifecycle enabled with a longer shutdown grace period",
+			lifecycleConfig: lifecycle.Config{
+				DefaultEnableProxyLifecycle:       true,
+				DefaultShutdownGracePeriodSeconds: 90,
+			},
+			podAnnotations:   map[string]string{constants.AnnotationSidecarProxyTerminationDrainDuration: "45s"},
+			expArgs:          []string{"-shutdown-drain-listeners", "-shutdown-grace-period-seconds=90"},
+			expNotContaining: []string{"-shutdown-grace-period-seconds=45"},
+		},
+		{
+			name:           "invalid annotation",
+			podAnnotations: map[string]string{constants.AnnotationSidecarProxyTerminationDrainDuration: "soon"},
+			expErr:         "unable to determine sidecar proxy termination drain duration: consul.hashicorp.com/sidecar-proxy-termination-drain-duration annotation value \"soon\" was invalid",
+		},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			w := MeshWebhook{
+				ConsulConfig:    &consul.Config{HTTPPort: 8500, GRPCPort: 8502},
+				LifecycleConfig: c.lifecycleConfig,
+			}
+			ns := corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "default", Annotations: c.nsAnnotations}}
+			pod := corev1.Pod{
+				ObjectMeta: metav1.ObjectMeta{Annotations: c.podAnnotations},
+				Spec: corev1.PodSpec{
+					Containers: []corev1.Container{{Name: "web"}},
+				},
+			}
+			container, err := w.consulDataplaneSidecar(ns, pod, multiPortInfo{})
+			if c.expErr != "" {
+				require.ErrorContains(t, err, c.expErr)
+				return
+			}
+			require.NoError(t, err)
+			for _, arg := range c.expArgs {
+				require.Contains(t, container.Args, arg)
+			}
+			for _, arg := range c.expNotContaining {
+				for _, actual := range container.Args {
+					require.NotContains(t, actual, arg)
+				}
+			}
+		})
+	}
+}
+
 // boolPtr returns pointer to b.
 func boolPtr(b bool) *bool {
 	return &b
@@ -34,6 +34,10 @@ type initContainerCommandData struct {
 	// of the services on the multi port Pod.
 	MultiPort bool
 
+	// EnableResourceAPIs configures connect-init to wait for the pod's workload instead of its
+	// service registration.
+	EnableResourceAPIs bool
+
 	// Log settings for the connect-init command.
 	LogLevel string
 	LogJSON  bool
@@ -51,10 +55,11 @@ func (w *MeshWebhook) containerInit(namespace corev1.Namespace, pod corev1.Pod,
 	multiPort := mpi.serviceName != ""
 
 	data := initContainerCommandData{
-		AuthMethod: w.AuthMethod,
-		MultiPort:  multiPort,
-		LogLevel:   w.LogLevel,
-		LogJSON:    w.LogJSON,
+		AuthMethod:         w.AuthMethod,
+		MultiPort:          multiPort,
+		EnableResourceAPIs: w.EnableResourceAPIs,
+		LogLevel:           w.LogLevel,
+		LogJSON:            w.LogJSON,
 	}
 
 	// Create expected volume mounts
@@ -81,7 +86,7 @@ func (w *MeshWebhook) containerInit(namespace corev1.Namespace, pod corev1.Pod,
 		}
 		// Extract the service account token's volume mount
 		var saTokenVolumeMount corev1.VolumeMount
-		saTokenVolumeMount, bearerTokenFile, err = findServiceAccountVolumeMount(pod, mpi.serviceName)
+		saTokenVolumeMount, bearerTokenFile, err = w.loginTokenVolumeMount(pod, mpi.serviceName)
 		if err != nil {
 			return corev1.Container{}, err
 		}
@@ -146,7 +151,7 @@ func (w *MeshWebhook) containerInit(namespace corev1.Namespace, pod corev1.Pod,
 			},
 			{
 				Name:  "CONSUL_NODE_NAME",
-				Value: "$(NODE_NAME)-virtual",
+				Value: w.NodeNamingStrategy.ConsulNodeNameEnv(namespace.Name),
 			},
 		},
 		Resources:    w.InitContainerResources,
@@ -170,6 +175,7 @@ func (w *MeshWebhook) containerInit(namespace corev1.Namespace, pod corev1.Pod,
 			})
 	}
 
+	partition := w.consulPartition(namespace.Name)
 	if w.AuthMethod != "" {
 		container.Env = append(container.Env,
 			corev1.EnvVar{
@@ -201,11 +207,11 @@ func (w *MeshWebhook) containerInit(namespace corev1.Namespace, pod corev1.Pod,
 			}
 		}
 
-		if w.ConsulPartition != "" {
+		if partition != "" {
 			container.Env = append(container.Env,
 				corev1.EnvVar{
 					Name:  "CONSUL_LOGIN_PARTITION",
-					Value: w.ConsulPartition,
+					Value: partition,
 				})
 		}
 	}
@@ -213,15 +219,15 @@ func (w *MeshWebhook) containerInit(namespace corev1.Namespace, pod corev1.Pod,
 		container.Env = append(container.Env,
 			corev1.EnvVar{
 				Name:  "CONSUL_NAMESPACE",
-				Value: w.consulNamespace(namespace.Name),
+				Value: w.podConsulNamespace(pod, namespace.Name),
 			})
 	}
 
-	if w.ConsulPartition != "" {
+	if partition != "" {
 		container.Env = append(container.Env,
 			corev1.EnvVar{
 				Name:  "CONSUL_PARTITION",
-				Value: w.ConsulPartition,
+				Value: partition,
 			})
 	}
 
@@ -240,14 +246,17 @@ func (w *MeshWebhook) containerInit(namespace corev1.Namespace, pod corev1.Pod,
 			// For Openshift with Transparent proxy + CNI, there is an annotation on the namespace that tells us what
 			// the user and group ids should be for the sidecar.
 			if w.EnableOpenShift {
-				var err error
+				dataplaneImage, err := w.consulDataplaneImage(namespace, pod)
+				if err != nil {
+					return corev1.Container{}, err
+				}
 
-				uid, err = common.GetConnectInitUID(namespace, pod, w.ImageConsulDataplane, w.ImageConsulK8S)
+				uid, err = common.GetConnectInitUID(namespace, pod, dataplaneImage, w.ImageConsulK8S)
 				if err != nil {
 					return corev1.Container{}, err
 				}
 
-				group, err = common.GetConnectInitGroupID(namespace, pod, w.ImageConsulDataplane, w.ImageConsulK8S)
+				group, err = common.GetConnectInitGroupID(namespace, pod, dataplaneImage, w.ImageConsulK8S)
 				if err != nil {
 					return corev1.Container{}, err
 				}
@@ -264,6 +273,9 @@ func (w *MeshWebhook) containerInit(namespace corev1.Namespace, pod corev1.Pod,
 				ReadOnlyRootFilesystem:   ptr.To(true),
 				AllowPrivilegeEscalation: ptr.To(false),
 			}
+			if w.EnableOpenShift {
+				container.SecurityContext.SeccompProfile = &corev1.SeccompProfile{Type: corev1.SeccompProfileTypeRuntimeDefault}
+			}
 		} else {
 			// Set redirect traffic config for the container so that we can apply iptables rules.
 			redirectTrafficConfig, err := w.iptablesConfigJSON(pod, namespace)
@@ -289,23 +301,53 @@ func (w *MeshWebhook) containerInit(namespace corev1.Namespace, pod corev1.Pod,
 				},
 			}
 		}
+	} else if w.EnableOpenShift {
+		// Without transparent proxy, connect-init does not need any privileges so it runs with a
+		// security context that is admitted by the restricted-v2 SCC, using an ID from the
+		// namespace's range rather than one that has to be configured for each namespace.
+		dataplaneImage, err := w.consulDataplaneImage(namespace, pod)
+		if err != nil {
+			return corev1.Container{}, err
+		}
+		uid, err := common.GetConnectInitUID(namespace, pod, dataplaneImage, w.ImageConsulK8S)
+		if err != nil {
+			return corev1.Container{}, err
+		}
+		group, err := common.GetConnectInitGroupID(namespace, pod, dataplaneImage, w.ImageConsulK8S)
+		if err != nil {
+			return corev1.Container{}, err
+		}
+		container.SecurityContext = &corev1.SecurityContext{
+			RunAsUser:    ptr.To(uid),
+			RunAsGroup:   ptr.To(group),
+			RunAsNonRoot: ptr.To(true),
+			Capabilities: &corev1.Capabilities{
+				Drop: []corev1.Capability{"ALL"},
+			},
+			ReadOnlyRootFilesystem:   ptr.To(true),
+			AllowPrivilegeEscalation: ptr.To(false),
+			SeccompProfile:           &corev1.SeccompProfile{Type: corev1.SeccompProfileTypeRuntimeDefault},
+		}
 	}
 
+	container.Env = append(container.Env, w.annotationPassthroughEnvVars(pod)...)
 	return container, nil
 }
 
 // consulDNSEnabled returns true if Consul DNS should be enabled for this pod.
 // It returns an error when the annotation value cannot be parsed by strconv.ParseBool or if we are unable
 // to read the pod's namespace label when it exists.
-func consulDNSEnabled(namespace corev1.Namespace, pod corev1.Pod, globalDNSEnabled bool, globalTProxyEnabled bool) (bool, error) {
-	// DNS is only possible when tproxy is also enabled because it relies
-	// on traffic being redirected.
-	tproxy, err := common.TransparentProxyEnabled(namespace, pod, globalTProxyEnabled)
-	if err != nil {
-		return false, err
-	}
-	if !tproxy {
-		return false, nil
+func consulDNSEnabled(namespace corev1.Namespace, pod corev1.Pod, globalDNSEnabled bool, globalTProxyEnabled bool, redirectionMode string) (bool, error) {
+	// With iptables redirection, DNS is only possible when tproxy is also enabled
+	// because it relies on traffic being redirected.
+	if redirectionMode != constants.DNSRedirectionModeDNSConfig {
+		tproxy, err := common.TransparentProxyEnabled(namespace, pod, globalTProxyEnabled)
+		if err != nil {
+			return false, err
+		}
+		if !tproxy {
+			return false, nil
+		}
 	}
 
 	// First check to see if the pod annotation exists to override the namespace or global settings.
@@ -341,6 +383,9 @@ consul-k8s-control-plane connect-init -pod-name=${POD_NAME} -pod-namespace=${POD
   -service-account-name="{{ .ServiceAccountName }}" \
   -service-name="{{ .ServiceName }}" \
   {{- end }}
+  {{- if .EnableResourceAPIs }}
+  -enable-resource-apis=true \
+  {{- end }}
   {{- if .MultiPort }}
   -multiport=true \
   -proxy-id-file=/consul/connect-inject/proxyid-{{ .ServiceName }} \
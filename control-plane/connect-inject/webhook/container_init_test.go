@@ -4,11 +4,13 @@
 package webhook
 
 import (
+	"context"
 	"fmt"
 	"strings"
 	"testing"
 	"time"
 
+	"github.com/hashicorp/consul-k8s/control-plane/connect-inject/common"
 	"github.com/hashicorp/consul-k8s/control-plane/connect-inject/constants"
 	"github.com/hashicorp/consul-k8s/control-plane/consul"
 	"github.com/hashicorp/consul-k8s/control-plane/namespaces"
@@ -17,6 +19,7 @@ import (
 	"k8s.io/apimachinery/pkg/api/resource"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/utils/ptr"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
 )
 
 const k8sNamespace = "k8snamespace"
@@ -327,6 +330,7 @@ func TestHandlerContainerInit_transparentProxy(t *testing.T) {
 					},
 					ReadOnlyRootFilesystem:   ptr.To(true),
 					AllowPrivilegeEscalation: ptr.To(false),
+					SeccompProfile:           &corev1.SeccompProfile{Type: corev1.SeccompProfileTypeRuntimeDefault},
 				}
 			}
 			ns := corev1.Namespace{
@@ -361,6 +365,88 @@ func TestHandlerContainerInit_transparentProxy(t *testing.T) {
 	}
 }
 
+func TestHandlerContainerInit_openShiftWithoutTransparentProxy(t *testing.T) {
+	w := MeshWebhook{
+		EnableOpenShift: true,
+		ConsulConfig:    &consul.Config{HTTPPort: 8500},
+	}
+	pod := corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{
+			Annotations: map[string]string{
+				constants.AnnotationService: "foo",
+			},
+		},
+		Spec: corev1.PodSpec{
+			Containers: []corev1.Container{
+				{
+					Name:  "web",
+					Image: "web",
+					SecurityContext: &corev1.SecurityContext{
+						RunAsUser: ptr.To(int64(1000799999)),
+					},
+				},
+			},
+		},
+	}
+	ns := corev1.Namespace{
+		ObjectMeta: metav1.ObjectMeta{
+			Name: k8sNamespace,
+			Annotations: map[string]string{
+				constants.AnnotationOpenShiftUIDRange: "1000700000/100000",
+				constants.AnnotationOpenShiftGroups:   "1000700000/100000",
+			},
+		},
+	}
+
+	container, err := w.containerInit(ns, pod, multiPortInfo{})
+	require.NoError(t, err)
+	// The application container's ID is not reused.
+	require.Equal(t, &corev1.SecurityContext{
+		RunAsUser:    ptr.To(int64(1000799998)),
+		RunAsGroup:   ptr.To(int64(1000799998)),
+		RunAsNonRoot: ptr.To(true),
+		Capabilities: &corev1.Capabilities{
+			Drop: []corev1.Capability{"ALL"},
+		},
+		ReadOnlyRootFilesystem:   ptr.To(true),
+		AllowPrivilegeEscalation: ptr.To(false),
+		SeccompProfile:           &corev1.SeccompProfile{Type: corev1.SeccompProfileTypeRuntimeDefault},
+	}, container.SecurityContext)
+
+	// Without the namespace annotations there is no range to pick an ID from.
+	ns.Annotations = nil
+	_, err = w.containerInit(ns, pod, multiPortInfo{})
+	require.ErrorContains(t, err, "unable to get valid userIDs from namespace annotation")
+}
+
+func TestHandlerContainerInit_resourceAPIs(t *testing.T) {
+	w := MeshWebhook{
+		EnableResourceAPIs: true,
+		ConsulConfig:       &consul.Config{HTTPPort: 8500, GRPCPort: 8502},
+		LogLevel:           "info",
+	}
+	pod := corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{
+			Annotations: map[string]string{
+				constants.AnnotationService: "web,web-admin",
+			},
+		},
+		Spec: corev1.PodSpec{
+			Containers: []corev1.Container{
+				{
+					Name: "web",
+				},
+			},
+		},
+	}
+
+	container, err := w.containerInit(testNS, pod, multiPortInfo{})
+	require.NoError(t, err)
+	actual := strings.Join(container.Command, " ")
+	require.Contains(t, actual, "-enable-resource-apis=true")
+	require.NotContains(t, actual, "-multiport=true")
+}
+
 func TestHandlerContainerInit_namespacesAndPartitionsEnabled(t *testing.T) {
 	minimal := func() *corev1.Pod {
 		return &corev1.Pod{
@@ -721,6 +807,67 @@ func TestHandlerContainerInit_namespacesAndPartitionsEnabled(t *testing.T) {
 				},
 			},
 		},
+		{
+			"auth method, partition mapped from namespace",
+			func(pod *corev1.Pod) *corev1.Pod {
+				pod.Annotations[constants.AnnotationService] = ""
+				return pod
+			},
+			MeshWebhook{
+				AuthMethod:       "auth-method",
+				ConsulPartition:  "default",
+				PartitionMapping: testPartitionMapping(t, "default", map[string]string{k8sNamespace: "mapped-part"}),
+				ConsulAddress:    "10.0.0.0",
+				ConsulConfig:     &consul.Config{HTTPPort: 8500, GRPCPort: 8502, APITimeout: 5 * time.Second},
+			},
+			`/bin/sh -ec consul-k8s-control-plane connect-init -pod-name=${POD_NAME} -pod-namespace=${POD_NAMESPACE} \
+  -log-level=info \
+  -log-json=false \
+  -service-account-name="web" \
+  -service-name="" \`,
+			[]corev1.EnvVar{
+				{
+					Name:  "CONSUL_ADDRESSES",
+					Value: "10.0.0.0",
+				},
+				{
+					Name:  "CONSUL_GRPC_PORT",
+					Value: "8502",
+				},
+				{
+					Name:  "CONSUL_HTTP_PORT",
+					Value: "8500",
+				},
+				{
+					Name:  "CONSUL_API_TIMEOUT",
+					Value: "5s",
+				},
+				{
+					Name:  "CONSUL_NODE_NAME",
+					Value: "$(NODE_NAME)-virtual",
+				},
+				{
+					Name:  "CONSUL_LOGIN_AUTH_METHOD",
+					Value: "auth-method",
+				},
+				{
+					Name:  "CONSUL_LOGIN_BEARER_TOKEN_FILE",
+					Value: "/var/run/secrets/kubernetes.io/serviceaccount/token",
+				},
+				{
+					Name:  "CONSUL_LOGIN_META",
+					Value: "pod=$(POD_NAMESPACE)/$(POD_NAME)",
+				},
+				{
+					Name:  "CONSUL_LOGIN_PARTITION",
+					Value: "mapped-part",
+				},
+				{
+					Name:  "CONSUL_PARTITION",
+					Value: "mapped-part",
+				},
+			},
+		},
 	}
 
 	for _, tt := range cases {
@@ -1026,3 +1173,70 @@ func minimal() *corev1.Pod {
 		},
 	}
 }
+
+// testPartitionMapping returns a partition mapping that has read the given mappings of Kubernetes
+// namespaces to Admin Partitions.
+func testPartitionMapping(t *testing.T, defaultPartition string, mappings map[string]string) *common.PartitionMapping {
+	configMap := &corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{Name: "partition-mapping", Namespace: "consul"},
+		Data:       mappings,
+	}
+	mapping := &common.PartitionMapping{
+		Client:           fake.NewClientBuilder().WithObjects(configMap).Build(),
+		Name:             configMap.Name,
+		Namespace:        configMap.Namespace,
+		DefaultPartition: defaultPartition,
+	}
+	require.NoError(t, mapping.Refresh(context.Background()))
+	return mapping
+}
+
+func TestHandlerContainerInit_openShiftConsulDataplaneImageOverride(t *testing.T) {
+	w := MeshWebhook{
+		EnableOpenShift:      true,
+		ImageConsulDataplane: "hashicorp/consul-dataplane:latest",
+		ConsulDataplaneImageOverrides: map[string]ConsulDataplaneImageOverride{
+			"canary": {Image: "hashicorp/consul-dataplane:canary"},
+		},
+		ConsulConfig: &consul.Config{HTTPPort: 8500},
+	}
+	pod := corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{
+			Annotations: map[string]string{
+				constants.AnnotationService:              "foo",
+				constants.AnnotationConsulDataplaneImage: "canary",
+			},
+		},
+		Spec: corev1.PodSpec{
+			Containers: []corev1.Container{
+				{
+					Name:  "web",
+					Image: "web",
+				},
+				{
+					Name:  sidecarContainer,
+					Image: "hashicorp/consul-dataplane:canary",
+					SecurityContext: &corev1.SecurityContext{
+						RunAsUser: ptr.To(int64(1000799998)),
+					},
+				},
+			},
+		},
+	}
+	ns := corev1.Namespace{
+		ObjectMeta: metav1.ObjectMeta{
+			Name: k8sNamespace,
+			Annotations: map[string]string{
+				constants.AnnotationOpenShiftUIDRange: "1000700000/100000",
+				constants.AnnotationOpenShiftGroups:   "1000700000/100000",
+			},
+		},
+	}
+
+	container, err := w.containerInit(ns, pod, multiPortInfo{})
+	require.NoError(t, err)
+	// The sidecar running the overridden image is not an application container, so its ID doesn't
+	// shift the ID of connect-init.
+	require.Equal(t, ptr.To(int64(1000799999)), container.SecurityContext.RunAsUser)
+	require.Equal(t, ptr.To(int64(1000799999)), container.SecurityContext.RunAsGroup)
+}
@@ -4,7 +4,11 @@
 package webhook
 
 import (
+	"fmt"
+
 	corev1 "k8s.io/api/core/v1"
+
+	"github.com/hashicorp/consul-k8s/control-plane/connect-inject/constants"
 )
 
 // volumeName is the name of the volume that is created to store the
@@ -21,3 +25,120 @@ func (w *MeshWebhook) containerVolume() corev1.Volume {
 		},
 	}
 }
+
+// serviceMetricsTLSVolumeName is the name of the volume with the CA certificate
+// used by the merged metrics server to scrape the service metrics over TLS.
+const serviceMetricsTLSVolumeName = "consul-merged-metrics-app-tls"
+
+// serviceMetricsTLSMountPath is where the service metrics TLS volume is mounted
+// in the consul-dataplane container.
+const serviceMetricsTLSMountPath = "/consul/merged-metrics-app-tls"
+
+// serviceMetricsCertDirs are the directories consul-dataplane loads its root
+// certificates from when the service metrics are scraped with the CA certificate
+// of a Secret. consul-dataplane has no flag for the CA certificate of the service
+// metrics, so the mounted CA certificate is added to the default directories of the
+// system roots through SSL_CERT_DIR.
+const serviceMetricsCertDirs = "/etc/ssl/certs:/etc/pki/tls/certs:" + serviceMetricsTLSMountPath
+
+// serviceMetricsTLSSecret returns the name of the Secret with the CA certificate used
+// to scrape the service metrics over TLS. It is empty unless the merged metrics
+// server runs and the Secret is set with the annotation. It also validates the
+// scheme used to scrape the service metrics.
+func (w *MeshWebhook) serviceMetricsTLSSecret(pod corev1.Pod) (string, error) {
+	run, err := w.MetricsConfig.ShouldRunMergedMetricsServer(pod)
+	if err != nil || !run {
+		return "", err
+	}
+	if _, err := w.MetricsConfig.ServiceMetricsScheme(pod); err != nil {
+		return "", err
+	}
+	return w.MetricsConfig.ServiceMetricsTLSSecret(pod)
+}
+
+// serviceMetricsTLSVolume returns the volume with the CA certificate used to scrape
+// the service metrics over TLS from the Secret. Only ca.crt is projected so that
+// no other certificate of the Secret is trusted.
+func serviceMetricsTLSVolume(secretName string) corev1.Volume {
+	return corev1.Volume{
+		Name: serviceMetricsTLSVolumeName,
+		VolumeSource: corev1.VolumeSource{
+			Secret: &corev1.SecretVolumeSource{
+				SecretName: secretName,
+				Items:      []corev1.KeyToPath{{Key: "ca.crt", Path: "ca.crt"}},
+			},
+		},
+	}
+}
+
+const (
+	// loginTokenVolumeName is the name of the volume with the projected service
+	// account token used to log in with the auth method.
+	loginTokenVolumeName = "consul-login-token"
+
+	// loginTokenMountPath is where the login token volume is mounted in the
+	// connect-inject-init and consul-dataplane containers.
+	loginTokenMountPath = "/consul/login-token"
+
+	// loginTokenPath is the path of the token within the login token volume.
+	loginTokenPath = "token"
+)
+
+// loginTokenVolume returns the volume with a service account token bound to the
+// login token audience. Unlike the token Kubernetes mounts in the pod, it is only
+// accepted for that audience and expires, and the kubelet rotates it before it does.
+func (w *MeshWebhook) loginTokenVolume() corev1.Volume {
+	var expirationSeconds *int64
+	if w.LoginTokenExpirationSeconds > 0 {
+		expirationSeconds = &w.LoginTokenExpirationSeconds
+	}
+	return corev1.Volume{
+		Name: loginTokenVolumeName,
+		VolumeSource: corev1.VolumeSource{
+			Projected: &corev1.ProjectedVolumeSource{
+				Sources: []corev1.VolumeProjection{
+					{
+						ServiceAccountToken: &corev1.ServiceAccountTokenProjection{
+							Audience:          w.LoginTokenAudience,
+							ExpirationSeconds: expirationSeconds,
+							Path:              loginTokenPath,
+						},
+					},
+				},
+			},
+		},
+	}
+}
+
+const (
+	// caCertVolumeName is the name of the downward API volume with the Consul CA certificate
+	// used by consul-dataplane when it logs in without the connect-inject-init container.
+	caCertVolumeName = "consul-ca-cert"
+
+	// caCertMountPath is where the CA certificate volume is mounted in the consul-dataplane container.
+	caCertMountPath = "/consul/ca"
+
+	// caCertPath is the path of the CA certificate within the CA certificate volume.
+	caCertPath = "consul-ca.pem"
+)
+
+// caCertVolume returns the volume that projects the Consul CA certificate from the pod's
+// annotation into a file, since consul-dataplane only reads the CA certificate from a file and
+// Secrets of the Consul namespace can't be mounted in the pod.
+func caCertVolume() corev1.Volume {
+	return corev1.Volume{
+		Name: caCertVolumeName,
+		VolumeSource: corev1.VolumeSource{
+			DownwardAPI: &corev1.DownwardAPIVolumeSource{
+				Items: []corev1.DownwardAPIVolumeFile{
+					{
+						Path: caCertPath,
+						FieldRef: &corev1.ObjectFieldSelector{
+							FieldPath: fmt.Sprintf("metadata.annotations['%s']", constants.KeyConsulCACert),
+						},
+					},
+				},
+			},
+		},
+	}
+}
@@ -0,0 +1,75 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package webhook
+
+import (
+	"fmt"
+
+	corev1 "k8s.io/api/core/v1"
+
+	"github.com/hashicorp/consul-k8s/control-plane/connect-inject/constants"
+)
+
+// ConsulDataplaneImageOverride is a consul-dataplane image that pods can use for their sidecar instead of
+// the default image, e.g. an image pinned to a digest for a tenant or a new version during a staged rollout.
+type ConsulDataplaneImageOverride struct {
+	// Image is the consul-dataplane image.
+	Image string `json:"image"`
+	// ImagePullSecrets are added to the pod so that the image can be pulled from a private registry.
+	ImagePullSecrets []corev1.LocalObjectReference `json:"image_pull_secrets"`
+}
+
+// consulDataplaneImageOverride returns the consul-dataplane image override of the pod, or nil if it uses the
+// default image. The override is named by the pod's annotation or, if it is not set, the namespace's label,
+// and must be in the allowlist of overrides.
+func (w *MeshWebhook) consulDataplaneImageOverride(namespace corev1.Namespace, pod corev1.Pod) (*ConsulDataplaneImageOverride, error) {
+	if name, ok := pod.Annotations[constants.AnnotationConsulDataplaneImage]; ok {
+		override, ok := w.ConsulDataplaneImageOverrides[name]
+		if !ok {
+			return nil, fmt.Errorf("%s annotation value %q is not an allowed consul-dataplane image override", constants.AnnotationConsulDataplaneImage, name)
+		}
+		return &override, nil
+	}
+	if name, ok := namespace.Labels[constants.LabelConsulDataplaneImage]; ok {
+		override, ok := w.ConsulDataplaneImageOverrides[name]
+		if !ok {
+			return nil, fmt.Errorf("%s label value %q of namespace %s is not an allowed consul-dataplane image override",
+				constants.LabelConsulDataplaneImage, name, namespace.Name)
+		}
+		return &override, nil
+	}
+	return nil, nil
+}
+
+// consulDataplaneImage returns the consul-dataplane image of the pod's sidecar. An image override takes
+// precedence over the image of the pod's architecture profile.
+func (w *MeshWebhook) consulDataplaneImage(namespace corev1.Namespace, pod corev1.Pod) (string, error) {
+	override, err := w.consulDataplaneImageOverride(namespace, pod)
+	if err != nil {
+		return "", err
+	}
+	if override != nil {
+		return override.Image, nil
+	}
+	if profile := w.architectureProfile(pod); profile != nil && profile.Image != "" {
+		return profile.Image, nil
+	}
+	return w.ImageConsulDataplane, nil
+}
+
+// addImagePullSecrets adds the secrets to the pod's image pull secrets unless it already has them.
+func addImagePullSecrets(pod *corev1.Pod, secrets []corev1.LocalObjectReference) {
+	for _, secret := range secrets {
+		found := false
+		for _, existing := range pod.Spec.ImagePullSecrets {
+			if existing.Name == secret.Name {
+				found = true
+				break
+			}
+		}
+		if !found {
+			pod.Spec.ImagePullSecrets = append(pod.Spec.ImagePullSecrets, secret)
+		}
+	}
+}
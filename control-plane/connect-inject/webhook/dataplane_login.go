@@ -0,0 +1,49 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package webhook
+
+import (
+	"fmt"
+
+	corev1 "k8s.io/api/core/v1"
+
+	"github.com/hashicorp/consul-k8s/control-plane/connect-inject/common"
+	"github.com/hashicorp/consul-k8s/control-plane/connect-inject/constants"
+)
+
+// useDataplaneLogin returns true if the connect-inject-init container isn't injected into the pod
+// because consul-dataplane does its work itself: it logs in with the auth method, looks up the proxy
+// registration by its ID and reads the CA certificate from a downward API volume.
+//
+// The init container is still injected when it applies the traffic redirection rules, i.e. with
+// transparent proxy unless the CNI plugin applies them, and when the ID of the proxy can't be
+// determined at admission because the service name isn't set with an annotation.
+func (w *MeshWebhook) useDataplaneLogin(namespace corev1.Namespace, pod corev1.Pod) (bool, error) {
+	if !w.EnableDataplaneLogin {
+		return false, nil
+	}
+	tproxyEnabled, err := common.TransparentProxyEnabled(namespace, pod, w.EnableTransparentProxy)
+	if err != nil {
+		return false, err
+	}
+	if tproxyEnabled && !w.EnableCNI {
+		return false, nil
+	}
+	// With resource APIs the proxy is identified by the workload, which is named after the pod.
+	if w.EnableResourceAPIs {
+		return true, nil
+	}
+	return pod.Annotations[constants.AnnotationService] != "", nil
+}
+
+// dataplaneProxyServiceID returns the ID of the proxy service that the endpoints controller
+// registers for the pod's service. The pod name isn't known at admission when it is generated,
+// so it is expanded from the POD_NAME environment variable of the consul-dataplane container.
+func dataplaneProxyServiceID(pod corev1.Pod, mpi multiPortInfo) string {
+	serviceName := mpi.serviceName
+	if serviceName == "" {
+		serviceName = pod.Annotations[constants.AnnotationService]
+	}
+	return fmt.Sprintf("$(POD_NAME)-%s-sidecar-proxy", serviceName)
+}
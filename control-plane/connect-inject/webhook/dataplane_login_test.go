@@ -0,0 +1,185 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package webhook
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	mapset "github.com/deckarep/golang-set"
+	jsonpatch "github.com/evanphx/json-patch"
+	logrtest "github.com/go-logr/logr/testr"
+	"github.com/stretchr/testify/require"
+	admissionv1 "k8s.io/api/admission/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
+
+	"github.com/hashicorp/consul-k8s/control-plane/connect-inject/constants"
+	"github.com/hashicorp/consul-k8s/control-plane/consul"
+	"github.com/hashicorp/consul-k8s/control-plane/namespaces"
+)
+
+func TestUseDataplaneLogin(t *testing.T) {
+	cases := map[string]struct {
+		webhook     MeshWebhook
+		annotations map[string]string
+		exp         bool
+	}{
+		"disabled": {
+			webhook:     MeshWebhook{},
+			annotations: map[string]string{constants.AnnotationService: "web"},
+		},
+		"service annotation": {
+			webhook:     MeshWebhook{EnableDataplaneLogin: true},
+			annotations: map[string]string{constants.AnnotationService: "web"},
+			exp:         true,
+		},
+		"multiport": {
+			webhook:     MeshWebhook{EnableDataplaneLogin: true},
+			annotations: map[string]string{constants.AnnotationService: "web,web-admin"},
+			exp:         true,
+		},
+		"no service annotation": {
+			webhook: MeshWebhook{EnableDataplaneLogin: true},
+		},
+		"no service annotation with resource APIs": {
+			webhook: MeshWebhook{EnableDataplaneLogin: true, EnableResourceAPIs: true},
+			exp:     true,
+		},
+		"transparent proxy": {
+			webhook:     MeshWebhook{EnableDataplaneLogin: true, EnableTransparentProxy: true},
+			annotations: map[string]string{constants.AnnotationService: "web"},
+		},
+		"transparent proxy with CNI": {
+			webhook:     MeshWebhook{EnableDataplaneLogin: true, EnableTransparentProxy: true, EnableCNI: true},
+			annotations: map[string]string{constants.AnnotationService: "web"},
+			exp:         true,
+		},
+		"transparent proxy disabled with an annotation": {
+			webhook: MeshWebhook{EnableDataplaneLogin: true, EnableTransparentProxy: true},
+			annotations: map[string]string{
+				constants.AnnotationService:   "web",
+				constants.KeyTransparentProxy: "false",
+			},
+			exp: true,
+		},
+	}
+	for name, c := range cases {
+		t.Run(name, func(t *testing.T) {
+			pod := corev1.Pod{ObjectMeta: metav1.ObjectMeta{Annotations: c.annotations}}
+			actual, err := c.webhook.useDataplaneLogin(corev1.Namespace{}, pod)
+			require.NoError(t, err)
+			require.Equal(t, c.exp, actual)
+		})
+	}
+}
+
+func TestHandlerHandle_DataplaneLogin(t *testing.T) {
+	s := runtime.NewScheme()
+	s.AddKnownTypes(schema.GroupVersion{Group: "", Version: "v1"}, &corev1.Pod{})
+	decoder := admission.NewDecoder(s)
+
+	cases := map[string]struct {
+		annotations       map[string]string
+		expInitContainers []string
+		expContainers     []string
+		expProxyIDArgs    []string
+	}{
+		"single port": {
+			annotations:    map[string]string{constants.AnnotationService: "web"},
+			expContainers:  []string{"web", "consul-dataplane"},
+			expProxyIDArgs: []string{"-proxy-service-id=$(POD_NAME)-web-sidecar-proxy"},
+		},
+		"multiport": {
+			annotations:   map[string]string{constants.AnnotationService: "web,web-admin"},
+			expContainers: []string{"web", "consul-dataplane-web", "consul-dataplane-web-admin"},
+			expProxyIDArgs: []string{
+				"-proxy-service-id=$(POD_NAME)-web-sidecar-proxy",
+				"-proxy-service-id=$(POD_NAME)-web-admin-sidecar-proxy",
+			},
+		},
+		"no service annotation": {
+			expInitContainers: []string{"consul-connect-inject-init"},
+			expContainers:     []string{"web", "consul-dataplane"},
+			expProxyIDArgs:    []string{"-proxy-service-id-path=/consul/connect-inject/proxyid"},
+		},
+	}
+	for name, c := range cases {
+		t.Run(name, func(t *testing.T) {
+			w := MeshWebhook{
+				Log:                   logrtest.New(t),
+				AllowK8sNamespacesSet: mapset.NewSetWith("*"),
+				DenyK8sNamespacesSet:  mapset.NewSet(),
+				decoder:               decoder,
+				Clientset:             defaultTestClientWithNamespace(),
+				ConsulConfig:          &consul.Config{HTTPPort: 8500},
+				TLSEnabled:            true,
+				ConsulCACert:          "ca-cert",
+				EnableDataplaneLogin:  true,
+			}
+			raw := encodeRaw(t, &corev1.Pod{
+				ObjectMeta: metav1.ObjectMeta{Annotations: c.annotations},
+				Spec: corev1.PodSpec{
+					Containers: []corev1.Container{{Name: "web"}},
+				},
+			})
+
+			resp := w.Handle(context.Background(), admission.Request{
+				AdmissionRequest: admissionv1.AdmissionRequest{
+					Namespace: namespaces.DefaultNamespace,
+					Object:    raw,
+				},
+			})
+			require.True(t, resp.Allowed, resp.Result)
+
+			patchJSON, err := json.Marshal(resp.Patches)
+			require.NoError(t, err)
+			patch, err := jsonpatch.DecodePatch(patchJSON)
+			require.NoError(t, err)
+			podJSON, err := patch.Apply(raw.Raw)
+			require.NoError(t, err)
+			var pod corev1.Pod
+			require.NoError(t, json.Unmarshal(podJSON, &pod))
+
+			var initContainers, containers, proxyIDArgs []string
+			for _, container := range pod.Spec.InitContainers {
+				initContainers = append(initContainers, container.Name)
+			}
+			dataplaneLogin := len(initContainers) == 0
+			for _, container := range pod.Spec.Containers {
+				containers = append(containers, container.Name)
+				if container.Name == "web" {
+					continue
+				}
+				for _, arg := range container.Args {
+					if strings.HasPrefix(arg, "-proxy-service-id") {
+						proxyIDArgs = append(proxyIDArgs, arg)
+					}
+				}
+				if dataplaneLogin {
+					require.Contains(t, container.Args, "-ca-certs=/consul/ca/consul-ca.pem")
+					require.Contains(t, container.VolumeMounts, corev1.VolumeMount{Name: caCertVolumeName, MountPath: caCertMountPath, ReadOnly: true})
+				} else {
+					require.Contains(t, container.Args, "-ca-certs="+constants.LegacyConsulCAFile)
+				}
+			}
+			require.Equal(t, c.expInitContainers, initContainers)
+			require.Equal(t, c.expContainers, containers)
+			require.Equal(t, c.expProxyIDArgs, proxyIDArgs)
+
+			if dataplaneLogin {
+				require.Equal(t, "ca-cert", pod.Annotations[constants.KeyConsulCACert])
+				require.Contains(t, pod.Spec.Volumes, caCertVolume())
+			} else {
+				require.NotContains(t, pod.Annotations, constants.KeyConsulCACert)
+				require.NotContains(t, pod.Spec.Volumes, caCertVolume())
+			}
+		})
+	}
+}
@@ -10,6 +10,8 @@ import (
 	"github.com/miekg/dns"
 	corev1 "k8s.io/api/core/v1"
 	"k8s.io/utils/ptr"
+
+	"github.com/hashicorp/consul-k8s/control-plane/connect-inject/constants"
 )
 
 const (
@@ -21,8 +23,58 @@ const (
 
 	// defaultEtcResolvConfFile is the default location of the /etc/resolv.conf file.
 	defaultEtcResolvConfFile = "/etc/resolv.conf"
+
+	// consulDataplaneDNSConfigBindPort is the port consul-dataplane's DNS proxy binds to when
+	// the pod's dnsConfig points at it directly. Nameservers in /etc/resolv.conf cannot specify
+	// a port, so the proxy has to listen on the standard DNS port.
+	consulDataplaneDNSConfigBindPort = 53
+
+	// sysctlUnprivilegedPortStart is the sysctl that controls the lowest port
+	// non-root processes are allowed to bind to in the pod's network namespace.
+	sysctlUnprivilegedPortStart = "net.ipv4.ip_unprivileged_port_start"
 )
 
+// consulDNS returns whether Consul DNS is enabled for this pod and the redirection mode
+// that should be used to send the pod's DNS queries to consul-dataplane's DNS proxy.
+func (w *MeshWebhook) consulDNS(namespace corev1.Namespace, pod corev1.Pod) (bool, string, error) {
+	mode, err := consulDNSRedirectionMode(pod, w.ConsulDNSRedirectionMode)
+	if err != nil {
+		return false, "", err
+	}
+	enabled, err := consulDNSEnabled(namespace, pod, w.EnableConsulDNS, w.EnableTransparentProxy, mode)
+	if err != nil {
+		return false, "", err
+	}
+	return enabled, mode, nil
+}
+
+// consulDNSRedirectionMode returns the DNS redirection mode for this pod. The pod annotation
+// takes precedence over the global default. It returns an error if the mode is not supported.
+func consulDNSRedirectionMode(pod corev1.Pod, globalMode string) (string, error) {
+	mode := globalMode
+	if raw, ok := pod.Annotations[constants.AnnotationConsulDNSRedirectionMode]; ok {
+		mode = raw
+	}
+	switch mode {
+	case "":
+		return constants.DNSRedirectionModeIPTables, nil
+	case constants.DNSRedirectionModeIPTables, constants.DNSRedirectionModeDNSConfig, constants.DNSRedirectionModeCapture:
+		return mode, nil
+	default:
+		return "", fmt.Errorf("%q is not a supported DNS redirection mode, must be one of %q, %q or %q",
+			mode, constants.DNSRedirectionModeIPTables, constants.DNSRedirectionModeDNSConfig, constants.DNSRedirectionModeCapture)
+	}
+}
+
+// consulDataplaneDNSPort returns the port consul-dataplane's DNS proxy should bind to
+// for the given DNS redirection mode.
+func consulDataplaneDNSPort(redirectionMode string) int {
+	if redirectionMode == constants.DNSRedirectionModeDNSConfig {
+		return consulDataplaneDNSConfigBindPort
+	}
+	return consulDataplaneDNSBindPort
+}
+
 func (w *MeshWebhook) configureDNS(pod *corev1.Pod, k8sNS string) error {
 	// First, we need to determine the nameservers configured in this cluster from /etc/resolv.conf.
 	etcResolvConf := defaultEtcResolvConfFile
@@ -91,3 +143,26 @@ func (w *MeshWebhook) configureDNS(pod *corev1.Pod, k8sNS string) error {
 	}
 	return nil
 }
+
+// configureDNSProxyPort allows consul-dataplane, which runs as a non-root user, to bind its DNS proxy
+// to the standard DNS port. This is needed when the pod's dnsConfig points directly at the DNS proxy
+// instead of relying on iptables to redirect DNS traffic to it.
+func configureDNSProxyPort(pod *corev1.Pod) {
+	if pod.Spec.SecurityContext == nil {
+		pod.Spec.SecurityContext = &corev1.PodSecurityContext{}
+	}
+	for i, sysctl := range pod.Spec.SecurityContext.Sysctls {
+		if sysctl.Name != sysctlUnprivilegedPortStart {
+			continue
+		}
+		// Keep the user's setting if it already allows binding to the DNS port.
+		if start, err := strconv.Atoi(sysctl.Value); err != nil || start > consulDataplaneDNSConfigBindPort {
+			pod.Spec.SecurityContext.Sysctls[i].Value = strconv.Itoa(consulDataplaneDNSConfigBindPort)
+		}
+		return
+	}
+	pod.Spec.SecurityContext.Sysctls = append(pod.Spec.SecurityContext.Sysctls, corev1.Sysctl{
+		Name:  sysctlUnprivilegedPortStart,
+		Value: strconv.Itoa(consulDataplaneDNSConfigBindPort),
+	})
+}
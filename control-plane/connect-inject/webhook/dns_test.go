@@ -10,6 +10,8 @@ import (
 	"github.com/stretchr/testify/require"
 	corev1 "k8s.io/api/core/v1"
 	"k8s.io/utils/ptr"
+
+	"github.com/hashicorp/consul-k8s/control-plane/connect-inject/constants"
 )
 
 func TestMeshWebhook_configureDNS(t *testing.T) {
@@ -103,3 +105,84 @@ func TestMeshWebhook_configureDNS_error(t *testing.T) {
 	err := w.configureDNS(pod, "default")
 	require.EqualError(t, err, "DNS redirection to Consul is not supported with an already defined DNSConfig on the pod")
 }
+
+func TestConsulDNSRedirectionMode(t *testing.T) {
+	cases := map[string]struct {
+		globalMode string
+		podMode    string
+		expMode    string
+		expErr     string
+	}{
+		"defaults to iptables": {
+			expMode: constants.DNSRedirectionModeIPTables,
+		},
+		"global mode": {
+			globalMode: constants.DNSRedirectionModeDNSConfig,
+			expMode:    constants.DNSRedirectionModeDNSConfig,
+		},
+		"pod annotation overrides global mode": {
+			globalMode: constants.DNSRedirectionModeDNSConfig,
+			podMode:    constants.DNSRedirectionModeIPTables,
+			expMode:    constants.DNSRedirectionModeIPTables,
+		},
+		"capture": {
+			podMode: constants.DNSRedirectionModeCapture,
+			expMode: constants.DNSRedirectionModeCapture,
+		},
+		"invalid pod annotation": {
+			podMode: "foo",
+			expErr:  `"foo" is not a supported DNS redirection mode, must be one of "iptables", "dns-config" or "capture"`,
+		},
+	}
+	for name, c := range cases {
+		t.Run(name, func(t *testing.T) {
+			pod := minimal()
+			if c.podMode != "" {
+				pod.Annotations[constants.AnnotationConsulDNSRedirectionMode] = c.podMode
+			}
+			mode, err := consulDNSRedirectionMode(*pod, c.globalMode)
+			if c.expErr != "" {
+				require.EqualError(t, err, c.expErr)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, c.expMode, mode)
+		})
+	}
+}
+
+func TestConfigureDNSProxyPort(t *testing.T) {
+	cases := map[string]struct {
+		sysctls    []corev1.Sysctl
+		expSysctls []corev1.Sysctl
+	}{
+		"no existing sysctls": {
+			expSysctls: []corev1.Sysctl{{Name: sysctlUnprivilegedPortStart, Value: "53"}},
+		},
+		"other sysctls are preserved": {
+			sysctls: []corev1.Sysctl{{Name: "net.ipv4.tcp_syncookies", Value: "0"}},
+			expSysctls: []corev1.Sysctl{
+				{Name: "net.ipv4.tcp_syncookies", Value: "0"},
+				{Name: sysctlUnprivilegedPortStart, Value: "53"},
+			},
+		},
+		"existing higher port start is lowered": {
+			sysctls:    []corev1.Sysctl{{Name: sysctlUnprivilegedPortStart, Value: "1024"}},
+			expSysctls: []corev1.Sysctl{{Name: sysctlUnprivilegedPortStart, Value: "53"}},
+		},
+		"existing lower port start is kept": {
+			sysctls:    []corev1.Sysctl{{Name: sysctlUnprivilegedPortStart, Value: "0"}},
+			expSysctls: []corev1.Sysctl{{Name: sysctlUnprivilegedPortStart, Value: "0"}},
+		},
+	}
+	for name, c := range cases {
+		t.Run(name, func(t *testing.T) {
+			pod := minimal()
+			if c.sysctls != nil {
+				pod.Spec.SecurityContext = &corev1.PodSecurityContext{Sysctls: c.sysctls}
+			}
+			configureDNSProxyPort(pod)
+			require.Equal(t, c.expSysctls, pod.Spec.SecurityContext.Sysctls)
+		})
+	}
+}
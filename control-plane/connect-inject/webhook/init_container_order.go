@@ -0,0 +1,110 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package webhook
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+
+	"github.com/hashicorp/consul-k8s/control-plane/connect-inject/constants"
+)
+
+// initContainersAfterMesh returns whether each init container of the pod, by name, runs after the init
+// containers that are injected for the mesh. By default the init containers of the pod run before them,
+// so before the traffic of the pod is redirected to the sidecar, unless the inject-init-first annotation
+// is true. The init-containers-before-mesh and init-containers-after-mesh annotations override the
+// default for the init containers they list.
+func initContainersAfterMesh(pod corev1.Pod) (map[string]bool, error) {
+	initFirst := false
+	if raw, ok := pod.Annotations[constants.AnnotationInjectInitFirst]; ok {
+		var err error
+		initFirst, err = strconv.ParseBool(raw)
+		if err != nil {
+			return nil, fmt.Errorf("%s annotation value of %q is not a valid boolean", constants.AnnotationInjectInitFirst, raw)
+		}
+	}
+
+	before, err := annotatedInitContainers(pod, constants.AnnotationInitContainersBeforeMesh)
+	if err != nil {
+		return nil, err
+	}
+	after, err := annotatedInitContainers(pod, constants.AnnotationInitContainersAfterMesh)
+	if err != nil {
+		return nil, err
+	}
+
+	runsAfter := make(map[string]bool, len(pod.Spec.InitContainers))
+	for _, c := range pod.Spec.InitContainers {
+		switch {
+		case before[c.Name] && after[c.Name]:
+			return nil, fmt.Errorf("init container %q is listed in both the %s and %s annotations",
+				c.Name, constants.AnnotationInitContainersBeforeMesh, constants.AnnotationInitContainersAfterMesh)
+		case before[c.Name]:
+			runsAfter[c.Name] = false
+		case after[c.Name]:
+			runsAfter[c.Name] = true
+		default:
+			runsAfter[c.Name] = initFirst
+		}
+	}
+	return runsAfter, nil
+}
+
+// annotatedInitContainers returns the names of the init containers listed in a comma separated annotation.
+// It returns an error if an init container isn't in the pod.
+func annotatedInitContainers(pod corev1.Pod, annotation string) (map[string]bool, error) {
+	raw, ok := pod.Annotations[annotation]
+	if !ok {
+		return nil, nil
+	}
+	names := make(map[string]bool)
+	for _, name := range strings.Split(raw, ",") {
+		name = strings.TrimSpace(name)
+		if name == "" {
+			continue
+		}
+		found := false
+		for _, c := range pod.Spec.InitContainers {
+			if c.Name == name {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return nil, fmt.Errorf("%s annotation lists init container %q which is not in the pod", annotation, name)
+		}
+		names[name] = true
+	}
+	return names, nil
+}
+
+// orderInitContainers moves the init containers of the pod that run after the init containers injected
+// for the mesh behind them. The first userInitContainers init containers are the pod's own and the rest
+// were injected. The relative order of the pod's own init containers that run before, or after, the
+// injected ones is kept.
+func orderInitContainers(pod *corev1.Pod, userInitContainers int) error {
+	runsAfter, err := initContainersAfterMesh(corev1.Pod{
+		ObjectMeta: pod.ObjectMeta,
+		Spec:       corev1.PodSpec{InitContainers: pod.Spec.InitContainers[:userInitContainers]},
+	})
+	if err != nil {
+		return err
+	}
+
+	ordered := make([]corev1.Container, 0, len(pod.Spec.InitContainers))
+	var after []corev1.Container
+	for _, c := range pod.Spec.InitContainers[:userInitContainers] {
+		if runsAfter[c.Name] {
+			after = append(after, c)
+		} else {
+			ordered = append(ordered, c)
+		}
+	}
+	ordered = append(ordered, pod.Spec.InitContainers[userInitContainers:]...)
+	pod.Spec.InitContainers = append(ordered, after...)
+	return nil
+}
@@ -0,0 +1,105 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package webhook
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/hashicorp/consul-k8s/control-plane/connect-inject/constants"
+)
+
+func TestOrderInitContainers(t *testing.T) {
+	cases := []struct {
+		name        string
+		annotations map[string]string
+		expOrder    []string
+		expErr      string
+	}{
+		{
+			name:     "default",
+			expOrder: []string{"migrate", "seed", "wait-for-db", "consul-connect-inject-init"},
+		},
+		{
+			name:        "inject init first",
+			annotations: map[string]string{constants.AnnotationInjectInitFirst: "true"},
+			expOrder:    []string{"consul-connect-inject-init", "migrate", "seed", "wait-for-db"},
+		},
+		{
+			name:        "inject init first false",
+			annotations: map[string]string{constants.AnnotationInjectInitFirst: "false"},
+			expOrder:    []string{"migrate", "seed", "wait-for-db", "consul-connect-inject-init"},
+		},
+		{
+			name:        "after mesh",
+			annotations: map[string]string{constants.AnnotationInitContainersAfterMesh: "seed, migrate"},
+			expOrder:    []string{"wait-for-db", "consul-connect-inject-init", "migrate", "seed"},
+		},
+		{
+			name: "before mesh with inject init first",
+			annotations: map[string]string{
+				constants.AnnotationInjectInitFirst:          "true",
+				constants.AnnotationInitContainersBeforeMesh: "migrate",
+			},
+			expOrder: []string{"migrate", "consul-connect-inject-init", "seed", "wait-for-db"},
+		},
+		{
+			name: "before and after mesh",
+			annotations: map[string]string{
+				constants.AnnotationInitContainersBeforeMesh: "wait-for-db",
+				constants.AnnotationInitContainersAfterMesh:  "migrate",
+			},
+			expOrder: []string{"seed", "wait-for-db", "consul-connect-inject-init", "migrate"},
+		},
+		{
+			name:        "invalid inject init first",
+			annotations: map[string]string{constants.AnnotationInjectInitFirst: "yes"},
+			expErr:      `consul.hashicorp.com/inject-init-first annotation value of "yes" is not a valid boolean`,
+		},
+		{
+			name:        "unknown init container",
+			annotations: map[string]string{constants.AnnotationInitContainersAfterMesh: "migrate,backfill"},
+			expErr:      `consul.hashicorp.com/init-containers-after-mesh annotation lists init container "backfill" which is not in the pod`,
+		},
+		{
+			name: "init container listed twice",
+			annotations: map[string]string{
+				constants.AnnotationInitContainersBeforeMesh: "migrate",
+				constants.AnnotationInitContainersAfterMesh:  "migrate",
+			},
+			expErr: `init container "migrate" is listed in both the consul.hashicorp.com/init-containers-before-mesh and consul.hashicorp.com/init-containers-after-mesh annotations`,
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			pod := &corev1.Pod{
+				ObjectMeta: metav1.ObjectMeta{Annotations: c.annotations},
+				Spec: corev1.PodSpec{
+					InitContainers: []corev1.Container{
+						{Name: "migrate"},
+						{Name: "seed"},
+						{Name: "wait-for-db"},
+						{Name: "consul-connect-inject-init"},
+					},
+				},
+			}
+
+			err := orderInitContainers(pod, 3)
+			if c.expErr != "" {
+				require.EqualError(t, err, c.expErr)
+				return
+			}
+			require.NoError(t, err)
+			var order []string
+			for _, container := range pod.Spec.InitContainers {
+				order = append(order, container.Name)
+			}
+			require.Equal(t, c.expOrder, order)
+		})
+	}
+}
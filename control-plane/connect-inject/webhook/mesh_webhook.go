@@ -19,10 +19,10 @@ import (
 	corev1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/api/resource"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
-	"k8s.io/apimachinery/pkg/util/intstr"
 	"k8s.io/client-go/kubernetes"
 	_ "k8s.io/client-go/plugin/pkg/client/auth"
 	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
 
 	"github.com/hashicorp/consul-k8s/control-plane/connect-inject/common"
@@ -58,6 +58,10 @@ var kubeSystemNamespaces = mapset.NewSetWith(metav1.NamespaceSystem, metav1.Name
 type MeshWebhook struct {
 	Clientset kubernetes.Interface
 
+	// Client reads the TrafficRedirectionDefaults of the pod's namespace.
+	// If it is not set, only the pod's annotations configure traffic redirection exclusions.
+	Client client.Reader
+
 	// ConsulConfig is the config to create a Consul API client.
 	ConsulConfig *consul.Config
 
@@ -71,6 +75,15 @@ type MeshWebhook struct {
 	ImageConsul          string
 	ImageConsulDataplane string
 
+	// ConsulDataplaneImageOverrides is the allowlist of consul-dataplane images that pods can use instead of
+	// ImageConsulDataplane, by name. A pod uses an override with the consul-dataplane-image annotation or
+	// the consul-dataplane-image label of its namespace.
+	ConsulDataplaneImageOverrides map[string]ConsulDataplaneImageOverride
+
+	// ArchitectureProfiles are the consul-dataplane images and default sidecar resources of the pods that
+	// can only be scheduled on nodes of a CPU architecture, by the architecture, e.g. arm64.
+	ArchitectureProfiles map[string]ArchitectureProfile
+
 	// ImageConsulK8S is the container image for consul-k8s to use.
 	// This image is used for the consul-sidecar container.
 	ImageConsulK8S string
@@ -90,6 +103,15 @@ type MeshWebhook struct {
 	// use for identity with connectInjection if ACLs are enabled.
 	AuthMethod string
 
+	// LoginTokenAudience is the audience of the projected service account token that
+	// the injected containers use to log in with the auth method. If not set, the
+	// service account token mounted in the pod by Kubernetes is used.
+	LoginTokenAudience string
+
+	// LoginTokenExpirationSeconds is the requested lifetime of the projected service
+	// account token. The kubelet rotates the token before it expires.
+	LoginTokenExpirationSeconds int64
+
 	// The PEM-encoded CA certificate string
 	// to use when communicating with Consul clients over HTTPS.
 	// If not set, will use HTTP.
@@ -111,6 +133,10 @@ type MeshWebhook struct {
 	// Its value is an empty string if partitions aren't enabled.
 	ConsulPartition string
 
+	// PartitionMapping, if set, maps Kubernetes namespaces to the Admin Partitions their
+	// pods are registered in and log in to. Unmapped namespaces use ConsulPartition.
+	PartitionMapping *common.PartitionMapping
+
 	// EnableNamespaces indicates that a user is running Consul Enterprise
 	// with version 1.7+ which is namespace aware. It enables Consul namespaces,
 	// with injection into either a single Consul namespace or mirrored from
@@ -157,6 +183,10 @@ type MeshWebhook struct {
 	DefaultProxyMemoryRequest resource.Quantity
 	DefaultProxyMemoryLimit   resource.Quantity
 
+	// ProxyResourceAutoSizing, if enabled, adds to the default requests of the sidecar
+	// proxies an amount per upstream and per listener of the proxy.
+	ProxyResourceAutoSizing ProxyResourceAutoSizing
+
 	DefaultSidecarProxyStartupFailureSeconds  int
 	DefaultSidecarProxyLivenessFailureSeconds int
 
@@ -164,9 +194,24 @@ type MeshWebhook struct {
 	// configuration should come from the default flags or annotations. The meshWebhook uses this to configure container sidecar proxy args.
 	LifecycleConfig lifecycle.Config
 
+	// EnableSleepAction is whether the Kubernetes cluster supports the Sleep lifecycle handler, which is
+	// enabled by default since Kubernetes 1.30. Otherwise the pre-stop drain hooks run the sleep command of the container.
+	EnableSleepAction bool
+
+	// EnableNativeSidecarsForJobs injects the sidecar proxies of pods that run to completion, e.g. the
+	// pods of Kubernetes Jobs, as native sidecars (init containers with an Always restart policy) so that
+	// they stop when the application containers exit. It requires Kubernetes 1.29+.
+	EnableNativeSidecarsForJobs bool
+
 	// Default Envoy concurrency flag, this is the number of worker threads to be used by the proxy.
 	DefaultEnvoyProxyConcurrency int
 
+	// MaxUpstreams is the maximum number of upstreams in the upstreams annotation of a pod and
+	// MaxUpstreamsAnnotationSize is the maximum size of the annotation in bytes. Pods exceeding
+	// them are rejected. Zero means no limit.
+	MaxUpstreams               int
+	MaxUpstreamsAnnotationSize int
+
 	// MetricsConfig contains metrics configuration from the inject-connect command and has methods to determine whether
 	// configuration should come from the default flags or annotations. The meshWebhook uses this to configure prometheus
 	// annotations and the merged metrics server.
@@ -198,15 +243,43 @@ type MeshWebhook struct {
 	// from mesh services.
 	EnableConsulDNS bool
 
+	// ConsulDNSRedirectionMode is the default mechanism used to direct DNS requests to Consul
+	// when Consul DNS is enabled. It can be overridden per pod with an annotation.
+	// See constants.AnnotationConsulDNSRedirectionMode for the supported values.
+	ConsulDNSRedirectionMode string
+
 	// EnableOpenShift indicates that when tproxy is enabled, the security context for the Envoy and init
 	// containers should not be added because OpenShift sets a random user for those and will not allow
 	// those containers to be created otherwise.
 	EnableOpenShift bool
 
+	// EnableResourceAPIs configures injected pods to use the Consul resource APIs (catalog v2) which
+	// is experimental. The proxy of a pod is identified by its workload, so multiport pods run a
+	// single proxy, and connect-init waits for the workload rather than a service registration.
+	EnableResourceAPIs bool
+
+	// EnableDataplaneLogin skips the connect-inject-init container where consul-dataplane can log in
+	// and look up its proxy registration itself, which speeds up the startup of pods and removes a
+	// container that needs its own security context. See useDataplaneLogin.
+	EnableDataplaneLogin bool
+
 	// SkipServerWatch prevents consul-dataplane from consuming the server update stream. This is useful
 	// for situations where Consul servers are behind a load balancer.
 	SkipServerWatch bool
 
+	// AnnotationsToEnv maps the keys of pod annotations to the names of the environment variables of
+	// the injected containers that are set to the values of the annotations, e.g. to pass tracing tags
+	// to consul-dataplane.
+	AnnotationsToEnv map[string]string
+	// AnnotationsToLabels maps the keys of pod annotations to the keys of the labels that are added to
+	// injected pods with the values of the annotations, e.g. for cost attribution.
+	AnnotationsToLabels map[string]string
+
+	// NodeNamingStrategy decides which synthetic Consul node the service instances of injected pods
+	// are registered on. It is recorded in the consul.hashicorp.com/node-naming-strategy annotation
+	// so that the endpoints controller registers the pod on the node its containers look it up on.
+	NodeNamingStrategy common.NodeNamingStrategy
+
 	// ReleaseNamespace is the Kubernetes namespace where this webhook is running.
 	ReleaseNamespace string
 
@@ -252,9 +325,22 @@ func (w *MeshWebhook) Handle(ctx context.Context, req admission.Request) admissi
 		return admission.Errored(http.StatusInternalServerError, fmt.Errorf("error creating default annotations: %s", err))
 	}
 
+	// The labels of the namespace set whether its pods are injected by default and, for example,
+	// whether tproxy is enabled for the entire namespace. The namespace is only fetched once it is
+	// needed, so pods that are skipped before then don't cost a request, and at most once.
+	var ns *corev1.Namespace
+	getNamespace := func() (*corev1.Namespace, error) {
+		if ns != nil {
+			return ns, nil
+		}
+		var err error
+		ns, err = w.Clientset.CoreV1().Namespaces().Get(ctx, req.Namespace, metav1.GetOptions{})
+		return ns, err
+	}
+
 	// Check if we should inject, for example we don't inject in the
 	// system namespaces.
-	if shouldInject, err := w.shouldInject(pod, req.Namespace); err != nil {
+	if shouldInject, err := w.shouldInject(pod, req.Namespace, getNamespace); err != nil {
 		w.Log.Error(err, "error checking if should inject", "request name", req.Name)
 		return admission.Errored(http.StatusInternalServerError, fmt.Errorf("error checking if should inject: %s", err))
 	} else if !shouldInject {
@@ -263,10 +349,50 @@ func (w *MeshWebhook) Handle(ctx context.Context, req admission.Request) admissi
 
 	w.Log.Info("received pod", "name", req.Name, "ns", req.Namespace)
 
+	if _, err := getNamespace(); err != nil {
+		w.Log.Error(err, "error fetching namespace metadata for container", "request name", req.Name)
+		return admission.Errored(http.StatusInternalServerError, fmt.Errorf("error getting namespace metadata for container: %s", err))
+	}
+
+	// Validate the upstream config annotations so that pods with invalid values are rejected
+	// instead of failing to be registered by the endpoints controller.
+	if _, err := common.UpstreamConfig(pod); err != nil {
+		w.Log.Error(err, "error validating upstream config annotations", "request name", req.Name)
+		return admission.Errored(http.StatusBadRequest, err)
+	}
+	if err := common.ValidateUpstreams(pod, w.MaxUpstreams, w.MaxUpstreamsAnnotationSize); err != nil {
+		w.Log.Error(err, "error validating upstreams annotation", "request name", req.Name)
+		return admission.Errored(http.StatusBadRequest, err)
+	}
+	if _, err := common.ServiceWeights(pod); err != nil {
+		w.Log.Error(err, "error validating service weight annotation", "request name", req.Name)
+		return admission.Errored(http.StatusBadRequest, err)
+	}
+	if err := w.validateConsulNamespaceOverride(pod); err != nil {
+		w.Log.Error(err, "error validating consul namespace override annotation", "request name", req.Name)
+		return admission.Errored(http.StatusBadRequest, err)
+	}
+	if _, err := initContainersAfterMesh(pod); err != nil {
+		w.Log.Error(err, "error validating init container order annotations", "request name", req.Name)
+		return admission.Errored(http.StatusBadRequest, err)
+	}
+
+	// Set the service and port annotations from the connect-service-ports annotation so that
+	// the services of a multiport pod are explicitly mapped to its ports.
+	if err := common.ApplyServicePorts(&pod); err != nil {
+		w.Log.Error(err, "error validating service ports annotation", "request name", req.Name)
+		return admission.Errored(http.StatusBadRequest, err)
+	}
+
 	// Add our volume that will be shared by the init container and
 	// the sidecar for passing data in the pod.
 	pod.Spec.Volumes = append(pod.Spec.Volumes, w.containerVolume())
 
+	// Add the projected service account token used to log in with the auth method.
+	if w.AuthMethod != "" && w.LoginTokenAudience != "" {
+		pod.Spec.Volumes = append(pod.Spec.Volumes, w.loginTokenVolume())
+	}
+
 	// Optionally mount data volume to other containers
 	w.injectVolumeMount(pod)
 
@@ -280,6 +406,16 @@ func (w *MeshWebhook) Handle(ctx context.Context, req admission.Request) admissi
 		pod.Spec.Volumes = append(pod.Spec.Volumes, userVolumes...)
 	}
 
+	// Add the Secret with the certificates used to scrape the service metrics over TLS, if one is set.
+	metricsTLSSecret, err := w.serviceMetricsTLSSecret(pod)
+	if err != nil {
+		w.Log.Error(err, "error validating merged metrics TLS annotations", "request name", req.Name)
+		return admission.Errored(http.StatusBadRequest, err)
+	}
+	if metricsTLSSecret != "" {
+		pod.Spec.Volumes = append(pod.Spec.Volumes, serviceMetricsTLSVolume(metricsTLSSecret))
+	}
+
 	// Add the upstream services as environment variables for easy
 	// service discovery.
 	containerEnvVars := w.containerEnvVars(pod)
@@ -291,30 +427,75 @@ func (w *MeshWebhook) Handle(ctx context.Context, req admission.Request) admissi
 		pod.Spec.Containers[i].Env = append(pod.Spec.Containers[i].Env, containerEnvVars...)
 	}
 
-	// A user can enable/disable tproxy for an entire namespace via a label.
-	ns, err := w.Clientset.CoreV1().Namespaces().Get(ctx, req.Namespace, metav1.GetOptions{})
+	// Add the image pull secrets of the consul-dataplane image override, if the pod or its namespace uses one,
+	// or else of the image of the pod's architecture profile.
+	dataplaneImageOverride, err := w.consulDataplaneImageOverride(*ns, pod)
 	if err != nil {
-		w.Log.Error(err, "error fetching namespace metadata for container", "request name", req.Name)
-		return admission.Errored(http.StatusInternalServerError, fmt.Errorf("error getting namespace metadata for container: %s", err))
+		w.Log.Error(err, "error validating consul-dataplane image override", "request name", req.Name)
+		return admission.Errored(http.StatusBadRequest, err)
+	}
+	if dataplaneImageOverride != nil {
+		addImagePullSecrets(&pod, dataplaneImageOverride.ImagePullSecrets)
+	} else if profile := w.architectureProfile(pod); profile != nil && profile.Image != "" {
+		addImagePullSecrets(&pod, profile.ImagePullSecrets)
+	}
+
+	// Translate exec probes into HTTP probes if requested. This MUST be done before the init container
+	// is created since the traffic redirection config excludes the ports of overwritten HTTP probes.
+	if err = w.translateExecProbes(*ns, &pod); err != nil {
+		w.Log.Error(err, "error translating exec probes", "request name", req.Name)
+		return admission.Errored(http.StatusInternalServerError, fmt.Errorf("error translating exec probes: %s", err))
+	}
+
+	// Delay the shutdown of the application containers so that in-flight requests complete. This MUST be
+	// done before the sidecars are added since they get their own preStop hook.
+	if err = w.injectPreStopDrainHooks(&pod); err != nil {
+		w.Log.Error(err, "error configuring pre-stop drain hooks", "request name", req.Name)
+		return admission.Errored(http.StatusBadRequest, err)
+	}
+
+	// Give the sidecar proxy enough time to drain its listeners before the kubelet kills the pod's containers.
+	if err = w.ensureTerminationGracePeriod(*ns, &pod); err != nil {
+		w.Log.Error(err, "error configuring termination grace period", "request name", req.Name)
+		return admission.Errored(http.StatusBadRequest, err)
 	}
 
 	// Get service names from the annotation. If theres 0-1 service names, it's a single port pod, otherwise it's multi
 	// port.
 	annotatedSvcNames := w.annotatedServiceNames(pod)
-	multiPort := len(annotatedSvcNames) > 1
+	multiPort := len(annotatedSvcNames) > 1 && !w.EnableResourceAPIs
+	// The init containers injected below are appended to the pod's own and ordered afterwards.
+	userInitContainers := len(pod.Spec.InitContainers)
 	lifecycleEnabled, ok := w.LifecycleConfig.EnableProxyLifecycle(pod)
 	if ok != nil {
 		w.Log.Error(err, "unable to get lifecycle enabled status")
 	}
+	nativeSidecar, err := w.useNativeSidecars(pod)
+	if err != nil {
+		w.Log.Error(err, "error determining if the sidecars are native", "request name", req.Name)
+		return admission.Errored(http.StatusBadRequest, err)
+	}
+	dataplaneLogin, err := w.useDataplaneLogin(*ns, pod)
+	if err != nil {
+		w.Log.Error(err, "error determining if consul-dataplane logs in without the init container", "request name", req.Name)
+		return admission.Errored(http.StatusBadRequest, err)
+	}
+	if dataplaneLogin && w.TLSEnabled && w.ConsulCACert != "" {
+		// The CA certificate is otherwise written to the shared volume by the init container.
+		pod.Annotations[constants.KeyConsulCACert] = w.ConsulCACert
+		pod.Spec.Volumes = append(pod.Spec.Volumes, caCertVolume())
+	}
 	// For single port pods, add the single init container and envoy sidecar.
 	if !multiPort {
 		// Add the init container that registers the service and sets up the Envoy configuration.
-		initContainer, err := w.containerInit(*ns, pod, multiPortInfo{})
-		if err != nil {
-			w.Log.Error(err, "error configuring injection init container", "request name", req.Name)
-			return admission.Errored(http.StatusInternalServerError, fmt.Errorf("error configuring injection init container: %s", err))
+		if !dataplaneLogin {
+			initContainer, err := w.containerInit(*ns, pod, multiPortInfo{})
+			if err != nil {
+				w.Log.Error(err, "error configuring injection init container", "request name", req.Name)
+				return admission.Errored(http.StatusInternalServerError, fmt.Errorf("error configuring injection init container: %s", err))
+			}
+			pod.Spec.InitContainers = append(pod.Spec.InitContainers, initContainer)
 		}
-		pod.Spec.InitContainers = append(pod.Spec.InitContainers, initContainer)
 
 		// Add the Envoy sidecar.
 		envoySidecar, err := w.consulDataplaneSidecar(*ns, pod, multiPortInfo{})
@@ -324,7 +505,9 @@ func (w *MeshWebhook) Handle(ctx context.Context, req admission.Request) admissi
 		}
 		//Append the Envoy sidecar before the application container only if lifecycle enabled.
 
-		if lifecycleEnabled && ok == nil {
+		if nativeSidecar {
+			pod.Spec.InitContainers = append(pod.Spec.InitContainers, nativeSidecarContainer(envoySidecar))
+		} else if lifecycleEnabled && ok == nil {
 			pod.Spec.Containers = append([]corev1.Container{envoySidecar}, pod.Spec.Containers...)
 		} else {
 			pod.Spec.Containers = append(pod.Spec.Containers, envoySidecar)
@@ -390,12 +573,14 @@ func (w *MeshWebhook) Handle(ctx context.Context, req admission.Request) admissi
 			}
 
 			// Add the init container that registers the service and sets up the Envoy configuration.
-			initContainer, err := w.containerInit(*ns, pod, mpi)
-			if err != nil {
-				w.Log.Error(err, "error configuring injection init container", "request name", req.Name)
-				return admission.Errored(http.StatusInternalServerError, fmt.Errorf("error configuring injection init container: %s", err))
+			if !dataplaneLogin {
+				initContainer, err := w.containerInit(*ns, pod, mpi)
+				if err != nil {
+					w.Log.Error(err, "error configuring injection init container", "request name", req.Name)
+					return admission.Errored(http.StatusInternalServerError, fmt.Errorf("error configuring injection init container: %s", err))
+				}
+				pod.Spec.InitContainers = append(pod.Spec.InitContainers, initContainer)
 			}
-			pod.Spec.InitContainers = append(pod.Spec.InitContainers, initContainer)
 
 			// Add the Envoy sidecar.
 			envoySidecar, err := w.consulDataplaneSidecar(*ns, pod, mpi)
@@ -403,9 +588,9 @@ func (w *MeshWebhook) Handle(ctx context.Context, req admission.Request) admissi
 				w.Log.Error(err, "error configuring injection sidecar container", "request name", req.Name)
 				return admission.Errored(http.StatusInternalServerError, fmt.Errorf("error configuring injection sidecar container: %s", err))
 			}
-			// If Lifecycle is enabled, add to the list of sidecar containers to be added
-			// to pod containers at the end in order to preserve relative ordering.
-			if lifecycleEnabled {
+			// If Lifecycle is enabled or the sidecars are native, add to the list of sidecar containers
+			// to be added to the pod at the end in order to preserve relative ordering.
+			if lifecycleEnabled || nativeSidecar {
 				sidecarContainers = append(sidecarContainers, envoySidecar)
 			} else {
 				pod.Spec.Containers = append(pod.Spec.Containers, envoySidecar)
@@ -415,11 +600,20 @@ func (w *MeshWebhook) Handle(ctx context.Context, req admission.Request) admissi
 		}
 
 		//Add sidecar containers first if lifecycle enabled.
-		if lifecycleEnabled {
+		if nativeSidecar {
+			for _, sidecar := range sidecarContainers {
+				pod.Spec.InitContainers = append(pod.Spec.InitContainers, nativeSidecarContainer(sidecar))
+			}
+		} else if lifecycleEnabled {
 			pod.Spec.Containers = append(sidecarContainers, pod.Spec.Containers...)
 		}
 	}
 
+	if err = orderInitContainers(&pod, userInitContainers); err != nil {
+		w.Log.Error(err, "error ordering init containers", "request name", req.Name)
+		return admission.Errored(http.StatusBadRequest, err)
+	}
+
 	// pod.Annotations has already been initialized by h.defaultAnnotations()
 	// and does not need to be checked for being a nil value.
 	pod.Annotations[constants.KeyInjectStatus] = constants.Injected
@@ -437,16 +631,22 @@ func (w *MeshWebhook) Handle(ctx context.Context, req admission.Request) admissi
 	}
 
 	// If DNS redirection is enabled, we want to configure dns on the pod.
-	dnsEnabled, err := consulDNSEnabled(*ns, pod, w.EnableConsulDNS, w.EnableTransparentProxy)
+	dnsEnabled, dnsMode, err := w.consulDNS(*ns, pod)
 	if err != nil {
 		w.Log.Error(err, "error determining if dns redirection is enabled", "request name", req.Name)
 		return admission.Errored(http.StatusInternalServerError, fmt.Errorf("error determining if dns redirection is enabled: %s", err))
 	}
-	if dnsEnabled {
+	// With the capture redirection mode, DNS traffic to the pod's existing nameservers is redirected
+	// to the DNS proxy so the pod's DNS config is left as is.
+	if dnsEnabled && dnsMode != constants.DNSRedirectionModeCapture {
 		if err = w.configureDNS(&pod, req.Namespace); err != nil {
 			w.Log.Error(err, "error configuring DNS on the pod", "request name", req.Name)
 			return admission.Errored(http.StatusInternalServerError, fmt.Errorf("error configuring DNS on the pod: %s", err))
 		}
+		// Without iptables redirection, the DNS proxy must listen on the port the pod's resolver uses.
+		if dnsMode == constants.DNSRedirectionModeDNSConfig {
+			configureDNSProxyPort(&pod)
+		}
 	}
 
 	// Add annotations for metrics.
@@ -464,9 +664,12 @@ func (w *MeshWebhook) Handle(ctx context.Context, req admission.Request) admissi
 	// from consul-k8s without Endpoints controller to consul-k8s with Endpoints controller.
 	pod.Labels[constants.KeyManagedBy] = constants.ManagedByValue
 
+	// Copy the allowlisted annotations into labels for the tooling that selects pods by label.
+	w.passthroughAnnotationLabels(&pod)
+
 	// Consul-ENT only: Add the Consul destination namespace as an annotation to the pod.
 	if w.EnableNamespaces {
-		pod.Annotations[constants.AnnotationConsulNamespace] = w.consulNamespace(req.Namespace)
+		pod.Annotations[constants.AnnotationConsulNamespace] = w.podConsulNamespace(pod, req.Namespace)
 	}
 
 	// Overwrite readiness/liveness probes if needed.
@@ -506,18 +709,18 @@ func (w *MeshWebhook) Handle(ctx context.Context, req admission.Request) admissi
 		serverState, err := w.ConsulServerConnMgr.State()
 		if err != nil {
 			w.Log.Error(err, "error checking or creating namespace",
-				"ns", w.consulNamespace(req.Namespace), "request name", req.Name)
+				"ns", w.podConsulNamespace(pod, req.Namespace), "request name", req.Name)
 			return admission.Errored(http.StatusInternalServerError, fmt.Errorf("error checking or creating namespace: %s", err))
 		}
 		apiClient, err := consul.NewClientFromConnMgrState(w.ConsulConfig, serverState)
 		if err != nil {
 			w.Log.Error(err, "error checking or creating namespace",
-				"ns", w.consulNamespace(req.Namespace), "request name", req.Name)
+				"ns", w.podConsulNamespace(pod, req.Namespace), "request name", req.Name)
 			return admission.Errored(http.StatusInternalServerError, fmt.Errorf("error checking or creating namespace: %s", err))
 		}
-		if _, err := namespaces.EnsureExists(apiClient, w.consulNamespace(req.Namespace), w.CrossNamespaceACLPolicy); err != nil {
+		if _, err := namespaces.EnsureExists(apiClient, w.podConsulNamespace(pod, req.Namespace), w.CrossNamespaceACLPolicy); err != nil {
 			w.Log.Error(err, "error checking or creating namespace",
-				"ns", w.consulNamespace(req.Namespace), "request name", req.Name)
+				"ns", w.podConsulNamespace(pod, req.Namespace), "request name", req.Name)
 			return admission.Errored(http.StatusInternalServerError, fmt.Errorf("error checking or creating namespace: %s", err))
 		}
 	}
@@ -541,24 +744,51 @@ func (w *MeshWebhook) overwriteProbes(ns corev1.Namespace, pod *corev1.Pod) erro
 	}
 
 	if tproxyEnabled && overwriteProbes {
-		// We don't use the loop index because this needs to line up w.withiptablesConfigJSON,
-		// which is performed before the sidecar is injected.
-		idx := 0
-		for _, container := range pod.Spec.Containers {
-			// skip the "envoy-sidecar" container from having it's probes overridden
-			if container.Name == sidecarContainer {
-				continue
-			}
-			if container.LivenessProbe != nil && container.LivenessProbe.HTTPGet != nil {
-				container.LivenessProbe.HTTPGet.Port = intstr.FromInt(exposedPathsLivenessPortsRangeStart + idx)
-			}
-			if container.ReadinessProbe != nil && container.ReadinessProbe.HTTPGet != nil {
-				container.ReadinessProbe.HTTPGet.Port = intstr.FromInt(exposedPathsReadinessPortsRangeStart + idx)
-			}
-			if container.StartupProbe != nil && container.StartupProbe.HTTPGet != nil {
-				container.StartupProbe.HTTPGet.Port = intstr.FromInt(exposedPathsStartupPortsRangeStart + idx)
+		// The overwritten probes need to line up with w.iptablesConfigJSON, which is computed
+		// before the sidecar is injected.
+		overwrites, err := probeOverwrites(pod)
+		if err != nil {
+			return err
+		}
+		for _, overwrite := range overwrites {
+			overwrite.setExposedPort()
+		}
+	}
+	return nil
+}
+
+// translateExecProbes replaces exec probes that make an HTTP request to the application over
+// the loopback interface with the equivalent HTTP probes. This only happens when transparent proxy
+// and overwrite probes are enabled and the pod opts in via annotation, so that the translated probes
+// can then be overwritten to go through the Envoy proxy by overwriteProbes.
+func (w *MeshWebhook) translateExecProbes(ns corev1.Namespace, pod *corev1.Pod) error {
+	translateExecProbes, err := common.ShouldTranslateExecProbes(*pod)
+	if err != nil {
+		return err
+	}
+	if !translateExecProbes {
+		return nil
+	}
+
+	tproxyEnabled, err := common.TransparentProxyEnabled(ns, *pod, w.EnableTransparentProxy)
+	if err != nil {
+		return err
+	}
+	overwriteProbes, err := common.ShouldOverwriteProbes(*pod, w.TProxyOverwriteProbes)
+	if err != nil {
+		return err
+	}
+	if !tproxyEnabled || !overwriteProbes {
+		return nil
+	}
+
+	for i := range pod.Spec.Containers {
+		container := &pod.Spec.Containers[i]
+		for _, probe := range []*corev1.Probe{container.LivenessProbe, container.ReadinessProbe, container.StartupProbe} {
+			if action, ok := common.HTTPGetActionFromExecProbe(probe); ok {
+				probe.Exec = nil
+				probe.HTTPGet = action
 			}
-			idx++
 		}
 	}
 	return nil
@@ -577,7 +807,9 @@ func (w *MeshWebhook) injectVolumeMount(pod corev1.Pod) {
 	}
 }
 
-func (w *MeshWebhook) shouldInject(pod corev1.Pod, namespace string) (bool, error) {
+// shouldInject returns whether the pod is injected. getNamespace returns the namespace of the pod and is
+// only called if the injection depends on its labels.
+func (w *MeshWebhook) shouldInject(pod corev1.Pod, namespace string, getNamespace func() (*corev1.Namespace, error)) (bool, error) {
 	// Don't inject in the Kubernetes system namespaces
 	if kubeSystemNamespaces.Contains(namespace) {
 		return false, nil
@@ -606,6 +838,19 @@ func (w *MeshWebhook) shouldInject(pod corev1.Pod, namespace string) (bool, erro
 		return strconv.ParseBool(raw)
 	}
 
+	// Otherwise the label of the namespace sets whether its pods are injected by default.
+	ns, err := getNamespace()
+	if err != nil {
+		return false, fmt.Errorf("error getting namespace %s: %w", namespace, err)
+	}
+	if raw, ok := ns.Labels[constants.LabelDefaultInject]; ok {
+		defaultInject, err := strconv.ParseBool(raw)
+		if err != nil {
+			return false, fmt.Errorf("%s label value %q of namespace %s is not a boolean", constants.LabelDefaultInject, raw, namespace)
+		}
+		return defaultInject, nil
+	}
+
 	return !w.RequireAnnotation, nil
 }
 
@@ -629,6 +874,9 @@ func (w *MeshWebhook) defaultAnnotations(pod *corev1.Pod, podJson string) error
 	pod.Annotations[constants.AnnotationOriginalPod] = podJson
 	pod.Annotations[constants.LegacyAnnotationConsulK8sVersion] = version.GetHumanVersion()
 	pod.Annotations[constants.AnnotationConsulK8sVersion] = version.GetHumanVersion()
+	if w.NodeNamingStrategy != "" && w.NodeNamingStrategy != common.NodeNamingPerNode {
+		pod.Annotations[constants.AnnotationNodeNamingStrategy] = string(w.NodeNamingStrategy)
+	}
 
 	return nil
 }
@@ -661,6 +909,58 @@ func (w *MeshWebhook) consulNamespace(ns string) string {
 	return namespaces.ConsulNamespace(ns, w.EnableNamespaces, w.ConsulDestinationNamespace, w.EnableK8SNSMirroring, w.K8SNSMirroringPrefix)
 }
 
+// podConsulNamespace returns the namespace that the services of the pod should be registered in,
+// which is the namespace of its override annotation if it has one. It returns an empty string if
+// namespaces aren't enabled.
+func (w *MeshWebhook) podConsulNamespace(pod corev1.Pod, ns string) string {
+	if override := pod.Annotations[constants.AnnotationConsulNamespaceOverride]; w.EnableNamespaces && override != "" {
+		return override
+	}
+	return w.consulNamespace(ns)
+}
+
+// validateConsulNamespaceOverride returns an error if the pod overrides its Consul namespace
+// when that isn't supported.
+func (w *MeshWebhook) validateConsulNamespaceOverride(pod corev1.Pod) error {
+	if _, ok := pod.Annotations[constants.AnnotationConsulNamespaceOverride]; !ok {
+		return nil
+	}
+	if !w.EnableNamespaces {
+		return fmt.Errorf("%s annotation requires Consul namespaces to be enabled", constants.AnnotationConsulNamespaceOverride)
+	}
+	if pod.Annotations[constants.AnnotationConsulNamespaceOverride] == "" {
+		return fmt.Errorf("%s annotation must not be empty", constants.AnnotationConsulNamespaceOverride)
+	}
+	// The pod's ACL token is bound to the Consul namespace of its Kubernetes namespace, so it
+	// couldn't be used to register or configure the services in another namespace.
+	if w.AuthMethod != "" {
+		return fmt.Errorf("%s annotation is not supported when ACLs are enabled", constants.AnnotationConsulNamespaceOverride)
+	}
+	return nil
+}
+
+// consulPartition returns the Admin Partition of pods in the Kubernetes namespace.
+func (w *MeshWebhook) consulPartition(ns string) string {
+	if w.PartitionMapping != nil {
+		return w.PartitionMapping.Partition(ns)
+	}
+	return w.ConsulPartition
+}
+
+// loginTokenVolumeMount returns the volume mount of the service account token that the injected
+// containers use to log in with the auth method, and the path of the token. This is the projected,
+// audience-bound token if a login token audience is set, and the pod's service account token otherwise.
+func (w *MeshWebhook) loginTokenVolumeMount(pod corev1.Pod, multiPortSvcName string) (corev1.VolumeMount, string, error) {
+	if w.LoginTokenAudience != "" && multiPortSvcName == "" {
+		return corev1.VolumeMount{
+			Name:      loginTokenVolumeName,
+			ReadOnly:  true,
+			MountPath: loginTokenMountPath,
+		}, filepath.Join(loginTokenMountPath, loginTokenPath), nil
+	}
+	return findServiceAccountVolumeMount(pod, multiPortSvcName)
+}
+
 func findServiceAccountVolumeMount(pod corev1.Pod, multiPortSvcName string) (corev1.VolumeMount, string, error) {
 	// In the case of a multiPort pod, there may be another service account
 	// token mounted as a different volume. Its name must be <svc>-serviceaccount.
@@ -728,12 +1028,16 @@ func (w *MeshWebhook) checkUnsupportedMultiPortCases(ns corev1.Namespace, pod co
 	if metricsMergingEnabled {
 		return fmt.Errorf("multi port services are not compatible with metrics merging")
 	}
+	if w.AuthMethod != "" && w.LoginTokenAudience != "" {
+		return fmt.Errorf("multi port services are not compatible with audience-bound login tokens")
+	}
 	return nil
 }
 
 func (w *MeshWebhook) SetupWithManager(mgr ctrl.Manager) {
 	w.decoder = admission.NewDecoder(mgr.GetScheme())
 	mgr.GetWebhookServer().Register("/mutate", &admission.Webhook{Handler: w})
+	mgr.GetWebhookServer().Register("/validate", &admission.Webhook{Handler: &podAnnotationValidator{w: w}})
 }
 
 func sliceContains(slice []string, entry string) bool {
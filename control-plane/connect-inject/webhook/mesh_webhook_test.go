@@ -100,6 +100,137 @@ func TestHandlerHandle(t *testing.T) {
 			nil,
 		},
 
+		{
+			"invalid upstream config annotation",
+			MeshWebhook{
+				Log:                   logrtest.New(t),
+				AllowK8sNamespacesSet: mapset.NewSetWith("*"),
+				DenyK8sNamespacesSet:  mapset.NewSet(),
+				decoder:               decoder,
+				Clientset:             defaultTestClientWithNamespace(),
+			},
+			admission.Request{
+				AdmissionRequest: admissionv1.AdmissionRequest{
+					Namespace: namespaces.DefaultNamespace,
+					Object: encodeRaw(t, &corev1.Pod{
+						ObjectMeta: metav1.ObjectMeta{
+							Annotations: map[string]string{
+								constants.AnnotationOutlierMaxEjectionPercent: "150",
+							},
+						},
+						Spec: basicSpec,
+					}),
+				},
+			},
+			"consul.hashicorp.com/outlier-max-ejection-percent annotation value of 150 must not be greater than 100",
+			nil,
+		},
+
+		{
+			"invalid service weight annotation",
+			MeshWebhook{
+				Log:                   logrtest.New(t),
+				AllowK8sNamespacesSet: mapset.NewSetWith("*"),
+				DenyK8sNamespacesSet:  mapset.NewSet(),
+				decoder:               decoder,
+				Clientset:             defaultTestClientWithNamespace(),
+			},
+			admission.Request{
+				AdmissionRequest: admissionv1.AdmissionRequest{
+					Namespace: namespaces.DefaultNamespace,
+					Object: encodeRaw(t, &corev1.Pod{
+						ObjectMeta: metav1.ObjectMeta{
+							Annotations: map[string]string{
+								constants.AnnotationServiceWeight: "passing=ten",
+							},
+						},
+						Spec: basicSpec,
+					}),
+				},
+			},
+			`consul.hashicorp.com/service-weight annotation value has invalid weight "ten" for state "passing"`,
+			nil,
+		},
+
+		{
+			"invalid init container order annotation",
+			MeshWebhook{
+				Log:                   logrtest.New(t),
+				AllowK8sNamespacesSet: mapset.NewSetWith("*"),
+				DenyK8sNamespacesSet:  mapset.NewSet(),
+				decoder:               decoder,
+				Clientset:             defaultTestClientWithNamespace(),
+			},
+			admission.Request{
+				AdmissionRequest: admissionv1.AdmissionRequest{
+					Namespace: namespaces.DefaultNamespace,
+					Object: encodeRaw(t, &corev1.Pod{
+						ObjectMeta: metav1.ObjectMeta{
+							Annotations: map[string]string{
+								constants.AnnotationInitContainersAfterMesh: "migrate",
+							},
+						},
+						Spec: basicSpec,
+					}),
+				},
+			},
+			`consul.hashicorp.com/init-containers-after-mesh annotation lists init container "migrate" which is not in the pod`,
+			nil,
+		},
+
+		{
+			"too many upstreams",
+			MeshWebhook{
+				Log:                   logrtest.New(t),
+				AllowK8sNamespacesSet: mapset.NewSetWith("*"),
+				DenyK8sNamespacesSet:  mapset.NewSet(),
+				decoder:               decoder,
+				Clientset:             defaultTestClientWithNamespace(),
+				MaxUpstreams:          1,
+			},
+			admission.Request{
+				AdmissionRequest: admissionv1.AdmissionRequest{
+					Namespace: namespaces.DefaultNamespace,
+					Object: encodeRaw(t, &corev1.Pod{
+						ObjectMeta: metav1.ObjectMeta{
+							Annotations: map[string]string{
+								constants.AnnotationUpstreams: "echo:1234,db:1235",
+							},
+						},
+						Spec: basicSpec,
+					}),
+				},
+			},
+			"consul.hashicorp.com/connect-service-upstreams annotation has 2 upstreams, which exceeds the maximum of 1 upstreams",
+			nil,
+		},
+
+		{
+			"malformed upstream",
+			MeshWebhook{
+				Log:                   logrtest.New(t),
+				AllowK8sNamespacesSet: mapset.NewSetWith("*"),
+				DenyK8sNamespacesSet:  mapset.NewSet(),
+				decoder:               decoder,
+				Clientset:             defaultTestClientWithNamespace(),
+			},
+			admission.Request{
+				AdmissionRequest: admissionv1.AdmissionRequest{
+					Namespace: namespaces.DefaultNamespace,
+					Object: encodeRaw(t, &corev1.Pod{
+						ObjectMeta: metav1.ObjectMeta{
+							Annotations: map[string]string{
+								constants.AnnotationUpstreams: "echo:1234,db",
+							},
+						},
+						Spec: basicSpec,
+					}),
+				},
+			},
+			`consul.hashicorp.com/connect-service-upstreams annotation has malformed upstream "db": no port set`,
+			nil,
+		},
+
 		{
 			"empty pod basic",
 			MeshWebhook{
@@ -791,6 +922,292 @@ func TestHandlerHandle(t *testing.T) {
 				},
 			},
 		},
+		{
+			"merged metrics TLS secret without the https scheme",
+			MeshWebhook{
+				Log:                   logrtest.New(t),
+				AllowK8sNamespacesSet: mapset.NewSetWith("*"),
+				DenyK8sNamespacesSet:  mapset.NewSet(),
+				decoder:               decoder,
+				Clientset:             defaultTestClientWithNamespace(),
+			},
+			admission.Request{
+				AdmissionRequest: admissionv1.AdmissionRequest{
+					Namespace: namespaces.DefaultNamespace,
+					Object: encodeRaw(t, &corev1.Pod{
+						Spec: basicSpec,
+						ObjectMeta: metav1.ObjectMeta{
+							Annotations: map[string]string{
+								constants.AnnotationEnableMetrics:             "true",
+								constants.AnnotationEnableMetricsMerging:      "true",
+								constants.AnnotationServiceMetricsPort:        "8080",
+								constants.AnnotationMergedMetricsAppTLSSecret: "web-metrics-tls",
+							},
+						},
+					}),
+				},
+			},
+			`consul.hashicorp.com/merged-metrics-app-tls-secret annotation requires consul.hashicorp.com/merged-metrics-app-scheme to be "https"`,
+			nil,
+		},
+		{
+			"consul-dataplane image override not in allowlist",
+			MeshWebhook{
+				Log:                   logrtest.New(t),
+				AllowK8sNamespacesSet: mapset.NewSetWith("*"),
+				DenyK8sNamespacesSet:  mapset.NewSet(),
+				decoder:               decoder,
+				Clientset:             defaultTestClientWithNamespace(),
+				ConsulDataplaneImageOverrides: map[string]ConsulDataplaneImageOverride{
+					"pinned": {Image: "hashicorp/consul-dataplane@sha256:1234"},
+				},
+			},
+			admission.Request{
+				AdmissionRequest: admissionv1.AdmissionRequest{
+					Namespace: namespaces.DefaultNamespace,
+					Object: encodeRaw(t, &corev1.Pod{
+						Spec: basicSpec,
+						ObjectMeta: metav1.ObjectMeta{
+							Annotations: map[string]string{
+								constants.AnnotationConsulDataplaneImage: "hashicorp/consul-dataplane:latest",
+							},
+						},
+					}),
+				},
+			},
+			`consul.hashicorp.com/consul-dataplane-image annotation value "hashicorp/consul-dataplane:latest" is not an allowed consul-dataplane image override`,
+			nil,
+		},
+		{
+			"consul-dataplane image override of namespace not in allowlist",
+			MeshWebhook{
+				Log:                   logrtest.New(t),
+				AllowK8sNamespacesSet: mapset.NewSetWith("*"),
+				DenyK8sNamespacesSet:  mapset.NewSet(),
+				decoder:               decoder,
+				Clientset: fake.NewSimpleClientset(&corev1.Namespace{
+					ObjectMeta: metav1.ObjectMeta{
+						Name:   "default",
+						Labels: map[string]string{constants.LabelConsulDataplaneImage: "canary"},
+					},
+				}),
+			},
+			admission.Request{
+				AdmissionRequest: admissionv1.AdmissionRequest{
+					Namespace: namespaces.DefaultNamespace,
+					Object: encodeRaw(t, &corev1.Pod{
+						Spec: basicSpec,
+					}),
+				},
+			},
+			`consul.hashicorp.com/consul-dataplane-image label value "canary" of namespace default is not an allowed consul-dataplane image override`,
+			nil,
+		},
+		{
+			"consul-dataplane image override of namespace with image pull secrets",
+			MeshWebhook{
+				Log:                   logrtest.New(t),
+				AllowK8sNamespacesSet: mapset.NewSetWith("*"),
+				DenyK8sNamespacesSet:  mapset.NewSet(),
+				decoder:               decoder,
+				Clientset: fake.NewSimpleClientset(&corev1.Namespace{
+					ObjectMeta: metav1.ObjectMeta{
+						Name:   "default",
+						Labels: map[string]string{constants.LabelConsulDataplaneImage: "canary"},
+					},
+				}),
+				ConsulDataplaneImageOverrides: map[string]ConsulDataplaneImageOverride{
+					"canary": {
+						Image:            "registry.example.com/consul-dataplane@sha256:1234",
+						ImagePullSecrets: []corev1.LocalObjectReference{{Name: "registry-credentials"}},
+					},
+				},
+			},
+			admission.Request{
+				AdmissionRequest: admissionv1.AdmissionRequest{
+					Namespace: namespaces.DefaultNamespace,
+					Object: encodeRaw(t, &corev1.Pod{
+						Spec: basicSpec,
+					}),
+				},
+			},
+			"",
+			[]jsonpatch.Operation{
+				{
+					Operation: "add",
+					Path:      "/metadata/labels",
+				},
+				{
+					Operation: "add",
+					Path:      "/metadata/annotations",
+				},
+				{
+					Operation: "add",
+					Path:      "/spec/volumes",
+				},
+				{
+					Operation: "add",
+					Path:      "/spec/initContainers",
+				},
+				{
+					Operation: "add",
+					Path:      "/spec/containers/1",
+				},
+				{
+					Operation: "add",
+					Path:      "/spec/imagePullSecrets",
+				},
+			},
+		},
+		{
+			"architecture profile with image pull secrets",
+			MeshWebhook{
+				Log:                   logrtest.New(t),
+				AllowK8sNamespacesSet: mapset.NewSetWith("*"),
+				DenyK8sNamespacesSet:  mapset.NewSet(),
+				decoder:               decoder,
+				Clientset:             defaultTestClientWithNamespace(),
+				ArchitectureProfiles: map[string]ArchitectureProfile{
+					"arm64": {
+						Image:            "registry.example.com/consul-dataplane:arm64",
+						ImagePullSecrets: []corev1.LocalObjectReference{{Name: "registry-credentials"}},
+					},
+				},
+			},
+			admission.Request{
+				AdmissionRequest: admissionv1.AdmissionRequest{
+					Namespace: namespaces.DefaultNamespace,
+					Object: encodeRaw(t, &corev1.Pod{
+						Spec: corev1.PodSpec{
+							Containers:   basicSpec.Containers,
+							NodeSelector: map[string]string{corev1.LabelArchStable: "arm64"},
+						},
+					}),
+				},
+			},
+			"",
+			[]jsonpatch.Operation{
+				{
+					Operation: "add",
+					Path:      "/metadata/labels",
+				},
+				{
+					Operation: "add",
+					Path:      "/metadata/annotations",
+				},
+				{
+					Operation: "add",
+					Path:      "/spec/volumes",
+				},
+				{
+					Operation: "add",
+					Path:      "/spec/initContainers",
+				},
+				{
+					Operation: "add",
+					Path:      "/spec/containers/1",
+				},
+				{
+					Operation: "add",
+					Path:      "/spec/imagePullSecrets",
+				},
+			},
+		},
+		{
+			"invalid service ports annotation",
+			MeshWebhook{
+				Log:                   logrtest.New(t),
+				AllowK8sNamespacesSet: mapset.NewSetWith("*"),
+				DenyK8sNamespacesSet:  mapset.NewSet(),
+				decoder:               decoder,
+				Clientset:             defaultTestClientWithNamespace(),
+			},
+			admission.Request{
+				AdmissionRequest: admissionv1.AdmissionRequest{
+					Namespace: namespaces.DefaultNamespace,
+					Object: encodeRaw(t, &corev1.Pod{
+						Spec: basicSpec,
+						ObjectMeta: metav1.ObjectMeta{
+							Annotations: map[string]string{
+								constants.AnnotationServicePorts: `[{"service":"web","port":"http"}]`,
+							},
+						},
+					}),
+				},
+			},
+			`consul.hashicorp.com/connect-service-ports annotation value has invalid port for service "web": named port "http" not found`,
+			nil,
+		},
+		{
+			"multiport pod with service ports annotation",
+			MeshWebhook{
+				Log:                   logrtest.New(t),
+				AllowK8sNamespacesSet: mapset.NewSetWith("*"),
+				DenyK8sNamespacesSet:  mapset.NewSet(),
+				decoder:               decoder,
+				Clientset:             defaultTestClientWithNamespace(),
+			},
+			admission.Request{
+				AdmissionRequest: admissionv1.AdmissionRequest{
+					Namespace: namespaces.DefaultNamespace,
+					Object: encodeRaw(t, &corev1.Pod{
+						Spec: basicSpec,
+						ObjectMeta: metav1.ObjectMeta{
+							Annotations: map[string]string{
+								constants.AnnotationServicePorts: `[{"service":"web","port":"8080"},{"service":"web-admin","port":"9090"}]`,
+							},
+						},
+					}),
+				},
+			},
+			"",
+			[]jsonpatch.Operation{
+				{
+					Operation: "add",
+					Path:      "/spec/volumes",
+				},
+				{
+					Operation: "add",
+					Path:      "/spec/initContainers",
+				},
+				{
+					Operation: "add",
+					Path:      "/spec/containers/1",
+				},
+				{
+					Operation: "add",
+					Path:      "/spec/containers/2",
+				},
+				{
+					Operation: "add",
+					Path:      "/metadata/annotations/" + escapeJSONPointer(constants.AnnotationService),
+				},
+				{
+					Operation: "add",
+					Path:      "/metadata/annotations/" + escapeJSONPointer(constants.AnnotationPort),
+				},
+				{
+					Operation: "add",
+					Path:      "/metadata/annotations/" + escapeJSONPointer(constants.KeyInjectStatus),
+				},
+				{
+					Operation: "add",
+					Path:      "/metadata/annotations/" + escapeJSONPointer(constants.AnnotationOriginalPod),
+				},
+				{
+					Operation: "add",
+					Path:      "/metadata/annotations/" + escapeJSONPointer(constants.LegacyAnnotationConsulK8sVersion),
+				},
+				{
+					Operation: "add",
+					Path:      "/metadata/annotations/" + escapeJSONPointer(constants.AnnotationConsulK8sVersion),
+				},
+				{
+					Operation: "add",
+					Path:      "/metadata/labels",
+				},
+			},
+		},
 		{
 			"multiport pod kube < 1.24 with AuthMethod, serviceaccount has secret ref",
 			MeshWebhook{
@@ -1676,6 +2093,16 @@ func TestConsulNamespace(t *testing.T) {
 			"namespace",
 			"test-namespace",
 		},
+
+		{
+			"namespaces enabled, mirroring enabled, templated prefix defined",
+			true,
+			"default",
+			true,
+			"{k8s-namespace}-east",
+			"namespace",
+			"namespace-east",
+		},
 	}
 
 	for _, tt := range cases {
@@ -1696,6 +2123,61 @@ func TestConsulNamespace(t *testing.T) {
 	}
 }
 
+// Test that the override annotation of a pod sets the Consul namespace of its services.
+func TestPodConsulNamespace(t *testing.T) {
+	cases := map[string]struct {
+		enableNamespaces bool
+		authMethod       string
+		annotations      map[string]string
+		expNamespace     string
+		expErr           string
+	}{
+		"no override": {
+			enableNamespaces: true,
+			expNamespace:     "k8s-web",
+		},
+		"override": {
+			enableNamespaces: true,
+			annotations:      map[string]string{constants.AnnotationConsulNamespaceOverride: "shared"},
+			expNamespace:     "shared",
+		},
+		"override with namespaces disabled": {
+			annotations: map[string]string{constants.AnnotationConsulNamespaceOverride: "shared"},
+			expErr:      "consul.hashicorp.com/consul-namespace-override annotation requires Consul namespaces to be enabled",
+		},
+		"empty override": {
+			enableNamespaces: true,
+			annotations:      map[string]string{constants.AnnotationConsulNamespaceOverride: ""},
+			expErr:           "consul.hashicorp.com/consul-namespace-override annotation must not be empty",
+		},
+		"override with ACLs": {
+			enableNamespaces: true,
+			authMethod:       "consul-k8s-auth-method",
+			annotations:      map[string]string{constants.AnnotationConsulNamespaceOverride: "shared"},
+			expErr:           "consul.hashicorp.com/consul-namespace-override annotation is not supported when ACLs are enabled",
+		},
+	}
+	for name, c := range cases {
+		t.Run(name, func(t *testing.T) {
+			w := MeshWebhook{
+				EnableNamespaces:     c.enableNamespaces,
+				EnableK8SNSMirroring: true,
+				K8SNSMirroringPrefix: "k8s-",
+				AuthMethod:           c.authMethod,
+			}
+			pod := corev1.Pod{ObjectMeta: metav1.ObjectMeta{Annotations: c.annotations}}
+
+			err := w.validateConsulNamespaceOverride(pod)
+			if c.expErr != "" {
+				require.EqualError(t, err, c.expErr)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, c.expNamespace, w.podConsulNamespace(pod, "web"))
+		})
+	}
+}
+
 // Test shouldInject function.
 func TestShouldInject(t *testing.T) {
 	cases := []struct {
@@ -1991,7 +2473,10 @@ func TestShouldInject(t *testing.T) {
 				DenyK8sNamespacesSet:  tt.DenyK8sNamespacesSet,
 			}
 
-			injected, err := w.shouldInject(*tt.Pod, tt.K8sNamespace)
+			getNamespace := func() (*corev1.Namespace, error) {
+				return &corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: tt.K8sNamespace}}, nil
+			}
+			injected, err := w.shouldInject(*tt.Pod, tt.K8sNamespace, getNamespace)
 
 			require.Equal(nil, err)
 			require.Equal(tt.Expected, injected)
@@ -1999,6 +2484,133 @@ func TestShouldInject(t *testing.T) {
 	}
 }
 
+// Test that the default-inject label of a namespace sets whether its pods are injected by default.
+func TestShouldInject_NamespaceDefaultInject(t *testing.T) {
+	cases := map[string]struct {
+		requireAnnotation bool
+		nsLabels          map[string]string
+		podAnnotations    map[string]string
+		expected          bool
+		expErr            string
+	}{
+		"no label, injector injects by default": {
+			requireAnnotation: false,
+			expected:          true,
+		},
+		"no label, injector requires the annotation": {
+			requireAnnotation: true,
+			expected:          false,
+		},
+		"label true, injector requires the annotation": {
+			requireAnnotation: true,
+			nsLabels:          map[string]string{constants.LabelDefaultInject: "true"},
+			expected:          true,
+		},
+		"label false, injector injects by default": {
+			requireAnnotation: false,
+			nsLabels:          map[string]string{constants.LabelDefaultInject: "false"},
+			expected:          false,
+		},
+		"label true, pod opts out": {
+			requireAnnotation: true,
+			nsLabels:          map[string]string{constants.LabelDefaultInject: "true"},
+			podAnnotations:    map[string]string{constants.AnnotationInject: "false"},
+			expected:          false,
+		},
+		"label false, pod opts in": {
+			requireAnnotation: false,
+			nsLabels:          map[string]string{constants.LabelDefaultInject: "false"},
+			podAnnotations:    map[string]string{constants.AnnotationInject: "true"},
+			expected:          true,
+		},
+		"invalid label": {
+			requireAnnotation: true,
+			nsLabels:          map[string]string{constants.LabelDefaultInject: "yes please"},
+			expErr:            `consul.hashicorp.com/default-inject label value "yes please" of namespace apps is not a boolean`,
+		},
+	}
+	for name, c := range cases {
+		t.Run(name, func(t *testing.T) {
+			ns := corev1.Namespace{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:   "apps",
+					Labels: c.nsLabels,
+				},
+			}
+			w := MeshWebhook{
+				RequireAnnotation:     c.requireAnnotation,
+				AllowK8sNamespacesSet: mapset.NewSetWith("*"),
+				DenyK8sNamespacesSet:  mapset.NewSet(),
+			}
+			pod := corev1.Pod{ObjectMeta: metav1.ObjectMeta{Annotations: c.podAnnotations}}
+
+			injected, err := w.shouldInject(pod, "apps", func() (*corev1.Namespace, error) { return &ns, nil })
+			if c.expErr != "" {
+				require.EqualError(t, err, c.expErr)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, c.expected, injected)
+		})
+	}
+}
+
+// Test that the namespace is fetched once per admission, and not at all for pods that are skipped first.
+func TestHandlerHandle_NamespaceFetchedOnce(t *testing.T) {
+	s := runtime.NewScheme()
+	s.AddKnownTypes(schema.GroupVersion{Group: "", Version: "v1"}, &corev1.Pod{})
+	cases := map[string]struct {
+		namespace   string
+		annotations map[string]string
+		expGets     int
+	}{
+		"injected with the default of the namespace": {
+			namespace: "default",
+			expGets:   1,
+		},
+		"injected with the annotation": {
+			namespace:   "default",
+			annotations: map[string]string{constants.AnnotationInject: "true"},
+			expGets:     1,
+		},
+		"denied namespace": {
+			namespace: "denied",
+		},
+	}
+	for name, c := range cases {
+		t.Run(name, func(t *testing.T) {
+			clientset := fake.NewSimpleClientset(&corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "default"}})
+			w := MeshWebhook{
+				Log:                   logrtest.New(t),
+				AllowK8sNamespacesSet: mapset.NewSetWith("*"),
+				DenyK8sNamespacesSet:  mapset.NewSetWith("denied"),
+				ConsulConfig:          &consul.Config{HTTPPort: 8500},
+				decoder:               admission.NewDecoder(s),
+				Clientset:             clientset,
+			}
+			req := admission.Request{
+				AdmissionRequest: admissionv1.AdmissionRequest{
+					Namespace: c.namespace,
+					Object: encodeRaw(t, &corev1.Pod{
+						ObjectMeta: metav1.ObjectMeta{Annotations: c.annotations},
+						Spec:       corev1.PodSpec{Containers: []corev1.Container{{Name: "web"}}},
+					}),
+				},
+			}
+
+			resp := w.Handle(context.Background(), req)
+			require.True(t, resp.Allowed)
+			gets := 0
+			for _, action := range clientset.Actions() {
+				if action.GetVerb() == "get" && action.GetResource().Resource == "namespaces" {
+					gets++
+				}
+			}
+			require.Equal(t, c.expGets, gets)
+		})
+	}
+}
+
 func TestOverwriteProbes(t *testing.T) {
 	t.Parallel()
 
@@ -2311,9 +2923,113 @@ func TestOverwriteProbes(t *testing.T) {
 	}
 }
 
+func TestTranslateExecProbes(t *testing.T) {
+	execProbe := func(cmd ...string) *corev1.Probe {
+		return &corev1.Probe{
+			ProbeHandler: corev1.ProbeHandler{
+				Exec: &corev1.ExecAction{Command: cmd},
+			},
+		}
+	}
+	httpProbe := func(port int, path string) *corev1.Probe {
+		return &corev1.Probe{
+			ProbeHandler: corev1.ProbeHandler{
+				HTTPGet: &corev1.HTTPGetAction{
+					Port:   intstr.FromInt(port),
+					Path:   path,
+					Scheme: corev1.URISchemeHTTP,
+				},
+			},
+		}
+	}
+
+	cases := map[string]struct {
+		tproxyEnabled   bool
+		overwriteProbes bool
+		annotations     map[string]string
+		container       corev1.Container
+		expContainer    corev1.Container
+	}{
+		"annotation not set": {
+			tproxyEnabled:   true,
+			overwriteProbes: true,
+			container: corev1.Container{
+				Name:          "test",
+				LivenessProbe: execProbe("curl", "http://localhost:8080/healthz"),
+			},
+			expContainer: corev1.Container{
+				Name:          "test",
+				LivenessProbe: execProbe("curl", "http://localhost:8080/healthz"),
+			},
+		},
+		"transparent proxy disabled": {
+			overwriteProbes: true,
+			annotations:     map[string]string{constants.AnnotationTransparentProxyTranslateExecProbes: "true"},
+			container: corev1.Container{
+				Name:          "test",
+				LivenessProbe: execProbe("curl", "http://localhost:8080/healthz"),
+			},
+			expContainer: corev1.Container{
+				Name:          "test",
+				LivenessProbe: execProbe("curl", "http://localhost:8080/healthz"),
+			},
+		},
+		"overwrite probes disabled": {
+			tproxyEnabled: true,
+			annotations:   map[string]string{constants.AnnotationTransparentProxyTranslateExecProbes: "true"},
+			container: corev1.Container{
+				Name:          "test",
+				LivenessProbe: execProbe("curl", "http://localhost:8080/healthz"),
+			},
+			expContainer: corev1.Container{
+				Name:          "test",
+				LivenessProbe: execProbe("curl", "http://localhost:8080/healthz"),
+			},
+		},
+		"translates all probes that make local HTTP requests": {
+			tproxyEnabled:   true,
+			overwriteProbes: true,
+			annotations:     map[string]string{constants.AnnotationTransparentProxyTranslateExecProbes: "true"},
+			container: corev1.Container{
+				Name:           "test",
+				LivenessProbe:  execProbe("curl", "-f", "http://localhost:8080/healthz"),
+				ReadinessProbe: execProbe("sh", "-c", "wget -q -O- http://127.0.0.1:8080/ready"),
+				StartupProbe:   execProbe("cat", "/tmp/started"),
+			},
+			expContainer: corev1.Container{
+				Name:           "test",
+				LivenessProbe:  httpProbe(8080, "/healthz"),
+				ReadinessProbe: httpProbe(8080, "/ready"),
+				StartupProbe:   execProbe("cat", "/tmp/started"),
+			},
+		},
+	}
+
+	for name, c := range cases {
+		t.Run(name, func(t *testing.T) {
+			pod := &corev1.Pod{
+				ObjectMeta: metav1.ObjectMeta{
+					Annotations: c.annotations,
+				},
+				Spec: corev1.PodSpec{
+					Containers: []corev1.Container{c.container},
+				},
+			}
+			w := MeshWebhook{
+				EnableTransparentProxy: c.tproxyEnabled,
+				TProxyOverwriteProbes:  c.overwriteProbes,
+			}
+			err := w.translateExecProbes(corev1.Namespace{}, pod)
+			require.NoError(t, err)
+			require.Equal(t, c.expContainer, pod.Spec.Containers[0])
+		})
+	}
+}
+
 func TestHandler_checkUnsupportedMultiPortCases(t *testing.T) {
 	cases := []struct {
 		name        string
+		webhook     MeshWebhook
 		annotations map[string]string
 		expErr      string
 	}{
@@ -2332,10 +3048,15 @@ func TestHandler_checkUnsupportedMultiPortCases(t *testing.T) {
 			annotations: map[string]string{constants.AnnotationEnableMetricsMerging: "true"},
 			expErr:      "multi port services are not compatible with metrics merging",
 		},
+		{
+			name:    "login token audience",
+			webhook: MeshWebhook{AuthMethod: "k8s", LoginTokenAudience: "consul"},
+			expErr:  "multi port services are not compatible with audience-bound login tokens",
+		},
 	}
 	for _, tt := range cases {
 		t.Run(tt.name, func(t *testing.T) {
-			w := MeshWebhook{}
+			w := tt.webhook
 			pod := minimal()
 			pod.Annotations = tt.annotations
 			err := w.checkUnsupportedMultiPortCases(corev1.Namespace{}, *pod)
@@ -0,0 +1,29 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package webhook
+
+import (
+	corev1 "k8s.io/api/core/v1"
+
+	"github.com/hashicorp/consul-k8s/control-plane/connect-inject/common"
+)
+
+// useNativeSidecars returns whether the sidecar proxies of the pod are injected as native sidecars.
+// The sidecar proxy of a pod that runs to completion, e.g. the pod of a Kubernetes Job, would otherwise
+// keep running after the application exits and the pod would never complete.
+func (w *MeshWebhook) useNativeSidecars(pod corev1.Pod) (bool, error) {
+	if !w.EnableNativeSidecarsForJobs {
+		return false, nil
+	}
+	return common.IsJob(pod)
+}
+
+// nativeSidecarContainer returns the sidecar container as a native sidecar, i.e. an init container that
+// keeps running alongside the application containers and is stopped by Kubernetes once they exit. The
+// init containers that run after it can already use the mesh.
+func nativeSidecarContainer(sidecar corev1.Container) corev1.Container {
+	restartPolicy := corev1.ContainerRestartPolicyAlways
+	sidecar.RestartPolicy = &restartPolicy
+	return sidecar
+}
@@ -0,0 +1,144 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package webhook
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	mapset "github.com/deckarep/golang-set"
+	jsonpatch "github.com/evanphx/json-patch"
+	logrtest "github.com/go-logr/logr/testr"
+	"github.com/stretchr/testify/require"
+	admissionv1 "k8s.io/api/admission/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
+
+	"github.com/hashicorp/consul-k8s/control-plane/connect-inject/constants"
+	"github.com/hashicorp/consul-k8s/control-plane/consul"
+	"github.com/hashicorp/consul-k8s/control-plane/namespaces"
+)
+
+func TestHandlerHandle_NativeSidecars(t *testing.T) {
+	s := runtime.NewScheme()
+	s.AddKnownTypes(schema.GroupVersion{Group: "", Version: "v1"}, &corev1.Pod{})
+	decoder := admission.NewDecoder(s)
+
+	jobOwner := []metav1.OwnerReference{{APIVersion: "batch/v1", Kind: "Job", Name: "migrate", UID: "uid"}}
+
+	cases := []struct {
+		name              string
+		enabled           bool
+		owners            []metav1.OwnerReference
+		annotations       map[string]string
+		expInitContainers []string
+		expContainers     []string
+		expErr            string
+	}{
+		{
+			name:              "job",
+			enabled:           true,
+			owners:            jobOwner,
+			expInitContainers: []string{"setup", "consul-connect-inject-init", "consul-dataplane", "seed"},
+			expContainers:     []string{"web"},
+		},
+		{
+			name:              "job when disabled",
+			owners:            jobOwner,
+			expInitContainers: []string{"setup", "consul-connect-inject-init", "seed"},
+			expContainers:     []string{"web", "consul-dataplane"},
+		},
+		{
+			name:              "not a job",
+			enabled:           true,
+			expInitContainers: []string{"setup", "consul-connect-inject-init", "seed"},
+			expContainers:     []string{"web", "consul-dataplane"},
+		},
+		{
+			name:              "annotated as a job",
+			enabled:           true,
+			annotations:       map[string]string{constants.AnnotationJob: "true"},
+			expInitContainers: []string{"setup", "consul-connect-inject-init", "consul-dataplane", "seed"},
+			expContainers:     []string{"web"},
+		},
+		{
+			name:        "invalid annotation",
+			enabled:     true,
+			annotations: map[string]string{constants.AnnotationJob: "maybe"},
+			expErr:      `consul.hashicorp.com/job annotation value of "maybe" is not a valid boolean`,
+		},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			w := MeshWebhook{
+				Log:                         logrtest.New(t),
+				AllowK8sNamespacesSet:       mapset.NewSetWith("*"),
+				DenyK8sNamespacesSet:        mapset.NewSet(),
+				decoder:                     decoder,
+				Clientset:                   defaultTestClientWithNamespace(),
+				ConsulConfig:                &consul.Config{HTTPPort: 8500},
+				EnableNativeSidecarsForJobs: c.enabled,
+			}
+			annotations := map[string]string{
+				constants.AnnotationInitContainersAfterMesh: "seed",
+			}
+			for k, v := range c.annotations {
+				annotations[k] = v
+			}
+			raw := encodeRaw(t, &corev1.Pod{
+				ObjectMeta: metav1.ObjectMeta{
+					Annotations:     annotations,
+					OwnerReferences: c.owners,
+				},
+				Spec: corev1.PodSpec{
+					InitContainers: []corev1.Container{{Name: "setup"}, {Name: "seed"}},
+					Containers:     []corev1.Container{{Name: "web"}},
+				},
+			})
+
+			resp := w.Handle(context.Background(), admission.Request{
+				AdmissionRequest: admissionv1.AdmissionRequest{
+					Namespace: namespaces.DefaultNamespace,
+					Object:    raw,
+				},
+			})
+			if c.expErr != "" {
+				require.False(t, resp.Allowed)
+				require.Contains(t, resp.Result.Message, c.expErr)
+				return
+			}
+			require.True(t, resp.Allowed)
+
+			patchJSON, err := json.Marshal(resp.Patches)
+			require.NoError(t, err)
+			patch, err := jsonpatch.DecodePatch(patchJSON)
+			require.NoError(t, err)
+			podJSON, err := patch.Apply(raw.Raw)
+			require.NoError(t, err)
+			var pod corev1.Pod
+			require.NoError(t, json.Unmarshal(podJSON, &pod))
+
+			var initContainers, containers []string
+			for _, container := range pod.Spec.InitContainers {
+				initContainers = append(initContainers, container.Name)
+				if container.Name == sidecarContainer {
+					require.NotNil(t, container.RestartPolicy)
+					require.Equal(t, corev1.ContainerRestartPolicyAlways, *container.RestartPolicy)
+				} else {
+					require.Nil(t, container.RestartPolicy)
+				}
+			}
+			for _, container := range pod.Spec.Containers {
+				containers = append(containers, container.Name)
+				require.Nil(t, container.RestartPolicy)
+			}
+			require.Equal(t, c.expInitContainers, initContainers)
+			require.Equal(t, c.expContainers, containers)
+		})
+	}
+}
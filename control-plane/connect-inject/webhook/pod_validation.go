@@ -0,0 +1,138 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package webhook
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
+
+	"github.com/hashicorp/consul-k8s/control-plane/connect-inject/common"
+	"github.com/hashicorp/consul-k8s/control-plane/connect-inject/constants"
+)
+
+// tproxyOnlyAnnotations are the pod annotations that only have an effect with transparent proxy.
+var tproxyOnlyAnnotations = []string{
+	constants.AnnotationTProxyExcludeInboundPorts,
+	constants.AnnotationTProxyExcludeOutboundPorts,
+	constants.AnnotationTProxyExcludeOutboundCIDRs,
+	constants.AnnotationTProxyExcludeUIDs,
+	constants.AnnotationTProxyExcludeDNSCaptureCIDRs,
+}
+
+// podAnnotationValidator is the validating admission handler for pods that rejects injected pods whose
+// annotations conflict with each other. The mutating webhook silently ignores some of these combinations,
+// e.g. Consul DNS without transparent proxy, which otherwise only surfaces as unexpected behavior at runtime.
+type podAnnotationValidator struct {
+	w *MeshWebhook
+}
+
+// Handle rejects the pod if its annotations conflict.
+func (v *podAnnotationValidator) Handle(_ context.Context, req admission.Request) admission.Response {
+	var pod corev1.Pod
+	if err := v.w.decoder.Decode(req, &pod); err != nil {
+		return admission.Errored(http.StatusBadRequest, err)
+	}
+
+	// Only the pods that were injected by the mutating webhook are validated.
+	if pod.Annotations[constants.KeyInjectStatus] != constants.Injected {
+		return admission.Allowed(fmt.Sprintf("%s %s was not injected", pod.Kind, pod.Name))
+	}
+
+	if err := v.w.validatePodAnnotations(pod); err != nil {
+		v.w.Log.Info("rejecting pod with conflicting annotations", "request name", req.Name, "ns", req.Namespace, "err", err.Error())
+		return admission.Denied(err.Error())
+	}
+	return admission.Allowed("pod annotations are valid")
+}
+
+// validatePodAnnotations returns an error for every combination of the pod's annotations that conflict.
+func (w *MeshWebhook) validatePodAnnotations(pod corev1.Pod) error {
+	var errs error
+
+	if raw, ok := pod.Annotations[constants.KeyTransparentProxy]; ok {
+		if tproxy, err := strconv.ParseBool(raw); err == nil && !tproxy {
+			errs = errors.Join(errs, w.validateWithoutTransparentProxy(pod))
+		}
+	}
+
+	return errors.Join(errs, validateUpstreamPorts(pod))
+}
+
+// validateWithoutTransparentProxy returns an error if the pod, which has transparent proxy disabled with
+// its annotation, has annotations that require transparent proxy.
+func (w *MeshWebhook) validateWithoutTransparentProxy(pod corev1.Pod) error {
+	var errs error
+	if raw, ok := pod.Annotations[constants.KeyConsulDNS]; ok {
+		dnsMode, err := consulDNSRedirectionMode(pod, w.ConsulDNSRedirectionMode)
+		if dns, _ := strconv.ParseBool(raw); dns && err == nil && dnsMode != constants.DNSRedirectionModeDNSConfig {
+			errs = errors.Join(errs, fmt.Errorf("%s is %q but %s is \"false\": the %q DNS redirection mode requires transparent proxy; "+
+				"enable transparent proxy or set %s to %q",
+				constants.KeyConsulDNS, raw, constants.KeyTransparentProxy, dnsMode,
+				constants.AnnotationConsulDNSRedirectionMode, constants.DNSRedirectionModeDNSConfig))
+		}
+	}
+	for _, annotation := range tproxyOnlyAnnotations {
+		if _, ok := pod.Annotations[annotation]; ok {
+			errs = errors.Join(errs, fmt.Errorf("%s is set but %s is \"false\": the exclusions only apply to transparent proxy; "+
+				"remove the annotation or enable transparent proxy", annotation, constants.KeyTransparentProxy))
+		}
+	}
+	return errs
+}
+
+// validateUpstreamPorts returns an error if two upstreams of the pod use the same local port, or if an
+// upstream uses the port of one of the services of a multi port pod. Both cause the proxy's listeners
+// to fail to bind. The format of the upstreams is validated by the mutating webhook.
+func validateUpstreamPorts(pod corev1.Pod) error {
+	raw, ok := pod.Annotations[constants.AnnotationUpstreams]
+	if !ok || raw == "" {
+		return nil
+	}
+
+	servicePorts := make(map[int32]string)
+	if services := strings.Split(pod.Annotations[constants.AnnotationService], ","); len(services) > 1 {
+		for i, port := range strings.Split(pod.Annotations[constants.AnnotationPort], ",") {
+			if value, err := common.PortValue(pod, strings.TrimSpace(port)); err == nil && i < len(services) {
+				servicePorts[value] = strings.TrimSpace(services[i])
+			}
+		}
+	}
+
+	var errs error
+	upstreamPorts := make(map[int32]string)
+	for _, upstream := range strings.Split(raw, ",") {
+		upstream = strings.TrimSpace(upstream)
+		parts := strings.SplitN(upstream, ":", 3)
+		port := ""
+		if parts[0] == "prepared_query" && len(parts) == 3 {
+			port = parts[2]
+		} else if len(parts) >= 2 {
+			port = parts[1]
+		}
+		value, err := common.PortValue(pod, port)
+		if err != nil {
+			continue
+		}
+
+		if other, ok := upstreamPorts[value]; ok {
+			errs = errors.Join(errs, fmt.Errorf("%s has upstreams %q and %q with the same local port %d; each upstream needs its own port",
+				constants.AnnotationUpstreams, other, upstream, value))
+			continue
+		}
+		upstreamPorts[value] = upstream
+
+		if svc, ok := servicePorts[value]; ok {
+			errs = errors.Join(errs, fmt.Errorf("%s has upstream %q with local port %d, which is the port of service %q of this multi port pod; "+
+				"use a port that isn't used by the pod", constants.AnnotationUpstreams, upstream, value, svc))
+		}
+	}
+	return errs
+}
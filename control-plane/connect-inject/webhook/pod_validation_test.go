@@ -0,0 +1,138 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package webhook
+
+import (
+	"context"
+	"testing"
+
+	logrtest "github.com/go-logr/logr/testr"
+	"github.com/stretchr/testify/require"
+	admissionv1 "k8s.io/api/admission/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
+
+	"github.com/hashicorp/consul-k8s/control-plane/connect-inject/constants"
+)
+
+func TestPodAnnotationValidator_Handle(t *testing.T) {
+	s := runtime.NewScheme()
+	require.NoError(t, corev1.AddToScheme(s))
+
+	cases := map[string]struct {
+		annotations map[string]string
+		dnsMode     string
+		expErrs     []string
+	}{
+		"not injected": {
+			annotations: map[string]string{
+				constants.KeyTransparentProxy:                 "false",
+				constants.AnnotationTProxyExcludeInboundPorts: "8080",
+			},
+		},
+		"no conflicts": {
+			annotations: map[string]string{
+				constants.KeyInjectStatus:     constants.Injected,
+				constants.KeyTransparentProxy: "true",
+				constants.KeyConsulDNS:        "true",
+				constants.AnnotationUpstreams: "db:1234,cache:1235",
+			},
+		},
+		"consul dns without transparent proxy": {
+			annotations: map[string]string{
+				constants.KeyInjectStatus:     constants.Injected,
+				constants.KeyTransparentProxy: "false",
+				constants.KeyConsulDNS:        "true",
+			},
+			expErrs: []string{`consul.hashicorp.com/consul-dns is "true" but consul.hashicorp.com/transparent-proxy is "false": the "iptables" DNS redirection mode requires transparent proxy`},
+		},
+		"consul dns without transparent proxy in capture mode": {
+			annotations: map[string]string{
+				constants.KeyInjectStatus:                    constants.Injected,
+				constants.KeyTransparentProxy:                "false",
+				constants.KeyConsulDNS:                       "true",
+				constants.AnnotationConsulDNSRedirectionMode: constants.DNSRedirectionModeCapture,
+			},
+			expErrs: []string{`the "capture" DNS redirection mode requires transparent proxy`},
+		},
+		"consul dns without transparent proxy in dns-config mode": {
+			annotations: map[string]string{
+				constants.KeyInjectStatus:     constants.Injected,
+				constants.KeyTransparentProxy: "false",
+				constants.KeyConsulDNS:        "true",
+			},
+			dnsMode: constants.DNSRedirectionModeDNSConfig,
+		},
+		"transparent proxy exclusions without transparent proxy": {
+			annotations: map[string]string{
+				constants.KeyInjectStatus:                      constants.Injected,
+				constants.KeyTransparentProxy:                  "false",
+				constants.AnnotationTProxyExcludeOutboundCIDRs: "10.0.0.0/8",
+				constants.AnnotationTProxyExcludeUIDs:          "1000",
+			},
+			expErrs: []string{
+				"consul.hashicorp.com/transparent-proxy-exclude-outbound-cidrs is set but consul.hashicorp.com/transparent-proxy is \"false\"",
+				"consul.hashicorp.com/transparent-proxy-exclude-uids is set but consul.hashicorp.com/transparent-proxy is \"false\"",
+			},
+		},
+		"upstreams with the same local port": {
+			annotations: map[string]string{
+				constants.KeyInjectStatus:     constants.Injected,
+				constants.AnnotationUpstreams: "db:1234, prepared_query:query:1234",
+			},
+			expErrs: []string{`consul.hashicorp.com/connect-service-upstreams has upstreams "db:1234" and "prepared_query:query:1234" with the same local port 1234`},
+		},
+		"multi port pod with an upstream on a service port": {
+			annotations: map[string]string{
+				constants.KeyInjectStatus:     constants.Injected,
+				constants.AnnotationService:   "web,web-admin",
+				constants.AnnotationPort:      "8080,9090",
+				constants.AnnotationUpstreams: "db:9090",
+			},
+			expErrs: []string{`upstream "db:9090" with local port 9090, which is the port of service "web-admin" of this multi port pod`},
+		},
+		"single port pod with an upstream on the service port": {
+			annotations: map[string]string{
+				constants.KeyInjectStatus:     constants.Injected,
+				constants.AnnotationService:   "web",
+				constants.AnnotationPort:      "8080",
+				constants.AnnotationUpstreams: "db:8080",
+			},
+		},
+	}
+
+	for name, c := range cases {
+		t.Run(name, func(t *testing.T) {
+			w := &MeshWebhook{
+				Log:                      logrtest.New(t),
+				ConsulDNSRedirectionMode: c.dnsMode,
+				decoder:                  admission.NewDecoder(s),
+			}
+			if w.ConsulDNSRedirectionMode == "" {
+				w.ConsulDNSRedirectionMode = constants.DNSRedirectionModeIPTables
+			}
+			v := &podAnnotationValidator{w: w}
+
+			resp := v.Handle(context.Background(), admission.Request{
+				AdmissionRequest: admissionv1.AdmissionRequest{
+					Namespace: "default",
+					Operation: admissionv1.Create,
+					Object: encodeRaw(t, &corev1.Pod{
+						ObjectMeta: metav1.ObjectMeta{Name: "pod", Annotations: c.annotations},
+					}),
+				},
+			})
+			if len(c.expErrs) == 0 {
+				require.True(t, resp.Allowed, resp.Result.Message)
+				return
+			}
+			require.False(t, resp.Allowed)
+			for _, expErr := range c.expErrs {
+				require.Contains(t, resp.Result.Message, expErr)
+			}
+		})
+	}
+}
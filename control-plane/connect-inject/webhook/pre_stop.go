@@ -0,0 +1,71 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package webhook
+
+import (
+	"fmt"
+	"strconv"
+
+	corev1 "k8s.io/api/core/v1"
+)
+
+// preStopDrainHook returns the preStop hook that delays the termination of a container by the
+// pre-stop drain duration of the pod, or nil if the duration is zero.
+//
+// When a pod is deleted, it is removed from the Consul catalog and from the endpoints of its
+// Kubernetes services at the same time as its containers are sent SIGTERM. Sleeping in a preStop hook
+// keeps the application and the sidecar proxy serving the in-flight requests, and the new requests of
+// the downstreams that haven't observed the removal yet, until the pod is no longer routed to.
+// The sidecar proxy then drains its listeners when it receives SIGTERM.
+//
+// The Sleep handler is only used when the cluster supports it, since it doesn't depend on the image of
+// the container. Otherwise the hook runs the sleep command of the container.
+func (w *MeshWebhook) preStopDrainHook(pod corev1.Pod) (*corev1.Lifecycle, error) {
+	seconds, err := w.LifecycleConfig.PreStopDrainSeconds(pod)
+	if err != nil {
+		return nil, err
+	}
+	if seconds == 0 {
+		return nil, nil
+	}
+	if !w.EnableSleepAction {
+		return &corev1.Lifecycle{
+			PreStop: &corev1.LifecycleHandler{
+				Exec: &corev1.ExecAction{Command: []string{"sleep", strconv.Itoa(seconds)}},
+			},
+		}, nil
+	}
+	return &corev1.Lifecycle{
+		PreStop: &corev1.LifecycleHandler{
+			Sleep: &corev1.SleepAction{Seconds: int64(seconds)},
+		},
+	}, nil
+}
+
+// injectPreStopDrainHooks adds the pre-stop drain hook to the application containers of the pod.
+// Containers that already have a preStop hook are left as they are so that their own shutdown
+// sequence isn't replaced.
+//
+// The hooks count towards the termination grace period of the pod, so the grace period must be
+// longer than the pre-stop drain duration for the containers to shut down gracefully.
+func (w *MeshWebhook) injectPreStopDrainHooks(pod *corev1.Pod) error {
+	hook, err := w.preStopDrainHook(*pod)
+	if err != nil {
+		return fmt.Errorf("unable to determine proxy lifecycle pre-stop drain duration: %w", err)
+	}
+	if hook == nil {
+		return nil
+	}
+	for i := range pod.Spec.Containers {
+		container := &pod.Spec.Containers[i]
+		if container.Lifecycle != nil && container.Lifecycle.PreStop != nil {
+			continue
+		}
+		if container.Lifecycle == nil {
+			container.Lifecycle = &corev1.Lifecycle{}
+		}
+		container.Lifecycle.PreStop = hook.PreStop.DeepCopy()
+	}
+	return nil
+}
@@ -0,0 +1,105 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package webhook
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/hashicorp/consul-k8s/control-plane/connect-inject/constants"
+	"github.com/hashicorp/consul-k8s/control-plane/connect-inject/lifecycle"
+)
+
+func TestInjectPreStopDrainHooks(t *testing.T) {
+	sleep := func(seconds string) *corev1.Lifecycle {
+		return &corev1.Lifecycle{
+			PreStop: &corev1.LifecycleHandler{Exec: &corev1.ExecAction{Command: []string{"sleep", seconds}}},
+		}
+	}
+	customPreStop := &corev1.Lifecycle{
+		PreStop: &corev1.LifecycleHandler{Exec: &corev1.ExecAction{Command: []string{"/bin/shutdown"}}},
+	}
+	postStart := &corev1.LifecycleHandler{Exec: &corev1.ExecAction{Command: []string{"/bin/start"}}}
+
+	cases := []struct {
+		name         string
+		config       lifecycle.Config
+		sleepAction  bool
+		annotations  map[string]string
+		containers   []corev1.Container
+		expLifecycle []*corev1.Lifecycle
+		expErr       string
+	}{
+		{
+			name:         "disabled",
+			containers:   []corev1.Container{{Name: "web"}},
+			expLifecycle: []*corev1.Lifecycle{nil},
+		},
+		{
+			name:         "default",
+			config:       lifecycle.Config{DefaultPreStopDrainSeconds: 10},
+			containers:   []corev1.Container{{Name: "web"}, {Name: "worker"}},
+			expLifecycle: []*corev1.Lifecycle{sleep("10"), sleep("10")},
+		},
+		{
+			name:        "sleep action",
+			config:      lifecycle.Config{DefaultPreStopDrainSeconds: 10},
+			sleepAction: true,
+			containers:  []corev1.Container{{Name: "web"}},
+			expLifecycle: []*corev1.Lifecycle{
+				{PreStop: &corev1.LifecycleHandler{Sleep: &corev1.SleepAction{Seconds: 10}}},
+			},
+		},
+		{
+			name:   "annotation overrides default",
+			config: lifecycle.Config{DefaultPreStopDrainSeconds: 10},
+			annotations: map[string]string{
+				constants.AnnotationSidecarProxyLifecyclePreStopDrainSeconds: "20",
+			},
+			containers:   []corev1.Container{{Name: "web"}},
+			expLifecycle: []*corev1.Lifecycle{sleep("20")},
+		},
+		{
+			name:   "existing preStop hook is kept",
+			config: lifecycle.Config{DefaultPreStopDrainSeconds: 10},
+			containers: []corev1.Container{
+				{Name: "web", Lifecycle: customPreStop.DeepCopy()},
+				{Name: "worker", Lifecycle: &corev1.Lifecycle{PostStart: postStart}},
+			},
+			expLifecycle: []*corev1.Lifecycle{
+				customPreStop,
+				{PostStart: postStart, PreStop: sleep("10").PreStop},
+			},
+		},
+		{
+			name: "invalid annotation",
+			annotations: map[string]string{
+				constants.AnnotationSidecarProxyLifecyclePreStopDrainSeconds: "ten",
+			},
+			containers: []corev1.Container{{Name: "web"}},
+			expErr:     "unable to determine proxy lifecycle pre-stop drain duration: unable to parse annotation \"consul.hashicorp.com/sidecar-proxy-lifecycle-pre-stop-drain-seconds\": strconv.ParseUint: parsing \"ten\": invalid syntax",
+		},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			w := MeshWebhook{LifecycleConfig: c.config, EnableSleepAction: c.sleepAction}
+			pod := &corev1.Pod{
+				ObjectMeta: metav1.ObjectMeta{Annotations: c.annotations},
+				Spec:       corev1.PodSpec{Containers: c.containers},
+			}
+			err := w.injectPreStopDrainHooks(pod)
+			if c.expErr != "" {
+				require.EqualError(t, err, c.expErr)
+				return
+			}
+			require.NoError(t, err)
+			for i, container := range pod.Spec.Containers {
+				require.Equal(t, c.expLifecycle[i], container.Lifecycle, container.Name)
+			}
+		})
+	}
+}
@@ -0,0 +1,116 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package webhook
+
+import (
+	"fmt"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
+
+	"github.com/hashicorp/consul-k8s/control-plane/connect-inject/common"
+	"github.com/hashicorp/consul-k8s/control-plane/connect-inject/constants"
+)
+
+// probeOverwrite is a probe of an application container that is overwritten to be sent to a path
+// exposed by the Envoy proxy, since the proxy requires mTLS for every other inbound request.
+type probeOverwrite struct {
+	probe *corev1.Probe
+	// exposedPort is the port of the Envoy listener the probe is sent to.
+	exposedPort int
+}
+
+// probeOverwrites returns the probes of the application containers of the pod to overwrite:
+//   - HTTP and gRPC probes are overwritten, since Envoy can expose paths over HTTP/1 and HTTP/2.
+//   - HTTPS probes are left as is, since Envoy only exposes plain HTTP paths.
+//   - Probes on the ports of the AnnotationTransparentProxyExcludeProbePorts annotation are left as is.
+//   - Probes on named ports that no container declares are left as is, since the kubelet can't resolve them either.
+//   - TCP probes are left as is, since Envoy accepts their connections.
+//   - Exec probes run in the container, so traffic redirection doesn't apply to them.
+//
+// Ports are never excluded from inbound traffic redirection here: the kubelet only reaches the HTTPS and
+// annotated probes that are left as is if their ports are also excluded with the
+// AnnotationTProxyExcludeInboundPorts annotation, which bypasses mTLS for all traffic to them.
+//
+// The probes are returned in a stable order so that the exposed ports computed before the sidecar
+// is injected, to configure traffic redirection, line up with the ports set after it is injected.
+func probeOverwrites(pod *corev1.Pod) ([]probeOverwrite, error) {
+	excluded := make(map[int32]struct{})
+	for _, raw := range splitCommaSeparatedItemsFromAnnotation(constants.AnnotationTransparentProxyExcludeProbePorts, *pod) {
+		raw = strings.TrimSpace(raw)
+		if raw == "" {
+			continue
+		}
+		port, err := common.PortValue(*pod, raw)
+		if err != nil {
+			return nil, fmt.Errorf("%s annotation value of %q is not a port number or name: %w",
+				constants.AnnotationTransparentProxyExcludeProbePorts, raw, err)
+		}
+		excluded[port] = struct{}{}
+	}
+
+	var overwrites []probeOverwrite
+	// We don't use the loop index because the sidecar container is skipped and may be anywhere in the list.
+	idx := 0
+	for i := range pod.Spec.Containers {
+		container := &pod.Spec.Containers[i]
+		// Skip the "consul-dataplane" container from having its probes overridden.
+		if container.Name == sidecarContainer {
+			continue
+		}
+		probes := []struct {
+			probe      *corev1.Probe
+			rangeStart int
+		}{
+			{container.LivenessProbe, exposedPathsLivenessPortsRangeStart},
+			{container.ReadinessProbe, exposedPathsReadinessPortsRangeStart},
+			{container.StartupProbe, exposedPathsStartupPortsRangeStart},
+		}
+		for _, p := range probes {
+			if p.probe == nil || (p.probe.HTTPGet == nil && p.probe.GRPC == nil) {
+				continue
+			}
+			if p.probe.HTTPGet != nil && p.probe.HTTPGet.Scheme == corev1.URISchemeHTTPS {
+				continue
+			}
+			port, _ := probePort(p.probe)
+			portValue, err := common.PortValue(*pod, port.String())
+			if err != nil {
+				continue
+			}
+			if _, ok := excluded[portValue]; ok {
+				continue
+			}
+			overwrites = append(overwrites, probeOverwrite{probe: p.probe, exposedPort: p.rangeStart + idx})
+		}
+		idx++
+	}
+	return overwrites, nil
+}
+
+// probePort returns the port a probe is sent to by the kubelet. It returns false for exec probes.
+func probePort(probe *corev1.Probe) (intstr.IntOrString, bool) {
+	switch {
+	case probe == nil:
+		return intstr.IntOrString{}, false
+	case probe.HTTPGet != nil:
+		return probe.HTTPGet.Port, true
+	case probe.GRPC != nil:
+		return intstr.FromInt32(probe.GRPC.Port), true
+	case probe.TCPSocket != nil:
+		return probe.TCPSocket.Port, true
+	}
+	return intstr.IntOrString{}, false
+}
+
+// setExposedPort points the probe at the Envoy listener of its exposed path.
+func (o probeOverwrite) setExposedPort() {
+	if o.probe.HTTPGet != nil {
+		o.probe.HTTPGet.Port = intstr.FromInt(o.exposedPort)
+	}
+	if o.probe.GRPC != nil {
+		o.probe.GRPC.Port = int32(o.exposedPort)
+	}
+}
@@ -0,0 +1,164 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package webhook
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
+
+	"github.com/hashicorp/consul-k8s/control-plane/connect-inject/constants"
+)
+
+func TestProbeOverwrites(t *testing.T) {
+	t.Parallel()
+	httpProbe := func(port intstr.IntOrString, scheme corev1.URIScheme) *corev1.Probe {
+		return &corev1.Probe{ProbeHandler: corev1.ProbeHandler{HTTPGet: &corev1.HTTPGetAction{Port: port, Scheme: scheme}}}
+	}
+	grpcProbe := func(port int32) *corev1.Probe {
+		return &corev1.Probe{ProbeHandler: corev1.ProbeHandler{GRPC: &corev1.GRPCAction{Port: port}}}
+	}
+	tcpProbe := func(port intstr.IntOrString) *corev1.Probe {
+		return &corev1.Probe{ProbeHandler: corev1.ProbeHandler{TCPSocket: &corev1.TCPSocketAction{Port: port}}}
+	}
+	ports := []corev1.ContainerPort{
+		{Name: "http", ContainerPort: 8080},
+		{Name: "https", ContainerPort: 8443},
+		{Name: "grpc", ContainerPort: 9090},
+	}
+
+	cases := map[string]struct {
+		annotation      string
+		containers      []corev1.Container
+		expExposedPorts []int
+		expErr          string
+	}{
+		"http and grpc probes are overwritten": {
+			containers: []corev1.Container{
+				{
+					Name:           "app",
+					Ports:          ports,
+					LivenessProbe:  httpProbe(intstr.FromString("http"), corev1.URISchemeHTTP),
+					ReadinessProbe: grpcProbe(9090),
+					StartupProbe:   httpProbe(intstr.FromInt(8080), ""),
+				},
+			},
+			expExposedPorts: []int{
+				exposedPathsLivenessPortsRangeStart,
+				exposedPathsReadinessPortsRangeStart,
+				exposedPathsStartupPortsRangeStart,
+			},
+		},
+		"https probes are left as is": {
+			containers: []corev1.Container{
+				{
+					Name:           "app",
+					Ports:          ports,
+					LivenessProbe:  httpProbe(intstr.FromString("https"), corev1.URISchemeHTTPS),
+					ReadinessProbe: httpProbe(intstr.FromInt(8443), corev1.URISchemeHTTPS),
+					StartupProbe:   httpProbe(intstr.FromInt(8080), ""),
+				},
+			},
+			expExposedPorts: []int{exposedPathsStartupPortsRangeStart},
+		},
+		"tcp and exec probes are left as is": {
+			containers: []corev1.Container{
+				{
+					Name:           "app",
+					Ports:          ports,
+					LivenessProbe:  tcpProbe(intstr.FromString("http")),
+					ReadinessProbe: &corev1.Probe{ProbeHandler: corev1.ProbeHandler{Exec: &corev1.ExecAction{Command: []string{"true"}}}},
+				},
+			},
+		},
+		"probes on annotated ports are left as is": {
+			annotation: "grpc, 8081",
+			containers: []corev1.Container{
+				{
+					Name:           "app",
+					Ports:          ports,
+					LivenessProbe:  grpcProbe(9090),
+					ReadinessProbe: tcpProbe(intstr.FromInt(8081)),
+					StartupProbe:   httpProbe(intstr.FromString("http"), ""),
+				},
+			},
+			expExposedPorts: []int{exposedPathsStartupPortsRangeStart},
+		},
+		"probes on unknown named ports are left as is": {
+			containers: []corev1.Container{
+				{
+					Name:           "app",
+					Ports:          ports,
+					LivenessProbe:  httpProbe(intstr.FromString("unknown"), ""),
+					ReadinessProbe: tcpProbe(intstr.FromString("unknown")),
+					StartupProbe:   httpProbe(intstr.FromString("http"), ""),
+				},
+			},
+			expExposedPorts: []int{exposedPathsStartupPortsRangeStart},
+		},
+		"ports are numbered by container, skipping the sidecar": {
+			containers: []corev1.Container{
+				{
+					Name:          "app",
+					Ports:         ports,
+					LivenessProbe: httpProbe(intstr.FromInt(8080), ""),
+				},
+				{
+					Name:          sidecarContainer,
+					LivenessProbe: tcpProbe(intstr.FromInt(20000)),
+				},
+				{
+					Name:          "other",
+					LivenessProbe: grpcProbe(9091),
+				},
+			},
+			expExposedPorts: []int{exposedPathsLivenessPortsRangeStart, exposedPathsLivenessPortsRangeStart + 1},
+		},
+		"invalid annotation": {
+			annotation: "unknown",
+			containers: []corev1.Container{{Name: "app", Ports: ports}},
+			expErr:     `consul.hashicorp.com/transparent-proxy-exclude-probe-ports annotation value of "unknown" is not a port number or name`,
+		},
+	}
+
+	for name, c := range cases {
+		c := c
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+			pod := &corev1.Pod{
+				ObjectMeta: metav1.ObjectMeta{Annotations: map[string]string{}},
+				Spec:       corev1.PodSpec{Containers: c.containers},
+			}
+			if c.annotation != "" {
+				pod.Annotations[constants.AnnotationTransparentProxyExcludeProbePorts] = c.annotation
+			}
+
+			overwrites, err := probeOverwrites(pod)
+			if c.expErr != "" {
+				require.ErrorContains(t, err, c.expErr)
+				return
+			}
+			require.NoError(t, err)
+			var exposedPorts []int
+			for _, o := range overwrites {
+				exposedPorts = append(exposedPorts, o.exposedPort)
+			}
+			require.Equal(t, c.expExposedPorts, exposedPorts)
+		})
+	}
+}
+
+func TestProbeOverwrite_setExposedPort(t *testing.T) {
+	t.Parallel()
+	httpProbe := &corev1.Probe{ProbeHandler: corev1.ProbeHandler{HTTPGet: &corev1.HTTPGetAction{Port: intstr.FromString("http")}}}
+	probeOverwrite{probe: httpProbe, exposedPort: exposedPathsLivenessPortsRangeStart}.setExposedPort()
+	require.Equal(t, intstr.FromInt(exposedPathsLivenessPortsRangeStart), httpProbe.HTTPGet.Port)
+
+	grpcProbe := &corev1.Probe{ProbeHandler: corev1.ProbeHandler{GRPC: &corev1.GRPCAction{Port: 9090}}}
+	probeOverwrite{probe: grpcProbe, exposedPort: exposedPathsStartupPortsRangeStart}.setExposedPort()
+	require.Equal(t, int32(exposedPathsStartupPortsRangeStart), grpcProbe.GRPC.Port)
+}
@@ -0,0 +1,99 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package webhook
+
+import (
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+
+	"github.com/hashicorp/consul-k8s/control-plane/connect-inject/common"
+	"github.com/hashicorp/consul-k8s/control-plane/connect-inject/constants"
+)
+
+// ProxyResourceAutoSizing configures the sizing of the CPU and memory requests of the sidecar
+// proxies from the number of upstreams and listeners they are expected to have, so that proxies
+// with few upstreams don't get the requests sized for the largest ones.
+type ProxyResourceAutoSizing struct {
+	// Enabled adds the per upstream and per listener amounts to the default requests.
+	Enabled bool
+
+	// CPUPerUpstream and MemoryPerUpstream are added for each upstream declared in the
+	// upstreams annotation of the pod.
+	CPUPerUpstream    resource.Quantity
+	MemoryPerUpstream resource.Quantity
+
+	// CPUPerListener and MemoryPerListener are added for each listener of the proxy.
+	CPUPerListener    resource.Quantity
+	MemoryPerListener resource.Quantity
+}
+
+// autoSizeSidecarResources adds the per upstream and per listener amounts of the auto-sizing
+// configuration to the default CPU and memory requests of the sidecar proxy, including those of the
+// pod's architecture profile. Requests set with annotations
+// are left as they are, and requests are capped at their limits so that the pod remains valid.
+//
+// The proxy has a public listener, a listener per declared upstream and, with transparent proxy,
+// an outbound listener for the upstreams that aren't declared.
+func (w *MeshWebhook) autoSizeSidecarResources(namespace corev1.Namespace, pod corev1.Pod, resources *corev1.ResourceRequirements) error {
+	if !w.ProxyResourceAutoSizing.Enabled {
+		return nil
+	}
+
+	upstreams := declaredUpstreams(pod)
+	listeners := 1 + upstreams
+	tproxyEnabled, err := common.TransparentProxyEnabled(namespace, pod, w.EnableTransparentProxy)
+	if err != nil {
+		return err
+	}
+	if tproxyEnabled {
+		listeners++
+	}
+
+	sizing := w.ProxyResourceAutoSizing
+	_, defaultCPURequest, _, defaultMemoryRequest := w.defaultSidecarResources(pod)
+	if _, ok := pod.Annotations[constants.AnnotationSidecarProxyCPURequest]; !ok {
+		autoSizeRequest(resources, corev1.ResourceCPU, defaultCPURequest,
+			scaledQuantity(sizing.CPUPerUpstream, upstreams), scaledQuantity(sizing.CPUPerListener, listeners))
+	}
+	if _, ok := pod.Annotations[constants.AnnotationSidecarProxyMemoryRequest]; !ok {
+		autoSizeRequest(resources, corev1.ResourceMemory, defaultMemoryRequest,
+			scaledQuantity(sizing.MemoryPerUpstream, upstreams), scaledQuantity(sizing.MemoryPerListener, listeners))
+	}
+	return nil
+}
+
+// autoSizeRequest sets the request of the resource to the sum of the base request and the amounts,
+// capped at the limit of the resource if it has one. The request isn't set if the sum is zero.
+func autoSizeRequest(resources *corev1.ResourceRequirements, name corev1.ResourceName, base resource.Quantity, amounts ...resource.Quantity) {
+	request := base.DeepCopy()
+	for _, amount := range amounts {
+		request.Add(amount)
+	}
+	if request.IsZero() {
+		return
+	}
+	if limit, ok := resources.Limits[name]; ok && request.Cmp(limit) > 0 {
+		request = limit.DeepCopy()
+	}
+	resources.Requests[name] = request
+}
+
+// scaledQuantity returns the quantity multiplied by n.
+func scaledQuantity(q resource.Quantity, n int) resource.Quantity {
+	scaled := resource.NewMilliQuantity(q.MilliValue()*int64(n), q.Format)
+	return *scaled
+}
+
+// declaredUpstreams returns the number of upstreams in the upstreams annotation of the pod.
+func declaredUpstreams(pod corev1.Pod) int {
+	count := 0
+	for _, upstream := range strings.Split(pod.Annotations[constants.AnnotationUpstreams], ",") {
+		if strings.TrimSpace(upstream) != "" {
+			count++
+		}
+	}
+	return count
+}
@@ -0,0 +1,171 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package webhook
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/hashicorp/consul-k8s/control-plane/connect-inject/constants"
+	"github.com/hashicorp/consul-k8s/control-plane/consul"
+)
+
+func TestHandlerConsulDataplaneSidecar_ResourceAutoSizing(t *testing.T) {
+	autoSizing := ProxyResourceAutoSizing{
+		Enabled:           true,
+		CPUPerUpstream:    resource.MustParse("10m"),
+		MemoryPerUpstream: resource.MustParse("2Mi"),
+		CPUPerListener:    resource.MustParse("5m"),
+		MemoryPerListener: resource.MustParse("1Mi"),
+	}
+	upstreams := "db:1234, cache:1235,api.svc.ns.ns.dc2.dc:1236"
+
+	cases := map[string]struct {
+		webhook        MeshWebhook
+		annotations    map[string]string
+		nodeSelector   map[string]string
+		expCPURequest  string
+		expMemRequest  string
+		expNoCPU       bool
+		expNoMemory    bool
+		expCPULimit    string
+		expMemoryLimit string
+	}{
+		"disabled": {
+			webhook: MeshWebhook{
+				DefaultProxyCPURequest:    resource.MustParse("50m"),
+				DefaultProxyMemoryRequest: resource.MustParse("64Mi"),
+				ProxyResourceAutoSizing:   ProxyResourceAutoSizing{CPUPerUpstream: resource.MustParse("10m")},
+			},
+			annotations:   map[string]string{constants.AnnotationUpstreams: upstreams},
+			expCPURequest: "50m",
+			expMemRequest: "64Mi",
+		},
+		"no defaults and no upstreams": {
+			webhook:       MeshWebhook{ProxyResourceAutoSizing: autoSizing},
+			expCPURequest: "5m",
+			expMemRequest: "1Mi",
+		},
+		"defaults and upstreams": {
+			webhook: MeshWebhook{
+				DefaultProxyCPURequest:    resource.MustParse("50m"),
+				DefaultProxyMemoryRequest: resource.MustParse("64Mi"),
+				ProxyResourceAutoSizing:   autoSizing,
+			},
+			annotations: map[string]string{constants.AnnotationUpstreams: upstreams},
+			// 50m + 3 upstreams * 10m + 4 listeners * 5m.
+			expCPURequest: "100m",
+			// 64Mi + 3 upstreams * 2Mi + 4 listeners * 1Mi.
+			expMemRequest: "74Mi",
+		},
+		"transparent proxy adds the outbound listener": {
+			webhook: MeshWebhook{
+				DefaultProxyCPURequest:    resource.MustParse("50m"),
+				DefaultProxyMemoryRequest: resource.MustParse("64Mi"),
+				ProxyResourceAutoSizing:   autoSizing,
+			},
+			annotations: map[string]string{
+				constants.AnnotationUpstreams: upstreams,
+				constants.KeyTransparentProxy: "true",
+			},
+			expCPURequest: "105m",
+			expMemRequest: "75Mi",
+		},
+		"requests are capped at the limits": {
+			webhook: MeshWebhook{
+				DefaultProxyCPURequest:    resource.MustParse("50m"),
+				DefaultProxyCPULimit:      resource.MustParse("80m"),
+				DefaultProxyMemoryRequest: resource.MustParse("64Mi"),
+				DefaultProxyMemoryLimit:   resource.MustParse("128Mi"),
+				ProxyResourceAutoSizing:   autoSizing,
+			},
+			annotations:    map[string]string{constants.AnnotationUpstreams: upstreams},
+			expCPURequest:  "80m",
+			expMemRequest:  "74Mi",
+			expCPULimit:    "80m",
+			expMemoryLimit: "128Mi",
+		},
+		"annotations override auto-sizing": {
+			webhook: MeshWebhook{
+				DefaultProxyCPURequest:    resource.MustParse("50m"),
+				DefaultProxyMemoryRequest: resource.MustParse("64Mi"),
+				ProxyResourceAutoSizing:   autoSizing,
+			},
+			annotations: map[string]string{
+				constants.AnnotationUpstreams:                 upstreams,
+				constants.AnnotationSidecarProxyCPURequest:    "20m",
+				constants.AnnotationSidecarProxyMemoryRequest: "32Mi",
+			},
+			expCPURequest: "20m",
+			expMemRequest: "32Mi",
+		},
+		"architecture profile defaults": {
+			webhook: MeshWebhook{
+				DefaultProxyCPURequest:    resource.MustParse("50m"),
+				DefaultProxyMemoryRequest: resource.MustParse("64Mi"),
+				ArchitectureProfiles: map[string]ArchitectureProfile{
+					"arm64": {
+						Resources: corev1.ResourceRequirements{
+							Requests: corev1.ResourceList{corev1.ResourceCPU: resource.MustParse("20m")},
+						},
+					},
+				},
+				ProxyResourceAutoSizing: autoSizing,
+			},
+			annotations:  map[string]string{constants.AnnotationUpstreams: upstreams},
+			nodeSelector: map[string]string{corev1.LabelArchStable: "arm64"},
+			// 20m + 3 upstreams * 10m + 4 listeners * 5m.
+			expCPURequest: "70m",
+			expMemRequest: "74Mi",
+		},
+		"zero coefficients and no defaults": {
+			webhook:     MeshWebhook{ProxyResourceAutoSizing: ProxyResourceAutoSizing{Enabled: true}},
+			annotations: map[string]string{constants.AnnotationUpstreams: upstreams},
+			expNoCPU:    true,
+			expNoMemory: true,
+		},
+	}
+
+	for name, c := range cases {
+		t.Run(name, func(t *testing.T) {
+			c.webhook.ConsulConfig = &consul.Config{HTTPPort: 8500, GRPCPort: 8502}
+			pod := corev1.Pod{
+				ObjectMeta: metav1.ObjectMeta{
+					Annotations: c.annotations,
+				},
+				Spec: corev1.PodSpec{
+					Containers: []corev1.Container{
+						{
+							Name: "web",
+						},
+					},
+					NodeSelector: c.nodeSelector,
+				},
+			}
+			container, err := c.webhook.consulDataplaneSidecar(testNS, pod, multiPortInfo{})
+			require.NoError(t, err)
+
+			requireQuantity(t, c.expCPURequest, c.expNoCPU, container.Resources.Requests, corev1.ResourceCPU)
+			requireQuantity(t, c.expMemRequest, c.expNoMemory, container.Resources.Requests, corev1.ResourceMemory)
+			requireQuantity(t, c.expCPULimit, c.expCPULimit == "", container.Resources.Limits, corev1.ResourceCPU)
+			requireQuantity(t, c.expMemoryLimit, c.expMemoryLimit == "", container.Resources.Limits, corev1.ResourceMemory)
+		})
+	}
+}
+
+func requireQuantity(t *testing.T, expected string, expectUnset bool, resources corev1.ResourceList, name corev1.ResourceName) {
+	t.Helper()
+	actual, ok := resources[name]
+	if expectUnset {
+		require.False(t, ok, "expected %s to be unset but got %s", name, actual.String())
+		return
+	}
+	require.True(t, ok, "expected %s to be set", name)
+	expectedQuantity := resource.MustParse(expected)
+	require.Zero(t, expectedQuantity.Cmp(actual), "expected %s to be %s but got %s", name, expected, actual.String())
+}
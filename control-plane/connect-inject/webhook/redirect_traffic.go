@@ -4,30 +4,36 @@
 package webhook
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
+	"slices"
 	"strconv"
 
 	"github.com/hashicorp/consul/sdk/iptables"
 	corev1 "k8s.io/api/core/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
 
+	"github.com/hashicorp/consul-k8s/control-plane/api/v1alpha1"
 	"github.com/hashicorp/consul-k8s/control-plane/connect-inject/common"
 	"github.com/hashicorp/consul-k8s/control-plane/connect-inject/constants"
 )
 
-// addRedirectTrafficConfigAnnotation creates an iptables.Config in JSON format based on proxy configuration.
-// iptables.Config:
+// iptablesConfigJSON creates a common.RedirectTrafficConfig in JSON format based on proxy configuration.
+// common.RedirectTrafficConfig:
 //
 //	ConsulDNSIP: an environment variable named RESOURCE_PREFIX_DNS_SERVICE_HOST where RESOURCE_PREFIX is the consul.fullname in helm.
 //	ProxyUserID: a constant set in Annotations or read from namespace when using OpenShift
 //	ProxyInboundPort: the service port or bind port
 //	ProxyOutboundPort: default transparent proxy outbound port or transparent proxy outbound listener port
-//	ExcludeInboundPorts: prometheus, envoy stats, expose paths, checks and excluded pod annotations
-//	ExcludeOutboundPorts: pod annotations
-//	ExcludeOutboundCIDRs: pod annotations
-//	ExcludeUIDs: pod annotations
+//	ExcludeInboundPorts: prometheus, envoy stats, expose paths, checks, excluded pod annotations and namespace defaults
+//	ExcludeOutboundPorts: pod annotations and namespace defaults
+//	ExcludeOutboundCIDRs: pod annotations and namespace defaults
+//	ExcludeUIDs: pod annotations and namespace defaults
+//	CaptureDNS: set with the capture DNS redirection mode
+//	ExcludeDNSCaptureCIDRs: pod annotations
 func (w *MeshWebhook) iptablesConfigJSON(pod corev1.Pod, ns corev1.Namespace) (string, error) {
-	cfg := iptables.Config{}
+	cfg := common.RedirectTrafficConfig{}
 
 	if !w.EnableOpenShift {
 		cfg.ProxyUserID = strconv.Itoa(sidecarUserAndGroupID)
@@ -36,14 +42,18 @@ func (w *MeshWebhook) iptablesConfigJSON(pod corev1.Pod, ns corev1.Namespace) (s
 		cfg.ExcludeUIDs = append(cfg.ExcludeUIDs, strconv.Itoa(initContainersUserAndGroupID))
 	} else {
 		// When using OpenShift, the uid and group are saved as an annotation on the namespace
-		uid, err := common.GetDataplaneUID(ns, pod, w.ImageConsulDataplane, w.ImageConsulK8S)
+		dataplaneImage, err := w.consulDataplaneImage(ns, pod)
+		if err != nil {
+			return "", err
+		}
+		uid, err := common.GetDataplaneUID(ns, pod, dataplaneImage, w.ImageConsulK8S)
 		if err != nil {
 			return "", err
 		}
 		cfg.ProxyUserID = strconv.FormatInt(uid, 10)
 
 		// Exclude the user ID for the init container from traffic redirection.
-		uid, err = common.GetConnectInitUID(ns, pod, w.ImageConsulDataplane, w.ImageConsulK8S)
+		uid, err = common.GetConnectInitUID(ns, pod, dataplaneImage, w.ImageConsulK8S)
 		if err != nil {
 			return "", err
 		}
@@ -82,24 +92,14 @@ func (w *MeshWebhook) iptablesConfigJSON(pod corev1.Pod, ns corev1.Namespace) (s
 	}
 
 	if overwriteProbes {
-		// We don't use the loop index because this needs to line up w.overwriteProbes(),
+		// The ports of the Envoy listeners of overwritten probes need to line up with w.overwriteProbes(),
 		// which is performed after the sidecar is injected.
-		idx := 0
-		for _, container := range pod.Spec.Containers {
-			// skip the "consul-dataplane" container from having its probes overridden
-			if container.Name == sidecarContainer {
-				continue
-			}
-			if container.LivenessProbe != nil && container.LivenessProbe.HTTPGet != nil {
-				cfg.ExcludeInboundPorts = append(cfg.ExcludeInboundPorts, strconv.Itoa(exposedPathsLivenessPortsRangeStart+idx))
-			}
-			if container.ReadinessProbe != nil && container.ReadinessProbe.HTTPGet != nil {
-				cfg.ExcludeInboundPorts = append(cfg.ExcludeInboundPorts, strconv.Itoa(exposedPathsReadinessPortsRangeStart+idx))
-			}
-			if container.StartupProbe != nil && container.StartupProbe.HTTPGet != nil {
-				cfg.ExcludeInboundPorts = append(cfg.ExcludeInboundPorts, strconv.Itoa(exposedPathsStartupPortsRangeStart+idx))
-			}
-			idx++
+		overwrites, err := probeOverwrites(&pod)
+		if err != nil {
+			return "", err
+		}
+		for _, overwrite := range overwrites {
+			cfg.ExcludeInboundPorts = append(cfg.ExcludeInboundPorts, strconv.Itoa(overwrite.exposedPort))
 		}
 	}
 
@@ -119,17 +119,36 @@ func (w *MeshWebhook) iptablesConfigJSON(pod corev1.Pod, ns corev1.Namespace) (s
 	excludeUIDs := splitCommaSeparatedItemsFromAnnotation(constants.AnnotationTProxyExcludeUIDs, pod)
 	cfg.ExcludeUIDs = append(cfg.ExcludeUIDs, excludeUIDs...)
 
-	dnsEnabled, err := consulDNSEnabled(ns, pod, w.EnableConsulDNS, w.EnableTransparentProxy)
+	// Exclusions from the TrafficRedirectionDefaults of the namespace.
+	defaults, err := w.trafficRedirectionDefaults(ns.Name)
+	if err != nil {
+		return "", err
+	}
+	cfg.ExcludeInboundPorts = appendMissing(cfg.ExcludeInboundPorts, defaults.ExcludeInboundPorts...)
+	cfg.ExcludeOutboundPorts = appendMissing(cfg.ExcludeOutboundPorts, defaults.ExcludeOutboundPorts...)
+	cfg.ExcludeOutboundCIDRs = appendMissing(cfg.ExcludeOutboundCIDRs, defaults.ExcludeOutboundCIDRs...)
+	cfg.ExcludeUIDs = appendMissing(cfg.ExcludeUIDs, defaults.ExcludeUIDs...)
+
+	dnsEnabled, dnsMode, err := w.consulDNS(ns, pod)
 	if err != nil {
 		return "", err
 	}
 
-	if dnsEnabled {
+	// With the dns-config redirection mode, the pod's dnsConfig already points at the DNS proxy
+	// so DNS traffic does not need to be redirected.
+	if dnsEnabled && dnsMode != constants.DNSRedirectionModeDNSConfig {
 		// If Consul DNS is enabled, we find the environment variable that has the value
 		// of the ClusterIP of the Consul DNS Service. constructDNSServiceHostName returns
 		// the name of the env variable whose value is the ClusterIP of the Consul DNS Service.
 		cfg.ConsulDNSIP = consulDataplaneDNSBindHost
 		cfg.ConsulDNSPort = consulDataplaneDNSBindPort
+
+		// With the capture redirection mode, DNS traffic to any nameserver is redirected
+		// rather than only the traffic to the DNS proxy.
+		if dnsMode == constants.DNSRedirectionModeCapture {
+			cfg.CaptureDNS = true
+			cfg.ExcludeDNSCaptureCIDRs = splitCommaSeparatedItemsFromAnnotation(constants.AnnotationTProxyExcludeDNSCaptureCIDRs, pod)
+		}
 	}
 
 	iptablesConfigJson, err := json.Marshal(&cfg)
@@ -151,3 +170,52 @@ func (w *MeshWebhook) addRedirectTrafficConfigAnnotation(pod *corev1.Pod, ns cor
 
 	return nil
 }
+
+// redirectionExclusions are the exclusions from traffic redirection in the format of iptables.Config.
+type redirectionExclusions struct {
+	ExcludeInboundPorts  []string
+	ExcludeOutboundPorts []string
+	ExcludeOutboundCIDRs []string
+	ExcludeUIDs          []string
+}
+
+// trafficRedirectionDefaults returns the exclusions of all the TrafficRedirectionDefaults in the namespace.
+// It returns an error if any of them is invalid so that pods aren't injected without the exclusions
+// that the namespace requires, e.g. for cloud metadata or node-local DNS.
+func (w *MeshWebhook) trafficRedirectionDefaults(namespace string) (redirectionExclusions, error) {
+	var exclusions redirectionExclusions
+	if w.Client == nil {
+		return exclusions, nil
+	}
+
+	var list v1alpha1.TrafficRedirectionDefaultsList
+	if err := w.Client.List(context.Background(), &list, client.InNamespace(namespace)); err != nil {
+		return exclusions, fmt.Errorf("unable to list TrafficRedirectionDefaults in namespace %s: %w", namespace, err)
+	}
+	for _, defaults := range list.Items {
+		if err := defaults.Validate(); err != nil {
+			return exclusions, fmt.Errorf("TrafficRedirectionDefaults %s/%s is invalid: %w", namespace, defaults.Name, err)
+		}
+		for _, port := range defaults.Spec.ExcludeInboundPorts {
+			exclusions.ExcludeInboundPorts = append(exclusions.ExcludeInboundPorts, strconv.Itoa(int(port)))
+		}
+		for _, port := range defaults.Spec.ExcludeOutboundPorts {
+			exclusions.ExcludeOutboundPorts = append(exclusions.ExcludeOutboundPorts, strconv.Itoa(int(port)))
+		}
+		exclusions.ExcludeOutboundCIDRs = append(exclusions.ExcludeOutboundCIDRs, defaults.Spec.ExcludeOutboundCIDRs...)
+		for _, uid := range defaults.Spec.ExcludeUIDs {
+			exclusions.ExcludeUIDs = append(exclusions.ExcludeUIDs, strconv.FormatInt(uid, 10))
+		}
+	}
+	return exclusions, nil
+}
+
+// appendMissing appends the items that aren't already in the slice.
+func appendMissing(items []string, more ...string) []string {
+	for _, item := range more {
+		if !slices.Contains(items, item) {
+			items = append(items, item)
+		}
+	}
+	return items
+}
@@ -19,8 +19,12 @@ import (
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/runtime/schema"
 	"k8s.io/apimachinery/pkg/util/intstr"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
 	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
 
+	"github.com/hashicorp/consul-k8s/control-plane/api/v1alpha1"
+	"github.com/hashicorp/consul-k8s/control-plane/connect-inject/common"
 	"github.com/hashicorp/consul-k8s/control-plane/connect-inject/constants"
 	"github.com/hashicorp/consul-k8s/control-plane/consul"
 )
@@ -219,6 +223,59 @@ func TestAddRedirectTrafficConfig(t *testing.T) {
 				ExcludeInboundPorts: []string{strconv.Itoa(exposedPathsLivenessPortsRangeStart)},
 			},
 		},
+		{
+			name: "overwrite probes, https and annotated probe ports not excluded",
+			webhook: MeshWebhook{
+				Log:                   logrtest.New(t),
+				AllowK8sNamespacesSet: mapset.NewSetWith("*"),
+				DenyK8sNamespacesSet:  mapset.NewSet(),
+				decoder:               decoder,
+			},
+			pod: &corev1.Pod{
+				ObjectMeta: metav1.ObjectMeta{
+					Namespace: defaultNamespace,
+					Name:      defaultPodName,
+					Annotations: map[string]string{
+						constants.AnnotationTransparentProxyOverwriteProbes:   "true",
+						constants.KeyTransparentProxy:                         "true",
+						constants.AnnotationTransparentProxyExcludeProbePorts: "9090",
+					},
+				},
+				Spec: corev1.PodSpec{
+					Containers: []corev1.Container{
+						{
+							Name: "test",
+							LivenessProbe: &corev1.Probe{
+								ProbeHandler: corev1.ProbeHandler{
+									GRPC: &corev1.GRPCAction{Port: 9090},
+								},
+							},
+							ReadinessProbe: &corev1.Probe{
+								ProbeHandler: corev1.ProbeHandler{
+									HTTPGet: &corev1.HTTPGetAction{
+										Port:   intstr.FromInt(8443),
+										Scheme: corev1.URISchemeHTTPS,
+									},
+								},
+							},
+							StartupProbe: &corev1.Probe{
+								ProbeHandler: corev1.ProbeHandler{
+									GRPC: &corev1.GRPCAction{Port: 9091},
+								},
+							},
+						},
+					},
+				},
+			},
+			expCfg: iptables.Config{
+				ConsulDNSIP:         "",
+				ProxyUserID:         strconv.Itoa(sidecarUserAndGroupID),
+				ProxyInboundPort:    constants.ProxyDefaultInboundPort,
+				ProxyOutboundPort:   iptables.DefaultTProxyOutboundPort,
+				ExcludeUIDs:         []string{"5996"},
+				ExcludeInboundPorts: []string{strconv.Itoa(exposedPathsStartupPortsRangeStart)},
+			},
+		},
 		{
 			name: "exclude inbound ports",
 			webhook: MeshWebhook{
@@ -479,3 +536,151 @@ func TestRedirectTraffic_consulDNS(t *testing.T) {
 		})
 	}
 }
+
+func TestRedirectTraffic_DNSRedirectionModes(t *testing.T) {
+	cases := map[string]struct {
+		mode          string
+		annotations   map[string]string
+		expDNSIP      string
+		expCaptureDNS bool
+		expExclusions []string
+	}{
+		"iptables": {
+			mode:     constants.DNSRedirectionModeIPTables,
+			expDNSIP: "127.0.0.1",
+		},
+		"dns-config": {
+			mode: constants.DNSRedirectionModeDNSConfig,
+		},
+		"capture": {
+			mode:          constants.DNSRedirectionModeCapture,
+			expDNSIP:      "127.0.0.1",
+			expCaptureDNS: true,
+		},
+		"capture with excluded nameservers": {
+			mode:          constants.DNSRedirectionModeCapture,
+			annotations:   map[string]string{constants.AnnotationTProxyExcludeDNSCaptureCIDRs: "169.254.20.10/32,10.0.0.0/8"},
+			expDNSIP:      "127.0.0.1",
+			expCaptureDNS: true,
+			expExclusions: []string{"169.254.20.10/32", "10.0.0.0/8"},
+		},
+		"capture set with the pod annotation": {
+			mode:          constants.DNSRedirectionModeIPTables,
+			annotations:   map[string]string{constants.AnnotationConsulDNSRedirectionMode: constants.DNSRedirectionModeCapture},
+			expDNSIP:      "127.0.0.1",
+			expCaptureDNS: true,
+		},
+	}
+	for name, c := range cases {
+		t.Run(name, func(t *testing.T) {
+			w := MeshWebhook{
+				EnableConsulDNS:          true,
+				EnableTransparentProxy:   true,
+				ConsulDNSRedirectionMode: c.mode,
+				ConsulConfig:             &consul.Config{HTTPPort: 8500},
+			}
+
+			pod := minimal()
+			pod.Annotations = c.annotations
+
+			iptablesConfig, err := w.iptablesConfigJSON(*pod, testNS)
+			require.NoError(t, err)
+
+			var actualConfig common.RedirectTrafficConfig
+			require.NoError(t, json.Unmarshal([]byte(iptablesConfig), &actualConfig))
+			require.Equal(t, c.expDNSIP, actualConfig.ConsulDNSIP)
+			require.Equal(t, c.expCaptureDNS, actualConfig.CaptureDNS)
+			require.Equal(t, c.expExclusions, actualConfig.ExcludeDNSCaptureCIDRs)
+		})
+	}
+}
+
+func TestRedirectTraffic_TrafficRedirectionDefaults(t *testing.T) {
+	cases := map[string]struct {
+		annotations map[string]string
+		defaults    []client.Object
+		expCfg      iptables.Config
+		expErr      string
+	}{
+		"no defaults": {
+			expCfg: iptables.Config{
+				ExcludeUIDs: []string{strconv.Itoa(initContainersUserAndGroupID)},
+			},
+		},
+		"defaults are merged with annotations": {
+			annotations: map[string]string{
+				constants.AnnotationTProxyExcludeOutboundCIDRs: "10.0.0.0/8",
+				constants.AnnotationTProxyExcludeOutboundPorts: "53",
+			},
+			defaults: []client.Object{
+				&v1alpha1.TrafficRedirectionDefaults{
+					ObjectMeta: metav1.ObjectMeta{Name: "metadata", Namespace: k8sNamespace},
+					Spec: v1alpha1.TrafficRedirectionDefaultsSpec{
+						ExcludeOutboundCIDRs: []string{"169.254.169.254"},
+						ExcludeOutboundPorts: []int32{53, 8125},
+					},
+				},
+				&v1alpha1.TrafficRedirectionDefaults{
+					ObjectMeta: metav1.ObjectMeta{Name: "node-local-dns", Namespace: k8sNamespace},
+					Spec: v1alpha1.TrafficRedirectionDefaultsSpec{
+						ExcludeOutboundCIDRs: []string{"169.254.20.10", "169.254.169.254"},
+						ExcludeInboundPorts:  []int32{9100},
+						ExcludeUIDs:          []int64{1234},
+					},
+				},
+				&v1alpha1.TrafficRedirectionDefaults{
+					ObjectMeta: metav1.ObjectMeta{Name: "other", Namespace: "other"},
+					Spec: v1alpha1.TrafficRedirectionDefaultsSpec{
+						ExcludeOutboundCIDRs: []string{"192.168.0.1"},
+					},
+				},
+			},
+			expCfg: iptables.Config{
+				ExcludeInboundPorts:  []string{"9100"},
+				ExcludeOutboundPorts: []string{"53", "8125"},
+				ExcludeOutboundCIDRs: []string{"10.0.0.0/8", "169.254.169.254", "169.254.20.10"},
+				ExcludeUIDs:          []string{strconv.Itoa(initContainersUserAndGroupID), "1234"},
+			},
+		},
+		"invalid defaults": {
+			defaults: []client.Object{
+				&v1alpha1.TrafficRedirectionDefaults{
+					ObjectMeta: metav1.ObjectMeta{Name: "metadata", Namespace: k8sNamespace},
+					Spec: v1alpha1.TrafficRedirectionDefaultsSpec{
+						ExcludeOutboundCIDRs: []string{"metadata.internal"},
+					},
+				},
+			},
+			expErr: fmt.Sprintf(`TrafficRedirectionDefaults %s/metadata is invalid: spec.excludeOutboundCIDRs[0]: Invalid value: "metadata.internal": must be an IP or CIDR`, k8sNamespace),
+		},
+	}
+	for name, c := range cases {
+		t.Run(name, func(t *testing.T) {
+			s := runtime.NewScheme()
+			s.AddKnownTypes(v1alpha1.GroupVersion, &v1alpha1.TrafficRedirectionDefaults{}, &v1alpha1.TrafficRedirectionDefaultsList{})
+			w := MeshWebhook{
+				Client:                 fake.NewClientBuilder().WithScheme(s).WithObjects(c.defaults...).Build(),
+				EnableTransparentProxy: true,
+				ConsulConfig:           &consul.Config{HTTPPort: 8500},
+			}
+
+			pod := minimal()
+			for k, v := range c.annotations {
+				pod.Annotations[k] = v
+			}
+			iptablesConfig, err := w.iptablesConfigJSON(*pod, testNS)
+			if c.expErr != "" {
+				require.EqualError(t, err, c.expErr)
+				return
+			}
+			require.NoError(t, err)
+
+			actualConfig := iptables.Config{}
+			require.NoError(t, json.Unmarshal([]byte(iptablesConfig), &actualConfig))
+			require.Equal(t, c.expCfg.ExcludeInboundPorts, actualConfig.ExcludeInboundPorts)
+			require.Equal(t, c.expCfg.ExcludeOutboundPorts, actualConfig.ExcludeOutboundPorts)
+			require.Equal(t, c.expCfg.ExcludeOutboundCIDRs, actualConfig.ExcludeOutboundCIDRs)
+			require.Equal(t, c.expCfg.ExcludeUIDs, actualConfig.ExcludeUIDs)
+		})
+	}
+}
@@ -0,0 +1,65 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package webhook
+
+import (
+	"fmt"
+	"math"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+
+	"github.com/hashicorp/consul-k8s/control-plane/connect-inject/constants"
+)
+
+// terminationDrainSeconds returns how long the sidecar proxy drains its listeners once it receives SIGTERM,
+// rounded up to the second. The duration is set by the pod's annotation or, if it is not set, the namespace's
+// annotation. Zero means that the proxy isn't configured to wait for the listeners to drain.
+func (w *MeshWebhook) terminationDrainSeconds(namespace corev1.Namespace, pod corev1.Pod) (int, error) {
+	raw, ok := pod.Annotations[constants.AnnotationSidecarProxyTerminationDrainDuration]
+	source := "annotation"
+	if !ok {
+		raw, ok = namespace.Annotations[constants.AnnotationSidecarProxyTerminationDrainDuration]
+		source = fmt.Sprintf("annotation of namespace %s", namespace.Name)
+	}
+	if !ok || raw == "" {
+		return 0, nil
+	}
+	duration, err := time.ParseDuration(raw)
+	if err != nil {
+		return 0, fmt.Errorf("%s %s value %q was invalid: %w", constants.AnnotationSidecarProxyTerminationDrainDuration, source, raw, err)
+	}
+	if duration < 0 {
+		return 0, fmt.Errorf("%s %s value %q was invalid: duration must not be negative", constants.AnnotationSidecarProxyTerminationDrainDuration, source, raw)
+	}
+	return int(math.Ceil(duration.Seconds())), nil
+}
+
+// ensureTerminationGracePeriod raises the termination grace period of the pod when it is shorter than the
+// pre-stop drain hooks and the termination drain of the sidecar proxy combined. Otherwise the kubelet kills
+// the proxy before its listeners are drained and the downstreams that still route to the pod get 503s.
+// The grace period is left as it is when no termination drain duration is set.
+func (w *MeshWebhook) ensureTerminationGracePeriod(namespace corev1.Namespace, pod *corev1.Pod) error {
+	drainSeconds, err := w.terminationDrainSeconds(namespace, *pod)
+	if err != nil {
+		return fmt.Errorf("unable to determine sidecar proxy termination drain duration: %w", err)
+	}
+	if drainSeconds == 0 {
+		return nil
+	}
+	preStopDrainSeconds, err := w.LifecycleConfig.PreStopDrainSeconds(*pod)
+	if err != nil {
+		return fmt.Errorf("unable to determine proxy lifecycle pre-stop drain duration: %w", err)
+	}
+
+	required := int64(preStopDrainSeconds + drainSeconds)
+	gracePeriod := int64(corev1.DefaultTerminationGracePeriodSeconds)
+	if pod.Spec.TerminationGracePeriodSeconds != nil {
+		gracePeriod = *pod.Spec.TerminationGracePeriodSeconds
+	}
+	if gracePeriod < required {
+		pod.Spec.TerminationGracePeriodSeconds = &required
+	}
+	return nil
+}
@@ -0,0 +1,101 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package webhook
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/utils/ptr"
+
+	"github.com/hashicorp/consul-k8s/control-plane/connect-inject/constants"
+	"github.com/hashicorp/consul-k8s/control-plane/connect-inject/lifecycle"
+)
+
+func TestEnsureTerminationGracePeriod(t *testing.T) {
+	cases := []struct {
+		name           string
+		config         lifecycle.Config
+		nsAnnotations  map[string]string
+		podAnnotations map[string]string
+		gracePeriod    *int64
+		expGracePeriod *int64
+		expErr         string
+	}{
+		{
+			name: "no drain duration",
+		},
+		{
+			name:           "drain duration shorter than the default grace period",
+			podAnnotations: map[string]string{constants.AnnotationSidecarProxyTerminationDrainDuration: "20s"},
+		},
+		{
+			name:           "drain duration longer than the default grace period",
+			podAnnotations: map[string]string{constants.AnnotationSidecarProxyTerminationDrainDuration: "45s"},
+			expGracePeriod: ptr.To(int64(45)),
+		},
+		{
+			name:           "drain duration is rounded up",
+			podAnnotations: map[string]string{constants.AnnotationSidecarProxyTerminationDrainDuration: "1m500ms"},
+			expGracePeriod: ptr.To(int64(61)),
+		},
+		{
+			name:           "pre-stop drain counts towards the grace period",
+			config:         lifecycle.Config{DefaultPreStopDrainSeconds: 15},
+			podAnnotations: map[string]string{constants.AnnotationSidecarProxyTerminationDrainDuration: "20s"},
+			expGracePeriod: ptr.To(int64(35)),
+		},
+		{
+			name:           "longer grace period is kept",
+			podAnnotations: map[string]string{constants.AnnotationSidecarProxyTerminationDrainDuration: "45s"},
+			gracePeriod:    ptr.To(int64(120)),
+			expGracePeriod: ptr.To(int64(120)),
+		},
+		{
+			name:           "shorter grace period is raised",
+			podAnnotations: map[string]string{constants.AnnotationSidecarProxyTerminationDrainDuration: "10s"},
+			gracePeriod:    ptr.To(int64(5)),
+			expGracePeriod: ptr.To(int64(10)),
+		},
+		{
+			name:           "namespace annotation",
+			nsAnnotations:  map[string]string{constants.AnnotationSidecarProxyTerminationDrainDuration: "40s"},
+			expGracePeriod: ptr.To(int64(40)),
+		},
+		{
+			name:           "pod annotation overrides namespace annotation",
+			nsAnnotations:  map[string]string{constants.AnnotationSidecarProxyTerminationDrainDuration: "40s"},
+			podAnnotations: map[string]string{constants.AnnotationSidecarProxyTerminationDrainDuration: "0s"},
+		},
+		{
+			name:           "invalid pod annotation",
+			podAnnotations: map[string]string{constants.AnnotationSidecarProxyTerminationDrainDuration: "45"},
+			expErr:         `unable to determine sidecar proxy termination drain duration: consul.hashicorp.com/sidecar-proxy-termination-drain-duration annotation value "45" was invalid: time: missing unit in duration "45"`,
+		},
+		{
+			name:          "negative namespace annotation",
+			nsAnnotations: map[string]string{constants.AnnotationSidecarProxyTerminationDrainDuration: "-5s"},
+			expErr:        `unable to determine sidecar proxy termination drain duration: consul.hashicorp.com/sidecar-proxy-termination-drain-duration annotation of namespace default value "-5s" was invalid: duration must not be negative`,
+		},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			w := MeshWebhook{LifecycleConfig: c.config}
+			ns := corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "default", Annotations: c.nsAnnotations}}
+			pod := &corev1.Pod{
+				ObjectMeta: metav1.ObjectMeta{Annotations: c.podAnnotations},
+				Spec:       corev1.PodSpec{TerminationGracePeriodSeconds: c.gracePeriod},
+			}
+			err := w.ensureTerminationGracePeriod(ns, pod)
+			if c.expErr != "" {
+				require.EqualError(t, err, c.expErr)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, c.expGracePeriod, pod.Spec.TerminationGracePeriodSeconds)
+		})
+	}
+}
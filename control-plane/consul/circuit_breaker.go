@@ -0,0 +1,258 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package consul
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/hashicorp/consul-server-connection-manager/discovery"
+	"github.com/prometheus/client_golang/prometheus"
+	ctrl "sigs.k8s.io/controller-runtime"
+	ctrlmetrics "sigs.k8s.io/controller-runtime/pkg/metrics"
+)
+
+// Results of the Consul API requests metric.
+const (
+	requestResultSuccess  = "success"
+	requestResultFailure  = "failure"
+	requestResultRejected = "rejected"
+)
+
+var (
+	// apiRequests is the number of requests to the Consul API by result. Requests are rejected
+	// without being sent while the circuit breaker is open.
+	apiRequests = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "consul_k8s_consul_api_requests_total",
+		Help: "Number of requests to the Consul API by result: success, failure (the server could not be reached) or rejected (the circuit breaker was open).",
+	}, []string{"result"})
+	// circuitOpen is 1 while the circuit breaker is open and 0 otherwise.
+	circuitOpen = prometheus.NewGauge(prometheus.GaugeOpts{
+		Name: "consul_k8s_consul_circuit_breaker_open",
+		Help: "Whether the circuit breaker of the Consul API requests is open because the Consul servers are unreachable.",
+	})
+	// circuitRetries is the number of trial requests sent after the circuit breaker was open.
+	circuitRetries = prometheus.NewCounter(prometheus.CounterOpts{
+		Name: "consul_k8s_consul_circuit_breaker_retries_total",
+		Help: "Number of trial requests sent to the Consul servers after the circuit breaker was open.",
+	})
+	// serverFailovers is the number of times the consul-server-connection-manager switched to another server.
+	serverFailovers = prometheus.NewCounter(prometheus.CounterOpts{
+		Name: "consul_k8s_consul_server_failovers_total",
+		Help: "Number of times the Consul server the API requests are sent to changed.",
+	})
+)
+
+func init() {
+	ctrlmetrics.Registry.MustRegister(apiRequests, circuitOpen, circuitRetries, serverFailovers)
+}
+
+// CircuitOpenError is returned instead of sending a request to the Consul API while the
+// circuit breaker is open.
+type CircuitOpenError struct {
+	// RetryAfter is how long until a request is sent to the servers again.
+	RetryAfter time.Duration
+}
+
+func (e *CircuitOpenError) Error() string {
+	return fmt.Sprintf("Consul servers are unreachable, retrying in %s", e.RetryAfter.Round(time.Second))
+}
+
+// RetryAfterOpenCircuit returns how long to wait before reconciling again if err was caused by
+// an open circuit breaker, so that controllers back off rather than retrying in a hot loop.
+func RetryAfterOpenCircuit(err error) (time.Duration, bool) {
+	var openErr *CircuitOpenError
+	if errors.As(err, &openErr) {
+		return openErr.RetryAfter, true
+	}
+	return 0, false
+}
+
+// RetryAfter returns how long until requests are sent to the Consul servers again if the circuit
+// breaker of the config is open, or zero if requests are sent.
+func (c *Config) RetryAfter() time.Duration {
+	if c == nil {
+		return 0
+	}
+	return c.CircuitBreaker.RetryAfter()
+}
+
+// ReconcileBackoff replaces the error of a reconcile that failed because the circuit breaker was open
+// with a requeue once requests are sent to the Consul servers again.
+func ReconcileBackoff(result ctrl.Result, err error) (ctrl.Result, error) {
+	retryAfter, ok := RetryAfterOpenCircuit(err)
+	if !ok {
+		return result, err
+	}
+	if result.RequeueAfter == 0 || retryAfter < result.RequeueAfter {
+		// Requeue at least a second later so that a reconcile never loops on an expiring circuit.
+		result.RequeueAfter = max(retryAfter, time.Second)
+	}
+	return result, nil
+}
+
+// CircuitBreaker stops requests to the Consul API after failureThreshold consecutive requests failed
+// to reach the servers. After openDuration a single trial request is sent; the circuit is closed again
+// when it succeeds or when the consul-server-connection-manager fails over to another server.
+// A nil *CircuitBreaker never rejects requests.
+type CircuitBreaker struct {
+	failureThreshold int
+	openDuration     time.Duration
+
+	mu       sync.Mutex
+	failures int
+	openedAt time.Time
+	probing  bool
+	address  string
+
+	// now is replaced in tests.
+	now func() time.Time
+}
+
+// NewCircuitBreaker returns a CircuitBreaker, or nil if failureThreshold is zero.
+func NewCircuitBreaker(failureThreshold int, openDuration time.Duration) *CircuitBreaker {
+	if failureThreshold <= 0 {
+		return nil
+	}
+	return &CircuitBreaker{
+		failureThreshold: failureThreshold,
+		openDuration:     openDuration,
+		now:              time.Now,
+	}
+}
+
+// RetryAfter returns how long until requests are sent to the servers again, or zero if the circuit is closed.
+func (b *CircuitBreaker) RetryAfter() time.Duration {
+	if b == nil {
+		return 0
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.openedAt.IsZero() {
+		return 0
+	}
+	return max(b.openedAt.Add(b.openDuration).Sub(b.now()), 0)
+}
+
+// allow returns an error if the request must not be sent. While the circuit is open only a single
+// trial request is allowed once openDuration has passed.
+func (b *CircuitBreaker) allow() error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.openedAt.IsZero() {
+		return nil
+	}
+	retryAfter := b.openedAt.Add(b.openDuration).Sub(b.now())
+	if retryAfter > 0 || b.probing {
+		return &CircuitOpenError{RetryAfter: max(retryAfter, 0)}
+	}
+	b.probing = true
+	circuitRetries.Inc()
+	return nil
+}
+
+// record updates the circuit with the result of a request that was allowed.
+func (b *CircuitBreaker) record(reachable bool) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.probing = false
+	if reachable {
+		b.failures = 0
+		b.closeLocked()
+		return
+	}
+	b.failures++
+	if b.failures >= b.failureThreshold {
+		// A failed trial request opens the circuit for another openDuration.
+		b.openedAt = b.now()
+		circuitOpen.Set(1)
+	}
+}
+
+// release lets another trial request be sent if the request that was allowed had no result.
+func (b *CircuitBreaker) release() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.probing = false
+}
+
+// serverChanged closes the circuit when requests are sent to another server than before.
+func (b *CircuitBreaker) serverChanged(address string) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.address == address {
+		return
+	}
+	if b.address != "" {
+		serverFailovers.Inc()
+		b.failures = 0
+		b.closeLocked()
+	}
+	b.address = address
+}
+
+func (b *CircuitBreaker) closeLocked() {
+	b.openedAt = time.Time{}
+	circuitOpen.Set(0)
+}
+
+// RoundTripper returns a http.RoundTripper that sends requests with next unless the circuit is open.
+func (b *CircuitBreaker) RoundTripper(next http.RoundTripper) http.RoundTripper {
+	if b == nil {
+		return next
+	}
+	return &circuitBreakerRoundTripper{breaker: b, next: next}
+}
+
+type circuitBreakerRoundTripper struct {
+	breaker *CircuitBreaker
+	next    http.RoundTripper
+}
+
+func (t *circuitBreakerRoundTripper) RoundTrip(req *http.Request) (*http.Response, error) {
+	if err := t.breaker.allow(); err != nil {
+		apiRequests.WithLabelValues(requestResultRejected).Inc()
+		return nil, err
+	}
+	resp, err := t.next.RoundTrip(req)
+	switch {
+	case err == nil:
+		// Error responses count as successes since the server could be reached.
+		apiRequests.WithLabelValues(requestResultSuccess).Inc()
+		t.breaker.record(true)
+	case errors.Is(req.Context().Err(), context.Canceled):
+		// A request cancelled by its caller says nothing about the servers. Timeouts are failures.
+		t.breaker.release()
+	default:
+		apiRequests.WithLabelValues(requestResultFailure).Inc()
+		t.breaker.record(false)
+	}
+	return resp, err
+}
+
+// ServerConnectionManager returns a ServerConnectionManager that tells the circuit breaker
+// when mgr fails over to another server.
+func (b *CircuitBreaker) ServerConnectionManager(mgr ServerConnectionManager) ServerConnectionManager {
+	if b == nil {
+		return mgr
+	}
+	return &circuitBreakerConnMgr{ServerConnectionManager: mgr, breaker: b}
+}
+
+type circuitBreakerConnMgr struct {
+	ServerConnectionManager
+	breaker *CircuitBreaker
+}
+
+func (m *circuitBreakerConnMgr) State() (discovery.State, error) {
+	state, err := m.ServerConnectionManager.State()
+	if err == nil {
+		m.breaker.serverChanged(state.Address.String())
+	}
+	return state, err
+}
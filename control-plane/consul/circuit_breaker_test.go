@@ -0,0 +1,196 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package consul
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/hashicorp/consul-server-connection-manager/discovery"
+	capi "github.com/hashicorp/consul/api"
+	"github.com/stretchr/testify/require"
+	ctrl "sigs.k8s.io/controller-runtime"
+)
+
+type roundTripperFunc func(*http.Request) (*http.Response, error)
+
+func (f roundTripperFunc) RoundTrip(req *http.Request) (*http.Response, error) { return f(req) }
+
+func TestCircuitBreaker(t *testing.T) {
+	now := time.Now()
+	breaker := NewCircuitBreaker(2, 30*time.Second)
+	breaker.now = func() time.Time { return now }
+
+	reachable := false
+	sent := 0
+	rt := breaker.RoundTripper(roundTripperFunc(func(*http.Request) (*http.Response, error) {
+		sent++
+		if !reachable {
+			return nil, errors.New("connection refused")
+		}
+		return &http.Response{StatusCode: http.StatusInternalServerError}, nil
+	}))
+	send := func() error {
+		req, err := http.NewRequest(http.MethodGet, "http://consul/v1/status/leader", nil)
+		require.NoError(t, err)
+		_, err = rt.RoundTrip(req)
+		return err
+	}
+
+	// The circuit opens after two consecutive failures.
+	require.Error(t, send())
+	require.Zero(t, breaker.RetryAfter())
+	require.Error(t, send())
+	require.Equal(t, 30*time.Second, breaker.RetryAfter())
+
+	// Requests are rejected without being sent while the circuit is open.
+	err := send()
+	retryAfter, ok := RetryAfterOpenCircuit(fmt.Errorf("registering service: %w", err))
+	require.True(t, ok)
+	require.Equal(t, 30*time.Second, retryAfter)
+	require.Equal(t, 2, sent)
+
+	// After the open duration a failed trial request opens the circuit again.
+	now = now.Add(30 * time.Second)
+	require.Error(t, send())
+	require.Equal(t, 3, sent)
+	require.Equal(t, 30*time.Second, breaker.RetryAfter())
+
+	// A successful trial request closes the circuit, even if the server responds with an error.
+	now = now.Add(30 * time.Second)
+	reachable = true
+	require.NoError(t, send())
+	require.Zero(t, breaker.RetryAfter())
+	require.NoError(t, send())
+	require.Equal(t, 5, sent)
+}
+
+func TestCircuitBreaker_CancelledRequestsAreNotFailures(t *testing.T) {
+	breaker := NewCircuitBreaker(1, time.Minute)
+	rt := breaker.RoundTripper(roundTripperFunc(func(req *http.Request) (*http.Response, error) {
+		return nil, req.Context().Err()
+	}))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, "http://consul/v1/status/leader", nil)
+	require.NoError(t, err)
+	_, err = rt.RoundTrip(req)
+	require.ErrorIs(t, err, context.Canceled)
+	require.Zero(t, breaker.RetryAfter())
+}
+
+func TestCircuitBreaker_ServerFailoverClosesCircuit(t *testing.T) {
+	breaker := NewCircuitBreaker(1, time.Minute)
+	states := []discovery.State{
+		{Address: discovery.Addr{TCPAddr: net.TCPAddr{IP: net.ParseIP("10.0.0.1"), Port: 8502}}},
+		{Address: discovery.Addr{TCPAddr: net.TCPAddr{IP: net.ParseIP("10.0.0.2"), Port: 8502}}},
+	}
+	mgr := &MockServerConnectionManager{}
+	mgr.On("State").Return(states[0], nil).Once()
+	mgr.On("State").Return(states[1], nil).Once()
+	watcher := breaker.ServerConnectionManager(mgr)
+
+	_, err := watcher.State()
+	require.NoError(t, err)
+	breaker.record(false)
+	require.NotZero(t, breaker.RetryAfter())
+
+	state, err := watcher.State()
+	require.NoError(t, err)
+	require.Equal(t, states[1], state)
+	require.Zero(t, breaker.RetryAfter())
+	mgr.AssertExpectations(t)
+}
+
+func TestCircuitBreaker_Disabled(t *testing.T) {
+	breaker := NewCircuitBreaker(0, time.Minute)
+	require.Nil(t, breaker)
+	require.Zero(t, breaker.RetryAfter())
+
+	next := roundTripperFunc(func(*http.Request) (*http.Response, error) { return nil, nil })
+	require.NotNil(t, breaker.RoundTripper(next))
+	mgr := &MockServerConnectionManager{}
+	require.Same(t, mgr, breaker.ServerConnectionManager(mgr))
+	require.Zero(t, (*Config)(nil).RetryAfter())
+}
+
+func TestNewClientFromConnMgrState_CircuitBreaker(t *testing.T) {
+	// The server is closed so that requests fail to reach it.
+	server := httptest.NewServer(http.NotFoundHandler())
+	host, port, err := net.SplitHostPort(server.Listener.Addr().String())
+	require.NoError(t, err)
+	server.Close()
+
+	var httpPort int
+	_, err = fmt.Sscan(port, &httpPort)
+	require.NoError(t, err)
+	cfg := &Config{
+		APIClientConfig: &capi.Config{},
+		HTTPPort:        httpPort,
+	}
+	require.NoError(t, cfg.UseCircuitBreaker(NewCircuitBreaker(1, time.Minute)))
+	transport := cfg.APIClientConfig.Transport
+	require.NotNil(t, transport)
+	state := discovery.State{Address: discovery.Addr{TCPAddr: net.TCPAddr{IP: net.ParseIP(host)}}}
+
+	client, err := NewClientFromConnMgrState(cfg, state)
+	require.NoError(t, err)
+	_, err = client.Status().Leader()
+	require.Error(t, err)
+	_, ok := RetryAfterOpenCircuit(err)
+	require.False(t, ok)
+
+	// The circuit is open so the request of a new client is rejected.
+	client, err = NewClientFromConnMgrState(cfg, state)
+	require.NoError(t, err)
+	_, err = client.Status().Leader()
+	_, ok = RetryAfterOpenCircuit(err)
+	require.True(t, ok)
+
+	// The clients share the transport but not the http.Client of the config.
+	require.Nil(t, cfg.APIClientConfig.HttpClient)
+	require.Same(t, transport, cfg.APIClientConfig.Transport)
+}
+
+func TestReconcileBackoff(t *testing.T) {
+	openErr := fmt.Errorf("deleting config entry: %w", &CircuitOpenError{RetryAfter: 20 * time.Second})
+	cases := map[string]struct {
+		result    ctrl.Result
+		err       error
+		expResult ctrl.Result
+		expErr    bool
+	}{
+		"other error": {
+			err:    errors.New("ACL not found"),
+			expErr: true,
+		},
+		"open circuit": {
+			err:       openErr,
+			expResult: ctrl.Result{RequeueAfter: 20 * time.Second},
+		},
+		"earlier requeue is kept": {
+			result:    ctrl.Result{RequeueAfter: 10 * time.Second},
+			err:       openErr,
+			expResult: ctrl.Result{RequeueAfter: 10 * time.Second},
+		},
+		"expiring circuit": {
+			err:       &CircuitOpenError{},
+			expResult: ctrl.Result{RequeueAfter: time.Second},
+		},
+	}
+	for name, c := range cases {
+		t.Run(name, func(t *testing.T) {
+			result, err := ReconcileBackoff(c.result, c.err)
+			require.Equal(t, c.expResult, result)
+			require.Equal(t, c.expErr, err != nil)
+		})
+	}
+}
@@ -67,6 +67,23 @@ type Config struct {
 	HTTPPort        int
 	GRPCPort        int
 	APITimeout      time.Duration
+	// CircuitBreaker, if set, rejects the requests of the clients created from the config
+	// while the Consul servers are unreachable.
+	CircuitBreaker *CircuitBreaker
+}
+
+// UseCircuitBreaker makes the clients created from the config send their requests through the circuit breaker.
+// The transport the clients share is set up here so that creating clients doesn't have to set it up.
+func (c *Config) UseCircuitBreaker(breaker *CircuitBreaker) error {
+	if c.APIClientConfig.Transport == nil {
+		tlsClientConfig, err := capi.SetupTLSConfig(&c.APIClientConfig.TLSConfig)
+		if err != nil {
+			return err
+		}
+		c.APIClientConfig.Transport = &http.Transport{TLSClientConfig: tlsClientConfig}
+	}
+	c.CircuitBreaker = breaker
+	return nil
 }
 
 // todo (ishustava): replace all usages of this one.
@@ -78,7 +95,22 @@ func NewClientFromConnMgrState(config *Config, state discovery.State) (*capi.Cli
 	if state.Token != "" {
 		config.APIClientConfig.Token = state.Token
 	}
-	return NewClient(config.APIClientConfig, config.APITimeout)
+	apiConfig := config.APIClientConfig
+	if config.CircuitBreaker != nil {
+		// The client gets its own http.Client for the circuit breaker's transport, since the
+		// http.Client of the shared config is used by the clients that were already created.
+		clientConfig := *config.APIClientConfig
+		clientConfig.HttpClient = nil
+		apiConfig = &clientConfig
+	}
+	client, err := NewClient(apiConfig, config.APITimeout)
+	if err != nil {
+		return nil, err
+	}
+	if config.CircuitBreaker != nil {
+		apiConfig.HttpClient.Transport = config.CircuitBreaker.RoundTripper(apiConfig.Transport)
+	}
+	return client, nil
 }
 
 // NewClientFromConnMgr creates a new V1 API client by first getting the state of the passed watcher.
@@ -0,0 +1,24 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package consul
+
+import (
+	"fmt"
+
+	"github.com/hashicorp/consul/proto-public/pbresource"
+)
+
+// NewResourceServiceClient creates a pbresource.ResourceServiceClient for reading and writing
+// Consul v2 resources. It uses the gRPC connection of the server connection manager, so the
+// connection follows the current server and carries the manager's ACL token.
+func NewResourceServiceClient(watcher ServerConnectionManager) (pbresource.ResourceServiceClient, error) {
+	state, err := watcher.State()
+	if err != nil {
+		return nil, fmt.Errorf("unable to get connection manager state: %w", err)
+	}
+	if state.GRPCConn == nil {
+		return nil, fmt.Errorf("unable to get gRPC connection from connection manager")
+	}
+	return pbresource.NewResourceServiceClient(state.GRPCConn), nil
+}
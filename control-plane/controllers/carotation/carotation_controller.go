@@ -0,0 +1,382 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package carotation
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/go-logr/logr"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/selection"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/utils/ptr"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/builder"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/predicate"
+
+	"github.com/hashicorp/consul-k8s/control-plane/api/v1alpha1"
+	"github.com/hashicorp/consul-k8s/control-plane/connect-inject/constants"
+	"github.com/hashicorp/consul-k8s/control-plane/consul"
+)
+
+const (
+	// rolloutRequeueInterval is how often restarted workloads are checked while a rotation is in progress.
+	rolloutRequeueInterval = 10 * time.Second
+
+	kindDeployment  = "Deployment"
+	kindStatefulSet = "StatefulSet"
+	kindDaemonSet   = "DaemonSet"
+	kindReplicaSet  = "ReplicaSet"
+
+	syncedReasonConsulUnavailable = "ConsulUnavailable"
+	syncedReasonRestartFailed     = "RestartFailed"
+
+	rotatedReasonUpToDate   = "UpToDate"
+	rotatedReasonInProgress = "RotationInProgress"
+	rotatedReasonPaused     = "Paused"
+)
+
+// Controller watches the Connect CA roots in Consul for each ConnectCARotation resource. When the
+// active root rotates, e.g. because the root of the Vault CA provider was rotated, it restarts the
+// injected workloads in the namespace of the resource a few at a time so that their sidecars request
+// leaf certificates signed by the active root.
+type Controller struct {
+	client.Client
+	ConsulClientConfig  *consul.Config
+	ConsulServerConnMgr consul.ServerConnectionManager
+
+	Scheme *runtime.Scheme
+	Log    logr.Logger
+}
+
+// workload is a Deployment, StatefulSet or DaemonSet with injected pods.
+type workload struct {
+	kind   string
+	object client.Object
+	// oldestPod is the creation time of the oldest injected pod of the workload.
+	oldestPod metav1.Time
+}
+
+func (w *workload) String() string {
+	return w.kind + "/" + w.object.GetName()
+}
+
+// +kubebuilder:rbac:groups=consul.hashicorp.com,resources=connectcarotations,verbs=get;list;watch;create;update;patch;delete
+// +kubebuilder:rbac:groups=consul.hashicorp.com,resources=connectcarotations/status,verbs=get;update;patch
+// +kubebuilder:rbac:groups=apps,resources=deployments;statefulsets;daemonsets,verbs=get;list;watch;patch
+// +kubebuilder:rbac:groups=apps,resources=replicasets,verbs=get;list;watch
+
+func (r *Controller) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
+	log := r.Log.WithValues("connectcarotation", req.NamespacedName)
+
+	rotation := &v1alpha1.ConnectCARotation{}
+	if err := r.Client.Get(ctx, req.NamespacedName, rotation); err != nil {
+		if !k8serrors.IsNotFound(err) {
+			log.Error(err, "unable to get CA rotation")
+		}
+		return ctrl.Result{}, client.IgnoreNotFound(err)
+	}
+	if !rotation.ObjectMeta.DeletionTimestamp.IsZero() {
+		return ctrl.Result{}, nil
+	}
+	previous := rotation.Status.DeepCopy()
+
+	if err := rotation.Validate(); err != nil {
+		log.Error(err, "invalid CA rotation")
+		return ctrl.Result{}, r.updateStatus(ctx, rotation, previous, v1alpha1.SyncedCondition(v1alpha1.SyncedReasonInvalidSpec, err), nil)
+	}
+
+	activeRootID, err := r.activeRootID()
+	if err != nil {
+		log.Error(err, "failed to read Connect CA roots")
+		if statusErr := r.updateStatus(ctx, rotation, previous, v1alpha1.SyncedCondition(syncedReasonConsulUnavailable, err), nil); statusErr != nil {
+			log.Error(statusErr, "failed to update CA rotation status")
+		}
+		return ctrl.Result{}, err
+	}
+	if rotation.Status.ActiveRootID != "" && rotation.Status.ActiveRootID != activeRootID {
+		log.Info("detected Connect CA root rotation", "previous", rotation.Status.ActiveRootID, "active", activeRootID)
+		rotation.Status.PreviousRootID = rotation.Status.ActiveRootID
+		rotation.Status.LastRotationTime = ptr.To(metav1.Now())
+	}
+	rotation.Status.ActiveRootID = activeRootID
+
+	pending, restarting, err := r.rotateWorkloads(ctx, log, rotation)
+	rotation.Status.PendingWorkloads = pending
+	rotation.Status.RestartingWorkloads = restarting
+	if err != nil {
+		log.Error(err, "failed to restart workloads")
+		if statusErr := r.updateStatus(ctx, rotation, previous, v1alpha1.SyncedCondition(syncedReasonRestartFailed, err), nil); statusErr != nil {
+			log.Error(statusErr, "failed to update CA rotation status")
+		}
+		return ctrl.Result{}, err
+	}
+
+	rotated := rotatedCondition(rotation, len(pending), len(restarting))
+	if err := r.updateStatus(ctx, rotation, previous, v1alpha1.SyncedCondition("", nil), &rotated); err != nil {
+		log.Error(err, "failed to update CA rotation status")
+		return ctrl.Result{}, err
+	}
+
+	// Consul doesn't notify Kubernetes of rotations, so poll the CA roots, and poll the
+	// restarted workloads more often until they have rolled out.
+	if len(restarting) > 0 && !rotation.Spec.Paused {
+		return ctrl.Result{RequeueAfter: rolloutRequeueInterval}, nil
+	}
+	return ctrl.Result{RequeueAfter: rotation.PollInterval()}, nil
+}
+
+// activeRootID returns the ID of the active Connect CA root.
+func (r *Controller) activeRootID() (string, error) {
+	consulClient, err := consul.NewClientFromConnMgr(r.ConsulClientConfig, r.ConsulServerConnMgr)
+	if err != nil {
+		return "", err
+	}
+	roots, _, err := consulClient.Connect().CARoots(nil)
+	if err != nil {
+		return "", err
+	}
+	if roots.ActiveRootID == "" {
+		return "", errors.New("no active Connect CA root in Consul")
+	}
+	return roots.ActiveRootID, nil
+}
+
+// rotateWorkloads restarts the workloads of rotation that have pods created before the last rotation,
+// without exceeding the maximum number of concurrent restarts. It returns the names of the workloads
+// that are still waiting to be restarted and of those that are rolling out.
+func (r *Controller) rotateWorkloads(ctx context.Context, log logr.Logger, rotation *v1alpha1.ConnectCARotation) ([]string, []string, error) {
+	if rotation.Status.LastRotationTime == nil {
+		return nil, nil, nil
+	}
+	workloads, err := r.workloads(ctx, rotation)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var pending, restarting []*workload
+	for _, w := range workloads {
+		if podTemplate(w.object).Annotations[constants.AnnotationConnectCARootID] == rotation.Status.ActiveRootID {
+			if !rolledOut(w.object) {
+				restarting = append(restarting, w)
+			}
+			continue
+		}
+		if w.oldestPod.Before(rotation.Status.LastRotationTime) {
+			pending = append(pending, w)
+		}
+	}
+
+	for !rotation.Spec.Paused && len(pending) > 0 && len(restarting) < rotation.MaxConcurrentRestarts() {
+		w := pending[0]
+		if err := r.restart(ctx, w, rotation.Status.ActiveRootID); err != nil {
+			return names(pending), names(restarting), fmt.Errorf("error restarting %s: %w", w, err)
+		}
+		log.Info("restarted workload to rotate leaf certificates", "workload", w.String())
+		pending, restarting = pending[1:], append(restarting, w)
+	}
+	return names(pending), names(restarting), nil
+}
+
+// workloads returns the workloads with injected pods selected by rotation, sorted by name.
+func (r *Controller) workloads(ctx context.Context, rotation *v1alpha1.ConnectCARotation) ([]*workload, error) {
+	selector := labels.Everything()
+	if rotation.Spec.Selector != nil {
+		var err error
+		if selector, err = metav1.LabelSelectorAsSelector(rotation.Spec.Selector); err != nil {
+			return nil, err
+		}
+	}
+	injected, err := labels.NewRequirement(constants.KeyInjectStatus, selection.Equals, []string{constants.Injected})
+	if err != nil {
+		return nil, err
+	}
+	podList := &corev1.PodList{}
+	if err := r.Client.List(ctx, podList,
+		client.InNamespace(rotation.Namespace),
+		client.MatchingLabelsSelector{Selector: selector.Add(*injected)},
+	); err != nil {
+		return nil, err
+	}
+
+	byName := make(map[string]*workload)
+	for _, pod := range podList.Items {
+		kind, name, err := r.podWorkload(ctx, &pod)
+		if err != nil {
+			return nil, err
+		}
+		if kind == "" {
+			// Pods without a workload can't be restarted, and are recreated with fresh leaf
+			// certificates by whatever created them.
+			continue
+		}
+		key := kind + "/" + name
+		if w, ok := byName[key]; ok {
+			if pod.CreationTimestamp.Before(&w.oldestPod) {
+				w.oldestPod = pod.CreationTimestamp
+			}
+			continue
+		}
+		object := newWorkloadObject(kind)
+		if err := r.Client.Get(ctx, types.NamespacedName{Name: name, Namespace: pod.Namespace}, object); err != nil {
+			if k8serrors.IsNotFound(err) {
+				continue
+			}
+			return nil, err
+		}
+		byName[key] = &workload{kind: kind, object: object, oldestPod: pod.CreationTimestamp}
+	}
+
+	workloads := make([]*workload, 0, len(byName))
+	for _, w := range byName {
+		workloads = append(workloads, w)
+	}
+	sort.Slice(workloads, func(i, j int) bool { return workloads[i].String() < workloads[j].String() })
+	return workloads, nil
+}
+
+// podWorkload returns the kind and name of the Deployment, StatefulSet or DaemonSet that manages pod.
+// It returns an empty kind if the pod is not managed by one of them.
+func (r *Controller) podWorkload(ctx context.Context, pod *corev1.Pod) (string, string, error) {
+	owner := metav1.GetControllerOf(pod)
+	if owner == nil {
+		return "", "", nil
+	}
+	switch owner.Kind {
+	case kindStatefulSet, kindDaemonSet:
+		return owner.Kind, owner.Name, nil
+	case kindReplicaSet:
+		replicaSet := &appsv1.ReplicaSet{}
+		err := r.Client.Get(ctx, types.NamespacedName{Name: owner.Name, Namespace: pod.Namespace}, replicaSet)
+		if k8serrors.IsNotFound(err) {
+			return "", "", nil
+		} else if err != nil {
+			return "", "", err
+		}
+		if owner := metav1.GetControllerOf(replicaSet); owner != nil && owner.Kind == kindDeployment {
+			return kindDeployment, owner.Name, nil
+		}
+	}
+	return "", "", nil
+}
+
+// restart triggers a rolling restart of w by setting the root ID annotation on its pod template.
+func (r *Controller) restart(ctx context.Context, w *workload, rootID string) error {
+	patch := client.MergeFrom(w.object.DeepCopyObject().(client.Object))
+	template := podTemplate(w.object)
+	if template.Annotations == nil {
+		template.Annotations = make(map[string]string)
+	}
+	template.Annotations[constants.AnnotationConnectCARootID] = rootID
+	return r.Client.Patch(ctx, w.object, patch)
+}
+
+func newWorkloadObject(kind string) client.Object {
+	switch kind {
+	case kindStatefulSet:
+		return &appsv1.StatefulSet{}
+	case kindDaemonSet:
+		return &appsv1.DaemonSet{}
+	default:
+		return &appsv1.Deployment{}
+	}
+}
+
+func podTemplate(object client.Object) *corev1.PodTemplateSpec {
+	switch o := object.(type) {
+	case *appsv1.Deployment:
+		return &o.Spec.Template
+	case *appsv1.StatefulSet:
+		return &o.Spec.Template
+	case *appsv1.DaemonSet:
+		return &o.Spec.Template
+	}
+	return nil
+}
+
+// rolledOut returns whether all pods of the workload run its current pod template and are available.
+func rolledOut(object client.Object) bool {
+	switch o := object.(type) {
+	case *appsv1.Deployment:
+		replicas := ptr.Deref(o.Spec.Replicas, 1)
+		return o.Status.ObservedGeneration >= o.Generation &&
+			o.Status.Replicas == replicas &&
+			o.Status.UpdatedReplicas == replicas &&
+			o.Status.AvailableReplicas == replicas
+	case *appsv1.StatefulSet:
+		replicas := ptr.Deref(o.Spec.Replicas, 1)
+		return o.Status.ObservedGeneration >= o.Generation &&
+			o.Status.UpdatedReplicas == replicas &&
+			o.Status.ReadyReplicas == replicas
+	case *appsv1.DaemonSet:
+		desired := o.Status.DesiredNumberScheduled
+		return o.Status.ObservedGeneration >= o.Generation &&
+			o.Status.UpdatedNumberScheduled == desired &&
+			o.Status.NumberAvailable == desired
+	}
+	return true
+}
+
+// names returns the sorted names of workloads.
+func names(workloads []*workload) []string {
+	var names []string
+	for _, w := range workloads {
+		names = append(names, w.String())
+	}
+	sort.Strings(names)
+	return names
+}
+
+// rotatedCondition returns the WorkloadsRotated condition of rotation given the number of workloads
+// waiting to be restarted and rolling out.
+func rotatedCondition(rotation *v1alpha1.ConnectCARotation, pending, restarting int) v1alpha1.Condition {
+	condition := v1alpha1.Condition{
+		Type:               v1alpha1.ConditionWorkloadsRotated,
+		LastTransitionTime: metav1.Now(),
+	}
+	switch {
+	case pending == 0 && restarting == 0:
+		condition.Status, condition.Reason = corev1.ConditionTrue, rotatedReasonUpToDate
+		condition.Message = fmt.Sprintf("all workloads use leaf certificates signed by root %s", rotation.Status.ActiveRootID)
+	case rotation.Spec.Paused:
+		condition.Status, condition.Reason = corev1.ConditionFalse, rotatedReasonPaused
+		condition.Message = fmt.Sprintf("restarts are paused with %d workloads pending and %d rolling out", pending, restarting)
+	default:
+		condition.Status, condition.Reason = corev1.ConditionFalse, rotatedReasonInProgress
+		condition.Message = fmt.Sprintf("%d workloads pending and %d rolling out", pending, restarting)
+	}
+	return condition
+}
+
+// updateStatus sets the conditions of rotation and writes its status if it changed from previous.
+func (r *Controller) updateStatus(ctx context.Context, rotation *v1alpha1.ConnectCARotation, previous *v1alpha1.ConnectCARotationStatus, synced v1alpha1.Condition, rotated *v1alpha1.Condition) error {
+	rotation.Status.Conditions = v1alpha1.Conditions{synced}
+	if rotated != nil {
+		rotation.Status.Conditions = append(rotation.Status.Conditions, *rotated)
+	}
+	rotation.Status.Conditions.KeepTransitionTimes(previous.Conditions)
+	if equality.Semantic.DeepEqual(previous, &rotation.Status) {
+		return nil
+	}
+	return r.Status().Update(ctx, rotation)
+}
+
+func (r *Controller) SetupWithManager(mgr ctrl.Manager) error {
+	return ctrl.NewControllerManagedBy(mgr).
+		// Updates of the status don't need to be reconciled, and the CA roots are polled
+		// with RequeueAfter.
+		For(&v1alpha1.ConnectCARotation{}, builder.WithPredicates(predicate.GenerationChangedPredicate{})).
+		Complete(r)
+}
@@ -0,0 +1,437 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package carotation
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	logrtest "github.com/go-logr/logr/testr"
+	capi "github.com/hashicorp/consul/api"
+	"github.com/stretchr/testify/require"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	"k8s.io/utils/ptr"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	"github.com/hashicorp/consul-k8s/control-plane/api/v1alpha1"
+	"github.com/hashicorp/consul-k8s/control-plane/connect-inject/constants"
+	"github.com/hashicorp/consul-k8s/control-plane/consul"
+	"github.com/hashicorp/consul-k8s/control-plane/helper/test"
+)
+
+func TestReconcile(t *testing.T) {
+	t.Parallel()
+	rotatedAt := metav1.NewTime(time.Now().Add(-10 * time.Minute))
+	beforeRotation := metav1.NewTime(rotatedAt.Add(-time.Hour))
+	afterRotation := metav1.NewTime(rotatedAt.Add(time.Minute))
+
+	// Workloads of the namespace: a Deployment, a StatefulSet and a DaemonSet with injected pods.
+	workloads := func(rootID string, rolledOut bool, podsCreated metav1.Time) []runtime.Object {
+		return []runtime.Object{
+			deployment("web", rootID, rolledOut),
+			replicaSet("web-7d4b9", "web"),
+			pod("web-7d4b9-abcde", kindReplicaSet, "web-7d4b9", "web", podsCreated),
+			statefulSet("db", rootID, rolledOut),
+			pod("db-0", kindStatefulSet, "db", "db", podsCreated),
+			daemonSet("agent", rootID, rolledOut),
+			pod("agent-xyz12", kindDaemonSet, "agent", "agent", podsCreated),
+		}
+	}
+
+	cases := map[string]struct {
+		spec          v1alpha1.ConnectCARotationSpec
+		status        v1alpha1.ConnectCARotationStatus
+		existing      []runtime.Object
+		expSynced     corev1.ConditionStatus
+		expRotated    corev1.ConditionStatus
+		expReason     string
+		expPrevious   string
+		expRestarted  []string
+		expPending    []string
+		expRestarting []string
+		expRequeue    time.Duration
+	}{
+		"first observation of the root doesn't restart workloads": {
+			existing:   workloads("", true, beforeRotation),
+			expSynced:  corev1.ConditionTrue,
+			expRotated: corev1.ConditionTrue,
+			expReason:  rotatedReasonUpToDate,
+			expRequeue: v1alpha1.DefaultCARotationPollInterval,
+		},
+		"rotation restarts one workload at a time by default": {
+			spec:          v1alpha1.ConnectCARotationSpec{PollInterval: "30s"},
+			status:        v1alpha1.ConnectCARotationStatus{ActiveRootID: "root-1"},
+			existing:      workloads("", true, beforeRotation),
+			expSynced:     corev1.ConditionTrue,
+			expRotated:    corev1.ConditionFalse,
+			expReason:     rotatedReasonInProgress,
+			expPrevious:   "root-1",
+			expRestarted:  []string{"DaemonSet/agent"},
+			expPending:    []string{"Deployment/web", "StatefulSet/db"},
+			expRestarting: []string{"DaemonSet/agent"},
+			expRequeue:    rolloutRequeueInterval,
+		},
+		"rotation restarts up to maxConcurrentRestarts workloads": {
+			spec:          v1alpha1.ConnectCARotationSpec{MaxConcurrentRestarts: ptr.To(int32(5))},
+			status:        v1alpha1.ConnectCARotationStatus{ActiveRootID: "root-1"},
+			existing:      workloads("root-1", true, beforeRotation),
+			expSynced:     corev1.ConditionTrue,
+			expRotated:    corev1.ConditionFalse,
+			expReason:     rotatedReasonInProgress,
+			expPrevious:   "root-1",
+			expRestarted:  []string{"DaemonSet/agent", "Deployment/web", "StatefulSet/db"},
+			expRestarting: []string{"DaemonSet/agent", "Deployment/web", "StatefulSet/db"},
+			expRequeue:    rolloutRequeueInterval,
+		},
+		"restarted workloads that haven't rolled out block further restarts": {
+			spec: v1alpha1.ConnectCARotationSpec{MaxConcurrentRestarts: ptr.To(int32(3))},
+			status: v1alpha1.ConnectCARotationStatus{
+				ActiveRootID:     "root-2",
+				PreviousRootID:   "root-1",
+				LastRotationTime: &rotatedAt,
+			},
+			existing: []runtime.Object{
+				deployment("web", "root-2", false),
+				replicaSet("web-7d4b9", "web"),
+				pod("web-7d4b9-abcde", kindReplicaSet, "web-7d4b9", "web", beforeRotation),
+				statefulSet("db", "root-2", false),
+				pod("db-0", kindStatefulSet, "db", "db", beforeRotation),
+				daemonSet("agent", "", true),
+				pod("agent-xyz12", kindDaemonSet, "agent", "agent", beforeRotation),
+				statefulSet("cache", "", true),
+				pod("cache-0", kindStatefulSet, "cache", "cache", beforeRotation),
+			},
+			expSynced:     corev1.ConditionTrue,
+			expRotated:    corev1.ConditionFalse,
+			expReason:     rotatedReasonInProgress,
+			expPrevious:   "root-1",
+			expRestarted:  []string{"DaemonSet/agent", "Deployment/web", "StatefulSet/db"},
+			expPending:    []string{"StatefulSet/cache"},
+			expRestarting: []string{"DaemonSet/agent", "Deployment/web", "StatefulSet/db"},
+			expRequeue:    rolloutRequeueInterval,
+		},
+		"rotation is complete when restarted workloads have rolled out": {
+			status: v1alpha1.ConnectCARotationStatus{
+				ActiveRootID:     "root-2",
+				PreviousRootID:   "root-1",
+				LastRotationTime: &rotatedAt,
+			},
+			existing:     workloads("root-2", true, afterRotation),
+			expSynced:    corev1.ConditionTrue,
+			expRotated:   corev1.ConditionTrue,
+			expReason:    rotatedReasonUpToDate,
+			expPrevious:  "root-1",
+			expRestarted: []string{"DaemonSet/agent", "Deployment/web", "StatefulSet/db"},
+			expRequeue:   v1alpha1.DefaultCARotationPollInterval,
+		},
+		"workloads with pods created after the rotation aren't restarted": {
+			status: v1alpha1.ConnectCARotationStatus{
+				ActiveRootID:     "root-2",
+				PreviousRootID:   "root-1",
+				LastRotationTime: &rotatedAt,
+			},
+			existing:    workloads("", true, afterRotation),
+			expSynced:   corev1.ConditionTrue,
+			expRotated:  corev1.ConditionTrue,
+			expReason:   rotatedReasonUpToDate,
+			expPrevious: "root-1",
+			expRequeue:  v1alpha1.DefaultCARotationPollInterval,
+		},
+		"paused rotation doesn't restart workloads": {
+			spec:        v1alpha1.ConnectCARotationSpec{Paused: true},
+			status:      v1alpha1.ConnectCARotationStatus{ActiveRootID: "root-1"},
+			existing:    workloads("", true, beforeRotation),
+			expSynced:   corev1.ConditionTrue,
+			expRotated:  corev1.ConditionFalse,
+			expReason:   rotatedReasonPaused,
+			expPrevious: "root-1",
+			expPending:  []string{"DaemonSet/agent", "Deployment/web", "StatefulSet/db"},
+			expRequeue:  v1alpha1.DefaultCARotationPollInterval,
+		},
+		"selector limits the restarted workloads": {
+			spec: v1alpha1.ConnectCARotationSpec{
+				Selector:              &metav1.LabelSelector{MatchLabels: map[string]string{"app": "web"}},
+				MaxConcurrentRestarts: ptr.To(int32(5)),
+			},
+			status:        v1alpha1.ConnectCARotationStatus{ActiveRootID: "root-1"},
+			existing:      workloads("", true, beforeRotation),
+			expSynced:     corev1.ConditionTrue,
+			expRotated:    corev1.ConditionFalse,
+			expReason:     rotatedReasonInProgress,
+			expPrevious:   "root-1",
+			expRestarted:  []string{"Deployment/web"},
+			expRestarting: []string{"Deployment/web"},
+			expRequeue:    rolloutRequeueInterval,
+		},
+		"invalid spec": {
+			spec:      v1alpha1.ConnectCARotationSpec{MaxConcurrentRestarts: ptr.To(int32(0))},
+			existing:  workloads("", true, beforeRotation),
+			expSynced: corev1.ConditionFalse,
+		},
+	}
+
+	for name, c := range cases {
+		c := c
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+			ctx := context.Background()
+			rotation := &v1alpha1.ConnectCARotation{
+				ObjectMeta: metav1.ObjectMeta{Name: "rotation", Namespace: "default"},
+				Spec:       c.spec,
+				Status:     c.status,
+			}
+
+			s := runtime.NewScheme()
+			require.NoError(t, clientgoscheme.AddToScheme(s))
+			s.AddKnownTypes(v1alpha1.GroupVersion, &v1alpha1.ConnectCARotation{}, &v1alpha1.ConnectCARotationList{})
+			// Pods of other workloads that aren't injected or have no controller are ignored.
+			existing := append([]runtime.Object{
+				rotation,
+				statefulSet("not-injected", "", true),
+				withoutInjection(pod("not-injected-0", kindStatefulSet, "not-injected", "not-injected", beforeRotation)),
+				pod("bare", "", "", "bare", beforeRotation),
+			}, c.existing...)
+			fakeClient := fake.NewClientBuilder().
+				WithScheme(s).
+				WithRuntimeObjects(existing...).
+				WithStatusSubresource(&v1alpha1.ConnectCARotation{}).
+				Build()
+
+			consulCfg, watcher := newFakeCARootsServer(t, "root-2")
+			controller := &Controller{
+				Client:              fakeClient,
+				ConsulClientConfig:  consulCfg,
+				ConsulServerConnMgr: watcher,
+				Scheme:              s,
+				Log:                 logrtest.New(t),
+			}
+
+			key := types.NamespacedName{Name: rotation.Name, Namespace: rotation.Namespace}
+			result, err := controller.Reconcile(ctx, ctrl.Request{NamespacedName: key})
+			require.NoError(t, err)
+			require.Equal(t, c.expRequeue, result.RequeueAfter)
+
+			fetched := &v1alpha1.ConnectCARotation{}
+			require.NoError(t, fakeClient.Get(ctx, key, fetched))
+			require.Equal(t, v1alpha1.ConditionSynced, fetched.Status.Conditions[0].Type)
+			require.Equal(t, c.expSynced, fetched.Status.Conditions[0].Status)
+			if c.expSynced == corev1.ConditionFalse {
+				require.Len(t, fetched.Status.Conditions, 1)
+				return
+			}
+
+			require.Len(t, fetched.Status.Conditions, 2)
+			require.Equal(t, v1alpha1.ConditionWorkloadsRotated, fetched.Status.Conditions[1].Type)
+			require.Equal(t, c.expRotated, fetched.Status.Conditions[1].Status)
+			require.Equal(t, c.expReason, fetched.Status.Conditions[1].Reason)
+			require.Equal(t, "root-2", fetched.Status.ActiveRootID)
+			require.Equal(t, c.expPrevious, fetched.Status.PreviousRootID)
+			require.Equal(t, c.expPrevious != "", fetched.Status.LastRotationTime != nil)
+			require.Equal(t, c.expPending, fetched.Status.PendingWorkloads)
+			require.Equal(t, c.expRestarting, fetched.Status.RestartingWorkloads)
+
+			var restarted []string
+			for _, w := range []*workload{
+				{kind: kindDaemonSet, object: &appsv1.DaemonSet{}},
+				{kind: kindDeployment, object: &appsv1.Deployment{}},
+				{kind: kindStatefulSet, object: &appsv1.StatefulSet{}},
+			} {
+				name := map[string]string{kindDaemonSet: "agent", kindDeployment: "web", kindStatefulSet: "db"}[w.kind]
+				require.NoError(t, fakeClient.Get(ctx, types.NamespacedName{Name: name, Namespace: "default"}, w.object))
+				if podTemplate(w.object).Annotations[constants.AnnotationConnectCARootID] == "root-2" {
+					restarted = append(restarted, w.String())
+				}
+			}
+			require.Equal(t, c.expRestarted, restarted)
+
+			notInjected := &appsv1.StatefulSet{}
+			require.NoError(t, fakeClient.Get(ctx, types.NamespacedName{Name: "not-injected", Namespace: "default"}, notInjected))
+			require.Empty(t, notInjected.Spec.Template.Annotations)
+
+			// Reconciling again once the workloads were rotated doesn't update the status.
+			if len(c.expRestarted) == 0 {
+				_, err = controller.Reconcile(ctx, ctrl.Request{NamespacedName: key})
+				require.NoError(t, err)
+				refetched := &v1alpha1.ConnectCARotation{}
+				require.NoError(t, fakeClient.Get(ctx, key, refetched))
+				require.Equal(t, fetched.ResourceVersion, refetched.ResourceVersion)
+			}
+		})
+	}
+}
+
+func TestRolledOut(t *testing.T) {
+	t.Parallel()
+	cases := map[string]struct {
+		object client.Object
+		exp    bool
+	}{
+		"deployment rolled out": {
+			object: deployment("web", "", true),
+			exp:    true,
+		},
+		"deployment with old replicas": {
+			object: func() client.Object {
+				d := deployment("web", "", true)
+				d.Status.Replicas = 3
+				return d
+			}(),
+		},
+		"deployment with an unobserved generation": {
+			object: func() client.Object {
+				d := deployment("web", "", true)
+				d.Generation = 3
+				return d
+			}(),
+		},
+		"statefulset not ready": {
+			object: statefulSet("db", "", false),
+		},
+		"daemonset rolled out": {
+			object: daemonSet("agent", "", true),
+			exp:    true,
+		},
+		"daemonset not updated": {
+			object: daemonSet("agent", "", false),
+		},
+	}
+	for name, c := range cases {
+		t.Run(name, func(t *testing.T) {
+			require.Equal(t, c.exp, rolledOut(c.object))
+		})
+	}
+}
+
+func deployment(name, rootID string, rolledOut bool) *appsv1.Deployment {
+	d := &appsv1.Deployment{
+		ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "default", Generation: 2},
+		Spec: appsv1.DeploymentSpec{
+			Replicas: ptr.To(int32(2)),
+			Template: podTemplateSpec(rootID),
+		},
+		Status: appsv1.DeploymentStatus{ObservedGeneration: 2, Replicas: 2, UpdatedReplicas: 1, AvailableReplicas: 2},
+	}
+	if rolledOut {
+		d.Status.UpdatedReplicas = 2
+	}
+	return d
+}
+
+func statefulSet(name, rootID string, rolledOut bool) *appsv1.StatefulSet {
+	s := &appsv1.StatefulSet{
+		ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "default", Generation: 2},
+		Spec: appsv1.StatefulSetSpec{
+			Replicas: ptr.To(int32(2)),
+			Template: podTemplateSpec(rootID),
+		},
+		Status: appsv1.StatefulSetStatus{ObservedGeneration: 2, UpdatedReplicas: 2, ReadyReplicas: 1},
+	}
+	if rolledOut {
+		s.Status.ReadyReplicas = 2
+	}
+	return s
+}
+
+func daemonSet(name, rootID string, rolledOut bool) *appsv1.DaemonSet {
+	d := &appsv1.DaemonSet{
+		ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "default", Generation: 2},
+		Spec:       appsv1.DaemonSetSpec{Template: podTemplateSpec(rootID)},
+		Status:     appsv1.DaemonSetStatus{ObservedGeneration: 2, DesiredNumberScheduled: 3, UpdatedNumberScheduled: 1, NumberAvailable: 3},
+	}
+	if rolledOut {
+		d.Status.UpdatedNumberScheduled = 3
+	}
+	return d
+}
+
+func podTemplateSpec(rootID string) corev1.PodTemplateSpec {
+	template := corev1.PodTemplateSpec{}
+	if rootID != "" {
+		template.Annotations = map[string]string{constants.AnnotationConnectCARootID: rootID}
+	}
+	return template
+}
+
+func replicaSet(name, deploymentName string) *appsv1.ReplicaSet {
+	return &appsv1.ReplicaSet{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:            name,
+			Namespace:       "default",
+			OwnerReferences: []metav1.OwnerReference{controllerRef(kindDeployment, deploymentName)},
+		},
+	}
+}
+
+// pod returns an injected pod controlled by the ownerKind ownerName, or a pod without an owner if
+// ownerKind is empty.
+func pod(name, ownerKind, ownerName, app string, created metav1.Time) *corev1.Pod {
+	p := &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:              name,
+			Namespace:         "default",
+			CreationTimestamp: created,
+			Labels: map[string]string{
+				"app":                     app,
+				constants.KeyInjectStatus: constants.Injected,
+			},
+		},
+	}
+	if ownerKind != "" {
+		p.OwnerReferences = []metav1.OwnerReference{controllerRef(ownerKind, ownerName)}
+	}
+	return p
+}
+
+func withoutInjection(p *corev1.Pod) *corev1.Pod {
+	delete(p.Labels, constants.KeyInjectStatus)
+	return p
+}
+
+func controllerRef(kind, name string) metav1.OwnerReference {
+	return metav1.OwnerReference{
+		APIVersion: "apps/v1",
+		Kind:       kind,
+		Name:       name,
+		UID:        types.UID(name),
+		Controller: ptr.To(true),
+	}
+}
+
+// newFakeCARootsServer returns the config of a Consul HTTP API server whose active Connect CA root has the given ID.
+func newFakeCARootsServer(t *testing.T, activeRootID string) (*consul.Config, consul.ServerConnectionManager) {
+	t.Helper()
+	mux := http.NewServeMux()
+	mux.HandleFunc("/v1/connect/ca/roots", func(w http.ResponseWriter, r *http.Request) {
+		val, err := json.Marshal(capi.CARootList{
+			ActiveRootID: activeRootID,
+			Roots:        []*capi.CARoot{{ID: activeRootID, Active: true}},
+		})
+		require.NoError(t, err)
+		w.Write(val)
+	})
+	server := httptest.NewServer(mux)
+	t.Cleanup(server.Close)
+
+	parsedURL, err := url.Parse(server.URL)
+	require.NoError(t, err)
+	host := strings.Split(parsedURL.Host, ":")[0]
+	port, err := strconv.Atoi(parsedURL.Port())
+	require.NoError(t, err)
+
+	return &consul.Config{APIClientConfig: &capi.Config{Address: host}, HTTPPort: port}, test.MockConnMgrForIPAndPort(t, host, port, false)
+}
@@ -7,6 +7,7 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"strconv"
 	"strings"
 	"time"
 
@@ -17,6 +18,7 @@ import (
 	k8serr "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/record"
 	"k8s.io/client-go/util/workqueue"
 	ctrl "sigs.k8s.io/controller-runtime"
 	"sigs.k8s.io/controller-runtime/pkg/client"
@@ -34,6 +36,12 @@ const (
 	ConsulPatchError             = "ConsulPatchError"
 	ExternallyManagedConfigError = "ExternallyManagedConfigError"
 	MigrationFailedError         = "MigrationFailedError"
+
+	// DryRunCreate, DryRunUpdate and DryRunNone are the operations recorded in
+	// the status of a resource reconciled in dry-run mode.
+	DryRunCreate = "Create"
+	DryRunUpdate = "Update"
+	DryRunNone   = "None"
 )
 
 // Controller is implemented by CRD-specific configentries. It is used by
@@ -94,6 +102,15 @@ type ConfigEntryController struct {
 	// any created Consul namespaces to allow cross namespace service discovery.
 	// Only necessary if ACLs are enabled.
 	CrossNSACLPolicy string
+
+	// EventRecorder, if set, records a Warning Event on the resource every time
+	// it fails to sync with Consul so that the error shows in kubectl describe.
+	EventRecorder record.EventRecorder
+
+	// DryRun, if true, records the config entries that resources would be
+	// written to Consul as in their status instead of writing them. The
+	// consul.hashicorp.com/dry-run annotation overrides it per resource.
+	DryRun bool
 }
 
 // ReconcileEntry reconciles an update to a resource. CRD-specific controller's
@@ -102,7 +119,13 @@ type ConfigEntryController struct {
 // CRD-specific controller should pass themselves in as updater since we
 // need to call back into their own update methods to ensure they update their
 // internal state.
+// Reconciles that fail because the Consul servers are unreachable are retried once
+// requests are sent to the servers again.
 func (r *ConfigEntryController) ReconcileEntry(ctx context.Context, crdCtrl Controller, req ctrl.Request, configEntry common.ConfigEntryResource) (ctrl.Result, error) {
+	return consul.ReconcileBackoff(r.reconcileEntry(ctx, crdCtrl, req, configEntry))
+}
+
+func (r *ConfigEntryController) reconcileEntry(ctx context.Context, crdCtrl Controller, req ctrl.Request, configEntry common.ConfigEntryResource) (ctrl.Result, error) {
 	logger := crdCtrl.Logger(req.NamespacedName)
 	err := crdCtrl.Get(ctx, req.NamespacedName, configEntry)
 	if k8serr.IsNotFound(err) {
@@ -112,6 +135,12 @@ func (r *ConfigEntryController) ReconcileEntry(ctx context.Context, crdCtrl Cont
 		return ctrl.Result{}, err
 	}
 
+	// Back off while the Consul servers are unreachable rather than marking the resource as failing to sync.
+	if retryAfter := r.ConsulClientConfig.RetryAfter(); retryAfter > 0 {
+		logger.V(1).Info("Consul servers are unreachable, retrying later", "retryAfter", retryAfter)
+		return ctrl.Result{RequeueAfter: retryAfter}, nil
+	}
+
 	// Create Consul client for this reconcile.
 	serverState, err := r.ConsulServerConnMgr.State()
 	if err != nil {
@@ -125,6 +154,7 @@ func (r *ConfigEntryController) ReconcileEntry(ctx context.Context, crdCtrl Cont
 	}
 
 	consulEntry := configEntry.ToConsul(r.DatacenterName)
+	dryRun := r.dryRun(configEntry)
 
 	if configEntry.GetDeletionTimestamp().IsZero() {
 		// The object is not being deleted, so if it does not have our finalizer,
@@ -147,9 +177,9 @@ func (r *ConfigEntryController) ReconcileEntry(ctx context.Context, crdCtrl Cont
 		if containsString(configEntry.GetFinalizers(), FinalizerName) {
 			logger.Info("deletion event")
 			// Check to see if consul has config entry with the same name
-			entry, _, err := consulClient.ConfigEntries().Get(configEntry.ConsulKind(), configEntry.ConsulName(), &capi.QueryOptions{
+			entry, _, err := consulClient.ConfigEntries().Get(configEntry.ConsulKind(), configEntry.ConsulName(), (&capi.QueryOptions{
 				Namespace: r.consulNamespace(consulEntry, configEntry.ConsulMirroringNS(), configEntry.ConsulGlobalResource()),
-			})
+			}).WithContext(ctx))
 
 			// Ignore the error where the config entry isn't found in Consul.
 			// It is indicative of desired state.
@@ -157,10 +187,12 @@ func (r *ConfigEntryController) ReconcileEntry(ctx context.Context, crdCtrl Cont
 				return ctrl.Result{}, fmt.Errorf("getting config entry from consul: %w", err)
 			} else if err == nil {
 				// Only delete the resource from Consul if it is owned by our datacenter.
-				if entry.GetMeta()[common.DatacenterKey] == r.DatacenterName {
-					_, err := consulClient.ConfigEntries().Delete(configEntry.ConsulKind(), configEntry.ConsulName(), &capi.WriteOptions{
+				if dryRun {
+					logger.Info("dry run - skipping delete from Consul")
+				} else if entry.GetMeta()[common.DatacenterKey] == r.DatacenterName {
+					_, err := consulClient.ConfigEntries().Delete(configEntry.ConsulKind(), configEntry.ConsulName(), (&capi.WriteOptions{
 						Namespace: r.consulNamespace(consulEntry, configEntry.ConsulMirroringNS(), configEntry.ConsulGlobalResource()),
-					})
+					}).WithContext(ctx))
 					if err != nil {
 						return r.syncFailed(ctx, logger, crdCtrl, configEntry, ConsulAgentError,
 							fmt.Errorf("deleting config entry from consul: %w", err))
@@ -183,13 +215,17 @@ func (r *ConfigEntryController) ReconcileEntry(ctx context.Context, crdCtrl Cont
 	}
 
 	// Check to see if consul has config entry with the same name
-	entryFromConsul, _, err := consulClient.ConfigEntries().Get(configEntry.ConsulKind(), configEntry.ConsulName(), &capi.QueryOptions{
+	entryFromConsul, _, err := consulClient.ConfigEntries().Get(configEntry.ConsulKind(), configEntry.ConsulName(), (&capi.QueryOptions{
 		Namespace: r.consulNamespace(consulEntry, configEntry.ConsulMirroringNS(), configEntry.ConsulGlobalResource()),
-	})
+	}).WithContext(ctx))
 	// If a config entry with this name does not exist
 	if isNotFoundErr(err) {
 		logger.Info("config entry not found in consul")
 
+		if dryRun {
+			return r.syncDryRun(ctx, logger, crdCtrl, configEntry, DryRunCreate, consulEntry)
+		}
+
 		// If Consul namespaces are enabled we may need to create the
 		// destination consul namespace first.
 		if r.EnableConsulNamespaces {
@@ -205,9 +241,9 @@ func (r *ConfigEntryController) ReconcileEntry(ctx context.Context, crdCtrl Cont
 		}
 
 		// Create the config entry
-		_, writeMeta, err := consulClient.ConfigEntries().Set(consulEntry, &capi.WriteOptions{
+		_, writeMeta, err := consulClient.ConfigEntries().Set(consulEntry, (&capi.WriteOptions{
 			Namespace: r.consulNamespace(consulEntry, configEntry.ConsulMirroringNS(), configEntry.ConsulGlobalResource()),
-		})
+		}).WithContext(ctx))
 		if err != nil {
 			return r.syncFailed(ctx, logger, crdCtrl, configEntry, ConsulAgentError,
 				fmt.Errorf("writing config entry to consul: %w", err))
@@ -235,9 +271,12 @@ func (r *ConfigEntryController) ReconcileEntry(ctx context.Context, crdCtrl Cont
 	// chart versions where they had previously created config entries themselves but
 	// now want to manage them through custom resources.
 	hasMigrationKey := configEntry.GetObjectMeta().Annotations[common.MigrateEntryKey] == common.MigrateEntryTrue
+	// The service-defaults the API gateway controller created to hold route rate limits are taken over
+	// as if they were migrated, since no custom resource manages them.
+	createdForRateLimits := entryFromConsul.GetMeta()[common.RouteRateLimitsKey] == common.RouteRateLimitsCreated
 
 	switch {
-	case !matchesConsul && !managedByThisDC && !hasMigrationKey:
+	case !matchesConsul && !managedByThisDC && !hasMigrationKey && !createdForRateLimits:
 		return r.syncFailed(ctx, logger, crdCtrl, configEntry, ExternallyManagedConfigError,
 			sourceDatacenterMismatchErr(sourceDatacenter))
 	case !matchesConsul && hasMigrationKey:
@@ -248,29 +287,37 @@ func (r *ConfigEntryController) ReconcileEntry(ctx context.Context, crdCtrl Cont
 			r.nonMatchingMigrationError(configEntry, entryFromConsul))
 	case !matchesConsul:
 		logger.Info("config entry does not match consul", "modify-index", entryFromConsul.GetModifyIndex())
-		_, writeMeta, err := consulClient.ConfigEntries().Set(consulEntry, &capi.WriteOptions{
+		if dryRun {
+			return r.syncDryRun(ctx, logger, crdCtrl, configEntry, DryRunUpdate, consulEntry)
+		}
+		_, writeMeta, err := consulClient.ConfigEntries().Set(consulEntry, (&capi.WriteOptions{
 			Namespace: r.consulNamespace(consulEntry, configEntry.ConsulMirroringNS(), configEntry.ConsulGlobalResource()),
-		})
+		}).WithContext(ctx))
 		if err != nil {
 			return r.syncUnknownWithError(ctx, logger, crdCtrl, configEntry, ConsulAgentError,
 				fmt.Errorf("updating config entry in consul: %w", err))
 		}
 		logger.Info("config entry updated", "request-time", writeMeta.RequestTime)
 		return r.syncSuccessful(ctx, crdCtrl, configEntry)
-	case hasMigrationKey && !managedByThisDC:
+	case (hasMigrationKey || createdForRateLimits) && !managedByThisDC:
 		// If we get here then we're doing a migration and the entry in Consul
 		// matches the entry in Kubernetes. We just need to update the metadata
 		// of the entry in Consul to say that it's now managed by Kubernetes.
 		logger.Info("migrating config entry to be managed by Kubernetes")
-		_, writeMeta, err := consulClient.ConfigEntries().Set(consulEntry, &capi.WriteOptions{
+		if dryRun {
+			return r.syncDryRun(ctx, logger, crdCtrl, configEntry, DryRunUpdate, consulEntry)
+		}
+		_, writeMeta, err := consulClient.ConfigEntries().Set(consulEntry, (&capi.WriteOptions{
 			Namespace: r.consulNamespace(consulEntry, configEntry.ConsulMirroringNS(), configEntry.ConsulGlobalResource()),
-		})
+		}).WithContext(ctx))
 		if err != nil {
 			return r.syncUnknownWithError(ctx, logger, crdCtrl, configEntry, ConsulAgentError,
 				fmt.Errorf("updating config entry in consul: %w", err))
 		}
 		logger.Info("config entry migrated", "request-time", writeMeta.RequestTime)
 		return r.syncSuccessful(ctx, crdCtrl, configEntry)
+	case dryRun:
+		return r.syncDryRun(ctx, logger, crdCtrl, configEntry, DryRunNone, consulEntry)
 	case configEntry.SyncedConditionStatus() != corev1.ConditionTrue:
 		return r.syncSuccessful(ctx, crdCtrl, configEntry)
 	}
@@ -342,6 +389,7 @@ func (r *ConfigEntryController) consulNamespace(configEntry capi.ConfigEntry, na
 }
 
 func (r *ConfigEntryController) syncFailed(ctx context.Context, logger logr.Logger, updater Controller, configEntry common.ConfigEntryResource, errType string, err error) (ctrl.Result, error) {
+	r.recordSyncError(configEntry, errType, err)
 	configEntry.SetSyncedCondition(corev1.ConditionFalse, errType, err.Error())
 	if updateErr := updater.UpdateStatus(ctx, configEntry); updateErr != nil {
 		// Log the original error here because we are returning the updateErr.
@@ -353,6 +401,7 @@ func (r *ConfigEntryController) syncFailed(ctx context.Context, logger logr.Logg
 }
 
 func (r *ConfigEntryController) syncSuccessful(ctx context.Context, updater Controller, configEntry common.ConfigEntryResource) (ctrl.Result, error) {
+	configEntry.ClearDryRun()
 	configEntry.SetSyncedCondition(corev1.ConditionTrue, "", "")
 	timeNow := metav1.NewTime(time.Now())
 	configEntry.SetLastSyncedTime(&timeNow)
@@ -373,6 +422,7 @@ func (r *ConfigEntryController) syncUnknownWithError(ctx context.Context,
 	errType string,
 	err error) (ctrl.Result, error) {
 
+	r.recordSyncError(configEntry, errType, err)
 	configEntry.SetSyncedCondition(corev1.ConditionUnknown, errType, err.Error())
 	if updateErr := updater.UpdateStatus(ctx, configEntry); updateErr != nil {
 		// Log the original error here because we are returning the updateErr.
@@ -383,6 +433,41 @@ func (r *ConfigEntryController) syncUnknownWithError(ctx context.Context,
 	return ctrl.Result{}, err
 }
 
+// syncDryRun records the write to Consul that the resource would cause, and the config
+// entry it would be written as, in the status of the resource without writing to Consul.
+func (r *ConfigEntryController) syncDryRun(ctx context.Context, logger logr.Logger, updater Controller, configEntry common.ConfigEntryResource, operation string, consulEntry capi.ConfigEntry) (ctrl.Result, error) {
+	entryJSON, err := json.Marshal(consulEntry)
+	if err != nil {
+		return ctrl.Result{}, fmt.Errorf("marshalling config entry: %w", err)
+	}
+	// Only update the status when the result changes, since every status update triggers another
+	// reconcile.
+	if !configEntry.SetDryRun(operation, string(entryJSON)) {
+		return ctrl.Result{}, nil
+	}
+	logger.Info("dry run - skipping write to Consul", "operation", operation)
+	return ctrl.Result{}, updater.UpdateStatus(ctx, configEntry)
+}
+
+// dryRun returns whether the resource is reconciled in dry-run mode. The
+// consul.hashicorp.com/dry-run annotation takes precedence over the controller's setting.
+func (r *ConfigEntryController) dryRun(configEntry common.ConfigEntryResource) bool {
+	if value, ok := configEntry.GetObjectMeta().Annotations[common.DryRunKey]; ok {
+		if dryRun, err := strconv.ParseBool(value); err == nil {
+			return dryRun
+		}
+	}
+	return r.DryRun
+}
+
+// recordSyncError records a Warning Event on the resource if an EventRecorder is configured.
+func (r *ConfigEntryController) recordSyncError(configEntry common.ConfigEntryResource, errType string, err error) {
+	if r.EventRecorder == nil {
+		return
+	}
+	r.EventRecorder.Event(configEntry, corev1.EventTypeWarning, errType, err.Error())
+}
+
 // nonMatchingMigrationError returns an error that indicates the migration failed
 // because the config entries did not match.
 func (r *ConfigEntryController) nonMatchingMigrationError(kubeEntry common.ConfigEntryResource, consulEntry capi.ConfigEntry) error {
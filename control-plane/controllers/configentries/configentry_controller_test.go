@@ -5,6 +5,7 @@ package configentries
 
 import (
 	"context"
+	"encoding/json"
 	"errors"
 	"fmt"
 	"testing"
@@ -22,6 +23,7 @@ import (
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/runtime/schema"
 	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/record"
 	ctrl "sigs.k8s.io/controller-runtime"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/client/fake"
@@ -1591,6 +1593,7 @@ func TestConfigEntryControllers_errorUpdatesSyncStatus(t *testing.T) {
 	// Stop the server before calling reconcile imitating a server that's not running.
 	_ = testClient.TestServer.Stop()
 
+	recorder := record.NewFakeRecorder(1)
 	reconciler := &ServiceDefaultsController{
 		Client: fakeClient,
 		Log:    logrtest.New(t),
@@ -1598,6 +1601,7 @@ func TestConfigEntryControllers_errorUpdatesSyncStatus(t *testing.T) {
 			ConsulClientConfig:  testClient.Cfg,
 			ConsulServerConnMgr: testClient.Watcher,
 			DatacenterName:      datacenterName,
+			EventRecorder:       recorder,
 		},
 	}
 
@@ -1623,6 +1627,15 @@ func TestConfigEntryControllers_errorUpdatesSyncStatus(t *testing.T) {
 	req.Equal(corev1.ConditionFalse, status)
 	req.Equal("ConsulAgentError", reason)
 	req.Contains(errMsg, expErr)
+
+	// Check that the error is kept in the LastSyncError condition and recorded in an Event.
+	lastSyncError := svcDefaults.Status.GetCondition(v1alpha1.ConditionLastSyncError)
+	req.NotNil(lastSyncError)
+	req.Equal(corev1.ConditionTrue, lastSyncError.Status)
+	req.Equal("ConsulAgentError", lastSyncError.Reason)
+	req.Contains(lastSyncError.Message, expErr)
+	req.Len(recorder.Events, 1)
+	req.Contains(<-recorder.Events, "Warning ConsulAgentError "+errMsg)
 }
 
 // Test that if the config entry hasn't changed in Consul but our resource
@@ -1689,6 +1702,220 @@ func TestConfigEntryControllers_setsSyncedToTrue(t *testing.T) {
 	req.Equal(corev1.ConditionTrue, svcDefaults.SyncedConditionStatus())
 }
 
+// Test that in dry-run mode the config entry the resource would be written as
+// is recorded in its status instead of being written to Consul.
+func TestConfigEntryControllers_dryRun(t *testing.T) {
+	t.Parallel()
+	kubeNS := "default"
+
+	cases := map[string]struct {
+		dryRun         bool
+		annotations    map[string]string
+		consulProtocol string
+		expOperation   string
+		expProtocol    string
+	}{
+		"creates": {
+			dryRun:       true,
+			expOperation: DryRunCreate,
+		},
+		"updates": {
+			dryRun:         true,
+			consulProtocol: "tcp",
+			expOperation:   DryRunUpdate,
+			expProtocol:    "tcp",
+		},
+		"matches": {
+			dryRun:         true,
+			consulProtocol: "http",
+			expOperation:   DryRunNone,
+			expProtocol:    "http",
+		},
+		"annotation enables dry run": {
+			annotations:  map[string]string{common.DryRunKey: "true"},
+			expOperation: DryRunCreate,
+		},
+		"annotation disables dry run": {
+			dryRun:      true,
+			annotations: map[string]string{common.DryRunKey: "false"},
+			expProtocol: "http",
+		},
+	}
+	for name, c := range cases {
+		t.Run(name, func(t *testing.T) {
+			req := require.New(t)
+			ctx := context.Background()
+
+			svcDefaults := &v1alpha1.ServiceDefaults{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:        "foo",
+					Namespace:   kubeNS,
+					Annotations: c.annotations,
+					Finalizers:  []string{FinalizerName},
+				},
+				Spec: v1alpha1.ServiceDefaultsSpec{
+					Protocol: "http",
+				},
+			}
+			s := runtime.NewScheme()
+			s.AddKnownTypes(v1alpha1.GroupVersion, svcDefaults)
+			fakeClient := fake.NewClientBuilder().WithScheme(s).WithObjects(svcDefaults).WithStatusSubresource(svcDefaults).Build()
+
+			testClient := test.TestServerWithMockConnMgrWatcher(t, nil)
+			testClient.TestServer.WaitForServiceIntentions(t)
+			consulClient := testClient.APIClient
+
+			if c.consulProtocol != "" {
+				_, _, err := consulClient.ConfigEntries().Set(&capi.ServiceConfigEntry{
+					Kind:     capi.ServiceDefaults,
+					Name:     svcDefaults.ConsulName(),
+					Protocol: c.consulProtocol,
+					Meta:     map[string]string{common.SourceKey: common.SourceValue, common.DatacenterKey: datacenterName},
+				}, nil)
+				req.NoError(err)
+			}
+
+			reconciler := &ServiceDefaultsController{
+				Client: fakeClient,
+				Log:    logrtest.New(t),
+				ConfigEntryController: &ConfigEntryController{
+					ConsulClientConfig:  testClient.Cfg,
+					ConsulServerConnMgr: testClient.Watcher,
+					DatacenterName:      datacenterName,
+					DryRun:              c.dryRun,
+				},
+			}
+
+			namespacedName := types.NamespacedName{
+				Namespace: kubeNS,
+				Name:      svcDefaults.KubernetesName(),
+			}
+			resp, err := reconciler.Reconcile(ctx, ctrl.Request{
+				NamespacedName: namespacedName,
+			})
+			req.NoError(err)
+			req.False(resp.Requeue)
+
+			// Check that Consul was only written to outside of dry-run mode.
+			entry, _, err := consulClient.ConfigEntries().Get(capi.ServiceDefaults, svcDefaults.ConsulName(), nil)
+			if c.expProtocol == "" {
+				req.True(isNotFoundErr(err))
+			} else {
+				req.NoError(err)
+				req.Equal(c.expProtocol, entry.(*capi.ServiceConfigEntry).Protocol)
+			}
+
+			err = fakeClient.Get(ctx, namespacedName, svcDefaults)
+			req.NoError(err)
+			if c.expOperation == "" {
+				req.Nil(svcDefaults.Status.DryRun)
+				req.Equal(corev1.ConditionTrue, svcDefaults.SyncedConditionStatus())
+				return
+			}
+			req.NotNil(svcDefaults.Status.DryRun)
+			req.Equal(c.expOperation, svcDefaults.Status.DryRun.Operation)
+			req.NotNil(svcDefaults.Status.DryRun.LastComputedTime)
+			req.NotEqual(corev1.ConditionTrue, svcDefaults.SyncedConditionStatus())
+
+			var dryRunEntry capi.ServiceConfigEntry
+			req.NoError(json.Unmarshal([]byte(svcDefaults.Status.DryRun.ConfigEntry), &dryRunEntry))
+			req.Equal(capi.ServiceDefaults, dryRunEntry.Kind)
+			req.Equal("http", dryRunEntry.Protocol)
+
+			// Reconciling again doesn't update the status when the result is the same.
+			resourceVersion := svcDefaults.ResourceVersion
+			_, err = reconciler.Reconcile(ctx, ctrl.Request{
+				NamespacedName: namespacedName,
+			})
+			req.NoError(err)
+			err = fakeClient.Get(ctx, namespacedName, svcDefaults)
+			req.NoError(err)
+			req.Equal(resourceVersion, svcDefaults.ResourceVersion)
+		})
+	}
+}
+
+// Test that in dry-run mode a deleted resource's finalizer is removed without
+// deleting the config entry from Consul.
+func TestConfigEntryControllers_dryRunDoesNotDelete(t *testing.T) {
+	t.Parallel()
+	kubeNS := "default"
+	req := require.New(t)
+	ctx := context.Background()
+
+	svcDefaults := &v1alpha1.ServiceDefaults{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:              "foo",
+			Namespace:         kubeNS,
+			DeletionTimestamp: &metav1.Time{Time: time.Now()},
+			Finalizers:        []string{FinalizerName},
+		},
+		Spec: v1alpha1.ServiceDefaultsSpec{
+			Protocol: "http",
+		},
+	}
+	s := runtime.NewScheme()
+	s.AddKnownTypes(v1alpha1.GroupVersion, svcDefaults)
+	fakeClient := fake.NewClientBuilder().WithScheme(s).WithObjects(svcDefaults).WithStatusSubresource(svcDefaults).Build()
+
+	testClient := test.TestServerWithMockConnMgrWatcher(t, nil)
+	testClient.TestServer.WaitForServiceIntentions(t)
+	consulClient := testClient.APIClient
+	_, _, err := consulClient.ConfigEntries().Set(svcDefaults.ToConsul(datacenterName), nil)
+	req.NoError(err)
+
+	reconciler := &ServiceDefaultsController{
+		Client: fakeClient,
+		Log:    logrtest.New(t),
+		ConfigEntryController: &ConfigEntryController{
+			ConsulClientConfig:  testClient.Cfg,
+			ConsulServerConnMgr: testClient.Watcher,
+			DatacenterName:      datacenterName,
+			DryRun:              true,
+		},
+	}
+
+	namespacedName := types.NamespacedName{
+		Namespace: kubeNS,
+		Name:      svcDefaults.KubernetesName(),
+	}
+	resp, err := reconciler.Reconcile(ctx, ctrl.Request{
+		NamespacedName: namespacedName,
+	})
+	req.NoError(err)
+	req.False(resp.Requeue)
+
+	_, _, err = consulClient.ConfigEntries().Get(capi.ServiceDefaults, svcDefaults.ConsulName(), nil)
+	req.NoError(err)
+
+	svcDefault := &v1alpha1.ServiceDefaults{}
+	_ = fakeClient.Get(ctx, namespacedName, svcDefault)
+	req.Empty(svcDefault.Finalizers())
+}
+
+func TestConfigEntryController_dryRunAnnotation(t *testing.T) {
+	cases := map[string]struct {
+		dryRun      bool
+		annotations map[string]string
+		exp         bool
+	}{
+		"flag not set":                 {exp: false},
+		"flag set":                     {dryRun: true, exp: true},
+		"annotation true":              {annotations: map[string]string{common.DryRunKey: "true"}, exp: true},
+		"annotation false":             {dryRun: true, annotations: map[string]string{common.DryRunKey: "false"}, exp: false},
+		"invalid annotation uses flag": {dryRun: true, annotations: map[string]string{common.DryRunKey: "yes"}, exp: true},
+	}
+	for name, c := range cases {
+		t.Run(name, func(t *testing.T) {
+			r := &ConfigEntryController{DryRun: c.dryRun}
+			svcDefaults := &v1alpha1.ServiceDefaults{
+				ObjectMeta: metav1.ObjectMeta{Name: "foo", Annotations: c.annotations},
+			}
+			require.Equal(t, c.exp, r.dryRun(svcDefaults))
+		})
+	}
+}
+
 // Test that if the config entry exists in Consul but is not managed by the
 // controller, creating/updating the resource fails.
 func TestConfigEntryControllers_doesNotCreateUnownedConfigEntry(t *testing.T) {
@@ -2047,6 +2274,25 @@ func TestConfigEntryController_Migration(t *testing.T) {
 			},
 			ExpErr: "migration failed: Kubernetes resource does not match existing Consul config entry",
 		},
+		"resources created for route rate limits should be taken over": {
+			KubeResource: v1alpha1.ServiceDefaults{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      cfgEntryName,
+					Namespace: kubeNS,
+				},
+				Spec: v1alpha1.ServiceDefaultsSpec{
+					Protocol: protocol,
+				},
+			},
+			ConsulResource: capi.ServiceConfigEntry{
+				Kind: capi.ServiceDefaults,
+				Name: cfgEntryName,
+				Meta: map[string]string{
+					common.SourceKey:          common.SourceValue,
+					common.RouteRateLimitsKey: common.RouteRateLimitsCreated,
+				},
+			},
+		},
 	}
 
 	for name, c := range cases {
@@ -2122,6 +2368,7 @@ func TestConfigEntryController_Migration(t *testing.T) {
 				require.NoError(t, err)
 				require.Contains(t, entry.GetMeta(), common.DatacenterKey)
 				require.Equal(t, "datacenter", entry.GetMeta()[common.DatacenterKey])
+				require.NotContains(t, entry.GetMeta(), common.RouteRateLimitsKey)
 			}
 		})
 	}
@@ -0,0 +1,338 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package snapshotschedule
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/go-logr/logr"
+	capi "github.com/hashicorp/consul/api"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/utils/ptr"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/builder"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+	"sigs.k8s.io/controller-runtime/pkg/predicate"
+
+	"github.com/hashicorp/consul-k8s/control-plane/api/v1alpha1"
+	"github.com/hashicorp/consul-k8s/control-plane/consul"
+)
+
+const (
+	containerName = "consul-snapshot-agent"
+
+	// labelSnapshotSchedule is the label on snapshot agent pods with the name of their ConsulSnapshotSchedule.
+	labelSnapshotSchedule = "consul.hashicorp.com/snapshot-schedule"
+
+	caCertFile          = "/tmp/consul-ca.pem"
+	gcsCredentialsDir   = "/consul/gcs"
+	gcsCredentialsFile  = "credentials.json"
+	gcsCredentialsMount = "gcs-credentials"
+
+	// statusRequeueInterval is how often the health of the snapshot agent is checked in Consul.
+	statusRequeueInterval = time.Minute
+
+	syncedReasonSyncFailed = "DeploymentSyncFailed"
+
+	healthyReasonSnapshotsPassing  = "SnapshotsPassing"
+	healthyReasonSnapshotsFailing  = "SnapshotsFailing"
+	healthyReasonAgentNotFound     = "AgentNotRegistered"
+	healthyReasonConsulUnavailable = "ConsulUnavailable"
+)
+
+// Controller deploys and configures a Consul snapshot agent for each ConsulSnapshotSchedule
+// resource and reports whether the agent is taking snapshots successfully.
+type Controller struct {
+	client.Client
+	ConsulClientConfig  *consul.Config
+	ConsulServerConnMgr consul.ServerConnectionManager
+
+	// ConsulImage is the Consul Enterprise image that runs the snapshot agent.
+	ConsulImage string
+	// ImagePullPolicy is the pull policy of the snapshot agent container.
+	ImagePullPolicy string
+	// ConsulHTTPAddr is the address snapshot agents use to reach the Consul servers,
+	// e.g. "https://consul-server.consul.svc:8501".
+	ConsulHTTPAddr string
+	// ConsulTLSServerName is the server name used to verify the certificate of the Consul servers.
+	ConsulTLSServerName string
+	// ConsulCACert is the PEM-encoded CA certificate of the Consul servers.
+	// If it is empty, snapshot agents don't verify the Consul servers' certificates with a custom CA.
+	ConsulCACert string
+
+	Scheme *runtime.Scheme
+	Log    logr.Logger
+}
+
+// +kubebuilder:rbac:groups=consul.hashicorp.com,resources=consulsnapshotschedules,verbs=get;list;watch;create;update;patch;delete
+// +kubebuilder:rbac:groups=consul.hashicorp.com,resources=consulsnapshotschedules/status,verbs=get;update;patch
+
+func (r *Controller) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
+	log := r.Log.WithValues("consulsnapshotschedule", req.NamespacedName)
+
+	schedule := &v1alpha1.ConsulSnapshotSchedule{}
+	if err := r.Client.Get(ctx, req.NamespacedName, schedule); err != nil {
+		if !k8serrors.IsNotFound(err) {
+			log.Error(err, "unable to get snapshot schedule")
+		}
+		// The snapshot agent deployment is garbage collected through its owner reference.
+		return ctrl.Result{}, client.IgnoreNotFound(err)
+	}
+	if !schedule.ObjectMeta.DeletionTimestamp.IsZero() {
+		return ctrl.Result{}, nil
+	}
+
+	if err := schedule.Validate(); err != nil {
+		log.Error(err, "invalid snapshot schedule")
+		return ctrl.Result{}, r.updateStatus(ctx, schedule, v1alpha1.SyncedCondition(v1alpha1.SyncedReasonInvalidSpec, err), nil)
+	}
+
+	if err := r.upsertDeployment(ctx, schedule); err != nil {
+		log.Error(err, "failed to sync snapshot agent deployment")
+		if statusErr := r.updateStatus(ctx, schedule, v1alpha1.SyncedCondition(syncedReasonSyncFailed, err), nil); statusErr != nil {
+			log.Error(statusErr, "failed to update snapshot schedule status")
+		}
+		return ctrl.Result{}, err
+	}
+
+	healthy := r.snapshotHealthCondition(schedule)
+	if err := r.updateStatus(ctx, schedule, v1alpha1.SyncedCondition("", nil), &healthy); err != nil {
+		log.Error(err, "failed to update snapshot schedule status")
+		return ctrl.Result{}, err
+	}
+
+	// The snapshot agent reports its health to Consul rather than to Kubernetes, so
+	// poll Consul to keep the status up to date.
+	return ctrl.Result{RequeueAfter: statusRequeueInterval}, nil
+}
+
+// upsertDeployment creates or updates the Deployment that runs the snapshot agent for schedule.
+func (r *Controller) upsertDeployment(ctx context.Context, schedule *v1alpha1.ConsulSnapshotSchedule) error {
+	deployment := &appsv1.Deployment{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      schedule.DeploymentName(),
+			Namespace: schedule.Namespace,
+		},
+	}
+	_, err := controllerutil.CreateOrUpdate(ctx, r.Client, deployment, func() error {
+		labels := map[string]string{
+			"app":                 "consul",
+			"component":           "snapshot-agent",
+			labelSnapshotSchedule: schedule.Name,
+		}
+		deployment.Labels = labels
+		deployment.Spec.Replicas = ptr.To(int32(1))
+		deployment.Spec.Selector = &metav1.LabelSelector{MatchLabels: labels}
+		deployment.Spec.Template = corev1.PodTemplateSpec{
+			ObjectMeta: metav1.ObjectMeta{
+				Labels: labels,
+				Annotations: map[string]string{
+					"consul.hashicorp.com/connect-inject": "false",
+					"consul.hashicorp.com/mesh-inject":    "false",
+				},
+			},
+			Spec: r.podSpec(schedule),
+		}
+		return controllerutil.SetControllerReference(schedule, deployment, r.Scheme)
+	})
+	return err
+}
+
+// podSpec returns the spec of the snapshot agent pods for schedule.
+func (r *Controller) podSpec(schedule *v1alpha1.ConsulSnapshotSchedule) corev1.PodSpec {
+	spec := schedule.Spec
+	env := []corev1.EnvVar{
+		{Name: "CONSUL_HTTP_ADDR", Value: r.ConsulHTTPAddr},
+	}
+	if r.ConsulTLSServerName != "" {
+		env = append(env, corev1.EnvVar{Name: "CONSUL_TLS_SERVER_NAME", Value: r.ConsulTLSServerName})
+	}
+	if r.ConsulCACert != "" {
+		env = append(env,
+			corev1.EnvVar{Name: "CONSUL_CACERT_PEM", Value: r.ConsulCACert},
+			corev1.EnvVar{Name: "CONSUL_CACERT", Value: caCertFile},
+		)
+	}
+	if spec.Token != nil {
+		env = append(env, secretEnvVar("CONSUL_HTTP_TOKEN", spec.Token))
+	}
+
+	args := []string{
+		"-interval=" + spec.Interval,
+		"-service=" + schedule.ServiceName(),
+		"-lock-key=" + schedule.LockKey(),
+	}
+	if spec.Retain != nil {
+		args = append(args, fmt.Sprintf("-retain=%d", *spec.Retain))
+	}
+	if spec.Stale {
+		args = append(args, "-stale")
+	}
+
+	var volumes []corev1.Volume
+	var volumeMounts []corev1.VolumeMount
+	switch storage := spec.Storage; {
+	case storage.S3 != nil:
+		s3 := storage.S3
+		args = append(args, "-aws-s3-region="+s3.Region, "-aws-s3-bucket="+s3.Bucket)
+		if s3.KeyPrefix != "" {
+			args = append(args, "-aws-s3-key-prefix="+s3.KeyPrefix)
+		}
+		if s3.Endpoint != "" {
+			args = append(args, "-aws-s3-endpoint="+s3.Endpoint)
+		}
+		if s3.ServerSideEncryption {
+			args = append(args, "-aws-s3-server-side-encryption")
+		}
+		// The AWS SDK reads the credentials from the environment.
+		if s3.AccessKeyID != nil && s3.SecretAccessKey != nil {
+			env = append(env,
+				secretEnvVar("AWS_ACCESS_KEY_ID", s3.AccessKeyID),
+				secretEnvVar("AWS_SECRET_ACCESS_KEY", s3.SecretAccessKey),
+			)
+		}
+	case storage.GCS != nil:
+		gcs := storage.GCS
+		args = append(args, "-gcs-bucket="+gcs.Bucket)
+		if gcs.Credentials != nil {
+			volumes = append(volumes, corev1.Volume{
+				Name: gcsCredentialsMount,
+				VolumeSource: corev1.VolumeSource{
+					Secret: &corev1.SecretVolumeSource{
+						SecretName: gcs.Credentials.Name,
+						Items:      []corev1.KeyToPath{{Key: gcs.Credentials.Key, Path: gcsCredentialsFile}},
+					},
+				},
+			})
+			volumeMounts = append(volumeMounts, corev1.VolumeMount{Name: gcsCredentialsMount, MountPath: gcsCredentialsDir, ReadOnly: true})
+			env = append(env, corev1.EnvVar{Name: "GOOGLE_APPLICATION_CREDENTIALS", Value: gcsCredentialsDir + "/" + gcsCredentialsFile})
+		}
+	case storage.AzureBlob != nil:
+		azure := storage.AzureBlob
+		env = append(env, secretEnvVar("AZURE_BLOB_ACCOUNT_KEY", &azure.AccountKey))
+		args = append(args,
+			"-azure-blob-account-name="+azure.AccountName,
+			"-azure-blob-account-key=$(AZURE_BLOB_ACCOUNT_KEY)",
+			"-azure-blob-container-name="+azure.ContainerName,
+		)
+		if azure.Environment != "" {
+			args = append(args, "-azure-blob-environment="+azure.Environment)
+		}
+	}
+
+	return corev1.PodSpec{
+		Volumes: volumes,
+		Containers: []corev1.Container{
+			{
+				Name:            containerName,
+				Image:           r.ConsulImage,
+				ImagePullPolicy: corev1.PullPolicy(r.ImagePullPolicy),
+				Env:             env,
+				// The arguments are passed to the script as "$@" so that they don't need to be shell-escaped.
+				Command:      []string{"/bin/sh", "-ec", r.startScript(), containerName},
+				Args:         args,
+				Resources:    spec.Resources,
+				VolumeMounts: volumeMounts,
+			},
+		},
+	}
+}
+
+// startScript returns the script that starts the snapshot agent with the arguments of the container.
+func (r *Controller) startScript() string {
+	var script strings.Builder
+	if r.ConsulCACert != "" {
+		fmt.Fprintf(&script, "echo \"${CONSUL_CACERT_PEM}\" > %s\n", caCertFile)
+	}
+	script.WriteString(`exec /bin/consul snapshot agent "$@"`)
+	return script.String()
+}
+
+func secretEnvVar(name string, selector *corev1.SecretKeySelector) corev1.EnvVar {
+	return corev1.EnvVar{
+		Name:      name,
+		ValueFrom: &corev1.EnvVarSource{SecretKeyRef: selector},
+	}
+}
+
+// snapshotHealthCondition returns the SnapshotHealthy condition of schedule based on the health checks of
+// its snapshot agent in Consul. The leader snapshot agent registers a check that fails when snapshots fail.
+func (r *Controller) snapshotHealthCondition(schedule *v1alpha1.ConsulSnapshotSchedule) v1alpha1.Condition {
+	condition := v1alpha1.Condition{
+		Type:               v1alpha1.ConditionSnapshotHealthy,
+		LastTransitionTime: metav1.Now(),
+	}
+
+	consulClient, err := consul.NewClientFromConnMgr(r.ConsulClientConfig, r.ConsulServerConnMgr)
+	if err != nil {
+		condition.Status, condition.Reason, condition.Message = corev1.ConditionUnknown, healthyReasonConsulUnavailable, err.Error()
+		return condition
+	}
+	checks, _, err := consulClient.Health().Checks(schedule.ServiceName(), nil)
+	if err != nil {
+		condition.Status, condition.Reason, condition.Message = corev1.ConditionUnknown, healthyReasonConsulUnavailable, err.Error()
+		return condition
+	}
+	return healthCondition(condition, checks)
+}
+
+// healthCondition sets the status of condition from the health checks of a snapshot agent service.
+func healthCondition(condition v1alpha1.Condition, checks capi.HealthChecks) v1alpha1.Condition {
+	if len(checks) == 0 {
+		condition.Status, condition.Reason = corev1.ConditionFalse, healthyReasonAgentNotFound
+		condition.Message = "the snapshot agent has not registered with Consul"
+		return condition
+	}
+	for _, check := range checks {
+		if check.Status != capi.HealthPassing {
+			condition.Status, condition.Reason = corev1.ConditionFalse, healthyReasonSnapshotsFailing
+			condition.Message = fmt.Sprintf("check %q is %s: %s", check.Name, check.Status, check.Output)
+			return condition
+		}
+	}
+	condition.Status, condition.Reason = corev1.ConditionTrue, healthyReasonSnapshotsPassing
+	return condition
+}
+
+// updateStatus sets the conditions of schedule and writes its status if they changed.
+// LastSyncedTime and LastSuccessfulSnapshotTime are updated when the status is written
+// while the deployment is synced and snapshots are healthy.
+func (r *Controller) updateStatus(ctx context.Context, schedule *v1alpha1.ConsulSnapshotSchedule, synced v1alpha1.Condition, healthy *v1alpha1.Condition) error {
+	previous := schedule.Status.Conditions
+	schedule.Status.Conditions = v1alpha1.Conditions{synced}
+	if healthy != nil {
+		schedule.Status.Conditions = append(schedule.Status.Conditions, *healthy)
+	}
+	schedule.Status.Conditions.KeepTransitionTimes(previous)
+	if equality.Semantic.DeepEqual(previous, schedule.Status.Conditions) {
+		return nil
+	}
+
+	now := metav1.Now()
+	if synced.IsTrue() {
+		schedule.Status.LastSyncedTime = &now
+	}
+	if healthy.IsTrue() {
+		schedule.Status.LastSuccessfulSnapshotTime = &now
+	}
+	return r.Status().Update(ctx, schedule)
+}
+
+func (r *Controller) SetupWithManager(mgr ctrl.Manager) error {
+	return ctrl.NewControllerManagedBy(mgr).
+		// Updates of the status don't need to be reconciled, and the health of the snapshot
+		// agent is polled with RequeueAfter.
+		For(&v1alpha1.ConsulSnapshotSchedule{}, builder.WithPredicates(predicate.GenerationChangedPredicate{})).
+		Owns(&appsv1.Deployment{}).
+		Complete(r)
+}
@@ -0,0 +1,297 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package snapshotschedule
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strconv"
+	"strings"
+	"testing"
+
+	logrtest "github.com/go-logr/logr/testr"
+	capi "github.com/hashicorp/consul/api"
+	"github.com/stretchr/testify/require"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	"k8s.io/utils/ptr"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	"github.com/hashicorp/consul-k8s/control-plane/api/v1alpha1"
+	"github.com/hashicorp/consul-k8s/control-plane/consul"
+	"github.com/hashicorp/consul-k8s/control-plane/helper/test"
+)
+
+func TestReconcile(t *testing.T) {
+	t.Parallel()
+	cases := map[string]struct {
+		spec          v1alpha1.ConsulSnapshotScheduleSpec
+		caCert        string
+		checks        capi.HealthChecks
+		expArgs       []string
+		expEnv        []corev1.EnvVar
+		expVolumes    []corev1.Volume
+		expScript     string
+		expSynced     corev1.ConditionStatus
+		expHealthy    corev1.ConditionStatus
+		expLastBackup bool
+	}{
+		"s3 with static credentials and a healthy agent": {
+			spec: v1alpha1.ConsulSnapshotScheduleSpec{
+				Interval: "1h",
+				Retain:   ptr.To(10),
+				Token:    &corev1.SecretKeySelector{LocalObjectReference: corev1.LocalObjectReference{Name: "token"}, Key: "token"},
+				Storage: v1alpha1.SnapshotStorage{
+					S3: &v1alpha1.S3SnapshotStorage{
+						Region:               "us-east-1",
+						Bucket:               "backups",
+						KeyPrefix:            "prod",
+						ServerSideEncryption: true,
+						AccessKeyID:          &corev1.SecretKeySelector{LocalObjectReference: corev1.LocalObjectReference{Name: "aws"}, Key: "id"},
+						SecretAccessKey:      &corev1.SecretKeySelector{LocalObjectReference: corev1.LocalObjectReference{Name: "aws"}, Key: "secret"},
+					},
+				},
+			},
+			checks: capi.HealthChecks{
+				{Name: "Consul Snapshot Agent Alive", Status: capi.HealthPassing},
+				{Name: "Consul Snapshot Agent Saving Snapshots", Status: capi.HealthPassing},
+			},
+			expArgs: []string{
+				"-interval=1h",
+				"-service=consul-snapshot-default-backup",
+				"-lock-key=consul-snapshot/default/backup/lock",
+				"-retain=10",
+				"-aws-s3-region=us-east-1",
+				"-aws-s3-bucket=backups",
+				"-aws-s3-key-prefix=prod",
+				"-aws-s3-server-side-encryption",
+			},
+			expEnv: []corev1.EnvVar{
+				{Name: "CONSUL_HTTP_ADDR", Value: "https://consul-server.consul.svc:8501"},
+				secretEnvVar("CONSUL_HTTP_TOKEN", &corev1.SecretKeySelector{LocalObjectReference: corev1.LocalObjectReference{Name: "token"}, Key: "token"}),
+				secretEnvVar("AWS_ACCESS_KEY_ID", &corev1.SecretKeySelector{LocalObjectReference: corev1.LocalObjectReference{Name: "aws"}, Key: "id"}),
+				secretEnvVar("AWS_SECRET_ACCESS_KEY", &corev1.SecretKeySelector{LocalObjectReference: corev1.LocalObjectReference{Name: "aws"}, Key: "secret"}),
+			},
+			expScript:     `exec /bin/consul snapshot agent "$@"`,
+			expSynced:     corev1.ConditionTrue,
+			expHealthy:    corev1.ConditionTrue,
+			expLastBackup: true,
+		},
+		"gcs with a CA certificate and a failing agent": {
+			spec: v1alpha1.ConsulSnapshotScheduleSpec{
+				Interval: "30m",
+				Stale:    true,
+				Storage: v1alpha1.SnapshotStorage{
+					GCS: &v1alpha1.GCSSnapshotStorage{
+						Bucket:      "backups",
+						Credentials: &corev1.SecretKeySelector{LocalObjectReference: corev1.LocalObjectReference{Name: "gcs"}, Key: "key.json"},
+					},
+				},
+			},
+			caCert: "-----BEGIN CERTIFICATE-----",
+			checks: capi.HealthChecks{
+				{Name: "Consul Snapshot Agent Alive", Status: capi.HealthPassing},
+				{Name: "Consul Snapshot Agent Saving Snapshots", Status: capi.HealthCritical, Output: "access denied"},
+			},
+			expArgs: []string{
+				"-interval=30m",
+				"-service=consul-snapshot-default-backup",
+				"-lock-key=consul-snapshot/default/backup/lock",
+				"-stale",
+				"-gcs-bucket=backups",
+			},
+			expEnv: []corev1.EnvVar{
+				{Name: "CONSUL_HTTP_ADDR", Value: "https://consul-server.consul.svc:8501"},
+				{Name: "CONSUL_CACERT_PEM", Value: "-----BEGIN CERTIFICATE-----"},
+				{Name: "CONSUL_CACERT", Value: "/tmp/consul-ca.pem"},
+				{Name: "GOOGLE_APPLICATION_CREDENTIALS", Value: "/consul/gcs/credentials.json"},
+			},
+			expVolumes: []corev1.Volume{
+				{
+					Name: "gcs-credentials",
+					VolumeSource: corev1.VolumeSource{
+						Secret: &corev1.SecretVolumeSource{
+							SecretName: "gcs",
+							Items:      []corev1.KeyToPath{{Key: "key.json", Path: "credentials.json"}},
+						},
+					},
+				},
+			},
+			expScript:  "echo \"${CONSUL_CACERT_PEM}\" > /tmp/consul-ca.pem\nexec /bin/consul snapshot agent \"$@\"",
+			expSynced:  corev1.ConditionTrue,
+			expHealthy: corev1.ConditionFalse,
+		},
+		"azure blob with an agent that has not registered": {
+			spec: v1alpha1.ConsulSnapshotScheduleSpec{
+				Interval: "1h",
+				Storage: v1alpha1.SnapshotStorage{
+					AzureBlob: &v1alpha1.AzureBlobSnapshotStorage{
+						AccountName:   "account",
+						AccountKey:    corev1.SecretKeySelector{LocalObjectReference: corev1.LocalObjectReference{Name: "azure"}, Key: "key"},
+						ContainerName: "backups",
+					},
+				},
+			},
+			expArgs: []string{
+				"-interval=1h",
+				"-service=consul-snapshot-default-backup",
+				"-lock-key=consul-snapshot/default/backup/lock",
+				"-azure-blob-account-name=account",
+				"-azure-blob-account-key=$(AZURE_BLOB_ACCOUNT_KEY)",
+				"-azure-blob-container-name=backups",
+			},
+			expEnv: []corev1.EnvVar{
+				{Name: "CONSUL_HTTP_ADDR", Value: "https://consul-server.consul.svc:8501"},
+				secretEnvVar("AZURE_BLOB_ACCOUNT_KEY", &corev1.SecretKeySelector{LocalObjectReference: corev1.LocalObjectReference{Name: "azure"}, Key: "key"}),
+			},
+			expScript:  `exec /bin/consul snapshot agent "$@"`,
+			expSynced:  corev1.ConditionTrue,
+			expHealthy: corev1.ConditionFalse,
+		},
+		"invalid spec": {
+			spec: v1alpha1.ConsulSnapshotScheduleSpec{
+				Interval: "1h",
+			},
+			expSynced: corev1.ConditionFalse,
+		},
+	}
+
+	for name, c := range cases {
+		c := c
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+			ctx := context.Background()
+			schedule := &v1alpha1.ConsulSnapshotSchedule{
+				ObjectMeta: metav1.ObjectMeta{Name: "backup", Namespace: "default"},
+				Spec:       c.spec,
+			}
+
+			s := runtime.NewScheme()
+			require.NoError(t, clientgoscheme.AddToScheme(s))
+			s.AddKnownTypes(v1alpha1.GroupVersion, &v1alpha1.ConsulSnapshotSchedule{}, &v1alpha1.ConsulSnapshotScheduleList{})
+			fakeClient := fake.NewClientBuilder().
+				WithScheme(s).
+				WithRuntimeObjects(schedule).
+				WithStatusSubresource(&v1alpha1.ConsulSnapshotSchedule{}).
+				Build()
+
+			consulCfg, watcher := newFakeHealthServer(t, schedule.ServiceName(), c.checks)
+			controller := &Controller{
+				Client:              fakeClient,
+				ConsulClientConfig:  consulCfg,
+				ConsulServerConnMgr: watcher,
+				ConsulImage:         "hashicorp/consul-enterprise:latest",
+				ConsulHTTPAddr:      "https://consul-server.consul.svc:8501",
+				ConsulCACert:        c.caCert,
+				Scheme:              s,
+				Log:                 logrtest.New(t),
+			}
+
+			key := types.NamespacedName{Name: schedule.Name, Namespace: schedule.Namespace}
+			_, err := controller.Reconcile(ctx, ctrl.Request{NamespacedName: key})
+			require.NoError(t, err)
+
+			fetched := &v1alpha1.ConsulSnapshotSchedule{}
+			require.NoError(t, fakeClient.Get(ctx, key, fetched))
+			require.Equal(t, v1alpha1.ConditionSynced, fetched.Status.Conditions[0].Type)
+			require.Equal(t, c.expSynced, fetched.Status.Conditions[0].Status)
+
+			deployment := &appsv1.Deployment{}
+			err = fakeClient.Get(ctx, types.NamespacedName{Name: "backup-snapshot-agent", Namespace: "default"}, deployment)
+			if c.expSynced == corev1.ConditionFalse {
+				require.True(t, err != nil, "deployment should not be created for an invalid spec")
+				require.Len(t, fetched.Status.Conditions, 1)
+				return
+			}
+			require.NoError(t, err)
+
+			require.Len(t, fetched.Status.Conditions, 2)
+			require.Equal(t, v1alpha1.ConditionSnapshotHealthy, fetched.Status.Conditions[1].Type)
+			require.Equal(t, c.expHealthy, fetched.Status.Conditions[1].Status)
+			require.Equal(t, c.expLastBackup, fetched.Status.LastSuccessfulSnapshotTime != nil)
+
+			require.Len(t, deployment.OwnerReferences, 1)
+			require.Equal(t, "backup", deployment.OwnerReferences[0].Name)
+			podSpec := deployment.Spec.Template.Spec
+			require.Equal(t, c.expVolumes, podSpec.Volumes)
+			require.Len(t, podSpec.Containers, 1)
+			container := podSpec.Containers[0]
+			require.Equal(t, "hashicorp/consul-enterprise:latest", container.Image)
+			require.Equal(t, []string{"/bin/sh", "-ec", c.expScript, containerName}, container.Command)
+			require.Equal(t, c.expArgs, container.Args)
+			require.Equal(t, c.expEnv, container.Env)
+
+			// Reconciling again without changes doesn't update the status.
+			_, err = controller.Reconcile(ctx, ctrl.Request{NamespacedName: key})
+			require.NoError(t, err)
+			refetched := &v1alpha1.ConsulSnapshotSchedule{}
+			require.NoError(t, fakeClient.Get(ctx, key, refetched))
+			require.Equal(t, fetched.ResourceVersion, refetched.ResourceVersion)
+		})
+	}
+}
+
+func TestHealthCondition(t *testing.T) {
+	t.Parallel()
+	cases := map[string]struct {
+		checks    capi.HealthChecks
+		expStatus corev1.ConditionStatus
+		expReason string
+	}{
+		"no checks": {
+			expStatus: corev1.ConditionFalse,
+			expReason: healthyReasonAgentNotFound,
+		},
+		"all checks passing": {
+			checks:    capi.HealthChecks{{Status: capi.HealthPassing}, {Status: capi.HealthPassing}},
+			expStatus: corev1.ConditionTrue,
+			expReason: healthyReasonSnapshotsPassing,
+		},
+		"a check is critical": {
+			checks:    capi.HealthChecks{{Status: capi.HealthPassing}, {Status: capi.HealthCritical}},
+			expStatus: corev1.ConditionFalse,
+			expReason: healthyReasonSnapshotsFailing,
+		},
+	}
+	for name, c := range cases {
+		t.Run(name, func(t *testing.T) {
+			condition := healthCondition(v1alpha1.Condition{Type: v1alpha1.ConditionSnapshotHealthy}, c.checks)
+			require.Equal(t, c.expStatus, condition.Status)
+			require.Equal(t, c.expReason, condition.Reason)
+		})
+	}
+}
+
+// newFakeHealthServer returns the config of a Consul HTTP API server that returns checks
+// as the health checks of the given service.
+func newFakeHealthServer(t *testing.T, service string, checks capi.HealthChecks) (*consul.Config, consul.ServerConnectionManager) {
+	t.Helper()
+	mux := http.NewServeMux()
+	mux.HandleFunc("/v1/health/checks/"+service, func(w http.ResponseWriter, r *http.Request) {
+		if checks == nil {
+			checks = capi.HealthChecks{}
+		}
+		val, err := json.Marshal(checks)
+		require.NoError(t, err)
+		w.Write(val)
+	})
+	server := httptest.NewServer(mux)
+	t.Cleanup(server.Close)
+
+	parsedURL, err := url.Parse(server.URL)
+	require.NoError(t, err)
+	host := strings.Split(parsedURL.Host, ":")[0]
+	port, err := strconv.Atoi(parsedURL.Port())
+	require.NoError(t, err)
+
+	return &consul.Config{APIClientConfig: &capi.Config{Address: host}, HTTPPort: port}, test.MockConnMgrForIPAndPort(t, host, port, false)
+}
@@ -0,0 +1,273 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package telemetrycollectorconfig
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+
+	"github.com/go-logr/logr"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	"github.com/hashicorp/consul-k8s/control-plane/api/v1alpha1"
+	"github.com/hashicorp/consul-k8s/control-plane/connect-inject/constants"
+)
+
+const (
+	// configKey is the key of the collector configuration in the Secret. The collector
+	// reads it with -config-file-path.
+	configKey = "config.json"
+
+	exporterTypeOTLPHTTP = "otlphttp"
+	exporterTypeOTLPGRPC = "otlp"
+
+	syncedReasonSyncFailed = "ConfigSyncFailed"
+)
+
+// collectorConfig is the configuration file of the consul-telemetry-collector. The collector
+// forwards telemetry either to the OTLP HTTP endpoint in http_collector_endpoint or to the
+// exporter in exporter_config, which also sets the headers and timeout of the requests, so the
+// controller always renders exporter_config.
+type collectorConfig struct {
+	HTTPCollectorEndpoint string          `json:"http_collector_endpoint,omitempty"`
+	ExporterConfig        *exporterConfig `json:"exporter_config,omitempty"`
+}
+
+type exporterConfig struct {
+	// Type is the OpenTelemetry exporter, either otlphttp or otlp for gRPC.
+	Type     string           `json:"type"`
+	Exporter exporterSettings `json:"exporter"`
+}
+
+type exporterSettings struct {
+	Endpoint string            `json:"endpoint"`
+	Headers  map[string]string `json:"headers,omitempty"`
+	Timeout  string            `json:"timeout,omitempty"`
+}
+
+// Controller generates the consul-telemetry-collector configuration of each TelemetryCollectorConfig
+// resource into a Secret, and restarts the Deployments that mount the Secret when the configuration changes.
+type Controller struct {
+	client.Client
+
+	Scheme *runtime.Scheme
+	Log    logr.Logger
+}
+
+// +kubebuilder:rbac:groups=consul.hashicorp.com,resources=telemetrycollectorconfigs,verbs=get;list;watch;create;update;patch;delete
+// +kubebuilder:rbac:groups=consul.hashicorp.com,resources=telemetrycollectorconfigs/status,verbs=get;update;patch
+
+func (r *Controller) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
+	log := r.Log.WithValues("telemetrycollectorconfig", req.NamespacedName)
+
+	config := &v1alpha1.TelemetryCollectorConfig{}
+	if err := r.Client.Get(ctx, req.NamespacedName, config); err != nil {
+		if !k8serrors.IsNotFound(err) {
+			log.Error(err, "unable to get telemetry collector config")
+		}
+		// The Secret with the collector configuration is garbage collected through its owner reference.
+		return ctrl.Result{}, client.IgnoreNotFound(err)
+	}
+	if !config.ObjectMeta.DeletionTimestamp.IsZero() {
+		return ctrl.Result{}, nil
+	}
+
+	if err := config.Validate(); err != nil {
+		log.Error(err, "invalid telemetry collector config")
+		return ctrl.Result{}, r.updateStatus(ctx, config, v1alpha1.SyncedCondition(v1alpha1.SyncedReasonInvalidSpec, err), "")
+	}
+
+	checksum, err := r.sync(ctx, log, config)
+	if err != nil {
+		log.Error(err, "failed to sync telemetry collector config")
+		if statusErr := r.updateStatus(ctx, config, v1alpha1.SyncedCondition(syncedReasonSyncFailed, err), ""); statusErr != nil {
+			log.Error(statusErr, "failed to update telemetry collector config status")
+		}
+		return ctrl.Result{}, err
+	}
+
+	if err := r.updateStatus(ctx, config, v1alpha1.SyncedCondition("", nil), checksum); err != nil {
+		log.Error(err, "failed to update telemetry collector config status")
+		return ctrl.Result{}, err
+	}
+	return ctrl.Result{}, nil
+}
+
+// sync saves the collector configuration of config in its Secret and restarts the Deployments
+// that mount it if it changed. It returns the checksum of the configuration.
+func (r *Controller) sync(ctx context.Context, log logr.Logger, config *v1alpha1.TelemetryCollectorConfig) (string, error) {
+	data, err := r.collectorConfig(ctx, config)
+	if err != nil {
+		return "", err
+	}
+	sum := sha256.Sum256(data)
+	checksum := hex.EncodeToString(sum[:])
+
+	secret := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      config.SecretName(),
+			Namespace: config.Namespace,
+		},
+	}
+	_, err = controllerutil.CreateOrUpdate(ctx, r.Client, secret, func() error {
+		secret.Labels = map[string]string{
+			"app":       "consul",
+			"component": "consul-telemetry-collector",
+		}
+		secret.Data = map[string][]byte{configKey: data}
+		return controllerutil.SetControllerReference(config, secret, r.Scheme)
+	})
+	if err != nil {
+		return "", fmt.Errorf("error syncing secret %s: %w", config.SecretName(), err)
+	}
+
+	if err := r.restartDeployments(ctx, log, config, checksum); err != nil {
+		return "", err
+	}
+	return checksum, nil
+}
+
+// collectorConfig returns the collector configuration of config, which was validated to have a
+// single exporter. The values of the headers that reference a secret are read from the secret.
+func (r *Controller) collectorConfig(ctx context.Context, config *v1alpha1.TelemetryCollectorConfig) ([]byte, error) {
+	var cfg collectorConfig
+	for _, exporter := range config.Spec.Exporters {
+		exporterCfg := &exporterConfig{
+			Type: exporterTypeOTLPHTTP,
+			Exporter: exporterSettings{
+				Endpoint: exporter.Endpoint,
+				Timeout:  exporter.Timeout,
+			},
+		}
+		if exporter.Protocol == v1alpha1.TelemetryExporterProtocolGRPC {
+			exporterCfg.Type = exporterTypeOTLPGRPC
+		}
+		for _, header := range exporter.Headers {
+			value := header.Value
+			if header.SecretRef != nil {
+				var err error
+				value, err = r.secretValue(ctx, config.Namespace, header.SecretRef)
+				if err != nil {
+					return nil, fmt.Errorf("error reading header %q of exporter %q: %w", header.Name, exporter.Name, err)
+				}
+			}
+			if exporterCfg.Exporter.Headers == nil {
+				exporterCfg.Exporter.Headers = make(map[string]string)
+			}
+			exporterCfg.Exporter.Headers[header.Name] = value
+		}
+		cfg.ExporterConfig = exporterCfg
+	}
+	return json.Marshal(cfg)
+}
+
+func (r *Controller) secretValue(ctx context.Context, namespace string, selector *corev1.SecretKeySelector) (string, error) {
+	secret := &corev1.Secret{}
+	if err := r.Client.Get(ctx, types.NamespacedName{Name: selector.Name, Namespace: namespace}, secret); err != nil {
+		return "", err
+	}
+	value, ok := secret.Data[selector.Key]
+	if !ok {
+		return "", fmt.Errorf("key %q not found in secret %s/%s", selector.Key, namespace, selector.Name)
+	}
+	return string(value), nil
+}
+
+// restartDeployments triggers a rolling restart of the Deployments in the namespace of config that mount
+// its Secret and were not started with the configuration of the given checksum.
+func (r *Controller) restartDeployments(ctx context.Context, log logr.Logger, config *v1alpha1.TelemetryCollectorConfig, checksum string) error {
+	var deployments appsv1.DeploymentList
+	if err := r.Client.List(ctx, &deployments, client.InNamespace(config.Namespace)); err != nil {
+		return fmt.Errorf("error listing deployments: %w", err)
+	}
+	for i := range deployments.Items {
+		deployment := &deployments.Items[i]
+		if !mountsSecret(deployment.Spec.Template.Spec, config.SecretName()) {
+			continue
+		}
+		if deployment.Spec.Template.Annotations[constants.AnnotationTelemetryCollectorConfigChecksum] == checksum {
+			continue
+		}
+		patch := client.MergeFrom(deployment.DeepCopy())
+		if deployment.Spec.Template.Annotations == nil {
+			deployment.Spec.Template.Annotations = make(map[string]string)
+		}
+		deployment.Spec.Template.Annotations[constants.AnnotationTelemetryCollectorConfigChecksum] = checksum
+		if err := r.Client.Patch(ctx, deployment, patch); err != nil {
+			return fmt.Errorf("error restarting deployment %s: %w", deployment.Name, err)
+		}
+		log.Info("restarted deployment to apply the telemetry collector config", "deployment", deployment.Name)
+	}
+	return nil
+}
+
+func mountsSecret(spec corev1.PodSpec, name string) bool {
+	for _, volume := range spec.Volumes {
+		if volume.Secret != nil && volume.Secret.SecretName == name {
+			return true
+		}
+	}
+	return false
+}
+
+// updateStatus sets the Synced condition of config. The checksum of the configuration
+// is only updated when it was synced. The status is only written when it changed, since
+// every update of the status triggers another reconcile.
+func (r *Controller) updateStatus(ctx context.Context, config *v1alpha1.TelemetryCollectorConfig, synced v1alpha1.Condition, checksum string) error {
+	previous := config.Status.DeepCopy()
+	config.Status.Conditions = v1alpha1.Conditions{synced}
+	config.Status.Conditions.KeepTransitionTimes(previous.Conditions)
+	if synced.IsTrue() {
+		config.Status.ConfigChecksum = checksum
+	}
+	if equality.Semantic.DeepEqual(previous, &config.Status) {
+		return nil
+	}
+	if synced.IsTrue() {
+		now := metav1.Now()
+		config.Status.LastSyncedTime = &now
+	}
+	return r.Status().Update(ctx, config)
+}
+
+// requestsForSecret returns the TelemetryCollectorConfigs with headers that reference secret,
+// so that the collector configuration is updated when the secret changes.
+func (r *Controller) requestsForSecret(ctx context.Context, secret client.Object) []reconcile.Request {
+	var configs v1alpha1.TelemetryCollectorConfigList
+	if err := r.Client.List(ctx, &configs, client.InNamespace(secret.GetNamespace())); err != nil {
+		r.Log.Error(err, "failed to list telemetry collector configs", "namespace", secret.GetNamespace())
+		return nil
+	}
+	var requests []reconcile.Request
+	for _, config := range configs.Items {
+		if config.ReferencesSecret(secret.GetName()) {
+			requests = append(requests, reconcile.Request{
+				NamespacedName: types.NamespacedName{Name: config.Name, Namespace: config.Namespace},
+			})
+		}
+	}
+	return requests
+}
+
+func (r *Controller) SetupWithManager(mgr ctrl.Manager) error {
+	return ctrl.NewControllerManagedBy(mgr).
+		For(&v1alpha1.TelemetryCollectorConfig{}).
+		Owns(&corev1.Secret{}).
+		Watches(&corev1.Secret{}, handler.EnqueueRequestsFromMapFunc(r.requestsForSecret)).
+		Complete(r)
+}
@@ -0,0 +1,231 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package telemetrycollectorconfig
+
+import (
+	"context"
+	"testing"
+
+	logrtest "github.com/go-logr/logr/testr"
+	"github.com/stretchr/testify/require"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	"github.com/hashicorp/consul-k8s/control-plane/api/v1alpha1"
+	"github.com/hashicorp/consul-k8s/control-plane/connect-inject/constants"
+)
+
+func TestReconcile(t *testing.T) {
+	t.Parallel()
+	apiKey := &corev1.SecretKeySelector{LocalObjectReference: corev1.LocalObjectReference{Name: "otel"}, Key: "api-key"}
+	cases := map[string]struct {
+		spec      v1alpha1.TelemetryCollectorConfigSpec
+		expConfig string
+		expSynced corev1.ConditionStatus
+	}{
+		"http exporter with headers": {
+			spec: v1alpha1.TelemetryCollectorConfigSpec{
+				Exporters: []v1alpha1.TelemetryExporter{
+					{
+						Name:     "vendor",
+						Endpoint: "https://otlp.example.com:4318",
+						Timeout:  "10s",
+						Headers: []v1alpha1.TelemetryExporterHeader{
+							{Name: "x-api-key", SecretRef: apiKey},
+							{Name: "x-team", Value: "platform"},
+						},
+					},
+				},
+			},
+			expConfig: `{"exporter_config":{"type":"otlphttp","exporter":` +
+				`{"endpoint":"https://otlp.example.com:4318","headers":{"x-api-key":"secret-key","x-team":"platform"},"timeout":"10s"}}}`,
+			expSynced: corev1.ConditionTrue,
+		},
+		"grpc exporter": {
+			spec: v1alpha1.TelemetryCollectorConfigSpec{
+				Exporters: []v1alpha1.TelemetryExporter{
+					{
+						Name:     "internal",
+						Protocol: v1alpha1.TelemetryExporterProtocolGRPC,
+						Endpoint: "otel-collector.monitoring.svc:4317",
+					},
+				},
+			},
+			expConfig: `{"exporter_config":{"type":"otlp","exporter":{"endpoint":"otel-collector.monitoring.svc:4317"}}}`,
+			expSynced: corev1.ConditionTrue,
+		},
+		"missing secret": {
+			spec: v1alpha1.TelemetryCollectorConfigSpec{
+				Exporters: []v1alpha1.TelemetryExporter{
+					{
+						Name:     "vendor",
+						Endpoint: "https://otlp.example.com:4318",
+						Headers: []v1alpha1.TelemetryExporterHeader{
+							{Name: "x-api-key", SecretRef: &corev1.SecretKeySelector{LocalObjectReference: corev1.LocalObjectReference{Name: "missing"}, Key: "api-key"}},
+						},
+					},
+				},
+			},
+			expSynced: corev1.ConditionFalse,
+		},
+		"invalid spec": {
+			spec:      v1alpha1.TelemetryCollectorConfigSpec{},
+			expSynced: corev1.ConditionFalse,
+		},
+	}
+
+	for name, c := range cases {
+		c := c
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+			ctx := context.Background()
+			config := &v1alpha1.TelemetryCollectorConfig{
+				ObjectMeta: metav1.ObjectMeta{Name: "exporters", Namespace: "consul"},
+				Spec:       c.spec,
+			}
+			secret := &corev1.Secret{
+				ObjectMeta: metav1.ObjectMeta{Name: "otel", Namespace: "consul"},
+				Data:       map[string][]byte{"api-key": []byte("secret-key")},
+			}
+			collector := collectorDeployment("consul-telemetry-collector", config.SecretName())
+			other := collectorDeployment("other", "other-secret")
+
+			fakeClient, controller := newController(t, config, secret, collector, other)
+			key := types.NamespacedName{Name: config.Name, Namespace: config.Namespace}
+			_, err := controller.Reconcile(ctx, ctrl.Request{NamespacedName: key})
+			if c.expSynced == corev1.ConditionTrue {
+				require.NoError(t, err)
+			}
+
+			fetched := &v1alpha1.TelemetryCollectorConfig{}
+			require.NoError(t, fakeClient.Get(ctx, key, fetched))
+			require.Len(t, fetched.Status.Conditions, 1)
+			require.Equal(t, v1alpha1.ConditionSynced, fetched.Status.Conditions[0].Type)
+			require.Equal(t, c.expSynced, fetched.Status.Conditions[0].Status)
+
+			configSecret := &corev1.Secret{}
+			err = fakeClient.Get(ctx, types.NamespacedName{Name: config.SecretName(), Namespace: config.Namespace}, configSecret)
+			if c.expSynced == corev1.ConditionFalse {
+				require.Error(t, err, "the configuration should not be synced")
+				require.Empty(t, fetched.Status.ConfigChecksum)
+				return
+			}
+			require.NoError(t, err)
+			require.JSONEq(t, c.expConfig, string(configSecret.Data[configKey]))
+			require.Len(t, configSecret.OwnerReferences, 1)
+			require.Equal(t, config.Name, configSecret.OwnerReferences[0].Name)
+
+			require.NotEmpty(t, fetched.Status.ConfigChecksum)
+			require.NotNil(t, fetched.Status.LastSyncedTime)
+
+			// Only the Deployments that mount the configuration are restarted.
+			require.NoError(t, fakeClient.Get(ctx, client.ObjectKeyFromObject(collector), collector))
+			require.Equal(t, fetched.Status.ConfigChecksum, collector.Spec.Template.Annotations[constants.AnnotationTelemetryCollectorConfigChecksum])
+			require.NoError(t, fakeClient.Get(ctx, client.ObjectKeyFromObject(other), other))
+			require.NotContains(t, other.Spec.Template.Annotations, constants.AnnotationTelemetryCollectorConfigChecksum)
+		})
+	}
+}
+
+func TestReconcile_RestartsOnSecretChange(t *testing.T) {
+	t.Parallel()
+	ctx := context.Background()
+	config := &v1alpha1.TelemetryCollectorConfig{
+		ObjectMeta: metav1.ObjectMeta{Name: "exporters", Namespace: "consul"},
+		Spec: v1alpha1.TelemetryCollectorConfigSpec{
+			Exporters: []v1alpha1.TelemetryExporter{
+				{
+					Name:     "vendor",
+					Endpoint: "https://otlp.example.com:4318",
+					Headers: []v1alpha1.TelemetryExporterHeader{
+						{Name: "x-api-key", SecretRef: &corev1.SecretKeySelector{LocalObjectReference: corev1.LocalObjectReference{Name: "otel"}, Key: "api-key"}},
+					},
+				},
+			},
+		},
+	}
+	secret := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Name: "otel", Namespace: "consul"},
+		Data:       map[string][]byte{"api-key": []byte("old-key")},
+	}
+	collector := collectorDeployment("consul-telemetry-collector", config.SecretName())
+	fakeClient, controller := newController(t, config, secret, collector)
+
+	key := types.NamespacedName{Name: config.Name, Namespace: config.Namespace}
+	_, err := controller.Reconcile(ctx, ctrl.Request{NamespacedName: key})
+	require.NoError(t, err)
+	require.NoError(t, fakeClient.Get(ctx, client.ObjectKeyFromObject(collector), collector))
+	oldChecksum := collector.Spec.Template.Annotations[constants.AnnotationTelemetryCollectorConfigChecksum]
+	require.NotEmpty(t, oldChecksum)
+
+	synced := &v1alpha1.TelemetryCollectorConfig{}
+	require.NoError(t, fakeClient.Get(ctx, key, synced))
+
+	// Reconciling again without changes doesn't restart the collector or update the status.
+	_, err = controller.Reconcile(ctx, ctrl.Request{NamespacedName: key})
+	require.NoError(t, err)
+	require.NoError(t, fakeClient.Get(ctx, client.ObjectKeyFromObject(collector), collector))
+	require.Equal(t, oldChecksum, collector.Spec.Template.Annotations[constants.AnnotationTelemetryCollectorConfigChecksum])
+	fetched := &v1alpha1.TelemetryCollectorConfig{}
+	require.NoError(t, fakeClient.Get(ctx, key, fetched))
+	require.Equal(t, synced.ResourceVersion, fetched.ResourceVersion)
+
+	// The referenced secret changes.
+	require.Equal(t, []ctrl.Request{{NamespacedName: key}}, controller.requestsForSecret(ctx, secret))
+	require.Empty(t, controller.requestsForSecret(ctx, &corev1.Secret{ObjectMeta: metav1.ObjectMeta{Name: "unrelated", Namespace: "consul"}}))
+	secret.Data["api-key"] = []byte("new-key")
+	require.NoError(t, fakeClient.Update(ctx, secret))
+
+	_, err = controller.Reconcile(ctx, ctrl.Request{NamespacedName: key})
+	require.NoError(t, err)
+	require.NoError(t, fakeClient.Get(ctx, client.ObjectKeyFromObject(collector), collector))
+	require.NotEqual(t, oldChecksum, collector.Spec.Template.Annotations[constants.AnnotationTelemetryCollectorConfigChecksum])
+
+	configSecret := &corev1.Secret{}
+	require.NoError(t, fakeClient.Get(ctx, types.NamespacedName{Name: config.SecretName(), Namespace: config.Namespace}, configSecret))
+	require.Contains(t, string(configSecret.Data[configKey]), "new-key")
+}
+
+func newController(t *testing.T, objects ...runtime.Object) (client.Client, *Controller) {
+	t.Helper()
+	s := runtime.NewScheme()
+	require.NoError(t, clientgoscheme.AddToScheme(s))
+	s.AddKnownTypes(v1alpha1.GroupVersion, &v1alpha1.TelemetryCollectorConfig{}, &v1alpha1.TelemetryCollectorConfigList{})
+	fakeClient := fake.NewClientBuilder().
+		WithScheme(s).
+		WithRuntimeObjects(objects...).
+		WithStatusSubresource(&v1alpha1.TelemetryCollectorConfig{}).
+		Build()
+	return fakeClient, &Controller{
+		Client: fakeClient,
+		Scheme: s,
+		Log:    logrtest.New(t),
+	}
+}
+
+func collectorDeployment(name, secretName string) *appsv1.Deployment {
+	return &appsv1.Deployment{
+		ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "consul"},
+		Spec: appsv1.DeploymentSpec{
+			Template: corev1.PodTemplateSpec{
+				Spec: corev1.PodSpec{
+					Volumes: []corev1.Volume{
+						{
+							Name:         "config",
+							VolumeSource: corev1.VolumeSource{Secret: &corev1.SecretVolumeSource{SecretName: secretName}},
+						},
+					},
+					Containers: []corev1.Container{{Name: "consul-telemetry-collector"}},
+				},
+			},
+		},
+	}
+}
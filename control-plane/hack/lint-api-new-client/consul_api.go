@@ -0,0 +1,80 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package main
+
+import (
+	"go/ast"
+	"go/types"
+	"strings"
+
+	"golang.org/x/tools/go/analysis"
+	"golang.org/x/tools/go/types/typeutil"
+)
+
+const (
+	consulAPIPackage = "github.com/hashicorp/consul/api"
+	// consulPackage is where we have our re-implementation of NewClient()
+	// which under the hood calls api.NewClient().
+	consulPackage = "github.com/hashicorp/consul-k8s/control-plane/consul"
+)
+
+// consulAPIFunc returns the function or method of the Consul API package that
+// call calls, or nil if it calls something else.
+func consulAPIFunc(info *types.Info, call *ast.CallExpr) *types.Func {
+	fn, ok := typeutil.Callee(info, call).(*types.Func)
+	if !ok || fn.Pkg() == nil || fn.Pkg().Path() != consulAPIPackage {
+		return nil
+	}
+	return fn
+}
+
+// consulAPIOptions returns the name of the Consul API type if t is QueryOptions
+// or WriteOptions, or a pointer to one of them.
+func consulAPIOptions(t types.Type) (string, bool) {
+	if ptr, ok := t.(*types.Pointer); ok {
+		t = ptr.Elem()
+	}
+	named, ok := t.(*types.Named)
+	if !ok {
+		return "", false
+	}
+	obj := named.Obj()
+	if obj.Pkg() == nil || obj.Pkg().Path() != consulAPIPackage {
+		return "", false
+	}
+	switch obj.Name() {
+	case "QueryOptions", "WriteOptions":
+		return obj.Name(), true
+	}
+	return "", false
+}
+
+// isTestCode returns true if file is a test file or belongs to a test helper
+// package, e.g. helper/test.
+func isTestCode(pass *analysis.Pass, file *ast.File) bool {
+	if strings.HasSuffix(pass.Fset.File(file.Pos()).Name(), "_test.go") {
+		return true
+	}
+	for _, elem := range strings.Split(pass.Pkg.Path(), "/") {
+		if strings.Contains(elem, "test") {
+			return true
+		}
+	}
+	return false
+}
+
+// inspectWithStack calls f for each node of file with the path of nodes from
+// file to the node, the node included.
+func inspectWithStack(file *ast.File, f func(n ast.Node, stack []ast.Node)) {
+	var stack []ast.Node
+	ast.Inspect(file, func(n ast.Node) bool {
+		if n == nil {
+			stack = stack[:len(stack)-1]
+			return true
+		}
+		stack = append(stack, n)
+		f(n, stack)
+		return true
+	})
+}
@@ -0,0 +1,105 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package main
+
+import (
+	"go/ast"
+	"go/types"
+
+	"golang.org/x/tools/go/analysis"
+	"golang.org/x/tools/go/ast/astutil"
+)
+
+var consulCtxAnalyzer = &analysis.Analyzer{
+	Name: "consulctx",
+	Doc: "require Consul API calls to pass the context of the enclosing function\n\n" +
+		"When a function has a context.Context parameter, the QueryOptions or WriteOptions " +
+		"of the Consul API calls it makes must not be nil or a literal without the context, " +
+		"e.g. (&api.QueryOptions{}).WithContext(ctx), so that the calls are cancelled with it. " +
+		"Options passed in variables and methods that take a context.Context are not checked.",
+	Run: runConsulCtx,
+}
+
+func runConsulCtx(pass *analysis.Pass) (interface{}, error) {
+	for _, file := range pass.Files {
+		if isTestCode(pass, file) {
+			continue
+		}
+		inspectWithStack(file, func(n ast.Node, stack []ast.Node) {
+			call, ok := n.(*ast.CallExpr)
+			if !ok {
+				return
+			}
+			fn := consulAPIFunc(pass.TypesInfo, call)
+			if fn == nil || !hasContextParam(pass.TypesInfo, stack) {
+				return
+			}
+			params := fn.Type().(*types.Signature).Params()
+			// Methods such as Peerings().Read() take the context as an argument.
+			for i := 0; i < params.Len(); i++ {
+				if isContext(params.At(i).Type()) {
+					return
+				}
+			}
+			for i := 0; i < params.Len() && i < len(call.Args); i++ {
+				options, ok := consulAPIOptions(params.At(i).Type())
+				if !ok || !withoutContext(pass.TypesInfo, call.Args[i]) {
+					continue
+				}
+				pass.Reportf(call.Args[i].Pos(), "call to %s doesn't pass the context of the enclosing function, use (&api.%s{}).WithContext(ctx)", fn.Name(), options)
+			}
+		})
+	}
+	return nil, nil
+}
+
+// hasContextParam returns true if one of the functions enclosing the last node
+// of stack has a context.Context parameter.
+func hasContextParam(info *types.Info, stack []ast.Node) bool {
+	for _, n := range stack {
+		var fnType *ast.FuncType
+		switch fn := n.(type) {
+		case *ast.FuncDecl:
+			fnType = fn.Type
+		case *ast.FuncLit:
+			fnType = fn.Type
+		default:
+			continue
+		}
+		for _, field := range fnType.Params.List {
+			if !isContext(info.TypeOf(field.Type)) {
+				continue
+			}
+			for _, name := range field.Names {
+				if name.Name != "_" {
+					return true
+				}
+			}
+		}
+	}
+	return false
+}
+
+func isContext(t types.Type) bool {
+	named, ok := t.(*types.Named)
+	if !ok {
+		return false
+	}
+	obj := named.Obj()
+	return obj.Pkg() != nil && obj.Pkg().Path() == "context" && obj.Name() == "Context"
+}
+
+// withoutContext returns true if expr is nil or an options literal, which can't
+// carry a context.
+func withoutContext(info *types.Info, expr ast.Expr) bool {
+	expr = astutil.Unparen(expr)
+	if info.Types[expr].IsNil() {
+		return true
+	}
+	if unary, ok := expr.(*ast.UnaryExpr); ok {
+		expr = astutil.Unparen(unary.X)
+	}
+	_, ok := expr.(*ast.CompositeLit)
+	return ok
+}
@@ -0,0 +1,148 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package main
+
+import (
+	"go/ast"
+	"go/importer"
+	"go/parser"
+	"go/token"
+	"go/types"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"golang.org/x/tools/go/analysis"
+)
+
+func TestNewClient(t *testing.T) {
+	runAnalyzer(t, newClientAnalyzer, "newclient")
+	runAnalyzer(t, newClientAnalyzer, consulPackage)
+}
+
+func TestConsulCtx(t *testing.T) {
+	runAnalyzer(t, consulCtxAnalyzer, "consulctx")
+}
+
+func TestNamespaces(t *testing.T) {
+	runAnalyzer(t, namespacesAnalyzer, "namespaces")
+}
+
+// wantRegexp matches the comments of the testdata files that expect a diagnostic
+// on their line, e.g. // want `call to Register`.
+var wantRegexp = regexp.MustCompile("// want `(.*)`")
+
+// runAnalyzer runs a on the package of testdata/src with the given import path and
+// checks that it reports exactly the diagnostics expected by the want comments.
+// The packages of testdata/src are imported instead of the real ones, e.g. the stub
+// of github.com/hashicorp/consul/api.
+func runAnalyzer(t *testing.T, a *analysis.Analyzer, pkgPath string) {
+	t.Helper()
+	fset := token.NewFileSet()
+	imp := &testdataImporter{fset: fset, pkgs: make(map[string]*types.Package), fallback: importer.Default()}
+	files, pkg, info, err := imp.load(pkgPath)
+	require.NoError(t, err)
+
+	var diagnostics []analysis.Diagnostic
+	pass := &analysis.Pass{
+		Analyzer:   a,
+		Fset:       fset,
+		Files:      files,
+		Pkg:        pkg,
+		TypesInfo:  info,
+		TypesSizes: types.SizesFor("gc", "amd64"),
+		ResultOf:   make(map[*analysis.Analyzer]interface{}),
+		Report: func(d analysis.Diagnostic) {
+			diagnostics = append(diagnostics, d)
+		},
+	}
+	_, err = a.Run(pass)
+	require.NoError(t, err)
+
+	want := make(map[string]*regexp.Regexp)
+	for _, file := range files {
+		for _, group := range file.Comments {
+			for _, comment := range group.List {
+				match := wantRegexp.FindStringSubmatch(comment.Text)
+				if match == nil {
+					continue
+				}
+				want[position(fset, comment.Pos())] = regexp.MustCompile(match[1])
+			}
+		}
+	}
+	for _, d := range diagnostics {
+		pos := position(fset, d.Pos)
+		expected, ok := want[pos]
+		if !ok {
+			t.Errorf("%s: unexpected diagnostic: %s", pos, d.Message)
+			continue
+		}
+		require.Regexp(t, expected, d.Message, pos)
+		delete(want, pos)
+	}
+	for pos, expected := range want {
+		t.Errorf("%s: expected diagnostic matching %q", pos, expected)
+	}
+}
+
+func position(fset *token.FileSet, pos token.Pos) string {
+	p := fset.Position(pos)
+	return filepath.Base(p.Filename) + ":" + strconv.Itoa(p.Line)
+}
+
+// testdataImporter type-checks the packages of testdata/src and imports the
+// other packages, e.g. context, with fallback.
+type testdataImporter struct {
+	fset     *token.FileSet
+	pkgs     map[string]*types.Package
+	fallback types.Importer
+}
+
+func (i *testdataImporter) Import(path string) (*types.Package, error) {
+	if pkg, ok := i.pkgs[path]; ok {
+		return pkg, nil
+	}
+	if _, err := os.Stat(filepath.Join("testdata", "src", path)); err != nil {
+		return i.fallback.Import(path)
+	}
+	_, pkg, _, err := i.load(path)
+	return pkg, err
+}
+
+func (i *testdataImporter) load(path string) ([]*ast.File, *types.Package, *types.Info, error) {
+	dir := filepath.Join("testdata", "src", path)
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	var files []*ast.File
+	for _, entry := range entries {
+		if !strings.HasSuffix(entry.Name(), ".go") {
+			continue
+		}
+		file, err := parser.ParseFile(i.fset, filepath.Join(dir, entry.Name()), nil, parser.ParseComments)
+		if err != nil {
+			return nil, nil, nil, err
+		}
+		files = append(files, file)
+	}
+	info := &types.Info{
+		Types:      make(map[ast.Expr]types.TypeAndValue),
+		Defs:       make(map[*ast.Ident]types.Object),
+		Uses:       make(map[*ast.Ident]types.Object),
+		Selections: make(map[*ast.SelectorExpr]*types.Selection),
+	}
+	config := types.Config{Importer: i}
+	pkg, err := config.Check(path, i.fset, files, info)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	i.pkgs[path] = pkg
+	return files, pkg, info, nil
+}
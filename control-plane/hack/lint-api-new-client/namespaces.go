@@ -0,0 +1,119 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package main
+
+import (
+	"go/ast"
+	"go/types"
+	"regexp"
+
+	"golang.org/x/tools/go/analysis"
+)
+
+var namespacesAnalyzer = &analysis.Analyzer{
+	Name: "namespaces",
+	Doc: "require the Namespace of Consul API options when Consul namespaces are enabled\n\n" +
+		"QueryOptions and WriteOptions literals in the body of an if statement whose " +
+		"condition checks whether Consul namespaces are enabled, e.g. " +
+		"`if r.EnableConsulNamespaces {`, must set the Namespace. Otherwise the request " +
+		"is made in the namespace of the ACL token, which is usually the default namespace.",
+	Run: runNamespaces,
+}
+
+// namespacesCondition matches the names of the fields, variables and flags that
+// enable Consul namespaces.
+var namespacesCondition = `^(?i)(flag)?enable(consul)?namespaces$`
+
+func init() {
+	namespacesAnalyzer.Flags.StringVar(&namespacesCondition, "condition", namespacesCondition,
+		"regular expression matching the names of the identifiers that enable Consul namespaces")
+}
+
+func runNamespaces(pass *analysis.Pass) (interface{}, error) {
+	condition, err := regexp.Compile(namespacesCondition)
+	if err != nil {
+		return nil, err
+	}
+	for _, file := range pass.Files {
+		if isTestCode(pass, file) {
+			continue
+		}
+		inspectWithStack(file, func(n ast.Node, stack []ast.Node) {
+			lit, ok := n.(*ast.CompositeLit)
+			if !ok {
+				return
+			}
+			options, ok := consulAPIOptions(pass.TypesInfo.TypeOf(lit))
+			if !ok || hasField(lit, "Namespace") || managesNamespaces(pass.TypesInfo, stack) || !namespacesEnabled(condition, stack) {
+				return
+			}
+			pass.Reportf(lit.Pos(), "api.%s doesn't set the Namespace while Consul namespaces are enabled", options)
+		})
+	}
+	return nil, nil
+}
+
+func hasField(lit *ast.CompositeLit, name string) bool {
+	for _, elt := range lit.Elts {
+		kv, ok := elt.(*ast.KeyValueExpr)
+		if !ok {
+			// Options set by position set every field.
+			return true
+		}
+		if key, ok := kv.Key.(*ast.Ident); ok && key.Name == name {
+			return true
+		}
+	}
+	return false
+}
+
+// managesNamespaces returns true if the last node of stack is an argument of a
+// call to the Namespaces API, whose requests aren't made in a namespace.
+func managesNamespaces(info *types.Info, stack []ast.Node) bool {
+	for i := len(stack) - 2; i >= 0; i-- {
+		switch n := stack[i].(type) {
+		case *ast.UnaryExpr, *ast.ParenExpr:
+			continue
+		case *ast.CallExpr:
+			fn := consulAPIFunc(info, n)
+			if fn == nil {
+				return false
+			}
+			recv := fn.Type().(*types.Signature).Recv()
+			if recv == nil {
+				return false
+			}
+			t := recv.Type()
+			if ptr, ok := t.(*types.Pointer); ok {
+				t = ptr.Elem()
+			}
+			named, ok := t.(*types.Named)
+			return ok && named.Obj().Name() == "Namespaces"
+		}
+		return false
+	}
+	return false
+}
+
+// namespacesEnabled returns true if the last node of stack is in the body of
+// an if statement whose condition refers to an identifier matching condition.
+func namespacesEnabled(condition *regexp.Regexp, stack []ast.Node) bool {
+	for i := 0; i < len(stack)-1; i++ {
+		ifStmt, ok := stack[i].(*ast.IfStmt)
+		if !ok || stack[i+1] != ifStmt.Body {
+			continue
+		}
+		found := false
+		ast.Inspect(ifStmt.Cond, func(n ast.Node) bool {
+			if ident, ok := n.(*ast.Ident); ok && condition.MatchString(ident.Name) {
+				found = true
+			}
+			return !found
+		})
+		if found {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,40 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package main
+
+import (
+	"go/ast"
+
+	"golang.org/x/tools/go/analysis"
+)
+
+var newClientAnalyzer = &analysis.Analyzer{
+	Name: "newclient",
+	Doc: "forbid github.com/hashicorp/consul/api.NewClient() in non-test code\n\n" +
+		"Use github.com/hashicorp/consul-k8s/control-plane/consul.NewClient() instead " +
+		"so that the consul-k8s version is sent to Consul as a header.",
+	Run: runNewClient,
+}
+
+func runNewClient(pass *analysis.Pass) (interface{}, error) {
+	if pass.Pkg.Path() == consulPackage {
+		return nil, nil
+	}
+	for _, file := range pass.Files {
+		if isTestCode(pass, file) {
+			continue
+		}
+		ast.Inspect(file, func(n ast.Node) bool {
+			call, ok := n.(*ast.CallExpr)
+			if !ok {
+				return true
+			}
+			if fn := consulAPIFunc(pass.TypesInfo, call); fn != nil && fn.Name() == "NewClient" {
+				pass.Reportf(call.Pos(), "use %s.NewClient() instead of %s.NewClient()", consulPackage, consulAPIPackage)
+			}
+			return true
+		})
+	}
+	return nil, nil
+}
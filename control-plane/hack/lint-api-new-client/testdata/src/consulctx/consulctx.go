@@ -0,0 +1,32 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package consulctx
+
+import (
+	"context"
+
+	"github.com/hashicorp/consul/api"
+)
+
+func withContext(ctx context.Context, client *api.Client, opts *api.QueryOptions) {
+	_ = client.Catalog().Register(nil, nil)                                       // want `call to Register doesn't pass the context of the enclosing function, use \(&api.WriteOptions\{\}\).WithContext\(ctx\)`
+	_ = client.Catalog().Register(nil, &api.WriteOptions{Partition: "default"})   // want `call to Register doesn't pass the context`
+	_, _ = client.Catalog().Services(&api.QueryOptions{Filter: "Service == web"}) // want `call to Services doesn't pass the context of the enclosing function, use \(&api.QueryOptions\{\}\).WithContext\(ctx\)`
+	_ = client.Catalog().Register(nil, (&api.WriteOptions{}).WithContext(ctx))
+	_, _ = client.Catalog().Services(opts)
+	_ = client.Peerings().Read(ctx, "peer", nil)
+
+	go func() {
+		_, _ = client.Catalog().Services(nil) // want `call to Services doesn't pass the context`
+	}()
+}
+
+func withoutContext(client *api.Client) {
+	_ = client.Catalog().Register(nil, nil)
+	_, _ = client.Catalog().Services(&api.QueryOptions{})
+}
+
+func ignoredContext(_ context.Context, client *api.Client) {
+	_, _ = client.Catalog().Services(nil)
+}
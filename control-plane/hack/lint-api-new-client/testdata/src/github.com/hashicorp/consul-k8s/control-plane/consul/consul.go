@@ -0,0 +1,14 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package consul
+
+import (
+	capi "github.com/hashicorp/consul/api"
+)
+
+// NewClient is allowed to call api.NewClient() since it is what the other
+// packages must use instead.
+func NewClient(config *capi.Config) (*capi.Client, error) {
+	return capi.NewClient(config)
+}
@@ -0,0 +1,64 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+// Package api is a stub of the parts of github.com/hashicorp/consul/api that
+// the analyzers check.
+package api
+
+import "context"
+
+type Config struct{}
+
+type Client struct{}
+
+func NewClient(config *Config) (*Client, error) { return &Client{}, nil }
+
+type QueryOptions struct {
+	Namespace string
+	Filter    string
+	ctx       context.Context
+}
+
+func (o *QueryOptions) WithContext(ctx context.Context) *QueryOptions {
+	o2 := *o
+	o2.ctx = ctx
+	return &o2
+}
+
+type WriteOptions struct {
+	Namespace string
+	Partition string
+	ctx       context.Context
+}
+
+func (o *WriteOptions) WithContext(ctx context.Context) *WriteOptions {
+	o2 := *o
+	o2.ctx = ctx
+	return &o2
+}
+
+type CatalogRegistration struct{}
+
+type Catalog struct{}
+
+func (c *Client) Catalog() *Catalog { return &Catalog{} }
+
+func (c *Catalog) Register(reg *CatalogRegistration, q *WriteOptions) error { return nil }
+
+func (c *Catalog) Services(q *QueryOptions) (map[string][]string, error) { return nil, nil }
+
+type Peerings struct{}
+
+func (c *Client) Peerings() *Peerings { return &Peerings{} }
+
+func (p *Peerings) Read(ctx context.Context, name string, q *QueryOptions) error { return nil }
+
+type Namespace struct {
+	Name string
+}
+
+type Namespaces struct{}
+
+func (c *Client) Namespaces() *Namespaces { return &Namespaces{} }
+
+func (n *Namespaces) Update(ns *Namespace, q *WriteOptions) error { return nil }
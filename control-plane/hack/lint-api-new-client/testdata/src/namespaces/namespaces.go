@@ -0,0 +1,31 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package namespaces
+
+import (
+	"github.com/hashicorp/consul/api"
+)
+
+type controller struct {
+	EnableConsulNamespaces bool
+	client                 *api.Client
+}
+
+func (c *controller) services(namespace string) {
+	if c.EnableConsulNamespaces {
+		_, _ = c.client.Catalog().Services(&api.QueryOptions{Filter: "Service == web"}) // want `api.QueryOptions doesn't set the Namespace while Consul namespaces are enabled`
+		_, _ = c.client.Catalog().Services(&api.QueryOptions{Namespace: namespace})
+		_ = c.client.Namespaces().Update(&api.Namespace{Name: namespace}, &api.WriteOptions{})
+	} else {
+		_, _ = c.client.Catalog().Services(&api.QueryOptions{Filter: "Service == web"})
+	}
+	_ = c.client.Catalog().Register(nil, &api.WriteOptions{})
+}
+
+func register(client *api.Client, flagEnableNamespaces bool, partition string) {
+	if flagEnableNamespaces && partition != "" {
+		opts := api.WriteOptions{Partition: partition} // want `api.WriteOptions doesn't set the Namespace while Consul namespaces are enabled`
+		_ = client.Catalog().Register(nil, &opts)
+	}
+}
@@ -0,0 +1,16 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package newclient
+
+import (
+	capi "github.com/hashicorp/consul/api"
+
+	"github.com/hashicorp/consul-k8s/control-plane/consul"
+)
+
+func clients() {
+	_, _ = capi.NewClient(&capi.Config{}) // want `use github.com/hashicorp/consul-k8s/control-plane/consul.NewClient\(\) instead of github.com/hashicorp/consul/api.NewClient\(\)`
+
+	_, _ = consul.NewClient(&capi.Config{})
+}
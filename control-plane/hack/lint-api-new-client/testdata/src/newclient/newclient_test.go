@@ -0,0 +1,12 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package newclient
+
+import (
+	"github.com/hashicorp/consul/api"
+)
+
+func testClient() {
+	_, _ = api.NewClient(&api.Config{})
+}
@@ -7,6 +7,7 @@ package namespaces
 
 import (
 	"fmt"
+	"strings"
 
 	capi "github.com/hashicorp/consul/api"
 )
@@ -14,6 +15,12 @@ import (
 const (
 	WildcardNamespace = "*"
 	DefaultNamespace  = "default"
+
+	// K8sNamespaceVariable is replaced with the Kubernetes namespace in a mirroring prefix
+	// that contains it, e.g. the prefix "{k8s-namespace}-east" mirrors the Kubernetes namespace
+	// "web" into the Consul namespace "web-east". A mirroring prefix that doesn't contain it
+	// is prepended to the Kubernetes namespace.
+	K8sNamespaceVariable = "{k8s-namespace}"
 )
 
 // EnsureExists ensures a Consul namespace with name ns exists. If it doesn't,
@@ -88,12 +95,38 @@ func ConsulNamespace(kubeNS string, enableConsulNamespaces bool, consulDestNS st
 
 	// Mirroring takes precedence.
 	if enableMirroring {
-		return fmt.Sprintf("%s%s", mirroringPrefix, kubeNS)
+		return MirroredNamespace(kubeNS, mirroringPrefix)
 	}
 
 	return consulDestNS
 }
 
+// MirroredNamespace returns the Consul namespace that kubeNS is mirrored into
+// with the mirroring prefix.
+func MirroredNamespace(kubeNS string, mirroringPrefix string) string {
+	if strings.Contains(mirroringPrefix, K8sNamespaceVariable) {
+		return strings.ReplaceAll(mirroringPrefix, K8sNamespaceVariable, kubeNS)
+	}
+	return fmt.Sprintf("%s%s", mirroringPrefix, kubeNS)
+}
+
+// MirroringNamespacePrefix returns the prefix shared by the names of all the
+// Consul namespaces mirrored with the mirroring prefix, i.e. the part of the
+// prefix before K8sNamespaceVariable.
+func MirroringNamespacePrefix(mirroringPrefix string) string {
+	prefix, _, _ := strings.Cut(mirroringPrefix, K8sNamespaceVariable)
+	return prefix
+}
+
+// ValidateMirroringPrefix returns an error if the mirroring prefix contains
+// variables other than K8sNamespaceVariable.
+func ValidateMirroringPrefix(mirroringPrefix string) error {
+	if strings.ContainsAny(strings.ReplaceAll(mirroringPrefix, K8sNamespaceVariable, ""), "{}") {
+		return fmt.Errorf("mirroring prefix %q contains an unknown variable, only %s is supported", mirroringPrefix, K8sNamespaceVariable)
+	}
+	return nil
+}
+
 // NonDefaultConsulNamespace returns the given Consul namespace if it is not default or empty.
 // Otherwise, it returns the empty string.
 func NonDefaultConsulNamespace(consulNS string) string {
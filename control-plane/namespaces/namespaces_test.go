@@ -235,6 +235,20 @@ func TestConsulNamespace(t *testing.T) {
 			kubeNS:                 "kube",
 			expNS:                  "prefix-kube",
 		},
+		"mirroring with templated prefix": {
+			enableConsulNamespaces: true,
+			enableMirroring:        true,
+			mirroringPrefix:        "{k8s-namespace}-east",
+			kubeNS:                 "kube",
+			expNS:                  "kube-east",
+		},
+		"mirroring with templated prefix and suffix": {
+			enableConsulNamespaces: true,
+			enableMirroring:        true,
+			mirroringPrefix:        "k8s-{k8s-namespace}-east",
+			kubeNS:                 "kube",
+			expNS:                  "k8s-kube-east",
+		},
 		"destination consul ns": {
 			enableConsulNamespaces: true,
 			consulDestNS:           "dest",
@@ -257,3 +271,37 @@ func TestConsulNamespace(t *testing.T) {
 		})
 	}
 }
+
+func TestMirroringNamespacePrefix(t *testing.T) {
+	cases := map[string]string{
+		"":                         "",
+		"k8s-":                     "k8s-",
+		"{k8s-namespace}-east":     "",
+		"k8s-{k8s-namespace}-east": "k8s-",
+	}
+	for mirroringPrefix, exp := range cases {
+		t.Run(mirroringPrefix, func(t *testing.T) {
+			require.Equal(t, exp, MirroringNamespacePrefix(mirroringPrefix))
+		})
+	}
+}
+
+func TestValidateMirroringPrefix(t *testing.T) {
+	cases := map[string]string{
+		"":                          "",
+		"k8s-":                      "",
+		"{k8s-namespace}-east":      "",
+		"{k8s-namespace}-{cluster}": `mirroring prefix "{k8s-namespace}-{cluster}" contains an unknown variable, only {k8s-namespace} is supported`,
+		"k8s-{k8s-namespace":        `mirroring prefix "k8s-{k8s-namespace" contains an unknown variable, only {k8s-namespace} is supported`,
+	}
+	for mirroringPrefix, expErr := range cases {
+		t.Run(mirroringPrefix, func(t *testing.T) {
+			err := ValidateMirroringPrefix(mirroringPrefix)
+			if expErr == "" {
+				require.NoError(t, err)
+			} else {
+				require.EqualError(t, err, expErr)
+			}
+		})
+	}
+}
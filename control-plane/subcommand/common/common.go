@@ -34,6 +34,15 @@ const (
 	// create Kubernetes secrets.
 	ACLTokenSecretKey = "token"
 
+	// The keys of the federation secret. The secret is created in the primary
+	// datacenter by the create-federation-secret and federation-controller
+	// commands and consumed in secondary datacenters.
+	FederationSecretGossipKey           = "gossipEncryptionKey"
+	FederationSecretCACertKey           = "caCert"
+	FederationSecretCAKeyKey            = "caKey"
+	FederationSecretServerConfigKey     = "serverConfigJSON"
+	FederationSecretReplicationTokenKey = "replicationToken"
+
 	// CLILabelKey and CLILabelValue are added to each secret on creation so the CLI knows
 	// which secrets to delete on an uninstall.
 	CLILabelKey   = "managed-by"
@@ -66,6 +75,24 @@ func NamedLogger(level string, jsonLogging bool, name string) (hclog.Logger, err
 
 // ZapLogger returns a logr.Logger instance with log level set and JSON logging enabled/disabled, or an error if the level is invalid.
 func ZapLogger(level string, jsonLogging bool) (logr.Logger, error) {
+	zapLevel, err := ZapLevel(level)
+	if err != nil {
+		return logr.Logger{}, err
+	}
+	return ZapLoggerWithLevel(zapLevel, jsonLogging), nil
+}
+
+// ZapLoggerWithLevel returns a logr.Logger instance that logs at the level enabled by level, e.g. a
+// zap.AtomicLevel whose level can be changed while the logger is in use.
+func ZapLoggerWithLevel(level zapcore.LevelEnabler, jsonLogging bool) logr.Logger {
+	if jsonLogging {
+		return zap.New(zap.UseDevMode(false), zap.Level(level), zap.JSONEncoder())
+	}
+	return zap.New(zap.UseDevMode(false), zap.Level(level), zap.ConsoleEncoder())
+}
+
+// ZapLevel parses a log level, or returns an error if the level is invalid.
+func ZapLevel(level string) (zapcore.Level, error) {
 	var zapLevel zapcore.Level
 	// It is possible that a user passes in "trace" from global.logLevel, until we standardize on one logging framework
 	// we will assume they meant debug here and not fail.
@@ -73,12 +100,9 @@ func ZapLogger(level string, jsonLogging bool) (logr.Logger, error) {
 		level = "debug"
 	}
 	if err := zapLevel.UnmarshalText([]byte(level)); err != nil {
-		return logr.Logger{}, fmt.Errorf("unknown log level %q: %s", level, err.Error())
-	}
-	if jsonLogging {
-		return zap.New(zap.UseDevMode(false), zap.Level(zapLevel), zap.JSONEncoder()), nil
+		return zapLevel, fmt.Errorf("unknown log level %q: %s", level, err.Error())
 	}
-	return zap.New(zap.UseDevMode(false), zap.Level(zapLevel), zap.ConsoleEncoder()), nil
+	return zapLevel, nil
 }
 
 // ValidateUnprivilegedPort converts flags representing ports into integer and validates
@@ -19,11 +19,14 @@ import (
 	"github.com/cenkalti/backoff"
 	"github.com/hashicorp/consul-server-connection-manager/discovery"
 	"github.com/hashicorp/consul/api"
+	pbcatalog "github.com/hashicorp/consul/proto-public/pbcatalog/v2beta1"
+	"github.com/hashicorp/consul/proto-public/pbresource"
 	"github.com/hashicorp/consul/sdk/iptables"
 	"github.com/hashicorp/go-hclog"
 	"github.com/mitchellh/cli"
 	"github.com/mitchellh/mapstructure"
 
+	injectcommon "github.com/hashicorp/consul-k8s/control-plane/connect-inject/common"
 	"github.com/hashicorp/consul-k8s/control-plane/connect-inject/constants"
 	"github.com/hashicorp/consul-k8s/control-plane/consul"
 	"github.com/hashicorp/consul-k8s/control-plane/namespaces"
@@ -55,6 +58,8 @@ type Command struct {
 	flagProxyIDFile string // Location to write the output proxyID. Default is defaultProxyIDFile.
 	flagMultiPort   bool
 
+	flagEnableResourceAPIs bool // Wait for the workload instead of the service registration.
+
 	serviceRegistrationPollingAttempts uint64 // Number of times to poll for this service to be registered.
 
 	flagSet *flag.FlagSet
@@ -70,7 +75,8 @@ type Command struct {
 
 	// Only used in tests.
 	iptablesProvider iptables.Provider
-	iptablesConfig   iptables.Config
+	iptablesConfig   injectcommon.RedirectTrafficConfig
+	resourceClient   pbresource.ResourceServiceClient
 }
 
 func (c *Command) init() {
@@ -82,6 +88,8 @@ func (c *Command) init() {
 	c.flagSet.StringVar(&c.flagServiceName, "service-name", "", "Service name as specified via the pod annotation.")
 	c.flagSet.StringVar(&c.flagProxyIDFile, "proxy-id-file", defaultProxyIDFile, "File name where proxy's Consul service ID should be saved.")
 	c.flagSet.BoolVar(&c.flagMultiPort, "multiport", false, "If the pod is a multi port pod.")
+	c.flagSet.BoolVar(&c.flagEnableResourceAPIs, "enable-resource-apis", false,
+		"Wait for the pod's workload to be written using Consul resource APIs instead of its service registration.")
 	c.flagSet.StringVar(&c.flagGatewayKind, "gateway-kind", "", "Kind of gateway that is being registered: ingress-gateway, terminating-gateway, or mesh-gateway.")
 	c.flagSet.StringVar(&c.flagRedirectTrafficConfig, "redirect-traffic-config", os.Getenv("CONSUL_REDIRECT_TRAFFIC_CONFIG"), "Config (in JSON format) to configure iptables for this pod.")
 	c.flagSet.StringVar(&c.flagLogLevel, "log-level", "info",
@@ -175,7 +183,19 @@ func (c *Command) Run(args []string) int {
 		}
 	}
 	proxyService := &api.AgentService{}
-	if c.flagGatewayKind != "" {
+	if c.flagEnableResourceAPIs {
+		// The proxy of a workload uses the default ports, so traffic redirection does not need
+		// anything from a proxy registration.
+		proxyService.Proxy = &api.AgentServiceConnectProxyConfig{}
+		if c.resourceClient == nil {
+			c.resourceClient = pbresource.NewResourceServiceClient(state.GRPCConn)
+		}
+		err = backoff.Retry(c.getWorkload(ctx), backoff.WithMaxRetries(backoff.NewConstantBackOff(1*time.Second), c.serviceRegistrationPollingAttempts))
+		if err != nil {
+			c.logger.Error("Timed out waiting for workload", "error", err)
+			return 1
+		}
+	} else if c.flagGatewayKind != "" {
 		err = backoff.Retry(c.getGatewayRegistration(consulClient), backoff.WithMaxRetries(backoff.NewConstantBackOff(1*time.Second), c.serviceRegistrationPollingAttempts))
 		if err != nil {
 			c.logger.Error("Timed out waiting for gateway registration", "error", err)
@@ -247,7 +267,7 @@ func (c *Command) getConnectServiceRegistrations(consulClient *api.Client, proxy
 			}
 			if len(serviceList.Services) > 2 {
 				c.logger.Error("There are multiple Consul services registered for this pod when there must only be one." +
-					" Check if there are multiple Kubernetes services selecting this pod and add the label" +
+					" Check if there are multiple Kubernetes services selecting this pod and add the label or annotation" +
 					" `consul.hashicorp.com/service-ignore: \"true\"` to all services except the one used by Consul for handling requests.")
 			}
 
@@ -294,6 +314,27 @@ func (c *Command) getConnectServiceRegistrations(consulClient *api.Client, proxy
 	}
 }
 
+// getWorkload returns an operation that succeeds once the workload of the pod has been written.
+func (c *Command) getWorkload(ctx context.Context) backoff.Operation {
+	id := &pbresource.ID{
+		Name: c.flagPodName,
+		Type: pbcatalog.WorkloadType,
+		Tenancy: &pbresource.Tenancy{
+			Partition: constants.GetNormalizedConsulPartition(c.consul.Partition),
+			Namespace: constants.GetNormalizedConsulNamespace(c.consul.Namespace),
+		},
+	}
+	return func() error {
+		_, err := c.resourceClient.Read(ctx, &pbresource.ReadRequest{Id: id})
+		if err != nil {
+			c.logger.Info("Unable to read workload", "name", c.flagPodName, "error", err)
+			return err
+		}
+		c.logger.Info("Workload has been written", "name", c.flagPodName)
+		return nil
+	}
+}
+
 func (c *Command) getGatewayRegistration(client *api.Client) backoff.Operation {
 	var proxyID string
 	registrationRetryCount := 0
@@ -323,7 +364,7 @@ func (c *Command) getGatewayRegistration(client *api.Client) backoff.Operation {
 			}
 			if len(gatewayList.Services) > 1 {
 				c.logger.Error("There are multiple Consul gateway services registered for this pod when there must only be one." +
-					" Check if there are multiple Kubernetes services selecting this gateway pod and add the label" +
+					" Check if there are multiple Kubernetes services selecting this gateway pod and add the label or annotation" +
 					" `consul.hashicorp.com/service-ignore: \"true\"` to all services except the one used by Consul for handling requests.")
 			}
 			return fmt.Errorf("did not find correct number of gateways, found: %d, services: %+v", len(gatewayList.Services), gatewayList)
@@ -420,7 +461,7 @@ func (c *Command) applyTrafficRedirectionRules(svc *api.AgentService) error {
 	}
 
 	// Configure any relevant information from the proxy service
-	err = iptables.Setup(c.iptablesConfig)
+	err = iptables.SetupWithAdditionalRules(c.iptablesConfig.Config, c.iptablesConfig.AdditionalRules())
 	if err != nil {
 		return err
 	}
@@ -4,6 +4,7 @@
 package connectinit
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 	"math/rand"
@@ -15,13 +16,19 @@ import (
 	"time"
 
 	"github.com/hashicorp/consul/api"
+	"github.com/hashicorp/consul/proto-public/pbresource"
 	"github.com/hashicorp/consul/sdk/iptables"
 	"github.com/hashicorp/consul/sdk/testutil"
+	"github.com/hashicorp/go-hclog"
 	"github.com/mitchellh/cli"
 	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
 
 	"github.com/hashicorp/consul-k8s/control-plane/helper/test"
 	"github.com/hashicorp/consul-k8s/control-plane/subcommand/common"
+	"github.com/hashicorp/consul-k8s/control-plane/subcommand/flags"
 )
 
 const nodeName = "test-node"
@@ -885,7 +892,7 @@ func TestRun_TrafficRedirection(t *testing.T) {
 			require.Equal(t, 0, code, ui.ErrorWriter.String())
 			require.Truef(t, iptablesProvider.applyCalled, "redirect traffic rules were not applied")
 			if c.expIptablesParamsFunc != nil {
-				actualIptablesConfigParamsEqualExpected, errMsg := c.expIptablesParamsFunc(cmd.iptablesConfig)
+				actualIptablesConfigParamsEqualExpected, errMsg := c.expIptablesParamsFunc(cmd.iptablesConfig.Config)
 				require.Truef(t, actualIptablesConfigParamsEqualExpected, errMsg)
 			}
 		})
@@ -973,3 +980,43 @@ func (f *fakeIptablesProvider) ApplyRules() error {
 func (f *fakeIptablesProvider) Rules() []string {
 	return f.rules
 }
+
+func TestGetWorkload(t *testing.T) {
+	t.Parallel()
+	resourceClient := &fakeWorkloadClient{}
+	cmd := Command{
+		flagPodName:    testPodName,
+		consul:         &flags.ConsulFlags{},
+		logger:         hclog.NewNullLogger(),
+		resourceClient: resourceClient,
+	}
+	op := cmd.getWorkload(context.Background())
+
+	require.Error(t, op())
+	resourceClient.written = true
+	require.NoError(t, op())
+	require.Equal(t, &pbresource.ID{
+		Name: testPodName,
+		Type: &pbresource.Type{Group: "catalog", GroupVersion: "v2beta1", Kind: "Workload"},
+		Tenancy: &pbresource.Tenancy{
+			Partition: "default",
+			Namespace: "default",
+		},
+	}, resourceClient.readID)
+}
+
+// fakeWorkloadClient returns a workload once written is set.
+type fakeWorkloadClient struct {
+	pbresource.ResourceServiceClient
+
+	written bool
+	readID  *pbresource.ID
+}
+
+func (f *fakeWorkloadClient) Read(_ context.Context, req *pbresource.ReadRequest, _ ...grpc.CallOption) (*pbresource.ReadResponse, error) {
+	f.readID = req.Id
+	if !f.written {
+		return nil, status.Error(codes.NotFound, "resource not found")
+	}
+	return &pbresource.ReadResponse{Resource: &pbresource.Resource{Id: req.Id}}, nil
+}
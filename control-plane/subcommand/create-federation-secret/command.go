@@ -29,14 +29,6 @@ import (
 	"github.com/hashicorp/consul-k8s/control-plane/subcommand/flags"
 )
 
-const (
-	fedSecretGossipKey           = "gossipEncryptionKey"
-	fedSecretCACertKey           = "caCert"
-	fedSecretCAKeyKey            = "caKey"
-	fedSecretServerConfigKey     = "serverConfigJSON"
-	fedSecretReplicationTokenKey = "replicationToken"
-)
-
 var retryInterval = 1 * time.Second
 
 type Command struct {
@@ -150,7 +142,7 @@ func (c *Command) Run(args []string) int {
 			c.UI.Error(fmt.Sprintf("gossip key file %q was empty", c.flagGossipKeyFile))
 			return 1
 		}
-		federationSecret.Data[fedSecretGossipKey] = gossipKey
+		federationSecret.Data[common.FederationSecretGossipKey] = gossipKey
 		logger.Info("Gossip encryption key retrieved successfully")
 	}
 
@@ -161,7 +153,7 @@ func (c *Command) Run(args []string) int {
 		c.UI.Error(fmt.Sprintf("Error reading server CA cert file: %s", err))
 		return 1
 	}
-	federationSecret.Data[fedSecretCACertKey] = caCert
+	federationSecret.Data[common.FederationSecretCACertKey] = caCert
 	logger.Info("Server CA cert retrieved successfully")
 
 	// Add server CA key.
@@ -171,7 +163,7 @@ func (c *Command) Run(args []string) int {
 		c.UI.Error(fmt.Sprintf("Error reading server CA key file: %s", err))
 		return 1
 	}
-	federationSecret.Data[fedSecretCAKeyKey] = caKey
+	federationSecret.Data[common.FederationSecretCAKeyKey] = caKey
 	logger.Info("Server CA key retrieved successfully")
 
 	// Create the Kubernetes clientset.
@@ -197,7 +189,7 @@ func (c *Command) Run(args []string) int {
 			logger.Error("error retrieving replication token", "err", err)
 			return 1
 		}
-		federationSecret.Data[fedSecretReplicationTokenKey] = replicationToken
+		federationSecret.Data[common.FederationSecretReplicationTokenKey] = replicationToken
 	}
 
 	// Set up Consul client because we need to make calls to Consul to retrieve
@@ -244,7 +236,7 @@ func (c *Command) Run(args []string) int {
 		logger.Error("Unable to create server config json", "err", err)
 		return 1
 	}
-	federationSecret.Data[fedSecretServerConfigKey] = serverCfg
+	federationSecret.Data[common.FederationSecretServerConfigKey] = serverCfg
 
 	// Now create the Kubernetes secret.
 	logger.Info("Creating/updating Kubernetes secret", "name", federationSecret.ObjectMeta.Name, "ns", c.flagK8sNamespace)
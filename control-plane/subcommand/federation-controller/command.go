@@ -0,0 +1,265 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package federationcontroller
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"os/signal"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/hashicorp/consul/api"
+	"github.com/hashicorp/go-hclog"
+	"github.com/mitchellh/cli"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/tools/clientcmd"
+
+	"github.com/hashicorp/consul-k8s/control-plane/subcommand"
+	"github.com/hashicorp/consul-k8s/control-plane/subcommand/common"
+	"github.com/hashicorp/consul-k8s/control-plane/subcommand/flags"
+)
+
+const (
+	rolePrimary   = "primary"
+	roleSecondary = "secondary"
+
+	defaultSyncInterval       = 1 * time.Minute
+	defaultGatewayDialTimeout = 5 * time.Second
+)
+
+type Command struct {
+	UI    cli.Ui
+	flags *flag.FlagSet
+	k8s   *flags.K8SFlags
+	http  *flags.HTTPFlags
+
+	flagRole           string
+	flagResourcePrefix string
+	flagK8sNamespace   string
+	flagSyncInterval   time.Duration
+	flagLogLevel       string
+	flagLogJSON        bool
+
+	// Flags used in the primary datacenter to export the federation secret.
+	flagExportReplicationToken bool
+	flagGossipKeyFile          string
+	flagServerCACertFile       string
+	flagServerCAKeyFile        string
+	flagMeshGatewayServiceName string
+
+	// Flags used in secondary datacenters to import the federation secret.
+	flagPrimaryKubeConfigFile       string
+	flagPrimaryFederationSecretName string
+	flagPrimaryK8sNamespace         string
+	flagGatewayDialTimeout          time.Duration
+
+	k8sClient kubernetes.Interface
+	// primaryK8sClient is the client of the Kubernetes cluster of the primary datacenter.
+	primaryK8sClient kubernetes.Interface
+	consulClient     *api.Client
+	logger           hclog.Logger
+
+	once  sync.Once
+	help  string
+	sigCh chan os.Signal
+	ctx   context.Context
+}
+
+func (c *Command) init() {
+	c.flags = flag.NewFlagSet("", flag.ContinueOnError)
+
+	c.flags.StringVar(&c.flagRole, "role", "",
+		fmt.Sprintf("Role of this datacenter in the federation. One of %q or %q.", rolePrimary, roleSecondary))
+	c.flags.StringVar(&c.flagResourcePrefix, "resource-prefix", "",
+		"Prefix to use for Kubernetes resources. The federation secret is named '<resource-prefix>-federation' "+
+			"and the status ConfigMap '<resource-prefix>-federation-status'.")
+	c.flags.StringVar(&c.flagK8sNamespace, "k8s-namespace", "",
+		"Name of Kubernetes namespace where Consul is deployed.")
+	c.flags.DurationVar(&c.flagSyncInterval, "sync-interval", defaultSyncInterval,
+		"Interval between the syncs of the federation secret.")
+	c.flags.BoolVar(&c.flagExportReplicationToken, "export-replication-token", false,
+		"Set to true if the ACL replication token should be contained in the exported secret. "+
+			"If ACLs are enabled this should be set to true. Only used in the primary datacenter.")
+	c.flags.StringVar(&c.flagGossipKeyFile, "gossip-key-file", "",
+		"Location of a file containing the gossip encryption key. If not set, the exported secret won't have a "+
+			"gossip encryption key. Only used in the primary datacenter.")
+	c.flags.StringVar(&c.flagServerCACertFile, "server-ca-cert-file", "",
+		"Location of a file containing the servers' CA certificate. Only used in the primary datacenter.")
+	c.flags.StringVar(&c.flagServerCAKeyFile, "server-ca-key-file", "",
+		"Location of a file containing the servers' CA signing key. Only used in the primary datacenter.")
+	c.flags.StringVar(&c.flagMeshGatewayServiceName, "mesh-gateway-service-name", "",
+		"Name of the mesh gateway service registered into Consul. Only used in the primary datacenter.")
+	c.flags.StringVar(&c.flagPrimaryKubeConfigFile, "primary-kubeconfig-file", "",
+		"Location of a kubeconfig file of the Kubernetes cluster of the primary datacenter. "+
+			"Only used in secondary datacenters.")
+	c.flags.StringVar(&c.flagPrimaryFederationSecretName, "primary-federation-secret-name", "",
+		"Name of the federation secret in the primary datacenter. Defaults to '<resource-prefix>-federation'. "+
+			"Only used in secondary datacenters.")
+	c.flags.StringVar(&c.flagPrimaryK8sNamespace, "primary-k8s-namespace", "",
+		"Name of the Kubernetes namespace of the federation secret in the primary datacenter. Defaults to -k8s-namespace. "+
+			"Only used in secondary datacenters.")
+	c.flags.DurationVar(&c.flagGatewayDialTimeout, "gateway-dial-timeout", defaultGatewayDialTimeout,
+		"Timeout of the connections to the mesh gateways of the primary datacenter that verify they are reachable. "+
+			"Only used in secondary datacenters.")
+	c.flags.StringVar(&c.flagLogLevel, "log-level", "info",
+		"Log verbosity level. Supported values (in order of detail) are \"trace\", "+
+			"\"debug\", \"info\", \"warn\", and \"error\".")
+	c.flags.BoolVar(&c.flagLogJSON, "log-json", false,
+		"Enable or disable JSON output format for logging.")
+
+	c.http = &flags.HTTPFlags{}
+	c.k8s = &flags.K8SFlags{}
+	flags.Merge(c.flags, c.http.Flags())
+	flags.Merge(c.flags, c.k8s.Flags())
+	c.help = flags.Usage(help, c.flags)
+
+	// Wait on an interrupt or terminate to exit. This channel must be initialized before
+	// Run() is called so that there are no race conditions where the channel
+	// is not defined.
+	if c.sigCh == nil {
+		c.sigCh = make(chan os.Signal, 1)
+		signal.Notify(c.sigCh, syscall.SIGINT, syscall.SIGTERM)
+	}
+}
+
+// Run syncs the federation secret until it is stopped. In the primary datacenter
+// the secret is exported with the data secondary datacenters need to federate
+// with the primary. In secondary datacenters the secret is imported from the
+// Kubernetes cluster of the primary datacenter.
+func (c *Command) Run(args []string) int {
+	c.once.Do(c.init)
+
+	if err := c.validateFlags(args); err != nil {
+		c.UI.Error(err.Error())
+		return 1
+	}
+
+	if c.logger == nil {
+		var err error
+		c.logger, err = common.Logger(c.flagLogLevel, c.flagLogJSON)
+		if err != nil {
+			c.UI.Error(err.Error())
+			return 1
+		}
+	}
+
+	if c.ctx == nil {
+		c.ctx = context.Background()
+	}
+
+	if c.k8sClient == nil {
+		k8sCfg, err := subcommand.K8SConfig(c.k8s.KubeConfig())
+		if err != nil {
+			c.UI.Error(fmt.Sprintf("Error retrieving Kubernetes auth: %s", err))
+			return 1
+		}
+		c.k8sClient, err = kubernetes.NewForConfig(k8sCfg)
+		if err != nil {
+			c.UI.Error(fmt.Sprintf("Error initializing Kubernetes client: %s", err))
+			return 1
+		}
+	}
+
+	if c.flagRole == roleSecondary && c.primaryK8sClient == nil {
+		// Don't fall back to in-cluster auth like subcommand.K8SConfig does
+		// because that would import the secret from this cluster.
+		primaryCfg, err := clientcmd.BuildConfigFromFlags("", c.flagPrimaryKubeConfigFile)
+		if err != nil {
+			c.UI.Error(fmt.Sprintf("Error loading the kubeconfig of the primary datacenter: %s", err))
+			return 1
+		}
+		c.primaryK8sClient, err = kubernetes.NewForConfig(primaryCfg)
+		if err != nil {
+			c.UI.Error(fmt.Sprintf("Error initializing Kubernetes client of the primary datacenter: %s", err))
+			return 1
+		}
+	}
+
+	ticker := time.NewTicker(c.flagSyncInterval)
+	defer ticker.Stop()
+	for {
+		c.sync()
+
+		select {
+		case <-ticker.C:
+		case sig := <-c.sigCh:
+			c.logger.Info(fmt.Sprintf("%s received, shutting down", sig))
+			return 0
+		}
+	}
+}
+
+func (c *Command) validateFlags(args []string) error {
+	if err := c.flags.Parse(args); err != nil {
+		return err
+	}
+	if len(c.flags.Args()) > 0 {
+		return errors.New("should have no non-flag arguments")
+	}
+	if c.flagResourcePrefix == "" {
+		return errors.New("-resource-prefix must be set")
+	}
+	if c.flagK8sNamespace == "" {
+		return errors.New("-k8s-namespace must be set")
+	}
+	if c.flagSyncInterval <= 0 {
+		return errors.New("-sync-interval must be greater than 0")
+	}
+
+	switch c.flagRole {
+	case rolePrimary:
+		if c.flagServerCACertFile == "" {
+			return errors.New("-server-ca-cert-file must be set")
+		}
+		if c.flagServerCAKeyFile == "" {
+			return errors.New("-server-ca-key-file must be set")
+		}
+		if c.flagMeshGatewayServiceName == "" {
+			return errors.New("-mesh-gateway-service-name must be set")
+		}
+		if c.http.ConsulAPITimeout() <= 0 {
+			return errors.New("-consul-api-timeout must be set to a value greater than 0")
+		}
+	case roleSecondary:
+		if c.flagPrimaryKubeConfigFile == "" {
+			return errors.New("-primary-kubeconfig-file must be set")
+		}
+		if c.flagGatewayDialTimeout <= 0 {
+			return errors.New("-gateway-dial-timeout must be greater than 0")
+		}
+		if c.flagPrimaryFederationSecretName == "" {
+			c.flagPrimaryFederationSecretName = c.federationSecretName()
+		}
+		if c.flagPrimaryK8sNamespace == "" {
+			c.flagPrimaryK8sNamespace = c.flagK8sNamespace
+		}
+	default:
+		return fmt.Errorf("-role must be one of %q or %q", rolePrimary, roleSecondary)
+	}
+	return nil
+}
+
+func (c *Command) Synopsis() string { return synopsis }
+func (c *Command) Help() string {
+	c.once.Do(c.init)
+	return c.help
+}
+
+const synopsis = "Export or import the federation secret and report the federation status"
+const help = `
+Usage: consul-k8s-control-plane federation-controller [options]
+
+  Keeps the federation secret of WAN federated datacenters in sync. In the
+  primary datacenter it exports the secret with all the data secondary
+  datacenters need to federate with the primary. In secondary datacenters it
+  imports the secret from the Kubernetes cluster of the primary datacenter and
+  verifies that the mesh gateways of the primary datacenter are reachable.
+  The status of the federation is reported in a ConfigMap.
+
+`
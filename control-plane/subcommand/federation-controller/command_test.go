@@ -0,0 +1,314 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package federationcontroller
+
+import (
+	"context"
+	"encoding/json"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/hashicorp/go-hclog"
+	"github.com/mitchellh/cli"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+
+	"github.com/hashicorp/consul-k8s/control-plane/subcommand/common"
+)
+
+func TestRun_FlagValidation(t *testing.T) {
+	t.Parallel()
+
+	cases := []struct {
+		flags  []string
+		expErr string
+	}{
+		{
+			flags:  nil,
+			expErr: "-resource-prefix must be set",
+		},
+		{
+			flags:  []string{"-resource-prefix=prefix"},
+			expErr: "-k8s-namespace must be set",
+		},
+		{
+			flags:  []string{"-resource-prefix=prefix", "-k8s-namespace=default", "-sync-interval=0s"},
+			expErr: "-sync-interval must be greater than 0",
+		},
+		{
+			flags:  []string{"-resource-prefix=prefix", "-k8s-namespace=default"},
+			expErr: `-role must be one of "primary" or "secondary"`,
+		},
+		{
+			flags:  []string{"-resource-prefix=prefix", "-k8s-namespace=default", "-role=primary"},
+			expErr: "-server-ca-cert-file must be set",
+		},
+		{
+			flags:  []string{"-resource-prefix=prefix", "-k8s-namespace=default", "-role=primary", "-server-ca-cert-file=cert"},
+			expErr: "-server-ca-key-file must be set",
+		},
+		{
+			flags: []string{"-resource-prefix=prefix", "-k8s-namespace=default", "-role=primary", "-server-ca-cert-file=cert",
+				"-server-ca-key-file=key"},
+			expErr: "-mesh-gateway-service-name must be set",
+		},
+		{
+			flags: []string{"-resource-prefix=prefix", "-k8s-namespace=default", "-role=primary", "-server-ca-cert-file=cert",
+				"-server-ca-key-file=key", "-mesh-gateway-service-name=mesh-gateway", "-consul-api-timeout=0s"},
+			expErr: "-consul-api-timeout must be set to a value greater than 0",
+		},
+		{
+			flags:  []string{"-resource-prefix=prefix", "-k8s-namespace=default", "-role=secondary"},
+			expErr: "-primary-kubeconfig-file must be set",
+		},
+		{
+			flags: []string{"-resource-prefix=prefix", "-k8s-namespace=default", "-role=secondary",
+				"-primary-kubeconfig-file=kubeconfig", "-gateway-dial-timeout=0s"},
+			expErr: "-gateway-dial-timeout must be greater than 0",
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.expErr, func(t *testing.T) {
+			ui := cli.NewMockUi()
+			cmd := Command{
+				UI: ui,
+			}
+			exitCode := cmd.Run(c.flags)
+			require.Equal(t, 1, exitCode, ui.ErrorWriter.String())
+			require.Contains(t, ui.ErrorWriter.String(), c.expErr)
+		})
+	}
+}
+
+func TestRun_ExitsCleanlyOnSignals(t *testing.T) {
+	for name, sig := range map[string]os.Signal{"SIGINT": syscall.SIGINT, "SIGTERM": syscall.SIGTERM} {
+		t.Run(name, func(t *testing.T) {
+			ui := cli.NewMockUi()
+			cmd := Command{
+				UI:               ui,
+				k8sClient:        fake.NewSimpleClientset(),
+				primaryK8sClient: fake.NewSimpleClientset(),
+				sigCh:            make(chan os.Signal, 1),
+			}
+
+			exitCh := make(chan int, 1)
+			go func() {
+				exitCh <- cmd.Run([]string{
+					"-role=secondary",
+					"-resource-prefix=consul",
+					"-k8s-namespace=default",
+					"-primary-kubeconfig-file=kubeconfig",
+				})
+			}()
+			cmd.sigCh <- sig
+
+			select {
+			case exitCode := <-exitCh:
+				require.Equal(t, 0, exitCode, ui.ErrorWriter.String())
+			case <-time.After(5 * time.Second):
+				require.Fail(t, "timeout waiting for command to exit")
+			}
+		})
+	}
+}
+
+func TestSync_Primary(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	caCertFile := filepath.Join(dir, "ca.crt")
+	caKeyFile := filepath.Join(dir, "ca.key")
+	gossipKeyFile := filepath.Join(dir, "gossip.key")
+	require.NoError(t, os.WriteFile(caCertFile, []byte("ca-cert"), 0o600))
+	require.NoError(t, os.WriteFile(caKeyFile, []byte("ca-key"), 0o600))
+	require.NoError(t, os.WriteFile(gossipKeyFile, []byte("gossip-key"), 0o600))
+
+	consulServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/v1/agent/self":
+			require.Equal(t, "replication-token", r.Header.Get("X-Consul-Token"))
+			w.Write([]byte(`{"Config": {"Datacenter": "dc1"}}`))
+		case "/v1/catalog/service/mesh-gateway":
+			w.Write([]byte(`[
+				{"ServiceID": "mesh-gateway-1", "ServiceTaggedAddresses": {"wan": {"Address": "2.2.2.2", "Port": 443}}},
+				{"ServiceID": "mesh-gateway-2", "ServiceTaggedAddresses": {"wan": {"Address": "1.1.1.1", "Port": 443}}},
+				{"ServiceID": "mesh-gateway-3", "ServiceTaggedAddresses": {"wan": {"Address": "1.1.1.1", "Port": 443}}}
+			]`))
+		default:
+			t.Errorf("unexpected request %s", r.URL.Path)
+		}
+	}))
+	defer consulServer.Close()
+
+	k8s := fake.NewSimpleClientset()
+	cmd := Command{UI: cli.NewMockUi(), k8sClient: k8s}
+	cmd.init()
+	require.NoError(t, cmd.validateFlags([]string{
+		"-role=primary",
+		"-resource-prefix=consul",
+		"-k8s-namespace=default",
+		"-export-replication-token",
+		"-gossip-key-file", gossipKeyFile,
+		"-server-ca-cert-file", caCertFile,
+		"-server-ca-key-file", caKeyFile,
+		"-mesh-gateway-service-name=mesh-gateway",
+		"-http-addr", consulServer.URL,
+		"-consul-api-timeout=5s",
+	}))
+	cmd.logger = hclog.NewNullLogger()
+	cmd.ctx = context.Background()
+
+	// The replication token secret is only created once ACLs are bootstrapped.
+	cmd.sync()
+	status := federationStatusConfigMap(t, k8s)
+	require.Equal(t, "false", status.Data[statusSyncedKey])
+	require.Contains(t, status.Data[statusErrorKey], "error retrieving replication token secret consul-acl-replication-acl-token")
+
+	_, err := k8s.CoreV1().Secrets("default").Create(context.Background(), &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Name: "consul-acl-replication-acl-token"},
+		Data:       map[string][]byte{common.ACLTokenSecretKey: []byte("replication-token")},
+	}, metav1.CreateOptions{})
+	require.NoError(t, err)
+
+	cmd.sync()
+	status = federationStatusConfigMap(t, k8s)
+	require.Equal(t, map[string]string{
+		statusRoleKey:              "primary",
+		statusSyncedKey:            "true",
+		statusPrimaryDatacenterKey: "dc1",
+		statusPrimaryGatewaysKey:   "1.1.1.1:443,2.2.2.2:443",
+		statusLastSyncTimeKey:      status.Data[statusLastSyncTimeKey],
+	}, status.Data)
+
+	secret, err := k8s.CoreV1().Secrets("default").Get(context.Background(), "consul-federation", metav1.GetOptions{})
+	require.NoError(t, err)
+	require.Equal(t, common.CLILabelValue, secret.Labels[common.CLILabelKey])
+	require.Equal(t, map[string][]byte{
+		common.FederationSecretGossipKey:           []byte("gossip-key"),
+		common.FederationSecretCACertKey:           []byte("ca-cert"),
+		common.FederationSecretCAKeyKey:            []byte("ca-key"),
+		common.FederationSecretReplicationTokenKey: []byte("replication-token"),
+		common.FederationSecretServerConfigKey:     []byte(`{"primary_datacenter":"dc1","primary_gateways":["1.1.1.1:443","2.2.2.2:443"]}`),
+	}, secret.Data)
+
+	// A rotated CA is exported on the next sync.
+	require.NoError(t, os.WriteFile(caCertFile, []byte("rotated-ca-cert"), 0o600))
+	cmd.sync()
+	secret, err = k8s.CoreV1().Secrets("default").Get(context.Background(), "consul-federation", metav1.GetOptions{})
+	require.NoError(t, err)
+	require.Equal(t, []byte("rotated-ca-cert"), secret.Data[common.FederationSecretCACertKey])
+}
+
+func TestSync_Secondary(t *testing.T) {
+	t.Parallel()
+
+	reachable, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	defer reachable.Close()
+	unreachable, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	require.NoError(t, unreachable.Close())
+
+	cases := map[string]struct {
+		gateways       []string
+		expUnreachable string
+		expErr         string
+	}{
+		"all gateways reachable": {
+			gateways: []string{reachable.Addr().String()},
+		},
+		"some gateways unreachable": {
+			gateways:       []string{reachable.Addr().String(), unreachable.Addr().String()},
+			expUnreachable: unreachable.Addr().String(),
+		},
+		"no gateways reachable": {
+			gateways:       []string{unreachable.Addr().String()},
+			expUnreachable: unreachable.Addr().String(),
+			expErr:         "none of the mesh gateways of the primary datacenter are reachable",
+		},
+	}
+
+	for name, c := range cases {
+		t.Run(name, func(t *testing.T) {
+			serverCfg, err := json.Marshal(serverConfig{PrimaryDatacenter: "dc1", PrimaryGateways: c.gateways})
+			require.NoError(t, err)
+			primaryData := map[string][]byte{
+				common.FederationSecretCACertKey:       []byte("ca-cert"),
+				common.FederationSecretCAKeyKey:        []byte("ca-key"),
+				common.FederationSecretServerConfigKey: serverCfg,
+			}
+			primaryK8s := fake.NewSimpleClientset(&corev1.Secret{
+				ObjectMeta: metav1.ObjectMeta{Name: "primary-consul-federation", Namespace: "consul"},
+				Data:       primaryData,
+			})
+			k8s := fake.NewSimpleClientset()
+
+			cmd := Command{UI: cli.NewMockUi(), k8sClient: k8s, primaryK8sClient: primaryK8s}
+			cmd.init()
+			require.NoError(t, cmd.validateFlags([]string{
+				"-role=secondary",
+				"-resource-prefix=consul",
+				"-k8s-namespace=default",
+				"-primary-kubeconfig-file=kubeconfig",
+				"-primary-federation-secret-name=primary-consul-federation",
+				"-primary-k8s-namespace=consul",
+				"-gateway-dial-timeout=1s",
+			}))
+			cmd.logger = hclog.NewNullLogger()
+			cmd.ctx = context.Background()
+
+			cmd.sync()
+
+			// The secret is imported even if the gateways are unreachable.
+			secret, err := k8s.CoreV1().Secrets("default").Get(context.Background(), "consul-federation", metav1.GetOptions{})
+			require.NoError(t, err)
+			require.Equal(t, primaryData, secret.Data)
+
+			status := federationStatusConfigMap(t, k8s)
+			require.Equal(t, "secondary", status.Data[statusRoleKey])
+			require.Equal(t, "dc1", status.Data[statusPrimaryDatacenterKey])
+			require.Equal(t, c.expUnreachable, status.Data[statusUnreachableGatewaysKey])
+			require.Equal(t, c.expErr, status.Data[statusErrorKey])
+			require.Equal(t, c.expErr == "", status.Data[statusSyncedKey] == "true")
+		})
+	}
+}
+
+func TestSync_SecondaryWithoutPrimarySecret(t *testing.T) {
+	t.Parallel()
+
+	k8s := fake.NewSimpleClientset()
+	cmd := Command{UI: cli.NewMockUi(), k8sClient: k8s, primaryK8sClient: fake.NewSimpleClientset()}
+	cmd.init()
+	require.NoError(t, cmd.validateFlags([]string{
+		"-role=secondary",
+		"-resource-prefix=consul",
+		"-k8s-namespace=default",
+		"-primary-kubeconfig-file=kubeconfig",
+	}))
+	cmd.logger = hclog.NewNullLogger()
+	cmd.ctx = context.Background()
+
+	cmd.sync()
+
+	status := federationStatusConfigMap(t, k8s)
+	require.Equal(t, "false", status.Data[statusSyncedKey])
+	require.Contains(t, status.Data[statusErrorKey], "error retrieving federation secret default/consul-federation of the primary datacenter")
+}
+
+func federationStatusConfigMap(t *testing.T, k8s *fake.Clientset) *corev1.ConfigMap {
+	t.Helper()
+	configMap, err := k8s.CoreV1().ConfigMaps("default").Get(context.Background(), "consul-federation-status", metav1.GetOptions{})
+	require.NoError(t, err)
+	return configMap
+}
@@ -0,0 +1,315 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package federationcontroller
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/hashicorp/consul/api"
+	corev1 "k8s.io/api/core/v1"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/hashicorp/consul-k8s/control-plane/consul"
+	"github.com/hashicorp/consul-k8s/control-plane/subcommand/common"
+)
+
+// The keys of the status ConfigMap.
+const (
+	statusRoleKey                = "role"
+	statusSyncedKey              = "synced"
+	statusPrimaryDatacenterKey   = "primaryDatacenter"
+	statusPrimaryGatewaysKey     = "primaryGateways"
+	statusUnreachableGatewaysKey = "unreachableGateways"
+	statusLastSyncTimeKey        = "lastSyncTime"
+	statusErrorKey               = "error"
+)
+
+// serverConfig is the Consul server config in the federation secret that
+// configures the servers of secondary datacenters to federate with the primary.
+type serverConfig struct {
+	PrimaryDatacenter string   `json:"primary_datacenter"`
+	PrimaryGateways   []string `json:"primary_gateways"`
+}
+
+// federationStatus is the result of a sync of the federation secret.
+type federationStatus struct {
+	primaryDatacenter string
+	primaryGateways   []string
+	// unreachableGateways are the mesh gateways of the primary datacenter that
+	// couldn't be reached. It is only set in secondary datacenters.
+	unreachableGateways []string
+}
+
+// sync exports or imports the federation secret and writes the status ConfigMap.
+// Errors are logged and reported in the status so that they are retried on the
+// next sync.
+func (c *Command) sync() {
+	var status federationStatus
+	var err error
+	if c.flagRole == rolePrimary {
+		status, err = c.exportFederationSecret()
+	} else {
+		status, err = c.importFederationSecret()
+	}
+	if err != nil {
+		c.logger.Error("Error syncing the federation secret", "err", err)
+	} else {
+		c.logger.Info("Synced the federation secret", "name", c.federationSecretName(), "ns", c.flagK8sNamespace)
+	}
+
+	if err := c.updateStatus(status, err); err != nil {
+		c.logger.Error("Error updating the federation status", "err", err)
+	}
+}
+
+// exportFederationSecret creates or updates the federation secret with the data
+// secondary datacenters need to federate with this datacenter.
+func (c *Command) exportFederationSecret() (federationStatus, error) {
+	data := make(map[string][]byte)
+
+	if c.flagGossipKeyFile != "" {
+		gossipKey, err := os.ReadFile(c.flagGossipKeyFile)
+		if err != nil {
+			return federationStatus{}, fmt.Errorf("error reading gossip encryption key file: %w", err)
+		}
+		if len(gossipKey) == 0 {
+			return federationStatus{}, fmt.Errorf("gossip key file %q was empty", c.flagGossipKeyFile)
+		}
+		data[common.FederationSecretGossipKey] = gossipKey
+	}
+
+	caCert, err := os.ReadFile(c.flagServerCACertFile)
+	if err != nil {
+		return federationStatus{}, fmt.Errorf("error reading server CA cert file: %w", err)
+	}
+	data[common.FederationSecretCACertKey] = caCert
+
+	caKey, err := os.ReadFile(c.flagServerCAKeyFile)
+	if err != nil {
+		return federationStatus{}, fmt.Errorf("error reading server CA key file: %w", err)
+	}
+	data[common.FederationSecretCAKeyKey] = caKey
+
+	var replicationToken []byte
+	if c.flagExportReplicationToken {
+		secretName := fmt.Sprintf("%s-%s-acl-token", c.flagResourcePrefix, common.ACLReplicationTokenName)
+		secret, err := c.k8sClient.CoreV1().Secrets(c.flagK8sNamespace).Get(c.ctx, secretName, metav1.GetOptions{})
+		if err != nil {
+			// The secret is only created once ACL bootstrapping is complete.
+			return federationStatus{}, fmt.Errorf("error retrieving replication token secret %s: %w", secretName, err)
+		}
+		var ok bool
+		replicationToken, ok = secret.Data[common.ACLTokenSecretKey]
+		if !ok {
+			return federationStatus{}, fmt.Errorf("expected key '%s' in secret %s not set", common.ACLTokenSecretKey, secretName)
+		}
+		data[common.FederationSecretReplicationTokenKey] = replicationToken
+	}
+
+	if c.consulClient == nil {
+		cfg := api.DefaultConfig()
+		// Use the replication token for our ACL token. If ACLs are disabled,
+		// this will be empty which won't matter because ACLs are disabled.
+		cfg.Token = string(replicationToken)
+		c.http.MergeOntoConfig(cfg)
+		c.consulClient, err = consul.NewClient(cfg, c.http.ConsulAPITimeout())
+		if err != nil {
+			return federationStatus{}, fmt.Errorf("error creating consul client: %w", err)
+		}
+	}
+
+	datacenter, err := c.consulDatacenter()
+	if err != nil {
+		return federationStatus{}, fmt.Errorf("error retrieving current datacenter: %w", err)
+	}
+	gateways, err := c.meshGatewayAddrs()
+	if err != nil {
+		return federationStatus{}, fmt.Errorf("error looking up mesh gateways: %w", err)
+	}
+	status := federationStatus{primaryDatacenter: datacenter, primaryGateways: gateways}
+
+	serverCfg, err := json.Marshal(serverConfig{PrimaryDatacenter: datacenter, PrimaryGateways: gateways})
+	if err != nil {
+		return status, fmt.Errorf("error creating server config json: %w", err)
+	}
+	data[common.FederationSecretServerConfigKey] = serverCfg
+
+	return status, c.upsertFederationSecret(data)
+}
+
+// importFederationSecret copies the federation secret from the Kubernetes cluster of
+// the primary datacenter and verifies that the mesh gateways of the primary datacenter
+// are reachable.
+func (c *Command) importFederationSecret() (federationStatus, error) {
+	primarySecret, err := c.primaryK8sClient.CoreV1().Secrets(c.flagPrimaryK8sNamespace).Get(c.ctx, c.flagPrimaryFederationSecretName, metav1.GetOptions{})
+	if err != nil {
+		return federationStatus{}, fmt.Errorf("error retrieving federation secret %s/%s of the primary datacenter: %w",
+			c.flagPrimaryK8sNamespace, c.flagPrimaryFederationSecretName, err)
+	}
+
+	var serverCfg serverConfig
+	if err := json.Unmarshal(primarySecret.Data[common.FederationSecretServerConfigKey], &serverCfg); err != nil {
+		return federationStatus{}, fmt.Errorf("error decoding key '%s' of the federation secret: %w", common.FederationSecretServerConfigKey, err)
+	}
+	status := federationStatus{primaryDatacenter: serverCfg.PrimaryDatacenter, primaryGateways: serverCfg.PrimaryGateways}
+
+	// Import the secret even if the gateways aren't reachable because the servers
+	// can't start without it and keep retrying to reach the primary datacenter.
+	if err := c.upsertFederationSecret(primarySecret.Data); err != nil {
+		return status, err
+	}
+
+	for _, gateway := range serverCfg.PrimaryGateways {
+		conn, err := net.DialTimeout("tcp", gateway, c.flagGatewayDialTimeout)
+		if err != nil {
+			c.logger.Warn("Mesh gateway of the primary datacenter is unreachable", "address", gateway, "err", err)
+			status.unreachableGateways = append(status.unreachableGateways, gateway)
+			continue
+		}
+		_ = conn.Close()
+	}
+	if len(serverCfg.PrimaryGateways) > 0 && len(status.unreachableGateways) == len(serverCfg.PrimaryGateways) {
+		return status, errors.New("none of the mesh gateways of the primary datacenter are reachable")
+	}
+	return status, nil
+}
+
+// upsertFederationSecret creates the federation secret or updates it if its data changed.
+func (c *Command) upsertFederationSecret(data map[string][]byte) error {
+	secret, err := c.k8sClient.CoreV1().Secrets(c.flagK8sNamespace).Get(c.ctx, c.federationSecretName(), metav1.GetOptions{})
+	if k8serrors.IsNotFound(err) {
+		_, err = c.k8sClient.CoreV1().Secrets(c.flagK8sNamespace).Create(c.ctx, &corev1.Secret{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      c.federationSecretName(),
+				Namespace: c.flagK8sNamespace,
+				Labels:    map[string]string{common.CLILabelKey: common.CLILabelValue},
+			},
+			Type: corev1.SecretTypeOpaque,
+			Data: data,
+		}, metav1.CreateOptions{})
+		if err != nil {
+			return fmt.Errorf("error creating federation secret: %w", err)
+		}
+		c.logger.Info("Created federation secret", "name", c.federationSecretName(), "ns", c.flagK8sNamespace)
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("error retrieving federation secret: %w", err)
+	}
+
+	if secretDataEqual(secret.Data, data) {
+		return nil
+	}
+	secret.Data = data
+	if _, err := c.k8sClient.CoreV1().Secrets(c.flagK8sNamespace).Update(c.ctx, secret, metav1.UpdateOptions{}); err != nil {
+		return fmt.Errorf("error updating federation secret: %w", err)
+	}
+	c.logger.Info("Updated federation secret", "name", c.federationSecretName(), "ns", c.flagK8sNamespace)
+	return nil
+}
+
+// updateStatus writes the result of a sync to the status ConfigMap.
+func (c *Command) updateStatus(status federationStatus, syncErr error) error {
+	data := map[string]string{
+		statusRoleKey:              c.flagRole,
+		statusSyncedKey:            strconv.FormatBool(syncErr == nil),
+		statusPrimaryDatacenterKey: status.primaryDatacenter,
+		statusPrimaryGatewaysKey:   strings.Join(status.primaryGateways, ","),
+		statusLastSyncTimeKey:      time.Now().UTC().Format(time.RFC3339),
+	}
+	if c.flagRole == roleSecondary {
+		data[statusUnreachableGatewaysKey] = strings.Join(status.unreachableGateways, ",")
+	}
+	if syncErr != nil {
+		data[statusErrorKey] = syncErr.Error()
+	}
+
+	name := c.flagResourcePrefix + "-federation-status"
+	configMap, err := c.k8sClient.CoreV1().ConfigMaps(c.flagK8sNamespace).Get(c.ctx, name, metav1.GetOptions{})
+	if k8serrors.IsNotFound(err) {
+		_, err = c.k8sClient.CoreV1().ConfigMaps(c.flagK8sNamespace).Create(c.ctx, &corev1.ConfigMap{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      name,
+				Namespace: c.flagK8sNamespace,
+				Labels:    map[string]string{common.CLILabelKey: common.CLILabelValue},
+			},
+			Data: data,
+		}, metav1.CreateOptions{})
+		return err
+	}
+	if err != nil {
+		return err
+	}
+	configMap.Data = data
+	_, err = c.k8sClient.CoreV1().ConfigMaps(c.flagK8sNamespace).Update(c.ctx, configMap, metav1.UpdateOptions{})
+	return err
+}
+
+// consulDatacenter returns the current datacenter.
+func (c *Command) consulDatacenter() (string, error) {
+	agentCfg, err := c.consulClient.Agent().Self()
+	if err != nil {
+		return "", err
+	}
+	dc, ok := agentCfg["Config"]["Datacenter"].(string)
+	if !ok || dc == "" {
+		return "", fmt.Errorf("/agent/self response did not contain Config.Datacenter: %s", agentCfg)
+	}
+	return dc, nil
+}
+
+// meshGatewayAddrs returns the sorted unique WAN addresses of all service
+// instances of the mesh gateway service.
+func (c *Command) meshGatewayAddrs() ([]string, error) {
+	meshGWSvcs, _, err := c.consulClient.Catalog().Service(c.flagMeshGatewayServiceName, "", nil)
+	if err != nil {
+		return nil, err
+	}
+	if len(meshGWSvcs) == 0 {
+		return nil, fmt.Errorf("no instances of mesh gateway service %q found", c.flagMeshGatewayServiceName)
+	}
+
+	unique := make(map[string]bool)
+	var addrs []string
+	for _, svc := range meshGWSvcs {
+		addr, ok := svc.ServiceTaggedAddresses["wan"]
+		if !ok {
+			return nil, fmt.Errorf("no 'wan' key found in tagged addresses for service instance %q", svc.ServiceID)
+		}
+		gateway := net.JoinHostPort(addr.Address, strconv.Itoa(addr.Port))
+		if !unique[gateway] {
+			unique[gateway] = true
+			addrs = append(addrs, gateway)
+		}
+	}
+	// Sort the addresses so that the secret only changes if the gateways change.
+	sort.Strings(addrs)
+	return addrs, nil
+}
+
+func (c *Command) federationSecretName() string {
+	return c.flagResourcePrefix + "-federation"
+}
+
+func secretDataEqual(a, b map[string][]byte) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for k, v := range a {
+		if other, ok := b[k]; !ok || !bytes.Equal(v, other) {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,257 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package fetchserverexternaladdress
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"net"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/hashicorp/go-hclog"
+	"github.com/mitchellh/cli"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/rest"
+	"k8s.io/client-go/tools/clientcmd"
+
+	"github.com/hashicorp/consul-k8s/control-plane/subcommand/common"
+	"github.com/hashicorp/consul-k8s/control-plane/subcommand/flags"
+)
+
+// pollInterval is how often the Service is read while its load balancer is provisioned.
+const pollInterval = 2 * time.Second
+
+// The fetch-server-external-address command writes the external address of the external Service of a
+// Consul server as the LAN and WAN addresses the server advertises.
+type Command struct {
+	UI cli.Ui
+
+	flagLogLevel    string
+	flagLogJSON     bool
+	flagServiceName string
+	flagNamespace   string
+	flagNodeName    string
+	flagOutputFile  string
+	flagTimeout     time.Duration
+
+	flagSet *flag.FlagSet
+	k8s     *flags.K8SFlags
+
+	once   sync.Once
+	help   string
+	logger hclog.Logger
+
+	// for testing
+	clientset    kubernetes.Interface
+	lookupIP     func(ctx context.Context, network, host string) ([]net.IP, error)
+	pollInterval time.Duration
+}
+
+type Config struct {
+	AdvertiseAddr    string `json:"advertise_addr"`
+	AdvertiseAddrWAN string `json:"advertise_addr_wan"`
+}
+
+func (c *Command) init() {
+	c.flagSet = flag.NewFlagSet("", flag.ContinueOnError)
+	c.flagSet.StringVar(&c.flagLogLevel, "log-level", "info",
+		"Log verbosity level. Supported values (in order of detail) are \"trace\", "+
+			"\"debug\", \"info\", \"warn\", and \"error\".")
+	c.flagSet.BoolVar(&c.flagLogJSON, "log-json", false,
+		"Enable or disable JSON output format for logging.")
+	c.flagSet.StringVar(&c.flagServiceName, "service-name", "",
+		"The name of the LoadBalancer or NodePort Service that exposes the Consul server outside the cluster.")
+	c.flagSet.StringVar(&c.flagNamespace, "namespace", "",
+		"The Kubernetes namespace of the Service.")
+	c.flagSet.StringVar(&c.flagNodeName, "node-name", "",
+		"The name of the Kubernetes node of the Consul server. Required for NodePort Services.")
+	c.flagSet.StringVar(&c.flagOutputFile, "output-file", "",
+		"The file path for writing the advertised addresses portion of a Consul agent configuration to.")
+	c.flagSet.DurationVar(&c.flagTimeout, "timeout", 5*time.Minute,
+		"How long to wait for the external address of the Service, formatted as a time.Duration.")
+
+	c.k8s = &flags.K8SFlags{}
+	flags.Merge(c.flagSet, c.k8s.Flags())
+
+	c.help = flags.Usage(help, c.flagSet)
+}
+
+func (c *Command) Run(args []string) int {
+	var err error
+	c.once.Do(c.init)
+
+	if err := c.flagSet.Parse(args); err != nil {
+		return 1
+	}
+
+	if c.logger == nil {
+		c.logger, err = common.Logger(c.flagLogLevel, c.flagLogJSON)
+		if err != nil {
+			c.UI.Error(err.Error())
+			return 1
+		}
+	}
+
+	if c.flagServiceName == "" {
+		c.UI.Error("-service-name is required")
+		return 1
+	}
+	if c.flagNamespace == "" {
+		c.UI.Error("-namespace is required")
+		return 1
+	}
+	if c.flagOutputFile == "" {
+		c.UI.Error("-output-file is required")
+		return 1
+	}
+
+	if c.clientset == nil {
+		config, err := rest.InClusterConfig()
+		if err != nil {
+			// This just allows us to test it locally.
+			kubeconfig := clientcmd.RecommendedHomeFile
+			config, err = clientcmd.BuildConfigFromFlags("", kubeconfig)
+			if err != nil {
+				c.UI.Error(err.Error())
+				return 1
+			}
+		}
+
+		c.clientset, err = kubernetes.NewForConfig(config)
+		if err != nil {
+			c.UI.Error(err.Error())
+			return 1
+		}
+	}
+	if c.lookupIP == nil {
+		c.lookupIP = net.DefaultResolver.LookupIP
+	}
+	if c.pollInterval == 0 {
+		c.pollInterval = pollInterval
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), c.flagTimeout)
+	defer cancel()
+	address, err := c.fetchExternalAddress(ctx)
+	if err != nil {
+		c.UI.Error(fmt.Sprintf("Error fetching external address of Service %s/%s: %s", c.flagNamespace, c.flagServiceName, err))
+		return 1
+	}
+	c.logger.Info("advertising external address as LAN and WAN address", "service", c.flagServiceName, "address", address)
+
+	jsonData, err := json.Marshal(Config{AdvertiseAddr: address, AdvertiseAddrWAN: address})
+	if err != nil {
+		c.UI.Error(err.Error())
+		return 1
+	}
+
+	err = os.WriteFile(c.flagOutputFile, jsonData, 0644)
+	if err != nil {
+		c.UI.Error(fmt.Sprintf("Error writing external address file: %s", err))
+		return 1
+	}
+
+	return 0
+}
+
+// fetchExternalAddress returns the external IP address of the Service. For a LoadBalancer Service, it
+// waits until the load balancer has an ingress and returns its IP address. Consul only advertises IP
+// addresses, so the hostname of a load balancer is resolved. For a NodePort Service, it returns the
+// address of the node of the server.
+func (c *Command) fetchExternalAddress(ctx context.Context) (string, error) {
+	for {
+		svc, err := c.clientset.CoreV1().Services(c.flagNamespace).Get(ctx, c.flagServiceName, metav1.GetOptions{})
+		if err != nil {
+			c.logger.Error("unable to get Service, retrying", "error", err)
+		} else if svc.Spec.Type == corev1.ServiceTypeNodePort {
+			if c.flagNodeName == "" {
+				return "", errors.New("-node-name is required for NodePort Services")
+			}
+			address, err := c.nodeAddress(ctx)
+			if err == nil {
+				return address, nil
+			}
+			c.logger.Error("unable to get node address, retrying", "node", c.flagNodeName, "error", err)
+		} else if svc.Spec.Type != corev1.ServiceTypeLoadBalancer {
+			return "", fmt.Errorf("type of the Service is %s, only %s and %s Services have an external address",
+				svc.Spec.Type, corev1.ServiceTypeLoadBalancer, corev1.ServiceTypeNodePort)
+		} else {
+			for _, ingress := range svc.Status.LoadBalancer.Ingress {
+				if ingress.IP != "" {
+					return ingress.IP, nil
+				}
+				if ingress.Hostname != "" {
+					ip, err := c.resolve(ctx, ingress.Hostname)
+					if err == nil {
+						return ip, nil
+					}
+					c.logger.Error("unable to resolve load balancer hostname, retrying", "hostname", ingress.Hostname, "error", err)
+				}
+			}
+			c.logger.Info("waiting for the load balancer of the Service to be provisioned", "service", c.flagServiceName)
+		}
+
+		select {
+		case <-ctx.Done():
+			return "", fmt.Errorf("timed out waiting for an external address: %w", ctx.Err())
+		case <-time.After(c.pollInterval):
+		}
+	}
+}
+
+// nodeAddress returns the external IP address of the node, or its internal IP address if it has none.
+func (c *Command) nodeAddress(ctx context.Context) (string, error) {
+	node, err := c.clientset.CoreV1().Nodes().Get(ctx, c.flagNodeName, metav1.GetOptions{})
+	if err != nil {
+		return "", err
+	}
+	for _, addressType := range []corev1.NodeAddressType{corev1.NodeExternalIP, corev1.NodeInternalIP} {
+		for _, address := range node.Status.Addresses {
+			if address.Type == addressType && address.Address != "" {
+				return address.Address, nil
+			}
+		}
+	}
+	return "", errors.New("node has no IP addresses")
+}
+
+// resolve returns the first IPv4 address of the hostname, or its first IPv6 address if it has none.
+func (c *Command) resolve(ctx context.Context, hostname string) (string, error) {
+	ips, err := c.lookupIP(ctx, "ip", hostname)
+	if err != nil {
+		return "", err
+	}
+	if len(ips) == 0 {
+		return "", errors.New("no IP addresses")
+	}
+	for _, ip := range ips {
+		if ip.To4() != nil {
+			return ip.String(), nil
+		}
+	}
+	return ips[0].String(), nil
+}
+
+func (c *Command) Synopsis() string { return synopsis }
+func (c *Command) Help() string {
+	c.once.Do(c.init)
+	return c.help
+}
+
+const synopsis = "Fetch the external address of a Consul server from its LoadBalancer or NodePort Service."
+const help = `
+Usage: consul-k8s-control-plane fetch-server-external-address [options]
+
+  Fetch the external address of the external Service of a Consul server, the
+  address of its load balancer or of the server's node, and write it as the LAN
+  and WAN addresses the server advertises.
+  Not intended for stand-alone use.
+`
@@ -0,0 +1,190 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package fetchserverexternaladdress
+
+import (
+	"context"
+	"errors"
+	"net"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/mitchellh/cli"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+func TestRun_FlagValidation(t *testing.T) {
+	t.Parallel()
+
+	cases := map[string]struct {
+		args []string
+		err  string
+	}{
+		"missing service name": {
+			args: []string{},
+			err:  "-service-name is required",
+		},
+		"missing namespace": {
+			args: []string{"-service-name", "consul-server-0-external"},
+			err:  "-namespace is required",
+		},
+		"missing output-file": {
+			args: []string{"-service-name", "consul-server-0-external", "-namespace", "consul"},
+			err:  "-output-file is required",
+		},
+	}
+
+	for n, c := range cases {
+		c := c
+		t.Run(n, func(t *testing.T) {
+			ui := cli.NewMockUi()
+			cmd := Command{UI: ui}
+			responseCode := cmd.Run(c.args)
+			require.Equal(t, 1, responseCode, ui.ErrorWriter.String())
+			require.Contains(t, ui.ErrorWriter.String(), c.err)
+		})
+	}
+}
+
+func TestRun(t *testing.T) {
+	t.Parallel()
+
+	cases := map[string]struct {
+		svcType       corev1.ServiceType
+		ingress       []corev1.LoadBalancerIngress
+		nodeAddresses []corev1.NodeAddress
+		expected      string
+		expErr        string
+	}{
+		"load balancer IP": {
+			svcType:  corev1.ServiceTypeLoadBalancer,
+			ingress:  []corev1.LoadBalancerIngress{{IP: "1.2.3.4"}},
+			expected: `{"advertise_addr":"1.2.3.4","advertise_addr_wan":"1.2.3.4"}`,
+		},
+		"load balancer hostname is resolved to its IPv4 address": {
+			svcType:  corev1.ServiceTypeLoadBalancer,
+			ingress:  []corev1.LoadBalancerIngress{{Hostname: "lb.example.com"}},
+			expected: `{"advertise_addr":"5.6.7.8","advertise_addr_wan":"5.6.7.8"}`,
+		},
+		"unresolvable hostnames are skipped": {
+			svcType:  corev1.ServiceTypeLoadBalancer,
+			ingress:  []corev1.LoadBalancerIngress{{Hostname: "missing.example.com"}, {IP: "1.2.3.4"}},
+			expected: `{"advertise_addr":"1.2.3.4","advertise_addr_wan":"1.2.3.4"}`,
+		},
+		"load balancer not provisioned": {
+			svcType: corev1.ServiceTypeLoadBalancer,
+			expErr:  "timed out waiting for an external address",
+		},
+		"node port uses the external IP of the node": {
+			svcType: corev1.ServiceTypeNodePort,
+			nodeAddresses: []corev1.NodeAddress{
+				{Type: corev1.NodeInternalIP, Address: "10.0.0.1"},
+				{Type: corev1.NodeExternalIP, Address: "1.2.3.4"},
+			},
+			expected: `{"advertise_addr":"1.2.3.4","advertise_addr_wan":"1.2.3.4"}`,
+		},
+		"node port falls back to the internal IP of the node": {
+			svcType:       corev1.ServiceTypeNodePort,
+			nodeAddresses: []corev1.NodeAddress{{Type: corev1.NodeInternalIP, Address: "10.0.0.1"}},
+			expected:      `{"advertise_addr":"10.0.0.1","advertise_addr_wan":"10.0.0.1"}`,
+		},
+		"node port without node addresses": {
+			svcType: corev1.ServiceTypeNodePort,
+			expErr:  "timed out waiting for an external address",
+		},
+		"cluster IP": {
+			svcType: corev1.ServiceTypeClusterIP,
+			expErr:  "type of the Service is ClusterIP, only LoadBalancer and NodePort Services have an external address",
+		},
+	}
+
+	for n, c := range cases {
+		c := c
+		t.Run(n, func(t *testing.T) {
+			outputFile, err := os.CreateTemp("", "external-address")
+			require.NoError(t, err)
+			t.Cleanup(func() {
+				os.RemoveAll(outputFile.Name())
+			})
+
+			svc := &corev1.Service{
+				ObjectMeta: metav1.ObjectMeta{Name: "consul-server-0-external", Namespace: "consul"},
+				Spec:       corev1.ServiceSpec{Type: c.svcType},
+				Status: corev1.ServiceStatus{
+					LoadBalancer: corev1.LoadBalancerStatus{Ingress: c.ingress},
+				},
+			}
+			node := &corev1.Node{
+				ObjectMeta: metav1.ObjectMeta{Name: "node-1"},
+				Status:     corev1.NodeStatus{Addresses: c.nodeAddresses},
+			}
+			ui := cli.NewMockUi()
+			cmd := Command{
+				UI:        ui,
+				clientset: fake.NewSimpleClientset(svc, node),
+				lookupIP: func(_ context.Context, _, host string) ([]net.IP, error) {
+					if host == "lb.example.com" {
+						return []net.IP{net.ParseIP("2001:db8::1"), net.ParseIP("5.6.7.8")}, nil
+					}
+					return nil, errors.New("no such host")
+				},
+				pollInterval: 10 * time.Millisecond,
+			}
+			code := cmd.Run([]string{
+				"-service-name", "consul-server-0-external",
+				"-namespace", "consul",
+				"-node-name", "node-1",
+				"-output-file", outputFile.Name(),
+				"-timeout", "100ms",
+			})
+			if c.expErr != "" {
+				require.Equal(t, 1, code)
+				require.Contains(t, ui.ErrorWriter.String(), c.expErr)
+				return
+			}
+			require.Equal(t, 0, code, ui.ErrorWriter.String())
+			cfg, err := os.ReadFile(outputFile.Name())
+			require.NoError(t, err)
+			require.JSONEq(t, c.expected, string(cfg))
+		})
+	}
+}
+
+// Test that the command waits for the load balancer to be provisioned.
+func TestRun_WaitsForLoadBalancer(t *testing.T) {
+	t.Parallel()
+
+	outputFile, err := os.CreateTemp("", "external-address")
+	require.NoError(t, err)
+	t.Cleanup(func() {
+		os.RemoveAll(outputFile.Name())
+	})
+
+	svc := &corev1.Service{
+		ObjectMeta: metav1.ObjectMeta{Name: "consul-server-0-external", Namespace: "consul"},
+		Spec:       corev1.ServiceSpec{Type: corev1.ServiceTypeLoadBalancer},
+	}
+	clientset := fake.NewSimpleClientset(svc)
+	go func() {
+		time.Sleep(50 * time.Millisecond)
+		svc.Status.LoadBalancer.Ingress = []corev1.LoadBalancerIngress{{IP: "1.2.3.4"}}
+		_, _ = clientset.CoreV1().Services("consul").UpdateStatus(context.Background(), svc, metav1.UpdateOptions{})
+	}()
+
+	ui := cli.NewMockUi()
+	cmd := Command{UI: ui, clientset: clientset, pollInterval: 10 * time.Millisecond}
+	code := cmd.Run([]string{
+		"-service-name", "consul-server-0-external",
+		"-namespace", "consul",
+		"-output-file", outputFile.Name(),
+	})
+	require.Equal(t, 0, code, ui.ErrorWriter.String())
+	cfg, err := os.ReadFile(outputFile.Name())
+	require.NoError(t, err)
+	require.JSONEq(t, `{"advertise_addr":"1.2.3.4","advertise_addr_wan":"1.2.3.4"}`, string(cfg))
+}
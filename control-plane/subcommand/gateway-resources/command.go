@@ -25,6 +25,7 @@ import (
 	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 	gwv1beta1 "sigs.k8s.io/gateway-api/apis/v1beta1"
+	k8syaml "sigs.k8s.io/yaml"
 
 	"github.com/hashicorp/consul-k8s/control-plane/api-gateway/common"
 	"github.com/hashicorp/consul-k8s/control-plane/api/v1alpha1"
@@ -74,17 +75,22 @@ type Command struct {
 	flagGatewayClassName       string
 	flagGatewayClassConfigName string
 
-	flagServiceType                string
-	flagDeploymentDefaultInstances int
-	flagDeploymentMaxInstances     int
-	flagDeploymentMinInstances     int
+	flagServiceType                  string
+	flagServiceLoadBalancerClass     string
+	flagServiceExternalTrafficPolicy string
+	flagDeploymentDefaultInstances   int
+	flagDeploymentMaxInstances       int
+	flagDeploymentMinInstances       int
 
 	flagResourceConfigFileLocation string
 	flagGatewayConfigLocation      string
 
 	flagNodeSelector       string // this is a yaml multiline string map
 	flagTolerations        string // this is a multiline yaml string matching the tolerations array
+	flagAffinity           string // this is a multiline yaml string matching the affinity object
+	flagTopologySpread     string // this is a multiline yaml string matching the topology spread constraints array
 	flagServiceAnnotations string // this is a multiline yaml string array of annotations to allow
+	flagAutoscaling        string // this is a multiline yaml string matching the autoscaling object
 
 	flagOpenshiftSCCName string
 
@@ -101,7 +107,10 @@ type Command struct {
 
 	nodeSelector       map[string]string
 	tolerations        []corev1.Toleration
+	affinity           *corev1.Affinity
+	topologySpread     []corev1.TopologySpreadConstraint
 	serviceAnnotations []string
+	autoscaling        *v1alpha1.AutoscalingSpec
 	resources          corev1.ResourceRequirements
 
 	ctx context.Context
@@ -129,6 +138,12 @@ func (c *Command) init() {
 	c.flags.StringVar(&c.flagServiceType, "service-type", "",
 		"The service type to use for a gateway deployment.",
 	)
+	c.flags.StringVar(&c.flagServiceLoadBalancerClass, "service-load-balancer-class", "",
+		"The load balancer class to use for LoadBalancer services of gateway deployments.",
+	)
+	c.flags.StringVar(&c.flagServiceExternalTrafficPolicy, "service-external-traffic-policy", "",
+		"The external traffic policy (Cluster or Local) to use for LoadBalancer and NodePort services of gateway deployments.",
+	)
 	c.flags.IntVar(&c.flagDeploymentDefaultInstances, "deployment-default-instances", 0,
 		"The number of instances to deploy for each gateway by default.",
 	)
@@ -144,9 +159,18 @@ func (c *Command) init() {
 	c.flags.StringVar(&c.flagTolerations, "tolerations", "",
 		"The tolerations to use in a deployed gateway.",
 	)
+	c.flags.StringVar(&c.flagAffinity, "affinity", "",
+		"The affinity to use in scheduling a gateway.",
+	)
+	c.flags.StringVar(&c.flagTopologySpread, "topology-spread-constraints", "",
+		"The topology spread constraints to use in scheduling a gateway.",
+	)
 	c.flags.StringVar(&c.flagServiceAnnotations, "service-annotations", "",
 		"The annotations to copy over from a gateway to its service.",
 	)
+	c.flags.StringVar(&c.flagAutoscaling, "autoscaling", "",
+		"The horizontal pod autoscaling configuration to use for gateway deployments.",
+	)
 	c.flags.StringVar(&c.flagOpenshiftSCCName, "openshift-scc-name", "",
 		"Name of security context constraint to use for gateways on Openshift.",
 	)
@@ -232,17 +256,22 @@ func (c *Command) Run(args []string) int {
 	classConfig := &v1alpha1.GatewayClassConfig{
 		ObjectMeta: metav1.ObjectMeta{Name: c.flagGatewayClassConfigName, Labels: labels},
 		Spec: v1alpha1.GatewayClassConfigSpec{
-			ServiceType:  serviceTypeIfSet(c.flagServiceType),
-			NodeSelector: c.nodeSelector,
+			ServiceType:           serviceTypeIfSet(c.flagServiceType),
+			LoadBalancerClass:     stringIfSet(c.flagServiceLoadBalancerClass),
+			ExternalTrafficPolicy: externalTrafficPolicyIfSet(c.flagServiceExternalTrafficPolicy),
+			NodeSelector:          c.nodeSelector,
 			CopyAnnotations: v1alpha1.CopyAnnotationsSpec{
 				Service: c.serviceAnnotations,
 			},
-			Tolerations: c.tolerations,
+			Tolerations:               c.tolerations,
+			Affinity:                  c.affinity,
+			TopologySpreadConstraints: c.topologySpread,
 			DeploymentSpec: v1alpha1.DeploymentSpec{
 				DefaultInstances: nonZeroOrNil(c.flagDeploymentDefaultInstances),
 				MaxInstances:     nonZeroOrNil(c.flagDeploymentMaxInstances),
 				MinInstances:     nonZeroOrNil(c.flagDeploymentMinInstances),
 				Resources:        &c.resources,
+				Autoscaling:      c.autoscaling,
 			},
 			OpenshiftSCCName:            c.flagOpenshiftSCCName,
 			MapPrivilegedContainerPorts: int32(c.flagMapPrivilegedContainerPorts),
@@ -321,6 +350,21 @@ func (c *Command) validateFlags() error {
 			return fmt.Errorf("error decoding node selector: %w", err)
 		}
 	}
+	if c.flagAffinity != "" {
+		if err := k8syaml.UnmarshalStrict([]byte(c.flagAffinity), &c.affinity); err != nil {
+			return fmt.Errorf("error decoding affinity: %w", err)
+		}
+	}
+	if c.flagTopologySpread != "" {
+		if err := k8syaml.UnmarshalStrict([]byte(c.flagTopologySpread), &c.topologySpread); err != nil {
+			return fmt.Errorf("error decoding topology spread constraints: %w", err)
+		}
+	}
+	if c.flagAutoscaling != "" {
+		if err := k8syaml.UnmarshalStrict([]byte(c.flagAutoscaling), &c.autoscaling); err != nil {
+			return fmt.Errorf("error decoding autoscaling: %w", err)
+		}
+	}
 
 	if c.flagServiceAnnotations != "" {
 		if err := yaml.Unmarshal([]byte(c.flagServiceAnnotations), &c.serviceAnnotations); err != nil {
@@ -328,6 +372,12 @@ func (c *Command) validateFlags() error {
 		}
 	}
 
+	switch corev1.ServiceExternalTrafficPolicy(c.flagServiceExternalTrafficPolicy) {
+	case "", corev1.ServiceExternalTrafficPolicyCluster, corev1.ServiceExternalTrafficPolicyLocal:
+	default:
+		return errors.New("-service-external-traffic-policy must be either 'Cluster' or 'Local'")
+	}
+
 	if c.flagEnableMetrics != "" {
 		if _, valid := metricsutil.GetMetricsEnabled(c.flagEnableMetrics); !valid {
 			return errors.New("-enable-metrics must be either 'true' or 'false'")
@@ -459,3 +509,17 @@ func serviceTypeIfSet(v string) *corev1.ServiceType {
 	}
 	return common.PointerTo(corev1.ServiceType(v))
 }
+
+func stringIfSet(v string) *string {
+	if v == "" {
+		return nil
+	}
+	return common.PointerTo(v)
+}
+
+func externalTrafficPolicyIfSet(v string) *corev1.ServiceExternalTrafficPolicy {
+	if v == "" {
+		return nil
+	}
+	return common.PointerTo(corev1.ServiceExternalTrafficPolicy(v))
+}
@@ -136,6 +136,48 @@ func TestRun_flagValidation(t *testing.T) {
 			},
 			expectedErr: "error decoding service annotations: yaml: unmarshal errors:\n  line 1: cannot unmarshal !!str `foo` into []string",
 		},
+		"required valid affinity": {
+			cmd: &Command{
+				flagGatewayClassConfigName: "test",
+				flagGatewayClassName:       "test",
+				flagHeritage:               "test",
+				flagChart:                  "test",
+				flagApp:                    "test",
+				flagRelease:                "test",
+				flagComponent:              "test",
+				flagControllerName:         "test",
+				flagAffinity:               "nodeAffinity: foo",
+			},
+			expectedErr: "error decoding affinity: error unmarshaling JSON: while decoding JSON: json: cannot unmarshal string into Go struct field .nodeAffinity of type v1.NodeAffinity",
+		},
+		"required valid topology spread constraints": {
+			cmd: &Command{
+				flagGatewayClassConfigName: "test",
+				flagGatewayClassName:       "test",
+				flagHeritage:               "test",
+				flagChart:                  "test",
+				flagApp:                    "test",
+				flagRelease:                "test",
+				flagComponent:              "test",
+				flagControllerName:         "test",
+				flagTopologySpread:         "- maxSkew: 1\n  topologyKeys: zone",
+			},
+			expectedErr: `error decoding topology spread constraints: error unmarshaling JSON: while decoding JSON: json: unknown field "topologyKeys"`,
+		},
+		"required valid autoscaling": {
+			cmd: &Command{
+				flagGatewayClassConfigName: "test",
+				flagGatewayClassName:       "test",
+				flagHeritage:               "test",
+				flagChart:                  "test",
+				flagApp:                    "test",
+				flagRelease:                "test",
+				flagComponent:              "test",
+				flagControllerName:         "test",
+				flagAutoscaling:            "maxReplicas: 4\ntargetCPU: 80",
+			},
+			expectedErr: `error decoding autoscaling: error unmarshaling JSON: while decoding JSON: json: unknown field "targetCPU"`,
+		},
 		"valid without optional flags": {
 			cmd: &Command{
 				flagGatewayClassConfigName: "test",
@@ -169,11 +211,42 @@ bar: 2`,
   "effect": "NoSchedule"
   "key": "node2"
   "value": "clients2"`,
+				flagAffinity: `
+nodeAffinity:
+  requiredDuringSchedulingIgnoredDuringExecution:
+    nodeSelectorTerms:
+    - matchExpressions:
+      - key: node-role.kubernetes.io/gateway
+        operator: Exists`,
+				flagTopologySpread: `
+- maxSkew: 1
+  topologyKey: topology.kubernetes.io/zone
+  whenUnsatisfiable: DoNotSchedule`,
 				flagServiceAnnotations: `
 - foo
 - bar`,
-				flagOpenshiftSCCName: "restricted-v2",
+				flagAutoscaling: `
+minReplicas: 2
+maxReplicas: 10
+targetCPUUtilizationPercentage: 60`,
+				flagOpenshiftSCCName:             "restricted-v2",
+				flagServiceLoadBalancerClass:     "metallb",
+				flagServiceExternalTrafficPolicy: "Local",
+			},
+		},
+		"invalid external traffic policy": {
+			cmd: &Command{
+				flagGatewayClassConfigName:       "test",
+				flagGatewayClassName:             "test",
+				flagHeritage:                     "test",
+				flagChart:                        "test",
+				flagApp:                          "test",
+				flagRelease:                      "test",
+				flagComponent:                    "test",
+				flagControllerName:               "test",
+				flagServiceExternalTrafficPolicy: "Nearest",
 			},
+			expectedErr: "-service-external-traffic-policy must be either 'Cluster' or 'Local'",
 		},
 	} {
 		t.Run(name, func(t *testing.T) {
@@ -8,32 +8,41 @@ import (
 	"errors"
 	"flag"
 	"fmt"
+	"net/http"
 	"os"
 	"os/signal"
 	"strconv"
 	"strings"
 	"sync"
 	"syscall"
+	"time"
 
 	"github.com/hashicorp/consul-server-connection-manager/discovery"
 	"github.com/mitchellh/cli"
+	"go.uber.org/zap"
 	"go.uber.org/zap/zapcore"
 	corev1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/api/resource"
 	"k8s.io/apimachinery/pkg/runtime"
 	utilruntime "k8s.io/apimachinery/pkg/util/runtime"
+	"k8s.io/apimachinery/pkg/util/validation"
 	"k8s.io/client-go/kubernetes"
 	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
 	"k8s.io/client-go/rest"
 	"k8s.io/klog/v2"
 	ctrl "sigs.k8s.io/controller-runtime"
+	ctrlmetrics "sigs.k8s.io/controller-runtime/pkg/metrics"
 	metricsserver "sigs.k8s.io/controller-runtime/pkg/metrics/server"
 	"sigs.k8s.io/controller-runtime/pkg/webhook"
 	gwv1alpha2 "sigs.k8s.io/gateway-api/apis/v1alpha2"
 	gwv1beta1 "sigs.k8s.io/gateway-api/apis/v1beta1"
 
 	"github.com/hashicorp/consul-k8s/control-plane/api/v1alpha1"
+	injectcommon "github.com/hashicorp/consul-k8s/control-plane/connect-inject/common"
 	"github.com/hashicorp/consul-k8s/control-plane/connect-inject/constants"
+	"github.com/hashicorp/consul-k8s/control-plane/connect-inject/sharding"
+	injectwebhook "github.com/hashicorp/consul-k8s/control-plane/connect-inject/webhook"
+	"github.com/hashicorp/consul-k8s/control-plane/namespaces"
 	"github.com/hashicorp/consul-k8s/control-plane/subcommand/common"
 	"github.com/hashicorp/consul-k8s/control-plane/subcommand/flags"
 )
@@ -57,12 +66,18 @@ type Command struct {
 	flagEnvoyExtraArgs        string // Extra envoy args when starting envoy
 	flagEnableWebhookCAUpdate bool
 	flagLogLevel              string
+	flagLogLevelConfigMap     string // ConfigMap that overrides the log level at runtime
 	flagLogJSON               bool
+	flagEnablePprof           bool // True to serve pprof and runtime diagnostics on localhost
+
+	flagKubeDNSStubDomain        string // Consul DNS domain to add to the kube-dns stub domains
+	flagKubeDNSStubDomainService string // DNS proxy Service that the kube-dns stub domain points at
 
 	flagAllowK8sNamespacesList []string // K8s namespaces to explicitly inject
 	flagDenyK8sNamespacesList  []string // K8s namespaces to deny injection (has precedence)
 
-	flagEnablePartitions bool // Use Admin Partitions on all components
+	flagEnablePartitions          bool   // Use Admin Partitions on all components
+	flagPartitionMappingConfigMap string // ConfigMap that maps K8s namespaces to Admin Partitions
 
 	// Flags to support Consul namespaces
 	flagEnableNamespaces           bool   // Use namespacing on all components
@@ -82,6 +97,17 @@ type Command struct {
 	flagDefaultSidecarProxyMemoryRequest string
 	flagDefaultEnvoyProxyConcurrency     int
 
+	// Sidecar proxy resource auto-sizing.
+	flagEnableSidecarProxyResourceAutoSizing bool
+	flagSidecarProxyCPURequestPerUpstream    string
+	flagSidecarProxyMemoryRequestPerUpstream string
+	flagSidecarProxyCPURequestPerListener    string
+	flagSidecarProxyMemoryRequestPerListener string
+
+	// Upstreams annotation limits.
+	flagMaxUpstreams               int
+	flagMaxUpstreamsAnnotationSize int
+
 	// Proxy lifecycle settings.
 	flagDefaultEnableSidecarProxyLifecycle                       bool
 	flagDefaultEnableSidecarProxyLifecycleShutdownDrainListeners bool
@@ -90,6 +116,9 @@ type Command struct {
 	flagDefaultSidecarProxyLifecycleGracefulPort                 string
 	flagDefaultSidecarProxyLifecycleGracefulShutdownPath         string
 	flagDefaultSidecarProxyLifecycleGracefulStartupPath          string
+	flagDefaultSidecarProxyLifecyclePreStopDrainSeconds          int
+
+	flagEnableNativeSidecarsForJobs bool
 
 	flagDefaultSidecarProxyStartupFailureSeconds  int
 	flagDefaultSidecarProxyLivenessFailureSeconds int
@@ -118,6 +147,17 @@ type Command struct {
 	// Additional metadata to get applied to nodes.
 	flagNodeMeta map[string]string
 
+	// Pod labels to set as the metadata and tags of the services.
+	flagServiceMetaFromLabels map[string]string
+	flagServiceTagsFromLabels map[string]string
+
+	// Node labels to set as the metadata of the services of the pods running on the nodes.
+	flagServiceMetaFromNodeLabels map[string]string
+
+	// Pod annotations to copy into the environment of the injected containers and the labels of the pods.
+	flagAnnotationsToEnv    map[string]string
+	flagAnnotationsToLabels map[string]string
+
 	// Peering flags.
 	flagEnablePeering bool
 
@@ -129,11 +169,47 @@ type Command struct {
 	// Consul telemetry collector
 	flagEnableTelemetryCollector bool
 
+	// Record Events on Kubernetes Services when their instances are deregistered from Consul.
+	flagEnableDeregistrationEvents bool
+
+	// Register the role of pods managed by Argo Rollouts in their service instances' metadata.
+	flagEnableArgoRollouts bool
+
+	// Endpoints controller settings.
+	flagEndpointsMaxConcurrentReconciles int
+	flagEndpointsConsulWriteRateLimit    float64
+	flagEndpointsConsulWriteBurst        int
+	flagCircuitBreakerFailureThreshold   int
+	flagCircuitBreakerOpenDuration       time.Duration
+	flagEndpointsOrphanReapInterval      time.Duration
+	flagEndpointsOrphanReapDryRun        bool
+	flagConfigEntryDryRun                bool
+	flagEndpointsShardConfigMap          string
+	flagMaintenanceModeConfigMap         string
+	flagPendingRegistrationsConfigMap    string
+	flagNodeNamingStrategy               string
+	flagPrimaryIPFamily                  string
+
+	// Gateway WAN address settings.
+	flagGatewayWANAddressResolvePeriod      time.Duration
+	flagGatewayWANAddressHealthCheckTimeout time.Duration
+
+	// Flags to configure the projected service account tokens used to log in with the auth method.
+	flagLoginTokenAudience          string
+	flagLoginTokenExpirationSeconds int64
+	flagLoginTokenAuthMethod        string
+
+	flagEnableDataplaneLogin bool
+
 	// Consul DNS flags.
-	flagEnableConsulDNS bool
-	flagResourcePrefix  string
+	flagEnableConsulDNS          bool
+	flagConsulDNSRedirectionMode string
+	flagResourcePrefix           string
 
 	flagEnableOpenShift bool
+	flagDetectOpenShift bool
+
+	flagEnableResourceAPIs bool // Write pods to Consul as catalog v2 workloads.
 
 	flagSet *flag.FlagSet
 	consul  *flags.ConsulFlags
@@ -147,11 +223,20 @@ type Command struct {
 	sidecarProxyMemoryLimit   resource.Quantity
 	sidecarProxyMemoryRequest resource.Quantity
 
+	// sidecarProxyResourceAutoSizing is parsed and validated from the auto-sizing flags.
+	sidecarProxyResourceAutoSizing injectwebhook.ProxyResourceAutoSizing
+
 	// static resources requirements for connect-init
 	initContainerResources corev1.ResourceRequirements
 
+	// endpointsShard is the shard of namespaces of the endpoints controller if -endpoints-shard-config-map is set.
+	endpointsShard *sharding.Shard
+
 	caCertPem []byte
 
+	// enableSleepAction is whether the cluster supports the Sleep lifecycle handler of the pre-stop drain hooks.
+	enableSleepAction bool
+
 	once sync.Once
 	help string
 }
@@ -175,9 +260,26 @@ func init() {
 func (c *Command) init() {
 	c.flagSet = flag.NewFlagSet("", flag.ContinueOnError)
 	c.flagSet.StringVar(&c.flagListen, "listen", ":8080", "Address to bind listener to.")
+	c.flagSet.BoolVar(&c.flagEnablePprof, "enable-pprof", false,
+		fmt.Sprintf("Serve net/http/pprof profiles and runtime statistics on %s.", debugBindAddress))
 	c.flagSet.StringVar(&c.flagConfigFile, "config-file", "", "Path to a JSON config file.")
 	c.flagSet.Var((*flags.FlagMapValue)(&c.flagNodeMeta), "node-meta",
 		"Metadata to set on the node, formatted as key=value. This flag may be specified multiple times to set multiple meta fields.")
+	c.flagSet.Var((*flags.FlagMapValue)(&c.flagServiceMetaFromLabels), "service-meta-from-label",
+		"Pod label to set as service metadata, formatted as label=key. The metadata key is set to the value of the label. "+
+			"This flag may be specified multiple times to set multiple meta fields.")
+	c.flagSet.Var((*flags.FlagMapValue)(&c.flagServiceMetaFromNodeLabels), "service-meta-from-node-label",
+		"Label of the node a pod runs on to set as service metadata, formatted as label=key, e.g. topology.kubernetes.io/rack=rack. "+
+			"The metadata key is set to the value of the label. This flag may be specified multiple times to set multiple meta fields.")
+	c.flagSet.Var((*flags.FlagMapValue)(&c.flagServiceTagsFromLabels), "service-tag-from-label",
+		"Pod label to add as a service tag, formatted as label=prefix. The tag is the prefix followed by the value of the label. "+
+			"This flag may be specified multiple times to add multiple tags.")
+	c.flagSet.Var((*flags.FlagMapValue)(&c.flagAnnotationsToEnv), "annotation-to-env",
+		"Pod annotation to set as an environment variable of the injected containers, formatted as annotation=NAME. "+
+			"This flag may be specified multiple times to set multiple environment variables.")
+	c.flagSet.Var((*flags.FlagMapValue)(&c.flagAnnotationsToLabels), "annotation-to-label",
+		"Pod annotation to add as a label of injected pods, formatted as annotation=label. Labels the pod already has "+
+			"are not overwritten. This flag may be specified multiple times to add multiple labels.")
 	c.flagSet.BoolVar(&c.flagDefaultInject, "default-inject", true, "Inject by default.")
 	c.flagSet.StringVar(&c.flagCertDir, "tls-cert-dir", "",
 		"Directory with PEM-encoded TLS certificate and key to serve.")
@@ -195,6 +297,19 @@ func (c *Command) init() {
 		"Extra envoy command line args to be set when starting envoy (e.g \"--log-level debug --disable-hot-restart\").")
 	c.flagSet.StringVar(&c.flagACLAuthMethod, "acl-auth-method", "",
 		"The name of the Kubernetes Auth Method to use for connectInjection if ACLs are enabled.")
+	c.flagSet.StringVar(&c.flagLoginTokenAudience, "login-token-audience", "",
+		"Audience of the projected service account token that injected pods log in with the auth method. "+
+			"If not set, pods log in with the service account token mounted by Kubernetes.")
+	c.flagSet.Int64Var(&c.flagLoginTokenExpirationSeconds, "login-token-expiration-seconds", 3600,
+		"Requested lifetime in seconds of the projected service account token that injected pods log in with. "+
+			"Must be at least 600.")
+	c.flagSet.StringVar(&c.flagLoginTokenAuthMethod, "login-token-auth-method", "",
+		"The name of the Auth Method that injected pods log in with using the projected service account token. "+
+			"Required if -login-token-audience is set.")
+	c.flagSet.BoolVar(&c.flagEnableDataplaneLogin, "enable-dataplane-login", false,
+		"Skip the connect-inject-init container where consul-dataplane can log in and look up its proxy registration itself. "+
+			"The init container is still injected to apply transparent proxy traffic redirection without the CNI plugin "+
+			"and into pods without the consul.hashicorp.com/connect-service annotation.")
 	c.flagSet.Var((*flags.AppendSliceValue)(&c.flagAllowK8sNamespacesList), "allow-k8s-namespace",
 		"K8s namespaces to explicitly allow. May be specified multiple times.")
 	c.flagSet.Var((*flags.AppendSliceValue)(&c.flagDenyK8sNamespacesList), "deny-k8s-namespace",
@@ -203,6 +318,10 @@ func (c *Command) init() {
 	c.flagSet.StringVar(&c.flagReleaseNamespace, "release-namespace", "default", "The Consul Helm installation namespace, e.g 'helm install <RELEASE-NAME> --namespace <RELEASE-NAMESPACE>'")
 	c.flagSet.BoolVar(&c.flagEnablePartitions, "enable-partitions", false,
 		"[Enterprise Only] Enables Admin Partitions.")
+	c.flagSet.StringVar(&c.flagPartitionMappingConfigMap, "partition-mapping-configmap", "",
+		"[Enterprise Only] Name of a ConfigMap in the release namespace that maps Kubernetes namespaces to the Admin Partitions "+
+			"their services are registered in. Each key is a Kubernetes namespace and its value is an Admin Partition. "+
+			"Namespaces that are not in the ConfigMap use the partition set by -partition.")
 	c.flagSet.BoolVar(&c.flagEnableNamespaces, "enable-namespaces", false,
 		"[Enterprise Only] Enables namespaces, in either a single Consul namespace or mirrored.")
 	c.flagSet.StringVar(&c.flagConsulDestinationNamespace, "consul-destination-namespace", "default",
@@ -211,7 +330,8 @@ func (c *Command) init() {
 	c.flagSet.BoolVar(&c.flagEnableK8SNSMirroring, "enable-k8s-namespace-mirroring", false, "[Enterprise Only] Enables "+
 		"k8s namespace mirroring.")
 	c.flagSet.StringVar(&c.flagK8SNSMirroringPrefix, "k8s-namespace-mirroring-prefix", "",
-		"[Enterprise Only] Prefix that will be added to all k8s namespaces mirrored into Consul if mirroring is enabled.")
+		"[Enterprise Only] Prefix that will be added to all k8s namespaces mirrored into Consul if mirroring is enabled. "+
+			"If it contains {k8s-namespace}, it is a template of the mirrored namespace instead, e.g. {k8s-namespace}-east.")
 	c.flagSet.StringVar(&c.flagCrossNamespaceACLPolicy, "consul-cross-namespace-acl-policy", "",
 		"[Enterprise Only] Name of the ACL policy to attach to all created Consul namespaces to allow service "+
 			"discovery across Consul namespaces. Only necessary if ACLs are enabled.")
@@ -223,27 +343,107 @@ func (c *Command) init() {
 		"Overwrite Kubernetes probes to point to Envoy by default when in Transparent Proxy mode.")
 	c.flagSet.BoolVar(&c.flagEnableConsulDNS, "enable-consul-dns", false,
 		"Enables Consul DNS lookup for services in the mesh.")
+	c.flagSet.StringVar(&c.flagConsulDNSRedirectionMode, "consul-dns-redirection-mode", constants.DNSRedirectionModeIPTables,
+		fmt.Sprintf("Default mechanism used to direct DNS requests from mesh services to Consul DNS. Supported values are %q, "+
+			"which redirects DNS traffic with iptables and requires transparent proxy, %q, which rewrites the pod's dnsConfig, "+
+			"and %q, which redirects DNS traffic to every nameserver with iptables and requires transparent proxy.",
+			constants.DNSRedirectionModeIPTables, constants.DNSRedirectionModeDNSConfig, constants.DNSRedirectionModeCapture))
 	c.flagSet.StringVar(&c.flagResourcePrefix, "resource-prefix", "",
 		"Release prefix of the Consul installation used to determine Consul DNS Service name.")
 	c.flagSet.BoolVar(&c.flagEnableOpenShift, "enable-openshift", false,
 		"Indicates that the command runs in an OpenShift cluster.")
+	c.flagSet.BoolVar(&c.flagEnableResourceAPIs, "enable-resource-apis", false,
+		"[Experimental] Write injected pods to Consul as catalog v2 workloads using resource APIs.")
+	c.flagSet.BoolVar(&c.flagDetectOpenShift, "detect-openshift", false,
+		"Enables OpenShift support if the cluster serves the security.openshift.io API group.")
 	c.flagSet.BoolVar(&c.flagEnableWebhookCAUpdate, "enable-webhook-ca-update", false,
 		"Enables updating the CABundle on the webhook within this controller rather than using the web cert manager.")
 	c.flagSet.BoolVar(&c.flagEnableAutoEncrypt, "enable-auto-encrypt", false,
 		"Indicates whether TLS with auto-encrypt should be used when talking to Consul clients.")
 	c.flagSet.BoolVar(&c.flagEnableTelemetryCollector, "enable-telemetry-collector", false,
 		"Indicates whether proxies should be registered with configuration to enable forwarding metrics to consul-telemetry-collector")
+	c.flagSet.BoolVar(&c.flagEnableDeregistrationEvents, "enable-deregistration-events", false,
+		"Indicates whether to record an Event on the Kubernetes Service every time one of its instances is deregistered from Consul.")
+	c.flagSet.BoolVar(&c.flagEnableArgoRollouts, "enable-argo-rollouts", false,
+		"Indicates whether to register the role of pods managed by Argo Rollouts (stable or canary) in the metadata of their service instances.")
+	c.flagSet.IntVar(&c.flagEndpointsMaxConcurrentReconciles, "endpoints-max-concurrent-reconciles", 1,
+		"The number of Services whose endpoints the endpoints controller reconciles concurrently.")
+	c.flagSet.Float64Var(&c.flagEndpointsConsulWriteRateLimit, "endpoints-consul-write-rate-limit", 0,
+		"The maximum number of catalog and ACL writes per second the endpoints controller makes to Consul. If 0, writes are not rate limited.")
+	c.flagSet.IntVar(&c.flagEndpointsConsulWriteBurst, "endpoints-consul-write-burst", 10,
+		"The number of writes the endpoints controller can make to Consul in a burst above -endpoints-consul-write-rate-limit.")
+	c.flagSet.IntVar(&c.flagCircuitBreakerFailureThreshold, "consul-circuit-breaker-failure-threshold", 5,
+		"The number of consecutive requests that must fail to reach the Consul servers before requests are rejected "+
+			"and controllers back off for -consul-circuit-breaker-open-duration. If 0, requests are never rejected.")
+	c.flagSet.DurationVar(&c.flagCircuitBreakerOpenDuration, "consul-circuit-breaker-open-duration", 30*time.Second,
+		"How long requests to the Consul servers are rejected after -consul-circuit-breaker-failure-threshold requests failed, "+
+			"formatted as a time.Duration. A single trial request is then sent to check whether the servers are reachable again.")
+	c.flagSet.DurationVar(&c.flagEndpointsOrphanReapInterval, "endpoints-orphan-reap-interval", 0,
+		"If set, how often the endpoints controller lists the service instances it registered in the Consul catalog and "+
+			"deregisters the instances whose pods no longer exist, formatted as a time.Duration. If not set, orphans are not reaped.")
+	c.flagSet.BoolVar(&c.flagEndpointsOrphanReapDryRun, "endpoints-orphan-reap-dry-run", false,
+		"If true, the orphan reaper only logs the service instances it would deregister.")
+	c.flagSet.BoolVar(&c.flagConfigEntryDryRun, "config-entry-dry-run", false,
+		"If true, the config entry controllers record the config entries they would write to Consul in the status "+
+			"of the custom resources instead of writing them. The consul.hashicorp.com/dry-run annotation of a resource overrides it.")
+	c.flagSet.StringVar(&c.flagEndpointsShardConfigMap, "endpoints-shard-config-map", "",
+		"If set, the name of the ConfigMap in the release namespace that assigns Kubernetes namespaces to shards. "+
+			"Every replica runs the endpoints controller for the namespaces of the shard it claims instead of only the leader "+
+			"running it for every namespace.")
+	c.flagSet.StringVar(&c.flagMaintenanceModeConfigMap, "maintenance-mode-configmap", "",
+		fmt.Sprintf("If set, the name of a ConfigMap in the release namespace whose %s annotation, when \"true\", pauses "+
+			"the registrations and deregistrations of the endpoints controller while the Consul servers are in maintenance, "+
+			"e.g. during an upgrade. Pods are still injected.", constants.AnnotationMaintenanceMode))
+	c.flagSet.StringVar(&c.flagPendingRegistrationsConfigMap, "endpoints-pending-registrations-configmap", "",
+		"If set, the name of a ConfigMap in the release namespace that durably queues the Endpoints the endpoints controller "+
+			"can't reconcile while the Consul servers are unreachable. The Endpoints are reconciled as soon as the servers are "+
+			"reachable again, including after a restart of the injector. The ConfigMap is created if it doesn't exist.")
+	c.flagSet.StringVar(&c.flagNodeNamingStrategy, "node-naming-strategy", string(injectcommon.NodeNamingPerNode),
+		fmt.Sprintf("The synthetic Consul nodes service instances are registered on: %q for a node per Kubernetes node, %q "+
+			"for a single node, or %q for a node per Kubernetes namespace. When it changes, service instances are moved "+
+			"to the nodes of the new strategy.", injectcommon.NodeNamingPerNode, injectcommon.NodeNamingPerCluster, injectcommon.NodeNamingPerNamespace))
+	c.flagSet.StringVar(&c.flagPrimaryIPFamily, "primary-ip-family", "",
+		fmt.Sprintf("The IP family, %q or %q, of the pod IP registered as the address of service instances in dual-stack "+
+			"clusters. If empty, the first pod IP is registered. Can be overridden per pod with the %s annotation.",
+			corev1.IPv4Protocol, corev1.IPv6Protocol, constants.AnnotationPrimaryIPFamily))
+	c.flagSet.DurationVar(&c.flagGatewayWANAddressResolvePeriod, "gateway-wan-address-resolve-interval", 0,
+		"If set, gateways whose WAN address is read from their LoadBalancer Service are registered with the IP addresses "+
+			"the hostname of the load balancer resolves to, and the hostname is resolved again on this interval, formatted "+
+			"as a time.Duration. If not set, the hostname is registered as is.")
+	c.flagSet.DurationVar(&c.flagGatewayWANAddressHealthCheckTimeout, "gateway-wan-address-health-check-timeout", 0,
+		"If set, the ingress addresses of the LoadBalancer Services of gateways are health checked with TCP connections "+
+			"to the WAN port with this timeout, formatted as a time.Duration, and the first healthy address is registered "+
+			"as the WAN address. If not set, the first address is registered.")
 	c.flagSet.StringVar(&c.flagLogLevel, "log-level", zapcore.InfoLevel.String(),
 		fmt.Sprintf("Log verbosity level. Supported values (in order of detail) are "+
 			"%q, %q, %q, and %q.", zapcore.DebugLevel.String(), zapcore.InfoLevel.String(), zapcore.WarnLevel.String(), zapcore.ErrorLevel.String()))
+	c.flagSet.StringVar(&c.flagLogLevelConfigMap, "log-level-configmap", "",
+		"Name of a ConfigMap in the release namespace whose logLevel key overrides -log-level while the injector "+
+			"is running, so that the log level can be changed without a restart. If the ConfigMap or the key "+
+			"doesn't exist, -log-level is used.")
 	c.flagSet.BoolVar(&c.flagLogJSON, "log-json", false,
 		"Enable or disable JSON output format for logging.")
+	c.flagSet.StringVar(&c.flagKubeDNSStubDomain, "kube-dns-stub-domain", "",
+		"Consul DNS domain, e.g. \"consul\", that kube-dns forwards to the DNS proxy. If set, the domain is "+
+			"added to the stub domains of the kube-system/kube-dns ConfigMap. Requires -kube-dns-stub-domain-service.")
+	c.flagSet.StringVar(&c.flagKubeDNSStubDomainService, "kube-dns-stub-domain-service", "",
+		"Name of the DNS proxy Service in the release namespace that the -kube-dns-stub-domain points at.")
 
 	// Proxy sidecar resource setting flags.
 	c.flagSet.StringVar(&c.flagDefaultSidecarProxyCPURequest, "default-sidecar-proxy-cpu-request", "", "Default sidecar proxy CPU request.")
 	c.flagSet.StringVar(&c.flagDefaultSidecarProxyCPULimit, "default-sidecar-proxy-cpu-limit", "", "Default sidecar proxy CPU limit.")
 	c.flagSet.StringVar(&c.flagDefaultSidecarProxyMemoryRequest, "default-sidecar-proxy-memory-request", "", "Default sidecar proxy memory request.")
 	c.flagSet.StringVar(&c.flagDefaultSidecarProxyMemoryLimit, "default-sidecar-proxy-memory-limit", "", "Default sidecar proxy memory limit.")
+	c.flagSet.BoolVar(&c.flagEnableSidecarProxyResourceAutoSizing, "enable-sidecar-proxy-resource-auto-sizing", false,
+		"Add to the default sidecar proxy CPU and memory requests an amount per declared upstream and per listener of the proxy.")
+	c.flagSet.StringVar(&c.flagSidecarProxyCPURequestPerUpstream, "sidecar-proxy-cpu-request-per-upstream", "",
+		"Sidecar proxy CPU request added per declared upstream when auto-sizing is enabled.")
+	c.flagSet.StringVar(&c.flagSidecarProxyMemoryRequestPerUpstream, "sidecar-proxy-memory-request-per-upstream", "",
+		"Sidecar proxy memory request added per declared upstream when auto-sizing is enabled.")
+	c.flagSet.StringVar(&c.flagSidecarProxyCPURequestPerListener, "sidecar-proxy-cpu-request-per-listener", "",
+		"Sidecar proxy CPU request added per listener of the proxy when auto-sizing is enabled.")
+	c.flagSet.StringVar(&c.flagSidecarProxyMemoryRequestPerListener, "sidecar-proxy-memory-request-per-listener", "",
+		"Sidecar proxy memory request added per listener of the proxy when auto-sizing is enabled.")
 
 	// Proxy lifecycle setting flags.
 	c.flagSet.BoolVar(&c.flagDefaultEnableSidecarProxyLifecycle, "default-enable-sidecar-proxy-lifecycle", false, "Default for enabling sidecar proxy lifecycle management.")
@@ -253,6 +453,11 @@ func (c *Command) init() {
 	c.flagSet.StringVar(&c.flagDefaultSidecarProxyLifecycleGracefulPort, "default-sidecar-proxy-lifecycle-graceful-port", strconv.Itoa(constants.DefaultGracefulPort), "Default port for sidecar proxy lifecycle management HTTP endpoints.")
 	c.flagSet.StringVar(&c.flagDefaultSidecarProxyLifecycleGracefulShutdownPath, "default-sidecar-proxy-lifecycle-graceful-shutdown-path", "/graceful_shutdown", "Default sidecar proxy lifecycle management graceful shutdown path.")
 	c.flagSet.StringVar(&c.flagDefaultSidecarProxyLifecycleGracefulStartupPath, "default-sidecar-proxy-lifecycle-graceful-startup-path", "/graceful_startup", "Default sidecar proxy lifecycle management graceful startup path.")
+	c.flagSet.IntVar(&c.flagDefaultSidecarProxyLifecyclePreStopDrainSeconds, "default-sidecar-proxy-lifecycle-pre-stop-drain-seconds", 0, "Default number of seconds that the application containers and the sidecar proxy sleep in a preStop hook before they are stopped. 0 disables the preStop hooks.")
+
+	c.flagSet.BoolVar(&c.flagEnableNativeSidecarsForJobs, "enable-native-sidecars-for-jobs", false,
+		"Inject the sidecar proxies of pods that run to completion, e.g. the pods of Kubernetes Jobs, as native sidecars so that "+
+			"they stop when the application exits. Requires Kubernetes 1.29+.")
 
 	c.flagSet.IntVar(&c.flagDefaultSidecarProxyStartupFailureSeconds, "default-sidecar-proxy-startup-failure-seconds", 0, "Default number of seconds for the k8s startup probe to fail before the proxy container is restarted. Zero disables the probe.")
 	c.flagSet.IntVar(&c.flagDefaultSidecarProxyLivenessFailureSeconds, "default-sidecar-proxy-liveness-failure-seconds", 0, "Default number of seconds for the k8s liveness probe to fail before the proxy container is restarted. Zero disables the probe.")
@@ -273,6 +478,11 @@ func (c *Command) init() {
 
 	c.flagSet.IntVar(&c.flagDefaultEnvoyProxyConcurrency, "default-envoy-proxy-concurrency", 2, "Default Envoy proxy concurrency.")
 
+	c.flagSet.IntVar(&c.flagMaxUpstreams, "max-upstreams", 0,
+		"Maximum number of upstreams in the upstreams annotation of a pod. Pods with more upstreams are rejected. Defaults to 0, which means no limit.")
+	c.flagSet.IntVar(&c.flagMaxUpstreamsAnnotationSize, "max-upstreams-annotation-size", 0,
+		"Maximum size in bytes of the upstreams annotation of a pod. Pods with a larger annotation are rejected. Defaults to 0, which means no limit.")
+
 	c.consul = &flags.ConsulFlags{}
 
 	flags.Merge(c.flagSet, c.consul.Flags())
@@ -320,14 +530,36 @@ func (c *Command) Run(args []string) int {
 		}
 	}
 
-	zapLogger, err := common.ZapLogger(c.flagLogLevel, c.flagLogJSON)
+	// The log level is atomic so that it can be changed from the -log-level-configmap while the injector is running.
+	zapLevel, err := common.ZapLevel(c.flagLogLevel)
 	if err != nil {
 		c.UI.Error(fmt.Sprintf("Error setting up logging: %s", err.Error()))
 		return 1
 	}
+	logLevel := zap.NewAtomicLevelAt(zapLevel)
+	zapLogger := common.ZapLoggerWithLevel(logLevel, c.flagLogJSON)
 	ctrl.SetLogger(zapLogger)
 	klog.SetLogger(zapLogger)
 
+	if c.flagDetectOpenShift && !c.flagEnableOpenShift {
+		isOpenShift, err := injectcommon.IsOpenShift(c.clientset.Discovery())
+		if err != nil {
+			c.UI.Error(fmt.Sprintf("error detecting OpenShift: %s", err))
+			return 1
+		}
+		if isOpenShift {
+			zapLogger.Info("detected OpenShift cluster, enabling OpenShift support")
+			c.flagEnableOpenShift = true
+		}
+	}
+
+	// The pre-stop drain hooks fall back to running the sleep command on clusters without the Sleep
+	// lifecycle handler.
+	c.enableSleepAction, err = injectcommon.SupportsSleepAction(c.clientset.Discovery())
+	if err != nil {
+		zapLogger.Error(err, "unable to detect support for the Sleep lifecycle handler, pre-stop drain hooks will run the sleep command")
+	}
+
 	// TODO (agentless): find a way to integrate zap logger (via having a generic logger interface in connection manager).
 	hcLog, err := common.NamedLogger(c.flagLogLevel, c.flagLogJSON, "consul-server-connection-manager")
 	if err != nil {
@@ -382,13 +614,24 @@ func (c *Command) Run(args []string) int {
 		return 1
 	}
 
+	// The leader status is served on the metrics server and tracked on all replicas.
+	leader := &leaderStatus{
+		Namespace: c.flagReleaseNamespace,
+		Gatherer:  ctrlmetrics.Registry,
+		Log:       ctrl.Log.WithName("leader"),
+	}
+
 	mgr, err := ctrl.NewManager(ctrl.GetConfigOrDie(), ctrl.Options{
-		Scheme:           scheme,
-		LeaderElection:   true,
-		LeaderElectionID: "consul-controller-lock",
-		Logger:           zapLogger,
+		Scheme:                  scheme,
+		LeaderElection:          true,
+		LeaderElectionID:        leaderElectionID,
+		LeaderElectionNamespace: c.flagReleaseNamespace,
+		Logger:                  zapLogger,
 		Metrics: metricsserver.Options{
 			BindAddress: "0.0.0.0:9444",
+			ExtraHandlers: map[string]http.Handler{
+				"/leader": leader,
+			},
 		},
 		HealthProbeBindAddress: "0.0.0.0:9445",
 		WebhookServer: webhook.NewServer(webhook.Options{
@@ -402,6 +645,80 @@ func (c *Command) Run(args []string) int {
 		return 1
 	}
 
+	leader.Client = mgr.GetAPIReader()
+	leader.Elected = mgr.Elected()
+	if err = mgr.Add(leader); err != nil {
+		setupLog.Error(err, "unable to add leader status to manager")
+		return 1
+	}
+
+	if c.flagEnablePprof {
+		debug := &debugServer{
+			Addr: debugBindAddress,
+			Log:  ctrl.Log.WithName("debug"),
+		}
+		if err = mgr.Add(debug); err != nil {
+			setupLog.Error(err, "unable to add pprof and runtime diagnostics to manager")
+			return 1
+		}
+	}
+
+	if c.flagLogLevelConfigMap != "" {
+		logLevelCfg := &logLevelConfig{
+			Client:       mgr.GetAPIReader(),
+			Name:         c.flagLogLevelConfigMap,
+			Namespace:    c.flagReleaseNamespace,
+			DefaultLevel: c.flagLogLevel,
+			ZapLevel:     logLevel,
+			HCLog:        hcLog,
+			Log:          ctrl.Log.WithName("log-level"),
+		}
+		// An invalid log level doesn't stop the injector from starting since it still logs at -log-level.
+		if err = logLevelCfg.Refresh(ctx); err != nil {
+			setupLog.Error(err, "unable to read log level")
+		}
+		if err = mgr.Add(logLevelCfg.Poller()); err != nil {
+			setupLog.Error(err, "unable to add log level config to manager")
+			return 1
+		}
+	}
+
+	if c.flagKubeDNSStubDomain != "" {
+		stubDomain := &kubeDNSStubDomain{
+			Reader:           mgr.GetAPIReader(),
+			Writer:           mgr.GetClient(),
+			Domain:           c.flagKubeDNSStubDomain,
+			ServiceName:      c.flagKubeDNSStubDomainService,
+			ServiceNamespace: c.flagReleaseNamespace,
+			Log:              ctrl.Log.WithName("kube-dns"),
+		}
+		if err = mgr.Add(stubDomain.Poller()); err != nil {
+			setupLog.Error(err, "unable to add kube-dns stub domain to manager")
+			return 1
+		}
+	}
+
+	if c.flagEndpointsShardConfigMap != "" {
+		// The hostname of a pod is its name.
+		identity, err := os.Hostname()
+		if err != nil {
+			setupLog.Error(err, "unable to get hostname for endpoints controller shard")
+			return 1
+		}
+		c.endpointsShard = &sharding.Shard{
+			Reader:        mgr.GetAPIReader(),
+			Writer:        mgr.GetClient(),
+			ConfigMapName: c.flagEndpointsShardConfigMap,
+			Namespace:     c.flagReleaseNamespace,
+			Identity:      identity,
+			Log:           ctrl.Log.WithName("endpoints-shard"),
+		}
+		if err = mgr.Add(c.endpointsShard); err != nil {
+			setupLog.Error(err, "unable to add endpoints controller shard to manager")
+			return 1
+		}
+	}
+
 	err = c.configureControllers(ctx, mgr, watcher)
 	if err != nil {
 		setupLog.Error(err, fmt.Sprintf("could not configure controllers: %s", err.Error()))
@@ -437,6 +754,56 @@ func (c *Command) validateFlags() error {
 		return errors.New("-global-image-pull-policy must be `IfNotPresent`, `Always`, `Never`, or `` ")
 	}
 
+	if err := namespaces.ValidateMirroringPrefix(c.flagK8SNSMirroringPrefix); err != nil {
+		return fmt.Errorf("-k8s-namespace-mirroring-prefix is invalid: %w", err)
+	}
+
+	if (c.flagKubeDNSStubDomain == "") != (c.flagKubeDNSStubDomainService == "") {
+		return errors.New("-kube-dns-stub-domain and -kube-dns-stub-domain-service must be set together")
+	}
+
+	if c.flagEndpointsMaxConcurrentReconciles < 1 {
+		return errors.New("-endpoints-max-concurrent-reconciles must be at least 1")
+	}
+	if c.flagEndpointsConsulWriteRateLimit < 0 {
+		return errors.New("-endpoints-consul-write-rate-limit must not be negative")
+	}
+	if c.flagEndpointsConsulWriteRateLimit > 0 && c.flagEndpointsConsulWriteBurst < 1 {
+		return errors.New("-endpoints-consul-write-burst must be at least 1 if -endpoints-consul-write-rate-limit is set")
+	}
+	if c.flagCircuitBreakerFailureThreshold < 0 {
+		return errors.New("-consul-circuit-breaker-failure-threshold must not be negative")
+	}
+	if c.flagCircuitBreakerFailureThreshold > 0 && c.flagCircuitBreakerOpenDuration <= 0 {
+		return errors.New("-consul-circuit-breaker-open-duration must be positive if -consul-circuit-breaker-failure-threshold is set")
+	}
+	if c.flagEndpointsOrphanReapInterval < 0 {
+		return errors.New("-endpoints-orphan-reap-interval must not be negative")
+	}
+	if c.flagEndpointsShardConfigMap != "" && c.flagEnableResourceAPIs {
+		return errors.New("-endpoints-shard-config-map is not supported with -enable-resource-apis")
+	}
+	if _, err := injectcommon.ParseNodeNamingStrategy(c.flagNodeNamingStrategy); err != nil {
+		return fmt.Errorf("-node-naming-strategy is invalid: %w", err)
+	}
+	switch corev1.IPFamily(c.flagPrimaryIPFamily) {
+	case "", corev1.IPv4Protocol, corev1.IPv6Protocol:
+	default:
+		return fmt.Errorf("-primary-ip-family must be %q or %q, got %q", corev1.IPv4Protocol, corev1.IPv6Protocol, c.flagPrimaryIPFamily)
+	}
+	if c.flagGatewayWANAddressResolvePeriod < 0 {
+		return errors.New("-gateway-wan-address-resolve-interval must not be negative")
+	}
+	if c.flagGatewayWANAddressHealthCheckTimeout < 0 {
+		return errors.New("-gateway-wan-address-health-check-timeout must not be negative")
+	}
+	if c.flagLoginTokenAudience != "" && c.flagLoginTokenAuthMethod == "" {
+		return errors.New("-login-token-auth-method must be set if -login-token-audience is set")
+	}
+	if c.flagLoginTokenAudience != "" && c.flagLoginTokenExpirationSeconds < 600 {
+		return errors.New("-login-token-expiration-seconds must be at least 600")
+	}
+
 	if c.flagEnablePartitions && c.consul.Partition == "" {
 		return errors.New("-partition must set if -enable-partitions is set to 'true'")
 	}
@@ -445,10 +812,68 @@ func (c *Command) validateFlags() error {
 		return errors.New("-enable-partitions must be set to 'true' if -partition is set")
 	}
 
+	if c.flagPartitionMappingConfigMap != "" && !c.flagEnablePartitions {
+		return errors.New("-enable-partitions must be set to 'true' if -partition-mapping-configmap is set")
+	}
+
+	// The auth method is copied to the mapped partitions, and only the policies of the default
+	// partition can grant the injector access to them.
+	if c.flagPartitionMappingConfigMap != "" && c.flagACLAuthMethod != "" && c.consul.Partition != "default" {
+		return errors.New("-partition must be \"default\" if -partition-mapping-configmap is set with -acl-auth-method")
+	}
+
+	switch c.flagConsulDNSRedirectionMode {
+	case constants.DNSRedirectionModeIPTables, constants.DNSRedirectionModeDNSConfig, constants.DNSRedirectionModeCapture:
+	default:
+		return fmt.Errorf("-consul-dns-redirection-mode must be %q, %q or %q",
+			constants.DNSRedirectionModeIPTables, constants.DNSRedirectionModeDNSConfig, constants.DNSRedirectionModeCapture)
+	}
+
 	if c.flagDefaultEnvoyProxyConcurrency < 0 {
 		return errors.New("-default-envoy-proxy-concurrency must be >= 0 if set")
 	}
 
+	if c.flagMaxUpstreams < 0 {
+		return errors.New("-max-upstreams must be >= 0 if set")
+	}
+	if c.flagMaxUpstreamsAnnotationSize < 0 {
+		return errors.New("-max-upstreams-annotation-size must be >= 0 if set")
+	}
+	for label, key := range c.flagServiceMetaFromLabels {
+		if label == "" || key == "" {
+			return fmt.Errorf("-service-meta-from-label must be formatted as label=key, got %q", label+"="+key)
+		}
+	}
+	for label, key := range c.flagServiceMetaFromNodeLabels {
+		if label == "" || key == "" {
+			return fmt.Errorf("-service-meta-from-node-label must be formatted as label=key, got %q", label+"="+key)
+		}
+	}
+	for label := range c.flagServiceTagsFromLabels {
+		if label == "" {
+			return errors.New("-service-tag-from-label must be formatted as label=prefix with a non-empty label")
+		}
+	}
+	for annotation, name := range c.flagAnnotationsToEnv {
+		if annotation == "" || name == "" {
+			return fmt.Errorf("-annotation-to-env must be formatted as annotation=NAME, got %q", annotation+"="+name)
+		}
+		if errs := validation.IsEnvVarName(name); len(errs) > 0 {
+			return fmt.Errorf("-annotation-to-env environment variable name %q is invalid: %s", name, strings.Join(errs, "; "))
+		}
+	}
+	for annotation, label := range c.flagAnnotationsToLabels {
+		if annotation == "" || label == "" {
+			return fmt.Errorf("-annotation-to-label must be formatted as annotation=label, got %q", annotation+"="+label)
+		}
+		if errs := validation.IsQualifiedName(label); len(errs) > 0 {
+			return fmt.Errorf("-annotation-to-label label %q is invalid: %s", label, strings.Join(errs, "; "))
+		}
+	}
+	if c.flagDefaultSidecarProxyLifecyclePreStopDrainSeconds < 0 {
+		return errors.New("-default-sidecar-proxy-lifecycle-pre-stop-drain-seconds must be >= 0 if set")
+	}
+
 	// Validate ports in metrics flags.
 	err := common.ValidateUnprivilegedPort("-default-merged-metrics-port", c.flagDefaultMergedMetricsPort)
 	if err != nil {
@@ -500,6 +925,29 @@ func (c *Command) parseAndValidateSidecarProxyFlags() error {
 			c.flagDefaultSidecarProxyMemoryRequest, c.flagDefaultSidecarProxyMemoryLimit)
 	}
 
+	c.sidecarProxyResourceAutoSizing.Enabled = c.flagEnableSidecarProxyResourceAutoSizing
+	for _, f := range []struct {
+		name   string
+		value  string
+		target *resource.Quantity
+	}{
+		{"sidecar-proxy-cpu-request-per-upstream", c.flagSidecarProxyCPURequestPerUpstream, &c.sidecarProxyResourceAutoSizing.CPUPerUpstream},
+		{"sidecar-proxy-memory-request-per-upstream", c.flagSidecarProxyMemoryRequestPerUpstream, &c.sidecarProxyResourceAutoSizing.MemoryPerUpstream},
+		{"sidecar-proxy-cpu-request-per-listener", c.flagSidecarProxyCPURequestPerListener, &c.sidecarProxyResourceAutoSizing.CPUPerListener},
+		{"sidecar-proxy-memory-request-per-listener", c.flagSidecarProxyMemoryRequestPerListener, &c.sidecarProxyResourceAutoSizing.MemoryPerListener},
+	} {
+		if f.value == "" {
+			continue
+		}
+		*f.target, err = resource.ParseQuantity(f.value)
+		if err != nil {
+			return fmt.Errorf("-%s is invalid: %w", f.name, err)
+		}
+		if f.target.Sign() < 0 {
+			return fmt.Errorf("-%s must be >= 0, got %q", f.name, f.value)
+		}
+	}
+
 	return nil
 }
 
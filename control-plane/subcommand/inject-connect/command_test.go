@@ -48,6 +48,96 @@ func TestRun_FlagValidation(t *testing.T) {
 				"-partition", "default"},
 			expErr: "-enable-partitions must be set to 'true' if -partition is set",
 		},
+		{
+			flags: []string{"-consul-k8s-image", "foo", "-consul-image", "foo", "-consul-dataplane-image", "consul-dataplane:1.14.0",
+				"-partition-mapping-configmap", "consul-partition-mapping"},
+			expErr: "-enable-partitions must be set to 'true' if -partition-mapping-configmap is set",
+		},
+		{
+			flags: []string{"-consul-k8s-image", "foo", "-consul-image", "foo", "-consul-dataplane-image", "consul-dataplane:1.14.0",
+				"-enable-partitions", "-partition", "ap1", "-partition-mapping-configmap", "consul-partition-mapping", "-acl-auth-method", "consul-k8s-auth-method"},
+			expErr: "-partition must be \"default\" if -partition-mapping-configmap is set with -acl-auth-method",
+		},
+		{
+			flags: []string{"-consul-k8s-image", "foo", "-consul-image", "foo", "-consul-dataplane-image", "consul-dataplane:1.14.0",
+				"-k8s-namespace-mirroring-prefix", "{k8s-namespace}-{cluster}"},
+			expErr: `-k8s-namespace-mirroring-prefix is invalid: mirroring prefix "{k8s-namespace}-{cluster}" contains an unknown variable, only {k8s-namespace} is supported`,
+		},
+		{
+			flags: []string{"-consul-k8s-image", "foo", "-consul-image", "foo", "-consul-dataplane-image", "consul-dataplane:1.14.0",
+				"-kube-dns-stub-domain", "consul"},
+			expErr: "-kube-dns-stub-domain and -kube-dns-stub-domain-service must be set together",
+		},
+		{
+			flags: []string{"-consul-k8s-image", "foo", "-consul-image", "foo", "-consul-dataplane-image", "consul-dataplane:1.14.0",
+				"-endpoints-max-concurrent-reconciles", "0"},
+			expErr: "-endpoints-max-concurrent-reconciles must be at least 1",
+		},
+		{
+			flags: []string{"-consul-k8s-image", "foo", "-consul-image", "foo", "-consul-dataplane-image", "consul-dataplane:1.14.0",
+				"-endpoints-consul-write-rate-limit", "-1"},
+			expErr: "-endpoints-consul-write-rate-limit must not be negative",
+		},
+		{
+			flags: []string{"-consul-k8s-image", "foo", "-consul-image", "foo", "-consul-dataplane-image", "consul-dataplane:1.14.0",
+				"-endpoints-consul-write-rate-limit", "50", "-endpoints-consul-write-burst", "0"},
+			expErr: "-endpoints-consul-write-burst must be at least 1 if -endpoints-consul-write-rate-limit is set",
+		},
+		{
+			flags: []string{"-consul-k8s-image", "foo", "-consul-image", "foo", "-consul-dataplane-image", "consul-dataplane:1.14.0",
+				"-consul-circuit-breaker-failure-threshold", "-1"},
+			expErr: "-consul-circuit-breaker-failure-threshold must not be negative",
+		},
+		{
+			flags: []string{"-consul-k8s-image", "foo", "-consul-image", "foo", "-consul-dataplane-image", "consul-dataplane:1.14.0",
+				"-consul-circuit-breaker-open-duration", "0s"},
+			expErr: "-consul-circuit-breaker-open-duration must be positive if -consul-circuit-breaker-failure-threshold is set",
+		},
+		{
+			flags: []string{"-consul-k8s-image", "foo", "-consul-image", "foo", "-consul-dataplane-image", "consul-dataplane:1.14.0",
+				"-endpoints-orphan-reap-interval", "-1m"},
+			expErr: "-endpoints-orphan-reap-interval must not be negative",
+		},
+		{
+			flags: []string{"-consul-k8s-image", "foo", "-consul-image", "foo", "-consul-dataplane-image", "consul-dataplane:1.14.0",
+				"-endpoints-shard-config-map", "consul-connect-inject-endpoints-shards", "-enable-resource-apis"},
+			expErr: "-endpoints-shard-config-map is not supported with -enable-resource-apis",
+		},
+		{
+			flags: []string{"-consul-k8s-image", "foo", "-consul-image", "foo", "-consul-dataplane-image", "consul-dataplane:1.14.0",
+				"-node-naming-strategy", "per-pod"},
+			expErr: `-node-naming-strategy is invalid: node naming strategy "per-pod" must be one of "per-node", "per-cluster" or "per-namespace"`,
+		},
+		{
+			flags: []string{"-consul-k8s-image", "foo", "-consul-image", "foo", "-consul-dataplane-image", "consul-dataplane:1.14.0",
+				"-annotation-to-env", "example.com/cost-center=COST CENTER"},
+			expErr: `-annotation-to-env environment variable name "COST CENTER" is invalid`,
+		},
+		{
+			flags: []string{"-consul-k8s-image", "foo", "-consul-image", "foo", "-consul-dataplane-image", "consul-dataplane:1.14.0",
+				"-annotation-to-label", "example.com/cost-center="},
+			expErr: `-annotation-to-label must be formatted as annotation=label, got "example.com/cost-center="`,
+		},
+		{
+			flags: []string{"-consul-k8s-image", "foo", "-consul-image", "foo", "-consul-dataplane-image", "consul-dataplane:1.14.0",
+				"-primary-ip-family", "ipv6"},
+			expErr: `-primary-ip-family must be "IPv4" or "IPv6", got "ipv6"`,
+		},
+		{
+			flags: []string{"-consul-k8s-image", "foo", "-consul-image", "foo", "-consul-dataplane-image", "consul-dataplane:1.14.0",
+				"-gateway-wan-address-health-check-timeout", "-1s"},
+			expErr: "-gateway-wan-address-health-check-timeout must not be negative",
+		},
+		{
+			flags: []string{"-consul-k8s-image", "foo", "-consul-image", "foo", "-consul-dataplane-image", "consul-dataplane:1.14.0",
+				"-login-token-audience", "consul"},
+			expErr: "-login-token-auth-method must be set if -login-token-audience is set",
+		},
+		{
+			flags: []string{"-consul-k8s-image", "foo", "-consul-image", "foo", "-consul-dataplane-image", "consul-dataplane:1.14.0",
+				"-login-token-audience", "consul", "-login-token-auth-method", "jwt", "-login-token-expiration-seconds", "60"},
+			expErr: "-login-token-expiration-seconds must be at least 600",
+		},
 		{
 			flags: []string{"-consul-k8s-image", "foo", "-consul-image", "foo", "-consul-dataplane-image", "consul-dataplane:1.14.0",
 				"-default-sidecar-proxy-cpu-limit=unparseable"},
@@ -75,6 +165,16 @@ func TestRun_FlagValidation(t *testing.T) {
 			},
 			expErr: "request must be <= limit: -default-sidecar-proxy-memory-request value of \"50Mi\" is greater than the -default-sidecar-proxy-memory-limit value of \"25Mi\"",
 		},
+		{
+			flags: []string{"-consul-k8s-image", "foo", "-consul-image", "foo", "-consul-dataplane-image", "consul-dataplane:1.14.0",
+				"-sidecar-proxy-memory-request-per-upstream=unparseable"},
+			expErr: "-sidecar-proxy-memory-request-per-upstream is invalid",
+		},
+		{
+			flags: []string{"-consul-k8s-image", "foo", "-consul-image", "foo", "-consul-dataplane-image", "consul-dataplane:1.14.0",
+				"-sidecar-proxy-cpu-request-per-listener=-5m"},
+			expErr: "-sidecar-proxy-cpu-request-per-listener must be >= 0, got \"-5m\"",
+		},
 		{
 			flags: []string{"-consul-k8s-image", "foo", "-consul-image", "foo", "-consul-dataplane-image", "consul-dataplane:1.14.0",
 				"-default-sidecar-proxy-cpu-request=50m",
@@ -132,12 +232,54 @@ func TestRun_FlagValidation(t *testing.T) {
 			},
 			expErr: "-default-envoy-proxy-concurrency must be >= 0 if set",
 		},
+		{
+			flags: []string{"-consul-k8s-image", "foo", "-consul-image", "foo", "-consul-dataplane-image", "consul-dataplane:1.14.0",
+				"-max-upstreams=-1",
+			},
+			expErr: "-max-upstreams must be >= 0 if set",
+		},
+		{
+			flags: []string{"-consul-k8s-image", "foo", "-consul-image", "foo", "-consul-dataplane-image", "consul-dataplane:1.14.0",
+				"-max-upstreams-annotation-size=-1",
+			},
+			expErr: "-max-upstreams-annotation-size must be >= 0 if set",
+		},
+		{
+			flags: []string{"-consul-k8s-image", "foo", "-consul-image", "foo", "-consul-dataplane-image", "consul-dataplane:1.14.0",
+				"-default-sidecar-proxy-lifecycle-pre-stop-drain-seconds=-1",
+			},
+			expErr: "-default-sidecar-proxy-lifecycle-pre-stop-drain-seconds must be >= 0 if set",
+		},
+		{
+			flags: []string{"-consul-k8s-image", "foo", "-consul-image", "foo", "-consul-dataplane-image", "consul-dataplane:1.14.0",
+				"-service-meta-from-label", "app.kubernetes.io/version=",
+			},
+			expErr: `-service-meta-from-label must be formatted as label=key, got "app.kubernetes.io/version="`,
+		},
+		{
+			flags: []string{"-consul-k8s-image", "foo", "-consul-image", "foo", "-consul-dataplane-image", "consul-dataplane:1.14.0",
+				"-service-meta-from-node-label", "=rack",
+			},
+			expErr: `-service-meta-from-node-label must be formatted as label=key, got "=rack"`,
+		},
+		{
+			flags: []string{"-consul-k8s-image", "foo", "-consul-image", "foo", "-consul-dataplane-image", "consul-dataplane:1.14.0",
+				"-service-tag-from-label", "=version-",
+			},
+			expErr: "-service-tag-from-label must be formatted as label=prefix with a non-empty label",
+		},
 		{
 			flags: []string{"-consul-k8s-image", "foo", "-consul-image", "foo", "-consul-dataplane-image", "consul-dataplane:1.14.0",
 				"-global-image-pull-policy", "garbage",
 			},
 			expErr: "-global-image-pull-policy must be `IfNotPresent`, `Always`, `Never`, or `` ",
 		},
+		{
+			flags: []string{"-consul-k8s-image", "foo", "-consul-image", "foo", "-consul-dataplane-image", "consul-dataplane:1.14.0",
+				"-consul-dns-redirection-mode", "garbage",
+			},
+			expErr: "-consul-dns-redirection-mode must be \"iptables\", \"dns-config\" or \"capture\"",
+		},
 	}
 
 	for _, c := range cases {
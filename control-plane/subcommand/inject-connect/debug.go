@@ -0,0 +1,132 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package connectinject
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net"
+	"net/http"
+	"net/http/pprof"
+	"runtime"
+	"time"
+
+	"github.com/go-logr/logr"
+)
+
+const (
+	// debugBindAddress is where the pprof and runtime diagnostics endpoints are served. It is only
+	// bound to localhost so that it can only be reached from within the pod, e.g. with a port-forward.
+	debugBindAddress = "127.0.0.1:9446"
+
+	// debugShutdownTimeout is how long in-flight requests, e.g. CPU profiles, are given to complete on shutdown.
+	debugShutdownTimeout = 5 * time.Second
+)
+
+// debugServer serves the net/http/pprof profiles and runtime statistics of the connect injector.
+type debugServer struct {
+	// Addr is the address to listen on.
+	Addr string
+	Log  logr.Logger
+
+	// startTime is when the server was created, to report the uptime.
+	startTime time.Time
+}
+
+// runtimeStats is the body of the /debug/runtime endpoint.
+type runtimeStats struct {
+	GoVersion     string  `json:"goVersion"`
+	GOMAXPROCS    int     `json:"gomaxprocs"`
+	NumCPU        int     `json:"numCPU"`
+	NumGoroutine  int     `json:"numGoroutine"`
+	UptimeSeconds float64 `json:"uptimeSeconds"`
+	// Memory statistics in bytes.
+	HeapAlloc   uint64 `json:"heapAllocBytes"`
+	HeapInuse   uint64 `json:"heapInuseBytes"`
+	HeapObjects uint64 `json:"heapObjects"`
+	StackInuse  uint64 `json:"stackInuseBytes"`
+	Sys         uint64 `json:"sysBytes"`
+	// Garbage collection statistics.
+	NumGC         uint32  `json:"numGC"`
+	PauseTotalNs  uint64  `json:"gcPauseTotalNs"`
+	GCCPUFraction float64 `json:"gcCPUFraction"`
+}
+
+// handler returns the handler of the pprof and runtime diagnostics endpoints.
+func (d *debugServer) handler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/debug/pprof/", pprof.Index)
+	mux.HandleFunc("/debug/pprof/cmdline", pprof.Cmdline)
+	mux.HandleFunc("/debug/pprof/profile", pprof.Profile)
+	mux.HandleFunc("/debug/pprof/symbol", pprof.Symbol)
+	mux.HandleFunc("/debug/pprof/trace", pprof.Trace)
+	mux.HandleFunc("/debug/runtime", d.serveRuntime)
+	return mux
+}
+
+// serveRuntime serves the runtime statistics as JSON.
+func (d *debugServer) serveRuntime(w http.ResponseWriter, _ *http.Request) {
+	var mem runtime.MemStats
+	runtime.ReadMemStats(&mem)
+	body, err := json.Marshal(runtimeStats{
+		GoVersion:     runtime.Version(),
+		GOMAXPROCS:    runtime.GOMAXPROCS(0),
+		NumCPU:        runtime.NumCPU(),
+		NumGoroutine:  runtime.NumGoroutine(),
+		UptimeSeconds: time.Since(d.startTime).Seconds(),
+		HeapAlloc:     mem.HeapAlloc,
+		HeapInuse:     mem.HeapInuse,
+		HeapObjects:   mem.HeapObjects,
+		StackInuse:    mem.StackInuse,
+		Sys:           mem.Sys,
+		NumGC:         mem.NumGC,
+		PauseTotalNs:  mem.PauseTotalNs,
+		GCCPUFraction: mem.GCCPUFraction,
+	})
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	_, _ = w.Write(body)
+}
+
+// Start serves the endpoints until ctx is cancelled.
+func (d *debugServer) Start(ctx context.Context) error {
+	if d.startTime.IsZero() {
+		d.startTime = time.Now()
+	}
+	listener, err := net.Listen("tcp", d.Addr)
+	if err != nil {
+		return err
+	}
+	srv := &http.Server{
+		Handler:           d.handler(),
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+
+	errCh := make(chan error, 1)
+	go func() {
+		d.Log.Info("serving pprof and runtime diagnostics", "address", listener.Addr().String())
+		errCh <- srv.Serve(listener)
+	}()
+
+	select {
+	case err := <-errCh:
+		return err
+	case <-ctx.Done():
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), debugShutdownTimeout)
+		defer cancel()
+		if err := srv.Shutdown(shutdownCtx); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			return err
+		}
+		return nil
+	}
+}
+
+// NeedLeaderElection implements manager.LeaderElectionRunnable so that every replica can be profiled.
+func (d *debugServer) NeedLeaderElection() bool {
+	return false
+}
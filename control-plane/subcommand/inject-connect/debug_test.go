@@ -0,0 +1,60 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package connectinject
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"runtime"
+	"testing"
+	"time"
+
+	"github.com/go-logr/logr"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDebugServer_Handler(t *testing.T) {
+	d := &debugServer{startTime: time.Now().Add(-time.Minute)}
+	srv := httptest.NewServer(d.handler())
+	defer srv.Close()
+
+	resp, err := http.Get(srv.URL + "/debug/runtime")
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	require.Equal(t, "application/json", resp.Header.Get("Content-Type"))
+
+	var stats runtimeStats
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&stats))
+	require.Equal(t, runtime.Version(), stats.GoVersion)
+	require.Positive(t, stats.NumGoroutine)
+	require.Positive(t, stats.HeapAlloc)
+	require.GreaterOrEqual(t, stats.UptimeSeconds, time.Minute.Seconds())
+
+	for _, path := range []string{"/debug/pprof/", "/debug/pprof/heap", "/debug/pprof/goroutine", "/debug/pprof/cmdline"} {
+		resp, err := http.Get(srv.URL + path)
+		require.NoError(t, err)
+		resp.Body.Close()
+		require.Equal(t, http.StatusOK, resp.StatusCode, path)
+	}
+}
+
+func TestDebugServer_Start(t *testing.T) {
+	d := &debugServer{Addr: "127.0.0.1:0", Log: logr.Discard()}
+	require.False(t, d.NeedLeaderElection())
+
+	ctx, cancel := context.WithCancel(context.Background())
+	errCh := make(chan error, 1)
+	go func() { errCh <- d.Start(ctx) }()
+
+	cancel()
+	select {
+	case err := <-errCh:
+		require.NoError(t, err)
+	case <-time.After(10 * time.Second):
+		t.Fatal("debug server did not stop")
+	}
+}
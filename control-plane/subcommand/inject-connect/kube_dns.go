@@ -0,0 +1,107 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package connectinject
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"slices"
+	"time"
+
+	"github.com/go-logr/logr"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"github.com/hashicorp/consul-k8s/control-plane/connect-inject/common"
+)
+
+const (
+	// kubeDNSStubDomainRefreshInterval is how often the stub domain is reconciled with the DNS proxy Service.
+	kubeDNSStubDomainRefreshInterval = 30 * time.Second
+
+	// kubeDNSConfigMapName and kubeDNSConfigMapNamespace identify the ConfigMap that kube-dns
+	// reads its stub domains from.
+	kubeDNSConfigMapName      = "kube-dns"
+	kubeDNSConfigMapNamespace = "kube-system"
+
+	// kubeDNSStubDomainsKey is the key of the stub domains in the kube-dns ConfigMap. Its value
+	// is a JSON map of domains to the nameservers that queries for the domain are forwarded to.
+	kubeDNSStubDomainsKey = "stubDomains"
+)
+
+// kubeDNSStubDomain makes kube-dns forward the queries for the Consul domain to the DNS proxy by
+// adding a stub domain to the kube-dns ConfigMap. The stub domain points at the cluster IP of the
+// DNS proxy Service so that, when the DNS proxy runs on every node, the queries are answered on
+// the node of the kube-dns pod instead of being sent to the Consul servers through cluster DNS.
+// The other stub domains of the ConfigMap are kept.
+type kubeDNSStubDomain struct {
+	// Reader reads the ConfigMap and the Service. It should not be cached.
+	Reader client.Reader
+	// Writer updates the ConfigMap.
+	Writer client.Writer
+	// Domain is the Consul DNS domain, e.g. "consul".
+	Domain string
+	// ServiceName is the name of the DNS proxy Service.
+	ServiceName string
+	// ServiceNamespace is the namespace of the DNS proxy Service.
+	ServiceNamespace string
+	Log              logr.Logger
+}
+
+// Refresh points the stub domain at the cluster IP of the DNS proxy Service if it doesn't already.
+func (k *kubeDNSStubDomain) Refresh(ctx context.Context) error {
+	var service corev1.Service
+	if err := k.Reader.Get(ctx, types.NamespacedName{Name: k.ServiceName, Namespace: k.ServiceNamespace}, &service); err != nil {
+		return fmt.Errorf("failed to get DNS proxy Service %s/%s: %w", k.ServiceNamespace, k.ServiceName, err)
+	}
+	clusterIP := service.Spec.ClusterIP
+	if clusterIP == "" || clusterIP == corev1.ClusterIPNone {
+		return fmt.Errorf("DNS proxy Service %s/%s has no cluster IP", k.ServiceNamespace, k.ServiceName)
+	}
+
+	var configMap corev1.ConfigMap
+	if err := k.Reader.Get(ctx, types.NamespacedName{Name: kubeDNSConfigMapName, Namespace: kubeDNSConfigMapNamespace}, &configMap); err != nil {
+		return fmt.Errorf("failed to get ConfigMap %s/%s: %w", kubeDNSConfigMapNamespace, kubeDNSConfigMapName, err)
+	}
+	stubDomains := make(map[string][]string)
+	if raw := configMap.Data[kubeDNSStubDomainsKey]; raw != "" {
+		if err := json.Unmarshal([]byte(raw), &stubDomains); err != nil {
+			return fmt.Errorf("ConfigMap %s/%s has invalid %s: %w", kubeDNSConfigMapNamespace, kubeDNSConfigMapName, kubeDNSStubDomainsKey, err)
+		}
+	}
+	nameservers := []string{clusterIP}
+	if slices.Equal(stubDomains[k.Domain], nameservers) {
+		return nil
+	}
+
+	stubDomains[k.Domain] = nameservers
+	raw, err := json.Marshal(stubDomains)
+	if err != nil {
+		return err
+	}
+	if configMap.Data == nil {
+		configMap.Data = make(map[string]string)
+	}
+	configMap.Data[kubeDNSStubDomainsKey] = string(raw)
+	if err := k.Writer.Update(ctx, &configMap); err != nil {
+		return fmt.Errorf("failed to update ConfigMap %s/%s: %w", kubeDNSConfigMapNamespace, kubeDNSConfigMapName, err)
+	}
+	k.Log.Info("configured kube-dns stub domain", "domain", k.Domain, "nameserver", clusterIP)
+	return nil
+}
+
+// Poller returns the runnable that refreshes the stub domain so that it follows the DNS proxy Service
+// and is restored if the ConfigMap is overwritten, e.g. by a cluster upgrade. Only the leader updates
+// the ConfigMap.
+func (k *kubeDNSStubDomain) Poller() *common.ConfigMapPoller {
+	return &common.ConfigMapPoller{
+		Refresh:        k.Refresh,
+		Interval:       kubeDNSStubDomainRefreshInterval,
+		RefreshOnStart: true,
+		LeaderElection: true,
+		Log:            k.Log,
+	}
+}
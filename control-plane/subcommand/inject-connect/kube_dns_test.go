@@ -0,0 +1,65 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package connectinject
+
+import (
+	"context"
+	"testing"
+
+	logrtest "github.com/go-logr/logr/testr"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+func TestKubeDNSStubDomain(t *testing.T) {
+	service := &corev1.Service{
+		ObjectMeta: metav1.ObjectMeta{Name: "consul-dns-proxy", Namespace: "consul"},
+		Spec:       corev1.ServiceSpec{ClusterIP: "10.0.0.53"},
+	}
+	configMap := &corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{Name: "kube-dns", Namespace: "kube-system"},
+		Data:       map[string]string{"stubDomains": `{"acme.local": ["1.2.3.4"]}`},
+	}
+	k8sClient := fake.NewClientBuilder().WithObjects(service, configMap).Build()
+	stubDomain := &kubeDNSStubDomain{
+		Reader:           k8sClient,
+		Writer:           k8sClient,
+		Domain:           "consul",
+		ServiceName:      "consul-dns-proxy",
+		ServiceNamespace: "consul",
+		Log:              logrtest.New(t),
+	}
+	stubDomains := func() string {
+		var actual corev1.ConfigMap
+		require.NoError(t, k8sClient.Get(context.Background(), types.NamespacedName{Name: "kube-dns", Namespace: "kube-system"}, &actual))
+		return actual.Data["stubDomains"]
+	}
+
+	// The stub domain is added and the other stub domains are kept.
+	require.NoError(t, stubDomain.Refresh(context.Background()))
+	require.JSONEq(t, `{"acme.local": ["1.2.3.4"], "consul": ["10.0.0.53"]}`, stubDomains())
+
+	// The stub domain follows the cluster IP of the Service.
+	service.Spec.ClusterIP = "10.0.0.54"
+	require.NoError(t, k8sClient.Update(context.Background(), service))
+	require.NoError(t, stubDomain.Refresh(context.Background()))
+	require.JSONEq(t, `{"acme.local": ["1.2.3.4"], "consul": ["10.0.0.54"]}`, stubDomains())
+
+	// Invalid stub domains are not overwritten.
+	require.NoError(t, k8sClient.Get(context.Background(), types.NamespacedName{Name: "kube-dns", Namespace: "kube-system"}, configMap))
+	configMap.Data = map[string]string{"stubDomains": "acme.local"}
+	require.NoError(t, k8sClient.Update(context.Background(), configMap))
+	err := stubDomain.Refresh(context.Background())
+	require.ErrorContains(t, err, "ConfigMap kube-system/kube-dns has invalid stubDomains")
+	require.Equal(t, "acme.local", stubDomains())
+
+	// A headless Service can't be a nameserver.
+	service.Spec.ClusterIP = corev1.ClusterIPNone
+	require.NoError(t, k8sClient.Update(context.Background(), service))
+	err = stubDomain.Refresh(context.Background())
+	require.EqualError(t, err, "DNS proxy Service consul/consul-dns-proxy has no cluster IP")
+}
@@ -0,0 +1,231 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package connectinject
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/go-logr/logr"
+	"github.com/prometheus/client_golang/prometheus"
+	coordinationv1 "k8s.io/api/coordination/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	ctrlmetrics "sigs.k8s.io/controller-runtime/pkg/metrics"
+)
+
+const (
+	// leaderElectionID is the name of the Lease used for the leader election of the controller manager.
+	leaderElectionID = "consul-controller-lock"
+
+	// leaderStatusInterval is how often the leader election Lease and the reconcile lag are refreshed.
+	leaderStatusInterval = 10 * time.Second
+
+	// workqueueQueueDurationMetric is the histogram of the time items wait in the controller queues,
+	// exported by controller-runtime.
+	workqueueQueueDurationMetric = "workqueue_queue_duration_seconds"
+)
+
+var (
+	// isLeader is whether this replica is the leader of the controller manager.
+	isLeader = prometheus.NewGauge(prometheus.GaugeOpts{
+		Name: "consul_connect_inject_leader_is_leader",
+		Help: "Whether this replica of the connect injector is the leader of the controller manager (1) or not (0).",
+	})
+	// leaderChanges is the number of leader changes observed by this replica.
+	leaderChanges = prometheus.NewCounter(prometheus.CounterOpts{
+		Name: "consul_connect_inject_leader_changes_total",
+		Help: "Number of changes of the leader of the controller manager observed by this replica of the connect injector.",
+	})
+	// leaseTransitions is the number of leader transitions recorded in the leader election Lease.
+	leaseTransitions = prometheus.NewGauge(prometheus.GaugeOpts{
+		Name: "consul_connect_inject_leader_lease_transitions",
+		Help: "Number of leader transitions recorded in the leader election Lease of the connect injector.",
+	})
+	// leaseRenewAge is the time since the leader last renewed the leader election Lease.
+	leaseRenewAge = prometheus.NewGauge(prometheus.GaugeOpts{
+		Name: "consul_connect_inject_leader_lease_renew_age_seconds",
+		Help: "Seconds since the leader of the connect injector last renewed the leader election Lease.",
+	})
+	// reconcileLag is the average time items waited in a controller queue before being reconciled.
+	reconcileLag = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "consul_connect_inject_reconcile_lag_seconds",
+		Help: "Average seconds items waited in the queue of a controller before being reconciled since the last refresh.",
+	}, []string{"controller"})
+)
+
+func init() {
+	ctrlmetrics.Registry.MustRegister(isLeader, leaderChanges, leaseTransitions, leaseRenewAge, reconcileLag)
+}
+
+// leaderStatus tracks the leader election of the controller manager and the reconcile lag of its controllers.
+// It exports them as metrics and serves them as JSON so that operators running multiple replicas can alert
+// on flapping leadership.
+type leaderStatus struct {
+	// Client reads the leader election Lease. It should not be cached.
+	Client client.Reader
+	// Namespace is the namespace of the leader election Lease.
+	Namespace string
+	// Elected is closed when this replica becomes the leader.
+	Elected <-chan struct{}
+	// Gatherer gathers the controller-runtime workqueue metrics.
+	Gatherer prometheus.Gatherer
+	Log      logr.Logger
+
+	mu          sync.RWMutex
+	status      leaderStatusResponse
+	queueTotals map[string]queueDurationTotal
+}
+
+// leaderStatusResponse is the body of the /leader endpoint.
+type leaderStatusResponse struct {
+	// IsLeader is whether this replica is the leader.
+	IsLeader bool `json:"isLeader"`
+	// Leader is the identity of the current leader.
+	Leader string `json:"leader"`
+	// LeaseTransitions is the number of leader transitions recorded in the Lease.
+	LeaseTransitions int32 `json:"leaseTransitions"`
+	// AcquireTime is when the current leader acquired the Lease.
+	AcquireTime *time.Time `json:"acquireTime,omitempty"`
+	// RenewTime is when the current leader last renewed the Lease.
+	RenewTime *time.Time `json:"renewTime,omitempty"`
+	// ReconcileLagSeconds is the average time items waited in the queue of each controller since the last refresh.
+	ReconcileLagSeconds map[string]float64 `json:"reconcileLagSeconds"`
+}
+
+// queueDurationTotal is the sum and count of the queue duration histogram of a controller.
+type queueDurationTotal struct {
+	sum   float64
+	count uint64
+}
+
+// Start refreshes the status until ctx is cancelled.
+func (l *leaderStatus) Start(ctx context.Context) error {
+	ticker := time.NewTicker(leaderStatusInterval)
+	defer ticker.Stop()
+	elected := l.Elected
+	for {
+		l.refresh(ctx)
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-elected:
+			l.mu.Lock()
+			l.status.IsLeader = true
+			l.mu.Unlock()
+			isLeader.Set(1)
+			// A closed channel is always ready, so stop selecting on it.
+			elected = nil
+		case <-ticker.C:
+		}
+	}
+}
+
+// NeedLeaderElection implements manager.LeaderElectionRunnable so that the status is tracked on all replicas.
+func (l *leaderStatus) NeedLeaderElection() bool {
+	return false
+}
+
+// refresh updates the status from the leader election Lease and the workqueue metrics.
+func (l *leaderStatus) refresh(ctx context.Context) {
+	var lease coordinationv1.Lease
+	err := l.Client.Get(ctx, types.NamespacedName{Name: leaderElectionID, Namespace: l.Namespace}, &lease)
+	if err != nil {
+		l.Log.Error(err, "failed to get leader election lease", "name", leaderElectionID, "namespace", l.Namespace)
+	}
+	lag := l.reconcileLag()
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.status.ReconcileLagSeconds = lag
+	if err != nil {
+		return
+	}
+
+	var holder string
+	if lease.Spec.HolderIdentity != nil {
+		holder = *lease.Spec.HolderIdentity
+	}
+	if l.status.Leader != "" && holder != l.status.Leader {
+		leaderChanges.Inc()
+		l.Log.Info("leader of the controller manager changed", "previous", l.status.Leader, "leader", holder)
+	}
+	l.status.Leader = holder
+	if lease.Spec.LeaseTransitions != nil {
+		l.status.LeaseTransitions = *lease.Spec.LeaseTransitions
+	}
+	leaseTransitions.Set(float64(l.status.LeaseTransitions))
+	l.status.AcquireTime = nil
+	if lease.Spec.AcquireTime != nil {
+		l.status.AcquireTime = &lease.Spec.AcquireTime.Time
+	}
+	l.status.RenewTime = nil
+	if lease.Spec.RenewTime != nil {
+		l.status.RenewTime = &lease.Spec.RenewTime.Time
+		leaseRenewAge.Set(time.Since(lease.Spec.RenewTime.Time).Seconds())
+	}
+}
+
+// reconcileLag returns the average time items waited in the queue of each controller since the last call,
+// computed from the queue duration histograms exported by controller-runtime.
+func (l *leaderStatus) reconcileLag() map[string]float64 {
+	lag := make(map[string]float64)
+	if l.Gatherer == nil {
+		return lag
+	}
+	families, err := l.Gatherer.Gather()
+	if err != nil {
+		l.Log.Error(err, "failed to gather workqueue metrics")
+		return lag
+	}
+
+	totals := make(map[string]queueDurationTotal)
+	for _, family := range families {
+		if family.GetName() != workqueueQueueDurationMetric {
+			continue
+		}
+		for _, m := range family.GetMetric() {
+			var name string
+			for _, label := range m.GetLabel() {
+				if label.GetName() == "name" {
+					name = label.GetValue()
+				}
+			}
+			totals[name] = queueDurationTotal{sum: m.GetHistogram().GetSampleSum(), count: m.GetHistogram().GetSampleCount()}
+		}
+	}
+
+	l.mu.RLock()
+	previous := l.queueTotals
+	l.mu.RUnlock()
+	for name, total := range totals {
+		prev := previous[name]
+		if total.count > prev.count {
+			lag[name] = (total.sum - prev.sum) / float64(total.count-prev.count)
+		} else {
+			lag[name] = 0
+		}
+		reconcileLag.WithLabelValues(name).Set(lag[name])
+	}
+	l.mu.Lock()
+	l.queueTotals = totals
+	l.mu.Unlock()
+	return lag
+}
+
+// ServeHTTP serves the status as JSON.
+func (l *leaderStatus) ServeHTTP(w http.ResponseWriter, _ *http.Request) {
+	l.mu.RLock()
+	body, err := json.Marshal(l.status)
+	l.mu.RUnlock()
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	_, _ = w.Write(body)
+}
@@ -0,0 +1,93 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package connectinject
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	logrtest "github.com/go-logr/logr/testr"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/stretchr/testify/require"
+	coordinationv1 "k8s.io/api/coordination/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/utils/ptr"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+func TestLeaderStatus(t *testing.T) {
+	renewTime := metav1.NewMicroTime(time.Now().Add(-2 * time.Second))
+	lease := &coordinationv1.Lease{
+		ObjectMeta: metav1.ObjectMeta{Name: leaderElectionID, Namespace: "consul"},
+		Spec: coordinationv1.LeaseSpec{
+			HolderIdentity:   ptr.To("consul-connect-injector-abc_1234"),
+			LeaseTransitions: ptr.To(int32(3)),
+			AcquireTime:      ptr.To(metav1.NewMicroTime(time.Now().Add(-time.Minute))),
+			RenewTime:        &renewTime,
+		},
+	}
+	k8sClient := fake.NewClientBuilder().WithScheme(scheme).WithObjects(lease).Build()
+
+	registry := prometheus.NewRegistry()
+	queueDuration := prometheus.NewHistogramVec(prometheus.HistogramOpts{Name: workqueueQueueDurationMetric}, []string{"name"})
+	registry.MustRegister(queueDuration)
+	queueDuration.WithLabelValues("endpoints").Observe(1)
+	queueDuration.WithLabelValues("endpoints").Observe(3)
+
+	elected := make(chan struct{})
+	status := &leaderStatus{
+		Client:    k8sClient,
+		Namespace: "consul",
+		Elected:   elected,
+		Gatherer:  registry,
+		Log:       logrtest.New(t),
+	}
+
+	status.refresh(context.Background())
+	resp := getLeaderStatus(t, status)
+	require.False(t, resp.IsLeader)
+	require.Equal(t, "consul-connect-injector-abc_1234", resp.Leader)
+	require.Equal(t, int32(3), resp.LeaseTransitions)
+	require.NotNil(t, resp.RenewTime)
+	require.Equal(t, map[string]float64{"endpoints": 2}, resp.ReconcileLagSeconds)
+
+	// The reconcile lag only includes items queued since the last refresh.
+	queueDuration.WithLabelValues("endpoints").Observe(5)
+	lease.Spec.HolderIdentity = ptr.To("consul-connect-injector-def_5678")
+	lease.Spec.LeaseTransitions = ptr.To(int32(4))
+	require.NoError(t, k8sClient.Update(context.Background(), lease))
+	status.refresh(context.Background())
+	resp = getLeaderStatus(t, status)
+	require.Equal(t, "consul-connect-injector-def_5678", resp.Leader)
+	require.Equal(t, int32(4), resp.LeaseTransitions)
+	require.Equal(t, map[string]float64{"endpoints": 5}, resp.ReconcileLagSeconds)
+
+	status.refresh(context.Background())
+	resp = getLeaderStatus(t, status)
+	require.Equal(t, map[string]float64{"endpoints": 0}, resp.ReconcileLagSeconds)
+
+	// The replica is the leader once it is elected.
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error)
+	go func() { done <- status.Start(ctx) }()
+	close(elected)
+	require.Eventually(t, func() bool { return getLeaderStatus(t, status).IsLeader }, 5*time.Second, 10*time.Millisecond)
+	cancel()
+	require.NoError(t, <-done)
+}
+
+func getLeaderStatus(t *testing.T, status *leaderStatus) leaderStatusResponse {
+	rec := httptest.NewRecorder()
+	status.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/leader", nil))
+	require.Equal(t, http.StatusOK, rec.Code)
+	require.Equal(t, "application/json", rec.Header().Get("Content-Type"))
+
+	var resp leaderStatusResponse
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &resp))
+	return resp
+}
@@ -0,0 +1,88 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package connectinject
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/go-logr/logr"
+	"github.com/hashicorp/go-hclog"
+	"go.uber.org/zap"
+	corev1 "k8s.io/api/core/v1"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	injectcommon "github.com/hashicorp/consul-k8s/control-plane/connect-inject/common"
+	"github.com/hashicorp/consul-k8s/control-plane/subcommand/common"
+)
+
+const (
+	// logLevelRefreshInterval is how often the log level is re-read from its ConfigMap.
+	logLevelRefreshInterval = 10 * time.Second
+
+	// logLevelConfigMapKey is the key of the log level in its ConfigMap.
+	logLevelConfigMapKey = "logLevel"
+)
+
+// logLevelConfig sets the log level of the connect injector from a ConfigMap so that it can be
+// changed while the injector is running, e.g. to debug an incident, without restarting it.
+// The level is read from the logLevel key of the ConfigMap. If the ConfigMap or the key doesn't
+// exist, DefaultLevel is used.
+type logLevelConfig struct {
+	// Client reads the ConfigMap. It should not be cached.
+	Client client.Reader
+	// Name is the name of the ConfigMap.
+	Name string
+	// Namespace is the namespace of the ConfigMap.
+	Namespace string
+	// DefaultLevel is the log level set by the -log-level flag.
+	DefaultLevel string
+	// ZapLevel is the level of the controller logger.
+	ZapLevel zap.AtomicLevel
+	// HCLog is the logger of the Consul server connection manager.
+	HCLog hclog.Logger
+	Log   logr.Logger
+
+	level string
+}
+
+// Refresh reads the log level from the ConfigMap and sets it if it changed.
+// If the level is invalid, the current level is kept.
+func (l *logLevelConfig) Refresh(ctx context.Context) error {
+	level := l.DefaultLevel
+	var configMap corev1.ConfigMap
+	err := l.Client.Get(ctx, types.NamespacedName{Name: l.Name, Namespace: l.Namespace}, &configMap)
+	if err != nil && !k8serrors.IsNotFound(err) {
+		return fmt.Errorf("failed to get log level ConfigMap %s/%s: %w", l.Namespace, l.Name, err)
+	}
+	if err == nil && configMap.Data[logLevelConfigMapKey] != "" {
+		level = configMap.Data[logLevelConfigMapKey]
+	}
+	if level == l.level {
+		return nil
+	}
+
+	zapLevel, err := common.ZapLevel(level)
+	if err != nil {
+		return fmt.Errorf("log level ConfigMap %s/%s is invalid: %w", l.Namespace, l.Name, err)
+	}
+	l.ZapLevel.SetLevel(zapLevel)
+	if hcLevel := hclog.LevelFromString(level); l.HCLog != nil && hcLevel != hclog.NoLevel {
+		l.HCLog.SetLevel(hcLevel)
+	}
+	if l.level != "" {
+		l.Log.Info("changed log level", "level", level)
+	}
+	l.level = level
+	return nil
+}
+
+// Poller returns the runnable that refreshes the log level. It runs on every replica since every
+// replica serves the webhook.
+func (l *logLevelConfig) Poller() *injectcommon.ConfigMapPoller {
+	return &injectcommon.ConfigMapPoller{Refresh: l.Refresh, Interval: logLevelRefreshInterval, Log: l.Log}
+}
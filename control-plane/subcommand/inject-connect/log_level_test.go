@@ -0,0 +1,79 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package connectinject
+
+import (
+	"context"
+	"testing"
+
+	logrtest "github.com/go-logr/logr/testr"
+	"github.com/hashicorp/go-hclog"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+func TestLogLevelConfig(t *testing.T) {
+	k8sClient := fake.NewClientBuilder().Build()
+	hcLog := hclog.New(&hclog.LoggerOptions{Level: hclog.Info})
+	cfg := &logLevelConfig{
+		Client:       k8sClient,
+		Name:         "consul-connect-injector-log-level",
+		Namespace:    "consul",
+		DefaultLevel: "info",
+		ZapLevel:     zap.NewAtomicLevelAt(zapcore.InfoLevel),
+		HCLog:        hcLog,
+		Log:          logrtest.New(t),
+	}
+
+	// Without the ConfigMap, the default level is used.
+	require.NoError(t, cfg.Refresh(context.Background()))
+	require.Equal(t, zapcore.InfoLevel, cfg.ZapLevel.Level())
+	require.Equal(t, hclog.Info, hcLog.GetLevel())
+
+	// The level is changed when the ConfigMap is created.
+	configMap := &corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{Name: "consul-connect-injector-log-level", Namespace: "consul"},
+		Data:       map[string]string{"logLevel": "debug"},
+	}
+	require.NoError(t, k8sClient.Create(context.Background(), configMap))
+	require.NoError(t, cfg.Refresh(context.Background()))
+	require.Equal(t, zapcore.DebugLevel, cfg.ZapLevel.Level())
+	require.Equal(t, hclog.Debug, hcLog.GetLevel())
+
+	// An invalid level is rejected and the current level is kept.
+	configMap.Data = map[string]string{"logLevel": "verbose"}
+	require.NoError(t, k8sClient.Update(context.Background(), configMap))
+	err := cfg.Refresh(context.Background())
+	require.ErrorContains(t, err, `log level ConfigMap consul/consul-connect-injector-log-level is invalid: unknown log level "verbose"`)
+	require.Equal(t, zapcore.DebugLevel, cfg.ZapLevel.Level())
+	require.Equal(t, hclog.Debug, hcLog.GetLevel())
+
+	// "trace" is debug for the controller logger.
+	configMap.Data = map[string]string{"logLevel": "trace"}
+	require.NoError(t, k8sClient.Update(context.Background(), configMap))
+	require.NoError(t, cfg.Refresh(context.Background()))
+	require.Equal(t, zapcore.DebugLevel, cfg.ZapLevel.Level())
+	require.Equal(t, hclog.Trace, hcLog.GetLevel())
+
+	// The default level is used again when the key is removed.
+	configMap.Data = nil
+	require.NoError(t, k8sClient.Update(context.Background(), configMap))
+	require.NoError(t, cfg.Refresh(context.Background()))
+	require.Equal(t, zapcore.InfoLevel, cfg.ZapLevel.Level())
+	require.Equal(t, hclog.Info, hcLog.GetLevel())
+
+	// The default level is used when the ConfigMap is deleted.
+	configMap.Data = map[string]string{"logLevel": "error"}
+	require.NoError(t, k8sClient.Update(context.Background(), configMap))
+	require.NoError(t, cfg.Refresh(context.Background()))
+	require.Equal(t, zapcore.ErrorLevel, cfg.ZapLevel.Level())
+	require.NoError(t, k8sClient.Delete(context.Background(), configMap))
+	require.NoError(t, cfg.Refresh(context.Background()))
+	require.Equal(t, zapcore.InfoLevel, cfg.ZapLevel.Level())
+	require.Equal(t, hclog.Info, hcLog.GetLevel())
+}
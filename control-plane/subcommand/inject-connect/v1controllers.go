@@ -19,22 +19,38 @@ import (
 	apicommon "github.com/hashicorp/consul-k8s/control-plane/api/common"
 	"github.com/hashicorp/consul-k8s/control-plane/api/v1alpha1"
 	"github.com/hashicorp/consul-k8s/control-plane/catalog/registration"
+	"github.com/hashicorp/consul-k8s/control-plane/connect-inject/common"
 	"github.com/hashicorp/consul-k8s/control-plane/connect-inject/controllers/endpoints"
 	"github.com/hashicorp/consul-k8s/control-plane/connect-inject/controllers/peering"
+	"github.com/hashicorp/consul-k8s/control-plane/connect-inject/controllers/workload"
 	"github.com/hashicorp/consul-k8s/control-plane/connect-inject/lifecycle"
 	"github.com/hashicorp/consul-k8s/control-plane/connect-inject/metrics"
 	"github.com/hashicorp/consul-k8s/control-plane/connect-inject/webhook"
+	"github.com/hashicorp/consul-k8s/control-plane/consul"
+	"github.com/hashicorp/consul-k8s/control-plane/controllers/carotation"
 	controllers "github.com/hashicorp/consul-k8s/control-plane/controllers/configentries"
+	"github.com/hashicorp/consul-k8s/control-plane/controllers/snapshotschedule"
+	"github.com/hashicorp/consul-k8s/control-plane/controllers/telemetrycollectorconfig"
 	webhookconfiguration "github.com/hashicorp/consul-k8s/control-plane/helper/webhook-configuration"
 	"github.com/hashicorp/consul-k8s/control-plane/subcommand/flags"
 )
 
-func (c *Command) configureControllers(ctx context.Context, mgr manager.Manager, watcher *discovery.Watcher) error {
+func (c *Command) configureControllers(ctx context.Context, mgr manager.Manager, serverWatcher *discovery.Watcher) error {
 	// Create Consul API config object.
 	consulConfig := c.consul.ConsulClientConfig()
 
+	// Requests to the Consul servers are rejected while they are unreachable so that the controllers
+	// back off instead of retrying in a hot loop.
+	if err := consulConfig.UseCircuitBreaker(consul.NewCircuitBreaker(c.flagCircuitBreakerFailureThreshold, c.flagCircuitBreakerOpenDuration)); err != nil {
+		setupLog.Error(err, "unable to set up the Consul client transport")
+		return err
+	}
+	watcher := consulConfig.CircuitBreaker.ServerConnectionManager(serverWatcher)
+
 	type FileConfig struct {
-		ImagePullSecrets []v1.LocalObjectReference `json:"image_pull_secrets"`
+		ImagePullSecrets              []v1.LocalObjectReference                       `json:"image_pull_secrets"`
+		ConsulDataplaneImageOverrides map[string]webhook.ConsulDataplaneImageOverride `json:"consul_dataplane_image_overrides"`
+		ArchitectureProfiles          map[string]webhook.ArchitectureProfile          `json:"architecture_profiles"`
 	}
 
 	var cfgFile FileConfig
@@ -54,6 +70,87 @@ func (c *Command) configureControllers(ctx context.Context, mgr manager.Manager,
 	allowK8sNamespaces := flags.ToSet(c.flagAllowK8sNamespacesList)
 	denyK8sNamespaces := flags.ToSet(c.flagDenyK8sNamespacesList)
 
+	// Resolve the Admin Partition of each Kubernetes namespace from the mapping ConfigMap if one is set.
+	// The mapping is read once before the webhook and controllers start so that no pod is injected or
+	// registered with the wrong partition, and is then refreshed in the background.
+	var partitionMapping *common.PartitionMapping
+	if c.flagPartitionMappingConfigMap != "" {
+		partitionMapping = &common.PartitionMapping{
+			Client:           mgr.GetAPIReader(),
+			Name:             c.flagPartitionMappingConfigMap,
+			Namespace:        c.flagReleaseNamespace,
+			DefaultPartition: c.consul.Partition,
+			Log:              ctrl.Log.WithName("partition-mapping"),
+		}
+		if err := partitionMapping.Refresh(ctx); err != nil {
+			setupLog.Error(err, "unable to read partition mapping")
+			return err
+		}
+		if err := mgr.Add(partitionMapping.Poller()); err != nil {
+			setupLog.Error(err, "unable to add partition mapping to the manager")
+			return err
+		}
+
+		// Pods in mapped namespaces log in to their partition, so the auth methods they log in
+		// with are copied to the mapped partitions.
+		if c.flagACLAuthMethod != "" {
+			partitionAuthMethods := &common.PartitionAuthMethods{
+				ConsulClientConfig:      consulConfig,
+				ConsulServerConnMgr:     watcher,
+				AuthMethods:             c.partitionAuthMethods(),
+				Namespace:               c.authMethodNamespace(),
+				CrossNamespaceACLPolicy: c.flagCrossNamespaceACLPolicy,
+				Mapping:                 partitionMapping,
+				Log:                     ctrl.Log.WithName("partition-auth-methods"),
+			}
+			if err := mgr.Add(partitionAuthMethods.Poller()); err != nil {
+				setupLog.Error(err, "unable to add partition auth methods to the manager")
+				return err
+			}
+		}
+	}
+
+	// Pause the changes to the Consul catalog while the servers are in maintenance. The mode is read
+	// before the controllers start so that a restart during maintenance doesn't change the catalog.
+	var maintenanceMode *common.MaintenanceMode
+	if c.flagMaintenanceModeConfigMap != "" {
+		maintenanceMode = &common.MaintenanceMode{
+			Client:    mgr.GetAPIReader(),
+			Name:      c.flagMaintenanceModeConfigMap,
+			Namespace: c.flagReleaseNamespace,
+			Log:       ctrl.Log.WithName("maintenance-mode"),
+		}
+		if err := maintenanceMode.Refresh(ctx); err != nil {
+			setupLog.Error(err, "unable to read maintenance mode")
+			return err
+		}
+		if err := mgr.Add(maintenanceMode.Poller()); err != nil {
+			setupLog.Error(err, "unable to add maintenance mode to the manager")
+			return err
+		}
+	}
+
+	// Queue the Endpoints that can't be reconciled while the Consul servers are unreachable so that they
+	// are reconciled as soon as the servers are reachable again, even if the injector restarted meanwhile.
+	var pendingRegistrations *common.PendingRegistrations
+	if c.flagPendingRegistrationsConfigMap != "" {
+		pendingRegistrations = &common.PendingRegistrations{
+			Reader:    mgr.GetAPIReader(),
+			Writer:    mgr.GetClient(),
+			Name:      c.flagPendingRegistrationsConfigMap,
+			Namespace: c.flagReleaseNamespace,
+			Reachable: func() bool { return consulConfig.RetryAfter() == 0 },
+			Log:       ctrl.Log.WithName("pending-registrations"),
+		}
+		if c.endpointsShard != nil {
+			pendingRegistrations.Owns = c.endpointsShard.Owns
+		}
+		if err := mgr.Add(pendingRegistrations); err != nil {
+			setupLog.Error(err, "unable to add pending registrations to the manager")
+			return err
+		}
+	}
+
 	lifecycleConfig := lifecycle.Config{
 		DefaultEnableProxyLifecycle:         c.flagDefaultEnableSidecarProxyLifecycle,
 		DefaultEnableShutdownDrainListeners: c.flagDefaultEnableSidecarProxyLifecycleShutdownDrainListeners,
@@ -62,6 +159,7 @@ func (c *Command) configureControllers(ctx context.Context, mgr manager.Manager,
 		DefaultGracefulPort:                 c.flagDefaultSidecarProxyLifecycleGracefulPort,
 		DefaultGracefulShutdownPath:         c.flagDefaultSidecarProxyLifecycleGracefulShutdownPath,
 		DefaultGracefulStartupPath:          c.flagDefaultSidecarProxyLifecycleGracefulStartupPath,
+		DefaultPreStopDrainSeconds:          c.flagDefaultSidecarProxyLifecyclePreStopDrainSeconds,
 	}
 
 	metricsConfig := metrics.Config{
@@ -73,35 +171,76 @@ func (c *Command) configureControllers(ctx context.Context, mgr manager.Manager,
 		DefaultPrometheusScrapePath: c.flagDefaultPrometheusScrapePath,
 	}
 
-	if err := (&endpoints.Controller{
-		Client:                     mgr.GetClient(),
-		ConsulClientConfig:         consulConfig,
-		ConsulServerConnMgr:        watcher,
-		AllowK8sNamespacesSet:      allowK8sNamespaces,
-		DenyK8sNamespacesSet:       denyK8sNamespaces,
-		MetricsConfig:              metricsConfig,
-		EnableConsulPartitions:     c.flagEnablePartitions,
-		EnableConsulNamespaces:     c.flagEnableNamespaces,
-		ConsulDestinationNamespace: c.flagConsulDestinationNamespace,
-		EnableNSMirroring:          c.flagEnableK8SNSMirroring,
-		NSMirroringPrefix:          c.flagK8SNSMirroringPrefix,
-		CrossNSACLPolicy:           c.flagCrossNamespaceACLPolicy,
-		LifecycleConfig:            lifecycleConfig,
-		EnableTransparentProxy:     c.flagDefaultEnableTransparentProxy,
-		EnableWANFederation:        c.flagEnableFederation,
-		TProxyOverwriteProbes:      c.flagTransparentProxyDefaultOverwriteProbes,
-		AuthMethod:                 c.flagACLAuthMethod,
-		NodeMeta:                   c.flagNodeMeta,
-		Log:                        ctrl.Log.WithName("controller").WithName("endpoints"),
-		Scheme:                     mgr.GetScheme(),
-		ReleaseName:                c.flagReleaseName,
-		ReleaseNamespace:           c.flagReleaseNamespace,
-		EnableAutoEncrypt:          c.flagEnableAutoEncrypt,
-		EnableTelemetryCollector:   c.flagEnableTelemetryCollector,
-		Context:                    ctx,
-	}).SetupWithManager(mgr); err != nil {
-		setupLog.Error(err, "unable to create controller", "controller", endpoints.Controller{})
-		return err
+	if c.flagEnableResourceAPIs {
+		// With resource APIs, pods are written to Consul as workloads instead of being
+		// registered as services from their endpoints.
+		if err := (&workload.Controller{
+			Client:                     mgr.GetClient(),
+			ConsulServerConnMgr:        watcher,
+			AllowK8sNamespacesSet:      allowK8sNamespaces,
+			DenyK8sNamespacesSet:       denyK8sNamespaces,
+			EnableConsulPartitions:     c.flagEnablePartitions,
+			ConsulPartition:            c.consul.Partition,
+			EnableConsulNamespaces:     c.flagEnableNamespaces,
+			ConsulDestinationNamespace: c.flagConsulDestinationNamespace,
+			EnableNSMirroring:          c.flagEnableK8SNSMirroring,
+			NSMirroringPrefix:          c.flagK8SNSMirroringPrefix,
+			Log:                        ctrl.Log.WithName("controller").WithName("workload"),
+		}).SetupWithManager(mgr); err != nil {
+			setupLog.Error(err, "unable to create controller", "controller", workload.Controller{})
+			return err
+		}
+	} else {
+		endpointsController := &endpoints.Controller{
+			Client:                     mgr.GetClient(),
+			ConsulClientConfig:         consulConfig,
+			ConsulServerConnMgr:        watcher,
+			AllowK8sNamespacesSet:      allowK8sNamespaces,
+			DenyK8sNamespacesSet:       denyK8sNamespaces,
+			MetricsConfig:              metricsConfig,
+			EnableConsulPartitions:     c.flagEnablePartitions,
+			PartitionMapping:           partitionMapping,
+			EnableConsulNamespaces:     c.flagEnableNamespaces,
+			ConsulDestinationNamespace: c.flagConsulDestinationNamespace,
+			EnableNSMirroring:          c.flagEnableK8SNSMirroring,
+			NSMirroringPrefix:          c.flagK8SNSMirroringPrefix,
+			CrossNSACLPolicy:           c.flagCrossNamespaceACLPolicy,
+			LifecycleConfig:            lifecycleConfig,
+			EnableTransparentProxy:     c.flagDefaultEnableTransparentProxy,
+			EnableWANFederation:        c.flagEnableFederation,
+			TProxyOverwriteProbes:      c.flagTransparentProxyDefaultOverwriteProbes,
+			AuthMethod:                 c.injectAuthMethod(),
+			NodeMeta:                   c.flagNodeMeta,
+			ServiceMetaFromLabels:      c.flagServiceMetaFromLabels,
+			ServiceMetaFromNodeLabels:  c.flagServiceMetaFromNodeLabels,
+			ServiceTagsFromLabels:      c.flagServiceTagsFromLabels,
+			Log:                        ctrl.Log.WithName("controller").WithName("endpoints"),
+			Scheme:                     mgr.GetScheme(),
+			ReleaseName:                c.flagReleaseName,
+			ReleaseNamespace:           c.flagReleaseNamespace,
+			EnableAutoEncrypt:          c.flagEnableAutoEncrypt,
+			EnableTelemetryCollector:   c.flagEnableTelemetryCollector,
+			EnableArgoRollouts:         c.flagEnableArgoRollouts,
+			MaxConcurrentReconciles:    c.flagEndpointsMaxConcurrentReconciles,
+			ConsulWriteLimiter:         endpoints.NewConsulWriteLimiter(c.flagEndpointsConsulWriteRateLimit, c.flagEndpointsConsulWriteBurst),
+			OrphanReapInterval:         c.flagEndpointsOrphanReapInterval,
+			OrphanReapDryRun:           c.flagEndpointsOrphanReapDryRun,
+			MaintenanceMode:            maintenanceMode,
+			PendingRegistrations:       pendingRegistrations,
+			PrimaryIPFamily:            v1.IPFamily(c.flagPrimaryIPFamily),
+			Shard:                      c.endpointsShard,
+			Context:                    ctx,
+
+			GatewayWANAddressResolvePeriod:      c.flagGatewayWANAddressResolvePeriod,
+			GatewayWANAddressHealthCheckTimeout: c.flagGatewayWANAddressHealthCheckTimeout,
+		}
+		if c.flagEnableDeregistrationEvents {
+			endpointsController.EventRecorder = mgr.GetEventRecorderFor("consul-endpoints-controller")
+		}
+		if err := endpointsController.SetupWithManager(mgr); err != nil {
+			setupLog.Error(err, "unable to create controller", "controller", endpoints.Controller{})
+			return err
+		}
 	}
 
 	// API Gateway Controllers
@@ -156,6 +295,7 @@ func (c *Command) configureControllers(ctx context.Context, mgr manager.Manager,
 			DefaultPrometheusScrapePath: c.flagDefaultPrometheusScrapePath,
 			DefaultPrometheusScrapePort: c.flagDefaultPrometheusScrapePort,
 			InitContainerResources:      &c.initContainerResources,
+			NodeNamingStrategy:          common.NodeNamingStrategy(c.flagNodeNamingStrategy),
 		},
 		AllowK8sNamespacesSet:   allowK8sNamespaces,
 		DenyK8sNamespacesSet:    denyK8sNamespaces,
@@ -188,6 +328,8 @@ func (c *Command) configureControllers(ctx context.Context, mgr manager.Manager,
 		EnableNSMirroring:          c.flagEnableK8SNSMirroring,
 		NSMirroringPrefix:          c.flagK8SNSMirroringPrefix,
 		CrossNSACLPolicy:           c.flagCrossNamespaceACLPolicy,
+		EventRecorder:              mgr.GetEventRecorderFor("consul-config-entry-controller"),
+		DryRun:                     c.flagConfigEntryDryRun,
 	}
 	if err := (&controllers.ServiceDefaultsController{
 		ConfigEntryController: configEntryReconciler,
@@ -318,6 +460,57 @@ func (c *Command) configureControllers(ctx context.Context, mgr manager.Manager,
 		return err
 	}
 
+	if err := (&registration.ExternalServicesController{
+		Client:              mgr.GetClient(),
+		ConsulClientConfig:  consulConfig,
+		ConsulServerConnMgr: watcher,
+		Scheme:              mgr.GetScheme(),
+		Log:                 ctrl.Log.WithName("controller").WithName(apicommon.ExternalService),
+	}).SetupWithManager(ctx, mgr); err != nil {
+		setupLog.Error(err, "unable to create controller", "controller", apicommon.ExternalService)
+		return err
+	}
+
+	consulHTTPScheme := "http"
+	if c.consul.UseTLS {
+		consulHTTPScheme = "https"
+	}
+	if err := (&snapshotschedule.Controller{
+		Client:              mgr.GetClient(),
+		ConsulClientConfig:  consulConfig,
+		ConsulServerConnMgr: watcher,
+		ConsulImage:         c.flagConsulImage,
+		ImagePullPolicy:     c.flagGlobalImagePullPolicy,
+		ConsulHTTPAddr:      fmt.Sprintf("%s://%s:%d", consulHTTPScheme, c.consul.Addresses, consulConfig.HTTPPort),
+		ConsulTLSServerName: c.consul.TLSServerName,
+		ConsulCACert:        string(c.caCertPem),
+		Scheme:              mgr.GetScheme(),
+		Log:                 ctrl.Log.WithName("controller").WithName(apicommon.ConsulSnapshotSchedule),
+	}).SetupWithManager(mgr); err != nil {
+		setupLog.Error(err, "unable to create controller", "controller", apicommon.ConsulSnapshotSchedule)
+		return err
+	}
+
+	if err := (&carotation.Controller{
+		Client:              mgr.GetClient(),
+		ConsulClientConfig:  consulConfig,
+		ConsulServerConnMgr: watcher,
+		Scheme:              mgr.GetScheme(),
+		Log:                 ctrl.Log.WithName("controller").WithName(apicommon.ConnectCARotation),
+	}).SetupWithManager(mgr); err != nil {
+		setupLog.Error(err, "unable to create controller", "controller", apicommon.ConnectCARotation)
+		return err
+	}
+
+	if err := (&telemetrycollectorconfig.Controller{
+		Client: mgr.GetClient(),
+		Scheme: mgr.GetScheme(),
+		Log:    ctrl.Log.WithName("controller").WithName(apicommon.TelemetryCollectorConfig),
+	}).SetupWithManager(mgr); err != nil {
+		setupLog.Error(err, "unable to create controller", "controller", apicommon.TelemetryCollectorConfig)
+		return err
+	}
+
 	if err := mgr.AddReadyzCheck("ready", webhook.ReadinessCheck{CertDir: c.flagCertDir}.Ready); err != nil {
 		setupLog.Error(err, "unable to create readiness check", "controller", endpoints.Controller{})
 		return err
@@ -362,16 +555,24 @@ func (c *Command) configureControllers(ctx context.Context, mgr manager.Manager,
 
 	(&webhook.MeshWebhook{
 		Clientset:                                 c.clientset,
+		Client:                                    mgr.GetClient(),
 		ReleaseNamespace:                          c.flagReleaseNamespace,
 		ConsulConfig:                              consulConfig,
 		ConsulServerConnMgr:                       watcher,
 		ImageConsul:                               c.flagConsulImage,
 		ImageConsulDataplane:                      c.flagConsulDataplaneImage,
+		ConsulDataplaneImageOverrides:             cfgFile.ConsulDataplaneImageOverrides,
+		ArchitectureProfiles:                      cfgFile.ArchitectureProfiles,
+		AnnotationsToEnv:                          c.flagAnnotationsToEnv,
+		AnnotationsToLabels:                       c.flagAnnotationsToLabels,
+		NodeNamingStrategy:                        common.NodeNamingStrategy(c.flagNodeNamingStrategy),
 		EnvoyExtraArgs:                            c.flagEnvoyExtraArgs,
 		ImageConsulK8S:                            c.flagConsulK8sImage,
 		GlobalImagePullPolicy:                     c.flagGlobalImagePullPolicy,
 		RequireAnnotation:                         !c.flagDefaultInject,
-		AuthMethod:                                c.flagACLAuthMethod,
+		AuthMethod:                                c.injectAuthMethod(),
+		LoginTokenAudience:                        c.flagLoginTokenAudience,
+		LoginTokenExpirationSeconds:               c.flagLoginTokenExpirationSeconds,
 		ConsulCACert:                              string(c.caCertPem),
 		TLSEnabled:                                c.consul.UseTLS,
 		ConsulAddress:                             c.consul.Addresses,
@@ -381,13 +582,20 @@ func (c *Command) configureControllers(ctx context.Context, mgr manager.Manager,
 		DefaultProxyCPULimit:                      c.sidecarProxyCPULimit,
 		DefaultProxyMemoryRequest:                 c.sidecarProxyMemoryRequest,
 		DefaultProxyMemoryLimit:                   c.sidecarProxyMemoryLimit,
+		ProxyResourceAutoSizing:                   c.sidecarProxyResourceAutoSizing,
 		DefaultEnvoyProxyConcurrency:              c.flagDefaultEnvoyProxyConcurrency,
+		MaxUpstreams:                              c.flagMaxUpstreams,
+		MaxUpstreamsAnnotationSize:                c.flagMaxUpstreamsAnnotationSize,
 		DefaultSidecarProxyStartupFailureSeconds:  c.flagDefaultSidecarProxyStartupFailureSeconds,
 		DefaultSidecarProxyLivenessFailureSeconds: c.flagDefaultSidecarProxyLivenessFailureSeconds,
+		EnableNativeSidecarsForJobs:               c.flagEnableNativeSidecarsForJobs,
+		EnableDataplaneLogin:                      c.flagEnableDataplaneLogin,
 		LifecycleConfig:                           lifecycleConfig,
+		EnableSleepAction:                         c.enableSleepAction,
 		MetricsConfig:                             metricsConfig,
 		InitContainerResources:                    c.initContainerResources,
 		ConsulPartition:                           c.consul.Partition,
+		PartitionMapping:                          partitionMapping,
 		AllowK8sNamespacesSet:                     allowK8sNamespaces,
 		DenyK8sNamespacesSet:                      denyK8sNamespaces,
 		EnableNamespaces:                          c.flagEnableNamespaces,
@@ -399,7 +607,9 @@ func (c *Command) configureControllers(ctx context.Context, mgr manager.Manager,
 		EnableCNI:                                 c.flagEnableCNI,
 		TProxyOverwriteProbes:                     c.flagTransparentProxyDefaultOverwriteProbes,
 		EnableConsulDNS:                           c.flagEnableConsulDNS,
+		ConsulDNSRedirectionMode:                  c.flagConsulDNSRedirectionMode,
 		EnableOpenShift:                           c.flagEnableOpenShift,
+		EnableResourceAPIs:                        c.flagEnableResourceAPIs,
 		Log:                                       ctrl.Log.WithName("handler").WithName("connect"),
 		LogLevel:                                  c.flagLogLevel,
 		LogJSON:                                   c.flagLogJSON,
@@ -506,6 +716,9 @@ func (c *Command) configureControllers(ctx context.Context, mgr manager.Manager,
 		ConsulMeta: consulMeta,
 	}).SetupWithManager(mgr)
 
+	// Converts config entries between the versions of their CRDs for the CRDs with a webhook conversion strategy.
+	v1alpha1.RegisterConversionWebhook(mgr)
+
 	if c.flagEnableWebhookCAUpdate {
 		err = c.updateWebhookCABundle(ctx)
 		if err != nil {
@@ -530,3 +743,32 @@ func (c *Command) updateWebhookCABundle(ctx context.Context) error {
 	}
 	return nil
 }
+
+// injectAuthMethod returns the name of the auth method that injected pods log in with.
+func (c *Command) injectAuthMethod() string {
+	if c.flagLoginTokenAudience != "" {
+		return c.flagLoginTokenAuthMethod
+	}
+	return c.flagACLAuthMethod
+}
+
+// partitionAuthMethods returns the names of the auth methods that are copied to the mapped
+// partitions: the kubernetes auth method, and the auth method of the login token audience if it is set.
+func (c *Command) partitionAuthMethods() []string {
+	authMethods := []string{c.flagACLAuthMethod}
+	if c.flagLoginTokenAudience != "" {
+		authMethods = append(authMethods, c.flagLoginTokenAuthMethod)
+	}
+	return authMethods
+}
+
+// authMethodNamespace returns the Consul namespace of the auth methods that injected pods log in with.
+func (c *Command) authMethodNamespace() string {
+	if !c.flagEnableNamespaces {
+		return ""
+	}
+	if c.flagEnableK8SNSMirroring {
+		return "default"
+	}
+	return c.flagConsulDestinationNamespace
+}
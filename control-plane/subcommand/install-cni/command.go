@@ -48,6 +48,16 @@ type Command struct {
 	flagLogJSON bool
 	// flagMultus is a boolean flag for multus support.
 	flagMultus bool
+	// flagLogFile is the file on the host the plugin writes its logs to.
+	flagLogFile string
+	// flagLogRotateMaxBytes is the size the plugin's log file can reach before it is rotated.
+	flagLogRotateMaxBytes int64
+	// flagLogRotateMaxFiles is the number of rotated plugin log files to keep.
+	flagLogRotateMaxFiles int
+	// flagMetricsPort is the port the installer serves its prometheus metrics on. Metrics are not served if it is empty.
+	flagMetricsPort string
+	// flagMetricsPath is the path the installer serves its prometheus metrics on.
+	flagMetricsPath string
 
 	flagSet *flag.FlagSet
 
@@ -55,6 +65,10 @@ type Command struct {
 	help   string
 	logger hclog.Logger
 	sigCh  chan os.Signal
+
+	// installed is true once the consul-cni plugin configuration has been installed in the config file.
+	// Any later change to the config file that removes or modifies it is counted as drift.
+	installed bool
 }
 
 func (c *Command) init() {
@@ -68,6 +82,15 @@ func (c *Command) init() {
 			"\"debug\", \"info\", \"warn\", and \"error\".")
 	c.flagSet.BoolVar(&c.flagLogJSON, "log-json", defaultLogJSON, "Enable or disable JSON output format for logging.")
 	c.flagSet.BoolVar(&c.flagMultus, "multus", config.DefaultMultus, "If the plugin is a multus plugin (default = false)")
+	c.flagSet.StringVar(&c.flagLogFile, "log-file", "",
+		"File on the host the plugin writes its logs to in JSON. If not set, the plugin logs to stderr.")
+	c.flagSet.Int64Var(&c.flagLogRotateMaxBytes, "log-rotate-max-bytes", config.DefaultLogRotateMaxBytes,
+		"Size in bytes the plugin's log file can reach before it is rotated.")
+	c.flagSet.IntVar(&c.flagLogRotateMaxFiles, "log-rotate-max-files", config.DefaultLogRotateMaxFiles,
+		"Number of rotated plugin log files to keep.")
+	c.flagSet.StringVar(&c.flagMetricsPort, "metrics-port", "",
+		"Port to serve prometheus metrics on. If not set, metrics are not served.")
+	c.flagSet.StringVar(&c.flagMetricsPath, "metrics-path", "/metrics", "Path to serve prometheus metrics on.")
 
 	c.help = flags.Usage(help, c.flagSet)
 
@@ -108,6 +131,11 @@ func (c *Command) Run(args []string) int {
 		LogLevel:   c.flagLogLevel,
 		Multus:     c.flagMultus,
 	}
+	if c.flagLogFile != "" {
+		cfg.LogFile = c.flagLogFile
+		cfg.LogRotateMaxBytes = c.flagLogRotateMaxBytes
+		cfg.LogRotateMaxFiles = c.flagLogRotateMaxFiles
+	}
 
 	c.logger.Info("Running CNI install with configuration",
 		"name", cfg.Name,
@@ -116,7 +144,16 @@ func (c *Command) Run(args []string) int {
 		"cni_net_dir", cfg.CNINetDir,
 		"multus", cfg.Multus,
 		"kubeconfig", cfg.Kubeconfig,
-		"log_level", cfg.LogLevel)
+		"log_level", cfg.LogLevel,
+		"log_file", cfg.LogFile)
+
+	if c.flagMetricsPort != "" {
+		if _, valid := common.ParseScrapePort(c.flagMetricsPort); !valid {
+			c.logger.Error("-metrics-port must be a valid unprivileged port number", "port", c.flagMetricsPort)
+			return 1
+		}
+		go c.serveMetrics()
+	}
 
 	ctx, cancel := context.WithCancel(context.Background())
 	defer cancel()
@@ -177,6 +214,7 @@ func (c *Command) Run(args []string) int {
 					return 1
 				}
 			}
+			c.installed = true
 		}
 	} else {
 		// When multus is enabled, the plugin configuration is set in a NetworkAttachementDefinition CRD and multus
@@ -215,6 +253,8 @@ func (c *Command) cleanup(cfg *config.CNIConfig, cfgFile string) {
 // directoryWatcher watches for changes in the cniNetDir forever. We watch the directory because there is a case where
 // the installer could be the first cni plugin installed and we need to wait for another plugin to show up. Once
 // installed we watch for changes, verify that our plugin installation is valid and re-install the consul-cni config.
+// A config file that no longer has a valid consul-cni config after it was installed, e.g. because another CNI
+// manager rewrote it, is counted as drift.
 func (c *Command) directoryWatcher(ctx context.Context, cfg *config.CNIConfig, dir, cfgFile string) error {
 	watcher, err := fsnotify.NewWatcher()
 	if err != nil {
@@ -226,7 +266,6 @@ func (c *Command) directoryWatcher(ctx context.Context, cfg *config.CNIConfig, d
 	if err != nil {
 		return fmt.Errorf("could not watch %s directory: %w", dir, err)
 	}
-	//}
 
 	// Cannot do "_ = defer watcher.Close()".
 	defer func() {
@@ -242,7 +281,9 @@ func (c *Command) directoryWatcher(ctx context.Context, cfg *config.CNIConfig, d
 			// For every event, get the config file, validate it and append the CNI configuration. If no
 			// config file is available, do nothing. This can happen if the consul-cni daemonset was
 			// created before other CNI plugins were installed.
-			if event.Op&(fsnotify.Create|fsnotify.Write|fsnotify.Remove) != 0 {
+			// Other CNI managers often replace the config file by renaming a temporary file over it, so renames
+			// are handled as well.
+			if event.Op&(fsnotify.Create|fsnotify.Write|fsnotify.Remove|fsnotify.Rename) != 0 {
 				// Only repair things if this is a non-multus setup. Multus config is handled differently
 				// than chained plugins
 				if !cfg.Multus {
@@ -268,6 +309,10 @@ func (c *Command) directoryWatcher(ctx context.Context, cfg *config.CNIConfig, d
 
 						err = validConfig(cfg, cfgFile)
 						if err != nil {
+							if c.installed {
+								c.logger.Info("CNI config drift detected", "file", cfgFile, "reason", err)
+								configDrift.Inc()
+							}
 							// The invalid config is not critical and we can recover from it.
 							c.logger.Info("Installing plugin", "reason", err)
 							err = appendCNIConfig(cfg, cfgFile)
@@ -275,7 +320,9 @@ func (c *Command) directoryWatcher(ctx context.Context, cfg *config.CNIConfig, d
 								c.logger.Error("Unable to install consul-cni config", "error", err)
 								return err
 							}
+							c.installed = true
 						} else {
+							c.installed = true
 							c.logger.Info("Valid config file detected, nothing to do")
 							break
 						}
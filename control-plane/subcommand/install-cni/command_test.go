@@ -14,6 +14,7 @@ import (
 	"github.com/hashicorp/consul-k8s/control-plane/cni/config"
 	"github.com/hashicorp/serf/testutil/retry"
 	"github.com/mitchellh/cli"
+	"github.com/prometheus/client_golang/prometheus/testutil"
 	"github.com/stretchr/testify/require"
 
 	"github.com/hashicorp/consul-k8s/control-plane/subcommand/common"
@@ -30,6 +31,11 @@ func TestRun_FlagDefaults(t *testing.T) {
 	require.Equal(t, cmd.flagLogLevel, config.DefaultLogLevel)
 	require.Equal(t, cmd.flagLogJSON, defaultLogJSON)
 	require.Equal(t, cmd.flagMultus, config.DefaultMultus)
+	require.Equal(t, cmd.flagLogFile, "")
+	require.Equal(t, cmd.flagLogRotateMaxBytes, int64(config.DefaultLogRotateMaxBytes))
+	require.Equal(t, cmd.flagLogRotateMaxFiles, config.DefaultLogRotateMaxFiles)
+	require.Equal(t, cmd.flagMetricsPort, "")
+	require.Equal(t, cmd.flagMetricsPath, "/metrics")
 }
 
 func TestRun_DirectoryWatcher(t *testing.T) {
@@ -56,6 +62,9 @@ func TestRun_DirectoryWatcher(t *testing.T) {
 	cmd.logger, err = common.Logger("info", false)
 	require.NoError(t, err)
 
+	// Metrics are global so compare against the count at the start of the test.
+	initialDrift := testutil.ToFloat64(configDrift)
+
 	// Create the file watcher.
 	go func() {
 		err := cmd.directoryWatcher(ctx, consulConfig, tempDir, "")
@@ -79,6 +88,8 @@ func TestRun_DirectoryWatcher(t *testing.T) {
 	retry.Run(t, func(r *retry.R) {
 		require.Equal(r, string(expected), string(actual))
 	})
+	// Installing the plugin for the first time is not drift.
+	require.Equal(t, initialDrift, testutil.ToFloat64(configDrift))
 
 	t.Log("File event 2: config file changed and consul-cni is not last in the plugin list. Should detect and fix.")
 	err = replaceFile(notLastConfigFile, filepath.Join(tempDir, configFile))
@@ -92,6 +103,10 @@ func TestRun_DirectoryWatcher(t *testing.T) {
 	retry.Run(t, func(r *retry.R) {
 		require.Equal(r, string(expected), string(actual))
 	})
+	retry.Run(t, func(r *retry.R) {
+		require.GreaterOrEqual(r, testutil.ToFloat64(configDrift), initialDrift+1)
+	})
+	driftBeforeEvent3 := testutil.ToFloat64(configDrift)
 
 	t.Log("File event 3: consul config was removed from the config file. Should detect and fix.")
 	err = replaceFile(baseConfigFile, filepath.Join(tempDir, configFile))
@@ -101,6 +116,9 @@ func TestRun_DirectoryWatcher(t *testing.T) {
 	retry.Run(t, func(r *retry.R) {
 		require.Equal(r, string(expected), string(actual))
 	})
+	retry.Run(t, func(r *retry.R) {
+		require.Greater(r, testutil.ToFloat64(configDrift), driftBeforeEvent3)
+	})
 
 	// If we exit the test too quickly it can cause a race condition where File event 3 is still running and we
 	// delete the config file while the test is doing a write.
@@ -0,0 +1,177 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package installcni
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io/fs"
+	"os"
+	"strings"
+	"sync"
+
+	"github.com/hashicorp/consul-k8s/control-plane/cni/config"
+	"github.com/mitchellh/cli"
+
+	"github.com/hashicorp/consul-k8s/control-plane/subcommand/flags"
+)
+
+const (
+	defaultLogLines = 100
+
+	// maxLogLineSize is the longest log line that can be read from the plugin's log files.
+	maxLogLineSize = 1024 * 1024
+)
+
+// LogsCommand prints the most recent logs the CNI plugin wrote to its log file on the node
+// the installer is running on.
+type LogsCommand struct {
+	UI cli.Ui
+
+	// flagLogFile is the file on the host the plugin writes its logs to.
+	flagLogFile string
+	// flagLogRotateMaxFiles is the number of rotated plugin log files that are kept.
+	flagLogRotateMaxFiles int
+	// flagLines is the number of log lines to print.
+	flagLines int
+	// flagPod only prints the logs of the pod with this <namespace>/<name>.
+	flagPod string
+
+	flagSet *flag.FlagSet
+
+	once sync.Once
+	help string
+}
+
+func (c *LogsCommand) init() {
+	c.flagSet = flag.NewFlagSet("", flag.ContinueOnError)
+	c.flagSet.StringVar(&c.flagLogFile, "log-file", config.DefaultLogFile, "File on the host the plugin writes its logs to.")
+	c.flagSet.IntVar(&c.flagLogRotateMaxFiles, "log-rotate-max-files", config.DefaultLogRotateMaxFiles,
+		"Number of rotated plugin log files that are kept.")
+	c.flagSet.IntVar(&c.flagLines, "lines", defaultLogLines, "Number of most recent log lines to print.")
+	c.flagSet.StringVar(&c.flagPod, "pod", "", "Only print the logs of the pod with this <namespace>/<name>.")
+
+	c.help = flags.Usage(logsHelp, c.flagSet)
+}
+
+// Run runs the command.
+func (c *LogsCommand) Run(args []string) int {
+	c.once.Do(c.init)
+
+	if err := c.flagSet.Parse(args); err != nil {
+		return 1
+	}
+	if c.flagLines <= 0 {
+		c.UI.Error("-lines must be greater than 0")
+		return 1
+	}
+
+	lines, err := c.tail()
+	if err != nil {
+		c.UI.Error(fmt.Sprintf("Error reading CNI plugin logs: %s", err))
+		return 1
+	}
+	if len(lines) == 0 {
+		c.UI.Info(fmt.Sprintf("No CNI plugin logs found in %s", c.flagLogFile))
+		return 0
+	}
+	for _, line := range lines {
+		c.UI.Output(line)
+	}
+	return 0
+}
+
+// tail returns the last lines of the plugin's log files, reading the rotated files from oldest to newest
+// before the current log file.
+func (c *LogsCommand) tail() ([]string, error) {
+	files := make([]string, 0, c.flagLogRotateMaxFiles+1)
+	for i := c.flagLogRotateMaxFiles; i >= 1; i-- {
+		files = append(files, config.RotatedLogFile(c.flagLogFile, i))
+	}
+	files = append(files, c.flagLogFile)
+
+	// lines is used as a ring buffer holding the last flagLines lines read.
+	lines := make([]string, 0, c.flagLines)
+	next := 0
+	found := false
+	for _, file := range files {
+		f, err := os.Open(file)
+		if errors.Is(err, fs.ErrNotExist) {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		found = true
+
+		scanner := bufio.NewScanner(f)
+		scanner.Buffer(make([]byte, 0, 64*1024), maxLogLineSize)
+		for scanner.Scan() {
+			line := scanner.Text()
+			if !c.matchesPod(line) {
+				continue
+			}
+			if len(lines) < c.flagLines {
+				lines = append(lines, line)
+			} else {
+				lines[next] = line
+			}
+			next = (next + 1) % c.flagLines
+		}
+		err = scanner.Err()
+		f.Close()
+		if err != nil {
+			return nil, fmt.Errorf("error reading %s: %w", file, err)
+		}
+	}
+	if !found {
+		return nil, fmt.Errorf("log file %s does not exist; check that the plugin is configured to log to it", c.flagLogFile)
+	}
+
+	if len(lines) < c.flagLines {
+		return lines, nil
+	}
+	return append(lines[next:], lines[:next]...), nil
+}
+
+// matchesPod returns true if the JSON log line was written for the pod given with -pod,
+// or if -pod is not set.
+func (c *LogsCommand) matchesPod(line string) bool {
+	if c.flagPod == "" {
+		return true
+	}
+	var entry struct {
+		Module string `json:"@module"`
+	}
+	if err := json.Unmarshal([]byte(line), &entry); err != nil {
+		return false
+	}
+	return entry.Module == c.flagPod || strings.HasPrefix(entry.Module, c.flagPod+".")
+}
+
+// Synopsis returns the summary of the cni logs command.
+func (c *LogsCommand) Synopsis() string { return logsSynopsis }
+
+// Help returns the help output of the command.
+func (c *LogsCommand) Help() string {
+	c.once.Do(c.init)
+	return c.help
+}
+
+const (
+	logsSynopsis = "Print the Consul CNI plugin logs on this node"
+	logsHelp     = `
+Usage: consul-k8s-control-plane install-cni logs [options]
+
+  Prints the most recent logs the Consul CNI plugin wrote to its log file
+  on the node this command runs on. The plugin only writes to a log file
+  if one is configured. Run this command in the CNI installer pod on the
+  node you are debugging, e.g.
+
+    kubectl exec <cni pod> -- consul-k8s-control-plane install-cni logs -pod default/web-0
+`
+)
@@ -0,0 +1,80 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package installcni
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/hashicorp/consul-k8s/control-plane/cni/config"
+	"github.com/mitchellh/cli"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLogsCommand(t *testing.T) {
+	logFile := filepath.Join(t.TempDir(), "consul-cni.log")
+	writeLogFile := func(path string, lines ...string) {
+		require.NoError(t, os.WriteFile(path, []byte(strings.Join(lines, "\n")+"\n"), 0o644))
+	}
+	writeLogFile(config.RotatedLogFile(logFile, 2),
+		`{"@module":"default/web-0","@message":"one"}`,
+		`{"@module":"default/api-0","@message":"two"}`)
+	writeLogFile(config.RotatedLogFile(logFile, 1),
+		`{"@module":"default/web-0","@message":"three"}`)
+	writeLogFile(logFile,
+		`{"@module":"default/api-0","@message":"four"}`,
+		`{"@module":"default/web-0","@message":"five"}`)
+
+	cases := map[string]struct {
+		args     []string
+		expLines []string
+		expErr   string
+	}{
+		"all logs in order": {
+			args:     []string{"-log-file", logFile},
+			expLines: []string{"one", "two", "three", "four", "five"},
+		},
+		"last lines": {
+			args:     []string{"-log-file", logFile, "-lines", "2"},
+			expLines: []string{"four", "five"},
+		},
+		"last lines of a pod": {
+			args:     []string{"-log-file", logFile, "-lines", "2", "-pod", "default/web-0"},
+			expLines: []string{"three", "five"},
+		},
+		"fewer rotated files": {
+			args:     []string{"-log-file", logFile, "-log-rotate-max-files", "1"},
+			expLines: []string{"three", "four", "five"},
+		},
+		"missing log file": {
+			args:   []string{"-log-file", filepath.Join(t.TempDir(), "consul-cni.log")},
+			expErr: "does not exist",
+		},
+		"invalid lines": {
+			args:   []string{"-log-file", logFile, "-lines", "0"},
+			expErr: "-lines must be greater than 0",
+		},
+	}
+	for name, c := range cases {
+		t.Run(name, func(t *testing.T) {
+			ui := cli.NewMockUi()
+			cmd := LogsCommand{UI: ui}
+			code := cmd.Run(c.args)
+			if c.expErr != "" {
+				require.Equal(t, 1, code)
+				require.Contains(t, ui.ErrorWriter.String(), c.expErr)
+				return
+			}
+			require.Equal(t, 0, code, ui.ErrorWriter.String())
+
+			var messages []string
+			for _, line := range strings.Split(strings.TrimSpace(ui.OutputWriter.String()), "\n") {
+				messages = append(messages, strings.TrimSuffix(line[strings.LastIndex(line, `"@message":"`)+len(`"@message":"`):], `"}`))
+			}
+			require.Equal(t, c.expLines, messages)
+		})
+	}
+}
@@ -0,0 +1,34 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package installcni
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+// configDrift is the number of times the consul-cni plugin entry was found to be missing or out of place
+// in the CNI config file after it had been installed, e.g. because another CNI manager rewrote the file.
+var configDrift = prometheus.NewCounter(prometheus.CounterOpts{
+	Name: "consul_cni_config_drift_total",
+	Help: "Number of times the consul-cni plugin configuration was found missing or modified after it was installed and was re-installed.",
+})
+
+func init() {
+	prometheus.MustRegister(configDrift)
+}
+
+// serveMetrics serves the installer's prometheus metrics on the metrics port and path.
+func (c *Command) serveMetrics() {
+	mux := http.NewServeMux()
+	mux.Handle(c.flagMetricsPath, promhttp.Handler())
+
+	c.logger.Info("Serving metrics", "port", c.flagMetricsPort, "path", c.flagMetricsPath)
+	if err := http.ListenAndServe(fmt.Sprintf(":%s", c.flagMetricsPort), mux); err != nil {
+		c.logger.Error("Error serving metrics", "error", err)
+	}
+}
@@ -28,6 +28,7 @@ import (
 	"k8s.io/client-go/kubernetes"
 
 	"github.com/hashicorp/consul-k8s/control-plane/consul"
+	"github.com/hashicorp/consul-k8s/control-plane/namespaces"
 	"github.com/hashicorp/consul-k8s/control-plane/subcommand"
 	"github.com/hashicorp/consul-k8s/control-plane/subcommand/common"
 	"github.com/hashicorp/consul-k8s/control-plane/subcommand/flags"
@@ -58,11 +59,15 @@ type Command struct {
 	flagConnectInject       bool
 	flagAuthMethodHost      string
 	flagBindingRuleSelector string
+	flagLoginTokenAudience  string
+
+	flagConnectInjectPartitionMapping bool
 
 	flagCreateEntLicenseToken bool
 	flagCreateDDAgentToken    bool
 
-	flagSnapshotAgent bool
+	flagSnapshotAgent        bool
+	flagServerConfigReloader bool
 
 	flagMeshGateway             bool
 	flagIngressGatewayNames     []string
@@ -108,6 +113,9 @@ type Command struct {
 	clientset   kubernetes.Interface
 	vaultClient *vaultApi.Client
 
+	// getKubernetesPath gets a path of the Kubernetes API server, for unit testing.
+	getKubernetesPath func(ctx context.Context, path string) ([]byte, error)
+
 	watcher consul.ServerConnectionManager
 
 	// ctx is cancelled when the command timeout is reached.
@@ -155,11 +163,19 @@ func (c *Command) init() {
 			"If not provided, the default cluster Kubernetes service will be used.")
 	c.flags.StringVar(&c.flagBindingRuleSelector, "acl-binding-rule-selector", "",
 		"Selector string for connectInject ACL Binding Rule.")
+	c.flags.StringVar(&c.flagLoginTokenAudience, "login-token-audience", "",
+		"Audience of the projected service account tokens that injected pods log in with. If set, "+
+			"a jwt auth method that only accepts tokens for this audience is also created for connectInject.")
+	c.flags.BoolVar(&c.flagConnectInjectPartitionMapping, "connect-inject-partition-mapping", false,
+		"[Enterprise Only] Toggle for allowing Connect inject to register services in, and copy its auth method to, "+
+			"the Admin Partitions that Kubernetes namespaces are mapped to. Requires -partition to be \"default\".")
 
 	c.flags.BoolVar(&c.flagCreateEntLicenseToken, "create-enterprise-license-token", false,
 		"Toggle for creating a token for the enterprise license job.")
 	c.flags.BoolVar(&c.flagSnapshotAgent, "snapshot-agent", false,
 		"[Enterprise Only] Toggle for configuring ACL login for the snapshot agent.")
+	c.flags.BoolVar(&c.flagServerConfigReloader, "server-config-reloader", false,
+		"Toggle for configuring ACL login for the server config reloader.")
 	c.flags.BoolVar(&c.flagMeshGateway, "mesh-gateway", false,
 		"Toggle for configuring ACL login for the mesh gateway.")
 	c.flags.Var((*flags.AppendSliceValue)(&c.flagIngressGatewayNames), "ingress-gateway-name",
@@ -537,12 +553,22 @@ func (c *Command) Run(args []string) int {
 
 	if c.flagConnectInject {
 		connectAuthMethodName := c.withPrefix("k8s-auth-method")
-		err := c.configureConnectInjectAuthMethod(dynamicClient, connectAuthMethodName)
+		err := c.configureConnectInjectAuthMethod(dynamicClient, connectAuthMethodName, false)
 		if err != nil {
 			c.log.Error(err.Error())
 			return 1
 		}
 
+		// Injected pods log in with audience-bound tokens with the jwt auth method, while
+		// other components keep logging in with the kubernetes auth method.
+		if c.flagLoginTokenAudience != "" {
+			err := c.configureConnectInjectAuthMethod(dynamicClient, c.withPrefix("k8s-jwt-auth-method"), true)
+			if err != nil {
+				c.log.Error(err.Error())
+				return 1
+			}
+		}
+
 		// The endpoints controller needs an ACL token always.
 		injectRules, err := c.injectRules()
 		if err != nil {
@@ -587,6 +613,14 @@ func (c *Command) Run(args []string) int {
 		}
 	}
 
+	if c.flagServerConfigReloader {
+		serviceAccountName := c.withPrefix("server")
+		if err := c.createACLPolicyRoleAndBindingRule("server-config-reloader", serverConfigReloaderRules, consulDC, primaryDC, localPolicy, primary, localComponentAuthMethodName, serviceAccountName, dynamicClient); err != nil {
+			c.log.Error(err.Error())
+			return 1
+		}
+	}
+
 	if c.flagMeshGateway {
 		rules, err := c.meshGatewayRules()
 		if err != nil {
@@ -1024,6 +1058,18 @@ func (c *Command) validateFlags() error {
 		return errors.New("-consul-api-timeout must be set to a value greater than 0")
 	}
 
+	// Only the policies of the default partition can grant access to other partitions.
+	if c.flagConnectInjectPartitionMapping && c.consulFlags.Partition != "default" {
+		return errors.New("-partition must be \"default\" if -connect-inject-partition-mapping is set")
+	}
+
+	if err := namespaces.ValidateMirroringPrefix(c.flagSyncK8SNSMirroringPrefix); err != nil {
+		return fmt.Errorf("-sync-k8s-namespace-mirroring-prefix is invalid: %w", err)
+	}
+	if err := namespaces.ValidateMirroringPrefix(c.flagInjectK8SNSMirroringPrefix); err != nil {
+		return fmt.Errorf("-inject-k8s-namespace-mirroring-prefix is invalid: %w", err)
+	}
+
 	//if c.flagVaultNamespace != "" && c.flagSecretsBackend != SecretsBackendTypeVault {
 	//	return fmt.Errorf("-vault-namespace not supported for -secrets-backend=%q", c.flagSecretsBackend)
 	//}
@@ -83,6 +83,15 @@ func TestRun_FlagValidation(t *testing.T) {
 			ExpErr: "-sync-consul-node-name=5r9OPGfSRXUdGzNjBdAwmhCBrzHDNYs4XjZVR4wp7lSLIzqwS0ta51nBLIN0TMPV-too-long is invalid: node name will not be discoverable " +
 				"via DNS due to it being too long. Valid lengths are between 1 and 63 bytes",
 		},
+		{
+			Flags: []string{
+				"-addresses=localhost",
+				"-resource-prefix=prefix",
+				"-partition=ap1",
+				"-connect-inject-partition-mapping",
+			},
+			ExpErr: "-partition must be \"default\" if -connect-inject-partition-mapping is set",
+		},
 	}
 
 	for _, c := range cases {
@@ -1982,6 +1991,12 @@ func TestRun_PoliciesAndBindingRulesForACLLogin_PrimaryDatacenter(t *testing.T)
 			PolicyNames: []string{"snapshot-agent-policy"},
 			Roles:       []string{resourcePrefix + "-snapshot-agent-acl-role"},
 		},
+		{
+			TestName:    "Server Config Reloader",
+			TokenFlags:  []string{"-server-config-reloader"},
+			PolicyNames: []string{"server-config-reloader-policy"},
+			Roles:       []string{resourcePrefix + "-server-config-reloader-acl-role"},
+		},
 		{
 			TestName:    "Mesh Gateway",
 			TokenFlags:  []string{"-mesh-gateway"},
@@ -2130,6 +2145,13 @@ func TestRun_PoliciesAndBindingRulesACLLogin_SecondaryDatacenter(t *testing.T) {
 			Roles:            []string{resourcePrefix + "-snapshot-agent-acl-role-" + secondaryDatacenter},
 			GlobalAuthMethod: false,
 		},
+		{
+			TestName:         "Server Config Reloader",
+			TokenFlags:       []string{"-server-config-reloader"},
+			PolicyNames:      []string{"server-config-reloader-policy-" + secondaryDatacenter},
+			Roles:            []string{resourcePrefix + "-server-config-reloader-acl-role-" + secondaryDatacenter},
+			GlobalAuthMethod: false,
+		},
 		{
 			TestName:         "Mesh Gateway",
 			TokenFlags:       []string{"-mesh-gateway"},
@@ -2281,6 +2303,13 @@ func TestRun_ValidateLoginToken_PrimaryDatacenter(t *testing.T) {
 			GlobalToken:        false,
 			ServiceAccountName: resourcePrefix + "-server",
 		},
+		{
+			ComponentName:      "server-config-reloader",
+			TokenFlags:         []string{"-server-config-reloader"},
+			Roles:              []string{resourcePrefix + "-server-config-reloader-acl-role"},
+			GlobalToken:        false,
+			ServiceAccountName: resourcePrefix + "-server",
+		},
 		{
 			ComponentName: "mesh-gateway",
 			TokenFlags:    []string{"-mesh-gateway"},
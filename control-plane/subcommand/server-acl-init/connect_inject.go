@@ -5,6 +5,7 @@ package serveraclinit
 
 import (
 	"fmt"
+	"strings"
 
 	"github.com/hashicorp/consul/api"
 	apiv1 "k8s.io/api/core/v1"
@@ -21,12 +22,19 @@ import (
 const defaultKubernetesHost = "https://kubernetes.default.svc"
 
 // configureConnectInject sets up auth methods so that connect injection will
-// work.
-func (c *Command) configureConnectInjectAuthMethod(client *consul.DynamicClient, authMethodName string) error {
+// work. If useJWT is true, the auth method accepts the projected, audience-bound
+// service account tokens of the pods.
+func (c *Command) configureConnectInjectAuthMethod(client *consul.DynamicClient, authMethodName string, useJWT bool) error {
 
 	// Create the auth method template. This requires calls to the
 	// kubernetes environment.
-	authMethodTmpl, err := c.createAuthMethodTmpl(authMethodName, true)
+	var authMethodTmpl api.ACLAuthMethod
+	var err error
+	if useJWT {
+		authMethodTmpl, err = c.createJWTAuthMethodTmpl(authMethodName)
+	} else {
+		authMethodTmpl, err = c.createAuthMethodTmpl(authMethodName, true)
+	}
 	if err != nil {
 		return err
 	}
@@ -85,6 +93,10 @@ func (c *Command) configureConnectInjectAuthMethod(client *consul.DynamicClient,
 		BindName:    "${serviceaccount.name}",
 		Selector:    c.flagBindingRuleSelector,
 	}
+	if useJWT {
+		abr.BindName = fmt.Sprintf("${value.%s}", claimServiceAccountName)
+		abr.Selector = jwtBindingRuleSelector(c.flagBindingRuleSelector)
+	}
 
 	return c.createConnectBindingRule(client, authMethodName, &abr)
 }
@@ -165,8 +177,18 @@ func (c *Command) createAuthMethodTmpl(authMethodName string, useNS bool) (api.A
 	// Add options for mirroring namespaces, this is only used by the connect inject auth method
 	// and so can be disabled for the component auth method.
 	if useNS && c.flagEnableNamespaces && c.flagEnableInjectK8SNSMirroring {
-		authMethodTmpl.Config["MapNamespaces"] = true
-		authMethodTmpl.Config["ConsulNamespacePrefix"] = c.flagInjectK8SNSMirroringPrefix
+		if strings.Contains(c.flagInjectK8SNSMirroringPrefix, namespaces.K8sNamespaceVariable) {
+			// The auth method can only prepend a prefix to the mirrored namespace, so bind the
+			// tokens to the namespaces of a templated prefix with a namespace rule instead.
+			authMethodTmpl.NamespaceRules = []*api.ACLAuthMethodNamespaceRule{
+				{
+					BindNamespace: strings.ReplaceAll(c.flagInjectK8SNSMirroringPrefix, namespaces.K8sNamespaceVariable, "${serviceaccount.namespace}"),
+				},
+			}
+		} else {
+			authMethodTmpl.Config["MapNamespaces"] = true
+			authMethodTmpl.Config["ConsulNamespacePrefix"] = c.flagInjectK8SNSMirroringPrefix
+		}
 	}
 
 	return authMethodTmpl, nil
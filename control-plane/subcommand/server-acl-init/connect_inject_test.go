@@ -7,6 +7,7 @@ import (
 	"context"
 	"testing"
 
+	"github.com/hashicorp/consul/api"
 	"github.com/hashicorp/go-hclog"
 	"github.com/stretchr/testify/require"
 	v1 "k8s.io/api/core/v1"
@@ -124,3 +125,55 @@ func TestCommand_createAuthMethodTmpl(t *testing.T) {
 	_, err = cmd.createAuthMethodTmpl("test", true)
 	require.NoError(t, err)
 }
+
+// Test that the auth method binds tokens to the namespaces of a templated mirroring prefix with a namespace rule.
+func TestCommand_createAuthMethodTmpl_MirroringPrefix(t *testing.T) {
+	cases := map[string]struct {
+		mirroringPrefix   string
+		expConfigPrefix   interface{}
+		expNamespaceRules []*api.ACLAuthMethodNamespaceRule
+	}{
+		"prefix": {
+			mirroringPrefix: "k8s-",
+			expConfigPrefix: "k8s-",
+		},
+		"templated prefix": {
+			mirroringPrefix:   "{k8s-namespace}-east",
+			expNamespaceRules: []*api.ACLAuthMethodNamespaceRule{{BindNamespace: "${serviceaccount.namespace}-east"}},
+		},
+	}
+	for name, c := range cases {
+		t.Run(name, func(t *testing.T) {
+			serviceAccountName := resourcePrefix + "-auth-method"
+			k8s := fake.NewSimpleClientset()
+			ctx := context.Background()
+			_, err := k8s.CoreV1().ServiceAccounts(ns).Create(ctx, &v1.ServiceAccount{ObjectMeta: metav1.ObjectMeta{Name: serviceAccountName}}, metav1.CreateOptions{})
+			require.NoError(t, err)
+			_, err = k8s.CoreV1().Secrets(ns).Create(ctx, &v1.Secret{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:        serviceAccountName,
+					Labels:      map[string]string{common.CLILabelKey: common.CLILabelValue},
+					Annotations: map[string]string{v1.ServiceAccountNameKey: serviceAccountName},
+				},
+				Type: v1.SecretTypeServiceAccountToken,
+			}, metav1.CreateOptions{})
+			require.NoError(t, err)
+
+			cmd := &Command{
+				flagK8sNamespace:               ns,
+				flagResourcePrefix:             resourcePrefix,
+				flagEnableNamespaces:           true,
+				flagEnableInjectK8SNSMirroring: true,
+				flagInjectK8SNSMirroringPrefix: c.mirroringPrefix,
+				clientset:                      k8s,
+				log:                            hclog.New(nil),
+				ctx:                            ctx,
+			}
+
+			authMethod, err := cmd.createAuthMethodTmpl("test", true)
+			require.NoError(t, err)
+			require.Equal(t, c.expConfigPrefix, authMethod.Config["ConsulNamespacePrefix"])
+			require.Equal(t, c.expNamespaceRules, authMethod.NamespaceRules)
+		})
+	}
+}
@@ -0,0 +1,134 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package serveraclinit
+
+import (
+	"context"
+	"crypto/x509"
+	"encoding/json"
+	"encoding/pem"
+	"fmt"
+	"strings"
+
+	"github.com/go-jose/go-jose/v3"
+	"github.com/hashicorp/consul/api"
+
+	"github.com/hashicorp/consul-k8s/control-plane/namespaces"
+)
+
+const (
+	// oidcDiscoveryPath and jwksPath are the paths of the Kubernetes API server's
+	// service account issuer discovery documents.
+	oidcDiscoveryPath = "/.well-known/openid-configuration"
+	jwksPath          = "/openid/v1/jwks"
+
+	// Claims of the projected service account tokens mapped into the values
+	// that binding rules can select on and interpolate.
+	claimServiceAccountName      = "serviceaccount_name"
+	claimServiceAccountNamespace = "serviceaccount_namespace"
+	claimPodName                 = "pod_name"
+)
+
+// createJWTAuthMethodTmpl sets up an auth method that validates the projected, audience-bound service
+// account tokens of the pods instead of reviewing their tokens with the Kubernetes API server, which only
+// accepts tokens for the API server's own audience. The tokens are validated against the keys of the
+// Kubernetes service account issuer, so the auth method needs to be updated, by rerunning this command,
+// if the issuer's signing keys are rotated.
+func (c *Command) createJWTAuthMethodTmpl(authMethodName string) (api.ACLAuthMethod, error) {
+	var issuer string
+	var pubKeys []string
+	err := c.untilSucceeds("getting the Kubernetes service account issuer keys",
+		func() error {
+			var err error
+			issuer, pubKeys, err = c.serviceAccountIssuerKeys(c.ctx)
+			return err
+		})
+	if err != nil {
+		return api.ACLAuthMethod{}, err
+	}
+
+	authMethodTmpl := api.ACLAuthMethod{
+		Name:        authMethodName,
+		Description: "Kubernetes JWT Auth Method",
+		Type:        "jwt",
+		Config: map[string]interface{}{
+			"BoundIssuer":          issuer,
+			"BoundAudiences":       []string{c.flagLoginTokenAudience},
+			"JWTValidationPubKeys": pubKeys,
+			"ClaimMappings": map[string]string{
+				"/kubernetes.io/serviceaccount/name": claimServiceAccountName,
+				"/kubernetes.io/namespace":           claimServiceAccountNamespace,
+				"/kubernetes.io/pod/name":            claimPodName,
+			},
+		},
+	}
+
+	// The jwt auth method can't map namespaces like the kubernetes auth method,
+	// so bind the tokens to the mirrored namespace with a namespace rule.
+	if c.flagEnableNamespaces && c.flagEnableInjectK8SNSMirroring {
+		authMethodTmpl.NamespaceRules = []*api.ACLAuthMethodNamespaceRule{
+			{
+				BindNamespace: namespaces.MirroredNamespace(fmt.Sprintf("${value.%s}", claimServiceAccountNamespace), c.flagInjectK8SNSMirroringPrefix),
+			},
+		}
+	}
+
+	return authMethodTmpl, nil
+}
+
+// jwtBindingRuleSelector translates a binding rule selector written for the kubernetes
+// auth method into the equivalent selector for the jwt auth method.
+func jwtBindingRuleSelector(selector string) string {
+	return strings.NewReplacer(
+		"serviceaccount.name", "value."+claimServiceAccountName,
+		"serviceaccount.namespace", "value."+claimServiceAccountNamespace,
+	).Replace(selector)
+}
+
+// serviceAccountIssuerKeys returns the issuer of the Kubernetes service account tokens
+// and the PEM encoded public keys that the tokens are signed with.
+func (c *Command) serviceAccountIssuerKeys(ctx context.Context) (string, []string, error) {
+	getPath := c.getKubernetesPath
+	if getPath == nil {
+		getPath = func(ctx context.Context, path string) ([]byte, error) {
+			return c.clientset.CoreV1().RESTClient().Get().AbsPath(path).DoRaw(ctx)
+		}
+	}
+
+	raw, err := getPath(ctx, oidcDiscoveryPath)
+	if err != nil {
+		return "", nil, fmt.Errorf("getting service account issuer discovery document: %w", err)
+	}
+	var discovery struct {
+		Issuer string `json:"issuer"`
+	}
+	if err := json.Unmarshal(raw, &discovery); err != nil {
+		return "", nil, fmt.Errorf("parsing service account issuer discovery document: %w", err)
+	}
+	if discovery.Issuer == "" {
+		return "", nil, fmt.Errorf("service account issuer discovery document has no issuer")
+	}
+
+	raw, err = getPath(ctx, jwksPath)
+	if err != nil {
+		return "", nil, fmt.Errorf("getting service account issuer keys: %w", err)
+	}
+	var jwks jose.JSONWebKeySet
+	if err := json.Unmarshal(raw, &jwks); err != nil {
+		return "", nil, fmt.Errorf("parsing service account issuer keys: %w", err)
+	}
+
+	var pubKeys []string
+	for _, key := range jwks.Keys {
+		der, err := x509.MarshalPKIXPublicKey(key.Key)
+		if err != nil {
+			return "", nil, fmt.Errorf("encoding service account issuer key %q: %w", key.KeyID, err)
+		}
+		pubKeys = append(pubKeys, string(pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: der})))
+	}
+	if len(pubKeys) == 0 {
+		return "", nil, fmt.Errorf("found no service account issuer keys")
+	}
+	return discovery.Issuer, pubKeys, nil
+}
@@ -0,0 +1,112 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package serveraclinit
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/json"
+	"encoding/pem"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/go-jose/go-jose/v3"
+	"github.com/hashicorp/consul/api"
+	"github.com/hashicorp/go-hclog"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCommand_createJWTAuthMethodTmpl(t *testing.T) {
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+	ecKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+
+	jwks, err := json.Marshal(jose.JSONWebKeySet{Keys: []jose.JSONWebKey{
+		{Key: &rsaKey.PublicKey, KeyID: "rsa", Algorithm: "RS256", Use: "sig"},
+		{Key: &ecKey.PublicKey, KeyID: "ec", Algorithm: "ES256", Use: "sig"},
+	}})
+	require.NoError(t, err)
+
+	cmd := &Command{
+		flagLoginTokenAudience:         "consul",
+		flagEnableNamespaces:           true,
+		flagEnableInjectK8SNSMirroring: true,
+		flagInjectK8SNSMirroringPrefix: "k8s-",
+		log:                            hclog.New(nil),
+		ctx:                            context.Background(),
+		retryDuration:                  10 * time.Millisecond,
+		getKubernetesPath: func(_ context.Context, path string) ([]byte, error) {
+			switch path {
+			case oidcDiscoveryPath:
+				return []byte(`{"issuer": "https://kubernetes.default.svc.cluster.local"}`), nil
+			case jwksPath:
+				return jwks, nil
+			}
+			return nil, fmt.Errorf("unexpected path %q", path)
+		},
+	}
+
+	authMethod, err := cmd.createJWTAuthMethodTmpl("test")
+	require.NoError(t, err)
+	require.Equal(t, "jwt", authMethod.Type)
+	require.Equal(t, "https://kubernetes.default.svc.cluster.local", authMethod.Config["BoundIssuer"])
+	require.Equal(t, []string{"consul"}, authMethod.Config["BoundAudiences"])
+	require.Equal(t, []*api.ACLAuthMethodNamespaceRule{{BindNamespace: "k8s-${value.serviceaccount_namespace}"}}, authMethod.NamespaceRules)
+
+	pubKeys := authMethod.Config["JWTValidationPubKeys"].([]string)
+	require.Len(t, pubKeys, 2)
+	for i, expKey := range []interface{}{&rsaKey.PublicKey, &ecKey.PublicKey} {
+		block, _ := pem.Decode([]byte(pubKeys[i]))
+		require.NotNil(t, block)
+		key, err := x509.ParsePKIXPublicKey(block.Bytes)
+		require.NoError(t, err)
+		require.Equal(t, expKey, key)
+	}
+}
+
+func TestCommand_serviceAccountIssuerKeys_Errors(t *testing.T) {
+	cases := map[string]struct {
+		discovery string
+		jwks      string
+		expErr    string
+	}{
+		"no issuer": {
+			discovery: `{}`,
+			expErr:    "service account issuer discovery document has no issuer",
+		},
+		"no keys": {
+			discovery: `{"issuer": "https://kubernetes.default.svc"}`,
+			jwks:      `{"keys": []}`,
+			expErr:    "found no service account issuer keys",
+		},
+	}
+
+	for name, c := range cases {
+		t.Run(name, func(t *testing.T) {
+			cmd := &Command{
+				getKubernetesPath: func(_ context.Context, path string) ([]byte, error) {
+					if path == oidcDiscoveryPath {
+						return []byte(c.discovery), nil
+					}
+					return []byte(c.jwks), nil
+				},
+			}
+			_, _, err := cmd.serviceAccountIssuerKeys(context.Background())
+			require.EqualError(t, err, c.expErr)
+		})
+	}
+}
+
+func TestJWTBindingRuleSelector(t *testing.T) {
+	require.Equal(t, `value.serviceaccount_name!=default`, jwtBindingRuleSelector("serviceaccount.name!=default"))
+	require.Equal(t, `value.serviceaccount_namespace==apps and value.serviceaccount_name!=default`,
+		jwtBindingRuleSelector("serviceaccount.namespace==apps and serviceaccount.name!=default"))
+	require.Empty(t, jwtBindingRuleSelector(""))
+}
@@ -7,6 +7,8 @@ import (
 	"bytes"
 	"strings"
 	"text/template"
+
+	"github.com/hashicorp/consul-k8s/control-plane/namespaces"
 )
 
 type rulesData struct {
@@ -20,6 +22,7 @@ type rulesData struct {
 	InjectConsulDestNS      string
 	InjectEnableNSMirroring bool
 	InjectNSMirroringPrefix string
+	InjectPartitionMapping  bool
 	SyncConsulNodeName      string
 }
 
@@ -40,6 +43,11 @@ service "consul-snapshot" {
    policy = "write"
 }`
 
+// The server config reloader reloads the configuration of the Consul server it runs alongside.
+const serverConfigReloaderRules = `agent_prefix "" {
+   policy = "write"
+}`
+
 // The enterprise license rules are acl="write" inside partitions as operator="write"
 // is unsupported in partitions.
 const entLicenseRules = `operator = "write"`
@@ -292,9 +300,15 @@ func (c *Command) injectRules() (string, error) {
 	// When ACLs are enabled, the endpoints controller (V1) or pod controller (v2)
 	// needs "acl:write" permissions to delete ACL tokens created via "consul login".
 	// policy = "write" is required when creating namespaces within a partition.
+	// With a partition mapping, the Connect injector registers services in, and copies its
+	// auth method to, every mapped partition, so the rules apply to all partitions.
 	injectRulesTpl := `
 {{- if .EnablePartitions }}
+{{- if .InjectPartitionMapping }}
+partition_prefix "" {
+{{- else }}
 partition "{{ .PartitionName }}" {
+{{- end }}
   mesh = "write"
   acl = "write"
 {{- else }}
@@ -403,10 +417,11 @@ func (c *Command) rulesData() rulesData {
 		EnableNamespaces:        c.flagEnableNamespaces,
 		SyncConsulDestNS:        c.flagConsulSyncDestinationNamespace,
 		SyncEnableNSMirroring:   c.flagEnableSyncK8SNSMirroring,
-		SyncNSMirroringPrefix:   c.flagSyncK8SNSMirroringPrefix,
+		SyncNSMirroringPrefix:   namespaces.MirroringNamespacePrefix(c.flagSyncK8SNSMirroringPrefix),
 		InjectConsulDestNS:      c.flagConsulInjectDestinationNamespace,
 		InjectEnableNSMirroring: c.flagEnableInjectK8SNSMirroring,
-		InjectNSMirroringPrefix: c.flagInjectK8SNSMirroringPrefix,
+		InjectNSMirroringPrefix: namespaces.MirroringNamespacePrefix(c.flagInjectK8SNSMirroringPrefix),
+		InjectPartitionMapping:  c.flagConnectInjectPartitionMapping,
 		SyncConsulNodeName:      c.flagSyncConsulNodeName,
 	}
 }
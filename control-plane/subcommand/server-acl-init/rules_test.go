@@ -866,12 +866,40 @@ partition "foo" {
 // Test the inject rules with namespaces enabled or disabled.
 func TestInjectRules(t *testing.T) {
 	cases := []struct {
-		EnableNamespaces bool
-		EnablePartitions bool
-		EnablePeering    bool
-		PartitionName    string
-		Expected         string
+		EnableNamespaces       bool
+		EnablePartitions       bool
+		EnablePeering          bool
+		PartitionName          string
+		InjectPartitionMapping bool
+		Expected               string
 	}{
+		{
+			EnableNamespaces:       true,
+			EnablePartitions:       true,
+			EnablePeering:          false,
+			PartitionName:          "default",
+			InjectPartitionMapping: true,
+			Expected: `
+partition_prefix "" {
+  mesh = "write"
+  acl = "write"
+  node_prefix "" {
+    policy = "write"
+  }
+  namespace_prefix "" {
+    acl = "write"
+    policy = "write"
+    service_prefix "" {
+      policy = "write"
+      intentions = "write"
+    }
+    identity_prefix "" {
+      policy = "write"
+      intentions = "write"
+    }
+  }
+}`,
+		},
 		{
 			EnableNamespaces: false,
 			EnablePartitions: false,
@@ -1063,13 +1091,14 @@ partition "part-1" {
 	}
 
 	for _, tt := range cases {
-		caseName := fmt.Sprintf("ns=%t, partition=%t, peering=%t", tt.EnableNamespaces, tt.EnablePartitions, tt.EnablePeering)
+		caseName := fmt.Sprintf("ns=%t, partition=%t, peering=%t, partition-mapping=%t", tt.EnableNamespaces, tt.EnablePartitions, tt.EnablePeering, tt.InjectPartitionMapping)
 		t.Run(caseName, func(t *testing.T) {
 
 			cmd := Command{
-				consulFlags:          &flags.ConsulFlags{Partition: tt.PartitionName},
-				flagEnableNamespaces: tt.EnableNamespaces,
-				flagEnablePeering:    tt.EnablePeering,
+				consulFlags:                       &flags.ConsulFlags{Partition: tt.PartitionName},
+				flagEnableNamespaces:              tt.EnableNamespaces,
+				flagEnablePeering:                 tt.EnablePeering,
+				flagConnectInjectPartitionMapping: tt.InjectPartitionMapping,
 			}
 
 			injectorRules, err := cmd.injectRules()
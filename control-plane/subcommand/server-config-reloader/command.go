@@ -0,0 +1,278 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package serverconfigreloader
+
+import (
+	"bytes"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"os/signal"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/hashicorp/consul/api"
+	"github.com/hashicorp/go-hclog"
+	"github.com/mitchellh/cli"
+
+	"github.com/hashicorp/consul-k8s/control-plane/subcommand/common"
+	"github.com/hashicorp/consul-k8s/control-plane/subcommand/flags"
+)
+
+const (
+	defaultBearerTokenFile = "/var/run/secrets/kubernetes.io/serviceaccount/token"
+
+	// loginComponent is the component of the ACL token the command logs in for.
+	loginComponent = "server-config-reloader"
+)
+
+// placeholders are replaced in the extra config with the value of the environment variable of the
+// same name, the same way the startup script of the server does before starting the agent.
+var placeholders = []string{"HOST_IP", "POD_IP", "HOSTNAME"}
+
+// The server-config-reloader command runs alongside a Consul server and applies changes of its extra
+// config with `consul reload` when all the changed keys can be reloaded, so that the server doesn't need
+// to be restarted. It reports the changed keys that require a restart instead of applying them.
+type Command struct {
+	UI cli.Ui
+
+	flagLogLevel        string
+	flagLogJSON         bool
+	flagSourceFile      string
+	flagOutputFile      string
+	flagInterval        time.Duration
+	flagACLAuthMethod   string
+	flagBearerTokenFile string
+
+	flagSet *flag.FlagSet
+	http    *flags.HTTPFlags
+
+	once   sync.Once
+	help   string
+	logger hclog.Logger
+
+	consulClient *api.Client
+	// token is the ACL token from logging in with the auth method.
+	token string
+	// applied is the extra config the server runs with.
+	applied map[string]interface{}
+	// lastSource is the content of the source file when it was last handled.
+	lastSource []byte
+
+	// for testing
+	sigCh chan os.Signal
+}
+
+func (c *Command) init() {
+	c.flagSet = flag.NewFlagSet("", flag.ContinueOnError)
+	c.flagSet.StringVar(&c.flagLogLevel, "log-level", "info",
+		"Log verbosity level. Supported values (in order of detail) are \"trace\", "+
+			"\"debug\", \"info\", \"warn\", and \"error\".")
+	c.flagSet.BoolVar(&c.flagLogJSON, "log-json", false,
+		"Enable or disable JSON output format for logging.")
+	c.flagSet.StringVar(&c.flagSourceFile, "source-file", "",
+		"The file path of the extra config, as mounted from its ConfigMap.")
+	c.flagSet.StringVar(&c.flagOutputFile, "output-file", "",
+		"The file path of the extra config the Consul server loads.")
+	c.flagSet.DurationVar(&c.flagInterval, "interval", 10*time.Second,
+		"How often to check the source file for changes, formatted as a time.Duration.")
+	c.flagSet.StringVar(&c.flagACLAuthMethod, "acl-auth-method", "",
+		"Name of the auth method to log in with to get an ACL token that can reload the Consul server.")
+	c.flagSet.StringVar(&c.flagBearerTokenFile, "bearer-token-file", defaultBearerTokenFile,
+		"Path to a file containing a secret bearer token to use with the auth method.")
+
+	c.http = &flags.HTTPFlags{}
+	flags.Merge(c.flagSet, c.http.Flags())
+
+	c.help = flags.Usage(help, c.flagSet)
+}
+
+func (c *Command) Run(args []string) int {
+	var err error
+	c.once.Do(c.init)
+
+	if err := c.flagSet.Parse(args); err != nil {
+		return 1
+	}
+
+	if c.logger == nil {
+		c.logger, err = common.Logger(c.flagLogLevel, c.flagLogJSON)
+		if err != nil {
+			c.UI.Error(err.Error())
+			return 1
+		}
+	}
+
+	if c.flagSourceFile == "" {
+		c.UI.Error("-source-file is required")
+		return 1
+	}
+	if c.flagOutputFile == "" {
+		c.UI.Error("-output-file is required")
+		return 1
+	}
+	if c.flagInterval <= 0 {
+		c.UI.Error("-interval must be positive")
+		return 1
+	}
+
+	if c.consulClient == nil {
+		c.consulClient, err = c.http.APIClient()
+		if err != nil {
+			c.UI.Error(fmt.Sprintf("Error creating Consul client: %s", err))
+			return 1
+		}
+	}
+
+	if c.sigCh == nil {
+		c.sigCh = make(chan os.Signal, 1)
+		signal.Notify(c.sigCh, syscall.SIGINT, syscall.SIGTERM)
+	}
+	defer c.logout()
+
+	ticker := time.NewTicker(c.flagInterval)
+	defer ticker.Stop()
+	for {
+		if err := c.reconcile(); err != nil {
+			c.logger.Error("failed to reload server config, retrying", "error", err)
+		}
+		select {
+		case sig := <-c.sigCh:
+			c.logger.Info(fmt.Sprintf("%s received, shutting down", sig))
+			return 0
+		case <-ticker.C:
+		}
+	}
+}
+
+// reconcile reloads the server when the extra config changed and all the changed keys can be reloaded.
+func (c *Command) reconcile() error {
+	source, err := os.ReadFile(c.flagSourceFile)
+	if err != nil {
+		return fmt.Errorf("reading %s: %w", c.flagSourceFile, err)
+	}
+	if bytes.Equal(source, c.lastSource) {
+		return nil
+	}
+	if c.applied == nil {
+		// The server writes the output file before it starts, so its content is what the server runs with.
+		c.applied, err = readConfig(c.flagOutputFile)
+		if err != nil {
+			return fmt.Errorf("reading extra config the server runs with: %w", err)
+		}
+	}
+
+	rendered := render(source)
+	desired, err := parseConfig(rendered)
+	if err != nil {
+		// Don't retry until the source changes again.
+		c.lastSource = source
+		return fmt.Errorf("parsing %s: %w", c.flagSourceFile, err)
+	}
+
+	changed := changedKeys(c.applied, desired)
+	if len(changed) == 0 {
+		c.lastSource = source
+		return nil
+	}
+	if required := restartRequired(changed); len(required) > 0 {
+		// The server pods are rolled when these keys change, and load the new config when they restart.
+		c.logger.Warn("extra config changes require a restart of the server, not reloading",
+			"changed-keys", strings.Join(changed, ","), "restart-required-keys", strings.Join(required, ","))
+		c.lastSource = source
+		return nil
+	}
+
+	if err := common.WriteFileWithPerms(c.flagOutputFile, string(rendered), 0644); err != nil {
+		return err
+	}
+	if err := c.reload(); err != nil {
+		return err
+	}
+	c.logger.Info("reloaded server config", "changed-keys", strings.Join(changed, ","))
+	c.applied = desired
+	c.lastSource = source
+	return nil
+}
+
+// reload reloads the configuration of the server, logging in first if an auth method is set.
+func (c *Command) reload() error {
+	if c.flagACLAuthMethod != "" && c.token == "" {
+		token, err := common.ConsulLogin(c.consulClient, common.LoginParams{
+			AuthMethod:      c.flagACLAuthMethod,
+			BearerTokenFile: c.flagBearerTokenFile,
+			Meta:            map[string]string{"component": loginComponent},
+		}, c.logger)
+		if err != nil {
+			return err
+		}
+		c.token = token
+	}
+	// Without a token from logging in, the request uses the token of the client, if any.
+	_, err := c.consulClient.Raw().Write("/v1/agent/reload", nil, nil, &api.WriteOptions{Token: c.token})
+	if err != nil && strings.Contains(err.Error(), "ACL not found") {
+		// Log in again on the next attempt.
+		c.token = ""
+	}
+	return err
+}
+
+// logout deletes the ACL token from logging in.
+func (c *Command) logout() {
+	if c.token == "" {
+		return
+	}
+	if _, err := c.consulClient.ACL().Logout(&api.WriteOptions{Token: c.token}); err != nil {
+		c.logger.Error("failed to log out", "error", err)
+	}
+}
+
+// render replaces the placeholders of the extra config.
+func render(source []byte) []byte {
+	rendered := string(source)
+	for _, placeholder := range placeholders {
+		if value := os.Getenv(placeholder); value != "" {
+			rendered = strings.ReplaceAll(rendered, placeholder, value)
+		}
+	}
+	return []byte(rendered)
+}
+
+func readConfig(path string) (map[string]interface{}, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	return parseConfig(data)
+}
+
+func parseConfig(data []byte) (map[string]interface{}, error) {
+	config := make(map[string]interface{})
+	if len(bytes.TrimSpace(data)) == 0 {
+		return config, nil
+	}
+	if err := json.Unmarshal(data, &config); err != nil {
+		return nil, err
+	}
+	return config, nil
+}
+
+func (c *Command) Synopsis() string { return synopsis }
+func (c *Command) Help() string {
+	c.once.Do(c.init)
+	return c.help
+}
+
+const synopsis = "Reload a Consul server when its extra config changes."
+const help = `
+Usage: consul-k8s-control-plane server-config-reloader [options]
+
+  Watch the extra config of a Consul server and reload the server when all
+  the changed keys can be reloaded. Changed keys that require a restart of
+  the server are reported and not applied.
+  Not intended for stand-alone use.
+`
@@ -0,0 +1,125 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package serverconfigreloader
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync/atomic"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/hashicorp/consul/api"
+	"github.com/hashicorp/consul/sdk/testutil/retry"
+	"github.com/mitchellh/cli"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRun_FlagValidation(t *testing.T) {
+	t.Parallel()
+
+	cases := map[string]struct {
+		args []string
+		err  string
+	}{
+		"missing source-file": {
+			args: []string{},
+			err:  "-source-file is required",
+		},
+		"missing output-file": {
+			args: []string{"-source-file", "/consul/tmp/extra-config/extra-from-values.json"},
+			err:  "-output-file is required",
+		},
+		"invalid interval": {
+			args: []string{
+				"-source-file", "/consul/tmp/extra-config/extra-from-values.json",
+				"-output-file", "/consul/extra-config/extra-from-values.json",
+				"-interval", "0s",
+			},
+			err: "-interval must be positive",
+		},
+	}
+
+	for n, c := range cases {
+		c := c
+		t.Run(n, func(t *testing.T) {
+			ui := cli.NewMockUi()
+			cmd := Command{UI: ui}
+			responseCode := cmd.Run(c.args)
+			require.Equal(t, 1, responseCode, ui.ErrorWriter.String())
+			require.Contains(t, ui.ErrorWriter.String(), c.err)
+		})
+	}
+}
+
+func TestRun(t *testing.T) {
+	// Not parallel because the placeholders are read from the environment.
+	t.Setenv("POD_IP", "10.0.0.1")
+
+	var reloads atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodPut && r.URL.Path == "/v1/agent/reload" {
+			reloads.Add(1)
+		}
+	}))
+	defer server.Close()
+	consulClient, err := api.NewClient(&api.Config{Address: server.URL})
+	require.NoError(t, err)
+
+	dir := t.TempDir()
+	sourceFile := filepath.Join(dir, "source.json")
+	outputFile := filepath.Join(dir, "output.json")
+	require.NoError(t, os.WriteFile(sourceFile, []byte(`{"log_level": "INFO", "bind_addr": "POD_IP"}`), 0644))
+	require.NoError(t, os.WriteFile(outputFile, []byte(`{"log_level": "INFO", "bind_addr": "10.0.0.1"}`), 0644))
+
+	ui := cli.NewMockUi()
+	cmd := Command{UI: ui, consulClient: consulClient, sigCh: make(chan os.Signal, 1)}
+	exitCh := make(chan int, 1)
+	go func() {
+		exitCh <- cmd.Run([]string{"-source-file", sourceFile, "-output-file", outputFile, "-interval", "10ms"})
+	}()
+
+	// Reloadable changes are written and reloaded.
+	require.NoError(t, os.WriteFile(sourceFile, []byte(`{"log_level": "DEBUG", "bind_addr": "POD_IP"}`), 0644))
+	retry.Run(t, func(r *retry.R) {
+		require.Equal(r, int32(1), reloads.Load())
+		output, err := os.ReadFile(outputFile)
+		require.NoError(r, err)
+		require.JSONEq(r, `{"log_level": "DEBUG", "bind_addr": "10.0.0.1"}`, string(output))
+	})
+
+	// Changes that require a restart are not applied.
+	require.NoError(t, os.WriteFile(sourceFile, []byte(`{"log_level": "TRACE", "bind_addr": "0.0.0.0"}`), 0644))
+	time.Sleep(100 * time.Millisecond)
+	require.Equal(t, int32(1), reloads.Load())
+	output, err := os.ReadFile(outputFile)
+	require.NoError(t, err)
+	require.JSONEq(t, `{"log_level": "DEBUG", "bind_addr": "10.0.0.1"}`, string(output))
+
+	cmd.sigCh <- syscall.SIGTERM
+	select {
+	case code := <-exitCh:
+		require.Equal(t, 0, code, ui.ErrorWriter.String())
+	case <-time.After(5 * time.Second):
+		t.Fatal("command did not exit")
+	}
+}
+
+func TestReconcile_InvalidSource(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	sourceFile := filepath.Join(dir, "source.json")
+	outputFile := filepath.Join(dir, "output.json")
+	require.NoError(t, os.WriteFile(sourceFile, []byte(`{"log_level": `), 0644))
+	require.NoError(t, os.WriteFile(outputFile, []byte(`{}`), 0644))
+
+	cmd := Command{UI: cli.NewMockUi(), flagSourceFile: sourceFile, flagOutputFile: outputFile}
+	require.ErrorContains(t, cmd.reconcile(), "parsing "+sourceFile)
+	// The invalid source isn't parsed again until it changes.
+	require.NoError(t, cmd.reconcile())
+}
@@ -0,0 +1,98 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package serverconfigreloader
+
+import (
+	"reflect"
+	"sort"
+	"strings"
+)
+
+// reloadableKeys are the dotted paths of the agent configuration that `consul reload` applies to a
+// running server. Changes to any other key only take effect after the server restarts. This list must
+// be kept in sync with the keys the Helm chart leaves out of the config checksum of the server pods.
+var reloadableKeys = []string{
+	"acl.tokens",
+	"check",
+	"checks",
+	"config_entries",
+	"discard_check_output",
+	"license_path",
+	"limits.request_limits",
+	"limits.rpc_max_burst",
+	"limits.rpc_rate",
+	"log_level",
+	"node_meta",
+	"raft_snapshot_interval",
+	"raft_snapshot_threshold",
+	"raft_trailing_logs",
+	"reporting",
+	"service",
+	"services",
+	"telemetry.prefix_filter",
+	"watches",
+}
+
+// changedKeys returns the sorted dotted paths of the keys whose value differs between both configurations.
+// Objects are compared key by key so that the path of a reloadable key nested in an object that also
+// holds non-reloadable keys, e.g. limits.rpc_rate, is reported on its own.
+func changedKeys(oldConfig, newConfig map[string]interface{}) []string {
+	var changed []string
+	diffKeys("", oldConfig, newConfig, &changed)
+	sort.Strings(changed)
+	return changed
+}
+
+func diffKeys(prefix string, oldConfig, newConfig map[string]interface{}, changed *[]string) {
+	keys := make(map[string]struct{})
+	for k := range oldConfig {
+		keys[k] = struct{}{}
+	}
+	for k := range newConfig {
+		keys[k] = struct{}{}
+	}
+	for k := range keys {
+		path := prefix + k
+		oldValue, oldExists := oldConfig[k]
+		newValue, newExists := newConfig[k]
+		oldObject, oldIsObject := oldValue.(map[string]interface{})
+		newObject, newIsObject := newValue.(map[string]interface{})
+		// A missing object is compared as an empty one, so that adding the first reloadable key of an
+		// object, e.g. limits.rpc_rate, is reported as that key.
+		if !oldExists && newIsObject {
+			oldIsObject = true
+		}
+		if !newExists && oldIsObject {
+			newIsObject = true
+		}
+		if oldIsObject && newIsObject && !isReloadable(path) {
+			diffKeys(path+".", oldObject, newObject, changed)
+			continue
+		}
+		if !reflect.DeepEqual(oldValue, newValue) {
+			*changed = append(*changed, path)
+		}
+	}
+}
+
+// restartRequired returns the keys that `consul reload` does not apply.
+func restartRequired(keys []string) []string {
+	var required []string
+	for _, k := range keys {
+		if !isReloadable(k) {
+			required = append(required, k)
+		}
+	}
+	return required
+}
+
+// isReloadable returns whether the key is, or is nested in, a reloadable key.
+func isReloadable(key string) bool {
+	for _, reloadable := range reloadableKeys {
+		if key == reloadable || strings.HasPrefix(key, reloadable+".") {
+			return true
+		}
+	}
+	return false
+}
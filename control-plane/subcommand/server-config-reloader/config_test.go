@@ -0,0 +1,73 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package serverconfigreloader
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestChangedKeys(t *testing.T) {
+	t.Parallel()
+
+	cases := map[string]struct {
+		oldConfig          string
+		newConfig          string
+		expChanged         []string
+		expRestartRequired []string
+	}{
+		"unchanged": {
+			oldConfig: `{"log_level": "INFO", "limits": {"rpc_rate": 100}}`,
+			newConfig: `{"limits": {"rpc_rate": 100}, "log_level": "INFO"}`,
+		},
+		"reloadable keys": {
+			oldConfig:  `{"log_level": "INFO", "node_meta": {"a": "b"}}`,
+			newConfig:  `{"log_level": "DEBUG", "node_meta": {"a": "c", "d": "e"}, "raft_trailing_logs": 20000}`,
+			expChanged: []string{"log_level", "node_meta", "raft_trailing_logs"},
+		},
+		"nested reloadable keys": {
+			oldConfig:  `{"limits": {"rpc_rate": 100, "http_max_conns_per_client": 200}, "acl": {"enabled": true}}`,
+			newConfig:  `{"limits": {"rpc_rate": 50, "http_max_conns_per_client": 200}, "acl": {"enabled": true, "tokens": {"agent": "x"}}}`,
+			expChanged: []string{"acl.tokens", "limits.rpc_rate"},
+		},
+		"keys that require a restart": {
+			oldConfig:          `{"log_level": "INFO", "limits": {"rpc_rate": 100, "http_max_conns_per_client": 200}}`,
+			newConfig:          `{"log_level": "DEBUG", "limits": {"rpc_rate": 100, "http_max_conns_per_client": 400}, "ui_config": {"enabled": true}}`,
+			expChanged:         []string{"limits.http_max_conns_per_client", "log_level", "ui_config.enabled"},
+			expRestartRequired: []string{"limits.http_max_conns_per_client", "ui_config.enabled"},
+		},
+		"reloadable keys of a new object": {
+			oldConfig:  `{"log_level": "INFO"}`,
+			newConfig:  `{"log_level": "INFO", "limits": {"rpc_rate": 100}}`,
+			expChanged: []string{"limits.rpc_rate"},
+		},
+		"reloadable keys of a removed object": {
+			oldConfig:  `{"limits": {"rpc_rate": 100}}`,
+			newConfig:  `{}`,
+			expChanged: []string{"limits.rpc_rate"},
+		},
+		"object replaced by a value": {
+			oldConfig:          `{"limits": {"rpc_rate": 100}}`,
+			newConfig:          `{"limits": null}`,
+			expChanged:         []string{"limits"},
+			expRestartRequired: []string{"limits"},
+		},
+	}
+
+	for name, c := range cases {
+		c := c
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+			oldConfig, err := parseConfig([]byte(c.oldConfig))
+			require.NoError(t, err)
+			newConfig, err := parseConfig([]byte(c.newConfig))
+			require.NoError(t, err)
+
+			changed := changedKeys(oldConfig, newConfig)
+			require.Equal(t, c.expChanged, changed)
+			require.Equal(t, c.expRestartRequired, restartRequired(changed))
+		})
+	}
+}
@@ -23,9 +23,14 @@ import (
 	"github.com/hashicorp/go-hclog"
 	"github.com/mitchellh/cli"
 	"github.com/prometheus/client_golang/prometheus/promhttp"
+	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/kubernetes/scheme"
+	typedcorev1 "k8s.io/client-go/kubernetes/typed/core/v1"
 	_ "k8s.io/client-go/plugin/pkg/client/auth/gcp"
+	"k8s.io/client-go/tools/record"
+	gatewayclient "sigs.k8s.io/gateway-api/pkg/client/clientset/versioned"
 
 	"github.com/hashicorp/consul-k8s/control-plane/catalog/metrics"
 	catalogtoconsul "github.com/hashicorp/consul-k8s/control-plane/catalog/to-consul"
@@ -48,9 +53,11 @@ type Command struct {
 	flagListen                   string
 	flagToConsul                 bool
 	flagToK8S                    bool
+	flagToK8SEndpointSlices      bool
 	flagConsulDomain             string
 	flagConsulK8STag             string
 	flagConsulNodeName           string
+	flagConflictPolicy           string
 	flagK8SDefault               bool
 	flagK8SServicePrefix         string
 	flagConsulServicePrefix      string
@@ -59,6 +66,7 @@ type Command struct {
 	flagConsulWritePeriod        time.Duration
 	flagSyncClusterIPServices    bool
 	flagSyncLBEndpoints          bool
+	flagLBHostnameResolvePeriod  time.Duration
 	flagNodePortSyncType         string
 	flagAddK8SNamespaceSuffix    bool
 	flagLogLevel                 string
@@ -85,7 +93,12 @@ type Command struct {
 	flagEnableIngress   bool // Register services using the hostname from an ingress resource
 	flagLoadBalancerIPs bool // Use the load balancer IP of an ingress resource instead of the hostname
 
-	clientset kubernetes.Interface
+	// Flags to register the hosts of Ingress and HTTPRoute resources as Consul services
+	flagSyncIngressHosts   bool
+	flagSyncHTTPRouteHosts bool
+
+	clientset     kubernetes.Interface
+	gatewayClient gatewayclient.Interface
 
 	// ready indicates whether this controller is ready to sync services. This will be changed to true once the
 	// consul-server-connection-manager has finished initial initialization.
@@ -106,6 +119,11 @@ func (c *Command) init() {
 		"If true, K8S services will be synced to Consul.")
 	c.flags.BoolVar(&c.flagToK8S, "to-k8s", true,
 		"If true, Consul services will be synced to Kubernetes.")
+	c.flags.BoolVar(&c.flagToK8SEndpointSlices, "to-k8s-endpoint-slices", false,
+		"If true, Consul services are synced to Kubernetes as ClusterIP services with EndpointSlices "+
+			"whose endpoints are ready only if the health checks of the Consul instance are passing, so that "+
+			"Kubernetes doesn't route to unhealthy instances. If false, they are synced as ExternalName "+
+			"services that point to Consul DNS.")
 	c.flags.BoolVar(&c.flagK8SDefault, "k8s-default-sync", true,
 		"If true, all valid services in K8S are synced by default. If false, "+
 			"the service must be annotated properly to sync. In either case "+
@@ -130,6 +148,10 @@ func (c *Command) init() {
 	c.flags.StringVar(&c.flagConsulNodeName, "consul-node-name", "k8s-sync",
 		"The Consul node name to register for catalog sync. Defaults to k8s-sync. To be discoverable "+
 			"via DNS, the name should only contain alpha-numerics and dashes.")
+	c.flags.StringVar(&c.flagConflictPolicy, "conflict-policy", string(catalogtoconsul.ConflictPolicyK8sWins),
+		"What to do with a Kubernetes service whose Consul service name is also registered natively "+
+			"in Consul. One of \"k8s-wins\" to sync it alongside the native service, \"consul-wins\" to "+
+			"not sync it or \"suffix-k8s\" to sync it with the \"-k8s\" suffix. Defaults to k8s-wins.")
 	c.flags.DurationVar(&c.flagConsulWritePeriod, "consul-write-interval", 30*time.Second,
 		"The interval to perform syncing operations creating Consul services, formatted "+
 			"as a time.Duration. All changes are merged and write calls are only made "+
@@ -140,6 +162,10 @@ func (c *Command) init() {
 	c.flags.BoolVar(&c.flagSyncLBEndpoints, "sync-lb-services-endpoints", false,
 		"If true, LoadBalancer service endpoints instead of ingress addresses will be synced to Consul. If false, "+
 			"LoadBalancer endpoints are not synced to Consul.")
+	c.flags.DurationVar(&c.flagLBHostnameResolvePeriod, "loadbalancer-hostname-resolve-interval", 0,
+		"If set, the hostnames of LoadBalancer services are synced to Consul as the IP addresses they resolve to, "+
+			"and are re-resolved on this interval, formatted as a time.Duration, so that Consul follows the load "+
+			"balancer when its addresses change. If not set, the hostnames are synced as is.")
 	c.flags.StringVar(&c.flagNodePortSyncType, "node-port-sync-type", "ExternalOnly",
 		"Defines the type of sync for NodePort services. Valid options are ExternalOnly, "+
 			"InternalOnly and ExternalFirst.")
@@ -185,6 +211,13 @@ func (c *Command) init() {
 	c.flags.BoolVar(&c.flagLoadBalancerIPs, "loadBalancer-ips", false,
 		"[Enterprise Only] Enables namespaces, in either a single Consul namespace or mirrored.")
 
+	c.flags.BoolVar(&c.flagSyncIngressHosts, "sync-ingress-hosts", false,
+		"If true, each host of the rules of Ingress resources is registered as a Consul service whose "+
+			"instances are the load balancer addresses of the Ingress.")
+	c.flags.BoolVar(&c.flagSyncHTTPRouteHosts, "sync-httproute-hosts", false,
+		"If true, each hostname of Gateway API HTTPRoutes is registered as a Consul service whose "+
+			"instances are the addresses of the Gateways the route is attached to.")
+
 	c.consul = &flags.ConsulFlags{}
 	c.k8s = &flags.K8SFlags{}
 	flags.Merge(c.flags, c.consul.Flags())
@@ -218,17 +251,26 @@ func (c *Command) Run(args []string) int {
 	}
 
 	// Create the k8s clientset
-	if c.clientset == nil {
+	if c.clientset == nil || (c.flagSyncHTTPRouteHosts && c.gatewayClient == nil) {
 		config, err := subcommand.K8SConfig(c.k8s.KubeConfig())
 		if err != nil {
 			c.UI.Error(fmt.Sprintf("Error retrieving Kubernetes auth: %s", err))
 			return 1
 		}
 
-		c.clientset, err = kubernetes.NewForConfig(config)
-		if err != nil {
-			c.UI.Error(fmt.Sprintf("Error initializing Kubernetes client: %s", err))
-			return 1
+		if c.clientset == nil {
+			c.clientset, err = kubernetes.NewForConfig(config)
+			if err != nil {
+				c.UI.Error(fmt.Sprintf("Error initializing Kubernetes client: %s", err))
+				return 1
+			}
+		}
+		if c.flagSyncHTTPRouteHosts && c.gatewayClient == nil {
+			c.gatewayClient, err = gatewayclient.NewForConfig(config)
+			if err != nil {
+				c.UI.Error(fmt.Sprintf("Error initializing Gateway API client: %s", err))
+				return 1
+			}
 		}
 	}
 
@@ -316,6 +358,12 @@ func (c *Command) Run(args []string) int {
 	// Start the K8S-to-Consul syncer
 	var toConsulCh chan struct{}
 	if c.flagToConsul {
+		// Record Events on the Kubernetes services that conflict with native Consul services.
+		eventBroadcaster := record.NewBroadcaster()
+		eventBroadcaster.StartRecordingToSink(&typedcorev1.EventSinkImpl{Interface: c.clientset.CoreV1().Events("")})
+		defer eventBroadcaster.Shutdown()
+		eventRecorder := eventBroadcaster.NewRecorder(scheme.Scheme, corev1.EventSource{Component: "consul-sync-catalog"})
+
 		// Build the Consul sync and start it
 		syncer := &catalogtoconsul.ConsulSyncer{
 			ConsulClientConfig:      consulConfig,
@@ -328,6 +376,8 @@ func (c *Command) Run(args []string) int {
 			ConsulK8STag:            c.flagConsulK8STag,
 			ConsulNodeName:          c.flagConsulNodeName,
 			PrometheusSink:          c.prometheusSink,
+			ConflictPolicy:          catalogtoconsul.ConflictPolicy(c.flagConflictPolicy),
+			EventRecorder:           eventRecorder,
 		}
 		go syncer.Run(ctx)
 
@@ -344,6 +394,7 @@ func (c *Command) Run(args []string) int {
 				ExplicitEnable:             !c.flagK8SDefault,
 				ClusterIPSync:              c.flagSyncClusterIPServices,
 				LoadBalancerEndpointsSync:  c.flagSyncLBEndpoints,
+				LBHostnameResolvePeriod:    c.flagLBHostnameResolvePeriod,
 				NodePortSync:               catalogtoconsul.NodePortSyncType(c.flagNodePortSyncType),
 				ConsulK8STag:               c.flagConsulK8STag,
 				ConsulServicePrefix:        c.flagConsulServicePrefix,
@@ -355,6 +406,9 @@ func (c *Command) Run(args []string) int {
 				ConsulNodeName:             c.flagConsulNodeName,
 				EnableIngress:              c.flagEnableIngress,
 				SyncLoadBalancerIPs:        c.flagLoadBalancerIPs,
+				SyncIngressHosts:           c.flagSyncIngressHosts,
+				SyncHTTPRouteHosts:         c.flagSyncHTTPRouteHosts,
+				GatewayClient:              c.gatewayClient,
 				MetricsConfig:              metricsConfig,
 			},
 		}
@@ -370,11 +424,12 @@ func (c *Command) Run(args []string) int {
 	var toK8SCh chan struct{}
 	if c.flagToK8S {
 		sink := &catalogtok8s.K8SSink{
-			Client:         c.clientset,
-			Namespace:      c.flagK8SWriteNamespace,
-			Log:            c.logger.Named("to-k8s/sink"),
-			Ctx:            ctx,
-			PrometheusSink: c.prometheusSink,
+			Client:             c.clientset,
+			Namespace:          c.flagK8SWriteNamespace,
+			Log:                c.logger.Named("to-k8s/sink"),
+			Ctx:                ctx,
+			PrometheusSink:     c.prometheusSink,
+			SyncEndpointSlices: c.flagToK8SEndpointSlices,
 		}
 
 		source := &catalogtok8s.Source{
@@ -385,6 +440,7 @@ func (c *Command) Run(args []string) int {
 			Prefix:              c.flagK8SServicePrefix,
 			Log:                 c.logger.Named("to-k8s/source"),
 			ConsulK8STag:        c.flagConsulK8STag,
+			SyncEndpoints:       c.flagToK8SEndpointSlices,
 		}
 		go source.Run(ctx)
 
@@ -516,6 +572,11 @@ func (c *Command) validateFlags() error {
 		)
 	}
 
+	if !catalogtoconsul.ValidConflictPolicy(c.flagConflictPolicy) {
+		return fmt.Errorf("-conflict-policy=%s is invalid: must be one of %v",
+			c.flagConflictPolicy, catalogtoconsul.ConflictPolicies)
+	}
+
 	if c.flagMetricsPort != "" {
 		if _, valid := common.ParseScrapePort(c.flagMetricsPort); !valid {
 			return errors.New("-metrics-port must be a valid unprivileged port number")
@@ -45,6 +45,10 @@ func TestRun_FlagValidation(t *testing.T) {
 			ExpErr: "-consul-node-name=5r9OPGfSRXUdGzNjBdAwmhCBrzHDNYs4XjZVR4wp7lSLIzqwS0ta51nBLIN0TMPV-too-long is invalid: node name will not be discoverable " +
 				"via DNS due to it being too long. Valid lengths are between 1 and 63 bytes",
 		},
+		{
+			Flags:  []string{"-conflict-policy=k8s-loses"},
+			ExpErr: "-conflict-policy=k8s-loses is invalid: must be one of [k8s-wins consul-wins suffix-k8s]",
+		},
 	}
 
 	for _, c := range cases {
@@ -0,0 +1,75 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package main
+
+import (
+	"context"
+	"fmt"
+	"strings"
+)
+
+// azureResourceGroup is a resource group as output by the az CLI.
+type azureResourceGroup struct {
+	Name string            `json:"name"`
+	Tags map[string]string `json:"tags"`
+}
+
+// cleanupAzure deletes the resource groups created by the acceptance tests. Every resource
+// of a test, including the AKS clusters and virtual networks, is in its resource group, and
+// the node resource groups of AKS clusters are deleted along with the clusters.
+func cleanupAzure(ctx context.Context) error {
+	var groups []azureResourceGroup
+	if err := runCLI(ctx, &groups, "az", "group", "list", "--output", "json"); err != nil {
+		return err
+	}
+
+	var toDeleteGroups []azureResourceGroup
+	for _, group := range groups {
+		if _, ok := group.Tags[buildURLTag]; ok && strings.HasPrefix(group.Name, "consul-k8s-") {
+			toDeleteGroups = append(toDeleteGroups, group)
+		}
+	}
+
+	if len(toDeleteGroups) == 0 {
+		fmt.Println("Found no resource groups or associated resources to clean up")
+		return nil
+	}
+
+	var groupPrint string
+	for _, group := range toDeleteGroups {
+		groupPrint += fmt.Sprintf("- %s (%s)\n", group.Name, group.Tags[buildURLTag])
+	}
+	fmt.Printf("Found resource groups:\n%s", groupPrint)
+
+	// Check for approval.
+	if !flagAutoApprove {
+		if err := confirm(ctx, "Do you want to delete these resource groups and associated resources including AKS clusters (y/n)?"); err != nil {
+			return err
+		}
+	}
+
+	for _, group := range toDeleteGroups {
+		fmt.Printf("Deleting resource group and associated resources: %s\n", group.Name)
+		if err := runCLI(ctx, nil, "az", "group", "delete", "--name", group.Name, "--yes", "--no-wait"); err != nil {
+			return err
+		}
+
+		err := destroyBackoff(ctx, "Resource group", group.Name, func() error {
+			var exists bool
+			if err := runCLI(ctx, &exists, "az", "group", "exists", "--name", group.Name, "--output", "json"); err != nil {
+				return err
+			}
+			if exists {
+				return errNotDestroyed
+			}
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+		fmt.Printf("Resource group: Destroyed [id=%s]\n", group.Name)
+	}
+
+	return nil
+}
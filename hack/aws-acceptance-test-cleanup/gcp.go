@@ -0,0 +1,122 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package main
+
+import (
+	"context"
+	"fmt"
+	"strings"
+)
+
+const (
+	// gkeClusterPrefix is the prefix of the names of the GKE clusters created by the acceptance tests.
+	gkeClusterPrefix = "consul-k8s-"
+	// gkeFirewallRulePrefix is the prefix of the names of the firewall rules that the acceptance
+	// tests create in the default network to allow traffic between clusters. The rule of a
+	// cluster is named after the suffix of the cluster name.
+	gkeFirewallRulePrefix = "consul-k8s-acceptance-firewall-"
+)
+
+// gkeCluster is a GKE cluster as output by the gcloud CLI.
+type gkeCluster struct {
+	Name           string            `json:"name"`
+	Location       string            `json:"location"`
+	ResourceLabels map[string]string `json:"resourceLabels"`
+}
+
+// gcpFirewallRule is a firewall rule as output by the gcloud CLI.
+type gcpFirewallRule struct {
+	Name string `json:"name"`
+}
+
+// cleanupGCP deletes the GKE clusters created by the acceptance tests and the firewall
+// rules of clusters that no longer exist.
+func cleanupGCP(ctx context.Context, project string) error {
+	gcloud := func(out interface{}, args ...string) error {
+		args = append(args, "--format", "json", "--quiet")
+		if project != "" {
+			args = append(args, "--project", project)
+		}
+		return runCLI(ctx, out, "gcloud", args...)
+	}
+
+	var clusters []gkeCluster
+	if err := gcloud(&clusters, "container", "clusters", "list"); err != nil {
+		return err
+	}
+	remainingClusters := make(map[string]struct{})
+	var toDeleteClusters []gkeCluster
+	for _, cluster := range clusters {
+		if _, ok := cluster.ResourceLabels[buildURLTag]; ok && strings.HasPrefix(cluster.Name, gkeClusterPrefix) {
+			toDeleteClusters = append(toDeleteClusters, cluster)
+		} else {
+			remainingClusters[cluster.Name] = struct{}{}
+		}
+	}
+
+	var rules []gcpFirewallRule
+	if err := gcloud(&rules, "compute", "firewall-rules", "list", "--filter", "name~^"+gkeFirewallRulePrefix); err != nil {
+		return err
+	}
+	var toDeleteRules []gcpFirewallRule
+	for _, rule := range rules {
+		clusterName := gkeClusterPrefix + strings.TrimPrefix(rule.Name, gkeFirewallRulePrefix)
+		if _, ok := remainingClusters[clusterName]; !ok {
+			toDeleteRules = append(toDeleteRules, rule)
+		}
+	}
+
+	if len(toDeleteClusters) == 0 && len(toDeleteRules) == 0 {
+		fmt.Println("Found no GKE clusters or firewall rules to clean up")
+		return nil
+	}
+
+	var clusterPrint string
+	for _, cluster := range toDeleteClusters {
+		clusterPrint += fmt.Sprintf("- %s in %s (%s)\n", cluster.Name, cluster.Location, cluster.ResourceLabels[buildURLTag])
+	}
+	for _, rule := range toDeleteRules {
+		clusterPrint += fmt.Sprintf("- firewall rule %s\n", rule.Name)
+	}
+	fmt.Printf("Found GKE clusters and firewall rules:\n%s", clusterPrint)
+
+	// Check for approval.
+	if !flagAutoApprove {
+		if err := confirm(ctx, "Do you want to delete these GKE clusters and firewall rules (y/n)?"); err != nil {
+			return err
+		}
+	}
+
+	for _, cluster := range toDeleteClusters {
+		fmt.Printf("Deleting GKE cluster: %s\n", cluster.Name)
+		if err := gcloud(nil, "container", "clusters", "delete", cluster.Name, "--location", cluster.Location, "--async"); err != nil {
+			return err
+		}
+
+		err := destroyBackoff(ctx, "GKE cluster", cluster.Name, func() error {
+			var existing []gkeCluster
+			if err := gcloud(&existing, "container", "clusters", "list", "--filter", "name="+cluster.Name); err != nil {
+				return err
+			}
+			if len(existing) > 0 {
+				return errNotDestroyed
+			}
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+		fmt.Printf("GKE cluster: Destroyed [id=%s]\n", cluster.Name)
+	}
+
+	for _, rule := range toDeleteRules {
+		fmt.Printf("Deleting firewall rule: %s\n", rule.Name)
+		if err := gcloud(nil, "compute", "firewall-rules", "delete", rule.Name); err != nil {
+			return err
+		}
+		fmt.Printf("Firewall rule: Destroyed [id=%s]\n", rule.Name)
+	}
+
+	return nil
+}
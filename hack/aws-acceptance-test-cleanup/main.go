@@ -3,10 +3,12 @@
 
 package main
 
-// This script deletes AWS resources created for acceptance tests that have
-// been left around after an acceptance test fails and is not cleaned up.
+// This script deletes AWS, Azure or GCP resources created for acceptance tests
+// that have been left around after an acceptance test fails and is not cleaned up.
+// Azure and GCP resources are found and deleted with the az and gcloud CLIs, which
+// must be installed and logged in.
 //
-// Usage: go run main.go [-auto-approve]
+// Usage: go run ./... [-provider aws|azure|gcp] [-gcp-project <project>] [-auto-approve]
 
 import (
 	"bufio"
@@ -34,8 +36,16 @@ const (
 	buildURLTag = "build_url"
 )
 
+const (
+	providerAWS   = "aws"
+	providerAzure = "azure"
+	providerGCP   = "gcp"
+)
+
 var (
 	flagAutoApprove bool
+	flagProvider    string
+	flagGCPProject  string
 	errNotDestroyed = errors.New("not yet destroyed")
 )
 
@@ -46,9 +56,11 @@ type oidc struct {
 
 func main() {
 	flag.BoolVar(&flagAutoApprove, "auto-approve", false, "Skip interactive approval before destroying.")
+	flag.StringVar(&flagProvider, "provider", providerAWS, "Cloud provider to clean up resources in: aws, azure or gcp.")
+	flag.StringVar(&flagGCPProject, "gcp-project", "", "GCP project to clean up resources in. Defaults to the project configured for gcloud.")
 	flag.Parse()
 
-	termChan := make(chan os.Signal)
+	termChan := make(chan os.Signal, 1)
 	signal.Notify(termChan, syscall.SIGINT, syscall.SIGTERM)
 
 	ctx, cancelFunc := context.WithCancel(context.Background())
@@ -65,6 +77,19 @@ func main() {
 }
 
 func realMain(ctx context.Context) error {
+	switch flagProvider {
+	case providerAWS:
+		return cleanupAWS(ctx)
+	case providerAzure:
+		return cleanupAzure(ctx)
+	case providerGCP:
+		return cleanupGCP(ctx, flagGCPProject)
+	default:
+		return fmt.Errorf("-provider must be one of %q, %q or %q", providerAWS, providerAzure, providerGCP)
+	}
+}
+
+func cleanupAWS(ctx context.Context) error {
 	// Create AWS clients.
 	clientSession, err := session.NewSession()
 	if err != nil {
@@ -141,36 +166,8 @@ func realMain(ctx context.Context) error {
 
 	// Check for approval.
 	if !flagAutoApprove && oidcProvidersExist {
-		type input struct {
-			text string
-			err  error
-		}
-		inputCh := make(chan input)
-
-		// Read input in a goroutine so we can also exit if we get a Ctrl-C
-		// (see select{} below).
-		go func() {
-			reader := bufio.NewReader(os.Stdin)
-			fmt.Println("\nDo you want to delete these OIDC Providers (y/n)?")
-			inputStr, err := reader.ReadString('\n')
-			if err != nil {
-				inputCh <- input{err: err}
-				return
-			}
-			inputCh <- input{text: inputStr}
-		}()
-
-		select {
-		case in := <-inputCh:
-			if in.err != nil {
-				return in.err
-			}
-			inputTrimmed := strings.TrimSpace(in.text)
-			if inputTrimmed != "y" && inputTrimmed != "yes" {
-				return errors.New("exiting after negative")
-			}
-		case <-ctx.Done():
-			return errors.New("context cancelled")
+		if err := confirm(ctx, "Do you want to delete these OIDC Providers (y/n)?"); err != nil {
+			return err
 		}
 	}
 
@@ -228,36 +225,8 @@ func realMain(ctx context.Context) error {
 
 	// Check for approval.
 	if !flagAutoApprove {
-		type input struct {
-			text string
-			err  error
-		}
-		inputCh := make(chan input)
-
-		// Read input in a goroutine so we can also exit if we get a Ctrl-C
-		// (see select{} below).
-		go func() {
-			reader := bufio.NewReader(os.Stdin)
-			fmt.Println("\nDo you want to delete these VPCs and associated resources including EKS clusters (y/n)?")
-			inputStr, err := reader.ReadString('\n')
-			if err != nil {
-				inputCh <- input{err: err}
-				return
-			}
-			inputCh <- input{text: inputStr}
-		}()
-
-		select {
-		case in := <-inputCh:
-			if in.err != nil {
-				return in.err
-			}
-			inputTrimmed := strings.TrimSpace(in.text)
-			if inputTrimmed != "y" && inputTrimmed != "yes" {
-				return errors.New("exiting after negative")
-			}
-		case <-ctx.Done():
-			return errors.New("context cancelled")
+		if err := confirm(ctx, "Do you want to delete these VPCs and associated resources including EKS clusters (y/n)?"); err != nil {
+			return err
 		}
 	}
 
@@ -744,6 +713,42 @@ func vpcNameAndBuildURL(vpc *ec2.Vpc) (string, string) {
 	return vpcName, buildURL
 }
 
+// confirm asks the user the question and returns an error unless they answer yes.
+func confirm(ctx context.Context, question string) error {
+	type input struct {
+		text string
+		err  error
+	}
+	inputCh := make(chan input)
+
+	// Read input in a goroutine so we can also exit if we get a Ctrl-C
+	// (see select{} below).
+	go func() {
+		reader := bufio.NewReader(os.Stdin)
+		fmt.Printf("\n%s\n", question)
+		inputStr, err := reader.ReadString('\n')
+		if err != nil {
+			inputCh <- input{err: err}
+			return
+		}
+		inputCh <- input{text: inputStr}
+	}()
+
+	select {
+	case in := <-inputCh:
+		if in.err != nil {
+			return in.err
+		}
+		inputTrimmed := strings.TrimSpace(in.text)
+		if inputTrimmed != "y" && inputTrimmed != "yes" {
+			return errors.New("exiting after negative")
+		}
+	case <-ctx.Done():
+		return errors.New("context cancelled")
+	}
+	return nil
+}
+
 // destroyBackoff runs destroyF in a backoff loop. It logs each loop.
 func destroyBackoff(ctx context.Context, resourceKind string, resourceID string, destroyF func() error) error {
 	start := time.Now()
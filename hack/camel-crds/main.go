@@ -1,41 +1,52 @@
 // Copyright (c) HashiCorp, Inc.
 // SPDX-License-Identifier: MPL-2.0
 
-// Script to parse a YAML CRD file and change all the
-// snake_case keys to camelCase and rewrite the file in-situ
+// Script to post-process the CRDs generated by controller-gen. It parses each YAML CRD
+// file, applies the transforms listed in the manifest file in order and rewrites the
+// file in-situ.
+//
+// Usage: make camel-crds
+//
+//	Applies the transforms in transforms.yaml to the CRDs in control-plane/config/crd/bases.
+//	See transforms.yaml for the transforms that are available and their options.
 package main
 
 import (
-	"encoding/json"
+	"flag"
 	"fmt"
 	"os"
 	"path/filepath"
-	"strings"
-
-	"github.com/iancoleman/strcase"
-	"sigs.k8s.io/yaml"
 )
 
 func main() {
-	if len(os.Args) != 1 {
-		fmt.Println("Usage: go run ./...")
+	manifestFile := flag.String("manifest", "transforms.yaml", "the manifest file listing the transforms to apply")
+	flag.Parse()
+	if flag.NArg() != 0 {
+		fmt.Println("Usage: go run ./... [-manifest <manifest file>]")
 		os.Exit(1)
 	}
 
-	if err := realMain(); err != nil {
+	if err := realMain(*manifestFile); err != nil {
 		fmt.Printf("Error: %s\n", err)
 		os.Exit(1)
 	}
 	os.Exit(0)
 }
 
-func realMain() error {
-	root := "../../control-plane/config/crd/"
-	// explicitly ignore the `external` folder since we only want this to apply to CRDs that we have built-in this project.
-	dirs := []string{"bases"}
+func realMain(manifestFile string) error {
+	m, err := loadManifest(manifestFile)
+	if err != nil {
+		return err
+	}
+	p, err := newPipeline(m.Transforms)
+	if err != nil {
+		return fmt.Errorf("%s: %w", manifestFile, err)
+	}
 
-	for _, dir := range dirs {
-		err := filepath.Walk(root+dir, func(path string, info os.FileInfo, err error) error {
+	// The root is relative to the manifest file.
+	root := filepath.Join(filepath.Dir(manifestFile), m.Root)
+	for _, dir := range m.Dirs {
+		err := filepath.Walk(filepath.Join(root, dir), func(path string, info os.FileInfo, err error) error {
 			if err != nil {
 				return err
 			}
@@ -48,14 +59,11 @@ func realMain() error {
 			if err != nil {
 				return err
 			}
-
-			jsonBytes, err := yaml.YAMLToJSON(contentBytes)
+			processed, err := p.process(filepath.Base(path), contentBytes)
 			if err != nil {
-				return err
+				return fmt.Errorf("%s: %w", path, err)
 			}
-			fixedJsonBytes := convertKeys(jsonBytes)
-			contentsCamel, err := yaml.JSONToYAML(fixedJsonBytes)
-			return os.WriteFile(path, contentsCamel, os.ModePerm)
+			return os.WriteFile(path, processed, 0644)
 		})
 		if err != nil {
 			return err
@@ -64,54 +72,6 @@ func realMain() error {
 	return nil
 }
 
-func convertKeys(j json.RawMessage) json.RawMessage {
-	m := make(map[string]json.RawMessage)
-	n := make([]json.RawMessage, 0)
-	array := false
-	if err := json.Unmarshal(j, &m); err != nil {
-		// Not a JSON object
-		errArray := json.Unmarshal(j, &n)
-		if errArray != nil {
-			return j
-		} else {
-			array = true
-		}
-	}
-	if !array {
-		for k, v := range m {
-			if k == "annotations" {
-				continue
-			}
-			var fixed string
-			if !strings.Contains(k, "_") {
-				fixed = k
-			} else {
-				fixed = strcase.ToLowerCamel(k)
-			}
-			delete(m, k)
-			m[fixed] = convertKeys(v)
-		}
-
-		b, err := json.Marshal(m)
-		if err != nil {
-			fmt.Printf("something went wrong", err)
-			return j
-		}
-		return b
-	} else {
-		for i, message := range n {
-			fixed := convertKeys(message)
-			n[i] = fixed
-		}
-		b, err := json.Marshal(n)
-		if err != nil {
-			fmt.Printf("something went wrong", err)
-			return j
-		}
-		return b
-	}
-}
-
 func printf(format string, args ...interface{}) {
 	fmt.Println(fmt.Sprintf(format, args...))
 }
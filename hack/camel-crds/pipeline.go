@@ -0,0 +1,128 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"sigs.k8s.io/yaml"
+)
+
+// manifest configures the CRDs to process and the transforms to apply to them.
+type manifest struct {
+	// Root is the directory holding the CRDs, relative to the manifest file.
+	Root string `json:"root"`
+	// Dirs are the directories under Root whose CRDs are processed.
+	Dirs []string `json:"dirs"`
+	// Transforms are applied to each CRD in order.
+	Transforms []transformConfig `json:"transforms"`
+}
+
+// transformConfig configures a transform of the pipeline.
+type transformConfig struct {
+	// Name is the name the transform is registered with in transforms.
+	Name string `json:"name"`
+	// Files are glob patterns matched against the file names of the CRDs to apply the
+	// transform to. The transform applies to all CRDs if it is empty.
+	Files []string `json:"files,omitempty"`
+	// Options are passed to the factory of the transform.
+	Options json.RawMessage `json:"options,omitempty"`
+}
+
+func loadManifest(path string) (*manifest, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var m manifest
+	if err := yaml.UnmarshalStrict(data, &m); err != nil {
+		return nil, fmt.Errorf("error parsing %s: %w", path, err)
+	}
+	if len(m.Dirs) == 0 {
+		return nil, fmt.Errorf("%s: dirs must not be empty", path)
+	}
+	return &m, nil
+}
+
+// pipeline applies a list of transforms to CRDs.
+type pipeline struct {
+	steps []step
+}
+
+type step struct {
+	name      string
+	files     []string
+	transform transformFunc
+}
+
+func newPipeline(configs []transformConfig) (*pipeline, error) {
+	p := &pipeline{}
+	for i, config := range configs {
+		factory, ok := transforms[config.Name]
+		if !ok {
+			return nil, fmt.Errorf("transforms[%d]: unknown transform %q", i, config.Name)
+		}
+		for _, pattern := range config.Files {
+			if _, err := filepath.Match(pattern, ""); err != nil {
+				return nil, fmt.Errorf("transforms[%d]: invalid file pattern %q: %w", i, pattern, err)
+			}
+		}
+		options := config.Options
+		if len(options) == 0 {
+			options = json.RawMessage("{}")
+		}
+		transform, err := factory(options)
+		if err != nil {
+			return nil, fmt.Errorf("transforms[%d] (%s): %w", i, config.Name, err)
+		}
+		p.steps = append(p.steps, step{name: config.Name, files: config.Files, transform: transform})
+	}
+	return p, nil
+}
+
+// process applies the transforms of the pipeline that match the file name to the YAML CRD.
+func (p *pipeline) process(fileName string, content []byte) ([]byte, error) {
+	jsonBytes, err := yaml.YAMLToJSON(content)
+	if err != nil {
+		return nil, err
+	}
+	// Decode numbers as json.Number so that they are written back as they were.
+	decoder := json.NewDecoder(bytes.NewReader(jsonBytes))
+	decoder.UseNumber()
+	var crd map[string]interface{}
+	if err := decoder.Decode(&crd); err != nil {
+		return nil, err
+	}
+	if crd == nil {
+		return nil, errors.New("file is empty")
+	}
+
+	for _, s := range p.steps {
+		if !s.matches(fileName) {
+			continue
+		}
+		if err := s.transform(crd); err != nil {
+			return nil, fmt.Errorf("%s: %w", s.name, err)
+		}
+	}
+	return yaml.Marshal(crd)
+}
+
+func (s step) matches(fileName string) bool {
+	if len(s.files) == 0 {
+		return true
+	}
+	for _, pattern := range s.files {
+		// The patterns are validated when the pipeline is created.
+		if ok, _ := filepath.Match(pattern, fileName); ok {
+			return true
+		}
+	}
+	return false
+}
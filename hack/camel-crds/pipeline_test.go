@@ -0,0 +1,132 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package main
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+const testCRD = `apiVersion: apiextensions.k8s.io/v1
+kind: CustomResourceDefinition
+metadata:
+  annotations:
+    some_annotation: value
+  name: servicedefaults.consul.hashicorp.com
+spec:
+  versions:
+  - name: v1alpha1
+    schema:
+      openAPIV3Schema:
+        description: ServiceDefaults is the Schema for the servicedefaults API.
+        properties:
+          spec:
+            properties:
+              max_connections:
+                description: MaxConnections is the maximum number of connections.
+                format: int32
+                type: integer
+              routes:
+                items:
+                  properties:
+                    config:
+                      type: object
+                      x-kubernetes-preserve-unknown-fields: true
+                  type: object
+                type: array
+            type: object
+        type: object
+`
+
+func TestPipeline(t *testing.T) {
+	cases := map[string]struct {
+		transforms []transformConfig
+		expected   []string
+		unexpected []string
+		expErr     string
+	}{
+		"snake-to-camel": {
+			transforms: []transformConfig{{Name: "snake-to-camel"}},
+			expected:   []string{"maxConnections:", "some_annotation: value", "format: int32"},
+			unexpected: []string{"max_connections:"},
+		},
+		"truncate-descriptions": {
+			transforms: []transformConfig{{Name: "truncate-descriptions", Options: json.RawMessage(`{"maxLength": 15}`)}},
+			expected:   []string{"description: ServiceDefaults\n", "description: MaxConnections\n"},
+		},
+		"truncate-descriptions removes descriptions": {
+			transforms: []transformConfig{{Name: "truncate-descriptions", Options: json.RawMessage(`{"maxLength": 0}`)}},
+			unexpected: []string{"description:"},
+		},
+		"inject-defaults": {
+			transforms: []transformConfig{
+				{Name: "snake-to-camel"},
+				{Name: "inject-defaults", Options: json.RawMessage(`{"defaults": [{"field": "spec.maxConnections", "value": 10}]}`)},
+			},
+			expected: []string{"default: 10\n                description: MaxConnections"},
+		},
+		"inject-defaults for a missing field": {
+			transforms: []transformConfig{
+				{Name: "inject-defaults", Options: json.RawMessage(`{"defaults": [{"field": "spec.protocol", "value": "tcp"}]}`)},
+			},
+			expErr: `inject-defaults: version v1alpha1: field "spec.protocol" not found`,
+		},
+		"preserve-unknown-fields": {
+			transforms: []transformConfig{
+				{Name: "preserve-unknown-fields", Options: json.RawMessage(`{"crd": false, "fields": ["spec"], "prune": true}`)},
+			},
+			expected: []string{
+				"preserveUnknownFields: false",
+				"type: object\n            x-kubernetes-preserve-unknown-fields: true\n        type: object",
+			},
+			unexpected: []string{"x-kubernetes-preserve-unknown-fields: true\n                  type: object\n                type: array"},
+		},
+		"transforms only apply to matching files": {
+			transforms: []transformConfig{{Name: "snake-to-camel", Files: []string{"consul.hashicorp.com_meshes.yaml"}}},
+			expected:   []string{"max_connections:"},
+		},
+		"unknown transform": {
+			transforms: []transformConfig{{Name: "camel-to-snake"}},
+			expErr:     `transforms[0]: unknown transform "camel-to-snake"`,
+		},
+		"unknown option": {
+			transforms: []transformConfig{{Name: "snake-to-camel", Options: json.RawMessage(`{"skip": ["annotations"]}`)}},
+			expErr:     `transforms[0] (snake-to-camel): invalid options: json: unknown field "skip"`,
+		},
+		"missing option": {
+			transforms: []transformConfig{{Name: "truncate-descriptions"}},
+			expErr:     "transforms[0] (truncate-descriptions): maxLength must be set",
+		},
+	}
+
+	for name, c := range cases {
+		t.Run(name, func(t *testing.T) {
+			p, err := newPipeline(c.transforms)
+			var out []byte
+			if err == nil {
+				out, err = p.process("consul.hashicorp.com_servicedefaults.yaml", []byte(testCRD))
+			}
+			if c.expErr != "" {
+				if err == nil || err.Error() != c.expErr {
+					t.Fatalf("expected error %q, got %v", c.expErr, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			for _, s := range c.expected {
+				if !strings.Contains(string(out), s) {
+					t.Errorf("expected output to contain %q:\n%s", s, out)
+				}
+			}
+			for _, s := range c.unexpected {
+				if strings.Contains(string(out), s) {
+					t.Errorf("expected output not to contain %q:\n%s", s, out)
+				}
+			}
+		})
+	}
+}
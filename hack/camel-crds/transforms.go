@@ -0,0 +1,279 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/iancoleman/strcase"
+)
+
+// transformFunc modifies a CRD, decoded from YAML, in place.
+type transformFunc func(crd map[string]interface{}) error
+
+// transformFactory creates a transform from the options it is configured with in the manifest.
+type transformFactory func(options json.RawMessage) (transformFunc, error)
+
+// transforms holds the transforms that can be used in the manifest, by name.
+var transforms = map[string]transformFactory{
+	"snake-to-camel":          newSnakeToCamel,
+	"truncate-descriptions":   newTruncateDescriptions,
+	"inject-defaults":         newInjectDefaults,
+	"preserve-unknown-fields": newPreserveUnknownFields,
+}
+
+// newSnakeToCamel creates a transform that changes all the snake_case keys of the CRD to camelCase.
+func newSnakeToCamel(options json.RawMessage) (transformFunc, error) {
+	opts := struct {
+		// SkipKeys are the keys whose values are left as they are.
+		SkipKeys []string `json:"skipKeys"`
+	}{
+		SkipKeys: []string{"annotations"},
+	}
+	if err := decodeOptions(options, &opts); err != nil {
+		return nil, err
+	}
+	skip := make(map[string]struct{})
+	for _, k := range opts.SkipKeys {
+		skip[k] = struct{}{}
+	}
+
+	var convert func(v interface{}) interface{}
+	convert = func(v interface{}) interface{} {
+		switch v := v.(type) {
+		case map[string]interface{}:
+			converted := make(map[string]interface{}, len(v))
+			for k, value := range v {
+				if _, ok := skip[k]; ok {
+					converted[k] = value
+					continue
+				}
+				if strings.Contains(k, "_") {
+					k = strcase.ToLowerCamel(k)
+				}
+				converted[k] = convert(value)
+			}
+			return converted
+		case []interface{}:
+			for i, value := range v {
+				v[i] = convert(value)
+			}
+			return v
+		default:
+			return v
+		}
+	}
+
+	return func(crd map[string]interface{}) error {
+		converted := convert(crd).(map[string]interface{})
+		for k := range crd {
+			delete(crd, k)
+		}
+		for k, v := range converted {
+			crd[k] = v
+		}
+		return nil
+	}, nil
+}
+
+// newTruncateDescriptions creates a transform that truncates the descriptions of the schemas of
+// the CRD. This keeps the CRD under the size limit of the last-applied-configuration annotation
+// that `kubectl apply` sets.
+func newTruncateDescriptions(options json.RawMessage) (transformFunc, error) {
+	var opts struct {
+		// MaxLength is the maximum length of the descriptions. Descriptions are removed if it is 0.
+		MaxLength *int `json:"maxLength"`
+	}
+	if err := decodeOptions(options, &opts); err != nil {
+		return nil, err
+	}
+	if opts.MaxLength == nil {
+		return nil, errors.New("maxLength must be set")
+	}
+	if *opts.MaxLength < 0 {
+		return nil, fmt.Errorf("maxLength must be >= 0, got %d", *opts.MaxLength)
+	}
+	maxLength := *opts.MaxLength
+
+	var truncate func(schema map[string]interface{})
+	truncate = func(schema map[string]interface{}) {
+		if description, ok := schema["description"].(string); ok {
+			if maxLength == 0 {
+				delete(schema, "description")
+			} else if runes := []rune(description); len(runes) > maxLength {
+				schema["description"] = strings.TrimSpace(string(runes[:maxLength]))
+			}
+		}
+		forEachSubSchema(schema, truncate)
+	}
+
+	return func(crd map[string]interface{}) error {
+		return forEachVersionSchema(crd, func(schema map[string]interface{}) error {
+			truncate(schema)
+			return nil
+		})
+	}, nil
+}
+
+// newInjectDefaults creates a transform that sets the default values of fields of the CRD
+// that can't be set with kubebuilder markers.
+func newInjectDefaults(options json.RawMessage) (transformFunc, error) {
+	var opts struct {
+		Defaults []struct {
+			// Field is the dot separated path of the field from the root of the resource, e.g. spec.protocol.
+			Field string      `json:"field"`
+			Value interface{} `json:"value"`
+		} `json:"defaults"`
+	}
+	if err := decodeOptions(options, &opts); err != nil {
+		return nil, err
+	}
+	for i, d := range opts.Defaults {
+		if d.Field == "" {
+			return nil, fmt.Errorf("defaults[%d]: field must be set", i)
+		}
+		if d.Value == nil {
+			return nil, fmt.Errorf("defaults[%d]: value must be set", i)
+		}
+	}
+
+	return func(crd map[string]interface{}) error {
+		return forEachVersionSchema(crd, func(schema map[string]interface{}) error {
+			for _, d := range opts.Defaults {
+				field, err := schemaField(schema, d.Field)
+				if err != nil {
+					return err
+				}
+				field["default"] = d.Value
+			}
+			return nil
+		})
+	}, nil
+}
+
+// newPreserveUnknownFields creates a transform that sets which fields of the CRD preserve the
+// fields that aren't in their schema, instead of pruning them.
+func newPreserveUnknownFields(options json.RawMessage) (transformFunc, error) {
+	var opts struct {
+		// CRD sets spec.preserveUnknownFields of the CRD if it is set.
+		CRD *bool `json:"crd"`
+		// Fields are the dot separated paths of the fields that preserve unknown fields.
+		Fields []string `json:"fields"`
+		// Prune removes x-kubernetes-preserve-unknown-fields from all the other fields.
+		Prune bool `json:"prune"`
+	}
+	if err := decodeOptions(options, &opts); err != nil {
+		return nil, err
+	}
+
+	var prune func(schema map[string]interface{})
+	prune = func(schema map[string]interface{}) {
+		delete(schema, "x-kubernetes-preserve-unknown-fields")
+		forEachSubSchema(schema, prune)
+	}
+
+	return func(crd map[string]interface{}) error {
+		if opts.CRD != nil {
+			spec, ok := crd["spec"].(map[string]interface{})
+			if !ok {
+				return errors.New("CRD has no spec")
+			}
+			spec["preserveUnknownFields"] = *opts.CRD
+		}
+		return forEachVersionSchema(crd, func(schema map[string]interface{}) error {
+			if opts.Prune {
+				prune(schema)
+			}
+			for _, path := range opts.Fields {
+				field, err := schemaField(schema, path)
+				if err != nil {
+					return err
+				}
+				field["x-kubernetes-preserve-unknown-fields"] = true
+			}
+			return nil
+		})
+	}, nil
+}
+
+// decodeOptions decodes the options of a transform, rejecting the options it doesn't have.
+func decodeOptions(options json.RawMessage, v interface{}) error {
+	decoder := json.NewDecoder(strings.NewReader(string(options)))
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(v); err != nil {
+		return fmt.Errorf("invalid options: %w", err)
+	}
+	return nil
+}
+
+// forEachVersionSchema calls f with the openAPIV3Schema of each version of the CRD.
+func forEachVersionSchema(crd map[string]interface{}, f func(schema map[string]interface{}) error) error {
+	spec, _ := crd["spec"].(map[string]interface{})
+	versions, _ := spec["versions"].([]interface{})
+	for _, v := range versions {
+		version, _ := v.(map[string]interface{})
+		schema, _ := version["schema"].(map[string]interface{})
+		openAPISchema, ok := schema["openAPIV3Schema"].(map[string]interface{})
+		if !ok {
+			continue
+		}
+		if err := f(openAPISchema); err != nil {
+			return fmt.Errorf("version %v: %w", version["name"], err)
+		}
+	}
+	return nil
+}
+
+// forEachSubSchema calls f with the schemas nested in the schema.
+func forEachSubSchema(schema map[string]interface{}, f func(schema map[string]interface{})) {
+	for _, key := range []string{"properties", "patternProperties", "definitions"} {
+		if properties, ok := schema[key].(map[string]interface{}); ok {
+			for _, p := range properties {
+				if property, ok := p.(map[string]interface{}); ok {
+					f(property)
+				}
+			}
+		}
+	}
+	for _, key := range []string{"items", "additionalProperties", "not"} {
+		if sub, ok := schema[key].(map[string]interface{}); ok {
+			f(sub)
+		}
+	}
+	for _, key := range []string{"allOf", "anyOf", "oneOf"} {
+		if subs, ok := schema[key].([]interface{}); ok {
+			for _, s := range subs {
+				if sub, ok := s.(map[string]interface{}); ok {
+					f(sub)
+				}
+			}
+		}
+	}
+}
+
+// schemaField returns the schema of the field at the dot separated path. The items of
+// arrays are traversed transparently, so spec.routes.match is the match field of the
+// items of the routes array.
+func schemaField(schema map[string]interface{}, path string) (map[string]interface{}, error) {
+	field := schema
+	for _, name := range strings.Split(path, ".") {
+		for field["type"] == "array" {
+			items, ok := field["items"].(map[string]interface{})
+			if !ok {
+				return nil, fmt.Errorf("field %q not found", path)
+			}
+			field = items
+		}
+		properties, _ := field["properties"].(map[string]interface{})
+		next, ok := properties[name].(map[string]interface{})
+		if !ok {
+			return nil, fmt.Errorf("field %q not found", path)
+		}
+		field = next
+	}
+	return field, nil
+}
@@ -1,3 +1,7 @@
 module github.com/hashicorp/consul-k8s/hack/copy-crds-to-chart
 
 go 1.20
+
+require sigs.k8s.io/yaml v1.3.0
+
+require gopkg.in/yaml.v2 v2.4.0 // indirect
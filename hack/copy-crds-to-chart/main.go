@@ -3,137 +3,158 @@
 
 // Script to copy generated CRD yaml into chart directory and modify it to match
 // the expected chart format (e.g. formatted YAML).
+//
+// Usage: make copy-crds-to-chart
+//
+//	Copies the CRDs in control-plane/config/crd into charts/consul/templates, rendered
+//	according to the rules in rules.yaml. See rules.yaml for the options of the rules.
 package main
 
 import (
+	"flag"
 	"fmt"
 	"os"
 	"path/filepath"
 	"strings"
-)
-
-var (
-	// HACK IT!
-	requiresPeering = map[string]struct{}{
-		"consul.hashicorp.com_peeringacceptors.yaml": {},
-		"consul.hashicorp.com_peeringdialers.yaml":   {},
-	}
 
-	// includeV1Suffix is used to add a ...-v1.yaml suffix for types that exist in
-	// v1 and v2 APIs with the same name and would otherwise result in last man wins
-	includeV1Suffix = map[string]struct{}{
-		"consul.hashicorp.com_exportedservices.yaml":    {},
-		"consul.hashicorp.com_gatewayclassconfigs.yaml": {},
-	}
+	"sigs.k8s.io/yaml"
 )
 
+var labelLines = []string{
+	`  labels:`,
+	`    app: {{ template "consul.name" . }}`,
+	`    chart: {{ template "consul.chart" . }}`,
+	`    heritage: {{ .Release.Service }}`,
+	`    release: {{ .Release.Name }}`,
+	`    component: crd`,
+}
+
 func main() {
-	if len(os.Args) != 1 {
-		fmt.Println("Usage: go run ./...")
+	rulesFile := flag.String("rules", "rules.yaml", "the file with the rules the CRDs are rendered with")
+	flag.Parse()
+	if flag.NArg() != 0 {
+		fmt.Println("Usage: go run ./... [-rules <rules file>]")
 		os.Exit(1)
 	}
 
-	if err := realMain("../../charts/consul"); err != nil {
+	if err := realMain(*rulesFile); err != nil {
 		fmt.Printf("Error: %s\n", err)
 		os.Exit(1)
 	}
 	os.Exit(0)
 }
 
-func realMain(helmPath string) error {
-	root := "../../control-plane/config/crd/"
-	dirs := []string{"bases", "external"}
+func realMain(rulesFile string) error {
+	c, err := loadConfig(rulesFile)
+	if err != nil {
+		return err
+	}
+
+	// The root and chart are relative to the rules file.
+	root := filepath.Join(filepath.Dir(rulesFile), c.Root)
+	helmPath := filepath.Join(filepath.Dir(rulesFile), c.Chart)
+
+	// Read all the CRDs first so that nothing is written if a CRD has no rule.
+	files, crds, err := readCRDs(root, c.Dirs)
+	if err != nil {
+		return err
+	}
+	if err := c.validate(crds); err != nil {
+		return fmt.Errorf("%s: %w", rulesFile, err)
+	}
+
+	for _, f := range files {
+		printf("processing %s", filepath.Base(f.path))
+		r, _ := c.ruleFor(f.crd.Spec.Group, f.crd.Spec.Names.Kind)
+
+		contents, err := render(f.contents, r)
+		if err != nil {
+			return fmt.Errorf("%s: %w", f.path, err)
+		}
+
+		destinationPath := filepath.Join(helmPath, "templates", r.templateName(f.crd.Spec.Names.Plural))
+		printf("writing to %s", destinationPath)
+		if err := os.WriteFile(destinationPath, []byte(contents), 0644); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// crdFile is a CRD read from the CRD directories.
+type crdFile struct {
+	path     string
+	crd      crd
+	contents string
+}
 
+// readCRDs reads the CRDs in the dirs under root. It also returns the set of the CRDs, keyed by group/kind.
+func readCRDs(root string, dirs []string) ([]crdFile, map[string]struct{}, error) {
+	var files []crdFile
+	crds := make(map[string]struct{})
 	for _, dir := range dirs {
-		err := filepath.Walk(root+dir, func(path string, info os.FileInfo, err error) error {
+		err := filepath.Walk(filepath.Join(root, dir), func(path string, info os.FileInfo, err error) error {
 			if err != nil {
 				return err
 			}
-
 			if info.IsDir() || filepath.Ext(path) != ".yaml" || filepath.Base(path) == "kustomization.yaml" {
 				return nil
 			}
 
-			printf("processing %s", filepath.Base(path))
-
 			contentBytes, err := os.ReadFile(path)
 			if err != nil {
 				return err
 			}
-			contents := string(contentBytes)
-
-			// Strip leading newline.
-			contents = strings.TrimPrefix(contents, "\n")
-
-			if _, ok := requiresPeering[info.Name()]; ok {
-				// Add {{- if and .Values.connectInject.enabled .Values.global.peering.enabled  }} {{- end }} wrapper.
-				contents = fmt.Sprintf("{{- if and .Values.connectInject.enabled .Values.global.peering.enabled }}\n%s{{- end }}\n", contents)
-			} else if dir == "external" {
-				// TCP Route is special, as it isn't installed onto GKE Autopilot, so it needs to have the option for `manageNonStandardCRDs`.
-				if info.Name() == "tcproutes.gateway.networking.k8s.io.yaml" {
-					contents = fmt.Sprintf("{{- if and .Values.connectInject.enabled (or .Values.connectInject.apiGateway.manageExternalCRDs .Values.connectInject.apiGateway.manageNonStandardCRDs ) }}\n%s{{- end }}\n", contents)
-				} else {
-					contents = fmt.Sprintf("{{- if and .Values.connectInject.enabled .Values.connectInject.apiGateway.manageExternalCRDs }}\n%s{{- end }}\n", contents)
-				}
-			} else {
-				// Add {{- if .Values.connectInject.enabled }} {{- end }} wrapper.
-				contents = fmt.Sprintf("{{- if .Values.connectInject.enabled }}\n%s{{- end }}\n", contents)
-			}
-
-			// Add labels, this is hacky because we're relying on the line number
-			// but it means we don't need to regex or yaml parse.
-			splitOnNewlines := strings.Split(contents, "\n")
-			labelLines := []string{
-				`  labels:`,
-				`    app: {{ template "consul.name" . }}`,
-				`    chart: {{ template "consul.chart" . }}`,
-				`    heritage: {{ .Release.Service }}`,
-				`    release: {{ .Release.Name }}`,
-				`    component: crd`,
-			}
-			var split int
-			if dir == "bases" {
-				split = 6
-			} else {
-				split = 9
-			}
-			withLabels := append(splitOnNewlines[0:split], append(labelLines, splitOnNewlines[split:]...)...)
-			contents = strings.Join(withLabels, "\n")
-
-			suffix := ""
-			if _, ok := includeV1Suffix[info.Name()]; ok {
-				suffix = "-v1"
+			var parsed crd
+			if err := yaml.Unmarshal(contentBytes, &parsed); err != nil {
+				return fmt.Errorf("error parsing %s: %w", path, err)
 			}
-
-			var crdName string
-			if dir == "bases" {
-				// Construct the destination filename.
-				filenameSplit := strings.Split(info.Name(), "_")
-				filenameSplit = strings.Split(filenameSplit[1], ".")
-				crdName = filenameSplit[0] + suffix + ".yaml"
-			} else if dir == "external" {
-				filenameSplit := strings.Split(info.Name(), ".")
-				crdName = filenameSplit[0] + suffix + "-external.yaml"
+			if parsed.Spec.Group == "" || parsed.Spec.Names.Kind == "" || parsed.Spec.Names.Plural == "" {
+				return fmt.Errorf("%s: spec.group, spec.names.kind and spec.names.plural must be set", path)
 			}
-
-			destinationPath := filepath.Join(helmPath, "templates", fmt.Sprintf("crd-%s", crdName))
-			// Write it.
-			printf("writing to %s", destinationPath)
-			return os.WriteFile(destinationPath, []byte(contents), 0644)
+			files = append(files, crdFile{path: path, crd: parsed, contents: string(contentBytes)})
+			crds[parsed.Spec.Group+"/"+parsed.Spec.Names.Kind] = struct{}{}
+			return nil
 		})
 		if err != nil {
-			return err
+			return nil, nil, err
 		}
 	}
-	return nil
+	return files, crds, nil
 }
 
-func printf(format string, args ...interface{}) {
-	fmt.Println(fmt.Sprintf(format, args...))
+// render returns the chart template of the CRD.
+func render(contents string, r rule) (string, error) {
+	// Strip the license header and leading newlines, the chart templates don't have headers.
+	lines := strings.Split(contents, "\n")
+	for len(lines) > 0 && (strings.HasPrefix(lines[0], "#") || strings.TrimSpace(lines[0]) == "") {
+		lines = lines[1:]
+	}
+
+	// Add the labels before the name of the CRD, after any annotations.
+	inMetadata := false
+	split := -1
+	for i, line := range lines {
+		if line == "metadata:" {
+			inMetadata = true
+			continue
+		}
+		if inMetadata && strings.HasPrefix(line, "  name:") {
+			split = i
+			break
+		}
+		if inMetadata && !strings.HasPrefix(line, " ") {
+			break
+		}
+	}
+	if split == -1 {
+		return "", fmt.Errorf("metadata.name not found")
+	}
+	withLabels := append(lines[:split:split], append(labelLines, lines[split:]...)...)
+
+	return r.wrap(strings.Join(withLabels, "\n")), nil
 }
 
-func formatCRDName(name string) string {
-	name = strings.TrimSuffix(name, ".yaml")
-	segments := strings.Split(name, "_")
-	return fmt.Sprintf("%s.%s.yaml", segments[1], segments[0])
+func printf(format string, args ...interface{}) {
+	fmt.Println(fmt.Sprintf(format, args...))
 }
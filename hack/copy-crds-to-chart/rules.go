@@ -0,0 +1,141 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package main
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+
+	"sigs.k8s.io/yaml"
+)
+
+// config configures the CRDs to copy into the chart and how they are rendered.
+type config struct {
+	// Root is the directory holding the CRDs, relative to the config file.
+	Root string `json:"root"`
+	// Dirs are the directories under Root whose CRDs are copied.
+	Dirs []string `json:"dirs"`
+	// Chart is the directory of the chart, relative to the config file.
+	Chart string `json:"chart"`
+	// Rules configure how each CRD is rendered. Every CRD must match exactly one rule.
+	Rules []rule `json:"rules"`
+}
+
+// rule configures how the CRDs it matches are rendered in the chart.
+type rule struct {
+	// Group is the API group of the CRDs the rule applies to.
+	Group string `json:"group"`
+	// Kinds are the kinds of the CRDs the rule applies to.
+	Kinds []string `json:"kinds"`
+	// WrapIf are the conditions the CRDs are rendered under, joined with `and`.
+	WrapIf []string `json:"wrapIf,omitempty"`
+	// Suffix is appended to the plural name of the CRDs in the name of their template.
+	Suffix string `json:"suffix,omitempty"`
+}
+
+// crd is the part of a CRD that rules are matched against.
+type crd struct {
+	Spec struct {
+		Group string `json:"group"`
+		Names struct {
+			Kind   string `json:"kind"`
+			Plural string `json:"plural"`
+		} `json:"names"`
+	} `json:"spec"`
+}
+
+func loadConfig(path string) (*config, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var c config
+	if err := yaml.UnmarshalStrict(data, &c); err != nil {
+		return nil, fmt.Errorf("error parsing %s: %w", path, err)
+	}
+	if len(c.Dirs) == 0 {
+		return nil, fmt.Errorf("%s: dirs must not be empty", path)
+	}
+	if c.Chart == "" {
+		return nil, fmt.Errorf("%s: chart must be set", path)
+	}
+
+	seen := make(map[string]int)
+	for i, r := range c.Rules {
+		if r.Group == "" {
+			return nil, fmt.Errorf("%s: rules[%d]: group must be set", path, i)
+		}
+		if len(r.Kinds) == 0 {
+			return nil, fmt.Errorf("%s: rules[%d]: kinds must not be empty", path, i)
+		}
+		for _, kind := range r.Kinds {
+			key := r.Group + "/" + kind
+			if j, ok := seen[key]; ok {
+				return nil, fmt.Errorf("%s: rules[%d]: %s is already matched by rules[%d]", path, i, key, j)
+			}
+			seen[key] = i
+		}
+	}
+	return &c, nil
+}
+
+// ruleFor returns the rule matching the CRD.
+func (c *config) ruleFor(group, kind string) (rule, bool) {
+	for _, r := range c.Rules {
+		if r.Group != group {
+			continue
+		}
+		for _, k := range r.Kinds {
+			if k == kind {
+				return r, true
+			}
+		}
+	}
+	return rule{}, false
+}
+
+// validate returns an error if a CRD has no rule or a rule matches a CRD that doesn't exist.
+// The CRDs are keyed by group/kind.
+func (c *config) validate(crds map[string]struct{}) error {
+	var errs []error
+	var missing []string
+	for key := range crds {
+		group, kind, _ := strings.Cut(key, "/")
+		if _, ok := c.ruleFor(group, kind); !ok {
+			missing = append(missing, key)
+		}
+	}
+	sort.Strings(missing)
+	for _, key := range missing {
+		errs = append(errs, fmt.Errorf("no rule for CRD %s, add one to the rules file", key))
+	}
+	for i, r := range c.Rules {
+		for _, kind := range r.Kinds {
+			if _, ok := crds[r.Group+"/"+kind]; !ok {
+				errs = append(errs, fmt.Errorf("rules[%d]: no CRD %s/%s, remove it from the rules file", i, r.Group, kind))
+			}
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// wrap wraps the contents of the CRD template in the conditions of the rule.
+func (r rule) wrap(contents string) string {
+	switch len(r.WrapIf) {
+	case 0:
+		return contents
+	case 1:
+		return fmt.Sprintf("{{- if %s }}\n%s{{- end }}\n", r.WrapIf[0], contents)
+	default:
+		return fmt.Sprintf("{{- if and %s }}\n%s{{- end }}\n", strings.Join(r.WrapIf, " "), contents)
+	}
+}
+
+// templateName returns the name of the chart template of the CRD.
+func (r rule) templateName(plural string) string {
+	return fmt.Sprintf("crd-%s%s.yaml", plural, r.Suffix)
+}
@@ -0,0 +1,133 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+const testCRD = `# Copyright (c) HashiCorp, Inc.
+# SPDX-License-Identifier: MPL-2.0
+
+apiVersion: apiextensions.k8s.io/v1
+kind: CustomResourceDefinition
+metadata:
+  annotations:
+    controller-gen.kubebuilder.io/version: v0.14.0
+  name: meshes.consul.hashicorp.com
+spec:
+  group: consul.hashicorp.com
+`
+
+func TestRender(t *testing.T) {
+	r := rule{WrapIf: []string{".Values.connectInject.enabled", ".Values.global.peering.enabled"}}
+	actual, err := render(testCRD, r)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := `{{- if and .Values.connectInject.enabled .Values.global.peering.enabled }}
+apiVersion: apiextensions.k8s.io/v1
+kind: CustomResourceDefinition
+metadata:
+  annotations:
+    controller-gen.kubebuilder.io/version: v0.14.0
+  labels:
+    app: {{ template "consul.name" . }}
+    chart: {{ template "consul.chart" . }}
+    heritage: {{ .Release.Service }}
+    release: {{ .Release.Name }}
+    component: crd
+  name: meshes.consul.hashicorp.com
+spec:
+  group: consul.hashicorp.com
+{{- end }}
+`
+	if actual != expected {
+		t.Fatalf("expected:\n%s\ngot:\n%s", expected, actual)
+	}
+}
+
+func TestRule_Wrap(t *testing.T) {
+	cases := map[string]struct {
+		wrapIf   []string
+		expected string
+	}{
+		"no conditions": {
+			expected: "crd\n",
+		},
+		"one condition": {
+			wrapIf:   []string{".Values.connectInject.enabled"},
+			expected: "{{- if .Values.connectInject.enabled }}\ncrd\n{{- end }}\n",
+		},
+		"multiple conditions": {
+			wrapIf:   []string{".Values.a", "(or .Values.b .Values.c)"},
+			expected: "{{- if and .Values.a (or .Values.b .Values.c) }}\ncrd\n{{- end }}\n",
+		},
+	}
+	for name, c := range cases {
+		t.Run(name, func(t *testing.T) {
+			if actual := (rule{WrapIf: c.wrapIf}).wrap("crd\n"); actual != c.expected {
+				t.Fatalf("expected %q, got %q", c.expected, actual)
+			}
+		})
+	}
+}
+
+func TestConfig_Validate(t *testing.T) {
+	c := &config{Rules: []rule{
+		{Group: "consul.hashicorp.com", Kinds: []string{"Mesh", "Removed"}},
+	}}
+	err := c.validate(map[string]struct{}{
+		"consul.hashicorp.com/Mesh":  {},
+		"consul.hashicorp.com/Added": {},
+	})
+	if err == nil {
+		t.Fatal("expected an error")
+	}
+	for _, expected := range []string{
+		"no rule for CRD consul.hashicorp.com/Added",
+		"rules[0]: no CRD consul.hashicorp.com/Removed",
+	} {
+		if !strings.Contains(err.Error(), expected) {
+			t.Errorf("expected error to contain %q, got %q", expected, err)
+		}
+	}
+}
+
+func TestLoadConfig_DuplicateKind(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "rules.yaml")
+	err := os.WriteFile(path, []byte(`dirs: [bases]
+chart: chart
+rules:
+- group: consul.hashicorp.com
+  kinds: [Mesh]
+- group: consul.hashicorp.com
+  kinds: [Mesh]
+`), 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = loadConfig(path)
+	if err == nil || !strings.Contains(err.Error(), "rules[1]: consul.hashicorp.com/Mesh is already matched by rules[0]") {
+		t.Fatalf("unexpected error: %v", err)
+	}
+}
+
+// Test that every CRD in the repo has a rule.
+func TestRulesFile(t *testing.T) {
+	c, err := loadConfig("rules.yaml")
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, crds, err := readCRDs(c.Root, c.Dirs)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := c.validate(crds); err != nil {
+		t.Fatal(err)
+	}
+}
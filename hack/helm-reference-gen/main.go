@@ -6,6 +6,10 @@ package main
 // This script generates markdown documentation out of the values.yaml file
 // for use on consul.io.
 //
+// It also generates a reference of the annotations and consul-k8s-control-plane
+// flags that each Helm value controls, which it extracts from the control-plane
+// Go packages and the chart templates.
+//
 // Usage: make gen-helm-docs [consul-repo-path] [-validate]
 //        Where [consul-repo-path] is the location of the hashicorp/consul repo. Defaults to ../../../consul.
 //        If -validate is set, the generated docs won't be output anywhere.
@@ -112,6 +116,29 @@ func main() {
 		os.Exit(1)
 	}
 
+	// Cross-link the values with the annotations and flags they control.
+	annotations, err := ParseAnnotations("../../control-plane/connect-inject/constants")
+	if err != nil {
+		fmt.Println(err.Error())
+		os.Exit(1)
+	}
+	flags, err := ParseFlags("../../control-plane/subcommand")
+	if err != nil {
+		fmt.Println(err.Error())
+		os.Exit(1)
+	}
+	templates, err := ReadTemplates("../../charts/consul/templates")
+	if err != nil {
+		fmt.Println(err.Error())
+		os.Exit(1)
+	}
+	references, err := GenerateReferences(string(inputBytes), References{Annotations: annotations, Flags: flags}, templates)
+	if err != nil {
+		fmt.Println(err.Error())
+		os.Exit(1)
+	}
+	out += "\n" + references
+
 	// If we're just validating that generation will succeed then we're done.
 	if *validateFlag {
 		fmt.Println("Validation successful")
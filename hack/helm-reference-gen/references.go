@@ -0,0 +1,519 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package main
+
+import (
+	"fmt"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+const (
+	referencesHeader = "## Annotations and Flags\n\nThe Helm values below set the following annotations and `consul-k8s-control-plane` command flags.\n"
+
+	// sharedFlagsPackage is the package of the flags shared by the consul-k8s-control-plane commands.
+	sharedFlagsPackage = "flags"
+
+	// annotationPrefix is the prefix of the keys of the annotations and labels set by Consul on Kubernetes.
+	annotationPrefix = "consul.hashicorp.com/"
+)
+
+var (
+	// valuesRef matches a reference to a Helm value in a template, e.g. .Values.global.enabled.
+	// It captures the path of the value.
+	valuesRef = regexp.MustCompile(`\.Values((?:\.[a-zA-Z0-9_]+)+)`)
+
+	// annotationRef matches the key of an annotation or label of Consul on Kubernetes.
+	annotationRef = regexp.MustCompile(regexp.QuoteMeta(annotationPrefix) + `[a-zA-Z0-9./_-]*[a-zA-Z0-9]`)
+
+	// commandRef matches the invocation of a consul-k8s-control-plane command. It captures the command.
+	commandRef = regexp.MustCompile(`consul-k8s-control-plane ([a-z0-9-]+)`)
+
+	// flagRef matches a flag passed to a command, e.g. -log-level=debug. It captures the flag name.
+	flagRef = regexp.MustCompile(`(?:^|[\s"'])-([a-z][a-z0-9-]*)(?:[=\s"'\\]|$)`)
+
+	// templateAction matches the go template actions that open, continue or close a block.
+	// It captures the action and its pipeline.
+	templateAction = regexp.MustCompile(`{{-?\s*(if|else if|else|with|range|define|block|end)\b(.*?)-?}}`)
+
+	// anyAction matches any go template action.
+	anyAction = regexp.MustCompile(`{{.*?}}`)
+
+	// sentenceEnd matches the end of a sentence. The characters before the
+	// period can't be periods so that abbreviations such as "e.g." don't match.
+	sentenceEnd = regexp.MustCompile(`[^.]{4}\.\s`)
+
+	// flagFuncs are the methods of flag.FlagSet that define a flag.
+	flagFuncs = map[string]bool{
+		"BoolVar":     true,
+		"DurationVar": true,
+		"Float64Var":  true,
+		"Int64Var":    true,
+		"IntVar":      true,
+		"StringVar":   true,
+		"Uint64Var":   true,
+		"UintVar":     true,
+		"Var":         true,
+	}
+)
+
+// Annotation is an annotation or label key constant.
+type Annotation struct {
+	// Name is the name of the constant, e.g. AnnotationInject.
+	Name string
+	// Key is the annotation key, e.g. consul.hashicorp.com/connect-inject.
+	Key string
+	// Doc is the doc comment of the constant.
+	Doc string
+}
+
+// Flag is a flag of a consul-k8s-control-plane command.
+type Flag struct {
+	// Command is the command the flag belongs to, or sharedFlagsPackage
+	// if the flag is shared by several commands.
+	Command string
+	// Name is the name of the flag without the leading dash.
+	Name string
+	// Usage is the usage text of the flag.
+	Usage string
+}
+
+// References are the annotations and flags that Helm values can be linked to.
+type References struct {
+	Annotations []Annotation
+	Flags       []Flag
+}
+
+// ParseAnnotations extracts the annotation and label key constants from the
+// Go package in dir.
+func ParseAnnotations(dir string) ([]Annotation, error) {
+	files, err := parseGoDir(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	var annotations []Annotation
+	for _, file := range files {
+		for _, decl := range file.Decls {
+			gen, ok := decl.(*ast.GenDecl)
+			if !ok || gen.Tok != token.CONST {
+				continue
+			}
+			for _, spec := range gen.Specs {
+				vs := spec.(*ast.ValueSpec)
+				for i, name := range vs.Names {
+					if i >= len(vs.Values) {
+						break
+					}
+					key, ok := stringLit(vs.Values[i], nil)
+					if !ok || !strings.HasPrefix(key, annotationPrefix) {
+						continue
+					}
+					annotations = append(annotations, Annotation{
+						Name: name.Name,
+						Key:  key,
+						Doc:  strings.TrimSpace(vs.Doc.Text()),
+					})
+				}
+			}
+		}
+	}
+	sort.Slice(annotations, func(i, j int) bool { return annotations[i].Key < annotations[j].Key })
+	return annotations, nil
+}
+
+// ParseFlags extracts the flags defined by the Go packages under dir. The
+// command of a flag is the name of the directory of its package.
+func ParseFlags(dir string) ([]Flag, error) {
+	var flags []Flag
+	err := filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil || !d.IsDir() {
+			return err
+		}
+		files, err := parseGoDir(path)
+		if err != nil {
+			return err
+		}
+		flags = append(flags, packageFlags(filepath.Base(path), files)...)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	sort.SliceStable(flags, func(i, j int) bool {
+		if flags[i].Command != flags[j].Command {
+			return flags[i].Command < flags[j].Command
+		}
+		return flags[i].Name < flags[j].Name
+	})
+	return flags, nil
+}
+
+// packageFlags returns the flags defined with a flag.FlagSet in the files of
+// a package.
+func packageFlags(command string, files []*ast.File) []Flag {
+	// Flag names may be constants, so collect the package's string constants first.
+	consts := make(map[string]string)
+	for _, file := range files {
+		for _, decl := range file.Decls {
+			gen, ok := decl.(*ast.GenDecl)
+			if !ok || gen.Tok != token.CONST {
+				continue
+			}
+			for _, spec := range gen.Specs {
+				vs := spec.(*ast.ValueSpec)
+				for i, name := range vs.Names {
+					if i >= len(vs.Values) {
+						break
+					}
+					if v, ok := stringLit(vs.Values[i], nil); ok {
+						consts[name.Name] = v
+					}
+				}
+			}
+		}
+	}
+
+	seen := make(map[string]bool)
+	var flags []Flag
+	for _, file := range files {
+		ast.Inspect(file, func(n ast.Node) bool {
+			call, ok := n.(*ast.CallExpr)
+			if !ok || len(call.Args) < 3 {
+				return true
+			}
+			sel, ok := call.Fun.(*ast.SelectorExpr)
+			if !ok || !flagFuncs[sel.Sel.Name] {
+				return true
+			}
+			name, ok := stringLit(call.Args[1], consts)
+			if !ok || name == "" || seen[name] {
+				return true
+			}
+			seen[name] = true
+			usage, _ := stringLit(call.Args[len(call.Args)-1], consts)
+			flags = append(flags, Flag{Command: command, Name: name, Usage: usage})
+			return true
+		})
+	}
+	return flags
+}
+
+// parseGoDir parses the non-test Go files of a directory.
+func parseGoDir(dir string) ([]*ast.File, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+	fset := token.NewFileSet()
+	var files []*ast.File
+	for _, e := range entries {
+		if e.IsDir() || !strings.HasSuffix(e.Name(), ".go") || strings.HasSuffix(e.Name(), "_test.go") {
+			continue
+		}
+		file, err := parser.ParseFile(fset, filepath.Join(dir, e.Name()), nil, parser.ParseComments)
+		if err != nil {
+			return nil, err
+		}
+		files = append(files, file)
+	}
+	return files, nil
+}
+
+// stringLit returns the value of a string literal, of a concatenation of
+// string literals, or of one of the string constants in consts.
+func stringLit(expr ast.Expr, consts map[string]string) (string, bool) {
+	switch e := expr.(type) {
+	case *ast.BasicLit:
+		if e.Kind != token.STRING {
+			return "", false
+		}
+		s, err := strconv.Unquote(e.Value)
+		return s, err == nil
+	case *ast.BinaryExpr:
+		if e.Op != token.ADD {
+			return "", false
+		}
+		x, ok := stringLit(e.X, consts)
+		if !ok {
+			return "", false
+		}
+		y, ok := stringLit(e.Y, consts)
+		return x + y, ok
+	case *ast.ParenExpr:
+		return stringLit(e.X, consts)
+	case *ast.Ident:
+		s, ok := consts[e.Name]
+		return s, ok
+	}
+	return "", false
+}
+
+// ReadTemplates reads the Helm templates in dir, keyed by file name.
+func ReadTemplates(dir string) (map[string]string, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+	templates := make(map[string]string)
+	for _, e := range entries {
+		ext := filepath.Ext(e.Name())
+		if e.IsDir() || (ext != ".yaml" && ext != ".tpl") {
+			continue
+		}
+		b, err := os.ReadFile(filepath.Join(dir, e.Name()))
+		if err != nil {
+			return nil, err
+		}
+		templates[e.Name()] = string(b)
+	}
+	return templates, nil
+}
+
+// GenerateReferences generates a section that links each Helm value to the
+// annotations and flags it controls.
+//
+// A value controls an annotation if its documentation mentions the
+// annotation, or if a template sets the annotation on the same line as or
+// inside a block conditioned on the value. A value controls a flag if a
+// template passes the flag to a consul-k8s-control-plane command on the same
+// line as or inside a block conditioned on the value.
+func GenerateReferences(yamlStr string, refs References, templates map[string]string) (string, error) {
+	node, err := Parse(yamlStr)
+	if err != nil {
+		return "", err
+	}
+
+	// Index the values by their path so template references can be resolved
+	// and the output follows the order of values.yaml.
+	var paths []string
+	nodes := make(map[string]DocNode)
+	indexValues(node, "", &paths, nodes)
+
+	annotations := make(map[string]Annotation)
+	for _, a := range refs.Annotations {
+		annotations[a.Key] = a
+	}
+	flags := make(map[string]map[string]Flag)
+	for _, f := range refs.Flags {
+		if flags[f.Command] == nil {
+			flags[f.Command] = make(map[string]Flag)
+		}
+		flags[f.Command][f.Name] = f
+	}
+
+	links := make(map[string]*valueLinks)
+	link := func(path string) *valueLinks {
+		if links[path] == nil {
+			links[path] = &valueLinks{annotations: make(map[string]bool), flags: make(map[string]bool)}
+		}
+		return links[path]
+	}
+
+	for _, path := range paths {
+		for _, key := range annotationRef.FindAllString(nodes[path].Comment, -1) {
+			if _, ok := annotations[key]; ok {
+				link(path).annotations[key] = true
+			}
+		}
+	}
+
+	names := make([]string, 0, len(templates))
+	for name := range templates {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		scanTemplate(templates[name], func(line string, values []string, command string) {
+			var lineAnnotations, lineFlags []string
+			for _, key := range annotationRef.FindAllString(line, -1) {
+				if _, ok := annotations[key]; ok {
+					lineAnnotations = append(lineAnnotations, key)
+				}
+			}
+			if command != "" {
+				for _, m := range flagRef.FindAllStringSubmatch(line, -1) {
+					if f, ok := lookupFlag(flags, command, m[1]); ok {
+						lineFlags = append(lineFlags, flagID(f))
+					}
+				}
+			}
+			if len(lineAnnotations) == 0 && len(lineFlags) == 0 {
+				return
+			}
+			for _, v := range values {
+				path, ok := resolveValue(v, nodes)
+				if !ok {
+					continue
+				}
+				for _, key := range lineAnnotations {
+					link(path).annotations[key] = true
+				}
+				for _, id := range lineFlags {
+					link(path).flags[id] = true
+				}
+			}
+		})
+	}
+
+	var out strings.Builder
+	out.WriteString(referencesHeader)
+	for _, path := range paths {
+		l, ok := links[path]
+		if !ok {
+			continue
+		}
+		fmt.Fprintf(&out, "\n- [`%s`](#v%s)\n", path, nodes[path].HTMLAnchor())
+		for _, id := range sortedKeys(l.flags) {
+			command, name, _ := strings.Cut(id, " ")
+			f := flags[command][name]
+			if f.Command == sharedFlagsPackage {
+				fmt.Fprintf(&out, "  - Flag `-%s`%s\n", f.Name, summary(f.Usage))
+			} else {
+				fmt.Fprintf(&out, "  - Flag `-%s` of `%s`%s\n", f.Name, f.Command, summary(f.Usage))
+			}
+		}
+		for _, key := range sortedKeys(l.annotations) {
+			fmt.Fprintf(&out, "  - Annotation `%s`%s\n", key, summary(annotations[key].Doc))
+		}
+	}
+	return out.String(), nil
+}
+
+// valueLinks are the annotation keys and flag IDs linked to a Helm value.
+type valueLinks struct {
+	annotations map[string]bool
+	flags       map[string]bool
+}
+
+// indexValues records the path of every value below node in values.yaml order.
+func indexValues(node DocNode, prefix string, paths *[]string, nodes map[string]DocNode) {
+	for _, c := range node.Children {
+		path := c.Key
+		if prefix != "" {
+			path = prefix + "." + c.Key
+		}
+		// Elements of arrays of maps aren't addressable with a .Values path.
+		if c.ParentWasMap {
+			continue
+		}
+		if _, ok := nodes[path]; !ok {
+			*paths = append(*paths, path)
+		}
+		nodes[path] = c
+		indexValues(c, path, paths, nodes)
+	}
+}
+
+// resolveValue returns the longest prefix of the value path that is documented
+// in values.yaml, ignoring the top-level stanzas which are too broad to link.
+func resolveValue(path string, nodes map[string]DocNode) (string, bool) {
+	for {
+		i := strings.LastIndex(path, ".")
+		if i == -1 {
+			return "", false
+		}
+		if _, ok := nodes[path]; ok {
+			return path, true
+		}
+		path = path[:i]
+	}
+}
+
+// lookupFlag returns the flag of the command, falling back to the shared flags.
+func lookupFlag(flags map[string]map[string]Flag, command, name string) (Flag, bool) {
+	if f, ok := flags[command][name]; ok {
+		return f, true
+	}
+	f, ok := flags[sharedFlagsPackage][name]
+	return f, ok
+}
+
+func flagID(f Flag) string {
+	return f.Command + " " + f.Name
+}
+
+// scanTemplate calls fn for each line of a template with the Helm values
+// referenced by the line and by the condition of its innermost block, and the
+// consul-k8s-control-plane command that was last invoked in the template.
+//
+// Blocks opened before the template renders anything guard the whole
+// template, e.g. on whether the component is enabled, so their conditions
+// are ignored.
+func scanTemplate(tmpl string, fn func(line string, values []string, command string)) {
+	// conditions holds the values referenced by the pipeline of each open block.
+	var conditions [][]string
+	command := ""
+	rendered := false
+	for _, line := range strings.Split(tmpl, "\n") {
+		if m := commandRef.FindStringSubmatch(line); m != nil {
+			command = m[1]
+		}
+
+		var values []string
+		if len(conditions) > 0 {
+			values = append(values, conditions[len(conditions)-1]...)
+		}
+		for _, m := range valuesRef.FindAllStringSubmatch(line, -1) {
+			values = append(values, strings.TrimPrefix(m[1], "."))
+		}
+		fn(line, values, command)
+
+		for _, m := range templateAction.FindAllStringSubmatch(line, -1) {
+			var refs []string
+			for _, v := range valuesRef.FindAllStringSubmatch(m[2], -1) {
+				refs = append(refs, strings.TrimPrefix(v[1], "."))
+			}
+			switch m[1] {
+			case "end":
+				if len(conditions) > 0 {
+					conditions = conditions[:len(conditions)-1]
+				}
+			case "else if":
+				if len(conditions) > 0 {
+					conditions[len(conditions)-1] = append(conditions[len(conditions)-1], refs...)
+				}
+			case "else":
+			default:
+				if !rendered {
+					refs = nil
+				}
+				conditions = append(conditions, refs)
+			}
+		}
+		if strings.TrimSpace(anyAction.ReplaceAllString(line, "")) != "" {
+			rendered = true
+		}
+	}
+}
+
+// summary returns the first sentence of doc formatted as a list item
+// description, or an empty string if there is no doc.
+func summary(doc string) string {
+	doc = strings.Join(strings.Fields(doc), " ")
+	if doc == "" {
+		return ""
+	}
+	if loc := sentenceEnd.FindStringIndex(doc); loc != nil {
+		doc = doc[:loc[1]-1]
+	}
+	return " - " + doc
+}
+
+func sortedKeys(m map[string]bool) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,159 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseAnnotations(t *testing.T) {
+	dir := t.TempDir()
+	writeFile(t, filepath.Join(dir, "constants.go"), `package constants
+
+const (
+	// AnnotationInject is the key of the annotation that controls whether
+	// injection is explicitly enabled or disabled for a pod.
+	AnnotationInject = "consul.hashicorp.com/connect-inject"
+
+	// DefaultConsulNS is not an annotation.
+	DefaultConsulNS = "default"
+)
+`)
+	writeFile(t, filepath.Join(dir, "constants_test.go"), `package constants
+
+const AnnotationTest = "consul.hashicorp.com/test"
+`)
+
+	annotations, err := ParseAnnotations(dir)
+	require.NoError(t, err)
+	require.Equal(t, []Annotation{
+		{
+			Name: "AnnotationInject",
+			Key:  "consul.hashicorp.com/connect-inject",
+			Doc:  "AnnotationInject is the key of the annotation that controls whether\ninjection is explicitly enabled or disabled for a pod.",
+		},
+	}, annotations)
+}
+
+func TestParseFlags(t *testing.T) {
+	dir := t.TempDir()
+	require.NoError(t, os.MkdirAll(filepath.Join(dir, "inject-connect"), 0755))
+	require.NoError(t, os.MkdirAll(filepath.Join(dir, "flags"), 0755))
+	writeFile(t, filepath.Join(dir, "inject-connect", "command.go"), `package connectinject
+
+import "flag"
+
+const flagNameListen = "listen"
+
+type Command struct {
+	flagSet *flag.FlagSet
+	flagListen string
+	flagDefaultInject bool
+}
+
+func (c *Command) init() {
+	c.flagSet = flag.NewFlagSet("", flag.ContinueOnError)
+	c.flagSet.StringVar(&c.flagListen, flagNameListen, ":8080", "Address to bind listener to.")
+	c.flagSet.BoolVar(&c.flagDefaultInject, "default-inject", true,
+		"Inject by default. "+
+			"Pods can override it with an annotation.")
+}
+`)
+	writeFile(t, filepath.Join(dir, "flags", "http.go"), `package flags
+
+import "flag"
+
+type HTTPFlags struct {
+	address string
+}
+
+func (f *HTTPFlags) Flags(fs *flag.FlagSet) {
+	fs.StringVar(&f.address, "http-addr", "", "The address of the Consul agent.")
+}
+`)
+
+	flags, err := ParseFlags(dir)
+	require.NoError(t, err)
+	require.Equal(t, []Flag{
+		{Command: "flags", Name: "http-addr", Usage: "The address of the Consul agent."},
+		{Command: "inject-connect", Name: "default-inject", Usage: "Inject by default. Pods can override it with an annotation."},
+		{Command: "inject-connect", Name: "listen", Usage: "Address to bind listener to."},
+	}, flags)
+}
+
+func TestGenerateReferences(t *testing.T) {
+	values := `---
+# Connect inject.
+connectInject:
+  # Enable it.
+  enabled: false
+  # Inject by default. Pods can override it with the
+  # ` + "`consul.hashicorp.com/connect-inject`" + ` annotation.
+  default: false
+  # Log level.
+  logLevel: ""
+  # Not used by the templates.
+  replicas: 1
+`
+	refs := References{
+		Annotations: []Annotation{
+			{Name: "AnnotationInject", Key: "consul.hashicorp.com/connect-inject", Doc: "AnnotationInject controls injection. It is a bool."},
+			{Name: "AnnotationUnused", Key: "consul.hashicorp.com/unused", Doc: "AnnotationUnused isn't set by a value."},
+		},
+		Flags: []Flag{
+			{Command: "inject-connect", Name: "default-inject", Usage: "Inject by default."},
+			{Command: "inject-connect", Name: "log-level", Usage: "Log verbosity level, e.g. debug."},
+			{Command: "inject-connect", Name: "listen", Usage: "Address to bind listener to."},
+			{Command: "flags", Name: "http-addr", Usage: "The address of the Consul agent."},
+			{Command: "sync-catalog", Name: "log-level", Usage: "Log verbosity level."},
+		},
+	}
+	templates := map[string]string{
+		"connect-inject-deployment.yaml": `{{- if .Values.connectInject.enabled }}
+apiVersion: apps/v1
+kind: Deployment
+spec:
+  template:
+    spec:
+      containers:
+        - name: sidecar-injector
+          command:
+            - "/bin/sh"
+            - "-ec"
+            - |
+              consul-k8s-control-plane inject-connect \
+                -listen=:8080 \
+                -http-addr=consul:8500 \
+                {{- if .Values.connectInject.default }}
+                -default-inject=true \
+                {{- else }}
+                -default-inject=false \
+                {{- end }}
+                -log-level={{ default .Values.global.logLevel .Values.connectInject.logLevel }} \
+{{- end }}
+`,
+	}
+
+	out, err := GenerateReferences(values, refs, templates)
+	require.NoError(t, err)
+	exp := referencesHeader + `
+- [$connectInject.default$](#v-connectinject-default)
+  - Flag $-default-inject$ of $inject-connect$ - Inject by default.
+  - Annotation $consul.hashicorp.com/connect-inject$ - AnnotationInject controls injection.
+
+- [$connectInject.logLevel$](#v-connectinject-loglevel)
+  - Flag $-log-level$ of $inject-connect$ - Log verbosity level, e.g. debug.
+`
+	require.Equal(t, strings.ReplaceAll(exp, "$", "`"), out)
+}
+
+func writeFile(t *testing.T, path, contents string) {
+	t.Helper()
+	require.NoError(t, os.WriteFile(path, []byte(contents), 0644))
+}
@@ -0,0 +1,10 @@
+module github.com/hashicorp/consul-k8s/hack/values-migrate
+
+go 1.20
+
+require (
+	github.com/hashicorp/consul-k8s/charts v0.0.0-00010101000000-000000000000
+	gopkg.in/yaml.v3 v3.0.1
+)
+
+replace github.com/hashicorp/consul-k8s/charts => ../../charts
@@ -0,0 +1,101 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package main
+
+// This script migrates a Helm values file that was written for an older version of the
+// Consul chart by moving or removing the values listed in charts/valuesmigrate/migrations.yaml.
+//
+// Usage: make values-migrate file=<values file> [out=<output file>] [from=<chart version>] [to=<chart version>]
+//        Where <values file> is the values file to migrate. The migrated values are written to
+//        <output file>, or to stdout if it isn't set. If from or to are set, only the migrations
+//        made after chart version from and up to chart version to are applied.
+//
+//        make values-migrate-check
+//        Validates charts/valuesmigrate/migrations.yaml against charts/consul/values.yaml.
+//        This is useful in CI to ensure migrations are added when values are moved or removed.
+
+import (
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/hashicorp/consul-k8s/charts/valuesmigrate"
+	"gopkg.in/yaml.v3"
+)
+
+func main() {
+	valuesFile := flag.String("f", "", "the values file to migrate")
+	outFile := flag.String("o", "", "the file to write the migrated values to, defaults to stdout")
+	fromVersion := flag.String("from", "", "only apply migrations made after this chart version")
+	toVersion := flag.String("to", "", "only apply migrations made up to and including this chart version")
+	validate := flag.Bool("validate", false, "only validate the migrations against the chart's values.yaml")
+	flag.Parse()
+
+	var err error
+	if *validate {
+		err = validateMigrations("../../charts/consul/values.yaml")
+	} else {
+		if *valuesFile == "" {
+			fmt.Println("Usage: go run ./... -f <values file> [-o <output file>] [-from <version>] [-to <version>]")
+			os.Exit(1)
+		}
+		err = migrate(*valuesFile, *outFile, *fromVersion, *toVersion)
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %s\n", err)
+		os.Exit(1)
+	}
+}
+
+func migrate(valuesFile, outFile, fromVersion, toVersion string) error {
+	migrations, err := valuesmigrate.Load()
+	if err != nil {
+		return err
+	}
+	migrations, err = valuesmigrate.Between(migrations, fromVersion, toVersion)
+	if err != nil {
+		return err
+	}
+
+	data, err := os.ReadFile(valuesFile)
+	if err != nil {
+		return err
+	}
+	migrated, changes, err := valuesmigrate.Migrate(data, migrations)
+	if err != nil {
+		return fmt.Errorf("%s: %w", valuesFile, err)
+	}
+	for _, change := range changes {
+		fmt.Fprintf(os.Stderr, "%s: %s\n", change.Action, change)
+	}
+	if len(changes) == 0 {
+		fmt.Fprintf(os.Stderr, "%s has no values to migrate\n", valuesFile)
+	}
+
+	if outFile == "" {
+		_, err = os.Stdout.Write(migrated)
+		return err
+	}
+	return os.WriteFile(outFile, migrated, 0o644)
+}
+
+func validateMigrations(chartValuesFile string) error {
+	migrations, err := valuesmigrate.Load()
+	if err != nil {
+		return err
+	}
+	data, err := os.ReadFile(chartValuesFile)
+	if err != nil {
+		return err
+	}
+	var values map[string]interface{}
+	if err := yaml.Unmarshal(data, &values); err != nil {
+		return fmt.Errorf("error parsing %s: %w", chartValuesFile, err)
+	}
+	if err := valuesmigrate.Validate(migrations, values); err != nil {
+		return err
+	}
+	fmt.Println("Migrations are valid")
+	return nil
+}
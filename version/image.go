@@ -0,0 +1,21 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package version
+
+import "strings"
+
+// IsEnterpriseImage returns true if image is a Consul Enterprise image. Enterprise images are
+// published to a consul-enterprise repository and release versions are tagged with an "-ent" suffix.
+func IsEnterpriseImage(image string) bool {
+	if image == "" {
+		return false
+	}
+	// Strip the digest so that it isn't confused with a tag.
+	image, _, _ = strings.Cut(image, "@")
+	repo, tag := image, ""
+	if i := strings.LastIndex(image, ":"); i > strings.LastIndex(image, "/") {
+		repo, tag = image[:i], image[i+1:]
+	}
+	return repo == "consul-enterprise" || strings.HasSuffix(repo, "/consul-enterprise") || strings.Contains(tag, "-ent")
+}